@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/spf13/cobra"
+)
+
+const (
+	backupAtlantisURLFlag = "atlantis-url"
+	backupAPISecretFlag   = "api-secret"
+	backupOutputFlag      = "output"
+	backupAPISecretEnvVar = "ATLANTIS_API_SECRET"
+	backupTimeout         = 5 * time.Minute
+)
+
+// BackupCmd downloads a backup of the locks and pull statuses of a running
+// Atlantis server. It can be restored with `atlantis server --restore-backup`.
+type BackupCmd struct {
+	AtlantisURL string
+	APISecret   string
+	Output      string
+	// HTTPClient defaults to a client with a timeout.
+	HTTPClient *http.Client
+}
+
+// Init returns the runnable cobra command.
+func (b *BackupCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the locks and pull statuses of a running Atlantis server",
+		Long: "Downloads the locks, pull statuses and command locks of a running Atlantis server" +
+			" so they can be restored on a new server with `atlantis server --restore-backup`." +
+			" Requires the server to be started with --api-secret.",
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return b.run()
+		},
+	}
+	c.Flags().StringVar(&b.AtlantisURL, backupAtlantisURLFlag, fmt.Sprintf("http://localhost:%d", DefaultPort), "URL of the Atlantis server to back up.")
+	c.Flags().StringVar(&b.APISecret, backupAPISecretFlag, "", fmt.Sprintf("API secret of the Atlantis server. Can also be set with %s.", backupAPISecretEnvVar))
+	c.Flags().StringVarP(&b.Output, backupOutputFlag, "o", "atlantis-backup.json.gz", "File to write the backup to. Use - for stdout.")
+	return c
+}
+
+func (b *BackupCmd) run() error {
+	secret := b.APISecret
+	if secret == "" {
+		secret = os.Getenv(backupAPISecretEnvVar)
+	}
+	if secret == "" {
+		return fmt.Errorf("--%s or %s must be set", backupAPISecretFlag, backupAPISecretEnvVar)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(b.AtlantisURL, "/")+"/api/admin/backup", nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --%s", backupAtlantisURLFlag)
+	}
+	req.Header.Set("X-Atlantis-Token", secret)
+	client := b.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: backupTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "requesting backup")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("requesting backup: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	backup, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading backup")
+	}
+	snapshot, err := locking.ReadSnapshot(bytes.NewReader(backup))
+	if err != nil {
+		return errors.Wrap(err, "validating backup")
+	}
+
+	if b.Output == "-" {
+		_, err = os.Stdout.Write(backup)
+		return err
+	}
+	if err := os.WriteFile(b.Output, backup, 0600); err != nil {
+		return errors.Wrapf(err, "writing backup to %s", b.Output)
+	}
+	fmt.Fprintf(os.Stderr, "Backed up %d locks, %d pull statuses and %d command locks to %s\n", len(snapshot.Locks), len(snapshot.Pulls), len(snapshot.CommandLocks), b.Output)
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestBackupCmd(t *testing.T) {
+	snapshot := locking.Snapshot{
+		Version:   locking.SnapshotVersion,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Locks:     []models.ProjectLock{{Workspace: "default", Project: models.NewProject("owner/repo", ".", "")}},
+	}
+	var backup bytes.Buffer
+	Ok(t, locking.WriteSnapshot(&backup, snapshot))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/admin/backup" || r.Header.Get("X-Atlantis-Token") != "secret" {
+			http.Error(w, "header X-Atlantis-Token did not match expected secret", http.StatusUnauthorized)
+			return
+		}
+		w.Write(backup.Bytes()) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	t.Run("writes the backup", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "backup.json.gz")
+		c := &BackupCmd{AtlantisURL: srv.URL + "/", APISecret: "secret", Output: output}
+		Ok(t, c.run())
+		f, err := os.Open(output)
+		Ok(t, err)
+		defer f.Close() // nolint: errcheck
+		written, err := locking.ReadSnapshot(f)
+		Ok(t, err)
+		Equals(t, snapshot, written)
+	})
+
+	t.Run("reads the secret from the environment", func(t *testing.T) {
+		t.Setenv(backupAPISecretEnvVar, "secret")
+		c := &BackupCmd{AtlantisURL: srv.URL, Output: filepath.Join(t.TempDir(), "backup.json.gz")}
+		Ok(t, c.run())
+	})
+
+	t.Run("requires the secret", func(t *testing.T) {
+		t.Setenv(backupAPISecretEnvVar, "")
+		c := &BackupCmd{AtlantisURL: srv.URL, Output: filepath.Join(t.TempDir(), "backup.json.gz")}
+		ErrEquals(t, "--api-secret or ATLANTIS_API_SECRET must be set", c.run())
+	})
+
+	t.Run("reports server errors", func(t *testing.T) {
+		c := &BackupCmd{AtlantisURL: srv.URL, APISecret: "wrong", Output: filepath.Join(t.TempDir(), "backup.json.gz")}
+		ErrEquals(t, "requesting backup: 401 Unauthorized: header X-Atlantis-Token did not match expected secret", c.run())
+	})
+}
@@ -22,15 +22,27 @@ import (
 )
 
 // TestdriveCmd starts the testdrive process for testing out Atlantis.
-type TestdriveCmd struct{}
+type TestdriveCmd struct {
+	fixtures    string
+	pull        int
+	atlantisURL string
+}
 
 // Init returns the runnable cobra command.
 func (b *TestdriveCmd) Init() *cobra.Command {
-	return &cobra.Command{
+	c := &cobra.Command{
 		Use:   "testdrive",
 		Short: "Start a guided tour of Atlantis",
+		Long: "Start a guided tour of Atlantis on an example repo forked to your GitHub account.\n\n" +
+			"With --fixtures, send the events for a fixture pull request to an Atlantis server started with\n" +
+			"--vcs-fake-fixtures instead, to try out workflows locally without a VCS host.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := testdrive.Start()
+			var err error
+			if b.fixtures != "" {
+				err = testdrive.StartFixtures(b.fixtures, b.pull, b.atlantisURL, os.Stdin)
+			} else {
+				err = testdrive.Start()
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "\033[31mError: %s\033[39m\n\n", err.Error())
 			}
@@ -38,4 +50,8 @@ func (b *TestdriveCmd) Init() *cobra.Command {
 		},
 		SilenceErrors: true,
 	}
+	c.Flags().StringVar(&b.fixtures, "fixtures", "", "Path to the pull request fixtures the Atlantis server was started with via --vcs-fake-fixtures.")
+	c.Flags().IntVar(&b.pull, "pull", 0, "Number of the fixture pull request to open. Defaults to the first one.")
+	c.Flags().StringVar(&b.atlantisURL, "atlantis-url", "http://localhost:4141", "URL of the Atlantis server to send events to.")
+	return c
 }
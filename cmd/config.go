@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/runatlantis/atlantis/server"
+	cfg "github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ConfigCmd works with the config files passed to `atlantis server --config`.
+type ConfigCmd struct {
+	Logger logging.SimpleLogging
+}
+
+// Init returns the runnable cobra command.
+func (c *ConfigCmd) Init() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with server config files",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate <file>",
+		Short: "Check a server config file before starting the server with it",
+		Long: "Reads a config file like `atlantis server --config` does and checks that the server would start with it." +
+			" Environment variables, both those referenced in the file and ATLANTIS_ variables, are read from the environment." +
+			" The server-side repo config referenced by repo-config or repo-config-json is also checked.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := c.validate(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("%s is valid\n", args[0])
+			return nil
+		},
+	})
+	return configCmd
+}
+
+func (c *ConfigCmd) validate(path string) error {
+	v := viper.New()
+	s := &ServerCmd{Viper: v, Logger: c.Logger, SilenceOutput: true}
+	// Init binds the flag defaults and ATLANTIS_ environment variables.
+	s.Init()
+	if err := readConfigFile(v, path); err != nil {
+		return errors.Wrapf(err, "reading %s", path)
+	}
+	if unknown := unknownConfigKeys(v); len(unknown) > 0 {
+		return fmt.Errorf("unknown keys in %s: %s", path, strings.Join(unknown, ", "))
+	}
+
+	var userConfig server.UserConfig
+	if err := v.Unmarshal(&userConfig); err != nil {
+		return err
+	}
+	s.setDefaults(&userConfig, v)
+	if err := s.validate(userConfig); err != nil {
+		return err
+	}
+
+	parserValidator := &cfg.ParserValidator{}
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+		PolicyCheckEnabled: userConfig.EnablePolicyChecksFlag,
+	})
+	if userConfig.RepoConfig != "" {
+		if _, err := parserValidator.ParseGlobalCfg(userConfig.RepoConfig, globalCfg); err != nil {
+			return errors.Wrapf(err, "parsing %s file", userConfig.RepoConfig)
+		}
+	} else if userConfig.RepoConfigJSON != "" {
+		if _, err := parserValidator.ParseGlobalCfgJSON(userConfig.RepoConfigJSON, globalCfg); err != nil {
+			return errors.Wrapf(err, "parsing --%s", RepoConfigJSONFlag)
+		}
+	}
+	return nil
+}
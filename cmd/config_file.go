@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// configFileTypes are the extensions of the config files that can be passed
+// to --config.
+var configFileTypes = []string{"yaml", "yml", "json", "toml", "hcl"}
+
+// envInterpolationRegex matches ${VAR} and ${VAR:-default} references to
+// environment variables in config files. $${ is an escaped ${.
+var envInterpolationRegex = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// readConfigFile reads the config file at path into v after replacing the
+// references to environment variables in it.
+func readConfigFile(v *viper.Viper, path string) error {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if !isConfigFileType(ext) {
+		return viper.UnsupportedConfigError(ext)
+	}
+	contents, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	contents, err = interpolateEnv(contents)
+	if err != nil {
+		return err
+	}
+	if ext == "hcl" {
+		values, err := decodeHCLConfig(path, contents)
+		if err != nil {
+			return err
+		}
+		return v.MergeConfigMap(values)
+	}
+	v.SetConfigType(ext)
+	return v.ReadConfig(bytes.NewReader(contents))
+}
+
+func isConfigFileType(ext string) bool {
+	for _, t := range configFileTypes {
+		if ext == t {
+			return true
+		}
+	}
+	return false
+}
+
+// interpolateEnv replaces ${VAR} in contents with the value of the VAR
+// environment variable, and ${VAR:-default} with default if VAR is unset or
+// empty. It errors if a variable without a default is unset so that a
+// missing secret doesn't silently become an empty value.
+func interpolateEnv(contents []byte) ([]byte, error) {
+	var missing []string
+	interpolated := envInterpolationRegex.ReplaceAllFunc(contents, func(match []byte) []byte {
+		if bytes.HasPrefix(match, []byte("$$")) {
+			return match[1:]
+		}
+		groups := envInterpolationRegex.FindSubmatch(match)
+		name := string(groups[1])
+		if val := os.Getenv(name); val != "" {
+			return []byte(val)
+		}
+		if len(groups[2]) > 0 {
+			return groups[3]
+		}
+		if _, ok := os.LookupEnv(name); !ok {
+			missing = append(missing, name)
+		}
+		return nil
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("environment variables referenced in config file are not set: %s", strings.Join(missing, ", "))
+	}
+	return interpolated, nil
+}
+
+// decodeHCLConfig decodes the attributes of an HCL config file, ex.
+// repo-allowlist = "github.com/myorg/*", into a map of flag names to values.
+func decodeHCLConfig(path string, contents []byte) (map[string]any, error) {
+	file, diags := hclsyntax.ParseConfig(contents, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	values := make(map[string]any, len(attrs))
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		// Round-trip through JSON to get the same Go types as the other
+		// config file types.
+		raw, err := ctyjson.SimpleJSONValue{Value: val}.MarshalJSON()
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding %s", name)
+		}
+		var goVal any
+		if err := json.Unmarshal(raw, &goVal); err != nil {
+			return nil, errors.Wrapf(err, "decoding %s", name)
+		}
+		values[name] = goVal
+	}
+	return values, nil
+}
+
+// unknownConfigKeys returns the keys set in the config file read by v that
+// aren't server flags, in order.
+func unknownConfigKeys(v *viper.Viper) []string {
+	var unknown []string
+	for _, key := range v.AllKeys() {
+		if !v.InConfig(key) || isServerFlag(key) {
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func isServerFlag(name string) bool {
+	if _, ok := stringFlags[name]; ok {
+		return true
+	}
+	if _, ok := intFlags[name]; ok {
+		return true
+	}
+	if _, ok := int64Flags[name]; ok {
+		return true
+	}
+	_, ok := boolFlags[name]
+	return ok
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	t.Setenv("ATLANTIS_TEST_TOKEN", "secret")
+	t.Setenv("ATLANTIS_TEST_EMPTY", "")
+	cases := map[string]string{
+		"gh-token: ${ATLANTIS_TEST_TOKEN}":           "gh-token: secret",
+		"gh-token: ${ATLANTIS_TEST_UNSET:-fallback}": "gh-token: fallback",
+		"gh-token: ${ATLANTIS_TEST_EMPTY:-fallback}": "gh-token: fallback",
+		"gh-token: ${ATLANTIS_TEST_EMPTY}":           "gh-token: ",
+		"gh-token: $${ATLANTIS_TEST_TOKEN}":          "gh-token: ${ATLANTIS_TEST_TOKEN}",
+		"gh-token: $ATLANTIS_TEST_TOKEN":             "gh-token: $ATLANTIS_TEST_TOKEN",
+	}
+	for contents, exp := range cases {
+		t.Run(contents, func(t *testing.T) {
+			act, err := interpolateEnv([]byte(contents))
+			Ok(t, err)
+			Equals(t, exp, string(act))
+		})
+	}
+
+	_, err := interpolateEnv([]byte("gh-token: ${ATLANTIS_TEST_UNSET}\ngh-user: ${ATLANTIS_TEST_UNSET_USER}"))
+	ErrEquals(t, "environment variables referenced in config file are not set: ATLANTIS_TEST_UNSET, ATLANTIS_TEST_UNSET_USER", err)
+}
+
+func TestReadConfigFile_HCL(t *testing.T) {
+	t.Setenv("ATLANTIS_TEST_TOKEN", "secret")
+	path := filepath.Join(t.TempDir(), "atlantis.hcl")
+	Ok(t, os.WriteFile(path, []byte(`
+gh-user        = "user"
+gh-token       = "${ATLANTIS_TEST_TOKEN}"
+repo-allowlist = "github.com/runatlantis/*"
+port           = 4142
+autoplan-modules = true
+`), 0600))
+
+	v := viper.New()
+	Ok(t, readConfigFile(v, path))
+	Equals(t, "secret", v.GetString(GHTokenFlag))
+	Equals(t, 4142, v.GetInt(PortFlag))
+	Equals(t, true, v.GetBool(AutoplanModules))
+	Equals(t, []string(nil), unknownConfigKeys(v))
+}
+
+func TestReadConfigFile_UnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atlantis.yaml")
+	Ok(t, os.WriteFile(path, []byte("gh-user: user\ngh-tokn: token\nrepos:\n  allowlist: '*'\n"), 0600))
+
+	v := viper.New()
+	Ok(t, readConfigFile(v, path))
+	Equals(t, []string{"gh-tokn", "repos.allowlist"}, unknownConfigKeys(v))
+}
+
+func TestConfigCmd_Validate(t *testing.T) {
+	dir := t.TempDir()
+	reposPath := filepath.Join(dir, "repos.yaml")
+	Ok(t, os.WriteFile(reposPath, []byte("repos:\n- id: /.*/\n  apply_requirements: [approved]\n"), 0600))
+	badReposPath := filepath.Join(dir, "bad-repos.yaml")
+	Ok(t, os.WriteFile(badReposPath, []byte("repos:\n- id: /.*/\n  unknown_key: true\n"), 0600))
+
+	cases := []struct {
+		description string
+		contents    string
+		expErr      string
+	}{
+		{
+			description: "valid",
+			contents:    "gh-user: user\ngh-token: token\nrepo-allowlist: '*'\nrepo-config: " + reposPath + "\n",
+		},
+		{
+			description: "unknown key",
+			contents:    "gh-user: user\ngh-token: token\nrepo-allowlist: '*'\nrepo-allowlst: '*'\n",
+			expErr:      "atlantis.yaml: repo-allowlst",
+		},
+		{
+			description: "invalid server config",
+			contents:    "gh-user: user\ngh-token: token\n",
+			expErr:      "--repo-allowlist must be set for security purposes",
+		},
+		{
+			description: "invalid repo config",
+			contents:    "gh-user: user\ngh-token: token\nrepo-allowlist: '*'\nrepo-config: " + badReposPath + "\n",
+			expErr:      "parsing " + badReposPath + " file",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "atlantis.yaml")
+			Ok(t, os.WriteFile(path, []byte(c.contents), 0600))
+			configCmd := &ConfigCmd{Logger: logging.NewNoopLogger(t)}
+			err := configCmd.validate(path)
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrContains(t, c.expErr, err)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/spf13/cobra"
+)
+
+const (
+	initRepoAtlantisURLFlag = "atlantis-url"
+	initRepoAPISecretFlag   = "api-secret"
+	initRepoRepoFlag        = "repo"
+	initRepoVCSTypeFlag     = "vcs-type"
+	initRepoDryRunFlag      = "dry-run"
+	initRepoTimeout         = 2 * time.Minute
+)
+
+// InitRepoCmd onboards a repo on a running Atlantis server by opening a pull
+// request adding an atlantis.yaml with the projects found on its default
+// branch.
+type InitRepoCmd struct {
+	AtlantisURL string
+	APISecret   string
+	Repo        string
+	VCSType     string
+	DryRun      bool
+	// Out is where the generated atlantis.yaml and the pull request are
+	// written. Defaults to stdout.
+	Out io.Writer
+	// HTTPClient defaults to a client with a timeout.
+	HTTPClient *http.Client
+}
+
+// Init returns the runnable cobra command.
+func (i *InitRepoCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "init-repo",
+		Short: "Open a pull request adding a generated atlantis.yaml to a repo",
+		Long: "Finds the Terraform projects on the default branch of a repo via a running Atlantis server," +
+			" generates an atlantis.yaml with them and opens a pull request adding it." +
+			" Requires the server to be started with --api-secret.",
+		Example:      "  atlantis init-repo --repo org/repo --dry-run",
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return i.run()
+		},
+	}
+	c.Flags().StringVar(&i.AtlantisURL, initRepoAtlantisURLFlag, fmt.Sprintf("http://localhost:%d", DefaultPort), "URL of the Atlantis server.")
+	c.Flags().StringVar(&i.APISecret, initRepoAPISecretFlag, "", fmt.Sprintf("API secret of the Atlantis server. Can also be set with %s.", backupAPISecretEnvVar))
+	c.Flags().StringVar(&i.Repo, initRepoRepoFlag, "", "Full name of the repo, ex. org/repo.")
+	c.Flags().StringVar(&i.VCSType, initRepoVCSTypeFlag, models.Github.String(), "VCS host of the repo, one of Github or Gitlab.")
+	c.Flags().BoolVar(&i.DryRun, initRepoDryRunFlag, false, "Print the generated atlantis.yaml without opening a pull request.")
+	c.MarkFlagRequired(initRepoRepoFlag) // nolint: errcheck
+	return c
+}
+
+func (i *InitRepoCmd) run() error {
+	secret := i.APISecret
+	if secret == "" {
+		secret = os.Getenv(backupAPISecretEnvVar)
+	}
+	if secret == "" {
+		return fmt.Errorf("--%s or %s must be set", initRepoAPISecretFlag, backupAPISecretEnvVar)
+	}
+	out := i.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	payload, err := json.Marshal(struct {
+		VCSHost string
+		Repo    string
+		DryRun  bool
+	}{i.VCSType, i.Repo, i.DryRun})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(i.AtlantisURL, "/")+"/api/admin/init-repo", bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrapf(err, "invalid --%s", initRepoAtlantisURLFlag)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Atlantis-Token", secret)
+	client := i.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: initRepoTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "requesting onboarding")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading onboarding result")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("onboarding %s: %s: %s", i.Repo, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var onboarding events.RepoOnboarding
+	if err := json.Unmarshal(body, &onboarding); err != nil {
+		return errors.Wrap(err, "parsing onboarding result")
+	}
+
+	fmt.Fprint(out, onboarding.Config)
+	if onboarding.PullURL != "" {
+		fmt.Fprintf(out, "\nOpened %s with %d project(s)\n", onboarding.PullURL, len(onboarding.Projects))
+	}
+	return nil
+}
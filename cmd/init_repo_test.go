@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestInitRepoCmd(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/admin/init-repo" || r.Header.Get("X-Atlantis-Token") != "secret" {
+			http.Error(w, `{"error":"header X-Atlantis-Token did not match expected secret"}`, http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		var request struct{ DryRun bool }
+		json.Unmarshal(body, &request) // nolint: errcheck
+		pullURL := "https://github.com/owner/repo/pull/1"
+		if request.DryRun {
+			pullURL = ""
+		}
+		json.NewEncoder(w).Encode(map[string]any{"repo": "owner/repo", "projects": []string{"."}, "config": "version: 3\n", "pull_url": pullURL}) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	t.Run("opens a pull request", func(t *testing.T) {
+		var out bytes.Buffer
+		c := &InitRepoCmd{AtlantisURL: srv.URL + "/", APISecret: "secret", Repo: "owner/repo", VCSType: "Github", Out: &out}
+		Ok(t, c.run())
+		Equals(t, "version: 3\n\nOpened https://github.com/owner/repo/pull/1 with 1 project(s)\n", out.String())
+		Equals(t, `{"VCSHost":"Github","Repo":"owner/repo","DryRun":false}`, requests[len(requests)-1])
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		var out bytes.Buffer
+		c := &InitRepoCmd{AtlantisURL: srv.URL, APISecret: "secret", Repo: "owner/repo", VCSType: "Github", DryRun: true, Out: &out}
+		Ok(t, c.run())
+		Equals(t, "version: 3\n", out.String())
+	})
+
+	t.Run("requires the secret", func(t *testing.T) {
+		t.Setenv(backupAPISecretEnvVar, "")
+		c := &InitRepoCmd{AtlantisURL: srv.URL, Repo: "owner/repo"}
+		ErrEquals(t, "--api-secret or ATLANTIS_API_SECRET must be set", c.run())
+	})
+
+	t.Run("reports server errors", func(t *testing.T) {
+		c := &InitRepoCmd{AtlantisURL: srv.URL, APISecret: "wrong", Repo: "owner/repo"}
+		ErrEquals(t, `onboarding owner/repo: 401 Unauthorized: {"error":"header X-Atlantis-Token did not match expected secret"}`, c.run())
+	})
+}
@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/spf13/cobra"
+)
+
+const (
+	remoteAtlantisURLFlag    = "atlantis-url"
+	remoteAPISecretFlag      = "api-secret"
+	remoteWebUsernameFlag    = "web-username"
+	remoteWebPasswordFlag    = "web-password"
+	remoteRepoFlag           = "repo"
+	remotePRFlag             = "pr"
+	remoteRefFlag            = "ref"
+	remoteVCSTypeFlag        = "vcs-type"
+	remoteProjectFlag        = "project"
+	remoteDirFlag            = "dir"
+	remoteWorkspaceFlag      = "workspace"
+	remoteWebUsernameEnvVar  = "ATLANTIS_WEB_USERNAME"
+	remoteWebPasswordEnvVar  = "ATLANTIS_WEB_PASSWORD" // nolint: gosec
+	remoteJobsPollInterval   = time.Second
+	remoteJobsRequestTimeout = 10 * time.Second
+	// remoteStreamDrainTimeout is how long to wait for job logs to finish
+	// streaming after the plan is done.
+	remoteStreamDrainTimeout = 30 * time.Second
+)
+
+// RemoteCmd runs commands on a running Atlantis server via its API and
+// streams their job logs to the terminal.
+type RemoteCmd struct {
+	AtlantisURL string
+	APISecret   string
+	WebUsername string
+	WebPassword string
+	Repo        string
+	PR          int
+	Ref         string
+	VCSType     string
+	Projects    []string
+	Dir         string
+	Workspace   string
+	// Out is where job logs and results are written. Defaults to stdout.
+	Out io.Writer
+	// HTTPClient defaults to a client without a timeout since plans can take
+	// a long time.
+	HTTPClient *http.Client
+	// PollInterval is how often new jobs are looked for. Defaults to
+	// remoteJobsPollInterval.
+	PollInterval time.Duration
+
+	outMu sync.Mutex
+}
+
+// Init returns the runnable cobra command.
+func (r *RemoteCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "remote",
+		Short: "Run commands on a running Atlantis server",
+	}
+	plan := &cobra.Command{
+		Use:   "plan",
+		Short: "Plan a pull request on a running Atlantis server and stream the job logs",
+		Long: "Plans the projects of a pull request via the API of a running Atlantis server, streams their job logs" +
+			" to the terminal and exits with a non-zero status if the plan fails." +
+			" Requires the server to be started with --api-secret.",
+		Example:      "  atlantis remote plan --repo org/repo --pr 42 --ref my-branch -p project",
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return r.runPlan()
+		},
+	}
+	plan.Flags().StringVar(&r.AtlantisURL, remoteAtlantisURLFlag, fmt.Sprintf("http://localhost:%d", DefaultPort), "URL of the Atlantis server.")
+	plan.Flags().StringVar(&r.APISecret, remoteAPISecretFlag, "", fmt.Sprintf("API secret of the Atlantis server. Can also be set with %s.", backupAPISecretEnvVar))
+	plan.Flags().StringVar(&r.WebUsername, remoteWebUsernameFlag, "", fmt.Sprintf("Username to stream job logs with if the server uses --web-basic-auth. Can also be set with %s.", remoteWebUsernameEnvVar))
+	plan.Flags().StringVar(&r.WebPassword, remoteWebPasswordFlag, "", fmt.Sprintf("Password to stream job logs with if the server uses --web-basic-auth. Can also be set with %s.", remoteWebPasswordEnvVar))
+	plan.Flags().StringVar(&r.Repo, remoteRepoFlag, "", "Full name of the repo, ex. org/repo.")
+	plan.Flags().IntVar(&r.PR, remotePRFlag, 0, "Number of the pull request.")
+	plan.Flags().StringVar(&r.Ref, remoteRefFlag, "", "Branch of the pull request to plan.")
+	plan.Flags().StringVar(&r.VCSType, remoteVCSTypeFlag, models.Github.String(), "VCS host of the repo, one of Github, Gitlab, BitbucketCloud, BitbucketServer, AzureDevops or Gitea.")
+	plan.Flags().StringSliceVarP(&r.Projects, remoteProjectFlag, "p", nil, "Name of a project to plan. Can be repeated.")
+	plan.Flags().StringVarP(&r.Dir, remoteDirFlag, "d", "", "Directory of the project to plan, relative to the repo root.")
+	plan.Flags().StringVarP(&r.Workspace, remoteWorkspaceFlag, "w", "", "Workspace of the project to plan with --dir. Defaults to default.")
+	for _, flag := range []string{remoteRepoFlag, remotePRFlag, remoteRefFlag} {
+		plan.MarkFlagRequired(flag) // nolint: errcheck
+	}
+	c.AddCommand(plan)
+	return c
+}
+
+// remotePlanResult is the part of the /api/plan response that's shown.
+// Errors are serialized as empty objects so only whether they're set is
+// known.
+type remotePlanResult struct {
+	Error          json.RawMessage
+	Failure        string
+	ProjectResults []struct {
+		ProjectName string
+		RepoRelDir  string
+		Workspace   string
+		Error       json.RawMessage
+		Failure     string
+		PlanSuccess *models.PlanSuccess
+	}
+}
+
+func (r *RemoteCmd) runPlan() error {
+	secret := r.APISecret
+	if secret == "" {
+		secret = os.Getenv(backupAPISecretEnvVar)
+	}
+	if secret == "" {
+		return fmt.Errorf("--%s or %s must be set", remoteAPISecretFlag, backupAPISecretEnvVar)
+	}
+	if len(r.Projects) == 0 && r.Dir == "" {
+		return fmt.Errorf("--%s or --%s must be set", remoteProjectFlag, remoteDirFlag)
+	}
+	if r.Workspace != "" && r.Dir == "" {
+		return fmt.Errorf("--%s requires --%s to be set", remoteWorkspaceFlag, remoteDirFlag)
+	}
+	baseURL, err := url.Parse(strings.TrimRight(r.AtlantisURL, "/"))
+	if err != nil {
+		return errors.Wrapf(err, "invalid --%s", remoteAtlantisURLFlag)
+	}
+	if r.Out == nil {
+		r.Out = os.Stdout
+	}
+	if r.HTTPClient == nil {
+		r.HTTPClient = &http.Client{}
+	}
+	if r.PollInterval == 0 {
+		r.PollInterval = remoteJobsPollInterval
+	}
+
+	// Jobs of earlier commands on the pull request aren't streamed.
+	existing, err := r.listJobs(baseURL, secret)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, job := range existing {
+		seen[job.JobID] = true
+	}
+
+	type planResponse struct {
+		code int
+		body []byte
+		err  error
+	}
+	done := make(chan planResponse, 1)
+	go func() {
+		code, body, err := r.postPlan(baseURL, secret)
+		done <- planResponse{code, body, err}
+	}()
+
+	var streams sync.WaitGroup
+	streamNewJobs := func() {
+		jobs, err := r.listJobs(baseURL, secret)
+		if err != nil {
+			// Streaming logs is best effort, the result of the plan is still
+			// shown.
+			return
+		}
+		for _, job := range jobs {
+			if seen[job.JobID] {
+				continue
+			}
+			seen[job.JobID] = true
+			streams.Add(1)
+			go func(job controllers.JobDetail) {
+				defer streams.Done()
+				if err := r.streamJob(baseURL, job); err != nil {
+					r.printf("[%s] failed to stream job logs: %s\n", jobLabel(job), err)
+				}
+			}(job)
+		}
+	}
+
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+	var resp planResponse
+wait:
+	for {
+		select {
+		case resp = <-done:
+			break wait
+		case <-ticker.C:
+			streamNewJobs()
+		}
+	}
+	// Jobs that finished since the last poll are streamed from their buffered
+	// output.
+	streamNewJobs()
+	drained := make(chan struct{})
+	go func() {
+		streams.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(remoteStreamDrainTimeout):
+		r.printf("timed out waiting for job logs to finish streaming\n")
+	}
+
+	if resp.err != nil {
+		return resp.err
+	}
+	return r.printPlanResult(resp.code, resp.body, len(seen) > len(existing))
+}
+
+func (r *RemoteCmd) postPlan(baseURL *url.URL, secret string) (int, []byte, error) {
+	type path struct {
+		Directory string
+		Workspace string
+	}
+	request := struct {
+		Repository string
+		Ref        string
+		Type       string
+		PR         int
+		Projects   []string
+		Paths      []path
+	}{
+		Repository: r.Repo,
+		Ref:        r.Ref,
+		Type:       r.VCSType,
+		PR:         r.PR,
+		Projects:   r.Projects,
+	}
+	if r.Dir != "" {
+		request.Paths = []path{{Directory: r.Dir, Workspace: r.Workspace}}
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, baseURL.String()+"/api/plan", bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Atlantis-Token", secret)
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "requesting plan")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "reading plan result")
+	}
+	return resp.StatusCode, body, nil
+}
+
+func (r *RemoteCmd) listJobs(baseURL *url.URL, secret string) ([]controllers.JobDetail, error) {
+	query := url.Values{"repository": {r.Repo}, "pr": {fmt.Sprint(r.PR)}}
+	req, err := http.NewRequest(http.MethodGet, baseURL.String()+"/api/jobs?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Atlantis-Token", secret)
+	client := *r.HTTPClient
+	client.Timeout = remoteJobsRequestTimeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing jobs")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("listing jobs: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var result controllers.ListJobsResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "parsing jobs")
+	}
+	return result.Jobs, nil
+}
+
+// streamJob prints the job's log lines until the server closes the stream,
+// which it does when the job is complete.
+func (r *RemoteCmd) streamJob(baseURL *url.URL, job controllers.JobDetail) error {
+	wsURL := *baseURL
+	wsURL.Scheme = "ws"
+	if baseURL.Scheme == "https" {
+		wsURL.Scheme = "wss"
+	}
+	wsURL.Path = strings.TrimRight(baseURL.Path, "/") + "/jobs/" + url.PathEscape(job.JobID) + "/ws"
+
+	header := http.Header{}
+	username, password := r.WebUsername, r.WebPassword
+	if username == "" {
+		username, password = os.Getenv(remoteWebUsernameEnvVar), os.Getenv(remoteWebPasswordEnvVar)
+	}
+	if username != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	}
+	dialer := *websocket.DefaultDialer
+	if transport, ok := r.HTTPClient.Transport.(*http.Transport); ok {
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+	conn, resp, err := dialer.Dial(wsURL.String(), header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("%s: %s", err, resp.Status)
+		}
+		return err
+	}
+	defer conn.Close() // nolint: errcheck
+
+	label := jobLabel(job)
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			// The server closes the connection once the job is complete.
+			if _, ok := err.(*websocket.CloseError); ok {
+				return nil
+			}
+			return err
+		}
+		line := strings.TrimSuffix(strings.TrimPrefix(string(msg), "\r"), "\n")
+		r.printf("[%s] %s\n", label, line)
+	}
+}
+
+func (r *RemoteCmd) printPlanResult(code int, body []byte, streamed bool) error {
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error != "" {
+		return fmt.Errorf("plan failed: %s", apiErr.Error)
+	}
+	var result remotePlanResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("plan failed: %d: %s", code, strings.TrimSpace(string(body)))
+	}
+	if len(result.ProjectResults) == 0 && code == http.StatusOK {
+		r.printf("\nNo projects were planned.\n")
+		return nil
+	}
+
+	failed := code != http.StatusOK || isSet(result.Error) || result.Failure != ""
+	r.printf("\n")
+	for _, project := range result.ProjectResults {
+		name := project.ProjectName
+		if name == "" {
+			name = fmt.Sprintf("dir %s workspace %s", project.RepoRelDir, project.Workspace)
+		}
+		switch {
+		case project.Failure != "":
+			failed = true
+			r.printf("%s: plan failed: %s\n", name, project.Failure)
+		case isSet(project.Error) || project.PlanSuccess == nil:
+			failed = true
+			r.printf("%s: plan errored, see the job logs or the server logs\n", name)
+		default:
+			if !streamed {
+				r.printf("%s\n", project.PlanSuccess.TerraformOutput)
+			}
+			r.printf("%s: plan succeeded\n", name)
+		}
+	}
+	if result.Failure != "" {
+		r.printf("%s\n", result.Failure)
+	}
+	if failed {
+		return errors.New("plan failed")
+	}
+	return nil
+}
+
+func (r *RemoteCmd) printf(format string, a ...any) {
+	r.outMu.Lock()
+	defer r.outMu.Unlock()
+	fmt.Fprintf(r.Out, format, a...) // nolint: errcheck
+}
+
+func jobLabel(job controllers.JobDetail) string {
+	if job.ProjectName != "" {
+		return job.ProjectName
+	}
+	return fmt.Sprintf("%s/%s", job.Path, job.Workspace)
+}
+
+// isSet returns true if the JSON value is set and not null.
+func isSet(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
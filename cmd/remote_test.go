@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/runatlantis/atlantis/server/controllers"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeRemoteServer serves /api/plan, /api/jobs and job logs like an Atlantis
+// server. The plan starts a job for the "app" project when it's requested.
+type fakeRemoteServer struct {
+	planCode int
+	planBody string
+
+	mu   sync.Mutex
+	jobs []controllers.JobDetail
+	plan map[string]interface{}
+}
+
+func (f *fakeRemoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/") && r.Header.Get("X-Atlantis-Token") != "secret" {
+		http.Error(w, `{"error":"header X-Atlantis-Token did not match expected secret"}`, http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/api/jobs":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(controllers.ListJobsResult{Jobs: f.jobs}) // nolint: errcheck
+	case r.URL.Path == "/api/plan":
+		f.mu.Lock()
+		json.NewDecoder(r.Body).Decode(&f.plan) // nolint: errcheck
+		f.jobs = append(f.jobs, controllers.JobDetail{JobID: "new-job", ProjectName: "app", Path: "app", Workspace: "default", JobStep: "plan"})
+		f.mu.Unlock()
+		// Give the command time to find the job while the plan is running.
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(f.planCode)
+		w.Write([]byte(f.planBody)) // nolint: errcheck
+	case strings.HasPrefix(r.URL.Path, "/jobs/"):
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		jobID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/ws")
+		for _, line := range []string{"Initializing...", "Plan: 1 to add, 0 to change, 0 to destroy. (" + jobID + ")"} {
+			conn.WriteMessage(websocket.BinaryMessage, []byte("\r"+line+"\n")) // nolint: errcheck
+		}
+		conn.Close() // nolint: errcheck
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRemoteCmd_Plan(t *testing.T) {
+	cases := []struct {
+		description string
+		planCode    int
+		planBody    string
+		expErr      string
+		expOut      []string
+	}{
+		{
+			description: "success",
+			planCode:    http.StatusOK,
+			planBody:    `{"Error":null,"Failure":"","ProjectResults":[{"ProjectName":"app","RepoRelDir":"app","Workspace":"default","Error":null,"Failure":"","PlanSuccess":{"TerraformOutput":"Plan: 1 to add"}}]}`,
+			expOut: []string{
+				"[app] Initializing...",
+				"[app] Plan: 1 to add, 0 to change, 0 to destroy. (new-job)",
+				"app: plan succeeded",
+			},
+		},
+		{
+			description: "project error",
+			planCode:    http.StatusInternalServerError,
+			planBody:    `{"Error":null,"Failure":"","ProjectResults":[{"ProjectName":"app","RepoRelDir":"app","Workspace":"default","Error":{},"Failure":"","PlanSuccess":null}]}`,
+			expErr:      "plan failed",
+			expOut:      []string{"app: plan errored, see the job logs or the server logs"},
+		},
+		{
+			description: "project failure",
+			planCode:    http.StatusInternalServerError,
+			planBody:    `{"Error":null,"Failure":"","ProjectResults":[{"ProjectName":"app","RepoRelDir":"app","Workspace":"default","Error":null,"Failure":"Plan is locked by pull 1","PlanSuccess":null}]}`,
+			expErr:      "plan failed",
+			expOut:      []string{"app: plan failed: Plan is locked by pull 1"},
+		},
+		{
+			description: "api error",
+			planCode:    http.StatusForbidden,
+			planBody:    `{"error":"repo not allowlisted"}`,
+			expErr:      "plan failed: repo not allowlisted",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			fake := &fakeRemoteServer{
+				planCode: c.planCode,
+				planBody: c.planBody,
+				// Jobs of earlier commands aren't streamed.
+				jobs: []controllers.JobDetail{{JobID: "old-job", ProjectName: "app", JobStep: "plan"}},
+			}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			var out bytes.Buffer
+			r := &RemoteCmd{
+				AtlantisURL:  srv.URL,
+				APISecret:    "secret",
+				Repo:         "owner/repo",
+				PR:           42,
+				Ref:          "branch",
+				VCSType:      "Github",
+				Projects:     []string{"app"},
+				Out:          &out,
+				PollInterval: 10 * time.Millisecond,
+			}
+			err := r.runPlan()
+			if c.expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, c.expErr, err)
+			}
+			for _, line := range c.expOut {
+				Assert(t, strings.Contains(out.String(), line), "expected output to contain %q, got %q", line, out.String())
+			}
+			Assert(t, !strings.Contains(out.String(), "old-job"), "expected earlier jobs not to be streamed, got %q", out.String())
+			Equals(t, map[string]interface{}{
+				"Repository": "owner/repo",
+				"Ref":        "branch",
+				"Type":       "Github",
+				"PR":         float64(42),
+				"Projects":   []interface{}{"app"},
+				"Paths":      nil,
+			}, fake.plan)
+		})
+	}
+}
+
+func TestRemoteCmd_Plan_Invalid(t *testing.T) {
+	t.Setenv(backupAPISecretEnvVar, "")
+	r := &RemoteCmd{Repo: "owner/repo", PR: 1, Ref: "branch", Projects: []string{"app"}}
+	ErrEquals(t, "--api-secret or ATLANTIS_API_SECRET must be set", r.runPlan())
+
+	r = &RemoteCmd{APISecret: "secret", Repo: "owner/repo", PR: 1, Ref: "branch"}
+	ErrEquals(t, "--project or --dir must be set", r.runPlan())
+
+	r = &RemoteCmd{APISecret: "secret", Repo: "owner/repo", PR: 1, Ref: "branch", Projects: []string{"app"}, Workspace: "staging"}
+	ErrEquals(t, "--workspace requires --dir to be set", r.runPlan())
+
+	srv := httptest.NewServer(&fakeRemoteServer{})
+	defer srv.Close()
+	r = &RemoteCmd{AtlantisURL: srv.URL, APISecret: "wrong", Repo: "owner/repo", PR: 1, Ref: "branch", Projects: []string{"app"}}
+	ErrContains(t, "listing jobs: 401 Unauthorized", r.runPlan())
+}
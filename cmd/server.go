@@ -17,7 +17,9 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -27,7 +29,11 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/core/ipallowlist"
+	"github.com/runatlantis/atlantis/server/core/vault"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
+	"github.com/runatlantis/atlantis/server/events/vcs/fake"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -37,6 +43,19 @@ const (
 	CheckoutStrategyMerge  = "merge"
 )
 
+// comment strategies
+const (
+	CommentStrategyNew        = "new"
+	CommentStrategyUpdateLast = "update-last"
+)
+
+// VCS config validation modes
+const (
+	VCSConfigValidationOff  = "off"
+	VCSConfigValidationWarn = "warn"
+	VCSConfigValidationFail = "fail"
+)
+
 // TF distributions
 const (
 	TFDistributionTerraform = "terraform"
@@ -49,117 +68,180 @@ const (
 // 3. Add your flag's description etc. to the stringFlags, intFlags, or boolFlags slices.
 const (
 	// Flag names.
-	ADWebhookPasswordFlag            = "azuredevops-webhook-password" // nolint: gosec
-	ADWebhookUserFlag                = "azuredevops-webhook-user"
-	ADTokenFlag                      = "azuredevops-token" // nolint: gosec
-	ADUserFlag                       = "azuredevops-user"
-	ADHostnameFlag                   = "azuredevops-hostname"
-	AllowCommandsFlag                = "allow-commands"
-	AllowForkPRsFlag                 = "allow-fork-prs"
-	AtlantisURLFlag                  = "atlantis-url"
-	AutoDiscoverModeFlag             = "autodiscover-mode"
-	AutomergeFlag                    = "automerge"
-	ParallelPlanFlag                 = "parallel-plan"
-	ParallelApplyFlag                = "parallel-apply"
-	AutoplanModules                  = "autoplan-modules"
-	AutoplanModulesFromProjects      = "autoplan-modules-from-projects"
-	AutoplanFileListFlag             = "autoplan-file-list"
-	BitbucketBaseURLFlag             = "bitbucket-base-url"
-	BitbucketTokenFlag               = "bitbucket-token"
-	BitbucketUserFlag                = "bitbucket-user"
-	BitbucketWebhookSecretFlag       = "bitbucket-webhook-secret"
-	CheckoutDepthFlag                = "checkout-depth"
-	CheckoutStrategyFlag             = "checkout-strategy"
-	ConfigFlag                       = "config"
-	DataDirFlag                      = "data-dir"
-	DefaultTFDistributionFlag        = "default-tf-distribution"
-	DefaultTFVersionFlag             = "default-tf-version"
-	DisableApplyAllFlag              = "disable-apply-all"
-	DisableAutoplanFlag              = "disable-autoplan"
-	DisableAutoplanLabelFlag         = "disable-autoplan-label"
-	DisableMarkdownFoldingFlag       = "disable-markdown-folding"
-	DisableRepoLockingFlag           = "disable-repo-locking"
-	DisableGlobalApplyLockFlag       = "disable-global-apply-lock"
-	DisableUnlockLabelFlag           = "disable-unlock-label"
-	DiscardApprovalOnPlanFlag        = "discard-approval-on-plan"
-	EmojiReaction                    = "emoji-reaction"
-	EnableDiffMarkdownFormat         = "enable-diff-markdown-format"
-	EnablePolicyChecksFlag           = "enable-policy-checks"
-	EnableRegExpCmdFlag              = "enable-regexp-cmd"
-	EnableProfilingAPI               = "enable-profiling-api"
-	ExecutableName                   = "executable-name"
-	FailOnPreWorkflowHookError       = "fail-on-pre-workflow-hook-error"
-	HideUnchangedPlanComments        = "hide-unchanged-plan-comments"
-	GHHostnameFlag                   = "gh-hostname"
-	GHTeamAllowlistFlag              = "gh-team-allowlist"
-	GHTokenFlag                      = "gh-token"
-	GHTokenFileFlag                  = "gh-token-file" // nolint: gosec
-	GHUserFlag                       = "gh-user"
-	GHAppIDFlag                      = "gh-app-id"
-	GHAppKeyFlag                     = "gh-app-key"
-	GHAppKeyFileFlag                 = "gh-app-key-file"
-	GHAppSlugFlag                    = "gh-app-slug"
-	GHAppInstallationIDFlag          = "gh-app-installation-id"
-	GHOrganizationFlag               = "gh-org"
-	GHWebhookSecretFlag              = "gh-webhook-secret"               // nolint: gosec
-	GHAllowMergeableBypassApply      = "gh-allow-mergeable-bypass-apply" // nolint: gosec
-	GiteaBaseURLFlag                 = "gitea-base-url"
-	GiteaTokenFlag                   = "gitea-token"
-	GiteaUserFlag                    = "gitea-user"
-	GiteaWebhookSecretFlag           = "gitea-webhook-secret" // nolint: gosec
-	GiteaPageSizeFlag                = "gitea-page-size"
-	GitlabGroupAllowlistFlag         = "gitlab-group-allowlist"
-	GitlabHostnameFlag               = "gitlab-hostname"
-	GitlabTokenFlag                  = "gitlab-token"
-	GitlabUserFlag                   = "gitlab-user"
-	GitlabWebhookSecretFlag          = "gitlab-webhook-secret" // nolint: gosec
-	IncludeGitUntrackedFiles         = "include-git-untracked-files"
-	APISecretFlag                    = "api-secret"
-	HidePrevPlanComments             = "hide-prev-plan-comments"
-	QuietPolicyChecks                = "quiet-policy-checks"
-	LockingDBType                    = "locking-db-type"
-	LogLevelFlag                     = "log-level"
-	MarkdownTemplateOverridesDirFlag = "markdown-template-overrides-dir"
-	MaxCommentsPerCommand            = "max-comments-per-command"
-	ParallelPoolSize                 = "parallel-pool-size"
-	StatsNamespace                   = "stats-namespace"
-	AllowDraftPRs                    = "allow-draft-prs"
-	PortFlag                         = "port"
-	RedisDB                          = "redis-db"
-	RedisHost                        = "redis-host"
-	RedisPassword                    = "redis-password"
-	RedisPort                        = "redis-port"
-	RedisTLSEnabled                  = "redis-tls-enabled"
-	RedisInsecureSkipVerify          = "redis-insecure-skip-verify"
-	RepoConfigFlag                   = "repo-config"
-	RepoConfigJSONFlag               = "repo-config-json"
-	RepoAllowlistFlag                = "repo-allowlist"
-	SilenceNoProjectsFlag            = "silence-no-projects"
-	SilenceForkPRErrorsFlag          = "silence-fork-pr-errors"
-	SilenceVCSStatusNoPlans          = "silence-vcs-status-no-plans"
-	SilenceVCSStatusNoProjectsFlag   = "silence-vcs-status-no-projects"
-	SilenceAllowlistErrorsFlag       = "silence-allowlist-errors"
-	SkipCloneNoChanges               = "skip-clone-no-changes"
-	SlackTokenFlag                   = "slack-token"
-	SSLCertFileFlag                  = "ssl-cert-file"
-	SSLKeyFileFlag                   = "ssl-key-file"
-	RestrictFileList                 = "restrict-file-list"
-	TFDistributionFlag               = "tf-distribution" // deprecated for DefaultTFDistributionFlag
-	TFDownloadFlag                   = "tf-download"
-	TFDownloadURLFlag                = "tf-download-url"
-	UseTFPluginCache                 = "use-tf-plugin-cache"
-	VarFileAllowlistFlag             = "var-file-allowlist"
-	VCSStatusName                    = "vcs-status-name"
-	IgnoreVCSStatusNames             = "ignore-vcs-status-names"
-	TFEHostnameFlag                  = "tfe-hostname"
-	TFELocalExecutionModeFlag        = "tfe-local-execution-mode"
-	TFETokenFlag                     = "tfe-token"
-	WriteGitCredsFlag                = "write-git-creds" // nolint: gosec
-	WebhookHttpHeaders               = "webhook-http-headers"
-	WebBasicAuthFlag                 = "web-basic-auth"
-	WebUsernameFlag                  = "web-username"
-	WebPasswordFlag                  = "web-password"
-	WebsocketCheckOrigin             = "websocket-check-origin"
+	ADWebhookPasswordFlag               = "azuredevops-webhook-password" // nolint: gosec
+	ADWebhookUserFlag                   = "azuredevops-webhook-user"
+	ADTokenFlag                         = "azuredevops-token" // nolint: gosec
+	ADUserFlag                          = "azuredevops-user"
+	ADHostnameFlag                      = "azuredevops-hostname"
+	AckCommentFlag                      = "ack-comment"
+	AllowCommandsFlag                   = "allow-commands"
+	AllowExtraArgsFlag                  = "allow-extra-args"
+	AllowForkPRsFlag                    = "allow-fork-prs"
+	ApplyConfirmationTTLFlag            = "apply-confirmation-ttl"
+	ApplyOnMergeFlag                    = "apply-on-merge"
+	AtlantisURLFlag                     = "atlantis-url"
+	AutoDiscoverModeFlag                = "autodiscover-mode"
+	AutomergeFlag                       = "automerge"
+	ParallelPlanFlag                    = "parallel-plan"
+	ParallelApplyFlag                   = "parallel-apply"
+	AutoplanModules                     = "autoplan-modules"
+	AutoplanModulesFromProjects         = "autoplan-modules-from-projects"
+	AutoplanFileListFlag                = "autoplan-file-list"
+	BitbucketBaseURLFlag                = "bitbucket-base-url"
+	BitbucketTokenFlag                  = "bitbucket-token"
+	BitbucketUserFlag                   = "bitbucket-user"
+	BitbucketWebhookSecondarySecretFlag = "bitbucket-webhook-secondary-secret" // nolint: gosec
+	BitbucketWebhookSecretFlag          = "bitbucket-webhook-secret"
+	CgroupParentFlag                    = "cgroup-parent"
+	CheckoutDepthFlag                   = "checkout-depth"
+	CheckoutStrategyFlag                = "checkout-strategy"
+	CloudIdentityTokenFileFlag          = "cloud-identity-token-file"
+	CommandRateLimitPerPullFlag         = "command-rate-limit-per-pull"
+	CommandRateLimitPerUserFlag         = "command-rate-limit-per-user"
+	CommentStrategyFlag                 = "comment-strategy"
+	ConfigFlag                          = "config"
+	DataDirFlag                         = "data-dir"
+	DefaultTFDistributionFlag           = "default-tf-distribution"
+	DefaultTFVersionFlag                = "default-tf-version"
+	DenyExtraArgsFlag                   = "deny-extra-args"
+	DisableApplyAllFlag                 = "disable-apply-all"
+	DisableAutoplanFlag                 = "disable-autoplan"
+	DisableAutoplanLabelFlag            = "disable-autoplan-label"
+	DisableMarkdownFoldingFlag          = "disable-markdown-folding"
+	DisableProjectStatusesFlag          = "disable-project-statuses"
+	DisableRepoLockingFlag              = "disable-repo-locking"
+	DisableGlobalApplyLockFlag          = "disable-global-apply-lock"
+	DisableUnlockLabelFlag              = "disable-unlock-label"
+	DiscardApprovalOnPlanFlag           = "discard-approval-on-plan"
+	EmojiReaction                       = "emoji-reaction"
+	EnableDiffMarkdownFormat            = "enable-diff-markdown-format"
+	EnableOutdatedDependenciesFlag      = "enable-outdated-dependencies"
+	EnablePolicyChecksFlag              = "enable-policy-checks"
+	EventTranslatorURLFlag              = "event-translator-url"
+	EventsIPAllowlistFlag               = "events-ip-allowlist"
+	EventsIPAllowlistTrustedProxiesFlag = "events-ip-allowlist-trusted-proxies"
+	EnablePRDescriptionStatusFlag       = "enable-pr-description-status"
+	EnableRegExpCmdFlag                 = "enable-regexp-cmd"
+	EnableProfilingAPI                  = "enable-profiling-api"
+	ExecutableName                      = "executable-name"
+	FailOnPreWorkflowHookError          = "fail-on-pre-workflow-hook-error"
+	ForkPRAllowlistFlag                 = "fork-pr-allowlist"
+	HideUnchangedPlanComments           = "hide-unchanged-plan-comments"
+	GHHostnameFlag                      = "gh-hostname"
+	GHTeamAllowlistFlag                 = "gh-team-allowlist"
+	GHTokenFlag                         = "gh-token"
+	GHTokenFileFlag                     = "gh-token-file" // nolint: gosec
+	GHUserFlag                          = "gh-user"
+	GHAppIDFlag                         = "gh-app-id"
+	GHAppKeyFlag                        = "gh-app-key"
+	GHAppKeyFileFlag                    = "gh-app-key-file"
+	GHAppSlugFlag                       = "gh-app-slug"
+	GHAppInstallationIDFlag             = "gh-app-installation-id"
+	GHAppRepoScopedTokensFlag           = "gh-app-repo-scoped-tokens" // nolint: gosec
+	GHOrganizationFlag                  = "gh-org"
+	GHWebhookSecretFlag                 = "gh-webhook-secret" // nolint: gosec
+	GHAllowEditedCommentsFlag           = "gh-allow-edited-comments"
+	GHAllowMergeableBypassApply         = "gh-allow-mergeable-bypass-apply" // nolint: gosec
+	GHAllowReviewCommentsFlag           = "gh-allow-review-comments"
+	GHUseCheckRunsFlag                  = "gh-use-check-runs"
+	GiteaBaseURLFlag                    = "gitea-base-url"
+	GiteaTokenFlag                      = "gitea-token"
+	GiteaUserFlag                       = "gitea-user"
+	GiteaWebhookSecretFlag              = "gitea-webhook-secret" // nolint: gosec
+	GiteaPageSizeFlag                   = "gitea-page-size"
+	GitlabGroupAllowlistFlag            = "gitlab-group-allowlist"
+	GitlabHostnameFlag                  = "gitlab-hostname"
+	GitlabTokenFlag                     = "gitlab-token"
+	GitlabTokenTypeFlag                 = "gitlab-token-type"
+	GitlabUserFlag                      = "gitlab-user"
+	GitlabWebhookSecretFlag             = "gitlab-webhook-secret" // nolint: gosec
+	IncludeGitUntrackedFiles            = "include-git-untracked-files"
+	JobLogCloudWatchGroupFlag           = "job-log-cloudwatch-group"
+	JobLogCloudWatchRegionFlag          = "job-log-cloudwatch-region"
+	JobLogLokiURLFlag                   = "job-log-loki-url"
+	APISecretFlag                       = "api-secret"
+	HidePrevPlanComments                = "hide-prev-plan-comments"
+	QuietPolicyChecks                   = "quiet-policy-checks"
+	LockingDBEncryptionKeyFileFlag      = "locking-db-encryption-key-file"
+	LockingDBType                       = "locking-db-type"
+	LogLevelFlag                        = "log-level"
+	MarkAppliedCommentsFlag             = "mark-applied-comments"
+	MarkdownTemplateOverridesDirFlag    = "markdown-template-overrides-dir"
+	MaxAutoplanProjects                 = "max-autoplan-projects"
+	MaxCommentsPerCommand               = "max-comments-per-command"
+	MessageCatalogFlag                  = "message-catalog"
+	ParallelPoolSize                    = "parallel-pool-size"
+	PendingPlanDiscardAgeFlag           = "pending-plan-discard-age"
+	PendingPlanReminderAgeFlag          = "pending-plan-reminder-age"
+	PlanEncryptionKeyFileFlag           = "plan-encryption-key-file"
+	PlanRetentionMaxAgeFlag             = "plan-retention-max-age"
+	PlanRetentionMaxCountFlag           = "plan-retention-max-count"
+	StatsNamespace                      = "stats-namespace"
+	AllowDraftPRs                       = "allow-draft-prs"
+	PortFlag                            = "port"
+	RedactEnvVarsFlag                   = "redact-env-vars"
+	RedactPatternsFlag                  = "redact-patterns"
+	RedisDB                             = "redis-db"
+	RedisHost                           = "redis-host"
+	RedisPassword                       = "redis-password"
+	RedisPort                           = "redis-port"
+	RedisTLSEnabled                     = "redis-tls-enabled"
+	RedisInsecureSkipVerify             = "redis-insecure-skip-verify"
+	RepoConfigFlag                      = "repo-config"
+	RepoConfigJSONFlag                  = "repo-config-json"
+	RepoAllowlistFlag                   = "repo-allowlist"
+	ShareWorkspaceInitFlag              = "share-workspace-init"
+	SilenceNoProjectsFlag               = "silence-no-projects"
+	SilenceForkPRErrorsFlag             = "silence-fork-pr-errors"
+	SilenceVCSStatusNoPlans             = "silence-vcs-status-no-plans"
+	SilenceVCSStatusNoProjectsFlag      = "silence-vcs-status-no-projects"
+	SilenceAllowlistErrorsFlag          = "silence-allowlist-errors"
+	SkipCloneNoChanges                  = "skip-clone-no-changes"
+	SummaryStatusFlag                   = "summary-status"
+	SlackSigningSecretFlag              = "slack-signing-secret" // nolint: gosec
+	SlackTokenFlag                      = "slack-token"
+	SlackUserMappingFlag                = "slack-user-mapping"
+	SSLCertFileFlag                     = "ssl-cert-file"
+	SSLClientAuthAllPathsFlag           = "ssl-client-auth-all-paths"
+	SSLClientCAFileFlag                 = "ssl-client-ca-file"
+	SSLClientSANAllowlistFlag           = "ssl-client-san-allowlist"
+	SSLKeyFileFlag                      = "ssl-key-file"
+	RestoreBackupFlag                   = "restore-backup"
+	RestrictFileList                    = "restrict-file-list"
+	RestrictForkPRsFlag                 = "restrict-fork-prs"
+	ReuseIdenticalPlanAnalysisFlag      = "reuse-identical-plan-analysis"
+	TeamSyncSCIMTokenFileFlag           = "team-sync-scim-token-file" // nolint: gosec
+	TeamSyncSCIMURLFlag                 = "team-sync-scim-url"
+	TFDistributionFlag                  = "tf-distribution" // deprecated for DefaultTFDistributionFlag
+	TFDownloadFlag                      = "tf-download"
+	TFDownloadURLFlag                   = "tf-download-url"
+	UseTFPluginCache                    = "use-tf-plugin-cache"
+	VarFileAllowlistFlag                = "var-file-allowlist"
+	VaultAddrFlag                       = "vault-addr"
+	VaultAuthMethodFlag                 = "vault-auth-method"
+	VaultAuthMountFlag                  = "vault-auth-mount"
+	VaultKubernetesTokenFileFlag        = "vault-kubernetes-token-file" // nolint: gosec
+	VaultNamespaceFlag                  = "vault-namespace"
+	VaultRoleFlag                       = "vault-role"
+	VaultSecretIDFileFlag               = "vault-secret-id-file" // nolint: gosec
+	VCSConfigValidationFlag             = "vcs-config-validation"
+	VCSEmojiReactionsFlag               = "vcs-emoji-reactions"
+	VCSFakeFixturesFlag                 = "vcs-fake-fixtures"
+	VCSHTTPCassetteFlag                 = "vcs-http-cassette"
+	VCSHTTPConfigFlag                   = "vcs-http-config"
+	VCSHTTPCassetteModeFlag             = "vcs-http-cassette-mode"
+	VCSRateLimitReserveFlag             = "vcs-rate-limit-reserve"
+	VCSStatusName                       = "vcs-status-name"
+	IgnoreVCSStatusNames                = "ignore-vcs-status-names"
+	TFEHostnameFlag                     = "tfe-hostname"
+	TFELocalExecutionModeFlag           = "tfe-local-execution-mode"
+	TFETokenFlag                        = "tfe-token"
+	WriteGitCredsFlag                   = "write-git-creds" // nolint: gosec
+	WebhookHttpHeaders                  = "webhook-http-headers"
+	WebBasicAuthFlag                    = "web-basic-auth"
+	WebUsernameFlag                     = "web-username"
+	WebPasswordFlag                     = "web-password"
+	WebsocketCheckOrigin                = "websocket-check-origin"
 
 	// NOTE: Must manually set these as defaults in the setDefaults function.
 	DefaultADBasicUser                  = ""
@@ -168,8 +250,10 @@ const (
 	DefaultAutoDiscoverMode             = "auto"
 	DefaultAutoplanFileList             = "**/*.tf,**/*.tfvars,**/*.tfvars.json,**/terragrunt.hcl,**/.terraform.lock.hcl"
 	DefaultAllowCommands                = "version,plan,apply,unlock,approve_policies"
+	DefaultApplyConfirmationTTL         = "10m"
 	DefaultCheckoutStrategy             = CheckoutStrategyBranch
 	DefaultCheckoutDepth                = 0
+	DefaultCommentStrategy              = CommentStrategyNew
 	DefaultBitbucketBaseURL             = bitbucketcloud.BaseURL
 	DefaultDataDir                      = "~/.atlantis"
 	DefaultEmojiReaction                = ""
@@ -179,6 +263,7 @@ const (
 	DefaultGiteaBaseURL                 = "https://gitea.com"
 	DefaultGiteaPageSize                = 30
 	DefaultGitlabHostname               = "gitlab.com"
+	DefaultGitlabTokenType              = vcs.GitlabAccessToken
 	DefaultLockingDBType                = "boltdb"
 	DefaultLogLevel                     = "info"
 	DefaultIgnoreVCSStatusNames         = ""
@@ -194,6 +279,8 @@ const (
 	DefaultTFDownloadURL                = "https://releases.hashicorp.com"
 	DefaultTFDownload                   = true
 	DefaultTFEHostname                  = "app.terraform.io"
+	DefaultVaultAuthMethod              = vault.AuthMethodKubernetes
+	DefaultVCSConfigValidation          = VCSConfigValidationWarn
 	DefaultVCSStatusName                = "atlantis"
 	DefaultWebBasicAuth                 = false
 	DefaultWebUsername                  = "atlantis"
@@ -223,10 +310,22 @@ var stringFlags = map[string]stringFlag{
 		description:  "Azure DevOps hostname to support cloud and self hosted instances.",
 		defaultValue: "dev.azure.com",
 	},
+	AckCommentFlag: {
+		description: "Comment made to acknowledge commands on VCS hosts that can't react to comments, ex. '🛠 received' on Bitbucket." +
+			" The comment is deleted once the command has completed. If not set, commands aren't acknowledged on those hosts.",
+	},
+	ApplyConfirmationTTLFlag: {
+		description:  "How long an apply to a repo with apply_confirmation set can be confirmed with 'atlantis confirm' for, ex. 10m.",
+		defaultValue: DefaultApplyConfirmationTTL,
+	},
 	AllowCommandsFlag: {
 		description:  "Comma separated list of acceptable atlantis commands.",
 		defaultValue: DefaultAllowCommands,
 	},
+	AllowExtraArgsFlag: {
+		description: "Comma separated list of the only terraform flags that can be passed to commands after '--' in comments, ex. '-target,-refresh=false'." +
+			" Defaults to allowing any flag.",
+	},
 	AtlantisURLFlag: {
 		description: "URL that Atlantis can be reached at. Defaults to http://$(hostname):$port where $port is from --" + PortFlag + ". Supports a base path ex. https://example.com/basepath.",
 	},
@@ -268,6 +367,16 @@ var stringFlags = map[string]stringFlag{
 			"This means that an attacker could spoof calls to Atlantis and cause it to perform malicious actions. " +
 			"Should be specified via the ATLANTIS_BITBUCKET_WEBHOOK_SECRET environment variable.",
 	},
+	BitbucketWebhookSecondarySecretFlag: {
+		description: "Secret that is also accepted when validating Bitbucket webhooks so that --" + BitbucketWebhookSecretFlag +
+			" can be rotated without downtime. Requires --" + BitbucketWebhookSecretFlag + " to be set." +
+			" Should be specified via the ATLANTIS_BITBUCKET_WEBHOOK_SECONDARY_SECRET environment variable.",
+	},
+	CgroupParentFlag: {
+		description: "Path of a cgroup v2 directory delegated to Atlantis, ex. /sys/fs/cgroup/atlantis/jobs." +
+			" The commands of repos with cpu or memory resource_limits in the server-side repo config are run in their own cgroup under it." +
+			" Only supported on Linux.",
+	},
 	CheckoutStrategyFlag: {
 		description: "How to check out pull requests. Accepts either 'branch' (default) or 'merge'." +
 			" If set to branch, Atlantis will check out the source branch of the pull request." +
@@ -277,13 +386,28 @@ var stringFlags = map[string]stringFlag{
 			" after the pull request is merged.",
 		defaultValue: "branch",
 	},
+	CloudIdentityTokenFileFlag: {
+		description: "Path to an OIDC identity token of the server, ex. a projected Kubernetes service account token." +
+			" It's exchanged for short-lived AWS or GCP credentials for projects that set cloud_credentials in their repo config.",
+	},
+	CommentStrategyFlag: {
+		description: "How to comment with the results of commands. Accepts either 'new' (default) or 'update-last'." +
+			" If set to new, Atlantis posts a new comment for every command." +
+			" If set to update-last, Atlantis edits its previous comment for the same command and dir instead" +
+			" where the VCS host supports it (GitHub, GitLab and Bitbucket Cloud), and otherwise posts a new comment.",
+		defaultValue: DefaultCommentStrategy,
+	},
 	ConfigFlag: {
-		description: "Path to yaml config file where flag values can also be set.",
+		description: "Path to a yaml, json, toml or hcl config file where flag values can also be set. ${VAR} is replaced with the value of the VAR environment variable.",
 	},
 	DataDirFlag: {
 		description:  "Path to directory to store Atlantis data.",
 		defaultValue: DefaultDataDir,
 	},
+	DenyExtraArgsFlag: {
+		description: "Comma separated list of terraform flags that can't be passed to commands after '--' in comments, ex. '-var,-var-file,-lock=false'." +
+			" Takes precedence over --" + AllowExtraArgsFlag + ".",
+	},
 	DisableAutoplanLabelFlag: {
 		description:  "Pull request label to disable atlantis auto planning feature only if present.",
 		defaultValue: "",
@@ -293,13 +417,30 @@ var stringFlags = map[string]stringFlag{
 		defaultValue: "",
 	},
 	EmojiReaction: {
-		description:  "Emoji Reaction to use to react to comments.",
+		description:  "Emoji Reaction to use to react to comments. The reaction is removed once the command has completed. Can be overridden per VCS host with --" + VCSEmojiReactionsFlag + ".",
 		defaultValue: DefaultEmojiReaction,
 	},
+	EventTranslatorURLFlag: {
+		description: "URL of a service that translates webhooks Atlantis doesn't support, ex. from Gerrit, into Atlantis events." +
+			" Requests to /events that weren't sent by a supported VCS host are forwarded to it with their headers and body." +
+			" If not set, they're ignored.",
+	},
+	EventsIPAllowlistFlag: {
+		description: "Comma separated list of IPs, CIDRs and VCS hosts allowed to send webhooks to /events, ex. 'github,10.0.0.0/8'." +
+			" The ranges of the VCS hosts " + strings.Join([]string{ipallowlist.GitHub, ipallowlist.Bitbucket, ipallowlist.GitLab}, ", ") + " are fetched from the ranges they publish and refreshed hourly." +
+			" If not set, webhooks are accepted from any IP.",
+	},
+	EventsIPAllowlistTrustedProxiesFlag: {
+		description: "Comma separated list of IPs and CIDRs of load balancers or proxies in front of Atlantis." +
+			" For requests from them, the client IP is read from the X-Forwarded-For header when checking --" + EventsIPAllowlistFlag + ".",
+	},
 	ExecutableName: {
 		description:  "Comment command executable name.",
 		defaultValue: DefaultExecutableName,
 	},
+	ForkPRAllowlistFlag: {
+		description: "Comma separated list of usernames whose fork pull requests aren't restricted by --" + RestrictForkPRsFlag + ", e.g. 'alice,bob'.",
+	},
 	GHHostnameFlag: {
 		description:  "Hostname of your Github Enterprise installation. If using github.com, no need to set.",
 		defaultValue: DefaultGHHostname,
@@ -383,6 +524,12 @@ var stringFlags = map[string]stringFlag{
 	GitlabTokenFlag: {
 		description: "GitLab token of API user. Can also be specified via the ATLANTIS_GITLAB_TOKEN environment variable.",
 	},
+	GitlabTokenTypeFlag: {
+		description: "Type of --" + GitlabTokenFlag + ". Either " + vcs.GitlabAccessToken + " for personal, group and project access tokens, or " +
+			vcs.GitlabJobToken + " for the CI_JOB_TOKEN of a GitLab CI job, which expires when its job finishes." +
+			" The scopes of access tokens are checked at startup.",
+		defaultValue: DefaultGitlabTokenType,
+	},
 	GitlabWebhookSecretFlag: {
 		description: "Optional secret used to validate GitLab webhooks." +
 			" SECURITY WARNING: If not specified, Atlantis won't be able to validate that the incoming webhook call came from GitLab. " +
@@ -392,6 +539,22 @@ var stringFlags = map[string]stringFlag{
 	APISecretFlag: {
 		description: "Secret used to validate requests made to the /api/* endpoints",
 	},
+	JobLogCloudWatchGroupFlag: {
+		description: "AWS CloudWatch Logs log group to also send the output of plan, apply and other jobs to, with a log stream per job." +
+			" Requests are signed with the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.",
+	},
+	JobLogCloudWatchRegionFlag: {
+		description: "AWS region of --" + JobLogCloudWatchGroupFlag + ". Defaults to the AWS_REGION environment variable.",
+	},
+	JobLogLokiURLFlag: {
+		description: "Base URL of a Grafana Loki server to also push the output of plan, apply and other jobs to, ex. http://loki:3100." +
+			" Lines are labeled with the repo, pull request, project, dir, workspace and step of their job.",
+	},
+	LockingDBEncryptionKeyFileFlag: {
+		description: "Path to a file with base64 encoded 32 byte keys, one per line, ex. generated with 'openssl rand -base64 32'." +
+			" If set, the locks, pull statuses and settings stored in the locking database are encrypted with the first key." +
+			" The other keys only decrypt records, so keys are rotated by adding the new key as the first line.",
+	},
 	LockingDBType: {
 		description:  "The locking database type to use for storing plan and apply locks.",
 		defaultValue: DefaultLockingDBType,
@@ -404,6 +567,24 @@ var stringFlags = map[string]stringFlag{
 		description:  "Directory for custom overrides to the markdown templates used for comments.",
 		defaultValue: DefaultMarkdownTemplateOverridesDir,
 	},
+	MessageCatalogFlag: {
+		description: "Path to a YAML or JSON file that overrides the text of comments and commit statuses, ex. to translate them.",
+	},
+	PendingPlanDiscardAgeFlag: {
+		description: "If set, how long plans can go unapplied, ex. 336h. Older plans are discarded, the locks their pull request holds on their projects are released" +
+			" and the pull request is commented on.",
+	},
+	PendingPlanReminderAgeFlag: {
+		description: "If set, how long plans can go unapplied before their pull request is reminded about them with a comment, ex. 72h.",
+	},
+	PlanEncryptionKeyFileFlag: {
+		description: "Path to a file with a base64 encoded 32 byte key, ex. generated with 'openssl rand -base64 32'." +
+			" If set, plan files and plan JSON are encrypted with it while they're stored between plan and apply.",
+	},
+	PlanRetentionMaxAgeFlag: {
+		description: "If set, how long plans are kept for, ex. 168h. Older plans are deleted and their projects must be planned again before they can be applied." +
+			" The plan commit status of their pull request is updated to no longer count them as planned.",
+	},
 	StatsNamespace: {
 		description:  "Namespace for aggregating stats.",
 		defaultValue: DefaultStatsNamespace,
@@ -420,27 +601,65 @@ var stringFlags = map[string]stringFlag{
 	RepoConfigJSONFlag: {
 		description: "Specify repo config as a JSON string. Useful if you don't want to write a config file to disk.",
 	},
+	RestoreBackupFlag: {
+		description: "Path to a backup created with 'atlantis backup' to restore the locks and pull statuses from on startup." +
+			" It's only restored if the locking DB is empty so it's safe to leave set.",
+	},
+	RedactEnvVarsFlag: {
+		description: "Comma separated list of environment variable names whose values are masked in logs, job output and pull request comments," +
+			" e.g. 'AWS_SECRET_ACCESS_KEY,GITHUB_TOKEN'.",
+	},
+	RedactPatternsFlag: {
+		description: "JSON array of regular expressions masked in logs, job output and pull request comments," +
+			` e.g. '["ghp_[A-Za-z0-9]{36}", "password=(\S+)"]'. If a pattern has capture groups, only the groups are masked.`,
+	},
 	RepoAllowlistFlag: {
 		description: "Comma separated list of repositories that Atlantis will operate on. " +
 			"The format is {hostname}/{owner}/{repo}, ex. github.com/runatlantis/atlantis. '*' matches any characters until the next comma. Examples: " +
 			"all repos: '*' (not secure), an entire hostname: 'internalgithub.com/*' or an organization: 'github.com/runatlantis/*'." +
 			" For Bitbucket Server, {owner} is the name of the project (not the key).",
 	},
+	SlackSigningSecretFlag: {
+		description: "Signing secret of the Slack app whose /atlantis slash command runs Atlantis commands." +
+			" If set, Slack slash commands are accepted at /slack/commands. Requires --" + SlackUserMappingFlag + ".",
+	},
 	SlackTokenFlag: {
 		description: "API token for Slack notifications.",
 	},
+	SlackUserMappingFlag: {
+		description: "Comma separated list of {slack user id}:{vcs user} pairs, ex. U012AB3CD:alice,U045EF6GH:bob." +
+			" Slack slash commands are run as the mapped VCS user and are rejected for Slack users that aren't mapped.",
+	},
 	SSLCertFileFlag: {
 		description: "File containing x509 Certificate used for serving HTTPS. If the cert is signed by a CA, the file should be the concatenation of the server's certificate, any intermediates, and the CA's certificate.",
 	},
+	SSLClientCAFileFlag: {
+		description: "File containing the PEM encoded CA certificates that client certificates are verified against." +
+			" If set, requests to the webhook, Slack and API endpoints must present a client certificate signed by one of them (mTLS)." +
+			" Requires --" + SSLCertFileFlag + ".",
+	},
+	SSLClientSANAllowlistFlag: {
+		description: "Comma separated list of patterns, ex. *.example.com,spiffe://example.com/ci/*." +
+			" If set, one of the DNS, URI, email or IP subject alternative names of client certificates must match one of them." +
+			" Requires --" + SSLClientCAFileFlag + ".",
+	},
 	SSLKeyFileFlag: {
 		description: fmt.Sprintf("File containing x509 private key matching --%s.", SSLCertFileFlag),
 	},
+	TeamSyncSCIMTokenFileFlag: {
+		description: "Path to the bearer token of the SCIM API set with --" + TeamSyncSCIMURLFlag + ". It's read before every sync so rotated tokens are picked up.",
+	},
+	TeamSyncSCIMURLFlag: {
+		description: "Base URL of the SCIM 2.0 API of an identity provider, ex. https://idp.example.com/scim/v2." +
+			" If set, the groups of users are synced from it every 10 minutes and used as their teams in team allowlists and policy owners," +
+			" instead of looking up their teams on the VCS host for every command. Requires --" + TeamSyncSCIMTokenFileFlag + ".",
+	},
 	TFDistributionFlag: {
 		description: "[Deprecated for --default-tf-distribution].",
 		hidden:      true,
 	},
 	TFDownloadURLFlag: {
-		description:  "Base URL to download Terraform versions from.",
+		description:  "Base URL to download Terraform versions from. {os} and {arch} are replaced with the platform of the server, ex. linux and arm64.",
 		defaultValue: DefaultTFDownloadURL,
 	},
 	TFEHostnameFlag: {
@@ -464,12 +683,63 @@ var stringFlags = map[string]stringFlag{
 		description: "Comma-separated list of additional paths where variable definition files can be read from." +
 			" If this argument is not provided, it defaults to Atlantis' data directory, determined by the --data-dir argument.",
 	},
+	VaultAddrFlag: {
+		description: "URL of a HashiCorp Vault server, ex. https://vault.example.com." +
+			" If set, run step commands and env step values can reference secrets with vault:<path>#<key>.",
+	},
+	VaultAuthMethodFlag: {
+		description:  "How to log in to Vault: " + strings.Join(vault.AuthMethods, " or ") + ".",
+		defaultValue: DefaultVaultAuthMethod,
+	},
+	VaultAuthMountFlag: {
+		description: "Path the Vault auth method is mounted at. Defaults to the name of the auth method.",
+	},
+	VaultKubernetesTokenFileFlag: {
+		description:  "Path to the Kubernetes service account token used to log in to Vault with the kubernetes auth method.",
+		defaultValue: vault.DefaultKubernetesTokenFile,
+	},
+	VaultNamespaceFlag: {
+		description: "Vault Enterprise namespace to read secrets from.",
+	},
+	VaultRoleFlag: {
+		description: "Vault role to log in as. The role name with the kubernetes auth method or the role ID with the approle auth method.",
+	},
+	VaultSecretIDFileFlag: {
+		description: "Path to the AppRole secret ID used to log in to Vault with the approle auth method.",
+	},
 	IgnoreVCSStatusNames: {
 		description: "Comma separated list of VCS status names from other atlantis services." +
 			" When `gh-allow-mergeable-bypass-apply` is true, will ignore status checks (e.g. `status1/plan`, `status1/apply`, `status2/plan`, `status2/apply`) from other Atlantis services when checking if the PR is mergeable." +
 			" Currently only implemented for GitHub.",
 		defaultValue: DefaultIgnoreVCSStatusNames,
 	},
+	VCSConfigValidationFlag: {
+		description: "Whether to check the VCS credentials and the webhooks of the repos allowlisted without wildcards on startup." +
+			" Accepts '" + VCSConfigValidationWarn + "' (default) to log the problems found, '" + VCSConfigValidationFail + "' to exit if any is found" +
+			" or '" + VCSConfigValidationOff + "' to skip the checks. They can also be run with GET /api/admin/vcs-config.",
+		defaultValue: DefaultVCSConfigValidation,
+	},
+	VCSEmojiReactionsFlag: {
+		description: "Comma separated list of VCS host and reaction pairs overriding --" + EmojiReaction + " on that host, ex. 'Github=rocket,Gitlab=thumbsup'." +
+			" The hosts are one of Github, Gitlab, Gitea, BitbucketCloud, BitbucketServer or AzureDevops and an empty reaction disables reacting on that host.",
+	},
+	VCSFakeFixturesFlag: {
+		description: "Path to a YAML file of pull request fixtures served by a fake VCS host instead of a real one, for running Atlantis locally without VCS credentials." +
+			" The pull requests are made from branches of local git repos and are served as GitHub pull requests on " + fake.Hostname + "." +
+			" Can't be used with the GitHub user or app flags. Use `atlantis testdrive --fixtures` to send events for them.",
+	},
+	VCSHTTPCassetteFlag: {
+		description: "Path to a cassette file the GitHub API requests and responses are recorded to or replayed from, depending on --" + VCSHTTPCassetteModeFlag + "." +
+			" Request headers aren't recorded so credentials don't end up in the cassette.",
+	},
+	VCSHTTPConfigFlag: {
+		description: "HTTP settings of the VCS hosts' API requests, keyed by hostname, provided as a JSON string." +
+			" Each host can set a \"proxy\" URL, a \"ca-file\" of PEM CAs trusted in addition to the system's and a \"tls-min-version\" of 1.0, 1.1, 1.2 or 1.3." +
+			" For example: `{\"github.com\":{\"proxy\":\"http://proxy.corp.com:3128\"},\"gitlab.corp.com\":{\"ca-file\":\"/etc/ssl/corp-ca.pem\",\"tls-min-version\":\"1.2\"}}`.",
+	},
+	VCSHTTPCassetteModeFlag: {
+		description: "Whether to " + fake.RecordMode + " the GitHub API requests to --" + VCSHTTPCassetteFlag + " or " + fake.ReplayMode + " the responses from it instead of sending requests.",
+	},
 	VCSStatusName: {
 		description:  "Name used to identify Atlantis for pull request statuses.",
 		defaultValue: DefaultVCSStatusName,
@@ -495,6 +765,11 @@ var boolFlags = map[string]boolFlag{
 		description:  "Allow Atlantis to run on pull requests from forks. A security issue for public repos.",
 		defaultValue: false,
 	},
+	ApplyOnMergeFlag: {
+		description: "Apply the plans of pull requests automatically after they are merged instead of by commenting 'atlantis apply'." +
+			" Plans are only applied if they were made for the commit that was merged. Can't be used with --" + AutomergeFlag + ".",
+		defaultValue: false,
+	},
 	AutoplanModules: {
 		description:  "Automatically plan projects that have a changed module from the local repository.",
 		defaultValue: false,
@@ -515,6 +790,10 @@ var boolFlags = map[string]boolFlag{
 	DisableRepoLockingFlag: {
 		description: "Disable atlantis locking repos",
 	},
+	DisableProjectStatusesFlag: {
+		description:  "Don't set a VCS commit status for each project. Combine with --" + SummaryStatusFlag + " to require a single status in branch protection.",
+		defaultValue: false,
+	},
 	DisableGlobalApplyLockFlag: {
 		description: "Disable atlantis global apply lock in UI",
 	},
@@ -522,10 +801,18 @@ var boolFlags = map[string]boolFlag{
 		description:  "Enables the discarding of approval if a new plan has been executed. Currently only Github is supported",
 		defaultValue: false,
 	},
+	EnableOutdatedDependenciesFlag: {
+		description:  "Report the providers and registry modules of each project that have newer releases in its plan comment. The latest versions are fetched from the registries. Repos can disable the report with outdated_dependencies: false in the server-side repo config.",
+		defaultValue: false,
+	},
 	EnablePolicyChecksFlag: {
 		description:  "Enable atlantis to run user defined policy checks.  This is explicitly disabled for TFE/TFC backends since plan files are inaccessible.",
 		defaultValue: false,
 	},
+	EnablePRDescriptionStatusFlag: {
+		description:  "Keep a block summarizing the plan, apply and policy check status of each project in the pull request description. Currently only GitHub and GitLab are supported.",
+		defaultValue: false,
+	},
 	EnableRegExpCmdFlag: {
 		description:  "Enable Atlantis to use regular expressions on plan/apply commands when \"-p\" flag is passed with it.",
 		defaultValue: false,
@@ -542,12 +829,31 @@ var boolFlags = map[string]boolFlag{
 		description:  "Fail and do not run the requested Atlantis command if any of the pre workflow hooks error.",
 		defaultValue: false,
 	},
+	GHAllowEditedCommentsFlag: {
+		description:  "Run commands from GitHub comments that are edited, not just from new comments. A command only runs again if the comment's text changed.",
+		defaultValue: false,
+	},
 	GHAllowMergeableBypassApply: {
 		description:  "Feature flag to enable functionality to allow mergeable check to ignore apply required check",
 		defaultValue: false,
 	},
+	GHAllowReviewCommentsFlag: {
+		description:  "Run commands from GitHub pull request review comments, ie. comments on the diff and replies in review threads, as well as from regular comments.",
+		defaultValue: false,
+	},
+	GHAppRepoScopedTokensFlag: {
+		description: "Authenticate API calls about a repository and clones of it with GitHub App installation tokens that can only access that repository." +
+			" Clone tokens can only read the repository's contents. Requires --" + GHAppIDFlag + ".",
+		defaultValue: false,
+	},
+	GHUseCheckRunsFlag: {
+		description: "Report the status of commands on GitHub pull requests as check runs instead of commit statuses." +
+			" Check runs show the results of commands, annotate files that failed policy checks and have buttons to re-run commands." +
+			" Requires --" + GHAppIDFlag + ".",
+		defaultValue: false,
+	},
 	AllowDraftPRs: {
-		description:  "Enable autoplan for Github Draft Pull Requests",
+		description:  "Enable autoplan for draft pull requests. Repos can override it with plan_drafts in the server-side repo config.",
 		defaultValue: false,
 	},
 	HidePrevPlanComments: {
@@ -559,6 +865,11 @@ var boolFlags = map[string]boolFlag{
 		description:  "Include git untracked files in the Atlantis modified file scope.",
 		defaultValue: false,
 	},
+	MarkAppliedCommentsFlag: {
+		description: "React to the comment an apply was run from when the apply succeeds. " +
+			"VCS support is limited to: GitHub, GitLab and Gitea.",
+		defaultValue: false,
+	},
 	ParallelPlanFlag: {
 		description:  "Run plan operations in parallel.",
 		defaultValue: false,
@@ -579,6 +890,10 @@ var boolFlags = map[string]boolFlag{
 		description:  "Controls whether the Redis client verifies the Redis server's certificate chain and host name. If true, accepts any certificate presented by the server and any host name in that certificate.",
 		defaultValue: DefaultRedisInsecureSkipVerify,
 	},
+	ShareWorkspaceInitFlag: {
+		description:  "Share the modules and providers installed by init between the workspaces of the same project dir in a pull request, so they're only downloaded once. The inits of these workspaces run one at a time.",
+		defaultValue: false,
+	},
 	SilenceNoProjectsFlag: {
 		description:  "Silences Atlants from responding to PRs when it finds no projects.",
 		defaultValue: false,
@@ -587,6 +902,10 @@ var boolFlags = map[string]boolFlag{
 		description:  "Silences the posting of fork pull requests not allowed error comments.",
 		defaultValue: false,
 	},
+	SummaryStatusFlag: {
+		description:  "Set a single VCS commit status that reports how many projects are planned and applied, in addition to the plan and apply statuses.",
+		defaultValue: false,
+	},
 	SilenceVCSStatusNoPlans: {
 		description:  "Silences VCS commit status when autoplan finds no projects to plan.",
 		defaultValue: false,
@@ -612,6 +931,11 @@ var boolFlags = map[string]boolFlag{
 		description:  "Skips cloning the PR repo if there are no projects were changed in the PR.",
 		defaultValue: false,
 	},
+	SSLClientAuthAllPathsFlag: {
+		description: "Require client certificates for every path except /healthz, including the UI, instead of only the webhook, Slack and API endpoints." +
+			" Requires --" + SSLClientCAFileFlag + ".",
+		defaultValue: false,
+	},
 	TFDownloadFlag: {
 		description:  "Allow Atlantis to list & download Terraform versions. Setting this to false can be helpful in air-gapped environments.",
 		defaultValue: DefaultTFDownload,
@@ -628,6 +952,16 @@ var boolFlags = map[string]boolFlag{
 		description:  "Block plan requests from projects outside the files modified in the pull request.",
 		defaultValue: false,
 	},
+	RestrictForkPRsFlag: {
+		description: "Allow commands on fork pull requests but restrict them: only plans can run and they use the server-side fork_pr_workflow," +
+			" which can't contain custom run steps. Authors listed in --" + ForkPRAllowlistFlag + " aren't restricted.",
+		defaultValue: false,
+	},
+	ReuseIdenticalPlanAnalysisFlag: {
+		description: "Reuse the policy check results of a project for the other projects of the pull request whose plans are identical," +
+			" ex. stacks stamped from the same module with the same inputs. Requires --" + EnablePolicyChecksFlag + ".",
+		defaultValue: false,
+	},
 	WebsocketCheckOrigin: {
 		description:  "Enable websocket origin check",
 		defaultValue: false,
@@ -648,6 +982,21 @@ var intFlags = map[string]intFlag{
 			" If merge base is further behind than this number of commits from any of branches heads, full fetch will be performed.",
 		defaultValue: DefaultCheckoutDepth,
 	},
+	CommandRateLimitPerPullFlag: {
+		description: "If non-zero, the maximum number of comment commands that can be run per minute on a pull request." +
+			" Further commands are ignored, with a comment on the first one, until older commands are over a minute old.",
+		defaultValue: 0,
+	},
+	CommandRateLimitPerUserFlag: {
+		description: "If non-zero, the maximum number of comment commands a user can run per minute across all pull requests." +
+			" Further commands are ignored, with a comment on the first one, until older commands are over a minute old.",
+		defaultValue: 0,
+	},
+	MaxAutoplanProjects: {
+		description: "If non-zero, the maximum number of projects that autoplan or a plan comment without flags will plan." +
+			" Pull requests affecting more projects must be confirmed with 'atlantis plan --confirm-all'.",
+		defaultValue: 0,
+	},
 	MaxCommentsPerCommand: {
 		description:  "If non-zero, the maximum number of comments to split command output into before truncating.",
 		defaultValue: DefaultMaxCommentsPerCommand,
@@ -660,6 +1009,11 @@ var intFlags = map[string]intFlag{
 		description:  "Max size of the wait group that runs parallel plans and applies (if enabled).",
 		defaultValue: DefaultParallelPoolSize,
 	},
+	PlanRetentionMaxCountFlag: {
+		description: "If non-zero, how many plans are kept for each project of a pull request, across its workspaces." +
+			" Older plans past this number are deleted like plans past --" + PlanRetentionMaxAgeFlag + ".",
+		defaultValue: 0,
+	},
 	PortFlag: {
 		description:  "Port to bind to.",
 		defaultValue: DefaultPort,
@@ -672,6 +1026,11 @@ var intFlags = map[string]intFlag{
 		description:  "The Redis Port for when using a Locking DB type of 'redis'.",
 		defaultValue: DefaultRedisPort,
 	},
+	VCSRateLimitReserveFlag: {
+		description: "Percentage of the API rate limit of each VCS host kept for commit statuses, merges and other critical calls." +
+			" When less of it is left, reactions and hiding previous comments are skipped. Also publishes the rate limits as metrics. 0 disables it.",
+		defaultValue: 0,
+	},
 }
 
 var int64Flags = map[string]int64Flag{
@@ -829,10 +1188,12 @@ func (s *ServerCmd) preRun() error {
 	// If passed a config file then try and load it.
 	configFile := s.Viper.GetString(ConfigFlag)
 	if configFile != "" {
-		s.Viper.SetConfigFile(configFile)
-		if err := s.Viper.ReadInConfig(); err != nil {
+		if err := readConfigFile(s.Viper, configFile); err != nil {
 			return errors.Wrapf(err, "invalid config: reading %s", configFile)
 		}
+		for _, key := range unknownConfigKeys(s.Viper) {
+			s.Logger.Warn("unknown key %q in config file %s", key, configFile)
+		}
 	}
 	return nil
 }
@@ -872,8 +1233,10 @@ func (s *ServerCmd) run() error {
 		AllowForkPRsFlag:          AllowForkPRsFlag,
 		AtlantisURLFlag:           AtlantisURLFlag,
 		AtlantisVersion:           s.AtlantisVersion,
+		CgroupParentFlag:          CgroupParentFlag,
 		DefaultTFDistributionFlag: DefaultTFDistributionFlag,
 		DefaultTFVersionFlag:      DefaultTFVersionFlag,
+		ForkPRAllowlistFlag:       ForkPRAllowlistFlag,
 		RepoConfigJSONFlag:        RepoConfigJSONFlag,
 		SilenceForkPRErrorsFlag:   SilenceForkPRErrorsFlag,
 	})
@@ -897,9 +1260,15 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if c.AllowCommands == "" {
 		c.AllowCommands = DefaultAllowCommands
 	}
+	if c.ApplyConfirmationTTL == "" {
+		c.ApplyConfirmationTTL = DefaultApplyConfirmationTTL
+	}
 	if c.CheckoutStrategy == "" {
 		c.CheckoutStrategy = DefaultCheckoutStrategy
 	}
+	if c.CommentStrategy == "" {
+		c.CommentStrategy = DefaultCommentStrategy
+	}
 	if c.DataDir == "" {
 		c.DataDir = DefaultDataDir
 	}
@@ -909,6 +1278,9 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if c.GitlabHostname == "" {
 		c.GitlabHostname = DefaultGitlabHostname
 	}
+	if c.GitlabTokenType == "" {
+		c.GitlabTokenType = DefaultGitlabTokenType
+	}
 	if c.GiteaBaseURL == "" {
 		c.GiteaBaseURL = DefaultGiteaBaseURL
 	}
@@ -960,6 +1332,9 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if c.TFDownloadURL == "" {
 		c.TFDownloadURL = DefaultTFDownloadURL
 	}
+	if c.VCSConfigValidation == "" {
+		c.VCSConfigValidation = DefaultVCSConfigValidation
+	}
 	if c.VCSStatusName == "" {
 		c.VCSStatusName = DefaultVCSStatusName
 	}
@@ -969,6 +1344,12 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig, v *viper.Viper) {
 	if c.TFEHostname == "" {
 		c.TFEHostname = DefaultTFEHostname
 	}
+	if c.VaultAuthMethod == "" {
+		c.VaultAuthMethod = DefaultVaultAuthMethod
+	}
+	if c.VaultKubernetesTokenFile == "" {
+		c.VaultKubernetesTokenFile = vault.DefaultKubernetesTokenFile
+	}
 	if c.WebUsername == "" {
 		c.WebUsername = DefaultWebUsername
 	}
@@ -997,9 +1378,113 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 			CheckoutStrategyBranch, CheckoutStrategyMerge)
 	}
 
+	if userConfig.CommentStrategy != CommentStrategyNew && userConfig.CommentStrategy != CommentStrategyUpdateLast {
+		return fmt.Errorf("invalid comment strategy: not one of %s or %s",
+			CommentStrategyNew, CommentStrategyUpdateLast)
+	}
+
+	if userConfig.GitlabTokenType != vcs.GitlabAccessToken && userConfig.GitlabTokenType != vcs.GitlabJobToken {
+		return fmt.Errorf("invalid --%s: not one of %s or %s", GitlabTokenTypeFlag, vcs.GitlabAccessToken, vcs.GitlabJobToken)
+	}
+
+	switch userConfig.VCSConfigValidation {
+	case VCSConfigValidationOff, VCSConfigValidationWarn, VCSConfigValidationFail:
+	default:
+		return fmt.Errorf("invalid --%s: not one of %s, %s or %s", VCSConfigValidationFlag, VCSConfigValidationOff, VCSConfigValidationWarn, VCSConfigValidationFail)
+	}
+
+	if userConfig.CommandRateLimitPerPull < 0 {
+		return fmt.Errorf("--%s must be 0 or greater", CommandRateLimitPerPullFlag)
+	}
+	if userConfig.CommandRateLimitPerUser < 0 {
+		return fmt.Errorf("--%s must be 0 or greater", CommandRateLimitPerUserFlag)
+	}
+	if userConfig.VCSRateLimitReserve < 0 || userConfig.VCSRateLimitReserve > 100 {
+		return fmt.Errorf("--%s must be between 0 and 100", VCSRateLimitReserveFlag)
+	}
+	if _, err := userConfig.ToPlanRetentionMaxAge(); err != nil {
+		return fmt.Errorf("invalid --%s: %s", PlanRetentionMaxAgeFlag, err)
+	}
+	reminderAge, err := userConfig.ToPendingPlanReminderAge()
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %s", PendingPlanReminderAgeFlag, err)
+	}
+	discardAge, err := userConfig.ToPendingPlanDiscardAge()
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %s", PendingPlanDiscardAgeFlag, err)
+	}
+	if reminderAge > 0 && discardAge > 0 && discardAge <= reminderAge {
+		return fmt.Errorf("--%s must be longer than --%s", PendingPlanDiscardAgeFlag, PendingPlanReminderAgeFlag)
+	}
+	if _, err := userConfig.ToApplyConfirmationTTL(); err != nil {
+		return fmt.Errorf("invalid --%s: %s", ApplyConfirmationTTLFlag, err)
+	}
+	if userConfig.PlanRetentionMaxCount < 0 {
+		return fmt.Errorf("--%s must be 0 or greater", PlanRetentionMaxCountFlag)
+	}
+	if userConfig.ApplyOnMerge && userConfig.Automerge {
+		return fmt.Errorf("--%s can't be used with --%s", ApplyOnMergeFlag, AutomergeFlag)
+	}
+
+	if userConfig.CommentStrategy == CommentStrategyUpdateLast && userConfig.HidePrevPlanComments {
+		return fmt.Errorf("--%s=%s can't be used with --%s", CommentStrategyFlag, CommentStrategyUpdateLast, HidePrevPlanComments)
+	}
+
 	if (userConfig.SSLKeyFile == "") != (userConfig.SSLCertFile == "") {
 		return fmt.Errorf("--%s and --%s are both required for ssl", SSLKeyFileFlag, SSLCertFileFlag)
 	}
+	if userConfig.SSLClientCAFile != "" && userConfig.SSLCertFile == "" {
+		return fmt.Errorf("--%s requires --%s and --%s to be set", SSLClientCAFileFlag, SSLCertFileFlag, SSLKeyFileFlag)
+	}
+	if userConfig.SSLClientCAFile == "" {
+		if userConfig.SSLClientSANAllowlist != "" {
+			return fmt.Errorf("--%s requires --%s to be set", SSLClientSANAllowlistFlag, SSLClientCAFileFlag)
+		}
+		if userConfig.SSLClientAuthAllPaths {
+			return fmt.Errorf("--%s requires --%s to be set", SSLClientAuthAllPathsFlag, SSLClientCAFileFlag)
+		}
+	}
+	if userConfig.VCSFakeFixtures != "" && (userConfig.GithubUser != "" || userConfig.GithubAppID != 0) {
+		return fmt.Errorf("--%s can't be used with --%s or --%s", VCSFakeFixturesFlag, GHUserFlag, GHAppIDFlag)
+	}
+	if (userConfig.VCSHTTPCassette == "") != (userConfig.VCSHTTPCassetteMode == "") {
+		return fmt.Errorf("--%s and --%s must be set together", VCSHTTPCassetteFlag, VCSHTTPCassetteModeFlag)
+	}
+	if userConfig.VCSHTTPCassette != "" {
+		if userConfig.VCSHTTPCassetteMode != fake.RecordMode && userConfig.VCSHTTPCassetteMode != fake.ReplayMode {
+			return fmt.Errorf("invalid --%s: must be %s or %s", VCSHTTPCassetteModeFlag, fake.RecordMode, fake.ReplayMode)
+		}
+		if userConfig.GithubUser == "" && userConfig.GithubAppID == 0 {
+			return fmt.Errorf("--%s requires --%s or --%s to be set", VCSHTTPCassetteFlag, GHUserFlag, GHAppIDFlag)
+		}
+	}
+	if userConfig.ReuseIdenticalPlanAnalysis && !userConfig.EnablePolicyChecksFlag {
+		return fmt.Errorf("--%s requires --%s to be set", ReuseIdenticalPlanAnalysisFlag, EnablePolicyChecksFlag)
+	}
+	if (userConfig.TeamSyncSCIMURL == "") != (userConfig.TeamSyncSCIMTokenFile == "") {
+		return fmt.Errorf("--%s and --%s must be set together", TeamSyncSCIMURLFlag, TeamSyncSCIMTokenFileFlag)
+	}
+	if userConfig.EventsIPAllowlist == "" && userConfig.EventsIPAllowlistTrustedProxies != "" {
+		return fmt.Errorf("--%s requires --%s to be set", EventsIPAllowlistTrustedProxiesFlag, EventsIPAllowlistFlag)
+	}
+	for _, entry := range userConfig.ToEventsIPAllowlist() {
+		if _, isHost := ipallowlist.DefaultFetchers("")[entry]; isHost {
+			continue
+		}
+		if _, err := ipallowlist.ParseCIDR(entry); err != nil {
+			return fmt.Errorf("invalid --%s: %s, must be an IP, CIDR or one of %s, %s, %s", EventsIPAllowlistFlag, err, ipallowlist.GitHub, ipallowlist.Bitbucket, ipallowlist.GitLab)
+		}
+	}
+	for _, proxy := range userConfig.ToEventsIPAllowlistTrustedProxies() {
+		if _, err := ipallowlist.ParseCIDR(proxy); err != nil {
+			return fmt.Errorf("invalid --%s: %s", EventsIPAllowlistTrustedProxiesFlag, err)
+		}
+	}
+	for _, pattern := range userConfig.ToSSLClientSANAllowlist() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q in --%s: %s", pattern, SSLClientSANAllowlistFlag, err)
+		}
+	}
 
 	// The following combinations are valid.
 	// 1. github user and (token or token file)
@@ -1009,6 +1494,7 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	// 5. bitbucket user and token set
 	// 6. azuredevops user and token set
 	// 7. any combination of the above
+	// 8. fake VCS fixtures set, alone or with any of the above except GitHub
 	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GHUserFlag, GHTokenFileFlag, GHAppIDFlag, GHAppKeyFileFlag, GHAppIDFlag, GHAppKeyFlag, GiteaUserFlag, GiteaTokenFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag, ADUserFlag, ADTokenFlag)
 	if ((userConfig.GiteaUser == "") != (userConfig.GiteaToken == "")) ||
 		((userConfig.GitlabUser == "") != (userConfig.GitlabToken == "")) ||
@@ -1028,7 +1514,7 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	}
 	// At this point, we know that there can't be a single user/token without
 	// its partner, but we haven't checked if any user/token is set at all.
-	if userConfig.GithubAppID == 0 && userConfig.GithubUser == "" && userConfig.GiteaUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" {
+	if userConfig.GithubAppID == 0 && userConfig.GithubUser == "" && userConfig.VCSFakeFixtures == "" && userConfig.GiteaUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" {
 		return vcsErr
 	}
 
@@ -1046,6 +1532,30 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	if parsed.Scheme != "http" && parsed.Scheme != "https" {
 		return fmt.Errorf("--%s must have http:// or https://, got %q", BitbucketBaseURLFlag, userConfig.BitbucketBaseURL)
 	}
+	if userConfig.GithubUseCheckRuns && userConfig.GithubAppID == 0 {
+		return fmt.Errorf("--%s requires --%s to be set because only GitHub apps can create check runs", GHUseCheckRunsFlag, GHAppIDFlag)
+	}
+	if userConfig.JobLogCloudWatchGroup == "" && userConfig.JobLogCloudWatchRegion != "" {
+		return fmt.Errorf("--%s requires --%s to be set", JobLogCloudWatchRegionFlag, JobLogCloudWatchGroupFlag)
+	}
+	if userConfig.JobLogCloudWatchGroup != "" && userConfig.JobLogCloudWatchRegion == "" && os.Getenv("AWS_REGION") == "" {
+		return fmt.Errorf("--%s requires --%s or AWS_REGION to be set", JobLogCloudWatchGroupFlag, JobLogCloudWatchRegionFlag)
+	}
+	if userConfig.JobLogLokiURL != "" {
+		parsed, err := url.Parse(userConfig.JobLogLokiURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("--%s must have http:// or https://, got %q", JobLogLokiURLFlag, userConfig.JobLogLokiURL)
+		}
+	}
+	if userConfig.GithubAppRepoScopedTokens && userConfig.GithubAppID == 0 {
+		return fmt.Errorf("--%s requires --%s to be set", GHAppRepoScopedTokensFlag, GHAppIDFlag)
+	}
+	if userConfig.BitbucketWebhookSecondarySecret != "" && userConfig.BitbucketWebhookSecret == "" {
+		return fmt.Errorf("--%s requires --%s to be set", BitbucketWebhookSecondarySecretFlag, BitbucketWebhookSecretFlag)
+	}
+	if userConfig.SlackSigningSecret != "" && userConfig.SlackUserMapping == "" {
+		return fmt.Errorf("--%s requires --%s to be set", SlackSigningSecretFlag, SlackUserMappingFlag)
+	}
 
 	parsed, err = url.Parse(userConfig.GiteaBaseURL)
 	if err != nil {
@@ -1061,15 +1571,17 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 
 	// Warn if any tokens have newlines.
 	for name, token := range map[string]string{
-		GHTokenFlag:                userConfig.GithubToken,
-		GHTokenFileFlag:            userConfig.GithubTokenFile,
-		GHWebhookSecretFlag:        userConfig.GithubWebhookSecret,
-		GitlabTokenFlag:            userConfig.GitlabToken,
-		GitlabWebhookSecretFlag:    userConfig.GitlabWebhookSecret,
-		BitbucketTokenFlag:         userConfig.BitbucketToken,
-		BitbucketWebhookSecretFlag: userConfig.BitbucketWebhookSecret,
-		GiteaTokenFlag:             userConfig.GiteaToken,
-		GiteaWebhookSecretFlag:     userConfig.GiteaWebhookSecret,
+		GHTokenFlag:                         userConfig.GithubToken,
+		GHTokenFileFlag:                     userConfig.GithubTokenFile,
+		GHWebhookSecretFlag:                 userConfig.GithubWebhookSecret,
+		GitlabTokenFlag:                     userConfig.GitlabToken,
+		GitlabWebhookSecretFlag:             userConfig.GitlabWebhookSecret,
+		BitbucketTokenFlag:                  userConfig.BitbucketToken,
+		BitbucketWebhookSecretFlag:          userConfig.BitbucketWebhookSecret,
+		BitbucketWebhookSecondarySecretFlag: userConfig.BitbucketWebhookSecondarySecret,
+		GiteaTokenFlag:                      userConfig.GiteaToken,
+		GiteaWebhookSecretFlag:              userConfig.GiteaWebhookSecret,
+		SlackSigningSecretFlag:              userConfig.SlackSigningSecret,
 	} {
 		if strings.Contains(token, "\n") {
 			s.Logger.Warn("--%s contains a newline which is usually unintentional", name)
@@ -1080,6 +1592,18 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("if setting --%s, must set --%s", TFEHostnameFlag, TFETokenFlag)
 	}
 
+	if userConfig.VaultAddr != "" {
+		if !slices.Contains(vault.AuthMethods, userConfig.VaultAuthMethod) {
+			return fmt.Errorf("invalid --%s %q, must be one of %s", VaultAuthMethodFlag, userConfig.VaultAuthMethod, strings.Join(vault.AuthMethods, ", "))
+		}
+		if userConfig.VaultRole == "" {
+			return fmt.Errorf("--%s requires --%s to be set", VaultAddrFlag, VaultRoleFlag)
+		}
+		if userConfig.VaultAuthMethod == vault.AuthMethodAppRole && userConfig.VaultSecretIDFile == "" {
+			return fmt.Errorf("--%s=%s requires --%s to be set", VaultAuthMethodFlag, vault.AuthMethodAppRole, VaultSecretIDFileFlag)
+		}
+	}
+
 	_, patternErr := patternmatcher.New(strings.Split(userConfig.AutoplanFileList, ","))
 	if patternErr != nil {
 		return errors.Wrapf(patternErr, "invalid pattern in --%s, %s", AutoplanFileListFlag, userConfig.AutoplanFileList)
@@ -1089,10 +1613,33 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return errors.Wrapf(err, "invalid --%s", AllowCommandsFlag)
 	}
 
+	if _, err := userConfig.ToAllowExtraArgs(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", AllowExtraArgsFlag)
+	}
+
+	if _, err := userConfig.ToDenyExtraArgs(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", DenyExtraArgsFlag)
+	}
+
+	if _, err := userConfig.ToRedactor(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", RedactPatternsFlag)
+	}
+
+	if _, err := userConfig.ToVCSHTTPConfigs(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", VCSHTTPConfigFlag)
+	}
 	if _, err := userConfig.ToWebhookHttpHeaders(); err != nil {
 		return errors.Wrapf(err, "invalid --%s", WebhookHttpHeaders)
 	}
 
+	if _, err := userConfig.ToVCSEmojiReactions(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", VCSEmojiReactionsFlag)
+	}
+
+	if _, err := userConfig.ToSlackUserMapping(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", SlackUserMappingFlag)
+	}
+
 	return nil
 }
 
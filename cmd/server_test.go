@@ -55,116 +55,179 @@ func (s *ServerStarterMock) Start() error {
 // Adding a new flag? Add it to this slice for testing in alphabetical
 // order.
 var testFlags = map[string]interface{}{
-	ADHostnameFlag:                   "dev.azure.com",
-	ADTokenFlag:                      "ad-token",
-	ADUserFlag:                       "ad-user",
-	ADWebhookPasswordFlag:            "ad-wh-pass",
-	ADWebhookUserFlag:                "ad-wh-user",
-	AtlantisURLFlag:                  "url",
-	AutoplanModules:                  false,
-	AutoplanModulesFromProjects:      "",
-	AllowCommandsFlag:                "version,plan,apply,unlock,import,approve_policies",
-	AllowForkPRsFlag:                 true,
-	APISecretFlag:                    "",
-	AutoDiscoverModeFlag:             "auto",
-	AutomergeFlag:                    true,
-	AutoplanFileListFlag:             "**/*.tf,**/*.yml",
-	BitbucketBaseURLFlag:             "https://bitbucket-base-url.com",
-	BitbucketTokenFlag:               "bitbucket-token",
-	BitbucketUserFlag:                "bitbucket-user",
-	BitbucketWebhookSecretFlag:       "bitbucket-secret",
-	CheckoutStrategyFlag:             CheckoutStrategyMerge,
-	CheckoutDepthFlag:                0,
-	DataDirFlag:                      "/path",
-	DefaultTFDistributionFlag:        "terraform",
-	DefaultTFVersionFlag:             "v0.11.0",
-	DisableApplyAllFlag:              true,
-	DisableMarkdownFoldingFlag:       true,
-	DisableRepoLockingFlag:           true,
-	DisableGlobalApplyLockFlag:       false,
-	DiscardApprovalOnPlanFlag:        true,
-	EmojiReaction:                    "eyes",
-	ExecutableName:                   "atlantis",
-	FailOnPreWorkflowHookError:       false,
-	GHAllowMergeableBypassApply:      false,
-	GHHostnameFlag:                   "ghhostname",
-	GHTeamAllowlistFlag:              "",
-	GHTokenFlag:                      "token",
-	GHTokenFileFlag:                  "",
-	GHUserFlag:                       "user",
-	GHAppIDFlag:                      int64(0),
-	GHAppKeyFlag:                     "",
-	GHAppKeyFileFlag:                 "",
-	GHAppSlugFlag:                    "atlantis",
-	GHAppInstallationIDFlag:          int64(0),
-	GHOrganizationFlag:               "",
-	GHWebhookSecretFlag:              "secret",
-	GiteaBaseURLFlag:                 "http://localhost",
-	GiteaTokenFlag:                   "gitea-token",
-	GiteaUserFlag:                    "gitea-user",
-	GiteaWebhookSecretFlag:           "gitea-secret",
-	GiteaPageSizeFlag:                30,
-	GitlabGroupAllowlistFlag:         "",
-	GitlabHostnameFlag:               "gitlab-hostname",
-	GitlabTokenFlag:                  "gitlab-token",
-	GitlabUserFlag:                   "gitlab-user",
-	GitlabWebhookSecretFlag:          "gitlab-secret",
-	HideUnchangedPlanComments:        false,
-	HidePrevPlanComments:             false,
-	IncludeGitUntrackedFiles:         false,
-	LockingDBType:                    "boltdb",
-	LogLevelFlag:                     "debug",
-	MarkdownTemplateOverridesDirFlag: "/path2",
-	MaxCommentsPerCommand:            10,
-	StatsNamespace:                   "atlantis",
-	AllowDraftPRs:                    true,
-	PortFlag:                         8181,
-	ParallelPoolSize:                 100,
-	ParallelPlanFlag:                 true,
-	ParallelApplyFlag:                true,
-	QuietPolicyChecks:                false,
-	RedisHost:                        "",
-	RedisInsecureSkipVerify:          false,
-	RedisPassword:                    "",
-	RedisPort:                        6379,
-	RedisTLSEnabled:                  false,
-	RedisDB:                          0,
-	RepoAllowlistFlag:                "github.com/runatlantis/atlantis",
-	RepoConfigFlag:                   "",
-	RepoConfigJSONFlag:               "",
-	SilenceNoProjectsFlag:            false,
-	SilenceVCSStatusNoProjectsFlag:   false,
-	SilenceForkPRErrorsFlag:          true,
-	SilenceAllowlistErrorsFlag:       true,
-	SilenceVCSStatusNoPlans:          true,
-	SkipCloneNoChanges:               true,
-	SlackTokenFlag:                   "slack-token",
-	SSLCertFileFlag:                  "cert-file",
-	SSLKeyFileFlag:                   "key-file",
-	RestrictFileList:                 false,
-	TFDistributionFlag:               "terraform",
-	TFDownloadFlag:                   true,
-	TFDownloadURLFlag:                "https://my-hostname.com",
-	TFEHostnameFlag:                  "my-hostname",
-	TFELocalExecutionModeFlag:        true,
-	TFETokenFlag:                     "my-token",
-	UseTFPluginCache:                 true,
-	VarFileAllowlistFlag:             "/path",
-	VCSStatusName:                    "my-status",
-	IgnoreVCSStatusNames:             "",
-	WebhookHttpHeaders:               `{"Authorization":"Bearer some-token","X-Custom-Header":["value1","value2"]}`,
-	WebBasicAuthFlag:                 false,
-	WebPasswordFlag:                  "atlantis",
-	WebUsernameFlag:                  "atlantis",
-	WebsocketCheckOrigin:             false,
-	WriteGitCredsFlag:                true,
-	DisableAutoplanFlag:              true,
-	DisableAutoplanLabelFlag:         "no-auto-plan",
-	DisableUnlockLabelFlag:           "do-not-unlock",
-	EnablePolicyChecksFlag:           false,
-	EnableRegExpCmdFlag:              false,
-	EnableDiffMarkdownFormat:         false,
-	EnableProfilingAPI:               false,
+	ADHostnameFlag:                      "dev.azure.com",
+	AckCommentFlag:                      "🛠 received",
+	ADTokenFlag:                         "ad-token",
+	ADUserFlag:                          "ad-user",
+	ADWebhookPasswordFlag:               "ad-wh-pass",
+	ADWebhookUserFlag:                   "ad-wh-user",
+	AtlantisURLFlag:                     "url",
+	AutoplanModules:                     false,
+	AutoplanModulesFromProjects:         "",
+	AllowCommandsFlag:                   "version,plan,apply,unlock,import,approve_policies",
+	AllowExtraArgsFlag:                  "-target,-refresh=false",
+	AllowForkPRsFlag:                    true,
+	ApplyConfirmationTTLFlag:            "15m",
+	ApplyOnMergeFlag:                    false,
+	APISecretFlag:                       "",
+	AutoDiscoverModeFlag:                "auto",
+	AutomergeFlag:                       true,
+	AutoplanFileListFlag:                "**/*.tf,**/*.yml",
+	BitbucketBaseURLFlag:                "https://bitbucket-base-url.com",
+	BitbucketTokenFlag:                  "bitbucket-token",
+	BitbucketUserFlag:                   "bitbucket-user",
+	BitbucketWebhookSecondarySecretFlag: "bitbucket-secondary-secret",
+	BitbucketWebhookSecretFlag:          "bitbucket-secret",
+	CgroupParentFlag:                    "/sys/fs/cgroup/atlantis",
+	CheckoutStrategyFlag:                CheckoutStrategyMerge,
+	CloudIdentityTokenFileFlag:          "/var/run/secrets/tokens/atlantis",
+	CommandRateLimitPerPullFlag:         20,
+	CommandRateLimitPerUserFlag:         10,
+	CommentStrategyFlag:                 CommentStrategyUpdateLast,
+	CheckoutDepthFlag:                   0,
+	DataDirFlag:                         "/path",
+	DenyExtraArgsFlag:                   "-lock=false",
+	DefaultTFDistributionFlag:           "terraform",
+	DefaultTFVersionFlag:                "v0.11.0",
+	DisableApplyAllFlag:                 true,
+	DisableMarkdownFoldingFlag:          true,
+	DisableRepoLockingFlag:              true,
+	DisableProjectStatusesFlag:          true,
+	DisableGlobalApplyLockFlag:          false,
+	DiscardApprovalOnPlanFlag:           true,
+	EmojiReaction:                       "eyes",
+	ExecutableName:                      "atlantis",
+	FailOnPreWorkflowHookError:          false,
+	ForkPRAllowlistFlag:                 "alice,bob",
+	GHAllowEditedCommentsFlag:           true,
+	GHAllowMergeableBypassApply:         false,
+	GHAllowReviewCommentsFlag:           true,
+	GHUseCheckRunsFlag:                  false,
+	GHAppRepoScopedTokensFlag:           false,
+	GHHostnameFlag:                      "ghhostname",
+	GHTeamAllowlistFlag:                 "",
+	GHTokenFlag:                         "token",
+	GHTokenFileFlag:                     "",
+	GHUserFlag:                          "user",
+	GHAppIDFlag:                         int64(0),
+	GHAppKeyFlag:                        "",
+	GHAppKeyFileFlag:                    "",
+	GHAppSlugFlag:                       "atlantis",
+	GHAppInstallationIDFlag:             int64(0),
+	GHOrganizationFlag:                  "",
+	GHWebhookSecretFlag:                 "secret",
+	GiteaBaseURLFlag:                    "http://localhost",
+	GiteaTokenFlag:                      "gitea-token",
+	GiteaUserFlag:                       "gitea-user",
+	GiteaWebhookSecretFlag:              "gitea-secret",
+	GiteaPageSizeFlag:                   30,
+	GitlabGroupAllowlistFlag:            "",
+	GitlabHostnameFlag:                  "gitlab-hostname",
+	GitlabTokenFlag:                     "gitlab-token",
+	GitlabTokenTypeFlag:                 "job",
+	GitlabUserFlag:                      "gitlab-user",
+	GitlabWebhookSecretFlag:             "gitlab-secret",
+	HideUnchangedPlanComments:           false,
+	HidePrevPlanComments:                false,
+	IncludeGitUntrackedFiles:            false,
+	JobLogCloudWatchGroupFlag:           "atlantis-jobs",
+	JobLogCloudWatchRegionFlag:          "us-east-1",
+	JobLogLokiURLFlag:                   "http://loki:3100",
+	LockingDBEncryptionKeyFileFlag:      "/etc/atlantis/db-keys",
+	LockingDBType:                       "boltdb",
+	LogLevelFlag:                        "debug",
+	MarkAppliedCommentsFlag:             true,
+	MarkdownTemplateOverridesDirFlag:    "/path2",
+	MaxAutoplanProjects:                 5,
+	MaxCommentsPerCommand:               10,
+	MessageCatalogFlag:                  "/path/to/messages.yaml",
+	StatsNamespace:                      "atlantis",
+	AllowDraftPRs:                       true,
+	PortFlag:                            8181,
+	ParallelPoolSize:                    100,
+	ParallelPlanFlag:                    true,
+	ParallelApplyFlag:                   true,
+	PendingPlanDiscardAgeFlag:           "336h",
+	PendingPlanReminderAgeFlag:          "72h",
+	PlanEncryptionKeyFileFlag:           "/etc/atlantis/plan-key",
+	PlanRetentionMaxAgeFlag:             "168h",
+	PlanRetentionMaxCountFlag:           2,
+	QuietPolicyChecks:                   false,
+	RedactEnvVarsFlag:                   "AWS_SECRET_ACCESS_KEY",
+	RedactPatternsFlag:                  `["password=(\\S+)"]`,
+	RedisHost:                           "",
+	RedisInsecureSkipVerify:             false,
+	RedisPassword:                       "",
+	RedisPort:                           6379,
+	RedisTLSEnabled:                     false,
+	RedisDB:                             0,
+	RepoAllowlistFlag:                   "github.com/runatlantis/atlantis",
+	RepoConfigFlag:                      "",
+	RepoConfigJSONFlag:                  "",
+	RestoreBackupFlag:                   "/path/to/backup.json.gz",
+	RestrictForkPRsFlag:                 true,
+	ShareWorkspaceInitFlag:              true,
+	SilenceNoProjectsFlag:               false,
+	SilenceVCSStatusNoProjectsFlag:      false,
+	SilenceForkPRErrorsFlag:             true,
+	SilenceAllowlistErrorsFlag:          true,
+	SilenceVCSStatusNoPlans:             true,
+	SkipCloneNoChanges:                  true,
+	SummaryStatusFlag:                   true,
+	SlackSigningSecretFlag:              "slack-signing-secret",
+	SlackTokenFlag:                      "slack-token",
+	SlackUserMappingFlag:                "U012AB3CD:alice",
+	SSLCertFileFlag:                     "cert-file",
+	SSLKeyFileFlag:                      "key-file",
+	SSLClientAuthAllPathsFlag:           true,
+	SSLClientCAFileFlag:                 "client-ca-file",
+	SSLClientSANAllowlistFlag:           "*.example.com",
+	RestrictFileList:                    false,
+	ReuseIdenticalPlanAnalysisFlag:      false,
+	TeamSyncSCIMTokenFileFlag:           "/etc/atlantis/scim-token",
+	TeamSyncSCIMURLFlag:                 "https://idp.example.com/scim/v2",
+	TFDistributionFlag:                  "terraform",
+	TFDownloadFlag:                      true,
+	TFDownloadURLFlag:                   "https://my-hostname.com",
+	TFEHostnameFlag:                     "my-hostname",
+	TFELocalExecutionModeFlag:           true,
+	TFETokenFlag:                        "my-token",
+	UseTFPluginCache:                    true,
+	VarFileAllowlistFlag:                "/path",
+	VaultAddrFlag:                       "https://vault.example.com",
+	VaultAuthMethodFlag:                 "approle",
+	VaultAuthMountFlag:                  "approle-atlantis",
+	VaultKubernetesTokenFileFlag:        "/var/run/secrets/tokens/vault",
+	VaultNamespaceFlag:                  "infra",
+	VaultRoleFlag:                       "atlantis",
+	VaultSecretIDFileFlag:               "/var/run/secrets/vault/secret-id",
+	VCSConfigValidationFlag:             "fail",
+	VCSEmojiReactionsFlag:               "Gitlab=thumbsup",
+	VCSFakeFixturesFlag:                 "",
+	VCSHTTPCassetteFlag:                 "cassette.json",
+	VCSHTTPConfigFlag:                   `{"github.com":{"proxy":"http://proxy.corp.com:3128"}}`,
+	VCSHTTPCassetteModeFlag:             "record",
+	VCSRateLimitReserveFlag:             20,
+	VCSStatusName:                       "my-status",
+	IgnoreVCSStatusNames:                "",
+	WebhookHttpHeaders:                  `{"Authorization":"Bearer some-token","X-Custom-Header":["value1","value2"]}`,
+	WebBasicAuthFlag:                    false,
+	WebPasswordFlag:                     "atlantis",
+	WebUsernameFlag:                     "atlantis",
+	WebsocketCheckOrigin:                false,
+	WriteGitCredsFlag:                   true,
+	DisableAutoplanFlag:                 true,
+	DisableAutoplanLabelFlag:            "no-auto-plan",
+	DisableUnlockLabelFlag:              "do-not-unlock",
+	EnableOutdatedDependenciesFlag:      true,
+	EnablePolicyChecksFlag:              false,
+	EventTranslatorURLFlag:              "http://translator:8080/translate",
+	EventsIPAllowlistFlag:               "github,10.0.0.0/8",
+	EventsIPAllowlistTrustedProxiesFlag: "10.1.0.1",
+	EnablePRDescriptionStatusFlag:       true,
+	EnableRegExpCmdFlag:                 false,
+	EnableDiffMarkdownFormat:            false,
+	EnableProfilingAPI:                  false,
 }
 
 func TestExecute_Defaults(t *testing.T) {
@@ -491,6 +554,347 @@ func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	ErrEquals(t, "invalid checkout strategy: not one of branch or merge", err)
 }
 
+func TestExecute_ValidateCommentStrategy(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		CommentStrategyFlag: "invalid",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid comment strategy: not one of new or update-last", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		CommentStrategyFlag:  CommentStrategyUpdateLast,
+		HidePrevPlanComments: true,
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, "--comment-strategy=update-last can't be used with --hide-prev-plan-comments", err)
+}
+
+func TestExecute_ValidateApplyOnMerge(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		ApplyOnMergeFlag: true,
+		AutomergeFlag:    true,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--apply-on-merge can't be used with --automerge", err)
+}
+
+func TestExecute_ValidateRedactPatterns(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		RedactPatternsFlag: `["password=(\\S+"]`,
+	}, t)
+	err := c.Execute()
+	ErrContains(t, "invalid --redact-patterns: compiling redaction pattern", err)
+}
+
+func TestExecute_ValidatePlanRetention(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		PlanRetentionMaxAgeFlag: "-1h",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --plan-retention-max-age: -1h must be positive", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		PlanRetentionMaxCountFlag: -1,
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, "--plan-retention-max-count must be 0 or greater", err)
+}
+
+func TestExecute_ValidatePendingPlanReminder(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		PendingPlanReminderAgeFlag: "3 days",
+	}, t)
+	err := c.Execute()
+	ErrContains(t, "invalid --pending-plan-reminder-age", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		PendingPlanReminderAgeFlag: "72h",
+		PendingPlanDiscardAgeFlag:  "24h",
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, "--pending-plan-discard-age must be longer than --pending-plan-reminder-age", err)
+}
+
+func TestExecute_ValidateApplyConfirmationTTL(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		ApplyConfirmationTTLFlag: "0s",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --apply-confirmation-ttl: 0s must be positive", err)
+}
+
+func TestExecute_ValidateVCSConfigValidation(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		VCSConfigValidationFlag: "strict",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --vcs-config-validation: not one of off, warn or fail", err)
+}
+
+func TestExecute_ValidateVCSHTTPConfig(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		VCSHTTPConfigFlag: `{"gitlab.corp.com":{"tls-min-version":"1.4"}}`,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --vcs-http-config: configuring HTTP for gitlab.corp.com: unsupported TLS version "1.4", must be one of 1.0, 1.1, 1.2 or 1.3`, err)
+}
+
+func TestExecute_ValidateGitlabTokenType(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		GitlabTokenTypeFlag: "oauth",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --gitlab-token-type: not one of access or job", err)
+}
+
+func TestExecute_ValidateBitbucketWebhookSecondarySecret(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		BitbucketWebhookSecondarySecretFlag: "secondary",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--bitbucket-webhook-secondary-secret requires --bitbucket-webhook-secret to be set", err)
+}
+
+func TestExecute_ValidateGHUseCheckRuns(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		GHUseCheckRunsFlag: true,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--gh-use-check-runs requires --gh-app-id to be set because only GitHub apps can create check runs", err)
+}
+
+func TestExecute_ValidateGHAppRepoScopedTokens(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		GHAppRepoScopedTokensFlag: true,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--gh-app-repo-scoped-tokens requires --gh-app-id to be set", err)
+}
+
+func TestExecute_ValidateJobLogSinks(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	cases := []struct {
+		flags  map[string]interface{}
+		expErr string
+	}{
+		{
+			flags:  map[string]interface{}{JobLogCloudWatchRegionFlag: "us-east-1"},
+			expErr: "--job-log-cloudwatch-region requires --job-log-cloudwatch-group to be set",
+		},
+		{
+			flags:  map[string]interface{}{JobLogCloudWatchGroupFlag: "atlantis-jobs"},
+			expErr: "--job-log-cloudwatch-group requires --job-log-cloudwatch-region or AWS_REGION to be set",
+		},
+		{
+			flags:  map[string]interface{}{JobLogLokiURLFlag: "loki:3100"},
+			expErr: `--job-log-loki-url must have http:// or https://, got "loki:3100"`,
+		},
+		{
+			flags: map[string]interface{}{JobLogCloudWatchGroupFlag: "atlantis-jobs", JobLogCloudWatchRegionFlag: "us-east-1", JobLogLokiURLFlag: "http://loki:3100"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			cmd := setupWithDefaults(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestExecute_ValidateReuseIdenticalPlanAnalysis(t *testing.T) {
+	cmd := setupWithDefaults(map[string]interface{}{ReuseIdenticalPlanAnalysisFlag: true}, t)
+	ErrEquals(t, "--reuse-identical-plan-analysis requires --enable-policy-checks to be set", cmd.Execute())
+
+	cmd = setupWithDefaults(map[string]interface{}{ReuseIdenticalPlanAnalysisFlag: true, EnablePolicyChecksFlag: true}, t)
+	Ok(t, cmd.Execute())
+}
+
+func TestExecute_ValidateSSLClientAuth(t *testing.T) {
+	cases := []struct {
+		flags  map[string]interface{}
+		expErr string
+	}{
+		{
+			flags:  map[string]interface{}{SSLClientCAFileFlag: "ca.pem"},
+			expErr: "--ssl-client-ca-file requires --ssl-cert-file and --ssl-key-file to be set",
+		},
+		{
+			flags:  map[string]interface{}{SSLClientSANAllowlistFlag: "*.example.com"},
+			expErr: "--ssl-client-san-allowlist requires --ssl-client-ca-file to be set",
+		},
+		{
+			flags:  map[string]interface{}{SSLClientAuthAllPathsFlag: true},
+			expErr: "--ssl-client-auth-all-paths requires --ssl-client-ca-file to be set",
+		},
+		{
+			flags:  map[string]interface{}{SSLCertFileFlag: "cert", SSLKeyFileFlag: "key", SSLClientCAFileFlag: "ca.pem", SSLClientSANAllowlistFlag: "[a-"},
+			expErr: `invalid pattern "[a-" in --ssl-client-san-allowlist: syntax error in pattern`,
+		},
+		{
+			flags: map[string]interface{}{SSLCertFileFlag: "cert", SSLKeyFileFlag: "key", SSLClientCAFileFlag: "ca.pem", SSLClientSANAllowlistFlag: "*.example.com, spiffe://example.com/*"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			cmd := setupWithDefaults(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestExecute_ValidateEventsIPAllowlist(t *testing.T) {
+	cases := []struct {
+		flags  map[string]interface{}
+		expErr string
+	}{
+		{
+			flags:  map[string]interface{}{EventsIPAllowlistTrustedProxiesFlag: "10.0.0.1"},
+			expErr: "--events-ip-allowlist-trusted-proxies requires --events-ip-allowlist to be set",
+		},
+		{
+			flags:  map[string]interface{}{EventsIPAllowlistFlag: "github,gitea"},
+			expErr: `invalid --events-ip-allowlist: "gitea" is not an IP or CIDR, must be an IP, CIDR or one of github, bitbucket, gitlab`,
+		},
+		{
+			flags:  map[string]interface{}{EventsIPAllowlistFlag: "github", EventsIPAllowlistTrustedProxiesFlag: "10.0.0.0/33"},
+			expErr: `invalid --events-ip-allowlist-trusted-proxies: "10.0.0.0/33" is not an IP or CIDR`,
+		},
+		{
+			flags: map[string]interface{}{EventsIPAllowlistFlag: "github, bitbucket, gitlab, 192.0.2.10, 2001:db8::/32", EventsIPAllowlistTrustedProxiesFlag: "10.0.0.0/8"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			cmd := setupWithDefaults(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestExecute_ValidateVCSFake(t *testing.T) {
+	cases := []struct {
+		flags  map[string]interface{}
+		expErr string
+	}{
+		{
+			flags:  map[string]interface{}{GHUserFlag: "user", GHTokenFlag: "token", VCSFakeFixturesFlag: "fixtures.yaml"},
+			expErr: "--vcs-fake-fixtures can't be used with --gh-user or --gh-app-id",
+		},
+		{
+			flags:  map[string]interface{}{GHUserFlag: "user", GHTokenFlag: "token", VCSHTTPCassetteFlag: "cassette.json"},
+			expErr: "--vcs-http-cassette and --vcs-http-cassette-mode must be set together",
+		},
+		{
+			flags:  map[string]interface{}{GHUserFlag: "user", GHTokenFlag: "token", VCSHTTPCassetteFlag: "cassette.json", VCSHTTPCassetteModeFlag: "rewind"},
+			expErr: "invalid --vcs-http-cassette-mode: must be record or replay",
+		},
+		{
+			flags:  map[string]interface{}{VCSFakeFixturesFlag: "fixtures.yaml", VCSHTTPCassetteFlag: "cassette.json", VCSHTTPCassetteModeFlag: "replay"},
+			expErr: "--vcs-http-cassette requires --gh-user or --gh-app-id to be set",
+		},
+		{
+			flags: map[string]interface{}{VCSFakeFixturesFlag: "fixtures.yaml"},
+		},
+		{
+			flags: map[string]interface{}{GHUserFlag: "user", GHTokenFlag: "token", VCSHTTPCassetteFlag: "cassette.json", VCSHTTPCassetteModeFlag: "replay"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			c.flags[RepoAllowlistFlag] = "*"
+			cmd := setup(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestExecute_ValidateVault(t *testing.T) {
+	cases := []struct {
+		flags  map[string]interface{}
+		expErr string
+	}{
+		{
+			flags:  map[string]interface{}{VaultAddrFlag: "https://vault.example.com"},
+			expErr: "--vault-addr requires --vault-role to be set",
+		},
+		{
+			flags:  map[string]interface{}{VaultAddrFlag: "https://vault.example.com", VaultRoleFlag: "atlantis", VaultAuthMethodFlag: "token"},
+			expErr: `invalid --vault-auth-method "token", must be one of kubernetes, approle`,
+		},
+		{
+			flags:  map[string]interface{}{VaultAddrFlag: "https://vault.example.com", VaultRoleFlag: "role-id", VaultAuthMethodFlag: "approle"},
+			expErr: "--vault-auth-method=approle requires --vault-secret-id-file to be set",
+		},
+		{
+			flags: map[string]interface{}{VaultAddrFlag: "https://vault.example.com", VaultRoleFlag: "atlantis"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			cmd := setupWithDefaults(c.flags, t)
+			err := cmd.Execute()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestExecute_ValidateSlackSigningSecret(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		SlackSigningSecretFlag: "secret",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--slack-signing-secret requires --slack-user-mapping to be set", err)
+}
+
+func TestExecute_ValidateSlackUserMapping(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		SlackSigningSecretFlag: "secret",
+		SlackUserMappingFlag:   "alice",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --slack-user-mapping: "alice" must be in the format {slack user id}:{vcs user}`, err)
+}
+
+func TestExecute_ValidateTeamSyncSCIM(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		TeamSyncSCIMURLFlag: "https://idp.example.com/scim/v2",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--team-sync-scim-url and --team-sync-scim-token-file must be set together", err)
+}
+
+func TestExecute_ValidateVCSEmojiReactions(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		VCSEmojiReactionsFlag: "Github=rocket,Bitbucket=eyes",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --vcs-emoji-reactions: "Bitbucket" is not a valid type`, err)
+}
+
 func TestExecute_ValidateSSLConfig(t *testing.T) {
 	expErr := "--ssl-key-file and --ssl-cert-file are both required for ssl"
 	cases := []struct {
@@ -817,6 +1221,20 @@ func TestExecute_ValidateAllowCommands(t *testing.T) {
 	}
 }
 
+func TestExecute_ValidateExtraArgs(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		AllowExtraArgsFlag: "target",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, `invalid --allow-extra-args: "target" is not a flag, flags must start with '-'`, err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		DenyExtraArgsFlag: "-var,var-file",
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, `invalid --deny-extra-args: "var-file" is not a flag, flags must start with '-'`, err)
+}
+
 func TestExecute_ExpandHomeInDataDir(t *testing.T) {
 	t.Log("If ~ is used as a data-dir path, should expand to absolute home path")
 	c := setup(map[string]interface{}{
@@ -1207,3 +1625,25 @@ func TestExecute_GiteaBaseURLPort(t *testing.T) {
 	Ok(t, c.Execute())
 	Equals(t, "http://mydomain.com:7990", passedConfig.GiteaBaseURL)
 }
+
+func TestExecute_ValidateCommandRateLimits(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		CommandRateLimitPerUserFlag: -1,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--command-rate-limit-per-user must be 0 or greater", err)
+
+	c = setupWithDefaults(map[string]interface{}{
+		CommandRateLimitPerPullFlag: -1,
+	}, t)
+	err = c.Execute()
+	ErrEquals(t, "--command-rate-limit-per-pull must be 0 or greater", err)
+}
+
+func TestExecute_ValidateVCSRateLimitReserve(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		VCSRateLimitReserveFlag: 101,
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "--vcs-rate-limit-reserve must be between 0 and 100", err)
+}
@@ -60,8 +60,16 @@ func main() {
 	}
 	version := &cmd.VersionCmd{AtlantisVersion: atlantisVersion}
 	testdrive := &cmd.TestdriveCmd{}
+	backup := &cmd.BackupCmd{}
+	remote := &cmd.RemoteCmd{}
+	initRepo := &cmd.InitRepoCmd{}
+	config := &cmd.ConfigCmd{Logger: logger}
 	cmd.RootCmd.AddCommand(server.Init())
 	cmd.RootCmd.AddCommand(version.Init())
 	cmd.RootCmd.AddCommand(testdrive.Init())
+	cmd.RootCmd.AddCommand(backup.Init())
+	cmd.RootCmd.AddCommand(remote.Init())
+	cmd.RootCmd.AddCommand(initRepo.Init())
+	cmd.RootCmd.AddCommand(config.Init())
 	cmd.Execute()
 }
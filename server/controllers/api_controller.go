@@ -1,25 +1,39 @@
 package controllers
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	tally "github.com/uber-go/tally/v4"
 )
 
 const atlantisTokenHeader = "X-Atlantis-Token"
 
+// RepoAllowlistSetting is the name the repo allowlist is persisted under when
+// it's changed via /api/admin/settings.
+const RepoAllowlistSetting = "repo-allowlist"
+
 type APIController struct {
 	APISecret                      []byte
 	Locker                         locking.Locker                   `validate:"required"`
@@ -37,6 +51,144 @@ type APIController struct {
 	WorkingDir                     events.WorkingDir                     `validate:"required"`
 	WorkingDirLocker               events.WorkingDirLocker               `validate:"required"`
 	CommitStatusUpdater            events.CommitStatusUpdater            `validate:"required"`
+	// ApplyOnMerge is the --apply-on-merge flag. Applies via /api/apply are
+	// refused when it's set, like apply comments.
+	ApplyOnMerge bool
+	// Snapshotter backs up the locks and pull statuses for /api/admin/backup.
+	Snapshotter locking.Snapshotter
+	// Reencrypter rewrites the locking DB records with the first encryption
+	// key for /api/admin/reencrypt. It's nil if records aren't encrypted.
+	Reencrypter locking.Reencrypter
+	// SettingsStore persists the settings changed via /api/admin/settings.
+	SettingsStore locking.SettingsStore
+	// DefaultRepoAllowlist is the --repo-allowlist flag. The repo allowlist
+	// is reset to it when it's set to an empty string via the API.
+	DefaultRepoAllowlist string
+	// GlobalCfgReloader reloads the server-side repo config for
+	// /api/admin/reload. It's nil if there's no config file to reload.
+	GlobalCfgReloader GlobalCfgReloader
+	// OutputHandler lists the jobs of a pull request for /api/jobs.
+	OutputHandler jobs.ProjectCommandOutputHandler
+	// PullStatusFetcher looks up the projects of a pull request to serve
+	// their plan JSON.
+	PullStatusFetcher events.PullStatusFetcher
+	// PlanEncryptor decrypts the plan JSON served if plans are encrypted.
+	PlanEncryptor events.PlanEncryptor
+	// DeleteLockCommand deletes the locks for DELETE /api/locks.
+	DeleteLockCommand events.DeleteLockCommand
+	// AtlantisVersion, ServerConfig, HealthChecks and ErrorRecorder make up
+	// the diagnostic bundle of /api/admin/diagnostics. ServerConfig is the
+	// server's flags with their secrets redacted.
+	AtlantisVersion string
+	ServerConfig    map[string]interface{}
+	HealthChecks    []HealthCheck
+	ErrorRecorder   *logging.ErrorRecorder
+	// VCSConfigValidator checks the VCS credentials and webhooks for
+	// /api/admin/vcs-config.
+	VCSConfigValidator *events.VCSConfigValidator
+	// WebhookRegistrar registers webhooks for /api/admin/webhooks.
+	WebhookRegistrar *events.WebhookRegistrar
+	// RepoOnboarder onboards repos for /api/admin/init-repo.
+	RepoOnboarder *events.RepoOnboarder
+	// ProjectSimulator, GlobalCfgParser and DefaultGlobalCfgArgs find the
+	// projects a candidate server-side repo config would configure for
+	// /api/admin/simulate.
+	ProjectSimulator     events.ProjectSimulator
+	GlobalCfgParser      GlobalCfgParser
+	DefaultGlobalCfgArgs valid.GlobalCfgArgs
+}
+
+// GlobalCfgReloader reloads the server-side repo config.
+type GlobalCfgReloader interface {
+	Reload() (valid.GlobalCfg, error)
+}
+
+// GlobalCfgParser parses a server-side repo config.
+type GlobalCfgParser interface {
+	ParseGlobalCfgYAML(cfgYAML string, defaultCfg valid.GlobalCfg) (valid.GlobalCfg, error)
+}
+
+// ReloadResult summarizes the reloaded server-side repo config.
+type ReloadResult struct {
+	Repos      int
+	Workflows  int
+	PolicySets int
+}
+
+// ReencryptResult is how many locking DB records were reencrypted.
+type ReencryptResult struct {
+	Records int
+}
+
+// ServerSettings are the server settings that can be changed at runtime via
+// /api/admin/settings.
+type ServerSettings struct {
+	RepoAllowlist string
+}
+
+// UpdateServerSettingsRequest changes the server settings. Settings that
+// are nil are left as is and settings that are empty are reset to the value
+// of their flag.
+type UpdateServerSettingsRequest struct {
+	RepoAllowlist *string
+}
+
+// RegisterWebhooksRequest registers the webhooks of the allowlisted repos of
+// Org, ex. a GitHub organization, GitLab group or Bitbucket workspace, on
+// VCSHost, ex. Github.
+type RegisterWebhooksRequest struct {
+	VCSHost       string `validate:"required"`
+	Org           string `validate:"required"`
+	UpdateSecrets bool
+	DryRun        bool
+}
+
+// InitRepoRequest generates an atlantis.yaml for Repo, ex. "owner/repo", on
+// VCSHost, ex. Github, and opens a pull request adding it unless DryRun is
+// true.
+type InitRepoRequest struct {
+	VCSHost string `validate:"required"`
+	Repo    string `validate:"required"`
+	DryRun  bool
+}
+
+// SimulateRequest finds the projects of pull request PR of Repository on
+// Type, ex. Github, at Ref under RepoConfig, a candidate server-side repo
+// config in YAML.
+type SimulateRequest struct {
+	Repository string `validate:"required"`
+	Ref        string `validate:"required"`
+	Type       string `validate:"required"`
+	PR         int
+	RepoConfig string `validate:"required"`
+}
+
+// SimulateResult compares the projects of a pull request under the current
+// and the candidate server-side repo configs.
+type SimulateResult struct {
+	Current   SimulatedConfig
+	Candidate SimulatedConfig
+}
+
+// SimulatedConfig is the projects a server-side repo config configures, or
+// the error if the repo's atlantis.yaml isn't allowed by it.
+type SimulatedConfig struct {
+	Projects []SimulatedProject
+	Error    string `json:",omitempty"`
+}
+
+// SimulatedProject is how a server-side repo config configures a project.
+type SimulatedProject struct {
+	Name               string
+	Dir                string
+	Workspace          string
+	Workflow           string
+	AutoplanEnabled    bool
+	PlanRequirements   []string
+	ApplyRequirements  []string
+	ImportRequirements []string
+	PolicyCheck        bool
+	RepoLocksMode      string
 }
 
 type APIRequest struct {
@@ -127,6 +279,10 @@ func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
 		a.apiReportError(w, code, err)
 		return
 	}
+	if a.ApplyOnMerge {
+		a.apiReportError(w, http.StatusForbidden, fmt.Errorf("applies are disabled because --apply-on-merge is set, plans are applied when their pull request is merged"))
+		return
+	}
 
 	err = a.apiSetup(ctx)
 	if err != nil {
@@ -170,45 +326,741 @@ type LockDetail struct {
 	User            string
 	Workspace       string
 	Time            time.Time
+	// HeldFor is how long the lock has been held for, ex. 1h2m3s.
+	HeldFor string
 }
 
 type ListLocksResult struct {
 	Locks []LockDetail
 }
 
-func (a *APIController) ListLocks(w http.ResponseWriter, r *http.Request) {
+// DiagnosticBundle is what support needs to troubleshoot an Atlantis server.
+type DiagnosticBundle struct {
+	CreatedAt       time.Time
+	AtlantisVersion string
+	GoVersion       string
+	OS              string
+	Arch            string
+	Config          map[string]interface{}
+	HealthChecks    []HealthCheckResult
+	RecentErrors    []logging.RecordedError
+}
+
+// DeleteLocksResult lists the locks deleted via the API, or the locks that
+// would have been deleted if DryRun is true.
+type DeleteLocksResult struct {
+	DryRun bool
+	Locks  []LockDetail
+}
+
+// JobDetail is a job whose output can be streamed from /jobs/{JobID}/ws.
+type JobDetail struct {
+	JobID       string
+	ProjectName string
+	Path        string
+	Workspace   string
+	JobStep     string
+	Time        time.Time
+}
+
+type ListJobsResult struct {
+	Jobs []JobDetail
+}
+
+// GetPlanJSON responds with the output of `terraform show -json` for the plan
+// of a project in a pull request. The project is looked up by name or, if no
+// project has that name, by its dir and the workspace query parameter. The
+// JSON is only available if the project's plan workflow ran the show step.
+func (a *APIController) GetPlanJSON(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.PullStatusFetcher == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("plan JSON isn't supported by this locking backend"))
+		return
+	}
+	vars := mux.Vars(r)
+	pullNum, err := strconv.Atoi(vars["pr"])
+	if vars["repo"] == "" || vars["project"] == "" || err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("repo, pull request number and project are required"))
+		return
+	}
+	baseRepo, code, err := a.apiParseRepo(r.URL.Query().Get("type"), vars["repo"])
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
 
-	locks, err := a.Locker.List()
+	pull := models.PullRequest{Num: pullNum, BaseRepo: baseRepo}
+	status, err := a.PullStatusFetcher.GetPullStatus(pull)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	project, ok := findProjectStatus(status, vars["project"], r.URL.Query().Get("workspace"))
+	if !ok {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no project %q has been planned in pull request %d", vars["project"], pullNum))
+		return
+	}
+
+	repoDir, err := a.WorkingDir.GetWorkingDir(baseRepo, pull, project.Workspace)
+	if err != nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("pull request %d hasn't been cloned", pullNum))
+		return
+	}
+	showFile := command.ProjectContext{ProjectName: project.ProjectName, Workspace: project.Workspace}.GetShowResultFileName()
+	readFile := os.ReadFile
+	if a.PlanEncryptor != nil {
+		readFile = a.PlanEncryptor.ReadFile
+	}
+	planJSON, err := readFile(filepath.Join(repoDir, project.RepoRelDir, showFile))
+	if os.IsNotExist(err) {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no plan JSON for project %q, the show step must be part of its plan workflow", vars["project"]))
+		return
+	}
 	if err != nil {
 		a.apiReportError(w, http.StatusInternalServerError, err)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(planJSON) // nolint: errcheck
+}
 
-	result := ListLocksResult{}
-	for name, lock := range locks {
-		lockDetail := LockDetail{
-			name,
-			lock.Project.ProjectName,
-			lock.Project.RepoFullName,
-			lock.Project.Path,
-			lock.Pull.Num,
-			lock.Pull.URL,
-			lock.User.Username,
-			lock.Workspace,
-			lock.Time,
+// PullStatusResult is the response of GET /api/pulls/{repo}/{pr}/status.
+type PullStatusResult struct {
+	Repository string
+	PR         int
+	HeadCommit string
+	Projects   []ProjectStatusResult
+	// History are the commands run on the pull request, oldest first.
+	History []CommandRunResult
+}
+
+// ProjectStatusResult is the status of a project of a pull request.
+type ProjectStatusResult struct {
+	ProjectName string
+	RepoRelDir  string
+	Workspace   string
+	Status      string
+	// LastPlan and LastApply are nil if the project hasn't been planned or
+	// applied at the head commit.
+	LastPlan  *CommandRunResult
+	LastApply *CommandRunResult
+}
+
+// CommandRunResult records who ran a command on a project, when and how.
+type CommandRunResult struct {
+	Command     string
+	ProjectName string
+	RepoRelDir  string
+	Workspace   string
+	User        string
+	HeadCommit  string
+	Status      string
+	Time        time.Time
+	Trigger     string
+	CommentID   int64  `json:",omitempty"`
+	CommentURL  string `json:",omitempty"`
+}
+
+// GetPullStatus responds with the status of the projects of a pull request
+// and the history of the commands run on it.
+func (a *APIController) GetPullStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.PullStatusFetcher == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("pull statuses aren't supported by this locking backend"))
+		return
+	}
+	vars := mux.Vars(r)
+	pullNum, err := strconv.Atoi(vars["pr"])
+	if vars["repo"] == "" || err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("repo and pull request number are required"))
+		return
+	}
+	baseRepo, code, err := a.apiParseRepo(r.URL.Query().Get("type"), vars["repo"])
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	status, err := a.PullStatusFetcher.GetPullStatus(models.PullRequest{Num: pullNum, BaseRepo: baseRepo})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if status == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no commands have run on pull request %d", pullNum))
+		return
+	}
+	result := PullStatusResult{
+		Repository: baseRepo.FullName,
+		PR:         pullNum,
+		HeadCommit: status.Pull.HeadCommit,
+		Projects:   []ProjectStatusResult{},
+		History:    []CommandRunResult{},
+	}
+	for _, p := range status.Projects {
+		result.Projects = append(result.Projects, ProjectStatusResult{
+			ProjectName: p.ProjectName,
+			RepoRelDir:  p.RepoRelDir,
+			Workspace:   p.Workspace,
+			Status:      p.Status.String(),
+			LastPlan:    newCommandRunResult(status.Pull, status.LastRun(p, command.Plan.String())),
+			LastApply:   newCommandRunResult(status.Pull, status.LastRun(p, command.Apply.String())),
+		})
+	}
+	for i := range status.History {
+		result.History = append(result.History, *newCommandRunResult(status.Pull, &status.History[i]))
+	}
+	response, err := json.Marshal(result)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+func newCommandRunResult(pull models.PullRequest, run *models.CommandRun) *CommandRunResult {
+	if run == nil {
+		return nil
+	}
+	return &CommandRunResult{
+		Command:     run.Command,
+		ProjectName: run.ProjectName,
+		RepoRelDir:  run.RepoRelDir,
+		Workspace:   run.Workspace,
+		User:        run.User,
+		HeadCommit:  run.HeadCommit,
+		Status:      run.Status.String(),
+		Time:        run.Time,
+		Trigger:     run.Trigger,
+		CommentID:   run.CommentID,
+		CommentURL:  pull.CommentURL(run.CommentID),
+	}
+}
+
+// findProjectStatus returns the project in status named project or, if none
+// is, the project in the dir project and workspace, which defaults to the
+// default workspace.
+func findProjectStatus(status *models.PullStatus, project string, workspace string) (models.ProjectStatus, bool) {
+	if status == nil {
+		return models.ProjectStatus{}, false
+	}
+	for _, p := range status.Projects {
+		if p.ProjectName == project && (workspace == "" || p.Workspace == workspace) {
+			return p, true
+		}
+	}
+	if workspace == "" {
+		workspace = events.DefaultWorkspace
+	}
+	dir := filepath.Clean(project)
+	for _, p := range status.Projects {
+		if filepath.Clean(p.RepoRelDir) == dir && p.Workspace == workspace {
+			return p, true
 		}
-		result.Locks = append(result.Locks, lockDetail)
 	}
+	return models.ProjectStatus{}, false
+}
+
+// ListJobs responds with the jobs run for the pull request in the
+// repository and pr query parameters, ordered by when they started.
+func (a *APIController) ListJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	repo := r.URL.Query().Get("repository")
+	pullNum, err := strconv.Atoi(r.URL.Query().Get("pr"))
+	if repo == "" || err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("repository and pr query parameters are required"))
+		return
+	}
+
+	result := ListJobsResult{Jobs: []JobDetail{}}
+	if a.OutputHandler != nil {
+		for _, pull := range a.OutputHandler.GetPullToJobMapping() {
+			if pull.Pull.RepoFullName != repo || pull.Pull.PullNum != pullNum {
+				continue
+			}
+			for _, job := range pull.JobIDInfos {
+				result.Jobs = append(result.Jobs, JobDetail{
+					JobID:       job.JobID,
+					ProjectName: pull.Pull.ProjectName,
+					Path:        pull.Pull.Path,
+					Workspace:   pull.Pull.Workspace,
+					JobStep:     job.JobStep,
+					Time:        job.Time,
+				})
+			}
+		}
+	}
+	sort.Slice(result.Jobs, func(i, j int) bool {
+		return result.Jobs[i].Time.Before(result.Jobs[j].Time)
+	})
 
 	response, err := json.Marshal(result)
 	if err != nil {
 		a.apiReportError(w, http.StatusInternalServerError, err)
 		return
 	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// ListLocks responds with the project locks that match the repository, pr,
+// user and older_than query parameters, oldest first. If the format query
+// parameter is csv, they're exported as CSV.
+func (a *APIController) ListLocks(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLockFilter(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	locks, err := a.filterLocks(filter)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		a.respondWithLocksCSV(w, locks)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	response, err := json.Marshal(ListLocksResult{Locks: locks})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
 	a.respond(w, logging.Warn, http.StatusOK, "%s", string(response))
 }
 
+// DeleteLocks deletes the project locks that match the repository, pr, user
+// and older_than query parameters and responds with them. At least one of
+// them must be set so that all locks can't be deleted by mistake. If the
+// dry_run query parameter is true, the locks are only listed.
+func (a *APIController) DeleteLocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.DeleteLockCommand == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("deleting locks isn't supported"))
+		return
+	}
+	filter, err := parseLockFilter(r)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.isEmpty() {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("one of the repository, pr, user or older_than query parameters is required"))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	locks, err := a.filterLocks(filter)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result := DeleteLocksResult{DryRun: dryRun, Locks: []LockDetail{}}
+	for _, lock := range locks {
+		if !dryRun {
+			if _, err := a.DeleteLockCommand.DeleteLock(a.Logger, lock.Name); err != nil {
+				a.apiReportError(w, http.StatusInternalServerError, fmt.Errorf("deleting lock %q: %w", lock.Name, err))
+				return
+			}
+		}
+		result.Locks = append(result.Locks, lock)
+	}
+	if !dryRun {
+		a.Logger.Info("deleted %d locks via the API", len(result.Locks))
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// lockFilter selects locks by the query parameters of a request. Unset
+// fields match all locks.
+type lockFilter struct {
+	repository string
+	pullNum    int
+	user       string
+	olderThan  time.Duration
+}
+
+func parseLockFilter(r *http.Request) (lockFilter, error) {
+	query := r.URL.Query()
+	filter := lockFilter{
+		repository: query.Get("repository"),
+		user:       query.Get("user"),
+	}
+	if pr := query.Get("pr"); pr != "" {
+		pullNum, err := strconv.Atoi(pr)
+		if err != nil {
+			return lockFilter{}, fmt.Errorf("invalid pr %q", pr)
+		}
+		filter.pullNum = pullNum
+	}
+	if olderThan := query.Get("older_than"); olderThan != "" {
+		d, err := time.ParseDuration(olderThan)
+		if err != nil {
+			return lockFilter{}, fmt.Errorf("invalid older_than %q: %w", olderThan, err)
+		}
+		filter.olderThan = d
+	}
+	return filter, nil
+}
+
+func (f lockFilter) isEmpty() bool {
+	return f == lockFilter{}
+}
+
+func (f lockFilter) matches(lock models.ProjectLock, now time.Time) bool {
+	return (f.repository == "" || lock.Project.RepoFullName == f.repository) &&
+		(f.pullNum == 0 || lock.Pull.Num == f.pullNum) &&
+		(f.user == "" || lock.User.Username == f.user) &&
+		now.Sub(lock.Time) >= f.olderThan
+}
+
+// filterLocks returns the locks that match filter, oldest first.
+func (a *APIController) filterLocks(filter lockFilter) ([]LockDetail, error) {
+	locks, err := a.Locker.List()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var result []LockDetail
+	for name, lock := range locks {
+		if !filter.matches(lock, now) {
+			continue
+		}
+		result = append(result, LockDetail{
+			Name:            name,
+			ProjectName:     lock.Project.ProjectName,
+			ProjectRepo:     lock.Project.RepoFullName,
+			ProjectRepoPath: lock.Project.Path,
+			PullID:          lock.Pull.Num,
+			PullURL:         lock.Pull.URL,
+			User:            lock.User.Username,
+			Workspace:       lock.Workspace,
+			Time:            lock.Time,
+			HeldFor:         now.Sub(lock.Time).Round(time.Second).String(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// respondWithLocksCSV responds with locks as a CSV file for audits.
+func (a *APIController) respondWithLocksCSV(w http.ResponseWriter, locks []LockDetail) {
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	out.Write([]string{"Name", "ProjectName", "ProjectRepo", "ProjectRepoPath", "PullID", "PullURL", "User", "Workspace", "Time", "HeldFor"}) // nolint: errcheck
+	for _, lock := range locks {
+		out.Write([]string{ // nolint: errcheck
+			lock.Name,
+			lock.ProjectName,
+			lock.ProjectRepo,
+			lock.ProjectRepoPath,
+			strconv.Itoa(lock.PullID),
+			lock.PullURL,
+			lock.User,
+			lock.Workspace,
+			lock.Time.Format(time.RFC3339),
+			lock.HeldFor,
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="atlantis-locks.csv"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes()) // nolint: errcheck
+}
+
+// Backup responds with a gzipped snapshot of all locks, pull statuses and
+// command locks that can be restored with --restore-backup.
+func (a *APIController) Backup(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.Snapshotter == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("backups aren't supported by this locking backend"))
+		return
+	}
+
+	snapshot, err := a.Snapshotter.Snapshot()
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := locking.WriteSnapshot(&buf, snapshot); err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.Logger.Info("backed up %d locks, %d pull statuses and %d command locks", len(snapshot.Locks), len(snapshot.Pulls), len(snapshot.CommandLocks))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("atlantis-backup-%s.json.gz", snapshot.CreatedAt.UTC().Format("20060102T150405Z"))))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes()) // nolint: errcheck
+}
+
+// Reencrypt rewrites the locking DB records that are unencrypted or
+// encrypted with an old key with the first encryption key.
+func (a *APIController) Reencrypt(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.Reencrypter == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("reencrypting requires --locking-db-encryption-key-file to be set"))
+		return
+	}
+
+	records, err := a.Reencrypter.Reencrypt()
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	response, err := json.Marshal(ReencryptResult{Records: records})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	a.respond(w, logging.Info, http.StatusOK, "%s", string(response))
+}
+
+// Diagnostics responds with a diagnostic bundle of the server's config, with
+// secrets redacted, versions, health checks and recent errors.
+func (a *APIController) Diagnostics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	bundle := DiagnosticBundle{
+		CreatedAt:       time.Now().UTC(),
+		AtlantisVersion: a.AtlantisVersion,
+		GoVersion:       runtime.Version(),
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		Config:          a.ServerConfig,
+		HealthChecks:    RunHealthChecks(a.HealthChecks),
+		RecentErrors:    a.ErrorRecorder.Recent(),
+	}
+	response, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("atlantis-diagnostics-%s.json", bundle.CreatedAt.Format("20060102T150405Z"))))
+	w.WriteHeader(http.StatusOK)
+	w.Write(response) // nolint: errcheck
+}
+
+// ValidateVCSConfig checks the credentials of the VCS hosts and the webhooks
+// of the repos allowlisted without wildcards and responds with the results.
+// It responds with a 200 even if checks fail.
+func (a *APIController) ValidateVCSConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.VCSConfigValidator == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("VCS config validation isn't configured"))
+		return
+	}
+
+	checks := a.VCSConfigValidator.Validate(a.Logger)
+	if checks == nil {
+		checks = []events.VCSConfigCheck{}
+	}
+	response, err := json.Marshal(checks)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// RegisterWebhooks creates or updates the webhooks of the allowlisted repos of
+// an organization and responds with what was done to each repo.
+func (a *APIController) RegisterWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.WebhookRegistrar == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("webhook registration isn't configured"))
+		return
+	}
+	var request RegisterWebhooksRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("VCSHost and Org are required"))
+		return
+	}
+
+	registrations, err := a.WebhookRegistrar.Register(a.Logger, request.VCSHost, request.Org, request.UpdateSecrets, request.DryRun)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := json.Marshal(registrations)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// InitRepo onboards a repo by opening a pull request adding an atlantis.yaml
+// with the projects found on its default branch.
+func (a *APIController) InitRepo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.RepoOnboarder == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("repo onboarding isn't configured"))
+		return
+	}
+	var request InitRepoRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("VCSHost and Repo are required"))
+		return
+	}
+
+	onboarding, err := a.RepoOnboarder.Onboard(a.Logger, request.VCSHost, request.Repo, request.DryRun)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := json.Marshal(onboarding)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// Simulate returns the projects of a pull request, with their workflows and
+// requirements, under the current and a candidate server-side repo config
+// without changing the config or running anything, so config changes can be
+// checked before they're rolled out.
+func (a *APIController) Simulate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.ProjectSimulator == nil || a.GlobalCfgParser == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("simulation isn't configured"))
+		return
+	}
+	var request SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("Type, Repository, Ref and RepoConfig are required"))
+		return
+	}
+	candidateCfg, err := a.GlobalCfgParser.ParseGlobalCfgYAML(request.RepoConfig, valid.NewGlobalCfgFromArgs(a.DefaultGlobalCfgArgs))
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("parsing RepoConfig: %w", err))
+		return
+	}
+	baseRepo, code, err := a.apiParseRepo(request.Type, request.Repository)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	ctx := &command.Context{
+		HeadRepo: baseRepo,
+		Pull: models.PullRequest{
+			Num:        request.PR,
+			BaseBranch: request.Ref,
+			HeadBranch: request.Ref,
+			HeadCommit: request.Ref,
+			BaseRepo:   baseRepo,
+		},
+		Scope: a.Scope,
+		Log:   a.Logger,
+		API:   true,
+	}
+
+	response, err := json.Marshal(SimulateResult{
+		Current:   newSimulatedConfig(a.ProjectSimulator.BuildHelpProjects(ctx)),
+		Candidate: newSimulatedConfig(a.ProjectSimulator.SimulateProjects(ctx, candidateCfg)),
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+func newSimulatedConfig(projects []valid.MergedProjectCfg, err error) SimulatedConfig {
+	if err != nil {
+		return SimulatedConfig{Error: err.Error()}
+	}
+	cfg := SimulatedConfig{Projects: []SimulatedProject{}}
+	for _, p := range projects {
+		cfg.Projects = append(cfg.Projects, SimulatedProject{
+			Name:               p.Name,
+			Dir:                p.RepoRelDir,
+			Workspace:          p.Workspace,
+			Workflow:           p.Workflow.Name,
+			AutoplanEnabled:    p.AutoplanEnabled,
+			PlanRequirements:   p.PlanRequirements,
+			ApplyRequirements:  p.ApplyRequirements,
+			ImportRequirements: p.ImportRequirements,
+			PolicyCheck:        p.PolicyCheck,
+			RepoLocksMode:      string(p.RepoLocks.Mode),
+		})
+	}
+	return cfg
+}
+
 func (a *APIController) apiSetup(ctx *command.Context) error {
 	pull := ctx.Pull
 	baseRepo := ctx.Pull.BaseRepo
@@ -286,15 +1138,126 @@ func (a *APIController) apiApply(request *APIRequest, ctx *command.Context) (*co
 	return &command.Result{ProjectResults: projectResults}, nil
 }
 
-func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
+// GetSettings responds with the current server settings.
+func (a *APIController) GetSettings(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	a.respondWithSettings(w)
+}
+
+// UpdateSettings changes the server settings and persists them so that they
+// survive restarts. The changes take effect immediately.
+func (a *APIController) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.SettingsStore == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("changing settings isn't supported by this locking backend"))
+		return
+	}
+	var request UpdateServerSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err))
+		return
+	}
+
+	if request.RepoAllowlist != nil {
+		if code, err := a.updateRepoAllowlist(*request.RepoAllowlist); err != nil {
+			a.apiReportError(w, code, err)
+			return
+		}
+	}
+	a.respondWithSettings(w)
+}
+
+// updateRepoAllowlist validates and persists allowlist before using it so
+// that the allowlist in use is always the one that's persisted.
+func (a *APIController) updateRepoAllowlist(allowlist string) (int, error) {
+	if allowlist == "" {
+		if err := a.SettingsStore.DeleteSetting(RepoAllowlistSetting); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		allowlist = a.DefaultRepoAllowlist
+		a.Logger.Info("reset repo allowlist to %q", allowlist)
+	} else {
+		if _, err := events.NewRepoAllowlistChecker(allowlist); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid repo allowlist: %w", err)
+		}
+		if err := a.SettingsStore.SetSetting(RepoAllowlistSetting, allowlist); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		a.Logger.Info("changed repo allowlist to %q", allowlist)
+	}
+	if err := a.RepoAllowlistChecker.Update(allowlist); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+func (a *APIController) respondWithSettings(w http.ResponseWriter) {
+	response, err := json.Marshal(ServerSettings{
+		RepoAllowlist: a.RepoAllowlistChecker.Allowlist(),
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	a.respond(w, logging.Debug, http.StatusOK, "%s", string(response))
+}
+
+// Reload reloads the server-side repo config so that changes to it take effect
+// without restarting Atlantis. If the config is invalid, the current config is
+// kept.
+func (a *APIController) Reload(w http.ResponseWriter, r *http.Request) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	if a.GlobalCfgReloader == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("reloading requires the server-side repo config to be set with --repo-config"))
+		return
+	}
+
+	globalCfg, err := a.GlobalCfgReloader.Reload()
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := json.Marshal(ReloadResult{
+		Repos:      len(globalCfg.Repos),
+		Workflows:  len(globalCfg.Workflows),
+		PolicySets: len(globalCfg.PolicySets.PolicySets),
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	a.respond(w, logging.Info, http.StatusOK, "%s", string(response))
+}
+
+// apiAuthenticate returns an error and the status code to respond with if the
+// API is disabled or the request doesn't have the API secret.
+func (a *APIController) apiAuthenticate(r *http.Request) (int, error) {
 	if len(a.APISecret) == 0 {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
+		return http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
 	}
 
 	// Validate the secret token
 	secret := r.Header.Get(atlantisTokenHeader)
 	if secret != string(a.APISecret) {
-		return nil, nil, http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+		return http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+	}
+	return http.StatusOK, nil
+}
+
+func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
+	if code, err := a.apiAuthenticate(r); err != nil {
+		return nil, nil, code, err
 	}
 
 	// Parse the JSON payload
@@ -310,23 +1273,9 @@ func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *comm
 		return nil, nil, http.StatusBadRequest, fmt.Errorf("request %q is missing fields", string(bytes))
 	}
 
-	VCSHostType, err := models.NewVCSHostType(request.Type)
+	baseRepo, code, err := a.apiParseRepo(request.Type, request.Repository)
 	if err != nil {
-		return nil, nil, http.StatusBadRequest, err
-	}
-	cloneURL, err := a.VCSClient.GetCloneURL(a.Logger, VCSHostType, request.Repository)
-	if err != nil {
-		return nil, nil, http.StatusInternalServerError, err
-	}
-
-	baseRepo, err := a.Parser.ParseAPIPlanRequest(VCSHostType, request.Repository, cloneURL)
-	if err != nil {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err)
-	}
-
-	// Check if the repo is allowlisted
-	if !a.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
-		return nil, nil, http.StatusForbidden, fmt.Errorf("repo not allowlisted")
+		return nil, nil, code, err
 	}
 
 	return &request, &command.Context{
@@ -344,6 +1293,30 @@ func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *comm
 	}, http.StatusOK, nil
 }
 
+// apiParseRepo returns the allowlisted repo repoFullName on the VCS host
+// vcsHostType or an error and the status code to respond with.
+func (a *APIController) apiParseRepo(vcsHostType string, repoFullName string) (models.Repo, int, error) {
+	VCSHostType, err := models.NewVCSHostType(vcsHostType)
+	if err != nil {
+		return models.Repo{}, http.StatusBadRequest, err
+	}
+	cloneURL, err := a.VCSClient.GetCloneURL(a.Logger, VCSHostType, repoFullName)
+	if err != nil {
+		return models.Repo{}, http.StatusInternalServerError, err
+	}
+
+	baseRepo, err := a.Parser.ParseAPIPlanRequest(VCSHostType, repoFullName, cloneURL)
+	if err != nil {
+		return models.Repo{}, http.StatusBadRequest, fmt.Errorf("failed to parse request: %v", err)
+	}
+
+	// Check if the repo is allowlisted
+	if !a.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
+		return models.Repo{}, http.StatusForbidden, fmt.Errorf("repo not allowlisted")
+	}
+	return baseRepo, http.StatusOK, nil
+}
+
 func (a *APIController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
 	response := fmt.Sprintf(format, args...)
 	a.Logger.Log(lvl, response)
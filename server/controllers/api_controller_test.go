@@ -3,20 +3,30 @@ package controllers_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/locking"
 	. "github.com/runatlantis/atlantis/server/core/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	. "github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	. "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/jobs"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	. "github.com/runatlantis/atlantis/testing"
@@ -200,6 +210,26 @@ func TestAPIController_Apply(t *testing.T) {
 	projectCommandRunner.VerifyWasCalled(Times(expectedCalls)).Apply(Any[command.ProjectContext]())
 }
 
+func TestAPIController_Apply_ApplyOnMerge(t *testing.T) {
+	ac, projectCommandBuilder, projectCommandRunner := setup(t)
+	ac.ApplyOnMerge = true
+
+	body, _ := json.Marshal(controllers.APIRequest{
+		Repository: "Repo",
+		Ref:        "main",
+		Type:       "Gitlab",
+		Projects:   []string{"default"},
+	})
+	req, _ := http.NewRequest("POST", "", bytes.NewBuffer(body))
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.Apply(w, req)
+	ResponseContains(t, w, http.StatusForbidden, "--apply-on-merge is set")
+
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+}
+
 func TestAPIController_ListLocks(t *testing.T) {
 	ac, _, _ := setup(t)
 	time := time.Now()
@@ -214,6 +244,7 @@ func TestAPIController_ListLocks(t *testing.T) {
 			User:            "jdoe",
 			Workspace:       "default",
 			Time:            time,
+			HeldFor:         "0s",
 		},
 	},
 	}
@@ -256,6 +287,661 @@ func TestAPIController_ListLocksEmpty(t *testing.T) {
 	Equals(t, expected, result)
 }
 
+func TestAPIController_ListLocksFiltered(t *testing.T) {
+	ac, _, _ := setup(t)
+	now := time.Now()
+	When(ac.Locker.List()).ThenReturn(map[string]models.ProjectLock{
+		"old": {
+			Project: models.Project{RepoFullName: "owner/repo", Path: "."},
+			Pull:    models.PullRequest{Num: 1, URL: "url1"},
+			User:    models.User{Username: "jdoe"},
+			Time:    now.Add(-3 * time.Hour),
+		},
+		"new": {
+			Project: models.Project{RepoFullName: "owner/repo", Path: "."},
+			Pull:    models.PullRequest{Num: 2, URL: "url2"},
+			User:    models.User{Username: "jdoe"},
+			Time:    now.Add(-time.Minute),
+		},
+		"other-user": {
+			Project: models.Project{RepoFullName: "owner/repo", Path: "."},
+			Pull:    models.PullRequest{Num: 3},
+			User:    models.User{Username: "alice"},
+			Time:    now.Add(-4 * time.Hour),
+		},
+		"other-repo": {
+			Project: models.Project{RepoFullName: "owner/other", Path: "."},
+			Pull:    models.PullRequest{Num: 4},
+			User:    models.User{Username: "jdoe"},
+			Time:    now.Add(-5 * time.Hour),
+		},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/locks?repository=owner/repo&user=jdoe", nil)
+	w := httptest.NewRecorder()
+	ac.ListLocks(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var result controllers.ListLocksResult
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	Equals(t, 2, len(result.Locks))
+	Equals(t, "old", result.Locks[0].Name)
+	Equals(t, "3h0m0s", result.Locks[0].HeldFor)
+	Equals(t, "new", result.Locks[1].Name)
+
+	req, _ = http.NewRequest("GET", "/api/locks?repository=owner/repo&older_than=2h&format=csv", nil)
+	w = httptest.NewRecorder()
+	ac.ListLocks(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	Equals(t, "text/csv", w.Result().Header.Get("Content-Type"))
+	body, _ := io.ReadAll(w.Result().Body)
+	Equals(t, "Name,ProjectName,ProjectRepo,ProjectRepoPath,PullID,PullURL,User,Workspace,Time,HeldFor\n"+
+		"other-user,,owner/repo,.,3,,alice,,"+now.Add(-4*time.Hour).Format(time.RFC3339)+",4h0m0s\n"+
+		"old,,owner/repo,.,1,url1,jdoe,,"+now.Add(-3*time.Hour).Format(time.RFC3339)+",3h0m0s\n", string(body))
+
+	req, _ = http.NewRequest("GET", "/api/locks?older_than=soon", nil)
+	w = httptest.NewRecorder()
+	ac.ListLocks(w, req)
+	Equals(t, http.StatusBadRequest, w.Result().StatusCode)
+}
+
+func TestAPIController_DeleteLocks(t *testing.T) {
+	ac, _, _ := setup(t)
+	deleteLockCommand := NewMockDeleteLockCommand()
+	ac.DeleteLockCommand = deleteLockCommand
+	now := time.Now()
+	When(ac.Locker.List()).ThenReturn(map[string]models.ProjectLock{
+		"old": {
+			Project: models.Project{RepoFullName: "owner/repo", Path: "."},
+			User:    models.User{Username: "jdoe"},
+			Time:    now.Add(-3 * time.Hour),
+		},
+		"new": {
+			Project: models.Project{RepoFullName: "owner/repo", Path: "."},
+			User:    models.User{Username: "jdoe"},
+			Time:    now.Add(-time.Minute),
+		},
+	}, nil)
+
+	// All locks can't be deleted by mistake.
+	req, _ := http.NewRequest("DELETE", "/api/locks", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.DeleteLocks(w, req)
+	Equals(t, http.StatusBadRequest, w.Result().StatusCode)
+
+	req, _ = http.NewRequest("DELETE", "/api/locks?older_than=1h&dry_run=true", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w = httptest.NewRecorder()
+	ac.DeleteLocks(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var result controllers.DeleteLocksResult
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	Assert(t, result.DryRun, "expected a dry run")
+	Equals(t, 1, len(result.Locks))
+	Equals(t, "old", result.Locks[0].Name)
+	deleteLockCommand.VerifyWasCalled(Never()).DeleteLock(Any[logging.SimpleLogging](), Any[string]())
+
+	req, _ = http.NewRequest("DELETE", "/api/locks?older_than=1h", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w = httptest.NewRecorder()
+	ac.DeleteLocks(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	result = controllers.DeleteLocksResult{}
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	Assert(t, !result.DryRun, "expected locks to be deleted")
+	Equals(t, 1, len(result.Locks))
+	deleteLockCommand.VerifyWasCalledOnce().DeleteLock(Any[logging.SimpleLogging](), Eq("old"))
+}
+
+func TestAPIController_DeleteLocks_Unauthorized(t *testing.T) {
+	ac, _, _ := setup(t)
+	deleteLockCommand := NewMockDeleteLockCommand()
+	ac.DeleteLockCommand = deleteLockCommand
+
+	req, _ := http.NewRequest("DELETE", "/api/locks?older_than=1h", nil)
+	req.Header.Set(atlantisTokenHeader, "wrong")
+	w := httptest.NewRecorder()
+	ac.DeleteLocks(w, req)
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+	deleteLockCommand.VerifyWasCalled(Never()).DeleteLock(Any[logging.SimpleLogging](), Any[string]())
+}
+
+func TestAPIController_Backup(t *testing.T) {
+	ac, _, _ := setup(t)
+	snapshotter := NewMockSnapshotter()
+	ac.Snapshotter = snapshotter
+	snapshot := locking.Snapshot{
+		Version:   locking.SnapshotVersion,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Locks: []models.ProjectLock{{
+			Project:   models.Project{RepoFullName: "owner/repo", Path: "."},
+			Pull:      models.PullRequest{Num: 123},
+			User:      models.User{Username: "jdoe"},
+			Workspace: "default",
+			Time:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}},
+	}
+	When(snapshotter.Snapshot()).ThenReturn(snapshot, nil)
+
+	req, _ := http.NewRequest("GET", "", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.Backup(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	Equals(t, "application/gzip", w.Result().Header.Get("Content-Type"))
+	Equals(t, `attachment; filename="atlantis-backup-20240102T030405Z.json.gz"`, w.Result().Header.Get("Content-Disposition"))
+	restored, err := locking.ReadSnapshot(w.Result().Body)
+	Ok(t, err)
+	Equals(t, snapshot, restored)
+}
+
+func TestAPIController_Backup_Unauthorized(t *testing.T) {
+	ac, _, _ := setup(t)
+	snapshotter := NewMockSnapshotter()
+	ac.Snapshotter = snapshotter
+
+	req, _ := http.NewRequest("GET", "", nil)
+	req.Header.Set(atlantisTokenHeader, "wrong")
+	w := httptest.NewRecorder()
+	ac.Backup(w, req)
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+	snapshotter.VerifyWasCalled(Never()).Snapshot()
+}
+
+// stubReencrypter reencrypts records of a locking DB.
+type stubReencrypter struct {
+	records int
+	calls   int
+}
+
+func (s *stubReencrypter) Reencrypt() (int, error) {
+	s.calls++
+	return s.records, nil
+}
+
+func TestAPIController_Reencrypt(t *testing.T) {
+	ac, _, _ := setup(t)
+	reencrypt := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/reencrypt", nil)
+		req.Header.Set(atlantisTokenHeader, token)
+		w := httptest.NewRecorder()
+		ac.Reencrypt(w, req)
+		return w
+	}
+
+	w := reencrypt(atlantisToken)
+	Equals(t, http.StatusBadRequest, w.Result().StatusCode)
+	ResponseContains(t, w, http.StatusBadRequest, "reencrypting requires --locking-db-encryption-key-file to be set")
+
+	reencrypter := &stubReencrypter{records: 3}
+	ac.Reencrypter = reencrypter
+	w = reencrypt("wrong")
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+	Equals(t, 0, reencrypter.calls)
+
+	w = reencrypt(atlantisToken)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var result controllers.ReencryptResult
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	Equals(t, controllers.ReencryptResult{Records: 3}, result)
+	Equals(t, 1, reencrypter.calls)
+}
+
+func TestAPIController_Diagnostics(t *testing.T) {
+	ac, _, _ := setup(t)
+	vcsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer vcsServer.Close()
+	recorder := logging.NewErrorRecorder(10)
+	recorder.Record(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "cloning failed")
+	ac.AtlantisVersion = "1.2.3"
+	ac.ServerConfig = map[string]interface{}{"gh-token": logging.RedactedValue}
+	ac.ErrorRecorder = recorder
+	ac.HealthChecks = []controllers.HealthCheck{
+		{Name: "locking DB", Check: func() error { return nil }},
+		controllers.VCSHealthCheck("github", vcsServer.URL, vcsServer.Client()),
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/diagnostics", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.Diagnostics(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	Assert(t, strings.HasPrefix(w.Result().Header.Get("Content-Disposition"), `attachment; filename="atlantis-diagnostics-`), "expected an attachment")
+
+	var bundle controllers.DiagnosticBundle
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&bundle))
+	Equals(t, "1.2.3", bundle.AtlantisVersion)
+	Equals(t, map[string]interface{}{"gh-token": logging.RedactedValue}, bundle.Config)
+	Equals(t, []controllers.HealthCheckResult{
+		{Name: "locking DB", OK: true},
+		{Name: "github", Error: vcsServer.URL + " responded with 502 Bad Gateway"},
+	}, bundle.HealthChecks)
+	Equals(t, recorder.Recent(), bundle.RecentErrors)
+}
+
+func TestAPIController_Diagnostics_Unauthorized(t *testing.T) {
+	ac, _, _ := setup(t)
+	checked := false
+	ac.HealthChecks = []controllers.HealthCheck{{Name: "locking DB", Check: func() error {
+		checked = true
+		return nil
+	}}}
+
+	req, _ := http.NewRequest("GET", "/api/admin/diagnostics", nil)
+	req.Header.Set(atlantisTokenHeader, "wrong")
+	w := httptest.NewRecorder()
+	ac.Diagnostics(w, req)
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+	Assert(t, !checked, "expected the health checks not to run")
+}
+
+func TestAPIController_ValidateVCSConfig(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.VCSConfigValidator = &events.VCSConfigValidator{
+		Hosts: []events.VCSConfigHost{{Type: models.AzureDevops, Hostname: "dev.azure.com", Client: NewMockClient()}},
+	}
+
+	req, _ := http.NewRequest("GET", "/api/admin/vcs-config", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.ValidateVCSConfig(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var checks []events.VCSConfigCheck
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&checks))
+	Equals(t, []events.VCSConfigCheck{
+		{Host: "AzureDevops", Status: events.VCSConfigCheckSkipped, Message: "the credentials of this host can't be checked"},
+	}, checks)
+
+	req.Header.Set(atlantisTokenHeader, "wrong")
+	w = httptest.NewRecorder()
+	ac.ValidateVCSConfig(w, req)
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+}
+
+func TestAPIController_RegisterWebhooks(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.WebhookRegistrar = &events.WebhookRegistrar{
+		Hosts: []events.VCSConfigHost{{Type: models.AzureDevops, Hostname: "dev.azure.com", Client: NewMockClient()}},
+	}
+
+	cases := []struct {
+		body    string
+		token   string
+		expCode int
+		expBody string
+	}{
+		{`{"VCSHost": "AzureDevops", "Org": "org"}`, "wrong", http.StatusUnauthorized, ""},
+		{`{"VCSHost": "AzureDevops"}`, atlantisToken, http.StatusBadRequest, `{"error":"VCSHost and Org are required"}`},
+		{`{"VCSHost": "AzureDevops", "Org": "org"}`, atlantisToken, http.StatusBadRequest, `{"error":"registering webhooks isn't supported on AzureDevops"}`},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("POST", "/api/admin/webhooks", bytes.NewBufferString(c.body))
+		req.Header.Set(atlantisTokenHeader, c.token)
+		w := httptest.NewRecorder()
+		ac.RegisterWebhooks(w, req)
+		Equals(t, c.expCode, w.Result().StatusCode)
+		if c.expBody != "" {
+			body, _ := io.ReadAll(w.Result().Body)
+			Equals(t, c.expBody, strings.TrimSpace(string(body)))
+		}
+	}
+}
+
+func TestAPIController_InitRepo(t *testing.T) {
+	ac, _, _ := setup(t)
+	ac.RepoOnboarder = &events.RepoOnboarder{
+		Hosts: []events.VCSConfigHost{{Type: models.AzureDevops, Hostname: "dev.azure.com", Client: NewMockClient()}},
+	}
+
+	cases := []struct {
+		body    string
+		token   string
+		expCode int
+		expBody string
+	}{
+		{`{"VCSHost": "AzureDevops", "Repo": "org/project/repo"}`, "wrong", http.StatusUnauthorized, ""},
+		{`{"VCSHost": "AzureDevops"}`, atlantisToken, http.StatusBadRequest, `{"error":"VCSHost and Repo are required"}`},
+		{`{"VCSHost": "AzureDevops", "Repo": "org/project/repo"}`, atlantisToken, http.StatusBadRequest, `{"error":"onboarding repos isn't supported on AzureDevops"}`},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("POST", "/api/admin/init-repo", bytes.NewBufferString(c.body))
+		req.Header.Set(atlantisTokenHeader, c.token)
+		w := httptest.NewRecorder()
+		ac.InitRepo(w, req)
+		Equals(t, c.expCode, w.Result().StatusCode)
+		if c.expBody != "" {
+			body, _ := io.ReadAll(w.Result().Body)
+			Equals(t, c.expBody, strings.TrimSpace(string(body)))
+		}
+	}
+}
+
+type stubProjectSimulator struct {
+	current    []valid.MergedProjectCfg
+	currentErr error
+}
+
+func (s stubProjectSimulator) BuildHelpProjects(_ *command.Context) ([]valid.MergedProjectCfg, error) {
+	return s.current, s.currentErr
+}
+
+// SimulateProjects requires approval to apply if the candidate config does.
+func (s stubProjectSimulator) SimulateProjects(_ *command.Context, globalCfg valid.GlobalCfg) ([]valid.MergedProjectCfg, error) {
+	var projects []valid.MergedProjectCfg
+	for _, p := range s.current {
+		p.ApplyRequirements = globalCfg.Repos[len(globalCfg.Repos)-1].ApplyRequirements
+		projects = append(projects, p)
+	}
+	return projects, nil
+}
+
+func TestAPIController_Simulate(t *testing.T) {
+	ac, _, _ := setup(t)
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Github, Hostname: "github.com"}}
+	When(ac.Parser.(*MockEventParsing).ParseAPIPlanRequest(Eq(models.Github), Eq("owner/repo"), Any[string]())).ThenReturn(repo, nil)
+	simulate := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/admin/simulate", bytes.NewBufferString(body))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.Simulate(w, req)
+		return w
+	}
+	body := `{"Type": "Github", "Repository": "owner/repo", "Ref": "main", "PR": 1, "RepoConfig": "repos:\n- id: /.*/\n  apply_requirements: [approved]\n"}`
+
+	w := simulate(body)
+	ResponseContains(t, w, http.StatusNotFound, "simulation isn't configured")
+
+	ac.GlobalCfgParser = &config.ParserValidator{}
+	ac.ProjectSimulator = stubProjectSimulator{current: []valid.MergedProjectCfg{{
+		Name:            "prod",
+		RepoRelDir:      "env/prod",
+		Workspace:       "default",
+		Workflow:        valid.Workflow{Name: "default"},
+		AutoplanEnabled: true,
+		RepoLocks:       valid.DefaultRepoLocks,
+	}}}
+
+	w = simulate(`{"Type": "Github", "Repository": "owner/repo"}`)
+	ResponseContains(t, w, http.StatusBadRequest, "Type, Repository, Ref and RepoConfig are required")
+
+	w = simulate(`{"Type": "Github", "Repository": "owner/repo", "Ref": "main", "RepoConfig": "repos:\n- unknown: key\n"}`)
+	ResponseContains(t, w, http.StatusBadRequest, "parsing RepoConfig")
+
+	w = simulate(body)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var result controllers.SimulateResult
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	Equals(t, controllers.SimulateResult{
+		Current: controllers.SimulatedConfig{Projects: []controllers.SimulatedProject{{
+			Name:            "prod",
+			Dir:             "env/prod",
+			Workspace:       "default",
+			Workflow:        "default",
+			AutoplanEnabled: true,
+			RepoLocksMode:   "on_plan",
+		}}},
+		Candidate: controllers.SimulatedConfig{Projects: []controllers.SimulatedProject{{
+			Name:              "prod",
+			Dir:               "env/prod",
+			Workspace:         "default",
+			Workflow:          "default",
+			AutoplanEnabled:   true,
+			ApplyRequirements: []string{"approved"},
+			RepoLocksMode:     "on_plan",
+		}}},
+	}, result)
+
+	ac.ProjectSimulator = stubProjectSimulator{currentErr: errors.New("repo config not allowed")}
+	w = simulate(body)
+	ResponseContains(t, w, http.StatusOK, `"Current":{"Projects":null,"Error":"repo config not allowed"}`)
+}
+
+func TestAPIController_GetPullStatus(t *testing.T) {
+	runTime := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	status := &models.PullStatus{
+		Pull:     models.PullRequest{HeadCommit: "sha", URL: "https://github.com/owner/repo/pull/1", BaseRepo: models.Repo{VCSHost: models.VCSHost{Type: models.Github}}},
+		Projects: []models.ProjectStatus{{RepoRelDir: "dir", Workspace: "default", Status: models.PlannedPlanStatus}},
+		History: []models.CommandRun{
+			{Command: "plan", RepoRelDir: "dir", Workspace: "default", User: "jdoe", HeadCommit: "sha", Status: models.PlannedPlanStatus, Time: runTime, Trigger: models.CommentCommandTrigger, CommentID: 42},
+		},
+	}
+	ac, _, _ := setup(t)
+	ac.PullStatusFetcher = stubPullStatusFetcher{status: status}
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Github, Hostname: "github.com"}}
+	When(ac.Parser.(*MockEventParsing).ParseAPIPlanRequest(Eq(models.Github), Eq("owner/repo"), Any[string]())).ThenReturn(repo, nil)
+
+	req, _ := http.NewRequest("GET", "/api/pulls/owner/repo/1/status?type=Github", nil)
+	req = mux.SetURLVars(req, map[string]string{"repo": "owner/repo", "pr": "1"})
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.GetPullStatus(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var result controllers.PullStatusResult
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	expRun := controllers.CommandRunResult{
+		Command:    "plan",
+		RepoRelDir: "dir",
+		Workspace:  "default",
+		User:       "jdoe",
+		HeadCommit: "sha",
+		Status:     "planned",
+		Time:       runTime,
+		Trigger:    models.CommentCommandTrigger,
+		CommentID:  42,
+		CommentURL: "https://github.com/owner/repo/pull/1#issuecomment-42",
+	}
+	Equals(t, controllers.PullStatusResult{
+		Repository: "owner/repo",
+		PR:         1,
+		HeadCommit: "sha",
+		Projects:   []controllers.ProjectStatusResult{{RepoRelDir: "dir", Workspace: "default", Status: "planned", LastPlan: &expRun}},
+		History:    []controllers.CommandRunResult{expRun},
+	}, result)
+
+	ac.PullStatusFetcher = stubPullStatusFetcher{}
+	w = httptest.NewRecorder()
+	ac.GetPullStatus(w, req)
+	ResponseContains(t, w, http.StatusNotFound, "no commands have run on pull request 1")
+}
+
+func TestAPIController_ListJobs(t *testing.T) {
+	ac, _, _ := setup(t)
+	outputHandler := jobmocks.NewMockProjectCommandOutputHandler()
+	ac.OutputHandler = outputHandler
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	When(outputHandler.GetPullToJobMapping()).ThenReturn([]jobs.PullInfoWithJobIDs{
+		{
+			Pull:       jobs.PullInfo{PullNum: 1, RepoFullName: "owner/repo", ProjectName: "b", Path: "b", Workspace: "default"},
+			JobIDInfos: []jobs.JobIDInfo{{JobID: "job-b", JobStep: "plan", Time: start.Add(time.Second)}},
+		},
+		{
+			Pull:       jobs.PullInfo{PullNum: 1, RepoFullName: "owner/repo", ProjectName: "a", Path: "a", Workspace: "default"},
+			JobIDInfos: []jobs.JobIDInfo{{JobID: "job-a", JobStep: "plan", Time: start}},
+		},
+		{
+			Pull:       jobs.PullInfo{PullNum: 2, RepoFullName: "owner/repo", ProjectName: "a", Path: "a", Workspace: "default"},
+			JobIDInfos: []jobs.JobIDInfo{{JobID: "other-pull", JobStep: "plan", Time: start}},
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/jobs?repository=owner/repo&pr=1", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.ListJobs(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var result controllers.ListJobsResult
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&result))
+	Equals(t, controllers.ListJobsResult{Jobs: []controllers.JobDetail{
+		{JobID: "job-a", ProjectName: "a", Path: "a", Workspace: "default", JobStep: "plan", Time: start},
+		{JobID: "job-b", ProjectName: "b", Path: "b", Workspace: "default", JobStep: "plan", Time: start.Add(time.Second)},
+	}}, result)
+}
+
+func TestAPIController_ListJobs_Invalid(t *testing.T) {
+	cases := []struct {
+		url     string
+		token   string
+		expCode int
+	}{
+		{"/api/jobs?repository=owner/repo&pr=1", "wrong", http.StatusUnauthorized},
+		{"/api/jobs?repository=owner/repo", atlantisToken, http.StatusBadRequest},
+		{"/api/jobs?pr=1", atlantisToken, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			ac, _, _ := setup(t)
+			req, _ := http.NewRequest("GET", c.url, nil)
+			req.Header.Set(atlantisTokenHeader, c.token)
+			w := httptest.NewRecorder()
+			ac.ListJobs(w, req)
+			Equals(t, c.expCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestAPIController_GetPlanJSON(t *testing.T) {
+	repoDir := t.TempDir()
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "dir"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir", "named-staging.json"), []byte(`{"format_version":"1.2"}`), 0600))
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir", "default.json"), []byte(`{"format_version":"1.1"}`), 0600))
+	status := &models.PullStatus{Projects: []models.ProjectStatus{
+		{ProjectName: "named", RepoRelDir: "dir", Workspace: "staging"},
+		{RepoRelDir: "dir", Workspace: "default"},
+		{ProjectName: "unplanned", RepoRelDir: "other", Workspace: "default"},
+	}}
+
+	cases := []struct {
+		description string
+		token       string
+		project     string
+		query       string
+		expCode     int
+		expBody     string
+	}{
+		{"by name", atlantisToken, "named", "type=Github", http.StatusOK, `{"format_version":"1.2"}`},
+		{"by dir", atlantisToken, "dir", "type=Github", http.StatusOK, `{"format_version":"1.1"}`},
+		{"by dir and workspace", atlantisToken, "dir", "type=Github&workspace=default", http.StatusOK, `{"format_version":"1.1"}`},
+		{"unknown project", atlantisToken, "missing", "type=Github", http.StatusNotFound, "no project"},
+		{"no show output", atlantisToken, "unplanned", "type=Github", http.StatusNotFound, "show step"},
+		{"invalid vcs type", atlantisToken, "named", "type=Unknown", http.StatusBadRequest, ""},
+		{"unauthorized", "wrong", "named", "type=Github", http.StatusUnauthorized, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ac, _, _ := setup(t)
+			ac.PullStatusFetcher = stubPullStatusFetcher{status: status}
+			repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Github, Hostname: "github.com"}}
+			When(ac.Parser.(*MockEventParsing).ParseAPIPlanRequest(Eq(models.Github), Eq("owner/repo"), Any[string]())).ThenReturn(repo, nil)
+			When(ac.WorkingDir.(*MockWorkingDir).GetWorkingDir(Eq(repo), Any[models.PullRequest](), Any[string]())).ThenReturn(repoDir, nil)
+
+			req, _ := http.NewRequest("GET", "/api/pulls/owner/repo/1/projects/"+c.project+"/plan.json?"+c.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"repo": "owner/repo", "pr": "1", "project": c.project})
+			req.Header.Set(atlantisTokenHeader, c.token)
+			w := httptest.NewRecorder()
+			ac.GetPlanJSON(w, req)
+			ResponseContains(t, w, c.expCode, c.expBody)
+		})
+	}
+}
+
+type stubGlobalCfgReloader struct {
+	cfg valid.GlobalCfg
+	err error
+}
+
+func (s stubGlobalCfgReloader) Reload() (valid.GlobalCfg, error) {
+	return s.cfg, s.err
+}
+
+func TestAPIController_Reload(t *testing.T) {
+	ac, _, _ := setup(t)
+	reload := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.Reload(w, req)
+		return w
+	}
+
+	w := reload()
+	ResponseContains(t, w, http.StatusBadRequest, "reloading requires the server-side repo config to be set with --repo-config")
+
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos = append(globalCfg.Repos, valid.Repo{ID: "github.com/owner/repo"})
+	ac.GlobalCfgReloader = stubGlobalCfgReloader{cfg: globalCfg}
+	w = reload()
+	ResponseContains(t, w, http.StatusOK, `{"Repos":2,"Workflows":1,"PolicySets":0}`)
+
+	ac.GlobalCfgReloader = stubGlobalCfgReloader{err: errors.New("parsing repos.yaml file: unknown key")}
+	w = reload()
+	ResponseContains(t, w, http.StatusBadRequest, "parsing repos.yaml file: unknown key")
+}
+
+func TestAPIController_Settings(t *testing.T) {
+	ac, _, _ := setup(t)
+	settingsStore := NewMockSettingsStore()
+	ac.SettingsStore = settingsStore
+	ac.DefaultRepoAllowlist = "*"
+
+	getSettings := func() controllers.ServerSettings {
+		req, _ := http.NewRequest("GET", "", nil)
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.GetSettings(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		var settings controllers.ServerSettings
+		Ok(t, json.Unmarshal(w.Body.Bytes(), &settings))
+		return settings
+	}
+	updateSettings := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "", bytes.NewBufferString(body))
+		req.Header.Set(atlantisTokenHeader, atlantisToken)
+		w := httptest.NewRecorder()
+		ac.UpdateSettings(w, req)
+		return w
+	}
+	Equals(t, controllers.ServerSettings{RepoAllowlist: "*"}, getSettings())
+
+	// Changes are persisted and take effect immediately.
+	w := updateSettings(`{"RepoAllowlist": "github.com/runatlantis/*"}`)
+	ResponseContains(t, w, http.StatusOK, `{"RepoAllowlist":"github.com/runatlantis/*"}`)
+	settingsStore.VerifyWasCalledOnce().SetSetting(controllers.RepoAllowlistSetting, "github.com/runatlantis/*")
+	Equals(t, controllers.ServerSettings{RepoAllowlist: "github.com/runatlantis/*"}, getSettings())
+	Assert(t, ac.RepoAllowlistChecker.IsAllowlisted("runatlantis/atlantis", "github.com"), "exp allowlisted")
+	Assert(t, !ac.RepoAllowlistChecker.IsAllowlisted("other/repo", "github.com"), "exp not allowlisted")
+
+	// Settings that aren't in the request are left as is.
+	w = updateSettings(`{}`)
+	ResponseContains(t, w, http.StatusOK, `{"RepoAllowlist":"github.com/runatlantis/*"}`)
+
+	// Invalid allowlists aren't persisted.
+	w = updateSettings(`{"RepoAllowlist": "https://github.com/runatlantis/atlantis"}`)
+	ResponseContains(t, w, http.StatusBadRequest, "invalid repo allowlist")
+	settingsStore.VerifyWasCalled(Never()).SetSetting(controllers.RepoAllowlistSetting, "https://github.com/runatlantis/atlantis")
+	Equals(t, controllers.ServerSettings{RepoAllowlist: "github.com/runatlantis/*"}, getSettings())
+
+	// An empty allowlist resets it to the flag.
+	w = updateSettings(`{"RepoAllowlist": ""}`)
+	ResponseContains(t, w, http.StatusOK, `{"RepoAllowlist":"*"}`)
+	settingsStore.VerifyWasCalledOnce().DeleteSetting(controllers.RepoAllowlistSetting)
+	Assert(t, ac.RepoAllowlistChecker.IsAllowlisted("other/repo", "github.com"), "exp allowlisted")
+}
+
+func TestAPIController_Settings_Unauthorized(t *testing.T) {
+	ac, _, _ := setup(t)
+	settingsStore := NewMockSettingsStore()
+	ac.SettingsStore = settingsStore
+
+	req, _ := http.NewRequest("PUT", "", bytes.NewBufferString(`{"RepoAllowlist": "github.com/runatlantis/*"}`))
+	w := httptest.NewRecorder()
+	ac.UpdateSettings(w, req)
+	Equals(t, http.StatusUnauthorized, w.Result().StatusCode)
+	settingsStore.VerifyWasCalled(Never()).SetSetting(Any[string](), Any[string]())
+	Assert(t, ac.RepoAllowlistChecker.IsAllowlisted("other/repo", "github.com"), "exp allowlist to be unchanged")
+}
+
 func setup(t *testing.T) (controllers.APIController, *MockProjectCommandBuilder, *MockProjectCommandRunner) {
 	RegisterMockTestingT(t)
 	locker := NewMockLocker()
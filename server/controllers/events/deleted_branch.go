@@ -0,0 +1,162 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/drmaxgit/go-azuredevops/azuredevops"
+	"github.com/google/go-github/v71/github"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
+	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
+	"github.com/runatlantis/atlantis/server/logging"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// zeroSHA is the commit a ref is pushed to when it's deleted.
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+const branchRefPrefix = "refs/heads/"
+
+// handleDeletedBranches cleans up after the pull requests whose source branch
+// is one of branches, the same way as if they were closed. Only pull requests
+// Atlantis already has locks or statuses for are affected, so events from
+// repos that aren't allowlisted have nothing to clean up.
+func (e *VCSEventsController) handleDeletedBranches(logger logging.SimpleLogging, vcsHostType models.VCSHostType, repoFullName string, branches []string) HTTPResponse {
+	if e.DeletedBranchCleaner == nil || len(branches) == 0 {
+		return HTTPResponse{
+			body: "Ignoring push event without deleted branches",
+		}
+	}
+	logger = logger.With("repo", repoFullName)
+	var cleaned int
+	for _, branch := range branches {
+		n, err := e.DeletedBranchCleaner.CleanUpDeletedBranch(logger, vcsHostType, repoFullName, branch)
+		cleaned += n
+		if err != nil {
+			return HTTPResponse{
+				body: err.Error(),
+				err: HTTPError{
+					code: http.StatusInternalServerError,
+					err:  err,
+				},
+			}
+		}
+	}
+	if cleaned > 0 {
+		logger.Info("Locks and workspaces of %d pull requests with deleted branches successfully deleted", cleaned)
+	}
+	return HTTPResponse{
+		body: fmt.Sprintf("Cleaned up %d pull requests with deleted branches", cleaned),
+	}
+}
+
+// writeResponse writes resp for VCS hosts that don't respond in their post
+// handler.
+func (e *VCSEventsController) writeResponse(w http.ResponseWriter, resp HTTPResponse) {
+	if resp.err.code != 0 {
+		e.respond(w, logging.Error, resp.err.code, "%s", resp.err.err.Error())
+		return
+	}
+	e.respond(w, logging.Debug, http.StatusOK, "%s", resp.body)
+}
+
+// HandleGithubDeleteEvent cleans up after the pull requests of a deleted
+// branch. It's exported to make testing easier.
+func (e *VCSEventsController) HandleGithubDeleteEvent(event *github.DeleteEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
+	if event.GetRefType() != "branch" {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring deleted %s %s", event.GetRefType(), githubReqID),
+		}
+	}
+	repo, err := e.Parser.ParseGithubRepo(event.GetRepo())
+	if err != nil {
+		return HTTPResponse{
+			body: err.Error(),
+			err: HTTPError{
+				code: http.StatusBadRequest,
+				err:  fmt.Errorf("parsing repo: %w %s", err, githubReqID),
+			},
+		}
+	}
+	return e.handleDeletedBranches(logger, models.Github, repo.FullName, []string{event.GetRef()})
+}
+
+// HandleGitlabPushEvent cleans up after the pull requests of the branches
+// deleted by a push. It's exported to make testing easier.
+func (e *VCSEventsController) HandleGitlabPushEvent(w http.ResponseWriter, event gitlab.PushEvent) {
+	var branches []string
+	if event.After == zeroSHA && strings.HasPrefix(event.Ref, branchRefPrefix) {
+		branches = append(branches, strings.TrimPrefix(event.Ref, branchRefPrefix))
+	}
+	e.writeResponse(w, e.handleDeletedBranches(e.Logger, models.Gitlab, event.Project.PathWithNamespace, branches))
+}
+
+func (e *VCSEventsController) handleBitbucketCloudPushEvent(w http.ResponseWriter, body []byte, reqID string) {
+	var event bitbucketcloud.PushEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Repository == nil || event.Repository.FullName == nil || event.Push == nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing push data: %v %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
+		return
+	}
+	var branches []string
+	for _, change := range event.Push.Changes {
+		old := change.Old
+		if change.New == nil && old != nil && old.Type != nil && *old.Type == "branch" && old.Name != nil {
+			branches = append(branches, *old.Name)
+		}
+	}
+	e.writeResponse(w, e.handleDeletedBranches(e.Logger, models.BitbucketCloud, *event.Repository.FullName, branches))
+}
+
+func (e *VCSEventsController) handleBitbucketServerRefsChangedEvent(w http.ResponseWriter, body []byte, reqID string) {
+	var event bitbucketserver.RefsChangedEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Repository == nil || event.Repository.Slug == nil || event.Repository.Project == nil || event.Repository.Project.Name == nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing push data: %v %s=%s", err, bitbucketServerRequestIDHeader, reqID)
+		return
+	}
+	var branches []string
+	for _, change := range event.Changes {
+		ref := change.Ref
+		if change.Type != nil && *change.Type == "DELETE" && ref != nil && ref.Type != nil && *ref.Type == "BRANCH" && ref.DisplayID != nil {
+			branches = append(branches, *ref.DisplayID)
+		}
+	}
+	repoFullName := fmt.Sprintf("%s/%s", *event.Repository.Project.Name, *event.Repository.Slug)
+	e.writeResponse(w, e.handleDeletedBranches(e.Logger, models.BitbucketServer, repoFullName, branches))
+}
+
+func (e *VCSEventsController) handleAzureDevopsPushEvent(w http.ResponseWriter, event *azuredevops.Event, azuredevopsReqID string) {
+	push, ok := event.Resource.(*azuredevops.GitPush)
+	if !ok || push.Repository == nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Event.Resource is nil or received bad event type %v; %s", event.Resource, azuredevopsReqID)
+		return
+	}
+	repo, err := e.Parser.ParseAzureDevopsRepo(push.Repository)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing repo: %s %s", err, azuredevopsReqID)
+		return
+	}
+	var branches []string
+	for _, update := range push.RefUpdates {
+		if update.GetNewObjectID() == zeroSHA && strings.HasPrefix(update.GetName(), branchRefPrefix) {
+			branches = append(branches, strings.TrimPrefix(update.GetName(), branchRefPrefix))
+		}
+	}
+	e.writeResponse(w, e.handleDeletedBranches(e.Logger, models.AzureDevops, repo.FullName, branches))
+}
+
+func (e *VCSEventsController) handleGiteaDeleteEvent(w http.ResponseWriter, body []byte, reqID string) {
+	var event gitea.GiteaDeletePayload
+	if err := json.Unmarshal(body, &event); err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing delete data: %s %s=%s", err, giteaRequestIDHeader, reqID)
+		return
+	}
+	var branches []string
+	if event.RefType == "branch" {
+		branches = append(branches, strings.TrimPrefix(event.Ref, branchRefPrefix))
+	}
+	e.writeResponse(w, e.handleDeletedBranches(e.Logger, models.Gitea, event.Repository.FullName, branches))
+}
@@ -0,0 +1,198 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// TranslatedPullEvent is a pull request being opened, updated, marked as
+	// ready for review or closed.
+	TranslatedPullEvent = "pull_request"
+	// TranslatedCommentEvent is a comment on a pull request.
+	TranslatedCommentEvent = "comment"
+	// TranslatedIgnoredEvent is a webhook Atlantis doesn't need to handle.
+	TranslatedIgnoredEvent = "ignore"
+)
+
+// EventTranslator translates the webhooks of VCS hosts Atlantis doesn't
+// support, ex. Gerrit or an internal Git frontend, into events Atlantis
+// handles. It's called with the webhooks that weren't sent by a supported
+// VCS host.
+type EventTranslator interface {
+	// Translate returns the event of the webhook r, whose body was already
+	// read into body. Since Atlantis can't validate webhooks it doesn't
+	// understand, the translator must authenticate them.
+	Translate(r *http.Request, body []byte) (TranslatedEvent, error)
+}
+
+// TranslatedEvent is a webhook translated into an event Atlantis handles. The
+// pull request is planned, applied and commented on through VCSHost, so it
+// must be one of the VCS hosts Atlantis is configured for.
+type TranslatedEvent struct {
+	// Type is TranslatedPullEvent, TranslatedCommentEvent or
+	// TranslatedIgnoredEvent.
+	Type string `json:"type" validate:"required"`
+	// Reason is logged when the event is ignored.
+	Reason string `json:"reason"`
+	// VCSHost is the type of VCS host, ex. Github, the repo is on.
+	VCSHost string `json:"vcs_host" validate:"required"`
+	// Repo is the full name of the repo, ex. owner/repo.
+	Repo string         `json:"repo" validate:"required"`
+	Pull TranslatedPull `json:"pull"`
+	// User is who opened, updated or commented on the pull request.
+	User string `json:"user" validate:"required"`
+	// Action is opened, updated, ready_for_review or closed for pull request
+	// events.
+	Action string `json:"action"`
+	// Comment is the text of the comment for comment events.
+	Comment string `json:"comment"`
+}
+
+// TranslatedPull is the pull request of a TranslatedEvent.
+type TranslatedPull struct {
+	Num        int    `json:"num" validate:"required"`
+	HeadCommit string `json:"head_commit" validate:"required"`
+	HeadBranch string `json:"head_branch" validate:"required"`
+	BaseBranch string `json:"base_branch" validate:"required"`
+	Author     string `json:"author"`
+	URL        string `json:"url"`
+	// State is open, closed or merged. It defaults to open.
+	State string `json:"state"`
+}
+
+// HTTPEventTranslator translates webhooks by forwarding them, with their
+// headers, to a translator service at URL that responds with a
+// TranslatedEvent in JSON.
+type HTTPEventTranslator struct {
+	URL    string
+	Client *http.Client
+}
+
+// Translate forwards r to the translator service.
+func (t *HTTPEventTranslator) Translate(r *http.Request, body []byte) (TranslatedEvent, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return TranslatedEvent{}, err
+	}
+	req.Header = r.Header.Clone()
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return TranslatedEvent{}, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return TranslatedEvent{}, fmt.Errorf("translator responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var event TranslatedEvent
+	if err := json.NewDecoder(resp.Body).Decode(&event); err != nil {
+		return TranslatedEvent{}, fmt.Errorf("parsing translated event: %w", err)
+	}
+	return event, nil
+}
+
+// handleTranslatedPost handles the webhooks that weren't sent by a supported
+// VCS host by translating them with EventTranslator.
+func (e *VCSEventsController) handleTranslatedPost(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s", err)
+		return
+	}
+	event, err := e.EventTranslator.Translate(r, body)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Unable to translate event: %s", err)
+		return
+	}
+	if event.Type == TranslatedIgnoredEvent {
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring translated event: %s", event.Reason)
+		return
+	}
+	if err := validator.New().Struct(event); err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Invalid translated event: %s", err)
+		return
+	}
+	vcsHostType, err := models.NewVCSHostType(event.VCSHost)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Invalid translated event: %s", err)
+		return
+	}
+	if !e.supportsHost(vcsHostType) {
+		e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring translated event since not configured to support %s", vcsHostType)
+		return
+	}
+	cloneURL, err := e.VCSClient.GetCloneURL(e.Logger, vcsHostType, event.Repo)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusInternalServerError, "Unable to get clone URL of %s: %s", event.Repo, err)
+		return
+	}
+	baseRepo, err := e.Parser.ParseAPIPlanRequest(vcsHostType, event.Repo, cloneURL)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Invalid translated event: %s", err)
+		return
+	}
+	pull, err := translatedPull(baseRepo, event.Pull)
+	if err != nil {
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Invalid translated event: %s", err)
+		return
+	}
+	user := models.User{Username: event.User}
+	logger := e.Logger.With("repo", baseRepo.FullName, "pull", pull.Num)
+
+	switch event.Type {
+	case TranslatedPullEvent:
+		pullEventType, err := translatedPullEventType(event.Action)
+		if err != nil {
+			e.respond(w, logging.Warn, http.StatusBadRequest, "Invalid translated event: %s", err)
+			return
+		}
+		logger.Info("Handling translated Pull Request '%s' event", pullEventType.String())
+		e.writeResponse(w, e.handlePullRequestEvent(logger, baseRepo, baseRepo, pull, user, pullEventType, ""))
+	case TranslatedCommentEvent:
+		e.writeResponse(w, e.handleCommentEvent(logger, baseRepo, &baseRepo, &pull, user, pull.Num, event.Comment, -1, vcsHostType))
+	default:
+		e.respond(w, logging.Warn, http.StatusBadRequest, "Invalid translated event: unknown type %q", event.Type)
+	}
+}
+
+func translatedPull(baseRepo models.Repo, p TranslatedPull) (models.PullRequest, error) {
+	pull := models.PullRequest{
+		Num:        p.Num,
+		HeadCommit: p.HeadCommit,
+		URL:        p.URL,
+		HeadBranch: p.HeadBranch,
+		BaseBranch: p.BaseBranch,
+		Author:     p.Author,
+		BaseRepo:   baseRepo,
+	}
+	switch p.State {
+	case "", "open":
+		pull.State = models.OpenPullState
+	case "closed":
+		pull.State = models.ClosedPullState
+	case "merged":
+		pull.State = models.MergedPullState
+	default:
+		return models.PullRequest{}, fmt.Errorf("unknown pull request state %q", p.State)
+	}
+	return pull, nil
+}
+
+func translatedPullEventType(action string) (models.PullRequestEventType, error) {
+	for _, t := range []models.PullRequestEventType{models.OpenedPullEvent, models.UpdatedPullEvent, models.ReadyForReviewPullEvent, models.ClosedPullEvent} {
+		if t.String() == action {
+			return t, nil
+		}
+	}
+	return models.OtherPullEvent, fmt.Errorf("unknown pull request action %q", action)
+}
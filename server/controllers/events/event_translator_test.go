@@ -0,0 +1,133 @@
+package events_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type stubEventTranslator struct {
+	event events_controllers.TranslatedEvent
+	err   error
+}
+
+func (s stubEventTranslator) Translate(_ *http.Request, _ []byte) (events_controllers.TranslatedEvent, error) {
+	return s.event, s.err
+}
+
+func TestHTTPEventTranslator_Translate(t *testing.T) {
+	translator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Gerrit-Event") != "comment-added" || string(body) != `{"change":1}` {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"type": "comment", "vcs_host": "Github", "repo": "owner/repo", "pull": {"num": 1}, "user": "bob", "comment": "atlantis plan"}`)) // nolint: errcheck
+	}))
+	defer translator.Close()
+	httpTranslator := &events_controllers.HTTPEventTranslator{URL: translator.URL, Client: translator.Client()}
+
+	req, _ := http.NewRequest("POST", "/events", nil)
+	req.Header.Set("X-Gerrit-Event", "comment-added")
+	event, err := httpTranslator.Translate(req, []byte(`{"change":1}`))
+	Ok(t, err)
+	Equals(t, events_controllers.TranslatedEvent{
+		Type:    events_controllers.TranslatedCommentEvent,
+		VCSHost: "Github",
+		Repo:    "owner/repo",
+		Pull:    events_controllers.TranslatedPull{Num: 1},
+		User:    "bob",
+		Comment: "atlantis plan",
+	}, event)
+
+	_, err = httpTranslator.Translate(req, []byte(`{}`))
+	ErrEquals(t, "translator responded with 401: unauthorized", err)
+}
+
+func TestPost_Translated(t *testing.T) {
+	pull := events_controllers.TranslatedPull{Num: 1, HeadCommit: "sha", HeadBranch: "feature", BaseBranch: "main", Author: "alice"}
+	baseRepo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Github, Hostname: "github.com"}}
+	expPull := models.PullRequest{Num: 1, HeadCommit: "sha", HeadBranch: "feature", BaseBranch: "main", Author: "alice", BaseRepo: baseRepo}
+	post := func(e events_controllers.VCSEventsController) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "", bytes.NewBufferString(`{"change":1}`))
+		e.Post(w, req)
+		return w
+	}
+
+	t.Run("pull request", func(t *testing.T) {
+		e, _, _, _, p, cr, _, vcsClient, _ := setup(t)
+		When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("owner/repo"))).ThenReturn("https://github.com/owner/repo.git", nil)
+		When(p.ParseAPIPlanRequest(models.Github, "owner/repo", "https://github.com/owner/repo.git")).ThenReturn(baseRepo, nil)
+		e.EventTranslator = stubEventTranslator{event: events_controllers.TranslatedEvent{
+			Type: events_controllers.TranslatedPullEvent, VCSHost: "Github", Repo: "owner/repo", Pull: pull, User: "alice", Action: "opened",
+		}}
+		w := post(e)
+		ResponseContains(t, w, http.StatusOK, "Processing...")
+		cr.VerifyWasCalledOnce().RunAutoplanCommand(baseRepo, baseRepo, expPull, models.User{Username: "alice"}, models.AutoplanTrigger{Event: models.OpenedPullEvent})
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		e, _, _, _, p, cr, _, vcsClient, cp := setup(t)
+		When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("owner/repo"))).ThenReturn("https://github.com/owner/repo.git", nil)
+		When(p.ParseAPIPlanRequest(models.Github, "owner/repo", "https://github.com/owner/repo.git")).ThenReturn(baseRepo, nil)
+		cmd := events.CommentCommand{Name: command.Plan}
+		When(cp.Parse("atlantis plan", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+		e.EventTranslator = stubEventTranslator{event: events_controllers.TranslatedEvent{
+			Type: events_controllers.TranslatedCommentEvent, VCSHost: "Github", Repo: "owner/repo", Pull: pull, User: "bob", Comment: "atlantis plan",
+		}}
+		w := post(e)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, &baseRepo, &expPull, models.User{Username: "bob"}, 1, &cmd)
+	})
+
+	cases := []struct {
+		description string
+		translator  stubEventTranslator
+		expCode     int
+		expBody     string
+	}{
+		{
+			"translator error",
+			stubEventTranslator{err: io.EOF},
+			http.StatusBadRequest,
+			"Unable to translate event: EOF",
+		},
+		{
+			"ignored",
+			stubEventTranslator{event: events_controllers.TranslatedEvent{Type: events_controllers.TranslatedIgnoredEvent, Reason: "ref-updated"}},
+			http.StatusOK,
+			"Ignoring translated event: ref-updated",
+		},
+		{
+			"missing fields",
+			stubEventTranslator{event: events_controllers.TranslatedEvent{Type: events_controllers.TranslatedCommentEvent, VCSHost: "Github", Repo: "owner/repo", User: "bob"}},
+			http.StatusBadRequest,
+			"Invalid translated event",
+		},
+		{
+			"unsupported host",
+			stubEventTranslator{event: events_controllers.TranslatedEvent{Type: events_controllers.TranslatedCommentEvent, VCSHost: "BitbucketCloud", Repo: "owner/repo", Pull: pull, User: "bob"}},
+			http.StatusBadRequest,
+			"Ignoring translated event since not configured to support BitbucketCloud",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			e, _, _, _, _, _, _, _, _ := setup(t)
+			e.EventTranslator = c.translator
+			w := post(e)
+			ResponseContains(t, w, c.expCode, c.expBody)
+		})
+	}
+}
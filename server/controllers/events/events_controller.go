@@ -27,6 +27,7 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
@@ -58,19 +59,40 @@ const azuredevopsTestURL = "https://fabrikam.visualstudio.com/DefaultCollection/
 // VCSEventsController handles all webhook requests which signify 'events' in the
 // VCS host, ex. GitHub.
 type VCSEventsController struct {
-	CommandRunner  events.CommandRunner  `validate:"required"`
-	PullCleaner    events.PullCleaner    `validate:"required"`
-	Logger         logging.SimpleLogging `validate:"required"`
-	Scope          tally.Scope           `validate:"required"`
-	Parser         events.EventParsing   `validate:"required"`
-	CommentParser  events.CommentParsing `validate:"required"`
-	ApplyDisabled  bool
-	EmojiReaction  string
-	ExecutableName string
+	CommandRunner events.CommandRunner `validate:"required"`
+	PullCleaner   events.PullCleaner   `validate:"required"`
+	// DeletedBranchCleaner cleans up after the pull requests of deleted
+	// branches. If nil, deleted branches are ignored.
+	DeletedBranchCleaner events.DeletedBranchCleaner
+	Logger               logging.SimpleLogging `validate:"required"`
+	Scope                tally.Scope           `validate:"required"`
+	Parser               events.EventParsing   `validate:"required"`
+	CommentParser        events.CommentParsing `validate:"required"`
+	ApplyDisabled        bool
+	EmojiReaction        string
+	ExecutableName       string
+	// EmojiReactions maps VCS hosts to the reaction comments with commands are
+	// acknowledged with on them instead of EmojiReaction.
+	EmojiReactions map[models.VCSHostType]string
+	// AckComment is commented to acknowledge commands on VCS hosts without
+	// reactions, ex. Bitbucket. If empty, commands aren't acknowledged there.
+	AckComment string
+	// ApplyOnMerge controls whether the plans of a pull request are applied
+	// once it's merged, before its locks and plans are cleaned up.
+	ApplyOnMerge bool
 	// GithubWebhookSecret is the secret added to this webhook via the GitHub
 	// UI that identifies this call as coming from GitHub. If empty, no
 	// request validation is done.
-	GithubWebhookSecret          []byte
+	GithubWebhookSecret []byte
+	// GithubAllowEditedComments controls whether commands in edited GitHub
+	// comments are run. If false, only new comments are.
+	GithubAllowEditedComments bool
+	// GithubAllowReviewComments controls whether commands in GitHub pull
+	// request review comments, ie. comments on the diff, are run.
+	GithubAllowReviewComments bool
+	// GithubAppID is the ID of the GitHub app Atlantis runs as. Check run
+	// events are only handled for the check runs of this app.
+	GithubAppID                  int64
 	GithubRequestValidator       GithubRequestValidator       `validate:"required"`
 	GitlabRequestParserValidator GitlabRequestParserValidator `validate:"required"`
 	// GitlabWebhookSecret is the secret added to this webhook via the GitLab
@@ -90,6 +112,10 @@ type VCSEventsController struct {
 	// UI that identifies this call as coming from Bitbucket. If empty, no
 	// request validation is done.
 	BitbucketWebhookSecret []byte
+	// BitbucketWebhookSecondarySecret is accepted in addition to
+	// BitbucketWebhookSecret so that the secret can be rotated without
+	// downtime. If empty, only BitbucketWebhookSecret is accepted.
+	BitbucketWebhookSecondarySecret []byte
 	// AzureDevopsWebhookUser is the Basic authentication username added to this
 	// webhook via the Azure DevOps UI that identifies this call as coming from your
 	// Azure DevOps Team Project. If empty, no request validation is done.
@@ -101,6 +127,9 @@ type VCSEventsController struct {
 	AzureDevopsWebhookBasicPassword []byte
 	AzureDevopsRequestValidator     AzureDevopsRequestValidator `validate:"required"`
 	GiteaWebhookSecret              []byte
+	// EventTranslator translates the webhooks that weren't sent by a
+	// supported VCS host. If nil, they're ignored.
+	EventTranslator EventTranslator
 }
 
 // Post handles POST webhook requests.
@@ -158,6 +187,11 @@ func (e *VCSEventsController) Post(w http.ResponseWriter, r *http.Request) {
 		e.handleAzureDevopsPost(w, r)
 		return
 	}
+	if e.EventTranslator != nil {
+		e.Logger.Debug("handling translated post")
+		e.handleTranslatedPost(w, r)
+		return
+	}
 	e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request")
 }
 
@@ -195,10 +229,20 @@ func (e *VCSEventsController) handleGithubPost(w http.ResponseWriter, r *http.Re
 		resp = e.HandleGithubCommentEvent(event, githubReqID, logger)
 		scope = scope.SubScope(fmt.Sprintf("comment_%s", *event.Action))
 		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetIssue().GetNumber())
+	case *github.PullRequestReviewCommentEvent:
+		resp = e.HandleGithubPullRequestReviewCommentEvent(event, githubReqID, logger)
+		scope = scope.SubScope(fmt.Sprintf("review_comment_%s", event.GetAction()))
+		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetPullRequest().GetNumber())
 	case *github.PullRequestEvent:
 		resp = e.HandleGithubPullRequestEvent(logger, event, githubReqID)
 		scope = scope.SubScope(fmt.Sprintf("pr_%s", *event.Action))
 		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetNumber())
+	case *github.CheckRunEvent:
+		resp = e.HandleGithubCheckRunEvent(event, githubReqID, logger)
+		scope = scope.SubScope(fmt.Sprintf("check_run_%s", event.GetAction()))
+	case *github.DeleteEvent:
+		resp = e.HandleGithubDeleteEvent(event, githubReqID, logger)
+		scope = scope.SubScope(fmt.Sprintf("delete_%s", event.GetRefType()))
 	default:
 		resp = HTTPResponse{
 			body: fmt.Sprintf("Ignoring unsupported event %s", githubReqID),
@@ -231,7 +275,7 @@ func (e *VCSEventsController) handleBitbucketCloudPost(w http.ResponseWriter, r
 		return
 	}
 	if len(e.BitbucketWebhookSecret) > 0 {
-		if err := bitbucketcloud.ValidateSignature(body, sig, e.BitbucketWebhookSecret); err != nil {
+		if err := e.validateBitbucketSignature(bitbucketcloud.ValidateSignature, body, sig); err != nil {
 			e.respond(w, logging.Warn, http.StatusBadRequest, "%s", errors.Wrap(err, "request did not pass validation").Error())
 			return
 		}
@@ -245,6 +289,10 @@ func (e *VCSEventsController) handleBitbucketCloudPost(w http.ResponseWriter, r
 		e.Logger.Debug("handling as comment created event")
 		e.HandleBitbucketCloudCommentEvent(w, body, reqID)
 		return
+	case bitbucketcloud.RepoPushHeader:
+		e.Logger.Debug("handling as push event")
+		e.handleBitbucketCloudPushEvent(w, body, reqID)
+		return
 	default:
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event type %s %s=%s", eventType, bitbucketCloudRequestIDHeader, reqID)
 	}
@@ -267,7 +315,7 @@ func (e *VCSEventsController) handleBitbucketServerPost(w http.ResponseWriter, r
 		return
 	}
 	if len(e.BitbucketWebhookSecret) > 0 {
-		if err := bitbucketserver.ValidateSignature(body, sig, e.BitbucketWebhookSecret); err != nil {
+		if err := e.validateBitbucketSignature(bitbucketserver.ValidateSignature, body, sig); err != nil {
 			e.respond(w, logging.Warn, http.StatusBadRequest, "%s", errors.Wrap(err, "request did not pass validation").Error())
 			return
 		}
@@ -281,11 +329,34 @@ func (e *VCSEventsController) handleBitbucketServerPost(w http.ResponseWriter, r
 		e.Logger.Debug("handling as comment created event")
 		e.HandleBitbucketServerCommentEvent(w, body, reqID)
 		return
+	case bitbucketserver.RepoRefsChangedHeader:
+		e.Logger.Debug("handling as push event")
+		e.handleBitbucketServerRefsChangedEvent(w, body, reqID)
+		return
 	default:
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event type %s %s=%s", eventType, bitbucketServerRequestIDHeader, reqID)
 	}
 }
 
+// validateBitbucketSignature validates sig against the primary webhook secret
+// and, while the secret is being rotated, the secondary webhook secret. It logs
+// which of the two secrets was used.
+func (e *VCSEventsController) validateBitbucketSignature(validate func(payload []byte, signature string, secretKey []byte) error, body []byte, sig string) error {
+	err := validate(body, sig, e.BitbucketWebhookSecret)
+	if err == nil {
+		e.Logger.Debug("request validated with the primary webhook secret")
+		return nil
+	}
+	if len(e.BitbucketWebhookSecondarySecret) == 0 {
+		return err
+	}
+	if validate(body, sig, e.BitbucketWebhookSecondarySecret) != nil {
+		return err
+	}
+	e.Logger.Info("request validated with the secondary webhook secret")
+	return nil
+}
+
 func (e *VCSEventsController) handleAzureDevopsPost(w http.ResponseWriter, r *http.Request) {
 	// Validate the request against the optional basic auth username and password.
 	payload, err := e.AzureDevopsRequestValidator.Validate(r, e.AzureDevopsWebhookBasicUser, e.AzureDevopsWebhookBasicPassword)
@@ -308,6 +379,9 @@ func (e *VCSEventsController) handleAzureDevopsPost(w http.ResponseWriter, r *ht
 	case azuredevops.PullRequestEvent:
 		e.Logger.Debug("handling as pull request event")
 		e.HandleAzureDevopsPullRequestEvent(w, event, azuredevopsReqID)
+	case azuredevops.PushEvent:
+		e.Logger.Debug("handling as push event")
+		e.handleAzureDevopsPushEvent(w, event, azuredevopsReqID)
 	default:
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event: %v %s", event.PayloadType, azuredevopsReqID)
 	}
@@ -345,6 +419,9 @@ func (e *VCSEventsController) handleGiteaPost(w http.ResponseWriter, r *http.Req
 	case "pull_request":
 		logger.Debug("Handling as pull_request")
 		e.handleGiteaPullRequestEvent(logger, w, body, reqID)
+	case "delete":
+		logger.Debug("Handling as delete")
+		e.handleGiteaDeleteEvent(w, body, reqID)
 	// Add other case handlers as necessary
 	default:
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported Gitea event type: %s %s=%s", eventType, "X-Gitea-Delivery", reqID)
@@ -379,7 +456,7 @@ func (e *VCSEventsController) handleGiteaPullRequestEvent(logger logging.SimpleL
 		"pull", strconv.Itoa(pull.Num),
 	)
 	logger.Info("Handling Gitea Pull Request '%s' event", pullEventType.String())
-	response := e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType)
+	response := e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType, "")
 
 	e.respond(w, logging.Debug, http.StatusOK, "%s", response.body)
 }
@@ -405,10 +482,12 @@ func (e *VCSEventsController) HandleGiteaPullRequestCommentEvent(w http.Response
 // HandleGithubCommentEvent handles comment events from GitHub where Atlantis
 // commands can come from. It's exported to make testing easier.
 func (e *VCSEventsController) HandleGithubCommentEvent(event *github.IssueCommentEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
-	if event.GetAction() != "created" {
-		return HTTPResponse{
-			body: fmt.Sprintf("Ignoring comment event since action was not created %s", githubReqID),
-		}
+	var previousBody *string
+	if event.GetChanges() != nil && event.GetChanges().Body != nil {
+		previousBody = event.GetChanges().Body.From
+	}
+	if resp, ignore := e.ignoreGithubCommentAction(event.GetAction(), event.GetComment().GetBody(), previousBody, event.GetSender().GetLogin(), event.GetComment().GetUser().GetLogin(), githubReqID); ignore {
+		return resp
 	}
 
 	baseRepo, user, pullNum, err := e.Parser.ParseGithubIssueCommentEvent(logger, event)
@@ -432,6 +511,127 @@ func (e *VCSEventsController) HandleGithubCommentEvent(event *github.IssueCommen
 	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, comment.GetBody(), comment.GetID(), models.Github)
 }
 
+// HandleGithubPullRequestReviewCommentEvent handles comments on the diff of
+// GitHub pull requests, including replies in review threads, if
+// GithubAllowReviewComments is set. It's exported to make testing easier.
+func (e *VCSEventsController) HandleGithubPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
+	if !e.GithubAllowReviewComments {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring review comment event since review comments are not enabled %s", githubReqID),
+		}
+	}
+	var previousBody *string
+	if event.GetChanges() != nil && event.GetChanges().Body != nil {
+		previousBody = event.GetChanges().Body.From
+	}
+	if resp, ignore := e.ignoreGithubCommentAction(event.GetAction(), event.GetComment().GetBody(), previousBody, event.GetSender().GetLogin(), event.GetComment().GetUser().GetLogin(), githubReqID); ignore {
+		return resp
+	}
+
+	baseRepo, user, pullNum, err := e.Parser.ParseGithubPullRequestReviewCommentEvent(logger, event)
+	if err != nil {
+		wrapped := errors.Wrapf(err, "Failed parsing event: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code:       http.StatusBadRequest,
+				err:        wrapped,
+				isSilenced: false,
+			},
+		}
+	}
+
+	// Review comments have their own reactions API which the VCS client
+	// doesn't support, so we pass -1 as the comment ID to skip the reaction.
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, event.GetComment().GetBody(), -1, models.Github)
+}
+
+// ignoreGithubCommentAction returns true and the response to send if a GitHub
+// comment event with action shouldn't be handled. New comments are always
+// handled. Edited comments are only handled if GithubAllowEditedComments is
+// set and their body changed, so that editing a comment that already ran a
+// command, ex. to fix a typo elsewhere in it, doesn't run the command again.
+// They're also only handled if they were edited by their author, since
+// commands run as the author and users with write access can edit the
+// comments of others.
+func (e *VCSEventsController) ignoreGithubCommentAction(action string, body string, previousBody *string, sender string, author string, githubReqID string) (HTTPResponse, bool) {
+	switch {
+	case action == "created":
+		return HTTPResponse{}, false
+	case action == "edited" && e.GithubAllowEditedComments:
+		if !strings.EqualFold(sender, author) {
+			return HTTPResponse{
+				body: fmt.Sprintf("Ignoring edited comment event since it was edited by %q, not its author %q %s", sender, author, githubReqID),
+			}, true
+		}
+		if previousBody == nil || strings.TrimSpace(*previousBody) == strings.TrimSpace(body) {
+			return HTTPResponse{
+				body: fmt.Sprintf("Ignoring edited comment event since the comment body didn't change %s", githubReqID),
+			}, true
+		}
+		return HTTPResponse{}, false
+	case action == "edited":
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring comment event since action was not created and edited comments are not enabled %s", githubReqID),
+		}, true
+	default:
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring comment event since action was not created %s", githubReqID),
+		}, true
+	}
+}
+
+// HandleGithubCheckRunEvent handles the check run re-runs and button clicks
+// on the check runs Atlantis creates when --gh-use-check-runs is set. They
+// are handled as if the user had commented the command they map to.
+func (e *VCSEventsController) HandleGithubCheckRunEvent(event *github.CheckRunEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
+	var args string
+	switch event.GetAction() {
+	case "rerequested":
+		args = event.GetCheckRun().GetExternalID()
+	case "requested_action":
+		args = event.GetRequestedAction().Identifier
+	default:
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring check run event since action was %q %s", event.GetAction(), githubReqID),
+		}
+	}
+	if appID := event.GetCheckRun().GetApp().GetID(); e.GithubAppID == 0 || appID != e.GithubAppID {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring check run event since it's for app %d instead of %d %s", appID, e.GithubAppID, githubReqID),
+		}
+	}
+	if cmd := strings.Fields(args); len(cmd) == 0 || (cmd[0] != command.Plan.String() && cmd[0] != command.Apply.String()) {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring check run event since it isn't for an Atlantis plan or apply %s", githubReqID),
+		}
+	}
+	if len(event.GetCheckRun().PullRequests) == 0 {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring check run event since it isn't for a pull request %s", githubReqID),
+		}
+	}
+
+	baseRepo, err := e.Parser.ParseGithubRepo(event.GetRepo())
+	if err != nil {
+		wrapped := errors.Wrapf(err, "Failed parsing event: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code:       http.StatusBadRequest,
+				err:        wrapped,
+				isSilenced: false,
+			},
+		}
+	}
+	user := models.User{
+		Username: event.GetSender().GetLogin(),
+	}
+	pullNum := event.GetCheckRun().PullRequests[0].GetNumber()
+	comment := fmt.Sprintf("%s %s", e.ExecutableName, args)
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, comment, -1, models.Github)
+}
+
 // HandleBitbucketCloudCommentEvent handles comment events from Bitbucket.
 func (e *VCSEventsController) HandleBitbucketCloudCommentEvent(w http.ResponseWriter, body []byte, reqID string) {
 	pull, baseRepo, headRepo, user, comment, err := e.Parser.ParseBitbucketCloudPullCommentEvent(body)
@@ -481,7 +681,7 @@ func (e *VCSEventsController) handleBitbucketCloudPullRequestEvent(logger loggin
 		return
 	}
 	e.Logger.Debug("SHA is %q", pull.HeadCommit)
-	pullEventType := e.Parser.GetBitbucketCloudPullEventType(eventType, pull.HeadCommit, pull.URL)
+	pullEventType := e.Parser.GetBitbucketCloudPullEventType(eventType, pull.HeadCommit, pull.URL, pull.Draft)
 
 	// Annotate logger with repo and pull/merge request number.
 	logger = logger.With(
@@ -490,7 +690,7 @@ func (e *VCSEventsController) handleBitbucketCloudPullRequestEvent(logger loggin
 	)
 
 	logger.Info("Handling Bitbucket Cloud Pull Request '%s' event", pullEventType.String())
-	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType)
+	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType, "")
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -519,7 +719,7 @@ func (e *VCSEventsController) handleBitbucketServerPullRequestEvent(logger loggi
 	)
 
 	logger.Info("Handling Bitbucket Server Pull Request '%s' event", pullEventType.String())
-	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType)
+	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType, "")
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -557,16 +757,18 @@ func (e *VCSEventsController) HandleGithubPullRequestEvent(logger logging.Simple
 	)
 
 	logger.Info("Handling GitHub Pull Request '%s' event", pullEventType.String())
-	return e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType)
+	return e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType, pullEvent.GetLabel().GetName())
 }
 
-func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLogging, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, eventType models.PullRequestEventType) HTTPResponse {
+// handlePullRequestEvent handles a parsed pull request event. label is the
+// label that was added if eventType is models.LabeledPullEvent.
+func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLogging, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, eventType models.PullRequestEventType, label string) HTTPResponse {
 	if !e.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
 		// If the repo isn't allowlisted and we receive an opened pull request
 		// event we comment back on the pull request that the repo isn't
 		// allowlisted. This is because the user might be expecting Atlantis to
 		// autoplan. For other events, we just ignore them.
-		if eventType == models.OpenedPullEvent {
+		if eventType == models.OpenedPullEvent || eventType == models.ReadyForReviewPullEvent {
 			e.commentNotAllowlisted(baseRepo, pull.Num)
 		}
 
@@ -583,22 +785,38 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 	}
 
 	switch eventType {
-	case models.OpenedPullEvent, models.UpdatedPullEvent:
-		// If the pull request was opened or updated, we will try to autoplan.
+	case models.OpenedPullEvent, models.UpdatedPullEvent, models.ReadyForReviewPullEvent, models.LabeledPullEvent:
+		// If the pull request was opened, updated, marked as ready for review
+		// or labeled, we will try to autoplan. Which projects are planned
+		// depends on their autoplan mode.
+		trigger := models.AutoplanTrigger{Event: eventType, Label: label}
 
 		// Respond with success and then actually execute the command asynchronously.
 		// We use a goroutine so that this function returns and the connection is
 		// closed.
 		if !e.TestingMode {
-			go e.CommandRunner.RunAutoplanCommand(baseRepo, headRepo, pull, user)
+			go e.CommandRunner.RunAutoplanCommand(baseRepo, headRepo, pull, user, trigger)
 		} else {
 			// When testing we want to wait for everything to complete.
-			e.CommandRunner.RunAutoplanCommand(baseRepo, headRepo, pull, user)
+			e.CommandRunner.RunAutoplanCommand(baseRepo, headRepo, pull, user, trigger)
 		}
 		return HTTPResponse{
 			body: "Processing...",
 		}
 	case models.ClosedPullEvent:
+		if e.ApplyOnMerge && !e.ApplyDisabled && pull.State == models.MergedPullState {
+			// The plans have to be applied before they're cleaned up so we
+			// do both asynchronously.
+			if !e.TestingMode {
+				go e.applyOnMergeAndCleanUp(logger, baseRepo, headRepo, pull, user)
+			} else {
+				e.applyOnMergeAndCleanUp(logger, baseRepo, headRepo, pull, user)
+			}
+			return HTTPResponse{
+				body: "Applying merged pull request...",
+			}
+		}
+
 		// If the pull request was closed, we delete locks.
 		logger.Info("Pull request closed, cleaning up...")
 		if err := e.PullCleaner.CleanUpPull(logger, baseRepo, pull); err != nil {
@@ -624,6 +842,20 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 	return HTTPResponse{}
 }
 
+// applyOnMergeAndCleanUp applies the plans of a merged pull request and then
+// deletes its locks and workspace.
+func (e *VCSEventsController) applyOnMergeAndCleanUp(logger logging.SimpleLogging, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
+	logger.Info("Pull request merged, applying...")
+	e.CommandRunner.RunApplyOnMergeCommand(baseRepo, headRepo, pull, user)
+
+	logger.Info("Pull request closed, cleaning up...")
+	if err := e.PullCleaner.CleanUpPull(logger, baseRepo, pull); err != nil {
+		logger.Err("cleaning up merged pull request: %s", err)
+		return
+	}
+	logger.Info("Locks and workspace successfully deleted")
+}
+
 func (e *VCSEventsController) handleGitlabPost(w http.ResponseWriter, r *http.Request) {
 	event, err := e.GitlabRequestParserValidator.ParseAndValidate(r, e.GitlabWebhookSecret)
 	if err != nil {
@@ -638,6 +870,8 @@ func (e *VCSEventsController) handleGitlabPost(w http.ResponseWriter, r *http.Re
 		e.HandleGitlabCommentEvent(w, event)
 	case gitlab.MergeEvent:
 		e.HandleGitlabMergeRequestEvent(e.Logger, w, event)
+	case gitlab.PushEvent:
+		e.HandleGitlabPushEvent(w, event)
 	case gitlab.CommitCommentEvent:
 		e.Logger.Debug("comments on commits are not supported, only comments on merge requests")
 		e.respond(w, logging.Debug, http.StatusOK, "Ignoring comment on commit event")
@@ -709,22 +943,19 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 		}
 	}
 
-	// It's a comment we're going to react to so add a reaction.
-	if e.EmojiReaction != "" {
-		err := e.VCSClient.ReactToComment(logger, baseRepo, pullNum, commentID, e.EmojiReaction)
-		if err != nil {
-			logger.Warn("Failed to react to comment: %s", err)
-		}
-	}
+	// It's a comment we're going to react to so acknowledge it.
+	clearAcknowledgment := e.acknowledgeComment(logger, baseRepo, pullNum, commentID)
 
-	// If the command isn't valid or doesn't require processing, ex.
-	// "atlantis help" then we just comment back immediately.
+	// If the command isn't valid or doesn't require processing then we just
+	// comment back immediately. Help is run as a command so that it can
+	// describe the repo's projects.
 	// We do this here rather than earlier because we need access to the pull
 	// variable to comment back on the pull request.
-	if parseResult.CommentResponse != "" {
+	if parseResult.CommentResponse != "" && parseResult.Command == nil {
 		if err := e.VCSClient.CreateComment(logger, baseRepo, pullNum, parseResult.CommentResponse, ""); err != nil {
 			logger.Err("Unable to comment on pull request: %s", err)
 		}
+		clearAcknowledgment()
 		return HTTPResponse{
 			body: "Commenting back on pull request",
 		}
@@ -735,14 +966,19 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 	} else {
 		logger.Info("Running comment command '%v' for user '%v'.", parseResult.Command.Name, user.Username)
 	}
+	parseResult.Command.CommentID = commentID
 	if !e.TestingMode {
 		// Respond with success and then actually execute the command asynchronously.
 		// We use a goroutine so that this function returns and the connection is
 		// closed.
-		go e.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, maybePull, user, pullNum, parseResult.Command)
+		go func() {
+			e.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, maybePull, user, pullNum, parseResult.Command)
+			clearAcknowledgment()
+		}()
 	} else {
 		// When testing we want to wait for everything to complete.
 		e.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, maybePull, user, pullNum, parseResult.Command)
+		clearAcknowledgment()
 	}
 
 	return HTTPResponse{
@@ -750,6 +986,50 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 	}
 }
 
+// acknowledgeComment acknowledges the comment commentID by reacting to it or,
+// if the comment is unknown or its VCS host has no reactions, by commenting
+// AckComment. It returns a func that removes the acknowledgment once the
+// command has completed.
+func (e *VCSEventsController) acknowledgeComment(logger logging.SimpleLogging, baseRepo models.Repo, pullNum int, commentID int64) func() {
+	reaction := e.EmojiReaction
+	if r, ok := e.EmojiReactions[baseRepo.VCSHost.Type]; ok {
+		reaction = r
+	}
+	if reaction != "" && commentID >= 0 {
+		if err := e.VCSClient.ReactToComment(logger, baseRepo, pullNum, commentID, reaction); err != nil {
+			logger.Warn("Failed to react to comment: %s", err)
+			return func() {}
+		}
+		remover, ok := e.VCSClient.(vcs.ReactionRemover)
+		if !ok {
+			return func() {}
+		}
+		return func() {
+			if err := remover.RemoveReaction(logger, baseRepo, pullNum, commentID, reaction); err != nil {
+				logger.Warn("Failed to remove reaction from comment: %s", err)
+			}
+		}
+	}
+
+	commenter, ok := e.VCSClient.(vcs.AcknowledgmentCommenter)
+	if e.AckComment == "" || !ok {
+		return func() {}
+	}
+	ackID, err := commenter.CreateAcknowledgment(logger, baseRepo, pullNum, e.AckComment)
+	if err != nil {
+		logger.Warn("Failed to acknowledge comment: %s", err)
+		return func() {}
+	}
+	if ackID == 0 {
+		return func() {}
+	}
+	return func() {
+		if err := commenter.DeleteAcknowledgment(logger, baseRepo, pullNum, ackID); err != nil {
+			logger.Warn("Failed to delete acknowledgment comment %d: %s", ackID, err)
+		}
+	}
+}
+
 // HandleGitlabMergeRequestEvent will delete any locks associated with the pull
 // request if the event is a merge request closed event. It's exported to make
 // testing easier.
@@ -766,7 +1046,7 @@ func (e *VCSEventsController) HandleGitlabMergeRequestEvent(logger logging.Simpl
 		"pull", strconv.Itoa(pull.Num),
 	)
 	logger.Info("Processing Gitlab merge request '%s' event", pullEventType.String())
-	resp := e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType)
+	resp := e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType, "")
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -871,7 +1151,7 @@ func (e *VCSEventsController) HandleAzureDevopsPullRequestEvent(w http.ResponseW
 		return
 	}
 	e.Logger.Info("identified event as type %q", pullEventType.String())
-	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType)
+	resp := e.handlePullRequestEvent(e.Logger, baseRepo, headRepo, pull, user, pullEventType, "")
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
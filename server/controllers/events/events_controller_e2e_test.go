@@ -1489,8 +1489,10 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		ApplyStepRunner: &runtime.ApplyStepRunner{
 			TerraformExecutor: terraformClient,
 		},
-		ImportStepRunner:  runtime.NewImportStepRunner(terraformClient, defaultTFDistribution, defaultTFVersion),
-		StateRmStepRunner: runtime.NewStateRmStepRunner(terraformClient, defaultTFDistribution, defaultTFVersion),
+		ImportStepRunner:      runtime.NewImportStepRunner(terraformClient, defaultTFDistribution, defaultTFVersion),
+		StateRmStepRunner:     runtime.NewStateRmStepRunner(terraformClient, defaultTFDistribution, defaultTFVersion),
+		StateCheckStepRunner:  runtime.NewStateCheckStepRunner(terraformClient, defaultTFDistribution, defaultTFVersion),
+		ForceUnlockStepRunner: runtime.NewForceUnlockStepRunner(terraformClient, defaultTFDistribution, defaultTFVersion),
 		RunStepRunner: &runtime.RunStepRunner{
 			TerraformExecutor:       terraformClient,
 			DefaultTFDistribution:   defaultTFDistribution,
@@ -1562,6 +1564,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		lockingClient,
 		discardApprovalOnPlan,
 		e2ePullReqStatusFetcher,
+		0,
 	)
 
 	applyCommandRunner := events.NewApplyCommandRunner(
@@ -1579,6 +1582,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		silenceNoProjects,
 		false,
 		e2ePullReqStatusFetcher,
+		false,
 	)
 
 	approvePoliciesCommandRunner := events.NewApprovePoliciesCommandRunner(
@@ -1621,6 +1625,13 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		projectCommandRunner,
 	)
 
+	forceUnlockCommandRunner := events.NewForceUnlockCommandRunner(
+		pullUpdater,
+		projectCommandBuilder,
+		projectCommandRunner,
+		silenceNoProjects,
+	)
+
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
 		command.Plan:            planCommandRunner,
 		command.Apply:           applyCommandRunner,
@@ -1629,6 +1640,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		command.Version:         versionCommandRunner,
 		command.Import:          importCommandRunner,
 		command.State:           stateCommandRunner,
+		command.ForceUnlock:     forceUnlockCommandRunner,
 	}
 
 	commandRunner := &events.DefaultCommandRunner{
@@ -15,6 +15,9 @@ package events_test
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -425,6 +428,207 @@ func TestPost_GithubCommentSuccess(t *testing.T) {
 	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
 }
 
+func TestPost_GithubCommentEdited(t *testing.T) {
+	cases := []struct {
+		description string
+		allowEdited bool
+		event       string
+		expRun      bool
+		expResp     string
+	}{
+		{
+			description: "edited comments are ignored by default",
+			event:       `{"action": "edited", "sender": {"login": "alice"}, "comment": {"body": "atlantis plan", "id": 1, "user": {"login": "alice"}}, "changes": {"body": {"from": "atlantis pln"}}}`,
+			expResp:     "Ignoring comment event since action was not created and edited comments are not enabled",
+		},
+		{
+			description: "edited comments run if enabled",
+			allowEdited: true,
+			event:       `{"action": "edited", "sender": {"login": "alice"}, "comment": {"body": "atlantis plan", "id": 1, "user": {"login": "alice"}}, "changes": {"body": {"from": "atlantis pln"}}}`,
+			expRun:      true,
+			expResp:     "Processing...",
+		},
+		{
+			description: "comments edited by someone else are ignored",
+			allowEdited: true,
+			event:       `{"action": "edited", "sender": {"login": "mallory"}, "comment": {"body": "atlantis apply", "id": 1, "user": {"login": "alice"}}, "changes": {"body": {"from": "looks good"}}}`,
+			expResp:     `Ignoring edited comment event since it was edited by "mallory", not its author "alice"`,
+		},
+		{
+			description: "edited comments whose body didn't change are ignored",
+			allowEdited: true,
+			event:       `{"action": "edited", "sender": {"login": "alice"}, "comment": {"body": "atlantis plan", "id": 1, "user": {"login": "alice"}}, "changes": {}}`,
+			expResp:     "Ignoring edited comment event since the comment body didn't change",
+		},
+		{
+			description: "deleted comments are ignored",
+			allowEdited: true,
+			event:       `{"action": "deleted", "comment": {"body": "atlantis plan", "id": 1}}`,
+			expResp:     "Ignoring comment event since action was not created",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			e, v, _, _, p, cr, _, _, cp := setup(t)
+			e.GithubAllowEditedComments = c.allowEdited
+			req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+			req.Header.Set(githubHeader, "issue_comment")
+			When(v.Validate(req, secret)).ThenReturn([]byte(c.event), nil)
+			baseRepo := models.Repo{}
+			user := models.User{}
+			cmd := events.CommentCommand{Name: command.Plan}
+			When(p.ParseGithubIssueCommentEvent(Any[logging.SimpleLogging](), Any[*github.IssueCommentEvent]())).ThenReturn(baseRepo, user, 1, nil)
+			When(cp.Parse("atlantis plan", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, http.StatusOK, c.expResp)
+
+			if c.expRun {
+				cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+			} else {
+				cr.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), AnyInt(), Any[*events.CommentCommand]())
+			}
+		})
+	}
+}
+
+func TestPost_GithubReviewComment(t *testing.T) {
+	cases := []struct {
+		description  string
+		allowReviews bool
+		allowEdited  bool
+		event        string
+		expRun       bool
+		expResp      string
+	}{
+		{
+			description: "review comments are ignored by default",
+			event:       `{"action": "created", "comment": {"body": "atlantis plan", "id": 1}}`,
+			expResp:     "Ignoring review comment event since review comments are not enabled",
+		},
+		{
+			description:  "review comments run if enabled",
+			allowReviews: true,
+			event:        `{"action": "created", "comment": {"body": "atlantis plan", "id": 1}}`,
+			expRun:       true,
+			expResp:      "Processing...",
+		},
+		{
+			description:  "edited review comments are ignored unless edited comments are enabled",
+			allowReviews: true,
+			event:        `{"action": "edited", "sender": {"login": "alice"}, "comment": {"body": "atlantis plan", "id": 1, "user": {"login": "alice"}}, "changes": {"body": {"from": "atlantis pln"}}}`,
+			expResp:      "Ignoring comment event since action was not created and edited comments are not enabled",
+		},
+		{
+			description:  "review comments edited by someone else are ignored",
+			allowReviews: true,
+			allowEdited:  true,
+			event:        `{"action": "edited", "sender": {"login": "mallory"}, "comment": {"body": "atlantis apply", "id": 1, "user": {"login": "alice"}}, "changes": {"body": {"from": "looks good"}}}`,
+			expResp:      `Ignoring edited comment event since it was edited by "mallory", not its author "alice"`,
+		},
+		{
+			description:  "edited review comments run if both are enabled",
+			allowReviews: true,
+			allowEdited:  true,
+			event:        `{"action": "edited", "sender": {"login": "alice"}, "comment": {"body": "atlantis plan", "id": 1, "user": {"login": "alice"}}, "changes": {"body": {"from": "atlantis pln"}}}`,
+			expRun:       true,
+			expResp:      "Processing...",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+			e.GithubAllowReviewComments = c.allowReviews
+			e.GithubAllowEditedComments = c.allowEdited
+			req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+			req.Header.Set(githubHeader, "pull_request_review_comment")
+			When(v.Validate(req, secret)).ThenReturn([]byte(c.event), nil)
+			baseRepo := models.Repo{}
+			user := models.User{Username: "reviewer"}
+			cmd := events.CommentCommand{Name: command.Plan}
+			When(p.ParseGithubPullRequestReviewCommentEvent(Any[logging.SimpleLogging](), Any[*github.PullRequestReviewCommentEvent]())).ThenReturn(baseRepo, user, 1, nil)
+			When(cp.Parse("atlantis plan", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, http.StatusOK, c.expResp)
+
+			if c.expRun {
+				cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+				// Review comments can't be reacted to with the issue comment API.
+				vcsClient.VerifyWasCalled(Never()).ReactToComment(Any[logging.SimpleLogging](), Any[models.Repo](), AnyInt(), Any[int64](), AnyString())
+			} else {
+				cr.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), AnyInt(), Any[*events.CommentCommand]())
+			}
+		})
+	}
+}
+
+func TestPost_GithubCheckRun(t *testing.T) {
+	cases := []struct {
+		description string
+		event       string
+		expComment  string
+		expResp     string
+	}{
+		{
+			description: "re-run runs the command in the external id",
+			event:       `{"action": "rerequested", "check_run": {"external_id": "plan -p myproject", "app": {"id": 1}, "pull_requests": [{"number": 1}]}, "sender": {"login": "user"}}`,
+			expComment:  "atlantis plan -p myproject",
+			expResp:     "Processing...",
+		},
+		{
+			description: "button runs the command in the identifier",
+			event:       `{"action": "requested_action", "requested_action": {"identifier": "apply"}, "check_run": {"app": {"id": 1}, "pull_requests": [{"number": 1}]}, "sender": {"login": "user"}}`,
+			expComment:  "atlantis apply",
+			expResp:     "Processing...",
+		},
+		{
+			description: "other actions are ignored",
+			event:       `{"action": "completed", "check_run": {"external_id": "plan", "pull_requests": [{"number": 1}]}}`,
+			expResp:     "Ignoring check run event since action was \"completed\"",
+		},
+		{
+			description: "other check runs are ignored",
+			event:       `{"action": "rerequested", "check_run": {"external_id": "lint", "app": {"id": 1}, "pull_requests": [{"number": 1}]}}`,
+			expResp:     "Ignoring check run event since it isn't for an Atlantis plan or apply",
+		},
+		{
+			description: "check runs of other apps are ignored",
+			event:       `{"action": "rerequested", "check_run": {"external_id": "plan", "app": {"id": 2}, "pull_requests": [{"number": 1}]}, "sender": {"login": "user"}}`,
+			expResp:     "Ignoring check run event since it's for app 2 instead of 1",
+		},
+		{
+			description: "check runs without pull requests are ignored",
+			event:       `{"action": "rerequested", "check_run": {"external_id": "plan", "app": {"id": 1}}}`,
+			expResp:     "Ignoring check run event since it isn't for a pull request",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+			e.GithubAppID = 1
+			req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+			req.Header.Set(githubHeader, "check_run")
+			When(v.Validate(req, secret)).ThenReturn([]byte(c.event), nil)
+			baseRepo := models.Repo{}
+			cmd := events.CommentCommand{}
+			When(p.ParseGithubRepo(Any[*github.Repository]())).ThenReturn(baseRepo, nil)
+			When(cp.Parse(c.expComment, models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, http.StatusOK, c.expResp)
+
+			if c.expComment != "" {
+				cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, models.User{Username: "user"}, 1, &cmd)
+				// There's no comment to react to.
+				vcsClient.VerifyWasCalled(Never()).ReactToComment(Any[logging.SimpleLogging](), Any[models.Repo](), AnyInt(), Any[int64](), AnyString())
+			} else {
+				cr.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), AnyInt(), Any[*events.CommentCommand]())
+			}
+		})
+	}
+}
+
 func TestPost_GithubCommentReaction(t *testing.T) {
 	t.Log("when the event is a github comment with a valid command we call the ReactToComment handler")
 	e, v, _, _, p, _, _, vcsClient, cp := setup(t)
@@ -459,6 +663,171 @@ func TestPost_GilabCommentReaction(t *testing.T) {
 	vcsClient.VerifyWasCalledOnce().ReactToComment(Any[logging.SimpleLogging](), Eq(models.Repo{}), Eq(0), Eq(int64(0)), Eq("eyes"))
 }
 
+// acknowledgingClient is a VCS client that records the acknowledgments it
+// removes.
+type acknowledgingClient struct {
+	*vcsmocks.MockClient
+	calls []string
+}
+
+func (c *acknowledgingClient) RemoveReaction(_ logging.SimpleLogging, _ models.Repo, _ int, commentID int64, reaction string) error {
+	c.calls = append(c.calls, fmt.Sprintf("remove %s from %d", reaction, commentID))
+	return nil
+}
+
+func (c *acknowledgingClient) CreateAcknowledgment(_ logging.SimpleLogging, _ models.Repo, pullNum int, text string) (int64, error) {
+	c.calls = append(c.calls, fmt.Sprintf("comment %q on %d", text, pullNum))
+	return 5, nil
+}
+
+func (c *acknowledgingClient) DeleteAcknowledgment(_ logging.SimpleLogging, _ models.Repo, _ int, id int64) error {
+	c.calls = append(c.calls, fmt.Sprintf("delete %d", id))
+	return nil
+}
+
+func TestPost_GithubCommentReactionRemoved(t *testing.T) {
+	e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+	client := &acknowledgingClient{MockClient: vcsClient}
+	e.VCSClient = client
+	e.EmojiReactions = map[models.VCSHostType]string{models.Github: "rocket", models.Gitlab: ""}
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "issue_comment")
+	event := `{"action": "created", "comment": {"body": "atlantis plan", "id": 1}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{VCSHost: models.VCSHost{Type: models.Github}}
+	cmd := events.CommentCommand{Name: command.Plan}
+	When(p.ParseGithubIssueCommentEvent(Any[logging.SimpleLogging](), Any[*github.IssueCommentEvent]())).ThenReturn(baseRepo, models.User{}, 1, nil)
+	When(cp.Parse("atlantis plan", models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	vcsClient.VerifyWasCalledOnce().ReactToComment(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(1), Eq(int64(1)), Eq("rocket"))
+	cr.VerifyWasCalledOnce().RunCommentCommand(Eq(baseRepo), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), Eq(1), Any[*events.CommentCommand]())
+	Equals(t, []string{"remove rocket from 1"}, client.calls)
+}
+
+func TestPost_BitbucketCloudCommentAcknowledged(t *testing.T) {
+	e, _, _, _, p, _, _, vcsClient, cp := setup(t)
+	client := &acknowledgingClient{MockClient: vcsClient}
+	e.VCSClient = client
+	baseRepo := models.Repo{VCSHost: models.VCSHost{Type: models.BitbucketCloud}}
+	pull := models.PullRequest{Num: 2}
+	When(p.ParseBitbucketCloudPullCommentEvent(Any[[]byte]())).ThenReturn(pull, baseRepo, baseRepo, models.User{}, "atlantis plan", nil)
+	cmd := events.CommentCommand{Name: command.Plan}
+	When(cp.Parse("atlantis plan", models.BitbucketCloud)).ThenReturn(events.CommentParseResult{Command: &cmd})
+
+	t.Run("without --ack-comment", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		e.HandleBitbucketCloudCommentEvent(w, nil, "request-id")
+		ResponseContains(t, w, http.StatusOK, "Processing...")
+		Equals(t, 0, len(client.calls))
+	})
+
+	t.Run("with --ack-comment", func(t *testing.T) {
+		e.AckComment = "🛠 received"
+		w := httptest.NewRecorder()
+		e.HandleBitbucketCloudCommentEvent(w, nil, "request-id")
+		ResponseContains(t, w, http.StatusOK, "Processing...")
+		Equals(t, []string{`comment "🛠 received" on 2`, "delete 5"}, client.calls)
+		vcsClient.VerifyWasCalled(Never()).ReactToComment(Any[logging.SimpleLogging](), Any[models.Repo](), AnyInt(), Any[int64](), AnyString())
+	})
+}
+
+// deletedBranchCleaner records the branches it's asked to clean up after.
+type deletedBranchCleaner struct {
+	calls []string
+}
+
+func (c *deletedBranchCleaner) CleanUpDeletedBranch(_ logging.SimpleLogging, vcsHostType models.VCSHostType, repoFullName string, branch string) (int, error) {
+	c.calls = append(c.calls, fmt.Sprintf("%s %s %s", vcsHostType.String(), repoFullName, branch))
+	return 1, nil
+}
+
+func TestPost_DeletedBranch(t *testing.T) {
+	e, v, gl, _, p, _, _, _, _ := setup(t)
+	cleaner := &deletedBranchCleaner{}
+	e.DeletedBranchCleaner = cleaner
+	e.SupportedVCSHosts = append(e.SupportedVCSHosts, models.BitbucketCloud, models.BitbucketServer)
+
+	t.Run("github", func(t *testing.T) {
+		cleaner.calls = nil
+		req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+		req.Header.Set(githubHeader, "delete")
+		When(v.Validate(req, secret)).ThenReturn([]byte(`{"ref": "feature", "ref_type": "branch", "repository": {"full_name": "owner/repo"}}`), nil)
+		When(p.ParseGithubRepo(Any[*github.Repository]())).ThenReturn(models.Repo{FullName: "owner/repo"}, nil)
+		w := httptest.NewRecorder()
+		e.Post(w, req)
+		ResponseContains(t, w, http.StatusOK, "Cleaned up 1 pull requests with deleted branches")
+		Equals(t, []string{"Github owner/repo feature"}, cleaner.calls)
+	})
+
+	t.Run("github tag", func(t *testing.T) {
+		cleaner.calls = nil
+		req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+		req.Header.Set(githubHeader, "delete")
+		When(v.Validate(req, secret)).ThenReturn([]byte(`{"ref": "v1", "ref_type": "tag", "repository": {"full_name": "owner/repo"}}`), nil)
+		w := httptest.NewRecorder()
+		e.Post(w, req)
+		ResponseContains(t, w, http.StatusOK, "Ignoring deleted tag")
+		Equals(t, 0, len(cleaner.calls))
+	})
+
+	t.Run("gitlab", func(t *testing.T) {
+		cleaner.calls = nil
+		req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+		req.Header.Set(gitlabHeader, "Push Hook")
+		var event gitlab.PushEvent
+		event.Ref = "refs/heads/feature"
+		event.After = "0000000000000000000000000000000000000000"
+		event.Project.PathWithNamespace = "group/repo"
+		When(gl.ParseAndValidate(req, secret)).ThenReturn(event, nil)
+		w := httptest.NewRecorder()
+		e.Post(w, req)
+		ResponseContains(t, w, http.StatusOK, "Cleaned up 1 pull requests with deleted branches")
+		Equals(t, []string{"Gitlab group/repo feature"}, cleaner.calls)
+	})
+
+	t.Run("gitlab push", func(t *testing.T) {
+		cleaner.calls = nil
+		req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+		req.Header.Set(gitlabHeader, "Push Hook")
+		var event gitlab.PushEvent
+		event.Ref = "refs/heads/feature"
+		event.After = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+		event.Project.PathWithNamespace = "group/repo"
+		When(gl.ParseAndValidate(req, secret)).ThenReturn(event, nil)
+		w := httptest.NewRecorder()
+		e.Post(w, req)
+		ResponseContains(t, w, http.StatusOK, "Ignoring push event without deleted branches")
+		Equals(t, 0, len(cleaner.calls))
+	})
+
+	t.Run("bitbucket cloud", func(t *testing.T) {
+		cleaner.calls = nil
+		body := `{"repository": {"full_name": "owner/repo"}, "push": {"changes": [{"old": {"type": "branch", "name": "feature"}, "new": null}, {"old": {"type": "branch", "name": "main"}, "new": {"type": "branch", "name": "main"}}]}}`
+		req, _ := http.NewRequest("POST", "", bytes.NewBufferString(body))
+		req.Header.Set("X-Event-Key", "repo:push")
+		req.Header.Set("X-Request-UUID", "request-id")
+		w := httptest.NewRecorder()
+		e.Post(w, req)
+		ResponseContains(t, w, http.StatusOK, "Cleaned up 1 pull requests with deleted branches")
+		Equals(t, []string{"BitbucketCloud owner/repo feature"}, cleaner.calls)
+	})
+
+	t.Run("bitbucket server", func(t *testing.T) {
+		cleaner.calls = nil
+		body := `{"repository": {"slug": "repo", "project": {"key": "PROJ", "name": "project"}}, "changes": [{"ref": {"displayId": "feature", "type": "BRANCH"}, "type": "DELETE"}]}`
+		req, _ := http.NewRequest("POST", "", bytes.NewBufferString(body))
+		req.Header.Set("X-Event-Key", "repo:refs_changed")
+		req.Header.Set("X-Request-ID", "request-id")
+		w := httptest.NewRecorder()
+		e.Post(w, req)
+		ResponseContains(t, w, http.StatusOK, "Cleaned up 1 pull requests with deleted branches")
+		Equals(t, []string{"BitbucketServer project/repo feature"}, cleaner.calls)
+	})
+}
+
 func TestPost_GithubPullRequestInvalid(t *testing.T) {
 	t.Log("when the event is a github pull request with invalid data we return a 400")
 	e, v, _, _, p, _, _, _, _ := setup(t)
@@ -928,6 +1297,85 @@ func TestPost_BBServerPullClosed(t *testing.T) {
 	}
 }
 
+// Test that either webhook secret is accepted while the secret is being rotated.
+func TestPost_BBServerWebhookSecretRotation(t *testing.T) {
+	cases := []struct {
+		description     string
+		secondarySecret string
+		signingSecret   string
+		expCode         int
+		expResp         string
+	}{
+		{
+			"signed with the primary secret",
+			"secondary",
+			"primary",
+			http.StatusOK,
+			"Pull request cleaned successfully",
+		},
+		{
+			"signed with the secondary secret",
+			"secondary",
+			"secondary",
+			http.StatusOK,
+			"Pull request cleaned successfully",
+		},
+		{
+			"signed with an unknown secret",
+			"secondary",
+			"unknown",
+			http.StatusBadRequest,
+			"request did not pass validation: payload signature check failed",
+		},
+		{
+			"signed with the secondary secret but no secondary secret set",
+			"",
+			"secondary",
+			http.StatusBadRequest,
+			"request did not pass validation: payload signature check failed",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			allowlist, err := events.NewRepoAllowlistChecker("*")
+			Ok(t, err)
+			logger := logging.NewNoopLogger(t)
+			scope, _, _ := metrics.NewLoggingScope(logger, "null")
+			ec := &events_controllers.VCSEventsController{
+				PullCleaner: emocks.NewMockPullCleaner(),
+				Parser: &events.EventParser{
+					BitbucketUser:      "bb-user",
+					BitbucketToken:     "bb-token",
+					BitbucketServerURL: "https://bbserver.com",
+				},
+				RepoAllowlistChecker:            allowlist,
+				SupportedVCSHosts:               []models.VCSHostType{models.BitbucketServer},
+				Logger:                          logger,
+				Scope:                           scope,
+				BitbucketWebhookSecret:          []byte("primary"),
+				BitbucketWebhookSecondarySecret: []byte(c.secondarySecret),
+			}
+
+			body, err := os.ReadFile(filepath.Join("testdata", "bb-server-pull-deleted-event.json"))
+			Ok(t, err)
+			mac := hmac.New(sha256.New, []byte(c.signingSecret))
+			mac.Write(body) // nolint: errcheck
+			req, err := http.NewRequest("POST", "/events", bytes.NewBuffer(body))
+			Ok(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Event-Key", "pr:deleted")
+			req.Header.Set("X-Request-ID", "request-id")
+			req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+			w := httptest.NewRecorder()
+			ec.Post(w, req)
+			ResponseContains(t, w, c.expCode, c.expResp)
+		})
+	}
+}
+
 func TestPost_PullOpenedOrUpdated(t *testing.T) {
 	cases := []struct {
 		Description string
@@ -984,7 +1432,79 @@ func TestPost_PullOpenedOrUpdated(t *testing.T) {
 			w := httptest.NewRecorder()
 			e.Post(w, req)
 			ResponseContains(t, w, http.StatusOK, "Processing...")
-			cr.VerifyWasCalledOnce().RunAutoplanCommand(models.Repo{}, models.Repo{}, models.PullRequest{State: models.ClosedPullState}, models.User{})
+			cr.VerifyWasCalledOnce().RunAutoplanCommand(models.Repo{}, models.Repo{}, models.PullRequest{State: models.ClosedPullState}, models.User{}, models.AutoplanTrigger{Event: models.OpenedPullEvent})
+		})
+	}
+}
+
+func TestPost_GithubPullLabeled(t *testing.T) {
+	t.Log("when a label is added to a pull request, autoplan should run with the label as its trigger")
+	e, v, _, _, p, cr, _, _, _ := setup(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "pull_request")
+	event := `{"action": "labeled", "label": {"name": "plan-me"}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	When(p.ParseGithubPullEvent(Any[logging.SimpleLogging](), Any[*github.PullRequestEvent]())).ThenReturn(models.PullRequest{}, models.LabeledPullEvent, models.Repo{}, models.Repo{}, models.User{}, nil)
+
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+	cr.VerifyWasCalledOnce().RunAutoplanCommand(models.Repo{}, models.Repo{}, models.PullRequest{}, models.User{}, models.AutoplanTrigger{Event: models.LabeledPullEvent, Label: "plan-me"})
+}
+
+func TestPost_GitlabMergeRequestMerged_ApplyOnMerge(t *testing.T) {
+	cases := []struct {
+		description  string
+		applyOnMerge bool
+		state        models.PullRequestState
+		expApply     bool
+		expBody      string
+	}{
+		{
+			description:  "merged with apply on merge",
+			applyOnMerge: true,
+			state:        models.MergedPullState,
+			expApply:     true,
+			expBody:      "Applying merged pull request...",
+		},
+		{
+			description:  "closed with apply on merge",
+			applyOnMerge: true,
+			state:        models.ClosedPullState,
+			expApply:     false,
+			expBody:      "Pull request cleaned successfully",
+		},
+		{
+			description:  "merged without apply on merge",
+			applyOnMerge: false,
+			state:        models.MergedPullState,
+			expApply:     false,
+			expBody:      "Pull request cleaned successfully",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			e, _, gl, _, p, cr, cleaner, _, _ := setup(t)
+			e.ApplyOnMerge = c.applyOnMerge
+			req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+			req.Header.Set(gitlabHeader, "value")
+			var event gitlab.MergeEvent
+			event.ObjectAttributes.Action = "merge"
+			When(gl.ParseAndValidate(req, secret)).ThenReturn(event, nil)
+			repo := models.Repo{}
+			pullRequest := models.PullRequest{State: c.state}
+			When(p.ParseGitlabMergeRequestEvent(event)).ThenReturn(pullRequest, models.ClosedPullEvent, repo, repo, models.User{}, nil)
+			When(cleaner.CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Eq(pullRequest))).ThenReturn(nil)
+
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, http.StatusOK, c.expBody)
+			if c.expApply {
+				cr.VerifyWasCalledOnce().RunApplyOnMergeCommand(repo, repo, pullRequest, models.User{})
+			} else {
+				cr.VerifyWasCalled(Never()).RunApplyOnMergeCommand(Any[models.Repo](), Any[models.Repo](), Any[models.PullRequest](), Any[models.User]())
+			}
+			cleaner.VerifyWasCalledOnce().CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Eq(pullRequest))
 		})
 	}
 }
@@ -115,6 +115,19 @@ func TestValidate_ValidMergeEvent(t *testing.T) {
 
 // If the comment was on a commit instead of a merge request, make sure we
 // return the right object.
+func TestValidate_ValidPushEvent(t *testing.T) {
+	t.Log("If the push event is valid it should be returned")
+	RegisterMockTestingT(t)
+	buf := bytes.NewBufferString(`{"object_kind": "push", "after": "0000000000000000000000000000000000000000", "ref": "refs/heads/feature", "project": {"path_with_namespace": "group/repo"}}`)
+	req, err := http.NewRequest("POST", "http://localhost/event", buf)
+	Ok(t, err)
+	req.Header.Set("X-Gitlab-Event", "Push Hook")
+	b, err := parser.ParseAndValidate(req, nil)
+	Ok(t, err)
+	Equals(t, "refs/heads/feature", b.(gitlab.PushEvent).Ref)
+	Equals(t, "group/repo", b.(gitlab.PushEvent).Project.PathWithNamespace)
+}
+
 func TestValidate_CommitCommentEvent(t *testing.T) {
 	RegisterMockTestingT(t)
 	buf := bytes.NewBufferString(commitCommentEventJSON)
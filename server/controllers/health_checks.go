@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// HealthCheck checks that something Atlantis depends on works, ex. that a
+// VCS host can be reached.
+type HealthCheck struct {
+	Name  string
+	Check func() error
+}
+
+// HealthCheckResult is the result of a HealthCheck.
+type HealthCheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// RunHealthChecks runs checks concurrently and returns their results in the
+// same order.
+func RunHealthChecks(checks []HealthCheck) []HealthCheckResult {
+	results := make([]HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = HealthCheckResult{Name: check.Name, OK: true}
+			if err := check.Check(); err != nil {
+				results[i].OK = false
+				results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// CachedHealthChecks runs Checks at most once every TTL and otherwise returns
+// the results of the last run, so frequent requests don't call every host
+// Atlantis depends on.
+type CachedHealthChecks struct {
+	Checks []HealthCheck
+	TTL    time.Duration
+
+	mu      sync.Mutex
+	results []HealthCheckResult
+	ranAt   time.Time
+}
+
+// Run returns the results of Checks, running them if the last results are
+// older than TTL. Concurrent calls wait for the same run.
+func (c *CachedHealthChecks) Run() []HealthCheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.results == nil || time.Since(c.ranAt) >= c.TTL {
+		c.results = RunHealthChecks(c.Checks)
+		c.ranAt = time.Now()
+	}
+	return c.results
+}
+
+// VCSHealthCheck checks that the API of a VCS host at url can be reached.
+// The request isn't authenticated so any response other than a server error
+// means the host is reachable.
+func VCSHealthCheck(name string, url string, client *http.Client) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func() error {
+			resp, err := client.Get(url) // nolint: noctx
+			if err != nil {
+				return err
+			}
+			resp.Body.Close() // nolint: errcheck
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("%s responded with %s", url, resp.Status)
+			}
+			return nil
+		},
+	}
+}
+
+// DiskSpaceHealthCheck checks that at least minFree bytes are available on
+// the file system of dir.
+func DiskSpaceHealthCheck(dir string, minFree uint64) HealthCheck {
+	return HealthCheck{
+		Name: "disk space",
+		Check: func() error {
+			free, err := utils.FreeDiskSpace(dir)
+			if err != nil {
+				return err
+			}
+			if free < minFree {
+				return fmt.Errorf("only %d MiB are available in %s, less than the %d MiB required", free>>20, dir, minFree>>20)
+			}
+			return nil
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -9,6 +10,7 @@ import (
 	"github.com/runatlantis/atlantis/server/controllers/web_templates"
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	tally "github.com/uber-go/tally/v4"
@@ -35,6 +37,14 @@ type JobsController struct {
 	WsMux                    *websocket.Multiplexor       `validate:"required"`
 	KeyGenerator             JobIDKeyGenerator
 	StatsScope               tally.Scope `validate:"required"`
+	// RunningCommands is used to cancel the running plan or apply of a job.
+	RunningCommands *events.RunningCommands
+	// WebAuthentication, WebUsername and WebPassword are the web basic auth
+	// config. Jobs can only be canceled with its credentials since job pages
+	// are public without it.
+	WebAuthentication bool
+	WebUsername       string
+	WebPassword       string
 }
 
 func (j *JobsController) getProjectJobs(w http.ResponseWriter, r *http.Request) error {
@@ -87,6 +97,36 @@ func (j *JobsController) GetProjectJobsWS(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// CancelProjectJob cancels the plan or apply running for the job.
+func (j *JobsController) CancelProjectJob(w http.ResponseWriter, r *http.Request) {
+	if !j.WebAuthentication {
+		j.respond(w, logging.Warn, http.StatusBadRequest, "Ignoring request since canceling jobs requires --web-basic-auth")
+		return
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(j.WebUsername)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(pass), []byte(j.WebPassword)) != 1 {
+		j.respond(w, logging.Warn, http.StatusUnauthorized, "Ignoring request since its web basic auth credentials are wrong")
+		return
+	}
+	// Browsers send the credentials with requests from other sites too, but
+	// can't add this header to them without Atlantis allowing it.
+	if r.Header.Get("X-Requested-With") != "XMLHttpRequest" {
+		j.respond(w, logging.Warn, http.StatusForbidden, "Ignoring request since it wasn't sent by the job page")
+		return
+	}
+	jobID, err := j.KeyGenerator.Generate(r)
+	if err != nil {
+		j.respond(w, logging.Error, http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	if !j.RunningCommands.CancelJob(jobID) {
+		j.respond(w, logging.Info, http.StatusNotFound, "Job %s isn't running a plan or apply", jobID)
+		return
+	}
+	j.respond(w, logging.Info, http.StatusOK, "Canceling job %s", jobID)
+}
+
 func (j *JobsController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
 	response := fmt.Sprintf(format, args...)
 	j.Logger.Log(lvl, response)
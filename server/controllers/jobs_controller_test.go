@@ -0,0 +1,86 @@
+package controllers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestJobsController_CancelProjectJob(t *testing.T) {
+	runningCommands := events.NewRunningCommands()
+	ctx, done := runningCommands.Track(command.ProjectContext{JobID: "job"})
+	defer done()
+	jc := controllers.JobsController{
+		Logger:            logging.NewNoopLogger(t),
+		RunningCommands:   runningCommands,
+		WebAuthentication: true,
+		WebUsername:       "atlantis",
+		WebPassword:       "password",
+	}
+	// newRequest returns a request to cancel jobID sent by the job page.
+	newRequest := func(jobID string) *http.Request {
+		req, _ := http.NewRequest("POST", "", bytes.NewBuffer(nil))
+		req = mux.SetURLVars(req, map[string]string{"job-id": jobID})
+		req.SetBasicAuth("atlantis", "password")
+		req.Header.Set("X-Requested-With", "XMLHttpRequest")
+		return req
+	}
+
+	t.Run("web basic auth disabled", func(t *testing.T) {
+		jc := jc
+		jc.WebAuthentication = false
+		w := httptest.NewRecorder()
+		jc.CancelProjectJob(w, newRequest("job"))
+		ResponseContains(t, w, http.StatusBadRequest, "canceling jobs requires --web-basic-auth")
+		Assert(t, !ctx.Cancellation.Canceled(), "expected the job to keep running")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		req := newRequest("job")
+		req.SetBasicAuth("atlantis", "wrong")
+		w := httptest.NewRecorder()
+		jc.CancelProjectJob(w, req)
+		ResponseContains(t, w, http.StatusUnauthorized, "credentials are wrong")
+		Assert(t, !ctx.Cancellation.Canceled(), "expected the job to keep running")
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		req := newRequest("job")
+		req.Header.Del("Authorization")
+		w := httptest.NewRecorder()
+		jc.CancelProjectJob(w, req)
+		ResponseContains(t, w, http.StatusUnauthorized, "credentials are wrong")
+		Assert(t, !ctx.Cancellation.Canceled(), "expected the job to keep running")
+	})
+
+	t.Run("not sent by the job page", func(t *testing.T) {
+		req := newRequest("job")
+		req.Header.Del("X-Requested-With")
+		w := httptest.NewRecorder()
+		jc.CancelProjectJob(w, req)
+		ResponseContains(t, w, http.StatusForbidden, "wasn't sent by the job page")
+		Assert(t, !ctx.Cancellation.Canceled(), "expected the job to keep running")
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		jc.CancelProjectJob(w, newRequest("other"))
+		ResponseContains(t, w, http.StatusNotFound, "Job other isn't running a plan or apply")
+		Assert(t, !ctx.Cancellation.Canceled(), "expected the job to keep running")
+	})
+
+	t.Run("running job", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		jc.CancelProjectJob(w, newRequest("job"))
+		ResponseContains(t, w, http.StatusOK, "Canceling job job")
+		Assert(t, ctx.Cancellation.Canceled(), "expected the job to be canceled")
+	})
+}
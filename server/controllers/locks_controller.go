@@ -10,6 +10,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -89,6 +90,19 @@ func (l *LocksController) GetLock(w http.ResponseWriter, r *http.Request) {
 		RepoOwner:       owner,
 		RepoName:        repo,
 	}
+	if l.Backend != nil {
+		status, err := l.Backend.GetPullStatus(lock.Pull)
+		if err != nil {
+			l.Logger.Warn("unable to get the status of pull request %d: %s", lock.Pull.Num, err)
+		} else if status != nil {
+			for _, p := range status.Projects {
+				if p.RepoRelDir == lock.Project.Path && p.Workspace == lock.Workspace && p.ProjectName == lock.Project.ProjectName {
+					viewData.LastPlan = lockDetailRun(status.Pull, status.LastRun(p, command.Plan.String()))
+					viewData.LastApply = lockDetailRun(status.Pull, status.LastRun(p, command.Apply.String()))
+				}
+			}
+		}
+	}
 
 	err = l.LockDetailTemplate.Execute(w, viewData)
 	if err != nil {
@@ -96,6 +110,23 @@ func (l *LocksController) GetLock(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func lockDetailRun(pull models.PullRequest, run *models.CommandRun) *web_templates.LockDetailRun {
+	if run == nil {
+		return nil
+	}
+	commit := run.HeadCommit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return &web_templates.LockDetailRun{
+		User:       run.User,
+		Time:       run.Time.UTC().Format("2006-01-02 15:04:05 MST"),
+		HeadCommit: commit,
+		Trigger:    run.Trigger,
+		CommentURL: pull.CommentURL(run.CommentID),
+	}
+}
+
 // DeleteLock handles deleting the lock at id and commenting back on the
 // pull request that the lock has been deleted.
 func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/slack-go/slack"
+)
+
+const slackCommandUsage = "Usage: `/atlantis <command> [vcs:]<owner>/<repo>#<pull number> [flags]`, ex. `/atlantis plan runatlantis/atlantis#123 -p project`."
+
+// SlackController handles Slack slash commands so that Atlantis commands can
+// be run without opening the pull request, ex.
+// `/atlantis plan runatlantis/atlantis#123 -p project`.
+type SlackController struct {
+	// SigningSecret is the signing secret of the Slack app that sends the
+	// slash commands. If empty, slash commands are disabled.
+	SigningSecret string
+	// Users maps Slack user IDs or usernames to the VCS usernames that
+	// commands are run as. Commands from other Slack users are rejected.
+	Users map[string]string
+	// SupportedVCSHosts is which VCS hosts Atlantis was configured upon
+	// startup to support.
+	SupportedVCSHosts    []models.VCSHostType         `validate:"required"`
+	ExecutableName       string                       `validate:"required"`
+	CommandRunner        events.CommandRunner         `validate:"required"`
+	CommentParser        events.CommentParsing        `validate:"required"`
+	Parser               events.EventParsing          `validate:"required"`
+	PullStatusFetcher    events.PullStatusFetcher     `validate:"required"`
+	RepoAllowlistChecker *events.RepoAllowlistChecker `validate:"required"`
+	VCSClient            vcs.Client                   `validate:"required"`
+	Logger               logging.SimpleLogging        `validate:"required"`
+	// PostResponse posts msg to the response URL of a slash command. It
+	// defaults to slack.PostWebhook.
+	PostResponse func(url string, msg *slack.WebhookMessage) error
+	TestingMode  bool
+}
+
+// Post handles a Slack slash command.
+func (s *SlackController) Post(w http.ResponseWriter, r *http.Request) {
+	if s.SigningSecret == "" {
+		s.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since Slack commands are disabled")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.respond(w, logging.Warn, http.StatusBadRequest, "Failed to read request: %s", err)
+		return
+	}
+	verifier, err := slack.NewSecretsVerifier(r.Header, s.SigningSecret)
+	if err != nil {
+		s.respond(w, logging.Warn, http.StatusUnauthorized, "Failed to verify Slack request: %s", err)
+		return
+	}
+	if _, err := verifier.Write(body); err != nil {
+		s.respond(w, logging.Warn, http.StatusUnauthorized, "Failed to verify Slack request: %s", err)
+		return
+	}
+	if err := verifier.Ensure(); err != nil {
+		s.respond(w, logging.Warn, http.StatusUnauthorized, "Failed to verify Slack request: %s", err)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(body))
+	slashCmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		s.respond(w, logging.Warn, http.StatusBadRequest, "Failed to parse Slack command: %s", err)
+		return
+	}
+
+	logger := s.Logger.With("slack_user", slashCmd.UserID)
+	msg := s.handleSlashCommand(logger, slashCmd)
+	s.respondToSlack(w, msg)
+}
+
+// handleSlashCommand runs slashCmd and returns the message to show in Slack.
+func (s *SlackController) handleSlashCommand(logger logging.SimpleLogging, slashCmd slack.SlashCommand) *slack.WebhookMessage {
+	// Slack usernames can be changed by their users so only the IDs are
+	// trusted.
+	username, ok := s.Users[slashCmd.UserID]
+	if !ok {
+		logger.Info("rejecting Slack command from unmapped user %q", slashCmd.UserName)
+		return ephemeral(fmt.Sprintf("You aren't allowed to run Atlantis commands from Slack. Ask an Atlantis admin to map your Slack user ID `%s` to your VCS username.", slashCmd.UserID))
+	}
+	user := models.User{Username: username}
+
+	name, repoSpec, flags, ok := splitSlashCommandText(slashCmd.Text)
+	if !ok {
+		return ephemeral(slackCommandUsage)
+	}
+	vcsHostType, repoFullName, pullNum, err := s.parseRepoSpec(repoSpec)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("Invalid pull request: %s.\n%s", err, slackCommandUsage))
+	}
+	if vcsHostType == models.BitbucketCloud || vcsHostType == models.BitbucketServer {
+		return ephemeral(fmt.Sprintf("Slack commands aren't supported for %s repos.", vcsHostType))
+	}
+
+	cloneURL, err := s.VCSClient.GetCloneURL(logger, vcsHostType, repoFullName)
+	if err != nil {
+		logger.Err("unable to get clone URL for %s: %s", repoFullName, err)
+		return ephemeral(fmt.Sprintf("Unable to find repo `%s`.", repoFullName))
+	}
+	baseRepo, err := s.Parser.ParseAPIPlanRequest(vcsHostType, repoFullName, cloneURL)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("Unable to parse repo `%s`: %s", repoFullName, err))
+	}
+	if !s.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
+		return ephemeral(fmt.Sprintf("Repo `%s` isn't allowlisted.", baseRepo.FullName))
+	}
+
+	comment := strings.TrimSpace(fmt.Sprintf("%s %s %s", s.ExecutableName, name, flags))
+	parseResult := s.CommentParser.Parse(comment, vcsHostType)
+	if parseResult.Ignore {
+		return ephemeral(slackCommandUsage)
+	}
+	if parseResult.CommentResponse != "" {
+		return ephemeral(parseResult.CommentResponse)
+	}
+
+	pullRef := fmt.Sprintf("%s#%d", baseRepo.FullName, pullNum)
+	logger = logger.WithHistory("repo", baseRepo.FullName, "pull", pullNum)
+	logger.Info("Running '%s' from Slack for user '%s'", comment, user.Username)
+
+	// Leave a trail on the pull request since there's no comment there that
+	// the command comes from.
+	prComment := fmt.Sprintf("Running `%s` from Slack on behalf of @%s.", comment, user.Username)
+	if err := s.VCSClient.CreateComment(logger, baseRepo, pullNum, prComment, ""); err != nil {
+		logger.Warn("unable to comment on pull request: %s", err)
+	}
+
+	run := func() {
+		s.CommandRunner.RunCommentCommand(baseRepo, nil, nil, user, pullNum, parseResult.Command)
+		s.postResults(logger, slashCmd.ResponseURL, comment, pullRef, baseRepo, pullNum)
+	}
+	if s.TestingMode {
+		run()
+	} else {
+		// Slack requires a response within 3 seconds so we run the command
+		// asynchronously and post the results to the response URL.
+		go run()
+	}
+
+	return &slack.WebhookMessage{
+		ResponseType: slack.ResponseTypeInChannel,
+		Text:         fmt.Sprintf("Running `%s` on `%s` for <@%s>. The output will be commented on the pull request.", comment, pullRef, slashCmd.UserID),
+	}
+}
+
+// postResults posts the status of the projects in the pull request to the
+// channel the command came from.
+func (s *SlackController) postResults(logger logging.SimpleLogging, responseURL string, comment string, pullRef string, baseRepo models.Repo, pullNum int) {
+	if responseURL == "" {
+		return
+	}
+	text := fmt.Sprintf("Finished `%s` on `%s`.", comment, pullRef)
+	status, err := s.PullStatusFetcher.GetPullStatus(models.PullRequest{Num: pullNum, BaseRepo: baseRepo})
+	if err != nil {
+		logger.Warn("unable to get pull status: %s", err)
+	}
+	if status != nil && len(status.Projects) > 0 {
+		var b strings.Builder
+		b.WriteString(text)
+		for _, p := range status.Projects {
+			fmt.Fprintf(&b, "\n• dir: `%s` workspace: `%s`", p.RepoRelDir, p.Workspace)
+			if p.ProjectName != "" {
+				fmt.Fprintf(&b, " project: `%s`", p.ProjectName)
+			}
+			fmt.Fprintf(&b, " – %s", strings.ReplaceAll(p.Status.String(), "_", " "))
+		}
+		text = b.String()
+	}
+	postResponse := s.PostResponse
+	if postResponse == nil {
+		postResponse = slack.PostWebhook
+	}
+	if err := postResponse(responseURL, &slack.WebhookMessage{ResponseType: slack.ResponseTypeInChannel, Text: text}); err != nil {
+		logger.Warn("unable to post results to Slack: %s", err)
+	}
+}
+
+// parseRepoSpec parses `[vcs:]owner/repo#123`. The VCS can be omitted if
+// Atlantis only supports one.
+func (s *SlackController) parseRepoSpec(spec string) (models.VCSHostType, string, int, error) {
+	var vcsHostType models.VCSHostType
+	if host, rest, ok := strings.Cut(spec, ":"); ok {
+		t, ok := s.supportedHost(host)
+		if !ok {
+			return vcsHostType, "", 0, fmt.Errorf("this server isn't configured to support %q", host)
+		}
+		vcsHostType, spec = t, rest
+	} else if len(s.SupportedVCSHosts) == 1 {
+		vcsHostType = s.SupportedVCSHosts[0]
+	} else {
+		return vcsHostType, "", 0, fmt.Errorf("the repo must be prefixed with its VCS since Atlantis supports more than one, ex. `github:%s`", spec)
+	}
+
+	repoFullName, num, ok := strings.Cut(spec, "#")
+	if !ok || repoFullName == "" || !strings.Contains(repoFullName, "/") {
+		return vcsHostType, "", 0, fmt.Errorf("`%s` isn't a pull request", spec)
+	}
+	pullNum, err := strconv.Atoi(num)
+	if err != nil || pullNum <= 0 {
+		return vcsHostType, "", 0, fmt.Errorf("`%s` isn't a pull request number", num)
+	}
+	return vcsHostType, repoFullName, pullNum, nil
+}
+
+// supportedHost returns the supported VCS host whose name is host, ignoring
+// case, ex. github or GitHub.
+func (s *SlackController) supportedHost(host string) (models.VCSHostType, bool) {
+	for _, supported := range s.SupportedVCSHosts {
+		if strings.EqualFold(host, supported.String()) {
+			return supported, true
+		}
+	}
+	return -1, false
+}
+
+// splitSlashCommandText splits `plan owner/repo#123 -p project` into the
+// command name, the pull request and the rest of the text, which is kept as
+// is so that quoted flags survive.
+func splitSlashCommandText(text string) (name string, repoSpec string, flags string, ok bool) {
+	text = strings.TrimSpace(text)
+	name, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+	repoSpec, flags, _ = strings.Cut(rest, " ")
+	if name == "" || repoSpec == "" {
+		return "", "", "", false
+	}
+	return name, repoSpec, strings.TrimSpace(flags), true
+}
+
+func ephemeral(text string) *slack.WebhookMessage {
+	return &slack.WebhookMessage{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+	}
+}
+
+// respondToSlack responds to the slash command with msg. Slack shows it in
+// the channel the command came from.
+func (s *SlackController) respondToSlack(w http.ResponseWriter, msg *slack.WebhookMessage) {
+	response, err := json.Marshal(msg)
+	if err != nil {
+		s.respond(w, logging.Error, http.StatusInternalServerError, "%s", errors.Wrap(err, "marshalling Slack response"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(response) // nolint: errcheck
+}
+
+func (s *SlackController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	s.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
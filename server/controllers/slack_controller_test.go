@@ -0,0 +1,231 @@
+package controllers_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/slack-go/slack"
+)
+
+const slackSigningSecret = "slack-secret"
+
+type stubPullStatusFetcher struct {
+	status *models.PullStatus
+}
+
+func (s stubPullStatusFetcher) GetPullStatus(_ models.PullRequest) (*models.PullStatus, error) {
+	return s.status, nil
+}
+
+func TestSlackController_Post(t *testing.T) {
+	baseRepo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost:  models.VCSHost{Type: models.Github, Hostname: "github.com"},
+	}
+	cases := []struct {
+		description       string
+		supportedVCSHosts []models.VCSHostType
+		users             map[string]string
+		userID            string
+		text              string
+		expResponseType   string
+		expText           string
+		expComment        string
+	}{
+		{
+			description:     "runs the command as the mapped user",
+			userID:          "U012AB3CD",
+			text:            "plan runatlantis/atlantis#123 -p project",
+			expResponseType: slack.ResponseTypeInChannel,
+			expText:         "Running `atlantis plan -p project` on `runatlantis/atlantis#123` for <@U012AB3CD>.",
+			expComment:      "atlantis plan -p project",
+		},
+		{
+			description:       "vcs prefix picks the host",
+			supportedVCSHosts: []models.VCSHostType{models.Github, models.Gitlab},
+			userID:            "U012AB3CD",
+			text:              "plan github:runatlantis/atlantis#123",
+			expResponseType:   slack.ResponseTypeInChannel,
+			expText:           "Running `atlantis plan` on `runatlantis/atlantis#123`",
+			expComment:        "atlantis plan",
+		},
+		{
+			description:       "vcs prefix is required with more than one host",
+			supportedVCSHosts: []models.VCSHostType{models.Github, models.Gitlab},
+			userID:            "U012AB3CD",
+			text:              "plan runatlantis/atlantis#123",
+			expResponseType:   slack.ResponseTypeEphemeral,
+			expText:           "the repo must be prefixed with its VCS",
+		},
+		{
+			description:     "unmapped users are rejected",
+			userID:          "U999",
+			text:            "plan runatlantis/atlantis#123",
+			expResponseType: slack.ResponseTypeEphemeral,
+			expText:         "You aren't allowed to run Atlantis commands from Slack",
+		},
+		{
+			description:     "users aren't matched by username",
+			users:           map[string]string{"slack-user": "alice"},
+			userID:          "U999",
+			text:            "plan runatlantis/atlantis#123",
+			expResponseType: slack.ResponseTypeEphemeral,
+			expText:         "You aren't allowed to run Atlantis commands from Slack",
+		},
+		{
+			description:     "missing pull request",
+			userID:          "U012AB3CD",
+			text:            "plan",
+			expResponseType: slack.ResponseTypeEphemeral,
+			expText:         "Usage:",
+		},
+		{
+			description:     "invalid pull request number",
+			userID:          "U012AB3CD",
+			text:            "plan runatlantis/atlantis#abc",
+			expResponseType: slack.ResponseTypeEphemeral,
+			expText:         "`abc` isn't a pull request number",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			sc, commandRunner, commentParser, vcsClient := setupSlackController(t, baseRepo)
+			if c.supportedVCSHosts != nil {
+				sc.SupportedVCSHosts = c.supportedVCSHosts
+			}
+			if c.users != nil {
+				sc.Users = c.users
+			}
+			var posted []*slack.WebhookMessage
+			sc.PostResponse = func(url string, msg *slack.WebhookMessage) error {
+				Equals(t, "https://hooks.slack.com/commands/1", url)
+				posted = append(posted, msg)
+				return nil
+			}
+			cmd := &events.CommentCommand{Name: command.Plan}
+			When(commentParser.Parse(Any[string](), Eq(models.Github))).ThenReturn(events.CommentParseResult{Command: cmd})
+
+			w := httptest.NewRecorder()
+			sc.Post(w, slackRequest(t, c.userID, c.text, time.Now()))
+			Equals(t, http.StatusOK, w.Result().StatusCode)
+			var msg slack.WebhookMessage
+			Ok(t, json.Unmarshal(w.Body.Bytes(), &msg))
+			Equals(t, c.expResponseType, msg.ResponseType)
+			Assert(t, strings.Contains(msg.Text, c.expText), "expected %q to contain %q", msg.Text, c.expText)
+
+			if c.expComment == "" {
+				commandRunner.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), AnyInt(), Any[*events.CommentCommand]())
+				Equals(t, 0, len(posted))
+				return
+			}
+			commentParser.VerifyWasCalledOnce().Parse(c.expComment, models.Github)
+			commandRunner.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, models.User{Username: "alice"}, 123, cmd)
+			vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(baseRepo), Eq(123), Eq(fmt.Sprintf("Running `%s` from Slack on behalf of @alice.", c.expComment)), Eq(""))
+			Equals(t, 1, len(posted))
+			Equals(t, slack.ResponseTypeInChannel, posted[0].ResponseType)
+			Equals(t, fmt.Sprintf("Finished `%s` on `runatlantis/atlantis#123`.\n• dir: `.` workspace: `default` project: `project` – planned", c.expComment), posted[0].Text)
+		})
+	}
+}
+
+func TestSlackController_Post_Disabled(t *testing.T) {
+	RegisterMockTestingT(t)
+	sc, _, _, _ := setupSlackController(t, models.Repo{})
+	sc.SigningSecret = ""
+	w := httptest.NewRecorder()
+	sc.Post(w, slackRequest(t, "U012AB3CD", "plan runatlantis/atlantis#123", time.Now()))
+	ResponseContains(t, w, http.StatusBadRequest, "Ignoring request since Slack commands are disabled")
+}
+
+func TestSlackController_Post_InvalidSignature(t *testing.T) {
+	RegisterMockTestingT(t)
+	sc, commandRunner, _, _ := setupSlackController(t, models.Repo{})
+
+	req := slackRequest(t, "U012AB3CD", "plan runatlantis/atlantis#123", time.Now())
+	req.Header.Set("X-Slack-Signature", "v0=deadbeef")
+	w := httptest.NewRecorder()
+	sc.Post(w, req)
+	ResponseContains(t, w, http.StatusUnauthorized, "Failed to verify Slack request")
+
+	// Old requests are rejected to prevent replays.
+	w = httptest.NewRecorder()
+	sc.Post(w, slackRequest(t, "U012AB3CD", "plan runatlantis/atlantis#123", time.Now().Add(-time.Hour)))
+	ResponseContains(t, w, http.StatusUnauthorized, "Failed to verify Slack request")
+
+	commandRunner.VerifyWasCalled(Never()).RunCommentCommand(Any[models.Repo](), Any[*models.Repo](), Any[*models.PullRequest](), Any[models.User](), AnyInt(), Any[*events.CommentCommand]())
+}
+
+func setupSlackController(t *testing.T, baseRepo models.Repo) (*controllers.SlackController, *MockCommandRunner, *MockCommentParsing, *MockClient) {
+	commandRunner := NewMockCommandRunner()
+	commentParser := NewMockCommentParsing()
+	parser := NewMockEventParsing()
+	vcsClient := NewMockClient()
+	repoAllowlistChecker, err := events.NewRepoAllowlistChecker("*")
+	Ok(t, err)
+	When(vcsClient.GetCloneURL(Any[logging.SimpleLogging](), Eq(models.Github), Eq("runatlantis/atlantis"))).ThenReturn("https://github.com/runatlantis/atlantis.git", nil)
+	When(parser.ParseAPIPlanRequest(models.Github, "runatlantis/atlantis", "https://github.com/runatlantis/atlantis.git")).ThenReturn(baseRepo, nil)
+
+	return &controllers.SlackController{
+		SigningSecret:     slackSigningSecret,
+		Users:             map[string]string{"U012AB3CD": "alice"},
+		SupportedVCSHosts: []models.VCSHostType{models.Github},
+		ExecutableName:    "atlantis",
+		CommandRunner:     commandRunner,
+		CommentParser:     commentParser,
+		Parser:            parser,
+		PullStatusFetcher: stubPullStatusFetcher{status: &models.PullStatus{
+			Projects: []models.ProjectStatus{{
+				RepoRelDir:  ".",
+				Workspace:   "default",
+				ProjectName: "project",
+				Status:      models.PlannedPlanStatus,
+			}},
+		}},
+		RepoAllowlistChecker: repoAllowlistChecker,
+		VCSClient:            vcsClient,
+		Logger:               logging.NewNoopLogger(t),
+		TestingMode:          true,
+	}, commandRunner, commentParser, vcsClient
+}
+
+// slackRequest returns a slash command request signed like Slack does.
+func slackRequest(t *testing.T, userID string, text string, sentAt time.Time) *http.Request {
+	body := url.Values{
+		"command":      {"/atlantis"},
+		"user_id":      {userID},
+		"user_name":    {"slack-user"},
+		"text":         {text},
+		"response_url": {"https://hooks.slack.com/commands/1"},
+	}.Encode()
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(slackSigningSecret))
+	_, err := mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	Ok(t, err)
+
+	req, err := http.NewRequest("POST", "/slack/commands", strings.NewReader(body))
+	Ok(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
@@ -96,6 +96,22 @@ type LockDetailData struct {
 	// not using a path-based proxy, this will be an empty string. Never ends
 	// in a '/' (hence "cleaned").
 	CleanedBasePath string
+	// LastPlan and LastApply are the last plan and apply of the locked
+	// project, or nil if it hasn't been planned or applied at the head commit.
+	LastPlan  *LockDetailRun
+	LastApply *LockDetailRun
+}
+
+// LockDetailRun is who ran a plan or apply of a locked project, when and
+// how.
+type LockDetailRun struct {
+	User       string
+	Time       string
+	HeadCommit string
+	Trigger    string
+	// CommentURL is the link to the comment the command was run from, or
+	// empty.
+	CommentURL string
 }
 
 var LockTemplate = templates.Lookup(templateFileNames["lock"])
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// GlobalCfgReloader reloads the server-side repo config file so that changes
+// to it take effect without restarting Atlantis.
+type GlobalCfgReloader struct {
+	ParserValidator *ParserValidator
+	// Path is the server-side repo config file, ex. from --repo-config.
+	Path string
+	// DefaultCfgArgs are the args of the default config that the file is
+	// merged into.
+	DefaultCfgArgs valid.GlobalCfgArgs
+	// GlobalCfg is where the reloaded config is stored.
+	GlobalCfg *valid.ReloadableGlobalCfg
+	Logger    logging.SimpleLogging
+
+	mu sync.Mutex
+}
+
+// Reload parses and validates the config file and swaps it in. If it's
+// invalid, the current config is kept and an error is returned.
+func (r *GlobalCfgReloader) Reload() (valid.GlobalCfg, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newCfg, err := r.ParserValidator.ParseGlobalCfg(r.Path, valid.NewGlobalCfgFromArgs(r.DefaultCfgArgs))
+	if err != nil {
+		return valid.GlobalCfg{}, errors.Wrapf(err, "parsing %s file", r.Path)
+	}
+
+	// Metrics and team authz are only read on startup.
+	oldCfg := r.GlobalCfg.Load()
+	if !reflect.DeepEqual(oldCfg.Metrics, newCfg.Metrics) {
+		r.Logger.Warn("changes to metrics in %s require a restart to take effect", r.Path)
+	}
+	if !reflect.DeepEqual(oldCfg.TeamAuthz, newCfg.TeamAuthz) {
+		r.Logger.Warn("changes to team_authz in %s require a restart to take effect", r.Path)
+	}
+
+	r.GlobalCfg.Store(newCfg)
+	r.Logger.Info("reloaded %s", r.Path)
+	return newCfg, nil
+}
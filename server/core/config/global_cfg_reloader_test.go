@@ -0,0 +1,47 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGlobalCfgReloader_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.yaml")
+	Ok(t, os.WriteFile(path, []byte("repos:\n- id: github.com/owner/repo\n  allowed_overrides: [workflow]\n"), 0600))
+	parserValidator := &config.ParserValidator{}
+	initial, err := parserValidator.ParseGlobalCfg(path, valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}))
+	Ok(t, err)
+	reloadable := valid.NewReloadableGlobalCfg(initial)
+	reloader := &config.GlobalCfgReloader{
+		ParserValidator: parserValidator,
+		Path:            path,
+		GlobalCfg:       reloadable,
+		Logger:          logging.NewNoopLogger(t),
+	}
+	Equals(t, []string{"workflow"}, reloadable.Load().MatchingRepo("github.com/owner/repo").AllowedOverrides)
+
+	// Changes take effect.
+	Ok(t, os.WriteFile(path, []byte("repos:\n- id: github.com/owner/repo\n  allowed_overrides: [workflow, apply_requirements]\n"), 0600))
+	reloaded, err := reloader.Reload()
+	Ok(t, err)
+	Equals(t, []string{"workflow", "apply_requirements"}, reloaded.MatchingRepo("github.com/owner/repo").AllowedOverrides)
+	Equals(t, reloaded, reloadable.Load())
+
+	// Invalid configs are rejected and the current config is kept.
+	Ok(t, os.WriteFile(path, []byte("repos:\n- id: github.com/owner/repo\n  unknown_key: true\n"), 0600))
+	_, err = reloader.Reload()
+	ErrContains(t, "parsing "+path+" file", err)
+	Equals(t, reloaded, reloadable.Load())
+
+	// So is a missing config.
+	Ok(t, os.Remove(path))
+	_, err = reloader.Reload()
+	ErrContains(t, "unable to read", err)
+	Equals(t, reloaded, reloadable.Load())
+}
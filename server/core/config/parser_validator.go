@@ -20,7 +20,11 @@ import (
 
 // ParserValidator parses and validates server-side repo config files and
 // repo-level atlantis.yaml files.
-type ParserValidator struct{}
+type ParserValidator struct {
+	// NoSingleFileDownloadHosts are the hostnames of the VCS hosts that
+	// can't download single files, so the repos on them can't use config_repo.
+	NoSingleFileDownloadHosts []string
+}
 
 // HasRepoCfg returns true if there is a repo config (atlantis.yaml) file
 // for the repo at absRepoDir.
@@ -116,13 +120,18 @@ func (p *ParserValidator) ParseGlobalCfg(configFile string, defaultCfg valid.Glo
 	if len(configData) == 0 {
 		return valid.GlobalCfg{}, fmt.Errorf("file %s was empty", configFile)
 	}
+	return p.ParseGlobalCfgYAML(string(configData), defaultCfg)
+}
 
+// ParseGlobalCfgYAML parses a yaml string cfgYAML into global config.
+// defaultCfg will be merged into the parsed config.
+func (p *ParserValidator) ParseGlobalCfgYAML(cfgYAML string, defaultCfg valid.GlobalCfg) (valid.GlobalCfg, error) {
 	var rawCfg raw.GlobalCfg
 
-	decoder := yaml.NewDecoder(bytes.NewReader(configData))
+	decoder := yaml.NewDecoder(strings.NewReader(cfgYAML))
 	decoder.KnownFields(true)
 
-	err = decoder.Decode(&rawCfg)
+	err := decoder.Decode(&rawCfg)
 	if err != nil && !errors.Is(err, io.EOF) {
 		return valid.GlobalCfg{}, err
 	}
@@ -149,6 +158,9 @@ func (p *ParserValidator) validateRawGlobalCfg(rawCfg raw.GlobalCfg, defaultCfg
 	}
 
 	validCfg := rawCfg.ToValid(defaultCfg)
+	if err := validCfg.ValidateConfigRepoHosts(p.NoSingleFileDownloadHosts); err != nil {
+		return valid.GlobalCfg{}, err
+	}
 	return validCfg, nil
 }
 
@@ -157,16 +169,19 @@ func (p *ParserValidator) repoCfgPath(repoDir, cfgFilename string) string {
 }
 
 func (p *ParserValidator) validateProjectNames(config valid.RepoCfg) error {
-	// First, validate that all names are unique.
-	seen := make(map[string]bool)
+	// First, validate that all names are unique. The environments of a
+	// project share its name, and projects without environments can share a
+	// name if they're in different workspaces.
+	seen := make(map[string][]valid.Project)
 	for _, project := range config.Projects {
 		if project.Name != nil {
 			name := *project.Name
-			exists := seen[name]
-			if exists {
-				return fmt.Errorf("found two or more projects with name %q; project names must be unique", name)
+			for _, other := range seen[name] {
+				if projectNamesConflict(project, other) {
+					return fmt.Errorf("found two or more projects with name %q; project names must be unique unless the projects are in different workspaces", name)
+				}
 			}
-			seen[name] = true
+			seen[name] = append(seen[name], project)
 		}
 	}
 
@@ -193,6 +208,19 @@ func (p *ParserValidator) validateProjectNames(config valid.RepoCfg) error {
 	return nil
 }
 
+// projectNamesConflict returns true if the projects a and b, which have the
+// same name, can't be told apart.
+func projectNamesConflict(a valid.Project, b valid.Project) bool {
+	switch {
+	case a.Environment == "" && b.Environment == "":
+		return a.Workspace == b.Workspace
+	case a.Environment != "" && b.Environment != "":
+		return a.Environment == b.Environment
+	default:
+		return true
+	}
+}
+
 // applyLegacyShellParsing changes any custom run commands in cfg to use the old
 // parsing method with shlex.Split().
 func (p *ParserValidator) applyLegacyShellParsing(cfg *valid.RepoCfg) error {
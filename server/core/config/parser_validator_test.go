@@ -170,8 +170,9 @@ workflows:
 								},
 							},
 						},
-						Import:  valid.DefaultImportStage,
-						StateRm: valid.DefaultStateRmStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 			},
@@ -715,7 +716,7 @@ projects:
 - name: myname
   dir: .
   workspace: workspace`,
-			expErr: "found two or more projects with name \"myname\"; project names must be unique",
+			expErr: "found two or more projects with name \"myname\"; project names must be unique unless the projects are in different workspaces",
 		},
 		{
 			description: "two projects with same dir/workspace with different names",
@@ -753,6 +754,95 @@ projects:
 				Workflows: map[string]valid.Workflow{},
 			},
 		},
+		{
+			description: "project with environments",
+			input: `
+version: 3
+projects:
+- name: myname
+  dir: .
+  environments:
+  - name: staging
+    var_files: [env/staging.tfvars]
+  - name: production
+    workspace: prod`,
+			exp: valid.RepoCfg{
+				Version: 3,
+				Projects: []valid.Project{
+					{
+						Name:        String("myname"),
+						Dir:         ".",
+						Workspace:   "staging",
+						Environment: "staging",
+						VarFiles:    []string{"env/staging.tfvars"},
+						Autoplan: valid.Autoplan{
+							WhenModified: raw.DefaultAutoPlanWhenModified,
+							Enabled:      true,
+						},
+					},
+					{
+						Name:        String("myname"),
+						Dir:         ".",
+						Workspace:   "prod",
+						Environment: "production",
+						Autoplan: valid.Autoplan{
+							WhenModified: raw.DefaultAutoPlanWhenModified,
+							Enabled:      true,
+						},
+					},
+				},
+				Workflows: map[string]valid.Workflow{},
+			},
+		},
+		{
+			description: "two projects with the same name in different workspaces",
+			input: `
+version: 3
+projects:
+- name: myname
+  dir: .
+  workspace: staging
+- name: myname
+  dir: .
+  workspace: production`,
+			exp: valid.RepoCfg{
+				Version: 3,
+				Projects: []valid.Project{
+					{
+						Name:      String("myname"),
+						Dir:       ".",
+						Workspace: "staging",
+						Autoplan: valid.Autoplan{
+							WhenModified: raw.DefaultAutoPlanWhenModified,
+							Enabled:      true,
+						},
+					},
+					{
+						Name:      String("myname"),
+						Dir:       ".",
+						Workspace: "production",
+						Autoplan: valid.Autoplan{
+							WhenModified: raw.DefaultAutoPlanWhenModified,
+							Enabled:      true,
+						},
+					},
+				},
+				Workflows: map[string]valid.Workflow{},
+			},
+		},
+		{
+			description: "project with environments and another project with the same name",
+			input: `
+version: 3
+projects:
+- name: myname
+  dir: .
+  environments:
+  - name: staging
+- name: myname
+  dir: other`,
+			expErr: "found two or more projects with name \"myname\"; project names must be unique unless the projects are in different workspaces",
+		},
 		{
 			description: "if steps are set then we parse them properly",
 			input: `
@@ -839,6 +929,7 @@ workflows:
 								},
 							},
 						},
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 			},
@@ -942,6 +1033,7 @@ workflows:
 								},
 							},
 						},
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 			},
@@ -1025,6 +1117,7 @@ workflows:
 								},
 							},
 						},
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 			},
@@ -1123,6 +1216,7 @@ workflows:
 								},
 							},
 						},
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 			},
@@ -1258,6 +1352,7 @@ func TestParseGlobalCfg(t *testing.T) {
 				},
 			},
 		},
+		ForceUnlock: valid.DefaultForceUnlockStage,
 	}
 
 	conftestVersion, _ := version.NewVersion("v1.0.0")
@@ -1313,7 +1408,7 @@ func TestParseGlobalCfg(t *testing.T) {
 			input: `repos:
 - id: /.*/
   allowed_overrides: [invalid]`,
-			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"plan_requirements\", \"apply_requirements\", \"import_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"repo_locking\", \"repo_locks\", \"policy_check\", \"custom_policy_check\", and \"silence_pr_comments\" are supported.).).",
+			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"plan_requirements\", \"apply_requirements\", \"import_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"repo_locking\", \"repo_locks\", \"policy_check\", \"custom_policy_check\", \"silence_pr_comments\", \"cloud_credentials\", and \"engine\" are supported.).).",
 		},
 		"invalid plan_requirement": {
 			input: `repos:
@@ -1325,7 +1420,7 @@ func TestParseGlobalCfg(t *testing.T) {
 			input: `repos:
 - id: /.*/
   apply_requirements: [invalid]`,
-			expErr: "repos: (0: (apply_requirements: \"invalid\" is not a valid apply_requirement, only \"approved\", \"mergeable\" and \"undiverged\" are supported.).).",
+			expErr: "repos: (0: (apply_requirements: \"invalid\" is not a valid apply_requirement, only \"approved\", \"mergeable\", \"undiverged\" and \"codeowners_approved\" are supported.).).",
 		},
 		"invalid import_requirement": {
 			input: `repos:
@@ -1377,6 +1472,224 @@ func TestParseGlobalCfg(t *testing.T) {
 				},
 			},
 		},
+		"command permissions": {
+			input: `roles:
+  admins:
+    users: [alice]
+    teams: [platform]
+repos:
+- id: /.*/
+  command_permissions:
+    apply:
+      teams: [sre]
+      roles: [admins]
+    unlock:
+      users: [bob]`,
+			exp: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					defaultCfg.Repos[0],
+					{
+						IDRegex: regexp.MustCompile(".*"),
+						CommandPermissions: map[string]valid.CommandPermission{
+							"apply": {
+								Teams: []string{"sre"},
+								Roles: []string{"admins"},
+							},
+							"unlock": {
+								Users: []string{"bob"},
+							},
+						},
+					},
+				},
+				Workflows: defaultCfg.Workflows,
+				TeamAuthz: valid.TeamAuthz{
+					Args: make([]string, 0),
+				},
+				Roles: map[string]valid.Role{
+					"admins": {
+						Users: []string{"alice"},
+						Teams: []string{"platform"},
+					},
+				},
+			},
+		},
+		"invalid command_permissions command": {
+			input: `repos:
+- id: /.*/
+  command_permissions:
+    destroy:
+      users: [alice]`,
+			expErr: "repos: (0: (command_permissions: \"destroy\" is not a valid command, only plan, apply, unlock, approve_policies, version, import, state, force-unlock, cancel, workspaces, status, confirm, help are supported.).).",
+		},
+		"command_permissions without anyone allowed": {
+			input: `repos:
+- id: /.*/
+  command_permissions:
+    apply: {}`,
+			expErr: "repos: (0: (command_permissions: \"apply\" must list at least one user, team or role.).).",
+		},
+		"command_permissions role doesn't exist": {
+			input: `repos:
+- id: /.*/
+  command_permissions:
+    apply:
+      roles: [notdefined]`,
+			expErr: "role \"notdefined\" used by command_permissions \"apply\" is not defined",
+		},
+		"invalid restricted_plan_flags flag": {
+			input: `repos:
+- id: /.*/
+  restricted_plan_flags:
+    -lock: deny`,
+			expErr: "repos: (0: (restricted_plan_flags: \"-lock\" is not a restrictable flag, only -target, -destroy, -replace are supported.).).",
+		},
+		"invalid restricted_plan_flags restriction": {
+			input: `repos:
+- id: /.*/
+  restricted_plan_flags:
+    -target: never`,
+			expErr: "repos: (0: (restricted_plan_flags: \"-target\" must be \"deny\" or \"approved\", got \"never\".).).",
+		},
+		"invalid pr_comments command": {
+			input: `repos:
+- id: /.*/
+  pr_comments:
+    import: silent`,
+			expErr: "repos: (0: (pr_comments: \"import\" is not a supported command, only plan, apply, policy_check are supported.).).",
+		},
+		"invalid pr_comments mode": {
+			input: `repos:
+- id: /.*/
+  pr_comments:
+    plan: never`,
+			expErr: "repos: (0: (pr_comments: \"plan\" must be one of always, on_failure, status_only, silent, got \"never\".).).",
+		},
+		"invalid resource_limits": {
+			input: `repos:
+- id: /.*/
+  resource_limits:
+    memory: 4GB`,
+			expErr: "repos: (0: (resource_limits: (memory: \"4GB\" is not a valid memory limit, use a size like 4Gi or 512Mi.).).).",
+		},
+		"negative comment_digest_threshold": {
+			input: `repos:
+- id: /.*/
+  comment_digest_threshold: -1`,
+			expErr: "repos: (0: (comment_digest_threshold: must be no less than 0.).).",
+		},
+		"config_repo without owner": {
+			input: `repos:
+- id: /.*/
+  config_repo:
+    repo: atlantis-config
+    branch: main`,
+			expErr: "repos: (0: (config_repo: (repo: must be the full name of a repository, ex. myorg/atlantis-config.).).).",
+		},
+		"config_repo path without repo": {
+			input: `repos:
+- id: /.*/
+  config_repo:
+    repo: myorg/atlantis-config
+    branch: main
+    path: atlantis.yaml`,
+			expErr: "repos: (0: (config_repo: (path: must contain {repo} so each repo has its own config.).).).",
+		},
+		"empty role": {
+			input: `roles:
+  admins: {}`,
+			expErr: "roles: (admins: must list at least one user or team.).",
+		},
+		"fork_pr_workflow": {
+			input: `repos:
+- id: /.*/
+  fork_pr_workflow: readonly
+workflows:
+  readonly:
+    plan:
+      steps:
+      - env:
+          name: AWS_PROFILE
+          value: readonly
+      - init
+      - plan`,
+			exp: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					defaultCfg.Repos[0],
+					{
+						IDRegex: regexp.MustCompile(".*"),
+						ForkPRWorkflow: &valid.Workflow{
+							Name:        "readonly",
+							Apply:       valid.DefaultApplyStage,
+							PolicyCheck: valid.DefaultPolicyCheckStage,
+							Import:      valid.DefaultImportStage,
+							StateRm:     valid.DefaultStateRmStage,
+							ForceUnlock: valid.DefaultForceUnlockStage,
+							Plan: valid.Stage{
+								Steps: []valid.Step{
+									{
+										StepName:    "env",
+										EnvVarName:  "AWS_PROFILE",
+										EnvVarValue: "readonly",
+									},
+									{
+										StepName: "init",
+									},
+									{
+										StepName: "plan",
+									},
+								},
+							},
+						},
+					},
+				},
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+					"readonly": {
+						Name:        "readonly",
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+						ForceUnlock: valid.DefaultForceUnlockStage,
+						Plan: valid.Stage{
+							Steps: []valid.Step{
+								{
+									StepName:    "env",
+									EnvVarName:  "AWS_PROFILE",
+									EnvVarValue: "readonly",
+								},
+								{
+									StepName: "init",
+								},
+								{
+									StepName: "plan",
+								},
+							},
+						},
+					},
+				},
+				TeamAuthz: valid.TeamAuthz{
+					Args: make([]string, 0),
+				},
+			},
+		},
+		"fork_pr_workflow doesn't exist": {
+			input: `repos:
+- id: /.*/
+  fork_pr_workflow: notdefined`,
+			expErr: "workflow \"notdefined\" used by fork_pr_workflow is not defined",
+		},
+		"fork_pr_workflow with run step": {
+			input: `repos:
+- id: /.*/
+  fork_pr_workflow: custom
+workflows:
+  custom:
+    plan:
+      steps:
+      - run: echo hi`,
+			expErr: "workflow \"custom\" used by fork_pr_workflow can't contain run, multienv or env command steps",
+		},
 		"no workflows key": {
 			input: `repos: []`,
 			exp:   defaultCfg,
@@ -1613,6 +1926,8 @@ workflows:
       steps: []
     state_rm:
       steps: []
+    force_unlock:
+      steps: []
 `,
 			exp: valid.GlobalCfg{
 				Repos: []valid.Repo{
@@ -1644,6 +1959,9 @@ workflows:
 							StateRm: valid.Stage{
 								Steps: nil,
 							},
+							ForceUnlock: valid.Stage{
+								Steps: nil,
+							},
 						},
 						AllowedWorkflows:          []string{},
 						AllowedOverrides:          []string{},
@@ -1719,6 +2037,21 @@ workflows:
 	}
 }
 
+// Test that config_repo is rejected for repos on hosts that can't download
+// single files.
+func TestParseGlobalCfg_ConfigRepoNoSingleFileDownload(t *testing.T) {
+	r := config.ParserValidator{NoSingleFileDownloadHosts: []string{"bitbucket.org"}}
+	path := filepath.Join(t.TempDir(), "conf.yaml")
+	Ok(t, os.WriteFile(path, []byte(`repos:
+- id: /bitbucket.org/myorg/.*/
+  config_repo:
+    repo: myorg/atlantis-config
+    branch: main`), 0600))
+
+	_, err := r.ParseGlobalCfg(path, valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}))
+	ErrEquals(t, "repo /bitbucket.org/myorg/.*/: config_repo can't be used for repos on bitbucket.org since it doesn't support downloading single files", err)
+}
+
 // Test that if we pass in JSON strings everything should parse fine.
 func TestParserValidator_ParseGlobalCfgJSON(t *testing.T) {
 	customWorkflow := valid.Workflow{
@@ -1773,6 +2106,7 @@ func TestParserValidator_ParseGlobalCfgJSON(t *testing.T) {
 				},
 			},
 		},
+		ForceUnlock: valid.DefaultForceUnlockStage,
 	}
 
 	conftestVersion, _ := version.NewVersion("v1.0.0")
@@ -2002,5 +2336,6 @@ func defaultWorkflow(name string) valid.Workflow {
 		PolicyCheck: valid.DefaultPolicyCheckStage,
 		Import:      valid.DefaultImportStage,
 		StateRm:     valid.DefaultStateRmStage,
+		ForceUnlock: valid.DefaultForceUnlockStage,
 	}
 }
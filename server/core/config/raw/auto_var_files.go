@@ -0,0 +1,47 @@
+package raw
+
+import (
+	"text/template"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// DefaultAutoVarFilesTemplate is the var file template used if auto_var_files
+// is enabled without a template.
+const DefaultAutoVarFilesTemplate = "{{ .Workspace }}.tfvars"
+
+type AutoVarFiles struct {
+	Enabled  *bool   `yaml:"enabled,omitempty"`
+	Template *string `yaml:"template,omitempty"`
+}
+
+func (a AutoVarFiles) ToValid() valid.AutoVarFiles {
+	var v valid.AutoVarFiles
+
+	// Setting the key at all means the user wants it on.
+	v.Enabled = a.Enabled == nil || *a.Enabled
+
+	if a.Template == nil || *a.Template == "" {
+		v.Template = DefaultAutoVarFilesTemplate
+	} else {
+		v.Template = *a.Template
+	}
+
+	return v
+}
+
+func (a AutoVarFiles) Validate() error {
+	templateValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		_, err := template.New("auto_var_files").Option("missingkey=error").Parse(*strPtr)
+		return err
+	}
+
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.Template, validation.By(templateValid)),
+	)
+}
@@ -0,0 +1,70 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAutoVarFiles_UnmarshalYAML(t *testing.T) {
+	var a raw.AutoVarFiles
+	err := unmarshalString(`
+enabled: true
+template: vars/{{ .Workspace }}.tfvars
+`, &a)
+	Ok(t, err)
+	Equals(t, raw.AutoVarFiles{
+		Enabled:  Bool(true),
+		Template: String("vars/{{ .Workspace }}.tfvars"),
+	}, a)
+}
+
+func TestAutoVarFiles_Validate(t *testing.T) {
+	Ok(t, raw.AutoVarFiles{}.Validate())
+	Ok(t, raw.AutoVarFiles{Template: String("{{ .ProjectName }}/{{ .Workspace }}.tfvars")}.Validate())
+	ErrContains(t, "template", raw.AutoVarFiles{Template: String("{{ .Workspace ")}.Validate())
+}
+
+func TestAutoVarFiles_ToValid(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.AutoVarFiles
+		exp         valid.AutoVarFiles
+	}{
+		{
+			description: "nothing set",
+			input:       raw.AutoVarFiles{},
+			exp: valid.AutoVarFiles{
+				Enabled:  true,
+				Template: raw.DefaultAutoVarFilesTemplate,
+			},
+		},
+		{
+			description: "disabled",
+			input: raw.AutoVarFiles{
+				Enabled: Bool(false),
+			},
+			exp: valid.AutoVarFiles{
+				Enabled:  false,
+				Template: raw.DefaultAutoVarFilesTemplate,
+			},
+		},
+		{
+			description: "template set",
+			input: raw.AutoVarFiles{
+				Template: String("env/{{ .Workspace }}.tfvars"),
+			},
+			exp: valid.AutoVarFiles{
+				Enabled:  true,
+				Template: "env/{{ .Workspace }}.tfvars",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, c.input.ToValid())
+		})
+	}
+}
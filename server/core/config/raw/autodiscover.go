@@ -3,6 +3,8 @@ package raw
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -12,9 +14,15 @@ import (
 
 var DefaultAutoDiscoverMode = valid.AutoDiscoverAutoMode
 
+// WorkspaceCaptureGroup is the named capture group a workspace pattern must
+// contain, ex. `envs/(?P<workspace>\w+)/`.
+const WorkspaceCaptureGroup = "workspace"
+
 type AutoDiscover struct {
-	Mode        *valid.AutoDiscoverMode `yaml:"mode,omitempty"`
-	IgnorePaths []string                `yaml:"ignore_paths,omitempty"`
+	Mode              *valid.AutoDiscoverMode `yaml:"mode,omitempty"`
+	IgnorePaths       []string                `yaml:"ignore_paths,omitempty"`
+	WorkspacePatterns []string                `yaml:"workspace_patterns,omitempty"`
+	CDKTF             bool                    `yaml:"cdktf,omitempty"`
 }
 
 func (a AutoDiscover) ToValid() *valid.AutoDiscover {
@@ -27,6 +35,11 @@ func (a AutoDiscover) ToValid() *valid.AutoDiscover {
 	}
 
 	v.IgnorePaths = a.IgnorePaths
+	v.CDKTF = a.CDKTF
+
+	for _, pattern := range a.WorkspacePatterns {
+		v.WorkspacePatterns = append(v.WorkspacePatterns, regexp.MustCompile(pattern))
+	}
 
 	return &v
 }
@@ -53,10 +66,24 @@ func (a AutoDiscover) Validate() error {
 		return nil
 	}
 
+	workspacePatternsValid := func(value interface{}) error {
+		for _, pattern := range value.([]string) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid regex: %s", pattern)
+			}
+			if !slices.Contains(re.SubexpNames(), WorkspaceCaptureGroup) {
+				return fmt.Errorf("pattern %s must contain a named capture group (?P<%s>...)", pattern, WorkspaceCaptureGroup)
+			}
+		}
+		return nil
+	}
+
 	res := validation.ValidateStruct(&a,
 		// If a.Mode is nil, this should still pass validation.
 		validation.Field(&a.Mode, validation.In(valid.AutoDiscoverAutoMode, valid.AutoDiscoverDisabledMode, valid.AutoDiscoverEnabledMode)),
 		validation.Field(&a.IgnorePaths, validation.By(ignoreValid)),
+		validation.Field(&a.WorkspacePatterns, validation.By(workspacePatternsValid)),
 	)
 	return res
 }
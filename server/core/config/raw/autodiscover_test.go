@@ -29,10 +29,15 @@ func TestAutoDiscover_UnmarshalYAML(t *testing.T) {
 mode: enabled
 ignore_paths:
   - foobar
+workspace_patterns:
+  - envs/(?P<workspace>\w+)/
+cdktf: true
 `,
 			exp: raw.AutoDiscover{
-				Mode:        &autoDiscoverEnabled,
-				IgnorePaths: []string{"foobar"},
+				Mode:              &autoDiscoverEnabled,
+				IgnorePaths:       []string{"foobar"},
+				WorkspacePatterns: []string{`envs/(?P<workspace>\w+)/`},
+				CDKTF:             true,
 			},
 		},
 	}
@@ -151,6 +156,27 @@ func TestAutoDiscover_Validate(t *testing.T) {
 			},
 			errContains: String("invalid pattern: foo["),
 		},
+		{
+			description: "workspace pattern with capture group",
+			input: raw.AutoDiscover{
+				WorkspacePatterns: []string{`envs/(?P<workspace>\w+)/`},
+			},
+			errContains: nil,
+		},
+		{
+			description: "workspace pattern without capture group",
+			input: raw.AutoDiscover{
+				WorkspacePatterns: []string{`envs/(\w+)/`},
+			},
+			errContains: String("must contain a named capture group (?P<workspace>...)"),
+		},
+		{
+			description: "workspace pattern with invalid regex",
+			input: raw.AutoDiscover{
+				WorkspacePatterns: []string{`envs/(?P<workspace>\w+/`},
+			},
+			errContains: String("invalid regex"),
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -186,6 +212,7 @@ func TestAutoDiscover_ToValid(t *testing.T) {
 					"foo",
 					"bar/*",
 				},
+				CDKTF: true,
 			},
 			exp: &valid.AutoDiscover{
 				Mode: valid.AutoDiscoverEnabledMode,
@@ -193,6 +220,7 @@ func TestAutoDiscover_ToValid(t *testing.T) {
 					"foo",
 					"bar/*",
 				},
+				CDKTF: true,
 			},
 		},
 	}
@@ -1,6 +1,12 @@
 package raw
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 )
 
@@ -15,6 +21,8 @@ var DefaultAutoPlanWhenModified = []string{
 type Autoplan struct {
 	WhenModified []string `yaml:"when_modified,omitempty"`
 	Enabled      *bool    `yaml:"enabled,omitempty"`
+	Mode         *string  `yaml:"mode,omitempty"`
+	Labels       []string `yaml:"labels,omitempty"`
 }
 
 func (a Autoplan) ToValid() valid.Autoplan {
@@ -31,11 +39,43 @@ func (a Autoplan) ToValid() valid.Autoplan {
 		v.Enabled = *a.Enabled
 	}
 
+	if a.Mode != nil {
+		v.Mode = *a.Mode
+	}
+	v.Labels = a.Labels
+
 	return v
 }
 
 func (a Autoplan) Validate() error {
-	return nil
+	modeValid := func(value interface{}) error {
+		mode := value.(*string)
+		if mode == nil {
+			return nil
+		}
+		if !slices.Contains(valid.AutoplanModes, *mode) {
+			return fmt.Errorf("%q is not a valid mode, must be one of %s", *mode, strings.Join(valid.AutoplanModes, ", "))
+		}
+		return nil
+	}
+	labelsValid := func(value interface{}) error {
+		labels := value.([]string)
+		labeled := a.Mode != nil && *a.Mode == valid.AutoplanModeLabeled
+		if labeled && len(labels) == 0 {
+			return fmt.Errorf("must be set if mode is %q", valid.AutoplanModeLabeled)
+		}
+		if !labeled && len(labels) > 0 {
+			return fmt.Errorf("can only be set if mode is %q", valid.AutoplanModeLabeled)
+		}
+		if slices.Contains(labels, "") {
+			return errors.New("cannot contain an empty label")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.Mode, validation.By(modeValid)),
+		validation.Field(&a.Labels, validation.By(labelsValid)),
+	)
 }
 
 // DefaultAutoPlan returns the default autoplan config.
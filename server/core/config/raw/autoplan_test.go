@@ -89,6 +89,19 @@ func TestAutoplan_Validate(t *testing.T) {
 				Enabled: Bool(false),
 			},
 		},
+		{
+			description: "mode set",
+			input: raw.Autoplan{
+				Mode: String("changed_only_with_lockfile"),
+			},
+		},
+		{
+			description: "labeled mode with labels",
+			input: raw.Autoplan{
+				Mode:   String("labeled"),
+				Labels: []string{"plan"},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -97,6 +110,41 @@ func TestAutoplan_Validate(t *testing.T) {
 	}
 }
 
+func TestAutoplan_ValidateErrors(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.Autoplan
+		expErr      string
+	}{
+		{
+			description: "invalid mode",
+			input: raw.Autoplan{
+				Mode: String("always"),
+			},
+			expErr: "Mode: \"always\" is not a valid mode, must be one of changed, changed_only_with_lockfile, ready_for_review, labeled.",
+		},
+		{
+			description: "labeled mode without labels",
+			input: raw.Autoplan{
+				Mode: String("labeled"),
+			},
+			expErr: "Labels: must be set if mode is \"labeled\".",
+		},
+		{
+			description: "labels without labeled mode",
+			input: raw.Autoplan{
+				Labels: []string{"plan"},
+			},
+			expErr: "Labels: can only be set if mode is \"labeled\".",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			ErrEquals(t, c.expErr, c.input.Validate())
+		})
+	}
+}
+
 func TestAutoplan_ToValid(t *testing.T) {
 	cases := []struct {
 		description string
@@ -141,6 +189,19 @@ func TestAutoplan_ToValid(t *testing.T) {
 				WhenModified: raw.DefaultAutoPlanWhenModified,
 			},
 		},
+		{
+			description: "labeled mode",
+			input: raw.Autoplan{
+				Mode:   String("labeled"),
+				Labels: []string{"plan"},
+			},
+			exp: valid.Autoplan{
+				Enabled:      true,
+				WhenModified: raw.DefaultAutoPlanWhenModified,
+				Mode:         "labeled",
+				Labels:       []string{"plan"},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -0,0 +1,69 @@
+package raw
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// validCheckoutArgs checks the keys of a checkout step.
+func validCheckoutArgs(args map[string]interface{}) error {
+	var keys []string
+	for k := range args {
+		keys = append(keys, k)
+	}
+	// Sort so tests can be deterministic.
+	sort.Strings(keys)
+	supported := []string{RepoArgKey, RefArgKey, DirArgKey, CredentialsArgKey, TokenEnvArgKey}
+	for _, k := range keys {
+		if !utils.SlicesContains(supported, k) {
+			return fmt.Errorf("checkout steps only support keys %q, %q, %q, %q and %q, found key %q",
+				RepoArgKey, RefArgKey, DirArgKey, CredentialsArgKey, TokenEnvArgKey, k)
+		}
+		if _, ok := args[k].(string); !ok {
+			return fmt.Errorf("checkout step %q option must be a string, found %v", k, args[k])
+		}
+	}
+	if args[RepoArgKey] == nil {
+		return fmt.Errorf("checkout steps must have a %q key set", RepoArgKey)
+	}
+	dir, ok := args[DirArgKey].(string)
+	if !ok {
+		return fmt.Errorf("checkout steps must have a %q key set", DirArgKey)
+	}
+	if !filepath.IsLocal(dir) {
+		return fmt.Errorf("checkout step %q option must be a relative path inside the workspace, found %q", DirArgKey, dir)
+	}
+	// The dir is deleted before each checkout, so it can't be the workspace.
+	if filepath.Clean(dir) == "." {
+		return fmt.Errorf("checkout step %q option must be a subdirectory of the workspace, found %q", DirArgKey, dir)
+	}
+	if creds, ok := args[CredentialsArgKey].(string); ok {
+		if !utils.SlicesContains(valid.CheckoutCredentials, creds) {
+			return fmt.Errorf("checkout step %q option must be one of %s, found %q",
+				CredentialsArgKey, strings.Join(valid.CheckoutCredentials, ", "), creds)
+		}
+		if args[TokenEnvArgKey] != nil {
+			return fmt.Errorf("checkout steps only support one of the %q or %q keys, found both",
+				CredentialsArgKey, TokenEnvArgKey)
+		}
+	}
+	return nil
+}
+
+// checkoutToValid converts the keys of a valid checkout step.
+func checkoutToValid(args map[string]interface{}) *valid.Checkout {
+	checkout := &valid.Checkout{Credentials: valid.VCSCheckoutCredentials}
+	checkout.Repo, _ = args[RepoArgKey].(string)
+	checkout.Ref, _ = args[RefArgKey].(string)
+	checkout.Dir, _ = args[DirArgKey].(string)
+	if creds, ok := args[CredentialsArgKey].(string); ok {
+		checkout.Credentials = creds
+	}
+	checkout.TokenEnv, _ = args[TokenEnvArgKey].(string)
+	return checkout
+}
@@ -0,0 +1,132 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// CloudCredentials are the cloud credentials Atlantis mints for a project's
+// steps.
+type CloudCredentials struct {
+	AWS *AWSCredentials `yaml:"aws,omitempty"`
+	GCP *GCPCredentials `yaml:"gcp,omitempty"`
+}
+
+// AWSCredentials is the AWS role Atlantis assumes for a project's steps.
+type AWSCredentials struct {
+	RoleARN  *string `yaml:"role_arn,omitempty"`
+	Region   *string `yaml:"region,omitempty"`
+	Duration *string `yaml:"duration,omitempty"`
+}
+
+// GCPCredentials is the workload identity provider and service account
+// Atlantis impersonates for a project's steps.
+type GCPCredentials struct {
+	WorkloadIdentityProvider *string `yaml:"workload_identity_provider,omitempty"`
+	ServiceAccount           *string `yaml:"service_account,omitempty"`
+}
+
+var (
+	awsRoleARNRegex               = regexp.MustCompile(`^arn:aws[a-z-]*:iam::[0-9]{12}:role/[\w+=,.@/-]+$`)
+	gcpWorkloadIdentityProviderRe = regexp.MustCompile(`^projects/[0-9]+/locations/global/workloadIdentityPools/[a-z0-9-]+/providers/[a-z0-9-]+$`)
+	gcpServiceAccountRegex        = regexp.MustCompile(`^[a-z0-9-]+@[a-z0-9-]+\.iam\.gserviceaccount\.com$`)
+)
+
+// AWS limits the duration of role sessions to between 15 minutes and 12 hours.
+const (
+	minAWSSessionDuration = 15 * time.Minute
+	maxAWSSessionDuration = 12 * time.Hour
+)
+
+func (c CloudCredentials) Validate() error {
+	if c.AWS == nil && c.GCP == nil {
+		return errors.New("must set aws or gcp")
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.AWS),
+		validation.Field(&c.GCP),
+	)
+}
+
+func (c CloudCredentials) ToValid() *valid.CloudCredentials {
+	var v valid.CloudCredentials
+	if c.AWS != nil {
+		v.AWS = &valid.AWSCredentials{RoleARN: *c.AWS.RoleARN}
+		if c.AWS.Region != nil {
+			v.AWS.Region = *c.AWS.Region
+		}
+		if c.AWS.Duration != nil {
+			// Validate() already checked the duration can be parsed.
+			v.AWS.Duration, _ = time.ParseDuration(*c.AWS.Duration)
+		}
+	}
+	if c.GCP != nil {
+		v.GCP = &valid.GCPCredentials{
+			WorkloadIdentityProvider: *c.GCP.WorkloadIdentityProvider,
+			ServiceAccount:           *c.GCP.ServiceAccount,
+		}
+	}
+	return &v
+}
+
+func (a AWSCredentials) Validate() error {
+	roleARNValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		if !awsRoleARNRegex.MatchString(*strPtr) {
+			return fmt.Errorf("%q is not an IAM role ARN, ex. arn:aws:iam::123456789012:role/atlantis", *strPtr)
+		}
+		return nil
+	}
+	durationValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		d, err := time.ParseDuration(*strPtr)
+		if err != nil || d < minAWSSessionDuration || d > maxAWSSessionDuration {
+			return fmt.Errorf("%q is not a valid duration, use a duration between %s and %s like 1h", *strPtr, minAWSSessionDuration, maxAWSSessionDuration)
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.RoleARN, validation.Required, validation.By(roleARNValid)),
+		validation.Field(&a.Duration, validation.By(durationValid)),
+	)
+}
+
+func (g GCPCredentials) Validate() error {
+	providerValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		if !gcpWorkloadIdentityProviderRe.MatchString(*strPtr) {
+			return fmt.Errorf("%q is not a workload identity provider, ex. projects/123456789/locations/global/workloadIdentityPools/atlantis/providers/atlantis", *strPtr)
+		}
+		return nil
+	}
+	serviceAccountValid := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		if !gcpServiceAccountRegex.MatchString(*strPtr) {
+			return fmt.Errorf("%q is not a service account email, ex. terraform@my-project.iam.gserviceaccount.com", *strPtr)
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(&g,
+		validation.Field(&g.WorkloadIdentityProvider, validation.Required, validation.By(providerValid)),
+		validation.Field(&g.ServiceAccount, validation.Required, validation.By(serviceAccountValid)),
+	)
+}
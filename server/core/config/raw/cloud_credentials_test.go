@@ -0,0 +1,74 @@
+package raw_test
+
+import (
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCloudCredentials_UnmarshalYAML(t *testing.T) {
+	var c raw.CloudCredentials
+	Ok(t, unmarshalString(`
+aws:
+  role_arn: arn:aws:iam::123456789012:role/atlantis
+  region: us-east-1
+  duration: 1h
+gcp:
+  workload_identity_provider: projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis
+  service_account: terraform@my-project.iam.gserviceaccount.com
+`, &c))
+	Equals(t, raw.CloudCredentials{
+		AWS: &raw.AWSCredentials{
+			RoleARN:  String("arn:aws:iam::123456789012:role/atlantis"),
+			Region:   String("us-east-1"),
+			Duration: String("1h"),
+		},
+		GCP: &raw.GCPCredentials{
+			WorkloadIdentityProvider: String("projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis"),
+			ServiceAccount:           String("terraform@my-project.iam.gserviceaccount.com"),
+		},
+	}, c)
+}
+
+func TestCloudCredentials_Validate(t *testing.T) {
+	validation.ErrorTag = "yaml"
+	provider := String("projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis")
+	serviceAccount := String("terraform@my-project.iam.gserviceaccount.com")
+
+	Ok(t, raw.CloudCredentials{AWS: &raw.AWSCredentials{RoleARN: String("arn:aws:iam::123456789012:role/path/atlantis")}}.Validate())
+	Ok(t, raw.CloudCredentials{AWS: &raw.AWSCredentials{RoleARN: String("arn:aws-us-gov:iam::123456789012:role/atlantis"), Duration: String("12h")}}.Validate())
+	Ok(t, raw.CloudCredentials{GCP: &raw.GCPCredentials{WorkloadIdentityProvider: provider, ServiceAccount: serviceAccount}}.Validate())
+	ErrEquals(t, "must set aws or gcp", raw.CloudCredentials{}.Validate())
+	ErrEquals(t, "aws: (role_arn: cannot be blank.).", raw.CloudCredentials{AWS: &raw.AWSCredentials{}}.Validate())
+	ErrEquals(t, "aws: (role_arn: \"arn:aws:iam::123456789012:user/atlantis\" is not an IAM role ARN, ex. arn:aws:iam::123456789012:role/atlantis.).",
+		raw.CloudCredentials{AWS: &raw.AWSCredentials{RoleARN: String("arn:aws:iam::123456789012:user/atlantis")}}.Validate())
+	ErrEquals(t, "aws: (duration: \"5m\" is not a valid duration, use a duration between 15m0s and 12h0m0s like 1h.).",
+		raw.CloudCredentials{AWS: &raw.AWSCredentials{RoleARN: String("arn:aws:iam::123456789012:role/atlantis"), Duration: String("5m")}}.Validate())
+	ErrEquals(t, "gcp: (service_account: cannot be blank.).", raw.CloudCredentials{GCP: &raw.GCPCredentials{WorkloadIdentityProvider: provider}}.Validate())
+	ErrEquals(t, "gcp: (workload_identity_provider: \"atlantis\" is not a workload identity provider, ex. projects/123456789/locations/global/workloadIdentityPools/atlantis/providers/atlantis.).",
+		raw.CloudCredentials{GCP: &raw.GCPCredentials{WorkloadIdentityProvider: String("atlantis"), ServiceAccount: serviceAccount}}.Validate())
+}
+
+func TestCloudCredentials_ToValid(t *testing.T) {
+	Equals(t, &valid.CloudCredentials{
+		AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"},
+	}, raw.CloudCredentials{AWS: &raw.AWSCredentials{RoleARN: String("arn:aws:iam::123456789012:role/atlantis")}}.ToValid())
+
+	Equals(t, &valid.CloudCredentials{
+		AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis", Region: "eu-west-1", Duration: 2 * time.Hour},
+		GCP: &valid.GCPCredentials{
+			WorkloadIdentityProvider: "projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis",
+			ServiceAccount:           "terraform@my-project.iam.gserviceaccount.com",
+		},
+	}, raw.CloudCredentials{
+		AWS: &raw.AWSCredentials{RoleARN: String("arn:aws:iam::123456789012:role/atlantis"), Region: String("eu-west-1"), Duration: String("2h")},
+		GCP: &raw.GCPCredentials{
+			WorkloadIdentityProvider: String("projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis"),
+			ServiceAccount:           String("terraform@my-project.iam.gserviceaccount.com"),
+		},
+	}.ToValid())
+}
@@ -0,0 +1,63 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// Role is the raw schema for a named group of users and teams in the
+// server-side repo config.
+type Role struct {
+	Users []string `yaml:"users,omitempty" json:"users,omitempty"`
+	Teams []string `yaml:"teams,omitempty" json:"teams,omitempty"`
+}
+
+func (r Role) Validate() error {
+	if len(r.Users) == 0 && len(r.Teams) == 0 {
+		return errors.New("must list at least one user or team")
+	}
+	return nil
+}
+
+func (r Role) ToValid() valid.Role {
+	return valid.Role{
+		Users: r.Users,
+		Teams: r.Teams,
+	}
+}
+
+// CommandPermission is the raw schema for who may run a single comment
+// command on the repos a server-side repo config entry matches.
+type CommandPermission struct {
+	Users []string `yaml:"users,omitempty" json:"users,omitempty"`
+	Teams []string `yaml:"teams,omitempty" json:"teams,omitempty"`
+	Roles []string `yaml:"roles,omitempty" json:"roles,omitempty"`
+}
+
+func (p CommandPermission) ToValid() valid.CommandPermission {
+	return valid.CommandPermission{
+		Users: p.Users,
+		Teams: p.Teams,
+		Roles: p.Roles,
+	}
+}
+
+// validCommandPermissions checks the keys of a command_permissions map. Role
+// references are checked by GlobalCfg.Validate since roles are defined at the
+// top level.
+func validCommandPermissions(value interface{}) error {
+	perms := value.(map[string]CommandPermission)
+	for cmd, perm := range perms {
+		if !utils.SlicesContains(valid.CommandPermissionCommands, cmd) {
+			return fmt.Errorf("%q is not a valid command, only %s are supported", cmd, strings.Join(valid.CommandPermissionCommands, ", "))
+		}
+		if len(perm.Users) == 0 && len(perm.Teams) == 0 && len(perm.Roles) == 0 {
+			return fmt.Errorf("%q must list at least one user, team or role", cmd)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ConfigRepo is a central repository that the repo configs of repos are read
+// from.
+type ConfigRepo struct {
+	Repo   string  `yaml:"repo" json:"repo"`
+	Branch string  `yaml:"branch" json:"branch"`
+	Path   *string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Authoritative keeps repos from falling back to their own repo config.
+	Authoritative bool `yaml:"authoritative,omitempty" json:"authoritative,omitempty"`
+}
+
+func (c ConfigRepo) Validate() error {
+	fullName := func(value interface{}) error {
+		repo := value.(string)
+		if !strings.Contains(strings.Trim(repo, "/"), "/") {
+			return errors.New("must be the full name of a repository, ex. myorg/atlantis-config")
+		}
+		return nil
+	}
+	pathValid := func(value interface{}) error {
+		path := value.(*string)
+		if path != nil && !strings.Contains(*path, valid.ConfigRepoRepoPlaceholder) {
+			return errors.New("must contain " + valid.ConfigRepoRepoPlaceholder + " so each repo has its own config")
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Repo, validation.Required, validation.By(fullName)),
+		validation.Field(&c.Branch, validation.Required),
+		validation.Field(&c.Path, validation.By(pathValid)),
+	)
+}
+
+func (c ConfigRepo) ToValid() *valid.ConfigRepo {
+	path := valid.DefaultConfigRepoPath
+	if c.Path != nil {
+		path = *c.Path
+	}
+	return &valid.ConfigRepo{
+		Repo:          strings.Trim(c.Repo, "/"),
+		Branch:        c.Branch,
+		Path:          path,
+		Authoritative: c.Authoritative,
+	}
+}
@@ -0,0 +1,56 @@
+package raw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Environment is one of the environments a project is deployed to. Each
+// environment is planned and applied separately in its own workspace.
+type Environment struct {
+	Name      *string  `yaml:"name,omitempty"`
+	Workspace *string  `yaml:"workspace,omitempty"`
+	VarFiles  []string `yaml:"var_files,omitempty"`
+	// CloudCredentials override the cloud credentials of the project.
+	CloudCredentials *CloudCredentials `yaml:"cloud_credentials,omitempty"`
+}
+
+func (e Environment) Validate() error {
+	validName := func(value interface{}) error {
+		strPtr := value.(*string)
+		if strPtr == nil {
+			return nil
+		}
+		if !validProjectName(*strPtr) {
+			return fmt.Errorf("%q is not allowed: must contain only URL safe characters", *strPtr)
+		}
+		return nil
+	}
+
+	varFilesValid := func(value interface{}) error {
+		for _, f := range value.([]string) {
+			if strings.Contains(f, "..") {
+				return errors.New("cannot contain '..'")
+			}
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(&e,
+		validation.Field(&e.Name, validation.Required, validation.By(validName)),
+		validation.Field(&e.VarFiles, validation.By(varFilesValid)),
+		validation.Field(&e.CloudCredentials),
+	)
+}
+
+// GetWorkspace returns the workspace of the environment, which defaults to
+// its name.
+func (e Environment) GetWorkspace() string {
+	if e.Workspace == nil || *e.Workspace == "" {
+		return *e.Name
+	}
+	return *e.Workspace
+}
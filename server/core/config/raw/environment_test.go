@@ -0,0 +1,39 @@
+package raw_test
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestEnvironment_UnmarshalYAML(t *testing.T) {
+	var e raw.Environment
+	err := unmarshalString(`
+name: staging
+workspace: stg
+var_files:
+- env/staging.tfvars
+`, &e)
+	Ok(t, err)
+	Equals(t, raw.Environment{
+		Name:      String("staging"),
+		Workspace: String("stg"),
+		VarFiles:  []string{"env/staging.tfvars"},
+	}, e)
+}
+
+func TestEnvironment_Validate(t *testing.T) {
+	validation.ErrorTag = "yaml"
+	Ok(t, raw.Environment{Name: String("staging")}.Validate())
+	Ok(t, raw.Environment{Name: String("staging"), VarFiles: []string{"env/staging.tfvars"}}.Validate())
+	ErrEquals(t, "name: cannot be blank.", raw.Environment{}.Validate())
+	ErrEquals(t, "name: \"my env\" is not allowed: must contain only URL safe characters.", raw.Environment{Name: String("my env")}.Validate())
+	ErrEquals(t, "var_files: cannot contain '..'.", raw.Environment{Name: String("staging"), VarFiles: []string{"../staging.tfvars"}}.Validate())
+}
+
+func TestEnvironment_GetWorkspace(t *testing.T) {
+	Equals(t, "staging", raw.Environment{Name: String("staging")}.GetWorkspace())
+	Equals(t, "stg", raw.Environment{Name: String("staging"), Workspace: String("stg")}.GetWorkspace())
+}
@@ -18,29 +18,40 @@ type GlobalCfg struct {
 	PolicySets PolicySets          `yaml:"policies" json:"policies"`
 	Metrics    Metrics             `yaml:"metrics" json:"metrics"`
 	TeamAuthz  TeamAuthz           `yaml:"team_authz" json:"team_authz"`
+	Roles      map[string]Role     `yaml:"roles,omitempty" json:"roles,omitempty"`
 }
 
 // Repo is the raw schema for repos in the server-side repo config.
 type Repo struct {
-	ID                        string         `yaml:"id" json:"id"`
-	Branch                    string         `yaml:"branch" json:"branch"`
-	RepoConfigFile            string         `yaml:"repo_config_file" json:"repo_config_file"`
-	PlanRequirements          []string       `yaml:"plan_requirements" json:"plan_requirements"`
-	ApplyRequirements         []string       `yaml:"apply_requirements" json:"apply_requirements"`
-	ImportRequirements        []string       `yaml:"import_requirements" json:"import_requirements"`
-	PreWorkflowHooks          []WorkflowHook `yaml:"pre_workflow_hooks" json:"pre_workflow_hooks"`
-	Workflow                  *string        `yaml:"workflow,omitempty" json:"workflow,omitempty"`
-	PostWorkflowHooks         []WorkflowHook `yaml:"post_workflow_hooks" json:"post_workflow_hooks"`
-	AllowedWorkflows          []string       `yaml:"allowed_workflows,omitempty" json:"allowed_workflows,omitempty"`
-	AllowedOverrides          []string       `yaml:"allowed_overrides" json:"allowed_overrides"`
-	AllowCustomWorkflows      *bool          `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
-	DeleteSourceBranchOnMerge *bool          `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
-	RepoLocking               *bool          `yaml:"repo_locking,omitempty" json:"repo_locking,omitempty"`
-	RepoLocks                 *RepoLocks     `yaml:"repo_locks,omitempty" json:"repo_locks,omitempty"`
-	PolicyCheck               *bool          `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
-	CustomPolicyCheck         *bool          `yaml:"custom_policy_check,omitempty" json:"custom_policy_check,omitempty"`
-	AutoDiscover              *AutoDiscover  `yaml:"autodiscover,omitempty" json:"autodiscover,omitempty"`
-	SilencePRComments         []string       `yaml:"silence_pr_comments,omitempty" json:"silence_pr_comments,omitempty"`
+	ID                        string                       `yaml:"id" json:"id"`
+	Branch                    string                       `yaml:"branch" json:"branch"`
+	RepoConfigFile            string                       `yaml:"repo_config_file" json:"repo_config_file"`
+	PlanRequirements          []string                     `yaml:"plan_requirements" json:"plan_requirements"`
+	ApplyRequirements         []string                     `yaml:"apply_requirements" json:"apply_requirements"`
+	ImportRequirements        []string                     `yaml:"import_requirements" json:"import_requirements"`
+	PreWorkflowHooks          []WorkflowHook               `yaml:"pre_workflow_hooks" json:"pre_workflow_hooks"`
+	Workflow                  *string                      `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	PostWorkflowHooks         []WorkflowHook               `yaml:"post_workflow_hooks" json:"post_workflow_hooks"`
+	AllowedWorkflows          []string                     `yaml:"allowed_workflows,omitempty" json:"allowed_workflows,omitempty"`
+	AllowedOverrides          []string                     `yaml:"allowed_overrides" json:"allowed_overrides"`
+	AllowCustomWorkflows      *bool                        `yaml:"allow_custom_workflows,omitempty" json:"allow_custom_workflows,omitempty"`
+	DeleteSourceBranchOnMerge *bool                        `yaml:"delete_source_branch_on_merge,omitempty" json:"delete_source_branch_on_merge,omitempty"`
+	RepoLocking               *bool                        `yaml:"repo_locking,omitempty" json:"repo_locking,omitempty"`
+	RepoLocks                 *RepoLocks                   `yaml:"repo_locks,omitempty" json:"repo_locks,omitempty"`
+	PolicyCheck               *bool                        `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
+	CustomPolicyCheck         *bool                        `yaml:"custom_policy_check,omitempty" json:"custom_policy_check,omitempty"`
+	AutoDiscover              *AutoDiscover                `yaml:"autodiscover,omitempty" json:"autodiscover,omitempty"`
+	SilencePRComments         []string                     `yaml:"silence_pr_comments,omitempty" json:"silence_pr_comments,omitempty"`
+	CommandPermissions        map[string]CommandPermission `yaml:"command_permissions,omitempty" json:"command_permissions,omitempty"`
+	ForkPRWorkflow            *string                      `yaml:"fork_pr_workflow,omitempty" json:"fork_pr_workflow,omitempty"`
+	RestrictedPlanFlags       map[string]string            `yaml:"restricted_plan_flags,omitempty" json:"restricted_plan_flags,omitempty"`
+	PRComments                map[string]string            `yaml:"pr_comments,omitempty" json:"pr_comments,omitempty"`
+	ResourceLimits            *ResourceLimits              `yaml:"resource_limits,omitempty" json:"resource_limits,omitempty"`
+	PlanDrafts                *bool                        `yaml:"plan_drafts,omitempty" json:"plan_drafts,omitempty"`
+	ApplyConfirmation         *bool                        `yaml:"apply_confirmation,omitempty" json:"apply_confirmation,omitempty"`
+	OutdatedDependencies      *bool                        `yaml:"outdated_dependencies,omitempty" json:"outdated_dependencies,omitempty"`
+	CommentDigestThreshold    *int                         `yaml:"comment_digest_threshold,omitempty" json:"comment_digest_threshold,omitempty"`
+	ConfigRepo                *ConfigRepo                  `yaml:"config_repo,omitempty" json:"config_repo,omitempty"`
 }
 
 func (g GlobalCfg) Validate() error {
@@ -48,11 +59,23 @@ func (g GlobalCfg) Validate() error {
 		validation.Field(&g.Repos),
 		validation.Field(&g.Workflows),
 		validation.Field(&g.Metrics),
+		validation.Field(&g.Roles),
 	)
 	if err != nil {
 		return err
 	}
 
+	// Check that all roles referenced by command permissions are defined.
+	for _, repo := range g.Repos {
+		for cmd, perm := range repo.CommandPermissions {
+			for _, role := range perm.Roles {
+				if _, ok := g.Roles[role]; !ok {
+					return fmt.Errorf("role %q used by %s %q is not defined", role, valid.CommandPermissionsKey, cmd)
+				}
+			}
+		}
+	}
+
 	// Check that all workflows referenced by repos are actually defined.
 	for _, repo := range g.Repos {
 		if repo.Workflow == nil {
@@ -98,6 +121,26 @@ func (g GlobalCfg) Validate() error {
 		}
 	}
 
+	// Check that fork PR workflows are defined and can't run arbitrary
+	// commands from the fork.
+	for _, repo := range g.Repos {
+		if repo.ForkPRWorkflow == nil {
+			continue
+		}
+		name := *repo.ForkPRWorkflow
+		w, ok := g.Workflows[name]
+		if !ok {
+			if name == valid.DefaultWorkflowName {
+				// The built-in 'default' workflow doesn't run custom commands.
+				continue
+			}
+			return fmt.Errorf("workflow %q used by %s is not defined", name, valid.ForkPRWorkflowKey)
+		}
+		if w.ToValid(name).RunsCustomCommands() {
+			return fmt.Errorf("workflow %q used by %s can't contain run, multienv or env command steps", name, valid.ForkPRWorkflowKey)
+		}
+	}
+
 	// Validate supported SilencePRComments values.
 	for _, repo := range g.Repos {
 		if repo.SilencePRComments == nil {
@@ -157,12 +200,21 @@ func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
 	}
 	repos = append(defaultCfg.Repos, repos...)
 
+	var roles map[string]valid.Role
+	if len(g.Roles) > 0 {
+		roles = make(map[string]valid.Role)
+		for name, role := range g.Roles {
+			roles[name] = role.ToValid()
+		}
+	}
+
 	return valid.GlobalCfg{
 		Repos:      repos,
 		Workflows:  workflows,
 		PolicySets: g.PolicySets.ToValid(),
 		Metrics:    g.Metrics.ToValid(),
 		TeamAuthz:  g.TeamAuthz.ToValid(),
+		Roles:      roles,
 	}
 }
 
@@ -223,8 +275,8 @@ func (r Repo) Validate() error {
 	overridesValid := func(value interface{}) error {
 		overrides := value.([]string)
 		for _, o := range overrides {
-			if o != valid.PlanRequirementsKey && o != valid.ApplyRequirementsKey && o != valid.ImportRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.RepoLockingKey && o != valid.RepoLocksKey && o != valid.PolicyCheckKey && o != valid.CustomPolicyCheckKey && o != valid.SilencePRCommentsKey {
-				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q, %q, %q, %q, %q, %q, and %q are supported", o, valid.PlanRequirementsKey, valid.ApplyRequirementsKey, valid.ImportRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.RepoLockingKey, valid.RepoLocksKey, valid.PolicyCheckKey, valid.CustomPolicyCheckKey, valid.SilencePRCommentsKey)
+			if o != valid.PlanRequirementsKey && o != valid.ApplyRequirementsKey && o != valid.ImportRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.RepoLockingKey && o != valid.RepoLocksKey && o != valid.PolicyCheckKey && o != valid.CustomPolicyCheckKey && o != valid.SilencePRCommentsKey && o != valid.CloudCredentialsKey && o != valid.EngineKey {
+				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q, %q, %q, %q, %q, %q, %q, %q, and %q are supported", o, valid.PlanRequirementsKey, valid.ApplyRequirementsKey, valid.ImportRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.RepoLockingKey, valid.RepoLocksKey, valid.PolicyCheckKey, valid.CustomPolicyCheckKey, valid.SilencePRCommentsKey, valid.CloudCredentialsKey, valid.EngineKey)
 			}
 		}
 		return nil
@@ -257,6 +309,22 @@ func (r Repo) Validate() error {
 		return nil
 	}
 
+	configRepoValid := func(value interface{}) error {
+		configRepo := value.(*ConfigRepo)
+		if configRepo != nil {
+			return configRepo.Validate()
+		}
+		return nil
+	}
+
+	resourceLimitsValid := func(value interface{}) error {
+		resourceLimits := value.(*ResourceLimits)
+		if resourceLimits != nil {
+			return resourceLimits.Validate()
+		}
+		return nil
+	}
+
 	return validation.ValidateStruct(&r,
 		validation.Field(&r.ID, validation.Required, validation.By(idValid)),
 		validation.Field(&r.Branch, validation.By(branchValid)),
@@ -269,6 +337,12 @@ func (r Repo) Validate() error {
 		validation.Field(&r.DeleteSourceBranchOnMerge, validation.By(deleteSourceBranchOnMergeValid)),
 		validation.Field(&r.AutoDiscover, validation.By(autoDiscoverValid)),
 		validation.Field(&r.RepoLocks, validation.By(repoLocksValid)),
+		validation.Field(&r.CommandPermissions, validation.By(validCommandPermissions)),
+		validation.Field(&r.RestrictedPlanFlags, validation.By(validRestrictedPlanFlags)),
+		validation.Field(&r.PRComments, validation.By(validPRComments)),
+		validation.Field(&r.ResourceLimits, validation.By(resourceLimitsValid)),
+		validation.Field(&r.CommentDigestThreshold, validation.Min(0)),
+		validation.Field(&r.ConfigRepo, validation.By(configRepoValid)),
 	)
 }
 
@@ -373,6 +447,32 @@ OuterGlobalImportReqs:
 		repoLocks = r.RepoLocks.ToValid()
 	}
 
+	var forkPRWorkflow *valid.Workflow
+	if r.ForkPRWorkflow != nil {
+		// This key is guaranteed to exist because we test for it in
+		// GlobalCfg.Validate.
+		ptr := workflows[*r.ForkPRWorkflow]
+		forkPRWorkflow = &ptr
+	}
+
+	var configRepo *valid.ConfigRepo
+	if r.ConfigRepo != nil {
+		configRepo = r.ConfigRepo.ToValid()
+	}
+
+	var resourceLimits *valid.ResourceLimits
+	if r.ResourceLimits != nil {
+		resourceLimits = r.ResourceLimits.ToValid()
+	}
+
+	var commandPermissions map[string]valid.CommandPermission
+	if len(r.CommandPermissions) > 0 {
+		commandPermissions = make(map[string]valid.CommandPermission)
+		for cmd, perm := range r.CommandPermissions {
+			commandPermissions[cmd] = perm.ToValid()
+		}
+	}
+
 	return valid.Repo{
 		ID:                        id,
 		IDRegex:                   idRegex,
@@ -394,5 +494,15 @@ OuterGlobalImportReqs:
 		CustomPolicyCheck:         r.CustomPolicyCheck,
 		AutoDiscover:              autoDiscover,
 		SilencePRComments:         r.SilencePRComments,
+		CommandPermissions:        commandPermissions,
+		ForkPRWorkflow:            forkPRWorkflow,
+		RestrictedPlanFlags:       r.RestrictedPlanFlags,
+		PRComments:                r.PRComments,
+		ResourceLimits:            resourceLimits,
+		PlanDrafts:                r.PlanDrafts,
+		ApplyConfirmation:         r.ApplyConfirmation,
+		OutdatedDependencies:      r.OutdatedDependencies,
+		CommentDigestThreshold:    r.CommentDigestThreshold,
+		ConfigRepo:                configRepo,
 	}
 }
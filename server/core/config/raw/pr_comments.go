@@ -0,0 +1,23 @@
+package raw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// validPRComments checks the commands and modes of a pr_comments map.
+func validPRComments(value interface{}) error {
+	modes := value.(map[string]string)
+	for cmd, mode := range modes {
+		if !utils.SlicesContains(valid.PRCommentCommands, cmd) {
+			return fmt.Errorf("%q is not a supported command, only %s are supported", cmd, strings.Join(valid.PRCommentCommands, ", "))
+		}
+		if !utils.SlicesContains(valid.PRCommentModes, mode) {
+			return fmt.Errorf("%q must be one of %s, got %q", cmd, strings.Join(valid.PRCommentModes, ", "), mode)
+		}
+	}
+	return nil
+}
@@ -18,28 +18,37 @@ const (
 	ApprovedRequirement   = "approved"
 	MergeableRequirement  = "mergeable"
 	UnDivergedRequirement = "undiverged"
+	// CodeOwnersApprovedRequirement requires the owners of the project's
+	// modified files to approve the pull request. It's only an apply
+	// requirement.
+	CodeOwnersApprovedRequirement = "codeowners_approved"
 )
 
 type Project struct {
-	Name                      *string    `yaml:"name,omitempty"`
-	Branch                    *string    `yaml:"branch,omitempty"`
-	Dir                       *string    `yaml:"dir,omitempty"`
-	Workspace                 *string    `yaml:"workspace,omitempty"`
-	Workflow                  *string    `yaml:"workflow,omitempty"`
-	TerraformDistribution     *string    `yaml:"terraform_distribution,omitempty"`
-	TerraformVersion          *string    `yaml:"terraform_version,omitempty"`
-	Autoplan                  *Autoplan  `yaml:"autoplan,omitempty"`
-	PlanRequirements          []string   `yaml:"plan_requirements,omitempty"`
-	ApplyRequirements         []string   `yaml:"apply_requirements,omitempty"`
-	ImportRequirements        []string   `yaml:"import_requirements,omitempty"`
-	DependsOn                 []string   `yaml:"depends_on,omitempty"`
-	DeleteSourceBranchOnMerge *bool      `yaml:"delete_source_branch_on_merge,omitempty"`
-	RepoLocking               *bool      `yaml:"repo_locking,omitempty"`
-	RepoLocks                 *RepoLocks `yaml:"repo_locks,omitempty"`
-	ExecutionOrderGroup       *int       `yaml:"execution_order_group,omitempty"`
-	PolicyCheck               *bool      `yaml:"policy_check,omitempty"`
-	CustomPolicyCheck         *bool      `yaml:"custom_policy_check,omitempty"`
-	SilencePRComments         []string   `yaml:"silence_pr_comments,omitempty"`
+	Name                      *string           `yaml:"name,omitempty"`
+	Branch                    *string           `yaml:"branch,omitempty"`
+	Dir                       *string           `yaml:"dir,omitempty"`
+	Workspace                 *string           `yaml:"workspace,omitempty"`
+	Workflow                  *string           `yaml:"workflow,omitempty"`
+	TerraformDistribution     *string           `yaml:"terraform_distribution,omitempty"`
+	TerraformVersion          *string           `yaml:"terraform_version,omitempty"`
+	Engine                    *string           `yaml:"engine,omitempty"`
+	Autoplan                  *Autoplan         `yaml:"autoplan,omitempty"`
+	PlanRequirements          []string          `yaml:"plan_requirements,omitempty"`
+	ApplyRequirements         []string          `yaml:"apply_requirements,omitempty"`
+	ImportRequirements        []string          `yaml:"import_requirements,omitempty"`
+	DependsOn                 []string          `yaml:"depends_on,omitempty"`
+	DeleteSourceBranchOnMerge *bool             `yaml:"delete_source_branch_on_merge,omitempty"`
+	RepoLocking               *bool             `yaml:"repo_locking,omitempty"`
+	RepoLocks                 *RepoLocks        `yaml:"repo_locks,omitempty"`
+	ExecutionOrderGroup       *int              `yaml:"execution_order_group,omitempty"`
+	PolicyCheck               *bool             `yaml:"policy_check,omitempty"`
+	CustomPolicyCheck         *bool             `yaml:"custom_policy_check,omitempty"`
+	SilencePRComments         []string          `yaml:"silence_pr_comments,omitempty"`
+	AutoVarFiles              *AutoVarFiles     `yaml:"auto_var_files,omitempty"`
+	Environments              []Environment     `yaml:"environments,omitempty"`
+	ShowOutputs               []string          `yaml:"show_outputs,omitempty"`
+	CloudCredentials          *CloudCredentials `yaml:"cloud_credentials,omitempty"`
 }
 
 func (p Project) Validate() error {
@@ -85,6 +94,35 @@ func (p Project) Validate() error {
 		return nil
 	}
 
+	environmentsValid := func(value interface{}) error {
+		environments := value.([]Environment)
+		if len(environments) == 0 {
+			return nil
+		}
+		if p.Name == nil {
+			return errors.New("projects with environments must have a name")
+		}
+		if p.Workspace != nil {
+			return errors.New("cannot be used with workspace, set the workspace of each environment instead")
+		}
+		names := make(map[string]bool)
+		workspaces := make(map[string]bool)
+		for _, e := range environments {
+			if e.Name == nil {
+				continue
+			}
+			if names[*e.Name] {
+				return fmt.Errorf("found two or more environments with name %q", *e.Name)
+			}
+			names[*e.Name] = true
+			if workspaces[e.GetWorkspace()] {
+				return fmt.Errorf("found two or more environments with workspace %q", e.GetWorkspace())
+			}
+			workspaces[e.GetWorkspace()] = true
+		}
+		return nil
+	}
+
 	return validation.ValidateStruct(&p,
 		validation.Field(&p.Dir, validation.Required, validation.By(hasDotDot)),
 		validation.Field(&p.PlanRequirements, validation.By(validPlanReq)),
@@ -92,9 +130,13 @@ func (p Project) Validate() error {
 		validation.Field(&p.ImportRequirements, validation.By(validImportReq)),
 		validation.Field(&p.TerraformDistribution, validation.By(validDistribution)),
 		validation.Field(&p.TerraformVersion, validation.By(VersionValidator)),
+		validation.Field(&p.Engine, validation.By(validEngine)),
 		validation.Field(&p.DependsOn, validation.By(DependsOn)),
 		validation.Field(&p.Name, validation.By(validName)),
 		validation.Field(&p.Branch, validation.By(branchValid)),
+		validation.Field(&p.Environments, validation.By(environmentsValid)),
+		validation.Field(&p.Autoplan),
+		validation.Field(&p.CloudCredentials),
 	)
 }
 
@@ -126,6 +168,9 @@ func (p Project) ToValid() valid.Project {
 	if p.TerraformDistribution != nil {
 		v.TerraformDistribution = p.TerraformDistribution
 	}
+	if p.Engine != nil {
+		v.Engine = *p.Engine
+	}
 	if p.Autoplan == nil {
 		v.Autoplan = DefaultAutoPlan()
 	} else {
@@ -169,9 +214,41 @@ func (p Project) ToValid() valid.Project {
 		v.SilencePRComments = p.SilencePRComments
 	}
 
+	if p.AutoVarFiles != nil {
+		v.AutoVarFiles = p.AutoVarFiles.ToValid()
+	}
+
+	if p.ShowOutputs != nil {
+		v.ShowOutputs = p.ShowOutputs
+	}
+
+	if p.CloudCredentials != nil {
+		v.CloudCredentials = p.CloudCredentials.ToValid()
+	}
+
 	return v
 }
 
+// ToValidProjects returns the valid project, or one valid project per
+// environment if the project has environments.
+func (p Project) ToValidProjects() []valid.Project {
+	if len(p.Environments) == 0 {
+		return []valid.Project{p.ToValid()}
+	}
+	var projects []valid.Project
+	for _, e := range p.Environments {
+		v := p.ToValid()
+		v.Environment = *e.Name
+		v.Workspace = e.GetWorkspace()
+		v.VarFiles = e.VarFiles
+		if e.CloudCredentials != nil {
+			v.CloudCredentials = e.CloudCredentials.ToValid()
+		}
+		projects = append(projects, v)
+	}
+	return projects
+}
+
 // validProjectName returns true if the project name is valid.
 // Since the name might be used in URLs and definitely in files we don't
 // support any characters that must be url escaped *except* for '/' because
@@ -194,8 +271,8 @@ func validPlanReq(value interface{}) error {
 func validApplyReq(value interface{}) error {
 	reqs := value.([]string)
 	for _, r := range reqs {
-		if r != ApprovedRequirement && r != MergeableRequirement && r != UnDivergedRequirement {
-			return fmt.Errorf("%q is not a valid apply_requirement, only %q, %q and %q are supported", r, ApprovedRequirement, MergeableRequirement, UnDivergedRequirement)
+		if r != ApprovedRequirement && r != MergeableRequirement && r != UnDivergedRequirement && r != CodeOwnersApprovedRequirement {
+			return fmt.Errorf("%q is not a valid apply_requirement, only %q, %q, %q and %q are supported", r, ApprovedRequirement, MergeableRequirement, UnDivergedRequirement, CodeOwnersApprovedRequirement)
 		}
 	}
 	return nil
@@ -218,3 +295,11 @@ func validDistribution(value interface{}) error {
 	}
 	return nil
 }
+
+func validEngine(value interface{}) error {
+	engine := value.(*string)
+	if engine != nil && *engine != valid.TerraformEngine && *engine != valid.PulumiEngine && *engine != valid.CheckEngine {
+		return fmt.Errorf("'%s' is not a valid engine, only '%s', '%s' and '%s' are supported", *engine, valid.TerraformEngine, valid.PulumiEngine, valid.CheckEngine)
+	}
+	return nil
+}
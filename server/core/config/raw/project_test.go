@@ -169,7 +169,7 @@ func TestProject_Validate(t *testing.T) {
 				Dir:               String("."),
 				ApplyRequirements: []string{"unsupported"},
 			},
-			expErr: "apply_requirements: \"unsupported\" is not a valid apply_requirement, only \"approved\", \"mergeable\" and \"undiverged\" are supported.",
+			expErr: "apply_requirements: \"unsupported\" is not a valid apply_requirement, only \"approved\", \"mergeable\", \"undiverged\" and \"codeowners_approved\" are supported.",
 		},
 		{
 			description: "apply reqs with approved requirement",
@@ -267,6 +267,22 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			description: "pulumi engine",
+			input: raw.Project{
+				Dir:    String("."),
+				Engine: String("pulumi"),
+			},
+			expErr: "",
+		},
+		{
+			description: "unsupported engine",
+			input: raw.Project{
+				Dir:    String("."),
+				Engine: String("cdk"),
+			},
+			expErr: "engine: 'cdk' is not a valid engine, only 'terraform', 'pulumi' and 'check' are supported.",
+		},
 		{
 			description: "empty string for project name",
 			input: raw.Project{
@@ -315,6 +331,60 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: `name: "namewith\\" is not allowed: must contain only URL safe characters.`,
 		},
+		{
+			description: "environments",
+			input: raw.Project{
+				Dir:  String("."),
+				Name: String("app"),
+				Environments: []raw.Environment{
+					{Name: String("staging")},
+					{Name: String("production"), Workspace: String("prod")},
+				},
+			},
+			expErr: "",
+		},
+		{
+			description: "environments without a project name",
+			input: raw.Project{
+				Dir:          String("."),
+				Environments: []raw.Environment{{Name: String("staging")}},
+			},
+			expErr: "environments: projects with environments must have a name.",
+		},
+		{
+			description: "environments with workspace",
+			input: raw.Project{
+				Dir:          String("."),
+				Name:         String("app"),
+				Workspace:    String("staging"),
+				Environments: []raw.Environment{{Name: String("staging")}},
+			},
+			expErr: "environments: cannot be used with workspace, set the workspace of each environment instead.",
+		},
+		{
+			description: "duplicate environment names",
+			input: raw.Project{
+				Dir:  String("."),
+				Name: String("app"),
+				Environments: []raw.Environment{
+					{Name: String("staging")},
+					{Name: String("staging"), Workspace: String("stg")},
+				},
+			},
+			expErr: "environments: found two or more environments with name \"staging\".",
+		},
+		{
+			description: "duplicate environment workspaces",
+			input: raw.Project{
+				Dir:  String("."),
+				Name: String("app"),
+				Environments: []raw.Environment{
+					{Name: String("staging")},
+					{Name: String("production"), Workspace: String("staging")},
+				},
+			},
+			expErr: "environments: found two or more environments with workspace \"staging\".",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -508,6 +578,38 @@ func TestProject_ToValid(t *testing.T) {
 			},
 		},
 
+		{
+			description: "show outputs",
+			input: raw.Project{
+				Dir:         String("."),
+				ShowOutputs: []string{"endpoint", "bucket"},
+			},
+			exp: valid.Project{
+				Dir:         ".",
+				Workspace:   "default",
+				ShowOutputs: []string{"endpoint", "bucket"},
+				Autoplan: valid.Autoplan{
+					WhenModified: raw.DefaultAutoPlanWhenModified,
+					Enabled:      true,
+				},
+			},
+		},
+		{
+			description: "engine",
+			input: raw.Project{
+				Dir:    String("."),
+				Engine: String("pulumi"),
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Engine:    "pulumi",
+				Autoplan: valid.Autoplan{
+					WhenModified: raw.DefaultAutoPlanWhenModified,
+					Enabled:      true,
+				},
+			},
+		},
 		{
 			description: "workspace set to empty string",
 			input: raw.Project{
@@ -530,3 +632,30 @@ func TestProject_ToValid(t *testing.T) {
 		})
 	}
 }
+
+func TestProject_ToValidProjects(t *testing.T) {
+	Equals(t, []valid.Project{{
+		Dir:       ".",
+		Workspace: "default",
+		Autoplan: valid.Autoplan{
+			WhenModified: raw.DefaultAutoPlanWhenModified,
+			Enabled:      true,
+		},
+	}}, raw.Project{Dir: String(".")}.ToValidProjects())
+
+	projects := raw.Project{
+		Dir:  String("."),
+		Name: String("app"),
+		Environments: []raw.Environment{
+			{Name: String("staging"), VarFiles: []string{"env/staging.tfvars"}},
+			{Name: String("production"), Workspace: String("prod")},
+		},
+	}.ToValidProjects()
+	Equals(t, 2, len(projects))
+	Equals(t, "staging", projects[0].Environment)
+	Equals(t, "staging", projects[0].Workspace)
+	Equals(t, []string{"env/staging.tfvars"}, projects[0].VarFiles)
+	Equals(t, "production", projects[1].Environment)
+	Equals(t, "prod", projects[1].Workspace)
+	Equals(t, "app", *projects[1].Name)
+}
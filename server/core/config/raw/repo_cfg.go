@@ -42,10 +42,18 @@ func (r RepoCfg) Validate() error {
 		}
 		return nil
 	}
+	serverSideOnly := func(value interface{}) error {
+		autoDiscover := value.(*AutoDiscover)
+		if autoDiscover != nil && autoDiscover.CDKTF {
+			return errors.New("cdktf can only be enabled in the server-side repo config")
+		}
+		return nil
+	}
 	return validation.ValidateStruct(&r,
 		validation.Field(&r.Version, validation.By(equals2)),
 		validation.Field(&r.Projects),
 		validation.Field(&r.Workflows),
+		validation.Field(&r.AutoDiscover, validation.By(serverSideOnly)),
 	)
 }
 
@@ -57,7 +65,7 @@ func (r RepoCfg) ToValid() valid.RepoCfg {
 
 	var validProjects []valid.Project
 	for _, p := range r.Projects {
-		validProjects = append(validProjects, p.ToValid())
+		validProjects = append(validProjects, p.ToValidProjects()...)
 	}
 
 	automerge := r.Automerge
@@ -233,6 +233,16 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expErr: "version: only versions 2 and 3 are supported.",
 		},
+		{
+			description: "autodiscover cdktf",
+			input: raw.RepoCfg{
+				Version: Int(3),
+				AutoDiscover: &raw.AutoDiscover{
+					CDKTF: true,
+				},
+			},
+			expErr: "autodiscover: cdktf can only be enabled in the server-side repo config.",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -399,6 +409,7 @@ func TestConfig_ToValid(t *testing.T) {
 						Apply:       valid.DefaultApplyStage,
 						Import:      valid.DefaultImportStage,
 						StateRm:     valid.DefaultStateRmStage,
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 			},
@@ -508,6 +519,7 @@ func TestConfig_ToValid(t *testing.T) {
 								},
 							},
 						},
+						ForceUnlock: valid.DefaultForceUnlockStage,
 					},
 				},
 				Projects: []valid.Project{
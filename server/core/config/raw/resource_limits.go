@@ -0,0 +1,107 @@
+package raw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// ResourceLimits limits the resources of the commands run for a repo's
+// projects.
+type ResourceLimits struct {
+	CPU    *string `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+	Memory *string `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Time   *string `yaml:"time,omitempty" json:"time,omitempty"`
+}
+
+// memoryUnits are the multipliers of the memory limit suffixes.
+var memoryUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"Ti", 1 << 40},
+	{"K", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+}
+
+func (r ResourceLimits) Validate() error {
+	cpuValid := func(value interface{}) error {
+		cpu := value.(*string)
+		if cpu == nil {
+			return nil
+		}
+		_, err := parseCPULimit(*cpu)
+		return err
+	}
+	memoryValid := func(value interface{}) error {
+		memory := value.(*string)
+		if memory == nil {
+			return nil
+		}
+		_, err := parseMemoryLimit(*memory)
+		return err
+	}
+	timeValid := func(value interface{}) error {
+		t := value.(*string)
+		if t == nil {
+			return nil
+		}
+		_, err := parseTimeout(*t)
+		return err
+	}
+
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.CPU, validation.By(cpuValid)),
+		validation.Field(&r.Memory, validation.By(memoryValid)),
+		validation.Field(&r.Time, validation.By(timeValid)),
+	)
+}
+
+func (r ResourceLimits) ToValid() *valid.ResourceLimits {
+	// Validate() already checked the limits can be parsed.
+	var v valid.ResourceLimits
+	if r.CPU != nil {
+		v.CPU, _ = parseCPULimit(*r.CPU)
+	}
+	if r.Memory != nil {
+		v.Memory, _ = parseMemoryLimit(*r.Memory)
+	}
+	if r.Time != nil {
+		v.Time, _ = parseTimeout(*r.Time)
+	}
+	return &v
+}
+
+// parseCPULimit parses a CPU limit, ex. 2 or 0.5.
+func parseCPULimit(cpu string) (float64, error) {
+	c, err := strconv.ParseFloat(cpu, 64)
+	// cgroups can't limit a command to less than a hundredth of a CPU.
+	if err != nil || c < 0.01 {
+		return 0, fmt.Errorf("%q is not a valid cpu limit, use a number of CPUs like 2 or 0.5", cpu)
+	}
+	return c, nil
+}
+
+// parseMemoryLimit parses a memory limit in bytes, ex. 4Gi or 512Mi.
+func parseMemoryLimit(memory string) (int64, error) {
+	number, multiplier := memory, int64(1)
+	for _, unit := range memoryUnits {
+		if strings.HasSuffix(memory, unit.suffix) {
+			number, multiplier = strings.TrimSuffix(memory, unit.suffix), unit.multiplier
+			break
+		}
+	}
+	m, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || m <= 0 {
+		return 0, fmt.Errorf("%q is not a valid memory limit, use a size like 4Gi or 512Mi", memory)
+	}
+	return m * multiplier, nil
+}
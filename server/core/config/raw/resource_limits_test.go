@@ -0,0 +1,70 @@
+package raw_test
+
+import (
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestResourceLimits_UnmarshalYAML(t *testing.T) {
+	var r raw.ResourceLimits
+	Ok(t, unmarshalString(`
+cpu: "0.5"
+memory: 512Mi
+time: 30m
+`, &r))
+	Equals(t, raw.ResourceLimits{
+		CPU:    String("0.5"),
+		Memory: String("512Mi"),
+		Time:   String("30m"),
+	}, r)
+}
+
+func TestResourceLimits_Validate(t *testing.T) {
+	validation.ErrorTag = "yaml"
+	Ok(t, raw.ResourceLimits{}.Validate())
+	Ok(t, raw.ResourceLimits{CPU: String("2"), Memory: String("4Gi"), Time: String("1h")}.Validate())
+	ErrEquals(t, "cpu: \"two\" is not a valid cpu limit, use a number of CPUs like 2 or 0.5.", raw.ResourceLimits{CPU: String("two")}.Validate())
+	ErrEquals(t, "cpu: \"0\" is not a valid cpu limit, use a number of CPUs like 2 or 0.5.", raw.ResourceLimits{CPU: String("0")}.Validate())
+	ErrEquals(t, "memory: \"4GB\" is not a valid memory limit, use a size like 4Gi or 512Mi.", raw.ResourceLimits{Memory: String("4GB")}.Validate())
+	ErrEquals(t, "memory: \"-1Mi\" is not a valid memory limit, use a size like 4Gi or 512Mi.", raw.ResourceLimits{Memory: String("-1Mi")}.Validate())
+	ErrEquals(t, "time: \"forever\" is not a valid timeout, use a positive duration like 30m or 1h30m.", raw.ResourceLimits{Time: String("forever")}.Validate())
+}
+
+func TestResourceLimits_ToValid(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.ResourceLimits
+		exp         *valid.ResourceLimits
+	}{
+		{
+			description: "nothing set",
+			input:       raw.ResourceLimits{},
+			exp:         &valid.ResourceLimits{},
+		},
+		{
+			description: "binary units",
+			input:       raw.ResourceLimits{CPU: String("0.5"), Memory: String("512Mi"), Time: String("30m")},
+			exp:         &valid.ResourceLimits{CPU: 0.5, Memory: 512 * 1024 * 1024, Time: 30 * time.Minute},
+		},
+		{
+			description: "decimal units",
+			input:       raw.ResourceLimits{CPU: String("2"), Memory: String("1G")},
+			exp:         &valid.ResourceLimits{CPU: 2, Memory: 1000 * 1000 * 1000},
+		},
+		{
+			description: "bytes",
+			input:       raw.ResourceLimits{Memory: String("1048576")},
+			exp:         &valid.ResourceLimits{Memory: 1048576},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, c.input.ToValid())
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package raw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+// validRestrictedPlanFlags checks the flags and restrictions of a
+// restricted_plan_flags map.
+func validRestrictedPlanFlags(value interface{}) error {
+	flags := value.(map[string]string)
+	for flag, restriction := range flags {
+		if !utils.SlicesContains(valid.RestrictablePlanFlags, flag) {
+			return fmt.Errorf("%q is not a restrictable flag, only %s are supported", flag, strings.Join(valid.RestrictablePlanFlags, ", "))
+		}
+		if restriction != valid.DenyPlanFlag && restriction != valid.ApprovedPlanFlag {
+			return fmt.Errorf("%q must be %q or %q, got %q", flag, valid.DenyPlanFlag, valid.ApprovedPlanFlag, restriction)
+		}
+	}
+	return nil
+}
@@ -1,17 +1,31 @@
 package raw
 
 import (
+	"fmt"
+	"time"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 )
 
 type Stage struct {
-	Steps []Step `yaml:"steps,omitempty" json:"steps,omitempty"`
+	Steps   []Step  `yaml:"steps,omitempty" json:"steps,omitempty"`
+	Timeout *string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 }
 
 func (s Stage) Validate() error {
+	timeoutValid := func(value interface{}) error {
+		timeout := value.(*string)
+		if timeout == nil {
+			return nil
+		}
+		_, err := parseTimeout(*timeout)
+		return err
+	}
+
 	return validation.ValidateStruct(&s,
 		validation.Field(&s.Steps),
+		validation.Field(&s.Timeout, validation.By(timeoutValid)),
 	)
 }
 
@@ -20,7 +34,22 @@ func (s Stage) ToValid() valid.Stage {
 	for _, s := range s.Steps {
 		validSteps = append(validSteps, s.ToValid())
 	}
+	var timeout time.Duration
+	if s.Timeout != nil {
+		// Validate() already checked the timeout can be parsed.
+		timeout, _ = parseTimeout(*s.Timeout)
+	}
 	return valid.Stage{
-		Steps: validSteps,
+		Steps:   validSteps,
+		Timeout: timeout,
+	}
+}
+
+// parseTimeout parses a stage or step timeout, ex. 30m.
+func parseTimeout(timeout string) (time.Duration, error) {
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a valid timeout, use a positive duration like 30m or 1h30m", timeout)
 	}
+	return d, nil
 }
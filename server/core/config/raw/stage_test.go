@@ -2,6 +2,7 @@ package raw_test
 
 import (
 	"testing"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/raw"
@@ -26,6 +27,7 @@ func TestStage_UnmarshalYAML(t *testing.T) {
 			description: "all fields set",
 			input: `
 steps: [step1]
+timeout: 1h
 `,
 			exp: raw.Stage{
 				Steps: []raw.Step{
@@ -33,6 +35,7 @@ steps: [step1]
 						Key: String("step1"),
 					},
 				},
+				Timeout: String("1h"),
 			},
 		},
 	}
@@ -61,6 +64,10 @@ func TestStage_Validate(t *testing.T) {
 
 	// Empty steps should validate.
 	Ok(t, (raw.Stage{}).Validate())
+
+	Ok(t, (raw.Stage{Timeout: String("1h30m")}).Validate())
+	ErrEquals(t, "timeout: \"forever\" is not a valid timeout, use a positive duration like 30m or 1h30m.", (raw.Stage{Timeout: String("forever")}).Validate())
+	ErrEquals(t, "timeout: \"-5m\" is not a valid timeout, use a positive duration like 30m or 1h30m.", (raw.Stage{Timeout: String("-5m")}).Validate())
 }
 
 func TestStage_ToValid(t *testing.T) {
@@ -84,6 +91,7 @@ func TestStage_ToValid(t *testing.T) {
 						Key: String("init"),
 					},
 				},
+				Timeout: String("1h"),
 			},
 			exp: valid.Stage{
 				Steps: []valid.Step{
@@ -91,6 +99,7 @@ func TestStage_ToValid(t *testing.T) {
 						StepName: "init",
 					},
 				},
+				Timeout: time.Hour,
 			},
 		},
 	}
@@ -13,23 +13,37 @@ import (
 )
 
 const (
-	ExtraArgsKey        = "extra_args"
-	NameArgKey          = "name"
-	CommandArgKey       = "command"
-	ValueArgKey         = "value"
-	OutputArgKey        = "output"
-	RunStepName         = "run"
-	PlanStepName        = "plan"
-	ShowStepName        = "show"
-	PolicyCheckStepName = "policy_check"
-	ApplyStepName       = "apply"
-	InitStepName        = "init"
-	EnvStepName         = "env"
-	MultiEnvStepName    = "multienv"
-	ImportStepName      = "import"
-	StateRmStepName     = "state_rm"
-	ShellArgKey         = "shell"
-	ShellArgsArgKey     = "shellArgs"
+	ExtraArgsKey          = "extra_args"
+	NameArgKey            = "name"
+	CommandArgKey         = "command"
+	ValueArgKey           = "value"
+	OutputArgKey          = "output"
+	TimeoutArgKey         = "timeout"
+	SuggestArgKey         = "suggest"
+	WarnOnlyArgKey        = "warn_only"
+	RepoArgKey            = "repo"
+	RefArgKey             = "ref"
+	DirArgKey             = "dir"
+	CredentialsArgKey     = "credentials"
+	TokenEnvArgKey        = "token_env"
+	RunStepName           = "run"
+	PlanStepName          = "plan"
+	ShowStepName          = "show"
+	PolicyCheckStepName   = "policy_check"
+	ApplyStepName         = "apply"
+	InitStepName          = "init"
+	EnvStepName           = "env"
+	MultiEnvStepName      = "multienv"
+	ImportStepName        = "import"
+	StateRmStepName       = "state_rm"
+	StateCheckStepName    = "state_check"
+	ForceUnlockStepName   = "force_unlock"
+	FmtCheckStepName      = "fmt_check"
+	ValidateStepName      = "validate"
+	LockfileCheckStepName = "lockfile_check"
+	CheckoutStepName      = "checkout"
+	ShellArgKey           = "shell"
+	ShellArgsArgKey       = "shellArgs"
 )
 
 /*
@@ -39,7 +53,9 @@ Step represents a single action/command to perform. In YAML, it can be set as
   - plan
   - policy_check
 
-2. A map for an env step with name and command or value, or a run step with a command and output config
+2. A map for an env step with name and command or value, a run step with a command and output config,
+an init, plan or apply step with a timeout, an fmt_check step with suggestions,
+a lockfile_check step that only warns, or a checkout step
   - env:
     name: test_command
     command: echo 312
@@ -59,6 +75,20 @@ Step represents a single action/command to perform. In YAML, it can be set as
   - run:
     command: my custom command
     output: hide
+    timeout: 10m
+  - plan:
+    extra_args: [-var-file=staging.tfvars]
+    timeout: 30m
+  - fmt_check:
+    suggest: true
+  - lockfile_check:
+    extra_args: [-platform=linux_amd64]
+    warn_only: true
+  - checkout:
+    repo: github.com/owner/modules
+    ref: v1.2.0
+    dir: modules
+    token_env: MODULES_TOKEN
 
 3. A map for a built-in command and extra_args:
   - plan:
@@ -113,7 +143,12 @@ func (s Step) validStepName(stepName string) bool {
 		stepName == ShowStepName ||
 		stepName == PolicyCheckStepName ||
 		stepName == ImportStepName ||
-		stepName == StateRmStepName
+		stepName == StateRmStepName ||
+		stepName == StateCheckStepName ||
+		stepName == ForceUnlockStepName ||
+		stepName == FmtCheckStepName ||
+		stepName == ValidateStepName ||
+		stepName == LockfileCheckStepName
 }
 
 func (s Step) Validate() error {
@@ -222,8 +257,65 @@ func (s Step) Validate() error {
 		delete(argMap, ShellArgsArgKey)
 		delete(argMap, ShellArgKey)
 
+		if timeout, ok := argMap[TimeoutArgKey]; ok {
+			if !utils.SlicesContains([]string{InitStepName, PlanStepName, ApplyStepName, RunStepName}, stepName) {
+				return fmt.Errorf("only init, plan, apply and run steps support the %q key, found it in step %s", TimeoutArgKey, stepName)
+			}
+			str, ok := timeout.(string)
+			if !ok {
+				return fmt.Errorf("%q step %q option must be a duration like 30m, found %v", stepName, TimeoutArgKey, timeout)
+			}
+			if _, err := parseTimeout(str); err != nil {
+				return err
+			}
+			delete(argMap, TimeoutArgKey)
+		}
+
 		// Validate keys per step type.
 		switch stepName {
+		case InitStepName, PlanStepName, ApplyStepName, FmtCheckStepName, LockfileCheckStepName:
+			switch t := argMap[ExtraArgsKey].(type) {
+			case nil:
+			case []interface{}:
+				for _, e := range t {
+					if _, ok := e.(string); !ok {
+						return fmt.Errorf("%q step %q option must contain only strings, found %v",
+							stepName, ExtraArgsKey, e)
+					}
+				}
+			default:
+				return fmt.Errorf("%q step %q option must be a list of strings, found %v",
+					stepName, ExtraArgsKey, t)
+			}
+			delete(argMap, ExtraArgsKey)
+			otherKey := TimeoutArgKey
+			if stepName == FmtCheckStepName {
+				if suggest, ok := argMap[SuggestArgKey]; ok {
+					if _, ok := suggest.(bool); !ok {
+						return fmt.Errorf("%q step %q option must be true or false, found %v",
+							stepName, SuggestArgKey, suggest)
+					}
+				}
+				delete(argMap, SuggestArgKey)
+				otherKey = SuggestArgKey
+			}
+			if stepName == LockfileCheckStepName {
+				if warnOnly, ok := argMap[WarnOnlyArgKey]; ok {
+					if _, ok := warnOnly.(bool); !ok {
+						return fmt.Errorf("%q step %q option must be true or false, found %v",
+							stepName, WarnOnlyArgKey, warnOnly)
+					}
+				}
+				delete(argMap, WarnOnlyArgKey)
+				otherKey = WarnOnlyArgKey
+			}
+			for _, k := range argKeys {
+				if _, ok := argMap[k]; !ok {
+					continue
+				}
+				return fmt.Errorf("built-in steps only support keys %q and %q, found %q in step %s",
+					ExtraArgsKey, otherKey, k, stepName)
+			}
 		case EnvStepName:
 			foundNameKey := false
 			for _, k := range argKeys {
@@ -245,6 +337,11 @@ func (s Step) Validate() error {
 					ValueArgKey, CommandArgKey)
 			}
 			delete(argMap, ValueArgKey)
+		case CheckoutStepName:
+			if err := validCheckoutArgs(argMap); err != nil {
+				return err
+			}
+			return nil
 		case RunStepName, MultiEnvStepName:
 			if _, ok := argMap[CommandArgKey].(string); !ok {
 				return fmt.Errorf("%q step must have a %q key set", stepName, CommandArgKey)
@@ -274,6 +371,10 @@ func (s Step) Validate() error {
 			}
 			// Sort so tests can be deterministic.
 			sort.Strings(argKeys)
+			if stepName == RunStepName {
+				return fmt.Errorf("%q steps only support keys %q, %q, %q, %q and %q, found extra keys %q",
+					stepName, CommandArgKey, OutputArgKey, ShellArgKey, ShellArgsArgKey, TimeoutArgKey, strings.Join(argKeys, ","))
+			}
 			return fmt.Errorf("%q steps only support keys %q, %q, %q and %q, found extra keys %q",
 				stepName, CommandArgKey, OutputArgKey, ShellArgKey, ShellArgsArgKey, strings.Join(argKeys, ","))
 		}
@@ -346,12 +447,30 @@ func (s Step) ToValid() valid.Step {
 			if shell, ok := stepArgs[ShellArgKey].(string); ok {
 				step.RunShell = &valid.CommandShell{
 					Shell:     shell,
-					ShellArgs: []string{"-c"},
+					ShellArgs: valid.DefaultShellArgs(shell),
 				}
 			}
 			if step.StepName == RunStepName && step.Output == "" {
 				step.Output = valid.PostProcessRunOutputShow
 			}
+			if extraArgs, ok := stepArgs[ExtraArgsKey].([]interface{}); ok {
+				for _, a := range extraArgs {
+					step.ExtraArgs = append(step.ExtraArgs, a.(string))
+				}
+			}
+			if timeout, ok := stepArgs[TimeoutArgKey].(string); ok {
+				// Validate() already checked the timeout can be parsed.
+				step.Timeout, _ = parseTimeout(timeout)
+			}
+			if suggest, ok := stepArgs[SuggestArgKey].(bool); ok {
+				step.Suggest = suggest
+			}
+			if warnOnly, ok := stepArgs[WarnOnlyArgKey].(bool); ok {
+				step.WarnOnly = warnOnly
+			}
+			if stepName == CheckoutStepName {
+				step.Checkout = checkoutToValid(stepArgs)
+			}
 
 			switch t := stepArgs[ShellArgsArgKey].(type) {
 			case nil:
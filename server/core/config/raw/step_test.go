@@ -2,6 +2,7 @@ package raw_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -129,6 +130,22 @@ key: value`,
 			},
 		},
 
+		{
+			description: "built-in step with timeout",
+			input: `
+plan:
+  extra_args: [arg1]
+  timeout: 30m`,
+			exp: raw.Step{
+				CommandMap: EnvType{
+					"plan": {
+						"extra_args": []interface{}{"arg1"},
+						"timeout":    "30m",
+					},
+				},
+			},
+		},
+
 		// Empty
 		{
 			description: "empty",
@@ -479,6 +496,240 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "\"run\" step \"shellArgs\" option must contain only strings, found 42\n",
 		},
+		{
+			description: "built-in steps with timeout",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"plan": {
+						"extra_args": []interface{}{"-var-file=staging.tfvars"},
+						"timeout":    "30m",
+					},
+				},
+			},
+		},
+		{
+			description: "run step with timeout",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "make test",
+						"timeout": "1h",
+					},
+				},
+			},
+		},
+		{
+			description: "step with invalid timeout",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"apply": {
+						"timeout": "soon",
+					},
+				},
+			},
+			expErr: "\"soon\" is not a valid timeout, use a positive duration like 30m or 1h30m",
+		},
+		{
+			description: "step with timeout that isn't a string",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"init": {
+						"timeout": 30,
+					},
+				},
+			},
+			expErr: "\"init\" step \"timeout\" option must be a duration like 30m, found 30",
+		},
+		{
+			description: "timeout on a step that doesn't support it",
+			input: raw.Step{
+				CommandMap: MultiEnvType{
+					"multienv": {
+						"command": "envs.sh",
+						"timeout": "5m",
+					},
+				},
+			},
+			expErr: "only init, plan, apply and run steps support the \"timeout\" key, found it in step multienv",
+		},
+		{
+			description: "built-in step with timeout and invalid key",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"plan": {
+						"command": "echo",
+						"timeout": "5m",
+					},
+				},
+			},
+			expErr: "built-in steps only support keys \"extra_args\" and \"timeout\", found \"command\" in step plan",
+		},
+		{
+			description: "built-in step with timeout and extra_args that aren't a list",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"plan": {
+						"extra_args": "-var-file=staging.tfvars",
+						"timeout":    "5m",
+					},
+				},
+			},
+			expErr: "\"plan\" step \"extra_args\" option must be a list of strings, found -var-file=staging.tfvars",
+		},
+		{
+			description: "fmt_check step with suggest",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"fmt_check": {
+						"extra_args": []interface{}{"-recursive"},
+						"suggest":    true,
+					},
+				},
+			},
+		},
+		{
+			description: "fmt_check step with suggest that isn't a bool",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"fmt_check": {
+						"suggest": "yes",
+					},
+				},
+			},
+			expErr: "\"fmt_check\" step \"suggest\" option must be true or false, found yes",
+		},
+		{
+			description: "fmt_check step with timeout",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"fmt_check": {
+						"timeout": "5m",
+					},
+				},
+			},
+			expErr: "only init, plan, apply and run steps support the \"timeout\" key, found it in step fmt_check",
+		},
+		{
+			description: "lockfile_check step with warn_only",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"lockfile_check": {
+						"extra_args": []interface{}{"-platform=linux_amd64"},
+						"warn_only":  true,
+					},
+				},
+			},
+		},
+		{
+			description: "lockfile_check step with warn_only that isn't a bool",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"lockfile_check": {
+						"warn_only": "yes",
+					},
+				},
+			},
+			expErr: "\"lockfile_check\" step \"warn_only\" option must be true or false, found yes",
+		},
+		{
+			description: "fmt_check step with warn_only",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"fmt_check": {
+						"warn_only": true,
+					},
+				},
+			},
+			expErr: "built-in steps only support keys \"extra_args\" and \"suggest\", found \"warn_only\" in step fmt_check",
+		},
+		{
+			description: "checkout step",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo":      "github.com/owner/modules",
+						"ref":       "v1.2.0",
+						"dir":       "modules",
+						"token_env": "MODULES_TOKEN",
+					},
+				},
+			},
+		},
+		{
+			description: "checkout step without dir",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo": "github.com/owner/modules",
+					},
+				},
+			},
+			expErr: "checkout steps must have a \"dir\" key set",
+		},
+		{
+			description: "checkout step outside the workspace",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo": "github.com/owner/modules",
+						"dir":  "../modules",
+					},
+				},
+			},
+			expErr: "checkout step \"dir\" option must be a relative path inside the workspace, found \"../modules\"",
+		},
+		{
+			description: "checkout step into the workspace",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo": "github.com/owner/modules",
+						"dir":  "./",
+					},
+				},
+			},
+			expErr: "checkout step \"dir\" option must be a subdirectory of the workspace, found \"./\"",
+		},
+		{
+			description: "checkout step with credentials and token_env",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo":        "github.com/owner/modules",
+						"dir":         "modules",
+						"credentials": "none",
+						"token_env":   "MODULES_TOKEN",
+					},
+				},
+			},
+			expErr: "checkout steps only support one of the \"credentials\" or \"token_env\" keys, found both",
+		},
+		{
+			description: "checkout step with unknown credentials",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo":        "github.com/owner/modules",
+						"dir":         "modules",
+						"credentials": "ssh",
+					},
+				},
+			},
+			expErr: "checkout step \"credentials\" option must be one of vcs, none, found \"ssh\"",
+		},
+		{
+			description: "checkout step with extra key",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo":    "github.com/owner/modules",
+						"dir":     "modules",
+						"command": "git pull",
+					},
+				},
+			},
+			expErr: "checkout steps only support keys \"repo\", \"ref\", \"dir\", \"credentials\" and \"token_env\", found key \"command\"",
+		},
 		{
 			// For atlantis.yaml v2, this wouldn't parse, but now there should
 			// be no error.
@@ -667,6 +918,133 @@ func TestStep_ToValid(t *testing.T) {
 				Output:     "hide",
 			},
 		},
+		{
+			description: "run step with timeout",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "make test",
+						"timeout": "1h",
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "make test",
+				Output:     "show",
+				Timeout:    time.Hour,
+			},
+		},
+		{
+			description: "run step with powershell",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command": "Write-Output $env:PLANFILE",
+						"shell":   "powershell",
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "Write-Output $env:PLANFILE",
+				Output:     "show",
+				RunShell: &valid.CommandShell{
+					Shell:     "powershell",
+					ShellArgs: []string{"-NoProfile", "-NonInteractive", "-Command"},
+				},
+			},
+		},
+		{
+			description: "run step with cmd and shellArgs",
+			input: raw.Step{
+				CommandMap: RunType{
+					"run": {
+						"command":   "echo %PLANFILE%",
+						"shell":     "cmd",
+						"shellArgs": "/C",
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "echo %PLANFILE%",
+				Output:     "show",
+				RunShell: &valid.CommandShell{
+					Shell:     "cmd",
+					ShellArgs: []string{"/C"},
+				},
+			},
+		},
+		{
+			description: "plan step with extra_args and timeout",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"plan": {
+						"extra_args": []interface{}{"-var-file=staging.tfvars"},
+						"timeout":    "30m",
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:  "plan",
+				ExtraArgs: []string{"-var-file=staging.tfvars"},
+				Timeout:   30 * time.Minute,
+			},
+		},
+		{
+			description: "fmt_check step with suggest",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"fmt_check": {
+						"extra_args": []interface{}{"-recursive"},
+						"suggest":    true,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:  "fmt_check",
+				ExtraArgs: []string{"-recursive"},
+				Suggest:   true,
+			},
+		},
+		{
+			description: "lockfile_check step with warn_only",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"lockfile_check": {
+						"extra_args": []interface{}{"-platform=linux_amd64"},
+						"warn_only":  true,
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName:  "lockfile_check",
+				ExtraArgs: []string{"-platform=linux_amd64"},
+				WarnOnly:  true,
+			},
+		},
+		{
+			description: "checkout step",
+			input: raw.Step{
+				CommandMap: EnvType{
+					"checkout": {
+						"repo": "github.com/owner/modules",
+						"ref":  "v1.2.0",
+						"dir":  "modules",
+					},
+				},
+			},
+			exp: valid.Step{
+				StepName: "checkout",
+				Checkout: &valid.Checkout{
+					Repo:        "github.com/owner/modules",
+					Ref:         "v1.2.0",
+					Dir:         "modules",
+					Credentials: "vcs",
+				},
+			},
+		},
 		{
 			description: "multienv step",
 			input: raw.Step{
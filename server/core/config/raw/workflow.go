@@ -11,6 +11,7 @@ type Workflow struct {
 	PolicyCheck *Stage `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
 	Import      *Stage `yaml:"import,omitempty" json:"import,omitempty"`
 	StateRm     *Stage `yaml:"state_rm,omitempty" json:"state_rm,omitempty"`
+	ForceUnlock *Stage `yaml:"force_unlock,omitempty" json:"force_unlock,omitempty"`
 }
 
 func (w Workflow) Validate() error {
@@ -20,6 +21,7 @@ func (w Workflow) Validate() error {
 		validation.Field(&w.PolicyCheck),
 		validation.Field(&w.Import),
 		validation.Field(&w.StateRm),
+		validation.Field(&w.ForceUnlock),
 	)
 }
 
@@ -41,6 +43,7 @@ func (w Workflow) ToValid(name string) valid.Workflow {
 	v.PolicyCheck = w.toValidStage(w.PolicyCheck, valid.DefaultPolicyCheckStage)
 	v.Import = w.toValidStage(w.Import, valid.DefaultImportStage)
 	v.StateRm = w.toValidStage(w.StateRm, valid.DefaultStateRmStage)
+	v.ForceUnlock = w.toValidStage(w.ForceUnlock, valid.DefaultForceUnlockStage)
 
 	return v
 }
@@ -149,6 +149,7 @@ func TestWorkflow_ToValid(t *testing.T) {
 				PolicyCheck: valid.DefaultPolicyCheckStage,
 				Import:      valid.DefaultImportStage,
 				StateRm:     valid.DefaultStateRmStage,
+				ForceUnlock: valid.DefaultForceUnlockStage,
 			},
 		},
 		{
@@ -226,6 +227,7 @@ func TestWorkflow_ToValid(t *testing.T) {
 						},
 					},
 				},
+				ForceUnlock: valid.DefaultForceUnlockStage,
 			},
 		},
 	}
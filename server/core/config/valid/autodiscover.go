@@ -1,5 +1,10 @@
 package valid
 
+import (
+	"path/filepath"
+	"regexp"
+)
+
 // AutoDiscoverMode enum
 type AutoDiscoverMode string
 
@@ -12,4 +17,29 @@ const (
 type AutoDiscover struct {
 	Mode        AutoDiscoverMode
 	IgnorePaths []string
+	// WorkspacePatterns are regexes with a named "workspace" capture group used
+	// to infer the workspace of a discovered project from its directory.
+	WorkspacePatterns []*regexp.Regexp
+	// CDKTF is true if CDKTF apps, i.e. directories with a cdktf.json file,
+	// should be synthesized and the stacks they generate discovered as
+	// projects.
+	CDKTF bool
+}
+
+// InferWorkspace returns the workspace captured by the first workspace pattern
+// matching path. The path is matched with a trailing slash so patterns like
+// `envs/(?P<workspace>\w+)/` also match the directory itself.
+// If no pattern matches, ok is false.
+func (a AutoDiscover) InferWorkspace(path string) (workspace string, ok bool) {
+	path = filepath.ToSlash(filepath.Clean(path)) + "/"
+	for _, re := range a.WorkspacePatterns {
+		match := re.FindStringSubmatch(path)
+		if match == nil {
+			continue
+		}
+		if workspace := match[re.SubexpIndex("workspace")]; workspace != "" {
+			return workspace, true
+		}
+	}
+	return "", false
 }
@@ -0,0 +1,33 @@
+package valid
+
+const (
+	// VCSCheckoutCredentials clones the repository of a checkout step with
+	// the git credentials Atlantis clones pull requests with. It's the
+	// default.
+	VCSCheckoutCredentials = "vcs"
+	// NoCheckoutCredentials clones the repository of a checkout step without
+	// credentials, ex. a public repository.
+	NoCheckoutCredentials = "none"
+)
+
+// CheckoutCredentials are the credentials a checkout step can select.
+var CheckoutCredentials = []string{VCSCheckoutCredentials, NoCheckoutCredentials}
+
+// Checkout is another repository that a checkout step clones into the
+// workspace, ex. shared modules.
+type Checkout struct {
+	// Repo is the clone URL of the repository, or its host and path like
+	// github.com/owner/modules to clone it over HTTPS.
+	Repo string
+	// Ref is the branch, tag or commit to check out. Defaults to the default
+	// branch of the repository.
+	Ref string
+	// Dir is where the repository is cloned, relative to the root of the
+	// workspace.
+	Dir string
+	// Credentials is VCSCheckoutCredentials or NoCheckoutCredentials.
+	Credentials string
+	// TokenEnv, if set, is the environment variable holding the token the
+	// repository is cloned with instead of Credentials.
+	TokenEnv string
+}
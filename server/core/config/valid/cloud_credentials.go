@@ -0,0 +1,34 @@
+package valid
+
+import "time"
+
+// CloudCredentialsKey is the repo config key for the cloud credentials minted
+// for a project's steps.
+const CloudCredentialsKey = "cloud_credentials"
+
+// CloudCredentials are the short-lived cloud credentials Atlantis mints for
+// the steps of a project's commands. Unset providers aren't minted.
+type CloudCredentials struct {
+	AWS *AWSCredentials
+	GCP *GCPCredentials
+}
+
+// AWSCredentials is the IAM role assumed with the server's identity token.
+type AWSCredentials struct {
+	RoleARN string
+	// Region is the AWS region of the STS endpoint and AWS_REGION. Empty
+	// uses the global endpoint and doesn't set AWS_REGION.
+	Region string
+	// Duration is how long the credentials are valid for. Zero uses the
+	// default of the role.
+	Duration time.Duration
+}
+
+// GCPCredentials is the service account impersonated after exchanging the
+// server's identity token with the workload identity provider.
+type GCPCredentials struct {
+	// WorkloadIdentityProvider is the full resource name of the provider, ex.
+	// projects/123/locations/global/workloadIdentityPools/pool/providers/provider.
+	WorkloadIdentityProvider string
+	ServiceAccount           string
+}
@@ -0,0 +1,71 @@
+package valid
+
+// CommandPermissionsKey is the server-side repo config key restricting who
+// can run each comment command.
+const CommandPermissionsKey = "command_permissions"
+
+// CommandPermissionCommands are the comment commands that can be restricted
+// with command_permissions.
+var CommandPermissionCommands = []string{"plan", "apply", "unlock", "approve_policies", "version", "import", "state", "force-unlock", "cancel", "workspaces", "status", "confirm", "help"}
+
+// Role is a named group of users and teams that command permissions can refer
+// to instead of repeating the same members for every repo.
+type Role struct {
+	Users []string
+	Teams []string
+}
+
+// CommandPermission lists who may run a comment command. A user is allowed if
+// they're listed in Users, belong to one of Teams or are a member of one of
+// Roles.
+type CommandPermission struct {
+	Users []string
+	Teams []string
+	Roles []string
+}
+
+// owners flattens p and the roles it refers to into a single list of users
+// and teams.
+func (p CommandPermission) owners(roles map[string]Role) PolicyOwners {
+	owners := PolicyOwners{
+		Users: append([]string{}, p.Users...),
+		Teams: append([]string{}, p.Teams...),
+	}
+	for _, name := range p.Roles {
+		role := roles[name]
+		owners.Users = append(owners.Users, role.Users...)
+		owners.Teams = append(owners.Teams, role.Teams...)
+	}
+	return owners
+}
+
+// IsAllowed returns true if username, or one of userTeams, is granted p either
+// directly or through one of its roles.
+func (p CommandPermission) IsAllowed(username string, userTeams []string, roles map[string]Role) bool {
+	owners := p.owners(roles)
+	return owners.IsOwner(username, userTeams)
+}
+
+// HasTeams returns true if p grants access to any team, in which case the
+// user's teams need to be fetched before calling IsAllowed.
+func (p CommandPermission) HasTeams(roles map[string]Role) bool {
+	return len(p.owners(roles).Teams) > 0
+}
+
+// CommandPermission returns the permission configured for cmdName on repoID
+// and false if the command isn't restricted. Like the other repo settings,
+// later matching repos override earlier ones.
+func (g GlobalCfg) CommandPermission(repoID string, cmdName string) (CommandPermission, bool) {
+	var perm CommandPermission
+	found := false
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		if p, ok := repo.CommandPermissions[cmdName]; ok {
+			perm = p
+			found = true
+		}
+	}
+	return perm, found
+}
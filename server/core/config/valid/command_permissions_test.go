@@ -0,0 +1,103 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCommandPermission_IsAllowed(t *testing.T) {
+	roles := map[string]valid.Role{
+		"admins": {
+			Users: []string{"alice"},
+			Teams: []string{"platform"},
+		},
+	}
+	perm := valid.CommandPermission{
+		Users: []string{"bob"},
+		Teams: []string{"sre"},
+		Roles: []string{"admins"},
+	}
+	cases := []struct {
+		description string
+		username    string
+		teams       []string
+		exp         bool
+	}{
+		{
+			description: "listed user",
+			username:    "Bob",
+			exp:         true,
+		},
+		{
+			description: "listed team",
+			username:    "carol",
+			teams:       []string{"sre"},
+			exp:         true,
+		},
+		{
+			description: "user from role",
+			username:    "alice",
+			exp:         true,
+		},
+		{
+			description: "team from role",
+			username:    "carol",
+			teams:       []string{"dev", "platform"},
+			exp:         true,
+		},
+		{
+			description: "not allowed",
+			username:    "carol",
+			teams:       []string{"dev"},
+			exp:         false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, perm.IsAllowed(c.username, c.teams, roles))
+		})
+	}
+}
+
+func TestCommandPermission_HasTeams(t *testing.T) {
+	roles := map[string]valid.Role{
+		"admins": {Teams: []string{"platform"}},
+	}
+	Equals(t, false, valid.CommandPermission{Users: []string{"bob"}}.HasTeams(roles))
+	Equals(t, true, valid.CommandPermission{Teams: []string{"sre"}}.HasTeams(roles))
+	Equals(t, true, valid.CommandPermission{Roles: []string{"admins"}}.HasTeams(roles))
+}
+
+func TestGlobalCfg_CommandPermission(t *testing.T) {
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+				CommandPermissions: map[string]valid.CommandPermission{
+					"apply":  {Teams: []string{"sre"}},
+					"unlock": {Teams: []string{"sre"}},
+				},
+			},
+			{
+				ID: "github.com/owner/prod",
+				CommandPermissions: map[string]valid.CommandPermission{
+					"apply": {Users: []string{"alice"}},
+				},
+			},
+		},
+	}
+
+	perm, ok := globalCfg.CommandPermission("github.com/owner/prod", "apply")
+	Equals(t, true, ok)
+	Equals(t, valid.CommandPermission{Users: []string{"alice"}}, perm)
+
+	perm, ok = globalCfg.CommandPermission("github.com/owner/prod", "unlock")
+	Equals(t, true, ok)
+	Equals(t, valid.CommandPermission{Teams: []string{"sre"}}, perm)
+
+	_, ok = globalCfg.CommandPermission("github.com/owner/prod", "plan")
+	Equals(t, false, ok)
+}
@@ -0,0 +1,80 @@
+package valid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ConfigRepoRepoPlaceholder is replaced by the full name of a repo in
+	// the path of its repo config in a config repo.
+	ConfigRepoRepoPlaceholder = "{repo}"
+	// DefaultConfigRepoPath is where the repo config of a repo is in a
+	// config repo by default.
+	DefaultConfigRepoPath = ConfigRepoRepoPlaceholder + "/atlantis.yaml"
+)
+
+// ConfigRepo is a central repository holding the repo configs of other
+// repos, for organizations that don't allow config files in each repo.
+type ConfigRepo struct {
+	// Repo is the full name of the config repo, ex. myorg/atlantis-config.
+	// It's on the same VCS host as the repos it configures.
+	Repo string
+	// Branch is the branch the repo configs are read from.
+	Branch string
+	// Path is where the repo config of a repo is, with
+	// ConfigRepoRepoPlaceholder in place of the repo's full name.
+	Path string
+	// Authoritative is true if repos without a repo config in the config
+	// repo have no repo config, instead of reading their own.
+	Authoritative bool
+}
+
+// RepoCfgPath returns the path of the repo config of the repo repoFullName.
+func (c ConfigRepo) RepoCfgPath(repoFullName string) string {
+	return strings.ReplaceAll(c.Path, ConfigRepoRepoPlaceholder, repoFullName)
+}
+
+// RepoConfigRepo returns the config repo the repo config of the repo with id
+// repoID is read from, or nil if it's only read from the repo itself. Later
+// matching repos override earlier ones.
+func (g GlobalCfg) RepoConfigRepo(repoID string) *ConfigRepo {
+	var configRepo *ConfigRepo
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ConfigRepo != nil {
+			configRepo = repo.ConfigRepo
+		}
+	}
+	return configRepo
+}
+
+// ValidateConfigRepoHosts returns an error if a repo that may be on one of
+// hosts reads its repo config from a config repo. hosts are the hostnames of
+// the VCS hosts that can't download single files, so can't read config repos.
+func (g GlobalCfg) ValidateConfigRepoHosts(hosts []string) error {
+	for _, repo := range g.Repos {
+		if repo.ConfigRepo == nil {
+			continue
+		}
+		for _, host := range hosts {
+			if repo.mayBeOnHost(host) {
+				return fmt.Errorf("repo %s: config_repo can't be used for repos on %s since it doesn't support downloading single files", repo.IDString(), host)
+			}
+		}
+	}
+	return nil
+}
+
+// mayBeOnHost returns true if r can match repos on host. Regexes are only
+// checked for matching any repo on host or starting with host.
+func (r Repo) mayBeOnHost(host string) bool {
+	if r.ID != "" {
+		return strings.HasPrefix(r.ID, host+"/")
+	}
+	if r.IDRegex.MatchString(host + "/") {
+		return true
+	}
+	source := strings.TrimPrefix(r.IDRegex.String(), "^")
+	return strings.HasPrefix(source, host) || strings.HasPrefix(source, regexp.QuoteMeta(host))
+}
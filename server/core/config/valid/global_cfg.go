@@ -21,6 +21,7 @@ const WorkflowKey = "workflow"
 const AllowedOverridesKey = "allowed_overrides"
 const AllowCustomWorkflowsKey = "allow_custom_workflows"
 const DefaultWorkflowName = "default"
+const ForkPRWorkflowKey = "fork_pr_workflow"
 const DeleteSourceBranchOnMergeKey = "delete_source_branch_on_merge"
 const RepoLockingKey = "repo_locking"
 const RepoLocksKey = "repo_locks"
@@ -28,6 +29,7 @@ const PolicyCheckKey = "policy_check"
 const CustomPolicyCheckKey = "custom_policy_check"
 const AutoDiscoverKey = "autodiscover"
 const SilencePRCommentsKey = "silence_pr_comments"
+const EngineKey = "engine"
 
 var AllowedSilencePRComments = []string{"plan", "apply"}
 
@@ -48,6 +50,7 @@ type GlobalCfg struct {
 	PolicySets PolicySets
 	Metrics    Metrics
 	TeamAuthz  TeamAuthz
+	Roles      map[string]Role
 }
 
 type Metrics struct {
@@ -90,6 +93,24 @@ type Repo struct {
 	CustomPolicyCheck         *bool
 	AutoDiscover              *AutoDiscover
 	SilencePRComments         []string
+	CommandPermissions        map[string]CommandPermission
+	ForkPRWorkflow            *Workflow
+	RestrictedPlanFlags       map[string]string
+	PRComments                map[string]string
+	ResourceLimits            *ResourceLimits
+	// PlanDrafts overrides whether draft pull requests are autoplanned.
+	PlanDrafts *bool
+	// ApplyConfirmation requires applies run from comments to be confirmed
+	// with `atlantis confirm` before they run.
+	ApplyConfirmation *bool
+	// OutdatedDependencies disables the outdated dependencies report of the
+	// plans if false.
+	OutdatedDependencies *bool
+	// CommentDigestThreshold is the number of projects above which the
+	// results of a command are commented as a single digest. 0 disables it.
+	CommentDigestThreshold *int
+	// ConfigRepo, if set, is the central repo the repo config is read from.
+	ConfigRepo *ConfigRepo
 }
 
 type MergedProjectCfg struct {
@@ -103,10 +124,13 @@ type MergedProjectCfg struct {
 	Workspace                 string
 	Name                      string
 	AutoplanEnabled           bool
+	AutoplanMode              string
+	AutoplanLabels            []string
 	AutoMergeDisabled         bool
 	AutoMergeMethod           string
 	TerraformDistribution     *string
 	TerraformVersion          *version.Version
+	Engine                    string
 	RepoCfgVersion            int
 	PolicySets                PolicySets
 	DeleteSourceBranchOnMerge bool
@@ -115,6 +139,20 @@ type MergedProjectCfg struct {
 	PolicyCheck               bool
 	CustomPolicyCheck         bool
 	SilencePRComments         []string
+	AutoVarFiles              AutoVarFiles
+	Environment               string
+	VarFiles                  []string
+	ShowOutputs               []string
+	RestrictedPlanFlags       map[string]string
+	PRCommentModes            map[string]string
+	ResourceLimits            ResourceLimits
+	CloudCredentials          *CloudCredentials
+	// WorkspaceQualified is true if the project's name is shared by projects
+	// in other workspaces, so it's identified by its name and workspace.
+	WorkspaceQualified bool
+	// OutdatedDepsDisabled is true if the plans don't report the outdated
+	// providers and modules.
+	OutdatedDepsDisabled bool
 }
 
 // WorkflowHook is a map of custom run commands to run before or after workflows.
@@ -184,6 +222,18 @@ var DefaultStateRmStage = Stage{
 	},
 }
 
+// DefaultForceUnlockStage is the Atlantis default force_unlock stage.
+var DefaultForceUnlockStage = Stage{
+	Steps: []Step{
+		{
+			StepName: "init",
+		},
+		{
+			StepName: "force_unlock",
+		},
+	},
+}
+
 type GlobalCfgArgs struct {
 	RepoConfigFile string
 	// No longer a user option as of https://github.com/runatlantis/atlantis/pull/3911,
@@ -203,6 +253,7 @@ func NewGlobalCfgFromArgs(args GlobalCfgArgs) GlobalCfg {
 		PolicyCheck: DefaultPolicyCheckStage,
 		Import:      DefaultImportStage,
 		StateRm:     DefaultStateRmStage,
+		ForceUnlock: DefaultForceUnlockStage,
 	}
 	// Must construct slices here instead of using a `var` declaration because
 	// we treat nil slices differently.
@@ -413,8 +464,11 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		DependsOn:                 proj.DependsOn,
 		Name:                      proj.GetName(),
 		AutoplanEnabled:           proj.Autoplan.Enabled,
+		AutoplanMode:              proj.Autoplan.Mode,
+		AutoplanLabels:            proj.Autoplan.Labels,
 		TerraformDistribution:     proj.TerraformDistribution,
 		TerraformVersion:          proj.TerraformVersion,
+		Engine:                    proj.Engine,
 		RepoCfgVersion:            rCfg.Version,
 		PolicySets:                g.PolicySets,
 		DeleteSourceBranchOnMerge: deleteSourceBranchOnMerge,
@@ -423,6 +477,16 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		PolicyCheck:               policyCheck,
 		CustomPolicyCheck:         customPolicyCheck,
 		SilencePRComments:         silencePRComments,
+		AutoVarFiles:              proj.AutoVarFiles,
+		Environment:               proj.Environment,
+		WorkspaceQualified:        rCfg.WorkspaceQualified(proj),
+		VarFiles:                  proj.VarFiles,
+		ShowOutputs:               proj.ShowOutputs,
+		RestrictedPlanFlags:       g.PlanFlagRestrictions(repoID),
+		PRCommentModes:            g.PRCommentModes(repoID),
+		ResourceLimits:            g.RepoResourceLimits(repoID),
+		CloudCredentials:          proj.CloudCredentials,
+		OutdatedDepsDisabled:      !g.RepoOutdatedDependencies(repoID),
 	}
 }
 
@@ -448,6 +512,10 @@ func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repo
 		PolicyCheck:               policyCheck,
 		CustomPolicyCheck:         customPolicyCheck,
 		SilencePRComments:         silencePRComments,
+		RestrictedPlanFlags:       g.PlanFlagRestrictions(repoID),
+		PRCommentModes:            g.PRCommentModes(repoID),
+		ResourceLimits:            g.RepoResourceLimits(repoID),
+		OutdatedDepsDisabled:      !g.RepoOutdatedDependencies(repoID),
 	}
 }
 
@@ -508,6 +576,12 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 		if p.CustomPolicyCheck != nil && !utils.SlicesContains(allowedOverrides, CustomPolicyCheckKey) {
 			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", CustomPolicyCheckKey, AllowedOverridesKey, CustomPolicyCheckKey)
 		}
+		if p.Engine != "" && p.Engine != TerraformEngine && !utils.SlicesContains(allowedOverrides, EngineKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", EngineKey, AllowedOverridesKey, EngineKey)
+		}
+		if p.CloudCredentials != nil && !utils.SlicesContains(allowedOverrides, CloudCredentialsKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", CloudCredentialsKey, AllowedOverridesKey, CloudCredentialsKey)
+		}
 		if p.SilencePRComments != nil {
 			if !utils.SlicesContains(allowedOverrides, SilencePRCommentsKey) {
 				return fmt.Errorf(
@@ -717,3 +791,77 @@ func (g GlobalCfg) RepoConfigFile(repoID string) string {
 	}
 	return DefaultAtlantisFile
 }
+
+// ForkPRWorkflow returns the workflow that restricted fork pull requests run
+// for the repo with id repoID. If no matching repo sets fork_pr_workflow then
+// the built-in default workflow is used, even if the server-side config
+// redefines the default workflow.
+func (g GlobalCfg) ForkPRWorkflow(repoID string) Workflow {
+	workflow := Workflow{
+		Name:        DefaultWorkflowName,
+		Apply:       DefaultApplyStage,
+		Plan:        DefaultPlanStage,
+		PolicyCheck: DefaultPolicyCheckStage,
+		Import:      DefaultImportStage,
+		StateRm:     DefaultStateRmStage,
+		ForceUnlock: DefaultForceUnlockStage,
+	}
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ForkPRWorkflow != nil {
+			workflow = *repo.ForkPRWorkflow
+		}
+	}
+	return workflow
+}
+
+// RepoPlanDrafts returns whether draft pull requests of the repo with id
+// repoID are autoplanned. If no matching repo sets plan_drafts then
+// defaultPlanDrafts, set by the --allow-draft-prs flag, is returned.
+func (g GlobalCfg) RepoPlanDrafts(repoID string, defaultPlanDrafts bool) bool {
+	planDrafts := defaultPlanDrafts
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.PlanDrafts != nil {
+			planDrafts = *repo.PlanDrafts
+		}
+	}
+	return planDrafts
+}
+
+// RepoApplyConfirmation returns whether applies run from comments on pull
+// requests of the repo with id repoID must be confirmed before they run.
+func (g GlobalCfg) RepoApplyConfirmation(repoID string) bool {
+	applyConfirmation := false
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.ApplyConfirmation != nil {
+			applyConfirmation = *repo.ApplyConfirmation
+		}
+	}
+	return applyConfirmation
+}
+
+// RepoOutdatedDependencies returns whether the plans of the repo with id
+// repoID report the outdated providers and modules when the report is
+// enabled. It's true unless a matching repo sets outdated_dependencies to
+// false.
+func (g GlobalCfg) RepoOutdatedDependencies(repoID string) bool {
+	outdatedDependencies := true
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.OutdatedDependencies != nil {
+			outdatedDependencies = *repo.OutdatedDependencies
+		}
+	}
+	return outdatedDependencies
+}
+
+// RepoCommentDigestThreshold returns the number of projects above which the
+// results of commands on pull requests of the repo with id repoID are
+// commented as a digest. It returns 0 if they never are.
+func (g GlobalCfg) RepoCommentDigestThreshold(repoID string) int {
+	threshold := 0
+	for _, repo := range g.Repos {
+		if repo.IDMatches(repoID) && repo.CommentDigestThreshold != nil {
+			threshold = *repo.CommentDigestThreshold
+		}
+	}
+	return threshold
+}
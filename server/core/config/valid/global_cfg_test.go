@@ -66,6 +66,7 @@ func TestNewGlobalCfg(t *testing.T) {
 				},
 			},
 		},
+		ForceUnlock: valid.DefaultForceUnlockStage,
 	}
 	baseCfg := valid.GlobalCfg{
 		Repos: []valid.Repo{
@@ -491,6 +492,71 @@ func TestGlobalCfg_ValidateRepoCfg(t *testing.T) {
 			repoID: "github.com/owner/repo",
 			expErr: "repo config not allowed to set 'import_requirements' key: server-side config needs 'allowed_overrides: [import_requirements]'",
 		},
+		"repo uses cloud_credentials without allowed override": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+				AllowAllRepoSettings: true,
+			}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:              ".",
+						Workspace:        "default",
+						CloudCredentials: &valid.CloudCredentials{AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"}},
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "repo config not allowed to set 'cloud_credentials' key: server-side config needs 'allowed_overrides: [cloud_credentials]'",
+		},
+		"repo uses cloud_credentials with allowed override": {
+			gCfg: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					{
+						IDRegex:          regexp.MustCompile(".*"),
+						AllowedOverrides: []string{valid.CloudCredentialsKey},
+					},
+				},
+			},
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:              ".",
+						Workspace:        "default",
+						CloudCredentials: &valid.CloudCredentials{AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"}},
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+		},
+		"repo uses pulumi engine without allowed override": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
+				AllowAllRepoSettings: true,
+			}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:       ".",
+						Workspace: "default",
+						Engine:    valid.PulumiEngine,
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+			expErr: "repo config not allowed to set 'engine' key: server-side config needs 'allowed_overrides: [engine]'",
+		},
+		"repo uses terraform engine without allowed override": {
+			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+			rCfg: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir:       ".",
+						Workspace: "default",
+						Engine:    valid.TerraformEngine,
+					},
+				},
+			},
+			repoID: "github.com/owner/repo",
+		},
 		"repo workflow doesn't exist": {
 			gCfg: valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{
 				AllowAllRepoSettings: true,
@@ -555,6 +621,7 @@ policies:
 					PolicyCheck: valid.DefaultPolicyCheckStage,
 					Import:      valid.DefaultImportStage,
 					StateRm:     valid.DefaultStateRmStage,
+					ForceUnlock: valid.DefaultForceUnlockStage,
 				},
 				PolicySets: valid.PolicySets{
 					Version:      nil,
@@ -604,6 +671,7 @@ policies:
 					PolicyCheck: valid.DefaultPolicyCheckStage,
 					Import:      valid.DefaultImportStage,
 					StateRm:     valid.DefaultStateRmStage,
+					ForceUnlock: valid.DefaultForceUnlockStage,
 				},
 				PolicySets: valid.PolicySets{
 					Version:      version,
@@ -663,6 +731,7 @@ func TestGlobalCfg_MergeProjectCfg(t *testing.T) {
 		Plan:        valid.DefaultPlanStage,
 		Import:      valid.DefaultImportStage,
 		StateRm:     valid.DefaultStateRmStage,
+		ForceUnlock: valid.DefaultForceUnlockStage,
 	}
 	cases := map[string]struct {
 		gCfg          string
@@ -702,8 +771,9 @@ workflows:
 							},
 						},
 					},
-					Import:  valid.DefaultImportStage,
-					StateRm: valid.DefaultStateRmStage,
+					Import:      valid.DefaultImportStage,
+					StateRm:     valid.DefaultStateRmStage,
+					ForceUnlock: valid.DefaultForceUnlockStage,
 				},
 				RepoRelDir:        ".",
 				Workspace:         "default",
@@ -1162,6 +1232,7 @@ func TestGlobalCfg_PolicyCheckOverride(t *testing.T) {
 		Plan:        valid.DefaultPlanStage,
 		Import:      valid.DefaultImportStage,
 		StateRm:     valid.DefaultStateRmStage,
+		ForceUnlock: valid.DefaultForceUnlockStage,
 	}
 	cases := map[string]struct {
 		gPolicyCheck  bool
@@ -1403,3 +1474,197 @@ func String(v string) *string { return &v }
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v }
+
+func TestGlobalCfg_ForkPRWorkflow(t *testing.T) {
+	readonly := valid.Workflow{
+		Name: "readonly",
+		Plan: valid.Stage{
+			Steps: []valid.Step{
+				{StepName: "env", EnvVarName: "AWS_PROFILE", EnvVarValue: "readonly"},
+				{StepName: "init"},
+				{StepName: "plan"},
+			},
+		},
+	}
+	redefinedDefault := valid.Workflow{
+		Name: valid.DefaultWorkflowName,
+		Plan: valid.Stage{
+			Steps: []valid.Step{{StepName: "run", RunCommand: "make plan"}},
+		},
+	}
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:  regexp.MustCompile(".*"),
+				Workflow: &redefinedDefault,
+			},
+			{
+				ID:             "github.com/owner/repo",
+				ForkPRWorkflow: &readonly,
+			},
+		},
+	}
+
+	t.Run("uses built-in default workflow", func(t *testing.T) {
+		w := gCfg.ForkPRWorkflow("github.com/owner/other")
+		Equals(t, valid.DefaultWorkflowName, w.Name)
+		Equals(t, valid.DefaultPlanStage, w.Plan)
+		Assert(t, !w.RunsCustomCommands(), "built-in default workflow shouldn't run custom commands")
+	})
+
+	t.Run("uses matching fork_pr_workflow", func(t *testing.T) {
+		Equals(t, readonly, gCfg.ForkPRWorkflow("github.com/owner/repo"))
+	})
+}
+
+func TestGlobalCfg_RepoPlanDrafts(t *testing.T) {
+	planDrafts := true
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				ID:         "github.com/owner/repo",
+				PlanDrafts: &planDrafts,
+			},
+		},
+	}
+	Equals(t, false, gCfg.RepoPlanDrafts("github.com/owner/other", false))
+	Equals(t, true, gCfg.RepoPlanDrafts("github.com/owner/other", true))
+	Equals(t, true, gCfg.RepoPlanDrafts("github.com/owner/repo", false))
+}
+
+func TestGlobalCfg_RepoOutdatedDependencies(t *testing.T) {
+	outdatedDependencies := false
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				ID:                   "github.com/owner/repo",
+				OutdatedDependencies: &outdatedDependencies,
+			},
+		},
+	}
+	Equals(t, true, gCfg.RepoOutdatedDependencies("github.com/owner/other"))
+	Equals(t, false, gCfg.RepoOutdatedDependencies("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_RepoApplyConfirmation(t *testing.T) {
+	applyConfirmation := true
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				ID:                "github.com/owner/repo",
+				ApplyConfirmation: &applyConfirmation,
+			},
+		},
+	}
+	Equals(t, false, gCfg.RepoApplyConfirmation("github.com/owner/other"))
+	Equals(t, true, gCfg.RepoApplyConfirmation("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_RepoCommentDigestThreshold(t *testing.T) {
+	threshold := 10
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				ID:                     "github.com/owner/repo",
+				CommentDigestThreshold: &threshold,
+			},
+		},
+	}
+	Equals(t, 0, gCfg.RepoCommentDigestThreshold("github.com/owner/other"))
+	Equals(t, 10, gCfg.RepoCommentDigestThreshold("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_RepoConfigRepo(t *testing.T) {
+	configRepo := &valid.ConfigRepo{
+		Repo:   "owner/atlantis-config",
+		Branch: "main",
+		Path:   valid.DefaultConfigRepoPath,
+	}
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+			},
+			{
+				IDRegex:    regexp.MustCompile("github.com/owner/.*"),
+				ConfigRepo: configRepo,
+			},
+		},
+	}
+	Assert(t, gCfg.RepoConfigRepo("github.com/other/repo") == nil, "exp no config repo")
+	Equals(t, configRepo, gCfg.RepoConfigRepo("github.com/owner/repo"))
+	Equals(t, "owner/repo/atlantis.yaml", configRepo.RepoCfgPath("owner/repo"))
+}
+
+func TestGlobalCfg_ValidateConfigRepoHosts(t *testing.T) {
+	configRepo := &valid.ConfigRepo{
+		Repo:   "owner/atlantis-config",
+		Branch: "main",
+		Path:   valid.DefaultConfigRepoPath,
+	}
+	hosts := []string{"bitbucket.org", "dev.azure.com"}
+	cases := []struct {
+		description string
+		repo        valid.Repo
+		expErr      string
+	}{
+		{
+			description: "no config repo",
+			repo:        valid.Repo{IDRegex: regexp.MustCompile(".*")},
+		},
+		{
+			description: "id on other host",
+			repo:        valid.Repo{ID: "github.com/owner/repo", ConfigRepo: configRepo},
+		},
+		{
+			description: "id on host",
+			repo:        valid.Repo{ID: "bitbucket.org/owner/repo", ConfigRepo: configRepo},
+			expErr:      "repo bitbucket.org/owner/repo: config_repo can't be used for repos on bitbucket.org since it doesn't support downloading single files",
+		},
+		{
+			description: "regex matching every host",
+			repo:        valid.Repo{IDRegex: regexp.MustCompile(".*"), ConfigRepo: configRepo},
+			expErr:      "repo /.*/: config_repo can't be used for repos on bitbucket.org since it doesn't support downloading single files",
+		},
+		{
+			description: "regex on host",
+			repo:        valid.Repo{IDRegex: regexp.MustCompile(`^dev\.azure\.com/owner/.*`), ConfigRepo: configRepo},
+			expErr:      `repo /^dev\.azure\.com/owner/.*/: config_repo can't be used for repos on dev.azure.com since it doesn't support downloading single files`,
+		},
+		{
+			description: "regex on other host",
+			repo:        valid.Repo{IDRegex: regexp.MustCompile("github.com/owner/.*"), ConfigRepo: configRepo},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := valid.GlobalCfg{Repos: []valid.Repo{c.repo}}.ValidateConfigRepoHosts(hosts)
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestReloadableGlobalCfg_LoadOr(t *testing.T) {
+	static := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	var unset *valid.ReloadableGlobalCfg
+	Equals(t, static, unset.LoadOr(static))
+
+	reloaded := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{PolicyCheckEnabled: true})
+	Equals(t, reloaded, valid.NewReloadableGlobalCfg(reloaded).LoadOr(static))
+}
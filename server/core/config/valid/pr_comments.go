@@ -0,0 +1,46 @@
+package valid
+
+// PRCommentsKey is the server-side repo config key choosing, per command,
+// when Atlantis comments the results of the command on pull requests.
+const PRCommentsKey = "pr_comments"
+
+// PRCommentCommands are the commands whose comments can be configured with
+// pr_comments.
+var PRCommentCommands = []string{"plan", "apply", "policy_check"}
+
+const (
+	// AlwaysPRComments comments the results of every project. It's the
+	// default.
+	AlwaysPRComments = "always"
+	// OnFailurePRComments only comments the results of projects that failed.
+	OnFailurePRComments = "on_failure"
+	// StatusOnlyPRComments doesn't comment but still sets the commit
+	// statuses of the projects.
+	StatusOnlyPRComments = "status_only"
+	// SilentPRComments doesn't comment or set the commit statuses of the
+	// projects. The overall commit status of the command is still set since
+	// branch protection may require it.
+	SilentPRComments = "silent"
+)
+
+// PRCommentModes are the modes a command can be set to in pr_comments.
+var PRCommentModes = []string{AlwaysPRComments, OnFailurePRComments, StatusOnlyPRComments, SilentPRComments}
+
+// PRCommentModes returns the pr_comments mode of each configured command on
+// repoID. Like the other repo settings, later matching repos override earlier
+// ones.
+func (g GlobalCfg) PRCommentModes(repoID string) map[string]string {
+	var modes map[string]string
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		for cmd, mode := range repo.PRComments {
+			if modes == nil {
+				modes = make(map[string]string)
+			}
+			modes[cmd] = mode
+		}
+	}
+	return modes
+}
@@ -0,0 +1,41 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGlobalCfg_PRCommentModes(t *testing.T) {
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+				PRComments: map[string]string{
+					"plan":  valid.OnFailurePRComments,
+					"apply": valid.StatusOnlyPRComments,
+				},
+			},
+			{
+				ID: "github.com/owner/prod",
+				PRComments: map[string]string{
+					"plan":         valid.AlwaysPRComments,
+					"policy_check": valid.SilentPRComments,
+				},
+			},
+		},
+	}
+
+	Equals(t, map[string]string{
+		"plan":         valid.AlwaysPRComments,
+		"apply":        valid.StatusOnlyPRComments,
+		"policy_check": valid.SilentPRComments,
+	}, globalCfg.PRCommentModes("github.com/owner/prod"))
+	Equals(t, map[string]string{
+		"plan":  valid.OnFailurePRComments,
+		"apply": valid.StatusOnlyPRComments,
+	}, globalCfg.PRCommentModes("github.com/owner/dev"))
+	Equals(t, map[string]string(nil), valid.GlobalCfg{}.PRCommentModes("github.com/owner/dev"))
+}
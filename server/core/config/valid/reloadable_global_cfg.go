@@ -0,0 +1,36 @@
+package valid
+
+import "sync/atomic"
+
+// ReloadableGlobalCfg holds a GlobalCfg that can be swapped while the server
+// is running, ex. when the server-side repo config is reloaded. Commands that
+// are running when it's swapped finish with the GlobalCfg they loaded.
+type ReloadableGlobalCfg struct {
+	cfg atomic.Pointer[GlobalCfg]
+}
+
+// NewReloadableGlobalCfg returns a ReloadableGlobalCfg holding cfg.
+func NewReloadableGlobalCfg(cfg GlobalCfg) *ReloadableGlobalCfg {
+	r := &ReloadableGlobalCfg{}
+	r.Store(cfg)
+	return r
+}
+
+// Load returns the current GlobalCfg.
+func (r *ReloadableGlobalCfg) Load() GlobalCfg {
+	return *r.cfg.Load()
+}
+
+// LoadOr returns the current GlobalCfg or cfg if r is nil. It's used by
+// components that can be constructed with a static GlobalCfg.
+func (r *ReloadableGlobalCfg) LoadOr(cfg GlobalCfg) GlobalCfg {
+	if r == nil {
+		return cfg
+	}
+	return r.Load()
+}
+
+// Store replaces the current GlobalCfg with cfg.
+func (r *ReloadableGlobalCfg) Store(cfg GlobalCfg) {
+	r.cfg.Store(&cfg)
+}
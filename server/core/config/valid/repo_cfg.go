@@ -5,8 +5,10 @@ package valid
 import (
 	"fmt"
 	"log"
+	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	version "github.com/hashicorp/go-version"
@@ -82,6 +84,21 @@ func (r RepoCfg) FindProjectsByName(name string) []Project {
 	return ps
 }
 
+// WorkspaceQualified returns true if proj shares its name with projects
+// without environments in other workspaces, so it's identified by its name
+// and workspace.
+func (r RepoCfg) WorkspaceQualified(proj Project) bool {
+	if proj.Name == nil || proj.Environment != "" {
+		return false
+	}
+	for _, p := range r.Projects {
+		if p.Name != nil && *p.Name == *proj.Name && p.Environment == "" && p.Workspace != proj.Workspace {
+			return true
+		}
+	}
+	return false
+}
+
 func isRegexAllowed(name string, allowedRegexpPrefixes []string) bool {
 	if len(allowedRegexpPrefixes) == 0 {
 		return true
@@ -157,14 +174,29 @@ func (r RepoCfg) ValidateWorkspaceAllowed(repoRelDir string, workspace string) e
 	)
 }
 
+const (
+	// TerraformEngine runs the commands of a project with Terraform or
+	// OpenTofu. It's the default engine.
+	TerraformEngine = "terraform"
+	// PulumiEngine runs the plans and applies of a project with Pulumi's
+	// preview and up commands. It's experimental.
+	PulumiEngine = "pulumi"
+	// CheckEngine only runs the custom steps of a project's plan stage, ex.
+	// validation commands. Nothing is planned so there's nothing to apply.
+	CheckEngine = "check"
+)
+
 type Project struct {
-	Dir                       string
-	BranchRegex               *regexp.Regexp
-	Workspace                 string
-	Name                      *string
-	WorkflowName              *string
-	TerraformDistribution     *string
-	TerraformVersion          *version.Version
+	Dir                   string
+	BranchRegex           *regexp.Regexp
+	Workspace             string
+	Name                  *string
+	WorkflowName          *string
+	TerraformDistribution *string
+	TerraformVersion      *version.Version
+	// Engine runs the project's commands, ex. pulumi. If empty, it's
+	// terraform.
+	Engine                    string
 	Autoplan                  Autoplan
 	PlanRequirements          []string
 	ApplyRequirements         []string
@@ -177,6 +209,18 @@ type Project struct {
 	PolicyCheck               *bool
 	CustomPolicyCheck         *bool
 	SilencePRComments         []string
+	AutoVarFiles              AutoVarFiles
+	// Environment is the name of the environment this project is for if the
+	// project has environments.
+	Environment string
+	// VarFiles are passed to plan as -var-file arguments.
+	VarFiles []string
+	// ShowOutputs are the names of the terraform outputs shown in the apply
+	// comment.
+	ShowOutputs []string
+	// CloudCredentials are minted for the steps of the project's commands if
+	// set.
+	CloudCredentials *CloudCredentials
 }
 
 // GetName returns the name of the project or an empty string if there is no
@@ -191,6 +235,44 @@ func (p Project) GetName() string {
 type Autoplan struct {
 	WhenModified []string
 	Enabled      bool
+	// Mode is one of the AutoplanMode constants. It's empty if not set, which
+	// behaves like AutoplanModeChanged.
+	Mode string
+	// Labels are the pull request labels that trigger an autoplan if Mode is
+	// AutoplanModeLabeled.
+	Labels []string
+}
+
+const (
+	// AutoplanModeChanged plans modified projects when a pull request is
+	// opened, updated or marked as ready for review.
+	AutoplanModeChanged = "changed"
+	// AutoplanModeChangedOnlyWithLockfile is like AutoplanModeChanged but only
+	// counts modified Terraform files and dependency lock files.
+	AutoplanModeChangedOnlyWithLockfile = "changed_only_with_lockfile"
+	// AutoplanModeReadyForReview plans modified projects only when a pull
+	// request is opened or marked as ready for review, not on new commits.
+	AutoplanModeReadyForReview = "ready_for_review"
+	// AutoplanModeLabeled plans modified projects only when one of the
+	// autoplan labels is added to the pull request.
+	AutoplanModeLabeled = "labeled"
+)
+
+// AutoplanModes are the valid values of Autoplan.Mode.
+var AutoplanModes = []string{
+	AutoplanModeChanged,
+	AutoplanModeChangedOnlyWithLockfile,
+	AutoplanModeReadyForReview,
+	AutoplanModeLabeled,
+}
+
+// AutoVarFiles configures the var file that is automatically passed to
+// terraform plan if it exists in the project directory.
+type AutoVarFiles struct {
+	Enabled bool
+	// Template is a Go template for the path of the var file, relative to the
+	// project directory. It can reference .Workspace and .ProjectName.
+	Template string
 }
 
 // PostProcessRunOutputOption is an enum of options for post-processing RunCommand output
@@ -204,6 +286,9 @@ const (
 
 type Stage struct {
 	Steps []Step
+	// Timeout, if set, is how long all the steps can run for before they're
+	// stopped.
+	Timeout time.Duration
 }
 
 // CommandShell sets up the shell for command execution
@@ -216,6 +301,27 @@ func (s CommandShell) String() string {
 	return fmt.Sprintf("%s %s", s.Shell, strings.Join(s.ShellArgs, " "))
 }
 
+// ShellName returns the name of shell without its directory or .exe
+// extension, ex. cmd for C:\Windows\System32\cmd.exe.
+func ShellName(shell string) string {
+	return strings.TrimSuffix(strings.ToLower(path.Base(strings.ReplaceAll(shell, `\`, "/"))), ".exe")
+}
+
+// DefaultShellArgs returns the arguments passed to shell before the command
+// when none are configured. POSIX shells run it with -c while the Windows
+// shells, cmd and powershell, need their own flags.
+func DefaultShellArgs(shell string) []string {
+	switch ShellName(shell) {
+	case "cmd":
+		// /S makes cmd run the quoted command as is.
+		return []string{"/S", "/C"}
+	case "powershell", "pwsh":
+		return []string{"-NoProfile", "-NonInteractive", "-Command"}
+	default:
+		return []string{"-c"}
+	}
+}
+
 type Step struct {
 	StepName  string
 	ExtraArgs []string
@@ -231,6 +337,16 @@ type Step struct {
 	EnvVarValue string
 	// The Shell to use for RunCommand execution.
 	RunShell *CommandShell
+	// Timeout, if set, is how long the step can run for before it's stopped.
+	Timeout time.Duration
+	// Suggest is whether an fmt_check step posts the formatting fixes as
+	// suggestions on the pull request.
+	Suggest bool
+	// WarnOnly is whether a lockfile_check step only warns about the
+	// problems it finds instead of failing.
+	WarnOnly bool
+	// Checkout is the repository a checkout step clones.
+	Checkout *Checkout
 }
 
 type Workflow struct {
@@ -240,4 +356,23 @@ type Workflow struct {
 	PolicyCheck Stage
 	Import      Stage
 	StateRm     Stage
+	ForceUnlock Stage
+}
+
+// RunsCustomCommands returns true if any stage of w has a step that runs an
+// arbitrary command: run and multienv steps, and env steps with a command.
+func (w Workflow) RunsCustomCommands() bool {
+	for _, stage := range []Stage{w.Apply, w.Plan, w.PolicyCheck, w.Import, w.StateRm, w.ForceUnlock} {
+		for _, step := range stage.Steps {
+			switch step.StepName {
+			case "run", "multienv":
+				return true
+			case "env":
+				if step.RunCommand != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
 }
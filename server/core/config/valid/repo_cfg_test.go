@@ -1,6 +1,7 @@
 package valid_test
 
 import (
+	"regexp"
 	"testing"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -415,3 +416,82 @@ func TestConfig_IsPathIgnoredForAutoDiscover(t *testing.T) {
 		})
 	}
 }
+
+func TestAutoDiscover_InferWorkspace(t *testing.T) {
+	autoDiscover := valid.AutoDiscover{
+		WorkspacePatterns: []*regexp.Regexp{
+			regexp.MustCompile(`^envs/(?P<workspace>\w+)/`),
+			regexp.MustCompile(`^regions/\w+/(?P<workspace>\w+)/`),
+		},
+	}
+	cases := []struct {
+		path         string
+		expWorkspace string
+		expOk        bool
+	}{
+		{"envs/prod", "prod", true},
+		{"envs/staging/app", "staging", true},
+		{"./envs/dev/", "dev", true},
+		{"regions/eu/prod", "prod", true},
+		{"modules/vpc", "", false},
+		{"envs", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			workspace, ok := autoDiscover.InferWorkspace(c.path)
+			Equals(t, c.expOk, ok)
+			Equals(t, c.expWorkspace, workspace)
+		})
+	}
+}
+
+func TestWorkflow_RunsCustomCommands(t *testing.T) {
+	cases := map[string]struct {
+		steps []valid.Step
+		exp   bool
+	}{
+		"built-in steps": {
+			steps: []valid.Step{{StepName: "init"}, {StepName: "plan", ExtraArgs: []string{"-lock=false"}}},
+			exp:   false,
+		},
+		"env with value": {
+			steps: []valid.Step{{StepName: "env", EnvVarName: "AWS_PROFILE", EnvVarValue: "readonly"}},
+			exp:   false,
+		},
+		"env with command": {
+			steps: []valid.Step{{StepName: "env", EnvVarName: "TOKEN", RunCommand: "cat token"}},
+			exp:   true,
+		},
+		"run": {
+			steps: []valid.Step{{StepName: "run", RunCommand: "echo hi"}},
+			exp:   true,
+		},
+		"multienv": {
+			steps: []valid.Step{{StepName: "multienv", RunCommand: "./envs.sh"}},
+			exp:   true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := valid.Workflow{PolicyCheck: valid.Stage{Steps: c.steps}}
+			Equals(t, c.exp, w.RunsCustomCommands())
+		})
+	}
+}
+
+func TestDefaultShellArgs(t *testing.T) {
+	cases := map[string][]string{
+		"sh":                               {"-c"},
+		"/bin/bash":                        {"-c"},
+		"cmd":                              {"/S", "/C"},
+		`C:\Windows\System32\cmd.exe`:      {"/S", "/C"},
+		"powershell":                       {"-NoProfile", "-NonInteractive", "-Command"},
+		"PowerShell.exe":                   {"-NoProfile", "-NonInteractive", "-Command"},
+		`C:\Program Files\PowerShell\pwsh`: {"-NoProfile", "-NonInteractive", "-Command"},
+	}
+	for shell, exp := range cases {
+		t.Run(shell, func(t *testing.T) {
+			Equals(t, exp, valid.DefaultShellArgs(shell))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package valid
+
+import "time"
+
+// ResourceLimitsKey is the server-side repo config key limiting the resources
+// of the commands run for a repo's projects.
+const ResourceLimitsKey = "resource_limits"
+
+// ResourceLimits limits the resources the terraform and run step processes
+// of a project's commands can use. Zero values aren't limited.
+type ResourceLimits struct {
+	// CPU is how many CPUs the processes of a command can use, ex. 0.5.
+	CPU float64
+	// Memory is how many bytes of memory the processes of a command can use.
+	Memory int64
+	// Time is how long the steps of a command can run for, like the timeout
+	// of a workflow stage.
+	Time time.Duration
+}
+
+// NeedsCgroup returns true if the limits are enforced with a cgroup.
+func (r ResourceLimits) NeedsCgroup() bool {
+	return r.CPU > 0 || r.Memory > 0
+}
+
+// RepoResourceLimits returns the resource limits of repoID. Like the other
+// repo settings, later matching repos override the limits they set.
+func (g GlobalCfg) RepoResourceLimits(repoID string) ResourceLimits {
+	var limits ResourceLimits
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) || repo.ResourceLimits == nil {
+			continue
+		}
+		if repo.ResourceLimits.CPU > 0 {
+			limits.CPU = repo.ResourceLimits.CPU
+		}
+		if repo.ResourceLimits.Memory > 0 {
+			limits.Memory = repo.ResourceLimits.Memory
+		}
+		if repo.ResourceLimits.Time > 0 {
+			limits.Time = repo.ResourceLimits.Time
+		}
+	}
+	return limits
+}
+
+// HasCgroupResourceLimits returns true if any repo limits resources that are
+// enforced with a cgroup.
+func (g GlobalCfg) HasCgroupResourceLimits() bool {
+	for _, repo := range g.Repos {
+		if repo.ResourceLimits != nil && repo.ResourceLimits.NeedsCgroup() {
+			return true
+		}
+	}
+	return false
+}
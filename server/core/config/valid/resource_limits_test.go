@@ -0,0 +1,35 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGlobalCfg_RepoResourceLimits(t *testing.T) {
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex:        regexp.MustCompile(".*"),
+				ResourceLimits: &valid.ResourceLimits{CPU: 2, Memory: 1 << 30, Time: time.Hour},
+			},
+			{
+				ID:             "github.com/owner/big",
+				ResourceLimits: &valid.ResourceLimits{Memory: 8 << 30},
+			},
+		},
+	}
+
+	Equals(t, valid.ResourceLimits{CPU: 2, Memory: 8 << 30, Time: time.Hour}, globalCfg.RepoResourceLimits("github.com/owner/big"))
+	Equals(t, valid.ResourceLimits{CPU: 2, Memory: 1 << 30, Time: time.Hour}, globalCfg.RepoResourceLimits("github.com/owner/small"))
+	Equals(t, valid.ResourceLimits{}, valid.GlobalCfg{}.RepoResourceLimits("github.com/owner/small"))
+	Assert(t, globalCfg.HasCgroupResourceLimits(), "expected cgroup resource limits")
+
+	timeOnly := valid.GlobalCfg{
+		Repos: []valid.Repo{{ID: "github.com/owner/big", ResourceLimits: &valid.ResourceLimits{Time: time.Hour}}},
+	}
+	Assert(t, !timeOnly.HasCgroupResourceLimits(), "expected no cgroup resource limits")
+}
@@ -0,0 +1,35 @@
+package valid
+
+// RestrictedPlanFlagsKey is the server-side repo config key restricting the
+// extra arguments that can be passed to plan in comments.
+const RestrictedPlanFlagsKey = "restricted_plan_flags"
+
+// RestrictablePlanFlags are the plan flags that can be restricted with
+// restricted_plan_flags.
+var RestrictablePlanFlags = []string{"-target", "-destroy", "-replace"}
+
+const (
+	// DenyPlanFlag never allows the flag to be used.
+	DenyPlanFlag = "deny"
+	// ApprovedPlanFlag only allows the flag once the pull request is approved.
+	ApprovedPlanFlag = "approved"
+)
+
+// PlanFlagRestrictions returns how each restricted plan flag is restricted on
+// repoID. Like the other repo settings, later matching repos override earlier
+// ones.
+func (g GlobalCfg) PlanFlagRestrictions(repoID string) map[string]string {
+	var restrictions map[string]string
+	for _, repo := range g.Repos {
+		if !repo.IDMatches(repoID) {
+			continue
+		}
+		for flag, restriction := range repo.RestrictedPlanFlags {
+			if restrictions == nil {
+				restrictions = make(map[string]string)
+			}
+			restrictions[flag] = restriction
+		}
+	}
+	return restrictions
+}
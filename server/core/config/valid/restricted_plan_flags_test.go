@@ -0,0 +1,39 @@
+package valid_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGlobalCfg_PlanFlagRestrictions(t *testing.T) {
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{
+				IDRegex: regexp.MustCompile(".*"),
+				RestrictedPlanFlags: map[string]string{
+					"-target":  valid.DenyPlanFlag,
+					"-destroy": valid.ApprovedPlanFlag,
+				},
+			},
+			{
+				ID: "github.com/owner/prod",
+				RestrictedPlanFlags: map[string]string{
+					"-destroy": valid.DenyPlanFlag,
+				},
+			},
+		},
+	}
+
+	Equals(t, map[string]string{
+		"-target":  valid.DenyPlanFlag,
+		"-destroy": valid.DenyPlanFlag,
+	}, globalCfg.PlanFlagRestrictions("github.com/owner/prod"))
+	Equals(t, map[string]string{
+		"-target":  valid.DenyPlanFlag,
+		"-destroy": valid.ApprovedPlanFlag,
+	}, globalCfg.PlanFlagRestrictions("github.com/owner/dev"))
+	Equals(t, map[string]string(nil), valid.GlobalCfg{}.PlanFlagRestrictions("github.com/owner/dev"))
+}
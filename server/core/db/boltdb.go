@@ -3,7 +3,6 @@ package db
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -11,6 +10,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	bolt "go.etcd.io/bbolt"
@@ -22,12 +22,18 @@ type BoltDB struct {
 	locksBucketName       []byte
 	pullsBucketName       []byte
 	globalLocksBucketName []byte
+	settingsBucketName    []byte
+	appliesBucketName     []byte
+	// Cipher encrypts the stored records. If nil, they aren't encrypted.
+	Cipher *locking.RecordCipher
 }
 
 const (
 	locksBucketName       = "runLocks"
 	pullsBucketName       = "pulls"
 	globalLocksBucketName = "globalLocks"
+	settingsBucketName    = "settings"
+	appliesBucketName     = "applies"
 	pullKeySeparator      = "::"
 )
 
@@ -56,6 +62,12 @@ func New(dataDir string) (*BoltDB, error) {
 		if _, err = tx.CreateBucketIfNotExists([]byte(globalLocksBucketName)); err != nil {
 			return errors.Wrapf(err, "creating bucket %q", globalLocksBucketName)
 		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(settingsBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", settingsBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(appliesBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", appliesBucketName)
+		}
 		return nil
 	})
 	if err != nil {
@@ -67,6 +79,8 @@ func New(dataDir string) (*BoltDB, error) {
 		locksBucketName:       []byte(locksBucketName),
 		pullsBucketName:       []byte(pullsBucketName),
 		globalLocksBucketName: []byte(globalLocksBucketName),
+		settingsBucketName:    []byte(settingsBucketName),
+		appliesBucketName:     []byte(appliesBucketName),
 	}, nil
 }
 
@@ -77,6 +91,8 @@ func NewWithDB(db *bolt.DB, bucket string, globalBucket string) (*BoltDB, error)
 		locksBucketName:       []byte(bucket),
 		pullsBucketName:       []byte(pullsBucketName),
 		globalLocksBucketName: []byte(globalBucket),
+		settingsBucketName:    []byte(settingsBucketName),
+		appliesBucketName:     []byte(appliesBucketName),
 	}, nil
 }
 
@@ -88,7 +104,7 @@ func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 	var lockAcquired bool
 	var currLock models.ProjectLock
 	key := b.lockKey(newLock.Project, newLock.Workspace)
-	newLockSerialized, _ := json.Marshal(newLock)
+	newLockSerialized, _ := b.Cipher.Marshal(newLock)
 	transactionErr := b.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.locksBucketName)
 
@@ -103,7 +119,7 @@ func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 		}
 
 		// otherwise the lock fails, return to caller the run that's holding the lock
-		if err := json.Unmarshal(currLockSerialized, &currLock); err != nil {
+		if err := b.Cipher.Unmarshal(currLockSerialized, &currLock); err != nil {
 			return errors.Wrap(err, "failed to deserialize current lock")
 		}
 		lockAcquired = false
@@ -129,7 +145,7 @@ func (b *BoltDB) Unlock(p models.Project, workspace string) (*models.ProjectLock
 		bucket := tx.Bucket(b.locksBucketName)
 		serialized := bucket.Get([]byte(key))
 		if serialized != nil {
-			if err := json.Unmarshal(serialized, &lock); err != nil {
+			if err := b.Cipher.Unmarshal(serialized, &lock); err != nil {
 				return errors.Wrap(err, "failed to deserialize lock")
 			}
 			foundLock = true
@@ -164,7 +180,7 @@ func (b *BoltDB) List() ([]models.ProjectLock, error) {
 	// deserialize bytes into the proper objects
 	for k, v := range locksBytes {
 		var lock models.ProjectLock
-		if err := json.Unmarshal(v, &lock); err != nil {
+		if err := b.Cipher.Unmarshal(v, &lock); err != nil {
 			return locks, errors.Wrap(err, fmt.Sprintf("failed to deserialize lock at key '%d'", k))
 		}
 		locks = append(locks, lock)
@@ -184,7 +200,7 @@ func (b *BoltDB) LockCommand(cmdName command.Name, lockTime time.Time) (*command
 		},
 	}
 
-	newLockSerialized, _ := json.Marshal(lock)
+	newLockSerialized, _ := b.Cipher.Marshal(lock)
 	transactionErr := b.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.globalLocksBucketName)
 
@@ -238,7 +254,7 @@ func (b *BoltDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error) {
 		serializedLock := bucket.Get([]byte(b.commandLockKey(cmdName)))
 
 		if serializedLock != nil {
-			if err := json.Unmarshal(serializedLock, &cmdLock); err != nil {
+			if err := b.Cipher.Unmarshal(serializedLock, &cmdLock); err != nil {
 				return errors.Wrap(err, "failed to deserialize UserConfig")
 			}
 			found = true
@@ -263,7 +279,7 @@ func (b *BoltDB) UnlockByPull(repoFullName string, pullNum int) ([]models.Projec
 		// we can use the repoFullName as a prefix search since that's the first part of the key
 		for k, v := c.Seek([]byte(repoFullName)); k != nil && bytes.HasPrefix(k, []byte(repoFullName)); k, v = c.Next() {
 			var lock models.ProjectLock
-			if err := json.Unmarshal(v, &lock); err != nil {
+			if err := b.Cipher.Unmarshal(v, &lock); err != nil {
 				return errors.Wrapf(err, "deserializing lock at key %q", string(k))
 			}
 			if lock.Pull.Num == pullNum {
@@ -304,7 +320,7 @@ func (b *BoltDB) GetLock(p models.Project, workspace string) (*models.ProjectLoc
 	}
 
 	var lock models.ProjectLock
-	if err := json.Unmarshal(lockBytes, &lock); err != nil {
+	if err := b.Cipher.Unmarshal(lockBytes, &lock); err != nil {
 		return nil, errors.Wrapf(err, "deserializing lock at key %q", key)
 	}
 
@@ -340,6 +356,11 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 				Pull:     pull,
 				Projects: statuses,
 			}
+			// The history covers the whole pull request so it's kept across
+			// commits.
+			if currStatus != nil {
+				newStatus.History = currStatus.History
+			}
 		} else {
 			// If there's an existing pull at the right commit then we have to
 			// merge our project results with the existing ones. We do a merge
@@ -359,6 +380,9 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 						res.ProjectName == proj.ProjectName {
 
 						proj.Status = res.PlanStatus()
+						if res.PlanSuccess != nil {
+							proj.PlannedResources = res.PlannedResources()
+						}
 
 						// Updating only policy sets which are included in results; keeping the rest.
 						if len(proj.PolicyStatus) > 0 {
@@ -386,6 +410,17 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 			}
 		}
 
+		now := time.Now()
+		appliesBucket := tx.Bucket(b.appliesBucketName)
+		for _, res := range newResults {
+			newStatus.AddHistory(res.CommandRun(pull, now))
+			if apply, ok := res.ProjectApply(pull, now); ok {
+				if err := b.writeApplyToBucket(appliesBucket, apply); err != nil {
+					return err
+				}
+			}
+		}
+
 		// Now, we overwrite the key with our new status.
 		return b.writePullToBucket(bucket, key, newStatus)
 	})
@@ -488,6 +523,18 @@ func (b *BoltDB) lockKey(p models.Project, workspace string) string {
 	return fmt.Sprintf("%s/%s/%s", p.RepoFullName, p.Path, workspace)
 }
 
+func (b *BoltDB) applyKey(apply models.ProjectApply) string {
+	return fmt.Sprintf("%s/%s/%s/%s", apply.RepoFullName, apply.RepoRelDir, apply.Workspace, apply.ProjectName)
+}
+
+func (b *BoltDB) writeApplyToBucket(bucket *bolt.Bucket, apply models.ProjectApply) error {
+	serialized, err := b.Cipher.Marshal(apply)
+	if err != nil {
+		return errors.Wrap(err, "serializing apply")
+	}
+	return bucket.Put([]byte(b.applyKey(apply)), serialized)
+}
+
 func (b *BoltDB) getPullFromBucket(bucket *bolt.Bucket, key []byte) (*models.PullStatus, error) {
 	serialized := bucket.Get(key)
 	if serialized == nil {
@@ -495,14 +542,14 @@ func (b *BoltDB) getPullFromBucket(bucket *bolt.Bucket, key []byte) (*models.Pul
 	}
 
 	var p models.PullStatus
-	if err := json.Unmarshal(serialized, &p); err != nil {
+	if err := b.Cipher.Unmarshal(serialized, &p); err != nil {
 		return nil, errors.Wrapf(err, "deserializing pull at %q with contents %q", key, serialized)
 	}
 	return &p, nil
 }
 
 func (b *BoltDB) writePullToBucket(bucket *bolt.Bucket, key []byte, pull models.PullStatus) error {
-	serialized, err := json.Marshal(pull)
+	serialized, err := b.Cipher.Marshal(pull)
 	if err != nil {
 		return errors.Wrap(err, "serializing")
 	}
@@ -511,12 +558,212 @@ func (b *BoltDB) writePullToBucket(bucket *bolt.Bucket, key []byte, pull models.
 
 func (b *BoltDB) projectResultToProject(p command.ProjectResult) models.ProjectStatus {
 	return models.ProjectStatus{
-		Workspace:    p.Workspace,
-		RepoRelDir:   p.RepoRelDir,
-		ProjectName:  p.ProjectName,
-		PolicyStatus: p.PolicyStatus(),
-		Status:       p.PlanStatus(),
+		Workspace:        p.Workspace,
+		RepoRelDir:       p.RepoRelDir,
+		ProjectName:      p.ProjectName,
+		PolicyStatus:     p.PolicyStatus(),
+		Status:           p.PlanStatus(),
+		PlannedResources: p.PlannedResources(),
+	}
+}
+
+// GetSetting returns the value of the setting and whether it's set.
+func (b *BoltDB) GetSetting(name string) (string, bool, error) {
+	var value string
+	var ok bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		// The value is only valid during the transaction so we copy it.
+		if v := tx.Bucket(b.settingsBucketName).Get([]byte(name)); v != nil {
+			decrypted, err := b.Cipher.Decrypt(v)
+			if err != nil {
+				return errors.Wrapf(err, "decrypting setting %q", name)
+			}
+			value, ok = string(decrypted), true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", false, errors.Wrap(err, "DB transaction failed")
+	}
+	return value, ok, nil
+}
+
+// SetSetting sets the setting to value.
+func (b *BoltDB) SetSetting(name string, value string) error {
+	encrypted, err := b.Cipher.Encrypt([]byte(value))
+	if err != nil {
+		return errors.Wrap(err, "encrypting setting")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.settingsBucketName).Put([]byte(name), encrypted)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteSetting unsets the setting.
+func (b *BoltDB) DeleteSetting(name string) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.settingsBucketName).Delete([]byte(name))
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// Snapshot returns all locks, pull statuses, command locks, settings and
+// applies.
+func (b *BoltDB) Snapshot() (locking.Snapshot, error) {
+	snapshot := locking.Snapshot{
+		Version:   locking.SnapshotVersion,
+		CreatedAt: time.Now(),
+	}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(b.locksBucketName).ForEach(func(k, v []byte) error {
+			var lock models.ProjectLock
+			if err := b.Cipher.Unmarshal(v, &lock); err != nil {
+				return errors.Wrapf(err, "deserializing lock at %q", k)
+			}
+			snapshot.Locks = append(snapshot.Locks, lock)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Bucket(b.pullsBucketName).ForEach(func(k, v []byte) error {
+			var pull models.PullStatus
+			if err := b.Cipher.Unmarshal(v, &pull); err != nil {
+				return errors.Wrapf(err, "deserializing pull at %q", k)
+			}
+			snapshot.Pulls = append(snapshot.Pulls, pull)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Bucket(b.globalLocksBucketName).ForEach(func(k, v []byte) error {
+			var cmdLock command.Lock
+			if err := b.Cipher.Unmarshal(v, &cmdLock); err != nil {
+				return errors.Wrapf(err, "deserializing command lock at %q", k)
+			}
+			snapshot.CommandLocks = append(snapshot.CommandLocks, cmdLock)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Bucket(b.settingsBucketName).ForEach(func(k, v []byte) error {
+			if snapshot.Settings == nil {
+				snapshot.Settings = make(map[string]string)
+			}
+			decrypted, err := b.Cipher.Decrypt(v)
+			if err != nil {
+				return errors.Wrapf(err, "decrypting setting at %q", k)
+			}
+			snapshot.Settings[string(k)] = string(decrypted)
+			return nil
+		}); err != nil {
+			return err
+		}
+		return tx.Bucket(b.appliesBucketName).ForEach(func(k, v []byte) error {
+			var apply models.ProjectApply
+			if err := b.Cipher.Unmarshal(v, &apply); err != nil {
+				return errors.Wrapf(err, "deserializing apply at %q", k)
+			}
+			snapshot.Applies = append(snapshot.Applies, apply)
+			return nil
+		})
+	})
+	if err != nil {
+		return snapshot, errors.Wrap(err, "DB transaction failed")
+	}
+	return snapshot, nil
+}
+
+// Restore writes all locks, pull statuses, command locks, settings and
+// applies in snapshot in a single transaction.
+func (b *BoltDB) Restore(snapshot locking.Snapshot) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		locksBucket := tx.Bucket(b.locksBucketName)
+		for _, lock := range snapshot.Locks {
+			serialized, err := b.Cipher.Marshal(lock)
+			if err != nil {
+				return errors.Wrap(err, "serializing lock")
+			}
+			if err := locksBucket.Put([]byte(b.lockKey(lock.Project, lock.Workspace)), serialized); err != nil {
+				return err
+			}
+		}
+		pullsBucket := tx.Bucket(b.pullsBucketName)
+		for _, pull := range snapshot.Pulls {
+			key, err := b.pullKey(pull.Pull)
+			if err != nil {
+				return err
+			}
+			if err := b.writePullToBucket(pullsBucket, key, pull); err != nil {
+				return err
+			}
+		}
+		globalLocksBucket := tx.Bucket(b.globalLocksBucketName)
+		for _, cmdLock := range snapshot.CommandLocks {
+			serialized, err := b.Cipher.Marshal(cmdLock)
+			if err != nil {
+				return errors.Wrap(err, "serializing command lock")
+			}
+			if err := globalLocksBucket.Put([]byte(b.commandLockKey(cmdLock.CommandName)), serialized); err != nil {
+				return err
+			}
+		}
+		settingsBucket := tx.Bucket(b.settingsBucketName)
+		for name, value := range snapshot.Settings {
+			encrypted, err := b.Cipher.Encrypt([]byte(value))
+			if err != nil {
+				return errors.Wrap(err, "encrypting setting")
+			}
+			if err := settingsBucket.Put([]byte(name), encrypted); err != nil {
+				return err
+			}
+		}
+		appliesBucket := tx.Bucket(b.appliesBucketName)
+		for _, apply := range snapshot.Applies {
+			if err := b.writeApplyToBucket(appliesBucket, apply); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// Reencrypt rewrites the records that are unencrypted or encrypted with an
+// old key with the first key of Cipher. They're rewritten in a single
+// transaction so no other write can happen in between.
+func (b *BoltDB) Reencrypt() (int, error) {
+	records := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{b.locksBucketName, b.pullsBucketName, b.globalLocksBucketName, b.settingsBucketName, b.appliesBucketName} {
+			bucket := tx.Bucket(name)
+			reencrypted := make(map[string][]byte)
+			if err := bucket.ForEach(func(k, v []byte) error {
+				record, changed, err := b.Cipher.Reencrypt(v)
+				if err != nil {
+					return errors.Wrapf(err, "reencrypting %q in bucket %q", k, name)
+				}
+				if changed {
+					reencrypted[string(k)] = record
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			// Buckets can't be modified while they're iterated over.
+			for k, record := range reencrypted {
+				if err := bucket.Put([]byte(k), record); err != nil {
+					return err
+				}
+			}
+			records += len(reencrypted)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "DB transaction failed")
 	}
+	return records, nil
 }
 
 func (b *BoltDB) Close() error {
@@ -15,10 +15,12 @@ package db_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -634,6 +636,7 @@ func TestPullStatus_UpdateNewCommit(t *testing.T) {
 				RepoRelDir: ".",
 				Workspace:  "default",
 				Failure:    "failure",
+				User:       "lkysow",
 			},
 		})
 	Ok(t, err)
@@ -662,6 +665,15 @@ func TestPullStatus_UpdateNewCommit(t *testing.T) {
 			Status:      models.AppliedPlanStatus,
 		},
 	}, maybeStatus.Projects)
+
+	// The history of the pull request is kept across commits.
+	Equals(t, 2, len(maybeStatus.History))
+	Equals(t, "sha", maybeStatus.History[0].HeadCommit)
+	Equals(t, "lkysow", maybeStatus.History[0].User)
+	Equals(t, models.ErroredApplyStatus, maybeStatus.History[0].Status)
+	Equals(t, "newsha", maybeStatus.History[1].HeadCommit)
+	Equals(t, "staging", maybeStatus.History[1].Workspace)
+	Equals(t, models.AppliedPlanStatus, maybeStatus.History[1].Status)
 	b.Close()
 }
 
@@ -778,6 +790,66 @@ func TestPullStatus_UpdateMerge_Apply(t *testing.T) {
 	b.Close()
 }
 
+// Test that re-planning a project on the same commit replaces its planned
+// resources, and that applying it keeps them.
+func TestPullStatus_UpdateMerge_PlannedResources(t *testing.T) {
+	b := newTestDB2(t)
+
+	pull := models.PullRequest{
+		Num:        1,
+		HeadCommit: "sha",
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost: models.VCSHost{
+				Hostname: "github.com",
+				Type:     models.Github,
+			},
+		},
+	}
+	_, err := b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:    command.Plan,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			PlanSuccess: &models.PlanSuccess{
+				TerraformOutput: "  # aws_instance.web will be created",
+			},
+		},
+	})
+	Ok(t, err)
+
+	_, err = b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:    command.Plan,
+			RepoRelDir: ".",
+			Workspace:  "default",
+			PlanSuccess: &models.PlanSuccess{
+				TerraformOutput: "  # aws_instance.web must be replaced",
+			},
+		},
+	})
+	Ok(t, err)
+
+	status, err := b.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:      command.Apply,
+			RepoRelDir:   ".",
+			Workspace:    "default",
+			ApplySuccess: "applied!",
+		},
+	})
+	Ok(t, err)
+	Equals(t, []models.ProjectStatus{
+		{
+			RepoRelDir:       ".",
+			Workspace:        "default",
+			Status:           models.AppliedPlanStatus,
+			PlannedResources: []models.ResourceChange{{Address: "aws_instance.web", Action: "replace"}},
+		},
+	}, status.Projects)
+	b.Close()
+}
+
 // Test that if we update one existing policy status via approve_policies and our new status is for a
 // the same commit, that we merge the statuses.
 func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
@@ -894,6 +966,89 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 }
 
 // newTestDB returns a TestDB using a temporary path.
+func TestSnapshotRestore(t *testing.T) {
+	src := newTestDB2(t)
+	lockTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pull := models.PullRequest{
+		Num: 1,
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+		},
+	}
+	projectLock := models.ProjectLock{
+		Pull:      pull,
+		User:      models.User{Username: "lkysow"},
+		Workspace: "default",
+		Project:   models.NewProject("runatlantis/atlantis", ".", ""),
+		Time:      lockTime,
+	}
+	_, _, err := src.TryLock(projectLock)
+	Ok(t, err)
+	_, err = src.UpdatePullWithResults(pull, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: ".", Workspace: "default", Failure: "failure"},
+		{Command: command.Apply, RepoRelDir: "staging", Workspace: "default", ApplySuccess: "success"},
+	})
+	Ok(t, err)
+	_, err = src.LockCommand(command.Apply, lockTime)
+	Ok(t, err)
+	Ok(t, src.SetSetting("repo-allowlist", "github.com/runatlantis/*"))
+
+	snapshot, err := src.Snapshot()
+	Ok(t, err)
+	Equals(t, locking.SnapshotVersion, snapshot.Version)
+	Equals(t, []models.ProjectLock{projectLock}, snapshot.Locks)
+	Equals(t, 1, len(snapshot.Pulls))
+	Equals(t, 1, len(snapshot.CommandLocks))
+	Equals(t, map[string]string{"repo-allowlist": "github.com/runatlantis/*"}, snapshot.Settings)
+	Equals(t, 1, len(snapshot.Applies))
+	Equals(t, "staging", snapshot.Applies[0].RepoRelDir)
+	Equals(t, 1, snapshot.Applies[0].PullNum)
+
+	dst := newTestDB2(t)
+	empty, err := dst.Snapshot()
+	Ok(t, err)
+	Assert(t, empty.Empty(), "exp empty snapshot")
+	Ok(t, dst.Restore(snapshot))
+
+	locks, err := dst.List()
+	Ok(t, err)
+	Equals(t, []models.ProjectLock{projectLock}, locks)
+	status, err := dst.GetPullStatus(pull)
+	Ok(t, err)
+	Equals(t, snapshot.Pulls[0], *status)
+	cmdLock, err := dst.CheckCommandLock(command.Apply)
+	Ok(t, err)
+	Assert(t, cmdLock.IsLocked(), "exp apply lock to be restored")
+	value, ok, err := dst.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, ok, "exp setting to be restored")
+	Equals(t, "github.com/runatlantis/*", value)
+	restored, err := dst.Snapshot()
+	Ok(t, err)
+	Equals(t, snapshot.Applies, restored.Applies)
+}
+
+func TestSettings(t *testing.T) {
+	b := newTestDB2(t)
+	_, ok, err := b.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, !ok, "exp setting to be unset")
+
+	Ok(t, b.SetSetting("repo-allowlist", "github.com/runatlantis/*"))
+	value, ok, err := b.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, ok, "exp setting to be set")
+	Equals(t, "github.com/runatlantis/*", value)
+
+	Ok(t, b.DeleteSetting("repo-allowlist"))
+	_, ok, err = b.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, !ok, "exp setting to be unset")
+	// Deleting an unset setting is a no-op.
+	Ok(t, b.DeleteSetting("repo-allowlist"))
+}
+
 func newTestDB() (*bolt.DB, *db.BoltDB) {
 	// Retrieve a temporary path.
 	f, err := os.CreateTemp("", "")
@@ -934,3 +1089,58 @@ func cleanupDB(db *bolt.DB) {
 	db.Close()           // nolint: errcheck
 	os.Remove(db.Path()) // nolint: errcheck
 }
+
+func TestBoltDB_Cipher(t *testing.T) {
+	writeKeys := func(keys string) *locking.RecordCipher {
+		path := t.TempDir() + "/db-keys"
+		Ok(t, os.WriteFile(path, []byte(keys), 0600))
+		c, err := locking.NewRecordCipher(path)
+		Ok(t, err)
+		return c
+	}
+	oldKey := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	newKey := "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+	dataDir := t.TempDir()
+	b, err := db.New(dataDir)
+	Ok(t, err)
+
+	// A lock stored before encryption was enabled.
+	_, _, err = b.TryLock(lock)
+	Ok(t, err)
+	b.Cipher = writeKeys(oldKey)
+	Ok(t, b.SetSetting("name", "value"))
+	locks, err := b.List()
+	Ok(t, err)
+	Equals(t, 1, len(locks))
+
+	b.Cipher = writeKeys(newKey + "\n" + oldKey)
+	records, err := b.Reencrypt()
+	Ok(t, err)
+	Equals(t, 2, records)
+	// Records already encrypted with the first key aren't rewritten.
+	records, err = b.Reencrypt()
+	Ok(t, err)
+	Equals(t, 0, records)
+	Ok(t, b.Close())
+	boltDB, err := bolt.Open(dataDir+"/atlantis.db", 0600, nil)
+	Ok(t, err)
+	Ok(t, boltDB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("runLocks")).ForEach(func(_, v []byte) error {
+			Assert(t, !strings.Contains(string(v), "lkysow"), "expected lock to be encrypted")
+			return nil
+		})
+	}))
+
+	// The old key isn't needed anymore.
+	b, err = db.NewWithDB(boltDB, "runLocks", "globalLocks")
+	Ok(t, err)
+	defer cleanupDB(boltDB)
+	b.Cipher = writeKeys(newKey)
+	locks, err = b.List()
+	Ok(t, err)
+	Equals(t, "lkysow", locks[0].User.Username)
+	value, ok, err := b.GetSetting("name")
+	Ok(t, err)
+	Equals(t, true, ok)
+	Equals(t, "value", value)
+}
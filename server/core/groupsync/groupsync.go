@@ -0,0 +1,95 @@
+// Package groupsync syncs the group membership of users from an identity
+// provider so that team allowlists don't need to look up the teams of users
+// on the VCS host for every command.
+package groupsync
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+)
+
+// SyncPeriod is how often the group membership is synced again.
+const SyncPeriod = 10 * time.Minute
+
+// Source fetches the group membership from an identity provider.
+type Source interface {
+	// Fetch returns the usernames of the members of each group, by group
+	// name.
+	Fetch() (map[string][]string, error)
+}
+
+// Syncer holds the groups of users last synced from Source. It looks up the
+// teams of users like vcs.Client does, so it can be used in its place.
+type Syncer struct {
+	log    logging.SimpleLogging
+	source Source
+
+	mu sync.RWMutex
+	// groups are the sorted group names of each user, by lowercase username.
+	groups map[string][]string
+}
+
+// New creates a Syncer that syncs the group membership from source.
+func New(log logging.SimpleLogging, source Source) *Syncer {
+	return &Syncer{
+		log:    log,
+		source: source,
+	}
+}
+
+// GenerateJob syncs the group membership and returns the job that syncs it
+// every SyncPeriod.
+func (s *Syncer) GenerateJob() (scheduled.JobDefinition, error) {
+	return scheduled.JobDefinition{
+		Job:    s,
+		Period: SyncPeriod,
+	}, s.sync()
+}
+
+// Run syncs the group membership. If it fails, the previous membership is
+// kept.
+func (s *Syncer) Run() {
+	if err := s.sync(); err != nil {
+		s.log.Err("syncing groups: %s", err)
+	}
+}
+
+func (s *Syncer) sync() error {
+	members, err := s.source.Fetch()
+	if err != nil {
+		return err
+	}
+	groups := make(map[string][]string)
+	for group, usernames := range members {
+		for _, username := range usernames {
+			username = strings.ToLower(username)
+			groups[username] = append(groups[username], group)
+		}
+	}
+	for _, userGroups := range groups {
+		sort.Strings(userGroups)
+	}
+	s.mu.Lock()
+	s.groups = groups
+	s.mu.Unlock()
+	s.log.Debug("synced %d groups with %d members", len(members), len(groups))
+	return nil
+}
+
+// GetTeamNamesForUser returns the names of the groups user is a member of.
+// Usernames are matched case-insensitively.
+func (s *Syncer) GetTeamNamesForUser(_ logging.SimpleLogging, _ models.Repo, user models.User) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.groups == nil {
+		return nil, errors.New("groups haven't been synced yet")
+	}
+	return s.groups[strings.ToLower(user.Username)], nil
+}
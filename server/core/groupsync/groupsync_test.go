@@ -0,0 +1,104 @@
+package groupsync_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/groupsync"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeSource returns members, or fails if err is set.
+type fakeSource struct {
+	members map[string][]string
+	err     error
+}
+
+func (f *fakeSource) Fetch() (map[string][]string, error) {
+	return f.members, f.err
+}
+
+func TestSyncer(t *testing.T) {
+	source := &fakeSource{members: map[string][]string{
+		"platform": {"Alice", "bob"},
+		"infra":    {"alice"},
+	}}
+	syncer := groupsync.New(logging.NewNoopLogger(t), source)
+	_, err := syncer.GetTeamNamesForUser(nil, models.Repo{}, models.User{Username: "alice"})
+	ErrEquals(t, "groups haven't been synced yet", err)
+
+	jd, err := syncer.GenerateJob()
+	Ok(t, err)
+	Equals(t, groupsync.SyncPeriod, jd.Period)
+	teams, err := syncer.GetTeamNamesForUser(nil, models.Repo{}, models.User{Username: "ALICE"})
+	Ok(t, err)
+	Equals(t, []string{"infra", "platform"}, teams)
+	teams, err = syncer.GetTeamNamesForUser(nil, models.Repo{}, models.User{Username: "carol"})
+	Ok(t, err)
+	Equals(t, 0, len(teams))
+
+	// A failed sync keeps the previous membership.
+	source.err = errors.New("unavailable")
+	syncer.Run()
+	teams, err = syncer.GetTeamNamesForUser(nil, models.Repo{}, models.User{Username: "bob"})
+	Ok(t, err)
+	Equals(t, []string{"platform"}, teams)
+
+	source.err = nil
+	source.members = map[string][]string{"infra": {"bob"}}
+	syncer.Run()
+	teams, err = syncer.GetTeamNamesForUser(nil, models.Repo{}, models.User{Username: "bob"})
+	Ok(t, err)
+	Equals(t, []string{"infra"}, teams)
+}
+
+func TestSyncer_GenerateJobErr(t *testing.T) {
+	syncer := groupsync.New(logging.NewNoopLogger(t), &fakeSource{err: errors.New("unavailable")})
+	_, err := syncer.GenerateJob()
+	ErrEquals(t, "unavailable", err)
+}
+
+func TestSCIMSource_Fetch(t *testing.T) {
+	users := `{"id": "1", "userName": "alice"}`
+	usersPage2 := `{"id": "2", "userName": "bob"}`
+	groups := `{"displayName": "infra", "members": [{"value": "1"}, {"value": "2"}, {"value": "nested-group"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		startIndex, _ := strconv.Atoi(r.URL.Query().Get("startIndex"))
+		switch {
+		// The users are returned one per page even though more are
+		// requested.
+		case r.URL.Path == "/scim/v2/Users" && startIndex == 1:
+			fmt.Fprintf(w, `{"totalResults": 2, "Resources": [%s]}`, users)
+		case r.URL.Path == "/scim/v2/Users" && startIndex == 2:
+			fmt.Fprintf(w, `{"totalResults": 2, "Resources": [%s]}`, usersPage2)
+		case r.URL.Path == "/scim/v2/Groups" && startIndex == 1:
+			fmt.Fprintf(w, `{"totalResults": 1, "Resources": [%s]}`, groups)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	Ok(t, os.WriteFile(tokenFile, []byte("token\n"), 0600))
+
+	source := &groupsync.SCIMSource{URL: server.URL + "/scim/v2/", TokenFile: tokenFile, HTTPClient: server.Client()}
+	members, err := source.Fetch()
+	Ok(t, err)
+	Equals(t, map[string][]string{"infra": {"alice", "bob"}}, members)
+
+	Ok(t, os.WriteFile(tokenFile, []byte("rotated"), 0600))
+	_, err = source.Fetch()
+	ErrEquals(t, "listing SCIM Users: responded with 401: unauthorized", err)
+}
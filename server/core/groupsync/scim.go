@@ -0,0 +1,132 @@
+package groupsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// scimPageSize is how many resources are requested per page.
+const scimPageSize = 100
+
+// SCIMSource fetches the groups from the SCIM 2.0 API of an identity
+// provider, ex. Okta or Microsoft Entra ID. The userName of the members must
+// be their username on the VCS host.
+type SCIMSource struct {
+	// URL is the base URL of the SCIM API, ex. https://idp.example.com/scim/v2.
+	URL string
+	// TokenFile is the path to the bearer token. It's read before every sync
+	// so that rotated tokens are picked up.
+	TokenFile  string
+	HTTPClient *http.Client
+}
+
+type scimListResponse struct {
+	TotalResults int               `json:"totalResults"`
+	Resources    []json.RawMessage `json:"Resources"`
+}
+
+type scimUser struct {
+	ID       string `json:"id"`
+	UserName string `json:"userName"`
+}
+
+type scimGroup struct {
+	DisplayName string `json:"displayName"`
+	Members     []struct {
+		Value string `json:"value"`
+	} `json:"members"`
+}
+
+// Fetch returns the groups and the userName of their members. Members that
+// aren't users, ex. nested groups, are skipped.
+func (s *SCIMSource) Fetch() (map[string][]string, error) {
+	token, err := os.ReadFile(s.TokenFile) // nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("reading SCIM token: %w", err)
+	}
+	userNames := make(map[string]string)
+	if err := s.list(strings.TrimSpace(string(token)), "Users", "userName", func(resource json.RawMessage) error {
+		var user scimUser
+		if err := json.Unmarshal(resource, &user); err != nil {
+			return err
+		}
+		userNames[user.ID] = user.UserName
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	members := make(map[string][]string)
+	if err := s.list(strings.TrimSpace(string(token)), "Groups", "displayName,members", func(resource json.RawMessage) error {
+		var group scimGroup
+		if err := json.Unmarshal(resource, &group); err != nil {
+			return err
+		}
+		usernames := []string{}
+		for _, member := range group.Members {
+			if userName, ok := userNames[member.Value]; ok {
+				usernames = append(usernames, userName)
+			}
+		}
+		members[group.DisplayName] = usernames
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// list calls fn with every resource of resourceType, fetched page by page.
+func (s *SCIMSource) list(token string, resourceType string, attributes string, fn func(json.RawMessage) error) error {
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	// Identity providers can return fewer resources than requested, so the
+	// next page starts after the resources that were returned.
+	for startIndex := 1; ; {
+		query := url.Values{
+			"attributes": {attributes},
+			"startIndex": {fmt.Sprint(startIndex)},
+			"count":      {fmt.Sprint(scimPageSize)},
+		}
+		reqURL := strings.TrimSuffix(s.URL, "/") + "/" + resourceType + "?" + query.Encode()
+		req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/scim+json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("listing SCIM %s: %w", resourceType, err)
+		}
+		var page scimListResponse
+		if err := decodeResponse(resp, &page); err != nil {
+			return fmt.Errorf("listing SCIM %s: %w", resourceType, err)
+		}
+		for _, resource := range page.Resources {
+			if err := fn(resource); err != nil {
+				return fmt.Errorf("parsing SCIM %s: %w", resourceType, err)
+			}
+		}
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || startIndex > page.TotalResults {
+			return nil
+		}
+	}
+}
+
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
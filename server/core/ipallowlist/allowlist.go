@@ -0,0 +1,184 @@
+// Package ipallowlist restricts requests to IP ranges, including the ranges
+// VCS hosts publish for their webhooks.
+package ipallowlist
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+)
+
+// RefreshPeriod is how often the published ranges are fetched again.
+const RefreshPeriod = time.Hour
+
+// Allowlist is the set of IP ranges allowed to send requests. It's made up of
+// static ranges and the ranges fetched from VCS hosts.
+type Allowlist struct {
+	log            logging.SimpleLogging
+	static         []*net.IPNet
+	fetchers       []Fetcher
+	trustedProxies []*net.IPNet
+
+	mu sync.RWMutex
+	// fetched are the last ranges successfully fetched, by fetcher name.
+	fetched map[string][]*net.IPNet
+}
+
+// New creates an Allowlist from entries, which are IPs, CIDRs or the names of
+// the VCS hosts in fetchers. Requests from trustedProxies are allowed based
+// on the client IP in their X-Forwarded-For header instead.
+func New(log logging.SimpleLogging, entries []string, trustedProxies []string, fetchers map[string]Fetcher) (*Allowlist, error) {
+	a := &Allowlist{
+		log:     log,
+		fetched: make(map[string][]*net.IPNet),
+	}
+	for _, entry := range entries {
+		if fetcher, ok := fetchers[entry]; ok {
+			a.fetchers = append(a.fetchers, fetcher)
+			continue
+		}
+		ipNet, err := ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		a.static = append(a.static, ipNet)
+	}
+	for _, proxy := range trustedProxies {
+		ipNet, err := ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		a.trustedProxies = append(a.trustedProxies, ipNet)
+	}
+	return a, nil
+}
+
+// ParseCIDR parses a CIDR, or a single IP as a range containing only that IP.
+func ParseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR", s)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an IP or CIDR", s)
+	}
+	return ipNet, nil
+}
+
+// GenerateJob fetches the published ranges and returns the job that refreshes
+// them every RefreshPeriod.
+func (a *Allowlist) GenerateJob() (scheduled.JobDefinition, error) {
+	return scheduled.JobDefinition{
+		Job:    a,
+		Period: RefreshPeriod,
+	}, a.refresh()
+}
+
+// Run refreshes the published ranges. If fetching the ranges of a host fails,
+// its previous ranges are kept.
+func (a *Allowlist) Run() {
+	if err := a.refresh(); err != nil {
+		a.log.Err(err.Error())
+	}
+}
+
+func (a *Allowlist) refresh() error {
+	var errs []string
+	for _, fetcher := range a.fetchers {
+		cidrs, err := fetcher.Fetch()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("fetching %s IP ranges: %s", fetcher.Name(), err))
+			continue
+		}
+		var ranges []*net.IPNet
+		for _, cidr := range cidrs {
+			ipNet, err := ParseCIDR(cidr)
+			if err != nil {
+				a.log.Warn("ignoring %s IP range: %s", fetcher.Name(), err)
+				continue
+			}
+			ranges = append(ranges, ipNet)
+		}
+		a.mu.Lock()
+		a.fetched[fetcher.Name()] = ranges
+		a.mu.Unlock()
+		a.log.Debug("fetched %d %s IP ranges", len(ranges), fetcher.Name())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Contains returns true if ip is in one of the ranges.
+func (a *Allowlist) Contains(ip net.IP) bool {
+	if containsIP(a.static, ip) {
+		return true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, ranges := range a.fetched {
+		if containsIP(ranges, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of the client that sent r. If r came from a
+// trusted proxy, that's the last IP in X-Forwarded-For that isn't a trusted
+// proxy, since proxies append the address they received the request from.
+func (a *Allowlist) ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(a.trustedProxies, ip) {
+		return ip
+	}
+	values := r.Header.Values("X-Forwarded-For")
+	if len(values) == 0 {
+		return ip
+	}
+	forwarded := strings.Split(strings.Join(values, ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			// A malformed address can't be trusted, nor anything before it.
+			return nil
+		}
+		ip = hop
+		if !containsIP(a.trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func containsIP(ranges []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range ranges {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package ipallowlist_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/ipallowlist"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakeFetcher returns ranges, or fails if err is set.
+type fakeFetcher struct {
+	ranges []string
+	err    error
+}
+
+func (f *fakeFetcher) Name() string { return "fake" }
+
+func (f *fakeFetcher) Fetch() ([]string, error) {
+	return f.ranges, f.err
+}
+
+func TestAllowlist_Contains(t *testing.T) {
+	fetcher := &fakeFetcher{ranges: []string{"192.30.252.0/22", "2a0a:a440::/29"}}
+	allowlist, err := ipallowlist.New(logging.NewNoopLogger(t), []string{"fake", "10.0.0.1"}, nil, map[string]ipallowlist.Fetcher{"fake": fetcher})
+	Ok(t, err)
+	jd, err := allowlist.GenerateJob()
+	Ok(t, err)
+	Equals(t, ipallowlist.RefreshPeriod, jd.Period)
+
+	Assert(t, allowlist.Contains(net.ParseIP("10.0.0.1")), "exp static IP to be allowed")
+	Assert(t, !allowlist.Contains(net.ParseIP("10.0.0.2")), "exp IP next to static IP not to be allowed")
+	Assert(t, allowlist.Contains(net.ParseIP("192.30.253.1")), "exp fetched IPv4 range to be allowed")
+	Assert(t, allowlist.Contains(net.ParseIP("2a0a:a440::1")), "exp fetched IPv6 range to be allowed")
+	Assert(t, !allowlist.Contains(nil), "exp missing IP not to be allowed")
+
+	// The previous ranges are kept if fetching fails.
+	fetcher.ranges, fetcher.err = nil, errors.New("timeout")
+	allowlist.Run()
+	Assert(t, allowlist.Contains(net.ParseIP("192.30.253.1")), "exp last fetched range to be allowed")
+
+	fetcher.ranges, fetcher.err = []string{"140.82.112.0/20"}, nil
+	allowlist.Run()
+	Assert(t, !allowlist.Contains(net.ParseIP("192.30.253.1")), "exp removed range not to be allowed")
+	Assert(t, allowlist.Contains(net.ParseIP("140.82.112.1")), "exp new range to be allowed")
+}
+
+func TestAllowlist_GenerateJobErr(t *testing.T) {
+	fetcher := &fakeFetcher{err: errors.New("timeout")}
+	allowlist, err := ipallowlist.New(logging.NewNoopLogger(t), []string{"fake"}, nil, map[string]ipallowlist.Fetcher{"fake": fetcher})
+	Ok(t, err)
+	_, err = allowlist.GenerateJob()
+	ErrEquals(t, "fetching fake IP ranges: timeout", err)
+}
+
+func TestAllowlist_ClientIP(t *testing.T) {
+	allowlist, err := ipallowlist.New(logging.NewNoopLogger(t), nil, []string{"10.0.0.0/8"}, nil)
+	Ok(t, err)
+
+	cases := map[string]struct {
+		remoteAddr   string
+		forwardedFor []string
+		expIP        string
+	}{
+		"direct": {
+			remoteAddr:   "192.0.2.1:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			expIP:        "192.0.2.1",
+		},
+		"trusted proxy": {
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"203.0.113.5, 198.51.100.1"},
+			expIP:        "198.51.100.1",
+		},
+		"chained trusted proxies": {
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.1", "10.0.0.2"},
+			expIP:        "198.51.100.1",
+		},
+		"trusted proxy without header": {
+			remoteAddr: "10.0.0.1:1234",
+			expIP:      "10.0.0.1",
+		},
+		"malformed header": {
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"unknown"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/events", nil)
+			req.RemoteAddr = c.remoteAddr
+			for _, header := range c.forwardedFor {
+				req.Header.Add("X-Forwarded-For", header)
+			}
+			Equals(t, net.ParseIP(c.expIP), allowlist.ClientIP(req))
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := ipallowlist.New(logging.NewNoopLogger(t), []string{"github"}, nil, nil)
+	ErrEquals(t, `"github" is not an IP or CIDR`, err)
+	_, err = ipallowlist.New(logging.NewNoopLogger(t), nil, []string{"10.0.0.0/40"}, nil)
+	ErrEquals(t, `"10.0.0.0/40" is not an IP or CIDR`, err)
+}
+
+func TestGitHubFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/meta", r.URL.Path)
+		fmt.Fprint(w, `{"verifiable_password_authentication": true, "hooks": ["192.30.252.0/22", "2a0a:a440::/29"], "web": ["140.82.112.0/20"]}`)
+	}))
+	defer server.Close()
+
+	ranges, err := (&ipallowlist.GitHubFetcher{URL: server.URL + "/meta"}).Fetch()
+	Ok(t, err)
+	Equals(t, []string{"192.30.252.0/22", "2a0a:a440::/29"}, ranges)
+}
+
+func TestBitbucketFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"creationDate": "2024-01-01", "items": [
+  {"cidr": "104.192.136.0/21", "product": ["bitbucket"], "direction": ["ingress", "egress"]},
+  {"cidr": "18.205.93.0/25", "product": ["jira", "confluence", "bitbucket"], "direction": ["egress"]},
+  {"cidr": "185.166.140.0/22", "product": ["bitbucket"], "direction": ["ingress"]},
+  {"cidr": "13.52.5.0/25", "product": ["jira"], "direction": ["egress"]}
+]}`)
+	}))
+	defer server.Close()
+
+	ranges, err := (&ipallowlist.BitbucketFetcher{URL: server.URL}).Fetch()
+	Ok(t, err)
+	Equals(t, []string{"104.192.136.0/21", "18.205.93.0/25"}, ranges)
+}
+
+func TestGitHubFetcher_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := (&ipallowlist.GitHubFetcher{URL: server.URL}).Fetch()
+	ErrEquals(t, fmt.Sprintf("%s responded with status 503", server.URL), err)
+}
+
+func TestDefaultFetchers(t *testing.T) {
+	Equals(t, &ipallowlist.GitHubFetcher{URL: "https://api.github.com/meta"}, ipallowlist.DefaultFetchers("")[ipallowlist.GitHub])
+	Equals(t, &ipallowlist.GitHubFetcher{URL: "https://github.example.com/api/v3/meta"}, ipallowlist.DefaultFetchers("github.example.com")[ipallowlist.GitHub])
+	ranges, err := ipallowlist.DefaultFetchers("")[ipallowlist.GitLab].Fetch()
+	Ok(t, err)
+	Equals(t, ipallowlist.GitLabWebhookRanges, ranges)
+}
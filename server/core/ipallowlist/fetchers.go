@@ -0,0 +1,136 @@
+package ipallowlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	// GitHub is the allowlist entry for the ranges GitHub sends webhooks from.
+	GitHub = "github"
+	// Bitbucket is the allowlist entry for the ranges Bitbucket Cloud sends
+	// webhooks from.
+	Bitbucket = "bitbucket"
+	// GitLab is the allowlist entry for the ranges GitLab.com sends webhooks
+	// from.
+	GitLab = "gitlab"
+
+	// DefaultGitHubMetaURL is the GitHub API endpoint that lists its ranges.
+	DefaultGitHubMetaURL = "https://api.github.com/meta"
+	// DefaultAtlassianIPRangesURL lists the ranges of Atlassian products.
+	DefaultAtlassianIPRangesURL = "https://ip-ranges.atlassian.com/"
+)
+
+// GitLabWebhookRanges are the ranges GitLab.com sends webhooks from. GitLab
+// doesn't publish them in a machine readable format, see
+// https://docs.gitlab.com/ee/user/gitlab_com/#ip-range.
+var GitLabWebhookRanges = []string{"34.74.90.64/28", "34.74.226.0/24"}
+
+// Fetcher fetches the IP ranges a VCS host sends webhooks from.
+type Fetcher interface {
+	// Name is the allowlist entry for the fetcher, ex. github.
+	Name() string
+	Fetch() ([]string, error)
+}
+
+// DefaultFetchers returns the built-in fetchers by name. githubHostname is
+// used for GitHub Enterprise, which publishes its ranges on its own API.
+func DefaultFetchers(githubHostname string) map[string]Fetcher {
+	githubURL := DefaultGitHubMetaURL
+	if githubHostname != "" && githubHostname != "github.com" {
+		githubURL = fmt.Sprintf("https://%s/api/v3/meta", githubHostname)
+	}
+	return map[string]Fetcher{
+		GitHub:    &GitHubFetcher{URL: githubURL},
+		Bitbucket: &BitbucketFetcher{URL: DefaultAtlassianIPRangesURL},
+		GitLab:    &StaticFetcher{FetcherName: GitLab, Ranges: GitLabWebhookRanges},
+	}
+}
+
+// GitHubFetcher fetches the hooks ranges from the GitHub meta API.
+type GitHubFetcher struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+func (f *GitHubFetcher) Name() string { return GitHub }
+
+func (f *GitHubFetcher) Fetch() ([]string, error) {
+	var meta struct {
+		Hooks []string `json:"hooks"`
+	}
+	if err := getJSON(f.HTTPClient, f.URL, &meta); err != nil {
+		return nil, err
+	}
+	if len(meta.Hooks) == 0 {
+		return nil, fmt.Errorf("no hooks ranges in %s", f.URL)
+	}
+	return meta.Hooks, nil
+}
+
+// BitbucketFetcher fetches the egress ranges of Bitbucket from the Atlassian
+// IP ranges.
+type BitbucketFetcher struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+func (f *BitbucketFetcher) Name() string { return Bitbucket }
+
+func (f *BitbucketFetcher) Fetch() ([]string, error) {
+	var ranges struct {
+		Items []struct {
+			CIDR      string   `json:"cidr"`
+			Product   []string `json:"product"`
+			Direction []string `json:"direction"`
+		} `json:"items"`
+	}
+	if err := getJSON(f.HTTPClient, f.URL, &ranges); err != nil {
+		return nil, err
+	}
+	var cidrs []string
+	for _, item := range ranges.Items {
+		if slices.Contains(item.Product, "bitbucket") && slices.Contains(item.Direction, "egress") {
+			cidrs = append(cidrs, item.CIDR)
+		}
+	}
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("no bitbucket egress ranges in %s", f.URL)
+	}
+	return cidrs, nil
+}
+
+// StaticFetcher returns ranges that aren't published by the VCS host.
+type StaticFetcher struct {
+	FetcherName string
+	Ranges      []string
+}
+
+func (f *StaticFetcher) Name() string { return f.FetcherName }
+
+func (f *StaticFetcher) Fetch() ([]string, error) {
+	return f.Ranges, nil
+}
+
+func getJSON(client *http.Client, url string, v any) error {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Get(url) // nolint: gosec
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
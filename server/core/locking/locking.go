@@ -43,6 +43,9 @@ type Backend interface {
 	LockCommand(cmdName command.Name, lockTime time.Time) (*command.Lock, error)
 	UnlockCommand(cmdName command.Name) error
 	CheckCommandLock(cmdName command.Name) (*command.Lock, error)
+
+	Snapshotter
+	SettingsStore
 }
 
 // TryLockResponse results from an attempted lock.
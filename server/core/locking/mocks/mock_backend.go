@@ -5,6 +5,7 @@ package mocks
 
 import (
 	pegomock "github.com/petergtz/pegomock/v4"
+	locking "github.com/runatlantis/atlantis/server/core/locking"
 	command "github.com/runatlantis/atlantis/server/events/command"
 	models "github.com/runatlantis/atlantis/server/events/models"
 	"reflect"
@@ -60,6 +61,21 @@ func (mock *MockBackend) DeletePullStatus(pull models.PullRequest) error {
 	return _ret0
 }
 
+func (mock *MockBackend) DeleteSetting(name string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{name}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteSetting", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockBackend) GetLock(project models.Project, workspace string) (*models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -98,6 +114,29 @@ func (mock *MockBackend) GetPullStatus(pull models.PullRequest) (*models.PullSta
 	return _ret0, _ret1
 }
 
+func (mock *MockBackend) GetSetting(name string) (string, bool, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{name}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("GetSetting", _params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 string
+	var _ret1 bool
+	var _ret2 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(string)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(bool)
+		}
+		if _result[2] != nil {
+			_ret2 = _result[2].(error)
+		}
+	}
+	return _ret0, _ret1, _ret2
+}
+
 func (mock *MockBackend) List() ([]models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -136,6 +175,55 @@ func (mock *MockBackend) LockCommand(cmdName command.Name, lockTime time.Time) (
 	return _ret0, _ret1
 }
 
+func (mock *MockBackend) Restore(s locking.Snapshot) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{s}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("Restore", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
+func (mock *MockBackend) SetSetting(name string, value string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{name, value}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("SetSetting", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
+func (mock *MockBackend) Snapshot() (locking.Snapshot, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	_params := []pegomock.Param{}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("Snapshot", _params, []reflect.Type{reflect.TypeOf((*locking.Snapshot)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 locking.Snapshot
+	var _ret1 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(locking.Snapshot)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(error)
+		}
+	}
+	return _ret0, _ret1
+}
+
 func (mock *MockBackend) TryLock(lock models.ProjectLock) (bool, models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -341,6 +429,35 @@ func (c *MockBackend_DeletePullStatus_OngoingVerification) GetAllCapturedArgumen
 	return
 }
 
+func (verifier *VerifierMockBackend) DeleteSetting(name string) *MockBackend_DeleteSetting_OngoingVerification {
+	_params := []pegomock.Param{name}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteSetting", _params, verifier.timeout)
+	return &MockBackend_DeleteSetting_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeleteSetting_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteSetting_OngoingVerification) GetCapturedArguments() string {
+	name := c.GetAllCapturedArguments()
+	return name[len(name)-1]
+}
+
+func (c *MockBackend_DeleteSetting_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) GetLock(project models.Project, workspace string) *MockBackend_GetLock_OngoingVerification {
 	_params := []pegomock.Param{project, workspace}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetLock", _params, verifier.timeout)
@@ -405,6 +522,35 @@ func (c *MockBackend_GetPullStatus_OngoingVerification) GetAllCapturedArguments(
 	return
 }
 
+func (verifier *VerifierMockBackend) GetSetting(name string) *MockBackend_GetSetting_OngoingVerification {
+	_params := []pegomock.Param{name}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetSetting", _params, verifier.timeout)
+	return &MockBackend_GetSetting_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_GetSetting_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_GetSetting_OngoingVerification) GetCapturedArguments() string {
+	name := c.GetAllCapturedArguments()
+	return name[len(name)-1]
+}
+
+func (c *MockBackend_GetSetting_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) List() *MockBackend_List_OngoingVerification {
 	_params := []pegomock.Param{}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "List", _params, verifier.timeout)
@@ -457,6 +603,87 @@ func (c *MockBackend_LockCommand_OngoingVerification) GetAllCapturedArguments()
 	return
 }
 
+func (verifier *VerifierMockBackend) Restore(s locking.Snapshot) *MockBackend_Restore_OngoingVerification {
+	_params := []pegomock.Param{s}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Restore", _params, verifier.timeout)
+	return &MockBackend_Restore_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_Restore_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_Restore_OngoingVerification) GetCapturedArguments() locking.Snapshot {
+	s := c.GetAllCapturedArguments()
+	return s[len(s)-1]
+}
+
+func (c *MockBackend_Restore_OngoingVerification) GetAllCapturedArguments() (_param0 []locking.Snapshot) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]locking.Snapshot, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(locking.Snapshot)
+			}
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) SetSetting(name string, value string) *MockBackend_SetSetting_OngoingVerification {
+	_params := []pegomock.Param{name, value}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "SetSetting", _params, verifier.timeout)
+	return &MockBackend_SetSetting_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_SetSetting_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_SetSetting_OngoingVerification) GetCapturedArguments() (string, string) {
+	name, value := c.GetAllCapturedArguments()
+	return name[len(name)-1], value[len(value)-1]
+}
+
+func (c *MockBackend_SetSetting_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(string)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]string, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(string)
+			}
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) Snapshot() *MockBackend_Snapshot_OngoingVerification {
+	_params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Snapshot", _params, verifier.timeout)
+	return &MockBackend_Snapshot_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_Snapshot_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_Snapshot_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_Snapshot_OngoingVerification) GetAllCapturedArguments() {
+}
+
 func (verifier *VerifierMockBackend) TryLock(lock models.ProjectLock) *MockBackend_TryLock_OngoingVerification {
 	_params := []pegomock.Param{lock}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", _params, verifier.timeout)
@@ -0,0 +1,153 @@
+package locking
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// encryptedRecordHeader starts encrypted records. Records without it were
+// stored before encryption was enabled and are read as is.
+const encryptedRecordHeader = "atlantis-encrypted-v1:"
+
+// RecordCipher encrypts the records the backends store, ex. locks and pull
+// statuses, since they contain repo names, usernames and command output.
+// Only the records are encrypted, not the keys they're stored under. A nil
+// RecordCipher stores records unencrypted.
+type RecordCipher struct {
+	// aeads are the ciphers of the keys. The first one encrypts and all of
+	// them decrypt.
+	aeads []cipher.AEAD
+}
+
+// NewRecordCipher returns a cipher with the base64 encoded 32 byte keys in
+// keyFile, one per line. Records are encrypted with the first key and
+// decrypted with whichever key they were encrypted with, so keys are rotated
+// by adding the new key as the first line.
+func NewRecordCipher(keyFile string) (*RecordCipher, error) {
+	data, err := os.ReadFile(keyFile) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	c := &RecordCipher{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("line %d of %s must be a base64 encoded 32 byte key, ex. generated with 'openssl rand -base64 32'", i+1, keyFile)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads = append(c.aeads, aead)
+	}
+	if len(c.aeads) == 0 {
+		return nil, fmt.Errorf("%s doesn't contain any keys", keyFile)
+	}
+	return c, nil
+}
+
+// Encrypt encrypts data with the first key.
+func (c *RecordCipher) Encrypt(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+	aead := c.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encrypted := append([]byte(encryptedRecordHeader), nonce...)
+	return aead.Seal(encrypted, nonce, data, nil), nil
+}
+
+// Decrypt decrypts data if it's encrypted.
+func (c *RecordCipher) Decrypt(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedRecordHeader)) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, errors.New("record is encrypted but no encryption key is configured")
+	}
+	data = data[len(encryptedRecordHeader):]
+	for _, aead := range c.aeads {
+		if len(data) < aead.NonceSize() {
+			break
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if decrypted, err := aead.Open(nil, nonce, ciphertext, nil); err == nil {
+			return decrypted, nil
+		}
+	}
+	return nil, errors.New("record can't be decrypted with any of the encryption keys")
+}
+
+// Marshal serializes v to JSON and encrypts it.
+func (c *RecordCipher) Marshal(v any) ([]byte, error) {
+	serialized, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.Encrypt(serialized)
+}
+
+// Unmarshal decrypts data and deserializes it from JSON into v.
+func (c *RecordCipher) Unmarshal(data []byte, v any) error {
+	decrypted, err := c.Decrypt(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decrypted, v)
+}
+
+// Reencrypt returns data encrypted with the first key and true if it's
+// unencrypted or encrypted with another key. Records already encrypted with
+// the first key are returned as is with false.
+func (c *RecordCipher) Reencrypt(data []byte) ([]byte, bool, error) {
+	if c == nil {
+		return data, false, nil
+	}
+	if bytes.HasPrefix(data, []byte(encryptedRecordHeader)) {
+		aead := c.aeads[0]
+		encrypted := data[len(encryptedRecordHeader):]
+		if len(encrypted) >= aead.NonceSize() {
+			if _, err := aead.Open(nil, encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():], nil); err == nil {
+				return data, false, nil
+			}
+		}
+	}
+	decrypted, err := c.Decrypt(data)
+	if err != nil {
+		return nil, false, err
+	}
+	reencrypted, err := c.Encrypt(decrypted)
+	if err != nil {
+		return nil, false, err
+	}
+	return reencrypted, true, nil
+}
+
+// Reencrypter is implemented by backends that can rewrite their records with
+// the first key of their cipher, ex. after a key is rotated or encryption is
+// enabled.
+type Reencrypter interface {
+	// Reencrypt rewrites the records that are unencrypted or encrypted with
+	// an old key and returns how many were rewritten. Records that are
+	// changed or deleted while they're rewritten are left as is.
+	Reencrypt() (int, error)
+}
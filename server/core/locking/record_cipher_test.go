@@ -0,0 +1,90 @@
+package locking_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const (
+	recordKey1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	recordKey2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func newRecordCipher(t *testing.T, keys string) *locking.RecordCipher {
+	path := filepath.Join(t.TempDir(), "db-keys")
+	Ok(t, os.WriteFile(path, []byte(keys), 0600))
+	c, err := locking.NewRecordCipher(path)
+	Ok(t, err)
+	return c
+}
+
+func TestNewRecordCipher_InvalidKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db-keys")
+	Ok(t, os.WriteFile(path, []byte(recordKey1+"\nc2hvcnQ=\n"), 0600))
+	_, err := locking.NewRecordCipher(path)
+	ErrEquals(t, "line 2 of "+path+" must be a base64 encoded 32 byte key, ex. generated with 'openssl rand -base64 32'", err)
+
+	Ok(t, os.WriteFile(path, []byte("\n"), 0600))
+	_, err = locking.NewRecordCipher(path)
+	ErrEquals(t, path+" doesn't contain any keys", err)
+}
+
+func TestRecordCipher(t *testing.T) {
+	type record struct {
+		User string
+	}
+	c := newRecordCipher(t, recordKey1+"\n")
+	encrypted, err := c.Marshal(record{User: "lkysow"})
+	Ok(t, err)
+	Assert(t, !strings.Contains(string(encrypted), "lkysow"), "expected record to be encrypted")
+	var decrypted record
+	Ok(t, c.Unmarshal(encrypted, &decrypted))
+	Equals(t, record{User: "lkysow"}, decrypted)
+
+	// Records stored before encryption was enabled are read as is.
+	var plain record
+	Ok(t, c.Unmarshal([]byte(`{"User":"lkysow"}`), &plain))
+	Equals(t, record{User: "lkysow"}, plain)
+
+	// After a rotation, records encrypted with the old key can still be read.
+	rotated := newRecordCipher(t, recordKey2+"\n"+recordKey1+"\n")
+	var rotatedRecord record
+	Ok(t, rotated.Unmarshal(encrypted, &rotatedRecord))
+	Equals(t, record{User: "lkysow"}, rotatedRecord)
+
+	ErrEquals(t, "record can't be decrypted with any of the encryption keys", newRecordCipher(t, recordKey2).Unmarshal(encrypted, &record{}))
+	var noCipher *locking.RecordCipher
+	ErrEquals(t, "record is encrypted but no encryption key is configured", noCipher.Unmarshal(encrypted, &record{}))
+}
+
+func TestRecordCipher_Reencrypt(t *testing.T) {
+	c := newRecordCipher(t, recordKey1+"\n")
+	encrypted, err := c.Encrypt([]byte("lkysow"))
+	Ok(t, err)
+
+	// Records encrypted with the first key are left as is.
+	reencrypted, changed, err := c.Reencrypt(encrypted)
+	Ok(t, err)
+	Equals(t, false, changed)
+	Equals(t, encrypted, reencrypted)
+
+	// Unencrypted records and records encrypted with an old key are
+	// encrypted with the first key.
+	rotated := newRecordCipher(t, recordKey2+"\n"+recordKey1+"\n")
+	for _, record := range [][]byte{[]byte("lkysow"), encrypted} {
+		reencrypted, changed, err = rotated.Reencrypt(record)
+		Ok(t, err)
+		Equals(t, true, changed)
+		decrypted, err := newRecordCipher(t, recordKey2).Decrypt(reencrypted)
+		Ok(t, err)
+		Equals(t, "lkysow", string(decrypted))
+	}
+
+	_, _, err = newRecordCipher(t, recordKey2).Reencrypt(encrypted)
+	ErrEquals(t, "record can't be decrypted with any of the encryption keys", err)
+}
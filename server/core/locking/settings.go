@@ -0,0 +1,14 @@
+package locking
+
+//go:generate pegomock generate --package mocks -o mocks/mock_settings_store.go SettingsStore
+
+// SettingsStore persists server settings that can be changed at runtime via
+// the API, ex. the repo allowlist, so that they survive restarts.
+type SettingsStore interface {
+	// GetSetting returns the value of the setting and whether it's set.
+	GetSetting(name string) (string, bool, error)
+	// SetSetting sets the setting to value.
+	SetSetting(name string, value string) error
+	// DeleteSetting unsets the setting. It's not an error if it isn't set.
+	DeleteSetting(name string) error
+}
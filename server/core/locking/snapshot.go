@@ -0,0 +1,73 @@
+package locking
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// SnapshotVersion is the version of the Snapshot format. It's incremented
+// whenever a change to the format isn't backwards compatible.
+const SnapshotVersion = 1
+
+//go:generate pegomock generate --package mocks -o mocks/mock_snapshotter.go Snapshotter
+
+// Snapshotter exports and imports everything a Backend stores so that it
+// can be backed up and restored, ex. when migrating to a new instance or from
+// BoltDB to Redis.
+type Snapshotter interface {
+	// Snapshot returns everything that's stored.
+	Snapshot() (Snapshot, error)
+	// Restore writes everything in s, replacing what's stored under the
+	// same keys.
+	Restore(s Snapshot) error
+}
+
+// Snapshot is a portable copy of everything a Backend stores.
+type Snapshot struct {
+	Version      int
+	CreatedAt    time.Time
+	Locks        []models.ProjectLock
+	Pulls        []models.PullStatus
+	CommandLocks []command.Lock
+	// Settings are the settings changed at runtime, keyed by name.
+	Settings map[string]string `json:",omitempty"`
+	// Applies are the last successful apply of each project.
+	Applies []models.ProjectApply `json:",omitempty"`
+}
+
+// Empty returns true if there's nothing in s.
+func (s Snapshot) Empty() bool {
+	return len(s.Locks) == 0 && len(s.Pulls) == 0 && len(s.CommandLocks) == 0 && len(s.Settings) == 0 && len(s.Applies) == 0
+}
+
+// WriteSnapshot writes s to w as gzipped JSON.
+func WriteSnapshot(w io.Writer, s Snapshot) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(s); err != nil {
+		return errors.Wrap(err, "encoding snapshot")
+	}
+	return errors.Wrap(gz.Close(), "compressing snapshot")
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var s Snapshot
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return s, errors.Wrap(err, "decompressing snapshot")
+	}
+	defer gz.Close() // nolint: errcheck
+	if err := json.NewDecoder(gz).Decode(&s); err != nil {
+		return s, errors.Wrap(err, "decoding snapshot")
+	}
+	if s.Version != SnapshotVersion {
+		return s, errors.Errorf("unsupported snapshot version %d, expected %d", s.Version, SnapshotVersion)
+	}
+	return s, nil
+}
@@ -4,13 +4,13 @@ package redis
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
+	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 )
@@ -20,6 +20,8 @@ var ctx = context.Background()
 // Redis is a database using Redis 6
 type RedisDB struct { // nolint: revive
 	client *redis.Client
+	// Cipher encrypts the stored records. If nil, they aren't encrypted.
+	Cipher *locking.RecordCipher
 }
 
 const (
@@ -69,7 +71,7 @@ func NewWithClient(client *redis.Client, _ string, _ string) (*RedisDB, error) {
 func (r *RedisDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock, error) {
 	var currLock models.ProjectLock
 	key := r.lockKey(newLock.Project, newLock.Workspace)
-	newLockSerialized, _ := json.Marshal(newLock)
+	newLockSerialized, _ := r.Cipher.Marshal(newLock)
 
 	val, err := r.client.Get(ctx, key).Result()
 	// if there is no run at that key then we're free to create the lock
@@ -84,7 +86,7 @@ func (r *RedisDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 		return false, currLock, errors.Wrap(err, "db transaction failed")
 	}
 
-	if err := json.Unmarshal([]byte(val), &currLock); err != nil {
+	if err := r.Cipher.Unmarshal([]byte(val), &currLock); err != nil {
 		return false, currLock, errors.Wrap(err, "failed to deserialize current lock")
 	}
 	return false, currLock, nil
@@ -105,7 +107,7 @@ func (r *RedisDB) Unlock(project models.Project, workspace string) (*models.Proj
 		return nil, errors.Wrap(err, "db transaction failed")
 	}
 
-	if err := json.Unmarshal([]byte(val), &lock); err != nil {
+	if err := r.Cipher.Unmarshal([]byte(val), &lock); err != nil {
 		return nil, errors.Wrap(err, "failed to deserialize current lock")
 	}
 	r.client.Del(ctx, key)
@@ -122,7 +124,7 @@ func (r *RedisDB) List() ([]models.ProjectLock, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "db transaction failed")
 		}
-		if err := json.Unmarshal([]byte(val), &lock); err != nil {
+		if err := r.Cipher.Unmarshal([]byte(val), &lock); err != nil {
 			return locks, errors.Wrap(err, fmt.Sprintf("failed to deserialize lock at key '%s'", iter.Val()))
 		}
 		locks = append(locks, lock)
@@ -147,7 +149,7 @@ func (r *RedisDB) GetLock(project models.Project, workspace string) (*models.Pro
 	}
 
 	var lock models.ProjectLock
-	if err := json.Unmarshal([]byte(val), &lock); err != nil {
+	if err := r.Cipher.Unmarshal([]byte(val), &lock); err != nil {
 		return nil, errors.Wrapf(err, "deserializing lock at key %q", key)
 	}
 	// need to set it to Local after deserialization due to https://github.com/golang/go/issues/19486
@@ -166,7 +168,7 @@ func (r *RedisDB) UnlockByPull(repoFullName string, pullNum int) ([]models.Proje
 		if err != nil {
 			return nil, errors.Wrap(err, "db transaction failed")
 		}
-		if err := json.Unmarshal([]byte(val), &lock); err != nil {
+		if err := r.Cipher.Unmarshal([]byte(val), &lock); err != nil {
 			return locks, errors.Wrap(err, fmt.Sprintf("failed to deserialize lock at key '%s'", iter.Val()))
 		}
 		if lock.Pull.Num == pullNum {
@@ -195,7 +197,7 @@ func (r *RedisDB) LockCommand(cmdName command.Name, lockTime time.Time) (*comman
 
 	cmdLockKey := r.commandLockKey(cmdName)
 
-	newLockSerialized, _ := json.Marshal(lock)
+	newLockSerialized, _ := r.Cipher.Marshal(lock)
 
 	_, err := r.client.Get(ctx, cmdLockKey).Result()
 	if err == redis.Nil {
@@ -235,7 +237,7 @@ func (r *RedisDB) CheckCommandLock(cmdName command.Name) (*command.Lock, error)
 		return nil, errors.Wrap(err, "db transaction failed")
 	}
 
-	if err := json.Unmarshal([]byte(val), &cmdLock); err != nil {
+	if err := r.Cipher.Unmarshal([]byte(val), &cmdLock); err != nil {
 		return nil, errors.Wrap(err, "failed to deserialize Lock")
 	}
 	return &cmdLock, err
@@ -324,6 +326,11 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 			Pull:     pull,
 			Projects: statuses,
 		}
+		// The history covers the whole pull request so it's kept across
+		// commits.
+		if currStatus != nil {
+			newStatus.History = currStatus.History
+		}
 	} else {
 		// If there's an existing pull at the right commit then we have to
 		// merge our project results with the existing ones. We do a merge
@@ -343,6 +350,9 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 					res.ProjectName == proj.ProjectName {
 
 					proj.Status = res.PlanStatus()
+					if res.PlanSuccess != nil {
+						proj.PlannedResources = res.PlannedResources()
+					}
 
 					// Updating only policy sets which are included in results; keeping the rest.
 					if len(proj.PolicyStatus) > 0 {
@@ -370,6 +380,16 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 		}
 	}
 
+	now := time.Now()
+	for _, res := range newResults {
+		newStatus.AddHistory(res.CommandRun(pull, now))
+		if apply, ok := res.ProjectApply(pull, now); ok {
+			if err := r.writeApply(apply); err != nil {
+				return models.PullStatus{}, err
+			}
+		}
+	}
+
 	// Now, we overwrite the key with our new status.
 	err = r.writePull(key, newStatus)
 	if err != nil {
@@ -378,6 +398,213 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 	return newStatus, nil
 }
 
+// Snapshot returns all locks, pull statuses, command locks, settings and
+// applies.
+func (r *RedisDB) Snapshot() (locking.Snapshot, error) {
+	snapshot := locking.Snapshot{
+		Version:   locking.SnapshotVersion,
+		CreatedAt: time.Now(),
+	}
+	locks, err := r.List()
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.Locks = locks
+
+	iter := r.client.Scan(ctx, 0, "*::*::*", 0).Iterator()
+	for iter.Next(ctx) {
+		pull, err := r.getPull(iter.Val())
+		if err != nil {
+			return snapshot, err
+		}
+		if pull != nil {
+			snapshot.Pulls = append(snapshot.Pulls, *pull)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return snapshot, errors.Wrap(err, "db transaction failed")
+	}
+
+	iter = r.client.Scan(ctx, 0, "settings/*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return snapshot, errors.Wrap(err, "db transaction failed")
+		}
+		if snapshot.Settings == nil {
+			snapshot.Settings = make(map[string]string)
+		}
+		decrypted, err := r.Cipher.Decrypt([]byte(val))
+		if err != nil {
+			return snapshot, errors.Wrapf(err, "decrypting setting at %q", iter.Val())
+		}
+		snapshot.Settings[strings.TrimPrefix(iter.Val(), "settings/")] = string(decrypted)
+	}
+	if err := iter.Err(); err != nil {
+		return snapshot, errors.Wrap(err, "db transaction failed")
+	}
+
+	iter = r.client.Scan(ctx, 0, "applies/*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return snapshot, errors.Wrap(err, "db transaction failed")
+		}
+		var apply models.ProjectApply
+		if err := r.Cipher.Unmarshal([]byte(val), &apply); err != nil {
+			return snapshot, errors.Wrapf(err, "deserializing apply at %q", iter.Val())
+		}
+		snapshot.Applies = append(snapshot.Applies, apply)
+	}
+	if err := iter.Err(); err != nil {
+		return snapshot, errors.Wrap(err, "db transaction failed")
+	}
+
+	iter = r.client.Scan(ctx, 0, "global/*", 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return snapshot, errors.Wrap(err, "db transaction failed")
+		}
+		var cmdLock command.Lock
+		if err := r.Cipher.Unmarshal([]byte(val), &cmdLock); err != nil {
+			return snapshot, errors.Wrapf(err, "deserializing command lock at %q", iter.Val())
+		}
+		snapshot.CommandLocks = append(snapshot.CommandLocks, cmdLock)
+	}
+	if err := iter.Err(); err != nil {
+		return snapshot, errors.Wrap(err, "db transaction failed")
+	}
+	return snapshot, nil
+}
+
+// Restore writes all locks, pull statuses, command locks, settings and
+// applies in snapshot.
+func (r *RedisDB) Restore(snapshot locking.Snapshot) error {
+	for _, lock := range snapshot.Locks {
+		serialized, err := r.Cipher.Marshal(lock)
+		if err != nil {
+			return errors.Wrap(err, "serializing lock")
+		}
+		if err := r.client.Set(ctx, r.lockKey(lock.Project, lock.Workspace), serialized, 0).Err(); err != nil {
+			return errors.Wrap(err, "db transaction failed")
+		}
+	}
+	for _, pull := range snapshot.Pulls {
+		key, err := r.pullKey(pull.Pull)
+		if err != nil {
+			return err
+		}
+		if err := r.writePull(key, pull); err != nil {
+			return err
+		}
+	}
+	for _, cmdLock := range snapshot.CommandLocks {
+		serialized, err := r.Cipher.Marshal(cmdLock)
+		if err != nil {
+			return errors.Wrap(err, "serializing command lock")
+		}
+		if err := r.client.Set(ctx, r.commandLockKey(cmdLock.CommandName), serialized, 0).Err(); err != nil {
+			return errors.Wrap(err, "db transaction failed")
+		}
+	}
+	for name, value := range snapshot.Settings {
+		if err := r.SetSetting(name, value); err != nil {
+			return err
+		}
+	}
+	for _, apply := range snapshot.Applies {
+		if err := r.writeApply(apply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reencrypt rewrites the records that are unencrypted or encrypted with an
+// old key with the first key of Cipher. Each record is watched while it's
+// rewritten so a record that another Atlantis server changes or deletes in
+// the meantime is left as is.
+func (r *RedisDB) Reencrypt() (int, error) {
+	records := 0
+	for _, pattern := range []string{"pr/*", "*::*::*", "global/*", "settings/*", "applies/*"} {
+		iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+				val, err := tx.Get(ctx, key).Result()
+				if err == redis.Nil {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				record, changed, err := r.Cipher.Reencrypt([]byte(val))
+				if err != nil || !changed {
+					return err
+				}
+				if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					return pipe.Set(ctx, key, record, redis.KeepTTL).Err()
+				}); err != nil {
+					return err
+				}
+				records++
+				return nil
+			}, key)
+			if err == redis.TxFailedErr {
+				continue
+			}
+			if err != nil {
+				return records, errors.Wrapf(err, "reencrypting %q", key)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return records, errors.Wrap(err, "db transaction failed")
+		}
+	}
+	return records, nil
+}
+
+// GetSetting returns the value of the setting and whether it's set.
+func (r *RedisDB) GetSetting(name string) (string, bool, error) {
+	val, err := r.client.Get(ctx, r.settingKey(name)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, errors.Wrap(err, "db transaction failed")
+	}
+	decrypted, err := r.Cipher.Decrypt([]byte(val))
+	if err != nil {
+		return "", false, errors.Wrapf(err, "decrypting setting %q", name)
+	}
+	return string(decrypted), true, nil
+}
+
+// SetSetting sets the setting to value.
+func (r *RedisDB) SetSetting(name string, value string) error {
+	encrypted, err := r.Cipher.Encrypt([]byte(value))
+	if err != nil {
+		return errors.Wrap(err, "encrypting setting")
+	}
+	if err := r.client.Set(ctx, r.settingKey(name), encrypted, 0).Err(); err != nil {
+		return errors.Wrap(err, "db transaction failed")
+	}
+	return nil
+}
+
+// DeleteSetting unsets the setting.
+func (r *RedisDB) DeleteSetting(name string) error {
+	if err := r.client.Del(ctx, r.settingKey(name)).Err(); err != nil {
+		return errors.Wrap(err, "db transaction failed")
+	}
+	return nil
+}
+
 func (r *RedisDB) getPull(key string) (*models.PullStatus, error) {
 	val, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
@@ -387,14 +614,14 @@ func (r *RedisDB) getPull(key string) (*models.PullStatus, error) {
 	}
 
 	var p models.PullStatus
-	if err := json.Unmarshal([]byte(val), &p); err != nil {
+	if err := r.Cipher.Unmarshal([]byte(val), &p); err != nil {
 		return nil, errors.Wrapf(err, "deserializing pull at %q with contents %q", key, val)
 	}
 	return &p, nil
 }
 
 func (r *RedisDB) writePull(key string, pull models.PullStatus) error {
-	serialized, err := json.Marshal(pull)
+	serialized, err := r.Cipher.Marshal(pull)
 	if err != nil {
 		return errors.Wrap(err, "serializing")
 	}
@@ -405,6 +632,17 @@ func (r *RedisDB) writePull(key string, pull models.PullStatus) error {
 	return nil
 }
 
+func (r *RedisDB) writeApply(apply models.ProjectApply) error {
+	serialized, err := r.Cipher.Marshal(apply)
+	if err != nil {
+		return errors.Wrap(err, "serializing apply")
+	}
+	if err := r.client.Set(ctx, r.applyKey(apply), serialized, 0).Err(); err != nil {
+		return errors.Wrap(err, "db transaction failed")
+	}
+	return nil
+}
+
 func (r *RedisDB) deletePull(key string) error {
 	err := r.client.Del(ctx, key).Err()
 	if err != nil {
@@ -417,6 +655,14 @@ func (r *RedisDB) lockKey(p models.Project, workspace string) string {
 	return fmt.Sprintf("pr/%s/%s/%s", p.RepoFullName, p.Path, workspace)
 }
 
+func (r *RedisDB) settingKey(name string) string {
+	return fmt.Sprintf("settings/%s", name)
+}
+
+func (r *RedisDB) applyKey(apply models.ProjectApply) string {
+	return fmt.Sprintf("applies/%s/%s/%s/%s", apply.RepoFullName, apply.RepoRelDir, apply.Workspace, apply.ProjectName)
+}
+
 func (r *RedisDB) commandLockKey(cmdName command.Name) string {
 	return fmt.Sprintf("global/%s/lock", cmdName)
 }
@@ -436,10 +682,11 @@ func (r *RedisDB) pullKey(pull models.PullRequest) (string, error) {
 
 func (r *RedisDB) projectResultToProject(p command.ProjectResult) models.ProjectStatus {
 	return models.ProjectStatus{
-		Workspace:    p.Workspace,
-		RepoRelDir:   p.RepoRelDir,
-		ProjectName:  p.ProjectName,
-		PolicyStatus: p.PolicyStatus(),
-		Status:       p.PlanStatus(),
+		Workspace:        p.Workspace,
+		RepoRelDir:       p.RepoRelDir,
+		ProjectName:      p.ProjectName,
+		PolicyStatus:     p.PolicyStatus(),
+		Status:           p.PlanStatus(),
+		PlannedResources: p.PlannedResources(),
 	}
 }
@@ -11,11 +11,13 @@ import (
 	"math/big"
 	"net"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/core/redis"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -672,6 +674,7 @@ func TestPullStatus_UpdateNewCommit(t *testing.T) {
 				RepoRelDir: ".",
 				Workspace:  "default",
 				Failure:    "failure",
+				User:       "lkysow",
 			},
 		})
 	Ok(t, err)
@@ -700,6 +703,15 @@ func TestPullStatus_UpdateNewCommit(t *testing.T) {
 			Status:      models.AppliedPlanStatus,
 		},
 	}, maybeStatus.Projects)
+
+	// The history of the pull request is kept across commits.
+	Equals(t, 2, len(maybeStatus.History))
+	Equals(t, "sha", maybeStatus.History[0].HeadCommit)
+	Equals(t, "lkysow", maybeStatus.History[0].User)
+	Equals(t, models.ErroredApplyStatus, maybeStatus.History[0].Status)
+	Equals(t, "newsha", maybeStatus.History[1].HeadCommit)
+	Equals(t, "staging", maybeStatus.History[1].Workspace)
+	Equals(t, models.AppliedPlanStatus, maybeStatus.History[1].Status)
 }
 
 // Test that if we update an existing pull status via Apply and our new status is for a
@@ -930,6 +942,121 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 	}
 }
 
+func TestSnapshotRestore(t *testing.T) {
+	src := newTestRedis(miniredis.RunT(t))
+	lockTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pull := models.PullRequest{
+		Num: 1,
+		BaseRepo: models.Repo{
+			FullName: "runatlantis/atlantis",
+			VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+		},
+	}
+	projectLock := models.ProjectLock{
+		Pull:      pull,
+		User:      models.User{Username: "lkysow"},
+		Workspace: "default",
+		Project:   models.NewProject("runatlantis/atlantis", ".", ""),
+		Time:      lockTime,
+	}
+	_, _, err := src.TryLock(projectLock)
+	Ok(t, err)
+	_, err = src.UpdatePullWithResults(pull, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: ".", Workspace: "default", Failure: "failure"},
+		{Command: command.Apply, RepoRelDir: "staging", Workspace: "default", ApplySuccess: "success"},
+	})
+	Ok(t, err)
+	_, err = src.LockCommand(command.Apply, lockTime)
+	Ok(t, err)
+	Ok(t, src.SetSetting("repo-allowlist", "github.com/runatlantis/*"))
+
+	snapshot, err := src.Snapshot()
+	Ok(t, err)
+	Equals(t, locking.SnapshotVersion, snapshot.Version)
+	Equals(t, []models.ProjectLock{projectLock}, snapshot.Locks)
+	Equals(t, 1, len(snapshot.Pulls))
+	Equals(t, 1, len(snapshot.CommandLocks))
+	Equals(t, map[string]string{"repo-allowlist": "github.com/runatlantis/*"}, snapshot.Settings)
+	Equals(t, 1, len(snapshot.Applies))
+	Equals(t, "staging", snapshot.Applies[0].RepoRelDir)
+	Equals(t, 1, snapshot.Applies[0].PullNum)
+
+	dst := newTestRedis(miniredis.RunT(t))
+	empty, err := dst.Snapshot()
+	Ok(t, err)
+	Assert(t, empty.Empty(), "exp empty snapshot")
+	Ok(t, dst.Restore(snapshot))
+
+	locks, err := dst.List()
+	Ok(t, err)
+	Equals(t, []models.ProjectLock{projectLock}, locks)
+	status, err := dst.GetPullStatus(pull)
+	Ok(t, err)
+	Equals(t, snapshot.Pulls[0], *status)
+	cmdLock, err := dst.CheckCommandLock(command.Apply)
+	Ok(t, err)
+	Assert(t, cmdLock.IsLocked(), "exp apply lock to be restored")
+	value, ok, err := dst.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, ok, "exp setting to be restored")
+	Equals(t, "github.com/runatlantis/*", value)
+	restored, err := dst.Snapshot()
+	Ok(t, err)
+	Equals(t, snapshot.Applies, restored.Applies)
+}
+
+func TestSettings(t *testing.T) {
+	b := newTestRedis(miniredis.RunT(t))
+	_, ok, err := b.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, !ok, "exp setting to be unset")
+
+	Ok(t, b.SetSetting("repo-allowlist", "github.com/runatlantis/*"))
+	value, ok, err := b.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, ok, "exp setting to be set")
+	Equals(t, "github.com/runatlantis/*", value)
+
+	Ok(t, b.DeleteSetting("repo-allowlist"))
+	_, ok, err = b.GetSetting("repo-allowlist")
+	Ok(t, err)
+	Assert(t, !ok, "exp setting to be unset")
+	// Deleting an unset setting is a no-op.
+	Ok(t, b.DeleteSetting("repo-allowlist"))
+}
+
+func TestReencrypt(t *testing.T) {
+	mr := miniredis.RunT(t)
+	b := newTestRedis(mr)
+	keyFile := t.TempDir() + "/db-keys"
+	Ok(t, os.WriteFile(keyFile, []byte("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"), 0600))
+	cipher, err := locking.NewRecordCipher(keyFile)
+	Ok(t, err)
+
+	// Records stored before encryption was enabled.
+	_, _, err = b.TryLock(lock)
+	Ok(t, err)
+	Ok(t, b.SetSetting("repo-allowlist", "github.com/runatlantis/*"))
+
+	b.Cipher = cipher
+	records, err := b.Reencrypt()
+	Ok(t, err)
+	Equals(t, 2, records)
+	for _, key := range mr.Keys() {
+		val, err := mr.Get(key)
+		Ok(t, err)
+		Assert(t, strings.HasPrefix(val, "atlantis-encrypted-v1:"), "expected %q to be encrypted", key)
+	}
+	locks, err := b.List()
+	Ok(t, err)
+	Equals(t, "lkysow", locks[0].User.Username)
+
+	// Records already encrypted with the first key aren't rewritten.
+	records, err = b.Reencrypt()
+	Ok(t, err)
+	Equals(t, 0, records)
+}
+
 func newTestRedis(mr *miniredis.Miniredis) *redis.RedisDB {
 	r, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
 	if err != nil {
@@ -0,0 +1,132 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// CheckoutStepRunner clones other repositories into the workspace of a
+// project, ex. shared modules or config repos, so workflows don't need to
+// run git themselves.
+type CheckoutStepRunner struct{}
+
+// Run clones checkout.Repo at checkout.Ref into checkout.Dir under the root of
+// the workspace that path, the project's dir, is in. Anything already there is
+// replaced so each command sees the ref as it is now.
+func (r *CheckoutStepRunner) Run(ctx command.ProjectContext, checkout valid.Checkout, path string, envs map[string]string) (string, error) {
+	if !filepath.IsLocal(checkout.Dir) || filepath.Clean(checkout.Dir) == "." {
+		return "", fmt.Errorf("checkout dir %q must be a subdirectory of the workspace", checkout.Dir)
+	}
+	// The checkout dir is deleted first, so it must not hold the project or
+	// any other file of the repo.
+	if rel, err := filepath.Rel(checkout.Dir, ctx.RepoRelDir); err == nil && filepath.IsLocal(rel) {
+		return "", fmt.Errorf("checkout dir %q must not contain the project dir %q", checkout.Dir, ctx.RepoRelDir)
+	}
+	root, err := filepath.Rel(filepath.Join("/", ctx.RepoRelDir), "/")
+	if err != nil {
+		return "", err
+	}
+	workspace := filepath.Join(path, root)
+	dir := filepath.Join(workspace, checkout.Dir)
+	if err := checkNoRepoFiles(workspace, checkout.Dir); err != nil {
+		return "", err
+	}
+
+	gitEnv, err := checkoutGitEnv(checkout, envs)
+	if err != nil {
+		return "", err
+	}
+	ref := checkout.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	ctx.Log.Info("checking out %s at %s into %s", checkout.Repo, ref, checkout.Dir)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", errors.Wrapf(err, "deleting %s before checking out", checkout.Dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", errors.Wrapf(err, "creating %s", checkout.Dir)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", checkoutURL(checkout.Repo)},
+		{"fetch", "--quiet", "--depth=1", "origin", ref},
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	} {
+		cmd := exec.Command("git", args...) // nolint: gosec
+		cmd.Dir = dir
+		cmd.Env = gitEnv
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("checking out %s at %s: running git %s: %s: %s", checkout.Repo, ref, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return "", nil
+}
+
+// checkNoRepoFiles returns an error if dir, relative to the workspace, holds
+// files committed to the repo of the workspace, ex. other projects.
+func checkNoRepoFiles(workspace string, dir string) error {
+	if _, err := os.Stat(filepath.Join(workspace, ".git")); os.IsNotExist(err) {
+		return nil
+	}
+	cmd := exec.Command("git", "ls-files", "-z", "--", dir) // nolint: gosec
+	cmd.Dir = workspace
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "listing the repo files in %s", dir)
+	}
+	if len(out) > 0 {
+		file, _, _ := strings.Cut(string(out), "\x00")
+		return fmt.Errorf("checkout dir %q must not contain files of the repo, found %q", dir, file)
+	}
+	return nil
+}
+
+// checkoutURL returns the URL repo is cloned from. Repos given as a host and
+// path are cloned over HTTPS.
+func checkoutURL(repo string) string {
+	if strings.Contains(repo, "://") || strings.HasPrefix(repo, "git@") {
+		return repo
+	}
+	return "https://" + repo
+}
+
+// checkoutGitEnv returns the environment git is run with to clone with the
+// credentials checkout selects. Config is passed through the environment so
+// the token isn't in the arguments of the git processes or in .git/config.
+func checkoutGitEnv(checkout valid.Checkout, envs map[string]string) ([]string, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var config [][2]string
+	if checkout.TokenEnv != "" {
+		token, ok := envs[checkout.TokenEnv]
+		if !ok {
+			token = os.Getenv(checkout.TokenEnv)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("checking out %s: %s isn't set", checkout.Repo, checkout.TokenEnv)
+		}
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		config = append(config,
+			[2]string{"credential.helper", ""},
+			[2]string{"http.extraHeader", "Authorization: Basic " + auth},
+		)
+	} else if checkout.Credentials == valid.NoCheckoutCredentials {
+		config = append(config, [2]string{"credential.helper", ""})
+	}
+	if len(config) > 0 {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)))
+		for i, kv := range config {
+			env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]))
+		}
+	}
+	return env, nil
+}
@@ -0,0 +1,91 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCheckoutStepRunner_Run(t *testing.T) {
+	modulesRepo := initRepo(t)
+	Ok(t, os.WriteFile(filepath.Join(modulesRepo, "main.tf"), []byte("v1"), 0600))
+	runCmd(t, modulesRepo, "git", "add", "main.tf")
+	runCmd(t, modulesRepo, "git", "commit", "-m", "v1")
+	runCmd(t, modulesRepo, "git", "tag", "v1")
+	Ok(t, os.WriteFile(filepath.Join(modulesRepo, "main.tf"), []byte("v2"), 0600))
+	runCmd(t, modulesRepo, "git", "commit", "-am", "v2")
+
+	workspace := t.TempDir()
+	projectDir := filepath.Join(workspace, "project")
+	Ok(t, os.Mkdir(projectDir, 0700))
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		RepoRelDir: "project",
+	}
+	r := &runtime.CheckoutStepRunner{}
+
+	checkout := valid.Checkout{
+		Repo:        "file://" + modulesRepo,
+		Ref:         "v1",
+		Dir:         "shared/modules",
+		Credentials: valid.VCSCheckoutCredentials,
+	}
+	out, err := r.Run(ctx, checkout, projectDir, nil)
+	Ok(t, err)
+	Equals(t, "", out)
+	contents, err := os.ReadFile(filepath.Join(workspace, "shared", "modules", "main.tf"))
+	Ok(t, err)
+	Equals(t, "v1", string(contents))
+
+	// Checking out again replaces the previous checkout.
+	checkout.Ref = ""
+	_, err = r.Run(ctx, checkout, projectDir, nil)
+	Ok(t, err)
+	contents, err = os.ReadFile(filepath.Join(workspace, "shared", "modules", "main.tf"))
+	Ok(t, err)
+	Equals(t, "v2", string(contents))
+}
+
+func TestCheckoutStepRunner_RunErrIfTokenNotSet(t *testing.T) {
+	r := &runtime.CheckoutStepRunner{}
+	_, err := r.Run(command.ProjectContext{Log: logging.NewNoopLogger(t), RepoRelDir: "."}, valid.Checkout{
+		Repo:     "github.com/owner/modules",
+		Dir:      "modules",
+		TokenEnv: "ATLANTIS_TEST_MODULES_TOKEN",
+	}, t.TempDir(), nil)
+	ErrEquals(t, "checking out github.com/owner/modules: ATLANTIS_TEST_MODULES_TOKEN isn't set", err)
+}
+
+func TestCheckoutStepRunner_RunErrIfDirHoldsRepoFiles(t *testing.T) {
+	workspace := initRepo(t)
+	Ok(t, os.MkdirAll(filepath.Join(workspace, "envs", "prod"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(workspace, "envs", "prod", "main.tf"), nil, 0600))
+	runCmd(t, workspace, "git", "add", "envs/prod/main.tf")
+	runCmd(t, workspace, "git", "commit", "-m", "prod")
+	Ok(t, os.MkdirAll(filepath.Join(workspace, "project"), 0700))
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		RepoRelDir: "project",
+	}
+	r := &runtime.CheckoutStepRunner{}
+
+	cases := map[string]string{
+		".":       `checkout dir "." must be a subdirectory of the workspace`,
+		"project": `checkout dir "project" must not contain the project dir "project"`,
+		"envs":    `checkout dir "envs" must not contain files of the repo, found "envs/prod/main.tf"`,
+	}
+	for dir, expErr := range cases {
+		t.Run(dir, func(t *testing.T) {
+			_, err := r.Run(ctx, valid.Checkout{Repo: "github.com/owner/modules", Dir: dir}, filepath.Join(workspace, "project"), nil)
+			ErrEquals(t, expErr, err)
+		})
+	}
+	_, err := os.Stat(filepath.Join(workspace, "envs", "prod", "main.tf"))
+	Ok(t, err)
+}
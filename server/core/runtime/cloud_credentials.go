@@ -0,0 +1,203 @@
+package runtime
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google/externalaccount"
+)
+
+// CloudCredentialsMinter mints the short-lived cloud credentials of a
+// project.
+type CloudCredentialsMinter interface {
+	// Mint returns the environment variables holding the credentials minted
+	// for ctx.CloudCredentials.
+	Mint(ctx command.ProjectContext) (map[string]string, error)
+}
+
+// DefaultCloudCredentialsMinter mints credentials by exchanging the server's
+// OIDC identity token, ex. a Kubernetes service account token, so that the
+// server itself doesn't need long-lived cloud keys.
+type DefaultCloudCredentialsMinter struct {
+	// IdentityTokenFile is the path to the identity token. It's read for each
+	// mint because the token is usually rotated on disk.
+	IdentityTokenFile string
+	// AWSSTSEndpoint overrides the AWS STS endpoint. Used in tests.
+	AWSSTSEndpoint string
+	// GCPSTSEndpoint overrides the GCP STS token endpoint. Used in tests.
+	GCPSTSEndpoint string
+	// GCPIAMCredentialsEndpoint overrides the GCP IAM credentials endpoint.
+	// Used in tests.
+	GCPIAMCredentialsEndpoint string
+	HTTPClient                *http.Client
+}
+
+const (
+	defaultGCPIAMCredentialsEndpoint = "https://iamcredentials.googleapis.com"
+	gcpCloudPlatformScope            = "https://www.googleapis.com/auth/cloud-platform"
+	gcpJWTTokenType                  = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// invalidSessionNameChars are the characters not allowed in AWS role session
+// names.
+var invalidSessionNameChars = regexp.MustCompile(`[^\w+=,.@-]`)
+
+func (m *DefaultCloudCredentialsMinter) Mint(ctx command.ProjectContext) (map[string]string, error) {
+	if ctx.CloudCredentials == nil {
+		return nil, nil
+	}
+	if m.IdentityTokenFile == "" {
+		return nil, errors.New("cloud credentials can't be minted because the server doesn't have an identity token file configured")
+	}
+	envs := make(map[string]string)
+	if aws := ctx.CloudCredentials.AWS; aws != nil {
+		if err := m.mintAWS(ctx, *aws, envs); err != nil {
+			return nil, errors.Wrapf(err, "assuming role %s", aws.RoleARN)
+		}
+	}
+	if gcp := ctx.CloudCredentials.GCP; gcp != nil {
+		if err := m.mintGCP(*gcp, envs); err != nil {
+			return nil, errors.Wrapf(err, "impersonating service account %s", gcp.ServiceAccount)
+		}
+	}
+	return envs, nil
+}
+
+// assumeRoleWithWebIdentityResponse is the response of the STS
+// AssumeRoleWithWebIdentity action.
+type assumeRoleWithWebIdentityResponse struct {
+	Credentials struct {
+		AccessKeyID     string `xml:"AccessKeyId"`
+		SecretAccessKey string `xml:"SecretAccessKey"`
+		SessionToken    string `xml:"SessionToken"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// stsErrorResponse is the response of STS when an action fails.
+type stsErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// mintAWS assumes the role with AssumeRoleWithWebIdentity, which doesn't
+// need the request to be signed with AWS credentials.
+func (m *DefaultCloudCredentialsMinter) mintAWS(ctx command.ProjectContext, creds valid.AWSCredentials, envs map[string]string) error {
+	token, err := m.identityToken()
+	if err != nil {
+		return err
+	}
+	endpoint := m.AWSSTSEndpoint
+	if endpoint == "" {
+		endpoint = "https://sts.amazonaws.com"
+		if creds.Region != "" {
+			endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", creds.Region)
+		}
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {creds.RoleARN},
+		"RoleSessionName":  {awsSessionName(ctx)},
+		"WebIdentityToken": {token},
+	}
+	if creds.Duration > 0 {
+		form.Set("DurationSeconds", strconv.Itoa(int(creds.Duration.Seconds())))
+	}
+
+	resp, err := m.httpClient().PostForm(endpoint, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var stsErr stsErrorResponse
+		if xml.Unmarshal(body, &stsErr) == nil && stsErr.Code != "" {
+			return fmt.Errorf("%s: %s", stsErr.Code, stsErr.Message)
+		}
+		return fmt.Errorf("sts responded with status %d", resp.StatusCode)
+	}
+	var assumed assumeRoleWithWebIdentityResponse
+	if err := xml.Unmarshal(body, &assumed); err != nil {
+		return errors.Wrap(err, "parsing sts response")
+	}
+
+	envs["AWS_ACCESS_KEY_ID"] = assumed.Credentials.AccessKeyID
+	envs["AWS_SECRET_ACCESS_KEY"] = assumed.Credentials.SecretAccessKey
+	envs["AWS_SESSION_TOKEN"] = assumed.Credentials.SessionToken
+	if creds.Region != "" {
+		envs["AWS_REGION"] = creds.Region
+	}
+	return nil
+}
+
+// mintGCP exchanges the identity token with the workload identity provider
+// and impersonates the service account with the federated token.
+func (m *DefaultCloudCredentialsMinter) mintGCP(creds valid.GCPCredentials, envs map[string]string) error {
+	iamEndpoint := m.GCPIAMCredentialsEndpoint
+	if iamEndpoint == "" {
+		iamEndpoint = defaultGCPIAMCredentialsEndpoint
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, m.httpClient())
+	ts, err := externalaccount.NewTokenSource(ctx, externalaccount.Config{
+		Audience:                       "//iam.googleapis.com/" + creds.WorkloadIdentityProvider,
+		SubjectTokenType:               gcpJWTTokenType,
+		TokenURL:                       m.GCPSTSEndpoint,
+		ServiceAccountImpersonationURL: fmt.Sprintf("%s/v1/projects/-/serviceAccounts/%s:generateAccessToken", iamEndpoint, creds.ServiceAccount),
+		CredentialSource:               &externalaccount.CredentialSource{File: m.IdentityTokenFile},
+		Scopes:                         []string{gcpCloudPlatformScope},
+	})
+	if err != nil {
+		return err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return err
+	}
+
+	// The google provider and gcloud read the access token from these.
+	envs["GOOGLE_OAUTH_ACCESS_TOKEN"] = token.AccessToken
+	envs["CLOUDSDK_AUTH_ACCESS_TOKEN"] = token.AccessToken
+	return nil
+}
+
+func (m *DefaultCloudCredentialsMinter) identityToken() (string, error) {
+	token, err := os.ReadFile(m.IdentityTokenFile)
+	if err != nil {
+		return "", errors.Wrap(err, "reading identity token")
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func (m *DefaultCloudCredentialsMinter) httpClient() *http.Client {
+	if m.HTTPClient != nil {
+		return m.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// awsSessionName returns the role session name, which shows up in CloudTrail,
+// ex. atlantis-infra-42.
+func awsSessionName(ctx command.ProjectContext) string {
+	name := invalidSessionNameChars.ReplaceAllString(fmt.Sprintf("atlantis-%s-%d", ctx.BaseRepo.Name, ctx.Pull.Num), "-")
+	// Session names can be at most 64 characters.
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
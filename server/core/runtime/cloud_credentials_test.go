@@ -0,0 +1,131 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func writeIdentityToken(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "token")
+	Ok(t, os.WriteFile(path, []byte("identity-token\n"), 0600))
+	return path
+}
+
+func cloudCredentialsCtx(creds valid.CloudCredentials) command.ProjectContext {
+	return command.ProjectContext{
+		BaseRepo:         models.Repo{Name: "infra"},
+		Pull:             models.PullRequest{Num: 42},
+		CloudCredentials: &creds,
+	}
+}
+
+func TestDefaultCloudCredentialsMinter_AWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, r.ParseForm())
+		Equals(t, "AssumeRoleWithWebIdentity", r.Form.Get("Action"))
+		Equals(t, "arn:aws:iam::123456789012:role/atlantis", r.Form.Get("RoleArn"))
+		Equals(t, "atlantis-infra-42", r.Form.Get("RoleSessionName"))
+		Equals(t, "identity-token", r.Form.Get("WebIdentityToken"))
+		Equals(t, "3600", r.Form.Get("DurationSeconds"))
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse>
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session</SessionToken>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)
+	}))
+	defer server.Close()
+
+	minter := &runtime.DefaultCloudCredentialsMinter{
+		IdentityTokenFile: writeIdentityToken(t),
+		AWSSTSEndpoint:    server.URL,
+	}
+	envs, err := minter.Mint(cloudCredentialsCtx(valid.CloudCredentials{
+		AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis", Region: "us-east-1", Duration: time.Hour},
+	}))
+	Ok(t, err)
+	Equals(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "ASIAEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+		"AWS_SESSION_TOKEN":     "session",
+		"AWS_REGION":            "us-east-1",
+	}, envs)
+}
+
+func TestDefaultCloudCredentialsMinter_AWSError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>Not authorized to perform sts:AssumeRoleWithWebIdentity</Message></Error></ErrorResponse>`)
+	}))
+	defer server.Close()
+
+	minter := &runtime.DefaultCloudCredentialsMinter{
+		IdentityTokenFile: writeIdentityToken(t),
+		AWSSTSEndpoint:    server.URL,
+	}
+	_, err := minter.Mint(cloudCredentialsCtx(valid.CloudCredentials{
+		AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"},
+	}))
+	ErrEquals(t, "assuming role arn:aws:iam::123456789012:role/atlantis: AccessDenied: Not authorized to perform sts:AssumeRoleWithWebIdentity", err)
+}
+
+func TestDefaultCloudCredentialsMinter_GCP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, r.ParseForm())
+		Equals(t, "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis", r.Form.Get("audience"))
+		Equals(t, "identity-token", r.Form.Get("subject_token"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "federated-token", "issued_token_type": "urn:ietf:params:oauth:token-type:access_token", "token_type": "Bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/v1/projects/-/serviceAccounts/terraform@my-project.iam.gserviceaccount.com:generateAccessToken", func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "Bearer federated-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		Ok(t, json.NewEncoder(w).Encode(map[string]string{
+			"accessToken": "access-token",
+			"expireTime":  time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		}))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	minter := &runtime.DefaultCloudCredentialsMinter{
+		IdentityTokenFile:         writeIdentityToken(t),
+		GCPSTSEndpoint:            server.URL + "/v1/token",
+		GCPIAMCredentialsEndpoint: server.URL,
+	}
+	envs, err := minter.Mint(cloudCredentialsCtx(valid.CloudCredentials{
+		GCP: &valid.GCPCredentials{
+			WorkloadIdentityProvider: "projects/123/locations/global/workloadIdentityPools/atlantis/providers/atlantis",
+			ServiceAccount:           "terraform@my-project.iam.gserviceaccount.com",
+		},
+	}))
+	Ok(t, err)
+	Equals(t, map[string]string{
+		"GOOGLE_OAUTH_ACCESS_TOKEN":  "access-token",
+		"CLOUDSDK_AUTH_ACCESS_TOKEN": "access-token",
+	}, envs)
+}
+
+func TestDefaultCloudCredentialsMinter_NoIdentityToken(t *testing.T) {
+	minter := &runtime.DefaultCloudCredentialsMinter{}
+	_, err := minter.Mint(cloudCredentialsCtx(valid.CloudCredentials{
+		AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"},
+	}))
+	ErrEquals(t, "cloud credentials can't be minted because the server doesn't have an identity token file configured", err)
+}
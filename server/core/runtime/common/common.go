@@ -89,3 +89,11 @@ func stringInSlice(stringSlice []string, target string) bool {
 	}
 	return false
 }
+
+// CommittedFileContent returns the content of the given file at the HEAD
+// commit of the git repo cloneDir is in. filename is relative to cloneDir.
+func CommittedFileContent(cloneDir string, filename string) ([]byte, error) {
+	cmd := exec.Command("git", "show", "HEAD:./"+filename) // nolint: gosec
+	cmd.Dir = cloneDir
+	return cmd.Output()
+}
@@ -0,0 +1,260 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+const (
+	// defaultRegistryHost is the registry of module sources without a host.
+	defaultRegistryHost = "registry.terraform.io"
+	// latestVersionTTL is how long the latest versions of dependencies are
+	// cached since most projects of a repo use the same ones.
+	latestVersionTTL = time.Hour
+	// registryTimeout bounds how long a plan waits for a registry.
+	registryTimeout = 10 * time.Second
+	lockFileName    = ".terraform.lock.hcl"
+)
+
+// DependencyChecker finds the dependencies of a project that are outdated.
+type DependencyChecker interface {
+	// Check returns the outdated providers and modules of the initialized
+	// project at path.
+	Check(ctx command.ProjectContext, path string) ([]models.OutdatedDependency, error)
+}
+
+// RegistryDependencyChecker compares the providers selected in the
+// dependency lock file and the registry modules installed by init to their
+// latest releases on their registry.
+type RegistryDependencyChecker struct {
+	// RegistryEndpoint overrides the URL of all registries. Used in tests.
+	RegistryEndpoint string
+	// HTTPClient defaults to a client that times out after registryTimeout.
+	HTTPClient *http.Client
+
+	mu sync.Mutex
+	// latest caches the latest versions by the URL they were listed from.
+	latest map[string]cachedVersion
+}
+
+type cachedVersion struct {
+	version *version.Version
+	expires time.Time
+}
+
+// lockFile is the dependency lock file written by init.
+type lockFile struct {
+	Providers []lockedProvider `hcl:"provider,block"`
+}
+
+type lockedProvider struct {
+	Address string   `hcl:"address,label"`
+	Version string   `hcl:"version"`
+	Hashes  []string `hcl:"hashes,optional"`
+	Remain  hcl.Body `hcl:",remain"`
+}
+
+// modulesManifest is the .terraform/modules/modules.json manifest of the
+// modules installed by init.
+type modulesManifest struct {
+	Modules []struct {
+		Key     string `json:"Key"`
+		Source  string `json:"Source"`
+		Version string `json:"Version"`
+	} `json:"Modules"`
+}
+
+func (c *RegistryDependencyChecker) Check(ctx command.ProjectContext, path string) ([]models.OutdatedDependency, error) {
+	var outdated []models.OutdatedDependency
+	check := func(depType string, source string, current string, versionsPath string) {
+		currentVersion, err := version.NewVersion(current)
+		if err != nil {
+			ctx.Log.Debug("not checking %s %s, its version %q can't be parsed", depType, source, current)
+			return
+		}
+		latest, err := c.latestVersion(versionsPath, depType)
+		if err != nil {
+			ctx.Log.Warn("unable to get the latest version of %s %s: %s", depType, source, err)
+			return
+		}
+		if latest != nil && latest.GreaterThan(currentVersion) {
+			outdated = append(outdated, models.OutdatedDependency{Type: depType, Source: source, Version: current, Latest: latest.String()})
+		}
+	}
+
+	providers, err := readLockFile(filepath.Join(path, lockFileName))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers.Providers {
+		parts := strings.Split(p.Address, "/")
+		if len(parts) != 3 {
+			continue
+		}
+		check(models.ProviderDependency, p.Address, p.Version, fmt.Sprintf("%s/v1/providers/%s/%s/versions", parts[0], parts[1], parts[2]))
+	}
+
+	modules, err := readModulesManifest(filepath.Join(path, ".terraform", "modules", "modules.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules.Modules {
+		// Only the project's own module calls are checked since the versions
+		// of nested modules are pinned by their parent. Modules that don't
+		// come from a registry have no version.
+		if m.Key == "" || strings.Contains(m.Key, ".") || m.Version == "" {
+			continue
+		}
+		source, _, _ := strings.Cut(m.Source, "//")
+		parts := strings.Split(source, "/")
+		if len(parts) == 3 {
+			parts = append([]string{defaultRegistryHost}, parts...)
+		}
+		if len(parts) != 4 {
+			continue
+		}
+		check(models.ModuleDependency, source, m.Version, fmt.Sprintf("%s/v1/modules/%s/%s/%s/versions", parts[0], parts[1], parts[2], parts[3]))
+	}
+	return outdated, nil
+}
+
+// latestVersion returns the latest release, ignoring pre-releases, listed by
+// the registry at versionsPath, ex.
+// "registry.terraform.io/v1/providers/hashicorp/aws/versions".
+func (c *RegistryDependencyChecker) latestVersion(versionsPath string, depType string) (*version.Version, error) {
+	c.mu.Lock()
+	cached, ok := c.latest[versionsPath]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.version, nil
+	}
+
+	host, apiPath, _ := strings.Cut(versionsPath, "/")
+	url := "https://" + host + "/" + apiPath
+	if c.RegistryEndpoint != "" {
+		url = strings.TrimRight(c.RegistryEndpoint, "/") + "/" + apiPath
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: registryTimeout}
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+
+	// The provider and module registry protocols list the versions
+	// differently.
+	var versions []string
+	if depType == models.ProviderDependency {
+		var body struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, errors.Wrapf(err, "parsing versions from %s", url)
+		}
+		for _, v := range body.Versions {
+			versions = append(versions, v.Version)
+		}
+	} else {
+		var body struct {
+			Modules []struct {
+				Versions []struct {
+					Version string `json:"version"`
+				} `json:"versions"`
+			} `json:"modules"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, errors.Wrapf(err, "parsing versions from %s", url)
+		}
+		for _, m := range body.Modules {
+			for _, v := range m.Versions {
+				versions = append(versions, v.Version)
+			}
+		}
+	}
+
+	var latest *version.Version
+	for _, v := range versions {
+		parsed, err := version.NewVersion(v)
+		if err != nil || parsed.Prerelease() != "" {
+			continue
+		}
+		if latest == nil || parsed.GreaterThan(latest) {
+			latest = parsed
+		}
+	}
+	c.mu.Lock()
+	if c.latest == nil {
+		c.latest = make(map[string]cachedVersion)
+	}
+	c.latest[versionsPath] = cachedVersion{version: latest, expires: time.Now().Add(latestVersionTTL)}
+	c.mu.Unlock()
+	return latest, nil
+}
+
+// readLockFile reads the dependency lock file at path. A project without
+// providers has no lock file.
+func readLockFile(path string) (lockFile, error) {
+	content, err := os.ReadFile(path) // nolint: gosec
+	if os.IsNotExist(err) {
+		return lockFile{}, nil
+	}
+	if err != nil {
+		return lockFile{}, err
+	}
+	return parseLockFile(content)
+}
+
+// parseLockFile parses the content of a dependency lock file.
+func parseLockFile(content []byte) (lockFile, error) {
+	var lock lockFile
+	file, diags := hclparse.NewParser().ParseHCL(content, lockFileName)
+	if diags.HasErrors() {
+		return lock, errors.Wrapf(diags, "parsing %s", lockFileName)
+	}
+	if diags := gohcl.DecodeBody(file.Body, nil, &lock); diags.HasErrors() {
+		return lock, errors.Wrapf(diags, "parsing %s", lockFileName)
+	}
+	sort.Slice(lock.Providers, func(i, j int) bool { return lock.Providers[i].Address < lock.Providers[j].Address })
+	return lock, nil
+}
+
+// readModulesManifest reads the manifest of the installed modules at path. A
+// project without modules has no manifest.
+func readModulesManifest(path string) (modulesManifest, error) {
+	var manifest modulesManifest
+	content, err := os.ReadFile(path) // nolint: gosec
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifest, errors.Wrap(err, "parsing modules manifest")
+	}
+	sort.Slice(manifest.Modules, func(i, j int) bool { return manifest.Modules[i].Key < manifest.Modules[j].Key })
+	return manifest, nil
+}
@@ -0,0 +1,87 @@
+package runtime_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const lockFileContent = `# This file is maintained automatically by "terraform init".
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.1.0"
+  constraints = "~> 5.0"
+  hashes = [
+    "h1:abc=",
+  ]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.6.0"
+}
+`
+
+const modulesManifestContent = `{"Modules":[
+  {"Key":"","Source":"","Dir":"."},
+  {"Key":"vpc","Source":"registry.terraform.io/terraform-aws-modules/vpc/aws","Version":"5.0.0","Dir":".terraform/modules/vpc"},
+  {"Key":"vpc.nested","Source":"registry.terraform.io/terraform-aws-modules/other/aws","Version":"1.0.0","Dir":".terraform/modules/vpc.nested"},
+  {"Key":"iam","Source":"terraform-aws-modules/iam/aws//modules/iam-role","Version":"5.30.0","Dir":".terraform/modules/iam"},
+  {"Key":"local","Source":"./modules/local","Dir":"modules/local"}
+]}`
+
+func TestRegistryDependencyChecker_Check(t *testing.T) {
+	requests := map[string]int{}
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/v1/providers/hashicorp/aws/versions":
+			fmt.Fprint(w, `{"versions":[{"version":"5.1.0"},{"version":"5.40.0"},{"version":"6.0.0-beta1"}]}`)
+		case "/v1/providers/hashicorp/random/versions":
+			fmt.Fprint(w, `{"versions":[{"version":"3.6.0"},{"version":"3.5.1"}]}`)
+		case "/v1/modules/terraform-aws-modules/vpc/aws/versions":
+			fmt.Fprint(w, `{"modules":[{"versions":[{"version":"5.0.0"},{"version":"5.8.1"}]}]}`)
+		case "/v1/modules/terraform-aws-modules/iam/aws/versions":
+			fmt.Fprint(w, `{"modules":[{"versions":[{"version":"5.30.0"}]}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+
+	dir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(dir, ".terraform.lock.hcl"), []byte(lockFileContent), 0600))
+	Ok(t, os.MkdirAll(filepath.Join(dir, ".terraform", "modules"), 0700))
+	Ok(t, os.WriteFile(filepath.Join(dir, ".terraform", "modules", "modules.json"), []byte(modulesManifestContent), 0600))
+
+	checker := &runtime.RegistryDependencyChecker{RegistryEndpoint: registry.URL}
+	ctx := command.ProjectContext{Log: logging.NewNoopLogger(t)}
+	exp := []models.OutdatedDependency{
+		{Type: "provider", Source: "registry.terraform.io/hashicorp/aws", Version: "5.1.0", Latest: "5.40.0"},
+		{Type: "module", Source: "registry.terraform.io/terraform-aws-modules/vpc/aws", Version: "5.0.0", Latest: "5.8.1"},
+	}
+	outdated, err := checker.Check(ctx, dir)
+	Ok(t, err)
+	Equals(t, exp, outdated)
+
+	// The latest versions are cached.
+	outdated, err = checker.Check(ctx, dir)
+	Ok(t, err)
+	Equals(t, exp, outdated)
+	Equals(t, 1, requests["/v1/providers/hashicorp/aws/versions"])
+	Equals(t, 0, requests["/v1/modules/terraform-aws-modules/other/aws/versions"])
+}
+
+func TestRegistryDependencyChecker_CheckUninitialized(t *testing.T) {
+	checker := &runtime.RegistryDependencyChecker{}
+	outdated, err := checker.Check(command.ProjectContext{Log: logging.NewNoopLogger(t)}, t.TempDir())
+	Ok(t, err)
+	Equals(t, []models.OutdatedDependency(nil), outdated)
+}
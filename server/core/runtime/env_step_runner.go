@@ -24,6 +24,9 @@ func (r *EnvStepRunner) Run(
 	envs map[string]string,
 ) (string, error) {
 	if value != "" {
+		if r.RunStepRunner != nil && r.RunStepRunner.SecretResolver != nil {
+			return r.RunStepRunner.SecretResolver.Resolve(value)
+		}
 		return value, nil
 	}
 	// Pass `false` for streamOutput because this isn't interesting to the user reading the build logs
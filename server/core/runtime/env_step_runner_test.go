@@ -1,6 +1,7 @@
 package runtime_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/go-version"
@@ -92,3 +93,48 @@ func TestEnvStepRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+type fakeSecretResolver struct {
+	secrets map[string]string
+}
+
+func (f fakeSecretResolver) Resolve(s string) (string, error) {
+	return f.ResolveFunc(s, func(secret string) string { return secret })
+}
+
+func (f fakeSecretResolver) ResolveFunc(s string, replace func(secret string) string) (string, error) {
+	for uri, secret := range f.secrets {
+		s = strings.ReplaceAll(s, uri, replace(secret))
+	}
+	return s, nil
+}
+
+// Test that secrets are resolved in env step values and in the commands run
+// for env steps.
+func TestEnvStepRunner_RunResolvesSecrets(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	envRunner := runtime.EnvStepRunner{
+		RunStepRunner: &runtime.RunStepRunner{
+			TerraformExecutor:       tfclientmocks.NewMockClient(),
+			DefaultTFDistribution:   terraform.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader()),
+			DefaultTFVersion:        tfVersion,
+			ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+			SecretResolver:          fakeSecretResolver{secrets: map[string]string{"vault:secret/data/db#password": "s3cr3t"}},
+		},
+	}
+	ctx := command.ProjectContext{
+		Log:              logging.NewNoopLogger(t),
+		Workspace:        "default",
+		TerraformVersion: tfVersion,
+	}
+
+	value, err := envRunner.Run(ctx, nil, "", "vault:secret/data/db#password", t.TempDir(), nil)
+	Ok(t, err)
+	Equals(t, "s3cr3t", value)
+
+	value, err = envRunner.Run(ctx, nil, "echo password=vault:secret/data/db#password", "", t.TempDir(), nil)
+	Ok(t, err)
+	Equals(t, "password=s3cr3t", value)
+}
@@ -0,0 +1,77 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// FmtCheckErr is returned by the fmt_check step when files aren't formatted.
+type FmtCheckErr struct {
+	// Files are the unformatted files, relative to the project dir.
+	Files []string
+	// Diff is the diff terraform fmt would apply to Files.
+	Diff string
+	// Suggested is true if the fixes were posted as suggestions on the pull
+	// request, in which case the error doesn't include Diff.
+	Suggested bool
+}
+
+func (e FmtCheckErr) Error() string {
+	if e.Suggested {
+		return fmt.Sprintf("terraform fmt found unformatted files, the fixes were suggested on the pull request: %s", strings.Join(e.Files, ", "))
+	}
+	return fmt.Sprintf("terraform fmt found unformatted files, run 'terraform fmt' to fix them:\n%s", strings.TrimSpace(e.Diff))
+}
+
+// fmtCheckStepRunner checks that the project's files are formatted with
+// terraform fmt.
+type fmtCheckStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+func NewFmtCheckStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	return &fmtCheckStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+}
+
+func (f *fmtCheckStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := f.defaultTFDistribution
+	tfVersion := f.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	fmtCmd := []string{"fmt", "-check", "-diff", "-no-color"}
+	fmtCmd = append(fmtCmd, extraArgs...)
+	out, err := f.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), fmtCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	if err == nil {
+		return "", nil
+	}
+
+	// With -diff, each unformatted file's diff starts with the
+	// "--- old/<file>" and "+++ new/<file>" headers. Without them the files
+	// couldn't be parsed, ex. because of a syntax error.
+	fmtErr := FmtCheckErr{Diff: out}
+	for _, line := range strings.Split(out, "\n") {
+		if file, ok := strings.CutPrefix(line, "+++ new/"); ok {
+			fmtErr.Files = append(fmtErr.Files, file)
+		}
+	}
+	if len(fmtErr.Files) == 0 {
+		return out, err
+	}
+	return "", fmtErr
+}
@@ -0,0 +1,77 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const fmtDiffOutput = `main.tf
+--- old/main.tf
++++ new/main.tf
+@@ -1,3 +1,3 @@
+ resource "null_resource" "this" {
+-  triggers = { a = 1 }
++  triggers = { a = 1 }
+ }
+`
+
+func TestFmtCheckStepRunner_Run(t *testing.T) {
+	cases := map[string]struct {
+		output    string
+		err       error
+		expErr    error
+		expOutput string
+	}{
+		"formatted": {},
+		"unformatted": {
+			output: fmtDiffOutput,
+			err:    errors.New("exit status 3"),
+			expErr: runtime.FmtCheckErr{Files: []string{"main.tf"}, Diff: fmtDiffOutput},
+		},
+		"invalid": {
+			output:    "Error: Invalid expression",
+			err:       errors.New("exit status 2"),
+			expErr:    errors.New("exit status 2"),
+			expOutput: "Error: Invalid expression",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			terraform := tfclientmocks.NewMockClient()
+			tfVersion, _ := version.NewVersion("1.5.0")
+			tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+			s := runtime.NewFmtCheckStepRunner(terraform, tfDistribution, tfVersion)
+
+			ctx := command.ProjectContext{
+				Log:       logging.NewNoopLogger(t),
+				Workspace: "default",
+			}
+			tmpDir := t.TempDir()
+			fmtCmd := []string{"fmt", "-check", "-diff", "-no-color", "-recursive"}
+			When(terraform.RunCommandWithVersion(ctx, tmpDir, fmtCmd, map[string]string(nil), tfDistribution, tfVersion, "default")).
+				ThenReturn(c.output, c.err)
+
+			output, err := s.Run(ctx, []string{"-recursive"}, tmpDir, map[string]string(nil))
+			Equals(t, c.expErr, err)
+			Equals(t, c.expOutput, output)
+		})
+	}
+}
+
+func TestFmtCheckErr_Error(t *testing.T) {
+	err := runtime.FmtCheckErr{Files: []string{"main.tf", "vars.tf"}, Diff: fmtDiffOutput}
+	Equals(t, "terraform fmt found unformatted files, run 'terraform fmt' to fix them:\n"+fmtDiffOutput[:len(fmtDiffOutput)-1], err.Error())
+	err.Suggested = true
+	Equals(t, "terraform fmt found unformatted files, the fixes were suggested on the pull request: main.tf, vars.tf", err.Error())
+}
@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+type forceUnlockStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+func NewForceUnlockStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	runner := &forceUnlockStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+	return NewWorkspaceStepRunnerDelegate(terraformExecutor, defaultTfDistribution, defaultTfVersion, runner)
+}
+
+func (p *forceUnlockStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := p.defaultTFDistribution
+	tfVersion := p.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	// -force skips the interactive confirmation prompt since there is no one
+	// to answer it. The lock ID is the only comment argument.
+	forceUnlockCmd := []string{"force-unlock", "-force"}
+	forceUnlockCmd = append(forceUnlockCmd, extraArgs...)
+	forceUnlockCmd = append(forceUnlockCmd, ctx.EscapedCommentArgs...)
+	return p.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), forceUnlockCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+}
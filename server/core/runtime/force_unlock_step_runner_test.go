@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestForceUnlockStepRunner_Run_Success(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	tmpDir := t.TempDir()
+	context := command.ProjectContext{
+		Log:                logger,
+		EscapedCommentArgs: []string{"1c9f8e3a-3b7d-4d3b-9a8c-5a0f6e2b7d41"},
+		Workspace:          "default",
+	}
+
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	mockDownloader := mocks.NewMockDownloader()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
+	s := NewForceUnlockStepRunner(terraform, tfDistribution, tfVersion)
+
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("Terraform state has been successfully unlocked!", nil)
+	output, err := s.Run(context, []string{"-no-color"}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "Terraform state has been successfully unlocked!", output)
+	commands := []string{"force-unlock", "-force", "-no-color", "1c9f8e3a-3b7d-4d3b-9a8c-5a0f6e2b7d41"}
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, commands, map[string]string(nil), tfDistribution, tfVersion, "default")
+}
+
+func TestForceUnlockStepRunner_Run_Workspace(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	workspace := "something"
+	tmpDir := t.TempDir()
+	context := command.ProjectContext{
+		Log:                logger,
+		EscapedCommentArgs: []string{"lock-id"},
+		Workspace:          workspace,
+	}
+
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	mockDownloader := mocks.NewMockDownloader()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
+	s := NewForceUnlockStepRunner(terraform, tfDistribution, tfVersion)
+
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("output", nil)
+	output, err := s.Run(context, []string{}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "output", output)
+
+	// switch workspace
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, []string{"workspace", "show"}, map[string]string(nil), tfDistribution, tfVersion, workspace)
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, []string{"workspace", "select", workspace}, map[string]string(nil), tfDistribution, tfVersion, workspace)
+
+	// exec force-unlock
+	commands := []string{"force-unlock", "-force", "lock-id"}
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, commands, map[string]string(nil), tfDistribution, tfVersion, workspace)
+}
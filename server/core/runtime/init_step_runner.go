@@ -1,7 +1,10 @@
 package runtime
 
 import (
+	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/runtime/common"
@@ -10,11 +13,27 @@ import (
 	"github.com/runatlantis/atlantis/server/utils"
 )
 
+// SharedInitDirName is the name of the dir inside the data dir where the
+// modules and providers installed by init are shared between workspaces.
+const SharedInitDirName = "init-cache"
+
+// sharedInitDirs are the dirs under .terraform that are shared between
+// workspaces. The others, ex. the backend configuration, are per workspace.
+var sharedInitDirs = []string{"modules", "providers"}
+
+// sharedInitLocks are the locks of the shared init dirs, by path. Only one
+// workspace can run init with a shared dir at a time.
+var sharedInitLocks sync.Map
+
 // InitStep runs `terraform init`.
 type InitStepRunner struct {
 	TerraformExecutor     TerraformExec
 	DefaultTFDistribution terraform.Distribution
 	DefaultTFVersion      *version.Version
+	// SharedInitDir, if set, is the dir where the modules and providers
+	// installed by init are shared between the workspaces of a project dir so
+	// they're only downloaded by the first one.
+	SharedInitDir string
 }
 
 func (i *InitStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
@@ -61,6 +80,17 @@ func (i *InitStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 
 	finalArgs := common.DeDuplicateExtraArgs(terraformInitArgs, extraArgs)
 
+	if i.SharedInitDir != "" {
+		sharedDir := filepath.Join(i.SharedInitDir, ctx.BaseRepo.FullName, strconv.Itoa(ctx.Pull.Num), ctx.RepoRelDir)
+		value, _ := sharedInitLocks.LoadOrStore(sharedDir, new(sync.Mutex))
+		lock := value.(*sync.Mutex)
+		lock.Lock()
+		defer lock.Unlock()
+		if err := linkSharedInitDirs(path, sharedDir); err != nil {
+			ctx.Log.Warn("unable to share the modules and providers of %s between workspaces: %s", ctx.RepoRelDir, err)
+		}
+	}
+
 	terraformInitCmd := append(terraformInitVerb, finalArgs...)
 
 	out, err := i.TerraformExecutor.RunCommandWithVersion(ctx, path, terraformInitCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
@@ -71,3 +101,31 @@ func (i *InitStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 	}
 	return "", nil
 }
+
+// linkSharedInitDirs replaces the sharedInitDirs of the .terraform dir of the
+// project at path with symlinks to the ones in sharedDir.
+func linkSharedInitDirs(path string, sharedDir string) error {
+	for _, name := range sharedInitDirs {
+		target := filepath.Join(sharedDir, name)
+		if err := os.MkdirAll(target, 0700); err != nil {
+			return err
+		}
+		link := filepath.Join(path, ".terraform", name)
+		if info, err := os.Lstat(link); err == nil {
+			if info.Mode()&os.ModeSymlink != 0 {
+				continue
+			}
+			// Installed before the dirs were shared.
+			if err := os.RemoveAll(link); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(link), 0700); err != nil {
+			return err
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
 	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -387,6 +388,44 @@ func TestRun_InitDeletesLockFileIfPresentAndNotTracked(t *testing.T) {
 	terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, repoDir, expectedArgs, map[string]string(nil), tfDistribution, tfVersion, "workspace")
 }
 
+func TestRun_InitSharesModulesAndProvidersBetweenWorkspaces(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	sharedInitDir := t.TempDir()
+	iso := runtime.InitStepRunner{
+		TerraformExecutor:     terraform,
+		DefaultTFDistribution: tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader()),
+		DefaultTFVersion:      tfVersion,
+		SharedInitDir:         sharedInitDir,
+	}
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("", nil)
+
+	pullDir := t.TempDir()
+	// The staging workspace was initialized before the dirs were shared.
+	Ok(t, os.MkdirAll(filepath.Join(pullDir, "staging", "project", ".terraform", "modules", "vpc"), 0700))
+	for _, workspace := range []string{"default", "staging"} {
+		ctx := command.ProjectContext{
+			BaseRepo:   models.Repo{FullName: "owner/repo"},
+			Pull:       models.PullRequest{Num: 1},
+			Workspace:  workspace,
+			RepoRelDir: "project",
+			Log:        logging.NewNoopLogger(t),
+		}
+		path := filepath.Join(pullDir, workspace, "project")
+		Ok(t, os.MkdirAll(path, 0700))
+		_, err := iso.Run(ctx, nil, path, map[string]string(nil))
+		Ok(t, err)
+
+		for _, dir := range []string{"modules", "providers"} {
+			target, err := os.Readlink(filepath.Join(path, ".terraform", dir))
+			Ok(t, err)
+			Equals(t, filepath.Join(sharedInitDir, "owner/repo", "1", "project", dir), target)
+		}
+	}
+}
+
 func runCmd(t *testing.T, dir string, name string, args ...string) string {
 	t.Helper()
 	cpCmd := exec.Command(name, args...)
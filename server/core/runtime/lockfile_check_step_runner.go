@@ -0,0 +1,115 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/runtime/common"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// LockfileCheckErr is returned by the lockfile_check step when the committed
+// dependency lock file doesn't cover the providers of the project.
+type LockfileCheckErr struct {
+	// Problems describe each provider that isn't covered.
+	Problems []string
+}
+
+func (e LockfileCheckErr) Error() string {
+	return fmt.Sprintf("the committed %s doesn't match the providers of the project, run 'terraform providers lock' for all the platforms Atlantis and your team use and commit it:\n* %s",
+		lockFileName, strings.Join(e.Problems, "\n* "))
+}
+
+// lockfileCheckStepRunner checks that the dependency lock file committed in
+// the project dir locks all the providers init selected, with checksums for
+// the platforms passed as -platform extra args. Otherwise plans only work on
+// the server, because init updated its copy of the lock file.
+type lockfileCheckStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+func NewLockfileCheckStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	return &lockfileCheckStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+}
+
+func (l *lockfileCheckStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := l.defaultTFDistribution
+	tfVersion := l.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	// Without -platform args, we only check what init changed for the
+	// server's platform.
+	if slices.ContainsFunc(extraArgs, func(arg string) bool { return strings.HasPrefix(arg, "-platform") }) {
+		lockCmd := append([]string{"providers", "lock"}, extraArgs...)
+		out, err := l.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), lockCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	selected, err := readLockFile(filepath.Join(path, lockFileName))
+	if err != nil {
+		return "", err
+	}
+	if len(selected.Providers) == 0 {
+		ctx.Log.Debug("project has no providers, not checking %s", lockFileName)
+		return "", nil
+	}
+	tracked, err := common.IsFileTracked(path, lockFileName)
+	if err != nil {
+		return "", err
+	}
+	if !tracked {
+		return "", LockfileCheckErr{Problems: []string{fmt.Sprintf("no %s is committed", lockFileName)}}
+	}
+	content, err := common.CommittedFileContent(path, lockFileName)
+	if err != nil {
+		return "", err
+	}
+	committed, err := parseLockFile(content)
+	if err != nil {
+		return "", err
+	}
+
+	var problems []string
+	for _, p := range selected.Providers {
+		i := slices.IndexFunc(committed.Providers, func(c lockedProvider) bool { return c.Address == p.Address })
+		if i == -1 {
+			problems = append(problems, fmt.Sprintf("%s isn't locked", p.Address))
+			continue
+		}
+		c := committed.Providers[i]
+		if c.Version != p.Version {
+			problems = append(problems, fmt.Sprintf("%s is locked at %s but %s is selected", p.Address, c.Version, p.Version))
+			continue
+		}
+		missing := 0
+		for _, hash := range p.Hashes {
+			if !slices.Contains(c.Hashes, hash) {
+				missing++
+			}
+		}
+		if missing > 0 {
+			problems = append(problems, fmt.Sprintf("%s is missing %d checksum(s), the lock file wasn't generated for all platforms", p.Address, missing))
+		}
+	}
+	if len(problems) > 0 {
+		return "", LockfileCheckErr{Problems: problems}
+	}
+	return "", nil
+}
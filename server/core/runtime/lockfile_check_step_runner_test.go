@@ -0,0 +1,109 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const committedLockFile = `provider "registry.terraform.io/hashicorp/aws" {
+  version = "5.1.0"
+  hashes = [
+    "h1:darwin=",
+    "zh:all=",
+  ]
+}
+`
+
+func TestLockfileCheckStepRunner_Run(t *testing.T) {
+	cases := map[string]struct {
+		// selected is the lock file after init, or empty if it's the
+		// committed one.
+		selected  string
+		untracked bool
+		extraArgs []string
+		expErr    error
+	}{
+		"up to date": {},
+		"no lock file committed": {
+			untracked: true,
+			expErr:    runtime.LockfileCheckErr{Problems: []string{"no .terraform.lock.hcl is committed"}},
+		},
+		"missing checksums": {
+			selected: `provider "registry.terraform.io/hashicorp/aws" {
+  version = "5.1.0"
+  hashes  = ["h1:darwin=", "h1:linux=", "zh:all="]
+}
+`,
+			extraArgs: []string{"-platform=linux_amd64"},
+			expErr:    runtime.LockfileCheckErr{Problems: []string{"registry.terraform.io/hashicorp/aws is missing 1 checksum(s), the lock file wasn't generated for all platforms"}},
+		},
+		"providers not locked": {
+			selected: `provider "registry.terraform.io/hashicorp/aws" {
+  version = "5.2.0"
+  hashes  = ["h1:other="]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.6.0"
+}
+`,
+			expErr: runtime.LockfileCheckErr{Problems: []string{
+				"registry.terraform.io/hashicorp/aws is locked at 5.1.0 but 5.2.0 is selected",
+				"registry.terraform.io/hashicorp/random isn't locked",
+			}},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			terraform := tfclientmocks.NewMockClient()
+			tfVersion, _ := version.NewVersion("1.5.0")
+			tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+			s := runtime.NewLockfileCheckStepRunner(terraform, tfDistribution, tfVersion)
+			ctx := command.ProjectContext{
+				Log:       logging.NewNoopLogger(t),
+				Workspace: "default",
+			}
+
+			repoDir := initRepo(t)
+			lockFilePath := filepath.Join(repoDir, ".terraform.lock.hcl")
+			Ok(t, os.WriteFile(lockFilePath, []byte(committedLockFile), 0600))
+			if !c.untracked {
+				runCmd(t, repoDir, "git", "add", ".terraform.lock.hcl")
+				runCmd(t, repoDir, "git", "commit", "-m", "add .terraform.lock.hcl")
+			}
+			if c.selected != "" {
+				Ok(t, os.WriteFile(lockFilePath, []byte(c.selected), 0600))
+			}
+
+			output, err := s.Run(ctx, c.extraArgs, repoDir, map[string]string(nil))
+			Equals(t, c.expErr, err)
+			Equals(t, "", output)
+			if len(c.extraArgs) > 0 {
+				terraform.VerifyWasCalledOnce().RunCommandWithVersion(ctx, repoDir, []string{"providers", "lock", "-platform=linux_amd64"}, map[string]string(nil), tfDistribution, tfVersion, "default")
+			} else {
+				terraform.VerifyWasCalled(Never()).RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())
+			}
+		})
+	}
+}
+
+func TestLockfileCheckStepRunner_RunNoProviders(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, _ := version.NewVersion("1.5.0")
+	s := runtime.NewLockfileCheckStepRunner(tfclientmocks.NewMockClient(), tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader()), tfVersion)
+	output, err := s.Run(command.ProjectContext{Log: logging.NewNoopLogger(t)}, nil, t.TempDir(), map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "", output)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// startInCgroup makes cmd start in cgroup so its processes are limited by it
+// from the start.
+func startInCgroup(cmd *exec.Cmd, cgroup *os.File) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(cgroup.Fd())
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package models
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// startInCgroup returns an error since cgroups only exist on Linux.
+func startInCgroup(_ *exec.Cmd, _ *os.File) error {
+	return errors.New("the cpu and memory resource limits are only supported on Linux")
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package models
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group so killProcessGroup
+// also stops the processes it starts, ex. terraform and its providers when
+// cmd is a shell. It's only used for commands that can be stopped.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// interruptProcessGroup interrupts cmd's process group like Ctrl-C would,
+// which lets terraform stop gracefully and release its state lock.
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
+
+// killProcessGroup kills cmd's process group.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// setProcessGroup starts cmd in its own process group so interruptProcessGroup
+// can send it Ctrl-Break without also interrupting Atlantis. It's only used
+// for commands that can be stopped.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+}
+
+// interruptProcessGroup sends Ctrl-Break to cmd's process group, which lets
+// terraform stop gracefully and release its state lock. Only processes
+// attached to the console of Atlantis can be sent it, so the process group is
+// killed instead when that fails, ex. when Atlantis runs as a service.
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid)); err != nil { // nolint: gosec
+		return killProcessGroup(cmd)
+	}
+	return nil
+}
+
+// killProcessGroup kills cmd's process and the processes it started, ex.
+// terraform and its providers when cmd is a shell.
+func killProcessGroup(cmd *exec.Cmd) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil { // #nosec
+		return cmd.Process.Kill()
+	}
+	return nil
+}
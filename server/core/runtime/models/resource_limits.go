@@ -0,0 +1,160 @@
+package models
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// cpuPeriod is the period in microseconds of the cgroup CPU limit.
+const cpuPeriod = 100000
+
+// ErrMemoryLimit is the error of commands killed for going over the memory
+// limit of their cgroup.
+var ErrMemoryLimit = errors.New("killed for using more memory than its limit")
+
+// cgroupStats are the stats of a cgroup that show whether its limits were hit.
+type cgroupStats struct {
+	// CPUThrottledPeriods is how many periods the processes were throttled
+	// for in cpu.stat.
+	CPUThrottledPeriods int64
+	// CPUThrottledUsec is how long the processes were throttled for in
+	// cpu.stat.
+	CPUThrottledUsec int64
+	// MemoryMaxEvents is how many times the processes hit the memory limit in
+	// memory.events.
+	MemoryMaxEvents int64
+	// OOMKills is how many of the processes were killed for going over the
+	// memory limit in memory.events.
+	OOMKills int64
+}
+
+// LimitResources places cmd in its own cgroup under ctx.CgroupParent
+// limiting its CPU and memory to ctx.ResourceLimits. It must be called
+// before cmd is started and the returned function must be called once it has
+// exited, which emits metrics if the limits were hit, removes the cgroup and
+// returns true if cmd was killed for using too much memory.
+func LimitResources(ctx command.ProjectContext, cmd *exec.Cmd) (func() bool, error) {
+	if !ctx.ResourceLimits.NeedsCgroup() {
+		return func() bool { return false }, nil
+	}
+	if ctx.CgroupParent == "" {
+		return nil, errors.New("the cpu and memory resource limits require Atlantis to be started with --cgroup-parent")
+	}
+	dir, err := os.MkdirTemp(ctx.CgroupParent, "atlantis-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cgroup")
+	}
+	remove := func() {
+		if err := removeCgroup(dir); err != nil {
+			ctx.Log.Warn("unable to remove cgroup '%s': %s", dir, err)
+		}
+	}
+	if ctx.ResourceLimits.CPU > 0 {
+		quota := int64(ctx.ResourceLimits.CPU * cpuPeriod)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(strconv.FormatInt(quota, 10)+" "+strconv.Itoa(cpuPeriod)), 0600); err != nil {
+			remove()
+			return nil, errors.Wrap(err, "limiting cgroup cpu")
+		}
+	}
+	if ctx.ResourceLimits.Memory > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(ctx.ResourceLimits.Memory, 10)), 0600); err != nil {
+			remove()
+			return nil, errors.Wrap(err, "limiting cgroup memory")
+		}
+	}
+	cgroup, err := os.Open(dir)
+	if err != nil {
+		remove()
+		return nil, errors.Wrap(err, "opening cgroup")
+	}
+	if err := startInCgroup(cmd, cgroup); err != nil {
+		cgroup.Close() // nolint: errcheck
+		remove()
+		return nil, err
+	}
+
+	return func() bool {
+		cgroup.Close() // nolint: errcheck
+		stats, err := readCgroupStats(dir)
+		if err != nil {
+			ctx.Log.Warn("unable to read stats of cgroup '%s': %s", dir, err)
+		}
+		if ctx.Scope != nil {
+			scope := ctx.Scope.SubScope("resource_limits")
+			if stats.CPUThrottledPeriods > 0 {
+				scope.Counter("cpu_throttled_periods").Inc(stats.CPUThrottledPeriods)
+				scope.Timer("cpu_throttled").Record(time.Duration(stats.CPUThrottledUsec) * time.Microsecond)
+			}
+			if stats.MemoryMaxEvents > 0 {
+				scope.Counter("memory_max_events").Inc(stats.MemoryMaxEvents)
+			}
+			if stats.OOMKills > 0 {
+				scope.Counter("oom_kills").Inc(stats.OOMKills)
+			}
+		}
+		remove()
+		return stats.OOMKills > 0
+	}, nil
+}
+
+// readCgroupStats reads the stats of the cgroup dir. Stats of controllers
+// that aren't enabled are left at zero.
+func readCgroupStats(dir string) (cgroupStats, error) {
+	var stats cgroupStats
+	cpu, err := readCgroupKeyedFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return stats, err
+	}
+	memory, err := readCgroupKeyedFile(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return stats, err
+	}
+	stats.CPUThrottledPeriods = cpu["nr_throttled"]
+	stats.CPUThrottledUsec = cpu["throttled_usec"]
+	stats.MemoryMaxEvents = memory["max"]
+	stats.OOMKills = memory["oom_kill"]
+	return stats, nil
+}
+
+// readCgroupKeyedFile reads a cgroup file of "key value" lines. A missing
+// file has no keys.
+func readCgroupKeyedFile(path string) (map[string]int64, error) {
+	values := make(map[string]int64)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values, scanner.Err()
+}
+
+// removeCgroup kills the processes left in the cgroup dir, ex. ones that
+// were started in the background, and removes it.
+func removeCgroup(dir string) error {
+	if kill, err := os.OpenFile(filepath.Join(dir, "cgroup.kill"), os.O_WRONLY, 0); err == nil {
+		kill.WriteString("1") // nolint: errcheck
+		kill.Close()          // nolint: errcheck
+	}
+	return os.Remove(dir)
+}
@@ -0,0 +1,53 @@
+package models_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestLimitResources_Cgroup(t *testing.T) {
+	// A regular directory stands in for the cgroup parent since tests can't
+	// create cgroups.
+	parent := t.TempDir()
+	scope := tally.NewTestScope("test", nil)
+	cmd := exec.Command("true")
+	release, err := models.LimitResources(command.ProjectContext{
+		Log:            logging.NewNoopLogger(t),
+		Scope:          scope,
+		CgroupParent:   parent,
+		ResourceLimits: valid.ResourceLimits{CPU: 0.5, Memory: 512 << 20},
+	}, cmd)
+	Ok(t, err)
+	Assert(t, cmd.SysProcAttr.UseCgroupFD, "expected the command to be started in the cgroup")
+
+	cgroups, err := os.ReadDir(parent)
+	Ok(t, err)
+	Equals(t, 1, len(cgroups))
+	dir := filepath.Join(parent, cgroups[0].Name())
+	cpuMax, err := os.ReadFile(filepath.Join(dir, "cpu.max"))
+	Ok(t, err)
+	Equals(t, "50000 100000", string(cpuMax))
+	memoryMax, err := os.ReadFile(filepath.Join(dir, "memory.max"))
+	Ok(t, err)
+	Equals(t, "536870912", string(memoryMax))
+
+	// Stats the kernel would have written while the command ran.
+	Ok(t, os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec 900\nnr_periods 10\nnr_throttled 3\nthrottled_usec 250\n"), 0600))
+	Ok(t, os.WriteFile(filepath.Join(dir, "memory.events"), []byte("low 0\nhigh 0\nmax 4\noom 1\noom_kill 1\n"), 0600))
+	Assert(t, release(), "expected the memory limit to be hit")
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(3), counters["test.resource_limits.cpu_throttled_periods+"].Value())
+	Equals(t, int64(4), counters["test.resource_limits.memory_max_events+"].Value())
+	Equals(t, int64(1), counters["test.resource_limits.oom_kills+"].Value())
+	Equals(t, 1, len(scope.Snapshot().Timers()["test.resource_limits.cpu_throttled+"].Values()))
+}
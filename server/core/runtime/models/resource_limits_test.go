@@ -0,0 +1,31 @@
+package models_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLimitResources_NoLimits(t *testing.T) {
+	cmd := exec.Command("true")
+	release, err := models.LimitResources(command.ProjectContext{
+		Log:            logging.NewNoopLogger(t),
+		ResourceLimits: valid.ResourceLimits{Time: 1},
+	}, cmd)
+	Ok(t, err)
+	Assert(t, cmd.SysProcAttr == nil, "expected the command to not be placed in a cgroup")
+	Assert(t, !release(), "expected no memory limit to be hit")
+}
+
+func TestLimitResources_NoCgroupParent(t *testing.T) {
+	_, err := models.LimitResources(command.ProjectContext{
+		Log:            logging.NewNoopLogger(t),
+		ResourceLimits: valid.ResourceLimits{CPU: 1},
+	}, exec.Command("true"))
+	ErrEquals(t, "the cpu and memory resource limits require Atlantis to be started with --cgroup-parent", err)
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package models
+
+import (
+	"os/exec"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// setShellCmdLine is a no-op outside of Windows where exec passes arguments
+// as is.
+func setShellCmdLine(_ *exec.Cmd, _ *valid.CommandShell, _ string) {}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// setShellCmdLine passes command to cmd.exe as is. Unlike other programs
+// cmd.exe doesn't parse the escaping exec uses for arguments, which breaks
+// commands containing quotes.
+func setShellCmdLine(cmd *exec.Cmd, shell *valid.CommandShell, command string) {
+	if valid.ShellName(shell.Shell) != "cmd" {
+		return
+	}
+	args := []string{syscall.EscapeArg(shell.Shell)}
+	for _, arg := range shell.ShellArgs {
+		args = append(args, syscall.EscapeArg(arg))
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CmdLine = fmt.Sprintf(`%s "%s"`, strings.Join(args, " "), command)
+}
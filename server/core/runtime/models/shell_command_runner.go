@@ -57,6 +57,7 @@ func NewShellCommandRunner(
 	cmd := exec.Command(shell.Shell, args...) // #nosec
 	cmd.Env = environ
 	cmd.Dir = workingDir
+	setShellCmdLine(cmd, shell, command)
 
 	return &ShellCommandRunner{
 		command:       command,
@@ -68,6 +69,30 @@ func NewShellCommandRunner(
 	}
 }
 
+// NewCommandRunner returns a runner that runs the program name with args
+// directly instead of through a shell, so none of args are interpreted.
+func NewCommandRunner(
+	name string,
+	args []string,
+	environ []string,
+	workingDir string,
+	streamOutput bool,
+	outputHandler jobs.ProjectCommandOutputHandler,
+) *ShellCommandRunner {
+	cmd := exec.Command(name, args...) // #nosec
+	cmd.Env = environ
+	cmd.Dir = workingDir
+
+	return &ShellCommandRunner{
+		command:       strings.Join(args, " "),
+		workingDir:    workingDir,
+		outputHandler: outputHandler,
+		streamOutput:  streamOutput,
+		cmd:           cmd,
+		shell:         &valid.CommandShell{Shell: name},
+	}
+}
+
 func (s *ShellCommandRunner) Run(ctx command.ProjectContext) (string, error) {
 	_, outCh := s.RunCommandAsync(ctx)
 
@@ -112,13 +137,23 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 		stdin, _ := s.cmd.StdinPipe()
 
 		ctx.Log.Debug("starting '%s %q' in '%s'", s.shell.String(), s.command, s.workingDir)
-		err := s.cmd.Start()
+		if !ctx.Deadline.IsZero() || ctx.Cancellation != nil {
+			setProcessGroup(s.cmd)
+		}
+		release, err := LimitResources(ctx, s.cmd)
+		if err == nil {
+			err = s.cmd.Start()
+			if err != nil {
+				release()
+			}
+		}
 		if err != nil {
 			err = errors.Wrapf(err, "running '%s %q' in '%s'", s.shell.String(), s.command, s.workingDir)
 			ctx.Log.Err(err.Error())
 			outCh <- Line{Err: err}
 			return
 		}
+		stop := stopWhenDone(s.cmd, ctx.Deadline, ctx.Cancellation.Done())
 
 		// If we get anything on inCh, write it to stdin.
 		// This function will exit when inCh is closed which we do in our defer.
@@ -168,6 +203,12 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 
 		// Wait for the command to complete.
 		err = s.cmd.Wait()
+		if stop() && err != nil {
+			err = errStopped
+		}
+		if release() && err != nil {
+			err = ErrMemoryLimit
+		}
 
 		dur := time.Since(start)
 		log := ctx.Log.With("duration", dur)
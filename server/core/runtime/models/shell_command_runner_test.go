@@ -5,11 +5,13 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/core/runtime/models"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
 	logmocks "github.com/runatlantis/atlantis/server/logging/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -78,3 +80,23 @@ func TestShellCommandRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestShellCommandRunner_RunDeadline(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		Deadline:   time.Now().Add(200 * time.Millisecond),
+	}
+	cwd, err := os.Getwd()
+	Ok(t, err)
+
+	// The background sleep keeps the output open so the run only finishes
+	// early if the processes the shell started are killed too.
+	start := time.Now()
+	runner := models.NewShellCommandRunner(nil, "sleep 30 & echo started; wait", nil, cwd, false, mocks.NewMockProjectCommandOutputHandler())
+	_, err = runner.Run(ctx)
+	ErrContains(t, "stopped", err)
+	Assert(t, time.Since(start) < 10*time.Second, "expected the command to be stopped at its deadline")
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"bytes"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errStopped is the error of commands that were stopped because they were
+// still running at their deadline or were canceled.
+var errStopped = errors.New("stopped")
+
+// cancelGracePeriod is how long canceled commands have to exit after being
+// interrupted before they're killed.
+var cancelGracePeriod = 10 * time.Minute
+
+// stopWhenDone kills the process group of the started cmd if it's still
+// running at deadline, and interrupts it once canceled is closed. The
+// returned function must be called once cmd exits and reports whether cmd
+// was stopped.
+func stopWhenDone(cmd *exec.Cmd, deadline time.Time, canceled <-chan struct{}) func() bool {
+	if deadline.IsZero() && canceled == nil {
+		return func() bool { return false }
+	}
+	var expired <-chan time.Time
+	var timer *time.Timer
+	if !deadline.IsZero() {
+		timer = time.NewTimer(time.Until(deadline))
+		expired = timer.C
+	}
+	exited := make(chan struct{})
+	stopped := make(chan bool, 1)
+	go func() {
+		if timer != nil {
+			defer timer.Stop()
+		}
+		select {
+		case <-exited:
+			stopped <- false
+		case <-expired:
+			killProcessGroup(cmd) // nolint: errcheck
+			stopped <- true
+		case <-canceled:
+			// Give terraform the chance to stop gracefully so it doesn't
+			// leave the state locked, but don't wait forever.
+			interruptProcessGroup(cmd) // nolint: errcheck
+			select {
+			case <-exited:
+			case <-time.After(cancelGracePeriod):
+				killProcessGroup(cmd) // nolint: errcheck
+			}
+			stopped <- true
+		}
+	}()
+	return func() bool {
+		close(exited)
+		return <-stopped
+	}
+}
+
+// CombinedOutputUntil runs cmd like cmd.CombinedOutput but stops it and the
+// processes it started if it's still running at deadline or once canceled
+// is closed. A zero deadline and a nil canceled never stop cmd.
+func CombinedOutputUntil(cmd *exec.Cmd, deadline time.Time, canceled <-chan struct{}) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if !deadline.IsZero() || canceled != nil {
+		setProcessGroup(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	stop := stopWhenDone(cmd, deadline, canceled)
+	err := cmd.Wait()
+	if stop() && err != nil {
+		err = errStopped
+	}
+	return out.Bytes(), err
+}
@@ -0,0 +1,39 @@
+package models_test
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCombinedOutputUntil(t *testing.T) {
+	out, err := models.CombinedOutputUntil(exec.Command("sh", "-c", "echo out; >&2 echo err"), time.Time{}, nil)
+	Ok(t, err)
+	Equals(t, "out\nerr\n", string(out))
+
+	out, err = models.CombinedOutputUntil(exec.Command("sh", "-c", "echo done"), time.Now().Add(time.Minute), make(chan struct{}))
+	Ok(t, err)
+	Equals(t, "done\n", string(out))
+
+	start := time.Now()
+	out, err = models.CombinedOutputUntil(exec.Command("sh", "-c", "echo started; sleep 30 & wait"), time.Now().Add(200*time.Millisecond), nil)
+	ErrEquals(t, "stopped", err)
+	Equals(t, "started\n", string(out))
+	Assert(t, time.Since(start) < 10*time.Second, "expected the command to be stopped at its deadline")
+}
+
+func TestCombinedOutputUntil_Canceled(t *testing.T) {
+	canceled := make(chan struct{})
+	time.AfterFunc(200*time.Millisecond, func() { close(canceled) })
+
+	// The trap shows the command is interrupted so it can clean up before
+	// exiting.
+	start := time.Now()
+	out, err := models.CombinedOutputUntil(exec.Command("sh", "-c", "trap 'echo interrupted; exit 1' INT; echo started; sleep 30"), time.Time{}, canceled)
+	ErrEquals(t, "stopped", err)
+	Equals(t, "started\ninterrupted\n", string(out))
+	Assert(t, time.Since(start) < 10*time.Second, "expected the command to be stopped once canceled")
+}
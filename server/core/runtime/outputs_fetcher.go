@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// OutputsFetcher reads the terraform outputs a project shows after apply.
+type OutputsFetcher interface {
+	// Fetch returns the outputs listed in ctx.ShowOutputs, in that order.
+	Fetch(ctx command.ProjectContext, path string) ([]models.TerraformOutput, error)
+}
+
+// DefaultOutputsFetcher reads outputs with `terraform output -json`.
+type DefaultOutputsFetcher struct {
+	TerraformExecutor     TerraformExec
+	DefaultTFDistribution terraform.Distribution
+	DefaultTFVersion      *version.Version
+}
+
+// terraformOutputJSON is a single output in the `terraform output -json`
+// output.
+type terraformOutputJSON struct {
+	Sensitive bool            `json:"sensitive"`
+	Value     json.RawMessage `json:"value"`
+}
+
+func (f *DefaultOutputsFetcher) Fetch(ctx command.ProjectContext, path string) ([]models.TerraformOutput, error) {
+	tfDistribution := f.DefaultTFDistribution
+	tfVersion := f.DefaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	out, err := f.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), []string{"output", "-json"}, nil, tfDistribution, tfVersion, ctx.Workspace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "running terraform output: %s", out)
+	}
+	return ParseTerraformOutputs(out, ctx.ShowOutputs)
+}
+
+// ParseTerraformOutputs returns the outputs named names from the output of
+// `terraform output -json`. Outputs that don't exist are skipped and the
+// values of sensitive outputs are left empty.
+func ParseTerraformOutputs(out string, names []string) ([]models.TerraformOutput, error) {
+	// The output is combined with stderr so skip anything around the JSON.
+	if start, end := strings.Index(out, "{"), strings.LastIndex(out, "}"); start != -1 && end > start {
+		out = out[start : end+1]
+	}
+	var all map[string]terraformOutputJSON
+	if err := json.Unmarshal([]byte(out), &all); err != nil {
+		return nil, errors.Wrap(err, "parsing terraform output")
+	}
+
+	var outputs []models.TerraformOutput
+	for _, name := range names {
+		o, ok := all[name]
+		if !ok {
+			continue
+		}
+		if o.Sensitive {
+			outputs = append(outputs, models.TerraformOutput{Name: name, Sensitive: true})
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(o.Value, &value); err != nil {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, o.Value); err != nil {
+				return nil, errors.Wrapf(err, "parsing value of output %q", name)
+			}
+			value = compact.String()
+		}
+		outputs = append(outputs, models.TerraformOutput{Name: name, Value: value})
+	}
+	return outputs, nil
+}
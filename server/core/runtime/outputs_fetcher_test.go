@@ -0,0 +1,86 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const outputJSON = `{
+  "endpoint": {"sensitive": false, "type": "string", "value": "https://example.com"},
+  "password": {"sensitive": true, "type": "string", "value": "hunter2"},
+  "ports": {"sensitive": false, "type": ["list", "number"], "value": [80, 443]}
+}`
+
+func TestDefaultOutputsFetcher_Fetch(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	f := &runtime.DefaultOutputsFetcher{
+		TerraformExecutor:     terraform,
+		DefaultTFDistribution: tfDistribution,
+		DefaultTFVersion:      tfVersion,
+	}
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "default",
+		ShowOutputs: []string{"ports", "password", "missing", "endpoint"},
+	}
+	tmpDir := t.TempDir()
+	When(terraform.RunCommandWithVersion(ctx, tmpDir, []string{"output", "-json"}, map[string]string(nil), tfDistribution, tfVersion, "default")).
+		ThenReturn(outputJSON, nil)
+
+	outputs, err := f.Fetch(ctx, tmpDir)
+	Ok(t, err)
+	Equals(t, []models.TerraformOutput{
+		{Name: "ports", Value: "[80,443]"},
+		{Name: "password", Sensitive: true},
+		{Name: "endpoint", Value: "https://example.com"},
+	}, outputs)
+}
+
+func TestDefaultOutputsFetcher_FetchErr(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+	f := &runtime.DefaultOutputsFetcher{
+		TerraformExecutor:     terraform,
+		DefaultTFDistribution: tfDistribution,
+		DefaultTFVersion:      tfVersion,
+	}
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "default",
+		ShowOutputs: []string{"endpoint"},
+	}
+	tmpDir := t.TempDir()
+	When(terraform.RunCommandWithVersion(ctx, tmpDir, []string{"output", "-json"}, map[string]string(nil), tfDistribution, tfVersion, "default")).
+		ThenReturn("Error: No state", errors.New("exit status 1"))
+
+	_, err := f.Fetch(ctx, tmpDir)
+	ErrContains(t, "running terraform output: Error: No state", err)
+}
+
+func TestParseTerraformOutputs(t *testing.T) {
+	// Warnings printed to stderr around the JSON are ignored.
+	outputs, err := runtime.ParseTerraformOutputs("Warning: deprecated\n"+outputJSON+"\n", []string{"endpoint"})
+	Ok(t, err)
+	Equals(t, []models.TerraformOutput{{Name: "endpoint", Value: "https://example.com"}}, outputs)
+
+	_, err = runtime.ParseTerraformOutputs("not json", []string{"endpoint"})
+	ErrContains(t, "parsing terraform output", err)
+}
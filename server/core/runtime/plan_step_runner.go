@@ -1,11 +1,14 @@
 package runtime
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"text/template"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
@@ -55,6 +58,13 @@ func (p *planStepRunner) Run(ctx command.ProjectContext, extraArgs []string, pat
 		tfVersion = ctx.TerraformVersion
 	}
 
+	autoVarFileArgs, err := p.autoVarFileArgs(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	// Don't append in place, extraArgs is shared with the step config.
+	extraArgs = slices.Concat(extraArgs, autoVarFileArgs, p.environmentVarFileArgs(ctx, path))
+
 	planFile := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
 	planCmd := p.buildPlanCmd(ctx, extraArgs, path, tfVersion, planFile)
 	output, err := p.TerraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), planCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
@@ -137,6 +147,44 @@ func (p *planStepRunner) buildPlanCmd(ctx command.ProjectContext, extraArgs []st
 	return p.flatten(argList)
 }
 
+// autoVarFileArgs returns the -var-file argument for the var file configured
+// with auto_var_files if that file exists in the project directory.
+func (p *planStepRunner) autoVarFileArgs(ctx command.ProjectContext, path string) ([]string, error) {
+	if !ctx.AutoVarFiles.Enabled {
+		return nil, nil
+	}
+	tmpl, err := template.New("auto_var_files").Option("missingkey=error").Parse(ctx.AutoVarFiles.Template)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing auto_var_files template")
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Workspace   string
+		ProjectName string
+	}{
+		Workspace:   ctx.Workspace,
+		ProjectName: ctx.ProjectName,
+	}); err != nil {
+		return nil, errors.Wrap(err, "rendering auto_var_files template")
+	}
+	varFile := filepath.Join(path, buf.String())
+	if _, err := os.Stat(varFile); err != nil {
+		ctx.Log.Debug("auto_var_files: %q does not exist, not passing it to plan", varFile)
+		return nil, nil
+	}
+	return []string{"-var-file", varFile}, nil
+}
+
+// environmentVarFileArgs returns the -var-file arguments for the var files of
+// the environment this project is planned for.
+func (p *planStepRunner) environmentVarFileArgs(ctx command.ProjectContext, path string) []string {
+	var args []string
+	for _, varFile := range ctx.VarFiles {
+		args = append(args, "-var-file", filepath.Join(path, varFile))
+	}
+	return args
+}
+
 // tfVars returns a list of "-var", "key=value" pairs that identify who and which
 // repo this command is running for. This can be used for naming the
 // session name in AWS which will identify in CloudTrail the source of
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	runtimemocks "github.com/runatlantis/atlantis/server/core/runtime/mocks"
 	runtimemodels "github.com/runatlantis/atlantis/server/core/runtime/models"
@@ -93,6 +94,106 @@ func TestRun_AddsEnvVarFile(t *testing.T) {
 	Equals(t, "output", output)
 }
 
+func TestRun_AddsAutoVarFile(t *testing.T) {
+	// Test that if auto_var_files is enabled and the rendered file exists we
+	// use -var-file option.
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	commitStatusUpdater := runtimemocks.NewMockStatusUpdater()
+	asyncTfExec := runtimemocks.NewMockAsyncTFExec()
+
+	tmpDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(tmpDir, "vars"), 0700)
+	Ok(t, err)
+	varFile := filepath.Join(tmpDir, "vars/myproject-staging.tfvars")
+	err = os.WriteFile(varFile, nil, 0600)
+	Ok(t, err)
+
+	mockDownloader := mocks.NewMockDownloader()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
+	tfVersion, _ := version.NewVersion("0.12.0")
+	logger := logging.NewNoopLogger(t)
+	s := runtime.NewPlanStepRunner(terraform, tfDistribution, tfVersion, commitStatusUpdater, asyncTfExec)
+
+	cases := map[string]struct {
+		autoVarFiles valid.AutoVarFiles
+		expArgs      []string
+	}{
+		"disabled": {
+			autoVarFiles: valid.AutoVarFiles{Enabled: false, Template: "vars/{{ .ProjectName }}-{{ .Workspace }}.tfvars"},
+		},
+		"file exists": {
+			autoVarFiles: valid.AutoVarFiles{Enabled: true, Template: "vars/{{ .ProjectName }}-{{ .Workspace }}.tfvars"},
+			expArgs:      []string{"-var-file", varFile},
+		},
+		"file missing": {
+			autoVarFiles: valid.AutoVarFiles{Enabled: true, Template: "{{ .Workspace }}.tfvars"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := command.ProjectContext{
+				Log:          logger,
+				Workspace:    "staging",
+				ProjectName:  "myproject",
+				RepoRelDir:   ".",
+				AutoVarFiles: c.autoVarFiles,
+			}
+			expPlanArgs := append([]string{"plan",
+				"-input=false",
+				"-refresh",
+				"-out",
+				fmt.Sprintf("%q", filepath.Join(tmpDir, "myproject-staging.tfplan")),
+				"extra",
+			}, c.expArgs...)
+			When(terraform.RunCommandWithVersion(ctx, tmpDir, expPlanArgs, map[string]string(nil), tfDistribution, tfVersion, "staging")).ThenReturn("output", nil)
+
+			output, err := s.Run(ctx, []string{"extra"}, tmpDir, map[string]string(nil))
+			Ok(t, err)
+			Equals(t, "output", output)
+		})
+	}
+}
+
+func TestRun_AddsEnvironmentVarFiles(t *testing.T) {
+	// Test that the var files of the project's environment are passed to plan.
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	commitStatusUpdater := runtimemocks.NewMockStatusUpdater()
+	asyncTfExec := runtimemocks.NewMockAsyncTFExec()
+	tmpDir := t.TempDir()
+
+	mockDownloader := mocks.NewMockDownloader()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
+	tfVersion, _ := version.NewVersion("0.12.0")
+	s := runtime.NewPlanStepRunner(terraform, tfDistribution, tfVersion, commitStatusUpdater, asyncTfExec)
+
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "staging",
+		ProjectName: "myproject",
+		RepoRelDir:  ".",
+		Environment: "staging",
+		VarFiles:    []string{"common.tfvars", "env/staging.tfvars"},
+	}
+	expPlanArgs := []string{"plan",
+		"-input=false",
+		"-refresh",
+		"-out",
+		fmt.Sprintf("%q", filepath.Join(tmpDir, "myproject-staging.tfplan")),
+		"extra",
+		"-var-file",
+		filepath.Join(tmpDir, "common.tfvars"),
+		"-var-file",
+		filepath.Join(tmpDir, "env/staging.tfvars"),
+	}
+	When(terraform.RunCommandWithVersion(ctx, tmpDir, expPlanArgs, map[string]string(nil), tfDistribution, tfVersion, "staging")).ThenReturn("output", nil)
+
+	output, err := s.Run(ctx, []string{"extra"}, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "output", output)
+}
+
 func TestRun_UsesDiffPathForProject(t *testing.T) {
 	// Test that if running for a project, uses a different path for the plan
 	// file.
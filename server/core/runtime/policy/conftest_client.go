@@ -155,6 +155,9 @@ type ConfTestExecutorWorkflow struct {
 	VersionCache           cache.ExecutionVersionCache
 	DefaultConftestVersion *version.Version
 	Exec                   runtime_models.Exec
+	// AnalysisCache reuses the results of identical plans in a pull
+	// request. It's nil unless --reuse-identical-plan-analysis is set.
+	AnalysisCache *PlanAnalysisCache
 }
 
 func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir string, conftestDownloder Downloader) *ConfTestExecutorWorkflow {
@@ -191,6 +194,14 @@ func (c *ConfTestExecutorWorkflow) Run(ctx command.ProjectContext, executablePat
 	var policySetResults []models.PolicySetResult
 	var combinedErr error
 
+	var inputKey string
+	if c.AnalysisCache != nil {
+		var keyErr error
+		if inputKey, keyErr = planKey(inputFile); keyErr != nil {
+			ctx.Log.Warn("unable to hash plan to reuse policy check results: %s", keyErr)
+		}
+	}
+
 	for _, policySet := range ctx.PolicySets.PolicySets {
 		path, resolveErr := c.SourceResolver.Resolve(policySet)
 
@@ -207,8 +218,7 @@ func (c *ConfTestExecutorWorkflow) Run(ctx command.ProjectContext, executablePat
 			Command:    executablePath,
 		}
 
-		serializedArgs, _ := args.build()
-		cmdOutput, cmdErr := c.Exec.CombinedOutput(serializedArgs, envs, workdir)
+		cmdOutput, cmdErr := c.runConftest(ctx, inputKey, args, envs, workdir)
 
 		if cmdErr != nil {
 			// Since we're running conftest for each policyset, individual command errors should be concatenated.
@@ -256,6 +266,40 @@ func (c *ConfTestExecutorWorkflow) Run(ctx command.ProjectContext, executablePat
 
 }
 
+// runConftest runs conftest with args, or reuses its result for an identical
+// plan of the pull request when inputKey is set.
+func (c *ConfTestExecutorWorkflow) runConftest(ctx command.ProjectContext, inputKey string, args ConftestTestCommandArgs, envs map[string]string, workdir string) (string, error) {
+	var key string
+	if inputKey != "" {
+		var keyErr error
+		if key, keyErr = policySetKey(inputKey, args); keyErr != nil {
+			ctx.Log.Warn("unable to hash policies to reuse policy check results: %s", keyErr)
+		}
+	}
+	if key != "" {
+		if cached, ok := c.AnalysisCache.get(ctx, key); ok {
+			ctx.Log.Info("reusing the policy check results of an identical plan in this pull request")
+			var err error
+			if cached.Failed {
+				err = errors.New("conftest failed on an identical plan")
+			}
+			return strings.Replace(cached.Output, planFilePlaceholder, args.InputFile, -1), err
+		}
+	}
+
+	serializedArgs, _ := args.build()
+	cmdOutput, cmdErr := c.Exec.CombinedOutput(serializedArgs, envs, workdir)
+	// Errors that aren't policy failures can be transient so they're not
+	// reused.
+	if key != "" && (cmdErr == nil || isValidConftestOutput(cmdOutput)) {
+		c.AnalysisCache.put(ctx, key, cachedPolicySetResult{
+			Output: strings.Replace(cmdOutput, args.InputFile, planFilePlaceholder, -1),
+			Failed: cmdErr != nil,
+		})
+	}
+	return cmdOutput, cmdErr
+}
+
 func (c *ConfTestExecutorWorkflow) sanitizeOutput(inputFile string, output string) string {
 	return strings.Replace(output, inputFile, "<redacted plan file>", -1)
 }
@@ -3,6 +3,7 @@ package policy
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 	models_mocks "github.com/runatlantis/atlantis/server/core/runtime/models/mocks"
 	conftest_mocks "github.com/runatlantis/atlantis/server/core/runtime/policy/mocks"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -320,3 +322,64 @@ func TestRun(t *testing.T) {
 
 	})
 }
+
+func TestRun_ReusesIdenticalPlans(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockResolver := conftest_mocks.NewMockSourceResolver()
+	mockExec := models_mocks.NewMockExec()
+	subject := &ConfTestExecutorWorkflow{
+		SourceResolver: mockResolver,
+		Exec:           mockExec,
+		AnalysisCache:  NewPlanAnalysisCache(),
+	}
+	executablePath := "/usr/bin/conftest"
+	policySet := valid.PolicySet{Source: valid.LocalPolicySet, Path: "/some/path", Name: "policy1"}
+	policyDir := t.TempDir()
+	Ok(t, os.WriteFile(filepath.Join(policyDir, "policy.rego"), []byte("package main"), 0600))
+	When(mockResolver.Resolve(policySet)).ThenReturn(policyDir, nil)
+
+	// writePlan writes the plan of a project and returns its dir.
+	writePlan := func(plan string) string {
+		workdir := t.TempDir()
+		Ok(t, os.WriteFile(filepath.Join(workdir, "testproj-default.json"), []byte(plan), 0600))
+		return workdir
+	}
+	staging := writePlan(`{"format_version":"1.2","timestamp":"2024-01-01T00:00:00Z","variables":{"size":{"value":"small"}}}`)
+	prod := writePlan(`{"timestamp":"2024-01-01T00:01:00Z","variables":{"size":{"value":"small"}},"format_version":"1.2"}`)
+	large := writePlan(`{"format_version":"1.2","timestamp":"2024-01-01T00:00:00Z","variables":{"size":{"value":"large"}}}`)
+	for _, workdir := range []string{staging, prod, large} {
+		planFile := filepath.Join(workdir, "testproj-default.json")
+		args := []string{executablePath, "test", "-p", policyDir, planFile, "--no-color"}
+		output := fmt.Sprintf("FAIL - %s - failure\n1 tests, 0 passed, 0 warnings, 1 failure, 0 exceptions", planFile)
+		When(mockExec.CombinedOutput(args, nil, workdir)).ThenReturn(output, errors.New("exit status code 1"))
+	}
+
+	ctx := command.ProjectContext{
+		PolicySets:  valid.PolicySets{PolicySets: []valid.PolicySet{policySet}},
+		ProjectName: "testproj",
+		Workspace:   "default",
+		Log:         logging.NewNoopLogger(t),
+		Pull:        models.PullRequest{Num: 1, HeadCommit: "abc"},
+	}
+	expResult := `[{"PolicySetName":"policy1","PolicyOutput":"FAIL - <redacted plan file> - failure\n1 tests, 0 passed, 0 warnings, 1 failure, 0 exceptions","Passed":false,"ReqApprovals":0,"CurApprovals":0}]`
+	for _, workdir := range []string{staging, prod, large} {
+		result, err := subject.Run(ctx, executablePath, nil, workdir, nil)
+		Equals(t, expResult, result)
+		Assert(t, err != nil, "exp policy failure")
+	}
+	mockExec.VerifyWasCalledOnce().CombinedOutput(Any[[]string](), Any[map[string]string](), Eq(staging))
+	mockExec.VerifyWasCalled(Never()).CombinedOutput(Any[[]string](), Any[map[string]string](), Eq(prod))
+	mockExec.VerifyWasCalledOnce().CombinedOutput(Any[[]string](), Any[map[string]string](), Eq(large))
+
+	// Results aren't reused once new commits are pushed.
+	ctx.Pull.HeadCommit = "def"
+	_, err := subject.Run(ctx, executablePath, nil, prod, nil)
+	Assert(t, err != nil, "exp policy failure")
+	mockExec.VerifyWasCalledOnce().CombinedOutput(Any[[]string](), Any[map[string]string](), Eq(prod))
+
+	// Results aren't reused once the policies change.
+	Ok(t, os.WriteFile(filepath.Join(policyDir, "policy.rego"), []byte("package main\n\ndeny[msg] { msg := \"no\" }"), 0600))
+	_, err = subject.Run(ctx, executablePath, nil, prod, nil)
+	Assert(t, err != nil, "exp policy failure")
+	mockExec.VerifyWasCalled(Times(2)).CombinedOutput(Any[[]string](), Any[map[string]string](), Eq(prod))
+}
@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+const (
+	// planAnalysisCacheTTL is how long the results of a pull request are
+	// kept after they were last used, ex. once it's closed.
+	planAnalysisCacheTTL = 24 * time.Hour
+	// planFilePlaceholder replaces the path of the plan file in cached
+	// outputs, since it's different for every project.
+	planFilePlaceholder = "<plan file>"
+)
+
+// PlanAnalysisCache keeps the policy check results of the plans of a pull
+// request so they can be reused for its other projects whose plans are
+// identical, ex. stacks stamped from the same module with the same inputs.
+// Results are dropped when new commits are pushed to the pull request.
+type PlanAnalysisCache struct {
+	mu    sync.Mutex
+	pulls map[string]*pullPlanAnalyses
+}
+
+type pullPlanAnalyses struct {
+	headCommit string
+	lastUsed   time.Time
+	results    map[string]cachedPolicySetResult
+}
+
+// cachedPolicySetResult is the result of running conftest for a policy set.
+type cachedPolicySetResult struct {
+	// Output has the plan file replaced by planFilePlaceholder.
+	Output string
+	Failed bool
+}
+
+func NewPlanAnalysisCache() *PlanAnalysisCache {
+	return &PlanAnalysisCache{pulls: make(map[string]*pullPlanAnalyses)}
+}
+
+// get returns the result of key in the pull request of ctx.
+func (c *PlanAnalysisCache) get(ctx command.ProjectContext, key string) (cachedPolicySetResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.pull(ctx).results[key]
+	return result, ok
+}
+
+// put stores the result of key in the pull request of ctx.
+func (c *PlanAnalysisCache) put(ctx command.ProjectContext, key string, result cachedPolicySetResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pull(ctx).results[key] = result
+	for pullKey, pull := range c.pulls {
+		if time.Since(pull.lastUsed) > planAnalysisCacheTTL {
+			delete(c.pulls, pullKey)
+		}
+	}
+}
+
+// pull returns the results of the pull request of ctx at its head commit.
+// c.mu must be held.
+func (c *PlanAnalysisCache) pull(ctx command.ProjectContext) *pullPlanAnalyses {
+	pullKey := fmt.Sprintf("%s#%d", ctx.BaseRepo.FullName, ctx.Pull.Num)
+	pull, ok := c.pulls[pullKey]
+	if !ok || pull.headCommit != ctx.Pull.HeadCommit {
+		pull = &pullPlanAnalyses{
+			headCommit: ctx.Pull.HeadCommit,
+			results:    make(map[string]cachedPolicySetResult),
+		}
+		c.pulls[pullKey] = pull
+	}
+	pull.lastUsed = time.Now()
+	return pull
+}
+
+// planKey returns a hash of the plan in the JSON file, which is the same for
+// identical plans of different projects.
+func planKey(planFile string) (string, error) {
+	contents, err := os.ReadFile(planFile) // nolint: gosec
+	if err != nil {
+		return "", err
+	}
+	var plan map[string]interface{}
+	if err := json.Unmarshal(contents, &plan); err != nil {
+		return "", err
+	}
+	// The time the plan was made is different even for identical plans.
+	delete(plan, "timestamp")
+	// Maps are marshaled with sorted keys so identical plans have the same
+	// serialization.
+	normalized, err := json.Marshal(plan)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// policySetKey returns the key of the result of running args for a policy
+// set on the plan with planKey. It includes the contents of the policies so
+// results aren't reused once they change, ex. when the server-side config is
+// reloaded.
+func policySetKey(planKey string, args ConftestTestCommandArgs) (string, error) {
+	parts := []string{planKey, args.Command}
+	for _, a := range args.PolicyArgs {
+		policies, err := policiesKey(a.Param)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, a.build()...)
+		parts = append(parts, policies)
+	}
+	parts = append(parts, args.ExtraArgs...)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// policiesKey returns a hash of the names and contents of the files in the
+// policy file or dir at path.
+func policiesKey(path string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		contents, err := os.ReadFile(file) // nolint: gosec
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, len(contents))
+		h.Write(contents) // nolint: errcheck
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+var (
+	// pulumiDiffMarkerRegex matches the indented +, -, ~ and +- markers of the
+	// resources in a preview so they can be moved to the start of the line.
+	pulumiDiffMarkerRegex = regexp.MustCompile(`(?m)^( +)(\+-|[-+~]) `)
+	// pulumiChangeMarkerRegex matches the markers of updated and replaced
+	// resources once they're at the start of the line.
+	pulumiChangeMarkerRegex = regexp.MustCompile(`(?m)^(\+-|~)`)
+)
+
+type pulumiPreviewStepRunner struct {
+	outputHandler jobs.ProjectCommandOutputHandler
+}
+
+// NewPulumiPreviewStepRunner returns a runner that previews the changes of a
+// project run by the Pulumi engine. The Atlantis workspace is the Pulumi
+// stack. The preview is saved to the planfile so the apply updates exactly
+// what was previewed.
+func NewPulumiPreviewStepRunner(outputHandler jobs.ProjectCommandOutputHandler) Runner {
+	return &pulumiPreviewStepRunner{outputHandler: outputHandler}
+}
+
+func (p *pulumiPreviewStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	planPath := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	args := append(append([]string{"preview", "--diff", "--save-plan", planPath}, extraArgs...), ctx.CommentArgs...)
+	out, err := runPulumi(ctx, p.outputHandler, args, path, envs)
+	if err != nil {
+		return "", err
+	}
+	return formatPulumiPreview(out), nil
+}
+
+type pulumiUpStepRunner struct {
+	outputHandler jobs.ProjectCommandOutputHandler
+}
+
+// NewPulumiUpStepRunner returns a runner that updates the stack of a project
+// run by the Pulumi engine with the preview saved by its plan.
+func NewPulumiUpStepRunner(outputHandler jobs.ProjectCommandOutputHandler) Runner {
+	return &pulumiUpStepRunner{outputHandler: outputHandler}
+}
+
+func (p *pulumiUpStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	planPath := filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	if _, err := os.Stat(planPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("no plan found at path %q and workspace %q–did you run plan?", ctx.RepoRelDir, ctx.Workspace)
+	}
+
+	args := append(append([]string{"up", "--yes", "--skip-preview", "--plan", planPath}, extraArgs...), ctx.CommentArgs...)
+	out, err := runPulumi(ctx, p.outputHandler, args, path, envs)
+	if err != nil {
+		return "", err
+	}
+
+	ctx.Log.Info("apply successful, deleting planfile")
+	if removeErr := utils.RemoveIgnoreNonExistent(planPath); removeErr != nil {
+		ctx.Log.Warn("failed to delete planfile after successful apply: %s", removeErr)
+	}
+	return out, nil
+}
+
+// runPulumi runs the pulumi CLI with args on the project's stack and streams
+// its output to the project's job. It isn't run through a shell so args are
+// passed to pulumi as is.
+func runPulumi(ctx command.ProjectContext, outputHandler jobs.ProjectCommandOutputHandler, args []string, path string, envs map[string]string) (string, error) {
+	args = append(args, "--stack", ctx.Workspace, "--non-interactive", "--color", "never")
+
+	environ := append(os.Environ(),
+		// Saving and applying previews is still an experimental feature.
+		"PULUMI_EXPERIMENTAL=true",
+		"PULUMI_SKIP_UPDATE_CHECK=true",
+	)
+	for key, val := range envs {
+		environ = append(environ, fmt.Sprintf("%s=%s", key, val))
+	}
+
+	runner := models.NewCommandRunner("pulumi", args, environ, path, true, outputHandler)
+	out, err := runner.Run(ctx)
+	if err != nil {
+		err = fmt.Errorf("%s: running %q in %q: \n%s", err, "pulumi "+strings.Join(args, " "), path, out)
+		ctx.Log.Debug("error: %s", err)
+		return "", err
+	}
+	return out, nil
+}
+
+// formatPulumiPreview moves the change markers of the resources in a preview
+// to the start of their lines so the preview is highlighted when it's shown
+// as a diff in the pull request. Updates and replacements are marked with !
+// like the changes of Terraform plans.
+func formatPulumiPreview(out string) string {
+	out = pulumiDiffMarkerRegex.ReplaceAllString(out, "$2$1 ")
+	return pulumiChangeMarkerRegex.ReplaceAllString(out, "!")
+}
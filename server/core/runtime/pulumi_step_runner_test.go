@@ -0,0 +1,78 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// fakePulumi puts a pulumi script on the PATH that prints its arguments
+// followed by output.
+func fakePulumi(t *testing.T, output string) {
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\ncat <<'EOF'\n" + output + "\nEOF\n"
+	Ok(t, os.WriteFile(filepath.Join(binDir, "pulumi"), []byte(script), 0700)) // nolint: gosec
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+}
+
+func TestPulumiPreviewStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	fakePulumi(t, `Previewing update (dev):
+  pulumi:pulumi:Stack: (same)
+    + aws:s3/bucket:Bucket: (create)
+    ~ aws:s3/bucketAcl:BucketAcl: (update)
+    +- aws:ec2/instance:Instance: (replace)
+    - aws:sns/topic:Topic: (delete)
+Resources:
+    + 1 to create`)
+	tmpDir := t.TempDir()
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		Workspace:   "dev",
+		CommentArgs: []string{`--target`, `urn;$(id)`},
+	}
+
+	runner := runtime.NewPulumiPreviewStepRunner(jobmocks.NewMockProjectCommandOutputHandler())
+	out, err := runner.Run(ctx, []string{"--refresh"}, tmpDir, map[string]string{})
+	Ok(t, err)
+	Equals(t, `preview --diff --save-plan `+filepath.Join(tmpDir, "dev.tfplan")+` --refresh --target urn;$(id) --stack dev --non-interactive --color never
+Previewing update (dev):
+  pulumi:pulumi:Stack: (same)
++     aws:s3/bucket:Bucket: (create)
+!     aws:s3/bucketAcl:BucketAcl: (update)
+!     aws:ec2/instance:Instance: (replace)
+-     aws:sns/topic:Topic: (delete)
+Resources:
++     1 to create
+`, out)
+}
+
+func TestPulumiUpStepRunner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	fakePulumi(t, "Updating (dev):\nResources:\n    1 created")
+	tmpDir := t.TempDir()
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "dev",
+		RepoRelDir: ".",
+	}
+	runner := runtime.NewPulumiUpStepRunner(jobmocks.NewMockProjectCommandOutputHandler())
+
+	_, err := runner.Run(ctx, nil, tmpDir, map[string]string{})
+	ErrEquals(t, `no plan found at path "." and workspace "dev"–did you run plan?`, err)
+
+	planPath := filepath.Join(tmpDir, "dev.tfplan")
+	Ok(t, os.WriteFile(planPath, []byte("{}"), 0600))
+	out, err := runner.Run(ctx, nil, tmpDir, map[string]string{})
+	Ok(t, err)
+	Equals(t, "up --yes --skip-preview --plan "+planPath+" --stack dev --non-interactive --color never\nUpdating (dev):\nResources:\n    1 created\n", out)
+	_, err = os.Stat(planPath)
+	Assert(t, os.IsNotExist(err), "expected the planfile to be deleted after the update")
+}
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -22,6 +23,38 @@ type RunStepRunner struct {
 	// TerraformBinDir is the directory where Atlantis downloads Terraform binaries.
 	TerraformBinDir         string
 	ProjectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	// SecretResolver, if set, replaces the references to secrets in commands
+	// before they're run.
+	SecretResolver SecretResolver
+}
+
+// SecretResolver replaces references to secrets, ex. vault: URIs, with the
+// secrets.
+type SecretResolver interface {
+	Resolve(s string) (string, error)
+	// ResolveFunc replaces the references to secrets with what replace
+	// returns for each secret.
+	ResolveFunc(s string, replace func(secret string) string) (string, error)
+}
+
+// secretEnvVarPrefix is the prefix of the env vars the secrets referenced by
+// a command are passed in.
+const secretEnvVarPrefix = "ATLANTIS_SECRET_"
+
+// secretEnvVarRef returns how the commands run by shell refer to the env var
+// name.
+func secretEnvVarRef(shell *valid.CommandShell, name string) string {
+	if shell == nil {
+		return fmt.Sprintf(`"$%s"`, name)
+	}
+	switch valid.ShellName(shell.Shell) {
+	case "cmd":
+		return fmt.Sprintf("%%%s%%", name)
+	case "powershell", "pwsh":
+		return fmt.Sprintf("$env:%s", name)
+	default:
+		return fmt.Sprintf(`"$%s"`, name)
+	}
 }
 
 func (r *RunStepRunner) Run(
@@ -49,11 +82,21 @@ func (r *RunStepRunner) Run(
 		return "", err
 	}
 
+	contextFile, err := writeRunStepContextFile(ctx)
+	if err != nil {
+		err = fmt.Errorf("writing run step context file: %w", err)
+		ctx.Log.Debug("error: %s", err)
+		return "", err
+	}
+	defer os.Remove(contextFile) // nolint: errcheck
+
 	baseEnvVars := os.Environ()
 	customEnvVars := map[string]string{
+		"ATLANTIS_CONTEXT_FILE":           contextFile,
 		"ATLANTIS_TERRAFORM_DISTRIBUTION": tfDistribution.BinName(),
 		"ATLANTIS_TERRAFORM_VERSION":      tfVersion.String(),
 		"BASE_BRANCH_NAME":                ctx.Pull.BaseBranch,
+		"BASE_COMMIT":                     ctx.Pull.BaseCommit,
 		"BASE_REPO_NAME":                  ctx.BaseRepo.Name,
 		"BASE_REPO_OWNER":                 ctx.BaseRepo.Owner,
 		"COMMENT_ARGS":                    strings.Join(ctx.EscapedCommentArgs, ","),
@@ -66,9 +109,12 @@ func (r *RunStepRunner) Run(
 		"PLANFILE":                        filepath.Join(path, GetPlanFilename(ctx.Workspace, ctx.ProjectName)),
 		"SHOWFILE":                        filepath.Join(path, ctx.GetShowResultFileName()),
 		"POLICYCHECKFILE":                 filepath.Join(path, ctx.GetPolicyCheckResultFileName()),
+		"MODIFIED_FILES":                  strings.Join(ctx.ModifiedFiles, ","),
 		"PROJECT_NAME":                    ctx.ProjectName,
 		"PULL_AUTHOR":                     ctx.Pull.Author,
+		"PULL_LABELS":                     strings.Join(ctx.PullLabels, ","),
 		"PULL_NUM":                        fmt.Sprintf("%d", ctx.Pull.Num),
+		"PULL_TITLE":                      ctx.Pull.Title,
 		"PULL_URL":                        ctx.Pull.URL,
 		"REPO_REL_DIR":                    ctx.RepoRelDir,
 		"USER_NAME":                       ctx.User.Username,
@@ -83,7 +129,25 @@ func (r *RunStepRunner) Run(
 		finalEnvVars = append(finalEnvVars, fmt.Sprintf("%s=%s", key, val))
 	}
 
-	runner := models.NewShellCommandRunner(shell, command, finalEnvVars, path, streamOutput, r.ProjectCmdOutputHandler)
+	resolvedCommand := command
+	if r.SecretResolver != nil {
+		// The secrets are passed in env vars rather than spliced into the
+		// command so that they're never interpreted by the shell.
+		secrets := 0
+		resolvedCommand, err = r.SecretResolver.ResolveFunc(command, func(secret string) string {
+			secrets++
+			name := fmt.Sprintf("%s%d", secretEnvVarPrefix, secrets)
+			finalEnvVars = append(finalEnvVars, fmt.Sprintf("%s=%s", name, secret))
+			return secretEnvVarRef(shell, name)
+		})
+		if err != nil {
+			err = fmt.Errorf("%s: running %q in %q", err, command, path)
+			ctx.Log.Debug("error: %s", err)
+			return "", err
+		}
+	}
+
+	runner := models.NewShellCommandRunner(shell, resolvedCommand, finalEnvVars, path, streamOutput, r.ProjectCmdOutputHandler)
 	output, err := runner.Run(ctx)
 
 	if postProcessOutput == valid.PostProcessRunOutputStripRefreshing {
@@ -112,3 +176,65 @@ func (r *RunStepRunner) Run(
 		return output, nil
 	}
 }
+
+// runStepContext is the context written as JSON to the file at
+// $ATLANTIS_CONTEXT_FILE for custom run steps.
+type runStepContext struct {
+	Pull struct {
+		Num        int      `json:"num"`
+		URL        string   `json:"url"`
+		Title      string   `json:"title"`
+		Author     string   `json:"author"`
+		Labels     []string `json:"labels"`
+		BaseBranch string   `json:"base_branch"`
+		BaseCommit string   `json:"base_commit"`
+		HeadBranch string   `json:"head_branch"`
+		HeadCommit string   `json:"head_commit"`
+	} `json:"pull"`
+	Project struct {
+		Name          string   `json:"name"`
+		Dir           string   `json:"dir"`
+		Workspace     string   `json:"workspace"`
+		ModifiedFiles []string `json:"modified_files"`
+	} `json:"project"`
+	Comment struct {
+		Command string   `json:"command"`
+		User    string   `json:"user"`
+		Args    []string `json:"args"`
+		Verbose bool     `json:"verbose"`
+	} `json:"comment"`
+}
+
+// writeRunStepContextFile writes the context of the run step to a temporary
+// file and returns its path. The caller must remove the file.
+func writeRunStepContextFile(ctx command.ProjectContext) (string, error) {
+	var c runStepContext
+	c.Pull.Num = ctx.Pull.Num
+	c.Pull.URL = ctx.Pull.URL
+	c.Pull.Title = ctx.Pull.Title
+	c.Pull.Author = ctx.Pull.Author
+	c.Pull.Labels = ctx.PullLabels
+	c.Pull.BaseBranch = ctx.Pull.BaseBranch
+	c.Pull.BaseCommit = ctx.Pull.BaseCommit
+	c.Pull.HeadBranch = ctx.Pull.HeadBranch
+	c.Pull.HeadCommit = ctx.Pull.HeadCommit
+	c.Project.Name = ctx.ProjectName
+	c.Project.Dir = ctx.RepoRelDir
+	c.Project.Workspace = ctx.Workspace
+	c.Project.ModifiedFiles = ctx.ModifiedFiles
+	c.Comment.Command = ctx.CommandName.String()
+	c.Comment.User = ctx.User.Username
+	c.Comment.Args = ctx.CommentArgs
+	c.Comment.Verbose = ctx.Verbose
+
+	f, err := os.CreateTemp("", "atlantis-run-context-*.json")
+	if err != nil {
+		return "", err
+	}
+	if err := json.NewEncoder(f).Encode(c); err != nil {
+		f.Close()           // nolint: errcheck
+		os.Remove(f.Name()) // nolint: errcheck
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
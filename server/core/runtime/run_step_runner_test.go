@@ -3,6 +3,7 @@ package runtime_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -99,6 +100,17 @@ func TestRunStepRunner_Run(t *testing.T) {
 			Command: "echo args=$COMMENT_ARGS",
 			ExpOut:  "args=-target=resource1,-target=resource2\n",
 		},
+		{
+			Command: "echo title=$PULL_TITLE labels=$PULL_LABELS base_commit=$BASE_COMMIT modified_files=$MODIFIED_FILES",
+			ExpOut:  "title=Add feat labels=infra,urgent base_commit=67890fedcba modified_files=mydir/main.tf,mydir/vars.tf\n",
+		},
+		{
+			Command: "cat $ATLANTIS_CONTEXT_FILE",
+			ExpOut: `{"pull":{"num":2,"url":"https://github.com/runatlantis/atlantis/pull/2","title":"Add feat","author":"acme","labels":["infra","urgent"],` +
+				`"base_branch":"main","base_commit":"67890fedcba","head_branch":"add-feat","head_commit":"12345abcdef"},` +
+				`"project":{"name":"","dir":"mydir","workspace":"myworkspace","modified_files":["mydir/main.tf","mydir/vars.tf"]},` +
+				`"comment":{"command":"plan","user":"acme-user","args":["-target=resource1","-target=resource2"],"verbose":false}}` + "\n",
+		},
 	}
 	for _, customPolicyCheck := range []bool{false, true} {
 		for _, c := range cases {
@@ -154,8 +166,13 @@ func TestRunStepRunner_Run(t *testing.T) {
 						HeadBranch: "add-feat",
 						HeadCommit: "12345abcdef",
 						BaseBranch: "main",
+						BaseCommit: "67890fedcba",
 						Author:     "acme",
+						Title:      "Add feat",
 					},
+					PullLabels:    []string{"infra", "urgent"},
+					ModifiedFiles: []string{"mydir/main.tf", "mydir/vars.tf"},
+					CommandName:   command.Plan,
 					User: models.User{
 						Username: "acme-user",
 					},
@@ -166,6 +183,7 @@ func TestRunStepRunner_Run(t *testing.T) {
 					TerraformVersion:      projVersion,
 					ProjectName:           c.ProjectName,
 					EscapedCommentArgs:    []string{"-target=resource1", "-target=resource2"},
+					CommentArgs:           []string{"-target=resource1", "-target=resource2"},
 					CustomPolicyCheck:     customPolicyCheck,
 				}
 				out, err := r.Run(ctx, nil, c.Command, tmpDir, map[string]string{"test": "var"}, true, valid.PostProcessRunOutputShow)
@@ -187,3 +205,46 @@ func TestRunStepRunner_Run(t *testing.T) {
 		}
 	}
 }
+
+func TestRunStepRunner_Run_Secrets(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	When(terraform.EnsureVersion(Any[logging.SimpleLogging](), Any[tf.Distribution](), Any[*version.Version]())).
+		ThenReturn(nil)
+	defaultVersion, _ := version.NewVersion("0.8")
+	tmpDir := t.TempDir()
+	r := runtime.RunStepRunner{
+		TerraformExecutor:       terraform,
+		DefaultTFDistribution:   tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader()),
+		DefaultTFVersion:        defaultVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+		SecretResolver:          fakeSecretResolver{secrets: map[string]string{"vault:secret/data/atlantis#password": "it's $(touch pwned)"}},
+	}
+	ctx := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+	}
+
+	out, err := r.Run(ctx, nil, "echo password=vault:secret/data/atlantis#password", tmpDir, nil, false, valid.PostProcessRunOutputShow)
+	Ok(t, err)
+	Equals(t, "password=it's $(touch pwned)\n", out)
+	_, err = os.Stat(tmpDir + "/pwned")
+	Assert(t, os.IsNotExist(err), "expected the secret not to be run by the shell")
+
+	// cmd and PowerShell can't run here, so they're faked by a script that
+	// prints the command it's given and the env var holding the secret.
+	for shell, expCommand := range map[string]string{
+		"cmd":        "echo password=%ATLANTIS_SECRET_1%",
+		"powershell": "echo password=$env:ATLANTIS_SECRET_1",
+		"pwsh":       "echo password=$env:ATLANTIS_SECRET_1",
+	} {
+		t.Run(shell, func(t *testing.T) {
+			shellPath := filepath.Join(t.TempDir(), shell)
+			Ok(t, os.WriteFile(shellPath, []byte("#!/bin/sh\nfor arg; do command=\"$arg\"; done\nprintf '%s\\n%s\\n' \"$command\" \"$ATLANTIS_SECRET_1\"\n"), 0700)) // nolint: gosec
+			out, err := r.Run(ctx, &valid.CommandShell{Shell: shellPath, ShellArgs: valid.DefaultShellArgs(shell)},
+				"echo password=vault:secret/data/atlantis#password", tmpDir, nil, false, valid.PostProcessRunOutputShow)
+			Ok(t, err)
+			Equals(t, expCommand+"\nit's $(touch pwned)\n", out)
+		})
+	}
+}
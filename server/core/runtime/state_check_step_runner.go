@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+const (
+	// stateCheckProbeAddr is an address that never exists in a state. It's
+	// what we pass to state rm so the command never modifies the state.
+	stateCheckProbeAddr = "terraform_data.atlantis_state_check_probe"
+	stateLockErr        = "Error acquiring the state lock"
+	stateNoMatchErr     = "No matching objects found"
+	// stateNotFoundErr is what state rm fails with before the first apply,
+	// when the backend is reachable but has no state yet.
+	stateNotFoundErr = "No state file was found!"
+)
+
+var lockInfoRegex = regexp.MustCompile(`(?m)^\s*(ID|Path|Operation|Who|Version|Created):\s*(.*?)\s*$`)
+
+// StateLockInfo is the lock information terraform prints when the state is
+// locked by someone else.
+type StateLockInfo struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Version   string
+	Created   string
+}
+
+// stateCheckStepRunner checks that the state backend is reachable and that
+// the state isn't locked before we plan. Otherwise plans against an
+// unreachable or locked backend only fail after terraform's own timeouts, deep
+// in the plan output.
+type stateCheckStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+func NewStateCheckStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	runner := &stateCheckStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+	return NewWorkspaceStepRunnerDelegate(terraformExecutor, defaultTfDistribution, defaultTfVersion, runner)
+}
+
+func (s *stateCheckStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := s.defaultTFDistribution
+	tfVersion := s.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	// There's no terraform command that only checks the lock, so we use a
+	// dry-run state rm of an address that can't exist: it acquires the lock
+	// and reads the state like a plan would, then fails because nothing
+	// matched, or because there's no state yet, without ever writing the
+	// state.
+	probeCmd := []string{"state", "rm", "-dry-run", "-lock-timeout=0s"}
+	probeCmd = append(probeCmd, extraArgs...)
+	probeCmd = append(probeCmd, stateCheckProbeAddr)
+	out, err := s.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), probeCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	if err == nil || strings.Contains(out, stateNoMatchErr) || strings.Contains(out, stateNotFoundErr) {
+		ctx.Log.Debug("state backend is reachable and the state is not locked")
+		return "", nil
+	}
+
+	if strings.Contains(out, stateLockErr) {
+		info := ParseStateLockInfo(out)
+		return "", fmt.Errorf("the state for workspace %q is locked by %q (operation: %s, created: %s, lock ID: %s). "+
+			"Wait for that operation to finish or, if the lock is stale, remove it with 'terraform force-unlock %s'",
+			ctx.Workspace, info.Who, info.Operation, info.Created, info.ID, info.ID)
+	}
+	return "", fmt.Errorf("unable to reach the state backend for workspace %q, check the backend configuration and credentials: %s", ctx.Workspace, out)
+}
+
+// ParseStateLockInfo parses the "Lock Info" block terraform outputs when it
+// can't acquire the state lock.
+func ParseStateLockInfo(output string) StateLockInfo {
+	var info StateLockInfo
+	for _, match := range lockInfoRegex.FindAllStringSubmatch(output, -1) {
+		switch match[1] {
+		case "ID":
+			info.ID = match[2]
+		case "Path":
+			info.Path = match[2]
+		case "Operation":
+			info.Operation = match[2]
+		case "Who":
+			info.Who = match[2]
+		case "Version":
+			info.Version = match[2]
+		case "Created":
+			info.Created = match[2]
+		}
+	}
+	return info
+}
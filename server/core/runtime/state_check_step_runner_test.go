@@ -0,0 +1,98 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const lockedOutput = `
+Error: Error acquiring the state lock
+
+Error message: ConditionalCheckFailedException: The conditional request failed
+Lock Info:
+  ID:        0b0e7c36-0a2e-11ef-9262-0242ac120002
+  Path:      my-bucket/env:/default/terraform.tfstate
+  Operation: OperationTypeApply
+  Who:       jdoe@laptop
+  Version:   1.7.5
+  Created:   2024-05-03 10:12:45.123 +0000 UTC
+  Info:
+
+Terraform acquires a state lock to protect the state from being written
+by multiple users at the same time.
+`
+
+func TestStateCheckStepRunner_Run(t *testing.T) {
+	cases := map[string]struct {
+		output    string
+		err       error
+		expErr    string
+		expOutput string
+	}{
+		"healthy": {
+			output: "Error: Invalid target address\n\nNo matching objects found. To view the available instances, use \"terraform state list\".",
+			err:    errors.New("exit status 1"),
+		},
+		"no state yet": {
+			output: "No state file was found!\n\nState management commands require a state file. Run this command\nin a directory where Terraform has been run or use the -state flag\nto point the command to a specific state location.",
+			err:    errors.New("exit status 1"),
+		},
+		"locked": {
+			output: lockedOutput,
+			err:    errors.New("exit status 1"),
+			expErr: `the state for workspace "default" is locked by "jdoe@laptop" (operation: OperationTypeApply, created: 2024-05-03 10:12:45.123 +0000 UTC, lock ID: 0b0e7c36-0a2e-11ef-9262-0242ac120002)`,
+		},
+		"unreachable": {
+			output: "Error: Failed to get existing workspaces: AccessDenied",
+			err:    errors.New("exit status 1"),
+			expErr: "unable to reach the state backend for workspace \"default\"",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			terraform := tfclientmocks.NewMockClient()
+			tfVersion, _ := version.NewVersion("0.15.0")
+			tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+			s := runtime.NewStateCheckStepRunner(terraform, tfDistribution, tfVersion)
+
+			ctx := command.ProjectContext{
+				Log:       logging.NewNoopLogger(t),
+				Workspace: "default",
+			}
+			tmpDir := t.TempDir()
+			probeCmd := []string{"state", "rm", "-dry-run", "-lock-timeout=0s", "terraform_data.atlantis_state_check_probe"}
+			When(terraform.RunCommandWithVersion(ctx, tmpDir, probeCmd, map[string]string(nil), tfDistribution, tfVersion, "default")).
+				ThenReturn(c.output, c.err)
+
+			output, err := s.Run(ctx, nil, tmpDir, map[string]string(nil))
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, err)
+			} else {
+				Ok(t, err)
+			}
+			Equals(t, "", output)
+		})
+	}
+}
+
+func TestParseStateLockInfo(t *testing.T) {
+	Equals(t, runtime.StateLockInfo{
+		ID:        "0b0e7c36-0a2e-11ef-9262-0242ac120002",
+		Path:      "my-bucket/env:/default/terraform.tfstate",
+		Operation: "OperationTypeApply",
+		Who:       "jdoe@laptop",
+		Version:   "1.7.5",
+		Created:   "2024-05-03 10:12:45.123 +0000 UTC",
+	}, runtime.ParseStateLockInfo(lockedOutput))
+}
@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// validateStepRunner runs terraform validate. The project must have been
+// initialized by an earlier init step.
+type validateStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+func NewValidateStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	return &validateStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+}
+
+func (v *validateStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := v.defaultTFDistribution
+	tfVersion := v.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	validateCmd := []string{"validate", "-no-color"}
+	validateCmd = append(validateCmd, extraArgs...)
+	out, err := v.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), validateCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+	if err != nil {
+		return out, err
+	}
+	// "Success! The configuration is valid." would only add noise to the
+	// comment.
+	return "", nil
+}
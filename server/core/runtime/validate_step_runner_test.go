@@ -0,0 +1,54 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestValidateStepRunner_Run(t *testing.T) {
+	cases := map[string]struct {
+		output    string
+		err       error
+		expOutput string
+	}{
+		"valid": {
+			output: "Success! The configuration is valid.",
+		},
+		"invalid": {
+			output:    "Error: Unsupported argument",
+			err:       errors.New("exit status 1"),
+			expOutput: "Error: Unsupported argument",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			terraform := tfclientmocks.NewMockClient()
+			tfVersion, _ := version.NewVersion("1.5.0")
+			tfDistribution := tf.NewDistributionTerraformWithDownloader(mocks.NewMockDownloader())
+			s := runtime.NewValidateStepRunner(terraform, tfDistribution, tfVersion)
+
+			ctx := command.ProjectContext{
+				Log:       logging.NewNoopLogger(t),
+				Workspace: "default",
+			}
+			tmpDir := t.TempDir()
+			When(terraform.RunCommandWithVersion(ctx, tmpDir, []string{"validate", "-no-color"}, map[string]string(nil), tfDistribution, tfVersion, "default")).
+				ThenReturn(c.output, c.err)
+
+			output, err := s.Run(ctx, nil, tmpDir, map[string]string(nil))
+			Equals(t, c.err, err)
+			Equals(t, c.expOutput, output)
+		})
+	}
+}
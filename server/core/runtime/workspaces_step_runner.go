@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"path/filepath"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+type workspacesStepRunner struct {
+	terraformExecutor     TerraformExec
+	defaultTFDistribution terraform.Distribution
+	defaultTFVersion      *version.Version
+}
+
+// NewWorkspacesStepRunner returns a runner that lists the terraform
+// workspaces of a project. Unlike the other terraform steps it doesn't
+// switch to the project's workspace first since listing doesn't depend on
+// it.
+func NewWorkspacesStepRunner(terraformExecutor TerraformExec, defaultTfDistribution terraform.Distribution, defaultTfVersion *version.Version) Runner {
+	return &workspacesStepRunner{
+		terraformExecutor:     terraformExecutor,
+		defaultTFDistribution: defaultTfDistribution,
+		defaultTFVersion:      defaultTfVersion,
+	}
+}
+
+func (p *workspacesStepRunner) Run(ctx command.ProjectContext, extraArgs []string, path string, envs map[string]string) (string, error) {
+	tfDistribution := p.defaultTFDistribution
+	tfVersion := p.defaultTFVersion
+	if ctx.TerraformDistribution != nil {
+		tfDistribution = terraform.NewDistribution(*ctx.TerraformDistribution)
+	}
+	if ctx.TerraformVersion != nil {
+		tfVersion = ctx.TerraformVersion
+	}
+
+	workspacesCmd := []string{"workspace", "list"}
+	workspacesCmd = append(workspacesCmd, extraArgs...)
+	return p.terraformExecutor.RunCommandWithVersion(ctx, filepath.Clean(path), workspacesCmd, envs, tfDistribution, tfVersion, ctx.Workspace)
+}
@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/petergtz/pegomock/v4"
+	tf "github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/core/terraform/mocks"
+	tfclientmocks "github.com/runatlantis/atlantis/server/core/terraform/tfclient/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestWorkspacesStepRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	tmpDir := t.TempDir()
+	context := command.ProjectContext{
+		Log:       logger,
+		Workspace: "staging",
+	}
+
+	RegisterMockTestingT(t)
+	terraform := tfclientmocks.NewMockClient()
+	tfVersion, _ := version.NewVersion("1.5.0")
+	mockDownloader := mocks.NewMockDownloader()
+	tfDistribution := tf.NewDistributionTerraformWithDownloader(mockDownloader)
+	s := NewWorkspacesStepRunner(terraform, tfDistribution, tfVersion)
+
+	When(terraform.RunCommandWithVersion(Any[command.ProjectContext](), Any[string](), Any[[]string](), Any[map[string]string](), Any[tf.Distribution](), Any[*version.Version](), Any[string]())).
+		ThenReturn("* default\n  staging\n", nil)
+	output, err := s.Run(context, nil, tmpDir, map[string]string(nil))
+	Ok(t, err)
+	Equals(t, "* default\n  staging\n", output)
+
+	// The workspace isn't switched before listing.
+	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(context, tmpDir, []string{"workspace", "show"}, map[string]string(nil), tfDistribution, tfVersion, "staging")
+	terraform.VerifyWasCalledOnce().RunCommandWithVersion(context, tmpDir, []string{"workspace", "list"}, map[string]string(nil), tfDistribution, tfVersion, "staging")
+}
@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -29,9 +30,15 @@ func (d *TofuDownloader) Install(ctx context.Context, dir string, _downloadURL s
 		return "", err
 	}
 
-	binary, err := dl.Download(ctx, tofudl.DownloadOptVersion(tofudl.Version(v.String())))
+	// tofudl verifies the signature of the binary it downloads.
+	platform := CurrentPlatform()
+	binary, err := dl.Download(ctx,
+		tofudl.DownloadOptVersion(tofudl.Version(v.String())),
+		tofudl.DownloadOptPlatform(tofudl.Platform(platform.OS)),
+		tofudl.DownloadOptArchitecture(tofudl.Architecture(platform.Arch)),
+	)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("downloading tofu %s for %s: %w", v, platform, err)
 	}
 
 	// Write out the tofu binary to the disk:
@@ -46,17 +53,20 @@ func (d *TofuDownloader) Install(ctx context.Context, dir string, _downloadURL s
 type TerraformDownloader struct{}
 
 func (d *TerraformDownloader) Install(ctx context.Context, dir string, downloadURL string, v *version.Version) (string, error) {
+	// hc-install downloads the build for the platform it runs on and
+	// verifies its checksum and signature.
+	platform := CurrentPlatform()
 	installer := install.NewInstaller()
 	execPath, err := installer.Install(ctx, []src.Installable{
 		&releases.ExactVersion{
 			Product:    product.Terraform,
 			Version:    v,
 			InstallDir: dir,
-			ApiBaseURL: downloadURL,
+			ApiBaseURL: platform.DownloadURL(downloadURL),
 		},
 	})
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("downloading terraform %s for %s: %w", v, platform, err)
 	}
 
 	// hc-install installs terraform binary as just "terraform".
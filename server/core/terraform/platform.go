@@ -0,0 +1,98 @@
+package terraform
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Platform is an OS and architecture that terraform binaries are built for,
+// ex. linux/arm64.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// CurrentPlatform returns the platform Atlantis runs on, which is the one
+// terraform binaries are downloaded for.
+func CurrentPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// DownloadURL replaces the {os} and {arch} placeholders of downloadURL with
+// p so servers of different architectures can share a download URL while
+// each using their own mirror, ex. https://mirror-{arch}.example.com.
+func (p Platform) DownloadURL(downloadURL string) string {
+	return strings.NewReplacer("{os}", p.OS, "{arch}", p.Arch).Replace(downloadURL)
+}
+
+// VerifyBinary returns an error if the executable at path is built for a
+// different platform than p, ex. a binary left in a data dir shared with a
+// server of another architecture. Files that aren't executables it knows,
+// like wrapper scripts, are assumed to run on p.
+func (p Platform) VerifyBinary(path string) error {
+	binOS, binArches := binaryPlatforms(path)
+	if binOS == "" {
+		return nil
+	}
+	// ELF is used by Linux and the BSDs alike.
+	osMatches := binOS == p.OS || (binOS == "linux" && p.OS != "darwin" && p.OS != "windows")
+	for _, arch := range binArches {
+		// Architectures missing from the maps below can't be compared.
+		if arch == "" || (osMatches && arch == p.Arch) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is built for %s/%s, not %s", path, binOS, strings.Join(binArches, ","), p)
+}
+
+// binaryPlatforms returns the OS and architectures the executable at path is
+// built for, or an empty OS if it isn't an executable it knows.
+func binaryPlatforms(path string) (string, []string) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close() // nolint: errcheck
+		return "linux", []string{elfArches[f.Machine]}
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close() // nolint: errcheck
+		return "darwin", []string{machoArches[f.Cpu]}
+	}
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close() // nolint: errcheck
+		var arches []string
+		for _, a := range f.Arches {
+			arches = append(arches, machoArches[a.Cpu])
+		}
+		return "darwin", arches
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close() // nolint: errcheck
+		return "windows", []string{peArches[f.Machine]}
+	}
+	return "", nil
+}
+
+var elfArches = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+	elf.EM_386:     "386",
+}
+
+var machoArches = map[macho.Cpu]string{
+	macho.CpuAmd64: "amd64",
+	macho.CpuArm64: "arm64",
+}
+
+var peArches = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+	pe.IMAGE_FILE_MACHINE_I386:  "386",
+}
@@ -0,0 +1,38 @@
+package terraform_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPlatform_DownloadURL(t *testing.T) {
+	p := terraform.Platform{OS: "linux", Arch: "arm64"}
+	Equals(t, "linux/arm64", p.String())
+	Equals(t, "https://releases.hashicorp.com", p.DownloadURL("https://releases.hashicorp.com"))
+	Equals(t, "https://mirror-arm64.example.com/linux", p.DownloadURL("https://mirror-{arch}.example.com/{os}"))
+}
+
+func TestPlatform_VerifyBinary(t *testing.T) {
+	// The test binary is built for the platform the tests run on.
+	bin, err := os.Executable()
+	Ok(t, err)
+	Ok(t, terraform.CurrentPlatform().VerifyBinary(bin))
+
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+	other := terraform.Platform{OS: runtime.GOOS, Arch: otherArch}
+	ErrContains(t, fmt.Sprintf("not %s", other), other.VerifyBinary(bin))
+
+	// Wrapper scripts can't be verified so they're assumed to work.
+	script := filepath.Join(t.TempDir(), "terraform1.8.1")
+	Ok(t, os.WriteFile(script, []byte("#!/bin/sh\necho 'Terraform v1.8.1'\n"), 0700)) // nolint: gosec
+	Ok(t, other.VerifyBinary(script))
+}
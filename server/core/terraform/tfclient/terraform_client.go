@@ -376,8 +376,15 @@ func (c *DefaultClient) RunCommandWithVersion(ctx command.ProjectContext, path s
 		envVars = append(envVars, fmt.Sprintf("%s=%s", key, val))
 	}
 	cmd.Env = envVars
+	release, err := models.LimitResources(ctx, cmd)
+	if err != nil {
+		return "", errors.Wrapf(err, "running '%s' in '%s'", tfCmd, path)
+	}
 	start := time.Now()
-	out, err := cmd.CombinedOutput()
+	out, err := models.CombinedOutputUntil(cmd, ctx.Deadline, ctx.Cancellation.Done())
+	if release() && err != nil {
+		err = models.ErrMemoryLimit
+	}
 	dur := time.Since(start)
 	log := ctx.Log.With("duration", dur)
 	if err != nil {
@@ -514,10 +521,16 @@ func ensureVersion(
 
 	// The version might also not be in the versions map if it's in our bin dir.
 	// This could happen if Atlantis was restarted without losing its disk.
+	// Binaries of another platform, ex. left by a server of another
+	// architecture sharing the data dir, are downloaded again.
 	dest := filepath.Join(binDir, binFile)
 	if _, err := os.Stat(dest); err == nil {
-		versions[v.String()] = dest
-		return dest, nil
+		if err := terraform.CurrentPlatform().VerifyBinary(dest); err != nil {
+			log.Warn("replacing %s %s: %s", dist.BinName(), v.String(), err)
+		} else {
+			versions[v.String()] = dest
+			return dest, nil
+		}
 	}
 	if !downloadsAllowed {
 		return "", fmt.Errorf(
@@ -530,13 +543,16 @@ func ensureVersion(
 
 	log.Info("could not find %s version %s in PATH or %s", dist.BinName(), v.String(), binDir)
 
-	log.Info("downloading %s version %s from download URL %s", dist.BinName(), v.String(), downloadURL)
+	log.Info("downloading %s version %s for %s from download URL %s", dist.BinName(), v.String(), terraform.CurrentPlatform(), downloadURL)
 
 	execPath, err := dist.Downloader().Install(context.Background(), binDir, downloadURL, v)
 
 	if err != nil {
 		return "", errors.Wrapf(err, "error downloading %s version %s", dist.BinName(), v.String())
 	}
+	if err := terraform.CurrentPlatform().VerifyBinary(execPath); err != nil {
+		return "", errors.Wrapf(err, "downloaded %s version %s", dist.BinName(), v.String())
+	}
 
 	log.Info("Downloaded %s %s to %s", dist.BinName(), v.String(), execPath)
 	versions[v.String()] = execPath
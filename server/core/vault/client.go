@@ -0,0 +1,261 @@
+// Package vault reads secrets from HashiCorp Vault for workflow steps.
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// AuthMethodKubernetes logs in with the service account token of the pod.
+	AuthMethodKubernetes = "kubernetes"
+	// AuthMethodAppRole logs in with an AppRole role ID and secret ID.
+	AuthMethodAppRole = "approle"
+	// DefaultKubernetesTokenFile is where Kubernetes mounts the service
+	// account token of a pod.
+	DefaultKubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token" // nolint: gosec
+)
+
+// AuthMethods are the supported ways of logging in to Vault.
+var AuthMethods = []string{AuthMethodKubernetes, AuthMethodAppRole}
+
+// uriRegex matches references to secrets, ex. vault:secret/data/aws#key. The
+// path is read from Vault and the value of the key in the secret is used.
+var uriRegex = regexp.MustCompile(`vault:([A-Za-z0-9_./-]+)#([A-Za-z0-9_.-]+)`)
+
+// tokenRenewBefore is how long before the Vault token expires that we log in
+// again, so a token doesn't expire in the middle of reading secrets.
+const tokenRenewBefore = 30 * time.Second
+
+// Client resolves vault: URIs by reading the secrets they reference.
+type Client struct {
+	// Address is the URL of the Vault server, ex. https://vault.example.com.
+	Address string
+	// Namespace is the Vault Enterprise namespace. Empty uses the root
+	// namespace.
+	Namespace string
+	// AuthMethod is one of AuthMethods.
+	AuthMethod string
+	// AuthMount is the path the auth method is mounted at. Empty uses the
+	// name of the auth method.
+	AuthMount string
+	// Role is the Kubernetes auth role, or the AppRole role ID.
+	Role string
+	// SecretIDFile is the path to the AppRole secret ID.
+	SecretIDFile string
+	// KubernetesTokenFile is the path to the service account token. Empty
+	// uses DefaultKubernetesTokenFile.
+	KubernetesTokenFile string
+	// Redactor masks the secrets that are read in logs, job output and
+	// comments.
+	Redactor   *logging.Redactor
+	HTTPClient *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// ContainsURI returns true if s references a secret.
+func ContainsURI(s string) bool {
+	return uriRegex.MatchString(s)
+}
+
+// Resolve returns s with every vault: URI in it replaced by the secret it
+// references. The secrets are read each time so that rotated secrets are
+// picked up.
+func (c *Client) Resolve(s string) (string, error) {
+	return c.ResolveFunc(s, func(secret string) string { return secret })
+}
+
+// ResolveFunc returns s with every vault: URI in it replaced by what replace
+// returns for the secret it references.
+func (c *Client) ResolveFunc(s string, replace func(secret string) string) (string, error) {
+	var resolveErr error
+	resolved := uriRegex.ReplaceAllStringFunc(s, func(uri string) string {
+		if resolveErr != nil {
+			return uri
+		}
+		groups := uriRegex.FindStringSubmatch(uri)
+		val, err := c.Read(groups[1], groups[2])
+		if err != nil {
+			resolveErr = errors.Wrapf(err, "resolving %s", uri)
+			return uri
+		}
+		return replace(val)
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+// Read returns the value of key in the secret at path. Both KV version 1 and
+// version 2 secrets are supported, for version 2 the path must include data/,
+// ex. secret/data/atlantis.
+func (c *Client) Read(path string, key string) (string, error) {
+	body, err := c.readSecret(path)
+	if err != nil {
+		return "", err
+	}
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", errors.Wrap(err, "parsing secret")
+	}
+	data := secret.Data
+	// KV version 2 nests the secret under data with its metadata.
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+	val, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", path, key)
+	}
+	var str string
+	switch v := val.(type) {
+	case string:
+		str = v
+	case float64, bool:
+		str = fmt.Sprint(v)
+	default:
+		return "", fmt.Errorf("key %q of secret %s isn't a string", key, path)
+	}
+	c.Redactor.Add(str)
+	return str, nil
+}
+
+func (c *Client) readSecret(path string) ([]byte, error) {
+	token, err := c.loginToken(false)
+	if err != nil {
+		return nil, err
+	}
+	status, body, err := c.do(http.MethodGet, "/v1/"+path, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The token may have been revoked, log in again once.
+	if status == http.StatusForbidden {
+		if token, err = c.loginToken(true); err != nil {
+			return nil, err
+		}
+		if status, body, err = c.do(http.MethodGet, "/v1/"+path, token, nil); err != nil {
+			return nil, err
+		}
+	}
+	if status != http.StatusOK {
+		return nil, responseError(status, body)
+	}
+	return body, nil
+}
+
+// loginToken returns the Vault token, logging in if there's no token yet,
+// it's about to expire or force is true.
+func (c *Client) loginToken(force bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !force && c.token != "" && (c.tokenExpiry.IsZero() || time.Now().Add(tokenRenewBefore).Before(c.tokenExpiry)) {
+		return c.token, nil
+	}
+
+	var login map[string]string
+	switch c.AuthMethod {
+	case AuthMethodKubernetes:
+		tokenFile := c.KubernetesTokenFile
+		if tokenFile == "" {
+			tokenFile = DefaultKubernetesTokenFile
+		}
+		jwt, err := os.ReadFile(tokenFile) // nolint: gosec
+		if err != nil {
+			return "", errors.Wrap(err, "reading kubernetes service account token")
+		}
+		login = map[string]string{"role": c.Role, "jwt": strings.TrimSpace(string(jwt))}
+	case AuthMethodAppRole:
+		secretID, err := os.ReadFile(c.SecretIDFile)
+		if err != nil {
+			return "", errors.Wrap(err, "reading approle secret id")
+		}
+		login = map[string]string{"role_id": c.Role, "secret_id": strings.TrimSpace(string(secretID))}
+	default:
+		return "", fmt.Errorf("unsupported vault auth method %q", c.AuthMethod)
+	}
+	mount := c.AuthMount
+	if mount == "" {
+		mount = c.AuthMethod
+	}
+	reqBody, err := json.Marshal(login)
+	if err != nil {
+		return "", err
+	}
+	status, body, err := c.do(http.MethodPost, fmt.Sprintf("/v1/auth/%s/login", strings.Trim(mount, "/")), "", reqBody)
+	if err != nil {
+		return "", errors.Wrap(err, "logging in to vault")
+	}
+	if status != http.StatusOK {
+		return "", errors.Wrap(responseError(status, body), "logging in to vault")
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", errors.Wrap(err, "parsing vault login response")
+	}
+	c.token = resp.Auth.ClientToken
+	c.tokenExpiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	}
+	return c.token, nil
+}
+
+func (c *Client) do(method string, path string, token string, reqBody []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.Address, "/")+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// responseError returns the errors in a failed Vault response.
+func responseError(status int, body []byte) error {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) == nil && len(resp.Errors) > 0 {
+		return fmt.Errorf("vault responded with status %d: %s", status, strings.Join(resp.Errors, ", "))
+	}
+	return fmt.Errorf("vault responded with status %d", status)
+}
@@ -0,0 +1,123 @@
+package vault_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/vault"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// vaultServer is a fake Vault server with a KV version 2 secret at
+// secret/data/atlantis and a KV version 1 secret at kv/atlantis.
+type vaultServer struct {
+	t      *testing.T
+	logins []map[string]string
+	// revoked is the token that's rejected as if it had been revoked.
+	revoked string
+}
+
+func (v *vaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Equals(v.t, "infra", r.Header.Get("X-Vault-Namespace"))
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login", "/v1/auth/approle-atlantis/login":
+		var login map[string]string
+		Ok(v.t, json.NewDecoder(r.Body).Decode(&login))
+		v.logins = append(v.logins, login)
+		fmt.Fprintf(w, `{"auth": {"client_token": "token-%d", "lease_duration": 3600}}`, len(v.logins))
+		return
+	}
+	token := r.Header.Get("X-Vault-Token")
+	if token == "" || token == v.revoked {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors": ["permission denied"]}`)
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/secret/data/atlantis":
+		fmt.Fprint(w, `{"data": {"data": {"password": "s3cr3t", "port": 5432}, "metadata": {"version": 2}}}`)
+	case "/v1/kv/atlantis":
+		fmt.Fprint(w, `{"data": {"token": "kv1-token"}}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors": []}`)
+	}
+}
+
+func newVaultClient(t *testing.T, server *httptest.Server, redactor *logging.Redactor) *vault.Client {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	Ok(t, os.WriteFile(tokenFile, []byte("service-account-jwt\n"), 0600))
+	return &vault.Client{
+		Address:             server.URL,
+		Namespace:           "infra",
+		AuthMethod:          vault.AuthMethodKubernetes,
+		Role:                "atlantis",
+		KubernetesTokenFile: tokenFile,
+		Redactor:            redactor,
+	}
+}
+
+func TestClient_Resolve(t *testing.T) {
+	fake := &vaultServer{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	redactor, err := logging.NewRedactor(nil, nil)
+	Ok(t, err)
+	client := newVaultClient(t, server, redactor)
+
+	resolved, err := client.Resolve("psql -p vault:secret/data/atlantis#port --password vault:secret/data/atlantis#password -t vault:kv/atlantis#token")
+	Ok(t, err)
+	Equals(t, "psql -p 5432 --password s3cr3t -t kv1-token", resolved)
+	Equals(t, []map[string]string{{"role": "atlantis", "jwt": "service-account-jwt"}}, fake.logins)
+	Equals(t, "password [REDACTED] token [REDACTED]", redactor.Redact("password s3cr3t token kv1-token"))
+
+	unchanged, err := client.Resolve("echo no secrets")
+	Ok(t, err)
+	Equals(t, "echo no secrets", unchanged)
+}
+
+func TestClient_ResolveErrors(t *testing.T) {
+	server := httptest.NewServer(&vaultServer{t: t})
+	defer server.Close()
+	client := newVaultClient(t, server, nil)
+
+	_, err := client.Resolve("vault:secret/data/atlantis#missing")
+	ErrEquals(t, `resolving vault:secret/data/atlantis#missing: secret secret/data/atlantis has no key "missing"`, err)
+	_, err = client.Resolve("vault:secret/data/other#password")
+	ErrEquals(t, "resolving vault:secret/data/other#password: vault responded with status 404", err)
+}
+
+func TestClient_AppRoleLogsInAgainIfTokenRevoked(t *testing.T) {
+	fake := &vaultServer{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	secretIDFile := filepath.Join(t.TempDir(), "secret-id")
+	Ok(t, os.WriteFile(secretIDFile, []byte("secret-id"), 0600))
+	client := &vault.Client{
+		Address:      server.URL,
+		Namespace:    "infra",
+		AuthMethod:   vault.AuthMethodAppRole,
+		AuthMount:    "approle-atlantis",
+		Role:         "role-id",
+		SecretIDFile: secretIDFile,
+	}
+
+	val, err := client.Read("kv/atlantis", "token")
+	Ok(t, err)
+	Equals(t, "kv1-token", val)
+
+	fake.revoked = "token-1"
+	val, err = client.Read("kv/atlantis", "token")
+	Ok(t, err)
+	Equals(t, "kv1-token", val)
+	Equals(t, []map[string]string{
+		{"role_id": "role-id", "secret_id": "secret-id"},
+		{"role_id": "role-id", "secret_id": "secret-id"},
+	}, fake.logins)
+}
@@ -1,8 +1,12 @@
 package events
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/messages"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 )
@@ -22,6 +26,7 @@ func NewApplyCommandRunner(
 	SilenceNoProjects bool,
 	silenceVCSStatusNoProjects bool,
 	pullReqStatusFetcher vcs.PullReqStatusFetcher,
+	markAppliedComments bool,
 ) *ApplyCommandRunner {
 	return &ApplyCommandRunner{
 		vcsClient:                  vcsClient,
@@ -38,6 +43,7 @@ func NewApplyCommandRunner(
 		SilenceNoProjects:          SilenceNoProjects,
 		silenceVCSStatusNoProjects: silenceVCSStatusNoProjects,
 		pullReqStatusFetcher:       pullReqStatusFetcher,
+		markAppliedComments:        markAppliedComments,
 	}
 }
 
@@ -61,6 +67,12 @@ type ApplyCommandRunner struct {
 	// are found
 	silenceVCSStatusNoProjects bool
 	SilencePRComments          []string
+	// markAppliedComments is whether the comment an apply was run from should
+	// be reacted to when the apply succeeds.
+	markAppliedComments bool
+	// Messages overrides the text of the comments made when applies are
+	// disabled.
+	Messages *messages.Catalog
 }
 
 func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
@@ -78,7 +90,7 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 
 	if locked {
 		ctx.Log.Info("ignoring apply command since apply disabled globally")
-		if err := a.vcsClient.CreateComment(ctx.Log, baseRepo, pull.Num, applyDisabledComment, command.Apply.String()); err != nil {
+		if err := a.vcsClient.CreateComment(ctx.Log, baseRepo, pull.Num, a.Messages.Sprintf(messages.CommentApplyDisabled), command.Apply.String()); err != nil {
 			ctx.Log.Err("unable to comment on pull request: %s", err)
 		}
 
@@ -87,7 +99,7 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 
 	if a.DisableApplyAll && !cmd.IsForSpecificProject() {
 		ctx.Log.Info("ignoring apply command without flags since apply all is disabled")
-		if err := a.vcsClient.CreateComment(ctx.Log, baseRepo, pull.Num, applyAllDisabledComment, command.Apply.String()); err != nil {
+		if err := a.vcsClient.CreateComment(ctx.Log, baseRepo, pull.Num, a.Messages.Sprintf(messages.CommentApplyAllDisabled), command.Apply.String()); err != nil {
 			ctx.Log.Err("unable to comment on pull request: %s", err)
 		}
 
@@ -107,6 +119,11 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 		// All PullRequestStatus fields are set to false by default when error.
 		ctx.Log.Warn("unable to get pull request status: %s. Continuing with mergeable and approved assumed false", err)
 	}
+	if pull.State == models.MergedPullState {
+		// A merged pull request is no longer mergeable but it passed the VCS
+		// host's merge checks when it was merged.
+		ctx.PullRequestStatus.Mergeable = true
+	}
 
 	var projectCmds []command.ProjectContext
 	projectCmds, err = a.prjCmdBuilder.BuildApplyCommands(ctx, cmd)
@@ -136,6 +153,7 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 					if err := a.commitStatusUpdater.UpdateCombinedCount(ctx.Log, baseRepo, pull, models.SuccessCommitStatus, command.Apply, 0, 0); err != nil {
 						ctx.Log.Warn("unable to update commit status: %s", err)
 					}
+					a.updateSummaryStatus(ctx, models.PullStatus{})
 					return
 				}
 				ctx.Log.Debug("resetting VCS status")
@@ -149,6 +167,7 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 				if err := a.commitStatusUpdater.UpdateCombinedCount(ctx.Log, baseRepo, pull, models.SuccessCommitStatus, command.Apply, 0, 0); err != nil {
 					ctx.Log.Warn("unable to update commit status: %s", err)
 				}
+				a.updateSummaryStatus(ctx, models.PullStatus{})
 			}
 		}
 		return
@@ -156,7 +175,9 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 
 	// Only run commands in parallel if enabled
 	var result command.Result
-	if a.isParallelEnabled(projectCmds) {
+	if len(splitByExecutionOrderGroup(projectCmds)) > 1 {
+		result = a.runExecutionOrderGroups(ctx, cmd, projectCmds)
+	} else if a.isParallelEnabled(projectCmds) {
 		ctx.Log.Info("Running applies in parallel")
 		result = runProjectCmdsParallelGroups(ctx, projectCmds, a.prjCmdRunner.Apply, a.parallelPoolSize)
 	} else {
@@ -177,11 +198,64 @@ func (a *ApplyCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
 
 	a.updateCommitStatus(ctx, pullStatus)
 
-	if a.autoMerger.automergeEnabled(projectCmds) && !cmd.AutoMergeDisabled {
+	if a.markAppliedComments && !result.HasErrors() {
+		if err := vcs.MarkCommentSucceeded(a.vcsClient, ctx.Log, baseRepo, pull.Num, cmd.CommentID); err != nil {
+			ctx.Log.Warn("unable to mark comment as succeeded: %s", err)
+		}
+	}
+
+	if a.autoMerger.automergeEnabled(projectCmds) && !cmd.AutoMergeDisabled && pull.State != models.MergedPullState {
 		a.autoMerger.automerge(ctx, pullStatus, a.autoMerger.deleteSourceBranchOnMergeEnabled(projectCmds), cmd.AutoMergeMethod)
 	}
 }
 
+// runExecutionOrderGroups applies the projects one execution order group at a
+// time. After each group but the last it posts a comment with the progress of
+// every group. When a group fails the following groups are halted unless the
+// command was run with --continue-on-group-failure.
+func (a *ApplyCommandRunner) runExecutionOrderGroups(ctx *command.Context, cmd *CommentCommand, projectCmds []command.ProjectContext) command.Result {
+	groups := splitByExecutionOrderGroup(projectCmds)
+	statuses := make([]string, len(groups))
+	for i := range statuses {
+		statuses[i] = executionOrderGroupPending
+	}
+
+	var results []command.ProjectResult
+	for i, group := range groups {
+		var res command.Result
+		if a.isParallelEnabled(group) {
+			ctx.Log.Info("running applies for execution order group %d in parallel", group[0].ExecutionOrderGroup)
+			res = runProjectCmdsParallel(group, a.prjCmdRunner.Apply, a.parallelPoolSize)
+		} else {
+			res = runProjectCmds(group, a.prjCmdRunner.Apply)
+		}
+		results = append(results, res.ProjectResults...)
+
+		statuses[i] = executionOrderGroupApplied
+		if failed := countFailedProjects(res.ProjectResults); failed > 0 {
+			statuses[i] = fmt.Sprintf("failed (%d of %d)", failed, len(group))
+		}
+		if i == len(groups)-1 {
+			break
+		}
+		halt := res.HasErrors() && !cmd.ContinueOnGroupFailure
+		if halt {
+			ctx.Log.Info("halting apply after execution order group %d failed", group[0].ExecutionOrderGroup)
+			for j := i + 1; j < len(groups); j++ {
+				statuses[j] = executionOrderGroupHalted
+			}
+		}
+		comment := renderExecutionOrderProgress(groups, statuses, halt)
+		if err := a.vcsClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, command.Apply.String()); err != nil {
+			ctx.Log.Warn("unable to comment with execution order group progress: %s", err)
+		}
+		if halt {
+			break
+		}
+	}
+	return command.Result{ProjectResults: results}
+}
+
 func (a *ApplyCommandRunner) IsLocked() (bool, error) {
 	lock, err := a.locker.CheckApplyLock()
 
@@ -219,12 +293,57 @@ func (a *ApplyCommandRunner) updateCommitStatus(ctx *command.Context, pullStatus
 	); err != nil {
 		ctx.Log.Warn("unable to update commit status: %s", err)
 	}
+	a.updateSummaryStatus(ctx, pullStatus)
+}
+
+// updateSummaryStatus updates the status that aggregates the plans and
+// applies of all projects in pullStatus.
+func (a *ApplyCommandRunner) updateSummaryStatus(ctx *command.Context, pullStatus models.PullStatus) {
+	if err := a.commitStatusUpdater.UpdateSummary(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, pullStatus); err != nil {
+		ctx.Log.Warn("unable to update summary commit status: %s", err)
+	}
+}
+
+const (
+	executionOrderGroupApplied = "applied"
+	executionOrderGroupPending = "pending"
+	executionOrderGroupHalted  = "halted"
+)
+
+func countFailedProjects(results []command.ProjectResult) int {
+	var failed int
+	for _, r := range results {
+		if r.Error != nil || r.Failure != "" {
+			failed++
+		}
+	}
+	return failed
 }
 
-// applyAllDisabledComment is posted when apply all commands (i.e. "atlantis apply")
-// are disabled and an apply all command is issued.
-var applyAllDisabledComment = "**Error:** Running `atlantis apply` without flags is disabled." +
-	" You must specify which project to apply via the `-d <dir>`, `-w <workspace>` or `-p <project name>` flags."
+// renderExecutionOrderProgress renders the comment posted between execution
+// order groups during an apply.
+func renderExecutionOrderProgress(groups [][]command.ProjectContext, statuses []string, halted bool) string {
+	var b strings.Builder
+	b.WriteString("### Apply progress\n\n")
+	b.WriteString("| Group | Projects | Status |\n")
+	b.WriteString("|-------|----------|--------|\n")
+	for i, group := range groups {
+		var projects []string
+		for _, p := range group {
+			projects = append(projects, fmt.Sprintf("`%s`", projectDisplayName(p)))
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s |\n", group[0].ExecutionOrderGroup, strings.Join(projects, ", "), statuses[i])
+	}
+	if halted {
+		b.WriteString("\nThe following groups were not applied because a group failed." +
+			" Fix the failure and apply again, or apply with `--continue-on-group-failure` to apply them anyway.\n")
+	}
+	return b.String()
+}
 
-// applyDisabledComment is posted when apply commands are disabled globally and an apply command is issued.
-var applyDisabledComment = "**Error:** Running `atlantis apply` is disabled."
+func projectDisplayName(p command.ProjectContext) string {
+	if p.ProjectName != "" {
+		return p.ProjectName
+	}
+	return fmt.Sprintf("%s/%s", p.RepoRelDir, p.Workspace)
+}
@@ -234,6 +234,10 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 		RunnerInvokeMatch []*EqMatcher
 		ExpComment        string
 		ApplyFailed       bool
+		// ContinueOnGroupFailure sets --continue-on-group-failure.
+		ContinueOnGroupFailure bool
+		// ExpProgressComments are the comments posted between groups.
+		ExpProgressComments []string
 	}{
 		{
 			Description: "When first apply fails, the second don't run",
@@ -302,6 +306,12 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 			},
 			ApplyFailed: true,
 			ExpComment:  "Ran Apply for dir: `` workspace: ``\n\n**Apply Error**\n```\nshabang\n```",
+			ExpProgressComments: []string{
+				"### Apply progress\n\n| Group | Projects | Status |\n|-------|----------|--------|\n" +
+					"| 0 | `First` | failed (1 of 1) |\n| 1 | `Second` | halted |\n\n" +
+					"The following groups were not applied because a group failed." +
+					" Fix the failure and apply again, or apply with `--continue-on-group-failure` to apply them anyway.\n",
+			},
 		},
 		{
 			Description: "When both in a group of two succeeds, the following two will run",
@@ -414,17 +424,15 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 				"4. dir: `` workspace: ``\n```diff\nGreat success!\n```\n\n---\n### Apply Summary\n\n4 projects, 3 successful, 0 failed, 1 errored",
 		},
 		{
-			Description: "Don't block when parallel is not set",
+			Description: "When parallel is not set and first apply fails, the second doesn't run",
 			ProjectContexts: []command.ProjectContext{
 				{
-					ExecutionOrderGroup:       0,
-					ProjectName:               "First",
-					AbortOnExecutionOrderFail: true,
+					ExecutionOrderGroup: 0,
+					ProjectName:         "First",
 				},
 				{
-					ExecutionOrderGroup:       1,
-					ProjectName:               "Second",
-					AbortOnExecutionOrderFail: true,
+					ExecutionOrderGroup: 1,
+					ProjectName:         "Second",
 				},
 			},
 			ProjectResults: []command.ProjectResult{
@@ -439,15 +447,14 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 			},
 			RunnerInvokeMatch: []*EqMatcher{
 				Once(),
-				Once(),
+				Never(),
 			},
 			ApplyFailed: true,
-			ExpComment: "Ran Apply for 2 projects:\n\n" +
-				"1. dir: `` workspace: ``\n1. dir: `` workspace: ``\n---\n\n### 1. dir: `` workspace: ``\n**Apply Error**\n```\nshabang\n```\n\n---\n### " +
-				"2. dir: `` workspace: ``\n```diff\nGreat success!\n```\n\n---\n### Apply Summary\n\n2 projects, 1 successful, 0 failed, 1 errored",
+			ExpComment:  "Ran Apply for dir: `` workspace: ``\n\n**Apply Error**\n```\nshabang\n```",
 		},
 		{
-			Description: "Don't block when abortOnExecutionOrderFail is not set",
+			Description:            "Don't block when --continue-on-group-failure is set",
+			ContinueOnGroupFailure: true,
 			ProjectContexts: []command.ProjectContext{
 				{
 					ExecutionOrderGroup: 0,
@@ -507,6 +514,10 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 			ExpComment: "Ran Apply for 2 projects:\n\n" +
 				"1. dir: `` workspace: ``\n1. dir: `` workspace: ``\n---\n\n### 1. dir: `` workspace: ``\n```diff\nGreat success!\n```\n\n---\n### " +
 				"2. dir: `` workspace: ``\n```diff\nGreat success!\n```\n\n---\n### Apply Summary\n\n2 projects, 2 successful, 0 failed, 0 errored",
+			ExpProgressComments: []string{
+				"### Apply progress\n\n| Group | Projects | Status |\n|-------|----------|--------|\n" +
+					"| 0 | `First` | applied |\n| 1 | `Second` | pending |\n",
+			},
 		},
 	}
 
@@ -521,7 +532,7 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 			}
 			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
 
-			cmd := &events.CommentCommand{Name: command.Apply}
+			cmd := &events.CommentCommand{Name: command.Apply, ContinueOnGroupFailure: c.ContinueOnGroupFailure}
 
 			ctx := &command.Context{
 				User:     testdata.User,
@@ -551,6 +562,66 @@ func TestApplyCommandRunner_ExecutionOrder(t *testing.T) {
 			vcsClient.VerifyWasCalledOnce().CreateComment(
 				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(c.ExpComment), Eq("apply"),
 			)
+			for _, progress := range c.ExpProgressComments {
+				vcsClient.VerifyWasCalledOnce().CreateComment(
+					Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(progress), Eq("apply"),
+				)
+			}
+		})
+	}
+}
+
+func TestApplyCommandRunner_MarkAppliedComments(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+
+	cases := []struct {
+		Description   string
+		ProjectResult command.ProjectResult
+		ExpMarked     bool
+	}{
+		{
+			Description:   "When the apply succeeds the comment is marked",
+			ProjectResult: command.ProjectResult{Command: command.Apply, ApplySuccess: "Great success!"},
+			ExpMarked:     true,
+		},
+		{
+			Description:   "When the apply fails the comment isn't marked",
+			ProjectResult: command.ProjectResult{Command: command.Apply, Error: errors.New("shabang")},
+			ExpMarked:     false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Description, func(t *testing.T) {
+			vcsClient := setup(t, func(tc *TestConfig) {
+				tc.markAppliedComments = true
+			})
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			cmd := &events.CommentCommand{Name: command.Apply, CommentID: 123}
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logging.NewNoopLogger(t),
+				Scope:    scopeNull,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			projectCtx := command.ProjectContext{ProjectName: "project"}
+
+			When(projectCommandBuilder.BuildApplyCommands(ctx, cmd)).ThenReturn([]command.ProjectContext{projectCtx}, nil)
+			When(projectCommandRunner.Apply(projectCtx)).ThenReturn(c.ProjectResult)
+
+			applyCommandRunner.Run(ctx, cmd)
+
+			expCalls := Never()
+			if c.ExpMarked {
+				expCalls = Once()
+			}
+			vcsClient.VerifyWasCalled(expCalls).ReactToComment(
+				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(int64(123)), Eq("rocket"))
 		})
 	}
 }
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ApplyConfirmations holds the applies that are waiting to be confirmed with
+// `atlantis confirm`. They're kept in memory so they're lost on restart and
+// have to be run again.
+type ApplyConfirmations struct {
+	// TTL is how long an apply can be confirmed for after it was run.
+	TTL time.Duration
+	// now returns the current time. It's overridden in tests.
+	now func() time.Time
+
+	mu sync.Mutex
+	// byNonce maps the nonce of each apply to confirm to the apply.
+	byNonce map[string]pendingApply
+}
+
+// pendingApply is an apply waiting to be confirmed.
+type pendingApply struct {
+	repoFullName string
+	pullNum      int
+	username     string
+	cmd          CommentCommand
+	expiresAt    time.Time
+}
+
+// NewApplyConfirmations returns an ApplyConfirmations whose applies can be
+// confirmed for ttl.
+func NewApplyConfirmations(ttl time.Duration) *ApplyConfirmations {
+	return &ApplyConfirmations{
+		TTL:     ttl,
+		now:     time.Now,
+		byNonce: map[string]pendingApply{},
+	}
+}
+
+// Request stores cmd, an apply run by username on the pull request pullNum
+// of repo, and returns the nonce it can be confirmed with.
+func (a *ApplyConfirmations) Request(repoFullName string, pullNum int, username string, cmd CommentCommand) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(b)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	for n, pending := range a.byNonce {
+		if now.After(pending.expiresAt) {
+			delete(a.byNonce, n)
+		}
+	}
+	a.byNonce[nonce] = pendingApply{
+		repoFullName: repoFullName,
+		pullNum:      pullNum,
+		username:     username,
+		cmd:          cmd,
+		expiresAt:    now.Add(a.TTL),
+	}
+	return nonce, nil
+}
+
+// Confirm returns the apply with nonce so it can be run. It can only be
+// confirmed once, on the pull request it was run on, by the user who ran it
+// and before it expires. A nil ApplyConfirmations has no applies to confirm.
+func (a *ApplyConfirmations) Confirm(repoFullName string, pullNum int, username string, nonce string) (*CommentCommand, error) {
+	if a == nil {
+		return nil, fmt.Errorf("no apply is waiting to be confirmed with %q on this pull request", nonce)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	pending, ok := a.byNonce[nonce]
+	if !ok || pending.repoFullName != repoFullName || pending.pullNum != pullNum {
+		return nil, fmt.Errorf("no apply is waiting to be confirmed with %q on this pull request", nonce)
+	}
+	if pending.username != username {
+		return nil, fmt.Errorf("only @%s, who ran the apply, can confirm it", pending.username)
+	}
+	delete(a.byNonce, nonce)
+	if a.now().After(pending.expiresAt) {
+		return nil, fmt.Errorf("the apply to confirm with %q expired, run it again", nonce)
+	}
+	cmd := pending.cmd
+	cmd.Confirmed = true
+	return &cmd, nil
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestApplyConfirmations_Confirm(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	confirmations := NewApplyConfirmations(10 * time.Minute)
+	confirmations.now = func() time.Time { return now }
+	cmd := CommentCommand{Name: command.Apply, ProjectName: "prod"}
+
+	nonce, err := confirmations.Request("owner/repo", 1, "alice", cmd)
+	Ok(t, err)
+	Equals(t, 8, len(nonce))
+
+	_, err = confirmations.Confirm("owner/repo", 2, "alice", nonce)
+	ErrEquals(t, `no apply is waiting to be confirmed with "`+nonce+`" on this pull request`, err)
+	_, err = confirmations.Confirm("owner/repo", 1, "bob", nonce)
+	ErrEquals(t, "only @alice, who ran the apply, can confirm it", err)
+
+	confirmed, err := confirmations.Confirm("owner/repo", 1, "alice", nonce)
+	Ok(t, err)
+	cmd.Confirmed = true
+	Equals(t, &cmd, confirmed)
+
+	_, err = confirmations.Confirm("owner/repo", 1, "alice", nonce)
+	ErrEquals(t, `no apply is waiting to be confirmed with "`+nonce+`" on this pull request`, err)
+}
+
+func TestApplyConfirmations_Expired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	confirmations := NewApplyConfirmations(10 * time.Minute)
+	confirmations.now = func() time.Time { return now }
+
+	expired, err := confirmations.Request("owner/repo", 1, "alice", CommentCommand{Name: command.Apply})
+	Ok(t, err)
+	now = now.Add(11 * time.Minute)
+	_, err = confirmations.Confirm("owner/repo", 1, "alice", expired)
+	ErrEquals(t, `the apply to confirm with "`+expired+`" expired, run it again`, err)
+
+	// Expired applies are dropped when another one is requested.
+	swept, err := confirmations.Request("owner/repo", 1, "alice", CommentCommand{Name: command.Apply})
+	Ok(t, err)
+	now = now.Add(11 * time.Minute)
+	_, err = confirmations.Request("owner/repo", 1, "alice", CommentCommand{Name: command.Apply})
+	Ok(t, err)
+	_, ok := confirmations.byNonce[swept]
+	Assert(t, !ok, "expected the expired apply to be dropped")
+}
+
+func TestApplyConfirmations_Nil(t *testing.T) {
+	var confirmations *ApplyConfirmations
+	_, err := confirmations.Confirm("owner/repo", 1, "alice", "abcd1234")
+	ErrEquals(t, `no apply is waiting to be confirmed with "abcd1234" on this pull request`, err)
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// AtlantisIgnoreFile is the file at the repo root listing, in gitignore
+// syntax, the paths that never contain auto-discovered projects.
+const AtlantisIgnoreFile = ".atlantisignore"
+
+// atlantisIgnorePattern is a pattern of an .atlantisignore file.
+type atlantisIgnorePattern struct {
+	// glob is the pattern without its leading !, leading / or trailing /.
+	glob string
+	// negate is true if the pattern re-includes paths, ex. !examples/live.
+	negate bool
+	// anchored is true if the pattern is matched against the whole path
+	// from the repo root instead of against the last element of the path,
+	// which is the case for patterns containing a /.
+	anchored bool
+}
+
+// atlantisIgnore matches paths against the patterns of an .atlantisignore
+// file.
+type atlantisIgnore struct {
+	patterns []atlantisIgnorePattern
+}
+
+// readAtlantisIgnore reads the .atlantisignore file of the repo cloned at
+// absRepoDir. It returns nil if the repo doesn't have one.
+func readAtlantisIgnore(absRepoDir string) (*atlantisIgnore, error) {
+	f, err := os.Open(filepath.Join(absRepoDir, AtlantisIgnoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	ignore := &atlantisIgnore{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var pattern atlantisIgnorePattern
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		// Only directories contain projects so a trailing / changes nothing.
+		line = strings.TrimSuffix(line, "/")
+		pattern.anchored = strings.Contains(line, "/")
+		pattern.glob = strings.TrimPrefix(line, "/")
+		if pattern.glob == "" || !doublestar.ValidatePattern(pattern.glob) {
+			continue
+		}
+		ignore.patterns = append(ignore.patterns, pattern)
+	}
+	return ignore, scanner.Err()
+}
+
+// Ignored returns true if the directory relDir, relative to the repo root,
+// or one of its parents is ignored. Like with gitignore, the last matching
+// pattern wins and a directory inside an ignored one can't be re-included.
+func (a *atlantisIgnore) Ignored(relDir string) bool {
+	if a == nil {
+		return false
+	}
+	relDir = path.Clean(filepath.ToSlash(relDir))
+	if relDir == "." {
+		return false
+	}
+	elems := strings.Split(relDir, "/")
+	for i := range elems {
+		if a.matches(strings.Join(elems[:i+1], "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the last pattern matching dir ignores it.
+func (a *atlantisIgnore) matches(dir string) bool {
+	ignored := false
+	for _, p := range a.patterns {
+		target := dir
+		if !p.anchored {
+			target = path.Base(dir)
+		}
+		if doublestar.MatchUnvalidated(p.glob, target) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewCancelCommandRunner(
+	runningCommands *RunningCommands,
+	vcsClient vcs.Client,
+	SilenceNoProjects bool,
+) *CancelCommandRunner {
+	return &CancelCommandRunner{
+		runningCommands:   runningCommands,
+		vcsClient:         vcsClient,
+		SilenceNoProjects: SilenceNoProjects,
+	}
+}
+
+// CancelCommandRunner cancels the plans and applies running for a pull
+// request.
+type CancelCommandRunner struct {
+	runningCommands *RunningCommands
+	vcsClient       vcs.Client
+	// SilenceNoProjects is whether Atlantis should respond to PRs if no projects
+	// are found
+	SilenceNoProjects bool
+}
+
+func (c *CancelCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	canceled := c.runningCommands.CancelPull(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.User.Username, func(projCtx command.ProjectContext) bool {
+		return (cmd.ProjectName == "" || cmd.ProjectName == projCtx.ProjectName) &&
+			(cmd.RepoRelDir == "" || cmd.RepoRelDir == projCtx.RepoRelDir) &&
+			(cmd.Workspace == "" || cmd.Workspace == projCtx.Workspace)
+	})
+
+	var vcsMessage string
+	if len(canceled) == 0 {
+		ctx.Log.Info("no running plans or applies to cancel")
+		if c.SilenceNoProjects {
+			return
+		}
+		vcsMessage = "There are no running plans or applies to cancel."
+	} else {
+		ctx.Log.Info("canceled %d running plans and applies", len(canceled))
+		var lines []string
+		for _, projCtx := range canceled {
+			line := fmt.Sprintf("* `%s` of dir: `%s` workspace: `%s`", projCtx.CommandName, projCtx.RepoRelDir, projCtx.Workspace)
+			if projCtx.ProjectName != "" {
+				line = fmt.Sprintf("* `%s` of project: `%s` dir: `%s` workspace: `%s`", projCtx.CommandName, projCtx.ProjectName, projCtx.RepoRelDir, projCtx.Workspace)
+			}
+			lines = append(lines, line)
+		}
+		sort.Strings(lines)
+		vcsMessage = fmt.Sprintf("Canceling the following commands, their results will be commented once they've stopped:\n\n%s", strings.Join(lines, "\n"))
+	}
+
+	if commentErr := c.vcsClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, vcsMessage, command.Cancel.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+}
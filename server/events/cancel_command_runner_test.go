@@ -0,0 +1,127 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v71/github"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCancelCommandRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+
+	tests := []struct {
+		name         string
+		silenced     bool
+		cmd          events.CommentCommand
+		expCanceled  []string
+		expComment   string
+		expNoComment bool
+	}{
+		{
+			name:        "cancel all",
+			cmd:         events.CommentCommand{Name: command.Cancel},
+			expCanceled: []string{"plan", "apply"},
+			expComment:  "Canceling the following commands, their results will be commented once they've stopped:\n\n* `apply` of project: `project1` dir: `dir1` workspace: `default`\n* `plan` of dir: `dir2` workspace: `staging`",
+		},
+		{
+			name:        "cancel project",
+			cmd:         events.CommentCommand{Name: command.Cancel, ProjectName: "project1"},
+			expCanceled: []string{"apply"},
+			expComment:  "Canceling the following commands, their results will be commented once they've stopped:\n\n* `apply` of project: `project1` dir: `dir1` workspace: `default`",
+		},
+		{
+			name:        "cancel dir and workspace",
+			cmd:         events.CommentCommand{Name: command.Cancel, RepoRelDir: "dir2", Workspace: "staging"},
+			expCanceled: []string{"plan"},
+			expComment:  "Canceling the following commands, their results will be commented once they've stopped:\n\n* `plan` of dir: `dir2` workspace: `staging`",
+		},
+		{
+			name:       "nothing to cancel",
+			cmd:        events.CommentCommand{Name: command.Cancel, Workspace: "prod"},
+			expComment: "There are no running plans or applies to cancel.",
+		},
+		{
+			name:         "nothing to cancel with silencing",
+			cmd:          events.CommentCommand{Name: command.Cancel, Workspace: "prod"},
+			silenced:     true,
+			expNoComment: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcsClient := setup(t, func(tc *TestConfig) {
+				tc.SilenceNoProjects = tt.silenced
+			})
+
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			apply, doneApply := runningCommands.Track(command.ProjectContext{
+				CommandName: command.Apply, JobID: "1", Pull: modelPull, ProjectName: "project1", RepoRelDir: "dir1", Workspace: "default",
+			})
+			defer doneApply()
+			plan, donePlan := runningCommands.Track(command.ProjectContext{
+				CommandName: command.Plan, JobID: "2", Pull: modelPull, RepoRelDir: "dir2", Workspace: "staging",
+			})
+			defer donePlan()
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logger,
+				Scope:    scopeNull,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			cancelCommandRunner.Run(ctx, &tt.cmd)
+
+			var canceled []string
+			for _, projCtx := range []command.ProjectContext{plan, apply} {
+				if projCtx.Cancellation.Canceled() {
+					Equals(t, testdata.User.Username, projCtx.Cancellation.By())
+					canceled = append(canceled, projCtx.CommandName.String())
+				}
+			}
+			Equals(t, tt.expCanceled, canceled)
+
+			if tt.expNoComment {
+				vcsClient.VerifyWasCalled(Never()).CreateComment(
+					Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+			} else {
+				vcsClient.VerifyWasCalledOnce().CreateComment(
+					Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(tt.expComment), Eq("cancel"))
+			}
+		})
+	}
+}
+
+func TestRunCommentCommand_CancelSkipsWorkflowHooks(t *testing.T) {
+	vcsClient := setup(t)
+	pull := &github.PullRequest{State: github.Ptr("open")}
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo),
+		Eq(testdata.Pull.Num))).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(pull))).ThenReturn(modelPull, modelPull.BaseRepo,
+		testdata.GithubRepo, nil)
+	plan, done := runningCommands.Track(command.ProjectContext{CommandName: command.Plan, JobID: "1", Pull: modelPull, RepoRelDir: ".", Workspace: "default"})
+	defer done()
+
+	ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, nil, testdata.User, testdata.Pull.Num,
+		&events.CommentCommand{Name: command.Cancel})
+
+	Assert(t, plan.Cancellation.Canceled(), "expected the plan to be canceled")
+	preWorkflowHooksCommandRunner.(*mocks.MockPreWorkflowHooksCommandRunner).VerifyWasCalled(Never()).RunPreHooks(Any[*command.Context](), Any[*events.CommentCommand]())
+	postWorkflowHooksCommandRunner.(*mocks.MockPostWorkflowHooksCommandRunner).VerifyWasCalled(Never()).RunPostHooks(Any[*command.Context](), Any[*events.CommentCommand]())
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+		Eq("Canceling the following commands, their results will be commented once they've stopped:\n\n* `plan` of dir: `.` workspace: `default`"), Eq("cancel"))
+}
@@ -0,0 +1,168 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// cdktfConfigFile is the file that marks the root of a CDKTF app.
+	cdktfConfigFile = "cdktf.json"
+	// cdktfDefaultOutput is where CDKTF writes the synthesized stacks if
+	// cdktf.json doesn't set an output directory.
+	cdktfDefaultOutput = "cdktf.out"
+	// cdktfSynthTimeout is how long `cdktf synth` can run before it's killed.
+	cdktfSynthTimeout = 10 * time.Minute
+)
+
+// cdktfSynthEnvVars are the environment variables of the Atlantis server
+// that are passed to `cdktf synth`. The app is code from the pull request so
+// it doesn't get the server's credentials.
+var cdktfSynthEnvVars = []string{"PATH", "HOME", "TMPDIR", "LANG"}
+
+// CDKTFSynthesizer synthesizes the Terraform configuration of CDKTF apps.
+type CDKTFSynthesizer interface {
+	// Synth runs `cdktf synth` in the CDKTF app at absAppDir.
+	Synth(log logging.SimpleLogging, absAppDir string) error
+}
+
+// DefaultCDKTFSynthesizer runs the cdktf CLI, which must be installed on the
+// Atlantis server.
+type DefaultCDKTFSynthesizer struct{}
+
+// Synth runs `cdktf synth` in the CDKTF app at absAppDir.
+func (d *DefaultCDKTFSynthesizer) Synth(log logging.SimpleLogging, absAppDir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cdktfSynthTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "cdktf", "synth")
+	cmd.Dir = absAppDir
+	// Stop cdktf from prompting or sending telemetry.
+	cmd.Env = []string{"CI=true", "CHECKPOINT_DISABLE=1"}
+	for _, name := range cdktfSynthEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("running cdktf synth in %s: timed out after %s", absAppDir, cdktfSynthTimeout)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "running cdktf synth in %s: %s", absAppDir, string(output))
+	}
+	log.Debug("ran cdktf synth in %s: %s", absAppDir, string(output))
+	return nil
+}
+
+// cdktfApp is a CDKTF app in a repo.
+type cdktfApp struct {
+	// Dir is the directory of the app's cdktf.json relative to the repo root.
+	Dir string
+	// Output is the directory the app's stacks are synthesized to, relative
+	// to Dir.
+	Output string
+}
+
+// findCDKTFApps returns the CDKTF apps in the repo cloned at absRepoDir.
+func findCDKTFApps(absRepoDir string) ([]cdktfApp, error) {
+	var apps []cdktfApp
+	err := filepath.WalkDir(absRepoDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".terraform", "node_modules", cdktfDefaultOutput:
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != cdktfConfigFile {
+			return nil
+		}
+
+		raw, err := os.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return err
+		}
+		var cfg struct {
+			Output string `json:"output"`
+		}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return errors.Wrapf(err, "parsing %s", path)
+		}
+		if cfg.Output == "" {
+			cfg.Output = cdktfDefaultOutput
+		}
+		if !filepath.IsLocal(cfg.Output) || filepath.Clean(cfg.Output) == "." {
+			return errors.Errorf("parsing %s: output %q must be a directory inside the app", path, cfg.Output)
+		}
+		dir, err := filepath.Rel(absRepoDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		apps = append(apps, cdktfApp{Dir: dir, Output: filepath.Clean(cfg.Output)})
+		return nil
+	})
+	return apps, err
+}
+
+// isModified returns true if one of modifiedFiles is part of the app. Files
+// in the app's output directory don't count since they're generated.
+func (a cdktfApp) isModified(modifiedFiles []string) bool {
+	outputDir := filepath.Join(a.Dir, a.Output) + "/"
+	for _, file := range modifiedFiles {
+		file = filepath.Clean(file)
+		if a.Dir != "." && !strings.HasPrefix(file, a.Dir+"/") {
+			continue
+		}
+		if !strings.HasPrefix(file, outputDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverCDKTFProjects synthesizes the CDKTF apps in the repo cloned at
+// absRepoDir that were modified and returns a project for each stack they
+// generated.
+func discoverCDKTFProjects(log logging.SimpleLogging, synthesizer CDKTFSynthesizer, modifiedFiles []string, repoFullName string, absRepoDir string) ([]models.Project, error) {
+	apps, err := findCDKTFApps(absRepoDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "looking for CDKTF apps")
+	}
+
+	var projects []models.Project
+	for _, app := range apps {
+		if !app.isModified(modifiedFiles) {
+			log.Debug("skipping CDKTF app at dir '%s' since it wasn't modified", app.Dir)
+			continue
+		}
+		log.Info("synthesizing CDKTF app at dir '%s'", app.Dir)
+		if err := synthesizer.Synth(log, filepath.Join(absRepoDir, app.Dir)); err != nil {
+			return nil, err
+		}
+
+		stacksDir := filepath.Join(app.Dir, app.Output, "stacks")
+		entries, err := os.ReadDir(filepath.Join(absRepoDir, stacksDir))
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing stacks of CDKTF app at dir '%s'", app.Dir)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				projects = append(projects, models.NewProject(repoFullName, filepath.Join(stacksDir, entry.Name()), ""))
+			}
+		}
+	}
+	return projects, nil
+}
@@ -0,0 +1,51 @@
+package command
+
+import "sync"
+
+// Cancellation signals that a running plan or apply was canceled. A nil
+// Cancellation is never canceled.
+type Cancellation struct {
+	once sync.Once
+	done chan struct{}
+	by   string
+}
+
+// NewCancellation returns a Cancellation that hasn't been canceled.
+func NewCancellation() *Cancellation {
+	return &Cancellation{done: make(chan struct{})}
+}
+
+// Cancel cancels the command. by is the user who canceled it and may be
+// empty. Only the first call has an effect.
+func (c *Cancellation) Cancel(by string) {
+	c.once.Do(func() {
+		c.by = by
+		close(c.done)
+	})
+}
+
+// Done returns a channel that's closed once the command is canceled.
+func (c *Cancellation) Done() <-chan struct{} {
+	if c == nil {
+		return nil
+	}
+	return c.done
+}
+
+// Canceled returns true if the command was canceled.
+func (c *Cancellation) Canceled() bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// By returns the user who canceled the command, if any.
+func (c *Cancellation) By() string {
+	if !c.Canceled() {
+		return ""
+	}
+	return c.by
+}
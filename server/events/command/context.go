@@ -42,14 +42,47 @@ type Context struct {
 	// ClearPolicyApproval is true if approval should be cleared on specified policies.
 	ClearPolicyApproval bool
 
+	// PolicyApprovalReason is the justification given for approving failing policies.
+	PolicyApprovalReason string
+
 	Trigger Trigger
 
+	// CommentID is the ID of the comment the command was run from, or 0 if
+	// it wasn't.
+	CommentID int64
+
+	// AutoplanTrigger is the pull request event that started an autoplan.
+	AutoplanTrigger models.AutoplanTrigger
+
 	// API is true if plan/apply by API endpoints
 	API bool
 
 	// TeamAllowlistChecker is used to check authorization on a project-level
 	TeamAllowlistChecker TeamAllowlistChecker
 
+	// ForkRestricted is true if the pull request comes from a fork whose author
+	// isn't allowlisted, in which case only plans can run and they use the
+	// restricted fork workflow.
+	ForkRestricted bool
+
+	// HistoryRewritten is true if the pull request's branch was force-pushed
+	// so its workspaces and plans were discarded before the command ran.
+	HistoryRewritten bool
+
 	// Set true if there were any errors during the command execution
 	CommandHasErrors bool
 }
+
+// CommandTrigger returns how the command was started, one of
+// models.AutoCommandTrigger, models.CommentCommandTrigger or
+// models.APICommandTrigger.
+func (c *Context) CommandTrigger() string {
+	switch {
+	case c.API:
+		return models.APICommandTrigger
+	case c.Trigger == CommentTrigger:
+		return models.CommentCommandTrigger
+	default:
+		return models.AutoCommandTrigger
+	}
+}
@@ -30,6 +30,18 @@ const (
 	Import
 	// State is a command to run terraform state rm
 	State
+	// ForceUnlock is a command to run terraform force-unlock
+	ForceUnlock
+	// Cancel is a command to cancel running plans and applies.
+	Cancel
+	// Workspaces is a command to list terraform workspaces.
+	Workspaces
+	// Status is a command to summarize the projects of a pull request.
+	Status
+	// Confirm is a command to run an apply that requires confirmation.
+	Confirm
+	// Help is a command to describe the commands and projects of a repo.
+	Help
 	// Adding more? Don't forget to update String() below
 )
 
@@ -47,6 +59,11 @@ var AllCommentCommands = []Name{
 	ApprovePolicies,
 	Import,
 	State,
+	ForceUnlock,
+	Cancel,
+	Workspaces,
+	Status,
+	Confirm,
 }
 
 // TitleString returns the string representation in title form.
@@ -74,6 +91,18 @@ func (c Name) String() string {
 		return "import"
 	case State:
 		return "state"
+	case ForceUnlock:
+		return "force-unlock"
+	case Cancel:
+		return "cancel"
+	case Workspaces:
+		return "workspaces"
+	case Status:
+		return "status"
+	case Confirm:
+		return "confirm"
+	case Help:
+		return "help"
 	}
 	return ""
 }
@@ -85,6 +114,10 @@ func (c Name) DefaultUsage() string {
 		return "import ADDRESS ID"
 	case State:
 		return "state [rm ADDRESS...]"
+	case ForceUnlock:
+		return "force-unlock LOCK_ID"
+	case Confirm:
+		return "confirm NONCE"
 	default:
 		return c.String()
 	}
@@ -105,6 +138,10 @@ func (c Name) CommandArgCount(subCommand string) (*ArgCount, error) {
 	switch c {
 	case Import:
 		return &ArgCount{2, 2}, nil // "atlantis import ADDRESS ID"
+	case ForceUnlock:
+		return &ArgCount{1, 1}, nil // "atlantis force-unlock LOCK_ID"
+	case Confirm:
+		return &ArgCount{1, 1}, nil // "atlantis confirm NONCE"
 	case State:
 		if subCommand == "rm" {
 			return &ArgCount{1, -1}, nil // "atlantis state rm ADDRESS..."
@@ -149,6 +186,16 @@ func ParseCommandName(name string) (Name, error) {
 		return Import, nil
 	case "state":
 		return State, nil
+	case "force-unlock":
+		return ForceUnlock, nil
+	case "cancel":
+		return Cancel, nil
+	case "workspaces":
+		return Workspaces, nil
+	case "status":
+		return Status, nil
+	case "confirm":
+		return Confirm, nil
 	}
 	return -1, fmt.Errorf("unknown command name: %s", name)
 }
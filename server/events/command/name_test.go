@@ -41,6 +41,11 @@ func TestName_String(t *testing.T) {
 		{command.Version, "version"},
 		{command.Import, "import"},
 		{command.State, "state"},
+		{command.ForceUnlock, "force-unlock"},
+		{command.Cancel, "cancel"},
+		{command.Workspaces, "workspaces"},
+		{command.Status, "status"},
+		{command.Confirm, "confirm"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.want, func(t *testing.T) {
@@ -64,6 +69,8 @@ func TestName_DefaultUsage(t *testing.T) {
 		{command.Version, "version"},
 		{command.Import, "import ADDRESS ID"},
 		{command.State, "state [rm ADDRESS...]"},
+		{command.ForceUnlock, "force-unlock LOCK_ID"},
+		{command.Confirm, "confirm NONCE"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.c.String(), func(t *testing.T) {
@@ -113,6 +120,8 @@ func TestName_CommandArgCount(t *testing.T) {
 		{c: command.Import, want: &command.ArgCount{Min: 2, Max: 2}},
 		{c: command.State, subCommand: "rm", want: &command.ArgCount{Min: 1, Max: -1}},
 		{c: command.State, subCommand: "unknown", wantErr: true},
+		{c: command.ForceUnlock, want: &command.ArgCount{Min: 1, Max: 1}},
+		{c: command.Confirm, want: &command.ArgCount{Min: 1, Max: 1}},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s %s", tt.c, tt.subCommand), func(t *testing.T) {
@@ -179,6 +188,11 @@ func TestParseCommandName(t *testing.T) {
 		{command.Version, "version"},
 		{command.Import, "import"},
 		{command.State, "state"},
+		{command.ForceUnlock, "force-unlock"},
+		{command.Cancel, "cancel"},
+		{command.Workspaces, "workspaces"},
+		{command.Status, "status"},
+		{command.Confirm, "confirm"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -46,13 +47,25 @@ type ProjectContext struct {
 	ParallelPolicyCheckEnabled bool
 	// AutoplanEnabled is true if autoplanning is enabled for this project.
 	AutoplanEnabled bool
+	// AutoplanMode selects the pull request events that autoplan this
+	// project. It's empty if the project uses the default mode.
+	AutoplanMode string
+	// AutoplanLabels are the labels that autoplan this project if
+	// AutoplanMode is labeled.
+	AutoplanLabels []string
 	// BaseRepo is the repository that the pull request will be merged into.
 	BaseRepo models.Repo
+	// AutoVarFiles configures the var file that is automatically passed to
+	// plan if it exists.
+	AutoVarFiles valid.AutoVarFiles
 	// EscapedCommentArgs are the extra arguments that were added to the atlantis
 	// command, ex. atlantis plan -- -target=resource. We then escape them
 	// by adding a \ before each character so that they can be used within
 	// sh -c safely, i.e. sh -c "terraform plan $(touch bad)".
 	EscapedCommentArgs []string
+	// CommentArgs are the unescaped extra arguments that were added to the
+	// atlantis command.
+	CommentArgs []string
 	// HeadRepo is the repository that is getting merged into the BaseRepo.
 	// If the pull request branch is from the same repository then HeadRepo will
 	// be the same as BaseRepo.
@@ -93,6 +106,35 @@ type ProjectContext struct {
 	// Steps are the sequence of commands we need to run for this project and this
 	// stage.
 	Steps []valid.Step
+	// Timeout, if set, is how long all the Steps can run for before they're
+	// stopped.
+	Timeout time.Duration
+	// Deadline, if set, is when the commands run for the current step are
+	// stopped. It's set by the project command runner from Timeout and the
+	// step's own timeout.
+	Deadline time.Time
+	// Cancellation, if set, is canceled when a user cancels the command.
+	// The commands run for the current step are then stopped.
+	Cancellation *Cancellation
+	// ResourceLimits limits the resources of the commands run for the Steps.
+	ResourceLimits valid.ResourceLimits
+	// CgroupParent is the cgroup directory under which the commands run for
+	// the Steps are placed in their own cgroup to enforce ResourceLimits.
+	// It's set by the project command runner.
+	CgroupParent string
+	// CloudCredentials, if set, are minted by the project command runner and
+	// passed to the Steps as environment variables.
+	CloudCredentials *valid.CloudCredentials
+	// OutdatedDepsDisabled is true if the repo disabled the outdated
+	// dependencies report of its plans.
+	OutdatedDepsDisabled bool
+	// PullLabels are the labels of the pull request. They're set by the
+	// project command runner when the Steps include custom run steps.
+	PullLabels []string
+	// ModifiedFiles are the files in the project's directory modified by the
+	// pull request, relative to the repo root. They're set by the project
+	// command runner when the Steps include custom run steps.
+	ModifiedFiles []string
 	// TerraformDistribution is the distribution of terraform we should use when
 	// executing commands for this project. This can be set to nil in which case
 	// we will use the default Atlantis terraform distribution.
@@ -101,6 +143,9 @@ type ProjectContext struct {
 	// commands for this project. This can be set to nil in which case we will
 	// use the default Atlantis terraform version.
 	TerraformVersion *version.Version
+	// Engine runs the project's commands, ex. pulumi. If empty, it's
+	// terraform.
+	Engine string
 	// Configuration metadata for a given project.
 	User models.User
 	// Verbose is true when the user would like verbose output.
@@ -115,6 +160,26 @@ type ProjectContext struct {
 	PolicySetTarget string
 	// ClearPolicyApproval determines whether policy counts will be incremented or cleared.
 	ClearPolicyApproval bool
+	// Environment is the environment of the project this command is for. It's
+	// empty unless the project defines environments.
+	Environment string
+	// WorkspaceQualified is true if the project's name is shared by projects
+	// in other workspaces, so it's targeted with its name and workspace.
+	WorkspaceQualified bool
+	// VarFiles are the var files of Environment, relative to the project
+	// directory.
+	VarFiles []string
+	// ShowOutputs are the names of the terraform outputs to show in the
+	// comment after a successful apply.
+	ShowOutputs []string
+	// RestrictedPlanFlags maps the plan flags restricted by the server-side
+	// repo config to how they're restricted.
+	RestrictedPlanFlags map[string]string
+	// PRCommentModes maps the commands configured in the server-side repo
+	// config's pr_comments to when their results are commented.
+	PRCommentModes map[string]string
+	// PolicyApprovalReason is the justification recorded with policy approvals.
+	PolicyApprovalReason string
 	// DeleteSourceBranchOnMerge will attempt to allow a branch to be deleted when merged (AzureDevOps & GitLab Support Only)
 	DeleteSourceBranchOnMerge bool
 	// Repo locks mode: disabled, on plan or on apply
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"time"
+
 	"github.com/runatlantis/atlantis/server/events/models"
 )
 
@@ -18,12 +20,32 @@ type ProjectResult struct {
 	VersionSuccess     string
 	ImportSuccess      *models.ImportSuccess
 	StateRmSuccess     *models.StateRmSuccess
+	ForceUnlockSuccess *models.ForceUnlockSuccess
+	WorkspacesSuccess  *models.WorkspacesSuccess
 	ProjectName        string
 	SilencePRComments  []string
+	// PRCommentMode is the pr_comments mode of the command for this project,
+	// or empty if it isn't configured.
+	PRCommentMode string
+	// ApplyOutputs are the terraform outputs shown after a successful apply.
+	ApplyOutputs []models.TerraformOutput
+	// Canceled is true if a user canceled the command. Failure then says
+	// who canceled it.
+	Canceled bool
+	// User is the username of who ran the command.
+	User string
+	// Trigger is how the command was started, see models.CommandRun.Trigger.
+	Trigger string
+	// CommentID is the ID of the comment the command was run from, or 0 if
+	// it wasn't.
+	CommentID int64
 }
 
 // CommitStatus returns the vcs commit status of this project result.
 func (p ProjectResult) CommitStatus() models.CommitStatus {
+	if p.Canceled {
+		return models.CanceledCommitStatus
+	}
 	if p.Error != nil {
 		return models.FailedCommitStatus
 	}
@@ -39,9 +61,10 @@ func (p ProjectResult) PolicyStatus() []models.PolicySetStatus {
 	if p.PolicyCheckResults != nil {
 		for _, policySet := range p.PolicyCheckResults.PolicySetResults {
 			policyStatus := models.PolicySetStatus{
-				PolicySetName: policySet.PolicySetName,
-				Passed:        policySet.Passed,
-				Approvals:     policySet.CurApprovals,
+				PolicySetName:  policySet.PolicySetName,
+				Passed:         policySet.Passed,
+				Approvals:      policySet.CurApprovals,
+				Justifications: policySet.Justifications,
 			}
 			policyStatuses = append(policyStatuses, policyStatus)
 		}
@@ -49,6 +72,47 @@ func (p ProjectResult) PolicyStatus() []models.PolicySetStatus {
 	return policyStatuses
 }
 
+// CommandRun returns the record of this command having run on pull at t,
+// which is kept in the pull request's history.
+func (p ProjectResult) CommandRun(pull models.PullRequest, t time.Time) models.CommandRun {
+	return models.CommandRun{
+		Command:     p.Command.String(),
+		ProjectName: p.ProjectName,
+		RepoRelDir:  p.RepoRelDir,
+		Workspace:   p.Workspace,
+		User:        p.User,
+		HeadCommit:  pull.HeadCommit,
+		Status:      p.PlanStatus(),
+		Time:        t,
+		Trigger:     p.Trigger,
+		CommentID:   p.CommentID,
+	}
+}
+
+// ProjectApply returns the record of a successful apply of this project on
+// pull at t, and false if this result isn't one.
+func (p ProjectResult) ProjectApply(pull models.PullRequest, t time.Time) (models.ProjectApply, bool) {
+	if p.Command != Apply || p.PlanStatus() != models.AppliedPlanStatus {
+		return models.ProjectApply{}, false
+	}
+	return models.ProjectApply{
+		RepoFullName: pull.BaseRepo.FullName,
+		ProjectName:  p.ProjectName,
+		RepoRelDir:   p.RepoRelDir,
+		Workspace:    p.Workspace,
+		PullNum:      pull.Num,
+		Time:         t,
+	}, true
+}
+
+// PlannedResources returns the resource changes of a successful plan.
+func (p ProjectResult) PlannedResources() []models.ResourceChange {
+	if p.PlanSuccess == nil {
+		return nil
+	}
+	return p.PlanSuccess.ResourceChanges()
+}
+
 // PlanStatus returns the plan status.
 func (p ProjectResult) PlanStatus() models.ProjectPlanStatus {
 	switch p.Command {
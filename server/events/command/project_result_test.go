@@ -3,6 +3,7 @@ package command_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -88,6 +89,16 @@ func TestProjectResult_PlanStatus(t *testing.T) {
 			},
 			expStatus: models.PlannedNoChangesPlanStatus,
 		},
+		{
+			p: command.ProjectResult{
+				Command: command.Plan,
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "All manifests are valid",
+					CheckOnly:       true,
+				},
+			},
+			expStatus: models.PlannedNoChangesPlanStatus,
+		},
 		{
 			p: command.ProjectResult{
 				Command: command.Apply,
@@ -298,3 +309,32 @@ func BenchmarkPlanSuccess_Summary(b *testing.B) {
 		})
 	}
 }
+
+func TestProjectResult_CommandRun(t *testing.T) {
+	runTime := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	ctx := &command.Context{Trigger: command.CommentTrigger, CommentID: 42}
+	result := command.ProjectResult{
+		Command:     command.Apply,
+		RepoRelDir:  "dir",
+		Workspace:   "default",
+		ProjectName: "project",
+		User:        "jdoe",
+		Trigger:     ctx.CommandTrigger(),
+		CommentID:   ctx.CommentID,
+	}
+	Equals(t, models.CommandRun{
+		Command:     "apply",
+		ProjectName: "project",
+		RepoRelDir:  "dir",
+		Workspace:   "default",
+		User:        "jdoe",
+		HeadCommit:  "sha",
+		Status:      models.AppliedPlanStatus,
+		Time:        runTime,
+		Trigger:     models.CommentCommandTrigger,
+		CommentID:   42,
+	}, result.CommandRun(models.PullRequest{HeadCommit: "sha"}, runTime))
+
+	Equals(t, models.AutoCommandTrigger, (&command.Context{Trigger: command.AutoTrigger}).CommandTrigger())
+	Equals(t, models.APICommandTrigger, (&command.Context{API: true}).CommandTrigger())
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// CommandRateLimitWindow is the window comment commands are counted over.
+const CommandRateLimitWindow = time.Minute
+
+// CommandRateLimiter limits how often comment commands can be run to protect
+// the server from comment loops and abuse.
+type CommandRateLimiter interface {
+	// Allow records that user commented a command on the pull request and
+	// returns nil if it can run, or why it can't.
+	Allow(repo models.Repo, pullNum int, user models.User) *RateLimitExceeded
+}
+
+// RateLimitExceeded describes a command rejected by a CommandRateLimiter.
+type RateLimitExceeded struct {
+	// Limit is the number of commands allowed per CommandRateLimitWindow.
+	Limit int
+	// PerUser is true if the user's limit was exceeded, otherwise the pull
+	// request's limit was.
+	PerUser bool
+	// RetryAfter is how long until another command is allowed.
+	RetryAfter time.Duration
+	// FirstRejection is true for the first command rejected since the limit
+	// was last exceeded. Only the first rejection is commented on so that a
+	// comment loop doesn't keep going with our comments.
+	FirstRejection bool
+}
+
+// Comment is the comment explaining the rejection.
+func (r *RateLimitExceeded) Comment() string {
+	scope := "on this pull request"
+	if r.PerUser {
+		scope = "by you"
+	}
+	retryAfter := r.RetryAfter.Round(time.Second)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return fmt.Sprintf("**Rate limit exceeded**: at most %d Atlantis commands can be run per minute %s. Please wait %s before trying again. Further commands will be ignored until then.",
+		r.Limit, scope, retryAfter)
+}
+
+// DefaultCommandRateLimiter counts the commands in a sliding window kept in
+// memory, so the limits apply per Atlantis server.
+type DefaultCommandRateLimiter struct {
+	// PerUser is the number of commands a user can run per window across
+	// all pull requests. 0 disables the limit.
+	PerUser int
+	// PerPull is the number of commands that can be run per window on a pull
+	// request. 0 disables the limit.
+	PerPull int
+
+	// now is replaced in tests.
+	now       func() time.Time
+	mu        sync.Mutex
+	commands  map[string][]time.Time
+	rejected  map[string]bool
+	lastSweep time.Time
+}
+
+// NewDefaultCommandRateLimiter creates a DefaultCommandRateLimiter.
+func NewDefaultCommandRateLimiter(perUser int, perPull int) *DefaultCommandRateLimiter {
+	return &DefaultCommandRateLimiter{
+		PerUser:  perUser,
+		PerPull:  perPull,
+		now:      time.Now,
+		commands: make(map[string][]time.Time),
+		rejected: make(map[string]bool),
+	}
+}
+
+func (l *DefaultCommandRateLimiter) Allow(repo models.Repo, pullNum int, user models.User) *RateLimitExceeded {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	userKey := fmt.Sprintf("user/%s/%s", repo.VCSHost.Hostname, user.Username)
+	pullKey := fmt.Sprintf("pull/%s/%s#%d", repo.VCSHost.Hostname, repo.FullName, pullNum)
+	if exceeded := l.exceeded(userKey, l.PerUser, now); exceeded != nil {
+		exceeded.PerUser = true
+		return exceeded
+	}
+	if exceeded := l.exceeded(pullKey, l.PerPull, now); exceeded != nil {
+		return exceeded
+	}
+	// Rejected commands aren't counted so that a loop that keeps commenting
+	// doesn't stay rate limited after it's stopped.
+	if l.PerUser > 0 {
+		l.commands[userKey] = append(l.commands[userKey], now)
+		delete(l.rejected, userKey)
+	}
+	if l.PerPull > 0 {
+		l.commands[pullKey] = append(l.commands[pullKey], now)
+		delete(l.rejected, pullKey)
+	}
+	return nil
+}
+
+func (l *DefaultCommandRateLimiter) exceeded(key string, limit int, now time.Time) *RateLimitExceeded {
+	if limit <= 0 {
+		return nil
+	}
+	times := l.inWindow(key, now)
+	if len(times) < limit {
+		return nil
+	}
+	first := !l.rejected[key]
+	l.rejected[key] = true
+	return &RateLimitExceeded{
+		Limit:          limit,
+		RetryAfter:     times[len(times)-limit].Add(CommandRateLimitWindow).Sub(now),
+		FirstRejection: first,
+	}
+}
+
+// inWindow drops the commands for key that are outside the window and
+// returns the rest.
+func (l *DefaultCommandRateLimiter) inWindow(key string, now time.Time) []time.Time {
+	times := l.commands[key]
+	i := 0
+	for i < len(times) && !times[i].After(now.Add(-CommandRateLimitWindow)) {
+		i++
+	}
+	times = times[i:]
+	if len(times) == 0 {
+		delete(l.commands, key)
+		return nil
+	}
+	l.commands[key] = times
+	return times
+}
+
+// sweep forgets users and pull requests that haven't run commands in the
+// window so the limiter doesn't grow forever.
+func (l *DefaultCommandRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < CommandRateLimitWindow {
+		return
+	}
+	l.lastSweep = now
+	for key := range l.commands {
+		l.inWindow(key, now)
+	}
+	for key := range l.rejected {
+		if _, ok := l.commands[key]; !ok {
+			delete(l.rejected, key)
+		}
+	}
+}
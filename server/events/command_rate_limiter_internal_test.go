@@ -0,0 +1,58 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDefaultCommandRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewDefaultCommandRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	alice := models.User{Username: "alice"}
+	bob := models.User{Username: "bob"}
+
+	Assert(t, limiter.Allow(repo, 1, alice) == nil, "exp first command to be allowed")
+	now = now.Add(10 * time.Second)
+	Assert(t, limiter.Allow(repo, 2, alice) == nil, "exp second command on another pull to be allowed")
+
+	// Alice's limit applies across pull requests.
+	now = now.Add(10 * time.Second)
+	Equals(t, &RateLimitExceeded{Limit: 2, PerUser: true, RetryAfter: 40 * time.Second, FirstRejection: true}, limiter.Allow(repo, 3, alice))
+	Equals(t, &RateLimitExceeded{Limit: 2, PerUser: true, RetryAfter: 40 * time.Second}, limiter.Allow(repo, 3, alice))
+
+	// The pull request limit applies across users.
+	Assert(t, limiter.Allow(repo, 1, bob) == nil, "exp bob to be allowed")
+	Assert(t, limiter.Allow(repo, 1, models.User{Username: "carol"}) == nil, "exp carol to be allowed")
+	Equals(t, &RateLimitExceeded{Limit: 3, RetryAfter: 40 * time.Second, FirstRejection: true}, limiter.Allow(repo, 1, bob))
+
+	// Alice's first command leaves the window.
+	now = now.Add(40 * time.Second)
+	Assert(t, limiter.Allow(repo, 3, alice) == nil, "exp alice to be allowed after the window")
+	Equals(t, &RateLimitExceeded{Limit: 2, PerUser: true, RetryAfter: 10 * time.Second, FirstRejection: true}, limiter.Allow(repo, 3, alice))
+}
+
+func TestDefaultCommandRateLimiter_Sweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewDefaultCommandRateLimiter(1, 0)
+	limiter.now = func() time.Time { return now }
+	repo := models.Repo{FullName: "owner/repo"}
+
+	Assert(t, limiter.Allow(repo, 1, models.User{Username: "alice"}) == nil, "exp alice to be allowed")
+	Assert(t, limiter.Allow(repo, 1, models.User{Username: "alice"}) != nil, "exp alice to be rate limited")
+	now = now.Add(2 * time.Minute)
+	Assert(t, limiter.Allow(repo, 1, models.User{Username: "bob"}) == nil, "exp bob to be allowed")
+	Equals(t, 1, len(limiter.commands))
+	Equals(t, 0, len(limiter.rejected))
+}
+
+func TestRateLimitExceeded_Comment(t *testing.T) {
+	Equals(t, "**Rate limit exceeded**: at most 5 Atlantis commands can be run per minute by you. Please wait 13s before trying again. Further commands will be ignored until then.",
+		(&RateLimitExceeded{Limit: 5, PerUser: true, RetryAfter: 12600 * time.Millisecond}).Comment())
+	Equals(t, "**Rate limit exceeded**: at most 10 Atlantis commands can be run per minute on this pull request. Please wait 1s before trying again. Further commands will be ignored until then.",
+		(&RateLimitExceeded{Limit: 10, RetryAfter: time.Millisecond}).Comment())
+}
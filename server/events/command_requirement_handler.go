@@ -1,12 +1,16 @@
 package events
 
 import (
+	"errors"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 )
 
 //go:generate pegomock generate --package mocks -o mocks/mock_command_requirement_handler.go CommandRequirementHandler
@@ -19,6 +23,8 @@ type CommandRequirementHandler interface {
 
 type DefaultCommandRequirementHandler struct {
 	WorkingDir WorkingDir
+	// VCSClient checks the codeowners_approved apply requirement.
+	VCSClient vcs.Client
 }
 
 func (a *DefaultCommandRequirementHandler) ValidatePlanProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
@@ -30,7 +36,7 @@ func (a *DefaultCommandRequirementHandler) ValidatePlanProject(repoDir string, c
 			}
 		case raw.MergeableRequirement:
 			if !ctx.PullReqStatus.Mergeable {
-				return "Pull request must be mergeable before running plan.", nil
+				return mergeableFailure("plan", ctx.PullReqStatus), nil
 			}
 		case raw.UnDivergedRequirement:
 			if a.WorkingDir.HasDiverged(ctx.Log, repoDir) {
@@ -38,10 +44,40 @@ func (a *DefaultCommandRequirementHandler) ValidatePlanProject(repoDir string, c
 			}
 		}
 	}
+	for _, flag := range valid.RestrictablePlanFlags {
+		restriction, ok := ctx.RestrictedPlanFlags[flag]
+		if !ok || !hasFlag(ctx.CommentArgs, flag) {
+			continue
+		}
+		switch restriction {
+		case valid.DenyPlanFlag:
+			return fmt.Sprintf("Running plan with %s is not allowed in this repository.", flag), nil
+		case valid.ApprovedPlanFlag:
+			if !ctx.PullReqStatus.ApprovalStatus.IsApproved {
+				return fmt.Sprintf("Pull request must be approved according to the project's approval rules before running plan with %s.", flag), nil
+			}
+		}
+	}
 	// Passed all plan requirements configured.
 	return "", nil
 }
 
+// hasFlag returns true if args sets flag, as -flag, --flag or with a value
+// like -flag=value.
+func hasFlag(args []string, flag string) bool {
+	name := strings.TrimLeft(flag, "-")
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg = strings.TrimLeft(arg, "-")
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *DefaultCommandRequirementHandler) ValidateApplyProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
 	for _, req := range ctx.ApplyRequirements {
 		switch req {
@@ -57,12 +93,16 @@ func (a *DefaultCommandRequirementHandler) ValidateApplyProject(repoDir string,
 			}
 		case raw.MergeableRequirement:
 			if !ctx.PullReqStatus.Mergeable {
-				return "Pull request must be mergeable before running apply.", nil
+				return mergeableFailure("apply", ctx.PullReqStatus), nil
 			}
 		case raw.UnDivergedRequirement:
 			if a.WorkingDir.HasDiverged(ctx.Log, repoDir) {
 				return "Default branch must be rebased onto pull request before running apply.", nil
 			}
+		case raw.CodeOwnersApprovedRequirement:
+			if failure, err := a.codeOwnersFailure(ctx); failure != "" || err != nil {
+				return failure, err
+			}
 		}
 	}
 	// Passed all apply requirements configured.
@@ -92,7 +132,7 @@ func (a *DefaultCommandRequirementHandler) ValidateImportProject(repoDir string,
 			}
 		case raw.MergeableRequirement:
 			if !ctx.PullReqStatus.Mergeable {
-				return "Pull request must be mergeable before running import.", nil
+				return mergeableFailure("import", ctx.PullReqStatus), nil
 			}
 		case raw.UnDivergedRequirement:
 			if a.WorkingDir.HasDiverged(ctx.Log, repoDir) {
@@ -103,3 +143,48 @@ func (a *DefaultCommandRequirementHandler) ValidateImportProject(repoDir string,
 	// Passed all import requirements configured.
 	return "", nil
 }
+
+// codeOwnersFailure returns why the owners of the files the project modifies
+// haven't approved its pull request, or an empty string if they have.
+func (a *DefaultCommandRequirementHandler) codeOwnersFailure(ctx command.ProjectContext) (string, error) {
+	unsupported := fmt.Sprintf("The %s apply requirement isn't supported on %s.", raw.CodeOwnersApprovedRequirement, ctx.BaseRepo.VCSHost.Type.String())
+	checker, ok := a.VCSClient.(vcs.CodeOwnersChecker)
+	if !ok {
+		return unsupported, nil
+	}
+	modifiedFiles, err := a.VCSClient.GetModifiedFiles(ctx.Log, ctx.BaseRepo, ctx.Pull)
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	dir := path.Clean(ctx.RepoRelDir)
+	for _, file := range modifiedFiles {
+		if dir == "." || strings.HasPrefix(file, dir+"/") {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	unapproved, err := checker.UnapprovedFiles(ctx.Log, ctx.BaseRepo, ctx.Pull, files)
+	if errors.Is(err, vcs.ErrCodeOwnersUnsupported) {
+		return unsupported, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(unapproved) > 0 {
+		return fmt.Sprintf("The owners of these files must approve the pull request before running apply: %s.", strings.Join(unapproved, ", ")), nil
+	}
+	return "", nil
+}
+
+// mergeableFailure returns the failure for a pull request that isn't
+// mergeable, listing what blocks it if the VCS host told us.
+func mergeableFailure(cmd string, status models.PullReqStatus) string {
+	failure := fmt.Sprintf("Pull request must be mergeable before running %s.", cmd)
+	if len(status.MergeBlockers) > 0 {
+		failure += fmt.Sprintf(" Blocked by: %s.", strings.Join(status.MergeBlockers, ", "))
+	}
+	return failure
+}
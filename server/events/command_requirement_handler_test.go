@@ -2,6 +2,7 @@ package events_test
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 
 	. "github.com/petergtz/pegomock/v4"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/mocks"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -82,6 +84,43 @@ func TestAggregateApplyRequirements_ValidatePlanProject(t *testing.T) {
 			wantFailure: "Default branch must be rebased onto pull request before running plan.",
 			wantErr:     assert.NoError,
 		},
+		{
+			name: "fail by denied plan flag",
+			ctx: command.ProjectContext{
+				CommentArgs:         []string{"-target=aws_instance.web"},
+				RestrictedPlanFlags: map[string]string{"-target": valid.DenyPlanFlag},
+			},
+			wantFailure: "Running plan with -target is not allowed in this repository.",
+			wantErr:     assert.NoError,
+		},
+		{
+			name: "fail by plan flag that requires approval",
+			ctx: command.ProjectContext{
+				CommentArgs:         []string{"--destroy"},
+				RestrictedPlanFlags: map[string]string{"-destroy": valid.ApprovedPlanFlag},
+			},
+			wantFailure: "Pull request must be approved according to the project's approval rules before running plan with -destroy.",
+			wantErr:     assert.NoError,
+		},
+		{
+			name: "pass approved plan flag",
+			ctx: command.ProjectContext{
+				CommentArgs:         []string{"-replace", "aws_instance.web"},
+				RestrictedPlanFlags: map[string]string{"-replace": valid.ApprovedPlanFlag},
+				PullReqStatus: models.PullReqStatus{
+					ApprovalStatus: models.ApprovalStatus{IsApproved: true},
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "pass unrestricted plan flag",
+			ctx: command.ProjectContext{
+				CommentArgs:         []string{"-target=aws_instance.web", "-var", "destroy=true"},
+				RestrictedPlanFlags: map[string]string{"-destroy": valid.DenyPlanFlag},
+			},
+			wantErr: assert.NoError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -179,6 +218,18 @@ func TestAggregateApplyRequirements_ValidateApplyProject(t *testing.T) {
 			wantFailure: "Pull request must be mergeable before running apply.",
 			wantErr:     assert.NoError,
 		},
+		{
+			name: "fail by no mergeable with blockers",
+			ctx: command.ProjectContext{
+				ApplyRequirements: []string{raw.MergeableRequirement},
+				PullReqStatus: models.PullReqStatus{
+					Mergeable:     false,
+					MergeBlockers: []string{`policy "Minimum number of reviewers" is rejected`, `policy "Build: ci" is queued`},
+				},
+			},
+			wantFailure: `Pull request must be mergeable before running apply. Blocked by: policy "Minimum number of reviewers" is rejected, policy "Build: ci" is queued.`,
+			wantErr:     assert.NoError,
+		},
 		{
 			name: "fail by diverged",
 			ctx: command.ProjectContext{
@@ -416,3 +467,50 @@ func TestAggregateApplyRequirements_ValidateImportProject(t *testing.T) {
 		})
 	}
 }
+
+// codeOwnersClient is a VCS client where only the files in unapproved aren't
+// approved by their owners.
+type codeOwnersClient struct {
+	*vcsmocks.MockClient
+	unapproved []string
+	checked    []string
+}
+
+func (c *codeOwnersClient) UnapprovedFiles(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, files []string) ([]string, error) {
+	c.checked = files
+	var unapproved []string
+	for _, file := range files {
+		if slices.Contains(c.unapproved, file) {
+			unapproved = append(unapproved, file)
+		}
+	}
+	return unapproved, nil
+}
+
+func TestAggregateApplyRequirements_ValidateApplyProjectCodeOwners(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := &codeOwnersClient{MockClient: vcsmocks.NewMockClient()}
+	When(client.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).
+		ThenReturn([]string{"README.md", "env/prod/main.tf", "env/prod/vars.tf", "env/production/main.tf"}, nil)
+	handler := &events.DefaultCommandRequirementHandler{VCSClient: client}
+	ctx := command.ProjectContext{
+		ApplyRequirements: []string{raw.CodeOwnersApprovedRequirement},
+		RepoRelDir:        "env/prod",
+		Log:               logging.NewNoopLogger(t),
+	}
+
+	failure, err := handler.ValidateApplyProject("repoDir", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "", failure)
+	assert.Equal(t, []string{"env/prod/main.tf", "env/prod/vars.tf"}, client.checked)
+
+	client.unapproved = []string{"env/prod/vars.tf", "README.md"}
+	failure, err = handler.ValidateApplyProject("repoDir", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "The owners of these files must approve the pull request before running apply: env/prod/vars.tf.", failure)
+
+	ctx.BaseRepo.VCSHost.Type = models.Gitlab
+	failure, err = (&events.DefaultCommandRequirementHandler{VCSClient: vcsmocks.NewMockClient()}).ValidateApplyProject("repoDir", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "The codeowners_approved apply requirement isn't supported on Gitlab.", failure)
+}
@@ -16,12 +16,14 @@ package events
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/drmaxgit/go-azuredevops/azuredevops"
 	"github.com/google/go-github/v71/github"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/messages"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
@@ -34,6 +36,8 @@ import (
 )
 
 const (
+	// ShutdownComment is the default comment made when a command is run while
+	// the server is shutting down.
 	ShutdownComment = "Atlantis server is shutting down, please try again later."
 )
 
@@ -45,7 +49,12 @@ type CommandRunner interface {
 	// It handles gathering additional information needed to execute the command
 	// and then calling the appropriate services to finish executing the command.
 	RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand)
-	RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User)
+	// RunAutoplanCommand plans the projects whose autoplan mode is triggered
+	// by trigger.
+	RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, trigger models.AutoplanTrigger)
+	// RunApplyOnMergeCommand applies the plans of a pull request after it has
+	// been merged.
+	RunApplyOnMergeCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User)
 }
 
 //go:generate pegomock generate github.com/runatlantis/atlantis/server/events --package mocks -o mocks/mock_github_pull_getter.go GithubPullGetter
@@ -92,6 +101,11 @@ func buildCommentCommandRunner(
 	return runner
 }
 
+// TeamNamesFetcher looks up the teams of users. vcs.Client implements it.
+type TeamNamesFetcher interface {
+	GetTeamNamesForUser(logger logging.SimpleLogging, repo models.Repo, user models.User) ([]string, error)
+}
+
 // DefaultCommandRunner is the first step when processing a comment command.
 type DefaultCommandRunner struct {
 	VCSClient                vcs.Client `validate:"required"`
@@ -102,7 +116,10 @@ type DefaultCommandRunner struct {
 	// User config option: Disables autoplan when a pull request is opened or updated.
 	DisableAutoplan      bool
 	DisableAutoplanLabel string
-	EventParser          EventParsing
+	// User config option: autoplans draft pull requests. Repos can override
+	// it with plan_drafts in the server-side repo config.
+	AllowDraftPRs bool
+	EventParser   EventParsing
 	// User config option: Fail and do not run the Atlantis command request if any of the pre workflow hooks error
 	FailOnPreWorkflowHookError bool
 	Logger                     logging.SimpleLogging `validate:"required"`
@@ -117,6 +134,17 @@ type DefaultCommandRunner struct {
 	// this in our error message back to the user on a forked PR so they know
 	// how to enable this functionality.
 	AllowForkPRsFlag string
+	// User config option: allows commands on pull requests from forks but
+	// restricts them to plans that run the fork PR workflow, unless the
+	// author is in ForkPRAllowlist.
+	RestrictForkPRs bool
+	// ForkPRAllowlist are the usernames whose fork pull requests aren't
+	// restricted by RestrictForkPRs.
+	ForkPRAllowlist []string
+	// ForkPRAllowlistFlag is the name of the flag that allowlists fork PR
+	// authors. We use this in our error message back to the user on a
+	// restricted fork PR so they know how to lift the restriction.
+	ForkPRAllowlistFlag string
 	// User config option: controls whether to comment on Fork PRs when AllowForkPRs = False
 	SilenceForkPRErrors bool
 	// SilenceForkPRErrorsFlag is the name of the flag that controls fork PR's. We use
@@ -131,12 +159,33 @@ type DefaultCommandRunner struct {
 	TeamAllowlistChecker           command.TeamAllowlistChecker          `validate:"required"`
 	VarFileAllowlistChecker        *VarFileAllowlistChecker              `validate:"required"`
 	CommitStatusUpdater            CommitStatusUpdater                   `validate:"required"`
+	// ReloadableGlobalCfg, if set, is used instead of GlobalCfg.
+	ReloadableGlobalCfg *valid.ReloadableGlobalCfg
+	// User config option: applies run after pull requests are merged instead
+	// of being run by comments on open pull requests.
+	ApplyOnMerge bool
+	// CommandRateLimiter, if set, limits how often comment commands can be
+	// run.
+	CommandRateLimiter CommandRateLimiter
+	// ApplyConfirmations holds the applies of repos with apply_confirmation
+	// that are waiting to be confirmed.
+	ApplyConfirmations *ApplyConfirmations
+	// WorkingDir, if set, resets the workspaces of pull requests whose branch
+	// was force-pushed before commands run on them.
+	WorkingDir WorkingDir
+	// Messages overrides the text of the comments made when commands can't
+	// be run.
+	Messages *messages.Catalog
+	// TeamNamesFetcher, if set, looks up the teams of users instead of
+	// VCSClient, ex. from the groups synced from an identity provider.
+	TeamNamesFetcher TeamNamesFetcher
 }
 
-// RunAutoplanCommand runs plan and policy_checks when a pull request is opened or updated.
-func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
+// RunAutoplanCommand runs plan and policy_checks when a pull request is opened,
+// updated, marked as ready for review or labeled.
+func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, trigger models.AutoplanTrigger) {
 	if opStarted := c.Drainer.StartOp(); !opStarted {
-		if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pull.Num, ShutdownComment, command.Plan.String()); commentErr != nil {
+		if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pull.Num, c.Messages.Sprintf(messages.CommentShutdown), command.Plan.String()); commentErr != nil {
 			c.Logger.Log(logging.Error, "unable to comment that Atlantis is shutting down: %s", commentErr)
 		}
 		return
@@ -145,6 +194,15 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 
 	log := c.buildLogger(baseRepo.FullName, pull.Num)
 	defer c.logPanics(baseRepo, pull.Num, log)
+
+	// Drafts can still be planned manually with a comment, they're only
+	// skipped for autoplanning. Once a draft is marked as ready for review it's
+	// autoplanned like a newly opened pull request.
+	if pull.Draft && !c.ReloadableGlobalCfg.LoadOr(c.GlobalCfg).RepoPlanDrafts(baseRepo.ID(), c.AllowDraftPRs) {
+		log.Info("not running autoplan because the pull request is a draft")
+		return
+	}
+
 	status, err := c.PullStatusFetcher.GetPullStatus(pull)
 
 	if err != nil {
@@ -156,31 +214,26 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 	defer timer.Stop()
 
 	// Check if the user who triggered the autoplan has permissions to run 'plan'.
-	if c.TeamAllowlistChecker != nil && c.TeamAllowlistChecker.HasRules() {
-		err := c.fetchUserTeams(log, baseRepo, &user)
-		if err != nil {
-			log.Err("Unable to fetch user teams: %s", err)
-			return
-		}
-
-		ok, err := c.checkUserPermissions(baseRepo, user, "plan")
-		if err != nil {
-			log.Err("Unable to check user permissions: %s", err)
-			return
-		}
-		if !ok {
-			return
-		}
+	denyReason, err := c.checkCommandPermissions(log, baseRepo, &user, command.Plan.String())
+	if err != nil {
+		log.Err("Unable to check user permissions: %s", err)
+		return
+	}
+	if denyReason != "" {
+		log.Info("not running autoplan: %s", denyReason)
+		return
 	}
 
 	ctx := &command.Context{
-		User:       user,
-		Log:        log,
-		Scope:      scope,
-		Pull:       pull,
-		HeadRepo:   headRepo,
-		PullStatus: status,
-		Trigger:    command.AutoTrigger,
+		User:            user,
+		Log:             log,
+		Scope:           scope,
+		Pull:            pull,
+		HeadRepo:        headRepo,
+		PullStatus:      status,
+		Trigger:         command.AutoTrigger,
+		ForkRestricted:  c.isForkRestricted(pull, headRepo),
+		AutoplanTrigger: trigger,
 	}
 	if !c.validateCtxAndComment(ctx, command.Autoplan) {
 		return
@@ -203,11 +256,17 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		Name: command.Autoplan,
 	}
 
-	// Update the combined plan commit status to pending
-	if err := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.PendingCommitStatus, command.Plan); err != nil {
-		ctx.Log.Warn("unable to update plan commit status: %s", err)
+	// Update the combined plan commit status to pending. Labeling a pull
+	// request doesn't change its commit, so we leave the status of the
+	// existing plans alone until we know whether any project is planned.
+	if trigger.Event != models.LabeledPullEvent {
+		if err := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.PendingCommitStatus, command.Plan); err != nil {
+			ctx.Log.Warn("unable to update plan commit status: %s", err)
+		}
 	}
 
+	c.resetRewrittenHistory(ctx)
+
 	err = c.PreWorkflowHooksCommandRunner.RunPreHooks(ctx, cmd)
 
 	if err != nil {
@@ -239,15 +298,245 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 	c.PostWorkflowHooksCommandRunner.RunPostHooks(ctx, cmd) // nolint: errcheck
 }
 
-// commentUserDoesNotHavePermissions comments on the pull request that the user
+// resetRewrittenHistory deletes the workspaces and plans of the pull request
+// if its branch was force-pushed since they were made, so that commands don't
+// run on rewritten commits. The comment of the command then notes the reset.
+func (c *DefaultCommandRunner) resetRewrittenHistory(ctx *command.Context) {
+	if c.WorkingDir == nil {
+		return
+	}
+	reset, err := c.WorkingDir.ResetIfHistoryRewritten(ctx.Log, ctx.HeadRepo, ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to reset workspaces after a force-push: %s", err)
+		return
+	}
+	ctx.HistoryRewritten = reset
+}
+
+// RunApplyOnMergeCommand applies the plans of a pull request once it has been
+// merged. The stored plans are only applied if they were made for the commit
+// that was merged, against the base commit it was merged into.
+func (c *DefaultCommandRunner) RunApplyOnMergeCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
+	if opStarted := c.Drainer.StartOp(); !opStarted {
+		if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pull.Num, c.Messages.Sprintf(messages.CommentShutdown), command.Apply.String()); commentErr != nil {
+			c.Logger.Log(logging.Error, "unable to comment that Atlantis is shutting down: %s", commentErr)
+		}
+		return
+	}
+	defer c.Drainer.OpDone()
+
+	log := c.buildLogger(baseRepo.FullName, pull.Num)
+	defer c.logPanics(baseRepo, pull.Num, log)
+
+	status, err := c.PullStatusFetcher.GetPullStatus(pull)
+	if err != nil {
+		log.Err("Unable to fetch pull status, this is likely a bug.", err)
+		return
+	}
+	if status == nil || len(status.Projects) == 0 {
+		log.Info("not applying merged pull request since it has no plans")
+		return
+	}
+	if status.Pull.HeadCommit != pull.HeadCommit {
+		log.Info("not applying merged pull request since it was planned at %s but merged at %s", status.Pull.HeadCommit, pull.HeadCommit)
+		comment := fmt.Sprintf(applyOnMergeStaleComment, pull.HeadCommit, status.Pull.HeadCommit)
+		if err := c.VCSClient.CreateComment(log, baseRepo, pull.Num, comment, command.Apply.String()); err != nil {
+			log.Err("unable to comment: %s", err)
+		}
+		return
+	}
+	if status.Pull.BaseCommit != pull.BaseCommit {
+		log.Info("not applying merged pull request since it was planned against %s but merged into %s", status.Pull.BaseCommit, pull.BaseCommit)
+		comment := fmt.Sprintf(applyOnMergeStaleBaseComment, pull.BaseBranch, pull.BaseCommit, status.Pull.BaseCommit)
+		if err := c.VCSClient.CreateComment(log, baseRepo, pull.Num, comment, command.Apply.String()); err != nil {
+			log.Err("unable to comment: %s", err)
+		}
+		return
+	}
+
+	scope := c.StatsScope.SubScope("apply_on_merge")
+	timer := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer timer.Stop()
+
+	// Check if the user who merged the pull request has permissions to run 'apply'.
+	denyReason, err := c.checkCommandPermissions(log, baseRepo, &user, command.Apply.String())
+	if err != nil {
+		log.Err("Unable to check user permissions: %s", err)
+		return
+	}
+	if denyReason != "" {
+		c.commentUserDoesNotHavePermissions(baseRepo, pull.Num, denyReason)
+		return
+	}
+
+	repo := c.ReloadableGlobalCfg.LoadOr(c.GlobalCfg).MatchingRepo(baseRepo.ID())
+	if !repo.BranchMatches(pull.BaseBranch) {
+		log.Info("not applying merged pull request since it doesn't match base branches")
+		return
+	}
+
+	ctx := &command.Context{
+		User:       user,
+		Log:        log,
+		Scope:      scope,
+		Pull:       pull,
+		HeadRepo:   headRepo,
+		PullStatus: status,
+		Trigger:    command.AutoTrigger,
+	}
+
+	ctx.Log.Info("Running apply on merge...")
+	cmd := &CommentCommand{
+		Name: command.Apply,
+	}
+
+	if err := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.PendingCommitStatus, command.Apply); err != nil {
+		ctx.Log.Warn("unable to update apply commit status: %s", err)
+	}
+
+	err = c.PreWorkflowHooksCommandRunner.RunPreHooks(ctx, cmd)
+	if err != nil {
+		if c.FailOnPreWorkflowHookError {
+			ctx.Log.Err("'fail-on-pre-workflow-hook-error' set, so not running %s command.", command.Apply)
+			if err := c.CommitStatusUpdater.UpdateCombined(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, models.FailedCommitStatus, command.Apply); err != nil {
+				ctx.Log.Warn("Unable to update apply commit status: %s", err)
+			}
+			return
+		}
+
+		ctx.Log.Err("'fail-on-pre-workflow-hook-error' not set so running %s command.", command.Apply)
+	}
+
+	applyRunner := buildCommentCommandRunner(c, command.Apply)
+
+	applyRunner.Run(ctx, cmd)
+
+	c.PostWorkflowHooksCommandRunner.RunPostHooks(ctx, cmd) // nolint: errcheck
+}
+
+// requestApplyConfirmation holds the apply cmd until it's confirmed and
+// comments which plans it will apply and how to confirm it.
+func (c *DefaultCommandRunner) requestApplyConfirmation(ctx *command.Context, cmd *CommentCommand) {
+	nonce, err := c.ApplyConfirmations.Request(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.User.Username, *cmd)
+	if err != nil {
+		ctx.Log.Err("unable to request apply confirmation: %s", err)
+		return
+	}
+	ctx.Log.Info("apply is waiting to be confirmed with nonce %s", nonce)
+
+	var lines []string
+	if ctx.PullStatus != nil {
+		for _, p := range ctx.PullStatus.Projects {
+			if !isAwaitingApply(p.Status) || !cmd.matchesProject(p) {
+				continue
+			}
+			line := fmt.Sprintf("* dir: `%s` workspace: `%s`", p.RepoRelDir, p.Workspace)
+			if p.ProjectName != "" {
+				line = fmt.Sprintf("* project: `%s` dir: `%s` workspace: `%s`", p.ProjectName, p.RepoRelDir, p.Workspace)
+			}
+			if badge := plannedResourcesBadge(p.PlannedResources); badge != "" {
+				line += fmt.Sprintf(" (%s)", badge)
+			}
+			lines = append(lines, line)
+		}
+	}
+	plans := "No unapplied plans match this apply."
+	if len(lines) > 0 {
+		plans = fmt.Sprintf("The following plans will be applied:\n\n%s", strings.Join(lines, "\n"))
+	}
+	comment := fmt.Sprintf(applyConfirmationComment, plans, nonce, c.ApplyConfirmations.TTL)
+	if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, command.Apply.String()); err != nil {
+		ctx.Log.Err("unable to comment on pull request: %s", err)
+	}
+}
+
+// isAwaitingApply returns true if a project with status has a plan that an
+// apply would run.
+func isAwaitingApply(status models.ProjectPlanStatus) bool {
+	switch status {
+	case models.PlannedPlanStatus, models.ErroredApplyStatus, models.PassedPolicyCheckStatus:
+		return true
+	}
+	return false
+}
+
+// plannedResourcesBadge summarizes changes like the badge of plan statuses,
+// ex. "+3 ~1 -0". Replaced resources count as both added and destroyed.
+func plannedResourcesBadge(changes []models.ResourceChange) string {
+	var stats models.PlanSuccessStats
+	for _, change := range changes {
+		switch change.Action {
+		case "create":
+			stats.Add++
+		case "update":
+			stats.Change++
+		case "delete":
+			stats.Destroy++
+		case "replace":
+			stats.Add++
+			stats.Destroy++
+		default:
+			continue
+		}
+		stats.Changes = true
+	}
+	return stats.Badge()
+}
+
+// commentUserDoesNotHavePermissions comments on the pull request why the user
 // is not allowed to execute the command.
-func (c *DefaultCommandRunner) commentUserDoesNotHavePermissions(baseRepo models.Repo, pullNum int, user models.User, cmd *CommentCommand) {
-	errMsg := fmt.Sprintf("```\nError: User @%s does not have permissions to execute '%s' command.\n```", user.Username, cmd.Name.String())
+func (c *DefaultCommandRunner) commentUserDoesNotHavePermissions(baseRepo models.Repo, pullNum int, denyReason string) {
+	errMsg := fmt.Sprintf("```\nError: %s\n```", denyReason)
 	if err := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, errMsg, ""); err != nil {
 		c.Logger.Err("unable to comment on pull request: %s", err)
 	}
 }
 
+// checkCommandPermissions decides whether user may execute cmdName on repo. It
+// applies the team allowlist and the command_permissions of the matching
+// server-side repo config, only fetching the user's teams if one of them needs
+// it. If the user isn't allowed, it returns the reason to post on the pull
+// request.
+func (c *DefaultCommandRunner) checkCommandPermissions(logger logging.SimpleLogging, repo models.Repo, user *models.User, cmdName string) (string, error) {
+	// The team allowlist doesn't apply to confirm, which is checked again as
+	// the apply it confirms, or to help, which only describes what can be run.
+	allowlistEnabled := c.TeamAllowlistChecker != nil && c.TeamAllowlistChecker.HasRules() &&
+		cmdName != command.Confirm.String() && cmdName != command.Help.String()
+	globalCfg := c.ReloadableGlobalCfg.LoadOr(c.GlobalCfg)
+	perm, restricted := globalCfg.CommandPermission(repo.ID(), cmdName)
+
+	if allowlistEnabled || (restricted && perm.HasTeams(globalCfg.Roles)) {
+		if err := c.fetchUserTeams(logger, repo, user); err != nil {
+			return "", fmt.Errorf("fetching user teams: %w", err)
+		}
+	}
+
+	if allowlistEnabled {
+		ok, err := c.checkUserPermissions(repo, *user, cmdName)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return fmt.Sprintf("User @%s does not have permissions to execute '%s' command.", user.Username, cmdName), nil
+		}
+	}
+
+	if restricted && !perm.IsAllowed(user.Username, user.Teams, globalCfg.Roles) {
+		var allowed []string
+		if len(perm.Users) > 0 {
+			allowed = append(allowed, fmt.Sprintf("users [%s]", strings.Join(perm.Users, ", ")))
+		}
+		if len(perm.Teams) > 0 {
+			allowed = append(allowed, fmt.Sprintf("teams [%s]", strings.Join(perm.Teams, ", ")))
+		}
+		if len(perm.Roles) > 0 {
+			allowed = append(allowed, fmt.Sprintf("roles [%s]", strings.Join(perm.Roles, ", ")))
+		}
+		return fmt.Sprintf("User @%s does not have permissions to execute '%s' command on %s. It is restricted to %s.", user.Username, cmdName, repo.FullName, strings.Join(allowed, ", ")), nil
+	}
+	return "", nil
+}
+
 // checkUserPermissions checks if the user has permissions to execute the command
 func (c *DefaultCommandRunner) checkUserPermissions(repo models.Repo, user models.User, cmdName string) (bool, error) {
 	if c.TeamAllowlistChecker == nil || !c.TeamAllowlistChecker.HasRules() {
@@ -286,7 +575,7 @@ func (c *DefaultCommandRunner) checkVarFilesInPlanCommandAllowlisted(cmd *Commen
 // wasteful) call to get the necessary data.
 func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand) {
 	if opStarted := c.Drainer.StartOp(); !opStarted {
-		if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, ShutdownComment, ""); commentErr != nil {
+		if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, c.Messages.Sprintf(messages.CommentShutdown), ""); commentErr != nil {
 			c.Logger.Log(logging.Error, "unable to comment that Atlantis is shutting down: %s", commentErr)
 		}
 		return
@@ -304,23 +593,51 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 	timer := scope.Timer(metrics.ExecutionTimeMetric).Start()
 	defer timer.Stop()
 
-	// Check if the user who commented has the permissions to execute the 'plan' or 'apply' commands
-	if c.TeamAllowlistChecker != nil && c.TeamAllowlistChecker.HasRules() {
-		err := c.fetchUserTeams(log, baseRepo, &user)
-		if err != nil {
-			c.Logger.Err("Unable to fetch user teams: %s", err)
+	if c.CommandRateLimiter != nil {
+		if exceeded := c.CommandRateLimiter.Allow(baseRepo, pullNum, user); exceeded != nil {
+			log.Warn("ignoring command from %s, rate limit of %d commands per minute exceeded", user.Username, exceeded.Limit)
+			scope.Counter(metrics.RateLimitedMetric).Inc(1)
+			if exceeded.FirstRejection {
+				if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, exceeded.Comment(), ""); commentErr != nil {
+					c.Logger.Err("unable to comment on pull request: %s", commentErr)
+				}
+			}
 			return
 		}
+	}
 
-		ok, err := c.checkUserPermissions(baseRepo, user, cmd.Name.String())
+	// A confirmed apply is run like the apply comment it confirms so it goes
+	// through the same checks again.
+	if cmd.Name == command.Confirm {
+		denyReason, err := c.checkCommandPermissions(log, baseRepo, &user, command.Confirm.String())
 		if err != nil {
 			c.Logger.Err("Unable to check user permissions: %s", err)
 			return
 		}
-		if !ok {
-			c.commentUserDoesNotHavePermissions(baseRepo, pullNum, user, cmd)
+		if denyReason != "" {
+			c.commentUserDoesNotHavePermissions(baseRepo, pullNum, denyReason)
 			return
 		}
+		confirmed, err := c.ApplyConfirmations.Confirm(baseRepo.FullName, pullNum, user.Username, cmd.Flags[0])
+		if err != nil {
+			if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, fmt.Sprintf("**Error:** %s.", err), command.Apply.String()); commentErr != nil {
+				c.Logger.Err("unable to comment on pull request: %s", commentErr)
+			}
+			return
+		}
+		cmd = confirmed
+	}
+
+	// Check if the user who commented has the permissions to execute the
+	// command.
+	denyReason, err := c.checkCommandPermissions(log, baseRepo, &user, cmd.Name.String())
+	if err != nil {
+		c.Logger.Err("Unable to check user permissions: %s", err)
+		return
+	}
+	if denyReason != "" {
+		c.commentUserDoesNotHavePermissions(baseRepo, pullNum, denyReason)
+		return
 	}
 
 	// Check if the provided var files in a 'plan' command are allowlisted
@@ -332,6 +649,13 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		return
 	}
 
+	if c.ApplyOnMerge && cmd.Name == command.Apply {
+		if commentErr := c.VCSClient.CreateComment(c.Logger, baseRepo, pullNum, applyOnMergeComment, command.Apply.String()); commentErr != nil {
+			c.Logger.Err("unable to comment on pull request: %s", commentErr)
+		}
+		return
+	}
+
 	headRepo, pull, err := c.ensureValidRepoMetadata(baseRepo, maybeHeadRepo, maybePull, user, pullNum, log)
 	if err != nil {
 		return
@@ -351,15 +675,33 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		HeadRepo:             headRepo,
 		Scope:                scope,
 		Trigger:              command.CommentTrigger,
+		CommentID:            cmd.CommentID,
 		PolicySet:            cmd.PolicySet,
 		ClearPolicyApproval:  cmd.ClearPolicyApproval,
+		PolicyApprovalReason: cmd.ApprovalReason,
 		TeamAllowlistChecker: c.TeamAllowlistChecker,
+		ForkRestricted:       c.isForkRestricted(pull, headRepo),
 	}
 
 	if !c.validateCtxAndComment(ctx, cmd.Name) {
 		return
 	}
 
+	// Cancel doesn't use the repo so it runs without workflow hooks, which
+	// can't run while the command being canceled holds its working dir.
+	// Status only reads what earlier commands recorded and help only reads
+	// the repo config so they don't need them either.
+	if cmd.Name == command.Cancel || cmd.Name == command.Status || cmd.Name == command.Help {
+		buildCommentCommandRunner(c, cmd.CommandName()).Run(ctx, cmd)
+		return
+	}
+
+	if cmd.Name == command.Apply && !cmd.Confirmed && c.ApplyConfirmations != nil &&
+		c.ReloadableGlobalCfg.LoadOr(c.GlobalCfg).RepoApplyConfirmation(baseRepo.ID()) {
+		c.requestApplyConfirmation(ctx, cmd)
+		return
+	}
+
 	// Update the combined plan or apply commit status to pending
 	switch cmd.Name {
 	case command.Plan:
@@ -372,6 +714,8 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		}
 	}
 
+	c.resetRewrittenHistory(ctx)
+
 	err = c.PreWorkflowHooksCommandRunner.RunPreHooks(ctx, cmd)
 
 	if err != nil {
@@ -510,7 +854,11 @@ func (c *DefaultCommandRunner) ensureValidRepoMetadata(
 }
 
 func (c *DefaultCommandRunner) fetchUserTeams(logger logging.SimpleLogging, repo models.Repo, user *models.User) error {
-	teams, err := c.VCSClient.GetTeamNamesForUser(logger, repo, *user)
+	var fetcher TeamNamesFetcher = c.VCSClient
+	if c.TeamNamesFetcher != nil {
+		fetcher = c.TeamNamesFetcher
+	}
+	teams, err := fetcher.GetTeamNamesForUser(logger, repo, *user)
 	if err != nil {
 		return err
 	}
@@ -519,27 +867,63 @@ func (c *DefaultCommandRunner) fetchUserTeams(logger logging.SimpleLogging, repo
 	return nil
 }
 
+// forkRestrictedCommands are the commands that can run on restricted fork
+// pull requests. None of them change infrastructure.
+var forkRestrictedCommands = []command.Name{
+	command.Plan,
+	command.Autoplan,
+	command.PolicyCheck,
+	command.ApprovePolicies,
+	command.Unlock,
+	command.Version,
+	command.Cancel,
+	command.Status,
+	command.Help,
+}
+
+// isForkRestricted returns true if pull comes from a fork and commands on it
+// must be restricted because its author isn't in ForkPRAllowlist.
+func (c *DefaultCommandRunner) isForkRestricted(pull models.PullRequest, headRepo models.Repo) bool {
+	if !c.RestrictForkPRs || headRepo.Owner == pull.BaseRepo.Owner {
+		return false
+	}
+	for _, author := range c.ForkPRAllowlist {
+		if strings.EqualFold(author, pull.Author) {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *DefaultCommandRunner) validateCtxAndComment(ctx *command.Context, commandName command.Name) bool {
-	if !c.AllowForkPRs && ctx.HeadRepo.Owner != ctx.Pull.BaseRepo.Owner {
+	if !c.AllowForkPRs && !c.RestrictForkPRs && ctx.HeadRepo.Owner != ctx.Pull.BaseRepo.Owner {
 		if c.SilenceForkPRErrors {
 			return false
 		}
 		ctx.Log.Info("command was run on a fork pull request which is disallowed")
-		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, fmt.Sprintf("Atlantis commands can't be run on fork pull requests. To enable, set --%s  or, to disable this message, set --%s", c.AllowForkPRsFlag, c.SilenceForkPRErrorsFlag), ""); err != nil {
+		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, c.Messages.Sprintf(messages.CommentForkPRsDisallowed, c.AllowForkPRsFlag, c.SilenceForkPRErrorsFlag), ""); err != nil {
 			ctx.Log.Err("unable to comment: %s", err)
 		}
 		return false
 	}
 
-	if ctx.Pull.State != models.OpenPullState && commandName != command.Unlock {
+	if ctx.ForkRestricted && !utils.SlicesContains(forkRestrictedCommands, commandName) {
+		ctx.Log.Info("command %s was run on a restricted fork pull request", commandName.String())
+		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, c.Messages.Sprintf(messages.CommentForkPRRestricted, commandName.String(), ctx.Pull.Author, c.ForkPRAllowlistFlag), ""); err != nil {
+			ctx.Log.Err("unable to comment: %s", err)
+		}
+		return false
+	}
+
+	if ctx.Pull.State != models.OpenPullState && commandName != command.Unlock && commandName != command.Help {
 		ctx.Log.Info("command was run on closed pull request")
-		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, "Atlantis commands can't be run on closed pull requests", ""); err != nil {
+		if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, c.Messages.Sprintf(messages.CommentClosedPR), ""); err != nil {
 			ctx.Log.Err("unable to comment: %s", err)
 		}
 		return false
 	}
 
-	repo := c.GlobalCfg.MatchingRepo(ctx.Pull.BaseRepo.ID())
+	repo := c.ReloadableGlobalCfg.LoadOr(c.GlobalCfg).MatchingRepo(ctx.Pull.BaseRepo.ID())
 	if !repo.BranchMatches(ctx.Pull.BaseBranch) {
 		ctx.Log.Info("command was run on a pull request which doesn't match base branches")
 		// just ignore it to allow us to use any git workflows without malicious intentions.
@@ -566,3 +950,23 @@ func (c *DefaultCommandRunner) logPanics(baseRepo models.Repo, pullNum int, logg
 }
 
 var automergeComment = `Automatically merging because all plans have been successfully applied.`
+
+// applyOnMergeComment is posted when an apply command is issued on an open
+// pull request while applies run on merge.
+var applyOnMergeComment = "**Error:** Running `atlantis apply` is disabled. Plans are applied automatically after this pull request is merged."
+
+// applyConfirmationComment is posted when an apply on a repo with
+// apply_confirmation is run. It's formatted with the plans that will be
+// applied, the nonce and how long the apply can be confirmed for.
+var applyConfirmationComment = "Applies to this repo must be confirmed. %s\n\n" +
+	"To apply, comment `atlantis confirm %s` within %s."
+
+// applyOnMergeStaleComment is posted when a pull request is merged at a
+// different commit than the one its plans were made for.
+var applyOnMergeStaleComment = "**Error:** Not applying because this pull request was merged at `%s` but was last planned at `%s`." +
+	" Open a new pull request to plan and apply these changes."
+
+// applyOnMergeStaleBaseComment is posted when a pull request is merged into a
+// different base commit than the one its plans were made against.
+var applyOnMergeStaleBaseComment = "**Error:** Not applying because this pull request was merged into `%s` at `%s` but was last planned against `%s`." +
+	" Open a new pull request to plan and apply these changes."
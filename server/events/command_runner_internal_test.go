@@ -289,6 +289,10 @@ func (m *MockCSU) UpdateCombined(_ logging.SimpleLogging, _ models.Repo, _ model
 	return nil
 }
 
+func (m *MockCSU) UpdateSummary(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, _ models.PullStatus) error {
+	return nil
+}
+
 func (m *MockCSU) UpdateProject(_ command.ProjectContext, _ command.Name, _ models.CommitStatus, _ string, _ *command.ProjectResult) error {
 	return nil
 }
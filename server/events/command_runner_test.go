@@ -19,6 +19,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/db"
@@ -66,6 +67,12 @@ var lockingLocker *lockingmocks.MockLocker
 var applyCommandRunner *events.ApplyCommandRunner
 var unlockCommandRunner *events.UnlockCommandRunner
 var importCommandRunner *events.ImportCommandRunner
+var forceUnlockCommandRunner *events.ForceUnlockCommandRunner
+var runningCommands *events.RunningCommands
+var cancelCommandRunner *events.CancelCommandRunner
+var workspacesCommandRunner *events.WorkspacesCommandRunner
+var statusCommandRunner *events.StatusCommandRunner
+var commandRequirementHandler *mocks.MockCommandRequirementHandler
 var preWorkflowHooksCommandRunner events.PreWorkflowHooksCommandRunner
 var postWorkflowHooksCommandRunner events.PostWorkflowHooksCommandRunner
 
@@ -78,6 +85,8 @@ type TestConfig struct {
 	discardApprovalOnPlan      bool
 	backend                    locking.Backend
 	DisableUnlockLabel         string
+	maxAutoplanProjects        int
+	markAppliedComments        bool
 }
 
 func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.MockClient {
@@ -166,6 +175,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		lockingLocker,
 		testConfig.discardApprovalOnPlan,
 		pullReqStatusFetcher,
+		testConfig.maxAutoplanProjects,
 	)
 
 	applyCommandRunner = events.NewApplyCommandRunner(
@@ -183,6 +193,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		testConfig.SilenceNoProjects,
 		testConfig.silenceVCSStatusNoProjects,
 		pullReqStatusFetcher,
+		testConfig.markAppliedComments,
 	)
 
 	approvePoliciesCommandRunner = events.NewApprovePoliciesCommandRunner(
@@ -219,6 +230,38 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		testConfig.SilenceNoProjects,
 	)
 
+	forceUnlockCommandRunner = events.NewForceUnlockCommandRunner(
+		pullUpdater,
+		projectCommandBuilder,
+		projectCommandRunner,
+		testConfig.SilenceNoProjects,
+	)
+
+	runningCommands = events.NewRunningCommands()
+	cancelCommandRunner = events.NewCancelCommandRunner(
+		runningCommands,
+		vcsClient,
+		testConfig.SilenceNoProjects,
+	)
+
+	workspacesCommandRunner = events.NewWorkspacesCommandRunner(
+		pullUpdater,
+		projectCommandBuilder,
+		projectCommandRunner,
+		lockingLocker,
+		testConfig.SilenceNoProjects,
+	)
+
+	commandRequirementHandler = mocks.NewMockCommandRequirementHandler()
+	statusCommandRunner = events.NewStatusCommandRunner(
+		vcsClient,
+		pullReqStatusFetcher,
+		projectCommandBuilder,
+		workingDir,
+		commandRequirementHandler,
+		testConfig.SilenceNoProjects,
+	)
+
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
 		command.Plan:            planCommandRunner,
 		command.Apply:           applyCommandRunner,
@@ -226,6 +269,10 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		command.Unlock:          unlockCommandRunner,
 		command.Version:         versionCommandRunner,
 		command.Import:          importCommandRunner,
+		command.ForceUnlock:     forceUnlockCommandRunner,
+		command.Cancel:          cancelCommandRunner,
+		command.Workspaces:      workspacesCommandRunner,
+		command.Status:          statusCommandRunner,
 	}
 
 	preWorkflowHooksCommandRunner = mocks.NewMockPreWorkflowHooksCommandRunner()
@@ -341,6 +388,96 @@ func TestRunCommentCommand_TeamAllowListChecker(t *testing.T) {
 	})
 }
 
+func TestRunCommentCommand_CommandPermissions(t *testing.T) {
+	t.Run("user not allowed", func(t *testing.T) {
+		vcsClient := setup(t)
+		ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+			IDRegex: regexp.MustCompile(".*"),
+			CommandPermissions: map[string]valid.CommandPermission{
+				"apply": {Users: []string{"someoneelse"}, Roles: []string{"admins"}},
+			},
+		})
+		ch.GlobalCfg.Roles = map[string]valid.Role{"admins": {Users: []string{"admin"}}}
+
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+		vcsClient.VerifyWasCalled(Never()).GetTeamNamesForUser(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.User]())
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+			Eq(fmt.Sprintf("```\nError: User @%s does not have permissions to execute 'apply' command on %s. It is restricted to users [someoneelse], roles [admins].\n```", testdata.User.Username, testdata.GithubRepo.FullName)),
+			Eq(""))
+	})
+
+	t.Run("user allowed via team", func(t *testing.T) {
+		vcsClient := setup(t)
+		ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+			IDRegex: regexp.MustCompile(".*"),
+			CommandPermissions: map[string]valid.CommandPermission{
+				"plan": {Teams: []string{"infra"}},
+			},
+		})
+		var pull github.PullRequest
+		modelPull := models.PullRequest{
+			BaseRepo: testdata.GithubRepo,
+			State:    models.OpenPullState,
+		}
+		When(vcsClient.GetTeamNamesForUser(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.User))).ThenReturn([]string{"infra"}, nil)
+		When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+		When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, testdata.GithubRepo, nil)
+
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
+	})
+
+	t.Run("user allowed via synced team", func(t *testing.T) {
+		vcsClient := setup(t)
+		ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+			IDRegex: regexp.MustCompile(".*"),
+			CommandPermissions: map[string]valid.CommandPermission{
+				"plan": {Teams: []string{"infra"}},
+			},
+		})
+		ch.TeamNamesFetcher = stubTeamNamesFetcher{testdata.User.Username: {"infra"}}
+		var pull github.PullRequest
+		modelPull := models.PullRequest{
+			BaseRepo: testdata.GithubRepo,
+			State:    models.OpenPullState,
+		}
+		When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+		When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, testdata.GithubRepo, nil)
+
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+		vcsClient.VerifyWasCalled(Never()).GetTeamNamesForUser(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.User]())
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
+	})
+
+	for _, cmd := range []*events.CommentCommand{{Name: command.Confirm, Flags: []string{"nonce"}}, {Name: command.Help}} {
+		t.Run(cmd.Name.String()+" not allowed", func(t *testing.T) {
+			vcsClient := setup(t)
+			ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+				IDRegex: regexp.MustCompile(".*"),
+				CommandPermissions: map[string]valid.CommandPermission{
+					cmd.Name.String(): {Users: []string{"someoneelse"}},
+				},
+			})
+
+			ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, cmd)
+			vcsClient.VerifyWasCalledOnce().CreateComment(
+				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+				Eq(fmt.Sprintf("```\nError: User @%s does not have permissions to execute '%s' command on %s. It is restricted to users [someoneelse].\n```", testdata.User.Username, cmd.Name, testdata.GithubRepo.FullName)),
+				Eq(""))
+		})
+	}
+}
+
+// stubTeamNamesFetcher returns the teams of users by username.
+type stubTeamNamesFetcher map[string][]string
+
+func (s stubTeamNamesFetcher) GetTeamNamesForUser(_ logging.SimpleLogging, _ models.Repo, user models.User) ([]string, error) {
+	return s[user.Username], nil
+}
+
 func TestRunCommentCommand_ForkPRDisabled(t *testing.T) {
 	t.Log("if a command is run on a forked pull request and this is disabled atlantis should" +
 		" comment saying that this is not allowed")
@@ -366,6 +503,49 @@ func TestRunCommentCommand_ForkPRDisabled(t *testing.T) {
 		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(commentMessage), Eq(""))
 }
 
+func TestRunCommentCommand_ForkPRRestricted(t *testing.T) {
+	headRepo := testdata.GithubRepo
+	headRepo.FullName = "forkrepo/atlantis"
+	headRepo.Owner = "forkrepo"
+
+	setupForkPR := func(t *testing.T, author string) *vcsmocks.MockClient {
+		vcsClient := setup(t)
+		ch.RestrictForkPRs = true
+		ch.ForkPRAllowlist = []string{"trusted"}
+		ch.ForkPRAllowlistFlag = "fork-pr-allowlist"
+		var pull github.PullRequest
+		modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, Author: author}
+		When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+		When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, headRepo, nil)
+		return vcsClient
+	}
+
+	t.Run("plan is allowed", func(t *testing.T) {
+		vcsClient := setupForkPR(t, "someone")
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Plan})
+		ctx, _ := projectCommandBuilder.VerifyWasCalledOnce().BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]()).GetCapturedArguments()
+		Assert(t, ctx.ForkRestricted, "expected fork pull request to be restricted")
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Eq("Ran Plan for 0 projects:"), Eq("plan"))
+	})
+
+	t.Run("apply is denied", func(t *testing.T) {
+		vcsClient := setupForkPR(t, "someone")
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+			Eq("Only plans can run on fork pull requests. To run `apply`, the pull request author @someone must be added to --fork-pr-allowlist"), Eq(""))
+		projectCommandBuilder.VerifyWasCalled(Never()).BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+	})
+
+	t.Run("allowlisted author isn't restricted", func(t *testing.T) {
+		setupForkPR(t, "Trusted")
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+		ctx, _ := projectCommandBuilder.VerifyWasCalledOnce().BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]()).GetCapturedArguments()
+		Assert(t, !ctx.ForkRestricted, "expected allowlisted author not to be restricted")
+	})
+}
+
 func TestRunCommentCommand_ForkPRDisabled_SilenceEnabled(t *testing.T) {
 	t.Log("if a command is run on a forked pull request and forks are disabled and we are silencing errors do not comment with error")
 	vcsClient := setup(t)
@@ -526,10 +706,59 @@ func TestRunCommentCommand_DisableAutoplan(t *testing.T) {
 			},
 		}, nil)
 	When(commitUpdater.UpdateCombinedCount(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[command.Name](), Any[int](), Any[int]())).ThenReturn(nil)
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User, models.AutoplanTrigger{})
 	projectCommandBuilder.VerifyWasCalled(Never()).BuildAutoplanCommands(Any[*command.Context]())
 }
 
+func TestRunAutoplanCommand_Draft(t *testing.T) {
+	t.Log("draft pull requests are only autoplanned if the server or the repo allows it")
+	cases := []struct {
+		description   string
+		allowDraftPRs bool
+		planDrafts    *bool
+		expPlanned    bool
+	}{
+		{
+			description: "not allowed by default",
+		},
+		{
+			description:   "allowed by flag",
+			allowDraftPRs: true,
+			expPlanned:    true,
+		},
+		{
+			description: "allowed by repo",
+			planDrafts:  github.Ptr(true),
+			expPlanned:  true,
+		},
+		{
+			description:   "repo overrides flag",
+			allowDraftPRs: true,
+			planDrafts:    github.Ptr(false),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			setup(t)
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, BaseBranch: "main", Draft: true}
+			ch.AllowDraftPRs = c.allowDraftPRs
+			defer func() { ch.AllowDraftPRs = false }()
+			ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+				IDRegex:    regexp.MustCompile(".*"),
+				PlanDrafts: c.planDrafts,
+			})
+
+			When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).ThenReturn([]command.ProjectContext{}, nil)
+			ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User, models.AutoplanTrigger{})
+			if c.expPlanned {
+				projectCommandBuilder.VerifyWasCalledOnce().BuildAutoplanCommands(Any[*command.Context]())
+			} else {
+				projectCommandBuilder.VerifyWasCalled(Never()).BuildAutoplanCommands(Any[*command.Context]())
+			}
+		})
+	}
+}
+
 func TestRunCommentCommand_DisableAutoplanLabel(t *testing.T) {
 	t.Log("if \"DisableAutoplanLabel\" is present and pull request has that label, auto plans are disabled and we are silencing return and do not comment with error")
 	vcsClient := setup(t)
@@ -550,7 +779,7 @@ func TestRunCommentCommand_DisableAutoplanLabel(t *testing.T) {
 	When(ch.VCSClient.GetPullLabels(
 		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull))).ThenReturn([]string{"disable-auto-plan", "need-help"}, nil)
 
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User, models.AutoplanTrigger{})
 	projectCommandBuilder.VerifyWasCalled(Never()).BuildAutoplanCommands(Any[*command.Context]())
 	vcsClient.VerifyWasCalledOnce().GetPullLabels(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull))
 }
@@ -574,7 +803,7 @@ func TestRunCommentCommand_DisableAutoplanLabel_PullNotLabeled(t *testing.T) {
 		}, nil)
 	When(ch.VCSClient.GetPullLabels(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull))).ThenReturn(nil, nil)
 
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, modelPull, testdata.User, models.AutoplanTrigger{})
 	projectCommandBuilder.VerifyWasCalled(Once()).BuildAutoplanCommands(Any[*command.Context]())
 	vcsClient.VerifyWasCalledOnce().GetPullLabels(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull))
 }
@@ -797,11 +1026,47 @@ func TestRunAutoplanCommand_DeletePlans(t *testing.T) {
 	When(projectCommandRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
 	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmp, nil)
 	testdata.Pull.BaseRepo = testdata.GithubRepo
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
 	pendingPlanFinder.VerifyWasCalledOnce().DeletePlans(tmp)
 	lockingLocker.VerifyWasCalledOnce().UnlockByPull(testdata.Pull.BaseRepo.FullName, testdata.Pull.Num)
 }
 
+func TestRunAutoplanCommand_LabeledKeepsPlans(t *testing.T) {
+	setup(t)
+	tmp := t.TempDir()
+	boltDB, err := db.New(tmp)
+	t.Cleanup(func() {
+		boltDB.Close()
+	})
+	Ok(t, err)
+	dbUpdater.Backend = boltDB
+	applyCommandRunner.Backend = boltDB
+
+	When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+			},
+		}, nil)
+	When(projectCommandRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmp, nil)
+	testdata.Pull.BaseRepo = testdata.GithubRepo
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{Event: models.LabeledPullEvent, Label: "plan"})
+	pendingPlanFinder.VerifyWasCalled(Never()).DeletePlans(tmp)
+	lockingLocker.VerifyWasCalled(Never()).UnlockByPull(testdata.Pull.BaseRepo.FullName, testdata.Pull.Num)
+}
+
+func TestRunAutoplanCommand_LabeledNoProjects(t *testing.T) {
+	t.Log("if a label triggers no projects, the commit statuses should not be changed")
+	vcsClient := setup(t)
+	When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).ThenReturn([]command.ProjectContext{}, nil)
+	testdata.Pull.BaseRepo = testdata.GithubRepo
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{Event: models.LabeledPullEvent, Label: "plan"})
+	commitUpdater.VerifyWasCalled(Never()).UpdateCombined(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[command.Name]())
+	commitUpdater.VerifyWasCalled(Never()).UpdateCombinedCount(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[command.Name](), Any[int](), Any[int]())
+	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
+
 func TestRunAutoplanCommand_FailedPreWorkflowHook_FailOnPreWorkflowHookError_False(t *testing.T) {
 	setup(t)
 	tmp := t.TempDir()
@@ -824,7 +1089,7 @@ func TestRunAutoplanCommand_FailedPreWorkflowHook_FailOnPreWorkflowHookError_Fal
 	When(preWorkflowHooksCommandRunner.RunPreHooks(Any[*command.Context](), Any[*events.CommentCommand]())).ThenReturn(errors.New("err"))
 	testdata.Pull.BaseRepo = testdata.GithubRepo
 	ch.FailOnPreWorkflowHookError = false
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
 	pendingPlanFinder.VerifyWasCalledOnce().DeletePlans(tmp)
 	lockingLocker.VerifyWasCalledOnce().UnlockByPull(testdata.Pull.BaseRepo.FullName, testdata.Pull.Num)
 	commitUpdater.VerifyWasCalledOnce().UpdateCombined(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
@@ -853,7 +1118,7 @@ func TestRunAutoplanCommand_FailedPreWorkflowHook_FailOnPreWorkflowHookError_Tru
 	When(preWorkflowHooksCommandRunner.RunPreHooks(Any[*command.Context](), Any[*events.CommentCommand]())).ThenReturn(errors.New("err"))
 	testdata.Pull.BaseRepo = testdata.GithubRepo
 	ch.FailOnPreWorkflowHookError = true
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
 	pendingPlanFinder.VerifyWasCalled(Never()).DeletePlans(Any[string]())
 	lockingLocker.VerifyWasCalled(Never()).UnlockByPull(Any[string](), Any[int]())
 	commitUpdater.VerifyWasCalledOnce().UpdateCombined(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
@@ -906,6 +1171,99 @@ func TestRunCommentCommand_FailedPreWorkflowHook_FailOnPreWorkflowHookError_True
 	lockingLocker.VerifyWasCalled(Never()).UnlockByPull(Any[string](), Any[int]())
 }
 
+func TestRunAutoplanCommand_NoProjectsSummaryStatus(t *testing.T) {
+	t.Log("if autoplan finds no projects, the summary status should report 0/0 projects")
+	setup(t)
+	When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).ThenReturn([]command.ProjectContext{}, nil)
+	testdata.Pull.BaseRepo = testdata.GithubRepo
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
+	commitUpdater.VerifyWasCalledOnce().UpdateSummary(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Eq(models.PullStatus{}))
+}
+
+func TestRunAutoplanCommand_MaxAutoplanProjects(t *testing.T) {
+	t.Log("if autoplan would plan more projects than the maximum, none should be planned")
+	vcsClient := setup(t, func(testConfig *TestConfig) {
+		testConfig.maxAutoplanProjects = 1
+	})
+	When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "a",
+				Workspace:   "default",
+			},
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "b",
+				Workspace:   "default",
+			},
+		}, nil)
+	testdata.Pull.BaseRepo = testdata.GithubRepo
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
+	projectCommandRunner.VerifyWasCalled(Never()).Plan(Any[command.ProjectContext]())
+	commitUpdater.VerifyWasCalledOnce().UpdateCombined(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Eq(models.FailedCommitStatus), Eq(command.Plan))
+	_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "atlantis plan --confirm-all"), "exp comment to explain how to confirm, got %q", comment)
+}
+
+func TestRunCommentCommand_MaxAutoplanProjects(t *testing.T) {
+	cases := []struct {
+		Description string
+		Command     events.CommentCommand
+		ExpPlans    int
+	}{
+		{
+			Description: "plan without flags needs confirmation",
+			Command:     events.CommentCommand{Name: command.Plan},
+			ExpPlans:    0,
+		},
+		{
+			Description: "plan --confirm-all plans every project",
+			Command:     events.CommentCommand{Name: command.Plan, ConfirmAll: true},
+			ExpPlans:    2,
+		},
+		{
+			Description: "plan of a specific dir doesn't need confirmation",
+			Command:     events.CommentCommand{Name: command.Plan, RepoRelDir: "a"},
+			ExpPlans:    2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Description, func(t *testing.T) {
+			setup(t, func(testConfig *TestConfig) {
+				testConfig.maxAutoplanProjects = 1
+			})
+			tmp := t.TempDir()
+			boltDB, err := db.New(tmp)
+			t.Cleanup(func() {
+				boltDB.Close()
+			})
+			Ok(t, err)
+			dbUpdater.Backend = boltDB
+			applyCommandRunner.Backend = boltDB
+
+			When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())).
+				ThenReturn([]command.ProjectContext{
+					{
+						CommandName: command.Plan,
+						RepoRelDir:  "a",
+						Workspace:   "default",
+					},
+					{
+						CommandName: command.Plan,
+						RepoRelDir:  "b",
+						Workspace:   "default",
+					},
+				}, nil)
+			When(projectCommandRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+			When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmp, nil)
+			testdata.Pull.BaseRepo = testdata.GithubRepo
+			ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, &testdata.Pull, testdata.User, testdata.Pull.Num, &c.Command)
+			projectCommandRunner.VerifyWasCalled(Times(c.ExpPlans)).Plan(Any[command.ProjectContext]())
+		})
+	}
+}
+
 func TestRunGenericPlanCommand_DeletePlans(t *testing.T) {
 	setup(t)
 	tmp := t.TempDir()
@@ -999,7 +1357,7 @@ func TestRunAutoplanCommandWithError_DeletePlans(t *testing.T) {
 	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).
 		ThenReturn(tmp, nil)
 	testdata.Pull.BaseRepo = testdata.GithubRepo
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
 	// gets called twice: the first time before the plan starts, the second time after the plan errors
 	pendingPlanFinder.VerifyWasCalled(Times(2)).DeletePlans(tmp)
 
@@ -1286,7 +1644,7 @@ func TestRunAutoplanCommand_DrainOngoing(t *testing.T) {
 	t.Log("if drain is ongoing then a message should be displayed")
 	vcsClient := setup(t)
 	drainer.ShutdownBlocking()
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
 	vcsClient.VerifyWasCalledOnce().CreateComment(
 		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Eq("Atlantis server is shutting down, please try again later."), Eq("plan"))
 }
@@ -1296,7 +1654,200 @@ func TestRunAutoplanCommand_DrainNotOngoing(t *testing.T) {
 	setup(t)
 	testdata.Pull.BaseRepo = testdata.GithubRepo
 	When(projectCommandBuilder.BuildAutoplanCommands(Any[*command.Context]())).ThenPanic("panic test - if you're seeing this in a test failure this isn't the failing test")
-	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User)
+	ch.RunAutoplanCommand(testdata.GithubRepo, testdata.GithubRepo, testdata.Pull, testdata.User, models.AutoplanTrigger{})
 	projectCommandBuilder.VerifyWasCalledOnce().BuildAutoplanCommands(Any[*command.Context]())
 	Equals(t, 0, drainer.GetStatus().InProgressOps)
 }
+
+func TestRunCommentCommand_ApplyOnMerge(t *testing.T) {
+	t.Log("if \"atlantis apply\" is run while applies run on merge atlantis" +
+		" should comment saying that this is not allowed")
+	vcsClient := setup(t)
+	ch.ApplyOnMerge = true
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+		Eq("**Error:** Running `atlantis apply` is disabled. Plans are applied automatically after this pull request is merged."), Eq("apply"))
+	githubGetter.VerifyWasCalled(Never()).GetPullRequest(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int]())
+}
+
+func TestRunCommentCommand_ApplyConfirmation(t *testing.T) {
+	vcsClient := setup(t)
+	applyConfirmation := true
+	ch.GlobalCfg.Repos = append(ch.GlobalCfg.Repos, valid.Repo{
+		IDRegex:           regexp.MustCompile(".*"),
+		ApplyConfirmation: &applyConfirmation,
+	})
+	ch.ApplyConfirmations = events.NewApplyConfirmations(10 * time.Minute)
+
+	var pull github.PullRequest
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	When(githubGetter.GetPullRequest(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num))).ThenReturn(&pull, nil)
+	When(eventParsing.ParseGithubPull(Any[logging.SimpleLogging](), Eq(&pull))).ThenReturn(modelPull, modelPull.BaseRepo, testdata.GithubRepo, nil)
+	_, err := ch.PullStatusFetcher.(locking.Backend).UpdatePullWithResults(modelPull, []command.ProjectResult{
+		{
+			Command:    command.Plan,
+			RepoRelDir: "prod",
+			Workspace:  "default",
+			PlanSuccess: &models.PlanSuccess{
+				TerraformOutput: "  # aws_instance.web must be replaced\n  # aws_s3_bucket.logs will be updated in-place\nPlan: 1 to add, 1 to change, 1 to destroy.",
+			},
+		},
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "staging",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes. Your infrastructure matches the configuration."},
+		},
+	})
+	Ok(t, err)
+
+	ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Any[string](), Eq("apply")).GetCapturedArguments()
+	nonce := regexp.MustCompile("atlantis confirm ([0-9a-f]+)").FindStringSubmatch(comment)
+	Assert(t, len(nonce) == 2, "expected a nonce in %q", comment)
+	Equals(t, fmt.Sprintf("Applies to this repo must be confirmed. The following plans will be applied:\n\n"+
+		"* dir: `prod` workspace: `default` (+1 ~1 -1)\n\n"+
+		"To apply, comment `atlantis confirm %s` within 10m0s.", nonce[1]), comment)
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+
+	t.Run("only the user who ran the apply can confirm it", func(t *testing.T) {
+		other := models.User{Username: "someoneelse"}
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, other, testdata.Pull.Num, &events.CommentCommand{Name: command.Confirm, Flags: []string{nonce[1]}})
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+			Eq(fmt.Sprintf("**Error:** only @%s, who ran the apply, can confirm it.", testdata.User.Username)), Eq("apply"))
+		projectCommandBuilder.VerifyWasCalled(Never()).BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+	})
+
+	t.Run("confirming runs the apply", func(t *testing.T) {
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Confirm, Flags: []string{nonce[1]}})
+		_, cmd := projectCommandBuilder.VerifyWasCalledOnce().BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]()).GetCapturedArguments()
+		Equals(t, command.Apply, cmd.Name)
+		Assert(t, cmd.Confirmed, "expected the apply to be confirmed")
+	})
+
+	t.Run("an apply can only be confirmed once", func(t *testing.T) {
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Confirm, Flags: []string{nonce[1]}})
+		vcsClient.VerifyWasCalledOnce().CreateComment(
+			Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num),
+			Eq(fmt.Sprintf("**Error:** no apply is waiting to be confirmed with %q on this pull request.", nonce[1])), Eq("apply"))
+	})
+}
+
+func TestRunApplyOnMergeCommand(t *testing.T) {
+	cases := []struct {
+		description   string
+		plannedCommit string
+		plannedBase   string
+		expApply      bool
+		expComment    string
+	}{
+		{
+			description:   "no plans",
+			plannedCommit: "",
+			expApply:      false,
+		},
+		{
+			description:   "planned at a different commit",
+			plannedCommit: "old-sha",
+			plannedBase:   "base-sha",
+			expApply:      false,
+			expComment: "**Error:** Not applying because this pull request was merged at `merged-sha` but was last planned at `old-sha`." +
+				" Open a new pull request to plan and apply these changes.",
+		},
+		{
+			description:   "planned against a different base commit",
+			plannedCommit: "merged-sha",
+			plannedBase:   "old-base-sha",
+			expApply:      false,
+			expComment: "**Error:** Not applying because this pull request was merged into `main` at `base-sha` but was last planned against `old-base-sha`." +
+				" Open a new pull request to plan and apply these changes.",
+		},
+		{
+			description:   "planned at the merged commit against the merged base",
+			plannedCommit: "merged-sha",
+			plannedBase:   "base-sha",
+			expApply:      true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			tmp := t.TempDir()
+			boltDB, err := db.New(tmp)
+			t.Cleanup(func() {
+				boltDB.Close()
+			})
+			Ok(t, err)
+			vcsClient := setup(t, func(tc *TestConfig) {
+				tc.backend = boltDB
+			})
+			dbUpdater.Backend = boltDB
+			applyCommandRunner.Backend = boltDB
+
+			mergedPull := models.PullRequest{
+				BaseRepo:   testdata.GithubRepo,
+				State:      models.MergedPullState,
+				Num:        testdata.Pull.Num,
+				HeadCommit: "merged-sha",
+				BaseCommit: "base-sha",
+				BaseBranch: "main",
+			}
+			if c.plannedCommit != "" {
+				plannedPull := mergedPull
+				plannedPull.State = models.OpenPullState
+				plannedPull.HeadCommit = c.plannedCommit
+				plannedPull.BaseCommit = c.plannedBase
+				_, err = boltDB.UpdatePullWithResults(plannedPull, []command.ProjectResult{
+					{
+						Command:     command.Plan,
+						RepoRelDir:  ".",
+						Workspace:   "default",
+						PlanSuccess: &models.PlanSuccess{},
+					},
+				})
+				Ok(t, err)
+			}
+
+			When(projectCommandBuilder.BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())).ThenReturn([]command.ProjectContext{
+				{
+					CommandName: command.Apply,
+					RepoRelDir:  ".",
+					Workspace:   "default",
+				},
+			}, nil)
+			When(projectCommandRunner.Apply(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{ApplySuccess: "success"})
+
+			ch.RunApplyOnMergeCommand(testdata.GithubRepo, testdata.GithubRepo, mergedPull, testdata.User)
+
+			if c.expApply {
+				projectCommandRunner.VerifyWasCalledOnce().Apply(Any[command.ProjectContext]())
+			} else {
+				projectCommandBuilder.VerifyWasCalled(Never()).BuildApplyCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+			}
+			if c.expComment != "" {
+				vcsClient.VerifyWasCalledOnce().CreateComment(
+					Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Eq(c.expComment), Eq("apply"))
+			}
+			Equals(t, 0, drainer.GetStatus().InProgressOps)
+		})
+	}
+}
+
+func TestRunCommentCommand_RateLimited(t *testing.T) {
+	t.Log("if a user exceeds the rate limit atlantis should comment once and ignore further commands")
+	vcsClient := setup(t)
+	ch.ApplyOnMerge = true
+	ch.CommandRateLimiter = events.NewDefaultCommandRateLimiter(1, 0)
+
+	for i := 0; i < 3; i++ {
+		ch.RunCommentCommand(testdata.GithubRepo, nil, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	}
+	_, _, _, comments, _ := vcsClient.VerifyWasCalled(Times(2)).CreateComment(
+		Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.Pull.Num), Any[string](), Any[string]()).GetAllCapturedArguments()
+	Equals(t, "**Error:** Running `atlantis apply` is disabled. Plans are applied automatically after this pull request is merged.", comments[0])
+	Assert(t, strings.HasPrefix(comments[1], "**Rate limit exceeded**: at most 1 Atlantis commands can be run per minute by you."),
+		fmt.Sprintf("comment should be about the rate limit but was %q", comments[1]))
+}
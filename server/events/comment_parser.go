@@ -23,8 +23,10 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/google/shlex"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/messages"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/utils"
 	"github.com/spf13/pflag"
@@ -37,6 +39,8 @@ const (
 	dirFlagShort                 = "d"
 	projectFlagLong              = "project"
 	projectFlagShort             = "p"
+	environmentFlagLong          = "environment"
+	environmentFlagShort         = "e"
 	policySetFlagLong            = "policy-set"
 	policySetFlagShort           = ""
 	autoMergeDisabledFlagLong    = "auto-merge-disabled"
@@ -47,6 +51,16 @@ const (
 	verboseFlagShort             = ""
 	clearPolicyApprovalFlagLong  = "clear-policy-approval"
 	clearPolicyApprovalFlagShort = ""
+	includeDirFlagLong           = "include-dir"
+	includeDirFlagShort          = ""
+	excludeDirFlagLong           = "exclude-dir"
+	excludeDirFlagShort          = ""
+	continueOnGroupFailureLong   = "continue-on-group-failure"
+	continueOnGroupFailureShort  = ""
+	reasonFlagLong               = "reason"
+	reasonFlagShort              = ""
+	confirmAllFlagLong           = "confirm-all"
+	confirmAllFlagShort          = ""
 )
 
 // multiLineRegex is used to ignore multi-line comments since those aren't valid
@@ -86,6 +100,15 @@ type CommentParser struct {
 	AzureDevopsUser string
 	ExecutableName  string
 	AllowCommands   []command.Name
+	// AllowExtraArgs, if set, are the only terraform flags that can be passed
+	// after '--' in comments.
+	AllowExtraArgs []string
+	// DenyExtraArgs are terraform flags that can't be passed after '--' in
+	// comments. They take precedence over AllowExtraArgs.
+	DenyExtraArgs []string
+	// Messages overrides the text of the comments made in response to
+	// comments that aren't valid commands.
+	Messages *messages.Catalog
 }
 
 // NewCommentParser returns a CommentParser
@@ -114,10 +137,12 @@ func NewCommentParser(githubUser, gitlabUser, giteaUser, bitbucketUser, azureDev
 // CommentParseResult describes the result of parsing a comment as a command.
 type CommentParseResult struct {
 	// Command is the successfully parsed command. Will be nil if
-	// CommentResponse or Ignore is set.
+	// CommentResponse or Ignore is set, except for atlantis help.
 	Command *CommentCommand
 	// CommentResponse is set when we should respond immediately to the command
-	// for example for atlantis help.
+	// for example for an invalid command. For atlantis help, it's set along
+	// with Command to the help that doesn't depend on the repo, for callers
+	// that can't run the help command on the pull request.
 	CommentResponse string
 	// Ignore is set to true when we should just ignore this comment.
 	Ignore bool
@@ -143,6 +168,9 @@ type CommentParseResult struct {
 // - atlantis version
 // - atlantis approve_policies
 // - atlantis import ADDRESS ID
+// - atlantis force-unlock LOCK_ID
+// - atlantis workspaces
+// - atlantis status
 func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) CommentParseResult {
 	comment := strings.TrimSpace(rawComment)
 	comment = strings.Trim(comment, "`")
@@ -163,12 +191,12 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 
 	// Helpfully warn the user if they're using "terraform" instead of "atlantis"
 	if executableName == "terraform" && e.ExecutableName != "terraform" {
-		return CommentParseResult{CommentResponse: fmt.Sprintf(DidYouMeanAtlantisComment, e.ExecutableName, "terraform")}
+		return CommentParseResult{CommentResponse: e.Messages.Sprintf(messages.CommentDidYouMean, e.ExecutableName, "terraform")}
 	}
 
 	// Helpfully warn the user that the command might be misspelled
 	if utils.IsSimilarWord(executableName, e.ExecutableName) {
-		return CommentParseResult{CommentResponse: fmt.Sprintf(DidYouMeanAtlantisComment, e.ExecutableName, args[0])}
+		return CommentParseResult{CommentResponse: e.Messages.Sprintf(messages.CommentDidYouMean, e.ExecutableName, args[0])}
 	}
 
 	// Atlantis can be invoked using the name of the VCS host user we're
@@ -195,7 +223,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// parser.
 	args, err := shlex.Split(comment)
 	if err != nil {
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError parsing command: %s\n```", err)}
+		return CommentParseResult{CommentResponse: e.Messages.Sprintf(messages.CommentParseError, err)}
 	}
 	if len(args) < 1 {
 		return CommentParseResult{Ignore: true}
@@ -204,7 +232,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	// If they've just typed the name of the executable then give them the help
 	// output.
 	if len(args) == 1 {
-		return CommentParseResult{CommentResponse: e.HelpComment()}
+		return e.helpResult()
 	}
 
 	// Lowercase it to avoid autocorrect issues with browsers.
@@ -212,7 +240,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 
 	// Help output.
 	if e.stringInSlice(cmd, []string{"help", "-h", "--help"}) {
-		return CommentParseResult{CommentResponse: e.HelpComment()}
+		return e.helpResult()
 	}
 
 	// Need to have allow commands at this point.
@@ -221,17 +249,23 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		for _, allowCommand := range e.AllowCommands {
 			allowCommandList = append(allowCommandList, allowCommand.String())
 		}
-		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError: unknown command %q.\nRun '%s --help' for usage.\nAvailable commands(--allow-commands): %s\n```", cmd, e.ExecutableName, strings.Join(allowCommandList, ", "))}
+		return CommentParseResult{CommentResponse: e.Messages.Sprintf(messages.CommentUnknownCommand, cmd, e.ExecutableName, strings.Join(allowCommandList, ", "))}
 	}
 
 	var workspace string
 	var dir string
 	var project string
+	var environment string
 	var policySet string
 	var clearPolicyApproval bool
+	var approvalReason string
 	var verbose bool
 	var autoMergeDisabled bool
 	var autoMergeMethod string
+	var includeDirs []string
+	var excludeDirs []string
+	var continueOnGroupFailure bool
+	var confirmAll bool
 	var flagSet *pflag.FlagSet
 	var name command.Name
 
@@ -243,7 +277,11 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.SetOutput(io.Discard)
 		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before planning.")
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run plan in relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run plan for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run plan for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
+		flagSet.StringVarP(&environment, environmentFlagLong, environmentFlagShort, "", "Which environment of the project to run plan for. Requires the project flag.")
+		flagSet.StringArrayVarP(&includeDirs, includeDirFlagLong, includeDirFlagShort, nil, "Only plan projects whose directory matches this `glob`, ex. 'envs/prod/**'. Can be repeated.")
+		flagSet.StringArrayVarP(&excludeDirs, excludeDirFlagLong, excludeDirFlagShort, nil, "Skip projects whose directory matches this `glob`, ex. 'modules/**'. Can be repeated.")
+		flagSet.BoolVarP(&confirmAll, confirmAllFlagLong, confirmAllFlagShort, false, "Plan all affected projects even if there are more than the maximum planned without confirmation.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	case command.Apply.String():
 		name = command.Apply
@@ -251,9 +289,13 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.SetOutput(io.Discard)
 		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Apply the plan for this Terraform workspace.")
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Apply the plan for this directory, relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Apply the plan for this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Apply the plan for this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
+		flagSet.StringVarP(&environment, environmentFlagLong, environmentFlagShort, "", "Apply the plan for this environment of the project. Requires the project flag.")
 		flagSet.BoolVarP(&autoMergeDisabled, autoMergeDisabledFlagLong, autoMergeDisabledFlagShort, false, "Disable automerge after apply.")
 		flagSet.StringVarP(&autoMergeMethod, autoMergeMethodFlagLong, autoMergeMethodFlagShort, "", "Specifies the merge method for the VCS if automerge is enabled. (Currently only implemented for GitHub)")
+		flagSet.StringArrayVarP(&includeDirs, includeDirFlagLong, includeDirFlagShort, nil, "Only apply plans whose directory matches this `glob`, ex. 'envs/prod/**'. Can be repeated.")
+		flagSet.StringArrayVarP(&excludeDirs, excludeDirFlagLong, excludeDirFlagShort, nil, "Skip plans whose directory matches this `glob`, ex. 'modules/**'. Can be repeated.")
+		flagSet.BoolVarP(&continueOnGroupFailure, continueOnGroupFailureLong, continueOnGroupFailureShort, false, "Keep applying the following execution order groups when a group fails.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	case command.ApprovePolicies.String():
 		name = command.ApprovePolicies
@@ -261,9 +303,10 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.SetOutput(io.Discard)
 		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Approve policies for this Terraform workspace.")
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Approve policies for this directory, relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Approve policies for this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Approve policies for this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
 		flagSet.StringVarP(&policySet, policySetFlagLong, policySetFlagShort, "", "Approve policies for this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
 		flagSet.BoolVarP(&clearPolicyApproval, clearPolicyApprovalFlagLong, clearPolicyApprovalFlagShort, false, "Clear any existing policy approvals.")
+		flagSet.StringVarP(&approvalReason, reasonFlagLong, reasonFlagShort, "", "Justification for approving the failing policies. Recorded with the approval.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	case command.Unlock.String():
 		name = command.Unlock
@@ -282,7 +325,7 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.SetOutput(io.Discard)
 		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before importing.")
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run import in relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run import for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run import for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	case command.State.String():
 		name = command.State
@@ -290,8 +333,38 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.SetOutput(io.Discard)
 		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before processing tfstate.")
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to run state command in relative to root of repo, ex. 'child/dir'.")
-		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run state command for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run state command for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.ForceUnlock.String():
+		name = command.ForceUnlock
+		flagSet = pflag.NewFlagSet(command.ForceUnlock.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Switch to this Terraform workspace before releasing the state lock.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to release the state lock in relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to release the state lock for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.Cancel.String():
+		name = command.Cancel
+		flagSet = pflag.NewFlagSet(command.Cancel.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", "Only cancel the running plan or apply in this Terraform workspace.")
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Only cancel the running plan or apply in this directory, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Only cancel the running plan or apply of this project. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
+	case command.Workspaces.String():
+		name = command.Workspaces
+		flagSet = pflag.NewFlagSet(command.Workspaces.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Which directory to list the workspaces of, relative to root of repo, ex. 'child/dir'.")
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to list the workspaces of. Refers to the name of the project configured in a repo config file. Cannot be used at same time as dir flag.")
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+	case command.Status.String():
+		name = command.Status
+		flagSet = pflag.NewFlagSet(command.Status.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+	case command.Confirm.String():
+		name = command.Confirm
+		flagSet = pflag.NewFlagSet(command.Confirm.String(), pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
 	default:
 		return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", cmd)}
 	}
@@ -301,6 +374,16 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		return CommentParseResult{CommentResponse: errResult}
 	}
 
+	// The nonce is the only argument of confirm, the options of the apply
+	// being confirmed were given when it was run.
+	if name == command.Confirm && len(extraArgs) != 1 {
+		return CommentParseResult{CommentResponse: e.errMarkdown("confirm doesn't take terraform options", cmd, flagSet)}
+	}
+
+	if err := e.validateExtraArgs(extraArgs); err != nil {
+		return CommentParseResult{CommentResponse: e.errMarkdown(err.Error(), cmd, flagSet)}
+	}
+
 	dir, err = e.validateDir(dir)
 	if err != nil {
 		return CommentParseResult{CommentResponse: e.errMarkdown(err.Error(), cmd, flagSet)}
@@ -313,13 +396,39 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid workspace: %q", workspace), cmd, flagSet)}
 	}
 
-	// If project is specified, dir or workspace should not be set. Since we
-	// dir/workspace have defaults we can't detect if the user set the flag
-	// to the default or didn't set the flag so there is an edge case here we
-	// don't detect, ex. atlantis plan -p project -d . -w default won't cause
-	// an error.
-	if project != "" && (workspace != "" || dir != "") {
-		err := fmt.Sprintf("cannot use -%s/--%s at same time as -%s/--%s or -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
+	// If project is specified, dir should not be set. Since dir has a default
+	// we can't detect if the user set the flag to the default or didn't set
+	// the flag so there is an edge case here we don't detect, ex. atlantis
+	// plan -p project -d . won't cause an error. Workspace narrows a project
+	// whose name is repeated across workspaces down to one of them.
+	if project != "" && dir != "" {
+		err := fmt.Sprintf("cannot use -%s/--%s at same time as -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+
+	if environment != "" && project == "" {
+		err := fmt.Sprintf("cannot use -%s/--%s without -%s/--%s", environmentFlagShort, environmentFlagLong, projectFlagShort, projectFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+
+	// The dir filters narrow down the set of projects Atlantis determines
+	// itself, so they make no sense when a single project is targeted.
+	if (len(includeDirs) > 0 || len(excludeDirs) > 0) && (project != "" || workspace != "" || dir != "") {
+		err := fmt.Sprintf("cannot use --%s/--%s at same time as -%s/--%s, -%s/--%s or -%s/--%s", includeDirFlagLong, excludeDirFlagLong, projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+	if confirmAll && (project != "" || workspace != "" || dir != "") {
+		err := fmt.Sprintf("cannot use --%s at same time as -%s/--%s, -%s/--%s or -%s/--%s", confirmAllFlagLong, projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
+		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
+	}
+	for _, pattern := range append(includeDirs, excludeDirs...) {
+		if !doublestar.ValidatePattern(pattern) {
+			return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid dir pattern: %q", pattern), cmd, flagSet)}
+		}
+	}
+
+	if approvalReason != "" && clearPolicyApproval {
+		err := fmt.Sprintf("cannot use --%s at the same time as --%s", reasonFlagLong, clearPolicyApprovalFlagLong)
 		return CommentParseResult{CommentResponse: e.errMarkdown(err, cmd, flagSet)}
 	}
 
@@ -335,8 +444,15 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		}
 	}
 
+	commentCommand := NewCommentCommand(dir, extraArgs, name, subName, verbose, autoMergeDisabled, autoMergeMethod, workspace, project, policySet, clearPolicyApproval)
+	commentCommand.IncludeDirs = includeDirs
+	commentCommand.ExcludeDirs = excludeDirs
+	commentCommand.ContinueOnGroupFailure = continueOnGroupFailure
+	commentCommand.ApprovalReason = approvalReason
+	commentCommand.Environment = environment
+	commentCommand.ConfirmAll = confirmAll
 	return CommentParseResult{
-		Command: NewCommentCommand(dir, extraArgs, name, subName, verbose, autoMergeDisabled, autoMergeMethod, workspace, project, policySet, clearPolicyApproval),
+		Command: commentCommand,
 	}
 }
 
@@ -483,6 +599,37 @@ func (e *CommentParser) validateDir(dir string) (string, error) {
 	return validatedDir, nil
 }
 
+// validateExtraArgs checks the terraform flags in extraArgs against
+// AllowExtraArgs and DenyExtraArgs. A configured flag matches both the bare
+// flag, ex. -lock, and the flag with any value, ex. -lock=false, unless it
+// includes a value itself.
+func (e *CommentParser) validateExtraArgs(extraArgs []string) error {
+	for _, arg := range extraArgs {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		// Terraform accepts flags with one or two dashes.
+		flag := "-" + strings.TrimLeft(arg, "-")
+		if e.matchesExtraArg(e.DenyExtraArgs, flag) {
+			return fmt.Errorf("terraform flag %q is not allowed", arg)
+		}
+		if len(e.AllowExtraArgs) > 0 && !e.matchesExtraArg(e.AllowExtraArgs, flag) {
+			return fmt.Errorf("terraform flag %q is not allowed, only %s can be used", arg, strings.Join(e.AllowExtraArgs, ", "))
+		}
+	}
+	return nil
+}
+
+func (e *CommentParser) matchesExtraArg(configured []string, flag string) bool {
+	name, _, _ := strings.Cut(flag, "=")
+	for _, c := range configured {
+		if c == flag || c == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *CommentParser) stringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
@@ -493,6 +640,10 @@ func (e *CommentParser) stringInSlice(a string, list []string) bool {
 }
 
 func (e *CommentParser) isAllowedCommand(cmd string) bool {
+	// confirm runs an apply so it's allowed whenever apply is.
+	if cmd == command.Confirm.String() {
+		cmd = command.Apply.String()
+	}
 	for _, allowed := range e.AllowCommands {
 		if allowed.String() == cmd {
 			return true
@@ -505,9 +656,18 @@ func (e *CommentParser) errMarkdown(errMsg string, cmd string, flagSet *pflag.Fl
 	return fmt.Sprintf("```\nError: %s.\nUsage of %s:\n%s```", errMsg, cmd, flagSet.FlagUsagesWrapped(usagesCols))
 }
 
+// helpResult returns the result of parsing atlantis help.
+func (e *CommentParser) helpResult() CommentParseResult {
+	return CommentParseResult{
+		Command:         &CommentCommand{Name: command.Help},
+		CommentResponse: e.HelpComment(),
+	}
+}
+
+// HelpComment returns the help for the commands that are allowed.
 func (e *CommentParser) HelpComment() string {
 	buf := &bytes.Buffer{}
-	var tmpl = template.Must(template.New("").Parse(helpCommentTemplate))
+	var tmpl = template.Must(template.New("").Parse(e.Messages.Text(messages.CommentHelp)))
 	if err := tmpl.Execute(buf, struct {
 		ExecutableName       string
 		AllowVersion         bool
@@ -517,6 +677,11 @@ func (e *CommentParser) HelpComment() string {
 		AllowApprovePolicies bool
 		AllowImport          bool
 		AllowState           bool
+		AllowForceUnlock     bool
+		AllowCancel          bool
+		AllowWorkspaces      bool
+		AllowStatus          bool
+		AllowConfirm         bool
 	}{
 		ExecutableName:       e.ExecutableName,
 		AllowVersion:         e.isAllowedCommand(command.Version.String()),
@@ -526,76 +691,19 @@ func (e *CommentParser) HelpComment() string {
 		AllowApprovePolicies: e.isAllowedCommand(command.ApprovePolicies.String()),
 		AllowImport:          e.isAllowedCommand(command.Import.String()),
 		AllowState:           e.isAllowedCommand(command.State.String()),
+		AllowForceUnlock:     e.isAllowedCommand(command.ForceUnlock.String()),
+		AllowCancel:          e.isAllowedCommand(command.Cancel.String()),
+		AllowWorkspaces:      e.isAllowedCommand(command.Workspaces.String()),
+		AllowStatus:          e.isAllowedCommand(command.Status.String()),
+		AllowConfirm:         e.isAllowedCommand(command.Confirm.String()),
 	}); err != nil {
 		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
 	}
 	return buf.String()
 }
 
-var helpCommentTemplate = "```cmake\n" +
-	`atlantis
-Terraform Pull Request Automation
-
-Usage:
-  {{ .ExecutableName }} <command> [options] -- [terraform options]
-
-Examples:
-  # show atlantis help
-  {{ .ExecutableName }} help
-{{- if .AllowPlan }}
-
-  # run plan in the root directory passing the -target flag to terraform
-  {{ .ExecutableName }} plan -d . -- -target=resource
-{{- end }}
-{{- if .AllowApply }}
-
-  # apply all unapplied plans from this pull request
-  {{ .ExecutableName }} apply
-
-  # apply the plan for the root directory and staging workspace
-  {{ .ExecutableName }} apply -d . -w staging
-{{- end }}
-
-Commands:
-{{- if .AllowPlan }}
-  plan     Runs 'terraform plan' for the changes in this pull request.
-           To plan a specific project, use the -d, -w and -p flags.
-{{- end }}
-{{- if .AllowApply }}
-  apply    Runs 'terraform apply' on all unapplied plans from this pull request.
-           To only apply a specific plan, use the -d, -w and -p flags.
-{{- end }}
-{{- if .AllowUnlock }}
-  unlock   Removes all atlantis locks and discards all plans for this PR.
-           To unlock a specific plan you can use the Atlantis UI.
-{{- end }}
-{{- if .AllowApprovePolicies }}
-  approve_policies
-           Approves all current policy checking failures for the PR.
-{{- end }}
-{{- if .AllowVersion }}
-  version  Print the output of 'terraform version'
-{{- end }}
-{{- if .AllowImport }}
-  import ADDRESS ID
-           Runs 'terraform import' for the passed address resource.
-           To import a specific project, use the -d, -w and -p flags.
-{{- end }}
-{{- if .AllowState }}
-  state rm ADDRESS...
-           Runs 'terraform state rm' for the passed address resource.
-           To remove a specific project resource, use the -d, -w and -p flags.
-{{- end }}
-  help     View help.
-
-Flags:
-  -h, --help   help for atlantis
-
-Use "{{ .ExecutableName }} [command] --help" for more information about a command.` +
-	"\n```"
-
-// DidYouMeanAtlantisComment is the comment we add to the pull request when
-// someone runs a misspelled command or terraform instead of atlantis.
+// DidYouMeanAtlantisComment is the default comment we add to the pull request
+// when someone runs a misspelled command or terraform instead of atlantis.
 var DidYouMeanAtlantisComment = "Did you mean to use `%s` instead of `%s`?"
 
 // UnlockUsage is the comment we add to the pull request when someone runs
@@ -139,6 +139,7 @@ func TestParse_HelpResponse(t *testing.T) {
 				}
 				r := commentParser.Parse(c, models.Github)
 				Equals(t, commentParser.HelpComment(), r.CommentResponse)
+				Equals(t, command.Help, r.Command.Name)
 			})
 		}
 	}
@@ -238,6 +239,11 @@ func TestParse_UnusedArguments(t *testing.T) {
 			"arg1 arg2 arg3 --",
 			"arg1 arg2 arg3",
 		},
+		{
+			command.ForceUnlock,
+			"arg1 arg2 --",
+			"arg1 arg2",
+		},
 	}
 	for _, c := range cases {
 		comment := fmt.Sprintf("atlantis %s %s", c.Command.String(), c.Args)
@@ -253,12 +259,86 @@ func TestParse_UnusedArguments(t *testing.T) {
 				usage = ApprovePolicyUsage
 			case command.Import:
 				usage = ImportUsage
+			case command.ForceUnlock:
+				usage = ForceUnlockUsage
 			}
 			Equals(t, fmt.Sprintf("```\nError: unknown argument(s) – %s.\n%s```", c.Unused, usage), r.CommentResponse)
 		})
 	}
 }
 
+func TestParse_Cancel(t *testing.T) {
+	cases := []struct {
+		comment      string
+		expDir       string
+		expWorkspace string
+		expProject   string
+	}{
+		{comment: "atlantis cancel"},
+		{comment: "atlantis cancel -p project", expProject: "project"},
+		{comment: "atlantis cancel -d dir -w staging", expDir: "dir", expWorkspace: "staging"},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			r := commentParser.Parse(c.comment, models.Github)
+			Equals(t, "", r.CommentResponse)
+			Equals(t, command.Cancel, r.Command.Name)
+			Equals(t, c.expDir, r.Command.RepoRelDir)
+			Equals(t, c.expWorkspace, r.Command.Workspace)
+			Equals(t, c.expProject, r.Command.ProjectName)
+		})
+	}
+
+	r := commentParser.Parse("atlantis cancel -p project -d dir", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "cannot use -p/--project at same time as -d/--dir"), "got %q", r.CommentResponse)
+}
+
+func TestParse_Workspaces(t *testing.T) {
+	cases := []struct {
+		comment    string
+		expDir     string
+		expProject string
+	}{
+		{comment: "atlantis workspaces"},
+		{comment: "atlantis workspaces -p project", expProject: "project"},
+		{comment: "atlantis workspaces -d dir", expDir: "dir"},
+	}
+	for _, c := range cases {
+		t.Run(c.comment, func(t *testing.T) {
+			r := commentParser.Parse(c.comment, models.Github)
+			Equals(t, "", r.CommentResponse)
+			Equals(t, command.Workspaces, r.Command.Name)
+			Equals(t, c.expDir, r.Command.RepoRelDir)
+			Equals(t, c.expProject, r.Command.ProjectName)
+		})
+	}
+
+	r := commentParser.Parse("atlantis workspaces -w staging", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown shorthand flag: 'w' in -w"), "got %q", r.CommentResponse)
+}
+
+func TestParse_Status(t *testing.T) {
+	r := commentParser.Parse("atlantis status", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Status, r.Command.Name)
+
+	r = commentParser.Parse("atlantis status -p project", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown shorthand flag: 'p' in -p"), "got %q", r.CommentResponse)
+}
+
+func TestParse_Confirm(t *testing.T) {
+	r := commentParser.Parse("atlantis confirm 3f9a1c2b", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, command.Confirm, r.Command.Name)
+	Equals(t, []string{"3f9a1c2b"}, r.Command.Flags)
+
+	r = commentParser.Parse("atlantis confirm", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown argument(s)"), "got %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis confirm 3f9a1c2b -- -target=foo", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "confirm doesn't take terraform options"), "got %q", r.CommentResponse)
+}
+
 func TestParse_UnknownShorthandFlag(t *testing.T) {
 	comment := "atlantis unlock -d ."
 	r := commentParser.Parse(comment, models.Github)
@@ -331,6 +411,12 @@ func TestParse_SubcommandUsage(t *testing.T) {
 		{"atlantis import --help", "import ADDRESS ID"},
 		{"atlantis state -h", "state [rm ADDRESS...]"},
 		{"atlantis state --help", "state [rm ADDRESS...]"},
+		{"atlantis force-unlock -h", "force-unlock LOCK_ID"},
+		{"atlantis force-unlock --help", "force-unlock LOCK_ID"},
+		{"atlantis cancel -h", "cancel"},
+		{"atlantis cancel --help", "cancel"},
+		{"atlantis workspaces -h", "workspaces"},
+		{"atlantis workspaces --help", "workspaces"},
 	}
 	for _, c := range tests {
 		r := commentParser.Parse(c.input, models.Github)
@@ -350,16 +436,16 @@ func TestParse_InvalidFlags(t *testing.T) {
 		exp     string
 	}{
 		{
-			"atlantis plan -e",
-			"Error: unknown shorthand flag: 'e' in -e",
+			"atlantis plan -x",
+			"Error: unknown shorthand flag: 'x' in -x",
 		},
 		{
 			"atlantis plan --abc",
 			"Error: unknown flag: --abc",
 		},
 		{
-			"atlantis apply -e",
-			"Error: unknown shorthand flag: 'e' in -e",
+			"atlantis apply -x",
+			"Error: unknown shorthand flag: 'x' in -x",
 		},
 		{
 			"atlantis apply --abc",
@@ -471,22 +557,154 @@ func TestParse_InvalidWorkspace(t *testing.T) {
 	}
 }
 
-func TestParse_UsingProjectAtSameTimeAsWorkspaceOrDir(t *testing.T) {
+func TestParse_UsingProjectAtSameTimeAsWorkspace(t *testing.T) {
+	for _, c := range []string{"atlantis plan -w staging -p project", "atlantis apply -p project -w staging"} {
+		t.Run(c, func(t *testing.T) {
+			r := commentParser.Parse(c, models.Github)
+			Equals(t, "", r.CommentResponse)
+			Equals(t, "project", r.Command.ProjectName)
+			Equals(t, "staging", r.Command.Workspace)
+		})
+	}
+}
+
+func TestParse_UsingProjectAtSameTimeAsDir(t *testing.T) {
 	cases := []string{
-		"atlantis plan -w workspace -p project",
 		"atlantis plan -d dir -p project",
 		"atlantis plan -d dir -w workspace -p project",
 	}
 	for _, c := range cases {
 		t.Run(c, func(t *testing.T) {
 			r := commentParser.Parse(c, models.Github)
-			exp := "Error: cannot use -p/--project at same time as -d/--dir or -w/--workspace"
+			exp := "Error: cannot use -p/--project at same time as -d/--dir"
 			Assert(t, strings.Contains(r.CommentResponse, exp),
 				"For comment %q expected CommentResponse %q to contain %q", c, r.CommentResponse, exp)
 		})
 	}
 }
 
+func TestParse_DirFilters(t *testing.T) {
+	r := commentParser.Parse("atlantis plan --include-dir envs/** --include-dir stacks/* --exclude-dir envs/dev/**", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, []string{"envs/**", "stacks/*"}, r.Command.IncludeDirs)
+	Equals(t, []string{"envs/dev/**"}, r.Command.ExcludeDirs)
+
+	r = commentParser.Parse("atlantis apply --exclude-dir modules/**", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, []string{"modules/**"}, r.Command.ExcludeDirs)
+
+	r = commentParser.Parse("atlantis plan --include-dir envs/{prod,staging}/**", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, []string{"envs/{prod,staging}/**"}, r.Command.IncludeDirs)
+
+	errCases := map[string]string{
+		"atlantis plan --include-dir envs/** -p project": "Error: cannot use --include-dir/--exclude-dir at same time as -p/--project, -d/--dir or -w/--workspace",
+		"atlantis apply --exclude-dir envs/** -d dir":    "Error: cannot use --include-dir/--exclude-dir at same time as -p/--project, -d/--dir or -w/--workspace",
+		"atlantis plan --include-dir envs/[":             "Error: invalid dir pattern: \"envs/[\"",
+		"atlantis unlock --include-dir envs/**":          "Usage of unlock",
+	}
+	for comment, exp := range errCases {
+		t.Run(comment, func(t *testing.T) {
+			r := commentParser.Parse(comment, models.Github)
+			Assert(t, strings.Contains(r.CommentResponse, exp),
+				"For comment %q expected CommentResponse %q to contain %q", comment, r.CommentResponse, exp)
+		})
+	}
+}
+
+func TestParse_ContinueOnGroupFailure(t *testing.T) {
+	r := commentParser.Parse("atlantis apply --continue-on-group-failure", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.ContinueOnGroupFailure)
+
+	r = commentParser.Parse("atlantis apply", models.Github)
+	Equals(t, false, r.Command.ContinueOnGroupFailure)
+
+	r = commentParser.Parse("atlantis plan --continue-on-group-failure", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Usage of plan"), "expected plan usage, got %q", r.CommentResponse)
+}
+
+func TestParse_ConfirmAll(t *testing.T) {
+	r := commentParser.Parse("atlantis plan --confirm-all", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.ConfirmAll)
+
+	r = commentParser.Parse("atlantis plan --confirm-all --include-dir envs/**", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.ConfirmAll)
+
+	r = commentParser.Parse("atlantis plan", models.Github)
+	Equals(t, false, r.Command.ConfirmAll)
+
+	r = commentParser.Parse("atlantis plan --confirm-all -d dir", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Error: cannot use --confirm-all at same time as -p/--project, -d/--dir or -w/--workspace"),
+		"unexpected response %q", r.CommentResponse)
+
+	r = commentParser.Parse("atlantis apply --confirm-all", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Usage of apply"), "expected apply usage, got %q", r.CommentResponse)
+}
+
+func TestParse_ApprovalReason(t *testing.T) {
+	r := commentParser.Parse(`atlantis approve_policies --reason "hotfix for outage"`, models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "hotfix for outage", r.Command.ApprovalReason)
+
+	r = commentParser.Parse(`atlantis approve_policies --reason "hotfix" --clear-policy-approval`, models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "Error: cannot use --reason at the same time as --clear-policy-approval"),
+		"unexpected response %q", r.CommentResponse)
+}
+
+func TestParse_Environment(t *testing.T) {
+	for _, cmdName := range []string{"plan", "apply"} {
+		r := commentParser.Parse(fmt.Sprintf("atlantis %s -p app -e staging", cmdName), models.Github)
+		Equals(t, "", r.CommentResponse)
+		Equals(t, "app", r.Command.ProjectName)
+		Equals(t, "staging", r.Command.Environment)
+
+		r = commentParser.Parse(fmt.Sprintf("atlantis %s --environment staging", cmdName), models.Github)
+		Assert(t, strings.Contains(r.CommentResponse, "Error: cannot use -e/--environment without -p/--project"),
+			"unexpected response %q", r.CommentResponse)
+	}
+}
+
+func TestParse_ExtraArgsRestrictions(t *testing.T) {
+	cp := events.CommentParser{
+		ExecutableName: "atlantis",
+		AllowCommands:  command.AllCommentCommands,
+		AllowExtraArgs: []string{"-target", "-lock", "-refresh=false"},
+		DenyExtraArgs:  []string{"-lock=false"},
+	}
+	cases := map[string]string{
+		"atlantis plan -- -target=aws_instance.web":      "",
+		"atlantis plan -- --target aws_instance.web":     "",
+		"atlantis plan -- -lock=true -refresh=false":     "",
+		"atlantis import ADDRESS ID -- -target=a.b":      "",
+		"atlantis plan -- -lock=false":                   `terraform flag "-lock=false" is not allowed`,
+		"atlantis plan -- -refresh=true":                 `terraform flag "-refresh=true" is not allowed, only -target, -lock, -refresh=false can be used`,
+		"atlantis plan -- -var password=hunter2":         `terraform flag "-var" is not allowed, only -target, -lock, -refresh=false can be used`,
+		"atlantis apply -- --var-file=secrets.tfvars":    `terraform flag "--var-file=secrets.tfvars" is not allowed, only -target, -lock, -refresh=false can be used`,
+		"atlantis plan -d dir -- -target a.b -var x=y":   `terraform flag "-var" is not allowed, only -target, -lock, -refresh=false can be used`,
+		"atlantis state rm ADDRESS -- -lock=false":       `terraform flag "-lock=false" is not allowed`,
+		"atlantis plan -p project -- -lock -lock=false":  `terraform flag "-lock=false" is not allowed`,
+		"atlantis plan -p project -- -target=x -lock=no": "",
+	}
+	for comment, expErr := range cases {
+		t.Run(comment, func(t *testing.T) {
+			r := cp.Parse(comment, models.Github)
+			if expErr == "" {
+				Equals(t, "", r.CommentResponse)
+				return
+			}
+			Assert(t, strings.Contains(r.CommentResponse, "Error: "+expErr+"."),
+				"expected %q to contain %q", r.CommentResponse, expErr)
+		})
+	}
+
+	// Without any configuration every flag is allowed.
+	r := commentParser.Parse("atlantis plan -- -var password=hunter2 -lock=false", models.Github)
+	Equals(t, "", r.CommentResponse)
+}
+
 func TestParse_Parsing(t *testing.T) {
 	cases := []struct {
 		flags        string
@@ -925,6 +1143,19 @@ Commands:
   state rm ADDRESS...
            Runs 'terraform state rm' for the passed address resource.
            To remove a specific project resource, use the -d, -w and -p flags.
+  force-unlock LOCK_ID
+           Runs 'terraform force-unlock' to release a stuck state lock.
+           To release the lock of a specific project, use the -d, -w and -p flags.
+  cancel   Cancels the plans and applies running for this pull request.
+           To cancel a specific project, use the -d, -w and -p flags.
+  workspaces
+           Lists the terraform workspaces and which have an Atlantis lock
+           or plan for this PR. To list a specific project, use the -d and -p flags.
+  status   Summarizes the projects of this PR, their latest results, who ran
+           them and the apply requirements they're still missing.
+  confirm NONCE
+           Runs an apply that requires confirmation. The nonce is commented
+           when the apply is run.
   help     View help.
 
 Flags:
@@ -984,6 +1215,9 @@ Commands:
            To only apply a specific plan, use the -d, -w and -p flags.
   unlock   Removes all atlantis locks and discards all plans for this PR.
            To unlock a specific plan you can use the Atlantis UI.
+  confirm NONCE
+           Runs an apply that requires confirmation. The nonce is commented
+           when the apply is run.
   help     View help.
 
 Flags:
@@ -1048,28 +1282,43 @@ func TestParse_VCSUsername(t *testing.T) {
 }
 
 var PlanUsage = `Usage of plan:
-  -d, --dir string         Which directory to run plan in relative to root of repo,
-                           ex. 'child/dir'.
-  -p, --project string     Which project to run plan for. Refers to the name of the
-                           project configured in a repo config file. Cannot be used
-                           at same time as workspace or dir flags.
-      --verbose            Append Atlantis log to comment.
-  -w, --workspace string   Switch to this Terraform workspace before planning.
+      --confirm-all          Plan all affected projects even if there are more than
+                             the maximum planned without confirmation.
+  -d, --dir string           Which directory to run plan in relative to root of
+                             repo, ex. 'child/dir'.
+  -e, --environment string   Which environment of the project to run plan for.
+                             Requires the project flag.
+      --exclude-dir glob     Skip projects whose directory matches this glob, ex.
+                             'modules/**'. Can be repeated.
+      --include-dir glob     Only plan projects whose directory matches this glob,
+                             ex. 'envs/prod/**'. Can be repeated.
+  -p, --project string       Which project to run plan for. Refers to the name of
+                             the project configured in a repo config file. Cannot be
+                             used at same time as dir flag.
+      --verbose              Append Atlantis log to comment.
+  -w, --workspace string     Switch to this Terraform workspace before planning.
 `
 
 var ApplyUsage = `Usage of apply:
-      --auto-merge-disabled        Disable automerge after apply.
-      --auto-merge-method string   Specifies the merge method for the VCS if
-                                   automerge is enabled. (Currently only implemented
-                                   for GitHub)
-  -d, --dir string                 Apply the plan for this directory, relative to
-                                   root of repo, ex. 'child/dir'.
-  -p, --project string             Apply the plan for this project. Refers to the
-                                   name of the project configured in a repo config
-                                   file. Cannot be used at same time as workspace or
-                                   dir flags.
-      --verbose                    Append Atlantis log to comment.
-  -w, --workspace string           Apply the plan for this Terraform workspace.
+      --auto-merge-disabled         Disable automerge after apply.
+      --auto-merge-method string    Specifies the merge method for the VCS if
+                                    automerge is enabled. (Currently only
+                                    implemented for GitHub)
+      --continue-on-group-failure   Keep applying the following execution order
+                                    groups when a group fails.
+  -d, --dir string                  Apply the plan for this directory, relative to
+                                    root of repo, ex. 'child/dir'.
+  -e, --environment string          Apply the plan for this environment of the
+                                    project. Requires the project flag.
+      --exclude-dir glob            Skip plans whose directory matches this glob,
+                                    ex. 'modules/**'. Can be repeated.
+      --include-dir glob            Only apply plans whose directory matches this
+                                    glob, ex. 'envs/prod/**'. Can be repeated.
+  -p, --project string              Apply the plan for this project. Refers to the
+                                    name of the project configured in a repo config
+                                    file. Cannot be used at same time as dir flag.
+      --verbose                     Append Atlantis log to comment.
+  -w, --workspace string            Apply the plan for this Terraform workspace.
 `
 
 var ApprovePolicyUsage = `Usage of approve_policies:
@@ -1082,8 +1331,9 @@ var ApprovePolicyUsage = `Usage of approve_policies:
                                 dir flags.
   -p, --project string          Approve policies for this project. Refers to the
                                 name of the project configured in a repo config
-                                file. Cannot be used at same time as workspace or
-                                dir flags.
+                                file. Cannot be used at same time as dir flag.
+      --reason string           Justification for approving the failing policies.
+                                Recorded with the approval.
       --verbose                 Append Atlantis log to comment.
   -w, --workspace string        Approve policies for this Terraform workspace.
 `
@@ -1101,7 +1351,18 @@ var ImportUsage = `Usage of import ADDRESS ID:
                            repo, ex. 'child/dir'.
   -p, --project string     Which project to run import for. Refers to the name of
                            the project configured in a repo config file. Cannot be
-                           used at same time as workspace or dir flags.
+                           used at same time as dir flag.
       --verbose            Append Atlantis log to comment.
   -w, --workspace string   Switch to this Terraform workspace before importing.
 `
+
+var ForceUnlockUsage = `Usage of force-unlock LOCK_ID:
+  -d, --dir string         Which directory to release the state lock in relative to
+                           root of repo, ex. 'child/dir'.
+  -p, --project string     Which project to release the state lock for. Refers to
+                           the name of the project configured in a repo config file.
+                           Cannot be used at same time as dir flag.
+      --verbose            Append Atlantis log to comment.
+  -w, --workspace string   Switch to this Terraform workspace before releasing the
+                           state lock.
+`
@@ -15,9 +15,14 @@ package events
 
 import (
 	"fmt"
+	"path"
+	"strings"
 
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/messages"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -36,6 +41,10 @@ type CommitStatusUpdater interface {
 	// UpdateCombinedCount updates the combined status to reflect the
 	// numSuccess out of numTotal.
 	UpdateCombinedCount(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, status models.CommitStatus, cmdName command.Name, numSuccess int, numTotal int) error
+	// UpdateSummary updates the summary status of the head commit of pull
+	// to reflect how many of the projects in pullStatus are planned and
+	// applied. It does nothing unless the summary status is enabled.
+	UpdateSummary(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, pullStatus models.PullStatus) error
 
 	UpdatePreWorkflowHook(logger logging.SimpleLogging, pull models.PullRequest, status models.CommitStatus, hookDescription string, runtimeDescription string, url string) error
 	UpdatePostWorkflowHook(logger logging.SimpleLogging, pull models.PullRequest, status models.CommitStatus, hookDescription string, runtimeDescription string, url string) error
@@ -46,6 +55,27 @@ type DefaultCommitStatusUpdater struct {
 	Client vcs.Client
 	// StatusName is the name used to identify Atlantis when creating PR statuses.
 	StatusName string
+	// CheckRunUpdater, if set, is used to report statuses on GitHub pull
+	// requests as check runs instead of commit statuses.
+	CheckRunUpdater vcs.GithubCheckRunUpdater
+	// Redactor masks secrets in check run output.
+	Redactor *logging.Redactor
+	// SummaryStatus is true if a single status that aggregates the plans
+	// and applies of all projects should be reported.
+	SummaryStatus bool
+	// DisableProjectStatuses is true if no status should be reported for
+	// each project.
+	DisableProjectStatuses bool
+	// Messages overrides the text of status descriptions.
+	Messages *messages.Catalog
+}
+
+// CheckRunResultsUpdater updates GitHub check runs with the results of
+// commands.
+type CheckRunResultsUpdater interface {
+	// UpdateCheckRunResults sets the output of the check run for cmdName to
+	// the rendered results of the command.
+	UpdateCheckRunResults(ctx *command.Context, cmdName command.Name, res command.Result, rendered string) error
 }
 
 // ensure DefaultCommitStatusUpdater implements runtime.StatusUpdater interface
@@ -57,55 +87,290 @@ func (d *DefaultCommitStatusUpdater) UpdateCombined(logger logging.SimpleLogging
 	var descripWords string
 	switch status {
 	case models.PendingCommitStatus:
-		descripWords = genProjectStatusDescription(cmdName.String(), "in progress...")
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusPending)
 	case models.FailedCommitStatus:
-		descripWords = genProjectStatusDescription(cmdName.String(), "failed.")
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusFailed)
+	case models.CanceledCommitStatus:
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusCanceled)
 	case models.SuccessCommitStatus:
-		descripWords = genProjectStatusDescription(cmdName.String(), "succeeded.")
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusSucceeded)
+	}
+	if d.usesCheckRuns(repo) {
+		return d.CheckRunUpdater.UpdateCheckRun(logger, repo, pull, combinedCheckRunOptions(src, status, cmdName, descripWords))
 	}
 	return d.Client.UpdateStatus(logger, repo, pull, status, src, descripWords, "")
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateCombinedCount(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, status models.CommitStatus, cmdName command.Name, numSuccess int, numTotal int) error {
 	src := fmt.Sprintf("%s/%s", d.StatusName, cmdName.String())
-	cmdVerb := "unknown"
+	description := d.genCountStatusDescription(cmdName, numSuccess, numTotal)
+	if d.usesCheckRuns(repo) {
+		return d.CheckRunUpdater.UpdateCheckRun(logger, repo, pull, combinedCheckRunOptions(src, status, cmdName, description))
+	}
+	return d.Client.UpdateStatus(logger, repo, pull, status, src, description, "")
+}
+
+func (d *DefaultCommitStatusUpdater) UpdateSummary(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, pullStatus models.PullStatus) error {
+	if !d.SummaryStatus {
+		return nil
+	}
+	src := fmt.Sprintf("%s/summary", d.StatusName)
+	numTotal := len(pullStatus.Projects)
+	numErrored := pullStatus.StatusCount(models.ErroredPlanStatus) + pullStatus.StatusCount(models.ErroredApplyStatus)
+	// Like the plan status, anything that isn't a plan error counts as
+	// planned.
+	numPlanned := numTotal - pullStatus.StatusCount(models.ErroredPlanStatus)
+	numApplied := pullStatus.StatusCount(models.AppliedPlanStatus) + pullStatus.StatusCount(models.PlannedNoChangesPlanStatus)
 
+	status := models.SuccessCommitStatus
+	if numErrored > 0 {
+		status = models.FailedCommitStatus
+	} else if numApplied < numTotal {
+		status = models.PendingCommitStatus
+	}
+	description := d.Messages.Sprintf(messages.StatusSummary, numPlanned, numTotal, numApplied, numTotal)
+	if d.usesCheckRuns(repo) {
+		return d.CheckRunUpdater.UpdateCheckRun(logger, repo, pull, vcs.CheckRunOptions{
+			Name:    src,
+			State:   status,
+			Title:   description,
+			Summary: description,
+		})
+	}
+	return d.Client.UpdateStatus(logger, repo, pull, status, src, description, "")
+}
+
+func (d *DefaultCommitStatusUpdater) genCountStatusDescription(cmdName command.Name, numSuccess int, numTotal int) string {
 	switch cmdName {
 	case command.Plan:
-		cmdVerb = "planned"
+		return d.Messages.Sprintf(messages.StatusPlanCount, numSuccess, numTotal)
 	case command.PolicyCheck:
-		cmdVerb = "policies checked"
+		return d.Messages.Sprintf(messages.StatusPolicyCheckCount, numSuccess, numTotal)
 	case command.Apply:
-		cmdVerb = "applied"
+		return d.Messages.Sprintf(messages.StatusApplyCount, numSuccess, numTotal)
 	}
-
-	return d.Client.UpdateStatus(logger, repo, pull, status, src, fmt.Sprintf("%d/%d projects %s successfully.", numSuccess, numTotal, cmdVerb), "")
+	return fmt.Sprintf("%d/%d projects unknown successfully.", numSuccess, numTotal)
 }
 
 func (d *DefaultCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, cmdName command.Name, status models.CommitStatus, url string, result *command.ProjectResult) error {
+	if d.DisableProjectStatuses {
+		return nil
+	}
+	if ctx.PRCommentModes[cmdName.String()] == valid.SilentPRComments {
+		return nil
+	}
 	projectID := ctx.ProjectName
 	if projectID == "" {
 		projectID = fmt.Sprintf("%s/%s", ctx.RepoRelDir, ctx.Workspace)
+	} else if ctx.Environment != "" {
+		projectID = fmt.Sprintf("%s/%s", ctx.ProjectName, ctx.Environment)
+	} else if ctx.WorkspaceQualified {
+		projectID = fmt.Sprintf("%s/%s", ctx.ProjectName, ctx.Workspace)
 	}
 	src := fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectID)
 	var descripWords string
 	switch status {
 	case models.PendingCommitStatus:
-		descripWords = genProjectStatusDescription(cmdName.String(), "in progress...")
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusPending)
 	case models.FailedCommitStatus:
-		descripWords = genProjectStatusDescription(cmdName.String(), "failed.")
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusFailed)
+	case models.CanceledCommitStatus:
+		descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusCanceled)
 	case models.SuccessCommitStatus:
 		if result != nil && result.PlanSuccess != nil {
 			descripWords = result.PlanSuccess.DiffSummary()
+			// Lead with the badge so the impact of the plan is visible in
+			// the checks list, which truncates long descriptions.
+			if badge := result.PlanSuccess.Stats().Badge(); badge != "" {
+				descripWords = fmt.Sprintf("%s %s", badge, descripWords)
+			}
 		} else {
-			descripWords = genProjectStatusDescription(cmdName.String(), "succeeded.")
+			descripWords = d.genProjectStatusDescription(cmdName.String(), messages.StatusSucceeded)
 		}
 	}
+	if d.usesCheckRuns(ctx.BaseRepo) {
+		rerunCmd := rerunCommandName(cmdName).String()
+		if ctx.ProjectName != "" {
+			rerunCmd += " -p " + ctx.ProjectName
+			if ctx.Environment != "" {
+				rerunCmd += " -e " + ctx.Environment
+			} else if ctx.WorkspaceQualified {
+				rerunCmd += " -w " + ctx.Workspace
+			}
+		} else {
+			rerunCmd += fmt.Sprintf(" -d %s -w %s", ctx.RepoRelDir, ctx.Workspace)
+		}
+		return d.CheckRunUpdater.UpdateCheckRun(ctx.Log, ctx.BaseRepo, ctx.Pull, vcs.CheckRunOptions{
+			Name:       src,
+			State:      status,
+			Title:      descripWords,
+			Summary:    d.Redactor.Redact(projectCheckRunSummary(descripWords, result)),
+			ExternalID: rerunCmd,
+			DetailsURL: url,
+		})
+	}
 	return d.Client.UpdateStatus(ctx.Log, ctx.BaseRepo, ctx.Pull, status, src, descripWords, url)
 }
 
-func genProjectStatusDescription(cmdName, description string) string {
-	return fmt.Sprintf("%s %s", cases.Title(language.English).String(cmdName), description)
+// UpdateCheckRunResults sets the output of the check run for cmdName to the
+// rendered results of the command and annotates the modified Terraform files
+// of projects that failed policy checks. It does nothing if check runs aren't
+// used for the pull request.
+func (d *DefaultCommitStatusUpdater) UpdateCheckRunResults(ctx *command.Context, cmdName command.Name, res command.Result, rendered string) error {
+	if !d.usesCheckRuns(ctx.Pull.BaseRepo) {
+		return nil
+	}
+	switch cmdName {
+	case command.Plan, command.PolicyCheck, command.Apply:
+	case command.ApprovePolicies:
+		// Approving policies updates the policy check status.
+		cmdName = command.PolicyCheck
+	default:
+		return nil
+	}
+
+	title := d.genProjectStatusDescription(cmdName.String(), messages.StatusFailed)
+	if res.Error == nil && res.Failure == "" {
+		numSuccess := 0
+		for _, r := range res.ProjectResults {
+			if r.IsSuccessful() {
+				numSuccess++
+			}
+		}
+		title = d.genCountStatusDescription(cmdName, numSuccess, len(res.ProjectResults))
+	}
+	annotations, err := d.policyCheckAnnotations(ctx, res)
+	if err != nil {
+		return err
+	}
+	return d.CheckRunUpdater.UpdateCheckRun(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, vcs.CheckRunOptions{
+		Name:        fmt.Sprintf("%s/%s", d.StatusName, cmdName.String()),
+		Title:       title,
+		Summary:     d.Redactor.Redact(rendered),
+		Annotations: annotations,
+		ExternalID:  rerunCommandName(cmdName).String(),
+	})
+}
+
+// policyCheckAnnotations annotates the modified Terraform files of each
+// project with the output of the policy sets it failed.
+func (d *DefaultCommitStatusUpdater) policyCheckAnnotations(ctx *command.Context, res command.Result) ([]vcs.CheckRunAnnotation, error) {
+	var annotations []vcs.CheckRunAnnotation
+	var modifiedFiles []string
+	fetchedFiles := false
+	for _, r := range res.ProjectResults {
+		if r.PolicyCheckResults == nil {
+			continue
+		}
+		for _, policySet := range r.PolicyCheckResults.PolicySetResults {
+			if policySet.Passed {
+				continue
+			}
+			if !fetchedFiles {
+				var err error
+				modifiedFiles, err = d.Client.GetModifiedFiles(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
+				if err != nil {
+					return nil, errors.Wrap(err, "getting modified files")
+				}
+				fetchedFiles = true
+			}
+			for _, file := range modifiedFiles {
+				if path.Ext(file) != ".tf" || path.Dir(file) != path.Clean(r.RepoRelDir) {
+					continue
+				}
+				annotations = append(annotations, vcs.CheckRunAnnotation{
+					Path:      file,
+					StartLine: 1,
+					EndLine:   1,
+					Level:     "failure",
+					Title:     fmt.Sprintf("Policy set %s failed", policySet.PolicySetName),
+					Message:   d.Redactor.Redact(policySet.PolicyOutput),
+				})
+			}
+		}
+	}
+	return annotations, nil
+}
+
+func (d *DefaultCommitStatusUpdater) usesCheckRuns(repo models.Repo) bool {
+	return d.CheckRunUpdater != nil && repo.VCSHost.Type == models.Github
+}
+
+// combinedCheckRunOptions describes the check run for the combined status of
+// cmdName. Its output is reset when the command starts and is otherwise left
+// to UpdateCheckRunResults.
+func combinedCheckRunOptions(src string, status models.CommitStatus, cmdName command.Name, description string) vcs.CheckRunOptions {
+	opts := vcs.CheckRunOptions{
+		Name:       src,
+		State:      status,
+		Title:      description,
+		ExternalID: rerunCommandName(cmdName).String(),
+		Actions:    checkRunActions(cmdName, status),
+	}
+	if status == models.PendingCommitStatus {
+		opts.Summary = description
+	}
+	return opts
+}
+
+// rerunCommandName is the command that's run when the check run for cmdName
+// is re-run.
+func rerunCommandName(cmdName command.Name) command.Name {
+	if cmdName == command.Apply {
+		return command.Apply
+	}
+	return command.Plan
+}
+
+// checkRunActions are the buttons shown on the check run for cmdName. Their
+// identifiers are the commands they run.
+func checkRunActions(cmdName command.Name, status models.CommitStatus) []vcs.CheckRunAction {
+	if status == models.PendingCommitStatus {
+		return nil
+	}
+	replan := vcs.CheckRunAction{Label: "Re-plan", Description: "Plan all modified projects again", Identifier: command.Plan.String()}
+	apply := vcs.CheckRunAction{Label: "Apply", Description: "Apply all unapplied plans", Identifier: command.Apply.String()}
+	switch cmdName {
+	case command.Plan:
+		if status == models.SuccessCommitStatus {
+			return []vcs.CheckRunAction{replan, apply}
+		}
+		return []vcs.CheckRunAction{replan}
+	case command.PolicyCheck:
+		return []vcs.CheckRunAction{replan}
+	case command.Apply:
+		if status == models.FailedCommitStatus {
+			return []vcs.CheckRunAction{replan, apply}
+		}
+		// The plan of a canceled apply may be partly applied so it has to
+		// be planned again.
+		if status == models.CanceledCommitStatus {
+			return []vcs.CheckRunAction{replan}
+		}
+	}
+	return nil
+}
+
+// projectCheckRunSummary is the output of a project's check run.
+func projectCheckRunSummary(description string, result *command.ProjectResult) string {
+	if result == nil {
+		return description
+	}
+	switch {
+	case result.Error != nil:
+		return fmt.Sprintf("%s\n\n```\n%s\n```", description, result.Error)
+	case result.Failure != "":
+		return fmt.Sprintf("%s\n\n%s", description, result.Failure)
+	case result.PlanSuccess != nil:
+		return fmt.Sprintf("%s\n\n```diff\n%s\n```", description, strings.TrimSpace(result.PlanSuccess.TerraformOutput))
+	case result.ApplySuccess != "":
+		return fmt.Sprintf("%s\n\n```diff\n%s\n```", description, strings.TrimSpace(result.ApplySuccess))
+	}
+	return description
+}
+
+func (d *DefaultCommitStatusUpdater) genProjectStatusDescription(cmdName string, id messages.ID) string {
+	return d.Messages.Sprintf(id, cases.Title(language.English).String(cmdName))
 }
 
 func (d *DefaultCommitStatusUpdater) UpdatePreWorkflowHook(log logging.SimpleLogging, pull models.PullRequest, status models.CommitStatus, hookDescription string, runtimeDescription string, url string) error {
@@ -15,12 +15,17 @@ package events_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/messages"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
@@ -146,15 +151,104 @@ func TestUpdateCombinedCount(t *testing.T) {
 	}
 }
 
+func TestUpdateSummary(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := []struct {
+		description string
+		statuses    []models.ProjectPlanStatus
+		expStatus   models.CommitStatus
+		expDescrip  string
+	}{
+		{
+			description: "no projects",
+			expStatus:   models.SuccessCommitStatus,
+			expDescrip:  "0/0 projects planned, 0/0 applied.",
+		},
+		{
+			description: "planned",
+			statuses:    []models.ProjectPlanStatus{models.PlannedPlanStatus, models.PlannedNoChangesPlanStatus},
+			expStatus:   models.PendingCommitStatus,
+			expDescrip:  "2/2 projects planned, 1/2 applied.",
+		},
+		{
+			description: "plan failed",
+			statuses:    []models.ProjectPlanStatus{models.ErroredPlanStatus, models.PlannedPlanStatus},
+			expStatus:   models.FailedCommitStatus,
+			expDescrip:  "1/2 projects planned, 0/2 applied.",
+		},
+		{
+			description: "apply failed",
+			statuses:    []models.ProjectPlanStatus{models.ErroredApplyStatus, models.AppliedPlanStatus},
+			expStatus:   models.FailedCommitStatus,
+			expDescrip:  "2/2 projects planned, 1/2 applied.",
+		},
+		{
+			description: "applied",
+			statuses:    []models.ProjectPlanStatus{models.AppliedPlanStatus, models.PlannedNoChangesPlanStatus},
+			expStatus:   models.SuccessCommitStatus,
+			expDescrip:  "2/2 projects planned, 2/2 applied.",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			client := mocks.NewMockClient()
+			s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis-test", SummaryStatus: true}
+			var pullStatus models.PullStatus
+			for _, status := range c.statuses {
+				pullStatus.Projects = append(pullStatus.Projects, models.ProjectStatus{Status: status})
+			}
+			err := s.UpdateSummary(logger, models.Repo{}, models.PullRequest{}, pullStatus)
+			Ok(t, err)
+			client.VerifyWasCalledOnce().UpdateStatus(logger, models.Repo{}, models.PullRequest{}, c.expStatus, "atlantis-test/summary", c.expDescrip, "")
+		})
+	}
+}
+
+func TestUpdateSummary_Disabled(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis"}
+	Ok(t, s.UpdateSummary(logger, models.Repo{}, models.PullRequest{}, models.PullStatus{}))
+	client.VerifyWasCalled(Never()).UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[string](), Any[string](), Any[string]())
+}
+
+func TestDefaultCommitStatusUpdater_UpdateProjectDisabled(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", DisableProjectStatuses: true}
+	err := s.UpdateProject(command.ProjectContext{RepoRelDir: ".", Workspace: "default"}, command.Plan, models.PendingCommitStatus, "url", nil)
+	Ok(t, err)
+	client.VerifyWasCalled(Never()).UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[string](), Any[string](), Any[string]())
+}
+
+// Projects whose command is silent in pr_comments don't get a status, but
+// other commands still do.
+func TestDefaultCommitStatusUpdater_UpdateProjectSilent(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis"}
+	ctx := command.ProjectContext{RepoRelDir: ".", Workspace: "default", PRCommentModes: map[string]string{"plan": valid.SilentPRComments}}
+	Ok(t, s.UpdateProject(ctx, command.Plan, models.PendingCommitStatus, "url", nil))
+	client.VerifyWasCalled(Never()).UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[string](), Any[string](), Any[string]())
+
+	Ok(t, s.UpdateProject(ctx, command.Apply, models.PendingCommitStatus, "url", nil))
+	client.VerifyWasCalledOnce().UpdateStatus(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Eq(models.PendingCommitStatus), Eq("atlantis/apply: ./default"), Any[string](), Eq("url"))
+}
+
 // Test that it sets the "source" properly depending on if the project is
 // named or not.
 func TestDefaultCommitStatusUpdater_UpdateProjectSrc(t *testing.T) {
 	RegisterMockTestingT(t)
 	cases := []struct {
-		projectName string
-		repoRelDir  string
-		workspace   string
-		expSrc      string
+		projectName        string
+		environment        string
+		workspaceQualified bool
+		repoRelDir         string
+		workspace          string
+		expSrc             string
 	}{
 		{
 			projectName: "name",
@@ -168,6 +262,20 @@ func TestDefaultCommitStatusUpdater_UpdateProjectSrc(t *testing.T) {
 			workspace:   "workspace",
 			expSrc:      "atlantis/plan: dir1/dir2/workspace",
 		},
+		{
+			projectName: "name",
+			environment: "staging",
+			repoRelDir:  ".",
+			workspace:   "staging",
+			expSrc:      "atlantis/plan: name/staging",
+		},
+		{
+			projectName:        "name",
+			workspaceQualified: true,
+			repoRelDir:         ".",
+			workspace:          "prod",
+			expSrc:             "atlantis/plan: name/prod",
+		},
 	}
 
 	for _, c := range cases {
@@ -175,9 +283,11 @@ func TestDefaultCommitStatusUpdater_UpdateProjectSrc(t *testing.T) {
 			client := mocks.NewMockClient()
 			s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis"}
 			err := s.UpdateProject(command.ProjectContext{
-				ProjectName: c.projectName,
-				RepoRelDir:  c.repoRelDir,
-				Workspace:   c.workspace,
+				ProjectName:        c.projectName,
+				Environment:        c.environment,
+				WorkspaceQualified: c.workspaceQualified,
+				RepoRelDir:         c.repoRelDir,
+				Workspace:          c.workspace,
 			}, command.Plan, models.PendingCommitStatus, "url", nil)
 			Ok(t, err)
 			client.VerifyWasCalledOnce().UpdateStatus(
@@ -214,7 +324,7 @@ func TestDefaultCommitStatusUpdater_UpdateProject(t *testing.T) {
 					TerraformOutput: "aaa\nNote: Objects have changed outside of Terraform\nbbb\nPlan: 1 to add, 2 to change, 3 to destroy.\nbbb",
 				},
 			},
-			expDescrip: "Plan: 1 to add, 2 to change, 3 to destroy.",
+			expDescrip: "+1 ~2 -3 Plan: 1 to add, 2 to change, 3 to destroy.",
 		},
 		{
 			status:     models.PendingCommitStatus,
@@ -226,6 +336,11 @@ func TestDefaultCommitStatusUpdater_UpdateProject(t *testing.T) {
 			cmd:        command.Apply,
 			expDescrip: "Apply failed.",
 		},
+		{
+			status:     models.CanceledCommitStatus,
+			cmd:        command.Apply,
+			expDescrip: "Apply canceled.",
+		},
 		{
 			status: models.SuccessCommitStatus,
 			cmd:    command.Apply,
@@ -264,3 +379,139 @@ func TestDefaultCommitStatusUpdater_UpdateProjectCustomStatusName(t *testing.T)
 	client.VerifyWasCalledOnce().UpdateStatus(Any[logging.SimpleLogging](), Eq(models.Repo{}), Eq(models.PullRequest{}),
 		Eq(models.SuccessCommitStatus), Eq("custom/apply: ./default"), Eq("Apply succeeded."), Eq("url"))
 }
+
+func TestDefaultCommitStatusUpdater_CheckRuns(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	githubRepo := models.Repo{VCSHost: models.VCSHost{Type: models.Github}}
+	pull := models.PullRequest{BaseRepo: githubRepo}
+	client := mocks.NewMockClient()
+	checkRuns := mocks.NewMockGithubCheckRunUpdater()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", CheckRunUpdater: checkRuns}
+
+	t.Run("pending resets the output", func(t *testing.T) {
+		err := s.UpdateCombined(logger, githubRepo, pull, models.PendingCommitStatus, command.Plan)
+		Ok(t, err)
+		checkRuns.VerifyWasCalledOnce().UpdateCheckRun(logger, githubRepo, pull, vcs.CheckRunOptions{
+			Name:       "atlantis/plan",
+			State:      models.PendingCommitStatus,
+			Title:      "Plan in progress...",
+			Summary:    "Plan in progress...",
+			ExternalID: "plan",
+		})
+	})
+
+	t.Run("success adds buttons and keeps the output", func(t *testing.T) {
+		err := s.UpdateCombinedCount(logger, githubRepo, pull, models.SuccessCommitStatus, command.Plan, 2, 2)
+		Ok(t, err)
+		checkRuns.VerifyWasCalledOnce().UpdateCheckRun(logger, githubRepo, pull, vcs.CheckRunOptions{
+			Name:       "atlantis/plan",
+			State:      models.SuccessCommitStatus,
+			Title:      "2/2 projects planned successfully.",
+			ExternalID: "plan",
+			Actions: []vcs.CheckRunAction{
+				{Label: "Re-plan", Description: "Plan all modified projects again", Identifier: "plan"},
+				{Label: "Apply", Description: "Apply all unapplied plans", Identifier: "apply"},
+			},
+		})
+	})
+
+	t.Run("projects get their own check runs", func(t *testing.T) {
+		ctx := command.ProjectContext{
+			Log:        logger,
+			BaseRepo:   githubRepo,
+			Pull:       pull,
+			RepoRelDir: "dir",
+			Workspace:  "default",
+		}
+		result := &command.ProjectResult{PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 1 to add, 0 to change, 0 to destroy."}}
+		err := s.UpdateProject(ctx, command.Plan, models.SuccessCommitStatus, "https://job", result)
+		Ok(t, err)
+		checkRuns.VerifyWasCalledOnce().UpdateCheckRun(logger, githubRepo, pull, vcs.CheckRunOptions{
+			Name:       "atlantis/plan: dir/default",
+			State:      models.SuccessCommitStatus,
+			Title:      "+1 ~0 -0 Plan: 1 to add, 0 to change, 0 to destroy.",
+			Summary:    "+1 ~0 -0 Plan: 1 to add, 0 to change, 0 to destroy.\n\n```diff\nPlan: 1 to add, 0 to change, 0 to destroy.\n```",
+			ExternalID: "plan -d dir -w default",
+			DetailsURL: "https://job",
+		})
+	})
+
+	t.Run("other hosts get commit statuses", func(t *testing.T) {
+		gitlabRepo := models.Repo{VCSHost: models.VCSHost{Type: models.Gitlab}}
+		err := s.UpdateCombined(logger, gitlabRepo, pull, models.PendingCommitStatus, command.Plan)
+		Ok(t, err)
+		client.VerifyWasCalledOnce().UpdateStatus(logger, gitlabRepo, pull, models.PendingCommitStatus, "atlantis/plan", "Plan in progress...", "")
+	})
+}
+
+func TestDefaultCommitStatusUpdater_UpdateCheckRunResults(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	redactor, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+	pull := models.PullRequest{BaseRepo: models.Repo{VCSHost: models.VCSHost{Type: models.Github}}}
+	ctx := &command.Context{Log: logger, Pull: pull}
+	client := mocks.NewMockClient()
+	When(client.GetModifiedFiles(logger, pull.BaseRepo, pull)).ThenReturn([]string{"dir/main.tf", "dir/README.md", "other/main.tf"}, nil)
+	checkRuns := mocks.NewMockGithubCheckRunUpdater()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", CheckRunUpdater: checkRuns, Redactor: redactor}
+
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				RepoRelDir: "dir",
+				Workspace:  "default",
+				Failure:    "policies failed",
+				PolicyCheckResults: &models.PolicyCheckResults{
+					PolicySetResults: []models.PolicySetResult{
+						{PolicySetName: "passing", Passed: true},
+						{PolicySetName: "failing", PolicyOutput: "FAIL - password s3cr3t"},
+					},
+				},
+			},
+		},
+	}
+	err = s.UpdateCheckRunResults(ctx, command.PolicyCheck, res, "rendered s3cr3t")
+	Ok(t, err)
+	checkRuns.VerifyWasCalledOnce().UpdateCheckRun(logger, pull.BaseRepo, pull, vcs.CheckRunOptions{
+		Name:    "atlantis/policy_check",
+		Title:   "0/1 projects policies checked successfully.",
+		Summary: "rendered [REDACTED]",
+		Annotations: []vcs.CheckRunAnnotation{
+			{
+				Path:      "dir/main.tf",
+				StartLine: 1,
+				EndLine:   1,
+				Level:     "failure",
+				Title:     "Policy set failing failed",
+				Message:   "FAIL - password [REDACTED]",
+			},
+		},
+		ExternalID: "plan",
+	})
+
+	// Commands without check runs are ignored.
+	err = s.UpdateCheckRunResults(ctx, command.Unlock, command.Result{}, "unlocked")
+	Ok(t, err)
+	checkRuns.VerifyWasCalledOnce().UpdateCheckRun(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[vcs.CheckRunOptions]())
+}
+
+func TestDefaultCommitStatusUpdater_Messages(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	path := filepath.Join(t.TempDir(), "messages.yaml")
+	Ok(t, os.WriteFile(path, []byte(`
+status.pending: "%s en cours..."
+status.plan-count: "%d/%d projets planifiés."
+`), 0600))
+	catalog, err := messages.Load(path)
+	Ok(t, err)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis", Messages: catalog}
+
+	Ok(t, s.UpdateCombined(logger, models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, command.Plan))
+	client.VerifyWasCalledOnce().UpdateStatus(logger, models.Repo{}, models.PullRequest{}, models.PendingCommitStatus, "atlantis/plan", "Plan en cours...", "")
+	Ok(t, s.UpdateCombinedCount(logger, models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, command.Plan, 1, 2))
+	client.VerifyWasCalledOnce().UpdateStatus(logger, models.Repo{}, models.PullRequest{}, models.SuccessCommitStatus, "atlantis/plan", "1/2 projets planifiés.", "")
+}
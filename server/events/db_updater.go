@@ -8,6 +8,9 @@ import (
 
 type DBUpdater struct {
 	Backend locking.Backend
+	// PullDescriptionUpdater keeps the status block in the pull request
+	// description up to date. It is nil if that's disabled.
+	PullDescriptionUpdater *PullDescriptionStatusUpdater
 }
 
 func (c *DBUpdater) updateDB(ctx *command.Context, pull models.PullRequest, results []command.ProjectResult) (models.PullStatus, error) {
@@ -20,8 +23,18 @@ func (c *DBUpdater) updateDB(ctx *command.Context, pull models.PullRequest, resu
 			ctx.Log.Debug("ignoring error result from project at dir %q workspace %q because it is dir not exist error", r.RepoRelDir, r.Workspace)
 			continue
 		}
+		r.Trigger, r.CommentID = ctx.CommandTrigger(), ctx.CommentID
 		filtered = append(filtered, r)
 	}
 	ctx.Log.Debug("updating DB with pull results")
-	return c.Backend.UpdatePullWithResults(pull, filtered)
+	pullStatus, err := c.Backend.UpdatePullWithResults(pull, filtered)
+	if err != nil {
+		return pullStatus, err
+	}
+	if c.PullDescriptionUpdater != nil {
+		if err := c.PullDescriptionUpdater.Update(ctx.Log, pull, pullStatus); err != nil {
+			ctx.Log.Warn("unable to update pull request description status: %s", err)
+		}
+	}
+	return pullStatus, nil
 }
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// DeletedBranchCleaner cleans up after pull requests whose source branch was
+// deleted. Some VCS hosts close these pull requests without sending a pull
+// request event, or leave them open with nothing left to apply, so their
+// locks would otherwise be held until someone unlocks them.
+type DeletedBranchCleaner interface {
+	// CleanUpDeletedBranch cleans up like PullCleaner.CleanUpPull after each
+	// pull request of the repo repoFullName on vcsHostType whose source branch
+	// is branch. It returns how many pull requests were cleaned up.
+	CleanUpDeletedBranch(logger logging.SimpleLogging, vcsHostType models.VCSHostType, repoFullName string, branch string) (int, error)
+}
+
+// DefaultDeletedBranchCleaner finds the pull requests of a deleted branch
+// among the pull requests that have locks or statuses.
+type DefaultDeletedBranchCleaner struct {
+	Backend     locking.Backend
+	PullCleaner PullCleaner
+}
+
+// CleanUpDeletedBranch cleans up after the pull requests of branch. Pull
+// requests from forks aren't cleaned up because their branches are deleted
+// in the fork.
+func (c *DefaultDeletedBranchCleaner) CleanUpDeletedBranch(logger logging.SimpleLogging, vcsHostType models.VCSHostType, repoFullName string, branch string) (int, error) {
+	snapshot, err := c.Backend.Snapshot()
+	if err != nil {
+		return 0, err
+	}
+	pulls := make(map[int]models.PullRequest)
+	matches := func(pull models.PullRequest) bool {
+		return pull.BaseRepo.VCSHost.Type == vcsHostType && pull.BaseRepo.FullName == repoFullName && pull.HeadBranch == branch
+	}
+	for _, pullStatus := range snapshot.Pulls {
+		if matches(pullStatus.Pull) {
+			pulls[pullStatus.Pull.Num] = pullStatus.Pull
+		}
+	}
+	for _, lock := range snapshot.Locks {
+		if _, ok := pulls[lock.Pull.Num]; !ok && matches(lock.Pull) {
+			pulls[lock.Pull.Num] = lock.Pull
+		}
+	}
+
+	var cleaned int
+	var errs []error
+	for _, pull := range pulls {
+		logger.Info("branch %q of pull request %d was deleted, cleaning up...", branch, pull.Num)
+		if err := c.PullCleaner.CleanUpPull(logger, pull.BaseRepo, pull); err != nil {
+			errs = append(errs, fmt.Errorf("cleaning up pull request %d: %w", pull.Num, err))
+			continue
+		}
+		cleaned++
+	}
+	return cleaned, errors.Join(errs...)
+}
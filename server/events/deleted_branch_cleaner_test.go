@@ -0,0 +1,48 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDefaultDeletedBranchCleaner_CleanUpDeletedBranch(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Gitlab}}
+	planned := models.PullRequest{Num: 1, BaseRepo: repo, HeadBranch: "feature"}
+	locked := models.PullRequest{Num: 2, BaseRepo: repo, HeadBranch: "feature"}
+	otherBranch := models.PullRequest{Num: 3, BaseRepo: repo, HeadBranch: "other"}
+	otherRepo := models.PullRequest{Num: 4, BaseRepo: models.Repo{FullName: "owner/other", VCSHost: repo.VCSHost}, HeadBranch: "feature"}
+
+	backend := lockmocks.NewMockBackend()
+	When(backend.Snapshot()).ThenReturn(locking.Snapshot{
+		Pulls: []models.PullStatus{{Pull: planned}, {Pull: otherBranch}, {Pull: otherRepo}},
+		Locks: []models.ProjectLock{{Pull: planned}, {Pull: locked}},
+	}, nil)
+	pullCleaner := mocks.NewMockPullCleaner()
+
+	cleaner := &events.DefaultDeletedBranchCleaner{
+		Backend:     backend,
+		PullCleaner: pullCleaner,
+	}
+	cleaned, err := cleaner.CleanUpDeletedBranch(logger, models.Gitlab, "owner/repo", "feature")
+	Ok(t, err)
+	Equals(t, 2, cleaned)
+	pullCleaner.VerifyWasCalledOnce().CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Eq(planned))
+	pullCleaner.VerifyWasCalledOnce().CleanUpPull(Any[logging.SimpleLogging](), Eq(repo), Eq(locked))
+	pullCleaner.VerifyWasCalled(Never()).CleanUpPull(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(otherBranch))
+	pullCleaner.VerifyWasCalled(Never()).CleanUpPull(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(otherRepo))
+
+	// Pull requests on other VCS hosts aren't cleaned up.
+	cleaned, err = cleaner.CleanUpDeletedBranch(logger, models.Github, "owner/repo", "feature")
+	Ok(t, err)
+	Equals(t, 0, cleaned)
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"runtime"
+
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// discoveryWorkers is how many dirs, files or projects are checked at once
+// during project discovery. Discovery is mostly reading small files so it
+// isn't limited to the number of CPUs.
+var discoveryWorkers = 4 * runtime.NumCPU()
+
+// forEachParallel calls f for each index from 0 to n-1 using up to
+// discoveryWorkers goroutines and waits for them to finish. f must only write
+// results to slots of its index so that they stay in order.
+func forEachParallel(n int, f func(i int)) {
+	wg := sizedwaitgroup.New(discoveryWorkers)
+	for i := 0; i < n; i++ {
+		wg.Add()
+		go func() {
+			defer wg.Done()
+			f(i)
+		}()
+	}
+	wg.Wait()
+}
@@ -37,11 +37,20 @@ import (
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
-const gitlabPullOpened = "opened"
+const (
+	gitlabPullOpened = "opened"
+	gitlabPullMerged = "merged"
+)
+
 const usagesCols = 90
 
 var lastBitbucketSha, _ = lru.New[string, string](300)
 
+// draftBitbucketPulls holds the URLs of the Bitbucket Cloud pull requests we
+// last saw as drafts. Bitbucket Cloud doesn't send an event when a draft is
+// marked as ready for review so we detect it from the next update.
+var draftBitbucketPulls, _ = lru.New[string, bool](300)
+
 // PullCommand is a command to run on a pull request.
 type PullCommand interface {
 	// Dir is the path relative to the repo root to run the command in.
@@ -144,6 +153,29 @@ type CommentCommand struct {
 	PolicySet string
 	// ClearPolicyApproval is true if approvals should be cleared out for specified policies.
 	ClearPolicyApproval bool
+	// IncludeDirs are glob patterns matched against project directories. If
+	// set, only projects whose directory matches one of them are run.
+	IncludeDirs []string
+	// ExcludeDirs are glob patterns matched against project directories.
+	// Projects whose directory matches one of them are skipped.
+	ExcludeDirs []string
+	// ContinueOnGroupFailure is true if an apply should keep applying the
+	// following execution order groups when a group fails.
+	ContinueOnGroupFailure bool
+	// ApprovalReason is the justification given for approving failing policies.
+	ApprovalReason string
+	// Environment is the name of the environment of the project to run the
+	// command on. If empty then the command runs on all its environments.
+	Environment string
+	// ConfirmAll is true if a plan should run even if it affects more projects
+	// than the maximum planned without confirmation.
+	ConfirmAll bool
+	// CommentID is the ID of the comment the command was run from. It isn't
+	// positive if the comment isn't known, ex. the VCS host doesn't tell us.
+	CommentID int64
+	// Confirmed is true if the command is an apply that was confirmed with
+	// `atlantis confirm` so it doesn't have to be confirmed again.
+	Confirmed bool
 }
 
 // IsForSpecificProject returns true if the command is for a specific dir, workspace
@@ -153,6 +185,14 @@ func (c CommentCommand) IsForSpecificProject() bool {
 	return c.RepoRelDir != "" || c.Workspace != "" || c.ProjectName != ""
 }
 
+// matchesProject returns true if the project p is one of the projects the
+// command runs on according to its -p, -d and -w flags.
+func (c CommentCommand) matchesProject(p models.ProjectStatus) bool {
+	return (c.ProjectName == "" || c.ProjectName == p.ProjectName) &&
+		(c.RepoRelDir == "" || c.RepoRelDir == p.RepoRelDir) &&
+		(c.Workspace == "" || c.Workspace == p.Workspace)
+}
+
 // Dir returns the dir of this command.
 func (c CommentCommand) Dir() string {
 	return c.RepoRelDir
@@ -180,7 +220,7 @@ func (c CommentCommand) IsAutoplan() bool {
 
 // String returns a string representation of the command.
 func (c CommentCommand) String() string {
-	return fmt.Sprintf("command=%q, verbose=%t, dir=%q, workspace=%q, project=%q, policyset=%q, auto-merge-disabled=%t, auto-merge-method=%s, clear-policy-approval=%t, flags=%q", c.Name.String(), c.Verbose, c.RepoRelDir, c.Workspace, c.ProjectName, c.PolicySet, c.AutoMergeDisabled, c.AutoMergeMethod, c.ClearPolicyApproval, strings.Join(c.Flags, ","))
+	return fmt.Sprintf("command=%q, verbose=%t, dir=%q, workspace=%q, project=%q, environment=%q, policyset=%q, auto-merge-disabled=%t, auto-merge-method=%s, clear-policy-approval=%t, include-dirs=%q, exclude-dirs=%q, continue-on-group-failure=%t, approval-reason=%q, confirm-all=%t, flags=%q", c.Name.String(), c.Verbose, c.RepoRelDir, c.Workspace, c.ProjectName, c.Environment, c.PolicySet, c.AutoMergeDisabled, c.AutoMergeMethod, c.ClearPolicyApproval, strings.Join(c.IncludeDirs, ","), strings.Join(c.ExcludeDirs, ","), c.ContinueOnGroupFailure, c.ApprovalReason, c.ConfirmAll, strings.Join(c.Flags, ","))
 }
 
 // NewCommentCommand constructs a CommentCommand, setting all missing fields to defaults.
@@ -221,6 +261,14 @@ type EventParsing interface {
 	ParseGithubIssueCommentEvent(logger logging.SimpleLogging, comment *github.IssueCommentEvent) (
 		baseRepo models.Repo, user models.User, pullNum int, err error)
 
+	// ParseGithubPullRequestReviewCommentEvent parses GitHub pull request
+	// review comment events, ie. comments on the diff or in a review thread.
+	// baseRepo is the repo that the pull request will be merged into.
+	// user is the user who commented.
+	// pullNum is the number of the pull request that triggered the webhook.
+	ParseGithubPullRequestReviewCommentEvent(logger logging.SimpleLogging, comment *github.PullRequestReviewCommentEvent) (
+		baseRepo models.Repo, user models.User, pullNum int, err error)
+
 	// ParseGithubPull parses the response from the GitHub API endpoint (not
 	// from a webhook) that returns a pull request.
 	// pull is the parsed pull request.
@@ -296,7 +344,8 @@ type EventParsing interface {
 
 	// GetBitbucketCloudPullEventType returns the type of the pull request
 	// event given the Bitbucket Cloud header.
-	GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string) models.PullRequestEventType
+	// draft is true if the pull request is currently a draft.
+	GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string, draft bool) models.PullRequestEventType
 
 	// ParseBitbucketServerPullEvent parses a pull request event from Bitbucket
 	// Server.
@@ -363,7 +412,6 @@ type EventParser struct {
 	GitlabToken        string
 	GiteaUser          string
 	GiteaToken         string
-	AllowDraftPRs      bool
 	BitbucketUser      string
 	BitbucketToken     string
 	BitbucketServerURL string
@@ -393,12 +441,21 @@ func (e *EventParser) ParseAPIPlanRequest(vcsHostType models.VCSHostType, repoFu
 
 // GetBitbucketCloudPullEventType returns the type of the pull request
 // event given the Bitbucket Cloud header.
-func (e *EventParser) GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string) models.PullRequestEventType {
+func (e *EventParser) GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string, draft bool) models.PullRequestEventType {
 	switch eventTypeHeader {
 	case bitbucketcloud.PullCreatedHeader:
 		lastBitbucketSha.Add(pr, sha)
+		if draft {
+			draftBitbucketPulls.Add(pr, true)
+		}
 		return models.OpenedPullEvent
 	case bitbucketcloud.PullUpdatedHeader:
+		if draft {
+			draftBitbucketPulls.Add(pr, true)
+		} else if draftBitbucketPulls.Remove(pr) {
+			lastBitbucketSha.Add(pr, sha)
+			return models.ReadyForReviewPullEvent
+		}
 		lastSha, _ := lastBitbucketSha.Get(pr)
 		if sha == lastSha {
 			// No change, ignore
@@ -436,7 +493,7 @@ func (e *EventParser) parseCommonBitbucketCloudEventData(event bitbucketcloud.Co
 	case "OPEN":
 		prState = models.OpenPullState
 	case "MERGED":
-		prState = models.ClosedPullState
+		prState = models.MergedPullState
 	case "SUPERSEDED":
 		prState = models.ClosedPullState
 	case "DECLINED":
@@ -474,6 +531,7 @@ func (e *EventParser) parseCommonBitbucketCloudEventData(event bitbucketcloud.Co
 		Author:     *event.Actor.AccountID,
 		State:      prState,
 		BaseRepo:   baseRepo,
+		Draft:      event.PullRequest.Draft != nil && *event.PullRequest.Draft,
 	}
 	user = models.User{
 		Username: *event.Actor.AccountID,
@@ -491,6 +549,11 @@ func (e *EventParser) ParseBitbucketCloudPullEvent(body []byte) (pull models.Pul
 		return
 	}
 	if err = validator.New().Struct(event); err != nil {
+		// Merged and declined pull requests are still parsed so that their
+		// locks are released even if their source branch was deleted.
+		if event.PullRequest != nil && event.PullRequest.State != nil && *event.PullRequest.State != "OPEN" {
+			return e.parseClosedBitbucketCloudPullEvent(body)
+		}
 		err = errors.Wrapf(err, "API response %q was missing fields", string(body))
 		return
 	}
@@ -498,6 +561,66 @@ func (e *EventParser) ParseBitbucketCloudPullEvent(body []byte) (pull models.Pul
 	return
 }
 
+// parseClosedBitbucketCloudPullEvent parses a merged or declined pull request
+// event that's missing fields only needed by open pull requests.
+func (e *EventParser) parseClosedBitbucketCloudPullEvent(body []byte) (pull models.PullRequest, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
+	var event bitbucketcloud.ClosedPullRequestEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		err = errors.Wrap(err, "parsing json")
+		return
+	}
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrapf(err, "API response %q was missing fields", string(body))
+		return
+	}
+	prState := models.ClosedPullState
+	if *event.PullRequest.State == "MERGED" {
+		prState = models.MergedPullState
+	}
+	baseRepo, err = models.NewRepo(
+		models.BitbucketCloud,
+		*event.Repository.FullName,
+		*event.Repository.Links.HTML.HREF,
+		e.BitbucketUser,
+		e.BitbucketToken)
+	if err != nil {
+		return
+	}
+	headRepo = baseRepo
+	pull = models.PullRequest{
+		Num:      *event.PullRequest.ID,
+		URL:      *event.PullRequest.Links.HTML.HREF,
+		State:    prState,
+		BaseRepo: baseRepo,
+	}
+	if source := event.PullRequest.Source; source != nil {
+		if source.Repository != nil && source.Repository.FullName != nil && source.Repository.Links.HTML != nil && source.Repository.Links.HTML.HREF != nil {
+			if headRepo, err = models.NewRepo(
+				models.BitbucketCloud,
+				*source.Repository.FullName,
+				*source.Repository.Links.HTML.HREF,
+				e.BitbucketUser,
+				e.BitbucketToken); err != nil {
+				return
+			}
+		}
+		if source.Commit != nil && source.Commit.Hash != nil {
+			pull.HeadCommit = *source.Commit.Hash
+		}
+		if source.Branch != nil && source.Branch.Name != nil {
+			pull.HeadBranch = *source.Branch.Name
+		}
+	}
+	if dest := event.PullRequest.Destination; dest != nil && dest.Branch != nil && dest.Branch.Name != nil {
+		pull.BaseBranch = *dest.Branch.Name
+	}
+	if event.Actor != nil && event.Actor.AccountID != nil {
+		pull.Author = *event.Actor.AccountID
+		user = models.User{Username: *event.Actor.AccountID}
+	}
+	return
+}
+
 // ParseGithubIssueCommentEvent parses GitHub pull request comment events.
 // See EventParsing for return value docs.
 func (e *EventParser) ParseGithubIssueCommentEvent(logger logging.SimpleLogging, comment *github.IssueCommentEvent) (baseRepo models.Repo, user models.User, pullNum int, err error) {
@@ -521,6 +644,29 @@ func (e *EventParser) ParseGithubIssueCommentEvent(logger logging.SimpleLogging,
 	return
 }
 
+// ParseGithubPullRequestReviewCommentEvent parses GitHub pull request review
+// comment events.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGithubPullRequestReviewCommentEvent(logger logging.SimpleLogging, comment *github.PullRequestReviewCommentEvent) (baseRepo models.Repo, user models.User, pullNum int, err error) {
+	baseRepo, err = e.ParseGithubRepo(comment.Repo)
+	if err != nil {
+		return
+	}
+	if comment.Comment == nil || comment.Comment.User.GetLogin() == "" {
+		err = errors.New("comment.user.login is null")
+		return
+	}
+	user = models.User{
+		Username: comment.Comment.User.GetLogin(),
+	}
+	pullNum = comment.PullRequest.GetNumber()
+	if pullNum == 0 {
+		err = errors.New("pull_request.number is null")
+		return
+	}
+	return
+}
+
 // ParseGithubPullEvent parses GitHub pull request events.
 // See EventParsing for return value docs.
 func (e *EventParser) ParseGithubPullEvent(logger logging.SimpleLogging, pullEvent *github.PullRequestEvent) (pull models.PullRequest, pullEventType models.PullRequestEventType, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
@@ -542,22 +688,15 @@ func (e *EventParser) ParseGithubPullEvent(logger logging.SimpleLogging, pullEve
 		return
 	}
 
-	action := pullEvent.GetAction()
-	// If it's a draft PR we ignore it for auto-planning if configured to do so
-	// however it's still possible for users to run plan on it manually via a
-	// comment so if any draft PR is closed we still need to check if we need
-	// to delete its locks.
-	if pullEvent.GetPullRequest().GetDraft() && pullEvent.GetAction() != "closed" && !e.AllowDraftPRs {
-		action = "other"
-	}
-
-	switch action {
+	switch pullEvent.GetAction() {
 	case "opened":
 		pullEventType = models.OpenedPullEvent
 	case "ready_for_review":
 		// when an author takes a PR out of 'draft' state a 'ready_for_review'
 		// event is triggered. We want atlantis to treat this as a freshly opened PR
-		pullEventType = models.OpenedPullEvent
+		pullEventType = models.ReadyForReviewPullEvent
+	case "labeled":
+		pullEventType = models.LabeledPullEvent
 	case "synchronize":
 		pullEventType = models.UpdatedPullEvent
 	case "closed":
@@ -617,17 +756,22 @@ func (e *EventParser) ParseGithubPull(logger logging.SimpleLogging, pull *github
 	pullState := models.ClosedPullState
 	if pull.GetState() == "open" {
 		pullState = models.OpenPullState
+	} else if pull.GetMerged() {
+		pullState = models.MergedPullState
 	}
 
 	pullModel = models.PullRequest{
 		Author:     authorUsername,
 		HeadBranch: headBranch,
 		HeadCommit: commit,
+		BaseCommit: pull.Base.GetSHA(),
 		URL:        url,
+		Title:      pull.GetTitle(),
 		Num:        num,
 		State:      pullState,
 		BaseRepo:   baseRepo,
 		BaseBranch: baseBranch,
+		Draft:      pull.GetDraft(),
 	}
 	return
 }
@@ -657,10 +801,13 @@ func (e *EventParser) ParseGiteaRepo(repo giteasdk.Repository) (models.Repo, err
 
 // ParseGitlabMergeRequestUpdateEvent dives deeper into Gitlab merge request update events
 func (e *EventParser) ParseGitlabMergeRequestUpdateEvent(event gitlab.MergeEvent) models.PullRequestEventType {
-	// New commit to opened MR
-	if len(event.ObjectAttributes.OldRev) > 0 ||
-		// Check for MR that has been marked as ready
+	// Check for MR that has been marked as ready
+	if (event.Changes.Draft.Previous && !event.Changes.Draft.Current) ||
 		(strings.HasPrefix(event.Changes.Title.Previous, "Draft:") && !strings.HasPrefix(event.Changes.Title.Current, "Draft:")) {
+		return models.ReadyForReviewPullEvent
+	}
+	// New commit to opened MR
+	if len(event.ObjectAttributes.OldRev) > 0 {
 		return models.UpdatedPullEvent
 	}
 	return models.OtherPullEvent
@@ -671,11 +818,12 @@ func (e *EventParser) ParseGitlabMergeRequestUpdateEvent(event gitlab.MergeEvent
 // See EventParsing for return value docs.
 func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pull models.PullRequest, eventType models.PullRequestEventType, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
 	modelState := models.ClosedPullState
-	if event.ObjectAttributes.State == gitlabPullOpened {
+	switch event.ObjectAttributes.State {
+	case gitlabPullOpened:
 		modelState = models.OpenPullState
+	case gitlabPullMerged:
+		modelState = models.MergedPullState
 	}
-	// GitLab also has a "merged" state, but we map that to Closed so we don't
-	// need to check for it.
 
 	baseRepo, err = models.NewRepo(models.Gitlab, event.Project.PathWithNamespace, event.Project.GitHTTPURL, e.GitlabUser, e.GitlabToken)
 	if err != nil {
@@ -688,6 +836,7 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 
 	pull = models.PullRequest{
 		URL:        event.ObjectAttributes.URL,
+		Title:      event.ObjectAttributes.Title,
 		Author:     event.User.Username,
 		Num:        event.ObjectAttributes.IID,
 		HeadCommit: event.ObjectAttributes.LastCommit.ID,
@@ -695,25 +844,18 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 		BaseBranch: event.ObjectAttributes.TargetBranch,
 		State:      modelState,
 		BaseRepo:   baseRepo,
+		Draft:      event.ObjectAttributes.Draft || event.ObjectAttributes.WorkInProgress,
 	}
 
-	// If it's a draft PR we ignore it for auto-planning if configured to do so
-	// however it's still possible for users to run plan on it manually via a
-	// comment so if any draft PR is closed we still need to check if we need
-	// to delete its locks.
-	if event.ObjectAttributes.WorkInProgress && event.ObjectAttributes.Action != "close" && !e.AllowDraftPRs {
+	switch event.ObjectAttributes.Action {
+	case "open":
+		eventType = models.OpenedPullEvent
+	case "update":
+		eventType = e.ParseGitlabMergeRequestUpdateEvent(event)
+	case "merge", "close":
+		eventType = models.ClosedPullEvent
+	default:
 		eventType = models.OtherPullEvent
-	} else {
-		switch event.ObjectAttributes.Action {
-		case "open":
-			eventType = models.OpenedPullEvent
-		case "update":
-			eventType = e.ParseGitlabMergeRequestUpdateEvent(event)
-		case "merge", "close":
-			eventType = models.ClosedPullEvent
-		default:
-			eventType = models.OtherPullEvent
-		}
 	}
 
 	user = models.User{
@@ -774,21 +916,25 @@ func (e *EventParser) ParseGiteaIssueCommentEvent(comment gitea.GiteaIssueCommen
 // data so we can construct the pull request object correctly.
 func (e *EventParser) ParseGitlabMergeRequest(mr *gitlab.MergeRequest, baseRepo models.Repo) models.PullRequest {
 	pullState := models.ClosedPullState
-	if mr.State == gitlabPullOpened {
+	switch mr.State {
+	case gitlabPullOpened:
 		pullState = models.OpenPullState
+	case gitlabPullMerged:
+		pullState = models.MergedPullState
 	}
-	// GitLab also has a "merged" state, but we map that to Closed so we don't
-	// need to check for it.
 
 	return models.PullRequest{
 		URL:        mr.WebURL,
+		Title:      mr.Title,
 		Author:     mr.Author.Username,
 		Num:        mr.IID,
 		HeadCommit: mr.SHA,
+		BaseCommit: mr.DiffRefs.BaseSha,
 		HeadBranch: mr.SourceBranch,
 		BaseBranch: mr.TargetBranch,
 		State:      pullState,
 		BaseRepo:   baseRepo,
+		Draft:      mr.Draft || mr.WorkInProgress,
 	}
 }
 
@@ -830,7 +976,7 @@ func (e *EventParser) parseCommonBitbucketServerEventData(event bitbucketserver.
 	case "OPEN":
 		prState = models.OpenPullState
 	case "MERGED":
-		prState = models.ClosedPullState
+		prState = models.MergedPullState
 	case "DECLINED":
 		prState = models.ClosedPullState
 	default:
@@ -873,6 +1019,7 @@ func (e *EventParser) parseCommonBitbucketServerEventData(event bitbucketserver.
 		Author:     *event.Actor.Username,
 		State:      prState,
 		BaseRepo:   baseRepo,
+		Draft:      event.PullRequest.Draft != nil && *event.PullRequest.Draft,
 	}
 	user = models.User{
 		Username: *event.Actor.Username,
@@ -924,7 +1071,7 @@ func (e *EventParser) ParseAzureDevopsPullEvent(event azuredevops.Event) (pull m
 		pullEventType = models.OpenedPullEvent
 	case "git.pullrequest.updated":
 		pullEventType = models.UpdatedPullEvent
-		if pull.State == models.ClosedPullState {
+		if pull.State != models.OpenPullState {
 			pullEventType = models.ClosedPullEvent
 		}
 	default:
@@ -983,8 +1130,11 @@ func (e *EventParser) ParseAzureDevopsPull(pull *azuredevops.GitPullRequest) (pu
 		return
 	}
 	pullState := models.ClosedPullState
-	if *pull.Status == azuredevops.PullActive.String() {
+	switch *pull.Status {
+	case azuredevops.PullActive.String():
 		pullState = models.OpenPullState
+	case azuredevops.PullCompleted.String():
+		pullState = models.MergedPullState
 	}
 
 	pullModel = models.PullRequest{
@@ -997,6 +1147,7 @@ func (e *EventParser) ParseAzureDevopsPull(pull *azuredevops.GitPullRequest) (pu
 		State:      pullState,
 		BaseRepo:   baseRepo,
 		BaseBranch: strings.Replace(baseBranch, "refs/heads/", "", 1),
+		Draft:      pull.GetIsDraft(),
 	}
 	return
 }
@@ -1158,13 +1309,17 @@ func (e *EventParser) ParseGiteaPull(pull *giteasdk.PullRequest) (pullModel mode
 	pullState := models.ClosedPullState
 	if pull.State == "open" {
 		pullState = models.OpenPullState
+	} else if pull.HasMerged {
+		pullState = models.MergedPullState
 	}
 
 	pullModel = models.PullRequest{
 		Author:     authorUsername,
 		HeadBranch: headBranch,
 		HeadCommit: commit,
+		BaseCommit: pull.Base.Sha,
 		URL:        url,
+		Title:      pull.Title,
 		Num:        int(num),
 		State:      pullState,
 		BaseRepo:   baseRepo,
@@ -39,7 +39,6 @@ var parser = events.EventParser{
 	GithubTokenFile:    "",
 	GitlabUser:         "gitlab-user",
 	GitlabToken:        "gitlab-token",
-	AllowDraftPRs:      false,
 	BitbucketUser:      "bitbucket-user",
 	BitbucketToken:     "bitbucket-token",
 	BitbucketServerURL: "http://mycorp.com:7490",
@@ -117,6 +116,43 @@ func TestParseGithubIssueCommentEvent(t *testing.T) {
 	Equals(t, *comment.Issue.Number, pullNum)
 }
 
+func TestParseGithubPullRequestReviewCommentEvent(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	comment := github.PullRequestReviewCommentEvent{
+		Repo: &Repo,
+		PullRequest: &github.PullRequest{
+			Number: github.Ptr(1),
+		},
+		Comment: &github.PullRequestComment{
+			User: &github.User{Login: github.Ptr("comment_user")},
+		},
+	}
+
+	testComment := deepcopy.Copy(comment).(github.PullRequestReviewCommentEvent)
+	testComment.Comment = nil
+	_, _, _, err := parser.ParseGithubPullRequestReviewCommentEvent(logger, &testComment)
+	ErrEquals(t, "comment.user.login is null", err)
+
+	testComment = deepcopy.Copy(comment).(github.PullRequestReviewCommentEvent)
+	testComment.Comment.User = nil
+	_, _, _, err = parser.ParseGithubPullRequestReviewCommentEvent(logger, &testComment)
+	ErrEquals(t, "comment.user.login is null", err)
+
+	testComment = deepcopy.Copy(comment).(github.PullRequestReviewCommentEvent)
+	testComment.PullRequest = nil
+	_, _, _, err = parser.ParseGithubPullRequestReviewCommentEvent(logger, &testComment)
+	ErrEquals(t, "pull_request.number is null", err)
+
+	// this should be successful
+	repo, user, pullNum, err := parser.ParseGithubPullRequestReviewCommentEvent(logger, &comment)
+	Ok(t, err)
+	Equals(t, *comment.Repo.FullName, repo.FullName)
+	Equals(t, models.User{
+		Username: "comment_user",
+	}, user)
+	Equals(t, 1, pullNum)
+}
+
 func TestParseGithubPullEvent(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	_, _, _, _, _, err := parser.ParseGithubPullEvent(logger, &github.PullRequestEvent{})
@@ -154,6 +190,7 @@ func TestParseGithubPullEvent(t *testing.T) {
 	Equals(t, expBaseRepo, actHeadRepo)
 	Equals(t, models.PullRequest{
 		URL:        Pull.GetHTMLURL(),
+		BaseCommit: Pull.Base.GetSHA(),
 		Author:     Pull.User.GetLogin(),
 		HeadBranch: Pull.Head.GetRef(),
 		BaseBranch: Pull.Base.GetRef(),
@@ -177,81 +214,65 @@ func TestParseGithubPullEventFromDraft(t *testing.T) {
 	Ok(t, err)
 	Equals(t, models.ClosedPullEvent, evType)
 
-	// verify that draft PRs are treated as 'other' events by default
+	// verify that draft PRs keep their event type and are marked as drafts,
+	// whether they're autoplanned is decided by the command runner.
 	testEvent := deepcopy.Copy(PullEvent).(github.PullRequestEvent)
 	testEvent.PullRequest.Draft = github.Ptr(true)
-	_, evType, _, _, _, err = parser.ParseGithubPullEvent(logger, &testEvent)
-	Ok(t, err)
-	Equals(t, models.OtherPullEvent, evType)
-	// verify that drafts are planned if requested
-	parser.AllowDraftPRs = true
-	defer func() { parser.AllowDraftPRs = false }()
-	_, evType, _, _, _, err = parser.ParseGithubPullEvent(logger, &testEvent)
+	pull, evType, _, _, _, err := parser.ParseGithubPullEvent(logger, &testEvent)
 	Ok(t, err)
 	Equals(t, models.OpenedPullEvent, evType)
+	Equals(t, true, pull.Draft)
 }
 
 func TestParseGithubPullEvent_EventType(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	cases := []struct {
-		action   string
-		exp      models.PullRequestEventType
-		draftExp models.PullRequestEventType
+		action string
+		exp    models.PullRequestEventType
 	}{
 		{
-			action:   "synchronize",
-			exp:      models.UpdatedPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "synchronize",
+			exp:    models.UpdatedPullEvent,
 		},
 		{
-			action:   "unassigned",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "unassigned",
+			exp:    models.OtherPullEvent,
 		},
 		{
-			action:   "review_requested",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "review_requested",
+			exp:    models.OtherPullEvent,
 		},
 		{
-			action:   "review_request_removed",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "review_request_removed",
+			exp:    models.OtherPullEvent,
 		},
 		{
-			action:   "labeled",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "labeled",
+			exp:    models.LabeledPullEvent,
 		},
 		{
-			action:   "unlabeled",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "unlabeled",
+			exp:    models.OtherPullEvent,
 		},
 		{
-			action:   "opened",
-			exp:      models.OpenedPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "opened",
+			exp:    models.OpenedPullEvent,
 		},
 		{
-			action:   "edited",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "edited",
+			exp:    models.OtherPullEvent,
 		},
 		{
-			action:   "closed",
-			exp:      models.ClosedPullEvent,
-			draftExp: models.ClosedPullEvent,
+			action: "closed",
+			exp:    models.ClosedPullEvent,
 		},
 		{
-			action:   "reopened",
-			exp:      models.OtherPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "reopened",
+			exp:    models.OtherPullEvent,
 		},
 		{
-			action:   "ready_for_review",
-			exp:      models.OpenedPullEvent,
-			draftExp: models.OtherPullEvent,
+			action: "ready_for_review",
+			exp:    models.ReadyForReviewPullEvent,
 		},
 	}
 
@@ -264,18 +285,13 @@ func TestParseGithubPullEvent_EventType(t *testing.T) {
 			_, actType, _, _, _, err := parser.ParseGithubPullEvent(logger, &event)
 			Ok(t, err)
 			Equals(t, c.exp, actType)
-			// Test draft parsing when draft PRs disabled
+			// Test draft parsing
 			draftPR := true
 			event.PullRequest.Draft = &draftPR
-			_, draftEvType, _, _, _, err := parser.ParseGithubPullEvent(logger, &event)
-			Ok(t, err)
-			Equals(t, c.draftExp, draftEvType)
-			// Test draft parsing when draft PRs are enabled.
-			draftParser := parser
-			draftParser.AllowDraftPRs = true
-			_, draftEvType, _, _, _, err = draftParser.ParseGithubPullEvent(logger, &event)
+			draftPull, draftEvType, _, _, _, err := parser.ParseGithubPullEvent(logger, &event)
 			Ok(t, err)
 			Equals(t, c.exp, draftEvType)
+			Equals(t, true, draftPull.Draft)
 		})
 	}
 }
@@ -327,6 +343,7 @@ func TestParseGithubPull(t *testing.T) {
 	}
 	Equals(t, models.PullRequest{
 		URL:        Pull.GetHTMLURL(),
+		BaseCommit: Pull.Base.GetSHA(),
 		Author:     Pull.User.GetLogin(),
 		HeadBranch: Pull.Head.GetRef(),
 		BaseBranch: Pull.Base.GetRef(),
@@ -339,6 +356,27 @@ func TestParseGithubPull(t *testing.T) {
 	Equals(t, expBaseRepo, actHeadRepo)
 }
 
+func TestParseGithubPull_States(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := []struct {
+		state  string
+		merged bool
+		exp    models.PullRequestState
+	}{
+		{"open", false, models.OpenPullState},
+		{"closed", false, models.ClosedPullState},
+		{"closed", true, models.MergedPullState},
+	}
+	for _, c := range cases {
+		testPull := deepcopy.Copy(Pull).(github.PullRequest)
+		testPull.State = github.Ptr(c.state)
+		testPull.Merged = github.Ptr(c.merged)
+		pullRes, _, _, err := parser.ParseGithubPull(logger, &testPull)
+		Ok(t, err)
+		Equals(t, c.exp, pullRes.State)
+	}
+}
+
 func TestParseGitlabMergeEvent(t *testing.T) {
 	t.Log("should properly parse a gitlab merge event")
 	path := filepath.Join("testdata", "gitlab-merge-request-event.json")
@@ -364,6 +402,7 @@ func TestParseGitlabMergeEvent(t *testing.T) {
 
 	Equals(t, models.PullRequest{
 		URL:        "https://gitlab.com/lkysow/atlantis-example/merge_requests/12",
+		Title:      "Update main.tf",
 		Author:     "lkysow",
 		Num:        12,
 		HeadCommit: "d2eae324ca26242abca45d7b49d582cddb2a4f15",
@@ -407,15 +446,37 @@ func TestParseGitlabMergeEventFromDraft(t *testing.T) {
 	testEvent := deepcopy.Copy(event).(gitlab.MergeEvent)
 	testEvent.ObjectAttributes.WorkInProgress = true
 
-	_, evType, _, _, _, err := parser.ParseGitlabMergeRequestEvent(testEvent)
+	pull, evType, _, _, _, err := parser.ParseGitlabMergeRequestEvent(testEvent)
 	Ok(t, err)
-	Equals(t, models.OtherPullEvent, evType)
+	Equals(t, models.OpenedPullEvent, evType)
+	Equals(t, true, pull.Draft)
 
-	parser.AllowDraftPRs = true
-	defer func() { parser.AllowDraftPRs = false }()
-	_, evType, _, _, _, err = parser.ParseGitlabMergeRequestEvent(testEvent)
+	testEvent = deepcopy.Copy(event).(gitlab.MergeEvent)
+	testEvent.ObjectAttributes.Draft = true
+
+	pull, _, _, _, _, err = parser.ParseGitlabMergeRequestEvent(testEvent)
 	Ok(t, err)
-	Equals(t, models.OpenedPullEvent, evType)
+	Equals(t, true, pull.Draft)
+}
+
+func TestParseGitlabMergeEvent_MarkedReady(t *testing.T) {
+	path := filepath.Join("testdata", "gitlab-merge-request-event.json")
+	bytes, err := os.ReadFile(path)
+	Ok(t, err)
+
+	var event gitlab.MergeEvent
+	err = json.Unmarshal(bytes, &event)
+	Ok(t, err)
+
+	testEvent := deepcopy.Copy(event).(gitlab.MergeEvent)
+	testEvent.ObjectAttributes.Action = "update"
+	testEvent.Changes.Draft.Previous = true
+	testEvent.Changes.Draft.Current = false
+
+	pull, evType, _, _, _, err := parser.ParseGitlabMergeRequestEvent(testEvent)
+	Ok(t, err)
+	Equals(t, models.ReadyForReviewPullEvent, evType)
+	Equals(t, false, pull.Draft)
 }
 
 // Should be able to parse a merge event from a repo that is in a subgroup,
@@ -444,6 +505,7 @@ func TestParseGitlabMergeEvent_Subgroup(t *testing.T) {
 
 	Equals(t, models.PullRequest{
 		URL:        "https://gitlab.com/lkysow-test/subgroup/sub-subgroup/atlantis-example/merge_requests/2",
+		Title:      "Update main.tf",
 		Author:     "lkysow",
 		Num:        2,
 		HeadCommit: "901d9770ef1a6862e2a73ec1bacc73590abb9aff",
@@ -512,7 +574,7 @@ func TestParseGitlabMergeEvent_Update_ActionType(t *testing.T) {
 		},
 		{
 			filename: "gitlab-merge-request-event-mark-as-ready.json",
-			exp:      models.UpdatedPullEvent,
+			exp:      models.ReadyForReviewPullEvent,
 		},
 	}
 
@@ -599,6 +661,7 @@ func TestParseGitlabMergeRequest(t *testing.T) {
 	pull := parser.ParseGitlabMergeRequest(event, repo)
 	Equals(t, models.PullRequest{
 		URL:        "https://gitlab.com/lkysow/atlantis-example/merge_requests/8",
+		Title:      "Update main.tf",
 		Author:     "lkysow",
 		Num:        8,
 		HeadCommit: "0b4ac85ea3063ad5f2974d10cd68dd1f937aaac2",
@@ -638,6 +701,8 @@ func TestParseGitlabMergeRequest_Subgroup(t *testing.T) {
 	pull := parser.ParseGitlabMergeRequest(event, repo)
 	Equals(t, models.PullRequest{
 		URL:        "https://gitlab.com/lkysow-test/subgroup/sub-subgroup/atlantis-example/merge_requests/2",
+		Title:      "Update main.tf",
+		BaseCommit: "cdf3f0f8aad6abc3c4700ad6e28936a2278a309b",
 		Author:     "lkysow",
 		Num:        2,
 		HeadCommit: "901d9770ef1a6862e2a73ec1bacc73590abb9aff",
@@ -817,7 +882,7 @@ func TestCommentCommand_IsAutoplan(t *testing.T) {
 }
 
 func TestCommentCommand_String(t *testing.T) {
-	exp := `command="plan", verbose=true, dir="mydir", workspace="myworkspace", project="myproject", policyset="", auto-merge-disabled=false, auto-merge-method=, clear-policy-approval=false, flags="flag1,flag2"`
+	exp := `command="plan", verbose=true, dir="mydir", workspace="myworkspace", project="myproject", environment="", policyset="", auto-merge-disabled=false, auto-merge-method=, clear-policy-approval=false, include-dirs="", exclude-dirs="", continue-on-group-failure=false, approval-reason="", confirm-all=false, flags="flag1,flag2"`
 	Equals(t, exp, (events.CommentCommand{
 		RepoRelDir:  "mydir",
 		Flags:       []string{"flag1", "flag2"},
@@ -872,7 +937,7 @@ func TestParseBitbucketCloudCommentEvent_ValidEvent(t *testing.T) {
 		HeadBranch: "lkysow/maintf-edited-online-with-bitbucket-1532029690581",
 		BaseBranch: "main",
 		Author:     "557058:dc3817de-68b5-45cd-b81c-5c39d2560090",
-		State:      models.ClosedPullState,
+		State:      models.MergedPullState,
 		BaseRepo:   expBaseRepo,
 	}, pull)
 	Equals(t, models.Repo{
@@ -909,7 +974,7 @@ func TestParseBitbucketCloudCommentEvent_MultipleStates(t *testing.T) {
 		},
 		{
 			"MERGED",
-			models.ClosedPullState,
+			models.MergedPullState,
 		},
 		{
 			"SUPERSEDED",
@@ -988,7 +1053,7 @@ func TestParseBitbucketCloudPullEvent_States(t *testing.T) {
 		},
 		{
 			JSON:     "bitbucket-cloud-pull-event-fulfilled.json",
-			ExpState: models.ClosedPullState,
+			ExpState: models.MergedPullState,
 		},
 		{
 			JSON:     "bitbucket-cloud-pull-event-rejected.json",
@@ -1006,42 +1071,83 @@ func TestParseBitbucketCloudPullEvent_States(t *testing.T) {
 	}
 }
 
+func TestParseBitbucketCloudPullEvent_DeclinedFromDeletedFork(t *testing.T) {
+	bytes, err := os.ReadFile(filepath.Join("testdata", "bitbucket-cloud-pull-event-rejected-deleted-fork.json"))
+	Ok(t, err)
+	pull, baseRepo, headRepo, user, err := parser.ParseBitbucketCloudPullEvent(bytes)
+	Ok(t, err)
+	Equals(t, "lkysow/atlantis-example", baseRepo.FullName)
+	Equals(t, baseRepo, headRepo)
+	Equals(t, models.PullRequest{
+		Num:        13,
+		URL:        "https://bitbucket.org/lkysow/atlantis-example/pull-requests/13",
+		HeadBranch: "test",
+		BaseBranch: "main",
+		Author:     "557058:dc3817de-68b5-45cd-b81c-5c39d2560090",
+		State:      models.ClosedPullState,
+		BaseRepo:   baseRepo,
+	}, pull)
+	Equals(t, models.User{Username: "557058:dc3817de-68b5-45cd-b81c-5c39d2560090"}, user)
+}
+
+func TestParseBitbucketCloudPullEvent_OpenMissingSource(t *testing.T) {
+	bytes, err := os.ReadFile(filepath.Join("testdata", "bitbucket-cloud-pull-event-rejected-deleted-fork.json"))
+	Ok(t, err)
+	open := strings.Replace(string(bytes), `"state": "DECLINED"`, `"state": "OPEN"`, 1)
+	_, _, _, _, err = parser.ParseBitbucketCloudPullEvent([]byte(open))
+	ErrContains(t, "was missing fields", err)
+}
+
 func TestBitBucketNonCodeChangesAreIgnored(t *testing.T) {
 	// lets say a user opens a PR
-	act := parser.GetBitbucketCloudPullEventType("pullrequest:created", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1")
+	act := parser.GetBitbucketCloudPullEventType("pullrequest:created", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.OpenedPullEvent, act)
 	// Another update with same SHA should be ignored
-	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1")
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.OtherPullEvent, act)
 	// Only if SHA changes do we act
-	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha2", "https://github.com/fakeorg/fakerepo/pull/1")
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha2", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.UpdatedPullEvent, act)
 
 	// If sha changes in separate PR,
-	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "otherPRSha", "https://github.com/fakeorg/fakerepo/pull/2")
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "otherPRSha", "https://github.com/fakeorg/fakerepo/pull/2", false)
 	Equals(t, models.UpdatedPullEvent, act)
 	// We will still ignore same shas in first PR
-	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha2", "https://github.com/fakeorg/fakerepo/pull/1")
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha2", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.OtherPullEvent, act)
 }
 
 func TestBitbucketShaCacheExpires(t *testing.T) {
 	// lets say a user opens a PR
-	act := parser.GetBitbucketCloudPullEventType("pullrequest:created", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1")
+	act := parser.GetBitbucketCloudPullEventType("pullrequest:created", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.OpenedPullEvent, act)
 	// Another update with same SHA should be ignored
-	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1")
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.OtherPullEvent, act)
 	// But after 300 times, the cache should expire
 	// this is so we don't have ever increasing memory usage
 	for i := 0; i < 302; i++ {
-		parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", fmt.Sprintf("https://github.com/fakeorg/fakerepo/pull/%d", i))
+		parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", fmt.Sprintf("https://github.com/fakeorg/fakerepo/pull/%d", i), false)
 	}
 	// and now SHA will seen as a change again
-	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1")
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "fakeSha", "https://github.com/fakeorg/fakerepo/pull/1", false)
 	Equals(t, models.UpdatedPullEvent, act)
 }
 
+func TestBitbucketDraftMarkedReady(t *testing.T) {
+	pr := "https://github.com/fakeorg/fakerepo/pull/draft"
+	act := parser.GetBitbucketCloudPullEventType("pullrequest:created", "draftSha", pr, true)
+	Equals(t, models.OpenedPullEvent, act)
+	// New commits to the draft are still updates.
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "draftSha2", pr, true)
+	Equals(t, models.UpdatedPullEvent, act)
+	// Marking it as ready is reported once even though the SHA didn't change.
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "draftSha2", pr, false)
+	Equals(t, models.ReadyForReviewPullEvent, act)
+	act = parser.GetBitbucketCloudPullEventType("pullrequest:updated", "draftSha2", pr, false)
+	Equals(t, models.OtherPullEvent, act)
+}
+
 func TestGetBitbucketCloudEventType(t *testing.T) {
 	cases := []struct {
 		header string
@@ -1072,7 +1178,7 @@ func TestGetBitbucketCloudEventType(t *testing.T) {
 		t.Run(c.header, func(t *testing.T) {
 			// we pass in the header as the SHA so the SHA changes each time
 			// the code will ignore duplicate SHAS to avoid extra TF plans
-			act := parser.GetBitbucketCloudPullEventType(c.header, c.header, "https://github.com/fakeorg/fakerepo/pull/1")
+			act := parser.GetBitbucketCloudPullEventType(c.header, c.header, "https://github.com/fakeorg/fakerepo/pull/1", false)
 			Equals(t, c.exp, act)
 		})
 	}
@@ -1163,7 +1269,7 @@ func TestParseBitbucketServerCommentEvent_MultipleStates(t *testing.T) {
 		},
 		{
 			"MERGED",
-			models.ClosedPullState,
+			models.MergedPullState,
 		},
 		{
 			"DECLINED",
@@ -1208,7 +1314,7 @@ func TestParseBitbucketServerPullEvent_ValidEvent(t *testing.T) {
 		HeadBranch: "branch",
 		BaseBranch: "main",
 		Author:     "lkysow",
-		State:      models.ClosedPullState,
+		State:      models.MergedPullState,
 		BaseRepo:   expBaseRepo,
 	}, pull)
 	Equals(t, models.Repo{
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+func NewForceUnlockCommandRunner(
+	pullUpdater *PullUpdater,
+	prjCmdBuilder ProjectForceUnlockCommandBuilder,
+	prjCmdRunner ProjectForceUnlockCommandRunner,
+	SilenceNoProjects bool,
+) *ForceUnlockCommandRunner {
+	return &ForceUnlockCommandRunner{
+		pullUpdater:       pullUpdater,
+		prjCmdBuilder:     prjCmdBuilder,
+		prjCmdRunner:      prjCmdRunner,
+		SilenceNoProjects: SilenceNoProjects,
+	}
+}
+
+type ForceUnlockCommandRunner struct {
+	pullUpdater       *PullUpdater
+	prjCmdBuilder     ProjectForceUnlockCommandBuilder
+	prjCmdRunner      ProjectForceUnlockCommandRunner
+	SilenceNoProjects bool
+}
+
+func (v *ForceUnlockCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	projectCmds, err := v.prjCmdBuilder.BuildForceUnlockCommands(ctx, cmd)
+	if err != nil {
+		ctx.Log.Warn("Error %s", err)
+	}
+
+	if len(projectCmds) == 0 && v.SilenceNoProjects {
+		ctx.Log.Info("determined there was no project to run force-unlock in.")
+		return
+	}
+	var result command.Result
+	if len(projectCmds) > 1 {
+		// A lock ID belongs to a single state, so releasing it in every
+		// project would at best fail everywhere but one.
+		result = command.Result{
+			Failure: "force-unlock cannot run on multiple projects. please specify one project.",
+		}
+	} else {
+		result = runProjectCmds(projectCmds, v.prjCmdRunner.ForceUnlock)
+	}
+	v.pullUpdater.updatePull(ctx, cmd, result)
+}
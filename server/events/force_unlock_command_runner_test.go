@@ -0,0 +1,69 @@
+package events_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+)
+
+func TestForceUnlockCommandRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+
+	tests := []struct {
+		name         string
+		silenced     bool
+		projectCmds  []command.ProjectContext
+		expComment   string
+		expNoComment bool
+	}{
+		{
+			name:        "failure with multiple projects",
+			projectCmds: []command.ProjectContext{{}, {}},
+			expComment:  "**Force-Unlock Failed**: force-unlock cannot run on multiple projects. please specify one project.",
+		},
+		{
+			name:         "no comment with zero projects and silencing",
+			projectCmds:  []command.ProjectContext{},
+			silenced:     true,
+			expNoComment: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcsClient := setup(t, func(tc *TestConfig) {
+				tc.SilenceNoProjects = tt.silenced
+			})
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logger,
+				Scope:    scopeNull,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			cmd := &events.CommentCommand{Name: command.ForceUnlock}
+
+			When(projectCommandBuilder.BuildForceUnlockCommands(ctx, cmd)).ThenReturn(tt.projectCmds, nil)
+
+			forceUnlockCommandRunner.Run(ctx, cmd)
+
+			if tt.expNoComment {
+				vcsClient.VerifyWasCalled(Never()).CreateComment(
+					Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+			} else {
+				vcsClient.VerifyWasCalledOnce().CreateComment(
+					Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Eq(tt.expComment), Eq("force-unlock"))
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package events
 import (
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -17,6 +18,12 @@ type GithubAppWorkingDir struct {
 	WorkingDir
 	Credentials    vcs.GithubCredentials
 	GithubHostname string
+	// RepoCredentials, if set, mints the tokens git uses to fetch each
+	// repository so that they can only read that repository.
+	RepoCredentials vcs.GithubRepoCredentials
+	// HomeDir is where the .git-credentials file with the repository
+	// tokens is written.
+	HomeDir string
 }
 
 // Clone writes a fresh token for Github App authentication
@@ -36,5 +43,40 @@ func (g *GithubAppWorkingDir) Clone(logger logging.SimpleLogging, headRepo model
 	headRepo.CloneURL = strings.Replace(headRepo.CloneURL, "://:@", replacement, 1)
 	headRepo.SanitizedCloneURL = strings.Replace(baseRepo.SanitizedCloneURL, redactedReplacement, replacement, 1)
 
+	if err := g.writeRepoCreds(logger, *baseRepo); err != nil {
+		return "", err
+	}
 	return g.WorkingDir.Clone(logger, headRepo, p, workspace)
 }
+
+// MergeAgain writes a fresh token for the repository before fetching it again.
+func (g *GithubAppWorkingDir) MergeAgain(logger logging.SimpleLogging, headRepo models.Repo, p models.PullRequest, workspace string) (bool, error) {
+	if err := g.writeRepoCreds(logger, p.BaseRepo); err != nil {
+		return false, err
+	}
+	return g.WorkingDir.MergeAgain(logger, headRepo, p, workspace)
+}
+
+// ResetIfHistoryRewritten writes a fresh token for the repository before
+// fetching the pull request's branch.
+func (g *GithubAppWorkingDir) ResetIfHistoryRewritten(logger logging.SimpleLogging, headRepo models.Repo, p models.PullRequest) (bool, error) {
+	if err := g.writeRepoCreds(logger, p.BaseRepo); err != nil {
+		return false, err
+	}
+	return g.WorkingDir.ResetIfHistoryRewritten(logger, headRepo, p)
+}
+
+// writeRepoCreds writes a token that can only read repo for git to use when
+// fetching it. Pull requests from forks are fetched from the base repository
+// with the GitHub app so its token is the only one needed.
+func (g *GithubAppWorkingDir) writeRepoCreds(logger logging.SimpleLogging, repo models.Repo) error {
+	if g.RepoCredentials == nil {
+		return nil
+	}
+	token, err := g.RepoCredentials.GetCloneToken(repo.FullName)
+	if err != nil {
+		return errors.Wrapf(err, "getting token to clone %s", repo.FullName)
+	}
+	cloneURL := strings.Replace(repo.CloneURL, "://:@", "://", 1)
+	return vcs.WriteGitRepoCreds("x-access-token", token, cloneURL, g.HomeDir, logger)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewHelpCommandRunner(
+	vcsClient vcs.Client,
+	commentParser *CommentParser,
+	prjHelpBuilder ProjectHelpBuilder,
+) *HelpCommandRunner {
+	return &HelpCommandRunner{
+		vcsClient:      vcsClient,
+		commentParser:  commentParser,
+		prjHelpBuilder: prjHelpBuilder,
+	}
+}
+
+// HelpCommandRunner comments the help for the allowed commands followed by
+// the projects of the repo, with the workspaces, workflows and apply
+// requirements the server-side and repo configs give them, so users see
+// what can be run on this pull request.
+type HelpCommandRunner struct {
+	vcsClient      vcs.Client
+	commentParser  *CommentParser
+	prjHelpBuilder ProjectHelpBuilder
+}
+
+func (h *HelpCommandRunner) Run(ctx *command.Context, _ *CommentCommand) {
+	var b strings.Builder
+	b.WriteString(h.commentParser.HelpComment())
+	b.WriteString("\n\n")
+
+	projects, err := h.prjHelpBuilder.BuildHelpProjects(ctx)
+	if err != nil {
+		// The help is still useful without the projects.
+		ctx.Log.Warn("unable to find projects for help: %s", err)
+		fmt.Fprintf(&b, "Unable to find the projects of this repo: %s", err)
+	} else {
+		renderHelpProjects(&b, h.commentParser.ExecutableName, projects)
+	}
+
+	if commentErr := h.vcsClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, b.String(), command.Help.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+}
+
+// renderHelpProjects writes a table of projects and how to plan each of
+// them.
+func renderHelpProjects(b *strings.Builder, executableName string, projects []valid.MergedProjectCfg) {
+	b.WriteString("### Projects\n\n")
+	if len(projects) == 0 {
+		b.WriteString("This repo doesn't configure any projects and this pull request doesn't modify any.")
+		return
+	}
+	b.WriteString("| Project | Dir | Workspace | Autoplan | Workflow | Apply requirements | Plan with |\n")
+	b.WriteString("|---------|-----|-----------|----------|----------|--------------------|-----------|\n")
+	for _, p := range projects {
+		project := ""
+		if p.Name != "" {
+			project = fmt.Sprintf("`%s`", p.Name)
+		}
+		autoplan := "no"
+		if p.AutoplanEnabled {
+			autoplan = "yes"
+		}
+		requirements := "none"
+		if len(p.ApplyRequirements) > 0 {
+			requirements = "`" + strings.Join(p.ApplyRequirements, "`, `") + "`"
+		}
+		fmt.Fprintf(b, "| %s | `%s` | `%s` | %s | `%s` | %s | `%s plan %s` |\n",
+			project, p.RepoRelDir, p.Workspace, autoplan, p.Workflow.Name, requirements, executableName, helpProjectFlags(p))
+	}
+	b.WriteString("\nApply a project with the same flags, ex. `" + executableName + " apply " + helpProjectFlags(projects[0]) + "`.")
+}
+
+// helpProjectFlags returns the flags that select project in a comment.
+func helpProjectFlags(project valid.MergedProjectCfg) string {
+	if project.Name == "" {
+		return fmt.Sprintf("-d %s -w %s", project.RepoRelDir, project.Workspace)
+	}
+	flags := "-p " + project.Name
+	if project.Environment != "" {
+		flags += " -e " + project.Environment
+	} else if project.WorkspaceQualified {
+		flags += " -w " + project.Workspace
+	}
+	return flags
+}
@@ -0,0 +1,89 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakeProjectHelpBuilder struct {
+	projects []valid.MergedProjectCfg
+	err      error
+}
+
+func (f *fakeProjectHelpBuilder) BuildHelpProjects(_ *command.Context) ([]valid.MergedProjectCfg, error) {
+	return f.projects, f.err
+}
+
+func TestHelpCommandRunner_Run(t *testing.T) {
+	commentParser := &events.CommentParser{ExecutableName: "atlantis", AllowCommands: command.AllCommentCommands}
+	cases := []struct {
+		description string
+		builder     *fakeProjectHelpBuilder
+		expProjects string
+	}{
+		{
+			description: "projects",
+			builder: &fakeProjectHelpBuilder{projects: []valid.MergedProjectCfg{
+				{
+					Name:              "staging",
+					RepoRelDir:        "staging",
+					Workspace:         "default",
+					AutoplanEnabled:   true,
+					Workflow:          valid.Workflow{Name: "default"},
+					ApplyRequirements: []string{"approved", "mergeable"},
+				},
+				{
+					Name:               "shared",
+					RepoRelDir:         "shared",
+					Workspace:          "prod",
+					WorkspaceQualified: true,
+					Workflow:           valid.Workflow{Name: "custom"},
+				},
+				{
+					RepoRelDir: "modules/vpc",
+					Workspace:  "default",
+					Workflow:   valid.Workflow{Name: "default"},
+				},
+			}},
+			expProjects: "### Projects\n\n" +
+				"| Project | Dir | Workspace | Autoplan | Workflow | Apply requirements | Plan with |\n" +
+				"|---------|-----|-----------|----------|----------|--------------------|-----------|\n" +
+				"| `staging` | `staging` | `default` | yes | `default` | `approved`, `mergeable` | `atlantis plan -p staging` |\n" +
+				"| `shared` | `shared` | `prod` | no | `custom` | none | `atlantis plan -p shared -w prod` |\n" +
+				"|  | `modules/vpc` | `default` | no | `default` | none | `atlantis plan -d modules/vpc -w default` |\n" +
+				"\nApply a project with the same flags, ex. `atlantis apply -p staging`.",
+		},
+		{
+			description: "no projects",
+			builder:     &fakeProjectHelpBuilder{},
+			expProjects: "### Projects\n\nThis repo doesn't configure any projects and this pull request doesn't modify any.",
+		},
+		{
+			description: "error",
+			builder:     &fakeProjectHelpBuilder{err: errors.New("parsing atlantis.yaml: invalid")},
+			expProjects: "Unable to find the projects of this repo: parsing atlantis.yaml: invalid",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := vcsmocks.NewMockClient()
+			pull := models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo"}, Num: 1}
+			runner := events.NewHelpCommandRunner(vcsClient, commentParser, c.builder)
+			runner.Run(&command.Context{Pull: pull, Log: logging.NewNoopLogger(t)}, &events.CommentCommand{Name: command.Help})
+
+			_, _, _, comment, cmdName := vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(1), Any[string](), Any[string]()).GetCapturedArguments()
+			Equals(t, commentParser.HelpComment()+"\n\n"+c.expProjects, comment)
+			Equals(t, "help", cmdName)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
@@ -58,6 +59,44 @@ func (b *InstrumentedProjectCommandBuilder) BuildStateRmCommands(ctx *command.Co
 	)
 }
 
+func (b *InstrumentedProjectCommandBuilder) BuildForceUnlockCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.buildAndEmitStats(
+		"force-unlock",
+		func() ([]command.ProjectContext, error) {
+			return b.ProjectCommandBuilder.BuildForceUnlockCommands(ctx, comment)
+		},
+	)
+}
+
+func (b *InstrumentedProjectCommandBuilder) BuildWorkspacesCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.buildAndEmitStats(
+		"workspaces",
+		func() ([]command.ProjectContext, error) {
+			return b.ProjectCommandBuilder.BuildWorkspacesCommands(ctx, comment)
+		},
+	)
+}
+
+// BuildHelpProjects finds the projects that help describes if the wrapped
+// builder can find them.
+func (b *InstrumentedProjectCommandBuilder) BuildHelpProjects(ctx *command.Context) ([]valid.MergedProjectCfg, error) {
+	helpBuilder, ok := b.ProjectCommandBuilder.(ProjectHelpBuilder)
+	if !ok {
+		return nil, nil
+	}
+	return helpBuilder.BuildHelpProjects(ctx)
+}
+
+// SimulateProjects finds the projects globalCfg would configure if the
+// wrapped builder can simulate them.
+func (b *InstrumentedProjectCommandBuilder) SimulateProjects(ctx *command.Context, globalCfg valid.GlobalCfg) ([]valid.MergedProjectCfg, error) {
+	simulator, ok := b.ProjectCommandBuilder.(ProjectSimulator)
+	if !ok {
+		return nil, nil
+	}
+	return simulator.SimulateProjects(ctx, globalCfg)
+}
+
 func (b *InstrumentedProjectCommandBuilder) buildAndEmitStats(
 	command string,
 	execute func() ([]command.ProjectContext, error),
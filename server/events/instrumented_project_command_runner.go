@@ -13,6 +13,8 @@ type IntrumentedCommandRunner interface {
 	ApprovePolicies(ctx command.ProjectContext) command.ProjectResult
 	Import(ctx command.ProjectContext) command.ProjectResult
 	StateRm(ctx command.ProjectContext) command.ProjectResult
+	ForceUnlock(ctx command.ProjectContext) command.ProjectResult
+	Workspaces(ctx command.ProjectContext) command.ProjectResult
 }
 
 type InstrumentedProjectCommandRunner struct {
@@ -58,6 +60,14 @@ func (p *InstrumentedProjectCommandRunner) StateRm(ctx command.ProjectContext) c
 	return RunAndEmitStats(ctx, p.projectCommandRunner.StateRm, p.scope)
 }
 
+func (p *InstrumentedProjectCommandRunner) ForceUnlock(ctx command.ProjectContext) command.ProjectResult {
+	return RunAndEmitStats(ctx, p.projectCommandRunner.ForceUnlock, p.scope)
+}
+
+func (p *InstrumentedProjectCommandRunner) Workspaces(ctx command.ProjectContext) command.ProjectResult {
+	return RunAndEmitStats(ctx, p.projectCommandRunner.Workspaces, p.scope)
+}
+
 func RunAndEmitStats(ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult, scope tally.Scope) command.ProjectResult {
 	commandName := ctx.CommandName.String()
 	// ensures we are differentiating between project level command and overall command
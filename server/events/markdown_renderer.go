@@ -35,6 +35,8 @@ var (
 	versionCommandTitle         = command.Version.TitleString()
 	importCommandTitle          = command.Import.TitleString()
 	stateCommandTitle           = command.State.TitleString()
+	forceUnlockCommandTitle     = command.ForceUnlock.TitleString()
+	workspacesCommandTitle      = command.Workspaces.TitleString()
 	// maxUnwrappedLines is the maximum number of lines the Terraform output
 	// can be before we wrap it in an expandable template.
 	maxUnwrappedLines = 12
@@ -43,6 +45,10 @@ var (
 	templatesFS embed.FS
 )
 
+// historyRewrittenNote starts the comments of commands that ran after the
+// pull request's branch was force-pushed.
+const historyRewrittenNote = "**Note:** The branch was force-pushed, so the workspaces of this pull request were cloned again and the plans made before the push were discarded."
+
 // MarkdownRenderer renders responses as markdown.
 type MarkdownRenderer struct {
 	// gitlabSupportsCommonMark is true if the version of GitLab we're
@@ -130,6 +136,19 @@ type policyCheckResultsData struct {
 	PolicyApprovalSummary string
 	PolicyCleared         bool
 	commonData
+	// PolicyJustificationSummary lists the reasons given for approving
+	// policy sets.
+	PolicyJustificationSummary string
+}
+
+// digestData is the data of a digest of the results of many projects.
+type digestData struct {
+	Results []projectResultTmplData
+	commonData
+	NumSuccesses int
+	NumFailures  int
+	// Collapse is true if the results of each project are collapsed.
+	Collapse bool
 }
 
 type projectResultTmplData struct {
@@ -139,6 +158,9 @@ type projectResultTmplData struct {
 	Rendered     string
 	NoChanges    bool
 	IsSuccessful bool
+	// PolicyJustifications lists the reasons given for approving the
+	// project's policy sets.
+	PolicyJustifications string
 }
 
 // Initialize templates
@@ -177,6 +199,25 @@ func NewMarkdownRenderer(
 // Render formats the data into a markdown string.
 // nolint: interfacer
 func (m *MarkdownRenderer) Render(ctx *command.Context, res command.Result, cmd PullCommand) string {
+	comment := m.render(ctx, res, cmd, false)
+	if ctx.HistoryRewritten {
+		return historyRewrittenNote + "\n\n" + comment
+	}
+	return comment
+}
+
+// RenderDigest formats the results of many projects into a digest: a summary
+// of every project followed by a section with the results of each, collapsed
+// where the VCS host supports it.
+func (m *MarkdownRenderer) RenderDigest(ctx *command.Context, res command.Result, cmd PullCommand) string {
+	comment := m.render(ctx, res, cmd, true)
+	if ctx.HistoryRewritten {
+		return historyRewrittenNote + "\n\n" + comment
+	}
+	return comment
+}
+
+func (m *MarkdownRenderer) render(ctx *command.Context, res command.Result, cmd PullCommand, digest bool) string {
 	commandStr := cases.Title(language.English).String(strings.Replace(cmd.CommandName().String(), "_", " ", -1))
 	var vcsRequestType string
 	if ctx.Pull.BaseRepo.VCSHost.Type == models.Gitlab {
@@ -209,10 +250,10 @@ func (m *MarkdownRenderer) Render(ctx *command.Context, res command.Result, cmd
 	if res.Failure != "" {
 		return m.renderTemplateTrimSpace(templates.Lookup("failureWithLog"), failureData{res.Failure, "", common})
 	}
-	return m.renderProjectResults(ctx, res.ProjectResults, common)
+	return m.renderProjectResults(ctx, res.ProjectResults, common, digest)
 }
 
-func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []command.ProjectResult, common commonData) string {
+func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []command.ProjectResult, common commonData, digest bool) string {
 	vcsHost := ctx.Pull.BaseRepo.VCSHost.Type
 
 	var resultsTmplData []projectResultTmplData
@@ -260,13 +301,14 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 			numPlanSuccesses++
 		} else if result.PolicyCheckResults != nil && common.Command == policyCheckCommandTitle {
 			policyCheckResults := policyCheckResultsData{
-				PreConftestOutput:     result.PolicyCheckResults.PreConftestOutput,
-				PostConftestOutput:    result.PolicyCheckResults.PostConftestOutput,
-				PolicyCheckResults:    *result.PolicyCheckResults,
-				PolicyCheckSummary:    result.PolicyCheckResults.Summary(),
-				PolicyApprovalSummary: result.PolicyCheckResults.PolicySummary(),
-				PolicyCleared:         result.PolicyCheckResults.PolicyCleared(),
-				commonData:            common,
+				PreConftestOutput:          result.PolicyCheckResults.PreConftestOutput,
+				PostConftestOutput:         result.PolicyCheckResults.PostConftestOutput,
+				PolicyCheckResults:         *result.PolicyCheckResults,
+				PolicyCheckSummary:         result.PolicyCheckResults.Summary(),
+				PolicyApprovalSummary:      result.PolicyCheckResults.PolicySummary(),
+				PolicyCleared:              result.PolicyCheckResults.PolicyCleared(),
+				commonData:                 common,
+				PolicyJustificationSummary: result.PolicyCheckResults.JustificationSummary(),
 			}
 			if m.shouldUseWrappedTmpl(vcsHost, result.PolicyCheckResults.CombinedOutput()) {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("policyCheckResultsWrapped"), policyCheckResults)
@@ -278,17 +320,19 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 			}
 		} else if result.PolicyCheckResults != nil && common.Command == approvePoliciesCommandTitle {
 			policyCheckResults := policyCheckResultsData{
-				PolicyCheckResults:    *result.PolicyCheckResults,
-				PolicyCheckSummary:    result.PolicyCheckResults.Summary(),
-				PolicyApprovalSummary: result.PolicyCheckResults.PolicySummary(),
-				PolicyCleared:         result.PolicyCheckResults.PolicyCleared(),
-				commonData:            common,
+				PolicyCheckResults:         *result.PolicyCheckResults,
+				PolicyCheckSummary:         result.PolicyCheckResults.Summary(),
+				PolicyApprovalSummary:      result.PolicyCheckResults.PolicySummary(),
+				PolicyCleared:              result.PolicyCheckResults.PolicyCleared(),
+				commonData:                 common,
+				PolicyJustificationSummary: result.PolicyCheckResults.JustificationSummary(),
 			}
 			if m.shouldUseWrappedTmpl(vcsHost, result.PolicyCheckResults.CombinedOutput()) {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("policyCheckResultsWrapped"), policyCheckResults)
 			} else {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("policyCheckResultsUnwrapped"), policyCheckResults)
 			}
+			resultData.PolicyJustifications = policyCheckResults.PolicyJustificationSummary
 			if result.Error == nil && result.Failure == "" {
 				numPolicyApprovalSuccesses++
 			}
@@ -299,6 +343,9 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 			} else {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("applyUnwrappedSuccess"), struct{ Output string }{output})
 			}
+			if len(result.ApplyOutputs) > 0 {
+				resultData.Rendered += "\n\n" + m.renderTemplateTrimSpace(templates.Lookup("applyOutputs"), struct{ Outputs []models.TerraformOutput }{result.ApplyOutputs})
+			}
 			numApplySuccesses++
 		} else if result.VersionSuccess != "" {
 			output := strings.TrimSpace(result.VersionSuccess)
@@ -322,6 +369,18 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 			} else {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("stateRmSuccessUnwrapped"), result.StateRmSuccess)
 			}
+		} else if result.ForceUnlockSuccess != nil {
+			result.ForceUnlockSuccess.Output = strings.TrimSpace(result.ForceUnlockSuccess.Output)
+			if m.shouldUseWrappedTmpl(vcsHost, result.ForceUnlockSuccess.Output) {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("forceUnlockSuccessWrapped"), result.ForceUnlockSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("forceUnlockSuccessUnwrapped"), result.ForceUnlockSuccess)
+			}
+		} else if result.WorkspacesSuccess != nil {
+			resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("workspacesSuccess"), struct {
+				models.WorkspacesSuccess
+				PullNum int
+			}{*result.WorkspacesSuccess, ctx.Pull.Num})
 			// Error out if no template was found, only if there are no errors or failures.
 			// This is because some errors and failures rely on additional context rendered by templates, but not all errors or failures.
 		} else if !(result.Error != nil || result.Failure != "") {
@@ -346,6 +405,18 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 		resultsTmplData = append(resultsTmplData, resultData)
 	}
 
+	if digest {
+		data := digestData{Results: resultsTmplData, commonData: common, Collapse: m.supportsFolding(vcsHost)}
+		for _, result := range resultsTmplData {
+			if result.IsSuccessful {
+				data.NumSuccesses++
+			} else {
+				data.NumFailures++
+			}
+		}
+		return m.renderTemplateTrimSpace(templates.Lookup("digest"), data)
+	}
+
 	var tmpl *template.Template
 	switch {
 	case len(resultsTmplData) == 1 && common.Command == planCommandTitle && numPlanSuccesses > 0:
@@ -371,6 +442,10 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 		default:
 			return fmt.Sprintf("no template matched–this is a bug: command=%s, subcommand=%s", common.Command, common.SubCommand)
 		}
+	case len(resultsTmplData) == 1 && common.Command == forceUnlockCommandTitle:
+		tmpl = templates.Lookup("singleProjectForceUnlock")
+	case len(resultsTmplData) == 1 && common.Command == workspacesCommandTitle:
+		tmpl = templates.Lookup("singleProjectWorkspaces")
 	case common.Command == planCommandTitle:
 		tmpl = templates.Lookup("multiProjectPlan")
 	case common.Command == policyCheckCommandTitle:
@@ -389,6 +464,8 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 		tmpl = templates.Lookup("multiProjectApply")
 	case common.Command == versionCommandTitle:
 		tmpl = templates.Lookup("multiProjectVersion")
+	case common.Command == workspacesCommandTitle:
+		tmpl = templates.Lookup("multiProjectWorkspaces")
 	case common.Command == importCommandTitle:
 		tmpl = templates.Lookup("multiProjectImport")
 	case common.Command == stateCommandTitle:
@@ -417,20 +494,26 @@ func (m *MarkdownRenderer) renderProjectResults(ctx *command.Context, results []
 // load. Some VCS providers or versions of VCS providers don't support this
 // syntax.
 func (m *MarkdownRenderer) shouldUseWrappedTmpl(vcsHost models.VCSHostType, output string) bool {
-	if m.disableMarkdownFolding {
+	if !m.supportsFolding(vcsHost) {
 		return false
 	}
 
-	// Bitbucket Cloud and Server don't support the folding markdown syntax.
-	if vcsHost == models.BitbucketServer || vcsHost == models.BitbucketCloud {
+	return strings.Count(output, "\n") > maxUnwrappedLines
+}
+
+// supportsFolding returns true if output can be collapsed in comments on
+// vcsHost.
+func (m *MarkdownRenderer) supportsFolding(vcsHost models.VCSHostType) bool {
+	if m.disableMarkdownFolding {
 		return false
 	}
 
-	if vcsHost == models.Gitlab && !m.gitlabSupportsCommonMark {
+	// Bitbucket Cloud and Server don't support the folding markdown syntax.
+	if vcsHost == models.BitbucketServer || vcsHost == models.BitbucketCloud {
 		return false
 	}
 
-	return strings.Count(output, "\n") > maxUnwrappedLines
+	return vcsHost != models.Gitlab || m.gitlabSupportsCommonMark
 }
 
 func (m *MarkdownRenderer) renderTemplateTrimSpace(tmpl *template.Template, data interface{}) string {
@@ -221,6 +221,30 @@ func TestRenderErrAndFailure(t *testing.T) {
 	Equals(t, "**Plan Error**\n```\nerror\n```", normalize(s))
 }
 
+func TestRenderHistoryRewritten(t *testing.T) {
+	r := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log:              logging.NewNoopLogger(t).WithHistory(),
+		Pull:             models.PullRequest{BaseRepo: models.Repo{VCSHost: models.VCSHost{Type: models.Github}}},
+		HistoryRewritten: true,
+	}
+	cmd := &events.CommentCommand{Name: command.Plan}
+
+	s := r.Render(ctx, command.Result{Error: errors.New("error")}, cmd)
+	Equals(t, "**Note:** The branch was force-pushed, so the workspaces of this pull request were cloned again and the plans made before the push were discarded.\n\n**Plan Error**\n```\nerror\n```", normalize(s))
+}
+
 func TestRenderProjectResults(t *testing.T) {
 	cases := []struct {
 		Description    string
@@ -597,6 +621,64 @@ $$$
   $$$shell
   atlantis plan -d path -w workspace
   $$$
+`,
+		},
+		{
+			"single successful force-unlock",
+			command.ForceUnlock,
+			"",
+			[]command.ProjectResult{
+				{
+					ForceUnlockSuccess: &models.ForceUnlockSuccess{
+						Output:    "Terraform state has been successfully unlocked!",
+						RePlanCmd: "atlantis plan -d path -w workspace",
+					},
+					Workspace:   "workspace",
+					RepoRelDir:  "path",
+					ProjectName: "projectname",
+				},
+			},
+			models.Github,
+			`
+Ran Force-Unlock for project: $projectname$ dir: $path$ workspace: $workspace$
+
+$$$diff
+Terraform state has been successfully unlocked!
+$$$
+
+:unlock: The state lock was released. Make sure the operation that held it is no longer running.
+
+* :repeat: To **plan** this project again, comment:
+  $$$shell
+  atlantis plan -d path -w workspace
+  $$$
+`,
+		},
+		{
+			"single successful workspaces",
+			command.Workspaces,
+			"",
+			[]command.ProjectResult{
+				{
+					WorkspacesSuccess: &models.WorkspacesSuccess{
+						Workspaces: []models.WorkspaceStatus{
+							{Name: "default", LockedBy: 7},
+							{Name: "staging", Plans: map[string]models.ProjectPlanStatus{"": models.PlannedPlanStatus, "other": models.ErroredPlanStatus}},
+						},
+					},
+					Workspace:   "default",
+					RepoRelDir:  "path",
+					ProjectName: "projectname",
+				},
+			},
+			models.Github,
+			`
+Ran Workspaces for project: $projectname$ dir: $path$
+
+| Workspace | Atlantis lock | Plan |
+|-----------|---------------|------|
+| $default$ | #7 | |
+| $staging$ |  | planned $other$: plan_errored |
 `,
 		},
 		{
@@ -2128,6 +2210,355 @@ func TestRenderProjectResults_DisableFolding(t *testing.T) {
 	Equals(t, false, strings.Contains(rendered, "\n<details>"))
 }
 
+func TestRenderProjectResults_PreviousPlanDiff(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log: logging.NewNoopLogger(t).WithHistory(),
+		Pull: models.PullRequest{
+			BaseRepo: models.Repo{
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+	}
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "terraform-output",
+					LockURL:         "lock-url",
+					RePlanCmd:       "atlantis plan -d .",
+					ApplyCmd:        "atlantis apply -d .",
+					PreviousPlanDiff: &models.PlanDiff{
+						Added:   []models.ResourceChange{{Address: "aws_s3_bucket.logs", Action: "create"}},
+						Removed: []models.ResourceChange{{Address: "aws_iam_role.old", Action: "delete"}},
+						Changed: []models.ResourceActionChange{{Address: "aws_instance.db", PreviousAction: "update", Action: "replace"}},
+					},
+				},
+			},
+		},
+	}
+	cmd := &events.CommentCommand{
+		Name: command.Plan,
+	}
+	rendered := mr.Render(ctx, res, cmd)
+	Assert(t, strings.Contains(rendered, `<details><summary>Changes since the last plan</summary>
+
+`+"```diff"+`
++ aws_s3_bucket.logs (create)
+- aws_iam_role.old (delete)
+! aws_instance.db (update -> replace)
+`+"```"+`
+</details>`), "exp rendered comment to contain the plan diff, got: %s", rendered)
+
+	// An empty diff isn't rendered.
+	res.ProjectResults[0].PlanSuccess.PreviousPlanDiff = &models.PlanDiff{}
+	rendered = mr.Render(ctx, res, cmd)
+	Equals(t, false, strings.Contains(rendered, "Changes since the last plan"))
+}
+
+func TestRenderProjectResults_OutdatedDependencies(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log: logging.NewNoopLogger(t).WithHistory(),
+		Pull: models.PullRequest{
+			BaseRepo: models.Repo{
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+	}
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "terraform-output",
+					LockURL:         "lock-url",
+					RePlanCmd:       "atlantis plan -d .",
+					ApplyCmd:        "atlantis apply -d .",
+					OutdatedDependencies: []models.OutdatedDependency{
+						{Type: "provider", Source: "registry.terraform.io/hashicorp/aws", Version: "5.1.0", Latest: "5.40.0"},
+						{Type: "module", Source: "registry.terraform.io/terraform-aws-modules/vpc/aws", Version: "5.0.0", Latest: "5.8.1"},
+					},
+				},
+			},
+		},
+	}
+	cmd := &events.CommentCommand{
+		Name: command.Plan,
+	}
+	rendered := mr.Render(ctx, res, cmd)
+	Assert(t, strings.Contains(rendered, `<details><summary>Outdated dependencies</summary>
+
+| Dependency | Type | Version | Latest |
+|------------|------|---------|--------|
+| `+"`registry.terraform.io/hashicorp/aws`"+` | provider | 5.1.0 | 5.40.0 |
+| `+"`registry.terraform.io/terraform-aws-modules/vpc/aws`"+` | module | 5.0.0 | 5.8.1 |
+</details>`), "exp rendered comment to contain the outdated dependencies, got: %s", rendered)
+
+	res.ProjectResults[0].PlanSuccess.OutdatedDependencies = nil
+	rendered = mr.Render(ctx, res, cmd)
+	Equals(t, false, strings.Contains(rendered, "Outdated dependencies"))
+}
+
+// The plan comment of a project run by the check engine shouldn't tell users
+// to apply it.
+func TestRenderProjectResults_CheckOnly(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log: logging.NewNoopLogger(t).WithHistory(),
+		Pull: models.PullRequest{
+			BaseRepo: models.Repo{
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+	}
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				RepoRelDir: "charts",
+				Workspace:  "default",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "All manifests are valid",
+					LockURL:         "lock-url",
+					RePlanCmd:       "atlantis plan -d charts",
+					ApplyCmd:        "atlantis apply -d charts",
+					CheckOnly:       true,
+				},
+			},
+		},
+	}
+	rendered := mr.Render(ctx, res, &events.CommentCommand{Name: command.Plan})
+	Assert(t, strings.Contains(rendered, "All manifests are valid"), "exp rendered comment to contain the check output, got: %s", rendered)
+	Assert(t, strings.Contains(rendered, "atlantis plan -d charts"), "exp rendered comment to contain the plan command, got: %s", rendered)
+	Equals(t, false, strings.Contains(rendered, "atlantis apply -d charts"))
+}
+
+func TestRenderProjectResults_PolicyJustifications(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log: logging.NewNoopLogger(t).WithHistory(),
+		Pull: models.PullRequest{
+			BaseRepo: models.Repo{
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+	}
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				PolicyCheckResults: &models.PolicyCheckResults{
+					PolicySetResults: []models.PolicySetResult{
+						{
+							PolicySetName: "policy1",
+							ReqApprovals:  1,
+							CurApprovals:  1,
+							Justifications: []models.PolicySetJustification{
+								{User: "lkysow", Reason: "hotfix for outage"},
+							},
+						},
+					},
+					LockURL:   "lock-url",
+					RePlanCmd: "atlantis plan -d .",
+					ApplyCmd:  "atlantis apply -d .",
+				},
+			},
+		},
+	}
+	cmd := &events.CommentCommand{
+		Name: command.ApprovePolicies,
+	}
+	rendered := mr.Render(ctx, res, cmd)
+	Equals(t, "Approved Policies for 1 projects:\n\n1. dir: `.` workspace: `default`\n\n"+
+		"#### Policy Approval Justifications for dir: `.` workspace: `default`\n```\npolicy set: policy1: approved by lkysow: hotfix for outage\n```", rendered)
+
+	// Justifications are also rendered while approvals are still required.
+	res.ProjectResults[0].PolicyCheckResults.PolicySetResults[0].ReqApprovals = 2
+	res.ProjectResults[0].Failure = "One or more policy sets require additional approval."
+	rendered = mr.Render(ctx, res, cmd)
+	Assert(t, strings.Contains(rendered, "#### Policy Approval Justifications:\n```\npolicy set: policy1: approved by lkysow: hotfix for outage\n```"),
+		"exp rendered comment to contain the justifications, got: %s", rendered)
+
+	// Approvals without a reason don't render the section.
+	res.ProjectResults[0].PolicyCheckResults.PolicySetResults[0].Justifications = nil
+	rendered = mr.Render(ctx, res, cmd)
+	Equals(t, false, strings.Contains(rendered, "Policy Approval Justifications"))
+}
+
+func TestRenderProjectResults_ApplyOutputs(t *testing.T) {
+	mr := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		"",         // markdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+		false,      // quietPolicyChecks
+	)
+	ctx := &command.Context{
+		Log: logging.NewNoopLogger(t).WithHistory(),
+		Pull: models.PullRequest{
+			BaseRepo: models.Repo{
+				VCSHost: models.VCSHost{
+					Type: models.Github,
+				},
+			},
+		},
+	}
+	res := command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				RepoRelDir:   ".",
+				Workspace:    "default",
+				ApplySuccess: "success",
+				ApplyOutputs: []models.TerraformOutput{
+					{Name: "endpoint", Value: "https://example.com"},
+					{Name: "password", Sensitive: true},
+				},
+			},
+		},
+	}
+	rendered := mr.Render(ctx, res, &events.CommentCommand{Name: command.Apply})
+	Equals(t, "Ran Apply for dir: `.` workspace: `default`\n\n```diff\nsuccess\n```\n\n"+
+		"**Outputs**\n\n* `endpoint`: `https://example.com`\n* `password`: _(sensitive value)_", rendered)
+}
+
+func TestRenderDigest(t *testing.T) {
+	cases := []struct {
+		VCSHost models.VCSHostType
+		Exp     string
+	}{
+		{
+			VCSHost: models.Github,
+			Exp: `Ran Apply for 2 projects: 1 succeeded, 1 failed
+
+| | Project | Dir | Workspace |
+|---|---|---|---|
+| :white_check_mark: | ` + "`app`" + ` | ` + "`app`" + ` | ` + "`default`" + ` |
+| :x: |  | ` + "`db`" + ` | ` + "`default`" + ` |
+
+<details><summary>1. project: <code>app</code> dir: <code>app</code> workspace: <code>default</code></summary>
+
+` + "```diff\nsuccess\n```" + `
+</details>
+
+<details><summary>2. dir: <code>db</code> workspace: <code>default</code></summary>
+
+**Apply Failed**: locked
+</details>`,
+		},
+		{
+			VCSHost: models.BitbucketCloud,
+			Exp: `Ran Apply for 2 projects: 1 succeeded, 1 failed
+
+| | Project | Dir | Workspace |
+|---|---|---|---|
+| :white_check_mark: | ` + "`app`" + ` | ` + "`app`" + ` | ` + "`default`" + ` |
+| :x: |  | ` + "`db`" + ` | ` + "`default`" + ` |
+
+### 1. project: ` + "`app`" + ` dir: ` + "`app`" + ` workspace: ` + "`default`" + `
+` + "```diff\nsuccess\n```" + `
+
+---
+### 2. dir: ` + "`db`" + ` workspace: ` + "`default`" + `
+**Apply Failed**: locked
+
+---`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.VCSHost.String(), func(t *testing.T) {
+			mr := events.NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false)
+			ctx := &command.Context{
+				Log: logging.NewNoopLogger(t).WithHistory(),
+				Pull: models.PullRequest{
+					BaseRepo: models.Repo{
+						VCSHost: models.VCSHost{
+							Type: c.VCSHost,
+						},
+					},
+				},
+			}
+			res := command.Result{
+				ProjectResults: []command.ProjectResult{
+					{
+						ProjectName:  "app",
+						RepoRelDir:   "app",
+						Workspace:    "default",
+						ApplySuccess: "success",
+					},
+					{
+						RepoRelDir: "db",
+						Workspace:  "default",
+						Failure:    "locked",
+					},
+				},
+			}
+			rendered := mr.RenderDigest(ctx, res, &events.CommentCommand{Name: command.Apply})
+			Equals(t, c.Exp, rendered)
+		})
+	}
+}
+
 // Test that if the output is longer than 12 lines, it gets wrapped on the right
 // VCS hosts during an error.
 func TestRenderProjectResults_WrappedErr(t *testing.T) {
@@ -0,0 +1,198 @@
+// Package messages holds the text of the comments and commit statuses
+// Atlantis responds to pull requests with, so that it can be translated or
+// changed to match an organization's terminology.
+package messages
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ID identifies a message.
+type ID string
+
+const (
+	// StatusPending is the description of a pending commit status. It's
+	// formatted with the title-cased command name, ex. Plan.
+	StatusPending ID = "status.pending"
+	// StatusFailed is the description of a failed commit status.
+	StatusFailed ID = "status.failed"
+	// StatusCanceled is the description of a canceled commit status.
+	StatusCanceled ID = "status.canceled"
+	// StatusSucceeded is the description of a successful commit status.
+	StatusSucceeded ID = "status.succeeded"
+	// StatusPlanCount is the description of the plan commit status. It's
+	// formatted with the number of projects planned successfully and the
+	// total number of projects.
+	StatusPlanCount ID = "status.plan-count"
+	// StatusPolicyCheckCount is the description of the policy check commit
+	// status.
+	StatusPolicyCheckCount ID = "status.policy-check-count"
+	// StatusApplyCount is the description of the apply commit status.
+	StatusApplyCount ID = "status.apply-count"
+	// StatusSummary is the description of the summary commit status. It's
+	// formatted with the number of projects planned, the total number of
+	// projects, the number of projects applied and the total again.
+	StatusSummary ID = "status.summary"
+
+	// CommentHelp is the comment made for `atlantis help`. It's a
+	// text/template executed with the executable name and which commands
+	// are allowed.
+	CommentHelp ID = "comment.help"
+	// CommentDidYouMean is made when a command is run with a misspelled
+	// executable name or with terraform. It's formatted with the executable
+	// name and the name that was used.
+	CommentDidYouMean ID = "comment.did-you-mean"
+	// CommentParseError is made when a command can't be parsed. It's
+	// formatted with the error.
+	CommentParseError ID = "comment.parse-error"
+	// CommentUnknownCommand is made when an unknown or disallowed command is
+	// run. It's formatted with the command, the executable name and the
+	// allowed commands.
+	CommentUnknownCommand ID = "comment.unknown-command"
+	// CommentShutdown is made when a command is run while the server is
+	// shutting down.
+	CommentShutdown ID = "comment.shutdown"
+	// CommentForkPRsDisallowed is made when a command is run on a pull
+	// request from a fork. It's formatted with the names of the flags that
+	// allow fork pull requests and silence the comment.
+	CommentForkPRsDisallowed ID = "comment.fork-prs-disallowed"
+	// CommentForkPRRestricted is made when a command other than plan is run
+	// on a restricted fork pull request. It's formatted with the command,
+	// the pull request's author and the name of the allowlist flag.
+	CommentForkPRRestricted ID = "comment.fork-pr-restricted"
+	// CommentClosedPR is made when a command is run on a closed pull
+	// request.
+	CommentClosedPR ID = "comment.closed-pr"
+	// CommentApplyDisabled is made when apply is run while applies are
+	// disabled.
+	CommentApplyDisabled ID = "comment.apply-disabled"
+	// CommentApplyAllDisabled is made when apply is run without flags while
+	// applying all projects at once is disabled.
+	CommentApplyAllDisabled ID = "comment.apply-all-disabled"
+)
+
+// message is the default text of a message.
+type message struct {
+	// text is a fmt format string, or a text/template if isTemplate is true.
+	text string
+	// args are example arguments text is formatted with, used to check that
+	// overrides expect the same arguments.
+	args       []any
+	isTemplate bool
+}
+
+var defaults = map[ID]message{
+	StatusPending:          {text: "%s in progress...", args: []any{"Plan"}},
+	StatusFailed:           {text: "%s failed.", args: []any{"Plan"}},
+	StatusCanceled:         {text: "%s canceled.", args: []any{"Plan"}},
+	StatusSucceeded:        {text: "%s succeeded.", args: []any{"Plan"}},
+	StatusPlanCount:        {text: "%d/%d projects planned successfully.", args: []any{1, 2}},
+	StatusPolicyCheckCount: {text: "%d/%d projects policies checked successfully.", args: []any{1, 2}},
+	StatusApplyCount:       {text: "%d/%d projects applied successfully.", args: []any{1, 2}},
+	StatusSummary:          {text: "%d/%d projects planned, %d/%d applied.", args: []any{2, 2, 1, 2}},
+
+	CommentHelp:       {text: helpCommentTemplate, isTemplate: true},
+	CommentDidYouMean: {text: "Did you mean to use `%s` instead of `%s`?", args: []any{"atlantis", "terraform"}},
+	CommentParseError: {text: "```\nError parsing command: %s\n```", args: []any{"EOF found after escape character"}},
+	CommentUnknownCommand: {
+		text: "```\nError: unknown command %q.\nRun '%s --help' for usage.\nAvailable commands(--allow-commands): %s\n```",
+		args: []any{"destroy", "atlantis", "plan, apply"},
+	},
+	CommentShutdown: {text: "Atlantis server is shutting down, please try again later."},
+	CommentForkPRsDisallowed: {
+		text: "Atlantis commands can't be run on fork pull requests. To enable, set --%s  or, to disable this message, set --%s",
+		args: []any{"allow-fork-prs", "silence-fork-pr-errors"},
+	},
+	CommentForkPRRestricted: {
+		text: "Only plans can run on fork pull requests. To run `%s`, the pull request author @%s must be added to --%s",
+		args: []any{"apply", "author", "fork-pr-allowlist"},
+	},
+	CommentClosedPR:      {text: "Atlantis commands can't be run on closed pull requests"},
+	CommentApplyDisabled: {text: "**Error:** Running `atlantis apply` is disabled."},
+	CommentApplyAllDisabled: {
+		text: "**Error:** Running `atlantis apply` without flags is disabled." +
+			" You must specify which project to apply via the `-d <dir>`, `-w <workspace>` or `-p <project name>` flags.",
+	},
+}
+
+// Catalog is a set of messages that override the default, English text of
+// messages. A nil Catalog uses the default text of every message.
+type Catalog struct {
+	messages map[ID]string
+}
+
+// Load reads a catalog from the YAML or JSON file at path, which maps message
+// IDs to their text. Messages that aren't in the file keep their default
+// text. Messages must be formatted with the same arguments as their default
+// text, although they can be reordered with explicit argument indexes, ex.
+// %[2]s.
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	c := &Catalog{messages: make(map[ID]string)}
+	for key, text := range raw {
+		id := ID(key)
+		if err := validate(id, text); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		c.messages[id] = text
+	}
+	return c, nil
+}
+
+// validate returns an error if id isn't a known message or text expects
+// different arguments than its default text.
+func validate(id ID, text string) error {
+	def, ok := defaults[id]
+	if !ok {
+		return fmt.Errorf("unknown message %q, must be one of %s", id, strings.Join(IDs(), ", "))
+	}
+	if def.isTemplate {
+		if _, err := template.New(string(id)).Parse(text); err != nil {
+			return fmt.Errorf("message %q: %w", id, err)
+		}
+		return nil
+	}
+	// fmt reports missing, extra and mismatched arguments inline with %!.
+	if formatted := fmt.Sprintf(text, def.args...); strings.Contains(formatted, "%!") {
+		return fmt.Errorf("message %q must be formatted like %q, got %q", id, def.text, formatted)
+	}
+	return nil
+}
+
+// IDs returns the IDs of every message, sorted.
+func IDs() []string {
+	var ids []string
+	for id := range defaults {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Text returns the unformatted text of id, ex. to parse it as a template.
+func (c *Catalog) Text(id ID) string {
+	if c != nil {
+		if text, ok := c.messages[id]; ok {
+			return text
+		}
+	}
+	return defaults[id].text
+}
+
+// Sprintf formats the text of id with args.
+func (c *Catalog) Sprintf(id ID, args ...any) string {
+	return fmt.Sprintf(c.Text(id), args...)
+}
@@ -0,0 +1,91 @@
+package messages_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/messages"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := writeCatalog(t, `
+status.pending: "%s en cours..."
+status.summary: "%[3]d/%[4]d appliqués, %[1]d/%[2]d planifiés."
+comment.closed-pr: "Pull request fermée."
+comment.help: "{{ .ExecutableName }} aide"
+`)
+	c, err := messages.Load(path)
+	Ok(t, err)
+	Equals(t, "Plan en cours...", c.Sprintf(messages.StatusPending, "Plan"))
+	Equals(t, "1/2 appliqués, 2/2 planifiés.", c.Sprintf(messages.StatusSummary, 2, 2, 1, 2))
+	Equals(t, "Pull request fermée.", c.Sprintf(messages.CommentClosedPR))
+	Equals(t, "{{ .ExecutableName }} aide", c.Text(messages.CommentHelp))
+	// Messages that aren't overridden keep their default text.
+	Equals(t, "Plan failed.", c.Sprintf(messages.StatusFailed, "Plan"))
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := writeCatalog(t, `{"status.failed": "%s fehlgeschlagen."}`)
+	c, err := messages.Load(path)
+	Ok(t, err)
+	Equals(t, "Apply fehlgeschlagen.", c.Sprintf(messages.StatusFailed, "Apply"))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	cases := []struct {
+		description string
+		contents    string
+		expErr      string
+	}{
+		{
+			description: "unknown id",
+			contents:    `status.unknown: "text"`,
+			expErr:      `unknown message "status.unknown"`,
+		},
+		{
+			description: "missing argument",
+			contents:    `status.summary: "%d/%d planned"`,
+			expErr:      `message "status.summary" must be formatted like "%d/%d projects planned, %d/%d applied."`,
+		},
+		{
+			description: "extra argument",
+			contents:    `comment.closed-pr: "closed %s"`,
+			expErr:      `message "comment.closed-pr" must be formatted like`,
+		},
+		{
+			description: "wrong verb",
+			contents:    `status.plan-count: "%s/%s planned"`,
+			expErr:      `message "status.plan-count" must be formatted like`,
+		},
+		{
+			description: "invalid template",
+			contents:    `comment.help: "{{ .ExecutableName "`,
+			expErr:      `message "comment.help"`,
+		},
+		{
+			description: "not a map",
+			contents:    `- status.pending`,
+			expErr:      "parsing",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			_, err := messages.Load(writeCatalog(t, c.contents))
+			ErrContains(t, c.expErr, err)
+		})
+	}
+}
+
+func TestCatalog_Nil(t *testing.T) {
+	var c *messages.Catalog
+	Equals(t, "Plan in progress...", c.Sprintf(messages.StatusPending, "Plan"))
+	Equals(t, "Atlantis server is shutting down, please try again later.", c.Sprintf(messages.CommentShutdown))
+}
+
+func writeCatalog(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "messages.yaml")
+	Ok(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
@@ -0,0 +1,87 @@
+package messages
+
+// helpCommentTemplate is the default text of CommentHelp.
+var helpCommentTemplate = "```cmake\n" +
+	`atlantis
+Terraform Pull Request Automation
+
+Usage:
+  {{ .ExecutableName }} <command> [options] -- [terraform options]
+
+Examples:
+  # show atlantis help
+  {{ .ExecutableName }} help
+{{- if .AllowPlan }}
+
+  # run plan in the root directory passing the -target flag to terraform
+  {{ .ExecutableName }} plan -d . -- -target=resource
+{{- end }}
+{{- if .AllowApply }}
+
+  # apply all unapplied plans from this pull request
+  {{ .ExecutableName }} apply
+
+  # apply the plan for the root directory and staging workspace
+  {{ .ExecutableName }} apply -d . -w staging
+{{- end }}
+
+Commands:
+{{- if .AllowPlan }}
+  plan     Runs 'terraform plan' for the changes in this pull request.
+           To plan a specific project, use the -d, -w and -p flags.
+{{- end }}
+{{- if .AllowApply }}
+  apply    Runs 'terraform apply' on all unapplied plans from this pull request.
+           To only apply a specific plan, use the -d, -w and -p flags.
+{{- end }}
+{{- if .AllowUnlock }}
+  unlock   Removes all atlantis locks and discards all plans for this PR.
+           To unlock a specific plan you can use the Atlantis UI.
+{{- end }}
+{{- if .AllowApprovePolicies }}
+  approve_policies
+           Approves all current policy checking failures for the PR.
+{{- end }}
+{{- if .AllowVersion }}
+  version  Print the output of 'terraform version'
+{{- end }}
+{{- if .AllowImport }}
+  import ADDRESS ID
+           Runs 'terraform import' for the passed address resource.
+           To import a specific project, use the -d, -w and -p flags.
+{{- end }}
+{{- if .AllowState }}
+  state rm ADDRESS...
+           Runs 'terraform state rm' for the passed address resource.
+           To remove a specific project resource, use the -d, -w and -p flags.
+{{- end }}
+{{- if .AllowForceUnlock }}
+  force-unlock LOCK_ID
+           Runs 'terraform force-unlock' to release a stuck state lock.
+           To release the lock of a specific project, use the -d, -w and -p flags.
+{{- end }}
+{{- if .AllowCancel }}
+  cancel   Cancels the plans and applies running for this pull request.
+           To cancel a specific project, use the -d, -w and -p flags.
+{{- end }}
+{{- if .AllowWorkspaces }}
+  workspaces
+           Lists the terraform workspaces and which have an Atlantis lock
+           or plan for this PR. To list a specific project, use the -d and -p flags.
+{{- end }}
+{{- if .AllowStatus }}
+  status   Summarizes the projects of this PR, their latest results, who ran
+           them and the apply requirements they're still missing.
+{{- end }}
+{{- if .AllowConfirm }}
+  confirm NONCE
+           Runs an apply that requires confirmation. The nonce is commented
+           when the apply is run.
+{{- end }}
+  help     View help.
+
+Flags:
+  -h, --help   help for atlantis
+
+Use "{{ .ExecutableName }} [command] --help" for more information about a command.` +
+	"\n```"
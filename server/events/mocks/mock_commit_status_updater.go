@@ -87,6 +87,21 @@ func (mock *MockCommitStatusUpdater) UpdatePreWorkflowHook(logger logging.Simple
 	return _ret0
 }
 
+func (mock *MockCommitStatusUpdater) UpdateSummary(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, pullStatus models.PullStatus) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommitStatusUpdater().")
+	}
+	_params := []pegomock.Param{logger, repo, pull, pullStatus}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("UpdateSummary", _params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(error)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockCommitStatusUpdater) VerifyWasCalledOnce() *VerifierMockCommitStatusUpdater {
 	return &VerifierMockCommitStatusUpdater{
 		mock:                   mock,
@@ -359,3 +374,50 @@ func (c *MockCommitStatusUpdater_UpdatePreWorkflowHook_OngoingVerification) GetA
 	}
 	return
 }
+
+func (verifier *VerifierMockCommitStatusUpdater) UpdateSummary(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, pullStatus models.PullStatus) *MockCommitStatusUpdater_UpdateSummary_OngoingVerification {
+	_params := []pegomock.Param{logger, repo, pull, pullStatus}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "UpdateSummary", _params, verifier.timeout)
+	return &MockCommitStatusUpdater_UpdateSummary_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommitStatusUpdater_UpdateSummary_OngoingVerification struct {
+	mock              *MockCommitStatusUpdater
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommitStatusUpdater_UpdateSummary_OngoingVerification) GetCapturedArguments() (logging.SimpleLogging, models.Repo, models.PullRequest, models.PullStatus) {
+	logger, repo, pull, pullStatus := c.GetAllCapturedArguments()
+	return logger[len(logger)-1], repo[len(repo)-1], pull[len(pull)-1], pullStatus[len(pullStatus)-1]
+}
+
+func (c *MockCommitStatusUpdater_UpdateSummary_OngoingVerification) GetAllCapturedArguments() (_param0 []logging.SimpleLogging, _param1 []models.Repo, _param2 []models.PullRequest, _param3 []models.PullStatus) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]logging.SimpleLogging, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(logging.SimpleLogging)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]models.Repo, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(models.Repo)
+			}
+		}
+		if len(_params) > 2 {
+			_param2 = make([]models.PullRequest, len(c.methodInvocations))
+			for u, param := range _params[2] {
+				_param2[u] = param.(models.PullRequest)
+			}
+		}
+		if len(_params) > 3 {
+			_param3 = make([]models.PullStatus, len(c.methodInvocations))
+			for u, param := range _params[3] {
+				_param3[u] = param.(models.PullStatus)
+			}
+		}
+	}
+	return
+}
@@ -31,11 +31,11 @@ func NewMockEventParsing(options ...pegomock.Option) *MockEventParsing {
 func (mock *MockEventParsing) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockEventParsing) FailHandler() pegomock.FailHandler      { return mock.fail }
 
-func (mock *MockEventParsing) GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string) models.PullRequestEventType {
+func (mock *MockEventParsing) GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string, draft bool) models.PullRequestEventType {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
 	}
-	_params := []pegomock.Param{eventTypeHeader, sha, pr}
+	_params := []pegomock.Param{eventTypeHeader, sha, pr, draft}
 	_result := pegomock.GetGenericMockFrom(mock).Invoke("GetBitbucketCloudPullEventType", _params, []reflect.Type{reflect.TypeOf((*models.PullRequestEventType)(nil)).Elem()})
 	var _ret0 models.PullRequestEventType
 	if len(_result) != 0 {
@@ -471,6 +471,33 @@ func (mock *MockEventParsing) ParseGithubPullEvent(logger logging.SimpleLogging,
 	return _ret0, _ret1, _ret2, _ret3, _ret4, _ret5
 }
 
+func (mock *MockEventParsing) ParseGithubPullRequestReviewCommentEvent(logger logging.SimpleLogging, comment *github.PullRequestReviewCommentEvent) (models.Repo, models.User, int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
+	}
+	_params := []pegomock.Param{logger, comment}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGithubPullRequestReviewCommentEvent", _params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*models.User)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var _ret0 models.Repo
+	var _ret1 models.User
+	var _ret2 int
+	var _ret3 error
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(models.Repo)
+		}
+		if _result[1] != nil {
+			_ret1 = _result[1].(models.User)
+		}
+		if _result[2] != nil {
+			_ret2 = _result[2].(int)
+		}
+		if _result[3] != nil {
+			_ret3 = _result[3].(error)
+		}
+	}
+	return _ret0, _ret1, _ret2, _ret3
+}
+
 func (mock *MockEventParsing) ParseGithubRepo(ghRepo *github.Repository) (models.Repo, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
@@ -623,8 +650,8 @@ type VerifierMockEventParsing struct {
 	timeout                time.Duration
 }
 
-func (verifier *VerifierMockEventParsing) GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string) *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification {
-	_params := []pegomock.Param{eventTypeHeader, sha, pr}
+func (verifier *VerifierMockEventParsing) GetBitbucketCloudPullEventType(eventTypeHeader string, sha string, pr string, draft bool) *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification {
+	_params := []pegomock.Param{eventTypeHeader, sha, pr, draft}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetBitbucketCloudPullEventType", _params, verifier.timeout)
 	return &MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -634,12 +661,12 @@ type MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification struct
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification) GetCapturedArguments() (string, string, string) {
-	eventTypeHeader, sha, pr := c.GetAllCapturedArguments()
-	return eventTypeHeader[len(eventTypeHeader)-1], sha[len(sha)-1], pr[len(pr)-1]
+func (c *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification) GetCapturedArguments() (string, string, string, bool) {
+	eventTypeHeader, sha, pr, draft := c.GetAllCapturedArguments()
+	return eventTypeHeader[len(eventTypeHeader)-1], sha[len(sha)-1], pr[len(pr)-1], draft[len(draft)-1]
 }
 
-func (c *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string) {
+func (c *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string, _param2 []string, _param3 []bool) {
 	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(_params) > 0 {
 		if len(_params) > 0 {
@@ -660,6 +687,12 @@ func (c *MockEventParsing_GetBitbucketCloudPullEventType_OngoingVerification) Ge
 				_param2[u] = param.(string)
 			}
 		}
+		if len(_params) > 3 {
+			_param3 = make([]bool, len(c.methodInvocations))
+			for u, param := range _params[3] {
+				_param3[u] = param.(bool)
+			}
+		}
 	}
 	return
 }
@@ -1129,6 +1162,41 @@ func (c *MockEventParsing_ParseGithubPullEvent_OngoingVerification) GetAllCaptur
 	return
 }
 
+func (verifier *VerifierMockEventParsing) ParseGithubPullRequestReviewCommentEvent(logger logging.SimpleLogging, comment *github.PullRequestReviewCommentEvent) *MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification {
+	_params := []pegomock.Param{logger, comment}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubPullRequestReviewCommentEvent", _params, verifier.timeout)
+	return &MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification) GetCapturedArguments() (logging.SimpleLogging, *github.PullRequestReviewCommentEvent) {
+	logger, comment := c.GetAllCapturedArguments()
+	return logger[len(logger)-1], comment[len(comment)-1]
+}
+
+func (c *MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []logging.SimpleLogging, _param1 []*github.PullRequestReviewCommentEvent) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]logging.SimpleLogging, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(logging.SimpleLogging)
+			}
+		}
+		if len(_params) > 1 {
+			_param1 = make([]*github.PullRequestReviewCommentEvent, len(c.methodInvocations))
+			for u, param := range _params[1] {
+				_param1[u] = param.(*github.PullRequestReviewCommentEvent)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockEventParsing) ParseGithubRepo(ghRepo *github.Repository) *MockEventParsing_ParseGithubRepo_OngoingVerification {
 	_params := []pegomock.Param{ghRepo}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubRepo", _params, verifier.timeout)
@@ -55,6 +55,21 @@ func (mock *MockProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext
 	return _ret0
 }
 
+func (mock *MockProjectCommandRunner) ForceUnlock(ctx command.ProjectContext) command.ProjectResult {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
+	}
+	_params := []pegomock.Param{ctx}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("ForceUnlock", _params, []reflect.Type{reflect.TypeOf((*command.ProjectResult)(nil)).Elem()})
+	var _ret0 command.ProjectResult
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(command.ProjectResult)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
@@ -130,6 +145,21 @@ func (mock *MockProjectCommandRunner) Version(ctx command.ProjectContext) comman
 	return _ret0
 }
 
+func (mock *MockProjectCommandRunner) Workspaces(ctx command.ProjectContext) command.ProjectResult {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockProjectCommandRunner().")
+	}
+	_params := []pegomock.Param{ctx}
+	_result := pegomock.GetGenericMockFrom(mock).Invoke("Workspaces", _params, []reflect.Type{reflect.TypeOf((*command.ProjectResult)(nil)).Elem()})
+	var _ret0 command.ProjectResult
+	if len(_result) != 0 {
+		if _result[0] != nil {
+			_ret0 = _result[0].(command.ProjectResult)
+		}
+	}
+	return _ret0
+}
+
 func (mock *MockProjectCommandRunner) VerifyWasCalledOnce() *VerifierMockProjectCommandRunner {
 	return &VerifierMockProjectCommandRunner{
 		mock:                   mock,
@@ -225,6 +255,35 @@ func (c *MockProjectCommandRunner_ApprovePolicies_OngoingVerification) GetAllCap
 	return
 }
 
+func (verifier *VerifierMockProjectCommandRunner) ForceUnlock(ctx command.ProjectContext) *MockProjectCommandRunner_ForceUnlock_OngoingVerification {
+	_params := []pegomock.Param{ctx}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ForceUnlock", _params, verifier.timeout)
+	return &MockProjectCommandRunner_ForceUnlock_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockProjectCommandRunner_ForceUnlock_OngoingVerification struct {
+	mock              *MockProjectCommandRunner
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockProjectCommandRunner_ForceUnlock_OngoingVerification) GetCapturedArguments() command.ProjectContext {
+	ctx := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1]
+}
+
+func (c *MockProjectCommandRunner_ForceUnlock_OngoingVerification) GetAllCapturedArguments() (_param0 []command.ProjectContext) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]command.ProjectContext, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(command.ProjectContext)
+			}
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockProjectCommandRunner) Import(ctx command.ProjectContext) *MockProjectCommandRunner_Import_OngoingVerification {
 	_params := []pegomock.Param{ctx}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Import", _params, verifier.timeout)
@@ -369,3 +428,32 @@ func (c *MockProjectCommandRunner_Version_OngoingVerification) GetAllCapturedArg
 	}
 	return
 }
+
+func (verifier *VerifierMockProjectCommandRunner) Workspaces(ctx command.ProjectContext) *MockProjectCommandRunner_Workspaces_OngoingVerification {
+	_params := []pegomock.Param{ctx}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Workspaces", _params, verifier.timeout)
+	return &MockProjectCommandRunner_Workspaces_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockProjectCommandRunner_Workspaces_OngoingVerification struct {
+	mock              *MockProjectCommandRunner
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockProjectCommandRunner_Workspaces_OngoingVerification) GetCapturedArguments() command.ProjectContext {
+	ctx := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1]
+}
+
+func (c *MockProjectCommandRunner_Workspaces_OngoingVerification) GetAllCapturedArguments() (_param0 []command.ProjectContext) {
+	_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(_params) > 0 {
+		if len(_params) > 0 {
+			_param0 = make([]command.ProjectContext, len(c.methodInvocations))
+			for u, param := range _params[0] {
+				_param0[u] = param.(command.ProjectContext)
+			}
+		}
+	}
+	return
+}
@@ -16,13 +16,15 @@ package models
 // CommitStatus is the result of executing an Atlantis command for the commit.
 // In Github the options are: error, failure, pending, success.
 // In Gitlab the options are: failed, canceled, pending, running, success.
-// We only support Failed, Pending, Success.
+// We only support Failed, Pending, Success and Canceled. VCS hosts without a
+// canceled status use their closest failure status instead.
 type CommitStatus int
 
 const (
 	PendingCommitStatus CommitStatus = iota
 	SuccessCommitStatus
 	FailedCommitStatus
+	CanceledCommitStatus
 )
 
 func (s CommitStatus) String() string {
@@ -33,6 +35,8 @@ func (s CommitStatus) String() string {
 		return "success"
 	case FailedCommitStatus:
 		return "failed"
+	case CanceledCommitStatus:
+		return "canceled"
 	}
 	return "failed"
 }
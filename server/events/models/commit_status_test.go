@@ -9,9 +9,10 @@ import (
 
 func TestStatus_String(t *testing.T) {
 	cases := map[models.CommitStatus]string{
-		models.PendingCommitStatus: "pending",
-		models.SuccessCommitStatus: "success",
-		models.FailedCommitStatus:  "failed",
+		models.PendingCommitStatus:  "pending",
+		models.SuccessCommitStatus:  "success",
+		models.FailedCommitStatus:   "failed",
+		models.CanceledCommitStatus: "canceled",
 	}
 	for k, v := range cases {
 		Equals(t, v, k.String())
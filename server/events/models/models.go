@@ -35,6 +35,9 @@ import (
 type PullReqStatus struct {
 	ApprovalStatus ApprovalStatus
 	Mergeable      bool
+	// MergeBlockers describes why the pull request isn't mergeable, if the
+	// VCS host can tell us.
+	MergeBlockers []string
 }
 
 // Repo is a VCS repository.
@@ -161,9 +164,15 @@ type PullRequest struct {
 	// the string will only be 12 characters long because Bitbucket Cloud
 	// truncates its commit IDs.
 	HeadCommit string
+	// BaseCommit is the sha of the head of the base branch when the pull
+	// request was last updated. It's empty if the VCS host doesn't send it.
+	BaseCommit string
 	// URL is the url of the pull request.
 	// ex. "https://github.com/runatlantis/atlantis/pull/1"
 	URL string
+	// Title is the title of the pull request. It's empty if the VCS host
+	// doesn't send it.
+	Title string
 	// HeadBranch is the name of the head branch (the branch that is getting
 	// merged into the base).
 	HeadBranch string
@@ -178,6 +187,27 @@ type PullRequest struct {
 	State PullRequestState
 	// BaseRepo is the repository that the pull request will be merged into.
 	BaseRepo Repo
+	// Draft is true if the pull request is a draft (or a work in progress merge
+	// request on GitLab). Drafts aren't autoplanned unless the repo allows it.
+	Draft bool
+}
+
+// CommentURL returns the URL of the comment with ID id on the pull request,
+// or empty if it's unknown for its VCS host. IDs below 1 are unknown
+// comments.
+func (p PullRequest) CommentURL(id int64) string {
+	if p.URL == "" || id <= 0 {
+		return ""
+	}
+	switch p.BaseRepo.VCSHost.Type {
+	case Github:
+		return fmt.Sprintf("%s#issuecomment-%d", p.URL, id)
+	case Gitlab:
+		return fmt.Sprintf("%s#note_%d", p.URL, id)
+	case BitbucketCloud:
+		return fmt.Sprintf("%s#comment-%d", p.URL, id)
+	}
+	return ""
 }
 
 // PullRequestOptions is used to set optional paralmeters for PullRequest
@@ -195,6 +225,8 @@ type PullRequestState int
 const (
 	OpenPullState PullRequestState = iota
 	ClosedPullState
+	// MergedPullState is a closed pull request whose changes were merged.
+	MergedPullState
 )
 
 type PullRequestEventType int
@@ -204,6 +236,11 @@ const (
 	UpdatedPullEvent
 	ClosedPullEvent
 	OtherPullEvent
+	// ReadyForReviewPullEvent is a draft pull request being marked as ready
+	// for review.
+	ReadyForReviewPullEvent
+	// LabeledPullEvent is a label being added to a pull request.
+	LabeledPullEvent
 )
 
 func (p PullRequestEventType) String() string {
@@ -216,10 +253,23 @@ func (p PullRequestEventType) String() string {
 		return "closed"
 	case OtherPullEvent:
 		return "other"
+	case ReadyForReviewPullEvent:
+		return "ready_for_review"
+	case LabeledPullEvent:
+		return "labeled"
 	}
 	return "<missing String() implementation>"
 }
 
+// AutoplanTrigger describes the pull request event that started an autoplan.
+// Projects use it to decide whether their autoplan mode wants to plan.
+type AutoplanTrigger struct {
+	// Event is the type of the pull request event.
+	Event PullRequestEventType
+	// Label is the label that was added if Event is LabeledPullEvent.
+	Label string
+}
+
 // User is a VCS user.
 // During an autoplan, the user will be the Atlantis API user.
 type User struct {
@@ -378,6 +428,15 @@ type PlanSuccess struct {
 	// branch we're merging into had been updated, and we had to merge again
 	// before planning
 	MergedAgain bool
+	// PreviousPlanDiff is how this plan differs from the last plan of the same
+	// project on this pull request. It's nil if there was no previous plan.
+	PreviousPlanDiff *PlanDiff
+	// CheckOnly is true if the project only ran checks, i.e. it's run by the
+	// check engine, so there's nothing to apply.
+	CheckOnly bool
+	// OutdatedDependencies are the providers and modules of the project that
+	// have newer releases.
+	OutdatedDependencies []OutdatedDependency
 }
 
 type PolicySetResult struct {
@@ -386,6 +445,9 @@ type PolicySetResult struct {
 	Passed        bool
 	ReqApprovals  int
 	CurApprovals  int
+	// Justifications are the reasons given by the owners who approved the
+	// policy set.
+	Justifications []PolicySetJustification `json:",omitempty"`
 }
 
 // PolicySetApproval tracks the number of approvals a given policy set has.
@@ -393,6 +455,17 @@ type PolicySetStatus struct {
 	PolicySetName string
 	Passed        bool
 	Approvals     int
+	// Justifications are the reasons given by the owners who approved the
+	// policy set.
+	Justifications []PolicySetJustification
+}
+
+// PolicySetJustification is the reason an owner gave when approving a failing
+// policy set.
+type PolicySetJustification struct {
+	// User is the username of the owner who approved the policy set.
+	User   string
+	Reason string
 }
 
 // Summary regexes
@@ -400,8 +473,21 @@ var (
 	reChangesOutside = regexp.MustCompile(`Note: Objects have changed outside of Terraform`)
 	rePlanChanges    = regexp.MustCompile(`Plan: (?:(\d+) to import, )?(\d+) to add, (\d+) to change, (\d+) to destroy.`)
 	reNoChanges      = regexp.MustCompile(`No changes. (Infrastructure is up-to-date|Your infrastructure matches the configuration).`)
+	reResourceChange = regexp.MustCompile(`(?m)^\s*# (.+?) (will be created|will be destroyed|will be updated in-place|must be replaced|is tainted, so must be replaced|will be replaced, as requested|will be read during apply)\s*$`)
 )
 
+// resourceChangeActions maps the phrases Terraform uses in plan output to
+// the action that will be taken on the resource.
+var resourceChangeActions = map[string]string{
+	"will be created":                 "create",
+	"will be destroyed":               "delete",
+	"will be updated in-place":        "update",
+	"must be replaced":                "replace",
+	"is tainted, so must be replaced": "replace",
+	"will be replaced, as requested":  "replace",
+	"will be read during apply":       "read",
+}
+
 // Summary extracts summaries of plan changes from TerraformOutput.
 func (p *PlanSuccess) Summary() string {
 	note := ""
@@ -421,7 +507,7 @@ func (p *PlanSuccess) DiffSummary() string {
 
 // NoChanges returns true if the plan has no changes.
 func (p *PlanSuccess) NoChanges() bool {
-	return reNoChanges.MatchString(p.TerraformOutput)
+	return p.CheckOnly || reNoChanges.MatchString(p.TerraformOutput)
 }
 
 // Diff Markdown regexes
@@ -445,6 +531,105 @@ func (p PlanSuccess) Stats() PlanSuccessStats {
 	return NewPlanSuccessStats(p.TerraformOutput)
 }
 
+// ResourceChanges extracts the resources that will be changed, and how, from
+// TerraformOutput.
+func (p PlanSuccess) ResourceChanges() []ResourceChange {
+	var changes []ResourceChange
+	for _, m := range reResourceChange.FindAllStringSubmatch(p.TerraformOutput, -1) {
+		changes = append(changes, ResourceChange{
+			Address: m[1],
+			Action:  resourceChangeActions[m[2]],
+		})
+	}
+	return changes
+}
+
+// ResourceChange is a change to a single resource in a plan.
+type ResourceChange struct {
+	// Address is the resource address, ex. aws_instance.web.
+	Address string
+	// Action is one of create, update, replace, delete or read.
+	Action string
+}
+
+// ResourceActionChange is a resource whose planned action is different from
+// the previous plan.
+type ResourceActionChange struct {
+	Address        string
+	PreviousAction string
+	Action         string
+}
+
+// The types of OutdatedDependency.
+const (
+	ProviderDependency = "provider"
+	ModuleDependency   = "module"
+)
+
+// OutdatedDependency is a provider or registry module of a project whose
+// version is older than its latest release.
+type OutdatedDependency struct {
+	// Type is ProviderDependency or ModuleDependency.
+	Type string
+	// Source is the address of the provider, ex.
+	// registry.terraform.io/hashicorp/aws, or the source of the module.
+	Source string
+	// Version is the version the project uses.
+	Version string
+	// Latest is the latest release.
+	Latest string
+}
+
+// PlanDiff is the difference in resource changes between two plans of the
+// same project.
+type PlanDiff struct {
+	// Added are the resource changes in the new plan but not the previous one.
+	Added []ResourceChange
+	// Removed are the resource changes in the previous plan but not the new one.
+	Removed []ResourceChange
+	// Changed are the resources in both plans with different actions.
+	Changed []ResourceActionChange
+}
+
+// NewPlanDiff compares the resource changes of a previous plan to those of
+// a new plan.
+func NewPlanDiff(previous []ResourceChange, current []ResourceChange) *PlanDiff {
+	prevActions := make(map[string]string)
+	for _, c := range previous {
+		prevActions[c.Address] = c.Action
+	}
+	currActions := make(map[string]string)
+	for _, c := range current {
+		currActions[c.Address] = c.Action
+	}
+
+	d := &PlanDiff{}
+	for _, c := range current {
+		prevAction, ok := prevActions[c.Address]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, c)
+		case prevAction != c.Action:
+			d.Changed = append(d.Changed, ResourceActionChange{
+				Address:        c.Address,
+				PreviousAction: prevAction,
+				Action:         c.Action,
+			})
+		}
+	}
+	for _, c := range previous {
+		if _, ok := currActions[c.Address]; !ok {
+			d.Removed = append(d.Removed, c)
+		}
+	}
+	return d
+}
+
+// Empty returns true if both plans change the same resources in the same way.
+func (d *PlanDiff) Empty() bool {
+	return d == nil || (len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0)
+}
+
 // PolicyCheckResults is the result of a successful policy check run.
 type PolicyCheckResults struct {
 	PreConftestOutput  string
@@ -473,6 +658,16 @@ type ImportSuccess struct {
 	RePlanCmd string
 }
 
+// TerraformOutput is a root module output of a project, read after a
+// successful apply.
+type TerraformOutput struct {
+	Name string
+	// Value is the value of the output. Strings are shown as is and other
+	// types as JSON. It's empty if the output is sensitive.
+	Value     string
+	Sensitive bool
+}
+
 // StateRmSuccess is the result of a successful state rm run.
 type StateRmSuccess struct {
 	// Output is the output from terraform state rm
@@ -481,6 +676,34 @@ type StateRmSuccess struct {
 	RePlanCmd string
 }
 
+// ForceUnlockSuccess is the result of a successful force-unlock run.
+type ForceUnlockSuccess struct {
+	// Output is the output from terraform force-unlock
+	Output string
+	// RePlanCmd is the command that users should run to re-plan this project.
+	RePlanCmd string
+}
+
+// WorkspacesSuccess is the result of a successful workspaces run.
+type WorkspacesSuccess struct {
+	// Workspaces are the terraform workspaces of the project's directory.
+	Workspaces []WorkspaceStatus
+}
+
+// WorkspaceStatus is a terraform workspace of a project's directory and
+// what Atlantis knows about it.
+type WorkspaceStatus struct {
+	// Name is the name of the workspace.
+	Name string
+	// LockedBy is the number of the pull request that holds the Atlantis
+	// lock of the directory and workspace, or 0 if it isn't locked.
+	LockedBy int
+	// Plans are the statuses of the plans of this pull request in the
+	// workspace keyed by project name. Projects without a name are keyed by
+	// the empty string.
+	Plans map[string]ProjectPlanStatus
+}
+
 func (p *PolicyCheckResults) CombinedOutput() string {
 	combinedOutput := ""
 	for _, psResult := range p.PolicySetResults {
@@ -530,6 +753,17 @@ func (p *PolicyCheckResults) PolicySummary() string {
 	return strings.Join(summary, "\n")
 }
 
+// JustificationSummary returns the reasons given for approving policy sets.
+func (p *PolicyCheckResults) JustificationSummary() string {
+	var summary []string
+	for _, policySetResult := range p.PolicySetResults {
+		for _, j := range policySetResult.Justifications {
+			summary = append(summary, fmt.Sprintf("policy set: %s: approved by %s: %s", policySetResult.PolicySetName, j.User, j.Reason))
+		}
+	}
+	return strings.Join(summary, "\n")
+}
+
 type VersionSuccess struct {
 	VersionOutput string
 }
@@ -540,6 +774,79 @@ type PullStatus struct {
 	Projects []ProjectStatus
 	// Pull is the original pull request model.
 	Pull PullRequest
+	// History are the commands that were run on the projects of this pull
+	// request, oldest first. Unlike Projects, it's kept when new commits are
+	// pushed.
+	History []CommandRun
+}
+
+// MaxPullStatusHistory is how many command runs are kept in the history of a
+// pull request. Older runs are dropped.
+const MaxPullStatusHistory = 100
+
+// AddHistory appends runs to the history of the pull request, dropping the
+// oldest runs past MaxPullStatusHistory.
+func (p *PullStatus) AddHistory(runs ...CommandRun) {
+	p.History = append(p.History, runs...)
+	if len(p.History) > MaxPullStatusHistory {
+		p.History = p.History[len(p.History)-MaxPullStatusHistory:]
+	}
+}
+
+// CommandRun is a command that was run on a project of a pull request.
+type CommandRun struct {
+	// Command is the name of the command, ex. plan.
+	Command     string
+	ProjectName string
+	RepoRelDir  string
+	Workspace   string
+	// User is the username of who ran the command.
+	User string
+	// HeadCommit is the commit of the pull request the command ran on.
+	HeadCommit string
+	// Status is the status of the project after the command ran.
+	Status ProjectPlanStatus
+	Time   time.Time
+	// Trigger is how the command was started, one of AutoCommandTrigger,
+	// CommentCommandTrigger or APICommandTrigger. It's empty for runs
+	// recorded before it was.
+	Trigger string
+	// CommentID is the ID of the comment the command was run from, or 0 if
+	// it wasn't. It's -1 if the comment is unknown.
+	CommentID int64
+}
+
+// The ways a command can be started, see CommandRun.Trigger.
+const (
+	// AutoCommandTrigger is an autoplan or an apply on merge.
+	AutoCommandTrigger    = "auto"
+	CommentCommandTrigger = "comment"
+	APICommandTrigger     = "api"
+)
+
+// ProjectApply is the last successful apply of a project. Unlike PullStatus,
+// it's kept after the pull request is closed.
+type ProjectApply struct {
+	RepoFullName string
+	ProjectName  string
+	RepoRelDir   string
+	Workspace    string
+	// PullNum is the pull request the project was applied from.
+	PullNum int
+	Time    time.Time
+}
+
+// LastRun returns the last run of command, ex. "plan", on project at the
+// head commit of the pull request, or nil if the history doesn't have one.
+func (p PullStatus) LastRun(project ProjectStatus, command string) *CommandRun {
+	for i := len(p.History) - 1; i >= 0; i-- {
+		run := p.History[i]
+		if run.Command == command && run.HeadCommit == p.Pull.HeadCommit &&
+			run.ProjectName == project.ProjectName && run.RepoRelDir == project.RepoRelDir && run.Workspace == project.Workspace {
+			return &run
+		}
+	}
+	return nil
 }
 
 // StatusCount returns the number of projects that have status.
@@ -562,6 +869,9 @@ type ProjectStatus struct {
 	PolicyStatus []PolicySetStatus
 	// Status is the status of where this project is at in the planning cycle.
 	Status ProjectPlanStatus
+	// PlannedResources are the resource changes from the last successful plan
+	// of this project.
+	PlannedResources []ResourceChange
 }
 
 // ProjectPlanStatus is the status of where this project is at in the planning
@@ -739,3 +1049,12 @@ func NewPlanSuccessStats(output string) PlanSuccessStats {
 
 	return s
 }
+
+// Badge returns a compact summary of the plan's changes, ex. "+3 ~1 -0". It's
+// empty if the plan has no changes.
+func (s PlanSuccessStats) Badge() string {
+	if !s.Changes {
+		return ""
+	}
+	return fmt.Sprintf("+%d ~%d -%d", s.Add, s.Change, s.Destroy)
+}
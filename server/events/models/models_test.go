@@ -443,6 +443,72 @@ func TestPlanSuccess_DiffSummary(t *testing.T) {
 	}
 }
 
+func TestPlanSuccess_ResourceChanges(t *testing.T) {
+	output := `Terraform will perform the following actions:
+
+  # aws_instance.web will be created
+  + resource "aws_instance" "web" {
+      + ami = "ami-123"
+    }
+
+  # aws_instance.db must be replaced
+-/+ resource "aws_instance" "db" {
+    }
+
+  # aws_s3_bucket.logs["a b"] will be updated in-place
+  ~ resource "aws_s3_bucket" "logs" {
+    }
+
+  # aws_iam_role.old will be destroyed
+  # (because aws_iam_role.old is not in configuration)
+  - resource "aws_iam_role" "old" {
+    }
+
+  # data.aws_ami.latest will be read during apply
+ <= data "aws_ami" "latest" {
+    }
+
+  # aws_instance.tainted is tainted, so must be replaced
+-/+ resource "aws_instance" "tainted" {
+    }
+
+Plan: 3 to add, 1 to change, 3 to destroy.`
+	pcs := models.PlanSuccess{TerraformOutput: output}
+	Equals(t, []models.ResourceChange{
+		{Address: "aws_instance.web", Action: "create"},
+		{Address: "aws_instance.db", Action: "replace"},
+		{Address: `aws_s3_bucket.logs["a b"]`, Action: "update"},
+		{Address: "aws_iam_role.old", Action: "delete"},
+		{Address: "data.aws_ami.latest", Action: "read"},
+		{Address: "aws_instance.tainted", Action: "replace"},
+	}, pcs.ResourceChanges())
+
+	noChanges := models.PlanSuccess{TerraformOutput: "No changes. Your infrastructure matches the configuration."}
+	Equals(t, 0, len(noChanges.ResourceChanges()))
+}
+
+func TestNewPlanDiff(t *testing.T) {
+	previous := []models.ResourceChange{
+		{Address: "aws_instance.web", Action: "create"},
+		{Address: "aws_instance.db", Action: "update"},
+		{Address: "aws_iam_role.old", Action: "delete"},
+	}
+	current := []models.ResourceChange{
+		{Address: "aws_instance.web", Action: "create"},
+		{Address: "aws_instance.db", Action: "replace"},
+		{Address: "aws_s3_bucket.logs", Action: "create"},
+	}
+
+	d := models.NewPlanDiff(previous, current)
+	Equals(t, false, d.Empty())
+	Equals(t, []models.ResourceChange{{Address: "aws_s3_bucket.logs", Action: "create"}}, d.Added)
+	Equals(t, []models.ResourceChange{{Address: "aws_iam_role.old", Action: "delete"}}, d.Removed)
+	Equals(t, []models.ResourceActionChange{{Address: "aws_instance.db", PreviousAction: "update", Action: "replace"}}, d.Changed)
+
+	Equals(t, true, models.NewPlanDiff(current, current).Empty())
+	Equals(t, true, models.NewPlanDiff(nil, nil).Empty())
+}
+
 func TestPolicyCheckResults_Summary(t *testing.T) {
 	cases := []struct {
 		description      string
@@ -601,6 +667,62 @@ policy set: policy3: passed.`,
 	}
 }
 
+func TestPolicyCheckResults_JustificationSummary(t *testing.T) {
+	pcs := models.PolicyCheckResults{
+		PolicySetResults: []models.PolicySetResult{
+			{
+				PolicySetName: "policy1",
+				Justifications: []models.PolicySetJustification{
+					{User: "alice", Reason: "known false positive"},
+					{User: "bob", Reason: "hotfix"},
+				},
+			},
+			{
+				PolicySetName: "policy2",
+				Passed:        true,
+			},
+		},
+	}
+	Equals(t, "policy set: policy1: approved by alice: known false positive\npolicy set: policy1: approved by bob: hotfix", pcs.JustificationSummary())
+	Equals(t, "", (&models.PolicyCheckResults{}).JustificationSummary())
+}
+
+func TestPullStatus_AddHistory(t *testing.T) {
+	var ps models.PullStatus
+	for i := 0; i < models.MaxPullStatusHistory+5; i++ {
+		ps.AddHistory(models.CommandRun{Command: "plan", HeadCommit: fmt.Sprintf("sha%d", i)})
+	}
+	Equals(t, models.MaxPullStatusHistory, len(ps.History))
+	Equals(t, "sha5", ps.History[0].HeadCommit)
+	Equals(t, fmt.Sprintf("sha%d", models.MaxPullStatusHistory+4), ps.History[len(ps.History)-1].HeadCommit)
+}
+
+func TestPullStatus_LastRun(t *testing.T) {
+	project := models.ProjectStatus{ProjectName: "prod", RepoRelDir: "prod", Workspace: "default"}
+	ps := models.PullStatus{
+		Pull: models.PullRequest{HeadCommit: "new"},
+		History: []models.CommandRun{
+			{Command: "apply", ProjectName: "prod", RepoRelDir: "prod", Workspace: "default", HeadCommit: "old"},
+			{Command: "plan", ProjectName: "prod", RepoRelDir: "prod", Workspace: "default", HeadCommit: "new", User: "first"},
+			{Command: "plan", ProjectName: "prod", RepoRelDir: "prod", Workspace: "default", HeadCommit: "new", User: "second"},
+			{Command: "plan", ProjectName: "staging", RepoRelDir: "staging", Workspace: "default", HeadCommit: "new", User: "third"},
+		},
+	}
+	Equals(t, "second", ps.LastRun(project, "plan").User)
+	Assert(t, ps.LastRun(project, "apply") == nil, "expected no apply at the head commit")
+}
+
+func TestPullRequest_CommentURL(t *testing.T) {
+	pull := models.PullRequest{URL: "https://github.com/owner/repo/pull/1", BaseRepo: models.Repo{VCSHost: models.VCSHost{Type: models.Github}}}
+	Equals(t, "https://github.com/owner/repo/pull/1#issuecomment-42", pull.CommentURL(42))
+	Equals(t, "", pull.CommentURL(0))
+	Equals(t, "", pull.CommentURL(-1))
+	pull.BaseRepo.VCSHost.Type = models.Gitlab
+	Equals(t, "https://github.com/owner/repo/pull/1#note_42", pull.CommentURL(42))
+	pull.BaseRepo.VCSHost.Type = models.AzureDevops
+	Equals(t, "", pull.CommentURL(42))
+}
+
 func TestPullStatus_StatusCount(t *testing.T) {
 	ps := models.PullStatus{
 		Projects: []models.ProjectStatus{
@@ -726,3 +848,9 @@ func TestPlanSuccessStats(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanSuccessStats_Badge(t *testing.T) {
+	Equals(t, "+3 ~1 -0", models.PlanSuccessStats{Changes: true, Add: 3, Change: 1}.Badge())
+	Equals(t, "+31 ~20 -1", models.PlanSuccessStats{Changes: true, Import: 42, Add: 31, Change: 20, Destroy: 1}.Badge())
+	Equals(t, "", models.PlanSuccessStats{ChangesOutside: true}.Badge())
+}
@@ -80,32 +80,72 @@ func (t tfFs) ReadDir(dirname string) ([]os.FileInfo, error) {
 
 var _ tfconfig.FS = tfFs{}
 
-func (m moduleInfo) load(files fs.FS, dir string, projects ...string) (_ *module, diags tfconfig.Diagnostics) {
-	if _, set := m[dir]; !set {
-		tfFiles := tfFs{files}
-		var mod *tfconfig.Module
-		mod, diags = tfconfig.LoadModuleFromFilesystem(tfFiles, dir)
-
-		deps := make(map[string]bool)
-		if mod != nil {
-			for _, c := range mod.ModuleCalls {
-				mPath := path.Join(dir, c.Source)
-				if !tfconfig.IsModuleDirOnFilesystem(tfFiles, mPath) {
-					continue
-				}
-				deps[mPath] = true
+// parsedModule is a parsed module and the diagnostics of parsing it.
+type parsedModule struct {
+	// dependencies are the dirs of the local modules it calls.
+	dependencies map[string]bool
+	diags        tfconfig.Diagnostics
+}
+
+func parseModule(files fs.FS, dir string) parsedModule {
+	tfFiles := tfFs{files}
+	mod, diags := tfconfig.LoadModuleFromFilesystem(tfFiles, dir)
+
+	deps := make(map[string]bool)
+	if mod != nil {
+		for _, c := range mod.ModuleCalls {
+			mPath := path.Join(dir, c.Source)
+			if !tfconfig.IsModuleDirOnFilesystem(tfFiles, mPath) {
+				continue
 			}
+			deps[mPath] = true
 		}
+	}
+	return parsedModule{dependencies: deps, diags: diags}
+}
 
+// parseModules parses the modules at dirs and all the local modules they
+// call. Modules at the same depth of calls are parsed in parallel.
+func parseModules(files fs.FS, dirs []string) map[string]parsedModule {
+	parsed := make(map[string]parsedModule)
+	queued := make(map[string]bool)
+	next := dirs
+	for len(next) > 0 {
+		var depth []string
+		for _, dir := range next {
+			if !queued[dir] {
+				queued[dir] = true
+				depth = append(depth, dir)
+			}
+		}
+		results := make([]parsedModule, len(depth))
+		forEachParallel(len(depth), func(i int) {
+			results[i] = parseModule(files, depth[i])
+		})
+		next = nil
+		for i, dir := range depth {
+			parsed[dir] = results[i]
+			for dep := range results[i].dependencies {
+				next = append(next, dep)
+			}
+		}
+	}
+	return parsed
+}
+
+func (m moduleInfo) load(parsed map[string]parsedModule, dir string, projects ...string) (_ *module, diags tfconfig.Diagnostics) {
+	if _, set := m[dir]; !set {
+		mod := parsed[dir]
+		diags = mod.diags
 		m[dir] = &module{
 			path:         dir,
-			dependencies: deps,
+			dependencies: mod.dependencies,
 			projects:     make(map[string]bool),
 		}
 	}
 	// set projects on my dependencies
 	for dep := range m[dir].dependencies {
-		_, err := m.load(files, dep, projects...)
+		_, err := m.load(parsed, dep, projects...)
 		if err != nil {
 			diags = append(diags, err...)
 		}
@@ -128,24 +168,35 @@ func findModuleDependants(files fs.FS, autoplanModuleDependants string) (ModuleP
 	}
 	// find all the projects matching autoplanModuleDependants
 	filter, _ := patternmatcher.New(strings.Split(autoplanModuleDependants, ","))
-	var projects []string
+	var matches []string
 	err := fs.WalkDir(files, ".", func(rel string, info fs.DirEntry, err error) error {
 		if match, _ := filter.MatchesOrParentMatches(rel); match {
-			if projectDir := getProjectDirFromFs(files, rel); projectDir != "" {
-				projects = append(projects, projectDir)
-			}
+			matches = append(matches, rel)
 		}
 		return err
 	})
 	if err != nil {
 		return nil, fmt.Errorf("find projects for module dependants: %w", err)
 	}
+	// Finding the project of a file reads the dirs above it, so files are
+	// checked in parallel.
+	matchDirs := make([]string, len(matches))
+	forEachParallel(len(matches), func(i int) {
+		matchDirs[i] = getProjectDirFromFs(files, matches[i])
+	})
+	var projects []string
+	for _, projectDir := range matchDirs {
+		if projectDir != "" {
+			projects = append(projects, projectDir)
+		}
+	}
 
+	parsed := parseModules(files, projects)
 	result := make(moduleInfo)
 	var diags tfconfig.Diagnostics
 	// for each project, find the modules it depends on, their deps, etc.
 	for _, projectDir := range projects {
-		if _, err := result.load(files, projectDir, projectDir); err != nil {
+		if _, err := result.load(parsed, projectDir, projectDir); err != nil {
 			diags = append(diags, err...)
 		}
 	}
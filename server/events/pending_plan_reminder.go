@@ -0,0 +1,211 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// pendingPlanReminderCommand is the command name reminder comments are made
+// for so that they can be told apart from the comments of other commands.
+const pendingPlanReminderCommand = "pending-plan-reminder"
+
+// PendingPlanReminder comments on pull requests with plans that were made a
+// while ago and still haven't been applied, so that their projects aren't
+// left locked by pull requests nobody is working on. Plans that are left
+// pending for even longer can be discarded along with their locks. It's run
+// periodically as a scheduled job.
+type PendingPlanReminder struct {
+	Backend             locking.Backend
+	WorkingDir          WorkingDir
+	WorkingDirLocker    WorkingDirLocker
+	PendingPlanFinder   PendingPlanFinder
+	CommitStatusUpdater CommitStatusUpdater
+	VCSClient           vcs.Client
+	Logger              logging.SimpleLogging
+	Scope               tally.Scope
+	// ExecutableName is the name comment commands are run with, ex. atlantis.
+	ExecutableName string
+	// RemindAfter is how old a plan must be before its pull request is
+	// reminded about it. Zero never reminds.
+	RemindAfter time.Duration
+	// DiscardAfter is how old a plan must be before it's discarded and the
+	// lock its pull request holds on its project is released. Zero never
+	// discards plans.
+	DiscardAfter time.Duration
+
+	mu sync.Mutex
+	// reminded is when the newest plan of each pull request that was
+	// reminded about was made, keyed by the pull request's repo and number,
+	// so that pull requests are only reminded again once more of their plans
+	// become stale. It's kept in memory so pull requests may be reminded
+	// again after a restart.
+	reminded map[string]time.Time
+}
+
+// Run reminds every pull request with stale plans about them and discards
+// the plans past DiscardAfter.
+func (r *PendingPlanReminder) Run() {
+	scope := r.Scope.SubScope("pending_plan_reminder")
+	snapshot, err := r.Backend.Snapshot()
+	if err != nil {
+		scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		r.Logger.Err("unable to list pull requests for pending plan reminders: %s", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reminded := make(map[string]time.Time)
+	for _, pullStatus := range snapshot.Pulls {
+		pull := pullStatus.Pull
+		key := fmt.Sprintf("%s#%d", pull.BaseRepo.FullName, pull.Num)
+		last, err := r.remindPull(scope, pull, r.reminded[key], time.Now())
+		if err != nil {
+			scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+			r.Logger.Warn("unable to remind %s#%d about pending plans: %s", pull.BaseRepo.FullName, pull.Num, err)
+		}
+		if !last.IsZero() {
+			reminded[key] = last
+		}
+	}
+	// Pull requests that were closed are forgotten.
+	r.reminded = reminded
+	scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+}
+
+// remindPull discards the plans of pull past DiscardAfter and comments about
+// the ones past RemindAfter, unless none were made after lastReminded. It
+// returns when the newest plan that pull was reminded about was made.
+func (r *PendingPlanReminder) remindPull(scope tally.Scope, pull models.PullRequest, lastReminded time.Time, now time.Time) (time.Time, error) {
+	plans, err := findRetainedPlans(r.WorkingDir, r.PendingPlanFinder, pull)
+	if err != nil {
+		return lastReminded, err
+	}
+
+	var stale, discarded []retainedPlan
+	for _, plan := range plans {
+		age := now.Sub(plan.ModTime)
+		switch {
+		case r.DiscardAfter > 0 && age > r.DiscardAfter:
+			if r.discardPlan(pull, plan) {
+				scope.Counter("plans_discarded").Inc(1)
+				discarded = append(discarded, plan)
+			}
+		case r.RemindAfter > 0 && age > r.RemindAfter:
+			stale = append(stale, plan)
+		}
+	}
+	if len(discarded) > 0 {
+		if err := updatePlanCommitStatuses(r.Backend, r.CommitStatusUpdater, r.Logger, pull); err != nil {
+			return lastReminded, err
+		}
+	}
+
+	newest := lastReminded
+	for _, plan := range stale {
+		if plan.ModTime.After(newest) {
+			newest = plan.ModTime
+		}
+	}
+	if len(discarded) == 0 && !newest.After(lastReminded) {
+		return lastReminded, nil
+	}
+	comment := r.renderReminder(stale, discarded, now)
+	if err := r.VCSClient.CreateComment(r.Logger, pull.BaseRepo, pull.Num, comment, pendingPlanReminderCommand); err != nil {
+		return lastReminded, err
+	}
+	scope.Counter("reminders_sent").Inc(1)
+	return newest, nil
+}
+
+// discardPlan deletes plan, releases the lock pull holds on its project and
+// marks its project's plan as discarded. It returns false if the plan wasn't
+// deleted, ex. because a command is running in its directory, in which case
+// it's retried on the next run.
+func (r *PendingPlanReminder) discardPlan(pull models.PullRequest, plan retainedPlan) bool {
+	unlockFn, err := r.WorkingDirLocker.TryLock(pull.BaseRepo.FullName, pull.Num, plan.Workspace, plan.RepoRelDir)
+	if err != nil {
+		r.Logger.Debug("skipping discarding plan for dir %q workspace %q: %s", plan.RepoRelDir, plan.Workspace, err)
+		return false
+	}
+	defer unlockFn()
+
+	if err := r.WorkingDir.DeletePlan(r.Logger, pull.BaseRepo, pull, plan.Workspace, plan.RepoRelDir, plan.ProjectName); err != nil {
+		r.Logger.Warn("unable to delete plan for dir %q workspace %q: %s", plan.RepoRelDir, plan.Workspace, err)
+		return false
+	}
+	project := models.NewProject(pull.BaseRepo.FullName, plan.RepoRelDir, plan.ProjectName)
+	lock, err := r.Backend.GetLock(project, plan.Workspace)
+	if err != nil {
+		r.Logger.Warn("unable to get lock for dir %q workspace %q: %s", plan.RepoRelDir, plan.Workspace, err)
+	} else if lock != nil && lock.Pull.Num == pull.Num && lock.Pull.BaseRepo.FullName == pull.BaseRepo.FullName {
+		// Only locks held by this pull request are released.
+		if _, err := r.Backend.Unlock(project, plan.Workspace); err != nil {
+			r.Logger.Warn("unable to release lock for dir %q workspace %q: %s", plan.RepoRelDir, plan.Workspace, err)
+		}
+	}
+	if err := r.Backend.UpdateProjectStatus(pull, plan.Workspace, plan.RepoRelDir, models.DiscardedPlanStatus); err != nil {
+		r.Logger.Warn("unable to update project status: %s", err)
+	}
+	return true
+}
+
+// renderReminder returns the comment reminding a pull request about its
+// stale plans and the plans that were discarded.
+func (r *PendingPlanReminder) renderReminder(stale []retainedPlan, discarded []retainedPlan, now time.Time) string {
+	var b strings.Builder
+	if len(stale) > 0 {
+		fmt.Fprintf(&b, "The following plans were made over %s ago and haven't been applied:\n\n", formatPlanAge(r.RemindAfter))
+		writePlanList(&b, stale, now)
+		fmt.Fprintf(&b, "\nTo **apply** them, comment `%s apply`. If they're no longer needed, comment `%s unlock` to delete them and release their locks.", r.ExecutableName, r.ExecutableName)
+		if r.DiscardAfter > 0 {
+			fmt.Fprintf(&b, " Plans are discarded and their locks released once they're %s old.", formatPlanAge(r.DiscardAfter))
+		}
+		b.WriteString("\n")
+	}
+	if len(discarded) > 0 {
+		if len(stale) > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "The following plans were discarded and their locks released because they weren't applied within %s:\n\n", formatPlanAge(r.DiscardAfter))
+		writePlanList(&b, discarded, now)
+		fmt.Fprintf(&b, "\nTo apply them, comment `%s plan` to plan them again.\n", r.ExecutableName)
+	}
+	return b.String()
+}
+
+// writePlanList writes a list of plans and their age, oldest first.
+func writePlanList(b *strings.Builder, plans []retainedPlan, now time.Time) {
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].ModTime.Before(plans[j].ModTime)
+	})
+	for _, plan := range plans {
+		b.WriteString("* ")
+		if plan.ProjectName != "" {
+			fmt.Fprintf(b, "project: `%s` ", plan.ProjectName)
+		}
+		fmt.Fprintf(b, "dir: `%s` workspace: `%s` (planned %s ago)\n", plan.RepoRelDir, plan.Workspace, formatPlanAge(now.Sub(plan.ModTime)))
+	}
+}
+
+// formatPlanAge formats d in days, hours or minutes, ex. 3d or 5h.
+func formatPlanAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
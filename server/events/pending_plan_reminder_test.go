@@ -0,0 +1,119 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestPendingPlanReminder_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	pull := models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo"}, Num: 1}
+	pullDir := t.TempDir()
+	plans := writePlans(t, pullDir, "dir", map[string]time.Duration{
+		"abandoned": 20 * 24 * time.Hour,
+		"stale":     4 * 24 * time.Hour,
+		"fresh":     time.Hour,
+	})
+	var remaining []events.PendingPlan
+	for _, plan := range plans {
+		if plan.Workspace != "abandoned" {
+			remaining = append(remaining, plan)
+		}
+	}
+
+	backend := lockmocks.NewMockBackend()
+	When(backend.Snapshot()).ThenReturn(locking.Snapshot{Pulls: []models.PullStatus{{Pull: pull}}}, nil)
+	project := models.NewProject("owner/repo", "dir", "")
+	When(backend.GetLock(project, "abandoned")).ThenReturn(&models.ProjectLock{Project: project, Workspace: "abandoned", Pull: pull}, nil)
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(pull.BaseRepo, pull)).ThenReturn(pullDir, nil)
+	pendingPlanFinder := mocks.NewMockPendingPlanFinder()
+	When(pendingPlanFinder.Find(pullDir)).ThenReturn(plans, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	scope := tally.NewTestScope("", nil)
+
+	reminder := &events.PendingPlanReminder{
+		Backend:             backend,
+		WorkingDir:          workingDir,
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		PendingPlanFinder:   pendingPlanFinder,
+		CommitStatusUpdater: mocks.NewMockCommitStatusUpdater(),
+		VCSClient:           vcsClient,
+		Logger:              logger,
+		Scope:               scope,
+		ExecutableName:      "atlantis",
+		RemindAfter:         72 * time.Hour,
+		DiscardAfter:        14 * 24 * time.Hour,
+	}
+	reminder.Run()
+
+	workingDir.VerifyWasCalledOnce().DeletePlan(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(pull), Eq("abandoned"), Eq("dir"), Eq(""))
+	workingDir.VerifyWasCalled(Never()).DeletePlan(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Eq("stale"), Any[string](), Any[string]())
+	backend.VerifyWasCalledOnce().Unlock(project, "abandoned")
+	backend.VerifyWasCalledOnce().UpdateProjectStatus(pull, "abandoned", "dir", models.DiscardedPlanStatus)
+	_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(1), Any[string](), Any[string]()).GetCapturedArguments()
+	Equals(t, "The following plans were made over 3d ago and haven't been applied:\n\n"+
+		"* dir: `dir` workspace: `stale` (planned 4d ago)\n\n"+
+		"To **apply** them, comment `atlantis apply`. If they're no longer needed, comment `atlantis unlock` to delete them and release their locks."+
+		" Plans are discarded and their locks released once they're 14d old.\n\n"+
+		"The following plans were discarded and their locks released because they weren't applied within 14d:\n\n"+
+		"* dir: `dir` workspace: `abandoned` (planned 20d ago)\n\n"+
+		"To apply them, comment `atlantis plan` to plan them again.\n", comment)
+
+	// The pull request isn't reminded again about the same plans.
+	When(pendingPlanFinder.Find(pullDir)).ThenReturn(remaining, nil)
+	reminder.Run()
+	vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["pending_plan_reminder.plans_discarded+"].Value())
+	Equals(t, int64(1), counters["pending_plan_reminder.reminders_sent+"].Value())
+}
+
+func TestPendingPlanReminder_Run_OtherPullsLock(t *testing.T) {
+	RegisterMockTestingT(t)
+	pull := models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo"}, Num: 1}
+	pullDir := t.TempDir()
+	plans := writePlans(t, pullDir, "dir", map[string]time.Duration{
+		"default": 20 * 24 * time.Hour,
+	})
+
+	backend := lockmocks.NewMockBackend()
+	When(backend.Snapshot()).ThenReturn(locking.Snapshot{Pulls: []models.PullStatus{{Pull: pull}}}, nil)
+	project := models.NewProject("owner/repo", "dir", "")
+	otherPull := models.PullRequest{BaseRepo: pull.BaseRepo, Num: 2}
+	When(backend.GetLock(project, "default")).ThenReturn(&models.ProjectLock{Project: project, Workspace: "default", Pull: otherPull}, nil)
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(pull.BaseRepo, pull)).ThenReturn(pullDir, nil)
+	pendingPlanFinder := mocks.NewMockPendingPlanFinder()
+	When(pendingPlanFinder.Find(pullDir)).ThenReturn(plans, nil)
+
+	reminder := &events.PendingPlanReminder{
+		Backend:             backend,
+		WorkingDir:          workingDir,
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		PendingPlanFinder:   pendingPlanFinder,
+		CommitStatusUpdater: mocks.NewMockCommitStatusUpdater(),
+		VCSClient:           vcsmocks.NewMockClient(),
+		Logger:              logging.NewNoopLogger(t),
+		Scope:               tally.NewTestScope("", nil),
+		ExecutableName:      "atlantis",
+		DiscardAfter:        14 * 24 * time.Hour,
+	}
+	reminder.Run()
+
+	workingDir.VerifyWasCalledOnce().DeletePlan(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(pull), Eq("default"), Eq("dir"), Eq(""))
+	backend.VerifyWasCalled(Never()).Unlock(Any[models.Project](), Any[string]())
+}
@@ -1,6 +1,9 @@
 package events
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -26,6 +29,7 @@ func NewPlanCommandRunner(
 	lockingLocker locking.Locker,
 	discardApprovalOnPlan bool,
 	pullReqStatusFetcher vcs.PullReqStatusFetcher,
+	maxAutoplanProjects int,
 ) *PlanCommandRunner {
 	return &PlanCommandRunner{
 		silenceVCSStatusNoPlans:    silenceVCSStatusNoPlans,
@@ -46,6 +50,7 @@ func NewPlanCommandRunner(
 		lockingLocker:              lockingLocker,
 		DiscardApprovalOnPlan:      discardApprovalOnPlan,
 		pullReqStatusFetcher:       pullReqStatusFetcher,
+		maxAutoplanProjects:        maxAutoplanProjects,
 	}
 }
 
@@ -77,6 +82,10 @@ type PlanCommandRunner struct {
 	DiscardApprovalOnPlan bool
 	pullReqStatusFetcher  vcs.PullReqStatusFetcher
 	SilencePRComments     []string
+	// maxAutoplanProjects is the number of projects above which autoplan and
+	// plan comments without flags need to be confirmed with --confirm-all.
+	// 0 means there's no maximum.
+	maxAutoplanProjects int
 }
 
 func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
@@ -94,9 +103,22 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 
 	projectCmds, policyCheckCmds := p.partitionProjectCmds(ctx, projectCmds)
 
+	// A label doesn't change the pull request's commit so the plans and
+	// commit statuses of other projects are still current.
+	labeled := ctx.AutoplanTrigger.Event == models.LabeledPullEvent
+
+	if failure := p.confirmationFailure(projectCmds); failure != "" {
+		ctx.Log.Info("not running autoplan because %d projects were modified, more than the maximum of %d", len(projectCmds), p.maxAutoplanProjects)
+		if err := p.commitStatusUpdater.UpdateCombined(ctx.Log, baseRepo, pull, models.FailedCommitStatus, command.Plan); err != nil {
+			ctx.Log.Warn("unable to update commit status: %s", err)
+		}
+		p.pullUpdater.updatePull(ctx, AutoplanCommand{}, command.Result{Failure: failure})
+		return
+	}
+
 	if len(projectCmds) == 0 {
 		ctx.Log.Info("determined there was no project to run plan in")
-		if !(p.silenceVCSStatusNoPlans || p.silenceVCSStatusNoProjects) {
+		if !(p.silenceVCSStatusNoPlans || p.silenceVCSStatusNoProjects || labeled) {
 			// If there were no projects modified, we set successful commit statuses
 			// with 0/0 projects planned/policy_checked/applied successfully because some users require
 			// the Atlantis status to be passing for all pull requests.
@@ -110,16 +132,21 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 			if err := p.commitStatusUpdater.UpdateCombinedCount(ctx.Log, baseRepo, pull, models.SuccessCommitStatus, command.Apply, 0, 0); err != nil {
 				ctx.Log.Warn("unable to update commit status: %s", err)
 			}
+			p.updateSummaryStatus(ctx, models.PullStatus{})
 		}
 		return
 	}
 
-	// discard previous plans that might not be relevant anymore
-	ctx.Log.Debug("deleting previous plans and locks")
-	p.deletePlans(ctx)
-	_, err = p.lockingLocker.UnlockByPull(baseRepo.FullName, pull.Num)
-	if err != nil {
-		ctx.Log.Err("deleting locks: %s", err)
+	if !labeled {
+		// discard previous plans that might not be relevant anymore
+		ctx.Log.Debug("deleting previous plans and locks")
+		p.deletePlans(ctx)
+		_, err = p.lockingLocker.UnlockByPull(baseRepo.FullName, pull.Num)
+		if err != nil {
+			ctx.Log.Err("deleting locks: %s", err)
+		}
+	} else if err := p.commitStatusUpdater.UpdateCombined(ctx.Log, baseRepo, pull, models.PendingCommitStatus, command.Plan); err != nil {
+		ctx.Log.Warn("unable to update plan commit status: %s", err)
 	}
 
 	// Only run commands in parallel if enabled
@@ -137,6 +164,7 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 		result.PlansDeleted = true
 	}
 
+	addPreviousPlanDiffs(ctx.PullStatus, result.ProjectResults)
 	p.pullUpdater.updatePull(ctx, AutoplanCommand{}, result)
 
 	pullStatus, err := p.dbUpdater.updateDB(ctx, ctx.Pull, result.ProjectResults)
@@ -146,6 +174,7 @@ func (p *PlanCommandRunner) runAutoplan(ctx *command.Context) {
 
 	p.updateCommitStatus(ctx, pullStatus, command.Plan)
 	p.updateCommitStatus(ctx, pullStatus, command.Apply)
+	p.updateSummaryStatus(ctx, pullStatus)
 
 	// Check if there are any planned projects and if there are any errors or if plans are being deleted
 	if len(policyCheckCmds) > 0 &&
@@ -192,6 +221,16 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 		return
 	}
 
+	if !cmd.IsForSpecificProject() && !cmd.ConfirmAll {
+		if failure := p.confirmationFailure(projectCmds); failure != "" {
+			if statusErr := p.commitStatusUpdater.UpdateCombined(ctx.Log, baseRepo, pull, models.FailedCommitStatus, command.Plan); statusErr != nil {
+				ctx.Log.Warn("unable to update commit status: %s", statusErr)
+			}
+			p.pullUpdater.updatePull(ctx, cmd, command.Result{Failure: failure})
+			return
+		}
+	}
+
 	if len(projectCmds) == 0 && p.SilenceNoProjects {
 		ctx.Log.Info("determined there was no project to run plan in")
 		if !p.silenceVCSStatusNoProjects {
@@ -208,10 +247,12 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 					if err := p.commitStatusUpdater.UpdateCombinedCount(ctx.Log, baseRepo, pull, models.SuccessCommitStatus, command.Plan, 0, 0); err != nil {
 						ctx.Log.Warn("unable to update commit status: %s", err)
 					}
+					p.updateSummaryStatus(ctx, models.PullStatus{})
 					return
 				}
 				ctx.Log.Debug("resetting VCS status")
 				p.updateCommitStatus(ctx, *pullStatus, command.Plan)
+				p.updateSummaryStatus(ctx, *pullStatus)
 			} else {
 				// With a generic plan, we set successful commit statuses
 				// with 0/0 projects planned successfully because some users require
@@ -227,6 +268,7 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 				if err := p.commitStatusUpdater.UpdateCombinedCount(ctx.Log, baseRepo, pull, models.SuccessCommitStatus, command.Apply, 0, 0); err != nil {
 					ctx.Log.Warn("unable to update commit status: %s", err)
 				}
+				p.updateSummaryStatus(ctx, models.PullStatus{})
 			}
 		}
 		return
@@ -261,6 +303,7 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 		result.PlansDeleted = true
 	}
 
+	addPreviousPlanDiffs(ctx.PullStatus, result.ProjectResults)
 	p.pullUpdater.updatePull(
 		ctx,
 		cmd,
@@ -274,6 +317,7 @@ func (p *PlanCommandRunner) run(ctx *command.Context, cmd *CommentCommand) {
 
 	p.updateCommitStatus(ctx, pullStatus, command.Plan)
 	p.updateCommitStatus(ctx, pullStatus, command.Apply)
+	p.updateSummaryStatus(ctx, pullStatus)
 
 	// Runs policy checks step after all plans are successful.
 	// This step does not approve any policies that require approval.
@@ -340,6 +384,38 @@ func (p *PlanCommandRunner) updateCommitStatus(ctx *command.Context, pullStatus
 	}
 }
 
+// updateSummaryStatus updates the status that aggregates the plans and
+// applies of all projects in pullStatus.
+func (p *PlanCommandRunner) updateSummaryStatus(ctx *command.Context, pullStatus models.PullStatus) {
+	if err := p.commitStatusUpdater.UpdateSummary(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, pullStatus); err != nil {
+		ctx.Log.Warn("unable to update summary commit status: %s", err)
+	}
+}
+
+// addPreviousPlanDiffs compares each successful plan in results to the last
+// plan of the same project in pullStatus so that reviewers can see what
+// changed since then.
+func addPreviousPlanDiffs(pullStatus *models.PullStatus, results []command.ProjectResult) {
+	if pullStatus == nil {
+		return
+	}
+	for _, res := range results {
+		if res.PlanSuccess == nil {
+			continue
+		}
+		for _, prev := range pullStatus.Projects {
+			if prev.Workspace != res.Workspace || prev.RepoRelDir != res.RepoRelDir || prev.ProjectName != res.ProjectName {
+				continue
+			}
+			// There's nothing to compare to if the last plan didn't succeed.
+			if prev.Status != models.ErroredPlanStatus && prev.Status != models.DiscardedPlanStatus {
+				res.PlanSuccess.PreviousPlanDiff = models.NewPlanDiff(prev.PlannedResources, res.PlannedResources())
+			}
+			break
+		}
+	}
+}
+
 // deletePlans deletes all plans generated in this ctx.
 func (p *PlanCommandRunner) deletePlans(ctx *command.Context) {
 	pullDir, err := p.workingDir.GetPullDir(ctx.Pull.BaseRepo, ctx.Pull)
@@ -374,3 +450,25 @@ func (p *PlanCommandRunner) partitionProjectCmds(
 func (p *PlanCommandRunner) isParallelEnabled(projectCmds []command.ProjectContext) bool {
 	return len(projectCmds) > 0 && projectCmds[0].ParallelPlanEnabled
 }
+
+// confirmationFailure returns the failure to comment if planning projectCmds
+// needs to be confirmed with --confirm-all because there are more of them
+// than maxAutoplanProjects. This stops changes that touch many projects, like
+// reformatting every file, from starting hundreds of plans by accident. It
+// returns an empty string if the projects can be planned.
+func (p *PlanCommandRunner) confirmationFailure(projectCmds []command.ProjectContext) string {
+	if p.maxAutoplanProjects <= 0 || len(projectCmds) <= p.maxAutoplanProjects {
+		return ""
+	}
+	var projects strings.Builder
+	for _, projCmd := range projectCmds {
+		if projCmd.ProjectName != "" {
+			fmt.Fprintf(&projects, "\n* project: `%s` dir: `%s` workspace: `%s`", projCmd.ProjectName, projCmd.RepoRelDir, projCmd.Workspace)
+		} else {
+			fmt.Fprintf(&projects, "\n* dir: `%s` workspace: `%s`", projCmd.RepoRelDir, projCmd.Workspace)
+		}
+	}
+	return fmt.Sprintf("This pull request affects %d projects, more than the maximum of %d that are planned without confirmation:\n%s\n\n"+
+		"To plan all of them, comment `atlantis plan --%s`. To plan some of them, use `-%s`, `-%s` or `--%s`.",
+		len(projectCmds), p.maxAutoplanProjects, projects.String(), confirmAllFlagLong, dirFlagShort, projectFlagShort, includeDirFlagLong)
+}
@@ -826,3 +826,69 @@ func TestPlanCommandRunner_AtlantisApplyStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanCommandRunner_PreviousPlanDiff(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	vcsClient := setup(t)
+
+	scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+	cmd := &events.CommentCommand{Name: command.Plan}
+
+	ctx := &command.Context{
+		User:     testdata.User,
+		Log:      logger,
+		Scope:    scopeNull,
+		Pull:     modelPull,
+		HeadRepo: testdata.GithubRepo,
+		Trigger:  command.CommentTrigger,
+		PullStatus: &models.PullStatus{
+			Pull: modelPull,
+			Projects: []models.ProjectStatus{
+				{
+					RepoRelDir: "mydir",
+					Workspace:  "default",
+					Status:     models.PlannedPlanStatus,
+					PlannedResources: []models.ResourceChange{
+						{Address: "aws_instance.web", Action: "create"},
+						{Address: "aws_iam_role.old", Action: "delete"},
+					},
+				},
+				{
+					RepoRelDir: "erroreddir",
+					Workspace:  "default",
+					Status:     models.ErroredPlanStatus,
+				},
+			},
+		},
+	}
+
+	projectCtxs := []command.ProjectContext{
+		{CommandName: command.Plan, RepoRelDir: "mydir", Workspace: "default"},
+		{CommandName: command.Plan, RepoRelDir: "erroreddir", Workspace: "default"},
+		{CommandName: command.Plan, RepoRelDir: "newdir", Workspace: "default"},
+	}
+	planOutput := "  # aws_instance.web will be created\n  # aws_s3_bucket.logs will be created\nPlan: 2 to add, 0 to change, 0 to destroy."
+	projectResults := []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: "mydir", Workspace: "default", PlanSuccess: &models.PlanSuccess{TerraformOutput: planOutput}},
+		{Command: command.Plan, RepoRelDir: "erroreddir", Workspace: "default", PlanSuccess: &models.PlanSuccess{TerraformOutput: planOutput}},
+		{Command: command.Plan, RepoRelDir: "newdir", Workspace: "default", PlanSuccess: &models.PlanSuccess{TerraformOutput: planOutput}},
+	}
+
+	When(projectCommandBuilder.BuildPlanCommands(ctx, cmd)).ThenReturn(projectCtxs, nil)
+	for i := range projectCtxs {
+		When(projectCommandRunner.Plan(projectCtxs[i])).ThenReturn(projectResults[i])
+	}
+
+	planCommandRunner.Run(ctx, cmd)
+
+	vcsClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), AnyInt(), AnyString(), AnyString())
+	Equals(t, &models.PlanDiff{
+		Added:   []models.ResourceChange{{Address: "aws_s3_bucket.logs", Action: "create"}},
+		Removed: []models.ResourceChange{{Address: "aws_iam_role.old", Action: "delete"}},
+	}, projectResults[0].PlanSuccess.PreviousPlanDiff)
+	// There's nothing to compare to if the last plan errored or there wasn't one.
+	Assert(t, projectResults[1].PlanSuccess.PreviousPlanDiff == nil, "exp no diff against an errored plan")
+	Assert(t, projectResults[2].PlanSuccess.PreviousPlanDiff == nil, "exp no diff without a previous plan")
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// encryptedPlanHeader starts the files encrypted by AESPlanEncryptor so that
+// plans made before encryption was enabled can still be applied.
+const encryptedPlanHeader = "ATLANTIS-ENCRYPTED-PLAN-V1\n"
+
+// PlanEncryptor encrypts the plan files and plan JSON of projects while
+// they're stored between commands, since plans can contain sensitive values.
+type PlanEncryptor interface {
+	// Encrypt encrypts the plan file and plan JSON of the project of ctx in
+	// projAbsPath in place. Files that don't exist or are already encrypted
+	// are skipped.
+	Encrypt(ctx command.ProjectContext, projAbsPath string) error
+	// Decrypt decrypts them in place so that terraform and the policy checks
+	// can read them.
+	Decrypt(ctx command.ProjectContext, projAbsPath string) error
+	// ReadFile returns the decrypted content of the file at path.
+	ReadFile(path string) ([]byte, error)
+}
+
+// AESPlanEncryptor encrypts plans with AES-256-GCM. The name of each file is
+// authenticated with it so that the plans of projects can't be swapped.
+type AESPlanEncryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESPlanEncryptor returns an encryptor that uses the base64 encoded
+// 32 byte key in keyFile.
+func NewAESPlanEncryptor(keyFile string) (*AESPlanEncryptor, error) {
+	data, err := os.ReadFile(keyFile) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must contain a base64 encoded 32 byte key, ex. generated with 'openssl rand -base64 32'", keyFile)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESPlanEncryptor{aead: aead}, nil
+}
+
+// Encrypt implements PlanEncryptor.
+func (e *AESPlanEncryptor) Encrypt(ctx command.ProjectContext, projAbsPath string) error {
+	for _, path := range planFiles(ctx, projAbsPath) {
+		data, err := os.ReadFile(path) // nolint: gosec
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(data, []byte(encryptedPlanHeader)) {
+			continue
+		}
+		nonce := make([]byte, e.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		encrypted := append([]byte(encryptedPlanHeader), nonce...)
+		encrypted = e.aead.Seal(encrypted, nonce, data, []byte(filepath.Base(path)))
+		if err := replaceFile(path, encrypted); err != nil {
+			return fmt.Errorf("encrypting %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// Decrypt implements PlanEncryptor.
+func (e *AESPlanEncryptor) Decrypt(ctx command.ProjectContext, projAbsPath string) error {
+	for _, path := range planFiles(ctx, projAbsPath) {
+		data, err := os.ReadFile(path) // nolint: gosec
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !bytes.HasPrefix(data, []byte(encryptedPlanHeader)) {
+			continue
+		}
+		decrypted, err := e.decrypt(path, data)
+		if err != nil {
+			return err
+		}
+		if err := replaceFile(path, decrypted); err != nil {
+			return fmt.Errorf("decrypting %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// ReadFile implements PlanEncryptor.
+func (e *AESPlanEncryptor) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) // nolint: gosec
+	if err != nil || !bytes.HasPrefix(data, []byte(encryptedPlanHeader)) {
+		return data, err
+	}
+	return e.decrypt(path, data)
+}
+
+func (e *AESPlanEncryptor) decrypt(path string, data []byte) ([]byte, error) {
+	data = data[len(encryptedPlanHeader):]
+	if len(data) < e.aead.NonceSize() {
+		return nil, fmt.Errorf("decrypting %s: file is truncated", filepath.Base(path))
+	}
+	nonce, ciphertext := data[:e.aead.NonceSize()], data[e.aead.NonceSize():]
+	decrypted, err := e.aead.Open(nil, nonce, ciphertext, []byte(filepath.Base(path)))
+	if err != nil {
+		return nil, errors.New("decrypting " + filepath.Base(path) + ": it was encrypted with a different key or modified, run plan again")
+	}
+	return decrypted, nil
+}
+
+// planFiles returns the paths of the plan file and plan JSON of the project
+// of ctx.
+func planFiles(ctx command.ProjectContext, projAbsPath string) []string {
+	return []string{
+		filepath.Join(projAbsPath, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName)),
+		filepath.Join(projAbsPath, ctx.GetShowResultFileName()),
+	}
+}
+
+// replaceFile atomically replaces the content of the file at path with data.
+func replaceFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,68 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func writeKeyFile(t *testing.T, key string) string {
+	path := filepath.Join(t.TempDir(), "plan-key")
+	Ok(t, os.WriteFile(path, []byte(key), 0600))
+	return path
+}
+
+func TestNewAESPlanEncryptor_InvalidKey(t *testing.T) {
+	for _, key := range []string{"not base64!", "c2hvcnQ="} {
+		keyFile := writeKeyFile(t, key)
+		_, err := events.NewAESPlanEncryptor(keyFile)
+		ErrEquals(t, keyFile+" must contain a base64 encoded 32 byte key, ex. generated with 'openssl rand -base64 32'", err)
+	}
+}
+
+func TestAESPlanEncryptor(t *testing.T) {
+	encryptor, err := events.NewAESPlanEncryptor(writeKeyFile(t, "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"))
+	Ok(t, err)
+	ctx := command.ProjectContext{ProjectName: "prod", Workspace: "default"}
+	dir := t.TempDir()
+	planFile := filepath.Join(dir, "prod-default.tfplan")
+	showFile := filepath.Join(dir, ctx.GetShowResultFileName())
+	Ok(t, os.WriteFile(planFile, []byte("plan"), 0600))
+	Ok(t, os.WriteFile(showFile, []byte(`{"password":"secret"}`), 0600))
+
+	Ok(t, encryptor.Encrypt(ctx, dir))
+	encrypted, err := os.ReadFile(showFile)
+	Ok(t, err)
+	Assert(t, string(encrypted) != `{"password":"secret"}`, "expected plan JSON to be encrypted")
+	// Encrypting again doesn't encrypt twice.
+	Ok(t, encryptor.Encrypt(ctx, dir))
+	planJSON, err := encryptor.ReadFile(showFile)
+	Ok(t, err)
+	Equals(t, `{"password":"secret"}`, string(planJSON))
+
+	Ok(t, encryptor.Decrypt(ctx, dir))
+	for path, exp := range map[string]string{planFile: "plan", showFile: `{"password":"secret"}`} {
+		content, err := os.ReadFile(path)
+		Ok(t, err)
+		Equals(t, exp, string(content))
+	}
+	// Unencrypted plans are read as is.
+	planJSON, err = encryptor.ReadFile(showFile)
+	Ok(t, err)
+	Equals(t, `{"password":"secret"}`, string(planJSON))
+
+	// The plan of a project can't be used as the plan of another one.
+	Ok(t, encryptor.Encrypt(ctx, dir))
+	Ok(t, os.Rename(planFile, filepath.Join(dir, "staging-default.tfplan")))
+	err = encryptor.Decrypt(command.ProjectContext{ProjectName: "staging", Workspace: "default"}, dir)
+	ErrContains(t, "decrypting staging-default.tfplan: it was encrypted with a different key or modified", err)
+
+	otherEncryptor, err := events.NewAESPlanEncryptor(writeKeyFile(t, "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="))
+	Ok(t, err)
+	_, err = otherEncryptor.ReadFile(showFile)
+	ErrContains(t, "it was encrypted with a different key or modified", err)
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// maxAgeRetentionReason tags plans deleted because they were older than
+	// the max age.
+	maxAgeRetentionReason = "max_age"
+	// maxCountRetentionReason tags plans deleted because their project had
+	// more plans than the max count.
+	maxCountRetentionReason = "max_count"
+)
+
+// PlanRetentionCleaner deletes the plans of pull requests that are past the
+// retention limits. Once a plan is deleted its project must be planned again
+// before it can be applied, so its status and the pull request's plan commit
+// status are updated to say so. It's run periodically as a scheduled job.
+type PlanRetentionCleaner struct {
+	Backend             locking.Backend
+	WorkingDir          WorkingDir
+	WorkingDirLocker    WorkingDirLocker
+	PendingPlanFinder   PendingPlanFinder
+	CommitStatusUpdater CommitStatusUpdater
+	Logger              logging.SimpleLogging
+	Scope               tally.Scope
+	// MaxAge is how long a plan is kept for. Zero keeps plans however old
+	// they are.
+	MaxAge time.Duration
+	// MaxCount is how many plans are kept for each project of a pull
+	// request, across its workspaces. The newest plans are kept. Zero keeps
+	// all of them.
+	MaxCount int
+}
+
+// retainedPlan is a plan of a pull request and when it was made.
+type retainedPlan struct {
+	PendingPlan
+	ModTime time.Time
+}
+
+// Run deletes the plans of every pull request that are past the retention
+// limits.
+func (c *PlanRetentionCleaner) Run() {
+	scope := c.Scope.SubScope("plan_retention")
+	snapshot, err := c.Backend.Snapshot()
+	if err != nil {
+		scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		c.Logger.Err("unable to list pull requests for plan retention: %s", err)
+		return
+	}
+	for _, pullStatus := range snapshot.Pulls {
+		if err := c.cleanPull(scope, pullStatus.Pull); err != nil {
+			scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+			c.Logger.Warn("unable to enforce plan retention for %s#%d: %s", pullStatus.Pull.BaseRepo.FullName, pullStatus.Pull.Num, err)
+		}
+	}
+	scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+}
+
+// findRetainedPlans returns the pending plans of pull and when they were made.
+func findRetainedPlans(workingDir WorkingDir, pendingPlanFinder PendingPlanFinder, pull models.PullRequest) ([]retainedPlan, error) {
+	pullDir, err := workingDir.GetPullDir(pull.BaseRepo, pull)
+	if os.IsNotExist(err) {
+		// The pull request was closed and its plans were already deleted.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pendingPlans, err := pendingPlanFinder.Find(pullDir)
+	if err != nil {
+		return nil, err
+	}
+	var plans []retainedPlan
+	for _, plan := range pendingPlans {
+		info, err := os.Stat(filepath.Join(plan.RepoDir, plan.RepoRelDir, runtime.GetPlanFilename(plan.Workspace, plan.ProjectName)))
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, retainedPlan{PendingPlan: plan, ModTime: info.ModTime()})
+	}
+	return plans, nil
+}
+
+func (c *PlanRetentionCleaner) cleanPull(scope tally.Scope, pull models.PullRequest) error {
+	plans, err := findRetainedPlans(c.WorkingDir, c.PendingPlanFinder, pull)
+	if err != nil {
+		return err
+	}
+
+	var deleted int
+	for reason, expired := range c.expiredPlans(plans, time.Now()) {
+		for _, plan := range expired {
+			if c.deletePlan(pull, plan) {
+				scope.Tagged(map[string]string{"reason": reason}).Counter("plans_deleted").Inc(1)
+				deleted++
+			}
+		}
+	}
+	if deleted == 0 {
+		return nil
+	}
+	return updatePlanCommitStatuses(c.Backend, c.CommitStatusUpdater, c.Logger, pull)
+}
+
+// expiredPlans returns the plans past the retention limits keyed by the
+// limit they're past.
+func (c *PlanRetentionCleaner) expiredPlans(plans []retainedPlan, now time.Time) map[string][]retainedPlan {
+	expired := make(map[string][]retainedPlan)
+	byProject := make(map[string][]retainedPlan)
+	for _, plan := range plans {
+		if c.MaxAge > 0 && now.Sub(plan.ModTime) > c.MaxAge {
+			expired[maxAgeRetentionReason] = append(expired[maxAgeRetentionReason], plan)
+			continue
+		}
+		key := plan.RepoRelDir + "/" + plan.ProjectName
+		byProject[key] = append(byProject[key], plan)
+	}
+	if c.MaxCount <= 0 {
+		return expired
+	}
+	for _, projectPlans := range byProject {
+		if len(projectPlans) <= c.MaxCount {
+			continue
+		}
+		sort.Slice(projectPlans, func(i, j int) bool {
+			return projectPlans[i].ModTime.After(projectPlans[j].ModTime)
+		})
+		expired[maxCountRetentionReason] = append(expired[maxCountRetentionReason], projectPlans[c.MaxCount:]...)
+	}
+	return expired
+}
+
+// deletePlan deletes plan and marks its project's plan as discarded. It
+// returns false if the plan wasn't deleted, ex. because a command is running
+// in its directory, in which case it's retried on the next run.
+func (c *PlanRetentionCleaner) deletePlan(pull models.PullRequest, plan retainedPlan) bool {
+	unlockFn, err := c.WorkingDirLocker.TryLock(pull.BaseRepo.FullName, pull.Num, plan.Workspace, plan.RepoRelDir)
+	if err != nil {
+		c.Logger.Debug("skipping plan retention for dir %q workspace %q: %s", plan.RepoRelDir, plan.Workspace, err)
+		return false
+	}
+	defer unlockFn()
+
+	if err := c.WorkingDir.DeletePlan(c.Logger, pull.BaseRepo, pull, plan.Workspace, plan.RepoRelDir, plan.ProjectName); err != nil {
+		c.Logger.Warn("unable to delete plan for dir %q workspace %q: %s", plan.RepoRelDir, plan.Workspace, err)
+		return false
+	}
+	if err := c.Backend.UpdateProjectStatus(pull, plan.Workspace, plan.RepoRelDir, models.DiscardedPlanStatus); err != nil {
+		c.Logger.Warn("unable to update project status: %s", err)
+	}
+	return true
+}
+
+// updatePlanCommitStatuses updates the plan and summary commit statuses of
+// pull so they no longer count deleted plans as planned.
+func updatePlanCommitStatuses(backend locking.Backend, commitStatusUpdater CommitStatusUpdater, logger logging.SimpleLogging, pull models.PullRequest) error {
+	pullStatus, err := backend.GetPullStatus(pull)
+	if err != nil || pullStatus == nil {
+		return err
+	}
+	numPlanned := len(pullStatus.Projects) - pullStatus.StatusCount(models.ErroredPlanStatus) - pullStatus.StatusCount(models.DiscardedPlanStatus)
+	if err := commitStatusUpdater.UpdateCombinedCount(logger, pull.BaseRepo, pull, models.FailedCommitStatus, command.Plan, numPlanned, len(pullStatus.Projects)); err != nil {
+		return err
+	}
+	return commitStatusUpdater.UpdateSummary(logger, pull.BaseRepo, pull, *pullStatus)
+}
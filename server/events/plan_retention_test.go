@@ -0,0 +1,126 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// writePlans writes a plan for dir in each workspace of ages, modified that
+// long ago, and returns them as found by a PendingPlanFinder.
+func writePlans(t *testing.T, pullDir string, dir string, ages map[string]time.Duration) []events.PendingPlan {
+	var plans []events.PendingPlan
+	for workspace, age := range ages {
+		repoDir := filepath.Join(pullDir, workspace)
+		Ok(t, os.MkdirAll(filepath.Join(repoDir, dir), 0700))
+		planPath := filepath.Join(repoDir, dir, runtime.GetPlanFilename(workspace, ""))
+		Ok(t, os.WriteFile(planPath, nil, 0600))
+		modTime := time.Now().Add(-age)
+		Ok(t, os.Chtimes(planPath, modTime, modTime))
+		plans = append(plans, events.PendingPlan{RepoDir: repoDir, RepoRelDir: dir, Workspace: workspace})
+	}
+	return plans
+}
+
+func TestPlanRetentionCleaner_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	pull := models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo"}, Num: 1}
+	pullDir := t.TempDir()
+	plans := writePlans(t, pullDir, "dir", map[string]time.Duration{
+		"old":    10 * 24 * time.Hour,
+		"older":  2 * time.Hour,
+		"newest": time.Hour,
+	})
+
+	backend := lockmocks.NewMockBackend()
+	When(backend.Snapshot()).ThenReturn(locking.Snapshot{Pulls: []models.PullStatus{{Pull: pull}}}, nil)
+	pullStatus := &models.PullStatus{
+		Pull: pull,
+		Projects: []models.ProjectStatus{
+			{RepoRelDir: "dir", Workspace: "old", Status: models.DiscardedPlanStatus},
+			{RepoRelDir: "dir", Workspace: "older", Status: models.DiscardedPlanStatus},
+			{RepoRelDir: "dir", Workspace: "newest", Status: models.PlannedPlanStatus},
+		},
+	}
+	When(backend.GetPullStatus(pull)).ThenReturn(pullStatus, nil)
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(pull.BaseRepo, pull)).ThenReturn(pullDir, nil)
+	pendingPlanFinder := mocks.NewMockPendingPlanFinder()
+	When(pendingPlanFinder.Find(pullDir)).ThenReturn(plans, nil)
+	commitStatusUpdater := mocks.NewMockCommitStatusUpdater()
+	scope := tally.NewTestScope("", nil)
+
+	cleaner := &events.PlanRetentionCleaner{
+		Backend:             backend,
+		WorkingDir:          workingDir,
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		PendingPlanFinder:   pendingPlanFinder,
+		CommitStatusUpdater: commitStatusUpdater,
+		Logger:              logger,
+		Scope:               scope,
+		MaxAge:              48 * time.Hour,
+		MaxCount:            1,
+	}
+	cleaner.Run()
+
+	for _, workspace := range []string{"old", "older"} {
+		workingDir.VerifyWasCalledOnce().DeletePlan(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(pull), Eq(workspace), Eq("dir"), Eq(""))
+		backend.VerifyWasCalledOnce().UpdateProjectStatus(pull, workspace, "dir", models.DiscardedPlanStatus)
+	}
+	workingDir.VerifyWasCalled(Never()).DeletePlan(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Eq("newest"), Any[string](), Any[string]())
+	commitStatusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(pull), Eq(models.FailedCommitStatus), Eq(command.Plan), Eq(1), Eq(3))
+	commitStatusUpdater.VerifyWasCalledOnce().UpdateSummary(Any[logging.SimpleLogging](), Eq(pull.BaseRepo), Eq(pull), Eq(*pullStatus))
+
+	counters := scope.Snapshot().Counters()
+	Equals(t, int64(1), counters["plan_retention.plans_deleted+reason=max_age"].Value())
+	Equals(t, int64(1), counters["plan_retention.plans_deleted+reason=max_count"].Value())
+}
+
+func TestPlanRetentionCleaner_Run_WithinLimits(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	pull := models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo"}, Num: 1}
+	pullDir := t.TempDir()
+	plans := writePlans(t, pullDir, "dir", map[string]time.Duration{
+		"default": time.Hour,
+		"staging": 2 * time.Hour,
+	})
+
+	backend := lockmocks.NewMockBackend()
+	When(backend.Snapshot()).ThenReturn(locking.Snapshot{Pulls: []models.PullStatus{{Pull: pull}}}, nil)
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(pull.BaseRepo, pull)).ThenReturn(pullDir, nil)
+	pendingPlanFinder := mocks.NewMockPendingPlanFinder()
+	When(pendingPlanFinder.Find(pullDir)).ThenReturn(plans, nil)
+	commitStatusUpdater := mocks.NewMockCommitStatusUpdater()
+
+	cleaner := &events.PlanRetentionCleaner{
+		Backend:             backend,
+		WorkingDir:          workingDir,
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		PendingPlanFinder:   pendingPlanFinder,
+		CommitStatusUpdater: commitStatusUpdater,
+		Logger:              logger,
+		Scope:               tally.NewTestScope("", nil),
+		MaxAge:              48 * time.Hour,
+		MaxCount:            2,
+	}
+	cleaner.Run()
+
+	workingDir.VerifyWasCalled(Never()).DeletePlan(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[string](), Any[string](), Any[string]())
+	commitStatusUpdater.VerifyWasCalled(Never()).UpdateCombinedCount(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](), Any[models.CommitStatus](), Any[command.Name](), Any[int](), Any[int]())
+}
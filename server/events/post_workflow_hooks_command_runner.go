@@ -34,12 +34,13 @@ type DefaultPostWorkflowHooksCommandRunner struct {
 	PostWorkflowHookRunner runtime.PostWorkflowHookRunner `validate:"required"`
 	CommitStatusUpdater    CommitStatusUpdater            `validate:"required"`
 	Router                 PostWorkflowHookURLGenerator   `validate:"required"`
+	ReloadableGlobalCfg    *valid.ReloadableGlobalCfg
 }
 
 // RunPostHooks runs post_workflow_hooks after a plan/apply has completed
 func (w *DefaultPostWorkflowHooksCommandRunner) RunPostHooks(ctx *command.Context, cmd *CommentCommand) error {
 	postWorkflowHooks := make([]*valid.WorkflowHook, 0)
-	for _, repo := range w.GlobalCfg.Repos {
+	for _, repo := range w.ReloadableGlobalCfg.LoadOr(w.GlobalCfg).Repos {
 		if repo.IDMatches(ctx.Pull.BaseRepo.ID()) && repo.BranchMatches(ctx.Pull.BaseBranch) && len(repo.PostWorkflowHooks) > 0 {
 			postWorkflowHooks = append(postWorkflowHooks, repo.PostWorkflowHooks...)
 		}
@@ -124,7 +125,7 @@ func (w *DefaultPostWorkflowHooksCommandRunner) runHooks(
 		shellArgs := hook.ShellArgs
 		if shellArgs == "" {
 			ctx.Log.Debug("Setting shellArgs to default: '%s'", shellArgs)
-			shellArgs = "-c"
+			shellArgs = strings.Join(valid.DefaultShellArgs(shell), " ")
 		}
 		url, err := w.Router.GenerateProjectWorkflowHookURL(ctx.HookID)
 		if err != nil && !ctx.API {
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// policyFailuresOpenMetric is the number of open pull requests in which
+	// a project's policy checks failed.
+	policyFailuresOpenMetric = "policy_failures_open"
+	// daysSinceLastApplyMetric is the number of days since a project was last
+	// applied successfully.
+	daysSinceLastApplyMetric = "days_since_last_apply"
+)
+
+// PostureReporter reports gauges of the posture of each project so that
+// regressions can be alerted on, ex. policy failures that are left open or
+// projects that haven't been applied in a long time. It's run periodically as
+// a scheduled job.
+type PostureReporter struct {
+	Backend locking.Backend
+	Logger  logging.SimpleLogging
+	Scope   tally.Scope
+	// Now defaults to time.Now. Used in tests.
+	Now func() time.Time
+
+	// reported are the projects policy failures were reported for in the
+	// last run, so they can be reset once their pull requests are closed.
+	reported map[postureProject]bool
+}
+
+// postureProject identifies a project in the posture gauges.
+type postureProject struct {
+	Repo      string
+	Project   string
+	Dir       string
+	Workspace string
+}
+
+func (p postureProject) tags() map[string]string {
+	return map[string]string{
+		"repo":      p.Repo,
+		"project":   p.Project,
+		"dir":       p.Dir,
+		"workspace": p.Workspace,
+	}
+}
+
+// Run reports the posture gauges of every project.
+func (r *PostureReporter) Run() {
+	scope := r.Scope.SubScope("posture")
+	snapshot, err := r.Backend.Snapshot()
+	if err != nil {
+		scope.Counter(metrics.ExecutionErrorMetric).Inc(1)
+		r.Logger.Err("unable to list pull requests and applies for posture metrics: %s", err)
+		return
+	}
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	policyFailures := map[postureProject]int{}
+	for _, pull := range snapshot.Pulls {
+		if pull.Pull.State == models.ClosedPullState {
+			continue
+		}
+		for _, project := range pull.Projects {
+			if project.Status != models.ErroredPolicyCheckStatus {
+				continue
+			}
+			policyFailures[postureProject{
+				Repo:      pull.Pull.BaseRepo.FullName,
+				Project:   project.ProjectName,
+				Dir:       project.RepoRelDir,
+				Workspace: project.Workspace,
+			}]++
+		}
+	}
+	for project := range r.reported {
+		if _, ok := policyFailures[project]; !ok {
+			scope.Tagged(project.tags()).Gauge(policyFailuresOpenMetric).Update(0)
+		}
+	}
+	for project, count := range policyFailures {
+		scope.Tagged(project.tags()).Gauge(policyFailuresOpenMetric).Update(float64(count))
+	}
+	r.reported = map[postureProject]bool{}
+	for project := range policyFailures {
+		r.reported[project] = true
+	}
+
+	for _, apply := range snapshot.Applies {
+		project := postureProject{
+			Repo:      apply.RepoFullName,
+			Project:   apply.ProjectName,
+			Dir:       apply.RepoRelDir,
+			Workspace: apply.Workspace,
+		}
+		scope.Tagged(project.tags()).Gauge(daysSinceLastApplyMetric).Update(now.Sub(apply.Time).Hours() / 24)
+	}
+	scope.Counter(metrics.ExecutionSuccessMetric).Inc(1)
+}
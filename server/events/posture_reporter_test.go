@@ -0,0 +1,72 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// postureGauges returns the values of the posture gauges named name, keyed by
+// the dir tag.
+func postureGauges(scope tally.TestScope, name string) map[string]float64 {
+	values := map[string]float64{}
+	for _, gauge := range scope.Snapshot().Gauges() {
+		if gauge.Name() == "posture."+name {
+			values[gauge.Tags()["dir"]] = gauge.Value()
+		}
+	}
+	return values
+}
+
+func TestPostureReporter_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	repo := models.Repo{FullName: "owner/repo"}
+	policyFailed := models.PullStatus{
+		Pull: models.PullRequest{BaseRepo: repo, Num: 1, State: models.OpenPullState},
+		Projects: []models.ProjectStatus{
+			{RepoRelDir: "prod", Workspace: "default", Status: models.ErroredPolicyCheckStatus},
+			{RepoRelDir: "staging", Workspace: "default", Status: models.PassedPolicyCheckStatus},
+		},
+	}
+	alsoPolicyFailed := models.PullStatus{
+		Pull: models.PullRequest{BaseRepo: repo, Num: 2, State: models.OpenPullState},
+		Projects: []models.ProjectStatus{
+			{RepoRelDir: "prod", Workspace: "default", Status: models.ErroredPolicyCheckStatus},
+		},
+	}
+	snapshot := locking.Snapshot{
+		Pulls: []models.PullStatus{policyFailed, alsoPolicyFailed},
+		Applies: []models.ProjectApply{
+			{RepoFullName: "owner/repo", RepoRelDir: "prod", Workspace: "default", PullNum: 3, Time: now.Add(-36 * time.Hour)},
+			{RepoFullName: "owner/repo", RepoRelDir: "staging", Workspace: "default", PullNum: 4, Time: now.Add(-6 * time.Hour)},
+		},
+	}
+	backend := lockmocks.NewMockBackend()
+	When(backend.Snapshot()).ThenReturn(snapshot, nil)
+	scope := tally.NewTestScope("", nil)
+	reporter := &events.PostureReporter{
+		Backend: backend,
+		Logger:  logging.NewNoopLogger(t),
+		Scope:   scope,
+		Now:     func() time.Time { return now },
+	}
+
+	reporter.Run()
+	Equals(t, map[string]float64{"prod": 2}, postureGauges(scope, "policy_failures_open"))
+	Equals(t, map[string]float64{"prod": 1.5, "staging": 0.25}, postureGauges(scope, "days_since_last_apply"))
+
+	// Once the pull requests are closed, the policy failures are reset.
+	snapshot.Pulls = nil
+	When(backend.Snapshot()).ThenReturn(snapshot, nil)
+	reporter.Run()
+	Equals(t, map[string]float64{"prod": 0}, postureGauges(scope, "policy_failures_open"))
+}
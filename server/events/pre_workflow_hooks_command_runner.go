@@ -34,12 +34,13 @@ type DefaultPreWorkflowHooksCommandRunner struct {
 	PreWorkflowHookRunner runtime.PreWorkflowHookRunner `validate:"required"`
 	CommitStatusUpdater   CommitStatusUpdater           `validate:"required"`
 	Router                PreWorkflowHookURLGenerator   `validate:"required"`
+	ReloadableGlobalCfg   *valid.ReloadableGlobalCfg
 }
 
 // RunPreHooks runs pre_workflow_hooks when PR is opened or updated.
 func (w *DefaultPreWorkflowHooksCommandRunner) RunPreHooks(ctx *command.Context, cmd *CommentCommand) error {
 	preWorkflowHooks := make([]*valid.WorkflowHook, 0)
-	for _, repo := range w.GlobalCfg.Repos {
+	for _, repo := range w.ReloadableGlobalCfg.LoadOr(w.GlobalCfg).Repos {
 		if repo.IDMatches(ctx.Pull.BaseRepo.ID()) && len(repo.PreWorkflowHooks) > 0 {
 			preWorkflowHooks = append(preWorkflowHooks, repo.PreWorkflowHooks...)
 		}
@@ -124,7 +125,7 @@ func (w *DefaultPreWorkflowHooksCommandRunner) runHooks(
 		shellArgs := hook.ShellArgs
 		if shellArgs == "" {
 			ctx.Log.Debug("Setting shellArgs to default: '%s'", shellArgs)
-			shellArgs = "-c"
+			shellArgs = strings.Join(valid.DefaultShellArgs(shell), " ")
 		}
 		url, err := w.Router.GenerateProjectWorkflowHookURL(ctx.HookID)
 		if err != nil && !ctx.API {
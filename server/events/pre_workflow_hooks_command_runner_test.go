@@ -189,6 +189,37 @@ func TestRunPreHooks_Clone(t *testing.T) {
 			Eq(events.DefaultWorkspace))
 	})
 
+	t.Run("success hooks in reloaded cfg", func(t *testing.T) {
+		preWorkflowHooksSetup(t)
+
+		// The hooks were added when the server-side repo config was
+		// reloaded so they're only in the reloadable config.
+		preWh.GlobalCfg = valid.GlobalCfg{}
+		preWh.ReloadableGlobalCfg = valid.NewReloadableGlobalCfg(valid.GlobalCfg{
+			Repos: []valid.Repo{
+				{
+					ID: testdata.GithubRepo.ID(),
+					PreWorkflowHooks: []*valid.WorkflowHook{
+						&testHook,
+					},
+				},
+			},
+		})
+
+		When(preWhWorkingDirLocker.TryLock(testdata.GithubRepo.FullName, newPull.Num, events.DefaultWorkspace,
+			events.DefaultRepoRelDir)).ThenReturn(func() {}, nil)
+		When(preWhWorkingDir.Clone(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(newPull),
+			Eq(events.DefaultWorkspace))).ThenReturn(repoDir, nil)
+		When(whPreWorkflowHookRunner.Run(Any[models.WorkflowHookCommandContext](), Eq(testHook.RunCommand),
+			Any[string](), Any[string](), Eq(repoDir))).ThenReturn(result, runtimeDesc, nil)
+
+		err := preWh.RunPreHooks(ctx, planCmd)
+
+		Ok(t, err)
+		whPreWorkflowHookRunner.VerifyWasCalledOnce().Run(Any[models.WorkflowHookCommandContext](),
+			Eq(testHook.RunCommand), Eq(defaultShell), Eq(defaultShellArgs), Eq(repoDir))
+	})
+
 	t.Run("error locking work dir", func(t *testing.T) {
 		preWorkflowHooksSetup(t)
 
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	tally "github.com/uber-go/tally/v4"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -44,7 +45,7 @@ func NewInstrumentedProjectCommandBuilder(
 	vcsClient vcs.Client,
 	workingDir WorkingDir,
 	workingDirLocker WorkingDirLocker,
-	globalCfg valid.GlobalCfg,
+	globalCfg *valid.ReloadableGlobalCfg,
 	pendingPlanFinder *DefaultPendingPlanFinder,
 	commentBuilder CommentBuilder,
 	skipCloneNoChanges bool,
@@ -67,33 +68,35 @@ func NewInstrumentedProjectCommandBuilder(
 		metrics.InitCounter(scope, m)
 	}
 
+	builder := NewProjectCommandBuilder(
+		policyChecksSupported,
+		parserValidator,
+		projectFinder,
+		vcsClient,
+		workingDir,
+		workingDirLocker,
+		globalCfg.Load(),
+		pendingPlanFinder,
+		commentBuilder,
+		skipCloneNoChanges,
+		EnableRegExpCmd,
+		EnableAutoMerge,
+		EnableParallelPlan,
+		EnableParallelApply,
+		AutoDetectModuleFiles,
+		AutoplanFileList,
+		RestrictFileList,
+		SilenceNoProjects,
+		IncludeGitUntrackedFiles,
+		AutoDiscoverMode,
+		scope,
+		terraformClient,
+	)
+	builder.ReloadableGlobalCfg = globalCfg
 	return &InstrumentedProjectCommandBuilder{
-		ProjectCommandBuilder: NewProjectCommandBuilder(
-			policyChecksSupported,
-			parserValidator,
-			projectFinder,
-			vcsClient,
-			workingDir,
-			workingDirLocker,
-			globalCfg,
-			pendingPlanFinder,
-			commentBuilder,
-			skipCloneNoChanges,
-			EnableRegExpCmd,
-			EnableAutoMerge,
-			EnableParallelPlan,
-			EnableParallelApply,
-			AutoDetectModuleFiles,
-			AutoplanFileList,
-			RestrictFileList,
-			SilenceNoProjects,
-			IncludeGitUntrackedFiles,
-			AutoDiscoverMode,
-			scope,
-			terraformClient,
-		),
-		Logger: logger,
-		scope:  scope,
+		ProjectCommandBuilder: builder,
+		Logger:                logger,
+		scope:                 scope,
 	}
 }
 
@@ -146,6 +149,8 @@ func NewProjectCommandBuilder(
 			scope,
 		),
 		TerraformExecutor: terraformClient,
+		CDKTFSynthesizer:  &DefaultCDKTFSynthesizer{},
+		Scope:             scope,
 	}
 }
 
@@ -192,6 +197,38 @@ type ProjectStateCommandBuilder interface {
 	BuildStateRmCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
 }
 
+type ProjectForceUnlockCommandBuilder interface {
+	// BuildForceUnlockCommands builds project force-unlock commands for this ctx and comment. If
+	// comment doesn't specify one project then there may be multiple commands
+	// to be run.
+	BuildForceUnlockCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
+}
+
+type ProjectWorkspacesCommandBuilder interface {
+	// BuildWorkspacesCommands builds project workspaces commands for this ctx and comment. If
+	// comment doesn't specify one project then there may be multiple commands
+	// to be run.
+	BuildWorkspacesCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error)
+}
+
+// ProjectHelpBuilder finds the projects that help describes.
+type ProjectHelpBuilder interface {
+	// BuildHelpProjects returns the merged config of every project configured
+	// in the repo config, or of every project the pull request modifies if
+	// the repo config doesn't configure any.
+	BuildHelpProjects(ctx *command.Context) ([]valid.MergedProjectCfg, error)
+}
+
+// ProjectSimulator finds the projects of a pull request as a server-side repo
+// config that isn't in use would configure them, to compare them with the
+// projects the current config configures.
+type ProjectSimulator interface {
+	ProjectHelpBuilder
+	// SimulateProjects returns the projects BuildHelpProjects would return
+	// if globalCfg was the server-side repo config.
+	SimulateProjects(ctx *command.Context, globalCfg valid.GlobalCfg) ([]valid.MergedProjectCfg, error)
+}
+
 //go:generate pegomock generate github.com/runatlantis/atlantis/server/events --package mocks -o mocks/mock_project_command_builder.go ProjectCommandBuilder
 
 // ProjectCommandBuilder builds commands that run on individual projects.
@@ -202,6 +239,8 @@ type ProjectCommandBuilder interface {
 	ProjectVersionCommandBuilder
 	ProjectImportCommandBuilder
 	ProjectStateCommandBuilder
+	ProjectForceUnlockCommandBuilder
+	ProjectWorkspacesCommandBuilder
 }
 
 // DefaultProjectCommandBuilder implements ProjectCommandBuilder.
@@ -220,6 +259,8 @@ type DefaultProjectCommandBuilder struct {
 	WorkingDirLocker WorkingDirLocker
 	// The final parsed version of the server-side repo config.
 	GlobalCfg valid.GlobalCfg
+	// Used instead of GlobalCfg if set so that reloading the server-side repo config takes effect.
+	ReloadableGlobalCfg *valid.ReloadableGlobalCfg
 	// Finds unapplied plans.
 	PendingPlanFinder *DefaultPendingPlanFinder
 	// Builds project command contexts for Atlantis commands.
@@ -250,6 +291,15 @@ type DefaultProjectCommandBuilder struct {
 	AutoDiscoverMode string
 	// Handles the actual running of Terraform commands.
 	TerraformExecutor tfclient.Client
+	// Synthesizes CDKTF apps when autodiscovering their stacks.
+	CDKTFSynthesizer CDKTFSynthesizer
+	// Scope reports how long project discovery takes.
+	Scope tally.Scope
+}
+
+// globalCfg returns the current server-side repo config.
+func (p *DefaultProjectCommandBuilder) globalCfg() valid.GlobalCfg {
+	return p.ReloadableGlobalCfg.LoadOr(p.GlobalCfg)
 }
 
 // See ProjectCommandBuilder.BuildAutoplanCommands.
@@ -264,16 +314,37 @@ func (p *DefaultProjectCommandBuilder) BuildAutoplanCommands(ctx *command.Contex
 			ctx.Log.Debug("ignoring project at dir '%s', workspace: '%s' because autoplan is disabled", projCtx.RepoRelDir, projCtx.Workspace)
 			continue
 		}
+		if !autoplanTriggered(projCtx, ctx.AutoplanTrigger) {
+			ctx.Log.Debug("ignoring project at dir '%s', workspace: '%s' because its autoplan mode isn't triggered by a %s event", projCtx.RepoRelDir, projCtx.Workspace, ctx.AutoplanTrigger.Event)
+			continue
+		}
 		autoplanEnabled = append(autoplanEnabled, projCtx)
 	}
 	return autoplanEnabled, nil
 }
 
+// autoplanTriggered returns true if the project's autoplan mode plans it for
+// the pull request event trigger.
+func autoplanTriggered(projCtx command.ProjectContext, trigger models.AutoplanTrigger) bool {
+	switch projCtx.AutoplanMode {
+	case valid.AutoplanModeReadyForReview:
+		return trigger.Event == models.OpenedPullEvent || trigger.Event == models.ReadyForReviewPullEvent
+	case valid.AutoplanModeLabeled:
+		return trigger.Event == models.LabeledPullEvent && slices.Contains(projCtx.AutoplanLabels, trigger.Label)
+	default:
+		return trigger.Event != models.LabeledPullEvent
+	}
+}
+
 // See ProjectCommandBuilder.BuildPlanCommands.
 func (p *DefaultProjectCommandBuilder) BuildPlanCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
 	if !cmd.IsForSpecificProject() {
 		ctx.Log.Debug("Building plan command for all affected projects")
-		return p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+		projCtxs, err := p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+		if err != nil {
+			return nil, err
+		}
+		return filterProjectsByDir(ctx, projCtxs, cmd.IncludeDirs, cmd.ExcludeDirs), nil
 	}
 	ctx.Log.Debug("Building plan command for specific project with directory: '%v', workspace: '%v', project: '%v'",
 		cmd.RepoRelDir, cmd.Workspace, cmd.ProjectName)
@@ -283,7 +354,11 @@ func (p *DefaultProjectCommandBuilder) BuildPlanCommands(ctx *command.Context, c
 // See ProjectCommandBuilder.BuildApplyCommands.
 func (p *DefaultProjectCommandBuilder) BuildApplyCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
 	if !cmd.IsForSpecificProject() {
-		return p.buildAllProjectCommandsByPlan(ctx, cmd)
+		projCtxs, err := p.buildAllProjectCommandsByPlan(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		return filterProjectsByDir(ctx, projCtxs, cmd.IncludeDirs, cmd.ExcludeDirs), nil
 	}
 	return p.buildProjectCommand(ctx, cmd)
 }
@@ -318,6 +393,165 @@ func (p *DefaultProjectCommandBuilder) BuildStateRmCommands(ctx *command.Context
 	return p.buildProjectCommand(ctx, cmd)
 }
 
+func (p *DefaultProjectCommandBuilder) BuildForceUnlockCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+	if !cmd.IsForSpecificProject() {
+		// a state lock can be stuck even when there's no plan, so use buildAllCommandsByCfg instead buildAllProjectCommandsByPlan.
+		return p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+	}
+	return p.buildProjectCommand(ctx, cmd)
+}
+
+func (p *DefaultProjectCommandBuilder) BuildWorkspacesCommands(ctx *command.Context, cmd *CommentCommand) ([]command.ProjectContext, error) {
+	if !cmd.IsForSpecificProject() {
+		// workspaces are useful to pick one before the first plan, so use buildAllCommandsByCfg instead buildAllProjectCommandsByPlan.
+		return p.buildAllCommandsByCfg(ctx, cmd.CommandName(), cmd.SubName, cmd.Flags, cmd.Verbose)
+	}
+	return p.buildProjectCommand(ctx, cmd)
+}
+
+// See ProjectHelpBuilder.BuildHelpProjects.
+func (p *DefaultProjectCommandBuilder) BuildHelpProjects(ctx *command.Context) ([]valid.MergedProjectCfg, error) {
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, DefaultWorkspace, DefaultRepoRelDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockFn()
+
+	repoDir, err := p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, DefaultWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	hasRepoCfg, repoCfg, repoCfgFile, err := p.loadRepoCfg(ctx, repoDir)
+	if err != nil {
+		if hasRepoCfg {
+			return nil, errors.Wrapf(err, "parsing %s", repoCfgFile)
+		}
+		return nil, err
+	}
+
+	if len(repoCfg.Projects) == 0 {
+		// Without configured projects, the projects are the ones plan would
+		// discover.
+		modifiedFiles, err := p.VCSClient.GetModifiedFiles(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
+		if err != nil {
+			return nil, err
+		}
+		return p.getMergedProjectCfgs(ctx, repoDir, modifiedFiles, repoCfg)
+	}
+	var mergedCfgs []valid.MergedProjectCfg
+	for _, project := range repoCfg.Projects {
+		mergedCfgs = append(mergedCfgs, p.globalCfg().MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), project, repoCfg))
+	}
+	return mergedCfgs, nil
+}
+
+// See ProjectSimulator.SimulateProjects.
+func (p *DefaultProjectCommandBuilder) SimulateProjects(ctx *command.Context, globalCfg valid.GlobalCfg) ([]valid.MergedProjectCfg, error) {
+	simulator := *p
+	simulator.GlobalCfg = globalCfg
+	simulator.ReloadableGlobalCfg = nil
+	return simulator.BuildHelpProjects(ctx)
+}
+
+// filterProjectsByDir drops the project contexts whose directory doesn't match
+// any of the include patterns (if there are any) or matches one of the exclude
+// patterns. Patterns are validated when the comment is parsed.
+func filterProjectsByDir(ctx *command.Context, projCtxs []command.ProjectContext, includeDirs []string, excludeDirs []string) []command.ProjectContext {
+	if len(includeDirs) == 0 && len(excludeDirs) == 0 {
+		return projCtxs
+	}
+	matchesAny := func(patterns []string, dir string) bool {
+		for _, pattern := range patterns {
+			if doublestar.MatchUnvalidated(pattern, dir) {
+				return true
+			}
+		}
+		return false
+	}
+	return slices.DeleteFunc(projCtxs, func(projCtx command.ProjectContext) bool {
+		dir := filepath.ToSlash(filepath.Clean(projCtx.RepoRelDir))
+		if len(includeDirs) > 0 && !matchesAny(includeDirs, dir) {
+			ctx.Log.Debug("skipping project at dir '%s' since it doesn't match --%s %v", dir, includeDirFlagLong, includeDirs)
+			return true
+		}
+		if matchesAny(excludeDirs, dir) {
+			ctx.Log.Debug("skipping project at dir '%s' since it matches --%s %v", dir, excludeDirFlagLong, excludeDirs)
+			return true
+		}
+		return false
+	})
+}
+
+// fetchConfigRepoCfg downloads the repo config of the pull request's repo
+// from the config repo configured for it. hasRepoCfg is false if there's no
+// config repo or it has no config for this repo, in which case the repo's own
+// config is used. repoCfgFile names where the config came from.
+func (p *DefaultProjectCommandBuilder) fetchConfigRepoCfg(ctx *command.Context) (hasRepoCfg bool, repoCfgData []byte, repoCfgFile string, err error) {
+	configRepo := p.globalCfg().RepoConfigRepo(ctx.Pull.BaseRepo.ID())
+	if configRepo == nil {
+		return false, nil, "", nil
+	}
+	repo := models.Repo{
+		FullName: configRepo.Repo,
+		VCSHost:  ctx.Pull.BaseRepo.VCSHost,
+	}
+	if i := strings.LastIndex(configRepo.Repo, "/"); i >= 0 {
+		repo.Owner, repo.Name = configRepo.Repo[:i], configRepo.Repo[i+1:]
+	}
+	path := configRepo.RepoCfgPath(ctx.Pull.BaseRepo.FullName)
+	repoCfgFile = fmt.Sprintf("%s/%s@%s", configRepo.Repo, path, configRepo.Branch)
+	if !p.VCSClient.SupportsSingleFileDownload(repo) {
+		return false, nil, "", fmt.Errorf("cannot read %s: downloading single files isn't supported for %s", repoCfgFile, repo.VCSHost.Type.String())
+	}
+	// The file is read from the config repo's branch, not a pull request.
+	// Some hosts read files at the head commit, so the branch is passed as it.
+	pull := models.PullRequest{
+		BaseRepo:   repo,
+		HeadBranch: configRepo.Branch,
+		BaseBranch: configRepo.Branch,
+		HeadCommit: configRepo.Branch,
+	}
+	hasRepoCfg, repoCfgData, err = p.VCSClient.GetFileContent(ctx.Log, pull, path)
+	if err != nil {
+		return false, nil, "", errors.Wrapf(err, "downloading %s", repoCfgFile)
+	}
+	if !hasRepoCfg && !configRepo.Authoritative {
+		ctx.Log.Debug("no repo config at %s, using the repo's own config", repoCfgFile)
+	}
+	return hasRepoCfg, repoCfgData, repoCfgFile, nil
+}
+
+// loadRepoCfg returns the repo config of the pull request's repo, from its
+// config repo if it has one there and otherwise from the repo config file in
+// repoDir. hasRepoCfg is false if there's neither, and true if there's one
+// even if parsing it failed. repoCfgFile names where the config came from.
+func (p *DefaultProjectCommandBuilder) loadRepoCfg(ctx *command.Context, repoDir string) (hasRepoCfg bool, repoCfg valid.RepoCfg, repoCfgFile string, err error) {
+	repoID := ctx.Pull.BaseRepo.ID()
+	hasRepoCfg, repoCfgData, repoCfgFile, err := p.fetchConfigRepoCfg(ctx)
+	if err != nil {
+		return false, repoCfg, "", err
+	}
+	if hasRepoCfg {
+		repoCfg, err = p.ParserValidator.ParseRepoCfgData(repoCfgData, p.globalCfg(), repoID, ctx.Pull.BaseBranch)
+		return true, repoCfg, repoCfgFile, err
+	}
+	if configRepo := p.globalCfg().RepoConfigRepo(repoID); configRepo != nil && configRepo.Authoritative {
+		ctx.Log.Debug("no repo config at %s and the config repo is authoritative, so the repo has no repo config", repoCfgFile)
+		return false, repoCfg, repoCfgFile, nil
+	}
+
+	repoCfgFile = p.globalCfg().RepoConfigFile(repoID)
+	hasRepoCfg, err = p.ParserValidator.HasRepoCfg(repoDir, repoCfgFile)
+	if err != nil {
+		return false, repoCfg, repoCfgFile, errors.Wrapf(err, "looking for '%s' file in '%s'", repoCfgFile, repoDir)
+	}
+	if !hasRepoCfg {
+		return false, repoCfg, repoCfgFile, nil
+	}
+	repoCfg, err = p.ParserValidator.ParseRepoCfg(repoDir, p.globalCfg(), repoID, ctx.Pull.BaseBranch)
+	return true, repoCfg, repoCfgFile, err
+}
+
 // shouldSkipClone determines whether we should skip cloning for a given context
 func (p *DefaultProjectCommandBuilder) shouldSkipClone(ctx *command.Context, modifiedFiles []string) (bool, error) {
 	// NOTE: We discard this work here and end up doing it again after
@@ -327,16 +561,22 @@ func (p *DefaultProjectCommandBuilder) shouldSkipClone(ctx *command.Context, mod
 	if !p.SkipCloneNoChanges || !p.VCSClient.SupportsSingleFileDownload(ctx.Pull.BaseRepo) {
 		return false, nil
 	}
-	repoCfgFile := p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID())
-	hasRepoCfg, repoCfgData, err := p.VCSClient.GetFileContent(ctx.Log, ctx.Pull, repoCfgFile)
+	hasRepoCfg, repoCfgData, repoCfgFile, err := p.fetchConfigRepoCfg(ctx)
 	if err != nil {
-		return false, errors.Wrapf(err, "downloading %s", repoCfgFile)
+		return false, err
+	}
+	if configRepo := p.globalCfg().RepoConfigRepo(ctx.Pull.BaseRepo.ID()); !hasRepoCfg && (configRepo == nil || !configRepo.Authoritative) {
+		repoCfgFile = p.globalCfg().RepoConfigFile(ctx.Pull.BaseRepo.ID())
+		hasRepoCfg, repoCfgData, err = p.VCSClient.GetFileContent(ctx.Log, ctx.Pull, repoCfgFile)
+		if err != nil {
+			return false, errors.Wrapf(err, "downloading %s", repoCfgFile)
+		}
 	}
 	// We can only skip if we determine that none of the modified files belong to projects configured in a repo config
 	if !hasRepoCfg {
 		return false, nil
 	}
-	repoCfg, err := p.ParserValidator.ParseRepoCfgData(repoCfgData, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
+	repoCfg, err := p.ParserValidator.ParseRepoCfgData(repoCfgData, p.globalCfg(), ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
 	if err != nil {
 		return false, errors.Wrapf(err, "parsing %s", repoCfgFile)
 	}
@@ -371,17 +611,44 @@ func (p *DefaultProjectCommandBuilder) shouldSkipClone(ctx *command.Context, mod
 // autoDiscoverModeEnabled determines whether to use autodiscover
 func (p *DefaultProjectCommandBuilder) autoDiscoverModeEnabled(ctx *command.Context, repoCfg valid.RepoCfg) bool {
 	defaultAutoDiscoverMode := valid.AutoDiscoverMode(p.AutoDiscoverMode)
-	globalAutoDiscover := p.GlobalCfg.RepoAutoDiscoverCfg(ctx.Pull.BaseRepo.ID())
+	globalAutoDiscover := p.globalCfg().RepoAutoDiscoverCfg(ctx.Pull.BaseRepo.ID())
 	if globalAutoDiscover != nil {
 		defaultAutoDiscoverMode = globalAutoDiscover.Mode
 	}
 	return repoCfg.AutoDiscoverEnabled(defaultAutoDiscoverMode)
 }
 
+// cdktfDiscoveryEnabled determines whether to discover the stacks of CDKTF
+// apps. Synthesizing an app runs its code so it can only be enabled by the
+// server-side config, and never for pull requests from untrusted forks.
+func (p *DefaultProjectCommandBuilder) cdktfDiscoveryEnabled(ctx *command.Context) bool {
+	if ctx.ForkRestricted {
+		return false
+	}
+	globalAutoDiscover := p.globalCfg().RepoAutoDiscoverCfg(ctx.Pull.BaseRepo.ID())
+	return globalAutoDiscover != nil && globalAutoDiscover.CDKTF
+}
+
+// inferWorkspaceFromPath infers the workspace of a discovered project from its
+// directory using the autodiscover workspace patterns. Patterns in the repo
+// config take precedence over the ones in the server-side config.
+func (p *DefaultProjectCommandBuilder) inferWorkspaceFromPath(ctx *command.Context, repoCfg valid.RepoCfg, path string) (string, bool) {
+	if repoCfg.AutoDiscover != nil && len(repoCfg.AutoDiscover.WorkspacePatterns) > 0 {
+		return repoCfg.AutoDiscover.InferWorkspace(path)
+	}
+	if globalAutoDiscover := p.globalCfg().RepoAutoDiscoverCfg(ctx.Pull.BaseRepo.ID()); globalAutoDiscover != nil {
+		return globalAutoDiscover.InferWorkspace(path)
+	}
+	return "", false
+}
+
 // getMergedProjectCfgs gets all merged project configs for building commands given a context and a clone repo
 func (p *DefaultProjectCommandBuilder) getMergedProjectCfgs(ctx *command.Context, repoDir string, modifiedFiles []string, repoCfg valid.RepoCfg) ([]valid.MergedProjectCfg, error) {
 	mergedCfgs := make([]valid.MergedProjectCfg, 0)
 
+	discoveryTimer := p.Scope.SubScope("discovery").Timer(metrics.ExecutionTimeMetric).Start()
+	defer discoveryTimer.Stop()
+
 	moduleInfo, err := FindModuleProjects(repoDir, p.AutoDetectModuleFiles)
 	if err != nil {
 		ctx.Log.Warn("error(s) loading project module dependencies: %s", err)
@@ -397,7 +664,7 @@ func (p *DefaultProjectCommandBuilder) getMergedProjectCfgs(ctx *command.Context
 
 		for _, mp := range matchingProjects {
 			ctx.Log.Debug("determining config for project at dir: '%s' workspace: '%s'", mp.Dir, mp.Workspace)
-			mergedCfg := p.GlobalCfg.MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, repoCfg)
+			mergedCfg := p.globalCfg().MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, repoCfg)
 			mergedCfgs = append(mergedCfgs, mergedCfg)
 		}
 	}
@@ -408,6 +675,19 @@ func (p *DefaultProjectCommandBuilder) getMergedProjectCfgs(ctx *command.Context
 		// build a module index for projects that are explicitly included
 		allModifiedProjects := p.ProjectFinder.DetermineProjects(
 			ctx.Log, modifiedFiles, ctx.Pull.BaseRepo.FullName, repoDir, p.AutoplanFileList, moduleInfo)
+		if p.cdktfDiscoveryEnabled(ctx) {
+			cdktfProjects, err := discoverCDKTFProjects(ctx.Log, p.CDKTFSynthesizer, modifiedFiles, ctx.Pull.BaseRepo.FullName, repoDir)
+			if err != nil {
+				return nil, err
+			}
+			// The stacks may already have been discovered if their generated
+			// files are untracked and were included in the modified files.
+			for _, cp := range cdktfProjects {
+				if !slices.ContainsFunc(allModifiedProjects, func(mp models.Project) bool { return mp.Path == cp.Path }) {
+					allModifiedProjects = append(allModifiedProjects, cp)
+				}
+			}
+		}
 		// If a project is already manually configured with the same dir as a discovered project, the manually configured
 		// project should take precedence
 		modifiedProjects := make([]models.Project, 0)
@@ -438,8 +718,14 @@ func (p *DefaultProjectCommandBuilder) getMergedProjectCfgs(ctx *command.Context
 			if err != nil {
 				return nil, errors.Wrapf(err, "Looking for Terraform Cloud workspace from configuration in '%s'", absProjectDir)
 			}
+			if pWorkspace == DefaultWorkspace {
+				if inferred, ok := p.inferWorkspaceFromPath(ctx, repoCfg, mp.Path); ok {
+					ctx.Log.Debug("inferred workspace '%s' for project at dir '%s' from its path", inferred, mp.Path)
+					pWorkspace = inferred
+				}
+			}
 
-			pCfg := p.GlobalCfg.DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp.Path, pWorkspace)
+			pCfg := p.globalCfg().DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp.Path, pWorkspace)
 			mergedCfgs = append(mergedCfgs, pCfg)
 		}
 	}
@@ -493,26 +779,22 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 		modifiedFiles = append(modifiedFiles, untrackedFiles...)
 	}
 
-	// Parse config file if it exists.
-	repoCfgFile := p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID())
-	hasRepoCfg, err := p.ParserValidator.HasRepoCfg(repoDir, repoCfgFile)
+	// Parse config file if it exists. If there's a repo cfg with projects
+	// then we'll use it to figure out which projects should be planed.
+	hasRepoCfg, repoCfg, repoCfgFile, err := p.loadRepoCfg(ctx, repoDir)
 	if err != nil {
-		return nil, errors.Wrapf(err, "looking for '%s' file in '%s'", repoCfgFile, repoDir)
+		if hasRepoCfg {
+			return nil, errors.Wrapf(err, "parsing %s", repoCfgFile)
+		}
+		return nil, err
 	}
 
 	var projCtxs []command.ProjectContext
-	var repoCfg valid.RepoCfg
 
 	if hasRepoCfg {
-		// If there's a repo cfg with projects then we'll use it to figure out which projects
-		// should be planed.
-		repoCfg, err = p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
-		if err != nil {
-			return nil, errors.Wrapf(err, "parsing %s", repoCfgFile)
-		}
 		ctx.Log.Info("successfully parsed %s file", repoCfgFile)
 	} else {
-		ctx.Log.Info("repo config file %s is absent, using global defaults", repoCfg)
+		ctx.Log.Info("repo config file %s is absent, using global defaults", repoCfgFile)
 	}
 
 	mergedProjectCfgs, err := p.getMergedProjectCfgs(ctx, repoDir, modifiedFiles, repoCfg)
@@ -543,7 +825,7 @@ func (p *DefaultProjectCommandBuilder) buildAllCommandsByCfg(ctx *command.Contex
 				ctx,
 				cmdName,
 				subCmdName,
-				mergedProjectCfg,
+				p.forkPRProjectCfg(ctx, mergedProjectCfg),
 				commentFlags,
 				repoDir,
 				automerge,
@@ -618,29 +900,29 @@ func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *command.Cont
 
 	if p.RestrictFileList {
 		ctx.Log.Debug("'restrict-file-list' option is set, checking modified files")
-		modifiedFiles, err := p.VCSClient.GetModifiedFiles(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
-		if err != nil {
-			return nil, err
-		}
-
+		var untrackedFiles []string
 		if p.IncludeGitUntrackedFiles {
 			ctx.Log.Debug(("'include-git-untracked-files' option is set, getting untracked files"))
-			untrackedFiles, err := p.WorkingDir.GetGitUntrackedFiles(ctx.Log, ctx.HeadRepo, ctx.Pull, workspace)
+			untrackedFiles, err = p.WorkingDir.GetGitUntrackedFiles(ctx.Log, ctx.HeadRepo, ctx.Pull, workspace)
 			if err != nil {
 				return nil, err
 			}
-			modifiedFiles = append(modifiedFiles, untrackedFiles...)
 		}
 
-		ctx.Log.Debug("%d files were modified in this pull request. Modified files: %v", len(modifiedFiles), modifiedFiles)
-
 		if cmd.RepoRelDir != "" {
 			ctx.Log.Debug("Command directory specified: %s", cmd.RepoRelDir)
-			foundDir := false
+			inDir := func(f string) bool { return filepath.Dir(f) == cmd.RepoRelDir }
 
-			for _, f := range modifiedFiles {
-				if filepath.Dir(f) == cmd.RepoRelDir {
-					foundDir = true
+			// The modified files are listed a page at a time so that we can
+			// stop as soon as one of them is in the dir.
+			foundDir := slices.ContainsFunc(untrackedFiles, inDir)
+			if !foundDir {
+				err = vcs.ListModifiedFiles(p.VCSClient, ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, func(files []string) bool {
+					foundDir = slices.ContainsFunc(files, inDir)
+					return !foundDir
+				})
+				if err != nil {
+					return nil, err
 				}
 			}
 
@@ -654,25 +936,37 @@ func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *command.Cont
 			var notFoundFiles = []string{}
 			var repoConfig valid.RepoCfg
 
-			repoConfig, err = p.ParserValidator.ParseRepoCfg(defaultRepoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
+			var hasRepoCfg bool
+			var repoCfgFile string
+			hasRepoCfg, repoConfig, repoCfgFile, err = p.loadRepoCfg(ctx, defaultRepoDir)
 			if err != nil {
 				return pcc, err
 			}
+			if !hasRepoCfg {
+				return pcc, fmt.Errorf("cannot specify a project name unless an %s file exists to configure projects", repoCfgFile)
+			}
 			repoCfgProjects := repoConfig.FindProjectsByName(cmd.ProjectName)
 
-			for _, f := range modifiedFiles {
-				foundDir := false
+			checkFiles := func(files []string) bool {
+				for _, f := range files {
+					foundDir := false
 
-				for _, p := range repoCfgProjects {
-					if filepath.Dir(f) == p.Dir {
-						foundDir = true
+					for _, p := range repoCfgProjects {
+						if filepath.Dir(f) == p.Dir {
+							foundDir = true
+						}
 					}
-				}
 
-				if !foundDir {
-					notFoundFiles = append(notFoundFiles, filepath.Dir(f))
+					if !foundDir {
+						notFoundFiles = append(notFoundFiles, filepath.Dir(f))
+					}
 				}
+				return true
+			}
+			if err = vcs.ListModifiedFiles(p.VCSClient, ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, checkFiles); err != nil {
+				return nil, err
 			}
+			checkFiles(untrackedFiles)
 
 			if len(notFoundFiles) > 0 {
 				return pcc, fmt.Errorf("the following directories are present in the pull request but not in the requested project:\n%s", strings.Join(notFoundFiles, "\n"))
@@ -692,12 +986,17 @@ func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *command.Cont
 	if cmd.RepoRelDir != "" {
 		repoRelDir = cmd.RepoRelDir
 	}
+	// A project is only narrowed down to a workspace if one was set.
+	if cmd.ProjectName != "" {
+		workspace = cmd.Workspace
+	}
 
 	return p.buildProjectCommandCtx(
 		ctx,
 		command.Plan,
 		"",
 		cmd.ProjectName,
+		cmd.Environment,
 		cmd.Flags,
 		defaultRepoDir,
 		repoRelDir,
@@ -707,12 +1006,12 @@ func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *command.Cont
 }
 
 // getCfg returns the atlantis.yaml config (if it exists) for this project. If
-// there is no config, then projectCfg and repoCfg will be nil.
-func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName string, dir string, workspace string, repoDir string) (projectsCfg []valid.Project, repoCfg *valid.RepoCfg, err error) {
-	repoCfgFile := p.GlobalCfg.RepoConfigFile(ctx.Pull.BaseRepo.ID())
-	hasRepoCfg, err := p.ParserValidator.HasRepoCfg(repoDir, repoCfgFile)
+// there is no config, then projectCfg and repoCfg will be nil. If environment
+// is set, only the projects for that environment of projectName are returned,
+// and if workspace is set only the projects of projectName in that workspace.
+func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName string, environment string, dir string, workspace string, repoDir string) (projectsCfg []valid.Project, repoCfg *valid.RepoCfg, err error) {
+	hasRepoCfg, repoConfig, repoCfgFile, err := p.loadRepoCfg(ctx, repoDir)
 	if err != nil {
-		err = errors.Wrapf(err, "looking for '%s' file in '%s'", repoCfgFile, repoDir)
 		return
 	}
 	if !hasRepoCfg {
@@ -722,12 +1021,6 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 		}
 		return
 	}
-
-	var repoConfig valid.RepoCfg
-	repoConfig, err = p.ParserValidator.ParseRepoCfg(repoDir, p.GlobalCfg, ctx.Pull.BaseRepo.ID(), ctx.Pull.BaseBranch)
-	if err != nil {
-		return
-	}
 	repoCfg = &repoConfig
 
 	// If they've specified a project by name we look it up. Otherwise we
@@ -736,8 +1029,12 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 		if p.EnableRegExpCmd {
 			projectsCfg = repoCfg.FindProjectsByName(projectName)
 		} else {
-			if p := repoCfg.FindProjectByName(projectName); p != nil {
-				projectsCfg = append(projectsCfg, *p)
+			// A project with environments is expanded into one project per
+			// environment, all sharing the same name.
+			for _, proj := range repoCfg.Projects {
+				if proj.Name != nil && *proj.Name == projectName {
+					projectsCfg = append(projectsCfg, proj)
+				}
 			}
 		}
 		if len(projectsCfg) == 0 {
@@ -748,6 +1045,25 @@ func (p *DefaultProjectCommandBuilder) getCfg(ctx *command.Context, projectName
 			}
 			return
 		}
+		if environment != "" {
+			projectsCfg = slices.DeleteFunc(projectsCfg, func(proj valid.Project) bool {
+				return proj.Environment != environment
+			})
+			if len(projectsCfg) == 0 {
+				err = fmt.Errorf("no environment '%s' is defined for project '%s' in '%s'", environment, projectName, repoCfgFile)
+				return
+			}
+		}
+		// The workspace only narrows down a name shared by projects in
+		// several workspaces.
+		if workspace != "" && slices.ContainsFunc(projectsCfg, func(proj valid.Project) bool { return proj.Workspace != projectsCfg[0].Workspace }) {
+			projectsCfg = slices.DeleteFunc(projectsCfg, func(proj valid.Project) bool {
+				return proj.Workspace != workspace
+			})
+			if len(projectsCfg) == 0 {
+				err = fmt.Errorf("no project with name '%s' is defined for workspace '%s' in '%s'", projectName, workspace, repoCfgFile)
+			}
+		}
 		return
 	}
 
@@ -791,7 +1107,7 @@ func (p *DefaultProjectCommandBuilder) buildAllProjectCommandsByPlan(ctx *comman
 			return nil, err
 		}
 		defer unlockFn()
-		commentCmds, err := p.buildProjectCommandCtx(ctx, commentCmd.CommandName(), commentCmd.SubName, plan.ProjectName, commentCmd.Flags, defaultRepoDir, plan.RepoRelDir, plan.Workspace, commentCmd.Verbose)
+		commentCmds, err := p.buildProjectCommandCtx(ctx, commentCmd.CommandName(), commentCmd.SubName, plan.ProjectName, "", commentCmd.Flags, defaultRepoDir, plan.RepoRelDir, plan.Workspace, commentCmd.Verbose)
 		if err != nil {
 			return nil, errors.Wrapf(err, "building command for dir '%s'", plan.RepoRelDir)
 		}
@@ -833,12 +1149,17 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommand(ctx *command.Context,
 	if cmd.RepoRelDir != "" {
 		repoRelDir = cmd.RepoRelDir
 	}
+	// A project is only narrowed down to a workspace if one was set.
+	if cmd.ProjectName != "" {
+		workspace = cmd.Workspace
+	}
 
 	return p.buildProjectCommandCtx(
 		ctx,
 		cmd.Name,
 		cmd.SubName,
 		cmd.ProjectName,
+		cmd.Environment,
 		cmd.Flags,
 		repoDir,
 		repoRelDir,
@@ -853,13 +1174,14 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 	cmd command.Name,
 	subCmd string,
 	projectName string,
+	environment string,
 	commentFlags []string,
 	repoDir string,
 	repoRelDir string,
 	workspace string,
 	verbose bool) ([]command.ProjectContext, error) {
 
-	matchingProjects, repoCfgPtr, err := p.getCfg(ctx, projectName, repoRelDir, workspace, repoDir)
+	matchingProjects, repoCfgPtr, err := p.getCfg(ctx, projectName, environment, repoRelDir, workspace, repoDir)
 	if err != nil {
 		return []command.ProjectContext{}, err
 	}
@@ -890,14 +1212,14 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 		workspace = projCfg.Workspace
 		for _, mp := range matchingProjects {
 			ctx.Log.Debug("Merging config for project at dir: '%s' workspace: '%s'", mp.Dir, mp.Workspace)
-			projCfg = p.GlobalCfg.MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, *repoCfgPtr)
+			projCfg = p.globalCfg().MergeProjectCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), mp, *repoCfgPtr)
 
 			projCtxs = append(projCtxs,
 				p.ProjectCommandContextBuilder.BuildProjectContext(
 					ctx,
 					cmd,
 					subCmd,
-					projCfg,
+					p.forkPRProjectCfg(ctx, projCfg),
 					commentFlags,
 					repoDir,
 					automerge,
@@ -915,13 +1237,13 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 			return []command.ProjectContext{}, nil
 		}
 
-		projCfg = p.GlobalCfg.DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), repoRelDir, workspace)
+		projCfg = p.globalCfg().DefaultProjCfg(ctx.Log, ctx.Pull.BaseRepo.ID(), repoRelDir, workspace)
 		projCtxs = append(projCtxs,
 			p.ProjectCommandContextBuilder.BuildProjectContext(
 				ctx,
 				cmd,
 				subCmd,
-				projCfg,
+				p.forkPRProjectCfg(ctx, projCfg),
 				commentFlags,
 				repoDir,
 				automerge,
@@ -964,6 +1286,20 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *command.Conte
 	return projCtxs, nil
 }
 
+// forkPRProjectCfg replaces the workflow of projCfg with the fork PR workflow
+// and runs it with Terraform if ctx is a restricted fork pull request, so that
+// none of the custom steps or programs the fork could have selected are run.
+// The fork can't get cloud credentials either since it could choose the role
+// they're minted for.
+func (p *DefaultProjectCommandBuilder) forkPRProjectCfg(ctx *command.Context, projCfg valid.MergedProjectCfg) valid.MergedProjectCfg {
+	if ctx.ForkRestricted {
+		projCfg.Workflow = p.globalCfg().ForkPRWorkflow(ctx.Pull.BaseRepo.ID())
+		projCfg.Engine = valid.TerraformEngine
+		projCfg.CloudCredentials = nil
+	}
+	return projCfg
+}
+
 // validateWorkspaceAllowed returns an error if repoCfg defines projects in
 // repoRelDir but none of them use workspace. We want this to be an error
 // because if users have gone to the trouble of defining projects in repoRelDir
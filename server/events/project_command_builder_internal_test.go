@@ -65,6 +65,7 @@ workflows:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   false,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -122,6 +123,7 @@ projects:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -183,6 +185,7 @@ projects:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -252,6 +255,7 @@ projects:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -408,6 +412,7 @@ workflows:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -471,6 +476,7 @@ projects:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -537,6 +543,7 @@ workflows:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -589,6 +596,7 @@ projects:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   false,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -687,7 +695,7 @@ projects:
 						PullRequestStatus: models.PullReqStatus{
 							Mergeable: true,
 						},
-					}, cmd, "", "", []string{"flag"}, tmp, "project1", "myworkspace", true)
+					}, cmd, "", "", "", []string{"flag"}, tmp, "project1", "myworkspace", true)
 
 					if c.expErr != "" {
 						ErrEquals(t, c.expErr, err)
@@ -803,6 +811,7 @@ projects:
 				ApprovePoliciesCmd: "atlantis approve_policies -p myproject_1",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -904,7 +913,7 @@ projects:
 						PullRequestStatus: models.PullReqStatus{
 							Mergeable: true,
 						},
-					}, cmd, "", "myproject_[1-2]", []string{"flag"}, tmp, "project1", "myworkspace", true)
+					}, cmd, "", "myproject_[1-2]", "", []string{"flag"}, tmp, "project1", "myworkspace", true)
 
 					if c.expErr != "" {
 						ErrEquals(t, c.expErr, err)
@@ -986,6 +995,7 @@ repos:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   false,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -1048,6 +1058,7 @@ workflows:
 				ApprovePoliciesCmd: "atlantis approve_policies -d project1 -w myworkspace",
 				BaseRepo:           baseRepo,
 				EscapedCommentArgs: []string{`\f\l\a\g`},
+				CommentArgs:        []string{"flag"},
 				AutomergeEnabled:   true,
 				AutoplanEnabled:    true,
 				HeadRepo:           models.Repo{},
@@ -1150,7 +1161,7 @@ workflows:
 					PullRequestStatus: models.PullReqStatus{
 						Mergeable: true,
 					},
-				}, command.Plan, "", "", []string{"flag"}, tmp, "project1", "myworkspace", true)
+				}, command.Plan, "", "", "", []string{"flag"}, tmp, "project1", "myworkspace", true)
 
 				if c.expErr != "" {
 					ErrEquals(t, c.expErr, err)
@@ -1302,7 +1313,7 @@ projects:
 						PullRequestStatus: models.PullReqStatus{
 							Mergeable: true,
 						},
-					}, cmd, "", "", []string{}, tmp, "project1", "myworkspace", true)
+					}, cmd, "", "", "", []string{}, tmp, "project1", "myworkspace", true)
 					Equals(t, c.expLen, len(ctxs))
 					Ok(t, err)
 				})
@@ -1459,3 +1470,92 @@ func mustVersion(v string) *version.Version {
 	}
 	return vers
 }
+
+func TestFilterProjectsByDir(t *testing.T) {
+	ctx := &command.Context{Log: logging.NewNoopLogger(t)}
+	dirs := []string{".", "envs/prod/app", "envs/staging/app", "modules/vpc"}
+	cases := map[string]struct {
+		include []string
+		exclude []string
+		exp     []string
+	}{
+		"no filters": {
+			exp: dirs,
+		},
+		"include only": {
+			include: []string{"envs/**"},
+			exp:     []string{"envs/prod/app", "envs/staging/app"},
+		},
+		"exclude only": {
+			exclude: []string{"modules/**", "."},
+			exp:     []string{"envs/prod/app", "envs/staging/app"},
+		},
+		"include and exclude": {
+			include: []string{"envs/*/app"},
+			exclude: []string{"envs/staging/*"},
+			exp:     []string{"envs/prod/app"},
+		},
+		"nothing matches": {
+			include: []string{"other/**"},
+			exp:     []string{},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var projCtxs []command.ProjectContext
+			for _, d := range dirs {
+				projCtxs = append(projCtxs, command.ProjectContext{RepoRelDir: d})
+			}
+			filtered := filterProjectsByDir(ctx, projCtxs, c.include, c.exclude)
+			actDirs := []string{}
+			for _, p := range filtered {
+				actDirs = append(actDirs, p.RepoRelDir)
+			}
+			Equals(t, c.exp, actDirs)
+		})
+	}
+}
+
+func TestAutoplanTriggered(t *testing.T) {
+	opened := models.AutoplanTrigger{Event: models.OpenedPullEvent}
+	updated := models.AutoplanTrigger{Event: models.UpdatedPullEvent}
+	ready := models.AutoplanTrigger{Event: models.ReadyForReviewPullEvent}
+	labeled := models.AutoplanTrigger{Event: models.LabeledPullEvent, Label: "plan"}
+	otherLabel := models.AutoplanTrigger{Event: models.LabeledPullEvent, Label: "other"}
+
+	cases := map[string]struct {
+		mode   string
+		labels []string
+		exp    map[models.AutoplanTrigger]bool
+	}{
+		"default": {
+			mode: "",
+			exp:  map[models.AutoplanTrigger]bool{opened: true, updated: true, ready: true, labeled: false},
+		},
+		"changed": {
+			mode: valid.AutoplanModeChanged,
+			exp:  map[models.AutoplanTrigger]bool{opened: true, updated: true, ready: true, labeled: false},
+		},
+		"changed_only_with_lockfile": {
+			mode: valid.AutoplanModeChangedOnlyWithLockfile,
+			exp:  map[models.AutoplanTrigger]bool{opened: true, updated: true, ready: true, labeled: false},
+		},
+		"ready_for_review": {
+			mode: valid.AutoplanModeReadyForReview,
+			exp:  map[models.AutoplanTrigger]bool{opened: true, updated: false, ready: true, labeled: false},
+		},
+		"labeled": {
+			mode:   valid.AutoplanModeLabeled,
+			labels: []string{"plan"},
+			exp:    map[models.AutoplanTrigger]bool{opened: false, updated: false, ready: false, labeled: true, otherLabel: false},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			projCtx := command.ProjectContext{AutoplanMode: c.mode, AutoplanLabels: c.labels}
+			for trigger, exp := range c.exp {
+				Equals(t, exp, autoplanTriggered(projCtx, trigger))
+			}
+		})
+	}
+}
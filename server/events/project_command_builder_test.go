@@ -1,6 +1,7 @@
 package events_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -17,6 +18,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
@@ -849,6 +851,77 @@ projects:
 	}
 }
 
+// pagedVCSClient lists the modified files of a pull request in pages.
+type pagedVCSClient struct {
+	vcs.Client
+	pages [][]string
+	// listed is the number of pages that were listed.
+	listed int
+}
+
+func (c *pagedVCSClient) ListModifiedFiles(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, f func(files []string) bool) error {
+	for _, page := range c.pages {
+		c.listed++
+		if !f(page) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// The modified files should stop being listed once one is found in the dir of
+// the plan.
+func TestDefaultProjectCommandBuilder_BuildSinglePlanApplyCommand_WithRestrictFileListStopsListing(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"directory-1": map[string]interface{}{"main.tf": nil},
+		"directory-2": map[string]interface{}{"main.tf": nil},
+	})
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(tmpDir, nil)
+	When(workingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(tmpDir, nil)
+	vcsClient := &pagedVCSClient{
+		Client: vcsmocks.NewMockClient(),
+		pages:  [][]string{{"directory-2/main.tf"}, {"directory-1/main.tf"}, {"directory-3/main.tf"}},
+	}
+
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		vcsClient,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{AllowAllRepoSettings: true}),
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		false,
+		false,
+		false,
+		false,
+		false,
+		"",
+		"**/*.tf,**/*.tfvars,**/*.tfvars.json,**/terragrunt.hcl,**/.terraform.lock.hcl",
+		true,
+		false,
+		false,
+		"",
+		scope,
+		tfclientmocks.NewMockClient(),
+	)
+
+	actCtxs, err := builder.BuildPlanCommands(&command.Context{
+		Log:   logger,
+		Scope: scope,
+	}, &events.CommentCommand{Name: command.Plan, RepoRelDir: "directory-1", Workspace: "default"})
+	Ok(t, err)
+	Equals(t, 1, len(actCtxs))
+	Equals(t, 2, vcsClient.listed)
+}
+
 func TestDefaultProjectCommandBuilder_BuildPlanCommands(t *testing.T) {
 	// expCtxFields define the ctx fields we're going to assert on.
 	// Since we're focused on autoplanning here, we don't validate all the
@@ -1355,6 +1428,188 @@ func TestDefaultProjectCommandBuilder_BuildMultiApply(t *testing.T) {
 	Equals(t, "workspace2", ctxs[3].Workspace)
 }
 
+// Test that the environments of a project are applied separately.
+func TestDefaultProjectCommandBuilder_BuildApplyEnvironments(t *testing.T) {
+	RegisterMockTestingT(t)
+	repoCfg := `
+version: 3
+projects:
+- name: app
+  dir: .
+  environments:
+  - name: staging
+  - name: production
+    workspace: prod
+`
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"default": map[string]interface{}{
+			"main.tf":       nil,
+			"atlantis.yaml": repoCfg,
+		},
+		"staging": map[string]interface{}{
+			"main.tf":            nil,
+			"app-staging.tfplan": nil,
+		},
+		"prod": map[string]interface{}{
+			"main.tf":         nil,
+			"app-prod.tfplan": nil,
+		},
+	})
+	runCmd(t, filepath.Join(tmpDir, "default"), "git", "init")
+	runCmd(t, filepath.Join(tmpDir, "staging"), "git", "init")
+	runCmd(t, filepath.Join(tmpDir, "prod"), "git", "init")
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmpDir, nil)
+	When(workingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(filepath.Join(tmpDir, "default"), nil)
+
+	logger := logging.NewNoopLogger(t)
+	userConfig := defaultUserConfig
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		nil,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		userConfig.SkipCloneNoChanges,
+		userConfig.EnableRegExpCmd,
+		userConfig.EnableAutoMerge,
+		userConfig.EnableParallelPlan,
+		userConfig.EnableParallelApply,
+		userConfig.AutoDetectModuleFiles,
+		userConfig.AutoplanFileList,
+		userConfig.RestrictFileList,
+		userConfig.SilenceNoProjects,
+		userConfig.IncludeGitUntrackedFiles,
+		userConfig.AutoDiscoverMode,
+		scope,
+		tfclientmocks.NewMockClient(),
+	)
+	cmdCtx := &command.Context{
+		Log:   logger,
+		Scope: scope,
+	}
+
+	// Applying all plans applies each environment once.
+	ctxs, err := builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply})
+	Ok(t, err)
+	Equals(t, 2, len(ctxs))
+	environments := map[string]string{}
+	for _, ctx := range ctxs {
+		environments[ctx.Workspace] = ctx.Environment
+	}
+	Equals(t, map[string]string{"prod": "production", "staging": "staging"}, environments)
+
+	// A single environment can be targeted.
+	ctxs, err = builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply, ProjectName: "app", Environment: "production"})
+	Ok(t, err)
+	Equals(t, 1, len(ctxs))
+	Equals(t, "prod", ctxs[0].Workspace)
+	Equals(t, "atlantis apply -p app -e production", ctxs[0].ApplyCmd)
+	Equals(t, "atlantis plan -p app -e production", ctxs[0].RePlanCmd)
+
+	_, err = builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply, ProjectName: "app", Environment: "dev"})
+	ErrEquals(t, "no environment 'dev' is defined for project 'app' in 'atlantis.yaml'", err)
+}
+
+func TestDefaultProjectCommandBuilder_BuildApplyWorkspaceQualified(t *testing.T) {
+	RegisterMockTestingT(t)
+	repoCfg := `
+version: 3
+projects:
+- name: app
+  dir: .
+  workspace: staging
+- name: app
+  dir: .
+  workspace: prod
+`
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"default": map[string]interface{}{
+			"main.tf":       nil,
+			"atlantis.yaml": repoCfg,
+		},
+		"staging": map[string]interface{}{
+			"main.tf":            nil,
+			"app-staging.tfplan": nil,
+		},
+		"prod": map[string]interface{}{
+			"main.tf":         nil,
+			"app-prod.tfplan": nil,
+		},
+	})
+	runCmd(t, filepath.Join(tmpDir, "default"), "git", "init")
+	runCmd(t, filepath.Join(tmpDir, "staging"), "git", "init")
+	runCmd(t, filepath.Join(tmpDir, "prod"), "git", "init")
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetPullDir(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn(tmpDir, nil)
+	When(workingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(filepath.Join(tmpDir, "default"), nil)
+
+	logger := logging.NewNoopLogger(t)
+	userConfig := defaultUserConfig
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		nil,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{}),
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		userConfig.SkipCloneNoChanges,
+		userConfig.EnableRegExpCmd,
+		userConfig.EnableAutoMerge,
+		userConfig.EnableParallelPlan,
+		userConfig.EnableParallelApply,
+		userConfig.AutoDetectModuleFiles,
+		userConfig.AutoplanFileList,
+		userConfig.RestrictFileList,
+		userConfig.SilenceNoProjects,
+		userConfig.IncludeGitUntrackedFiles,
+		userConfig.AutoDiscoverMode,
+		scope,
+		tfclientmocks.NewMockClient(),
+	)
+	cmdCtx := &command.Context{
+		Log:   logger,
+		Scope: scope,
+	}
+
+	// Applying all plans applies each workspace once.
+	ctxs, err := builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply})
+	Ok(t, err)
+	Equals(t, 2, len(ctxs))
+	for _, ctx := range ctxs {
+		Assert(t, ctx.WorkspaceQualified, "expected %s to be workspace qualified", ctx.Workspace)
+	}
+
+	// The project name without a workspace targets every workspace.
+	ctxs, err = builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply, ProjectName: "app"})
+	Ok(t, err)
+	Equals(t, 2, len(ctxs))
+
+	// A single workspace can be targeted.
+	ctxs, err = builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply, ProjectName: "app", Workspace: "prod"})
+	Ok(t, err)
+	Equals(t, 1, len(ctxs))
+	Equals(t, "prod", ctxs[0].Workspace)
+	Equals(t, "atlantis apply -p app -w prod", ctxs[0].ApplyCmd)
+	Equals(t, "atlantis plan -p app -w prod", ctxs[0].RePlanCmd)
+
+	_, err = builder.BuildApplyCommands(cmdCtx, &events.CommentCommand{Name: command.Apply, ProjectName: "app", Workspace: "dev"})
+	ErrEquals(t, "no project with name 'app' is defined for workspace 'dev' in 'atlantis.yaml'", err)
+}
+
 // Test that if a directory has a list of workspaces configured then we don't
 // allow plans for other workspace names.
 func TestDefaultProjectCommandBuilder_WrongWorkspaceName(t *testing.T) {
@@ -1809,6 +2064,263 @@ projects:
 	}
 }
 
+// Test that the repo config is read from the config repo instead of the repo
+// when the server-side config sets one, and that repos without one there only
+// fall back to their own if the config repo isn't authoritative.
+func TestDefaultProjectCommandBuilder_BuildAutoplanCommands_ConfigRepo(t *testing.T) {
+	cases := []struct {
+		description   string
+		inConfigRepo  bool
+		authoritative bool
+		expProjects   []string
+		expDirs       []string
+	}{
+		{
+			description:  "config in config repo",
+			inConfigRepo: true,
+			expProjects:  []string{"from-config-repo"},
+			expDirs:      []string{"dir1"},
+		},
+		{
+			description: "no config in config repo",
+			expProjects: []string{"in-repo"},
+			expDirs:     []string{"dir2"},
+		},
+		{
+			description:   "no config in authoritative config repo",
+			authoritative: true,
+			expProjects:   []string{"", ""},
+			expDirs:       []string{"dir1", "dir2"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir := DirStructure(t, map[string]interface{}{
+				"dir1": map[string]interface{}{
+					"main.tf": nil,
+				},
+				"dir2": map[string]interface{}{
+					"main.tf": nil,
+				},
+				valid.DefaultAtlantisFile: nil,
+			})
+			err := os.WriteFile(filepath.Join(tmpDir, valid.DefaultAtlantisFile), []byte(`
+version: 3
+projects:
+- name: in-repo
+  dir: dir2`), 0600)
+			Ok(t, err)
+
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](),
+				Any[models.PullRequest]())).ThenReturn([]string{"dir1/main.tf", "dir2/main.tf"}, nil)
+			When(vcsClient.SupportsSingleFileDownload(Any[models.Repo]())).ThenReturn(true)
+			var configRepoCfg []byte
+			if c.inConfigRepo {
+				configRepoCfg = []byte(`
+version: 3
+projects:
+- name: from-config-repo
+  dir: dir1`)
+			}
+			When(vcsClient.GetFileContent(Any[logging.SimpleLogging](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(c.inConfigRepo, configRepoCfg, nil)
+
+			globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+			globalCfg.Repos[0].ConfigRepo = &valid.ConfigRepo{
+				Repo:          "owner/atlantis-config",
+				Branch:        "main",
+				Path:          valid.DefaultConfigRepoPath,
+				Authoritative: c.authoritative,
+			}
+			logger := logging.NewNoopLogger(t)
+			scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			userConfig := defaultUserConfig
+
+			builder := events.NewProjectCommandBuilder(
+				false,
+				&config.ParserValidator{},
+				&events.DefaultProjectFinder{},
+				vcsClient,
+				workingDir,
+				events.NewDefaultWorkingDirLocker(),
+				globalCfg,
+				&events.DefaultPendingPlanFinder{},
+				&events.CommentParser{ExecutableName: "atlantis"},
+				userConfig.SkipCloneNoChanges,
+				userConfig.EnableRegExpCmd,
+				userConfig.EnableAutoMerge,
+				userConfig.EnableParallelPlan,
+				userConfig.EnableParallelApply,
+				userConfig.AutoDetectModuleFiles,
+				userConfig.AutoplanFileList,
+				userConfig.RestrictFileList,
+				userConfig.SilenceNoProjects,
+				userConfig.IncludeGitUntrackedFiles,
+				userConfig.AutoDiscoverMode,
+				scope,
+				tfclientmocks.NewMockClient(),
+			)
+
+			ctxs, err := builder.BuildAutoplanCommands(&command.Context{
+				Pull: models.PullRequest{
+					BaseRepo: models.Repo{
+						FullName: "owner/repo",
+						VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+					},
+					BaseBranch: "main",
+				},
+				PullRequestStatus: models.PullReqStatus{
+					Mergeable: true,
+				},
+				Log:   logger,
+				Scope: scope,
+			})
+			Ok(t, err)
+			var projects, dirs []string
+			for _, ctx := range ctxs {
+				projects = append(projects, ctx.ProjectName)
+				dirs = append(dirs, ctx.RepoRelDir)
+			}
+			Equals(t, c.expProjects, projects)
+			Equals(t, c.expDirs, dirs)
+
+			_, pull, path := vcsClient.VerifyWasCalledOnce().GetFileContent(Any[logging.SimpleLogging](), Any[models.PullRequest](),
+				Any[string]()).GetCapturedArguments()
+			Equals(t, "owner/atlantis-config", pull.BaseRepo.FullName)
+			Equals(t, "atlantis-config", pull.BaseRepo.Name)
+			Equals(t, "main", pull.HeadBranch)
+			Equals(t, "main", pull.HeadCommit)
+			Equals(t, "owner/repo/atlantis.yaml", path)
+		})
+	}
+}
+
+func TestDefaultProjectCommandBuilder_BuildHelpProjects(t *testing.T) {
+	cases := []struct {
+		description  string
+		atlantisYAML string
+		expProjects  []string
+		// expSimulated are the projects under a candidate config that
+		// requires mergeable and allows no overrides, or expSimulatedErr.
+		expSimulated    []string
+		expSimulatedErr string
+	}{
+		{
+			description: "configured projects",
+			atlantisYAML: `
+version: 3
+projects:
+- name: staging
+  dir: dir1
+  apply_requirements: [approved]
+- dir: dir2
+  workspace: prod`,
+			// All configured projects are described, not only the modified
+			// ones.
+			expProjects:     []string{"staging/dir1/default/approved", "/dir2/prod/"},
+			expSimulatedErr: "repo config not allowed to set 'apply_requirements' key",
+		},
+		{
+			description:  "no repo config",
+			expProjects:  []string{"/dir1/default/"},
+			expSimulated: []string{"/dir1/default/mergeable"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir := DirStructure(t, map[string]interface{}{
+				"dir1": map[string]interface{}{
+					"main.tf": nil,
+				},
+				"dir2": map[string]interface{}{
+					"main.tf": nil,
+				},
+			})
+			if c.atlantisYAML != "" {
+				Ok(t, os.WriteFile(filepath.Join(tmpDir, valid.DefaultAtlantisFile), []byte(c.atlantisYAML), 0600))
+			}
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](),
+				Any[models.PullRequest]())).ThenReturn([]string{"dir1/main.tf"}, nil)
+
+			globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+			globalCfg.Repos[0].AllowedOverrides = []string{valid.ApplyRequirementsKey}
+			logger := logging.NewNoopLogger(t)
+			scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			userConfig := defaultUserConfig
+
+			builder := events.NewProjectCommandBuilder(
+				false,
+				&config.ParserValidator{},
+				&events.DefaultProjectFinder{},
+				vcsClient,
+				workingDir,
+				events.NewDefaultWorkingDirLocker(),
+				globalCfg,
+				&events.DefaultPendingPlanFinder{},
+				&events.CommentParser{ExecutableName: "atlantis"},
+				userConfig.SkipCloneNoChanges,
+				userConfig.EnableRegExpCmd,
+				userConfig.EnableAutoMerge,
+				userConfig.EnableParallelPlan,
+				userConfig.EnableParallelApply,
+				userConfig.AutoDetectModuleFiles,
+				userConfig.AutoplanFileList,
+				userConfig.RestrictFileList,
+				userConfig.SilenceNoProjects,
+				userConfig.IncludeGitUntrackedFiles,
+				userConfig.AutoDiscoverMode,
+				scope,
+				tfclientmocks.NewMockClient(),
+			)
+
+			ctx := &command.Context{
+				Pull: models.PullRequest{
+					BaseRepo: models.Repo{
+						FullName: "owner/repo",
+						VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+					},
+				},
+				Log:   logger,
+				Scope: scope,
+			}
+			describe := func(projects []valid.MergedProjectCfg) []string {
+				var got []string
+				for _, p := range projects {
+					got = append(got, fmt.Sprintf("%s/%s/%s/%s", p.Name, p.RepoRelDir, p.Workspace, strings.Join(p.ApplyRequirements, ",")))
+				}
+				return got
+			}
+			projects, err := builder.BuildHelpProjects(ctx)
+			Ok(t, err)
+			Equals(t, c.expProjects, describe(projects))
+
+			candidateCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+			candidateCfg.Repos[0].ApplyRequirements = []string{valid.MergeableCommandReq}
+			projects, err = builder.SimulateProjects(ctx, candidateCfg)
+			if c.expSimulatedErr != "" {
+				ErrContains(t, c.expSimulatedErr, err)
+			} else {
+				Ok(t, err)
+				Equals(t, c.expSimulated, describe(projects))
+			}
+			// Simulating doesn't change the config in use.
+			projects, err = builder.BuildHelpProjects(ctx)
+			Ok(t, err)
+			Equals(t, c.expProjects, describe(projects))
+		})
+	}
+}
+
 func TestDefaultProjectCommandBuilder_WithPolicyCheckEnabled_BuildAutoplanCommand(t *testing.T) {
 	RegisterMockTestingT(t)
 	tmpDir := DirStructure(t, map[string]interface{}{
@@ -1877,6 +2389,158 @@ func TestDefaultProjectCommandBuilder_WithPolicyCheckEnabled_BuildAutoplanComman
 	Equals(t, globalCfg.Workflows["default"].PolicyCheck.Steps, policyCheckCtx.Steps)
 }
 
+func TestDefaultProjectCommandBuilder_ForkRestricted_BuildAutoplanCommand(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	userConfig := defaultUserConfig
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(tmpDir, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](),
+		Any[models.PullRequest]())).ThenReturn([]string{"main.tf"}, nil)
+
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	customWorkflow := valid.Workflow{
+		Name: "custom",
+		Plan: valid.Stage{
+			Steps: []valid.Step{{StepName: "run", RunCommand: "make plan"}},
+		},
+	}
+	globalCfg.Repos[0].Workflow = &customWorkflow
+	terraformClient := tfclientmocks.NewMockClient()
+
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		vcsClient,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		globalCfg,
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		userConfig.SkipCloneNoChanges,
+		userConfig.EnableRegExpCmd,
+		userConfig.EnableAutoMerge,
+		userConfig.EnableParallelPlan,
+		userConfig.EnableParallelApply,
+		userConfig.AutoDetectModuleFiles,
+		userConfig.AutoplanFileList,
+		userConfig.RestrictFileList,
+		userConfig.SilenceNoProjects,
+		userConfig.IncludeGitUntrackedFiles,
+		userConfig.AutoDiscoverMode,
+		scope,
+		terraformClient,
+	)
+
+	cases := map[string]struct {
+		restricted bool
+		expSteps   []valid.Step
+	}{
+		"not restricted uses the repo's workflow": {false, customWorkflow.Plan.Steps},
+		"restricted uses the built-in workflow":   {true, valid.DefaultPlanStage.Steps},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctxs, err := builder.BuildAutoplanCommands(&command.Context{
+				Log:            logger,
+				Scope:          scope,
+				ForkRestricted: c.restricted,
+			})
+			Ok(t, err)
+			Equals(t, 1, len(ctxs))
+			Equals(t, c.expSteps, ctxs[0].Steps)
+		})
+	}
+}
+
+func TestDefaultProjectCommandBuilder_ForkRestricted_RepoSettings(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir := DirStructure(t, map[string]interface{}{
+		"main.tf": nil,
+	})
+	Ok(t, os.WriteFile(filepath.Join(tmpDir, valid.DefaultAtlantisFile), []byte(`version: 3
+projects:
+- dir: .
+  engine: pulumi
+  cloud_credentials:
+    aws:
+      role_arn: arn:aws:iam::123456789012:role/atlantis
+`), 0600))
+
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	userConfig := defaultUserConfig
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(tmpDir, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](),
+		Any[models.PullRequest]())).ThenReturn([]string{"main.tf"}, nil)
+
+	globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+	globalCfg.Repos[0].AllowedOverrides = []string{valid.EngineKey, valid.CloudCredentialsKey}
+	cloudCredentials := &valid.CloudCredentials{AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"}}
+
+	builder := events.NewProjectCommandBuilder(
+		false,
+		&config.ParserValidator{},
+		&events.DefaultProjectFinder{},
+		vcsClient,
+		workingDir,
+		events.NewDefaultWorkingDirLocker(),
+		globalCfg,
+		&events.DefaultPendingPlanFinder{},
+		&events.CommentParser{ExecutableName: "atlantis"},
+		userConfig.SkipCloneNoChanges,
+		userConfig.EnableRegExpCmd,
+		userConfig.EnableAutoMerge,
+		userConfig.EnableParallelPlan,
+		userConfig.EnableParallelApply,
+		userConfig.AutoDetectModuleFiles,
+		userConfig.AutoplanFileList,
+		userConfig.RestrictFileList,
+		userConfig.SilenceNoProjects,
+		userConfig.IncludeGitUntrackedFiles,
+		userConfig.AutoDiscoverMode,
+		scope,
+		tfclientmocks.NewMockClient(),
+	)
+
+	cases := map[string]struct {
+		restricted          bool
+		expEngine           string
+		expSteps            []valid.Step
+		expCloudCredentials *valid.CloudCredentials
+	}{
+		"not restricted uses the repo's settings":             {false, valid.PulumiEngine, []valid.Step{{StepName: "pulumi_preview"}}, cloudCredentials},
+		"restricted uses terraform without cloud credentials": {true, valid.TerraformEngine, valid.DefaultPlanStage.Steps, nil},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctxs, err := builder.BuildAutoplanCommands(&command.Context{
+				Log:            logger,
+				Scope:          scope,
+				ForkRestricted: c.restricted,
+			})
+			Ok(t, err)
+			Equals(t, 1, len(ctxs))
+			Equals(t, c.expEngine, ctxs[0].Engine)
+			Equals(t, c.expSteps, ctxs[0].Steps)
+			Equals(t, c.expCloudCredentials, ctxs[0].CloudCredentials)
+		})
+	}
+}
+
 // Test building version command for multiple projects
 func TestDefaultProjectCommandBuilder_BuildVersionCommand(t *testing.T) {
 	RegisterMockTestingT(t)
@@ -2206,3 +2870,152 @@ func TestDefaultProjectCommandBuilder_BuildPlanCommands_with_IncludeGitUntracked
 		})
 	}
 }
+
+// fakeCDKTFSynthesizer synthesizes an empty stack for each of stacks.
+type fakeCDKTFSynthesizer struct {
+	output   string
+	stacks   []string
+	synthDir []string
+}
+
+func (f *fakeCDKTFSynthesizer) Synth(_ logging.SimpleLogging, absAppDir string) error {
+	f.synthDir = append(f.synthDir, absAppDir)
+	for _, stack := range f.stacks {
+		stackDir := filepath.Join(absAppDir, f.output, "stacks", stack)
+		if err := os.MkdirAll(stackDir, 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(stackDir, "cdk.tf.json"), []byte("{}"), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDefaultProjectCommandBuilder_BuildPlanCommands_CDKTF(t *testing.T) {
+	cases := []struct {
+		Description    string
+		CDKTFJSON      string
+		Output         string
+		ModifiedFiles  []string
+		ForkRestricted bool
+		ExpSynthesized bool
+		ExpRepoRelDirs []string
+		ExpErr         string
+	}{
+		{
+			Description:    "modified app",
+			CDKTFJSON:      `{"language": "typescript", "app": "npx ts-node main.ts"}`,
+			Output:         "cdktf.out",
+			ModifiedFiles:  []string{"infra/main.ts"},
+			ExpSynthesized: true,
+			ExpRepoRelDirs: []string{"infra/cdktf.out/stacks/dev", "infra/cdktf.out/stacks/prod"},
+		},
+		{
+			Description:    "modified app with custom output",
+			CDKTFJSON:      `{"language": "typescript", "app": "npx ts-node main.ts", "output": "synth"}`,
+			Output:         "synth",
+			ModifiedFiles:  []string{"infra/main.ts"},
+			ExpSynthesized: true,
+			ExpRepoRelDirs: []string{"infra/synth/stacks/dev", "infra/synth/stacks/prod"},
+		},
+		{
+			Description:    "unmodified app",
+			CDKTFJSON:      `{"language": "typescript", "app": "npx ts-node main.ts"}`,
+			Output:         "cdktf.out",
+			ModifiedFiles:  []string{"README.md"},
+			ExpSynthesized: false,
+		},
+		{
+			Description:    "fork restricted pull request",
+			CDKTFJSON:      `{"language": "typescript", "app": "npx ts-node main.ts"}`,
+			Output:         "cdktf.out",
+			ModifiedFiles:  []string{"infra/main.ts"},
+			ForkRestricted: true,
+			ExpSynthesized: false,
+		},
+		{
+			Description:    "output outside of the app",
+			CDKTFJSON:      `{"language": "typescript", "app": "npx ts-node main.ts", "output": "../synth"}`,
+			Output:         "../synth",
+			ModifiedFiles:  []string{"infra/main.ts"},
+			ExpSynthesized: false,
+			ExpErr:         "must be a directory inside the app",
+		},
+	}
+
+	logger := logging.NewNoopLogger(t)
+	scope, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+	userConfig := defaultUserConfig
+
+	for _, c := range cases {
+		t.Run(c.Description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir := DirStructure(t, map[string]interface{}{
+				"infra": map[string]interface{}{
+					"main.ts": nil,
+				},
+			})
+			Ok(t, os.WriteFile(filepath.Join(tmpDir, "infra", "cdktf.json"), []byte(c.CDKTFJSON), 0600))
+			globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+			globalCfg.Repos[0].AutoDiscover = &valid.AutoDiscover{Mode: valid.AutoDiscoverAutoMode, CDKTF: true}
+
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(tmpDir, nil)
+			When(workingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](),
+				Any[models.PullRequest]())).ThenReturn(c.ModifiedFiles, nil)
+
+			builder := events.NewProjectCommandBuilder(
+				false, // policyChecksSupported
+				&config.ParserValidator{},
+				&events.DefaultProjectFinder{},
+				vcsClient,
+				workingDir,
+				events.NewDefaultWorkingDirLocker(),
+				globalCfg,
+				&events.DefaultPendingPlanFinder{},
+				&events.CommentParser{ExecutableName: "atlantis"},
+				userConfig.SkipCloneNoChanges,
+				userConfig.EnableRegExpCmd,
+				userConfig.EnableAutoMerge,
+				userConfig.EnableParallelPlan,
+				userConfig.EnableParallelApply,
+				userConfig.AutoDetectModuleFiles,
+				userConfig.AutoplanFileList,
+				userConfig.RestrictFileList,
+				userConfig.SilenceNoProjects,
+				userConfig.IncludeGitUntrackedFiles,
+				userConfig.AutoDiscoverMode,
+				scope,
+				tfclientmocks.NewMockClient(),
+			)
+			synthesizer := &fakeCDKTFSynthesizer{output: c.Output, stacks: []string{"dev", "prod"}}
+			builder.CDKTFSynthesizer = synthesizer
+
+			actCtxs, err := builder.BuildPlanCommands(&command.Context{
+				Log:            logger,
+				Scope:          scope,
+				ForkRestricted: c.ForkRestricted,
+			}, &events.CommentCommand{Name: command.Plan})
+			if c.ExpErr != "" {
+				ErrContains(t, c.ExpErr, err)
+				return
+			}
+			Ok(t, err)
+
+			if c.ExpSynthesized {
+				Equals(t, []string{filepath.Join(tmpDir, "infra")}, synthesizer.synthDir)
+			} else {
+				Equals(t, 0, len(synthesizer.synthDir))
+			}
+			var actRepoRelDirs []string
+			for _, actCtx := range actCtxs {
+				actRepoRelDirs = append(actRepoRelDirs, actCtx.RepoRelDir)
+			}
+			Equals(t, c.ExpRepoRelDirs, actRepoRelDirs)
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package events
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -97,28 +100,38 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 ) (projectCmds []command.ProjectContext) {
 	ctx.Log.Debug("Building project command context for %s", cmdName)
 
-	var steps []valid.Step
+	var stage valid.Stage
 	switch cmdName {
 	case command.Plan:
-		steps = prjCfg.Workflow.Plan.Steps
+		stage = prjCfg.Workflow.Plan
 	case command.Apply:
-		steps = prjCfg.Workflow.Apply.Steps
+		stage = prjCfg.Workflow.Apply
 	case command.Version:
 		// Setting statically since there will only be one step
-		steps = []valid.Step{{
+		stage = valid.Stage{Steps: []valid.Step{{
 			StepName: "version",
-		}}
+		}}}
 	case command.Import:
-		steps = prjCfg.Workflow.Import.Steps
+		stage = prjCfg.Workflow.Import
 	case command.State:
 		switch subName {
 		case "rm":
-			steps = prjCfg.Workflow.StateRm.Steps
+			stage = prjCfg.Workflow.StateRm
 		default:
 			// comment_parser prevent invalid subcommand, so not need to handle this.
 			// if comes here, state_command_runner will respond on PR, so it's enough to do log only.
 			ctx.Log.Err("unknown state subcommand: %s", subName)
 		}
+	case command.ForceUnlock:
+		stage = prjCfg.Workflow.ForceUnlock
+	case command.Workspaces:
+		stage = workspacesStage(prjCfg.Workflow.Plan)
+	}
+	switch prjCfg.Engine {
+	case valid.PulumiEngine:
+		stage = pulumiStage(cmdName, stage)
+	case valid.CheckEngine:
+		stage = checkStage(cmdName, stage)
 	}
 
 	// If TerraformVersion not defined in config file look for a
@@ -130,13 +143,13 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 	projectCmdContext := newProjectCommandContext(
 		ctx,
 		cmdName,
-		cb.CommentBuilder.BuildApplyComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, prjCfg.AutoMergeDisabled, prjCfg.AutoMergeMethod),
-		cb.CommentBuilder.BuildApprovePoliciesComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name),
-		cb.CommentBuilder.BuildPlanComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, commentFlags),
+		withProjectQualifiers(cb.CommentBuilder.BuildApplyComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, prjCfg.AutoMergeDisabled, prjCfg.AutoMergeMethod), prjCfg),
+		withProjectQualifiers(cb.CommentBuilder.BuildApprovePoliciesComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name), prjCfg),
+		withProjectQualifiers(cb.CommentBuilder.BuildPlanComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, commentFlags), prjCfg),
 		prjCfg,
-		steps,
+		stage,
 		prjCfg.PolicySets,
-		escapeArgs(commentFlags),
+		commentFlags,
 		automerge,
 		parallelApply,
 		parallelPlan,
@@ -196,20 +209,20 @@ func (cb *PolicyCheckProjectCommandContextBuilder) BuildProjectContext(
 		terraformClient,
 	)
 
-	if cmdName == command.Plan && prjCfg.PolicyCheck {
+	if cmdName == command.Plan && prjCfg.PolicyCheck && usesTerraform(prjCfg.Engine) {
 		ctx.Log.Debug("Building project command context for %s", command.PolicyCheck)
-		steps := prjCfg.Workflow.PolicyCheck.Steps
+		stage := prjCfg.Workflow.PolicyCheck
 
 		projectCmds = append(projectCmds, newProjectCommandContext(
 			ctx,
 			command.PolicyCheck,
-			cb.CommentBuilder.BuildApplyComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, prjCfg.AutoMergeDisabled, prjCfg.AutoMergeMethod),
-			cb.CommentBuilder.BuildApprovePoliciesComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name),
-			cb.CommentBuilder.BuildPlanComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, commentFlags),
+			withProjectQualifiers(cb.CommentBuilder.BuildApplyComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, prjCfg.AutoMergeDisabled, prjCfg.AutoMergeMethod), prjCfg),
+			withProjectQualifiers(cb.CommentBuilder.BuildApprovePoliciesComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name), prjCfg),
+			withProjectQualifiers(cb.CommentBuilder.BuildPlanComment(prjCfg.RepoRelDir, prjCfg.Workspace, prjCfg.Name, commentFlags), prjCfg),
 			prjCfg,
-			steps,
+			stage,
 			prjCfg.PolicySets,
-			escapeArgs(commentFlags),
+			commentFlags,
 			automerge,
 			parallelApply,
 			parallelPlan,
@@ -233,9 +246,9 @@ func newProjectCommandContext(ctx *command.Context,
 	approvePoliciesCmd string,
 	planCmd string,
 	projCfg valid.MergedProjectCfg,
-	steps []valid.Step,
+	stage valid.Stage,
 	policySets valid.PolicySets,
-	escapedCommentArgs []string,
+	commentArgs []string,
 	automergeEnabled bool,
 	parallelApplyEnabled bool,
 	parallelPlanEnabled bool,
@@ -260,7 +273,10 @@ func newProjectCommandContext(ctx *command.Context,
 				break
 			}
 
-			if projCfg.Name != "" && project.ProjectName == projCfg.Name {
+			// environments of a project and projects in different workspaces
+			// share a name so also match the workspace
+			if projCfg.Name != "" && project.ProjectName == projCfg.Name &&
+				((projCfg.Environment == "" && !projCfg.WorkspaceQualified) || project.Workspace == projCfg.Workspace) {
 				projectPlanStatus = project.Status
 				projectPolicyStatus = project.PolicyStatus
 				break
@@ -273,7 +289,9 @@ func newProjectCommandContext(ctx *command.Context,
 		ApplyCmd:                   applyCmd,
 		ApprovePoliciesCmd:         approvePoliciesCmd,
 		BaseRepo:                   ctx.Pull.BaseRepo,
-		EscapedCommentArgs:         escapedCommentArgs,
+		EscapedCommentArgs:         escapeArgs(commentArgs),
+		CommentArgs:                commentArgs,
+		AutoVarFiles:               projCfg.AutoVarFiles,
 		AutomergeEnabled:           automergeEnabled,
 		DeleteSourceBranchOnMerge:  projCfg.DeleteSourceBranchOnMerge,
 		RepoLocksMode:              projCfg.RepoLocks.Mode,
@@ -283,7 +301,10 @@ func newProjectCommandContext(ctx *command.Context,
 		ParallelPolicyCheckEnabled: parallelPlanEnabled,
 		DependsOn:                  projCfg.DependsOn,
 		AutoplanEnabled:            projCfg.AutoplanEnabled,
-		Steps:                      steps,
+		AutoplanMode:               projCfg.AutoplanMode,
+		AutoplanLabels:             projCfg.AutoplanLabels,
+		Steps:                      stage.Steps,
+		Timeout:                    stepsTimeout(stage, projCfg.ResourceLimits),
 		HeadRepo:                   ctx.HeadRepo,
 		Log:                        ctx.Log,
 		Scope:                      scope,
@@ -298,6 +319,7 @@ func newProjectCommandContext(ctx *command.Context,
 		RepoRelDir:                 projCfg.RepoRelDir,
 		RepoConfigVersion:          projCfg.RepoCfgVersion,
 		TerraformDistribution:      projCfg.TerraformDistribution,
+		Engine:                     projCfg.Engine,
 		TerraformVersion:           projCfg.TerraformVersion,
 		User:                       ctx.User,
 		Verbose:                    verbose,
@@ -305,6 +327,7 @@ func newProjectCommandContext(ctx *command.Context,
 		PolicySets:                 policySets,
 		PolicySetTarget:            ctx.PolicySet,
 		ClearPolicyApproval:        ctx.ClearPolicyApproval,
+		PolicyApprovalReason:       ctx.PolicyApprovalReason,
 		PullReqStatus:              pullReqStatus,
 		PullStatus:                 pullStatus,
 		JobID:                      uuid.New().String(),
@@ -312,6 +335,91 @@ func newProjectCommandContext(ctx *command.Context,
 		AbortOnExecutionOrderFail:  abortOnExecutionOrderFail,
 		SilencePRComments:          projCfg.SilencePRComments,
 		TeamAllowlistChecker:       teamAllowlistChecker,
+		Environment:                projCfg.Environment,
+		WorkspaceQualified:         projCfg.WorkspaceQualified,
+		VarFiles:                   projCfg.VarFiles,
+		ShowOutputs:                projCfg.ShowOutputs,
+		RestrictedPlanFlags:        projCfg.RestrictedPlanFlags,
+		PRCommentModes:             projCfg.PRCommentModes,
+		ResourceLimits:             projCfg.ResourceLimits,
+		CloudCredentials:           projCfg.CloudCredentials,
+		OutdatedDepsDisabled:       projCfg.OutdatedDepsDisabled,
+	}
+}
+
+// workspacesStage returns the stage that lists the terraform workspaces of
+// a project. The backend has to be initialized the same way as for plans so
+// the init steps of planStage are run first.
+func workspacesStage(planStage valid.Stage) valid.Stage {
+	var stage valid.Stage
+	for _, step := range planStage.Steps {
+		if step.StepName == "init" {
+			stage.Steps = append(stage.Steps, step)
+		}
+	}
+	if len(stage.Steps) == 0 {
+		stage.Steps = append(stage.Steps, valid.Step{StepName: "init"})
+	}
+	stage.Steps = append(stage.Steps, valid.Step{StepName: "workspaces"})
+	return stage
+}
+
+// pulumiStage returns the stage of projects run by the Pulumi engine. Plans
+// run `pulumi preview` and applies run `pulumi up` instead of the steps of the
+// workflow. Other commands keep the stage of the workflow.
+func pulumiStage(cmdName command.Name, stage valid.Stage) valid.Stage {
+	switch cmdName {
+	case command.Plan:
+		return valid.Stage{Steps: []valid.Step{{StepName: "pulumi_preview"}}, Timeout: stage.Timeout}
+	case command.Apply:
+		return valid.Stage{Steps: []valid.Step{{StepName: "pulumi_up"}}, Timeout: stage.Timeout}
+	}
+	return stage
+}
+
+// checkStage returns the stage of projects run by the check engine. Plans only
+// run the custom steps of the stage, ex. validation commands, and the other
+// commands run nothing since there's no Terraform configuration.
+func checkStage(cmdName command.Name, stage valid.Stage) valid.Stage {
+	checks := valid.Stage{Timeout: stage.Timeout}
+	if cmdName != command.Plan {
+		return checks
+	}
+	for _, step := range stage.Steps {
+		switch step.StepName {
+		case "run", "env", "multienv":
+			checks.Steps = append(checks.Steps, step)
+		}
+	}
+	return checks
+}
+
+// usesTerraform returns true if projects run by engine run Terraform.
+func usesTerraform(engine string) bool {
+	return engine == "" || engine == valid.TerraformEngine
+}
+
+// stepsTimeout returns the shortest of the timeout of stage and the time limit
+// of limits.
+func stepsTimeout(stage valid.Stage, limits valid.ResourceLimits) time.Duration {
+	if limits.Time > 0 && (stage.Timeout == 0 || limits.Time < stage.Timeout) {
+		return limits.Time
+	}
+	return stage.Timeout
+}
+
+// withProjectQualifiers adds the environment flag after the project flag of
+// comment if prjCfg is for an environment of the project, or the workspace
+// flag if the project's name is shared by projects in other workspaces.
+func withProjectQualifiers(comment string, prjCfg valid.MergedProjectCfg) string {
+	projectFlag := fmt.Sprintf(" -%s %s", projectFlagShort, prjCfg.Name)
+	switch {
+	case prjCfg.Environment != "":
+		return strings.Replace(comment, projectFlag, fmt.Sprintf("%s -%s %s", projectFlag, environmentFlagShort, prjCfg.Environment), 1)
+	case prjCfg.WorkspaceQualified:
+		return strings.Replace(comment, projectFlag, fmt.Sprintf("%s -%s %s", projectFlag, workspaceFlagShort, prjCfg.Workspace), 1)
+	default:
+		return comment
 	}
 }
 
@@ -2,6 +2,7 @@ package events_test
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -125,4 +126,95 @@ func TestProjectCommandContextBuilder_PullStatus(t *testing.T) {
 
 		assert.True(t, result[0].AbortOnExecutionOrderFail)
 	})
+
+	t.Run("with a resource time limit", func(t *testing.T) {
+		projCfg.Workflow.Plan = valid.Stage{Steps: valid.DefaultPlanStage.Steps, Timeout: 2 * time.Hour}
+		projCfg.ResourceLimits = valid.ResourceLimits{Memory: 1 << 30, Time: time.Hour}
+
+		result := subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, false, terraformClient)
+		assert.Equal(t, time.Hour, result[0].Timeout)
+		assert.Equal(t, projCfg.ResourceLimits, result[0].ResourceLimits)
+
+		projCfg.ResourceLimits.Time = 3 * time.Hour
+		result = subject.BuildProjectContext(commandCtx, command.Plan, "", projCfg, []string{}, "some/dir", false, false, false, false, false, terraformClient)
+		assert.Equal(t, 2*time.Hour, result[0].Timeout)
+	})
+}
+
+func TestProjectCommandContextBuilder_PulumiEngine(t *testing.T) {
+	RegisterMockTestingT(t)
+	subject := events.DefaultProjectCommandContextBuilder{
+		CommentBuilder: mocks.NewMockCommentBuilder(),
+	}
+	projCfg := valid.MergedProjectCfg{
+		RepoRelDir: "dir1",
+		Workspace:  "dev",
+		Engine:     valid.PulumiEngine,
+		Workflow: valid.Workflow{
+			Name:  valid.DefaultWorkflowName,
+			Plan:  valid.DefaultPlanStage,
+			Apply: valid.DefaultApplyStage,
+		},
+	}
+	commandCtx := &command.Context{
+		Log:        logging.NewNoopLogger(t),
+		PullStatus: &models.PullStatus{},
+	}
+	terraformClient := tfclientmocks.NewMockClient()
+
+	cases := []struct {
+		cmdName  command.Name
+		expSteps []valid.Step
+	}{
+		{command.Plan, []valid.Step{{StepName: "pulumi_preview"}}},
+		{command.Apply, []valid.Step{{StepName: "pulumi_up"}}},
+	}
+	for _, c := range cases {
+		t.Run(c.cmdName.String(), func(t *testing.T) {
+			result := subject.BuildProjectContext(commandCtx, c.cmdName, "", projCfg, []string{}, "some/dir", false, false, false, false, false, terraformClient)
+			assert.Equal(t, c.expSteps, result[0].Steps)
+		})
+	}
+}
+
+func TestProjectCommandContextBuilder_CheckEngine(t *testing.T) {
+	RegisterMockTestingT(t)
+	subject := events.DefaultProjectCommandContextBuilder{
+		CommentBuilder: mocks.NewMockCommentBuilder(),
+	}
+	checkStep := valid.Step{StepName: "run", RunCommand: "kubeval *.yaml"}
+	projCfg := valid.MergedProjectCfg{
+		RepoRelDir: "charts",
+		Workspace:  "default",
+		Engine:     valid.CheckEngine,
+		Workflow: valid.Workflow{
+			Name: "kubeval",
+			Plan: valid.Stage{Steps: []valid.Step{
+				{StepName: "init"},
+				checkStep,
+				{StepName: "plan"},
+			}},
+			Apply: valid.DefaultApplyStage,
+		},
+	}
+	commandCtx := &command.Context{
+		Log:        logging.NewNoopLogger(t),
+		PullStatus: &models.PullStatus{},
+	}
+	terraformClient := tfclientmocks.NewMockClient()
+
+	cases := []struct {
+		cmdName  command.Name
+		expSteps []valid.Step
+	}{
+		{command.Plan, []valid.Step{checkStep}},
+		{command.Apply, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.cmdName.String(), func(t *testing.T) {
+			result := subject.BuildProjectContext(commandCtx, c.cmdName, "", projCfg, []string{}, "some/dir", false, false, false, false, false, terraformClient)
+			assert.Equal(t, c.expSteps, result[0].Steps)
+			assert.Equal(t, valid.CheckEngine, result[0].Engine)
+		})
+	}
 }
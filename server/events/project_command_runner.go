@@ -18,8 +18,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/runtime"
@@ -42,6 +45,56 @@ func (d DirNotExistErr) Error() string {
 	return fmt.Sprintf("dir %q does not exist", d.RepoRelDir)
 }
 
+// StepTimeoutErr is returned when a step is stopped because it ran past its
+// own timeout or the timeout of all of the command's steps.
+type StepTimeoutErr struct {
+	Command command.Name
+	Step    string
+	Timeout time.Duration
+	// StepTimeout is true if the step's own timeout was hit.
+	StepTimeout bool
+}
+
+// Error implements the error interface.
+func (s StepTimeoutErr) Error() string {
+	if s.StepTimeout {
+		return fmt.Sprintf("The %s step timed out after %s and was stopped.", s.Step, s.Timeout)
+	}
+	return fmt.Sprintf("The %s steps timed out after %s and were stopped during the %s step.", s.Command, s.Timeout, s.Step)
+}
+
+// StepsCanceledErr is returned when the steps are stopped because a user
+// canceled the command.
+type StepsCanceledErr struct {
+	Command command.Name
+	Step    string
+	// CanceledBy is the user who canceled the command. It's empty if the
+	// command was canceled from its job page.
+	CanceledBy string
+}
+
+// Error implements the error interface.
+func (s StepsCanceledErr) Error() string {
+	if s.CanceledBy == "" {
+		return fmt.Sprintf("The %s was canceled during the %s step.", s.Command, s.Step)
+	}
+	return fmt.Sprintf("The %s was canceled by @%s during the %s step.", s.Command, s.CanceledBy, s.Step)
+}
+
+// stepsFailure returns the error or failure to report when runSteps failed
+// with err. Timeouts and cancellations are reported as failures.
+func stepsFailure(err error, outputs []string) (string, error) {
+	var timeoutErr StepTimeoutErr
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.Error(), nil
+	}
+	var canceledErr StepsCanceledErr
+	if errors.As(err, &canceledErr) {
+		return canceledErr.Error(), nil
+	}
+	return "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+}
+
 //go:generate pegomock generate --package mocks -o mocks/mock_lock_url_generator.go LockURLGenerator
 
 // LockURLGenerator generates urls to locks.
@@ -102,6 +155,12 @@ type MultiEnvStepRunner interface {
 	) (string, error)
 }
 
+// CheckoutStepRunner runs checkout steps.
+type CheckoutStepRunner interface {
+	// Run clones checkout into the workspace of the project in path.
+	Run(ctx command.ProjectContext, checkout valid.Checkout, path string, envs map[string]string) (string, error)
+}
+
 //go:generate pegomock generate --package mocks -o mocks/mock_webhooks_sender.go WebhooksSender
 
 // WebhooksSender sends webhook.
@@ -147,6 +206,16 @@ type ProjectStateCommandRunner interface {
 	StateRm(ctx command.ProjectContext) command.ProjectResult
 }
 
+type ProjectForceUnlockCommandRunner interface {
+	// ForceUnlock runs terraform force-unlock for the project described by ctx.
+	ForceUnlock(ctx command.ProjectContext) command.ProjectResult
+}
+
+type ProjectWorkspacesCommandRunner interface {
+	// Workspaces lists the terraform workspaces for the project described by ctx.
+	Workspaces(ctx command.ProjectContext) command.ProjectResult
+}
+
 // ProjectCommandRunner runs project commands. A project command is a command
 // for a specific TF project.
 type ProjectCommandRunner interface {
@@ -157,6 +226,8 @@ type ProjectCommandRunner interface {
 	ProjectVersionCommandRunner
 	ProjectImportCommandRunner
 	ProjectStateCommandRunner
+	ProjectForceUnlockCommandRunner
+	ProjectWorkspacesCommandRunner
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_job_url_setter.go JobURLSetter
@@ -204,6 +275,14 @@ func (p *ProjectOutputWrapper) updateProjectPRStatus(commandName command.Name, c
 	// ensures we are differentiating between project level command and overall command
 	result := execute(ctx)
 
+	if result.Canceled {
+		if err := p.JobURLSetter.SetJobURLWithStatus(ctx, commandName, models.CanceledCommitStatus, &result); err != nil {
+			ctx.Log.Err("updating project PR status", err)
+		}
+
+		return result
+	}
+
 	if result.Error != nil || result.Failure != "" {
 		if err := p.JobURLSetter.SetJobURLWithStatus(ctx, commandName, models.FailedCommitStatus, &result); err != nil {
 			ctx.Log.Err("updating project PR status", err)
@@ -233,28 +312,61 @@ type DefaultProjectCommandRunner struct {
 	VersionStepRunner         StepRunner
 	ImportStepRunner          StepRunner
 	StateRmStepRunner         StepRunner
+	StateCheckStepRunner      StepRunner
+	ForceUnlockStepRunner     StepRunner
+	FmtCheckStepRunner        StepRunner
+	ValidateStepRunner        StepRunner
+	LockfileCheckStepRunner   StepRunner
+	WorkspacesStepRunner      StepRunner
+	PulumiPreviewStepRunner   StepRunner
+	PulumiUpStepRunner        StepRunner
 	RunStepRunner             CustomStepRunner
 	EnvStepRunner             EnvStepRunner
 	MultiEnvStepRunner        MultiEnvStepRunner
+	CheckoutStepRunner        CheckoutStepRunner
 	PullApprovedChecker       runtime.PullApprovedChecker
 	WorkingDir                WorkingDir
 	Webhooks                  WebhooksSender
 	WorkingDirLocker          WorkingDirLocker
 	CommandRequirementHandler CommandRequirementHandler
+	OutputsFetcher            runtime.OutputsFetcher
+	// RunningCommands tracks the running plans and applies so they can be
+	// canceled.
+	RunningCommands *RunningCommands
+	// CgroupParent is the cgroup directory under which the commands run for
+	// the steps are placed to enforce the repo's resource limits.
+	CgroupParent string
+	// CloudCredentialsMinter mints the cloud credentials of projects that set
+	// them.
+	CloudCredentialsMinter runtime.CloudCredentialsMinter
+	// DependencyChecker, if set, reports the outdated providers and modules of
+	// successful plans.
+	DependencyChecker runtime.DependencyChecker
+	// PlanEncryptor, if set, encrypts the plans of projects between
+	// commands. They're decrypted while policy checks and applies run.
+	PlanEncryptor PlanEncryptor
+	// TeamNamesFetcher, if set, looks up the teams of policy owners instead
+	// of VcsClient.
+	TeamNamesFetcher TeamNamesFetcher
 }
 
 // Plan runs terraform plan for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	ctx, done := p.RunningCommands.Track(ctx)
+	defer done()
 	planSuccess, failure, err := p.doPlan(ctx)
 	return command.ProjectResult{
 		Command:           command.Plan,
 		PlanSuccess:       planSuccess,
 		Error:             err,
 		Failure:           failure,
+		Canceled:          failure != "" && ctx.Cancellation.Canceled(),
 		RepoRelDir:        ctx.RepoRelDir,
 		Workspace:         ctx.Workspace,
 		ProjectName:       ctx.ProjectName,
+		User:              ctx.User.Username,
 		SilencePRComments: ctx.SilencePRComments,
+		PRCommentMode:     ctx.PRCommentModes[command.Plan.String()],
 	}
 }
 
@@ -269,21 +381,29 @@ func (p *DefaultProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) co
 		RepoRelDir:         ctx.RepoRelDir,
 		Workspace:          ctx.Workspace,
 		ProjectName:        ctx.ProjectName,
+		User:               ctx.User.Username,
+		PRCommentMode:      ctx.PRCommentModes[command.PolicyCheck.String()],
 	}
 }
 
 // Apply runs terraform apply for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
-	applyOut, failure, err := p.doApply(ctx)
+	ctx, done := p.RunningCommands.Track(ctx)
+	defer done()
+	applyOut, applyOutputs, failure, err := p.doApply(ctx)
 	return command.ProjectResult{
 		Command:           command.Apply,
 		Failure:           failure,
+		Canceled:          failure != "" && ctx.Cancellation.Canceled(),
 		Error:             err,
 		ApplySuccess:      applyOut,
+		ApplyOutputs:      applyOutputs,
 		RepoRelDir:        ctx.RepoRelDir,
 		Workspace:         ctx.Workspace,
 		ProjectName:       ctx.ProjectName,
+		User:              ctx.User.Username,
 		SilencePRComments: ctx.SilencePRComments,
+		PRCommentMode:     ctx.PRCommentModes[command.Apply.String()],
 	}
 }
 
@@ -297,6 +417,7 @@ func (p *DefaultProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext
 		RepoRelDir:         ctx.RepoRelDir,
 		Workspace:          ctx.Workspace,
 		ProjectName:        ctx.ProjectName,
+		User:               ctx.User.Username,
 	}
 }
 
@@ -342,6 +463,34 @@ func (p *DefaultProjectCommandRunner) StateRm(ctx command.ProjectContext) comman
 	}
 }
 
+// ForceUnlock runs terraform force-unlock for the project described by ctx.
+func (p *DefaultProjectCommandRunner) ForceUnlock(ctx command.ProjectContext) command.ProjectResult {
+	forceUnlockSuccess, failure, err := p.doForceUnlock(ctx)
+	return command.ProjectResult{
+		Command:            command.ForceUnlock,
+		ForceUnlockSuccess: forceUnlockSuccess,
+		Error:              err,
+		Failure:            failure,
+		RepoRelDir:         ctx.RepoRelDir,
+		Workspace:          ctx.Workspace,
+		ProjectName:        ctx.ProjectName,
+	}
+}
+
+// Workspaces lists the terraform workspaces for the project described by ctx.
+func (p *DefaultProjectCommandRunner) Workspaces(ctx command.ProjectContext) command.ProjectResult {
+	workspacesSuccess, failure, err := p.doWorkspaces(ctx)
+	return command.ProjectResult{
+		Command:           command.Workspaces,
+		WorkspacesSuccess: workspacesSuccess,
+		Error:             err,
+		Failure:           failure,
+		RepoRelDir:        ctx.RepoRelDir,
+		Workspace:         ctx.Workspace,
+		ProjectName:       ctx.ProjectName,
+	}
+}
+
 func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectContext) (*models.PolicyCheckResults, string, error) {
 	// Acquire Atlantis lock for this repo/dir/workspace.
 	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode == valid.RepoLocksOnPlanMode)
@@ -367,7 +516,11 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 	// Only query the users team membership if any teams have been configured as owners on any policy set(s).
 	if policySetCfg.HasTeamOwners() {
 		// A convenient way to access vcsClient. Not sure if best way.
-		userTeams, err := p.VcsClient.GetTeamNamesForUser(p.Logger, ctx.Pull.BaseRepo, ctx.User)
+		var fetcher TeamNamesFetcher = p.VcsClient
+		if p.TeamNamesFetcher != nil {
+			fetcher = p.TeamNamesFetcher
+		}
+		userTeams, err := fetcher.GetTeamNamesForUser(p.Logger, ctx.Pull.BaseRepo, ctx.User)
 		if err != nil {
 			ctx.Log.Err("unable to get team membership for user: %s", err)
 			return nil, "", err
@@ -402,8 +555,17 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 				if isOwner && !ignorePolicy && (ctx.User.Username != ctx.Pull.Author || !policySet.PreventSelfApprove) {
 					if !ctx.ClearPolicyApproval {
 						prjPolicyStatus[i].Approvals = policyStatus.Approvals + 1
+						if ctx.PolicyApprovalReason != "" {
+							prjPolicyStatus[i].Justifications = append(prjPolicyStatus[i].Justifications, models.PolicySetJustification{
+								User:   ctx.User.Username,
+								Reason: ctx.PolicyApprovalReason,
+							})
+						}
+						ctx.Log.Info("policy set %s approved by %s with reason %q", policySet.Name, ctx.User.Username, ctx.PolicyApprovalReason)
 					} else {
 						prjPolicyStatus[i].Approvals = 0
+						prjPolicyStatus[i].Justifications = nil
+						ctx.Log.Info("policy set %s approvals cleared by %s", policySet.Name, ctx.User.Username)
 					}
 					// User matches the author and prevent self approve is set to true
 				} else if isOwner && !ignorePolicy && ctx.User.Username == ctx.Pull.Author && policySet.PreventSelfApprove {
@@ -418,10 +580,11 @@ func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectConte
 				}
 
 				prjPolicySetResults = append(prjPolicySetResults, models.PolicySetResult{
-					PolicySetName: policySet.Name,
-					Passed:        policyStatus.Passed,
-					CurApprovals:  prjPolicyStatus[i].Approvals,
-					ReqApprovals:  policySet.ApproveCount,
+					PolicySetName:  policySet.Name,
+					Passed:         policyStatus.Passed,
+					CurApprovals:   prjPolicyStatus[i].Approvals,
+					ReqApprovals:   policySet.ApproveCount,
+					Justifications: prjPolicyStatus[i].Justifications,
 				})
 			}
 		}
@@ -490,8 +653,14 @@ func (p *DefaultProjectCommandRunner) doPolicyCheck(ctx command.ProjectContext)
 		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
+	if err := p.decryptPlan(ctx, absPath); err != nil {
+		return nil, "", err
+	}
 	var failure string
 	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	if encryptErr := p.encryptPlan(ctx, absPath); encryptErr != nil {
+		return nil, "", encryptErr
+	}
 	var errs error
 	if err != nil {
 		for {
@@ -607,64 +776,86 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx command.ProjectContext) (*model
 	}
 
 	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	if encryptErr := p.encryptPlan(ctx, projAbsPath); encryptErr != nil && err == nil {
+		err = encryptErr
+	}
 
 	if err != nil {
 		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
 			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
 		}
-		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		failure, stepsErr := stepsFailure(err, outputs)
+		return nil, failure, stepsErr
 	}
 
-	return &models.PlanSuccess{
+	planSuccess := &models.PlanSuccess{
 		LockURL:         p.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey),
 		TerraformOutput: strings.Join(outputs, "\n"),
 		RePlanCmd:       ctx.RePlanCmd,
 		ApplyCmd:        ctx.ApplyCmd,
 		MergedAgain:     mergedAgain,
-	}, "", nil
+		CheckOnly:       ctx.Engine == valid.CheckEngine,
+	}
+	if p.DependencyChecker != nil && !ctx.OutdatedDepsDisabled {
+		// The report is informational so failing to check doesn't fail the
+		// plan.
+		planSuccess.OutdatedDependencies, err = p.DependencyChecker.Check(ctx, projAbsPath)
+		if err != nil {
+			ctx.Log.Warn("unable to check for outdated dependencies: %s", err)
+		}
+	}
+	return planSuccess, "", nil
 }
 
-func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (applyOut string, failure string, err error) {
+func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (applyOut string, applyOutputs []models.TerraformOutput, failure string, err error) {
 	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", "", errors.New("project has not been cloned–did you run plan?")
+			return "", nil, "", errors.New("project has not been cloned–did you run plan?")
 		}
-		return "", "", err
+		return "", nil, "", err
 	}
 	absPath := filepath.Join(repoDir, ctx.RepoRelDir)
 	if _, err = os.Stat(absPath); os.IsNotExist(err) {
-		return "", "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+		return "", nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
 	failure, err = p.CommandRequirementHandler.ValidateApplyProject(repoDir, ctx)
 	if failure != "" || err != nil {
-		return "", failure, err
+		return "", nil, failure, err
 	}
 
 	failure, err = p.CommandRequirementHandler.ValidateProjectDependencies(ctx)
 	if failure != "" || err != nil {
-		return "", failure, err
+		return "", nil, failure, err
 	}
 
 	// Acquire Atlantis lock for this repo/dir/workspace.
 	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode == valid.RepoLocksOnApplyMode)
 	if err != nil {
-		return "", "", fmt.Errorf("acquiring lock: %w", err)
+		return "", nil, "", fmt.Errorf("acquiring lock: %w", err)
 	}
 	if !lockAttempt.LockAcquired {
-		return "", lockAttempt.LockFailureReason, nil
+		return "", nil, lockAttempt.LockFailureReason, nil
 	}
 	ctx.Log.Debug("acquired lock for project")
 
 	// Acquire internal lock for the directory we're going to operate in.
 	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
 	if err != nil {
-		return "", "", err
+		return "", nil, "", err
 	}
 	defer unlockFn()
 
+	if err := p.decryptPlan(ctx, absPath); err != nil {
+		return "", nil, "", err
+	}
 	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	// The plan was applied or not, either way failing to encrypt what's left
+	// of it doesn't change the result.
+	if encryptErr := p.encryptPlan(ctx, absPath); encryptErr != nil {
+		ctx.Log.Err("unable to encrypt plan: %s", encryptErr)
+	}
 
 	p.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
 		Workspace:   ctx.Workspace,
@@ -677,10 +868,52 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 	})
 
 	if err != nil {
-		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		// A canceled apply may have changed the infrastructure so its plan
+		// can't be applied again. Release the lock so it can be re-planned.
+		if ctx.Cancellation.Canceled() {
+			if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
+				ctx.Log.Err("error unlocking state after canceled apply: %v", unlockErr)
+			}
+		}
+		failure, stepsErr := stepsFailure(err, outputs)
+		return "", nil, failure, stepsErr
 	}
 
-	return strings.Join(outputs, "\n"), "", nil
+	if len(ctx.ShowOutputs) > 0 && p.OutputsFetcher != nil {
+		// The apply succeeded so don't fail it if we can't show the outputs.
+		applyOutputs, err = p.OutputsFetcher.Fetch(ctx, absPath)
+		if err != nil {
+			ctx.Log.Warn("unable to fetch outputs to show: %s", err)
+		}
+	}
+
+	return strings.Join(outputs, "\n"), applyOutputs, "", nil
+}
+
+// encryptPlan encrypts the plan of the project of ctx if plans are
+// encrypted. If it can't be, the plan is deleted rather than left unencrypted.
+func (p *DefaultProjectCommandRunner) encryptPlan(ctx command.ProjectContext, projAbsPath string) error {
+	if p.PlanEncryptor == nil {
+		return nil
+	}
+	err := p.PlanEncryptor.Encrypt(ctx, projAbsPath)
+	if err != nil {
+		for _, path := range planFiles(ctx, projAbsPath) {
+			if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+				ctx.Log.Err("unable to delete unencrypted plan: %s", removeErr)
+			}
+		}
+	}
+	return err
+}
+
+// decryptPlan decrypts the plan of the project of ctx if plans are
+// encrypted.
+func (p *DefaultProjectCommandRunner) decryptPlan(ctx command.ProjectContext, projAbsPath string) error {
+	if p.PlanEncryptor == nil {
+		return nil
+	}
+	return p.PlanEncryptor.Decrypt(ctx, projAbsPath)
 }
 
 func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (versionOut string, failure string, err error) {
@@ -705,7 +938,8 @@ func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (ver
 
 	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
 	if err != nil {
-		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		failure, stepsErr := stepsFailure(err, outputs)
+		return "", failure, stepsErr
 	}
 
 	return strings.Join(outputs, "\n"), "", nil
@@ -746,7 +980,8 @@ func (p *DefaultProjectCommandRunner) doImport(ctx command.ProjectContext) (out
 
 	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		failure, stepsErr := stepsFailure(err, outputs)
+		return nil, failure, stepsErr
 	}
 
 	// after import, re-plan command is required without import args
@@ -787,7 +1022,8 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 
 	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		failure, stepsErr := stepsFailure(err, outputs)
+		return nil, failure, stepsErr
 	}
 
 	// after state rm, re-plan command is required without state rm args
@@ -798,13 +1034,89 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 	}, "", nil
 }
 
+func (p *DefaultProjectCommandRunner) doForceUnlock(ctx command.ProjectContext) (out *models.ForceUnlockSuccess, failure string, err error) {
+	// Clone is idempotent so okay to run even if the repo was already cloned.
+	repoDir, cloneErr := p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+	if cloneErr != nil {
+		return nil, "", cloneErr
+	}
+	projAbsPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(projAbsPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	// Acquire Atlantis lock for this repo/dir/workspace so we don't release
+	// the state lock from under another pull request's plan or apply.
+	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir, ctx.ProjectName), ctx.RepoLocksMode != valid.RepoLocksDisabledMode)
+	if err != nil {
+		return nil, "", fmt.Errorf("acquiring lock: %w", err)
+	}
+	if !lockAttempt.LockAcquired {
+		return nil, lockAttempt.LockFailureReason, nil
+	}
+	ctx.Log.Debug("acquired lock for project")
+
+	// Acquire internal lock for the directory we're going to operate in.
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	if err != nil {
+		failure, stepsErr := stepsFailure(err, outputs)
+		return nil, failure, stepsErr
+	}
+
+	// after force-unlock, re-plan command is required without the lock ID
+	rePlanCmd := strings.TrimSpace(strings.Split(ctx.RePlanCmd, "--")[0])
+	return &models.ForceUnlockSuccess{
+		Output:    strings.Join(outputs, "\n"),
+		RePlanCmd: rePlanCmd,
+	}, "", nil
+}
+
 func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.ProjectContext, absPath string) ([]string, error) {
 	var outputs []string
 
+	// The commands of each step are stopped at the earliest of the deadline
+	// of all the steps and the deadline of the step itself.
+	var stepsDeadline time.Time
+	if ctx.Timeout > 0 {
+		stepsDeadline = time.Now().Add(ctx.Timeout)
+	}
+
+	ctx.CgroupParent = p.CgroupParent
 	envs := make(map[string]string)
+	if ctx.CloudCredentials != nil {
+		// The credentials are minted for each command so they're only valid
+		// for as long as they're needed.
+		creds, err := p.CloudCredentialsMinter.Mint(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("minting cloud credentials: %w", err)
+		}
+		for name, val := range creds {
+			envs[name] = val
+		}
+	}
+	if hasCustomSteps(steps) {
+		ctx = p.withPullMetadata(ctx)
+	}
 	for _, step := range steps {
+		if ctx.Cancellation.Canceled() {
+			return outputs, StepsCanceledErr{Command: ctx.CommandName, Step: step.StepName, CanceledBy: ctx.Cancellation.By()}
+		}
 		var out string
 		var err error
+		ctx.Deadline = stepsDeadline
+		stepTimeout := false
+		if step.Timeout > 0 {
+			if stepDeadline := time.Now().Add(step.Timeout); stepsDeadline.IsZero() || stepDeadline.Before(stepsDeadline) {
+				ctx.Deadline = stepDeadline
+				stepTimeout = true
+			}
+		}
 		switch step.StepName {
 		case "init":
 			out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
@@ -822,6 +1134,31 @@ func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.P
 			out, err = p.ImportStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
 		case "state_rm":
 			out, err = p.StateRmStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "state_check":
+			out, err = p.StateCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "force_unlock":
+			out, err = p.ForceUnlockStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "fmt_check":
+			out, err = p.FmtCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			var fmtErr runtime.FmtCheckErr
+			if step.Suggest && errors.As(err, &fmtErr) {
+				err = p.suggestFmtFixes(ctx, fmtErr)
+			}
+		case "validate":
+			out, err = p.ValidateStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "lockfile_check":
+			out, err = p.LockfileCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+			var lockfileErr runtime.LockfileCheckErr
+			if step.WarnOnly && errors.As(err, &lockfileErr) {
+				ctx.Log.Warn("%s", lockfileErr)
+				out, err = "Warning: "+lockfileErr.Error(), nil
+			}
+		case "workspaces":
+			out, err = p.WorkspacesStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "pulumi_preview":
+			out, err = p.PulumiPreviewStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+		case "pulumi_up":
+			out, err = p.PulumiUpStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
 		case "run":
 			out, err = p.RunStepRunner.Run(ctx, step.RunShell, step.RunCommand, absPath, envs, true, step.Output)
 		case "env":
@@ -832,14 +1169,148 @@ func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.P
 			out = ""
 		case "multienv":
 			out, err = p.MultiEnvStepRunner.Run(ctx, step.RunShell, step.RunCommand, absPath, envs, step.Output)
+		case "checkout":
+			out, err = p.CheckoutStepRunner.Run(ctx, *step.Checkout, absPath, envs)
 		}
 
 		if out != "" {
 			outputs = append(outputs, out)
 		}
 		if err != nil {
+			if ctx.Cancellation.Canceled() {
+				canceledErr := StepsCanceledErr{Command: ctx.CommandName, Step: step.StepName, CanceledBy: ctx.Cancellation.By()}
+				ctx.Log.Info("%s: %s", canceledErr, err)
+				return outputs, canceledErr
+			}
+			if !ctx.Deadline.IsZero() && !time.Now().Before(ctx.Deadline) {
+				timeoutErr := StepTimeoutErr{Command: ctx.CommandName, Step: step.StepName, Timeout: ctx.Timeout, StepTimeout: stepTimeout}
+				if stepTimeout {
+					timeoutErr.Timeout = step.Timeout
+				}
+				ctx.Log.Err("%s: %s", timeoutErr, err)
+				return outputs, timeoutErr
+			}
 			return outputs, err
 		}
 	}
 	return outputs, nil
 }
+
+func (p *DefaultProjectCommandRunner) doWorkspaces(ctx command.ProjectContext) (out *models.WorkspacesSuccess, failure string, err error) {
+	// Clone is idempotent so okay to run even if the repo was already cloned.
+	repoDir, cloneErr := p.WorkingDir.Clone(ctx.Log, ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+	if cloneErr != nil {
+		return nil, "", cloneErr
+	}
+	projAbsPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(projAbsPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	// Listing doesn't change anything so only the internal lock for the
+	// directory is acquired, not the Atlantis lock.
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	if err != nil {
+		failure, stepsErr := stepsFailure(err, outputs)
+		return nil, failure, stepsErr
+	}
+	if len(outputs) == 0 {
+		return nil, "", errors.New("terraform workspace list had no output")
+	}
+
+	// The workspaces step is last so its output is the last one. Terraform
+	// prints each workspace on its own line and marks the selected one with
+	// a *.
+	out = &models.WorkspacesSuccess{}
+	for _, line := range strings.Split(outputs[len(outputs)-1], "\n") {
+		name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if name != "" {
+			out.Workspaces = append(out.Workspaces, models.WorkspaceStatus{Name: name})
+		}
+	}
+	return out, "", nil
+}
+
+// hasCustomSteps returns true if any of steps runs a custom command.
+func hasCustomSteps(steps []valid.Step) bool {
+	for _, step := range steps {
+		switch step.StepName {
+		case "run", "multienv":
+			return true
+		case "env":
+			if step.RunCommand != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suggestFmtFixes posts the fixes of fmtErr as suggestions on the pull
+// request if its VCS host supports them. VCS hosts only allow suggestions on
+// the lines of the pull request's diff, so the fixes to files it doesn't
+// modify aren't posted. The returned error is only marked as suggested if all
+// the fixes were posted, otherwise it still includes the diff.
+func (p *DefaultProjectCommandRunner) suggestFmtFixes(ctx command.ProjectContext, fmtErr runtime.FmtCheckErr) runtime.FmtCheckErr {
+	modifiedFiles, err := p.VcsClient.GetModifiedFiles(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get modified files to suggest formatting fixes: %s", err)
+		return fmtErr
+	}
+	fixes := vcs.SuggestionsFromDiff(ctx.RepoRelDir, fmtErr.Diff)
+	var suggestions []vcs.Suggestion
+	for _, s := range fixes {
+		if slices.Contains(modifiedFiles, s.Path) {
+			suggestions = append(suggestions, s)
+		}
+	}
+	commenter, ok := p.VcsClient.(vcs.SuggestionCommenter)
+	if !ok || len(suggestions) == 0 {
+		return fmtErr
+	}
+
+	body := fmt.Sprintf("`terraform fmt` found unformatted files in `%s`, apply the suggestions to format them.", ctx.RepoRelDir)
+	err = commenter.CreateSuggestions(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull, body, suggestions)
+	if errors.Is(err, vcs.ErrSuggestionsUnsupported) {
+		ctx.Log.Debug("not suggesting formatting fixes: %s", err)
+		return fmtErr
+	}
+	if err != nil {
+		ctx.Log.Warn("unable to suggest formatting fixes: %s", err)
+		return fmtErr
+	}
+	fmtErr.Suggested = len(suggestions) == len(fixes)
+	return fmtErr
+}
+
+// withPullMetadata sets the pull request labels and the files modified in
+// the project's directory on ctx so they can be passed to custom run steps
+// without them having to query the VCS host themselves. Failing to get
+// them isn't fatal since most steps don't use them.
+func (p *DefaultProjectCommandRunner) withPullMetadata(ctx command.ProjectContext) command.ProjectContext {
+	labels, err := p.VcsClient.GetPullLabels(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
+	if err != nil {
+		ctx.Log.Debug("unable to get pull request labels for run steps: %s", err)
+	} else {
+		ctx.PullLabels = labels
+	}
+
+	modifiedFiles, err := p.VcsClient.GetModifiedFiles(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get modified files for run steps: %s", err)
+		return ctx
+	}
+	dir := path.Clean(ctx.RepoRelDir)
+	for _, f := range modifiedFiles {
+		if dir == "." || strings.HasPrefix(path.Clean(f), dir+"/") {
+			ctx.ModifiedFiles = append(ctx.ModifiedFiles, f)
+		}
+	}
+	return ctx
+}
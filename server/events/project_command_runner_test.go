@@ -17,7 +17,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock/v4"
@@ -31,6 +34,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
 	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -50,6 +54,7 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	mockCommandRequirementHandler := mocks.NewMockCommandRequirementHandler()
 
 	runner := events.DefaultProjectCommandRunner{
+		VcsClient:                 vcsmocks.NewMockClient(),
 		Locker:                    mockLocker,
 		LockURLGenerator:          mockURLGenerator{},
 		InitStepRunner:            mockInit,
@@ -141,6 +146,7 @@ func TestProjectOutputWrapper(t *testing.T) {
 		Failure     bool
 		Error       bool
 		Success     bool
+		Canceled    bool
 		CommandName command.Name
 	}{
 		{
@@ -173,6 +179,11 @@ func TestProjectOutputWrapper(t *testing.T) {
 			Error:       true,
 			CommandName: command.Apply,
 		},
+		{
+			Description: "apply canceled",
+			Canceled:    true,
+			CommandName: command.Apply,
+		},
 	}
 
 	for _, c := range cases {
@@ -206,6 +217,12 @@ func TestProjectOutputWrapper(t *testing.T) {
 					Error: errors.New("error"),
 				}
 				expCommitStatus = models.FailedCommitStatus
+			} else if c.Canceled {
+				prjResult = command.ProjectResult{
+					Failure:  "canceled",
+					Canceled: true,
+				}
+				expCommitStatus = models.CanceledCommitStatus
 			}
 
 			When(mockProjectCommandRunner.Plan(Any[command.ProjectContext]())).ThenReturn(prjResult)
@@ -409,6 +426,7 @@ func TestDefaultProjectCommandRunner_Apply(t *testing.T) {
 			}
 
 			runner := events.DefaultProjectCommandRunner{
+				VcsClient:                 vcsmocks.NewMockClient(),
 				Locker:                    mockLocker,
 				LockURLGenerator:          mockURLGenerator{},
 				InitStepRunner:            mockInit,
@@ -541,6 +559,157 @@ func TestDefaultProjectCommandRunner_ApplyRunStepFailure(t *testing.T) {
 	mockApply.VerifyWasCalledOnce().Run(ctx, nil, repoDir, expEnvs)
 }
 
+type fakeOutputsFetcher struct {
+	outputs []models.TerraformOutput
+	err     error
+	path    string
+}
+
+func (f *fakeOutputsFetcher) Fetch(_ command.ProjectContext, path string) ([]models.TerraformOutput, error) {
+	f.path = path
+	return f.outputs, f.err
+}
+
+// Test that the outputs of the project are shown after a successful apply and
+// that failing to fetch them doesn't fail the apply.
+func TestDefaultProjectCommandRunner_ApplyShowOutputs(t *testing.T) {
+	outputs := []models.TerraformOutput{{Name: "endpoint", Value: "https://example.com"}}
+	cases := map[string]struct {
+		showOutputs []string
+		fetcher     *fakeOutputsFetcher
+		expOutputs  []models.TerraformOutput
+	}{
+		"no outputs to show": {
+			fetcher: &fakeOutputsFetcher{outputs: outputs},
+		},
+		"outputs shown": {
+			showOutputs: []string{"endpoint"},
+			fetcher:     &fakeOutputsFetcher{outputs: outputs},
+			expOutputs:  outputs,
+		},
+		"fetching outputs fails": {
+			showOutputs: []string{"endpoint"},
+			fetcher:     &fakeOutputsFetcher{err: errors.New("no state")},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockApply := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:           mockLocker,
+				LockURLGenerator: mockURLGenerator{},
+				ApplyStepRunner:  mockApply,
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: &events.DefaultCommandRequirementHandler{
+					WorkingDir: mockWorkingDir,
+				},
+				Webhooks:       mocks.NewMockWebhooksSender(),
+				OutputsFetcher: c.fetcher,
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(repoDir, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+
+			ctx := command.ProjectContext{
+				Log:         logging.NewNoopLogger(t),
+				Steps:       []valid.Step{{StepName: "apply"}},
+				Workspace:   "default",
+				RepoRelDir:  ".",
+				ShowOutputs: c.showOutputs,
+			}
+			When(mockApply.Run(ctx, nil, repoDir, map[string]string{})).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Ok(t, res.Error)
+			Equals(t, "apply", res.ApplySuccess)
+			Equals(t, c.expOutputs, res.ApplyOutputs)
+			if len(c.showOutputs) > 0 {
+				Equals(t, repoDir, c.fetcher.path)
+			} else {
+				Equals(t, "", c.fetcher.path)
+			}
+		})
+	}
+}
+
+type fakeCloudCredentialsMinter struct {
+	envs map[string]string
+	err  error
+}
+
+func (f *fakeCloudCredentialsMinter) Mint(_ command.ProjectContext) (map[string]string, error) {
+	return f.envs, f.err
+}
+
+// Test that the cloud credentials of the project are passed to its steps and
+// that the command fails if they can't be minted.
+func TestDefaultProjectCommandRunner_ApplyCloudCredentials(t *testing.T) {
+	creds := map[string]string{"AWS_ACCESS_KEY_ID": "ASIAEXAMPLE", "AWS_SECRET_ACCESS_KEY": "secret", "AWS_SESSION_TOKEN": "session"}
+	cases := map[string]struct {
+		minter  *fakeCloudCredentialsMinter
+		expErr  string
+		expEnvs map[string]string
+	}{
+		"credentials minted": {
+			minter:  &fakeCloudCredentialsMinter{envs: creds},
+			expEnvs: creds,
+		},
+		"minting fails": {
+			minter: &fakeCloudCredentialsMinter{err: errors.New("AccessDenied")},
+			expErr: "minting cloud credentials: AccessDenied",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockApply := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:           mockLocker,
+				LockURLGenerator: mockURLGenerator{},
+				ApplyStepRunner:  mockApply,
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: &events.DefaultCommandRequirementHandler{
+					WorkingDir: mockWorkingDir,
+				},
+				Webhooks:               mocks.NewMockWebhooksSender(),
+				CloudCredentialsMinter: c.minter,
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(repoDir, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+
+			ctx := command.ProjectContext{
+				Log:        logging.NewNoopLogger(t),
+				Steps:      []valid.Step{{StepName: "apply"}},
+				Workspace:  "default",
+				RepoRelDir: ".",
+				CloudCredentials: &valid.CloudCredentials{
+					AWS: &valid.AWSCredentials{RoleARN: "arn:aws:iam::123456789012:role/atlantis"},
+				},
+			}
+			When(mockApply.Run(ctx, nil, repoDir, c.expEnvs)).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, res.Error)
+				mockApply.VerifyWasCalled(Never()).Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())
+				return
+			}
+			Ok(t, res.Error)
+			Equals(t, "apply", res.ApplySuccess)
+		})
+	}
+}
+
 // Test run and env steps. We don't use mocks for this test since we're
 // not running any Terraform.
 func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
@@ -564,6 +733,7 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	mockCommandRequirementHandler := mocks.NewMockCommandRequirementHandler()
 
 	runner := events.DefaultProjectCommandRunner{
+		VcsClient:                 vcsmocks.NewMockClient(),
 		Locker:                    mockLocker,
 		LockURLGenerator:          mockURLGenerator{},
 		RunStepRunner:             &run,
@@ -625,6 +795,428 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 	Equals(t, "var=\n\nvar=value\n\ndynamic_var=dynamic_value\n\ndynamic_var=overridden\n", res.PlanSuccess.TerraformOutput)
 }
 
+// Test that run steps get the pull request labels and the files modified in
+// the project's directory.
+func TestDefaultProjectCommandRunner_RunStepPullMetadata(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfClient := tfclientmocks.NewMockClient()
+	tfDistribution := terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader())
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor:       tfClient,
+		DefaultTFDistribution:   tfDistribution,
+		DefaultTFVersion:        tfVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	mockVcsClient := vcsmocks.NewMockClient()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+
+	runner := events.DefaultProjectCommandRunner{
+		VcsClient:                 mockVcsClient,
+		Locker:                    mockLocker,
+		LockURLGenerator:          mockURLGenerator{},
+		RunStepRunner:             &run,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+	}
+
+	repoDir := t.TempDir()
+	Ok(t, os.Mkdir(filepath.Join(repoDir, "staging"), 0700))
+	When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(repoDir, nil)
+	When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+		Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+	When(mockVcsClient.GetPullLabels(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).
+		ThenReturn([]string{"infra", "urgent"}, nil)
+	When(mockVcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).
+		ThenReturn([]string{"staging/main.tf", "staging-old/main.tf", "prod/main.tf", "staging/modules/vpc.tf"}, nil)
+
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{
+				StepName:   "run",
+				RunCommand: "echo labels=$PULL_LABELS files=$MODIFIED_FILES",
+			},
+		},
+		Workspace:  "default",
+		RepoRelDir: "staging",
+	}
+	res := runner.Plan(ctx)
+	Assert(t, res.PlanSuccess != nil, "exp plan success")
+	Equals(t, "labels=infra,urgent files=staging/main.tf,staging/modules/vpc.tf\n", res.PlanSuccess.TerraformOutput)
+}
+
+// fixedDependencyChecker reports the same outdated dependencies for all
+// projects.
+type fixedDependencyChecker []models.OutdatedDependency
+
+func (c fixedDependencyChecker) Check(_ command.ProjectContext, _ string) ([]models.OutdatedDependency, error) {
+	return c, nil
+}
+
+// Test that successful plans report the outdated dependencies unless the repo
+// disabled it.
+func TestDefaultProjectCommandRunner_PlanOutdatedDependencies(t *testing.T) {
+	outdated := []models.OutdatedDependency{{Type: "provider", Source: "registry.terraform.io/hashicorp/aws", Version: "5.1.0", Latest: "5.40.0"}}
+	for _, disabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("disabled %t", disabled), func(t *testing.T) {
+			RegisterMockTestingT(t)
+			run := runtime.RunStepRunner{
+				TerraformExecutor:       tfclientmocks.NewMockClient(),
+				DefaultTFDistribution:   terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader()),
+				DefaultTFVersion:        version.Must(version.NewVersion("0.12.0")),
+				ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+			}
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				VcsClient:                 vcsmocks.NewMockClient(),
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				RunStepRunner:             &run,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+				DependencyChecker:         fixedDependencyChecker(outdated),
+			}
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(t.TempDir(), nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+
+			res := runner.Plan(command.ProjectContext{
+				Log:                  logging.NewNoopLogger(t),
+				Steps:                []valid.Step{{StepName: "run", RunCommand: "echo plan"}},
+				Workspace:            "default",
+				RepoRelDir:           ".",
+				OutdatedDepsDisabled: disabled,
+			})
+			Assert(t, res.PlanSuccess != nil, "exp plan success")
+			if disabled {
+				Equals(t, []models.OutdatedDependency(nil), res.PlanSuccess.OutdatedDependencies)
+			} else {
+				Equals(t, outdated, res.PlanSuccess.OutdatedDependencies)
+			}
+		})
+	}
+}
+
+// Test that plans are encrypted between plan and apply and decrypted for the
+// apply steps.
+func TestDefaultProjectCommandRunner_PlanEncryption(t *testing.T) {
+	RegisterMockTestingT(t)
+	keyFile := filepath.Join(t.TempDir(), "plan-key")
+	Ok(t, os.WriteFile(keyFile, []byte("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="), 0600))
+	encryptor, err := events.NewAESPlanEncryptor(keyFile)
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor:       tfclientmocks.NewMockClient(),
+		DefaultTFDistribution:   terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader()),
+		DefaultTFVersion:        version.Must(version.NewVersion("0.12.0")),
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		VcsClient:                 vcsmocks.NewMockClient(),
+		Locker:                    mockLocker,
+		LockURLGenerator:          mockURLGenerator{},
+		RunStepRunner:             &run,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+		Webhooks:                  mocks.NewMockWebhooksSender(),
+		PlanEncryptor:             encryptor,
+	}
+	repoDir := t.TempDir()
+	When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(repoDir, nil)
+	When(mockWorkingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(repoDir, nil)
+	When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+		Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key"}, nil)
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+
+	ctx.Steps = []valid.Step{{StepName: "run", RunCommand: "printf secret > $PLANFILE"}}
+	res := runner.Plan(ctx)
+	Ok(t, res.Error)
+	planFile, err := os.ReadFile(filepath.Join(repoDir, runtime.GetPlanFilename("default", "")))
+	Ok(t, err)
+	Assert(t, !strings.Contains(string(planFile), "secret"), "expected the plan to be encrypted")
+
+	ctx.Steps = []valid.Step{{StepName: "run", RunCommand: "cat $PLANFILE"}}
+	res = runner.Apply(ctx)
+	Ok(t, res.Error)
+	Equals(t, "secret\n", res.ApplySuccess)
+	planFile, err = os.ReadFile(filepath.Join(repoDir, runtime.GetPlanFilename("default", "")))
+	Ok(t, err)
+	Assert(t, !strings.Contains(string(planFile), "secret"), "expected the plan to be encrypted again")
+}
+
+// Test that canceling a running plan or apply stops its steps, reports it as
+// canceled and releases its lock.
+func TestDefaultProjectCommandRunner_Cancel(t *testing.T) {
+	for _, cmdName := range []command.Name{command.Plan, command.Apply} {
+		t.Run(cmdName.String(), func(t *testing.T) {
+			RegisterMockTestingT(t)
+			run := runtime.RunStepRunner{
+				TerraformExecutor:       tfclientmocks.NewMockClient(),
+				DefaultTFDistribution:   terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader()),
+				DefaultTFVersion:        version.Must(version.NewVersion("0.12.0")),
+				ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+			}
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runningCommands := events.NewRunningCommands()
+			runner := events.DefaultProjectCommandRunner{
+				VcsClient:                 vcsmocks.NewMockClient(),
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				RunStepRunner:             &run,
+				WorkingDir:                mockWorkingDir,
+				Webhooks:                  mocks.NewMockWebhooksSender(),
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+				RunningCommands:           runningCommands,
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(repoDir, nil)
+			When(mockWorkingDir.GetWorkingDir(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(repoDir, nil)
+			unlocked := false
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error {
+				unlocked = true
+				return nil
+			}}, nil)
+
+			ctx := command.ProjectContext{
+				CommandName: cmdName,
+				JobID:       "job",
+				Log:         logging.NewNoopLogger(t),
+				Steps:       []valid.Step{{StepName: "run", RunCommand: "sleep 30"}},
+				Workspace:   "default",
+				RepoRelDir:  ".",
+			}
+			time.AfterFunc(200*time.Millisecond, func() { runningCommands.CancelJob("job") })
+			start := time.Now()
+			var res command.ProjectResult
+			if cmdName == command.Plan {
+				res = runner.Plan(ctx)
+			} else {
+				res = runner.Apply(ctx)
+			}
+			Ok(t, res.Error)
+			Equals(t, fmt.Sprintf("The %s was canceled during the run step.", cmdName), res.Failure)
+			Assert(t, res.Canceled, "expected the result to be canceled")
+			Assert(t, unlocked, "expected the lock to be released")
+			Assert(t, time.Since(start) < 10*time.Second, "expected the steps to be stopped once canceled")
+			Assert(t, !runningCommands.CancelJob("job"), "expected the command to not be tracked anymore")
+		})
+	}
+}
+
+// Test that steps running past their timeout or the timeout of all the steps
+// are stopped and reported as failures.
+func TestDefaultProjectCommandRunner_StepTimeouts(t *testing.T) {
+	cases := map[string]struct {
+		timeout    time.Duration
+		steps      []valid.Step
+		expFailure string
+	}{
+		"step timeout": {
+			steps: []valid.Step{
+				{StepName: "run", RunCommand: "sleep 30", Timeout: 200 * time.Millisecond},
+			},
+			expFailure: "The run step timed out after 200ms and was stopped.",
+		},
+		"steps timeout": {
+			timeout: 200 * time.Millisecond,
+			steps: []valid.Step{
+				{StepName: "run", RunCommand: "echo first"},
+				{StepName: "run", RunCommand: "sleep 30", Timeout: time.Hour},
+			},
+			expFailure: "The plan steps timed out after 200ms and were stopped during the run step.",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tfClient := tfclientmocks.NewMockClient()
+			tfDistribution := terraform.NewDistributionTerraformWithDownloader(tmocks.NewMockDownloader())
+			tfVersion, err := version.NewVersion("0.12.0")
+			Ok(t, err)
+			run := runtime.RunStepRunner{
+				TerraformExecutor:       tfClient,
+				DefaultTFDistribution:   tfDistribution,
+				DefaultTFVersion:        tfVersion,
+				ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+			}
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				VcsClient:                 vcsmocks.NewMockClient(),
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				RunStepRunner:             &run,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(repoDir, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+
+			start := time.Now()
+			res := runner.Plan(command.ProjectContext{
+				CommandName: command.Plan,
+				Log:         logging.NewNoopLogger(t),
+				Steps:       c.steps,
+				Timeout:     c.timeout,
+				Workspace:   "default",
+				RepoRelDir:  ".",
+			})
+			Ok(t, res.Error)
+			Equals(t, c.expFailure, res.Failure)
+			Assert(t, time.Since(start) < 10*time.Second, "expected the steps to be stopped at their timeout")
+		})
+	}
+}
+
+// suggestingClient is a VCS client that can comment with suggestions.
+type suggestingClient struct {
+	*vcsmocks.MockClient
+	suggestions []vcs.Suggestion
+}
+
+func (c *suggestingClient) CreateSuggestions(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, _ string, suggestions []vcs.Suggestion) error {
+	c.suggestions = append(c.suggestions, suggestions...)
+	return nil
+}
+
+// Test that the fixes of an fmt_check step are suggested on the pull request
+// when they're all in files it modifies.
+func TestDefaultProjectCommandRunner_FmtCheckSuggestions(t *testing.T) {
+	diff := "--- old/main.tf\n+++ new/main.tf\n@@ -1 +1 @@\n-a = 1\n+a   = 1\n" +
+		"--- old/vars.tf\n+++ new/vars.tf\n@@ -2 +2 @@\n-b = 2\n+b   = 2\n"
+	cases := map[string]struct {
+		suggest        bool
+		modifiedFiles  []string
+		expSuggestions []vcs.Suggestion
+		expSuggested   bool
+	}{
+		"suggestions disabled": {
+			modifiedFiles: []string{"staging/main.tf", "staging/vars.tf"},
+		},
+		"all files modified": {
+			suggest:       true,
+			modifiedFiles: []string{"staging/main.tf", "staging/vars.tf"},
+			expSuggestions: []vcs.Suggestion{
+				{Path: "staging/main.tf", StartLine: 1, EndLine: 1, Replacement: "a   = 1"},
+				{Path: "staging/vars.tf", StartLine: 2, EndLine: 2, Replacement: "b   = 2"},
+			},
+			expSuggested: true,
+		},
+		"some files modified": {
+			suggest:       true,
+			modifiedFiles: []string{"staging/main.tf"},
+			expSuggestions: []vcs.Suggestion{
+				{Path: "staging/main.tf", StartLine: 1, EndLine: 1, Replacement: "a   = 1"},
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			vcsClient := &suggestingClient{MockClient: vcsmocks.NewMockClient()}
+			mockFmtCheck := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				VcsClient:                 vcsClient,
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				FmtCheckStepRunner:        mockFmtCheck,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+			}
+			repoDir := t.TempDir()
+			Ok(t, os.Mkdir(filepath.Join(repoDir, "staging"), 0700))
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(repoDir, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+			When(vcsClient.GetModifiedFiles(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest]())).
+				ThenReturn(c.modifiedFiles, nil)
+			fmtErr := runtime.FmtCheckErr{Files: []string{"main.tf", "vars.tf"}, Diff: diff}
+			When(mockFmtCheck.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).
+				ThenReturn("", fmtErr)
+
+			res := runner.Plan(command.ProjectContext{
+				CommandName: command.Plan,
+				Log:         logging.NewNoopLogger(t),
+				Steps:       []valid.Step{{StepName: "fmt_check", Suggest: c.suggest}},
+				Workspace:   "default",
+				RepoRelDir:  "staging",
+			})
+			Equals(t, c.expSuggestions, vcsClient.suggestions)
+			fmtErr.Suggested = c.expSuggested
+			ErrEquals(t, fmtErr.Error()+"\n", res.Error)
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_LockfileCheckWarnOnly(t *testing.T) {
+	lockfileErr := runtime.LockfileCheckErr{Problems: []string{"registry.terraform.io/hashicorp/aws isn't locked"}}
+	for _, warnOnly := range []bool{false, true} {
+		t.Run(fmt.Sprintf("warn_only %t", warnOnly), func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockLockfileCheck := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				LockfileCheckStepRunner:   mockLockfileCheck,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(repoDir, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](),
+				Any[models.Project](), AnyBool())).ThenReturn(&events.TryLockResponse{LockAcquired: true, LockKey: "lock-key", UnlockFn: func() error { return nil }}, nil)
+			When(mockLockfileCheck.Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())).
+				ThenReturn("", lockfileErr)
+
+			res := runner.Plan(command.ProjectContext{
+				CommandName: command.Plan,
+				Log:         logging.NewNoopLogger(t),
+				Steps:       []valid.Step{{StepName: "lockfile_check", WarnOnly: warnOnly}},
+				Workspace:   "default",
+				RepoRelDir:  ".",
+			})
+			if !warnOnly {
+				ErrEquals(t, lockfileErr.Error()+"\n", res.Error)
+				return
+			}
+			Ok(t, res.Error)
+			Equals(t, "Warning: "+lockfileErr.Error(), res.PlanSuccess.TerraformOutput)
+		})
+	}
+}
+
 // Test that it runs the expected import steps.
 func TestDefaultProjectCommandRunner_Import(t *testing.T) {
 	expEnvs := map[string]string{}
@@ -739,6 +1331,138 @@ func TestDefaultProjectCommandRunner_Import(t *testing.T) {
 	}
 }
 
+func TestDefaultProjectCommandRunner_ForceUnlock(t *testing.T) {
+	expEnvs := map[string]string{}
+	cases := []struct {
+		description  string
+		lockAcquired bool
+		lockFailure  string
+
+		expOut     *models.ForceUnlockSuccess
+		expFailure string
+	}{
+		{
+			description:  "normal workflow",
+			lockAcquired: true,
+			expOut: &models.ForceUnlockSuccess{
+				Output:    "init\nforce-unlock",
+				RePlanCmd: "atlantis plan -d .",
+			},
+		},
+		{
+			description:  "project locked by another pull request",
+			lockAcquired: false,
+			lockFailure:  "This project is currently locked by an unapplied plan from pull #2.",
+			expFailure:   "This project is currently locked by an unapplied plan from pull #2.",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockInit := mocks.NewMockStepRunner()
+			mockForceUnlock := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			mockSender := mocks.NewMockWebhooksSender()
+
+			runner := events.DefaultProjectCommandRunner{
+				Locker:                mockLocker,
+				LockURLGenerator:      mockURLGenerator{},
+				InitStepRunner:        mockInit,
+				ForceUnlockStepRunner: mockForceUnlock,
+				WorkingDir:            mockWorkingDir,
+				Webhooks:              mockSender,
+				WorkingDirLocker:      events.NewDefaultWorkingDirLocker(),
+			}
+			ctx := command.ProjectContext{
+				Log:                logging.NewNoopLogger(t),
+				Steps:              valid.DefaultForceUnlockStage.Steps,
+				Workspace:          "default",
+				RepoRelDir:         ".",
+				EscapedCommentArgs: []string{"lock-id"},
+				RePlanCmd:          "atlantis plan -d . -- lock-id",
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+				Any[string]())).ThenReturn(repoDir, nil)
+			When(mockLocker.TryLock(
+				Any[logging.SimpleLogging](),
+				Any[models.PullRequest](),
+				Any[models.User](),
+				Any[string](),
+				Any[models.Project](),
+				AnyBool(),
+			)).ThenReturn(&events.TryLockResponse{
+				LockAcquired:      c.lockAcquired,
+				LockFailureReason: c.lockFailure,
+			}, nil)
+			When(mockInit.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("init", nil)
+			When(mockForceUnlock.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("force-unlock", nil)
+
+			res := runner.ForceUnlock(ctx)
+			Equals(t, c.expOut, res.ForceUnlockSuccess)
+			Equals(t, c.expFailure, res.Failure)
+
+			if c.lockAcquired {
+				mockForceUnlock.VerifyWasCalledOnce().Run(ctx, nil, repoDir, expEnvs)
+			} else {
+				mockForceUnlock.VerifyWasCalled(Never()).Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())
+			}
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_Workspaces(t *testing.T) {
+	RegisterMockTestingT(t)
+	expEnvs := map[string]string{}
+	mockInit := mocks.NewMockStepRunner()
+	mockWorkspaces := mocks.NewMockStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+
+	runner := events.DefaultProjectCommandRunner{
+		Locker:               mockLocker,
+		LockURLGenerator:     mockURLGenerator{},
+		InitStepRunner:       mockInit,
+		WorkspacesStepRunner: mockWorkspaces,
+		WorkingDir:           mockWorkingDir,
+		Webhooks:             mocks.NewMockWebhooksSender(),
+		WorkingDirLocker:     events.NewDefaultWorkingDirLocker(),
+	}
+	ctx := command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{StepName: "init"},
+			{StepName: "workspaces"},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	}
+	repoDir := t.TempDir()
+	When(mockWorkingDir.Clone(Any[logging.SimpleLogging](), Any[models.Repo](), Any[models.PullRequest](),
+		Any[string]())).ThenReturn(repoDir, nil)
+	When(mockInit.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("init", nil)
+	When(mockWorkspaces.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("* default\n  staging\n\n", nil)
+
+	res := runner.Workspaces(ctx)
+	Equals(t, "", res.Failure)
+	Ok(t, res.Error)
+	Equals(t, &models.WorkspacesSuccess{
+		Workspaces: []models.WorkspaceStatus{{Name: "default"}, {Name: "staging"}},
+	}, res.WorkspacesSuccess)
+
+	// Listing workspaces must not take the Atlantis lock.
+	mockLocker.VerifyWasCalled(Never()).TryLock(
+		Any[logging.SimpleLogging](),
+		Any[models.PullRequest](),
+		Any[models.User](),
+		Any[string](),
+		Any[models.Project](),
+		AnyBool(),
+	)
+}
+
 type mockURLGenerator struct{}
 
 func (m mockURLGenerator) GenerateLockURL(lockID string) string {
@@ -755,6 +1479,7 @@ func TestDefaultProjectCommandRunner_ApprovePolicies(t *testing.T) {
 		userTeams           []string // Teams the user is a member of
 		targetedPolicy      string   // Policy to target when running approvals
 		clearPolicyApproval bool
+		approvalReason      string
 
 		expOut     []models.PolicySetResult
 		expFailure string
@@ -1223,6 +1948,69 @@ func TestDefaultProjectCommandRunner_ApprovePolicies(t *testing.T) {
 			expFailure: `One or more policy sets require additional approval.`,
 			hasErr:     true,
 		},
+		{
+			description:    "When an approval has a reason, record it with earlier justifications.",
+			approvalReason: "hotfix for outage",
+			policySetCfg: valid.PolicySets{
+				Owners: valid.PolicyOwners{
+					Users: []string{testdata.User.Username},
+				},
+				PolicySets: []valid.PolicySet{
+					{
+						Name:         "policy1",
+						ApproveCount: 2,
+					},
+				},
+			},
+			policySetStatus: []models.PolicySetStatus{
+				{
+					PolicySetName:  "policy1",
+					Approvals:      1,
+					Justifications: []models.PolicySetJustification{{User: "someotheruser", Reason: "known false positive"}},
+				},
+			},
+			expOut: []models.PolicySetResult{
+				{
+					PolicySetName: "policy1",
+					ReqApprovals:  2,
+					CurApprovals:  2,
+					Justifications: []models.PolicySetJustification{
+						{User: "someotheruser", Reason: "known false positive"},
+						{User: testdata.User.Username, Reason: "hotfix for outage"},
+					},
+				},
+			},
+		},
+		{
+			description:         "When approvals are cleared, clear the justifications.",
+			clearPolicyApproval: true,
+			policySetCfg: valid.PolicySets{
+				Owners: valid.PolicyOwners{
+					Users: []string{testdata.User.Username},
+				},
+				PolicySets: []valid.PolicySet{
+					{
+						Name:         "policy1",
+						ApproveCount: 1,
+					},
+				},
+			},
+			policySetStatus: []models.PolicySetStatus{
+				{
+					PolicySetName:  "policy1",
+					Approvals:      1,
+					Justifications: []models.PolicySetJustification{{User: "someotheruser", Reason: "known false positive"}},
+				},
+			},
+			expOut: []models.PolicySetResult{
+				{
+					PolicySetName: "policy1",
+					ReqApprovals:  1,
+					CurApprovals:  0,
+				},
+			},
+			expFailure: `One or more policy sets require additional approval.`,
+		},
 	}
 
 	for _, c := range cases {
@@ -1283,15 +2071,16 @@ func TestDefaultProjectCommandRunner_ApprovePolicies(t *testing.T) {
 			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num, Author: testdata.User.Username}
 			When(runner.VcsClient.GetTeamNamesForUser(Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(testdata.User))).ThenReturn(c.userTeams, nil)
 			ctx := command.ProjectContext{
-				User:                testdata.User,
-				Log:                 logging.NewNoopLogger(t),
-				Workspace:           "default",
-				RepoRelDir:          ".",
-				PolicySets:          c.policySetCfg,
-				ProjectPolicyStatus: projPolicyStatus,
-				Pull:                modelPull,
-				PolicySetTarget:     c.targetedPolicy,
-				ClearPolicyApproval: c.clearPolicyApproval,
+				User:                 testdata.User,
+				Log:                  logging.NewNoopLogger(t),
+				Workspace:            "default",
+				RepoRelDir:           ".",
+				PolicySets:           c.policySetCfg,
+				ProjectPolicyStatus:  projPolicyStatus,
+				Pull:                 modelPull,
+				PolicySetTarget:      c.targetedPolicy,
+				ClearPolicyApproval:  c.clearPolicyApproval,
+				PolicyApprovalReason: c.approvalReason,
 			}
 
 			res := runner.ApprovePolicies(ctx)
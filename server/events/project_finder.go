@@ -19,7 +19,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"testing/fstest"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/utils"
@@ -147,16 +149,23 @@ func (p *DefaultProjectFinder) DetermineProjects(log logging.SimpleLogging, modi
 	log.Info("filtered modified files to %d file(s) in the autoplan file list: %v",
 		len(modifiedTerraformFiles), modifiedTerraformFiles)
 
-	var dirs []string
-	for _, modifiedFile := range modifiedTerraformFiles {
+	// Finding the project of a file reads the dirs above it, so files are
+	// checked in parallel.
+	fileDirs := make([][]string, len(modifiedTerraformFiles))
+	forEachParallel(len(modifiedTerraformFiles), func(i int) {
+		modifiedFile := modifiedTerraformFiles[i]
 		projectDir := getProjectDir(modifiedFile, absRepoDir)
 		if projectDir != "" {
-			dirs = append(dirs, projectDir)
+			fileDirs[i] = []string{projectDir}
 		} else if moduleInfo != nil {
 			downstreamProjects := moduleInfo.DependentProjects(path.Dir(modifiedFile))
 			log.Debug("found downstream projects for %q: %v", modifiedFile, downstreamProjects)
-			dirs = append(dirs, downstreamProjects...)
+			fileDirs[i] = downstreamProjects
 		}
+	})
+	var dirs []string
+	for _, d := range fileDirs {
+		dirs = append(dirs, d...)
 	}
 	uniqueDirs := p.unique(dirs)
 
@@ -165,6 +174,7 @@ func (p *DefaultProjectFinder) DetermineProjects(log logging.SimpleLogging, modi
 	// change however we want to remove directories that have been completely
 	// deleted.
 	exists := p.removeNonExistingDirs(uniqueDirs, absRepoDir)
+	exists = p.removeIgnoredDirs(log, exists, absRepoDir)
 
 	for _, p := range exists {
 		// It's unclear how we are supposed to determine the project name at this point
@@ -178,6 +188,32 @@ func (p *DefaultProjectFinder) DetermineProjects(log logging.SimpleLogging, modi
 	return projects
 }
 
+// DetermineRepoProjectDirs returns the sorted dirs of the projects of a repo
+// that isn't cloned, given the paths of all its files. Unlike
+// DetermineProjects, a dir is only a project if it has a Terraform or
+// Terragrunt file of its own, so dirs with only .tfvars files aren't.
+func (p *DefaultProjectFinder) DetermineRepoProjectDirs(log logging.SimpleLogging, files []string, autoplanFileList string) []string {
+	repoFS := fstest.MapFS{}
+	rootFiles := make(map[string]bool)
+	for _, file := range files {
+		repoFS[file] = &fstest.MapFile{}
+		if base := path.Base(file); strings.HasSuffix(base, ".tf") || base == "terragrunt.hcl" {
+			rootFiles[path.Dir(file)] = true
+		}
+	}
+
+	var dirs []string
+	for _, file := range p.filterToFileList(log, files, autoplanFileList) {
+		if dir := getProjectDirFromFs(repoFS, file); dir != "" && rootFiles[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	dirs = p.unique(dirs)
+	sort.Strings(dirs)
+	log.Info("found %d project(s) at path(s): %v", len(dirs), strings.Join(dirs, ", "))
+	return dirs
+}
+
 // See ProjectFinder.DetermineProjectsViaConfig.
 func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log logging.SimpleLogging, modifiedFiles []string, config valid.RepoCfg, absRepoDir string, moduleInfo ModuleProjects) ([]valid.Project, error) {
 
@@ -191,72 +227,89 @@ func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log logging.SimpleLogg
 		}
 	}
 
+	// Matching the modified files against the when_modified patterns of
+	// thousands of projects adds up so projects are checked in parallel.
+	modified := make([]bool, len(config.Projects))
+	errs := make([]error, len(config.Projects))
+	forEachParallel(len(config.Projects), func(i int) {
+		modified[i], errs[i] = p.projectModified(log, config.Projects[i], modifiedFiles, dependentProjects, absRepoDir)
+	})
 	var projects []valid.Project
-	for _, project := range config.Projects {
-		log.Debug("checking if project at dir %q workspace %q was modified", project.Dir, project.Workspace)
-
-		if utils.SlicesContains(dependentProjects, project.Dir) {
+	for i, project := range config.Projects {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if modified[i] {
 			projects = append(projects, project)
-			continue
 		}
+	}
+	return projects, nil
+}
 
-		var whenModifiedRelToRepoRoot []string
-		for _, wm := range project.Autoplan.WhenModified {
-			wm = strings.TrimSpace(wm)
-			// An exclusion uses a '!' at the beginning. If it's there, we need
-			// to remove it, then add in the project path, then add it back.
-			exclusion := false
-			if wm != "" && wm[0] == '!' {
-				wm = wm[1:]
-				exclusion = true
-			}
+// projectModified returns true if project is modified by modifiedFiles based
+// on its when_modified config, or if it depends on a modified module.
+func (p *DefaultProjectFinder) projectModified(log logging.SimpleLogging, project valid.Project, modifiedFiles []string, dependentProjects []string, absRepoDir string) (bool, error) {
+	log.Debug("checking if project at dir %q workspace %q was modified", project.Dir, project.Workspace)
 
-			// Prepend project dir to when modified patterns because the patterns
-			// are relative to the project dirs but our list of modified files is
-			// relative to the repo root.
-			wmRelPath := filepath.Join(project.Dir, wm)
-			if exclusion {
-				wmRelPath = "!" + wmRelPath
-			}
-			whenModifiedRelToRepoRoot = append(whenModifiedRelToRepoRoot, wmRelPath)
+	if utils.SlicesContains(dependentProjects, project.Dir) {
+		return true, nil
+	}
+
+	var whenModifiedRelToRepoRoot []string
+	for _, wm := range project.Autoplan.WhenModified {
+		wm = strings.TrimSpace(wm)
+		// An exclusion uses a '!' at the beginning. If it's there, we need
+		// to remove it, then add in the project path, then add it back.
+		exclusion := false
+		if wm != "" && wm[0] == '!' {
+			wm = wm[1:]
+			exclusion = true
 		}
-		pm, err := patternmatcher.New(whenModifiedRelToRepoRoot)
-		if err != nil {
-			return nil, errors.Wrapf(err, "matching modified files with patterns: %v", project.Autoplan.WhenModified)
+
+		// Prepend project dir to when modified patterns because the patterns
+		// are relative to the project dirs but our list of modified files is
+		// relative to the repo root.
+		wmRelPath := filepath.Join(project.Dir, wm)
+		if exclusion {
+			wmRelPath = "!" + wmRelPath
 		}
+		whenModifiedRelToRepoRoot = append(whenModifiedRelToRepoRoot, wmRelPath)
+	}
+	pm, err := patternmatcher.New(whenModifiedRelToRepoRoot)
+	if err != nil {
+		return false, errors.Wrapf(err, "matching modified files with patterns: %v", project.Autoplan.WhenModified)
+	}
 
-		// If any of the modified files matches the pattern then this project is
-		// considered modified.
-		for _, file := range modifiedFiles {
-			match, err := pm.MatchesOrParentMatches(file)
-			if err != nil {
-				log.Debug("match err for file %q: %s", file, err)
-				continue
-			}
-			if match {
-				log.Debug("file %q matched pattern", file)
-				// If we're checking using an atlantis.yaml file we downloaded
-				// directly from the repo (when doing a no-clone check) then
-				// absRepoDir will be empty. Since we didn't clone the repo
-				// yet we can't do this check. If there was a file modified
-				// in a deleted directory then when we finally do clone the repo
-				// we'll call this function again and then we'll detect the
-				// directory was deleted.
-				if absRepoDir != "" {
-					_, err := os.Stat(filepath.Join(absRepoDir, project.Dir))
-					if err == nil {
-						projects = append(projects, project)
-					} else {
-						log.Debug("project at dir %q not included because dir does not exist", project.Dir)
-					}
-				} else {
-					projects = append(projects, project)
+	// If any of the modified files matches the pattern then this project is
+	// considered modified.
+	for _, file := range modifiedFiles {
+		if project.Autoplan.Mode == valid.AutoplanModeChangedOnlyWithLockfile && !isTerraformOrLockfile(file) {
+			continue
+		}
+		match, err := pm.MatchesOrParentMatches(file)
+		if err != nil {
+			log.Debug("match err for file %q: %s", file, err)
+			continue
+		}
+		if match {
+			log.Debug("file %q matched pattern", file)
+			// If we're checking using an atlantis.yaml file we downloaded
+			// directly from the repo (when doing a no-clone check) then
+			// absRepoDir will be empty. Since we didn't clone the repo
+			// yet we can't do this check. If there was a file modified
+			// in a deleted directory then when we finally do clone the repo
+			// we'll call this function again and then we'll detect the
+			// directory was deleted.
+			if absRepoDir != "" {
+				if _, err := os.Stat(filepath.Join(absRepoDir, project.Dir)); err != nil {
+					log.Debug("project at dir %q not included because dir does not exist", project.Dir)
+					return false, nil
 				}
-				break
 			}
+			return true, nil
 		}
 	}
-	return projects, nil
+	return false, nil
 }
 
 // filterToFileList filters out files not included in the file list
@@ -390,3 +443,34 @@ func (p *DefaultProjectFinder) removeNonExistingDirs(relativePaths []string, abs
 	}
 	return filtered
 }
+
+// removeIgnoredDirs removes paths from relativePaths that are ignored by the
+// .atlantisignore file of the repo cloned at absRepoDir.
+func (p *DefaultProjectFinder) removeIgnoredDirs(log logging.SimpleLogging, relativePaths []string, absRepoDir string) []string {
+	if absRepoDir == "" {
+		return relativePaths
+	}
+	ignore, err := readAtlantisIgnore(absRepoDir)
+	if err != nil {
+		log.Warn("unable to read %s, not ignoring any projects: %s", AtlantisIgnoreFile, err)
+		return relativePaths
+	}
+	var filtered []string
+	for _, pth := range relativePaths {
+		if ignore.Ignored(pth) {
+			log.Debug("ignoring project at dir %q because of %s", pth, AtlantisIgnoreFile)
+			continue
+		}
+		filtered = append(filtered, pth)
+	}
+	return filtered
+}
+
+// isTerraformOrLockfile returns true if file is a Terraform configuration file
+// or a dependency lock file. Other files such as READMEs or .tfvars files
+// don't count as modifications for the changed_only_with_lockfile autoplan
+// mode.
+func isTerraformOrLockfile(file string) bool {
+	base := filepath.Base(file)
+	return strings.HasSuffix(base, ".tf") || strings.HasSuffix(base, ".tf.json") || base == ".terraform.lock.hcl"
+}
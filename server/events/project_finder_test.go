@@ -14,6 +14,7 @@
 package events_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -322,6 +323,40 @@ func TestDetermineProjects(t *testing.T) {
 	}
 }
 
+func TestDetermineProjects_AtlantisIgnore(t *testing.T) {
+	repoDir := t.TempDir()
+	files := []string{
+		"live/main.tf",
+		"docs/main.tf",
+		"examples/basic/main.tf",
+		"examples/live/main.tf",
+		"test/fixtures/simple/main.tf",
+		"stacks/dev/main.tf",
+		"stacks/prod/main.tf",
+	}
+	for _, f := range files {
+		Ok(t, os.MkdirAll(filepath.Join(repoDir, filepath.Dir(f)), 0700))
+		_, err := os.Create(filepath.Join(repoDir, f))
+		Ok(t, err)
+	}
+	Ok(t, os.WriteFile(filepath.Join(repoDir, events.AtlantisIgnoreFile), []byte(`# Not real infrastructure.
+examples/
+/docs
+fixtures
+
+stacks/*
+!stacks/prod
+!live
+`), 0600))
+
+	projects := m.DetermineProjects(logging.NewNoopLogger(t), files, modifiedRepo, repoDir, "**/*.tf", nil)
+	var paths []string
+	for _, project := range projects {
+		paths = append(paths, project.Path)
+	}
+	Equals(t, []string{"live", "stacks/prod"}, paths)
+}
+
 func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 	// Create dir structure:
 	// main.tf
@@ -376,6 +411,40 @@ func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 			modified:     []string{"main.tf"},
 			expProjPaths: []string{"."},
 		},
+		{
+			description: "autoplan changed_only_with_lockfile ignores tfvars",
+			config: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir: "project2",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							Mode:         valid.AutoplanModeChangedOnlyWithLockfile,
+							WhenModified: []string{"**/*"},
+						},
+					},
+				},
+			},
+			modified:     []string{"project2/terraform.tfvars", "project2/README.md"},
+			expProjPaths: nil,
+		},
+		{
+			description: "autoplan changed_only_with_lockfile counts tf files",
+			config: valid.RepoCfg{
+				Projects: []valid.Project{
+					{
+						Dir: "project2",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							Mode:         valid.AutoplanModeChangedOnlyWithLockfile,
+							WhenModified: []string{"**/*"},
+						},
+					},
+				},
+			},
+			modified:     []string{"project2/terraform.tfvars", "project2/main.tf"},
+			expProjPaths: []string{"project2"},
+		},
 		{
 			description: "autoplan default",
 			config: valid.RepoCfg{
@@ -564,3 +633,33 @@ func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 		})
 	}
 }
+
+// Projects are checked in parallel but must be returned in the order of the
+// config.
+func TestDefaultProjectFinder_DetermineProjectsViaConfig_ManyProjects(t *testing.T) {
+	structure := map[string]interface{}{}
+	var config valid.RepoCfg
+	var modified, expDirs []string
+	for i := 0; i < 500; i++ {
+		dir := fmt.Sprintf("stack%03d", i)
+		structure[dir] = map[string]interface{}{"main.tf": nil}
+		config.Projects = append(config.Projects, valid.Project{
+			Dir:      dir,
+			Autoplan: valid.Autoplan{Enabled: true, WhenModified: []string{"*.tf"}},
+		})
+		if i%3 == 0 {
+			modified = append(modified, dir+"/main.tf")
+			expDirs = append(expDirs, dir)
+		}
+	}
+	tmpDir := DirStructure(t, structure)
+
+	pf := events.DefaultProjectFinder{}
+	projects, err := pf.DetermineProjectsViaConfig(logging.NewNoopLogger(t), modified, config, tmpDir, nil)
+	Ok(t, err)
+	var dirs []string
+	for _, proj := range projects {
+		dirs = append(dirs, proj.Dir)
+	}
+	Equals(t, expDirs, dirs)
+}
@@ -96,8 +96,13 @@ func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo mode
 		}
 	}
 
-	if err := p.WorkingDir.Delete(logger, repo, pull); err != nil {
-		return errors.Wrap(err, "cleaning workspace")
+	// If the workspace can't be deleted we still release the locks, a
+	// leftover workspace only takes up disk space but a dangling lock blocks
+	// other pull requests. The error is returned so the clean up is retried.
+	workspaceErr := p.WorkingDir.Delete(logger, repo, pull)
+	if workspaceErr != nil {
+		workspaceErr = errors.Wrap(workspaceErr, "cleaning workspace")
+		logger.Err("%s", workspaceErr)
 	}
 
 	// Finally, delete locks. We do this last because when someone
@@ -115,7 +120,7 @@ func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo mode
 
 	// If there are no locks then there's no need to comment.
 	if len(locks) == 0 {
-		return nil
+		return workspaceErr
 	}
 
 	templateData := p.buildTemplateData(locks)
@@ -123,7 +128,10 @@ func (p *PullClosedExecutor) CleanUpPull(logger logging.SimpleLogging, repo mode
 	if err = pullClosedTemplate.Execute(&buf, templateData); err != nil {
 		return errors.Wrap(err, "rendering template for comment")
 	}
-	return p.VCSClient.CreateComment(logger, repo, pull.Num, buf.String(), "")
+	if err := p.VCSClient.CreateComment(logger, repo, pull.Num, buf.String(), ""); err != nil {
+		return err
+	}
+	return workspaceErr
 }
 
 // buildTemplateData formats the lock data into a slice that can easily be
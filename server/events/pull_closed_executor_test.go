@@ -37,10 +37,11 @@ import (
 )
 
 func TestCleanUpPullWorkspaceErr(t *testing.T) {
-	t.Log("when workspace.Delete returns an error, we return it")
+	t.Log("when workspace.Delete returns an error, we still delete the locks and return it")
 	RegisterMockTestingT(t)
 	logger := logging.NewNoopLogger(t)
 	w := mocks.NewMockWorkingDir()
+	l := lockmocks.NewMockLocker()
 	tmp := t.TempDir()
 	db, err := db.New(tmp)
 	t.Cleanup(func() {
@@ -48,6 +49,7 @@ func TestCleanUpPullWorkspaceErr(t *testing.T) {
 	})
 	Ok(t, err)
 	pce := events.PullClosedExecutor{
+		Locker:             l,
 		WorkingDir:         w,
 		PullClosedTemplate: &events.PullClosedEventTemplate{},
 		Backend:            db,
@@ -56,6 +58,7 @@ func TestCleanUpPullWorkspaceErr(t *testing.T) {
 	When(w.Delete(logger, testdata.GithubRepo, testdata.Pull)).ThenReturn(err)
 	actualErr := pce.CleanUpPull(logger, testdata.GithubRepo, testdata.Pull)
 	Equals(t, "cleaning workspace: err", actualErr.Error())
+	l.VerifyWasCalledOnce().UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)
 }
 
 func TestCleanUpPullUnlockErr(t *testing.T) {
@@ -237,6 +240,7 @@ func TestCleanUpLogStreaming(t *testing.T) {
 		var lockBucket = "bucket"
 		var configBucket = "configBucket"
 		var pullsBucketName = "pulls"
+		var appliesBucketName = "applies"
 
 		f, err := os.CreateTemp("", "")
 		if err != nil {
@@ -254,6 +258,9 @@ func TestCleanUpLogStreaming(t *testing.T) {
 			if _, err := tx.CreateBucketIfNotExists([]byte(pullsBucketName)); err != nil {
 				return errors.Wrap(err, "failed to create bucket")
 			}
+			if _, err := tx.CreateBucketIfNotExists([]byte(appliesBucketName)); err != nil {
+				return errors.Wrap(err, "failed to create bucket")
+			}
 			return nil
 		}); err != nil {
 			panic(errors.Wrap(err, "could not create bucket"))
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	pullDescriptionStatusStart = "<!-- atlantis-status:start -->"
+	pullDescriptionStatusEnd   = "<!-- atlantis-status:end -->"
+)
+
+// PullDescriptionStatusUpdater keeps a block summarizing the status of each
+// project in the pull request description. The block sits between markers so
+// the rest of the description is left as the author wrote it.
+type PullDescriptionStatusUpdater struct {
+	VCSClient vcs.PullDescriptionUpdater
+}
+
+// Update replaces the status block in the description of pull with one
+// rendered from status, appending it if the description doesn't have one yet.
+func (u *PullDescriptionStatusUpdater) Update(logger logging.SimpleLogging, pull models.PullRequest, status models.PullStatus) error {
+	current, err := u.VCSClient.GetPullDescription(logger, pull.BaseRepo, pull)
+	if err != nil {
+		return errors.Wrap(err, "getting pull request description")
+	}
+	updated := replacePullDescriptionStatus(current, renderPullDescriptionStatus(status))
+	if updated == current {
+		return nil
+	}
+	if err := u.VCSClient.UpdatePullDescription(logger, pull.BaseRepo, pull, updated); err != nil {
+		return errors.Wrap(err, "updating pull request description")
+	}
+	return nil
+}
+
+// replacePullDescriptionStatus swaps the block between the status markers in
+// description for block.
+func replacePullDescriptionStatus(description string, block string) string {
+	start := strings.Index(description, pullDescriptionStatusStart)
+	end := strings.Index(description, pullDescriptionStatusEnd)
+	if start == -1 || end < start {
+		if strings.TrimSpace(description) == "" {
+			return block
+		}
+		return strings.TrimRight(description, "\n") + "\n\n" + block
+	}
+	return description[:start] + block + description[end+len(pullDescriptionStatusEnd):]
+}
+
+func renderPullDescriptionStatus(status models.PullStatus) string {
+	var b strings.Builder
+	b.WriteString(pullDescriptionStatusStart + "\n")
+	b.WriteString("### Atlantis Status\n\n")
+	if len(status.Projects) == 0 {
+		b.WriteString("No projects.\n")
+	} else {
+		b.WriteString("| Project | Dir | Workspace | Status | Policies |\n")
+		b.WriteString("|---------|-----|-----------|--------|----------|\n")
+		for _, p := range status.Projects {
+			project := ""
+			if p.ProjectName != "" {
+				project = fmt.Sprintf("`%s`", p.ProjectName)
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | `%s` | %s | %s |\n",
+				project, p.RepoRelDir, p.Workspace, strings.ReplaceAll(p.Status.String(), "_", " "), renderPolicyStatus(p.PolicyStatus))
+		}
+	}
+	b.WriteString(pullDescriptionStatusEnd)
+	return b.String()
+}
+
+func renderPolicyStatus(statuses []models.PolicySetStatus) string {
+	var policies []string
+	for _, s := range statuses {
+		if s.Passed {
+			policies = append(policies, fmt.Sprintf("%s: passed", s.PolicySetName))
+		} else {
+			policies = append(policies, fmt.Sprintf("%s: failed, %d approval(s)", s.PolicySetName, s.Approvals))
+		}
+	}
+	return strings.Join(policies, ", ")
+}
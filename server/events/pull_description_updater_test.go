@@ -0,0 +1,97 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type fakePullDescriptionClient struct {
+	description string
+	updates     int
+}
+
+func (f *fakePullDescriptionClient) GetPullDescription(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) (string, error) {
+	return f.description, nil
+}
+
+func (f *fakePullDescriptionClient) UpdatePullDescription(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, description string) error {
+	f.description = description
+	f.updates++
+	return nil
+}
+
+func TestPullDescriptionStatusUpdater_Update(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	status := models.PullStatus{
+		Projects: []models.ProjectStatus{
+			{
+				RepoRelDir:  "staging",
+				Workspace:   "default",
+				ProjectName: "staging",
+				Status:      models.AppliedPlanStatus,
+			},
+			{
+				RepoRelDir: "production",
+				Workspace:  "default",
+				Status:     models.PlannedPlanStatus,
+				PolicyStatus: []models.PolicySetStatus{
+					{PolicySetName: "policy1", Passed: true},
+					{PolicySetName: "policy2", Approvals: 1},
+				},
+			},
+		},
+	}
+	block := "<!-- atlantis-status:start -->\n" +
+		"### Atlantis Status\n\n" +
+		"| Project | Dir | Workspace | Status | Policies |\n" +
+		"|---------|-----|-----------|--------|----------|\n" +
+		"| `staging` | `staging` | `default` | applied |  |\n" +
+		"|  | `production` | `default` | planned | policy1: passed, policy2: failed, 1 approval(s) |\n" +
+		"<!-- atlantis-status:end -->"
+
+	cases := []struct {
+		description string
+		current     string
+		exp         string
+	}{
+		{
+			description: "empty description",
+			current:     "",
+			exp:         block,
+		},
+		{
+			description: "appends to the description",
+			current:     "Adds a bucket.\n",
+			exp:         "Adds a bucket.\n\n" + block,
+		},
+		{
+			description: "replaces the existing block",
+			current:     "Adds a bucket.\n\n<!-- atlantis-status:start -->\nold\n<!-- atlantis-status:end -->\n\nFooter",
+			exp:         "Adds a bucket.\n\n" + block + "\n\nFooter",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			client := &fakePullDescriptionClient{description: c.current}
+			u := &events.PullDescriptionStatusUpdater{VCSClient: client}
+			Ok(t, u.Update(logger, models.PullRequest{Num: 1}, status))
+			Equals(t, c.exp, client.description)
+			Equals(t, 1, client.updates)
+
+			// An unchanged status doesn't edit the description again.
+			Ok(t, u.Update(logger, models.PullRequest{Num: 1}, status))
+			Equals(t, 1, client.updates)
+		})
+	}
+}
+
+func TestPullDescriptionStatusUpdater_NoProjects(t *testing.T) {
+	client := &fakePullDescriptionClient{}
+	u := &events.PullDescriptionStatusUpdater{VCSClient: client}
+	Ok(t, u.Update(logging.NewNoopLogger(t), models.PullRequest{Num: 1}, models.PullStatus{}))
+	Equals(t, "<!-- atlantis-status:start -->\n### Atlantis Status\n\nNo projects.\n<!-- atlantis-status:end -->", client.description)
+}
@@ -1,15 +1,36 @@
 package events
 
 import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/utils"
 )
 
+const (
+	// NewCommentStrategy posts a new comment with the results of every
+	// command.
+	NewCommentStrategy = "new"
+	// UpdateLastCommentStrategy edits the previous comment for the same
+	// command and dir where the VCS host supports it, and otherwise posts a
+	// new comment.
+	UpdateLastCommentStrategy = "update-last"
+)
+
 type PullUpdater struct {
 	HidePrevPlanComments bool
 	VCSClient            vcs.Client
 	MarkdownRenderer     *MarkdownRenderer
+	// CheckRunResultsUpdater, if set, also reports the results of commands
+	// on GitHub check runs.
+	CheckRunResultsUpdater CheckRunResultsUpdater
+	// CommentStrategy is NewCommentStrategy or UpdateLastCommentStrategy.
+	// Defaults to NewCommentStrategy.
+	CommentStrategy string
+	// GlobalCfg sets the comment_digest_threshold of the repos.
+	GlobalCfg valid.GlobalCfg
+	// ReloadableGlobalCfg, if set, is used instead of GlobalCfg.
+	ReloadableGlobalCfg *valid.ReloadableGlobalCfg
 }
 
 func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res command.Result) {
@@ -20,16 +41,6 @@ func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res comm
 		ctx.Log.Warn(res.Failure)
 	}
 
-	// HidePrevCommandComments will hide old comments left from previous runs to reduce
-	// clutter in a pull/merge request. This will not delete the comment, since the
-	// comment trail may be useful in auditing or backtracing problems.
-	if c.HidePrevPlanComments {
-		ctx.Log.Debug("hiding previous plan comments for command: '%v', directory: '%v'", cmd.CommandName().TitleString(), cmd.Dir())
-		if err := c.VCSClient.HidePrevCommandComments(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, cmd.CommandName().TitleString(), cmd.Dir()); err != nil {
-			ctx.Log.Err("unable to hide old comments: %s", err)
-		}
-	}
-
 	if len(res.ProjectResults) > 0 {
 		var commentOnProjects []command.ProjectResult
 		for _, result := range res.ProjectResults {
@@ -37,18 +48,96 @@ func (c *PullUpdater) updatePull(ctx *command.Context, cmd PullCommand, res comm
 				ctx.Log.Debug("silenced command '%s' comment for project '%s'", cmd.CommandName().String(), result.ProjectName)
 				continue
 			}
+			switch result.PRCommentMode {
+			case valid.StatusOnlyPRComments, valid.SilentPRComments:
+				ctx.Log.Debug("command '%s' comment for project '%s' is %s", cmd.CommandName().String(), result.ProjectName, result.PRCommentMode)
+				continue
+			case valid.OnFailurePRComments:
+				if result.IsSuccessful() {
+					ctx.Log.Debug("command '%s' succeeded for project '%s', only commenting failures", cmd.CommandName().String(), result.ProjectName)
+					continue
+				}
+			}
 			commentOnProjects = append(commentOnProjects, result)
 		}
 
 		if len(commentOnProjects) == 0 {
+			c.hidePrevComments(ctx, cmd)
 			return
 		}
 
 		res.ProjectResults = commentOnProjects
 	}
 
+	// The results of many projects are combined into a digest, which is
+	// edited in place so the previous one isn't hidden.
+	threshold := c.ReloadableGlobalCfg.LoadOr(c.GlobalCfg).RepoCommentDigestThreshold(ctx.Pull.BaseRepo.ID())
+	if threshold > 0 && len(res.ProjectResults) > threshold && res.Error == nil && res.Failure == "" {
+		comment := c.MarkdownRenderer.RenderDigest(ctx, res, cmd)
+		if err := c.commentDigest(ctx, cmd, comment); err != nil {
+			ctx.Log.Err("unable to comment: %s", err)
+		}
+		c.updateCheckRunResults(ctx, cmd, res, comment)
+		return
+	}
+	c.hidePrevComments(ctx, cmd)
+
 	comment := c.MarkdownRenderer.Render(ctx, res, cmd)
-	if err := c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().String()); err != nil {
+	if err := c.comment(ctx, cmd, comment); err != nil {
 		ctx.Log.Err("unable to comment: %s", err)
 	}
+	c.updateCheckRunResults(ctx, cmd, res, comment)
+}
+
+// hidePrevComments hides the comments left by previous runs of cmd if
+// HidePrevPlanComments is set.
+func (c *PullUpdater) hidePrevComments(ctx *command.Context, cmd PullCommand) {
+	// HidePrevCommandComments will hide old comments left from previous runs to reduce
+	// clutter in a pull/merge request. This will not delete the comment, since the
+	// comment trail may be useful in auditing or backtracing problems.
+	if c.HidePrevPlanComments {
+		ctx.Log.Debug("hiding previous plan comments for command: '%v', directory: '%v'", cmd.CommandName().TitleString(), cmd.Dir())
+		if err := c.VCSClient.HidePrevCommandComments(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, cmd.CommandName().TitleString(), cmd.Dir()); err != nil {
+			ctx.Log.Err("unable to hide old comments: %s", err)
+		}
+	}
+}
+
+func (c *PullUpdater) updateCheckRunResults(ctx *command.Context, cmd PullCommand, res command.Result, comment string) {
+	if c.CheckRunResultsUpdater != nil {
+		if err := c.CheckRunResultsUpdater.UpdateCheckRunResults(ctx, cmd.CommandName(), res, comment); err != nil {
+			ctx.Log.Err("unable to update check run: %s", err)
+		}
+	}
+}
+
+// comment posts comment on the pull request, or edits the previous comment
+// for cmd if that's the configured strategy.
+func (c *PullUpdater) comment(ctx *command.Context, cmd PullCommand, comment string) error {
+	if c.CommentStrategy == UpdateLastCommentStrategy {
+		if u, ok := c.VCSClient.(vcs.LastCommentUpdater); ok {
+			updated, err := u.UpdateLastComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().TitleString(), cmd.Dir())
+			if err != nil {
+				ctx.Log.Warn("unable to update previous comment, posting a new one: %s", err)
+			} else if updated {
+				return nil
+			}
+		}
+	}
+	return c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().String())
+}
+
+// commentDigest edits the previous comment for cmd to be the digest comment,
+// where the VCS host supports it, so that each command keeps a single
+// comment on pull requests with many projects. Otherwise it posts a new one.
+func (c *PullUpdater) commentDigest(ctx *command.Context, cmd PullCommand, comment string) error {
+	if u, ok := c.VCSClient.(vcs.LastCommentUpdater); ok {
+		updated, err := u.UpdateLastComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().TitleString(), "")
+		if err != nil {
+			ctx.Log.Warn("unable to update previous digest, posting a new one: %s", err)
+		} else if updated {
+			return nil
+		}
+	}
+	return c.VCSClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, comment, cmd.CommandName().String())
 }
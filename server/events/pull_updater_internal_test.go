@@ -0,0 +1,229 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/utils"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// lastCommentClient is a vcs.Client that can also edit comments.
+type lastCommentClient struct {
+	vcs.Client
+	updated bool
+	err     error
+	command string
+	dir     string
+}
+
+func (c *lastCommentClient) UpdateLastComment(_ logging.SimpleLogging, _ models.Repo, _ int, _ string, command string, dir string) (bool, error) {
+	c.command = command
+	c.dir = dir
+	return c.updated, c.err
+}
+
+func TestPullUpdater_CommentStrategy(t *testing.T) {
+	cases := []struct {
+		description string
+		strategy    string
+		updated     bool
+		err         error
+		expCreate   bool
+	}{
+		{
+			description: "new comment strategy creates a comment",
+			strategy:    NewCommentStrategy,
+			updated:     true,
+			expCreate:   true,
+		},
+		{
+			description: "update-last edits the previous comment",
+			strategy:    UpdateLastCommentStrategy,
+			updated:     true,
+			expCreate:   false,
+		},
+		{
+			description: "update-last creates a comment without a previous one",
+			strategy:    UpdateLastCommentStrategy,
+			updated:     false,
+			expCreate:   true,
+		},
+		{
+			description: "update-last creates a comment if the edit fails",
+			strategy:    UpdateLastCommentStrategy,
+			err:         errors.New("forbidden"),
+			expCreate:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockClient := mocks.NewMockClient()
+			client := &lastCommentClient{Client: mockClient, updated: c.updated, err: c.err}
+			updater := &PullUpdater{
+				VCSClient:        client,
+				MarkdownRenderer: NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+				CommentStrategy:  c.strategy,
+			}
+			ctx := &command.Context{
+				Log:  logging.NewNoopLogger(t),
+				Pull: models.PullRequest{Num: 1},
+			}
+			cmd := &CommentCommand{Name: command.Plan, RepoRelDir: "dir"}
+			updater.updatePull(ctx, cmd, command.Result{ProjectResults: []command.ProjectResult{{
+				Command:    command.Plan,
+				RepoRelDir: "dir",
+				Workspace:  "default",
+				Failure:    "failure",
+			}}})
+
+			if c.expCreate {
+				mockClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(1), Any[string](), Eq("plan"))
+			} else {
+				mockClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+			}
+			if c.strategy == UpdateLastCommentStrategy {
+				Equals(t, "Plan", client.command)
+				Equals(t, "dir", client.dir)
+			}
+		})
+	}
+}
+
+func TestPullUpdater_PRCommentModes(t *testing.T) {
+	succeeded := command.ProjectResult{
+		Command:     command.Plan,
+		RepoRelDir:  "succeeded",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes."},
+	}
+	failed := command.ProjectResult{
+		Command:    command.Plan,
+		RepoRelDir: "failed",
+		Workspace:  "default",
+		Failure:    "failure",
+	}
+	cases := []struct {
+		mode       string
+		expComment bool
+		expDirs    []string
+	}{
+		{mode: "", expComment: true, expDirs: []string{"succeeded", "failed"}},
+		{mode: valid.AlwaysPRComments, expComment: true, expDirs: []string{"succeeded", "failed"}},
+		{mode: valid.OnFailurePRComments, expComment: true, expDirs: []string{"failed"}},
+		{mode: valid.StatusOnlyPRComments, expComment: false},
+		{mode: valid.SilentPRComments, expComment: false},
+	}
+	for _, c := range cases {
+		t.Run(c.mode, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			client := mocks.NewMockClient()
+			updater := &PullUpdater{
+				VCSClient:        client,
+				MarkdownRenderer: NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+			}
+			ctx := &command.Context{
+				Log:  logging.NewNoopLogger(t),
+				Pull: models.PullRequest{Num: 1},
+			}
+			succeeded.PRCommentMode = c.mode
+			failed.PRCommentMode = c.mode
+			updater.updatePull(ctx, &CommentCommand{Name: command.Plan}, command.Result{ProjectResults: []command.ProjectResult{succeeded, failed}})
+
+			if !c.expComment {
+				client.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+				return
+			}
+			_, _, _, comment, _ := client.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(1), Any[string](), Eq("plan")).GetCapturedArguments()
+			for _, dir := range []string{"succeeded", "failed"} {
+				Equals(t, utils.SlicesContains(c.expDirs, dir), strings.Contains(comment, "dir: `"+dir+"`"))
+			}
+		})
+	}
+}
+
+func TestPullUpdater_CommentDigest(t *testing.T) {
+	threshold := 1
+	globalCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{{ID: "github.com/owner/repo", CommentDigestThreshold: &threshold}},
+	}
+	succeeded := command.ProjectResult{
+		Command:     command.Plan,
+		RepoRelDir:  "succeeded",
+		Workspace:   "default",
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes."},
+	}
+	failed := command.ProjectResult{
+		Command:    command.Plan,
+		RepoRelDir: "failed",
+		Workspace:  "default",
+		Failure:    "failure",
+	}
+	cases := []struct {
+		description string
+		results     []command.ProjectResult
+		updated     bool
+		expDigest   bool
+		expCreate   bool
+	}{
+		{
+			description: "results at the threshold aren't a digest",
+			results:     []command.ProjectResult{succeeded},
+			expCreate:   true,
+		},
+		{
+			description: "results above the threshold create a digest",
+			results:     []command.ProjectResult{succeeded, failed},
+			expDigest:   true,
+			expCreate:   true,
+		},
+		{
+			description: "results above the threshold update the previous digest",
+			results:     []command.ProjectResult{succeeded, failed},
+			updated:     true,
+			expDigest:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockClient := mocks.NewMockClient()
+			client := &lastCommentClient{Client: mockClient, updated: c.updated}
+			updater := &PullUpdater{
+				VCSClient:        client,
+				MarkdownRenderer: NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false, false),
+				GlobalCfg:        globalCfg,
+			}
+			ctx := &command.Context{
+				Log: logging.NewNoopLogger(t),
+				Pull: models.PullRequest{
+					Num:      1,
+					BaseRepo: models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}},
+				},
+			}
+			updater.updatePull(ctx, &CommentCommand{Name: command.Plan}, command.Result{ProjectResults: c.results})
+
+			if c.expDigest {
+				Equals(t, "Plan", client.command)
+				Equals(t, "", client.dir)
+			} else {
+				Equals(t, "", client.command)
+			}
+			if !c.expCreate {
+				mockClient.VerifyWasCalled(Never()).CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+				return
+			}
+			_, _, _, comment, _ := mockClient.VerifyWasCalledOnce().CreateComment(Any[logging.SimpleLogging](), Any[models.Repo](), Eq(1), Any[string](), Eq("plan")).GetCapturedArguments()
+			Equals(t, c.expDigest, strings.HasPrefix(comment, "Ran Plan for 2 projects: 1 succeeded, 1 failed"))
+		})
+	}
+}
@@ -16,14 +16,17 @@ package events
 import (
 	"fmt"
 	"strings"
+	"sync"
 )
 
 // Wildcard matches 0-n of all characters except commas.
 const Wildcard = "*"
 
 // RepoAllowlistChecker implements checking if repos are allowlisted to be used with
-// this Atlantis.
+// this Atlantis. The allowlist can be updated while the server is running.
 type RepoAllowlistChecker struct {
+	mu           sync.RWMutex
+	allowlist    string
 	includeRules []string
 	omitRules    []string
 }
@@ -31,11 +34,21 @@ type RepoAllowlistChecker struct {
 // NewRepoAllowlistChecker constructs a new checker and validates that the
 // allowlist isn't malformed.
 func NewRepoAllowlistChecker(allowlist string) (*RepoAllowlistChecker, error) {
+	r := &RepoAllowlistChecker{}
+	if err := r.Update(allowlist); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update validates allowlist and replaces the current allowlist with it. If
+// it's malformed, the current allowlist is kept.
+func (r *RepoAllowlistChecker) Update(allowlist string) error {
 	includeRules := make([]string, 0)
 	omitRules := make([]string, 0)
 	for _, rule := range strings.Split(allowlist, ",") {
 		if strings.Contains(rule, "://") {
-			return nil, fmt.Errorf("allowlist %q contained ://", rule)
+			return fmt.Errorf("allowlist %q contained ://", rule)
 		}
 		if len(rule) > 1 && rule[0] == '!' {
 			omitRules = append(omitRules, rule[1:])
@@ -43,15 +56,27 @@ func NewRepoAllowlistChecker(allowlist string) (*RepoAllowlistChecker, error) {
 			includeRules = append(includeRules, rule)
 		}
 	}
-	return &RepoAllowlistChecker{
-		includeRules: includeRules,
-		omitRules:    omitRules,
-	}, nil
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.allowlist = allowlist
+	r.includeRules = includeRules
+	r.omitRules = omitRules
+	return nil
+}
+
+// Allowlist returns the current allowlist.
+func (r *RepoAllowlistChecker) Allowlist() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.allowlist
 }
 
 // IsAllowlisted returns true if this repo is in our allowlist and false
 // otherwise.
 func (r *RepoAllowlistChecker) IsAllowlisted(repoFullName string, vcsHostname string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	candidate := fmt.Sprintf("%s/%s", vcsHostname, repoFullName)
 	shouldInclude := r.matchesAtLeastOneRule(r.includeRules, candidate)
 	shouldOmit := r.matchesAtLeastOneRule(r.omitRules, candidate)
@@ -107,3 +132,21 @@ func (r *RepoAllowlistChecker) matchesRule(rule string, candidate string) bool {
 	// substr(rule): abc
 	return candidate[:wildcardIdx] == rule[:wildcardIdx]
 }
+
+// ExactRepos returns the full names, ex. "owner/repo", of the repos on
+// vcsHostname that are allowlisted without a wildcard.
+func (r *RepoAllowlistChecker) ExactRepos(vcsHostname string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var repos []string
+	for _, rule := range r.includeRules {
+		if strings.Contains(rule, Wildcard) || r.matchesAtLeastOneRule(r.omitRules, rule) {
+			continue
+		}
+		host, repoFullName, ok := strings.Cut(rule, "/")
+		if ok && strings.EqualFold(host, vcsHostname) && strings.Count(repoFullName, "/") >= 1 {
+			repos = append(repos, repoFullName)
+		}
+	}
+	return repos
+}
@@ -223,3 +223,29 @@ func TestRepoAllowlistChecker_ContainsSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoAllowlistChecker_Update(t *testing.T) {
+	r, err := events.NewRepoAllowlistChecker("github.com/owner/repo")
+	Ok(t, err)
+	Equals(t, "github.com/owner/repo", r.Allowlist())
+	Equals(t, true, r.IsAllowlisted("owner/repo", "github.com"))
+	Equals(t, false, r.IsAllowlisted("owner/other", "github.com"))
+
+	Ok(t, r.Update("github.com/owner/*,!github.com/owner/repo"))
+	Equals(t, "github.com/owner/*,!github.com/owner/repo", r.Allowlist())
+	Equals(t, false, r.IsAllowlisted("owner/repo", "github.com"))
+	Equals(t, true, r.IsAllowlisted("owner/other", "github.com"))
+
+	// A malformed allowlist is rejected and the current one is kept.
+	ErrEquals(t, `allowlist "https://github.com/owner/repo" contained ://`, r.Update("https://github.com/owner/repo"))
+	Equals(t, "github.com/owner/*,!github.com/owner/repo", r.Allowlist())
+	Equals(t, true, r.IsAllowlisted("owner/other", "github.com"))
+}
+
+func TestRepoAllowlistChecker_ExactRepos(t *testing.T) {
+	r, err := events.NewRepoAllowlistChecker("github.com/owner/repo,github.com/owner/*,GitHub.com/owner/other,github.com/owner/omitted,!github.com/owner/omitted,gitlab.com/group/sub/project")
+	Ok(t, err)
+	Equals(t, []string{"owner/repo", "owner/other"}, r.ExactRepos("github.com"))
+	Equals(t, []string{"group/sub/project"}, r.ExactRepos("gitlab.com"))
+	Equals(t, []string(nil), r.ExactRepos("bitbucket.org"))
+}
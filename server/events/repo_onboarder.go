@@ -0,0 +1,136 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RepoOnboardingBranch is the branch the pull request adding the
+	// generated atlantis.yaml is opened from.
+	RepoOnboardingBranch = "atlantis/init-repo"
+	repoOnboardingTitle  = "Add atlantis.yaml"
+	repoOnboardingBody   = "This pull request adds an `atlantis.yaml` generated by Atlantis from the Terraform projects found on the default branch.\n\n" +
+		"Review the projects before merging: remove the ones Atlantis shouldn't plan and add the settings they need, see https://www.runatlantis.io/docs/repo-level-atlantis-yaml."
+)
+
+// RepoOnboarding is the result of onboarding a repo.
+type RepoOnboarding struct {
+	Repo string `json:"repo"`
+	// Projects are the dirs of the projects found.
+	Projects []string `json:"projects"`
+	// Config is the generated atlantis.yaml.
+	Config string `json:"config"`
+	// PullURL is the URL of the pull request adding Config, or empty on a
+	// dry run.
+	PullURL string `json:"pull_url,omitempty"`
+}
+
+// RepoOnboarder generates an atlantis.yaml for a repo from the projects found
+// on its default branch and opens a pull request adding it.
+type RepoOnboarder struct {
+	Hosts                []VCSConfigHost
+	RepoAllowlistChecker *RepoAllowlistChecker
+	ProjectFinder        *DefaultProjectFinder
+	// AutoplanFileList is the --autoplan-file-list projects are found with.
+	AutoplanFileList string
+}
+
+type onboardingRepoCfg struct {
+	Version  int                 `yaml:"version"`
+	Projects []onboardingProject `yaml:"projects"`
+}
+
+type onboardingProject struct {
+	Name     string             `yaml:"name"`
+	Dir      string             `yaml:"dir"`
+	Autoplan onboardingAutoplan `yaml:"autoplan"`
+}
+
+type onboardingAutoplan struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Onboard onboards the repo repoFullName on the VCS host hostType, ex.
+// "Github". If dryRun is true, the generated atlantis.yaml is returned
+// without opening a pull request. It returns an error if the repo isn't
+// allowlisted, already has an atlantis.yaml or has no projects.
+func (o *RepoOnboarder) Onboard(logger logging.SimpleLogging, hostType string, repoFullName string, dryRun bool) (RepoOnboarding, error) {
+	onboarding := RepoOnboarding{Repo: repoFullName}
+	vcsHostType, err := models.NewVCSHostType(hostType)
+	if err != nil {
+		return onboarding, err
+	}
+	var host *VCSConfigHost
+	for i := range o.Hosts {
+		if o.Hosts[i].Type == vcsHostType {
+			host = &o.Hosts[i]
+		}
+	}
+	if host == nil {
+		return onboarding, fmt.Errorf("%s isn't configured", hostType)
+	}
+	bootstrapper, ok := host.Client.(vcs.RepoBootstrapper)
+	if !ok {
+		return onboarding, fmt.Errorf("onboarding repos isn't supported on %s", hostType)
+	}
+	if o.RepoAllowlistChecker != nil && !o.RepoAllowlistChecker.IsAllowlisted(repoFullName, host.Hostname) {
+		return onboarding, fmt.Errorf("%s isn't allowlisted", repoFullName)
+	}
+
+	base, files, err := bootstrapper.ListDefaultBranchFiles(logger, repoFullName)
+	if err != nil {
+		return onboarding, err
+	}
+	if slices.Contains(files, valid.DefaultAtlantisFile) {
+		return onboarding, fmt.Errorf("%s already has an %s", repoFullName, valid.DefaultAtlantisFile)
+	}
+	onboarding.Projects = o.ProjectFinder.DetermineRepoProjectDirs(logger, files, o.AutoplanFileList)
+	if len(onboarding.Projects) == 0 {
+		return onboarding, fmt.Errorf("no Terraform projects were found on branch %s of %s", base, repoFullName)
+	}
+
+	cfg := onboardingRepoCfg{Version: 3}
+	for _, dir := range onboarding.Projects {
+		cfg.Projects = append(cfg.Projects, onboardingProject{
+			Name:     onboardingProjectName(repoFullName, dir),
+			Dir:      dir,
+			Autoplan: onboardingAutoplan{Enabled: true},
+		})
+	}
+	var config bytes.Buffer
+	encoder := yaml.NewEncoder(&config)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return onboarding, err
+	}
+	onboarding.Config = config.String()
+	if dryRun {
+		return onboarding, nil
+	}
+
+	onboarding.PullURL, err = bootstrapper.OpenPullWithFile(logger, repoFullName, base, RepoOnboardingBranch, valid.DefaultAtlantisFile, config.Bytes(), repoOnboardingTitle, repoOnboardingBody)
+	if err != nil {
+		return onboarding, err
+	}
+	logger.Info("opened %s to onboard %s with %d project(s)", onboarding.PullURL, repoFullName, len(onboarding.Projects))
+	return onboarding, nil
+}
+
+// onboardingProjectName returns the name of the project at dir, ex.
+// "env-prod" for "env/prod", or the name of the repo for the root dir.
+func onboardingProjectName(repoFullName string, dir string) string {
+	if dir == "." {
+		return path.Base(repoFullName)
+	}
+	return strings.ReplaceAll(dir, "/", "-")
+}
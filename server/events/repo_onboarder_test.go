@@ -0,0 +1,101 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// bootstrappingClient is a VCS client that can open pull requests adding a
+// file.
+type bootstrappingClient struct {
+	*vcsmocks.MockClient
+	files  []string
+	opened map[string]string
+}
+
+func (c *bootstrappingClient) ListDefaultBranchFiles(_ logging.SimpleLogging, _ string) (string, []string, error) {
+	return "main", c.files, nil
+}
+
+func (c *bootstrappingClient) OpenPullWithFile(_ logging.SimpleLogging, repoFullName string, base string, branch string, path string, content []byte, _ string, _ string) (string, error) {
+	c.opened[path] = base + ".." + branch + "\n" + string(content)
+	return "https://github.com/" + repoFullName + "/pull/1", nil
+}
+
+func TestRepoOnboarder_Onboard(t *testing.T) {
+	allowlist, err := events.NewRepoAllowlistChecker("github.com/owner/*")
+	Ok(t, err)
+	client := &bootstrappingClient{
+		MockClient: vcsmocks.NewMockClient(),
+		files: []string{
+			"README.md",
+			"main.tf",
+			"env/prod/main.tf",
+			"env/prod/terraform.tfvars",
+			"modules/vpc/main.tf",
+			"vars/shared.tfvars",
+			"live/app/terragrunt.hcl",
+		},
+		opened: map[string]string{},
+	}
+	onboarder := events.RepoOnboarder{
+		Hosts: []events.VCSConfigHost{
+			{Type: models.Github, Hostname: "github.com", Client: client},
+			{Type: models.AzureDevops, Hostname: "dev.azure.com", Client: vcsmocks.NewMockClient()},
+		},
+		RepoAllowlistChecker: allowlist,
+		ProjectFinder:        &events.DefaultProjectFinder{},
+		AutoplanFileList:     "**/*.tf,**/*.tfvars,**/terragrunt.hcl",
+	}
+	logger := logging.NewNoopLogger(t)
+	expConfig := `version: 3
+projects:
+  - name: repo
+    dir: .
+    autoplan:
+      enabled: true
+  - name: env-prod
+    dir: env/prod
+    autoplan:
+      enabled: true
+  - name: live-app
+    dir: live/app
+    autoplan:
+      enabled: true
+`
+
+	t.Run("dry run", func(t *testing.T) {
+		onboarding, err := onboarder.Onboard(logger, "Github", "owner/repo", true)
+		Ok(t, err)
+		Equals(t, events.RepoOnboarding{Repo: "owner/repo", Projects: []string{".", "env/prod", "live/app"}, Config: expConfig}, onboarding)
+		Equals(t, 0, len(client.opened))
+	})
+
+	t.Run("opens a pull request", func(t *testing.T) {
+		onboarding, err := onboarder.Onboard(logger, "Github", "owner/repo", false)
+		Ok(t, err)
+		Equals(t, "https://github.com/owner/repo/pull/1", onboarding.PullURL)
+		Equals(t, "main.."+events.RepoOnboardingBranch+"\n"+expConfig, client.opened["atlantis.yaml"])
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := onboarder.Onboard(logger, "Github", "other/repo", false)
+		ErrEquals(t, "other/repo isn't allowlisted", err)
+		_, err = onboarder.Onboard(logger, "AzureDevops", "org/project/repo", false)
+		ErrEquals(t, "onboarding repos isn't supported on AzureDevops", err)
+		_, err = onboarder.Onboard(logger, "Gitlab", "group/repo", false)
+		ErrEquals(t, "Gitlab isn't configured", err)
+
+		client.files = []string{"README.md", "atlantis.yaml"}
+		_, err = onboarder.Onboard(logger, "Github", "owner/repo", false)
+		ErrEquals(t, "owner/repo already has an atlantis.yaml", err)
+		client.files = []string{"README.md"}
+		_, err = onboarder.Onboard(logger, "Github", "owner/repo", false)
+		ErrEquals(t, "no Terraform projects were found on branch main of owner/repo", err)
+	})
+}
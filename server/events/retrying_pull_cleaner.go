@@ -0,0 +1,56 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// RetryingPullCleaner retries cleaning up after a closed pull request in the
+// background if it fails, so that a transient error doesn't leave dangling
+// locks behind. VCS hosts like Bitbucket Cloud don't redeliver the merged or
+// declined event, so it's our only chance to clean up.
+type RetryingPullCleaner struct {
+	PullCleaner PullCleaner
+	// Retries is how many more times cleaning up is tried after it fails.
+	Retries int
+	// Delay is how long to wait before the first retry, it doubles for each
+	// retry after that.
+	Delay time.Duration
+
+	wg sync.WaitGroup
+}
+
+func (r *RetryingPullCleaner) CleanUpPull(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error {
+	err := r.PullCleaner.CleanUpPull(logger, repo, pull)
+	if err == nil || r.Retries <= 0 {
+		return err
+	}
+	r.wg.Add(1)
+	go r.retry(logger, repo, pull)
+	return errors.Wrap(err, "retrying clean up in the background")
+}
+
+// Wait waits for the clean ups being retried to finish.
+func (r *RetryingPullCleaner) Wait() {
+	r.wg.Wait()
+}
+
+func (r *RetryingPullCleaner) retry(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) {
+	defer r.wg.Done()
+	delay := r.Delay
+	for attempt := 1; attempt <= r.Retries; attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+		err := r.PullCleaner.CleanUpPull(logger, repo, pull)
+		if err == nil {
+			logger.Info("Locks and workspace successfully deleted after %d retries", attempt)
+			return
+		}
+		logger.Warn("retry %d of %d to clean up pull request failed: %s", attempt, r.Retries, err)
+	}
+	logger.Err("giving up cleaning up pull request after %d retries, its locks may need to be deleted manually", r.Retries)
+}
@@ -0,0 +1,67 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// flakyPullCleaner fails the first failures calls to CleanUpPull.
+type flakyPullCleaner struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyPullCleaner) CleanUpPull(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("db locked")
+	}
+	return nil
+}
+
+func TestRetryingPullCleaner(t *testing.T) {
+	cases := map[string]struct {
+		failures int
+		expErr   string
+		expCalls int
+	}{
+		"succeeds": {
+			expCalls: 1,
+		},
+		"succeeds on retry": {
+			failures: 2,
+			expErr:   "retrying clean up in the background: db locked",
+			expCalls: 3,
+		},
+		"gives up": {
+			failures: 10,
+			expErr:   "retrying clean up in the background: db locked",
+			expCalls: 4,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			flaky := &flakyPullCleaner{failures: c.failures}
+			cleaner := &events.RetryingPullCleaner{
+				PullCleaner: flaky,
+				Retries:     3,
+				Delay:       time.Millisecond,
+			}
+			err := cleaner.CleanUpPull(logging.NewNoopLogger(t), testdata.GithubRepo, testdata.Pull)
+			cleaner.Wait()
+			if c.expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, c.expErr, err)
+			}
+			Equals(t, c.expCalls, flaky.calls)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// RunningCommands tracks the plans and applies that are running so they can
+// be canceled from a comment or from their job page. A nil RunningCommands
+// doesn't track anything.
+type RunningCommands struct {
+	mu sync.Mutex
+	// byJobID maps the job ID of each running command to its context.
+	byJobID map[string]command.ProjectContext
+}
+
+// NewRunningCommands returns a RunningCommands that isn't tracking any
+// commands.
+func NewRunningCommands() *RunningCommands {
+	return &RunningCommands{byJobID: map[string]command.ProjectContext{}}
+}
+
+// Track tracks the command of ctx until the returned function is called and
+// returns ctx with the Cancellation that's canceled if the command is.
+func (r *RunningCommands) Track(ctx command.ProjectContext) (command.ProjectContext, func()) {
+	if r == nil {
+		return ctx, func() {}
+	}
+	ctx.Cancellation = command.NewCancellation()
+	r.mu.Lock()
+	r.byJobID[ctx.JobID] = ctx
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.byJobID, ctx.JobID)
+		r.mu.Unlock()
+	}
+}
+
+// CancelPull cancels the running commands of the pull request num of repo
+// for which matches returns true. by is the user canceling them. It returns
+// the contexts of the canceled commands.
+func (r *RunningCommands) CancelPull(repoFullName string, num int, by string, matches func(command.ProjectContext) bool) []command.ProjectContext {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var canceled []command.ProjectContext
+	for _, ctx := range r.byJobID {
+		if ctx.Pull.BaseRepo.FullName != repoFullName || ctx.Pull.Num != num || !matches(ctx) {
+			continue
+		}
+		if !ctx.Cancellation.Canceled() {
+			ctx.Cancellation.Cancel(by)
+			canceled = append(canceled, ctx)
+		}
+	}
+	return canceled
+}
+
+// CancelJob cancels the running command with jobID. It returns false if no
+// command with jobID is running.
+func (r *RunningCommands) CancelJob(jobID string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ctx, ok := r.byJobID[jobID]
+	if !ok {
+		return false
+	}
+	ctx.Cancellation.Cancel("")
+	return true
+}
@@ -0,0 +1,63 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRunningCommands_CancelPull(t *testing.T) {
+	pull := models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}}
+	otherPull := models.PullRequest{Num: 2, BaseRepo: models.Repo{FullName: "owner/repo"}}
+
+	r := events.NewRunningCommands()
+	staging, doneStaging := r.Track(command.ProjectContext{JobID: "1", Pull: pull, Workspace: "staging"})
+	prod, doneProd := r.Track(command.ProjectContext{JobID: "2", Pull: pull, Workspace: "prod"})
+	other, doneOther := r.Track(command.ProjectContext{JobID: "3", Pull: otherPull, Workspace: "staging"})
+	defer doneProd()
+	defer doneOther()
+
+	canceled := r.CancelPull("owner/repo", 1, "alice", func(ctx command.ProjectContext) bool {
+		return ctx.Workspace == "staging"
+	})
+	Equals(t, 1, len(canceled))
+	Equals(t, "1", canceled[0].JobID)
+	Assert(t, staging.Cancellation.Canceled(), "expected the staging command to be canceled")
+	Equals(t, "alice", staging.Cancellation.By())
+	Assert(t, !prod.Cancellation.Canceled(), "expected the prod command to keep running")
+	Assert(t, !other.Cancellation.Canceled(), "expected the other pull's command to keep running")
+
+	// Canceling again doesn't return the already canceled command.
+	canceled = r.CancelPull("owner/repo", 1, "bob", func(command.ProjectContext) bool { return true })
+	Equals(t, 1, len(canceled))
+	Equals(t, "2", canceled[0].JobID)
+	Equals(t, "alice", staging.Cancellation.By())
+
+	// Finished commands aren't tracked anymore.
+	doneStaging()
+	Assert(t, !r.CancelJob("1"), "expected the finished command to not be found")
+}
+
+func TestRunningCommands_CancelJob(t *testing.T) {
+	r := events.NewRunningCommands()
+	ctx, done := r.Track(command.ProjectContext{JobID: "1"})
+	defer done()
+
+	Assert(t, !r.CancelJob("2"), "expected an unknown job to not be found")
+	Assert(t, r.CancelJob("1"), "expected the job to be canceled")
+	Assert(t, ctx.Cancellation.Canceled(), "expected the command to be canceled")
+	Equals(t, "", ctx.Cancellation.By())
+}
+
+func TestRunningCommands_Nil(t *testing.T) {
+	var r *events.RunningCommands
+	ctx, done := r.Track(command.ProjectContext{JobID: "1"})
+	done()
+	Assert(t, ctx.Cancellation == nil, "expected no cancellation")
+	Assert(t, !ctx.Cancellation.Canceled(), "expected a nil cancellation to not be canceled")
+	Assert(t, !r.CancelJob("1"), "expected nothing to be canceled")
+	Equals(t, 0, len(r.CancelPull("owner/repo", 1, "alice", func(command.ProjectContext) bool { return true })))
+}
@@ -0,0 +1,188 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// statusTimeFormat is how the times of the command history are shown.
+const statusTimeFormat = "2006-01-02 15:04 MST"
+
+func NewStatusCommandRunner(
+	vcsClient vcs.Client,
+	pullReqStatusFetcher vcs.PullReqStatusFetcher,
+	prjCmdBuilder ProjectApplyCommandBuilder,
+	workingDir WorkingDir,
+	commandRequirementHandler CommandRequirementHandler,
+	SilenceNoProjects bool,
+) *StatusCommandRunner {
+	return &StatusCommandRunner{
+		vcsClient:                 vcsClient,
+		pullReqStatusFetcher:      pullReqStatusFetcher,
+		prjCmdBuilder:             prjCmdBuilder,
+		workingDir:                workingDir,
+		commandRequirementHandler: commandRequirementHandler,
+		SilenceNoProjects:         SilenceNoProjects,
+	}
+}
+
+// StatusCommandRunner comments a summary of the projects of a pull request
+// from its status in the database: their latest results, who ran them and
+// the apply requirements they're still missing.
+type StatusCommandRunner struct {
+	vcsClient                 vcs.Client
+	pullReqStatusFetcher      vcs.PullReqStatusFetcher
+	prjCmdBuilder             ProjectApplyCommandBuilder
+	workingDir                WorkingDir
+	commandRequirementHandler CommandRequirementHandler
+	// SilenceNoProjects is whether Atlantis should respond to PRs if no projects
+	// are found
+	SilenceNoProjects bool
+}
+
+func (s *StatusCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	var vcsMessage string
+	if ctx.PullStatus == nil || (len(ctx.PullStatus.Projects) == 0 && len(ctx.PullStatus.History) == 0) {
+		ctx.Log.Info("no commands have run on this pull request")
+		if s.SilenceNoProjects {
+			return
+		}
+		vcsMessage = "No plans, policy checks or applies have run on this pull request yet."
+	} else {
+		vcsMessage = renderPullStatus(*ctx.PullStatus, s.missingApplyRequirements(ctx))
+	}
+
+	if commentErr := s.vcsClient.CreateComment(ctx.Log, ctx.Pull.BaseRepo, ctx.Pull.Num, vcsMessage, command.Status.String()); commentErr != nil {
+		ctx.Log.Err("unable to comment: %s", commentErr)
+	}
+}
+
+// missingApplyRequirements returns the apply requirement each planned
+// project is still missing, keyed by projectStatusKey. Projects without a
+// plan to apply aren't included.
+func (s *StatusCommandRunner) missingApplyRequirements(ctx *command.Context) map[string]string {
+	var err error
+	ctx.PullRequestStatus, err = s.pullReqStatusFetcher.FetchPullStatus(ctx.Log, ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get pull request status: %s. Continuing with mergeable and approved assumed false", err)
+	}
+	projectCmds, err := s.prjCmdBuilder.BuildApplyCommands(ctx, &CommentCommand{Name: command.Apply})
+	if err != nil {
+		// The rest of the status is still useful without the requirements.
+		ctx.Log.Warn("unable to build apply commands: %s", err)
+		return nil
+	}
+
+	missing := make(map[string]string)
+	for _, projCtx := range projectCmds {
+		repoDir, err := s.workingDir.GetWorkingDir(projCtx.Pull.BaseRepo, projCtx.Pull, projCtx.Workspace)
+		if err != nil {
+			ctx.Log.Warn("unable to get working dir of %s: %s", projCtx.RepoRelDir, err)
+			continue
+		}
+		failure, err := s.commandRequirementHandler.ValidateApplyProject(repoDir, projCtx)
+		if err == nil && failure == "" {
+			failure, err = s.commandRequirementHandler.ValidateProjectDependencies(projCtx)
+		}
+		if err != nil {
+			failure = err.Error()
+		}
+		missing[projectStatusKey(projCtx.ProjectName, projCtx.RepoRelDir, projCtx.Workspace)] = failure
+	}
+	return missing
+}
+
+// renderPullStatus renders the status comment of pull. missing is the apply
+// requirement each planned project is still missing, or empty if it has
+// none.
+func renderPullStatus(pull models.PullStatus, missing map[string]string) string {
+	lastRuns := make(map[string]models.CommandRun)
+	for _, run := range pull.History {
+		lastRuns[projectStatusKey(run.ProjectName, run.RepoRelDir, run.Workspace)] = run
+	}
+
+	var b strings.Builder
+	b.WriteString("### Atlantis Status\n\n")
+	if len(pull.Projects) == 0 {
+		b.WriteString("No projects have results for the latest commit.\n")
+	} else {
+		b.WriteString("| Project | Dir | Workspace | Status | Policies | Last command | Apply requirements |\n")
+		b.WriteString("|---------|-----|-----------|--------|----------|--------------|--------------------|\n")
+		for _, p := range pull.Projects {
+			key := projectStatusKey(p.ProjectName, p.RepoRelDir, p.Workspace)
+			project := ""
+			if p.ProjectName != "" {
+				project = fmt.Sprintf("`%s`", p.ProjectName)
+			}
+			lastRun := ""
+			if run, ok := lastRuns[key]; ok {
+				lastRun = renderCommandRun(pull.Pull, run)
+			}
+			requirements := ""
+			if failure, ok := missing[key]; ok {
+				requirements = "met"
+				if failure != "" {
+					requirements = failure
+				}
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | `%s` | %s | %s | %s | %s |\n",
+				project, p.RepoRelDir, p.Workspace, strings.ReplaceAll(p.Status.String(), "_", " "), renderPolicyStatus(p.PolicyStatus), lastRun, requirements)
+		}
+	}
+
+	if len(pull.History) > 0 {
+		b.WriteString("\n<details><summary>Command history</summary>\n\n")
+		for i := len(pull.History) - 1; i >= 0; i-- {
+			run := pull.History[i]
+			fmt.Fprintf(&b, "* %s: %s for %s: %s\n",
+				run.Time.UTC().Format(statusTimeFormat),
+				renderCommandRun(pull.Pull, run),
+				renderProjectStatusName(run.ProjectName, run.RepoRelDir, run.Workspace),
+				strings.ReplaceAll(run.Status.String(), "_", " "))
+		}
+		b.WriteString("</details>\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderCommandRun renders who ran the command of run on pull, on which
+// commit and how.
+func renderCommandRun(pull models.PullRequest, run models.CommandRun) string {
+	out := fmt.Sprintf("`%s`", run.Command)
+	if run.User != "" {
+		out += fmt.Sprintf(" by @%s", run.User)
+	}
+	if run.HeadCommit != "" {
+		commit := run.HeadCommit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		out += fmt.Sprintf(" on `%s`", commit)
+	}
+	switch {
+	case run.CommentID > 0 && pull.CommentURL(run.CommentID) != "":
+		out += fmt.Sprintf(" from [comment](%s)", pull.CommentURL(run.CommentID))
+	case run.CommentID > 0:
+		out += fmt.Sprintf(" from comment %d", run.CommentID)
+	case run.Trigger == models.APICommandTrigger:
+		out += " via the API"
+	case run.Trigger == models.AutoCommandTrigger:
+		out += " automatically"
+	}
+	return out
+}
+
+func renderProjectStatusName(projectName string, repoRelDir string, workspace string) string {
+	if projectName != "" {
+		return fmt.Sprintf("project: `%s` dir: `%s` workspace: `%s`", projectName, repoRelDir, workspace)
+	}
+	return fmt.Sprintf("dir: `%s` workspace: `%s`", repoRelDir, workspace)
+}
+
+func projectStatusKey(projectName string, repoRelDir string, workspace string) string {
+	return fmt.Sprintf("%s/%s/%s", projectName, repoRelDir, workspace)
+}
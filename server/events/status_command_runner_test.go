@@ -0,0 +1,123 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStatusCommandRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+	runTime := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		silenced     bool
+		pullStatus   *models.PullStatus
+		expContains  []string
+		expNoComment bool
+	}{
+		{
+			name: "projects with history and missing requirements",
+			pullStatus: &models.PullStatus{
+				Projects: []models.ProjectStatus{
+					{RepoRelDir: "staging", Workspace: "default", Status: models.PlannedPlanStatus},
+					{
+						RepoRelDir:   "prod",
+						Workspace:    "default",
+						ProjectName:  "prod",
+						Status:       models.AppliedPlanStatus,
+						PolicyStatus: []models.PolicySetStatus{{PolicySetName: "policies", Passed: true}},
+					},
+				},
+				History: []models.CommandRun{
+					{Command: "plan", RepoRelDir: "prod", Workspace: "default", ProjectName: "prod", User: "lkysow", HeadCommit: "0123456789", Status: models.PlannedPlanStatus, Time: runTime},
+					{Command: "plan", RepoRelDir: "staging", Workspace: "default", User: "lkysow", HeadCommit: "0123456789", Status: models.PlannedPlanStatus, Time: runTime},
+					{Command: "apply", RepoRelDir: "prod", Workspace: "default", ProjectName: "prod", User: "jdoe", HeadCommit: "0123456789", Status: models.AppliedPlanStatus, Time: runTime.Add(time.Hour)},
+				},
+			},
+			expContains: []string{
+				"|  | `staging` | `default` | planned |  | `plan` by @lkysow on `0123456` | Pull request must be approved according to the project's approval rules before running apply. |",
+				"| `prod` | `prod` | `default` | applied | policies: passed | `apply` by @jdoe on `0123456` |  |",
+				"* 2024-05-01 11:30 UTC: `apply` by @jdoe on `0123456` for project: `prod` dir: `prod` workspace: `default`: applied\n" +
+					"* 2024-05-01 10:30 UTC: `plan` by @lkysow on `0123456` for dir: `staging` workspace: `default`: planned\n",
+			},
+		},
+		{
+			name: "how commands were started",
+			pullStatus: &models.PullStatus{
+				Pull: models.PullRequest{URL: "https://github.com/runatlantis/atlantis/pull/1", BaseRepo: testdata.GithubRepo},
+				History: []models.CommandRun{
+					{Command: "plan", RepoRelDir: "prod", Workspace: "default", User: "lkysow", HeadCommit: "0123456789", Status: models.PlannedPlanStatus, Time: runTime, Trigger: models.AutoCommandTrigger},
+					{Command: "plan", RepoRelDir: "prod", Workspace: "default", User: "lkysow", HeadCommit: "0123456789", Status: models.PlannedPlanStatus, Time: runTime, Trigger: models.APICommandTrigger},
+					{Command: "apply", RepoRelDir: "prod", Workspace: "default", User: "jdoe", HeadCommit: "0123456789", Status: models.AppliedPlanStatus, Time: runTime, Trigger: models.CommentCommandTrigger, CommentID: 42},
+					{Command: "apply", RepoRelDir: "prod", Workspace: "default", User: "jdoe", HeadCommit: "0123456789", Status: models.AppliedPlanStatus, Time: runTime, Trigger: models.CommentCommandTrigger, CommentID: -1},
+				},
+			},
+			expContains: []string{
+				"* 2024-05-01 10:30 UTC: `apply` by @jdoe on `0123456` for dir: `prod` workspace: `default`: applied\n" +
+					"* 2024-05-01 10:30 UTC: `apply` by @jdoe on `0123456` from [comment](https://github.com/runatlantis/atlantis/pull/1#issuecomment-42) for dir: `prod` workspace: `default`: applied\n" +
+					"* 2024-05-01 10:30 UTC: `plan` by @lkysow on `0123456` via the API for dir: `prod` workspace: `default`: planned\n" +
+					"* 2024-05-01 10:30 UTC: `plan` by @lkysow on `0123456` automatically for dir: `prod` workspace: `default`: planned\n",
+			},
+		},
+		{
+			name:        "no commands have run",
+			expContains: []string{"No plans, policy checks or applies have run on this pull request yet."},
+		},
+		{
+			name:         "no comment with no commands and silencing",
+			silenced:     true,
+			expNoComment: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcsClient := setup(t, func(tc *TestConfig) {
+				tc.SilenceNoProjects = tt.silenced
+			})
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			ctx := &command.Context{
+				User:       testdata.User,
+				Log:        logger,
+				Scope:      scopeNull,
+				Pull:       modelPull,
+				PullStatus: tt.pullStatus,
+				HeadRepo:   testdata.GithubRepo,
+				Trigger:    command.CommentTrigger,
+			}
+			cmd := &events.CommentCommand{Name: command.Status}
+
+			stagingCtx := command.ProjectContext{RepoRelDir: "staging", Workspace: "default"}
+			When(projectCommandBuilder.BuildApplyCommands(Eq(ctx), Any[*events.CommentCommand]())).
+				ThenReturn([]command.ProjectContext{stagingCtx}, nil)
+			When(commandRequirementHandler.ValidateApplyProject(Any[string](), Eq(stagingCtx))).
+				ThenReturn("Pull request must be approved according to the project's approval rules before running apply.", nil)
+
+			statusCommandRunner.Run(ctx, cmd)
+
+			if tt.expNoComment {
+				vcsClient.VerifyWasCalled(Never()).CreateComment(
+					Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+				return
+			}
+			_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(
+				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Any[string](), Eq("status")).GetCapturedArguments()
+			for _, exp := range tt.expContains {
+				Assert(t, strings.Contains(comment, exp), "expected comment to contain %q, got %q", exp, comment)
+			}
+		})
+	}
+}
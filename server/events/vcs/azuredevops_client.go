@@ -23,11 +23,18 @@ type AzureDevopsClient struct {
 	UserName string
 }
 
+// Statuses of pull request threads. Resolved is called fixed in the API.
+const (
+	azureDevopsActiveThreadStatus   = "active"
+	azureDevopsResolvedThreadStatus = "fixed"
+)
+
 // NewAzureDevopsClient returns a valid Azure DevOps client.
-func NewAzureDevopsClient(hostname string, userName string, token string) (*AzureDevopsClient, error) {
+func NewAzureDevopsClient(hostname string, userName string, token string, transport http.RoundTripper) (*AzureDevopsClient, error) {
 	tp := azuredevops.BasicAuthTransport{
-		Username: "",
-		Password: strings.TrimSpace(token),
+		Username:  "",
+		Password:  strings.TrimSpace(token),
+		Transport: transport,
 	}
 	httpClient := tp.Client()
 	httpClient.Timeout = time.Second * 10
@@ -137,6 +144,7 @@ func (g *AzureDevopsClient) CreateComment(logger logging.SimpleLogging, repo mod
 		prComments := []*azuredevops.Comment{&prComment}
 		body := azuredevops.GitPullRequestCommentThread{
 			Comments: prComments,
+			Status:   azuredevops.String(azureDevopsActiveThreadStatus),
 		}
 		_, _, err := g.Client.PullRequests.CreateComments(g.ctx, owner, project, repoName, pullNum, &body)
 		if err != nil {
@@ -150,10 +158,61 @@ func (g *AzureDevopsClient) ReactToComment(logger logging.SimpleLogging, repo mo
 	return nil
 }
 
-func (g *AzureDevopsClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error { //nolint: revive
+// HidePrevCommandComments resolves the active threads Atlantis started for
+// command and dir on the pull request. Azure DevOps can't hide comments but
+// collapses resolved threads.
+func (g *AzureDevopsClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
+	logger.Debug("Resolving previous command threads on Azure DevOps pull request %d", pullNum)
+	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
+	threadsURL := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullRequests/%d/threads", owner, project, repoName, pullNum)
+
+	req, err := g.Client.NewRequest("GET", threadsURL+"?api-version=5.1", nil)
+	if err != nil {
+		return err
+	}
+	var threads struct {
+		Value []*azuredevops.GitPullRequestCommentThread `json:"value"`
+	}
+	if _, err := g.Client.Execute(g.ctx, req, &threads); err != nil {
+		return errors.Wrap(err, "listing threads")
+	}
+
+	for _, thread := range threads.Value {
+		if thread.GetStatus() != azureDevopsActiveThreadStatus || !g.isCommandThread(thread, command, dir) {
+			continue
+		}
+		logger.Debug("Resolving thread %d", thread.GetID())
+		req, err := g.Client.NewRequest("PATCH", fmt.Sprintf("%s/%d?api-version=5.1", threadsURL, thread.GetID()),
+			&azuredevops.GitPullRequestCommentThread{Status: azuredevops.String(azureDevopsResolvedThreadStatus)})
+		if err != nil {
+			return err
+		}
+		if _, err := g.Client.Execute(g.ctx, req, nil); err != nil {
+			return errors.Wrapf(err, "resolving thread %d", thread.GetID())
+		}
+	}
 	return nil
 }
 
+// isCommandThread returns true if thread was started by Atlantis for command
+// and dir. Like on GitHub, the first line of Atlantis' comments includes the
+// command and dir.
+func (g *AzureDevopsClient) isCommandThread(thread *azuredevops.GitPullRequestCommentThread, command string, dir string) bool {
+	if len(thread.Comments) == 0 {
+		return false
+	}
+	comment := thread.Comments[0]
+	if comment.Author != nil && !strings.EqualFold(comment.Author.GetUniqueName(), g.UserName) &&
+		!strings.EqualFold(comment.Author.GetDisplayName(), g.UserName) {
+		return false
+	}
+	firstLine, _, _ := strings.Cut(strings.ToLower(comment.GetContent()), "\n")
+	if !strings.Contains(firstLine, strings.ToLower(command)) {
+		return false
+	}
+	return dir == "" || strings.Contains(firstLine, strings.ToLower(dir))
+}
+
 // PullIsApproved returns true if the merge request was approved by another reviewer.
 // https://docs.microsoft.com/en-us/azure/devops/repos/git/branch-policies?view=azure-devops#require-a-minimum-number-of-reviewers
 func (g *AzureDevopsClient) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
@@ -193,33 +252,46 @@ func (g *AzureDevopsClient) DiscardReviews(logger logging.SimpleLogging, repo mo
 
 // PullIsMergeable returns true if the merge request can be merged.
 func (g *AzureDevopsClient) PullIsMergeable(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, _ string, _ []string) (bool, error) { //nolint: revive
+	blockers, err := g.PullMergeBlockers(logger, repo, pull)
+	if err != nil {
+		return false, err
+	}
+	return len(blockers) == 0, nil
+}
+
+// PullMergeBlockers returns a description of each reason the pull request
+// can't be merged: merge conflicts, draft or inactive pull requests and
+// blocking branch policies, ex. build validation, minimum number of reviewers
+// or comment resolution, that haven't been approved.
+func (g *AzureDevopsClient) PullMergeBlockers(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
 	owner, project, repoName := SplitAzureDevopsRepoFullName(repo.FullName)
 
 	opts := azuredevops.PullRequestGetOptions{IncludeWorkItemRefs: true}
 	adPull, _, err := g.Client.PullRequests.GetWithRepo(g.ctx, owner, project, repoName, pull.Num, &opts)
 	if err != nil {
-		return false, errors.Wrap(err, "getting pull request")
+		return nil, errors.Wrap(err, "getting pull request")
 	}
 
 	if *adPull.MergeStatus != azuredevops.MergeSucceeded.String() {
-		return false, nil
+		return []string{fmt.Sprintf("merge status is %q", *adPull.MergeStatus)}, nil
 	}
 
 	if *adPull.IsDraft {
-		return false, nil
+		return []string{"pull request is a draft"}, nil
 	}
 
 	if *adPull.Status != azuredevops.PullActive.String() {
-		return false, nil
+		return []string{fmt.Sprintf("pull request status is %q", *adPull.Status)}, nil
 	}
 
 	projectID := *adPull.Repository.Project.ID
 	artifactID := g.Client.PolicyEvaluations.GetPullRequestArtifactID(projectID, pull.Num)
 	policyEvaluations, _, err := g.Client.PolicyEvaluations.List(g.ctx, owner, project, artifactID, &azuredevops.PolicyEvaluationsListOptions{})
 	if err != nil {
-		return false, errors.Wrap(err, "getting policy evaluations")
+		return nil, errors.Wrap(err, "getting policy evaluations")
 	}
 
+	var blockers []string
 	for _, policyEvaluation := range policyEvaluations {
 		if !*policyEvaluation.Configuration.IsEnabled || *policyEvaluation.Configuration.IsDeleted {
 			continue
@@ -227,7 +299,7 @@ func (g *AzureDevopsClient) PullIsMergeable(logger logging.SimpleLogging, repo m
 
 		// Ignore the Atlantis status, even if its set as a blocker.
 		// This status should not be considered when evaluating if the pull request can be applied.
-		settings := (policyEvaluation.Configuration.Settings).(map[string]interface{})
+		settings, _ := (policyEvaluation.Configuration.Settings).(map[string]interface{})
 		if genre, ok := settings["statusGenre"]; ok && genre == "Atlantis Bot/atlantis" {
 			if name, ok := settings["statusName"]; ok && name == "apply" {
 				continue
@@ -235,11 +307,35 @@ func (g *AzureDevopsClient) PullIsMergeable(logger logging.SimpleLogging, repo m
 		}
 
 		if *policyEvaluation.Configuration.IsBlocking && *policyEvaluation.Status != azuredevops.PolicyEvaluationApproved {
-			return false, nil
+			name := policyName(policyEvaluation.Configuration, settings)
+			logger.Debug("Azure DevOps pull request %d is blocked by policy %q with status %q", pull.Num, name, *policyEvaluation.Status)
+			blockers = append(blockers, fmt.Sprintf("policy %q is %s", name, *policyEvaluation.Status))
 		}
 	}
 
-	return true, nil
+	return blockers, nil
+}
+
+// policyName returns a human readable name for a branch policy, ex.
+// "Minimum number of reviewers", "Build: ci" or "Status: genre/name".
+func policyName(config *azuredevops.PolicyConfiguration, settings map[string]interface{}) string {
+	name := "unknown policy"
+	if config.Type != nil && config.Type.DisplayName != nil {
+		name = *config.Type.DisplayName
+	}
+	if displayName, ok := settings["displayName"].(string); ok && displayName != "" {
+		return fmt.Sprintf("%s: %s", name, displayName)
+	}
+	if statusName, ok := settings["statusName"].(string); ok && statusName != "" {
+		if config.Type == nil || config.Type.DisplayName == nil {
+			name = "Status"
+		}
+		if genre, ok := settings["statusGenre"].(string); ok && genre != "" {
+			statusName = genre + "/" + statusName
+		}
+		return fmt.Sprintf("%s: %s", name, statusName)
+	}
+	return name
 }
 
 // GetPullRequest returns the pull request.
@@ -262,6 +358,8 @@ func (g *AzureDevopsClient) UpdateStatus(logger logging.SimpleLogging, repo mode
 		adState = azuredevops.GitSucceeded.String()
 	case models.FailedCommitStatus:
 		adState = azuredevops.GitFailed.String()
+	case models.CanceledCommitStatus:
+		adState = azuredevops.GitError.String()
 	}
 
 	logger.Info("Updating Azure DevOps commit status for '%s' to '%s'", src, adState)
@@ -2,6 +2,7 @@ package vcs_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -102,7 +103,7 @@ func TestAzureDevopsClient_MergePull(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token", nil)
 			client.Client.VsaexBaseURL = *testServerURL
 			Ok(t, err)
 			defer disableSSLVerification()()
@@ -219,7 +220,7 @@ func TestAzureDevopsClient_UpdateStatus(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token", nil)
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -286,7 +287,7 @@ func TestAzureDevopsClient_GetModifiedFiles(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+	client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token", nil)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -321,6 +322,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 		mergeStatus  string
 		policy       Policy
 		expMergeable bool
+		expBlockers  []string
 	}{
 		{
 			"merge conflicts",
@@ -331,6 +333,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				"approved",
 			},
 			false,
+			[]string{`merge status is "conflicts"`},
 		},
 		{
 			"rejected policy status",
@@ -341,6 +344,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				"rejected",
 			},
 			false,
+			[]string{`policy "Status: Not Atlantis/foo" is rejected`},
 		},
 		{
 			"merge succeeded",
@@ -351,6 +355,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				"approved",
 			},
 			true,
+			nil,
 		},
 		{
 			"pending policy status",
@@ -361,6 +366,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				"pending",
 			},
 			false,
+			[]string{`policy "Status: Not Atlantis/foo" is pending`},
 		},
 		{
 			"atlantis apply status rejected",
@@ -371,6 +377,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				"rejected",
 			},
 			true,
+			nil,
 		},
 	}
 
@@ -409,7 +416,7 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
 
-			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token", nil)
 			Ok(t, err)
 
 			defer disableSSLVerification()()
@@ -431,6 +438,20 @@ func TestAzureDevopsClient_PullIsMergeable(t *testing.T) {
 				}, "atlantis-test", []string{})
 			Ok(t, err)
 			Equals(t, c.expMergeable, actMergeable)
+
+			actBlockers, err := client.PullMergeBlockers(
+				logger,
+				models.Repo{
+					FullName: "owner/project/repo",
+					VCSHost: models.VCSHost{
+						Type:     models.AzureDevops,
+						Hostname: "dev.azure.com",
+					},
+				}, models.PullRequest{
+					Num: 1,
+				})
+			Ok(t, err)
+			Equals(t, c.expBlockers, actBlockers)
 		})
 	}
 }
@@ -506,7 +527,7 @@ func TestAzureDevopsClient_PullIsApproved(t *testing.T) {
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
 
-			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+			client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token", nil)
 			Ok(t, err)
 
 			defer disableSSLVerification()()
@@ -554,7 +575,7 @@ func TestAzureDevopsClient_GetPullRequest(t *testing.T) {
 			}))
 		testServerURL, err := url.Parse(testServer.URL)
 		Ok(t, err)
-		client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token")
+		client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "user", "token", nil)
 		Ok(t, err)
 		defer disableSSLVerification()()
 
@@ -575,8 +596,72 @@ func TestAzureDevopsClient_GetPullRequest(t *testing.T) {
 	})
 }
 
+func TestAzureDevopsClient_CreateComment(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	var thread azuredevops.GitPullRequestCommentThread
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/owner/project/_apis/git/repositories/repo/pullrequests/1/threads?api-version=5.1-preview.1":
+				Ok(t, json.NewDecoder(r.Body).Decode(&thread))
+				w.Write([]byte("{}")) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "atlantis", "token", nil)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	err = client.CreateComment(logger, models.Repo{FullName: "owner/project/repo"}, 1, "Ran Plan for dir: `dir1`", "plan")
+	Ok(t, err)
+	Equals(t, "active", thread.GetStatus())
+	Equals(t, "Ran Plan for dir: `dir1`", thread.Comments[0].GetContent())
+}
+
+// Only the active threads Atlantis started for the command and dir should be
+// resolved.
+func TestAzureDevopsClient_HidePrevCommandComments(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	threads := `{"count": 5, "value": [
+		{"id": 1, "status": "active", "comments": [{"author": {"uniqueName": "atlantis"}, "content": "Ran Plan for dir: ` + "`dir1`" + ` workspace: ` + "`default`" + `\n\nplan output"}]},
+		{"id": 2, "status": "fixed", "comments": [{"author": {"uniqueName": "atlantis"}, "content": "Ran Plan for dir: ` + "`dir1`" + ` workspace: ` + "`default`" + `"}]},
+		{"id": 3, "status": "active", "comments": [{"author": {"uniqueName": "someone"}, "content": "Ran Plan for dir: ` + "`dir1`" + `"}]},
+		{"id": 4, "status": "active", "comments": [{"author": {"uniqueName": "Atlantis"}, "content": "Ran Apply for dir: ` + "`dir1`" + `"}]},
+		{"id": 5, "status": "active", "comments": [{"author": {"uniqueName": "atlantis"}, "content": "Ran Plan for dir: ` + "`dir2`" + `"}]}
+	]}`
+	var resolved []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == "GET" && r.RequestURI == "/owner/project/_apis/git/repositories/repo/pullRequests/1/threads?api-version=5.1":
+				w.Write([]byte(threads)) // nolint: errcheck
+			case r.Method == "PATCH":
+				body, err := io.ReadAll(r.Body)
+				Ok(t, err)
+				resolved = append(resolved, r.RequestURI+" "+strings.TrimSpace(string(body)))
+				w.Write([]byte("{}")) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewAzureDevopsClient(testServerURL.Host, "atlantis", "token", nil)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	err = client.HidePrevCommandComments(logger, models.Repo{FullName: "owner/project/repo"}, 1, "Plan", "dir1")
+	Ok(t, err)
+	Equals(t, []string{`/owner/project/_apis/git/repositories/repo/pullRequests/1/threads/1?api-version=5.1 {"status":"fixed"}`}, resolved)
+}
+
 func TestAzureDevopsClient_MarkdownPullLink(t *testing.T) {
-	client, err := vcs.NewAzureDevopsClient("hostname", "user", "token")
+	client, err := vcs.NewAzureDevopsClient("hostname", "user", "token", nil)
 	Ok(t, err)
 	pull := models.PullRequest{Num: 1}
 	s, _ := client.MarkdownPullLink(pull)
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	validator "github.com/go-playground/validator/v10"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -21,6 +24,9 @@ type Client struct {
 	Password    string
 	BaseURL     string
 	AtlantisURL string
+	// diffStatCache caches the diffstat pages of pull requests, which are
+	// requested more than once per command.
+	diffStatCache responseCache
 }
 
 // NewClient builds a bitbucket cloud client. atlantisURL is the
@@ -42,50 +48,58 @@ func NewClient(httpClient *http.Client, username string, password string, atlant
 
 var MY_UUID = ""
 
+// codeOwnersFiles are the paths, in order of precedence, of the CODEOWNERS
+// file of a repo.
+var codeOwnersFiles = []string{".bitbucket/CODEOWNERS", "CODEOWNERS"}
+
 // GetModifiedFiles returns the names of files that were modified in the merge request
 // relative to the repo root, e.g. parent/child/file.txt.
 func (b *Client) GetModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
 	var files []string
+	err := b.ListModifiedFiles(logger, repo, pull, func(page []string) bool {
+		files = append(files, page...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
 
+// ListModifiedFiles calls f with each page of the diffstat of the pull
+// request until f returns false. Files are only listed once across pages.
+func (b *Client) ListModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error {
+	seen := make(map[string]bool)
 	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/diffstat", b.BaseURL, repo.FullName, pull.Num)
 	// We'll only loop 1000 times as a safety measure.
 	maxLoops := 1000
 	for i := 0; i < maxLoops; i++ {
-		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		resp, err := b.makeCachedRequest(nextPageURL, pull.HeadCommit)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		var diffStat DiffStat
 		if err := json.Unmarshal(resp, &diffStat); err != nil {
-			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+			return errors.Wrapf(err, "Could not parse response %q", string(resp))
 		}
 		if err := validator.New().Struct(diffStat); err != nil {
-			return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+			return errors.Wrapf(err, "API response %q was missing fields", string(resp))
 		}
+		var files []string
 		for _, v := range diffStat.Values {
-			if v.Old != nil {
-				files = append(files, *v.Old.Path)
-			}
-			if v.New != nil {
-				files = append(files, *v.New.Path)
+			for _, file := range []*DiffStatFile{v.Old, v.New} {
+				if file != nil && !seen[*file.Path] {
+					seen[*file.Path] = true
+					files = append(files, *file.Path)
+				}
 			}
 		}
-		if diffStat.Next == nil || *diffStat.Next == "" {
+		if !f(files) || diffStat.Next == nil || *diffStat.Next == "" {
 			break
 		}
 		nextPageURL = *diffStat.Next
 	}
-
-	// Now ensure all files are unique.
-	hash := make(map[string]bool)
-	var unique []string
-	for _, f := range files {
-		if !hash[f] {
-			unique = append(unique, f)
-			hash[f] = true
-		}
-	}
-	return unique, nil
+	return nil
 }
 
 // CreateComment creates a comment on the merge request.
@@ -104,12 +118,91 @@ func (b *Client) CreateComment(logger logging.SimpleLogging, repo models.Repo, p
 	return err
 }
 
-// UpdateComment updates the body of a comment on the merge request.
+// UpdateComment replaces the body of a comment on the merge request. Only
+// comments made by the Atlantis user can be updated.
+func (b *Client) UpdateComment(repo models.Repo, pullNum int, commentID int, comment string) error {
+	bodyBytes, err := json.Marshal(map[string]map[string]string{"content": {
+		"raw": comment,
+	}})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments/%d", b.BaseURL, repo.FullName, pullNum, commentID)
+	_, err = b.makeRequest("PUT", path, bytes.NewBuffer(bodyBytes))
+	return err
+}
+
+// UpdateLastComment replaces the body of the last comment Atlantis made for
+// command on the merge request. It returns false if there's no such comment.
+func (b *Client) UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error) {
+	me, err := b.GetMyUUID()
+	if err != nil {
+		return false, errors.Wrapf(err, "Cannot get my uuid! Please check required scope of the auth token!")
+	}
+	comments, err := b.GetPullRequestComments(repo, pullNum)
+	if err != nil {
+		return false, err
+	}
+
+	var last *PullRequestComment
+	for i, c := range comments {
+		if !strings.EqualFold(*c.User.UUID, me) {
+			continue
+		}
+		firstLine := strings.ToLower(strings.Split(c.Content.Raw, "\n")[0])
+		if !strings.Contains(firstLine, strings.ToLower(command)) {
+			continue
+		}
+		if dir != "" && !strings.Contains(firstLine, strings.ToLower(dir)) {
+			continue
+		}
+		last = &comments[i]
+	}
+	if last == nil {
+		return false, nil
+	}
+
+	logger.Debug("Updating comment with id %d", *last.ID)
+	if err := b.UpdateComment(repo, pullNum, *last.ID, comment); err != nil {
+		return false, errors.Wrapf(err, "updating comment %d", *last.ID)
+	}
+	return true, nil
+}
+
 func (b *Client) ReactToComment(_ logging.SimpleLogging, _ models.Repo, _ int, _ int64, _ string) error {
 	// TODO: Bitbucket support for reactions
 	return nil
 }
 
+// CreateAcknowledgment comments text on the merge request. Bitbucket has no
+// reactions so commands are acknowledged with a comment.
+func (b *Client) CreateAcknowledgment(_ logging.SimpleLogging, repo models.Repo, pullNum int, text string) (int64, error) {
+	bodyBytes, err := json.Marshal(map[string]map[string]string{"content": {
+		"raw": text,
+	}})
+	if err != nil {
+		return 0, errors.Wrap(err, "json encoding")
+	}
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments", b.BaseURL, repo.FullName, pullNum)
+	resp, err := b.makeRequest("POST", path, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return 0, err
+	}
+	var comment PullRequestComment
+	if err := json.Unmarshal(resp, &comment); err != nil {
+		return 0, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if comment.ID == nil {
+		return 0, fmt.Errorf("no comment id in response %q", string(resp))
+	}
+	return int64(*comment.ID), nil
+}
+
+// DeleteAcknowledgment deletes the comment id made by CreateAcknowledgment.
+func (b *Client) DeleteAcknowledgment(_ logging.SimpleLogging, repo models.Repo, pullNum int, id int64) error {
+	return b.DeletePullRequestComment(repo, pullNum, int(id))
+}
+
 func (b *Client) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, _ string) error {
 	// there is no way to hide comment, so delete them instead
 	me, err := b.GetMyUUID()
@@ -155,18 +248,29 @@ func (b *Client) DeletePullRequestComment(repo models.Repo, pullNum int, comment
 	return nil
 }
 
+// GetPullRequestComments returns all the comments on the merge request,
+// oldest first.
 func (b *Client) GetPullRequestComments(repo models.Repo, pullNum int) (comments []PullRequestComment, err error) {
-	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments", b.BaseURL, repo.FullName, pullNum)
-	res, err := b.makeRequest("GET", path, nil)
-	if err != nil {
-		return comments, err
-	}
+	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/comments", b.BaseURL, repo.FullName, pullNum)
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		res, err := b.makeRequest("GET", nextPageURL, nil)
+		if err != nil {
+			return comments, err
+		}
 
-	var pulls PullRequestComments
-	if err := json.Unmarshal(res, &pulls); err != nil {
-		return comments, errors.Wrapf(err, "Could not parse response %q", string(res))
+		var pulls PullRequestComments
+		if err := json.Unmarshal(res, &pulls); err != nil {
+			return comments, errors.Wrapf(err, "Could not parse response %q", string(res))
+		}
+		comments = append(comments, pulls.Values...)
+		if pulls.Next == nil || *pulls.Next == "" {
+			break
+		}
+		nextPageURL = *pulls.Next
 	}
-	return pulls.Values, nil
+	return comments, nil
 }
 
 func (b *Client) GetMyUUID() (uuid string, err error) {
@@ -196,6 +300,32 @@ func (b *Client) GetMyUUID() (uuid string, err error) {
 	}
 }
 
+// ValidateCredentials checks that the username and app password are valid.
+func (b *Client) ValidateCredentials(logger logging.SimpleLogging) error {
+	req, err := b.prepRequest("GET", fmt.Sprintf("%s/2.0/user", b.BaseURL), nil)
+	if err != nil {
+		return errors.Wrap(err, "constructing request")
+	}
+	resp, err := b.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	logger.Debug("GET /2.0/user returned: %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("the Bitbucket app password of --bitbucket-user %q is invalid, create a new one as described in https://www.runatlantis.io/docs/access-credentials.html#bitbucket-cloud-bitbucket-org", b.Username)
+	case http.StatusForbidden:
+		// The app password is valid but lacks the Account: Read permission,
+		// which is only needed to hide previous comments.
+		return nil
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("getting the user of the Bitbucket app password: unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+}
+
 // PullIsApproved returns true if the merge request was approved.
 func (b *Client) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
 	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
@@ -229,7 +359,7 @@ func (b *Client) PullIsMergeable(logger logging.SimpleLogging, repo models.Repo,
 	// We'll only loop 1000 times as a safety measure.
 	maxLoops := 1000
 	for i := 0; i < maxLoops; i++ {
-		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		resp, err := b.makeCachedRequest(nextPageURL, pull.HeadCommit)
 		if err != nil {
 			return false, err
 		}
@@ -264,6 +394,8 @@ func (b *Client) UpdateStatus(logger logging.SimpleLogging, repo models.Repo, pu
 		bbState = "SUCCESSFUL"
 	case models.FailedCommitStatus:
 		bbState = "FAILED"
+	case models.CanceledCommitStatus:
+		bbState = "STOPPED"
 	}
 
 	logger.Info("Updating BitBucket commit status for '%s' to '%s'", src, bbState)
@@ -350,6 +482,53 @@ func (b *Client) makeRequest(method string, path string, reqBody io.Reader) ([]b
 	return respBody, nil
 }
 
+// makeCachedRequest makes a GET request to path for the pull request at
+// headCommit. Responses are cached for responseCacheTTL and then revalidated
+// with their ETag, if Bitbucket returned one.
+func (b *Client) makeCachedRequest(path string, headCommit string) ([]byte, error) {
+	// The diffstat URLs don't change with the commits of the pull request so
+	// the head commit is part of the key.
+	key := headCommit + " " + path
+	cached, ok := b.diffStatCache.Get(key)
+	if ok && time.Since(cached.FetchedAt) <= responseCacheTTL {
+		return cached.Body, nil
+	}
+
+	req, err := b.prepRequest("GET", path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing request")
+	}
+	if ok && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	resp, err := b.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	requestStr := fmt.Sprintf("GET %s", path)
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		cached.FetchedAt = time.Now()
+		b.diffStatCache.Set(key, cached)
+		return cached.Body, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("making request %q unexpected status code: %d, body: %s", requestStr, resp.StatusCode, string(respBody))
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading response from request %q", requestStr)
+	}
+	b.diffStatCache.Set(key, cachedResponse{
+		ETag:      resp.Header.Get("ETag"),
+		Body:      respBody,
+		FetchedAt: time.Now(),
+	})
+	return respBody, nil
+}
+
 // GetTeamNamesForUser returns the names of the teams or groups that the user belongs to (in the organization the repository belongs to).
 func (b *Client) GetTeamNamesForUser(_ logging.SimpleLogging, _ models.Repo, _ models.User) ([]string, error) {
 	return nil, nil
@@ -373,3 +552,195 @@ func (b *Client) GetCloneURL(_ logging.SimpleLogging, _ models.VCSHostType, _ st
 func (b *Client) GetPullLabels(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest) ([]string, error) {
 	return nil, fmt.Errorf("not yet implemented")
 }
+
+// webhookEvents are the events the webhooks of repos must send.
+var webhookEvents = []string{
+	"pullrequest:created",
+	"pullrequest:updated",
+	"pullrequest:fulfilled",
+	"pullrequest:rejected",
+	"pullrequest:comment_created",
+}
+
+// ListOrgRepos returns the repos of the workspace org.
+func (b *Client) ListOrgRepos(_ logging.SimpleLogging, org string) ([]string, error) {
+	var repos []string
+	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s?pagelen=100", b.BaseURL, org)
+	for nextPageURL != "" {
+		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page Repositories
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		for _, repo := range page.Values {
+			if repo.FullName != nil {
+				repos = append(repos, *repo.FullName)
+			}
+		}
+		nextPageURL = ""
+		if page.Next != nil {
+			nextPageURL = *page.Next
+		}
+	}
+	return repos, nil
+}
+
+// RegisterWebhook creates or updates the webhook of the repo to url. Other
+// events the webhook sends are kept.
+func (b *Client) RegisterWebhook(_ logging.SimpleLogging, repoFullName string, url string, secret string, updateSecret bool, dryRun bool) (string, error) {
+	var hooks []Webhook
+	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/hooks?pagelen=100", b.BaseURL, repoFullName)
+	for nextPageURL != "" {
+		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		if err != nil {
+			return "", err
+		}
+		var page Webhooks
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return "", errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		hooks = append(hooks, page.Values...)
+		nextPageURL = ""
+		if page.Next != nil {
+			nextPageURL = *page.Next
+		}
+	}
+
+	hook := Webhook{Description: "Atlantis", URL: url, Active: true, Events: webhookEvents, Secret: secret}
+	method, path, result := "POST", fmt.Sprintf("%s/2.0/repositories/%s/hooks", b.BaseURL, repoFullName), vcs.WebhookCreated
+	for _, existing := range hooks {
+		if !strings.EqualFold(strings.TrimSuffix(existing.URL, "/"), strings.TrimSuffix(url, "/")) {
+			continue
+		}
+		events := existing.Events
+		for _, event := range webhookEvents {
+			if !slices.Contains(events, event) {
+				events = append(events, event)
+			}
+		}
+		if existing.Active && len(events) == len(existing.Events) && !updateSecret {
+			return vcs.WebhookUnchanged, nil
+		}
+		hook.Description, hook.Events = existing.Description, events
+		method, path, result = "PUT", fmt.Sprintf("%s/2.0/repositories/%s/hooks/%s", b.BaseURL, repoFullName, existing.UUID), vcs.WebhookUpdated
+		break
+	}
+	if dryRun {
+		return result, nil
+	}
+	body, err := json.Marshal(hook)
+	if err != nil {
+		return "", errors.Wrap(err, "json encoding")
+	}
+	if _, err := b.makeRequest(method, path, bytes.NewBuffer(body)); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// UnapprovedFiles returns the files, out of files, that have owners and that
+// none of their owners approved pull. The owners of a file are the ones the
+// CODEOWNERS file of the base branch lists for it, or the default reviewers
+// of the repo if no pattern matches it. Owners are @nicknames or {uuid}s.
+// Like for PullIsApproved, the author's own approval doesn't count.
+func (b *Client) UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error) {
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var pullResp PullRequest
+	if err := json.Unmarshal(resp, &pullResp); err != nil {
+		return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(pullResp); err != nil {
+		return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+	var approvers []string
+	for _, participant := range pullResp.Participants {
+		if !*participant.Approved || *participant.User.UUID == *pullResp.Author.UUID {
+			continue
+		}
+		approvers = append(approvers, *participant.User.UUID)
+		if participant.User.Nickname != nil {
+			approvers = append(approvers, "@"+*participant.User.Nickname)
+		}
+	}
+
+	// The CODEOWNERS file is read from the base branch so pull requests
+	// can't change their own owners.
+	var codeOwners vcs.CodeOwners
+	for _, file := range codeOwnersFiles {
+		content, found, err := b.getFileContent(repo, *pullResp.Destination.Commit.Hash, file)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			codeOwners = vcs.ParseCodeOwners(string(content))
+			break
+		}
+	}
+	defaultReviewers, err := b.getDefaultReviewers(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	unapproved := codeOwners.UnapprovedFiles(files, defaultReviewers, approvers)
+	logger.Debug("%d/%d files of pull request %d aren't approved by their owners", len(unapproved), len(files), pull.Num)
+	return unapproved, nil
+}
+
+// getFileContent returns the content of the file at path in repo at commit,
+// and false if it doesn't exist.
+func (b *Client) getFileContent(repo models.Repo, commit string, path string) ([]byte, bool, error) {
+	req, err := b.prepRequest("GET", fmt.Sprintf("%s/2.0/repositories/%s/src/%s/%s", b.BaseURL, repo.FullName, commit, path), nil)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "constructing request")
+	}
+	resp, err := b.HTTPClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "reading %s", path)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("getting %s unexpected status code: %d, body: %s", path, resp.StatusCode, string(body))
+	}
+}
+
+// getDefaultReviewers returns the default reviewers of repo as {uuid}s.
+func (b *Client) getDefaultReviewers(repo models.Repo) ([]string, error) {
+	var reviewers []string
+	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/default-reviewers?pagelen=100", b.BaseURL, repo.FullName)
+	for nextPageURL != "" {
+		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page DefaultReviewers
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		for _, reviewer := range page.Values {
+			if reviewer.UUID != nil {
+				reviewers = append(reviewers, *reviewer.UUID)
+			}
+		}
+		nextPageURL = ""
+		if page.Next != nil {
+			nextPageURL = *page.Next
+		}
+	}
+	return reviewers, nil
+}
@@ -0,0 +1,63 @@
+package bitbucketcloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// The diffstat of a pull request should be requested once per command and
+// revalidated with its ETag afterwards.
+func TestClient_DiffStatCache(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	diffStat := `{"values": [{"status": "modified", "old": {"path": "main.tf"}, "new": {"path": "main.tf"}}]}`
+	var requests, notModified int
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(diffStat)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	client := NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	repo := models.Repo{FullName: "owner/repo"}
+	pull := models.PullRequest{Num: 1, HeadCommit: "abc123"}
+
+	mergeable, err := client.PullIsMergeable(logger, repo, pull, "atlantis-test", nil)
+	Ok(t, err)
+	Equals(t, true, mergeable)
+	files, err := client.GetModifiedFiles(logger, repo, pull)
+	Ok(t, err)
+	Equals(t, []string{"main.tf"}, files)
+	Equals(t, 1, requests)
+
+	// A new commit isn't served from the cache.
+	pull.HeadCommit = "def456"
+	_, err = client.GetModifiedFiles(logger, repo, pull)
+	Ok(t, err)
+	Equals(t, 2, requests)
+	Equals(t, 0, notModified)
+
+	// Once the TTL passed the response is revalidated.
+	key := pull.HeadCommit + " " + testServer.URL + "/2.0/repositories/owner/repo/pullrequests/1/diffstat"
+	cached, ok := client.diffStatCache.Get(key)
+	Assert(t, ok, "expected the diffstat to be cached")
+	cached.FetchedAt = time.Now().Add(-2 * responseCacheTTL)
+	client.diffStatCache.Set(key, cached)
+	files, err = client.GetModifiedFiles(logger, repo, pull)
+	Ok(t, err)
+	Equals(t, []string{"main.tf"}, files)
+	Equals(t, 3, requests)
+	Equals(t, 1, notModified)
+}
@@ -2,6 +2,7 @@ package bitbucketcloud_test
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -524,3 +525,218 @@ func TestClient_HidePRComments(t *testing.T) {
 	Ok(t, err)
 	Equals(t, 2, called)
 }
+
+func TestClient_UpdateComment(t *testing.T) {
+	var body string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/2.0/repositories/myorg/myrepo/pullrequests/5/comments/498931882":
+			Equals(t, "PUT", r.Method)
+			b, err := io.ReadAll(r.Body)
+			Ok(t, err)
+			body = string(b)
+			w.Write([]byte("{}")) // nolint: errcheck
+			return
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	err := client.UpdateComment(models.Repo{FullName: "myorg/myrepo"}, 5, 498931882, "new comment")
+	Ok(t, err)
+	Equals(t, `{"content":{"raw":"new comment"}}`, body)
+}
+
+func TestClient_UpdateLastComment(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	comments, err := os.ReadFile(filepath.Join("testdata", "comments.json"))
+	Ok(t, err)
+	json, err := os.ReadFile(filepath.Join("testdata", "user.json"))
+	Ok(t, err)
+
+	var updated []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/2.0/repositories/myorg/myrepo/pullrequests/5/comments/498931784",
+			"/2.0/repositories/myorg/myrepo/pullrequests/5/comments/498931882":
+			Equals(t, "PUT", r.Method)
+			updated = append(updated, r.RequestURI)
+			w.Write([]byte("{}")) // nolint: errcheck
+			return
+		case "/2.0/repositories/myorg/myrepo/pullrequests/5/comments":
+			w.Write(comments) // nolint: errcheck
+			return
+		case "/2.0/user":
+			w.Write(json) // nolint: errcheck
+			return
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	repo := models.Repo{FullName: "myorg/myrepo"}
+
+	// Only the last of our own comments for the command is updated.
+	ok, err := client.UpdateLastComment(logger, repo, 5, "new comment", "plan", "")
+	Ok(t, err)
+	Equals(t, true, ok)
+	Equals(t, []string{"/2.0/repositories/myorg/myrepo/pullrequests/5/comments/498931882"}, updated)
+
+	// Comments made by other users aren't updated.
+	ok, err = client.UpdateLastComment(logger, repo, 5, "new comment", "apply", "")
+	Ok(t, err)
+	Equals(t, false, ok)
+	Equals(t, 1, len(updated))
+}
+
+func TestClient_GetPullRequestCommentsPagination(t *testing.T) {
+	var serverURL string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/2.0/repositories/myorg/myrepo/pullrequests/5/comments":
+			resp := fmt.Sprintf(`{"values":[{"id":1,"content":{"raw":"first"}}],"next":"%s/2.0/repositories/myorg/myrepo/pullrequests/5/comments?page=2"}`, serverURL)
+			w.Write([]byte(resp)) // nolint: errcheck
+			return
+		case "/2.0/repositories/myorg/myrepo/pullrequests/5/comments?page=2":
+			w.Write([]byte(`{"values":[{"id":2,"content":{"raw":"second"}}]}`)) // nolint: errcheck
+			return
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	}))
+	defer testServer.Close()
+	serverURL = testServer.URL
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	comments, err := client.GetPullRequestComments(models.Repo{FullName: "myorg/myrepo"}, 5)
+	Ok(t, err)
+	Equals(t, 2, len(comments))
+	Equals(t, "first", comments[0].Content.Raw)
+	Equals(t, "second", comments[1].Content.Raw)
+}
+
+func TestClient_ValidateCredentials(t *testing.T) {
+	cases := map[int]string{
+		http.StatusOK:           "",
+		http.StatusForbidden:    "",
+		http.StatusUnauthorized: `the Bitbucket app password of --bitbucket-user "user" is invalid, create a new one as described in https://www.runatlantis.io/docs/access-credentials.html#bitbucket-cloud-bitbucket-org`,
+		http.StatusBadGateway:   "getting the user of the Bitbucket app password: unexpected status code: 502, body: bad gateway",
+	}
+	for status, expErr := range cases {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.RequestURI != "/2.0/user" {
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+				}
+				w.WriteHeader(status)
+				if status == http.StatusBadGateway {
+					w.Write([]byte("bad gateway")) // nolint: errcheck
+				}
+			}))
+			defer testServer.Close()
+
+			client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+			client.BaseURL = testServer.URL
+			err := client.ValidateCredentials(logging.NewNoopLogger(t))
+			if expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, expErr, err)
+			}
+		})
+	}
+}
+
+func TestClient_RegisterWebhook(t *testing.T) {
+	var requests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.RequestURI {
+		case "GET /2.0/repositories/workspace?pagelen=100":
+			fmt.Fprintf(w, `{"values": [{"full_name": "workspace/new"}], "next": "http://%s/2.0/repositories/workspace?pagelen=100&page=2"}`, r.Host)
+		case "GET /2.0/repositories/workspace?pagelen=100&page=2":
+			w.Write([]byte(`{"values": [{"full_name": "workspace/drifted"}]}`)) // nolint: errcheck
+		case "GET /2.0/repositories/workspace/new/hooks?pagelen=100":
+			w.Write([]byte(`{"values": []}`)) // nolint: errcheck
+		case "GET /2.0/repositories/workspace/drifted/hooks?pagelen=100":
+			w.Write([]byte(`{"values": [{"uuid": "{1}", "description": "Atlantis", "url": "https://atlantis.example.com/events/", "active": true, "events": ["pullrequest:created"]}]}`)) // nolint: errcheck
+		case "POST /2.0/repositories/workspace/new/hooks", "PUT /2.0/repositories/workspace/drifted/hooks/%7B1%7D":
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+string(body))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+	logger := logging.NewNoopLogger(t)
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+
+	repos, err := client.ListOrgRepos(logger, "workspace")
+	Ok(t, err)
+	Equals(t, []string{"workspace/new", "workspace/drifted"}, repos)
+
+	result, err := client.RegisterWebhook(logger, "workspace/new", "https://atlantis.example.com/events", "s3cr3t", false, false)
+	Ok(t, err)
+	Equals(t, "created", result)
+	result, err = client.RegisterWebhook(logger, "workspace/drifted", "https://atlantis.example.com/events", "s3cr3t", false, false)
+	Ok(t, err)
+	Equals(t, "updated", result)
+	Equals(t, []string{
+		`POST {"description":"Atlantis","url":"https://atlantis.example.com/events","active":true,"events":["pullrequest:created","pullrequest:updated","pullrequest:fulfilled","pullrequest:rejected","pullrequest:comment_created"],"secret":"s3cr3t"}`,
+		`PUT {"description":"Atlantis","url":"https://atlantis.example.com/events","active":true,"events":["pullrequest:created","pullrequest:updated","pullrequest:fulfilled","pullrequest:rejected","pullrequest:comment_created"],"secret":"s3cr3t"}`,
+	}, requests)
+}
+
+func TestClient_UnapprovedFiles(t *testing.T) {
+	// In pull-approved.json, Atlantisbot approved the pull request and the
+	// author Luke didn't.
+	pull, err := os.ReadFile(filepath.Join("testdata", "pull-approved.json"))
+	Ok(t, err)
+	// Files no pattern matches are owned by the default reviewers.
+	codeOwners := `*.tfvars @platform
+/env/prod/ @atlantisbot
+/env/staging/ @luke
+/docs/
+`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/2.0/repositories/owner/repo/pullrequests/1":
+			w.Write(pull) // nolint: errcheck
+		case "/2.0/repositories/owner/repo/src/c641f2c615ad/.bitbucket/CODEOWNERS":
+			http.Error(w, "not found", http.StatusNotFound)
+		case "/2.0/repositories/owner/repo/src/c641f2c615ad/CODEOWNERS":
+			w.Write([]byte(codeOwners)) // nolint: errcheck
+		case "/2.0/repositories/owner/repo/default-reviewers?pagelen=100":
+			w.Write([]byte(`{"values": [{"uuid": "{73686412-4495-426f-89a7-c69ff1c8d7b8}", "nickname": "Atlantisbot"}]}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+
+	unapproved, err := client.UnapprovedFiles(logging.NewNoopLogger(t), repo, models.PullRequest{Num: 1, BaseRepo: repo},
+		[]string{"main.tf", "prod.tfvars", "env/prod/main.tf", "env/staging/main.tf", "docs/README.md"})
+	Ok(t, err)
+	Equals(t, []string{"prod.tfvars", "env/staging/main.tf"}, unapproved)
+}
@@ -6,8 +6,29 @@ const (
 	PullFulfilledHeader      = "pullrequest:fulfilled"
 	PullRejectedHeader       = "pullrequest:rejected"
 	PullCommentCreatedHeader = "pullrequest:comment_created"
+	RepoPushHeader           = "repo:push"
 )
 
+// PushEvent is the part of a push event that's needed to find the branches
+// that were deleted.
+type PushEvent struct {
+	Repository *Repository `json:"repository,omitempty" validate:"required"`
+	Push       *struct {
+		Changes []PushChange `json:"changes,omitempty"`
+	} `json:"push,omitempty" validate:"required"`
+}
+
+// PushChange is a ref that was pushed. New is nil if the ref was deleted.
+type PushChange struct {
+	Old *PushRef `json:"old,omitempty"`
+	New *PushRef `json:"new,omitempty"`
+}
+
+type PushRef struct {
+	Type *string `json:"type,omitempty"`
+	Name *string `json:"name,omitempty"`
+}
+
 type CommentEvent struct {
 	CommonEventData
 	Comment *Comment `json:"comment,omitempty" validate:"required"`
@@ -17,6 +38,24 @@ type PullRequestEvent struct {
 	CommonEventData
 }
 
+// ClosedPullRequestEvent is the part of a pull request event that's needed to
+// clean up after a merged or declined pull request. The source branch or fork
+// of a closed pull request may have been deleted, in which case the event
+// doesn't have it, so only the fields used to find its locks are required.
+type ClosedPullRequestEvent struct {
+	Actor       *Actor             `json:"actor,omitempty" validate:"-"`
+	Repository  *Repository        `json:"repository,omitempty" validate:"required"`
+	PullRequest *ClosedPullRequest `json:"pullrequest,omitempty" validate:"required"`
+}
+
+type ClosedPullRequest struct {
+	ID          *int        `json:"id,omitempty" validate:"required"`
+	Links       *Links      `json:"links,omitempty" validate:"required"`
+	State       *string     `json:"state,omitempty" validate:"required"`
+	Source      *BranchMeta `json:"source,omitempty" validate:"-"`
+	Destination *BranchMeta `json:"destination,omitempty" validate:"-"`
+}
+
 type CommonEventData struct {
 	Actor       *Actor       `json:"actor,omitempty" validate:"required"`
 	Repository  *Repository  `json:"repository,omitempty" validate:"required"`
@@ -71,6 +110,7 @@ type PullRequestComment struct {
 
 type PullRequestComments struct {
 	Values []PullRequestComment `json:"values,omitempty"`
+	Next   *string              `json:"next,omitempty"`
 }
 
 type PullRequest struct {
@@ -81,6 +121,7 @@ type PullRequest struct {
 	Links        *Links        `json:"links,omitempty" validate:"required"`
 	State        *string       `json:"state,omitempty" validate:"required"`
 	Author       *Author       `jsonN:"author,omitempty" validate:"required"`
+	Draft        *bool         `json:"draft,omitempty"`
 }
 type Links struct {
 	HTML *Link `json:"html,omitempty" validate:"required"`
@@ -91,7 +132,8 @@ type Link struct {
 type Participant struct {
 	Approved *bool `json:"approved,omitempty" validate:"required"`
 	User     *struct {
-		UUID *string `json:"uuid,omitempty" validate:"required"`
+		UUID     *string `json:"uuid,omitempty" validate:"required"`
+		Nickname *string `json:"nickname,omitempty"`
 	} `json:"user,omitempty" validate:"required"`
 }
 type BranchMeta struct {
@@ -114,3 +156,32 @@ type CommentContent struct {
 type Author struct {
 	UUID *string `json:"uuid,omitempty" validate:"required"`
 }
+
+// DefaultReviewers is a page of the default reviewers of a repository.
+type DefaultReviewers struct {
+	Values []struct {
+		UUID     *string `json:"uuid,omitempty"`
+		Nickname *string `json:"nickname,omitempty"`
+	} `json:"values,omitempty"`
+	Next *string `json:"next,omitempty"`
+}
+
+type Repositories struct {
+	Values []Repository `json:"values,omitempty"`
+	Next   *string      `json:"next,omitempty"`
+}
+
+type Webhook struct {
+	UUID        string   `json:"uuid,omitempty"`
+	Description string   `json:"description,omitempty"`
+	URL         string   `json:"url"`
+	Active      bool     `json:"active"`
+	Events      []string `json:"events"`
+	// Secret is only sent, Bitbucket doesn't return it.
+	Secret string `json:"secret,omitempty"`
+}
+
+type Webhooks struct {
+	Values []Webhook `json:"values,omitempty"`
+	Next   *string   `json:"next,omitempty"`
+}
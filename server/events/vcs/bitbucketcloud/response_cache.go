@@ -0,0 +1,56 @@
+package bitbucketcloud
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// responseCacheTTL is how long a cached response is used without asking
+	// Bitbucket whether it changed. It covers the requests of a single
+	// command, ex. checking mergeability and then listing the modified files.
+	responseCacheTTL = 30 * time.Second
+	// responseCacheMaxAge is how long a cached response is kept to revalidate
+	// with its ETag once it's older than responseCacheTTL.
+	responseCacheMaxAge = 10 * time.Minute
+)
+
+// cachedResponse is the body of a response and its ETag.
+type cachedResponse struct {
+	ETag      string
+	Body      []byte
+	FetchedAt time.Time
+}
+
+// responseCache caches responses by URL. Its zero value is ready to use.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+// Get returns the cached response for key, if any.
+func (c *responseCache) Get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && time.Since(entry.FetchedAt) > responseCacheMaxAge {
+		delete(c.entries, key)
+		return cachedResponse{}, false
+	}
+	return entry, ok
+}
+
+// Set caches resp for key and drops the responses too old to be used.
+func (c *responseCache) Set(key string, resp cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedResponse)
+	}
+	for k, entry := range c.entries {
+		if time.Since(entry.FetchedAt) > responseCacheMaxAge {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = resp
+}
@@ -154,6 +154,46 @@ func (b *Client) HidePrevCommandComments(_ logging.SimpleLogging, _ models.Repo,
 	return nil
 }
 
+// CreateAcknowledgment comments text on the merge request. Bitbucket has no
+// reactions so commands are acknowledged with a comment.
+func (b *Client) CreateAcknowledgment(_ logging.SimpleLogging, repo models.Repo, pullNum int, text string) (int64, error) {
+	bodyBytes, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return 0, errors.Wrap(err, "json encoding")
+	}
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return 0, err
+	}
+	path := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/comments", b.BaseURL, projectKey, repo.Name, pullNum)
+	resp, err := b.makeRequest("POST", path, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return 0, err
+	}
+	var comment struct {
+		ID *int64 `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &comment); err != nil {
+		return 0, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if comment.ID == nil {
+		return 0, fmt.Errorf("no comment id in response %q", string(resp))
+	}
+	return *comment.ID, nil
+}
+
+// DeleteAcknowledgment deletes the comment id made by CreateAcknowledgment.
+// The comment is never edited so it's deleted at version 0.
+func (b *Client) DeleteAcknowledgment(_ logging.SimpleLogging, repo models.Repo, pullNum int, id int64) error {
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/comments/%d?version=0", b.BaseURL, projectKey, repo.Name, pullNum, id)
+	_, err = b.makeRequest("DELETE", path, nil)
+	return err
+}
+
 // postComment actually posts the comment. It's a helper for CreateComment().
 func (b *Client) postComment(repo models.Repo, pullNum int, comment string) error {
 	bodyBytes, err := json.Marshal(map[string]string{"text": comment})
@@ -236,6 +276,8 @@ func (b *Client) UpdateStatus(logger logging.SimpleLogging, _ models.Repo, pull
 		bbState = "SUCCESSFUL"
 	case models.FailedCommitStatus:
 		bbState = "FAILED"
+	case models.CanceledCommitStatus:
+		bbState = "STOPPED"
 	}
 
 	logger.Info("Updating BitBucket commit status for '%s' to '%s'", src, bbState)
@@ -264,3 +264,47 @@ func TestClient_MarkdownPullLink(t *testing.T) {
 	exp := "#1"
 	Equals(t, exp, s)
 }
+
+func TestClient_Acknowledgment(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	var requests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.RequestURI)
+		switch r.RequestURI {
+		case "/rest/api/1.0/projects/ow/repos/repo/pull-requests/1/comments":
+			b, err := io.ReadAll(r.Body)
+			Ok(t, err)
+			Equals(t, `{"text":"received"}`, string(b))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":7,"version":0,"text":"received"}`)) // nolint: errcheck
+		case "/rest/api/1.0/projects/ow/repos/repo/pull-requests/1/comments/7?version=0":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+	repo := models.Repo{
+		FullName:          "owner/repo",
+		Owner:             "owner",
+		Name:              "repo",
+		SanitizedCloneURL: fmt.Sprintf("%s/scm/ow/repo.git", testServer.URL),
+		VCSHost: models.VCSHost{
+			Type:     models.BitbucketServer,
+			Hostname: "bitbucket.org",
+		},
+	}
+
+	id, err := client.CreateAcknowledgment(logger, repo, 1, "received")
+	Ok(t, err)
+	Equals(t, int64(7), id)
+	Ok(t, client.DeleteAcknowledgment(logger, repo, 1, id))
+	Equals(t, []string{
+		"POST /rest/api/1.0/projects/ow/repos/repo/pull-requests/1/comments",
+		"DELETE /rest/api/1.0/projects/ow/repos/repo/pull-requests/1/comments/7?version=0",
+	}, requests)
+}
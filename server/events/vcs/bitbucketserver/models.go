@@ -8,8 +8,25 @@ const (
 	PullDeclinedHeader       = "pr:declined"
 	PullDeletedHeader        = "pr:deleted"
 	PullCommentCreatedHeader = "pr:comment:added"
+	RepoRefsChangedHeader    = "repo:refs_changed"
 )
 
+// RefsChangedEvent is the part of a push event that's needed to find the
+// branches that were deleted.
+type RefsChangedEvent struct {
+	Repository *Repository `json:"repository,omitempty" validate:"required"`
+	Changes    []RefChange `json:"changes,omitempty"`
+}
+
+// RefChange is a ref that was pushed. Type is DELETE if it was deleted.
+type RefChange struct {
+	Ref *struct {
+		DisplayID *string `json:"displayId,omitempty"`
+		Type      *string `json:"type,omitempty"`
+	} `json:"ref,omitempty"`
+	Type *string `json:"type,omitempty"`
+}
+
 type CommentEvent struct {
 	CommonEventData
 	Comment *Comment `json:"comment,omitempty" validate:"required"`
@@ -33,6 +50,7 @@ type PullRequest struct {
 	Reviewers []struct {
 		Approved *bool `json:"approved,omitempty" validate:"required"`
 	} `json:"reviewers,omitempty" validate:"required"`
+	Draft *bool `json:"draft,omitempty"`
 }
 
 type Ref struct {
@@ -54,3 +54,29 @@ type Client interface {
 	// GetPullLabels returns the labels of a pull request
 	GetPullLabels(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error)
 }
+
+// PullMergeBlockersFetcher is implemented by clients that can explain why a
+// pull request isn't mergeable.
+type PullMergeBlockersFetcher interface {
+	// PullMergeBlockers returns a description of each reason the pull
+	// request can't be merged. It returns nil if the pull request is
+	// mergeable.
+	PullMergeBlockers(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error)
+}
+
+// PullDescriptionUpdater is implemented by clients that can edit the
+// description of a pull request.
+type PullDescriptionUpdater interface {
+	// GetPullDescription returns the current description of the pull request.
+	GetPullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (string, error)
+	// UpdatePullDescription replaces the description of the pull request.
+	UpdatePullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, description string) error
+}
+
+// LastCommentUpdater is implemented by clients that can edit comments.
+type LastCommentUpdater interface {
+	// UpdateLastComment replaces the body of the last comment Atlantis made
+	// for command and dir on the pull request. It returns false if there's
+	// no comment to update, in which case the caller should create one.
+	UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error)
+}
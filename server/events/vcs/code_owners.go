@@ -0,0 +1,145 @@
+package vcs
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ErrCodeOwnersUnsupported is returned by CodeOwnersChecker when the VCS host
+// of the repo can't check the approvals of code owners.
+var ErrCodeOwnersUnsupported = errors.New("checking the approvals of code owners isn't supported by this VCS host")
+
+// CodeOwnersChecker is implemented by clients that can check that the owners
+// of the files of a pull request approved it, for VCS hosts that don't
+// enforce it themselves.
+type CodeOwnersChecker interface {
+	// UnapprovedFiles returns the files, out of files, that have owners and
+	// that none of their owners approved pull.
+	UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error)
+}
+
+// CodeOwners are the rules of a CODEOWNERS file.
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// ParseCodeOwners parses a CODEOWNERS file. Each line is a gitignore-style
+// pattern followed by the owners of the files it matches, ex.
+// "/modules/ @alice @bob". Patterns without owners make the files they match
+// unowned. Lines starting with # are comments.
+func ParseCodeOwners(content string) CodeOwners {
+	var co CodeOwners
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var owners []string
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, owner)
+		}
+		co.rules = append(co.rules, codeOwnersRule{pattern: codeOwnersPattern(fields[0]), owners: owners})
+	}
+	return co
+}
+
+// Owners returns the owners of file, a path relative to the repo root, and
+// false if no rule matches it. The last rule matching file wins.
+func (c CodeOwners) Owners(file string) ([]string, bool) {
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(file) {
+			return c.rules[i].owners, true
+		}
+	}
+	return nil, false
+}
+
+// UnapprovedFiles returns the files, out of files, that have owners and that
+// none of their owners approved. The owners of a file are the ones of the last
+// pattern matching it, or defaultOwners if none does. approvers are the
+// owners who approved, ex. "@alice" or "@org/team", and are compared
+// case-insensitively.
+func (c CodeOwners) UnapprovedFiles(files []string, defaultOwners []string, approvers []string) []string {
+	approved := map[string]bool{}
+	for _, approver := range approvers {
+		approved[strings.ToLower(approver)] = true
+	}
+	var unapproved []string
+	for _, file := range files {
+		owners, ok := c.Owners(file)
+		if !ok {
+			owners = defaultOwners
+		}
+		if len(owners) == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(owners, func(owner string) bool { return approved[strings.ToLower(owner)] }) {
+			unapproved = append(unapproved, file)
+		}
+	}
+	return unapproved
+}
+
+// hasTeams returns true if some owners are teams or groups, ex. "@org/team".
+func (c CodeOwners) hasTeams() bool {
+	for _, rule := range c.rules {
+		if slices.ContainsFunc(rule.owners, func(owner string) bool { return strings.Contains(owner, "/") }) {
+			return true
+		}
+	}
+	return false
+}
+
+// codeOwnersPattern converts a gitignore-style pattern to a regexp matching
+// the paths of the files it matches.
+func codeOwnersPattern(pattern string) *regexp.Regexp {
+	// Patterns with a slash, except a trailing one, are relative to the repo
+	// root. The others match at any depth.
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	// A pattern matching a dir matches all the files in it.
+	if dirOnly {
+		expr.WriteString("/.*$")
+	} else {
+		expr.WriteString("(/.*)?$")
+	}
+	return regexp.MustCompile(expr.String())
+}
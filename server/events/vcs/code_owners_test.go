@@ -0,0 +1,47 @@
+package vcs_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCodeOwners_Owners(t *testing.T) {
+	owners := vcs.ParseCodeOwners(`# Default owners.
+*                @platform
+
+/env/prod/       @alice @bob # production
+modules/         @modules-owner
+*.tfvars         @carol
+/docs/**/*.md
+docs/README.md   @dave
+`)
+	cases := map[string]struct {
+		exp      []string
+		expFound bool
+	}{
+		"main.tf":                    {[]string{"@platform"}, true},
+		"env/prod/main.tf":           {[]string{"@alice", "@bob"}, true},
+		"env/prod/nested/main.tf":    {[]string{"@alice", "@bob"}, true},
+		"env/prod":                   {[]string{"@platform"}, true},
+		"other/env/prod/main.tf":     {[]string{"@platform"}, true},
+		"modules/vpc/main.tf":        {[]string{"@modules-owner"}, true},
+		"env/staging/modules/a.tf":   {[]string{"@modules-owner"}, true},
+		"env/prod/terraform.tfvars":  {[]string{"@carol"}, true},
+		"docs/guide/setup.md":        {nil, true},
+		"docs/index.md":              {nil, true},
+		"docs/README.md":             {[]string{"@dave"}, true},
+		"nested/docs/guide/setup.md": {[]string{"@platform"}, true},
+	}
+	for file, c := range cases {
+		t.Run(file, func(t *testing.T) {
+			got, found := owners.Owners(file)
+			Equals(t, c.expFound, found)
+			Equals(t, c.exp, got)
+		})
+	}
+
+	_, found := vcs.ParseCodeOwners("/env/ @alice").Owners("main.tf")
+	Equals(t, false, found)
+}
@@ -0,0 +1,48 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// succeededReactions are the reactions each VCS host marks a comment with
+// when its command succeeded. GitHub and Gitea only support a fixed set of
+// reactions that doesn't include a check mark so they get a rocket instead.
+// Hosts that aren't listed can't react to comments.
+var succeededReactions = map[models.VCSHostType]string{
+	models.Github: "rocket",
+	models.Gitlab: "white_check_mark",
+	models.Gitea:  "rocket",
+}
+
+// MarkCommentSucceeded reacts to the comment a command was run from to show
+// that the command succeeded. It does nothing if the comment isn't known,
+// i.e. commentID isn't positive, or if the repo's VCS host can't react to
+// comments.
+func MarkCommentSucceeded(client Client, logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64) error {
+	reaction, ok := succeededReactions[repo.VCSHost.Type]
+	if !ok || commentID <= 0 {
+		logger.Debug("not marking comment %d as succeeded, %s doesn't support reactions or the comment is unknown", commentID, repo.VCSHost.Type.String())
+		return nil
+	}
+	return client.ReactToComment(logger, repo, pullNum, commentID, reaction)
+}
+
+// ReactionRemover is implemented by clients that can remove the reactions
+// they added to comments.
+type ReactionRemover interface {
+	// RemoveReaction removes reaction from the comment commentID if the
+	// Atlantis user added it.
+	RemoveReaction(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error
+}
+
+// AcknowledgmentCommenter is implemented by clients of VCS hosts that can't
+// react to comments. They acknowledge commands with a comment instead.
+type AcknowledgmentCommenter interface {
+	// CreateAcknowledgment comments text on the pull request and returns the
+	// ID of the comment.
+	CreateAcknowledgment(logger logging.SimpleLogging, repo models.Repo, pullNum int, text string) (int64, error)
+	// DeleteAcknowledgment deletes the comment id made by
+	// CreateAcknowledgment.
+	DeleteAcknowledgment(logger logging.SimpleLogging, repo models.Repo, pullNum int, id int64) error
+}
@@ -0,0 +1,47 @@
+package vcs_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestMarkCommentSucceeded(t *testing.T) {
+	cases := []struct {
+		host        models.VCSHostType
+		commentID   int64
+		expReaction string
+	}{
+		{models.Github, 1, "rocket"},
+		{models.Gitlab, 1, "white_check_mark"},
+		{models.Gitea, 1, "rocket"},
+		// Hosts that can't react to comments and unknown comments aren't
+		// marked.
+		{models.BitbucketCloud, 1, ""},
+		{models.BitbucketServer, 1, ""},
+		{models.AzureDevops, 1, ""},
+		{models.Github, -1, ""},
+		{models.Github, 0, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.host.String(), func(t *testing.T) {
+			RegisterMockTestingT(t)
+			logger := logging.NewNoopLogger(t)
+			repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: c.host}}
+			client := mocks.NewMockClient()
+
+			Ok(t, vcs.MarkCommentSucceeded(client, logger, repo, 1, c.commentID))
+			if c.expReaction == "" {
+				client.VerifyWasCalled(Never()).ReactToComment(
+					Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[int64](), Any[string]())
+			} else {
+				client.VerifyWasCalledOnce().ReactToComment(logger, repo, 1, c.commentID, c.expReaction)
+			}
+		})
+	}
+}
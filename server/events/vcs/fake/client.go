@@ -0,0 +1,232 @@
+package fake
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Comment is a comment Atlantis made on a pull request.
+type Comment struct {
+	Repo    string
+	PullNum int
+	Body    string
+	Command string
+}
+
+// Status is a commit status Atlantis set on a pull request.
+type Status struct {
+	Repo        string
+	PullNum     int
+	Commit      string
+	State       models.CommitStatus
+	Src         string
+	Description string
+}
+
+// Client implements vcs.Client and the GitHub pull request getter for the
+// pull requests in Fixtures. It records the comments and commit statuses
+// Atlantis makes instead of sending them anywhere.
+type Client struct {
+	// Out, if set, is where comments, statuses and merges are printed.
+	Out io.Writer
+
+	mu       sync.Mutex
+	pulls    map[string]*Pull
+	merged   map[string]bool
+	comments []Comment
+	statuses []Status
+}
+
+// NewClient creates a Client serving the pull requests in fixtures.
+func NewClient(fixtures *Fixtures, out io.Writer) *Client {
+	c := &Client{
+		Out:    out,
+		pulls:  make(map[string]*Pull),
+		merged: make(map[string]bool),
+	}
+	for _, pull := range fixtures.Pulls {
+		c.pulls[pullKey(pull.Repo, pull.Number)] = pull
+	}
+	return c
+}
+
+// Comments returns the comments made on the pull request.
+func (c *Client) Comments(repo string, pullNum int) []Comment {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var comments []Comment
+	for _, comment := range c.comments {
+		if comment.Repo == repo && comment.PullNum == pullNum {
+			comments = append(comments, comment)
+		}
+	}
+	return comments
+}
+
+// Statuses returns the commit statuses set on the pull request.
+func (c *Client) Statuses(repo string, pullNum int) []Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var statuses []Status
+	for _, status := range c.statuses {
+		if status.Repo == repo && status.PullNum == pullNum {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// Merged returns true if Atlantis merged the pull request.
+func (c *Client) Merged(repo string, pullNum int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.merged[pullKey(repo, pullNum)]
+}
+
+func (c *Client) pull(repo string, pullNum int) (*Pull, error) {
+	pull, ok := c.pulls[pullKey(repo, pullNum)]
+	if !ok {
+		return nil, fmt.Errorf("pull %s#%d isn't in the fixtures", repo, pullNum)
+	}
+	return pull, nil
+}
+
+func (c *Client) printf(format string, a ...any) {
+	if c.Out != nil {
+		fmt.Fprintf(c.Out, format, a...) // nolint: errcheck
+	}
+}
+
+// GetPullRequest returns the pull request in the format of the GitHub API so
+// that the fake VCS host can stand in for GitHub.
+func (c *Client) GetPullRequest(_ logging.SimpleLogging, repo models.Repo, num int) (*github.PullRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pull, err := c.pull(repo.FullName, num)
+	if err != nil {
+		return nil, err
+	}
+	state := "open"
+	if c.merged[pullKey(pull.Repo, pull.Number)] {
+		state = "closed"
+	}
+	return PullRequest(pull, state), nil
+}
+
+func (c *Client) GetModifiedFiles(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.pull(repo.FullName, pull.Num)
+	if err != nil {
+		return nil, err
+	}
+	return p.ModifiedFiles, nil
+}
+
+func (c *Client) CreateComment(_ logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.comments = append(c.comments, Comment{Repo: repo.FullName, PullNum: pullNum, Body: comment, Command: command})
+	c.printf("\n--- comment on %s#%d ---\n%s\n---\n", repo.FullName, pullNum, comment)
+	return nil
+}
+
+func (c *Client) ReactToComment(_ logging.SimpleLogging, _ models.Repo, _ int, _ int64, _ string) error {
+	return nil
+}
+
+func (c *Client) HidePrevCommandComments(_ logging.SimpleLogging, _ models.Repo, _ int, _ string, _ string) error {
+	return nil
+}
+
+func (c *Client) PullIsApproved(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (models.ApprovalStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.pull(repo.FullName, pull.Num)
+	if err != nil {
+		return models.ApprovalStatus{}, err
+	}
+	return models.ApprovalStatus{IsApproved: p.Approved}, nil
+}
+
+func (c *Client) PullIsMergeable(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest, _ string, _ []string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.pull(repo.FullName, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	return p.Mergeable == nil || *p.Mergeable, nil
+}
+
+func (c *Client) UpdateStatus(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, _ string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statuses = append(c.statuses, Status{
+		Repo:        repo.FullName,
+		PullNum:     pull.Num,
+		Commit:      pull.HeadCommit,
+		State:       state,
+		Src:         src,
+		Description: description,
+	})
+	c.printf("status %s on %s#%d: %s %s\n", src, repo.FullName, pull.Num, state.String(), description)
+	return nil
+}
+
+func (c *Client) DiscardReviews(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.pull(repo.FullName, pull.Num)
+	if err != nil {
+		return err
+	}
+	p.Approved = false
+	return nil
+}
+
+func (c *Client) MergePull(_ logging.SimpleLogging, pull models.PullRequest, _ models.PullRequestOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.pull(pull.BaseRepo.FullName, pull.Num); err != nil {
+		return err
+	}
+	c.merged[pullKey(pull.BaseRepo.FullName, pull.Num)] = true
+	c.printf("merged %s#%d\n", pull.BaseRepo.FullName, pull.Num)
+	return nil
+}
+
+func (c *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
+	return fmt.Sprintf("#%d", pull.Num), nil
+}
+
+func (c *Client) GetTeamNamesForUser(_ logging.SimpleLogging, _ models.Repo, _ models.User) ([]string, error) {
+	return nil, nil
+}
+
+func (c *Client) GetFileContent(_ logging.SimpleLogging, _ models.PullRequest, _ string) (bool, []byte, error) {
+	return false, nil, fmt.Errorf("not implemented")
+}
+
+func (c *Client) SupportsSingleFileDownload(_ models.Repo) bool {
+	return false
+}
+
+func (c *Client) GetCloneURL(_ logging.SimpleLogging, _ models.VCSHostType, repo string) (string, error) {
+	return (&Pull{Repo: repo}).CloneURL(), nil
+}
+
+func (c *Client) GetPullLabels(_ logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.pull(repo.FullName, pull.Num)
+	if err != nil {
+		return nil, err
+	}
+	return p.Labels, nil
+}
@@ -0,0 +1,117 @@
+package fake_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/fake"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var _ vcs.IGithubClient = &fake.Client{}
+
+func TestClient(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	fixtures, err := fake.LoadFixtures(initRepo(t))
+	Ok(t, err)
+	var out bytes.Buffer
+	client := fake.NewClient(fixtures, &out)
+
+	// The pull request is parsed the same way as a GitHub one.
+	parser := &events.EventParser{}
+	repo := models.Repo{FullName: "owner/repo"}
+	ghPull, err := client.GetPullRequest(logger, repo, 1)
+	Ok(t, err)
+	pull, baseRepo, _, err := parser.ParseGithubPull(logger, ghPull)
+	Ok(t, err)
+	Equals(t, fake.Hostname, baseRepo.VCSHost.Hostname)
+	Equals(t, models.OpenPullState, pull.State)
+	Equals(t, "branch", pull.HeadBranch)
+	Equals(t, fixtures.Pulls[0].HeadCommit, pull.HeadCommit)
+
+	_, err = client.GetPullRequest(logger, repo, 2)
+	ErrEquals(t, "pull owner/repo#2 isn't in the fixtures", err)
+
+	files, err := client.GetModifiedFiles(logger, baseRepo, pull)
+	Ok(t, err)
+	Equals(t, []string{"dir/main.tf"}, files)
+	labels, err := client.GetPullLabels(logger, baseRepo, pull)
+	Ok(t, err)
+	Equals(t, []string{"infra"}, labels)
+	approved, err := client.PullIsApproved(logger, baseRepo, pull)
+	Ok(t, err)
+	Equals(t, false, approved.IsApproved)
+	mergeable, err := client.PullIsMergeable(logger, baseRepo, pull, "atlantis", nil)
+	Ok(t, err)
+	Equals(t, true, mergeable)
+
+	Ok(t, client.CreateComment(logger, baseRepo, 1, "Ran Plan", "plan"))
+	Equals(t, []fake.Comment{{Repo: "owner/repo", PullNum: 1, Body: "Ran Plan", Command: "plan"}}, client.Comments("owner/repo", 1))
+	Assert(t, bytes.Contains(out.Bytes(), []byte("Ran Plan")), "expected comment to be printed, got %q", out.String())
+
+	Ok(t, client.UpdateStatus(logger, baseRepo, pull, models.SuccessCommitStatus, "atlantis/plan", "Plan succeeded.", ""))
+	Equals(t, []fake.Status{{
+		Repo:        "owner/repo",
+		PullNum:     1,
+		Commit:      pull.HeadCommit,
+		State:       models.SuccessCommitStatus,
+		Src:         "atlantis/plan",
+		Description: "Plan succeeded.",
+	}}, client.Statuses("owner/repo", 1))
+
+	Ok(t, client.MergePull(logger, pull, models.PullRequestOptions{}))
+	Equals(t, true, client.Merged("owner/repo", 1))
+	ghPull, err = client.GetPullRequest(logger, repo, 1)
+	Ok(t, err)
+	Equals(t, "closed", ghPull.GetState())
+}
+
+func TestSendEvent(t *testing.T) {
+	fixtures, err := fake.LoadFixtures(initRepo(t))
+	Ok(t, err)
+	pull := fixtures.Pulls[0]
+
+	var eventTypes []string
+	var received []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload bytes.Buffer
+		_, err := payload.ReadFrom(r.Body)
+		Ok(t, err)
+		event, err := github.ParseWebHook(github.WebHookType(r), payload.Bytes())
+		Ok(t, err)
+		eventTypes = append(eventTypes, github.WebHookType(r))
+		received = append(received, event)
+	}))
+	defer server.Close()
+
+	payload, err := fake.OpenedEvent(pull)
+	Ok(t, err)
+	Ok(t, fake.SendEvent(server.Client(), server.URL, "pull_request", payload))
+	payload, err = fake.CommentEvent(pull, "dev", "atlantis plan")
+	Ok(t, err)
+	Ok(t, fake.SendEvent(server.Client(), server.URL, "issue_comment", payload))
+	Equals(t, []string{"pull_request", "issue_comment"}, eventTypes)
+
+	logger := logging.NewNoopLogger(t)
+	parser := &events.EventParser{}
+	parsedPull, eventType, _, _, user, err := parser.ParseGithubPullEvent(logger, received[0].(*github.PullRequestEvent))
+	Ok(t, err)
+	Equals(t, models.OpenedPullEvent, eventType)
+	Equals(t, 1, parsedPull.Num)
+	Equals(t, "developer", user.Username)
+
+	comment := received[1].(*github.IssueCommentEvent)
+	_, user, pullNum, err := parser.ParseGithubIssueCommentEvent(logger, comment)
+	Ok(t, err)
+	Equals(t, 1, pullNum)
+	Equals(t, "dev", user.Username)
+	Equals(t, "atlantis plan", comment.GetComment().GetBody())
+	Equals(t, "created", comment.GetAction())
+}
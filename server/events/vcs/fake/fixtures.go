@@ -0,0 +1,161 @@
+// Package fake is a VCS host that serves pull requests from fixtures on disk
+// and records what Atlantis does with them, so Atlantis can be run locally
+// without a real VCS host or credentials, for example to develop custom
+// workflows.
+package fake
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Hostname is the hostname of the fake VCS host. Repos are served as GitHub
+// repos on this host, ex. https://fake-vcs.localhost/owner/repo.
+const Hostname = "fake-vcs.localhost"
+
+// Fixtures are the pull requests served by the fake VCS host.
+type Fixtures struct {
+	Pulls []*Pull `yaml:"pulls"`
+}
+
+// Pull is a pull request made from a branch of a local git repo.
+type Pull struct {
+	// Repo is the full name of the repo, ex. owner/repo.
+	Repo   string `yaml:"repo"`
+	Number int    `yaml:"number"`
+	// Author is the username of the author. Defaults to "developer".
+	Author string `yaml:"author"`
+	// Path is the local git repo, relative to the fixtures file.
+	Path       string `yaml:"path"`
+	HeadBranch string `yaml:"head_branch"`
+	BaseBranch string `yaml:"base_branch"`
+	// HeadCommit defaults to the commit HeadBranch points to.
+	HeadCommit string `yaml:"head_commit"`
+	// ModifiedFiles defaults to the files changed between BaseBranch and
+	// HeadBranch.
+	ModifiedFiles []string `yaml:"modified_files"`
+	Approved      bool     `yaml:"approved"`
+	// Mergeable defaults to true.
+	Mergeable *bool    `yaml:"mergeable"`
+	Labels    []string `yaml:"labels"`
+}
+
+// CloneURL is the URL the repo of the pull request is cloned from. It's
+// rewritten to Path by GitConfigEnv.
+func (p *Pull) CloneURL() string {
+	return fmt.Sprintf("https://%s/%s.git", Hostname, p.Repo)
+}
+
+// HTMLURL is the URL of the pull request.
+func (p *Pull) HTMLURL() string {
+	return fmt.Sprintf("https://%s/%s/pull/%d", Hostname, p.Repo, p.Number)
+}
+
+// LoadFixtures reads the fixtures file at path. Commits and modified files
+// that aren't set are read from the local git repos.
+func LoadFixtures(path string) (*Fixtures, error) {
+	bytes, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "reading fixtures")
+	}
+	var fixtures Fixtures
+	if err := yaml.Unmarshal(bytes, &fixtures); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+	if len(fixtures.Pulls) == 0 {
+		return nil, fmt.Errorf("%s has no pulls", path)
+	}
+	seen := make(map[string]bool)
+	for _, pull := range fixtures.Pulls {
+		if err := pull.resolve(filepath.Dir(path)); err != nil {
+			return nil, errors.Wrapf(err, "pull %s#%d", pull.Repo, pull.Number)
+		}
+		key := pullKey(pull.Repo, pull.Number)
+		if seen[key] {
+			return nil, fmt.Errorf("pull %s is defined more than once", key)
+		}
+		seen[key] = true
+	}
+	return &fixtures, nil
+}
+
+func (p *Pull) resolve(dir string) error {
+	if owner, name := models.SplitRepoFullName(p.Repo); owner == "" || name == "" {
+		return fmt.Errorf("repo %q must be in the format owner/repo", p.Repo)
+	}
+	if p.Number <= 0 {
+		return errors.New("number must be greater than 0")
+	}
+	if p.Path == "" || p.HeadBranch == "" || p.BaseBranch == "" {
+		return errors.New("path, head_branch and base_branch must be set")
+	}
+	if p.Author == "" {
+		p.Author = "developer"
+	}
+	if !filepath.IsAbs(p.Path) {
+		p.Path = filepath.Join(dir, p.Path)
+	}
+	if p.HeadCommit == "" {
+		out, err := git(p.Path, "rev-parse", p.HeadBranch)
+		if err != nil {
+			return err
+		}
+		p.HeadCommit = strings.TrimSpace(out)
+	}
+	if p.ModifiedFiles == nil {
+		out, err := git(p.Path, "diff", "--name-only", fmt.Sprintf("%s...%s", p.BaseBranch, p.HeadCommit))
+		if err != nil {
+			return err
+		}
+		p.ModifiedFiles = strings.Fields(out)
+	}
+	return nil
+}
+
+// GitConfigEnv returns the environment variables that make git clone the
+// repos of the pull requests from their local paths instead of from the fake
+// VCS host. vcsUser and vcsToken are the credentials Atlantis adds to clone
+// URLs, which are part of the URL git sees.
+func (f *Fixtures) GitConfigEnv(vcsUser string, vcsToken string) ([]string, error) {
+	rewrites := make(map[string]string)
+	for _, pull := range f.Pulls {
+		repo, err := models.NewRepo(models.Github, pull.Repo, pull.CloneURL(), vcsUser, vcsToken)
+		if err != nil {
+			return nil, err
+		}
+		if path, ok := rewrites[repo.CloneURL]; ok && path != pull.Path {
+			return nil, fmt.Errorf("repo %s has pulls with different paths %s and %s", pull.Repo, path, pull.Path)
+		}
+		rewrites[repo.CloneURL] = pull.Path
+	}
+	var env []string
+	i := 0
+	for cloneURL, path := range rewrites {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=url.%s.insteadOf", i, path),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, cloneURL))
+		i++
+	}
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", i)), nil
+}
+
+func pullKey(repo string, num int) string {
+	return fmt.Sprintf("%s#%d", repo, num)
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...) // nolint: gosec
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("running git %s in %s: %s: %s", strings.Join(args, " "), dir, err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
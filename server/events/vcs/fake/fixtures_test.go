@@ -0,0 +1,107 @@
+package fake_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs/fake"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// initRepo creates a git repo in a temp dir with a main branch and a branch
+// modifying dir/main.tf, and returns the fixtures file for a pull request
+// from that branch.
+func initRepo(t *testing.T) string {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	Ok(t, os.MkdirAll(filepath.Join(repoDir, "dir"), 0700))
+	runGit(t, repoDir, "init", "-b", "main")
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("readme"), 0600))
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "initial")
+	runGit(t, repoDir, "checkout", "-b", "branch")
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "dir", "main.tf"), []byte(`resource "null_resource" "a" {}`), 0600))
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "add main.tf")
+
+	fixturesPath := filepath.Join(dir, "fixtures.yaml")
+	Ok(t, os.WriteFile(fixturesPath, []byte(`
+pulls:
+- repo: owner/repo
+  number: 1
+  path: repo
+  head_branch: branch
+  base_branch: main
+  labels: [infra]
+`), 0600))
+	return fixturesPath
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=atlantis", "GIT_AUTHOR_EMAIL=atlantis@example.com",
+		"GIT_COMMITTER_NAME=atlantis", "GIT_COMMITTER_EMAIL=atlantis@example.com")
+	out, err := cmd.CombinedOutput()
+	Assert(t, err == nil, "git %s: %s: %s", strings.Join(args, " "), err, out)
+	return strings.TrimSpace(string(out))
+}
+
+func TestLoadFixtures(t *testing.T) {
+	fixturesPath := initRepo(t)
+	fixtures, err := fake.LoadFixtures(fixturesPath)
+	Ok(t, err)
+	Equals(t, 1, len(fixtures.Pulls))
+
+	pull := fixtures.Pulls[0]
+	Equals(t, "developer", pull.Author)
+	Equals(t, filepath.Join(filepath.Dir(fixturesPath), "repo"), pull.Path)
+	Equals(t, runGit(t, pull.Path, "rev-parse", "branch"), pull.HeadCommit)
+	Equals(t, []string{"dir/main.tf"}, pull.ModifiedFiles)
+	Equals(t, "https://fake-vcs.localhost/owner/repo.git", pull.CloneURL())
+	Equals(t, "https://fake-vcs.localhost/owner/repo/pull/1", pull.HTMLURL())
+}
+
+func TestLoadFixtures_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"pulls: []":                       "has no pulls",
+		"pulls: [{repo: repo}]":           `pull repo#0: repo "repo" must be in the format owner/repo`,
+		"pulls: [{repo: owner/repo}]":     "pull owner/repo#0: number must be greater than 0",
+		"pulls: [{repo: o/r, number: 1}]": "pull o/r#1: path, head_branch and base_branch must be set",
+		`pulls:
+- {repo: o/r, number: 1, path: ., head_branch: b, base_branch: m, head_commit: abc, modified_files: []}
+- {repo: o/r, number: 1, path: ., head_branch: b, base_branch: m, head_commit: abc, modified_files: []}`: "pull o/r#1 is defined more than once",
+	}
+	for contents, expErr := range cases {
+		t.Run(expErr, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fixtures.yaml")
+			Ok(t, os.WriteFile(path, []byte(contents), 0600))
+			_, err := fake.LoadFixtures(path)
+			ErrContains(t, expErr, err)
+		})
+	}
+}
+
+// Cloning the clone URL of a pull request with the git config from
+// GitConfigEnv clones the local repo.
+func TestFixtures_GitConfigEnv(t *testing.T) {
+	fixtures, err := fake.LoadFixtures(initRepo(t))
+	Ok(t, err)
+	env, err := fixtures.GitConfigEnv("user", "token")
+	Ok(t, err)
+
+	cloneDir := t.TempDir()
+	authedCloneURL := strings.Replace(fixtures.Pulls[0].CloneURL(), "https://", "https://user:token@", 1)
+	cmd := exec.Command("git", "clone", "--branch", "branch", authedCloneURL, cloneDir) // nolint: gosec
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	Assert(t, err == nil, "git clone: %s: %s", err, out)
+	_, err = os.Stat(filepath.Join(cloneDir, "dir", "main.tf"))
+	Ok(t, err)
+	Equals(t, fixtures.Pulls[0].HeadCommit, runGit(t, cloneDir, "rev-parse", "HEAD"))
+	Equals(t, "GIT_CONFIG_COUNT=1", env[len(env)-1])
+}
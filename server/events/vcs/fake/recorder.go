@@ -0,0 +1,169 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RecordMode sends requests to the VCS host and saves the responses.
+	RecordMode = "record"
+	// ReplayMode serves the saved responses instead of sending requests.
+	ReplayMode = "replay"
+)
+
+// Interaction is a request to a VCS host and its response.
+type Interaction struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        string      `json:"body"`
+}
+
+// Recorder records the HTTP interactions with a VCS host API to a cassette
+// file, or replays them from it. Request headers aren't recorded so that
+// credentials don't end up in the cassette.
+type Recorder struct {
+	mode string
+	path string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder creates a Recorder in mode for the cassette at path. In
+// ReplayMode the cassette is read, in RecordMode it's overwritten.
+func NewRecorder(mode string, path string) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	switch mode {
+	case RecordMode:
+		return r, r.save()
+	case ReplayMode:
+		bytes, err := os.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return nil, errors.Wrap(err, "reading cassette")
+		}
+		if err := json.Unmarshal(bytes, &r.interactions); err != nil {
+			return nil, errors.Wrapf(err, "parsing cassette %s", path)
+		}
+		r.used = make([]bool, len(r.interactions))
+		return r, nil
+	default:
+		return nil, fmt.Errorf("invalid mode %q, must be %q or %q", mode, RecordMode, ReplayMode)
+	}
+}
+
+// Wrap returns a RoundTripper that records or replays the requests sent
+// through next. In ReplayMode, next is never used.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := readBody(req)
+		if err != nil {
+			return nil, err
+		}
+		if r.mode == ReplayMode {
+			return r.replay(req, body)
+		}
+		return r.record(next, req, body)
+	})
+}
+
+func (r *Recorder) record(next http.RoundTripper, req *http.Request, reqBody string) (*http.Response, error) {
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close() // nolint: errcheck
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: reqBody,
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		Body:        string(body),
+	})
+	// The cassette is saved after every interaction since the server is
+	// usually stopped by killing it.
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay serves the first unused interaction with the same method, URL and
+// body as req.
+func (r *Recorder) replay(req *http.Request, reqBody string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Method != req.Method || interaction.URL != req.URL.String() || interaction.RequestBody != reqBody {
+			continue
+		}
+		r.used[i] = true
+		header := interaction.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+			StatusCode:    interaction.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Body))),
+			ContentLength: int64(len(interaction.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, req.URL, r.path)
+}
+
+func (r *Recorder) save() error {
+	interactions := r.interactions
+	if interactions == nil {
+		interactions = []Interaction{}
+	}
+	bytes, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(os.WriteFile(r.path, bytes, 0600), "writing cassette")
+}
+
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close() // nolint: errcheck
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return string(body), nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,80 @@
+package fake_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs/fake"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"request":` + string(body) + `,"count":` + strconv.Itoa(requests) + `}`)) // nolint: errcheck
+	}))
+	defer server.Close()
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := fake.NewRecorder(fake.RecordMode, cassette)
+	Ok(t, err)
+	client := &http.Client{Transport: recorder.Wrap(http.DefaultTransport)}
+	for _, body := range []string{`"a"`, `"a"`, `"b"`} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/repos/owner/repo/issues/1/comments", strings.NewReader(body))
+		Ok(t, err)
+		req.Header.Set("Authorization", "token secret")
+		resp, err := client.Do(req)
+		Ok(t, err)
+		resp.Body.Close() // nolint: errcheck
+	}
+	Equals(t, 3, requests)
+
+	contents, err := os.ReadFile(cassette)
+	Ok(t, err)
+	Assert(t, !strings.Contains(string(contents), "secret"), "expected request headers not to be recorded, got %s", contents)
+
+	// Replaying doesn't send requests and answers identical requests in the
+	// order they were recorded.
+	recorder, err = fake.NewRecorder(fake.ReplayMode, cassette)
+	Ok(t, err)
+	client = &http.Client{Transport: recorder.Wrap(nil)}
+	for _, c := range []struct {
+		body    string
+		expBody string
+	}{
+		{`"b"`, `{"request":"b","count":3}`},
+		{`"a"`, `{"request":"a","count":1}`},
+		{`"a"`, `{"request":"a","count":2}`},
+	} {
+		resp, err := client.Post(server.URL+"/repos/owner/repo/issues/1/comments", "application/json", strings.NewReader(c.body))
+		Ok(t, err)
+		body, err := io.ReadAll(resp.Body)
+		Ok(t, err)
+		resp.Body.Close() // nolint: errcheck
+		Equals(t, http.StatusCreated, resp.StatusCode)
+		Equals(t, "application/json", resp.Header.Get("Content-Type"))
+		Equals(t, c.expBody, string(body))
+	}
+	Equals(t, 3, requests)
+
+	_, err = client.Post(server.URL+"/repos/owner/repo/issues/1/comments", "application/json", strings.NewReader(`"a"`))
+	ErrContains(t, "no recorded response for POST "+server.URL+"/repos/owner/repo/issues/1/comments", err)
+}
+
+func TestNewRecorder_Invalid(t *testing.T) {
+	_, err := fake.NewRecorder("rewind", filepath.Join(t.TempDir(), "cassette.json"))
+	ErrEquals(t, `invalid mode "rewind", must be "record" or "replay"`, err)
+
+	_, err = fake.NewRecorder(fake.ReplayMode, filepath.Join(t.TempDir(), "missing.json"))
+	ErrContains(t, "reading cassette", err)
+}
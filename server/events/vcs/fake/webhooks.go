@@ -0,0 +1,90 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v71/github"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// PullRequest returns pull in the format of the GitHub API.
+func PullRequest(pull *Pull, state string) *github.PullRequest {
+	owner, name := models.SplitRepoFullName(pull.Repo)
+	repo := &github.Repository{
+		FullName: github.Ptr(pull.Repo),
+		Name:     github.Ptr(name),
+		Owner:    &github.User{Login: github.Ptr(owner)},
+		CloneURL: github.Ptr(pull.CloneURL()),
+		HTMLURL:  github.Ptr(fmt.Sprintf("https://%s/%s", Hostname, pull.Repo)),
+	}
+	return &github.PullRequest{
+		Number:  github.Ptr(pull.Number),
+		State:   github.Ptr(state),
+		HTMLURL: github.Ptr(pull.HTMLURL()),
+		User:    &github.User{Login: github.Ptr(pull.Author)},
+		Head: &github.PullRequestBranch{
+			Ref:  github.Ptr(pull.HeadBranch),
+			SHA:  github.Ptr(pull.HeadCommit),
+			Repo: repo,
+		},
+		Base: &github.PullRequestBranch{
+			Ref:  github.Ptr(pull.BaseBranch),
+			Repo: repo,
+		},
+		Mergeable: github.Ptr(pull.Mergeable == nil || *pull.Mergeable),
+	}
+}
+
+// OpenedEvent returns the GitHub webhook payload for pull being opened.
+func OpenedEvent(pull *Pull) ([]byte, error) {
+	pr := PullRequest(pull, "open")
+	return json.Marshal(&github.PullRequestEvent{
+		Action:      github.Ptr("opened"),
+		Number:      github.Ptr(pull.Number),
+		PullRequest: pr,
+		Repo:        pr.Base.Repo,
+		Sender:      pr.User,
+	})
+}
+
+// CommentEvent returns the GitHub webhook payload for user commenting body
+// on pull.
+func CommentEvent(pull *Pull, user string, body string) ([]byte, error) {
+	pr := PullRequest(pull, "open")
+	return json.Marshal(&github.IssueCommentEvent{
+		Action: github.Ptr("created"),
+		Issue: &github.Issue{
+			Number:           github.Ptr(pull.Number),
+			PullRequestLinks: &github.PullRequestLinks{HTMLURL: pr.HTMLURL},
+		},
+		Comment: &github.IssueComment{
+			Body: github.Ptr(body),
+			User: &github.User{Login: github.Ptr(user)},
+		},
+		Repo:   pr.Base.Repo,
+		Sender: &github.User{Login: github.Ptr(user)},
+	})
+}
+
+// SendEvent posts a webhook payload of eventType, ex. pull_request or
+// issue_comment, to the events endpoint at eventsURL as GitHub would.
+func SendEvent(client *http.Client, eventsURL string, eventType string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, eventsURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Github-Event", eventType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sending %s event to %s: got status %d", eventType, eventsURL, resp.StatusCode)
+	}
+	return nil
+}
@@ -2,20 +2,29 @@ package vcs
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
+// gitCredsMu serializes writes to the .git-credentials file since repository
+// credentials are written by concurrent clones.
+var gitCredsMu sync.Mutex
+
 // WriteGitCreds generates a .git-credentials file containing the username and token
 // used for authenticating with git over HTTPS
 // It will create the file in home/.git-credentials
 // If ghAccessToken is true we will look for a line starting with https://x-access-token and ending with gitHostname and replace it.
 func WriteGitCreds(gitUser string, gitToken string, gitHostname string, home string, logger logging.SimpleLogging, ghAccessToken bool) error {
+	gitCredsMu.Lock()
+	defer gitCredsMu.Unlock()
+
 	const credsFilename = ".git-credentials"
 	credsFile := filepath.Join(home, credsFilename)
 	credsFileContentsPattern := `https://%s:%s@%s` // nolint: gosec
@@ -78,6 +87,60 @@ func WriteGitCreds(gitUser string, gitToken string, gitHostname string, home str
 	return nil
 }
 
+// WriteGitRepoCreds writes credentials to home/.git-credentials that git only
+// uses for the repository at cloneURL, replacing the ones previously written
+// for it. Git is configured to match the credentials of cloneURL's host by
+// path so that the token of one repository isn't used for another.
+func WriteGitRepoCreds(gitUser string, gitToken string, cloneURL string, home string, logger logging.SimpleLogging) error {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return errors.Wrap(err, "parsing clone URL")
+	}
+	repoPath := u.Host + u.Path
+
+	gitCredsMu.Lock()
+	defer gitCredsMu.Unlock()
+
+	credsFile := filepath.Join(home, ".git-credentials")
+	config := fmt.Sprintf("https://%s:%s@%s", gitUser, gitToken, repoPath)
+	if _, err := os.Stat(credsFile); err != nil {
+		if err := os.WriteFile(credsFile, []byte(config), 0600); err != nil {
+			return errors.Wrapf(err, "writing generated .git-credentials file to %s", credsFile)
+		}
+	} else {
+		hasLine, err := fileHasLine(config, credsFile)
+		if err != nil {
+			return err
+		}
+		if hasLine {
+			return nil
+		}
+		hasRepoToken, err := fileHasGHToken(gitUser, repoPath, credsFile)
+		if err != nil {
+			return err
+		}
+		if hasRepoToken {
+			err = fileLineReplace(config, gitUser, repoPath, credsFile)
+		} else {
+			err = fileAppend(config, credsFile)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "writing git credentials for %s", repoPath)
+		}
+	}
+	logger.Debug("wrote git credentials for %s to %s", repoPath, credsFile)
+
+	pathCmd := exec.Command("git", "config", "--global", fmt.Sprintf("credential.https://%s.useHttpPath", u.Host), "true") // nolint: gosec
+	if out, err := pathCmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "There was an error running %s: %s", strings.Join(pathCmd.Args, " "), string(out))
+	}
+	credentialCmd := exec.Command("git", "config", "--global", "credential.helper", "store")
+	if out, err := credentialCmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "There was an error running %s: %s", strings.Join(credentialCmd.Args, " "), string(out))
+	}
+	return nil
+}
+
 func fileHasLine(line string, filename string) (bool, error) {
 	currContents, err := os.ReadFile(filename) // nolint: gosec
 	if err != nil {
@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -60,13 +61,17 @@ type GiteaPullGetter interface {
 // client to use to make the requests, username and password are used as basic
 // auth in the requests, baseURL is the API's baseURL, ex. https://corp.com:7990.
 // Don't include the API version, ex. '/1.0'.
-func NewClient(baseURL string, username string, token string, pagesize int, logger logging.SimpleLogging) (*GiteaClient, error) {
+func NewClient(httpClient *http.Client, baseURL string, username string, token string, pagesize int, logger logging.SimpleLogging) (*GiteaClient, error) {
 	logger.Debug("Creating new Gitea client for: %s", baseURL)
 
-	giteaClient, err := gitea.NewClient(baseURL,
+	options := []gitea.ClientOption{
 		gitea.SetToken(token),
 		gitea.SetUserAgent("atlantis"),
-	)
+	}
+	if httpClient != nil {
+		options = append(options, gitea.SetHTTPClient(httpClient))
+	}
+	giteaClient, err := gitea.NewClient(baseURL, options...)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "creating gitea client")
@@ -167,6 +172,22 @@ func (c *GiteaClient) ReactToComment(logger logging.SimpleLogging, repo models.R
 	return nil
 }
 
+// RemoveReaction removes reaction from the comment commentID.
+func (c *GiteaClient) RemoveReaction(logger logging.SimpleLogging, repo models.Repo, _ int, commentID int64, reaction string) error {
+	logger.Debug("Removing reaction from Gitea pull request comment %d", commentID)
+
+	resp, err := c.giteaClient.DeleteIssueCommentReaction(repo.Owner, repo.Name, commentID, reaction)
+
+	if err != nil {
+		if resp != nil {
+			logger.Debug("DELETE /repos/%v/%v/issues/comments/%d/reactions returned: %v", repo.Owner, repo.Name, commentID, resp.StatusCode)
+		}
+		return err
+	}
+
+	return nil
+}
+
 // HidePrevCommandComments hides the previous command comments from the pull
 // request.
 func (c *GiteaClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
@@ -314,20 +335,27 @@ func (c *GiteaClient) UpdateStatus(logger logging.SimpleLogging, repo models.Rep
 		giteaState = gitea.StatusSuccess
 	case models.FailedCommitStatus:
 		giteaState = gitea.StatusFailure
+	case models.CanceledCommitStatus:
+		giteaState = gitea.StatusError
 	}
 
-	logger.Info("Updating Gitea check status for '%s' to '%s'", src, state)
+	logger.Info("Updating Gitea commit status for '%s' to '%s'", src, giteaState)
 
+	// Gitea keeps the latest status of each context, so src must be set for
+	// the statuses of different commands and projects to not replace each
+	// other. url links to the job that set the status, if any.
 	newStatusOption := gitea.CreateStatusOption{
 		State:       giteaState,
 		TargetURL:   url,
 		Description: description,
+		Context:     src,
 	}
 
 	_, resp, err := c.giteaClient.CreateStatus(repo.Owner, repo.Name, pull.HeadCommit, newStatusOption)
-
-	if err != nil {
+	if resp != nil {
 		logger.Debug("POST /repos/%v/%v/statuses/%s returned: %v", repo.Owner, repo.Name, pull.HeadCommit, resp.StatusCode)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -426,8 +454,13 @@ func (c *GiteaClient) GetFileContent(logger logging.SimpleLogging, pull models.P
 
 	content, resp, err := c.giteaClient.GetContents(pull.BaseRepo.Owner, pull.BaseRepo.Name, pull.HeadCommit, fileName)
 
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil, nil
+	}
 	if err != nil {
-		logger.Debug("GET /repos/%v/%v/contents/%s?ref=%v returned: %v", pull.BaseRepo.Owner, pull.BaseRepo.Name, fileName, pull.HeadCommit, resp.StatusCode)
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/contents/%s?ref=%v returned: %v", pull.BaseRepo.Owner, pull.BaseRepo.Name, fileName, pull.HeadCommit, resp.StatusCode)
+		}
 		return false, nil, err
 	}
 
@@ -0,0 +1,104 @@
+package gitea_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestClient_UpdateStatus(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := []struct {
+		status   models.CommitStatus
+		expState string
+	}{
+		{models.PendingCommitStatus, "pending"},
+		{models.SuccessCommitStatus, "success"},
+		{models.FailedCommitStatus, "failure"},
+		{models.CanceledCommitStatus, "error"},
+	}
+	for _, c := range cases {
+		t.Run(c.status.String(), func(t *testing.T) {
+			var body map[string]string
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/api/v1/version":
+					w.Write([]byte(`{"version": "1.22.0"}`)) // nolint: errcheck
+				case "/api/v1/repos/owner/repo/statuses/sha":
+					Equals(t, http.MethodPost, r.Method)
+					Ok(t, json.NewDecoder(r.Body).Decode(&body))
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{}`)) // nolint: errcheck
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client, err := gitea.NewClient(nil, testServer.URL, "user", "token", 30, logger)
+			Ok(t, err)
+			repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+			err = client.UpdateStatus(logger, repo, models.PullRequest{Num: 1, HeadCommit: "sha"}, c.status,
+				"atlantis/plan: project1", "Plan succeeded.", "https://atlantis.example.com/jobs/1234")
+			Ok(t, err)
+			Equals(t, map[string]string{
+				"state":       c.expState,
+				"context":     "atlantis/plan: project1",
+				"description": "Plan succeeded.",
+				"target_url":  "https://atlantis.example.com/jobs/1234",
+			}, body)
+		})
+	}
+}
+
+// An error from Gitea should be returned.
+func TestClient_UpdateStatusError(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI == "/api/v1/version" {
+			w.Write([]byte(`{"version": "1.22.0"}`)) // nolint: errcheck
+			return
+		}
+		http.Error(w, `{"message": "commit not found"}`, http.StatusNotFound)
+	}))
+	defer testServer.Close()
+
+	client, err := gitea.NewClient(nil, testServer.URL, "user", "token", 30, logger)
+	Ok(t, err)
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+	err = client.UpdateStatus(logger, repo, models.PullRequest{Num: 1, HeadCommit: "sha"}, models.SuccessCommitStatus, "atlantis/plan", "", "")
+	Assert(t, err != nil, "expected an error")
+}
+
+// A file that doesn't exist at the ref should be reported as not found
+// instead of as an error.
+func TestClient_GetFileContentNotFound(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/api/v1/version":
+			w.Write([]byte(`{"version": "1.22.0"}`)) // nolint: errcheck
+		case "/api/v1/repos/owner/atlantis-config/contents/owner/repo/atlantis.yaml?ref=main":
+			http.Error(w, `{"message": "object does not exist"}`, http.StatusNotFound)
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := gitea.NewClient(nil, testServer.URL, "user", "token", 30, logger)
+	Ok(t, err)
+	repo := models.Repo{FullName: "owner/atlantis-config", Owner: "owner", Name: "atlantis-config"}
+	found, content, err := client.GetFileContent(logger, models.PullRequest{BaseRepo: repo, HeadCommit: "main"}, "owner/repo/atlantis.yaml")
+	Ok(t, err)
+	Equals(t, false, found)
+	Equals(t, []byte(nil), content)
+}
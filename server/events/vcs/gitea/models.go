@@ -28,3 +28,10 @@ type GiteaIssueCommentPayload struct {
 	Repository gitea.Repository `json:"repository"`
 	Issue      gitea.Issue      `json:"issue"`
 }
+
+// GiteaDeletePayload is sent when a branch or tag is deleted.
+type GiteaDeletePayload struct {
+	Ref        string           `json:"ref"`
+	RefType    string           `json:"ref_type"`
+	Repository gitea.Repository `json:"repository"`
+}
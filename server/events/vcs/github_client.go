@@ -27,6 +27,7 @@ import (
 
 	"github.com/gofri/go-github-ratelimit/github_ratelimit"
 	"github.com/google/go-github/v71/github"
+	"github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -87,8 +88,20 @@ type GithubClient struct {
 	config                GithubConfig
 	maxCommentsPerCommand int
 	repoIdCache           GitHubRepoIdCache
+	// app is true if the client authenticates as a GitHub app.
+	app bool
+	// Version is set to the version of GitHub Enterprise Server hosts by
+	// DetectVersion. It's nil for github.com.
+	Version *version.Version
 }
 
+// The GitHub Enterprise Server versions that added the API features Atlantis
+// can do without.
+var (
+	githubCheckRunsSupported = MustConstraint(">= 2.15")
+	githubRulesetsSupported  = MustConstraint(">= 3.11")
+)
+
 // GithubAppTemporarySecrets holds app credentials obtained from github after creation.
 type GithubAppTemporarySecrets struct {
 	// ID is the app id.
@@ -125,8 +138,13 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 		return nil, errors.Wrap(err, "error initializing github authentication transport")
 	}
 
+	baseTransport := transport.Transport
+	if config.WrapTransport != nil {
+		baseTransport = config.WrapTransport(baseTransport)
+	}
+
 	transportWithRateLimit, err := github_ratelimit.NewRateLimitWaiterClient(
-		transport.Transport,
+		baseTransport,
 		github_ratelimit.WithTotalSleepLimit(time.Minute, func(callbackContext *github_ratelimit.CallbackContext) {
 			logger.Warn("github rate limit exceeded total sleep time, requests will fail to avoid penalties from github")
 		}))
@@ -154,6 +172,7 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 
 	user, err := credentials.GetUser()
 	logger.Debug("GH User: %s", user)
+	_, isApp := credentials.(*GithubAppCredentials)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "getting user")
@@ -167,14 +186,87 @@ func NewGithubClient(hostname string, credentials GithubCredentials, config Gith
 		config:                config,
 		maxCommentsPerCommand: maxCommentsPerCommand,
 		repoIdCache:           NewGitHubRepoIdCache(),
+		app:                   isApp,
 	}, nil
 }
 
+// GetVersion returns the version of the GitHub Enterprise Server this client
+// is using, or nil if the host doesn't report one, like github.com.
+func (g *GithubClient) GetVersion(logger logging.SimpleLogging) (*version.Version, error) {
+	logger.Debug("Getting GitHub Enterprise Server version")
+	req, err := g.client.NewRequest("GET", "meta", nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta struct {
+		InstalledVersion string `json:"installed_version"`
+	}
+	resp, err := g.client.Do(g.ctx, req, &meta)
+	if resp != nil {
+		logger.Debug("GET /meta returned: %v", resp.StatusCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if meta.InstalledVersion == "" {
+		return nil, nil
+	}
+	parsedVersion, err := version.NewVersion(meta.InstalledVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing response to /meta: %q", meta.InstalledVersion)
+	}
+	return parsedVersion, nil
+}
+
+// DetectVersion sets Version and warns about the features Atlantis disables
+// because the GitHub Enterprise Server is too old for them, instead of
+// failing when they're used.
+func (g *GithubClient) DetectVersion(logger logging.SimpleLogging) error {
+	v, err := g.GetVersion(logger)
+	if err != nil {
+		return err
+	}
+	g.Version = v
+	if v == nil {
+		return nil
+	}
+	logger.Info("GitHub Enterprise Server host is running version %s", v.String())
+	if !g.SupportsCheckRuns() {
+		logger.Warn("GitHub Enterprise Server %s doesn't support check runs (%s), commit statuses are used instead, without summaries, annotations and re-run buttons", v.String(), githubCheckRunsSupported.String())
+	}
+	if !g.SupportsRulesets() {
+		logger.Warn("GitHub Enterprise Server %s doesn't support repository rulesets (%s), only the required checks of branch protection rules are used to check if pull requests are mergeable", v.String(), githubRulesetsSupported.String())
+	}
+	return nil
+}
+
+// SupportsCheckRuns returns true if the GitHub host supports check runs and
+// their annotations.
+func (g *GithubClient) SupportsCheckRuns() bool {
+	return g.Version == nil || githubCheckRunsSupported.Check(g.Version)
+}
+
+// SupportsRulesets returns true if the GitHub host supports repository
+// rulesets.
+func (g *GithubClient) SupportsRulesets() bool {
+	return g.Version == nil || githubRulesetsSupported.Check(g.Version)
+}
+
 // GetModifiedFiles returns the names of files that were modified in the pull request
 // relative to the repo root, e.g. parent/child/file.txt.
 func (g *GithubClient) GetModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
-	logger.Debug("Getting modified files for GitHub pull request %d", pull.Num)
 	var files []string
+	err := g.ListModifiedFiles(logger, repo, pull, func(page []string) bool {
+		files = append(files, page...)
+		return true
+	})
+	return files, err
+}
+
+// ListModifiedFiles calls f with each page of the files that were modified in
+// the pull request until f returns false. See ModifiedFilesLister.
+func (g *GithubClient) ListModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error {
+	logger.Debug("Getting modified files for GitHub pull request %d", pull.Num)
 	nextPage := 0
 
 listloop:
@@ -206,25 +298,26 @@ listloop:
 					continue
 				}
 				// something else, give up
-				return files, err
+				return err
 			}
-			for _, f := range pageFiles {
-				files = append(files, f.GetFilename())
+			var files []string
+			for _, file := range pageFiles {
+				files = append(files, file.GetFilename())
 
 				// If the file was renamed, we'll want to run plan in the directory
 				// it was moved from as well.
-				if f.GetStatus() == "renamed" {
-					files = append(files, f.GetPreviousFilename())
+				if file.GetStatus() == "renamed" {
+					files = append(files, file.GetPreviousFilename())
 				}
 			}
-			if resp.NextPage == 0 {
+			if !f(files) || resp.NextPage == 0 {
 				break listloop
 			}
 			nextPage = resp.NextPage
 			break
 		}
 	}
-	return files, nil
+	return nil
 }
 
 // CreateComment creates a comment on the pull request.
@@ -272,51 +365,60 @@ func (g *GithubClient) ReactToComment(logger logging.SimpleLogging, repo models.
 	return err
 }
 
-func (g *GithubClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
-	logger.Debug("Hiding previous command comments on GitHub pull request %d", pullNum)
-	var allComments []*github.IssueComment
-	nextPage := 0
-	for {
-		comments, resp, err := g.client.Issues.ListComments(g.ctx, repo.Owner, repo.Name, pullNum, &github.IssueListCommentsOptions{
-			Sort:        github.Ptr("created"),
-			Direction:   github.Ptr("asc"),
-			ListOptions: github.ListOptions{Page: nextPage},
-		})
-		if resp != nil {
-			logger.Debug("GET /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
-		}
-		if err != nil {
-			return errors.Wrap(err, "listing comments")
-		}
-		allComments = append(allComments, comments...)
-		if resp.NextPage == 0 {
-			break
-		}
-		nextPage = resp.NextPage
+// RemoveReaction removes reaction from the comment commentID. Reacting again
+// with the same reaction returns the existing reaction, which is then deleted.
+func (g *GithubClient) RemoveReaction(logger logging.SimpleLogging, repo models.Repo, _ int, commentID int64, reaction string) error {
+	logger.Debug("Removing reaction from GitHub pull request comment %d", commentID)
+	r, _, err := g.client.Reactions.CreateIssueCommentReaction(g.ctx, repo.Owner, repo.Name, commentID, reaction)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Reactions.DeleteIssueCommentReaction(g.ctx, repo.Owner, repo.Name, commentID, r.GetID())
+	if resp != nil {
+		logger.Debug("DELETE /repos/%v/%v/issues/comments/%d/reactions/%d returned: %v", repo.Owner, repo.Name, commentID, r.GetID(), resp.StatusCode)
 	}
+	return err
+}
 
-	for _, comment := range allComments {
-		// Using a case insensitive compare here because usernames aren't case
-		// sensitive and users may enter their atlantis users with different
-		// cases.
-		if comment.User != nil && !strings.EqualFold(comment.User.GetLogin(), g.user) {
-			continue
-		}
-		// Crude filtering: The comment templates typically include the command name
-		// somewhere in the first line. It's a bit of an assumption, but seems like
-		// a reasonable one, given we've already filtered the comments by the
-		// configured Atlantis user.
-		body := strings.Split(comment.GetBody(), "\n")
-		if len(body) == 0 {
-			continue
+// CreateSuggestions creates a review of the pull request commenting each
+// suggestion on its lines.
+func (g *GithubClient) CreateSuggestions(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, body string, suggestions []Suggestion) error {
+	logger.Debug("Creating %d suggestion(s) on GitHub pull request %d", len(suggestions), pull.Num)
+	var comments []*github.DraftReviewComment
+	for _, s := range suggestions {
+		comment := &github.DraftReviewComment{
+			Path: github.Ptr(s.Path),
+			Body: github.Ptr(fmt.Sprintf("```suggestion\n%s\n```", s.Replacement)),
+			Side: github.Ptr("RIGHT"),
+			Line: github.Ptr(s.EndLine),
 		}
-		firstLine := strings.ToLower(body[0])
-		if !strings.Contains(firstLine, strings.ToLower(command)) {
-			continue
+		if s.StartLine < s.EndLine {
+			comment.StartSide = github.Ptr("RIGHT")
+			comment.StartLine = github.Ptr(s.StartLine)
 		}
+		comments = append(comments, comment)
+	}
+	_, resp, err := g.client.PullRequests.CreateReview(g.ctx, repo.Owner, repo.Name, pull.Num, &github.PullRequestReviewRequest{
+		CommitID: github.Ptr(pull.HeadCommit),
+		Body:     github.Ptr(body),
+		Event:    github.Ptr("COMMENT"),
+		Comments: comments,
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%v/%v/pulls/%d/reviews returned: %v", repo.Owner, repo.Name, pull.Num, resp.StatusCode)
+	}
+	return err
+}
 
-		// If dir was specified, skip processing comments that don't contain the dir in the first line
-		if dir != "" && !strings.Contains(firstLine, strings.ToLower(dir)) {
+func (g *GithubClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
+	logger.Debug("Hiding previous command comments on GitHub pull request %d", pullNum)
+	allComments, err := g.listComments(logger, repo, pullNum)
+	if err != nil {
+		return err
+	}
+
+	for _, comment := range allComments {
+		if !g.isCommandComment(comment, command, dir) {
 			continue
 		}
 
@@ -342,6 +444,93 @@ func (g *GithubClient) HidePrevCommandComments(logger logging.SimpleLogging, rep
 	return nil
 }
 
+// UpdateLastComment replaces the body of the last comment Atlantis made for
+// command and dir on the pull request. It returns false without editing
+// anything if there's no such comment or if comment doesn't fit in a single
+// comment.
+func (g *GithubClient) UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error) {
+	if len(comment) > maxCommentLength {
+		return false, nil
+	}
+	allComments, err := g.listComments(logger, repo, pullNum)
+	if err != nil {
+		return false, err
+	}
+	var last *github.IssueComment
+	for _, c := range allComments {
+		if g.isCommandComment(c, command, dir) {
+			last = c
+		}
+	}
+	if last == nil {
+		return false, nil
+	}
+
+	logger.Debug("Updating comment %d on GitHub pull request %d", last.GetID(), pullNum)
+	_, resp, err := g.client.Issues.EditComment(g.ctx, repo.Owner, repo.Name, last.GetID(), &github.IssueComment{Body: &comment})
+	if resp != nil {
+		logger.Debug("PATCH /repos/%v/%v/issues/comments/%d returned: %v", repo.Owner, repo.Name, last.GetID(), resp.StatusCode)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "updating comment %d", last.GetID())
+	}
+	return true, nil
+}
+
+// listComments returns all the comments on the pull request, oldest first.
+func (g *GithubClient) listComments(logger logging.SimpleLogging, repo models.Repo, pullNum int) ([]*github.IssueComment, error) {
+	var allComments []*github.IssueComment
+	nextPage := 0
+	for {
+		comments, resp, err := g.client.Issues.ListComments(g.ctx, repo.Owner, repo.Name, pullNum, &github.IssueListCommentsOptions{
+			Sort:        github.Ptr("created"),
+			Direction:   github.Ptr("asc"),
+			ListOptions: github.ListOptions{Page: nextPage},
+		})
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/issues/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "listing comments")
+		}
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return allComments, nil
+}
+
+// isCommandComment returns true if comment was made by Atlantis for command
+// and, if set, dir.
+func (g *GithubClient) isCommandComment(comment *github.IssueComment, command string, dir string) bool {
+	// Using a case insensitive compare here because usernames aren't case
+	// sensitive and users may enter their atlantis users with different
+	// cases.
+	if comment.User != nil && !strings.EqualFold(comment.User.GetLogin(), g.user) {
+		return false
+	}
+	// Crude filtering: The comment templates typically include the command name
+	// somewhere in the first line. It's a bit of an assumption, but seems like
+	// a reasonable one, given we've already filtered the comments by the
+	// configured Atlantis user.
+	body := strings.Split(comment.GetBody(), "\n")
+	if len(body) == 0 {
+		return false
+	}
+	firstLine := strings.ToLower(body[0])
+	if !strings.Contains(firstLine, strings.ToLower(command)) {
+		return false
+	}
+
+	// If dir was specified, skip processing comments that don't contain the dir in the first line
+	if dir != "" && !strings.Contains(firstLine, strings.ToLower(dir)) {
+		return false
+	}
+	return true
+}
+
 // getPRReviews Retrieves PR reviews for a pull request on a specific repository.
 // The reviews are being retrieved using pages with the size of 10 reviews.
 func (g *GithubClient) getPRReviews(repo models.Repo, pull models.PullRequest) (GithubPRReviewSummary, error) {
@@ -429,6 +618,87 @@ func (g *GithubClient) PullIsApproved(logger logging.SimpleLogging, repo models.
 	return approvalStatus, nil
 }
 
+// githubCodeOwnersFiles are the paths, in order of precedence, of the
+// CODEOWNERS file of a repo.
+var githubCodeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// UnapprovedFiles returns the files, out of files, that have owners in the
+// CODEOWNERS file of the base branch and that none of their owners approved
+// pull. Only the latest review of each reviewer counts, and owners are @logins
+// or @org/team-slugs.
+func (g *GithubClient) UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error) {
+	logger.Debug("Checking if the code owners of GitHub pull request %d approved it", pull.Num)
+	// The CODEOWNERS file is read from the base branch so pull requests
+	// can't change their own owners.
+	var codeOwners CodeOwners
+	for _, file := range githubCodeOwnersFiles {
+		opt := github.RepositoryContentGetOptions{Ref: pull.BaseBranch}
+		fileContent, _, resp, err := g.client.Repositories.GetContents(g.ctx, repo.Owner, repo.Name, file, &opt)
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/contents/%s returned: %v", repo.Owner, repo.Name, file, resp.StatusCode)
+		}
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting %s", file)
+		}
+		content, err := fileContent.GetContent()
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding %s", file)
+		}
+		codeOwners = ParseCodeOwners(content)
+		break
+	}
+
+	// Reviews are listed in chronological order and comments don't change
+	// the previous review of a reviewer.
+	latestStates := map[string]string{}
+	nextPage := 0
+	for {
+		opts := github.ListOptions{
+			PerPage: 300,
+		}
+		if nextPage != 0 {
+			opts.Page = nextPage
+		}
+		pageReviews, resp, err := g.client.PullRequests.ListReviews(g.ctx, repo.Owner, repo.Name, pull.Num, &opts)
+		if resp != nil {
+			logger.Debug("GET /repos/%v/%v/pulls/%d/reviews returned: %v", repo.Owner, repo.Name, pull.Num, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "getting reviews")
+		}
+		for _, review := range pageReviews {
+			if state := review.GetState(); state != "COMMENTED" && state != "PENDING" {
+				latestStates[review.GetUser().GetLogin()] = state
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+
+	var approvers []string
+	for login, state := range latestStates {
+		if state != "APPROVED" {
+			continue
+		}
+		approvers = append(approvers, "@"+login)
+		if codeOwners.hasTeams() {
+			teams, err := g.GetTeamNamesForUser(logger, repo, models.User{Username: login})
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting the teams of %s", login)
+			}
+			for _, team := range teams {
+				approvers = append(approvers, "@"+repo.Owner+"/"+team)
+			}
+		}
+	}
+	return codeOwners.UnapprovedFiles(files, nil, approvers), nil
+}
+
 // DiscardReviews dismisses all reviews on a pull request
 func (g *GithubClient) DiscardReviews(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) error {
 	logger.Debug("Discarding all reviews on GitHub pull request %d", pull.Num)
@@ -588,17 +858,34 @@ func (g *GithubClient) GetPullRequestMergeabilityInfo(
 	statusContexts []StatusContext,
 	err error,
 ) {
+	type branchProtectionRule struct {
+		RequiredStatusChecks []struct {
+			Context githubv4.String
+		}
+	}
+	type commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup struct {
+					Contexts struct {
+						PageInfo PageInfo
+						Nodes    []struct {
+							Typename      githubv4.String `graphql:"__typename"`
+							CheckRun      CheckRun        `graphql:"... on CheckRun"`
+							StatusContext StatusContext   `graphql:"... on StatusContext"`
+						}
+					} `graphql:"contexts(first: 100, after: $contextCursor)"`
+				}
+			}
+		}
+	}
 	var query struct {
 		Repository struct {
 			PullRequest struct {
 				ReviewDecision githubv4.String
 				BaseRef        struct {
-					BranchProtectionRule struct {
-						RequiredStatusChecks []struct {
-							Context githubv4.String
-						}
-					}
-					Rules struct {
+					BranchProtectionRule branchProtectionRule
+					Rules                struct {
 						PageInfo PageInfo
 						Nodes    []struct {
 							Type              githubv4.String
@@ -618,22 +905,20 @@ func (g *GithubClient) GetPullRequestMergeabilityInfo(
 						}
 					} `graphql:"rules(first: 100, after: $ruleCursor)"`
 				}
-				Commits struct {
-					Nodes []struct {
-						Commit struct {
-							StatusCheckRollup struct {
-								Contexts struct {
-									PageInfo PageInfo
-									Nodes    []struct {
-										Typename      githubv4.String `graphql:"__typename"`
-										CheckRun      CheckRun        `graphql:"... on CheckRun"`
-										StatusContext StatusContext   `graphql:"... on StatusContext"`
-									}
-								} `graphql:"contexts(first: 100, after: $contextCursor)"`
-							}
-						}
-					}
-				} `graphql:"commits(last: 1)"`
+				Commits commits `graphql:"commits(last: 1)"`
+			} `graphql:"pullRequest(number: $number)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	// GitHub Enterprise Server hosts without rulesets reject queries for
+	// them.
+	var queryWithoutRules struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewDecision githubv4.String
+				BaseRef        struct {
+					BranchProtectionRule branchProtectionRule
+				}
+				Commits commits `graphql:"commits(last: 1)"`
 			} `graphql:"pullRequest(number: $number)"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
 	}
@@ -645,12 +930,23 @@ func (g *GithubClient) GetPullRequestMergeabilityInfo(
 		"ruleCursor":    (*githubv4.String)(nil),
 		"contextCursor": (*githubv4.String)(nil),
 	}
+	supportsRulesets := g.SupportsRulesets()
+	if !supportsRulesets {
+		delete(variables, "ruleCursor")
+	}
 
 	requiredChecksSet := make(map[githubv4.String]any)
 
 pagination:
 	for {
-		err = g.v4Client.Query(g.ctx, &query, variables)
+		if supportsRulesets {
+			err = g.v4Client.Query(g.ctx, &query, variables)
+		} else {
+			err = g.v4Client.Query(g.ctx, &queryWithoutRules, variables)
+			query.Repository.PullRequest.ReviewDecision = queryWithoutRules.Repository.PullRequest.ReviewDecision
+			query.Repository.PullRequest.BaseRef.BranchProtectionRule = queryWithoutRules.Repository.PullRequest.BaseRef.BranchProtectionRule
+			query.Repository.PullRequest.Commits = queryWithoutRules.Repository.PullRequest.Commits
+		}
 
 		if err != nil {
 			break pagination
@@ -919,6 +1215,8 @@ func (g *GithubClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 		ghState = "success"
 	case models.FailedCommitStatus:
 		ghState = "failure"
+	case models.CanceledCommitStatus:
+		ghState = "error"
 	}
 
 	logger.Info("Updating GitHub Check status for '%s' to '%s'", src, ghState)
@@ -936,6 +1234,178 @@ func (g *GithubClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 	return err
 }
 
+// maxCheckRunAnnotations is the maximum number of annotations GitHub accepts
+// in a single check run request.
+const maxCheckRunAnnotations = 50
+
+// CheckRunOptions describes a check run to create or update.
+type CheckRunOptions struct {
+	// Name identifies the check run on the head commit of the pull request.
+	Name  string
+	State models.CommitStatus
+	// Title and Summary are shown on the check run's page. Summary is
+	// markdown. If Summary is empty, the check run's output isn't changed.
+	Title       string
+	Summary     string
+	Annotations []CheckRunAnnotation
+	// Actions are buttons shown on the check run's page.
+	Actions []CheckRunAction
+	// ExternalID is sent back to us in check_run webhooks, ex. when the check
+	// run is re-run.
+	ExternalID string
+	DetailsURL string
+}
+
+// CheckRunAnnotation is a comment on lines of a file in a check run.
+type CheckRunAnnotation struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	// Level is one of notice, warning or failure.
+	Level   string
+	Title   string
+	Message string
+}
+
+// CheckRunAction is a button on a check run. When it's clicked, GitHub sends
+// a check_run webhook with the action's Identifier.
+type CheckRunAction struct {
+	// Label can be at most 20 characters.
+	Label string
+	// Description can be at most 40 characters.
+	Description string
+	// Identifier can be at most 20 characters.
+	Identifier string
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_github_check_run_updater.go GithubCheckRunUpdater
+
+// GithubCheckRunUpdater creates and updates GitHub check runs.
+type GithubCheckRunUpdater interface {
+	UpdateCheckRun(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, opts CheckRunOptions) error
+}
+
+// UpdateCheckRun creates the check run named opts.Name on the head commit of
+// pull, or updates it if it already exists. Check runs can only be created by
+// GitHub apps.
+func (g *GithubClient) UpdateCheckRun(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, opts CheckRunOptions) error {
+	var status, conclusion string
+	switch opts.State {
+	case models.PendingCommitStatus:
+		status = "in_progress"
+	case models.SuccessCommitStatus:
+		status, conclusion = "completed", "success"
+	case models.FailedCommitStatus:
+		status, conclusion = "completed", "failure"
+	case models.CanceledCommitStatus:
+		status, conclusion = "completed", "cancelled"
+	}
+
+	var output *github.CheckRunOutput
+	if opts.Summary != "" {
+		title := opts.Title
+		if title == "" {
+			title = opts.Name
+		}
+		output = &github.CheckRunOutput{
+			Title:   github.Ptr(title),
+			Summary: github.Ptr(truncate(opts.Summary, maxCommentLength)),
+		}
+		for i, a := range opts.Annotations {
+			if i == maxCheckRunAnnotations {
+				logger.Warn("only the first %d of %d annotations were added to check run '%s'", maxCheckRunAnnotations, len(opts.Annotations), opts.Name)
+				break
+			}
+			output.Annotations = append(output.Annotations, &github.CheckRunAnnotation{
+				Path:            github.Ptr(a.Path),
+				StartLine:       github.Ptr(a.StartLine),
+				EndLine:         github.Ptr(a.EndLine),
+				AnnotationLevel: github.Ptr(a.Level),
+				Title:           github.Ptr(a.Title),
+				Message:         github.Ptr(truncate(a.Message, maxCommentLength)),
+			})
+		}
+	}
+	var actions []*github.CheckRunAction
+	for _, a := range opts.Actions {
+		actions = append(actions, &github.CheckRunAction{
+			Label:       a.Label,
+			Description: a.Description,
+			Identifier:  a.Identifier,
+		})
+	}
+	var completedAt *github.Timestamp
+	if conclusion != "" {
+		completedAt = &github.Timestamp{Time: time.Now()}
+	}
+
+	logger.Info("Updating GitHub Check Run for '%s' to '%s'", opts.Name, opts.State.String())
+
+	existing, resp, err := g.client.Checks.ListCheckRunsForRef(g.ctx, repo.Owner, repo.Name, pull.HeadCommit, &github.ListCheckRunsOptions{
+		CheckName: github.Ptr(opts.Name),
+		Filter:    github.Ptr("latest"),
+	})
+	if resp != nil {
+		logger.Debug("GET /repos/%v/%v/commits/%s/check-runs returned: %v", repo.Owner, repo.Name, pull.HeadCommit, resp.StatusCode)
+	}
+	if err != nil {
+		return errors.Wrap(err, "listing check runs")
+	}
+
+	if len(existing.CheckRuns) == 0 {
+		_, resp, err = g.client.Checks.CreateCheckRun(g.ctx, repo.Owner, repo.Name, github.CreateCheckRunOptions{
+			Name:        opts.Name,
+			HeadSHA:     pull.HeadCommit,
+			DetailsURL:  optionalString(opts.DetailsURL),
+			ExternalID:  optionalString(opts.ExternalID),
+			Status:      optionalString(status),
+			Conclusion:  optionalString(conclusion),
+			CompletedAt: completedAt,
+			Output:      output,
+			Actions:     actions,
+		})
+		if resp != nil {
+			logger.Debug("POST /repos/%v/%v/check-runs returned: %v", repo.Owner, repo.Name, resp.StatusCode)
+		}
+		return errors.Wrap(err, "creating check run")
+	}
+
+	checkRunID := existing.CheckRuns[0].GetID()
+	_, resp, err = g.client.Checks.UpdateCheckRun(g.ctx, repo.Owner, repo.Name, checkRunID, github.UpdateCheckRunOptions{
+		Name:        opts.Name,
+		DetailsURL:  optionalString(opts.DetailsURL),
+		ExternalID:  optionalString(opts.ExternalID),
+		Status:      optionalString(status),
+		Conclusion:  optionalString(conclusion),
+		CompletedAt: completedAt,
+		Output:      output,
+		Actions:     actions,
+	})
+	if resp != nil {
+		logger.Debug("PATCH /repos/%v/%v/check-runs/%d returned: %v", repo.Owner, repo.Name, checkRunID, resp.StatusCode)
+	}
+	return errors.Wrap(err, "updating check run")
+}
+
+// truncate shortens s to at most maxLen characters, noting that it was
+// truncated.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	warning := "\n...\n**Warning**: Output truncated because it was too long for GitHub.\n"
+	return s[:maxLen-len(warning)] + warning
+}
+
+// optionalString returns nil for empty strings so that they're omitted from
+// requests.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 // MergePull merges the pull request.
 func (g *GithubClient) MergePull(logger logging.SimpleLogging, pull models.PullRequest, pullOptions models.PullRequestOptions) error {
 	logger.Debug("Merging GitHub pull request %d", pull.Num)
@@ -1142,3 +1612,357 @@ func (g *GithubClient) GetPullLabels(logger logging.SimpleLogging, repo models.R
 
 	return labels, nil
 }
+
+// GetPullDescription returns the body of the pull request.
+func (g *GithubClient) GetPullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (string, error) {
+	logger.Debug("Getting description of GitHub pull request %d", pull.Num)
+	pullDetails, resp, err := g.client.PullRequests.Get(g.ctx, repo.Owner, repo.Name, pull.Num)
+	if resp != nil {
+		logger.Debug("GET /repos/%v/%v/pulls/%d returned: %v", repo.Owner, repo.Name, pull.Num, resp.StatusCode)
+	}
+	if err != nil {
+		return "", err
+	}
+	return pullDetails.GetBody(), nil
+}
+
+// UpdatePullDescription replaces the body of the pull request.
+func (g *GithubClient) UpdatePullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, description string) error {
+	logger.Debug("Updating description of GitHub pull request %d", pull.Num)
+	_, resp, err := g.client.PullRequests.Edit(g.ctx, repo.Owner, repo.Name, pull.Num, &github.PullRequest{
+		Body: github.Ptr(description),
+	})
+	if resp != nil {
+		logger.Debug("PATCH /repos/%v/%v/pulls/%d returned: %v", repo.Owner, repo.Name, pull.Num, resp.StatusCode)
+	}
+	return err
+}
+
+// githubWebhookEvents are the events the webhooks of repos must send.
+var githubWebhookEvents = []string{"issue_comment", "pull_request", "pull_request_review", "push"}
+
+// ValidateCredentials checks that the token can access the API and, for
+// classic tokens, that it has the repo scope. For apps, it checks that the
+// installation can be accessed.
+func (g *GithubClient) ValidateCredentials(logger logging.SimpleLogging) error {
+	if g.app {
+		_, resp, err := g.client.Apps.ListRepos(g.ctx, &github.ListOptions{PerPage: 1})
+		if resp != nil {
+			logger.Debug("GET /installation/repositories returned: %v", resp.StatusCode)
+		}
+		if err != nil {
+			return fmt.Errorf("the GitHub app can't access its installation, check --gh-app-id, --gh-app-key and --gh-app-installation-id and that the app is installed: %w", err)
+		}
+		return nil
+	}
+
+	user, resp, err := g.client.Users.Get(g.ctx, "")
+	if resp != nil {
+		logger.Debug("GET /user returned: %v", resp.StatusCode)
+	}
+	if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("the GitHub token is invalid or expired, create a new one as described in https://www.runatlantis.io/docs/access-credentials.html#github-user")
+	}
+	if err != nil {
+		return errors.Wrap(err, "getting the user of the GitHub token")
+	}
+	if !strings.EqualFold(user.GetLogin(), g.user) {
+		return fmt.Errorf("the GitHub token belongs to %q, not --gh-user %q, so Atlantis won't recognize its own comments: set --gh-user to %q", user.GetLogin(), g.user, user.GetLogin())
+	}
+	// Only classic tokens have scopes, fine-grained tokens have permissions
+	// that can't be looked up.
+	scopes, ok := resp.Header["X-Oauth-Scopes"]
+	if !ok {
+		return nil
+	}
+	for _, scope := range strings.Split(strings.Join(scopes, ","), ",") {
+		if strings.TrimSpace(scope) == "repo" {
+			return nil
+		}
+	}
+	return fmt.Errorf("the GitHub token has the scopes %q but Atlantis requires the repo scope, create a new token with it", strings.Join(scopes, ","))
+}
+
+// ValidateWebhook checks that the repo, or its organization, has an active
+// webhook sending the events Atlantis needs to url. The webhooks of apps are
+// configured on the app so they can't be checked.
+func (g *GithubClient) ValidateWebhook(logger logging.SimpleLogging, repoFullName string, url string) error {
+	if g.app {
+		return fmt.Errorf("%w: GitHub apps receive events through the webhook of the app", ErrWebhookUnchecked)
+	}
+	owner, name, ok := strings.Cut(repoFullName, "/")
+	if !ok {
+		return fmt.Errorf("invalid repo name %q", repoFullName)
+	}
+
+	hooks, err := g.listHooks(func(opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+		return g.client.Repositories.ListHooks(g.ctx, owner, name, opts)
+	})
+	if err != nil {
+		var ghErr *github.ErrorResponse
+		if errors.As(err, &ghErr) && (ghErr.Response.StatusCode == http.StatusForbidden || ghErr.Response.StatusCode == http.StatusNotFound) {
+			return fmt.Errorf("%w: the GitHub token needs admin access to %s to list its webhooks", ErrWebhookUnchecked, repoFullName)
+		}
+		return errors.Wrapf(err, "listing the webhooks of %s", repoFullName)
+	}
+	// Webhooks can also be installed on the organization. The token may not
+	// be allowed to list them, or the owner may be a user, in which case
+	// only the repo's webhooks are checked.
+	orgHooks, err := g.listHooks(func(opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+		return g.client.Organizations.ListHooks(g.ctx, owner, opts)
+	})
+	if err != nil {
+		logger.Debug("unable to list the webhooks of organization %s: %s", owner, err)
+	}
+	hooks = append(hooks, orgHooks...)
+
+	var problems []string
+	for _, hook := range hooks {
+		if !sameWebhookURL(hook.GetConfig().GetURL(), url) {
+			continue
+		}
+		if !hook.GetActive() {
+			problems = append(problems, "is inactive, activate it")
+			continue
+		}
+		var missing []string
+		for _, event := range githubWebhookEvents {
+			if !slices.Contains(hook.Events, event) && !slices.Contains(hook.Events, "*") {
+				missing = append(missing, event)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("doesn't send the %s events, select them", strings.Join(missing, ", ")))
+			continue
+		}
+		return nil
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the webhook of %s to %s %s", repoFullName, url, strings.Join(problems, " and "))
+	}
+	return fmt.Errorf("no webhook of %s or its organization sends events to %s, add one as described in https://www.runatlantis.io/docs/configuring-webhooks.html#github-github-enterprise", repoFullName, url)
+}
+
+// listHooks returns all the webhooks listed by list.
+func (g *GithubClient) listHooks(list func(opts *github.ListOptions) ([]*github.Hook, *github.Response, error)) ([]*github.Hook, error) {
+	var hooks []*github.Hook
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, page...)
+		if resp.NextPage == 0 {
+			return hooks, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListOrgRepos returns the repos of the organization or user org that aren't
+// archived.
+func (g *GithubClient) ListOrgRepos(logger logging.SimpleLogging, org string) ([]string, error) {
+	var repos []string
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := g.client.Repositories.ListByOrg(g.ctx, org, opts)
+		if resp != nil {
+			logger.Debug("GET /orgs/%s/repos returned: %v", org, resp.StatusCode)
+		}
+		var ghErr *github.ErrorResponse
+		if errors.As(err, &ghErr) && ghErr.Response.StatusCode == http.StatusNotFound && opts.Page == 0 {
+			return g.listUserRepos(logger, org)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the repos of %s", org)
+		}
+		for _, repo := range page {
+			if !repo.GetArchived() {
+				repos = append(repos, repo.GetFullName())
+			}
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listUserRepos returns the repos owned by user that aren't archived.
+func (g *GithubClient) listUserRepos(logger logging.SimpleLogging, user string) ([]string, error) {
+	var repos []string
+	opts := &github.RepositoryListByUserOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := g.client.Repositories.ListByUser(g.ctx, user, opts)
+		if resp != nil {
+			logger.Debug("GET /users/%s/repos returned: %v", user, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the repos of %s", user)
+		}
+		for _, repo := range page {
+			if !repo.GetArchived() {
+				repos = append(repos, repo.GetFullName())
+			}
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// RegisterWebhook creates or updates the webhook of the repo to url. The
+// events the webhook already sends are kept. GitHub apps receive events
+// through the webhook of the app so they can't register webhooks.
+func (g *GithubClient) RegisterWebhook(logger logging.SimpleLogging, repoFullName string, url string, secret string, updateSecret bool, dryRun bool) (string, error) {
+	if g.app {
+		return "", errors.New("GitHub apps receive events through the webhook of the app, configure it in the app's settings instead")
+	}
+	owner, name, ok := strings.Cut(repoFullName, "/")
+	if !ok {
+		return "", fmt.Errorf("invalid repo name %q", repoFullName)
+	}
+	hooks, err := g.listHooks(func(opts *github.ListOptions) ([]*github.Hook, *github.Response, error) {
+		return g.client.Repositories.ListHooks(g.ctx, owner, name, opts)
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "listing the webhooks of %s", repoFullName)
+	}
+
+	config := &github.HookConfig{
+		URL:         github.Ptr(url),
+		ContentType: github.Ptr("json"),
+		Secret:      github.Ptr(secret),
+	}
+	for _, hook := range hooks {
+		if !sameWebhookURL(hook.GetConfig().GetURL(), url) {
+			continue
+		}
+		events := hook.Events
+		for _, event := range githubWebhookEvents {
+			if !slices.Contains(events, event) && !slices.Contains(events, "*") {
+				events = append(events, event)
+			}
+		}
+		if hook.GetActive() && len(events) == len(hook.Events) && hook.GetConfig().GetContentType() == "json" && !updateSecret {
+			return WebhookUnchanged, nil
+		}
+		if dryRun {
+			return WebhookUpdated, nil
+		}
+		_, resp, err := g.client.Repositories.EditHook(g.ctx, owner, name, hook.GetID(), &github.Hook{
+			Config: config,
+			Events: events,
+			Active: github.Ptr(true),
+		})
+		if resp != nil {
+			logger.Debug("PATCH /repos/%s/hooks/%d returned: %v", repoFullName, hook.GetID(), resp.StatusCode)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "updating the webhook of %s", repoFullName)
+		}
+		return WebhookUpdated, nil
+	}
+
+	if dryRun {
+		return WebhookCreated, nil
+	}
+	_, resp, err := g.client.Repositories.CreateHook(g.ctx, owner, name, &github.Hook{
+		Config: config,
+		Events: githubWebhookEvents,
+		Active: github.Ptr(true),
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%s/hooks returned: %v", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "creating the webhook of %s", repoFullName)
+	}
+	return WebhookCreated, nil
+}
+
+// ListDefaultBranchFiles returns the default branch of the repo and the
+// paths of the files on it.
+func (g *GithubClient) ListDefaultBranchFiles(logger logging.SimpleLogging, repoFullName string) (string, []string, error) {
+	owner, name, ok := strings.Cut(repoFullName, "/")
+	if !ok {
+		return "", nil, fmt.Errorf("invalid repo name %q", repoFullName)
+	}
+	repo, resp, err := g.client.Repositories.Get(g.ctx, owner, name)
+	if resp != nil {
+		logger.Debug("GET /repos/%s returned: %v", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "getting repo %s", repoFullName)
+	}
+	branch := repo.GetDefaultBranch()
+	tree, resp, err := g.client.Git.GetTree(g.ctx, owner, name, branch, true)
+	if resp != nil {
+		logger.Debug("GET /repos/%s/git/trees/%s returned: %v", repoFullName, branch, resp.StatusCode)
+	}
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "listing the files of %s", repoFullName)
+	}
+	if tree.GetTruncated() {
+		logger.Warn("the files of %s were truncated by GitHub, some projects may be missing", repoFullName)
+	}
+	var files []string
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			files = append(files, entry.GetPath())
+		}
+	}
+	return branch, files, nil
+}
+
+// OpenPullWithFile creates branch from base, commits the file to it and opens
+// a pull request.
+func (g *GithubClient) OpenPullWithFile(logger logging.SimpleLogging, repoFullName string, base string, branch string, path string, content []byte, title string, body string) (string, error) {
+	owner, name, ok := strings.Cut(repoFullName, "/")
+	if !ok {
+		return "", fmt.Errorf("invalid repo name %q", repoFullName)
+	}
+	baseRef, resp, err := g.client.Git.GetRef(g.ctx, owner, name, "heads/"+base)
+	if resp != nil {
+		logger.Debug("GET /repos/%s/git/ref/heads/%s returned: %v", repoFullName, base, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "getting branch %s", base)
+	}
+	_, resp, err = g.client.Git.CreateRef(g.ctx, owner, name, &github.Reference{
+		Ref:    github.Ptr("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.GetObject().SHA},
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%s/git/refs returned: %v", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "creating branch %s", branch)
+	}
+	_, resp, err = g.client.Repositories.CreateFile(g.ctx, owner, name, path, &github.RepositoryContentFileOptions{
+		Message: github.Ptr(title),
+		Content: content,
+		Branch:  github.Ptr(branch),
+	})
+	if resp != nil {
+		logger.Debug("PUT /repos/%s/contents/%s returned: %v", repoFullName, path, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "committing %s", path)
+	}
+	pull, resp, err := g.client.PullRequests.Create(g.ctx, owner, name, &github.NewPullRequest{
+		Title: github.Ptr(title),
+		Head:  github.Ptr(branch),
+		Base:  github.Ptr(base),
+		Body:  github.Ptr(body),
+	})
+	if resp != nil {
+		logger.Debug("POST /repos/%s/pulls returned: %v", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "opening pull request")
+	}
+	return pull.GetHTMLURL(), nil
+}
@@ -2,13 +2,16 @@ package vcs_test
 
 import (
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -64,7 +67,7 @@ func TestGithubClient_GetModifiedFiles(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -87,6 +90,36 @@ func TestGithubClient_GetModifiedFiles(t *testing.T) {
 	Equals(t, []string{"file1.txt", "file2.txt"}, files)
 }
 
+// ListModifiedFiles shouldn't fetch the next pages once it's told to stop.
+func TestGithubClient_ListModifiedFilesStops(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/pulls/1/files?per_page=300":
+				w.Header().Add("Link", `<https://api.github.com/resource?page=2>; rel="next"`)
+				w.Write([]byte(`[{"filename": "env/prod/main.tf", "status": "modified"}]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	var pages [][]string
+	err = client.ListModifiedFiles(logger, models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}, models.PullRequest{Num: 1}, func(files []string) bool {
+		pages = append(pages, files)
+		return false
+	})
+	Ok(t, err)
+	Equals(t, [][]string{{"env/prod/main.tf"}}, pages)
+}
+
 // GetModifiedFiles should include the source and destination of a moved
 // file.
 func TestGithubClient_GetModifiedFilesMovedFile(t *testing.T) {
@@ -122,7 +155,7 @@ func TestGithubClient_GetModifiedFilesMovedFile(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -219,7 +252,7 @@ func TestGithubClient_PaginatesComments(t *testing.T) {
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
 
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -335,7 +368,7 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
 
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{atlantisUser, "pass", ""}, vcs.GithubConfig{}, 0,
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{atlantisUser, "pass", "", nil}, vcs.GithubConfig{}, 0,
 				logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
@@ -367,6 +400,190 @@ func TestGithubClient_HideOldComments(t *testing.T) {
 	}
 }
 
+func TestGithubClient_UpdateLastComment(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	issueResp := strings.ReplaceAll(`[
+	{"id": 1, "body": "Ran Plan for dir: 'stack1' workspace: 'default'", "user": {"login": "someone-else"}},
+	{"id": 2, "body": "Ran Plan for dir: 'stack1' workspace: 'default'", "user": {"login": "AtlantisUser"}},
+	{"id": 3, "body": "Ran Plan for dir: 'stack1' workspace: 'default'", "user": {"login": "AtlantisUser"}},
+	{"id": 4, "body": "Ran Plan for dir: 'stack2' workspace: 'default'", "user": {"login": "AtlantisUser"}},
+	{"id": 5, "body": "Ran Apply for dir: 'stack1' workspace: 'default'", "user": {"login": "AtlantisUser"}}
+]`, "'", "`")
+
+	cases := []struct {
+		description string
+		command     string
+		dir         string
+		comment     string
+		expUpdated  bool
+		expURI      string
+	}{
+		{
+			description: "updates the last comment for the command and dir",
+			command:     command.Plan.TitleString(),
+			dir:         "stack1",
+			comment:     "new plan",
+			expUpdated:  true,
+			expURI:      "/api/v3/repos/owner/repo/issues/comments/3",
+		},
+		{
+			description: "updates the last comment for the command without a dir",
+			command:     command.Plan.TitleString(),
+			comment:     "new plan",
+			expUpdated:  true,
+			expURI:      "/api/v3/repos/owner/repo/issues/comments/4",
+		},
+		{
+			description: "doesn't update anything without a matching comment",
+			command:     command.Import.TitleString(),
+			comment:     "new import",
+		},
+		{
+			description: "doesn't update anything if the comment needs splitting",
+			command:     command.Plan.TitleString(),
+			comment:     strings.Repeat("a", 65537),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			var gotURI, gotBody string
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.Method {
+					case "GET":
+						w.Write([]byte(issueResp)) // nolint: errcheck
+					case "PATCH":
+						var req struct {
+							Body string `json:"body"`
+						}
+						Ok(t, json.NewDecoder(r.Body).Decode(&req))
+						gotURI = r.RequestURI
+						gotBody = req.Body
+						w.Write([]byte(`{}`)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"AtlantisUser", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			updated, err := client.UpdateLastComment(logger, models.Repo{Owner: "owner", Name: "repo"}, 123, c.comment, c.command, c.dir)
+			Ok(t, err)
+			Equals(t, c.expUpdated, updated)
+			Equals(t, c.expURI, gotURI)
+			if c.expUpdated {
+				Equals(t, c.comment, gotBody)
+			}
+		})
+	}
+}
+
+func TestGithubClient_UpdateCheckRun(t *testing.T) {
+	cases := []struct {
+		description string
+		existing    string
+		expMethod   string
+		expURI      string
+		opts        vcs.CheckRunOptions
+		expBody     string
+	}{
+		{
+			description: "creates the check run if it doesn't exist",
+			existing:    `{"total_count":0,"check_runs":[]}`,
+			expMethod:   "POST",
+			expURI:      "/api/v3/repos/owner/repo/check-runs",
+			opts: vcs.CheckRunOptions{
+				Name:       "atlantis/plan",
+				State:      models.PendingCommitStatus,
+				Title:      "Plan in progress...",
+				Summary:    "Plan in progress...",
+				ExternalID: "plan",
+			},
+			expBody: `{"name":"atlantis/plan","head_sha":"sha","external_id":"plan","status":"in_progress","output":{"title":"Plan in progress...","summary":"Plan in progress..."}}`,
+		},
+		{
+			description: "updates the existing check run",
+			existing:    `{"total_count":1,"check_runs":[{"id":123,"name":"atlantis/plan"}]}`,
+			expMethod:   "PATCH",
+			expURI:      "/api/v3/repos/owner/repo/check-runs/123",
+			opts: vcs.CheckRunOptions{
+				Name:    "atlantis/plan",
+				State:   models.FailedCommitStatus,
+				Title:   "Policy check failed.",
+				Summary: "policies failed",
+				Annotations: []vcs.CheckRunAnnotation{
+					{Path: "main.tf", StartLine: 1, EndLine: 1, Level: "failure", Title: "policy failed", Message: "msg"},
+				},
+				Actions: []vcs.CheckRunAction{
+					{Label: "Re-plan", Description: "Plan all projects again", Identifier: "plan"},
+				},
+			},
+			expBody: `{"name":"atlantis/plan","status":"completed","conclusion":"failure","completed_at":"TIME","output":{"title":"Policy check failed.","summary":"policies failed","annotations":[{"path":"main.tf","start_line":1,"end_line":1,"annotation_level":"failure","message":"msg","title":"policy failed"}]},"actions":[{"label":"Re-plan","description":"Plan all projects again","identifier":"plan"}]}`,
+		},
+		{
+			description: "leaves the output unchanged without a summary",
+			existing:    `{"total_count":1,"check_runs":[{"id":123,"name":"atlantis/plan"}]}`,
+			expMethod:   "PATCH",
+			expURI:      "/api/v3/repos/owner/repo/check-runs/123",
+			opts: vcs.CheckRunOptions{
+				Name:       "atlantis/plan",
+				State:      models.SuccessCommitStatus,
+				Title:      "1/1 projects planned successfully.",
+				DetailsURL: "https://atlantis",
+			},
+			expBody: `{"name":"atlantis/plan","details_url":"https://atlantis","status":"completed","conclusion":"success","completed_at":"TIME"}`,
+		},
+	}
+
+	completedAt := regexp.MustCompile(`"completed_at":"[^"]+"`)
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			called := false
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch {
+					case r.Method == "GET" && r.RequestURI == "/api/v3/repos/owner/repo/commits/sha/check-runs?check_name=atlantis%2Fplan&filter=latest":
+						w.Write([]byte(c.existing)) // nolint: errcheck
+					case r.Method == c.expMethod && r.RequestURI == c.expURI:
+						called = true
+						body, err := io.ReadAll(r.Body)
+						Ok(t, err)
+						Equals(t, c.expBody, completedAt.ReplaceAllString(strings.TrimSpace(string(body)), `"completed_at":"TIME"`))
+						w.Write([]byte(`{"id":123}`)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.UpdateCheckRun(
+				logging.NewNoopLogger(t),
+				models.Repo{
+					FullName: "owner/repo",
+					Owner:    "owner",
+					Name:     "repo",
+				},
+				models.PullRequest{
+					Num:        1,
+					HeadCommit: "sha",
+				},
+				c.opts)
+			Ok(t, err)
+			Assert(t, called, "exp %s %s to be called", c.expMethod, c.expURI)
+		})
+	}
+}
+
 func TestGithubClient_UpdateStatus(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	cases := []struct {
@@ -408,7 +625,7 @@ func TestGithubClient_UpdateStatus(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -497,7 +714,7 @@ func TestGithubClient_PullIsApproved(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -592,7 +809,7 @@ func TestGithubClient_PullIsMergeable(t *testing.T) {
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -881,7 +1098,7 @@ func TestGithubClient_PullIsMergeableWithAllowMergeableBypassApply(t *testing.T)
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{AllowMergeableBypassApply: true}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{AllowMergeableBypassApply: true}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -906,6 +1123,117 @@ func TestGithubClient_PullIsMergeableWithAllowMergeableBypassApply(t *testing.T)
 	}
 }
 
+func TestGithubClient_DetectVersion(t *testing.T) {
+	cases := []struct {
+		installedVersion string
+		expVersion       string
+		expCheckRuns     bool
+		expRulesets      bool
+		expErr           string
+	}{
+		{"", "", true, true, ""},
+		{"3.14.2", "3.14.2", true, true, ""},
+		{"3.10.0", "3.10.0", true, false, ""},
+		{"2.14.0", "2.14.0", false, false, ""},
+		{"latest", "", true, true, `parsing response to /meta: "latest"`},
+	}
+	for _, c := range cases {
+		t.Run(c.installedVersion, func(t *testing.T) {
+			logger := logging.NewNoopLogger(t)
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/meta":
+						w.Write([]byte(fmt.Sprintf(`{"verifiable_password_authentication":true,"installed_version":%q}`, c.installedVersion))) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.DetectVersion(logger)
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, err)
+			} else {
+				Ok(t, err)
+			}
+			if c.expVersion == "" {
+				Assert(t, client.Version == nil, "expected no version, got %s", client.Version)
+			} else {
+				Equals(t, c.expVersion, client.Version.String())
+			}
+			Equals(t, c.expCheckRuns, client.SupportsCheckRuns())
+			Equals(t, c.expRulesets, client.SupportsRulesets())
+		})
+	}
+}
+
+// Hosts without rulesets reject queries for them, so only branch protection
+// rules are used.
+func TestGithubClient_PullIsMergeableWithoutRulesets(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	jsBytes, err := os.ReadFile("testdata/github-pull-request.json")
+	Ok(t, err)
+	prJSON := strings.Replace(string(jsBytes), `"mergeable_state": "clean"`, `"mergeable_state": "blocked"`, 1)
+	mergeabilityJSON := `{"data":{"repository":{"pullRequest":{
+		"reviewDecision":"APPROVED",
+		"baseRef":{"branchProtectionRule":{"requiredStatusChecks":[{"context":"atlantis/apply"},{"context":"my-required-expected-check"}]}},
+		"commits":{"nodes":[{"commit":{"statusCheckRollup":{"contexts":{
+			"pageInfo":{"endCursor":"QWERTY","hasNextPage":false},
+			"nodes":[
+				{"__typename":"StatusContext","context":"atlantis/apply","state":"PENDING","isRequired":true},
+				{"__typename":"StatusContext","context":"my-required-expected-check","state":"FAILED","isRequired":true}
+			]
+		}}}}]}
+	}}}}`
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/meta":
+				w.Write([]byte(`{"installed_version":"3.10.0"}`)) // nolint: errcheck
+			case "/api/v3/repos/octocat/repo/pulls/1":
+				w.Write([]byte(prJSON)) // nolint: errcheck
+			case "/api/graphql":
+				body, err := io.ReadAll(r.Body)
+				Ok(t, err)
+				if strings.Contains(string(body), "rules(") || strings.Contains(string(body), "ruleCursor") {
+					t.Errorf("queried rulesets: %s", body)
+				}
+				w.Write([]byte(mergeabilityJSON)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{AllowMergeableBypassApply: true}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+	Ok(t, client.DetectVersion(logger))
+
+	mergeable, err := client.PullIsMergeable(
+		logger,
+		models.Repo{
+			FullName: "octocat/repo",
+			Owner:    "octocat",
+			Name:     "repo",
+			VCSHost: models.VCSHost{
+				Type:     models.Github,
+				Hostname: "github.com",
+			},
+		}, models.PullRequest{
+			Num: 1,
+		}, "atlantis", nil)
+	Ok(t, err)
+	Equals(t, false, mergeable)
+}
+
 func TestGithubClient_MergePullHandlesError(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	cases := []struct {
@@ -966,7 +1294,7 @@ func TestGithubClient_MergePullHandlesError(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -1146,7 +1474,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 
@@ -1180,7 +1508,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 }
 
 func TestGithubClient_MarkdownPullLink(t *testing.T) {
-	client, err := vcs.NewGithubClient("hostname", &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient("hostname", &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	pull := models.PullRequest{Num: 1}
 	s, _ := client.MarkdownPullLink(pull)
@@ -1236,7 +1564,7 @@ func TestGithubClient_SplitComments(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	pull := models.PullRequest{Num: 1}
@@ -1295,7 +1623,7 @@ func TestGithubClient_Retry404(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	repo := models.Repo{
@@ -1341,7 +1669,7 @@ func TestGithubClient_Retry404Files(t *testing.T) {
 
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 	Ok(t, err)
 	defer disableSSLVerification()()
 	repo := models.Repo{
@@ -1394,7 +1722,7 @@ func TestGithubClient_GetTeamNamesForUser(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -1409,6 +1737,56 @@ func TestGithubClient_GetTeamNamesForUser(t *testing.T) {
 	Equals(t, []string{"frontend-developers", "employees"}, teams)
 }
 
+func TestGithubClient_UnapprovedFiles(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	codeOwners := base64.StdEncoding.EncodeToString([]byte("/env/prod/ @alice\n/env/staging/ @bob\n/modules/ @Carol\n"))
+	// bob's approval is replaced by his later request for changes.
+	reviews := `[
+		{"user":{"login":"bob"},"state":"APPROVED"},
+		{"user":{"login":"carol"},"state":"APPROVED"},
+		{"user":{"login":"bob"},"state":"CHANGES_REQUESTED"},
+		{"user":{"login":"carol"},"state":"COMMENTED"}
+	]`
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/contents/.github/CODEOWNERS?ref=main":
+				http.Error(w, "not found", http.StatusNotFound)
+			case "/api/v3/repos/owner/repo/contents/CODEOWNERS?ref=main":
+				w.Write([]byte(fmt.Sprintf(`{"type":"file","encoding":"base64","content":%q}`, codeOwners))) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/pulls/1/reviews?per_page=300":
+				w.Write([]byte(reviews)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	unapproved, err := client.UnapprovedFiles(
+		logger,
+		models.Repo{
+			FullName: "owner/repo",
+			Owner:    "owner",
+			Name:     "repo",
+			VCSHost: models.VCSHost{
+				Type:     models.Github,
+				Hostname: "github.com",
+			},
+		}, models.PullRequest{
+			Num:        1,
+			BaseBranch: "main",
+		},
+		[]string{"env/prod/main.tf", "env/staging/main.tf", "modules/vpc/main.tf", "README.md"})
+	Ok(t, err)
+	Equals(t, []string{"env/prod/main.tf", "env/staging/main.tf"}, unapproved)
+}
+
 func TestGithubClient_DiscardReviews(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	type ResponseDef struct {
@@ -1595,7 +1973,7 @@ func TestGithubClient_DiscardReviews(t *testing.T) {
 				}))
 			testServerURL, err := url.Parse(testServer.URL)
 			Ok(t, err)
-			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logging.NewNoopLogger(t))
 			Ok(t, err)
 			defer disableSSLVerification()()
 			if err := client.DiscardReviews(logger, tt.args.repo, tt.args.pull); (err != nil) != tt.wantErr {
@@ -1664,7 +2042,7 @@ func TestGithubClient_GetPullLabels(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -1701,7 +2079,7 @@ func TestGithubClient_GetPullLabels_EmptyResponse(t *testing.T) {
 		}))
 	testServerURL, err := url.Parse(testServer.URL)
 	Ok(t, err)
-	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", ""}, vcs.GithubConfig{}, 0, logger)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
 	Ok(t, err)
 	defer disableSSLVerification()()
 
@@ -1768,3 +2146,217 @@ func TestGithubClient_SecondaryRateLimitHandling_CreateComment(t *testing.T) {
 	Assert(t, calls > maxCalls, "Expected more than %d calls due to rate limiting, but got %d", maxCalls, calls)
 
 }
+
+func TestGithubClient_PullDescription(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	var gotBody string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v3/repos/runatlantis/atlantis/pulls/1":
+				w.Write([]byte(`{"number": 1, "body": "Adds a bucket."}`)) // nolint: errcheck
+			case "PATCH /api/v3/repos/runatlantis/atlantis/pulls/1":
+				var req struct {
+					Body string `json:"body"`
+				}
+				Ok(t, json.NewDecoder(r.Body).Decode(&req))
+				gotBody = req.Body
+				w.Write([]byte(`{"number": 1}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	repo := models.Repo{Owner: "runatlantis", Name: "atlantis"}
+	pull := models.PullRequest{Num: 1}
+	description, err := client.GetPullDescription(logger, repo, pull)
+	Ok(t, err)
+	Equals(t, "Adds a bucket.", description)
+
+	Ok(t, client.UpdatePullDescription(logger, repo, pull, "Adds a bucket.\n\nstatus"))
+	Equals(t, "Adds a bucket.\n\nstatus", gotBody)
+}
+
+func TestGithubClient_ValidateCredentials(t *testing.T) {
+	cases := []struct {
+		description string
+		status      int
+		scopes      string
+		login       string
+		expErr      string
+	}{
+		{"classic token with repo scope", http.StatusOK, "repo, workflow", "user", ""},
+		{"fine-grained token", http.StatusOK, "", "user", ""},
+		{"missing repo scope", http.StatusOK, "read:org", "user", `the GitHub token has the scopes "read:org" but Atlantis requires the repo scope, create a new token with it`},
+		{"other user", http.StatusOK, "repo", "someone-else", `the GitHub token belongs to "someone-else", not --gh-user "user", so Atlantis won't recognize its own comments: set --gh-user to "someone-else"`},
+		{"invalid token", http.StatusUnauthorized, "", "", "the GitHub token is invalid or expired, create a new one as described in https://www.runatlantis.io/docs/access-credentials.html#github-user"},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			logger := logging.NewNoopLogger(t)
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.RequestURI != "/api/v3/user" {
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+						return
+					}
+					if c.scopes != "" {
+						w.Header().Set("X-OAuth-Scopes", c.scopes)
+					}
+					w.WriteHeader(c.status)
+					fmt.Fprintf(w, `{"login": %q}`, c.login)
+				}))
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			err = client.ValidateCredentials(logger)
+			if c.expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, c.expErr, err)
+			}
+		})
+	}
+}
+
+func TestGithubClient_ValidateWebhook(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/hooks?per_page=100":
+				w.Write([]byte(`[{"active": true, "events": ["push"], "config": {"url": "https://atlantis.example.com/events"}}]`)) // nolint: errcheck
+			case "/api/v3/orgs/owner/hooks?per_page=100":
+				w.Write([]byte(`[{"active": true, "events": ["*"], "config": {"url": "https://atlantis.example.com/events/"}}]`)) // nolint: errcheck
+			case "/api/v3/repos/user/inactive/hooks?per_page=100":
+				w.Write([]byte(`[{"active": false, "events": ["*"], "config": {"url": "https://atlantis.example.com/events"}}]`)) // nolint: errcheck
+			case "/api/v3/repos/user/repo/hooks?per_page=100":
+				w.Write([]byte(`[{"active": true, "events": ["push", "pull_request"], "config": {"url": "https://atlantis.example.com/events"}}]`)) // nolint: errcheck
+			case "/api/v3/repos/user/missing/hooks?per_page=100":
+				w.Write([]byte(`[]`)) // nolint: errcheck
+			case "/api/v3/repos/owner/forbidden/hooks?per_page=100":
+				http.Error(w, `{"message": "Must have admin rights to Repository."}`, http.StatusForbidden)
+			default:
+				http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	// The repo's webhook lacks events but the organization's sends them all.
+	Ok(t, client.ValidateWebhook(logger, "owner/repo", "https://atlantis.example.com/events"))
+	ErrEquals(t, "the webhook of user/inactive to https://atlantis.example.com/events is inactive, activate it",
+		client.ValidateWebhook(logger, "user/inactive", "https://atlantis.example.com/events"))
+	ErrEquals(t, "the webhook of user/repo to https://atlantis.example.com/events doesn't send the issue_comment, pull_request_review events, select them",
+		client.ValidateWebhook(logger, "user/repo", "https://atlantis.example.com/events"))
+	ErrEquals(t, "no webhook of user/missing or its organization sends events to https://atlantis.example.com/events, add one as described in https://www.runatlantis.io/docs/configuring-webhooks.html#github-github-enterprise",
+		client.ValidateWebhook(logger, "user/missing", "https://atlantis.example.com/events"))
+	err = client.ValidateWebhook(logger, "owner/forbidden", "https://atlantis.example.com/events")
+	Assert(t, errors.Is(err, vcs.ErrWebhookUnchecked), "expected the webhooks to be unchecked, got %s", err)
+}
+
+func TestGithubClient_RegisterWebhook(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	var requests []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v3/repos/owner/new/hooks?per_page=100":
+				w.Write([]byte(`[{"id": 1, "active": true, "events": ["*"], "config": {"url": "https://other.example.com/events"}}]`)) // nolint: errcheck
+			case "GET /api/v3/repos/owner/ok/hooks?per_page=100":
+				w.Write([]byte(`[{"id": 2, "active": true, "events": ["issue_comment", "pull_request", "pull_request_review", "push"], "config": {"url": "https://atlantis.example.com/events", "content_type": "json"}}]`)) // nolint: errcheck
+			case "GET /api/v3/repos/owner/drifted/hooks?per_page=100":
+				w.Write([]byte(`[{"id": 3, "active": false, "events": ["push", "release"], "config": {"url": "https://atlantis.example.com/events", "content_type": "json"}}]`)) // nolint: errcheck
+			case "POST /api/v3/repos/owner/new/hooks", "PATCH /api/v3/repos/owner/drifted/hooks/3", "PATCH /api/v3/repos/owner/ok/hooks/2":
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+strings.TrimSpace(string(body)))
+				w.Write([]byte(`{}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	webhookURL := "https://atlantis.example.com/events"
+	for repo, expResult := range map[string]string{"owner/new": vcs.WebhookCreated, "owner/ok": vcs.WebhookUnchanged, "owner/drifted": vcs.WebhookUpdated} {
+		result, err := client.RegisterWebhook(logger, repo, webhookURL, "s3cr3t", false, true)
+		Ok(t, err)
+		Equals(t, expResult, result)
+	}
+	Equals(t, 0, len(requests))
+
+	result, err := client.RegisterWebhook(logger, "owner/new", webhookURL, "s3cr3t", false, false)
+	Ok(t, err)
+	Equals(t, vcs.WebhookCreated, result)
+	result, err = client.RegisterWebhook(logger, "owner/drifted", webhookURL, "s3cr3t", false, false)
+	Ok(t, err)
+	Equals(t, vcs.WebhookUpdated, result)
+	result, err = client.RegisterWebhook(logger, "owner/ok", webhookURL, "s3cr3t", true, false)
+	Ok(t, err)
+	Equals(t, vcs.WebhookUpdated, result)
+	Equals(t, []string{
+		`POST {"name":"web","config":{"content_type":"json","url":"https://atlantis.example.com/events","secret":"s3cr3t"},"events":["issue_comment","pull_request","pull_request_review","push"],"active":true}`,
+		`PATCH {"config":{"content_type":"json","url":"https://atlantis.example.com/events","secret":"s3cr3t"},"events":["push","release","issue_comment","pull_request","pull_request_review"],"active":true}`,
+	}, requests[:2])
+	Equals(t, 3, len(requests))
+}
+
+func TestGithubClient_OnboardRepo(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	var requests []string
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.RequestURI {
+			case "GET /api/v3/repos/owner/repo":
+				w.Write([]byte(`{"full_name": "owner/repo", "default_branch": "main"}`)) // nolint: errcheck
+			case "GET /api/v3/repos/owner/repo/git/trees/main?recursive=1":
+				w.Write([]byte(`{"tree": [{"path": "env", "type": "tree"}, {"path": "env/main.tf", "type": "blob"}, {"path": "README.md", "type": "blob"}]}`)) // nolint: errcheck
+			case "GET /api/v3/repos/owner/repo/git/ref/heads/main":
+				w.Write([]byte(`{"ref": "refs/heads/main", "object": {"sha": "abc123"}}`)) // nolint: errcheck
+			case "POST /api/v3/repos/owner/repo/git/refs", "PUT /api/v3/repos/owner/repo/contents/atlantis.yaml", "POST /api/v3/repos/owner/repo/pulls":
+				body, _ := io.ReadAll(r.Body)
+				requests = append(requests, r.Method+" "+strings.TrimSpace(string(body)))
+				w.Write([]byte(`{"html_url": "https://github.com/owner/repo/pull/1"}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, &vcs.GithubUserCredentials{"user", "pass", "", nil}, vcs.GithubConfig{}, 0, logger)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	branch, files, err := client.ListDefaultBranchFiles(logger, "owner/repo")
+	Ok(t, err)
+	Equals(t, "main", branch)
+	Equals(t, []string{"env/main.tf", "README.md"}, files)
+
+	pullURL, err := client.OpenPullWithFile(logger, "owner/repo", "main", "atlantis/init-repo", "atlantis.yaml", []byte("version: 3\n"), "Add atlantis.yaml", "body")
+	Ok(t, err)
+	Equals(t, "https://github.com/owner/repo/pull/1", pullURL)
+	Equals(t, []string{
+		`POST {"ref":"refs/heads/atlantis/init-repo","sha":"abc123"}`,
+		`PUT {"message":"Add atlantis.yaml","content":"dmVyc2lvbjogMwo=","branch":"atlantis/init-repo"}`,
+		`POST {"title":"Add atlantis.yaml","head":"atlantis/init-repo","base":"main","body":"body"}`,
+	}, requests)
+}
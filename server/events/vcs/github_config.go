@@ -1,6 +1,11 @@
 package vcs
 
+import "net/http"
+
 // GithubConfig allows for custom github-specific functionality and behavior
 type GithubConfig struct {
 	AllowMergeableBypassApply bool
+	// WrapTransport, if set, wraps the transport API requests are sent
+	// through, ex. to record and replay them.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
 }
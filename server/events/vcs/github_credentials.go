@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v71/github"
@@ -22,6 +23,14 @@ type GithubCredentials interface {
 	GetUser() (string, error)
 }
 
+// GithubRepoCredentials mints tokens that can only access a single
+// repository.
+type GithubRepoCredentials interface {
+	// GetCloneToken returns a token that can only read the contents of the
+	// repository repoFullName, ex. "owner/repo".
+	GetCloneToken(repoFullName string) (string, error)
+}
+
 // GithubAnonymousCredentials expose no credentials.
 type GithubAnonymousCredentials struct{}
 
@@ -46,6 +55,9 @@ type GithubUserCredentials struct {
 	User      string
 	Token     string
 	TokenFile string
+	// Transport is the transport requests are sent through. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
 }
 
 type GitHubUserTransport struct {
@@ -76,8 +88,9 @@ func (c *GithubUserCredentials) Client() (*http.Client, error) {
 		Transport: &GitHubUserTransport{
 			Credentials: c,
 			Transport: &github.BasicAuthTransport{
-				Username: strings.TrimSpace(c.User),
-				Password: strings.TrimSpace(password),
+				Username:  strings.TrimSpace(c.User),
+				Password:  strings.TrimSpace(password),
+				Transport: c.Transport,
 			},
 		},
 	}
@@ -112,17 +125,100 @@ type GithubAppCredentials struct {
 	InstallationID int64
 	tr             *ghinstallation.Transport
 	AppSlug        string
+	// RepoScoped is true if API calls about a repository should use an
+	// installation token that can only access that repository.
+	RepoScoped bool
+	// Transport is the transport requests, including the ones for
+	// installation tokens, are sent through. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+
+	repoTransportsMu sync.Mutex
+	repoTransports   map[string]*ghinstallation.Transport
 }
 
+var _ GithubRepoCredentials = (*GithubAppCredentials)(nil)
+
 // Client returns a github app installation client.
 func (c *GithubAppCredentials) Client() (*http.Client, error) {
 	itr, err := c.transport()
 	if err != nil {
 		return nil, err
 	}
+	if c.RepoScoped {
+		return &http.Client{Transport: &githubRepoScopedTransport{creds: c, installation: itr}}, nil
+	}
 	return &http.Client{Transport: itr}, nil
 }
 
+// GetCloneToken returns an installation token that can only read the
+// contents of repoFullName. It's refreshed before it expires.
+func (c *GithubAppCredentials) GetCloneToken(repoFullName string) (string, error) {
+	tr, err := c.repoTransport(repoFullName, true)
+	if err != nil {
+		return "", err
+	}
+	return tr.Token(context.Background())
+}
+
+// repoTransport returns the transport that authenticates with installation
+// tokens for repoFullName. If cloneOnly is true the tokens can only read the
+// repository's contents, otherwise they have all the app's permissions.
+func (c *GithubAppCredentials) repoTransport(repoFullName string, cloneOnly bool) (*ghinstallation.Transport, error) {
+	c.repoTransportsMu.Lock()
+	defer c.repoTransportsMu.Unlock()
+
+	key := fmt.Sprintf("%s:%t", repoFullName, cloneOnly)
+	if tr, ok := c.repoTransports[key]; ok {
+		return tr, nil
+	}
+	installationID, err := c.getInstallationID()
+	if err != nil {
+		return nil, err
+	}
+	itr, err := ghinstallation.New(c.baseTransport(), c.AppID, installationID, c.Key)
+	if err != nil {
+		return nil, err
+	}
+	itr.BaseURL = strings.TrimSuffix(c.getAPIURL().String(), "/")
+	// Installation tokens are restricted by repository name because an
+	// installation belongs to a single owner.
+	_, repoName, _ := strings.Cut(repoFullName, "/")
+	itr.InstallationTokenOptions = &github.InstallationTokenOptions{Repositories: []string{repoName}}
+	if cloneOnly {
+		itr.InstallationTokenOptions.Permissions = &github.InstallationPermissions{
+			Contents: github.Ptr("read"),
+			Metadata: github.Ptr("read"),
+		}
+	}
+	if c.repoTransports == nil {
+		c.repoTransports = make(map[string]*ghinstallation.Transport)
+	}
+	c.repoTransports[key] = itr
+	return itr, nil
+}
+
+// githubRepoScopedTransport authenticates requests to the /repos/{owner}/{repo}
+// API endpoints with a token that can only access that repository, and
+// other requests with the installation's token.
+type githubRepoScopedTransport struct {
+	creds        *GithubAppCredentials
+	installation http.RoundTripper
+}
+
+func (t *githubRepoScopedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(t.creds.getAPIURL().Path, "/"))
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	if len(parts) < 3 || parts[0] != "repos" || parts[1] == "" || parts[2] == "" {
+		return t.installation.RoundTrip(req)
+	}
+	tr, err := t.creds.repoTransport(parts[1]+"/"+parts[2], false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating token for %s/%s", parts[1], parts[2])
+	}
+	return tr.RoundTrip(req)
+}
+
 // GetUser returns the username for these credentials.
 func (c *GithubAppCredentials) GetUser() (string, error) {
 	// Keeping backwards compatibility since this flag is optional
@@ -164,7 +260,7 @@ func (c *GithubAppCredentials) getInstallationID() (int64, error) {
 		return c.InstallationID, nil
 	}
 
-	tr := http.DefaultTransport
+	tr := c.baseTransport()
 	// A non-installation transport
 	t, err := ghinstallation.NewAppsTransport(tr, c.AppID, c.Key)
 	if err != nil {
@@ -200,7 +296,7 @@ func (c *GithubAppCredentials) transport() (*ghinstallation.Transport, error) {
 		return nil, err
 	}
 
-	tr := http.DefaultTransport
+	tr := c.baseTransport()
 	itr, err := ghinstallation.New(tr, c.AppID, installationID, c.Key)
 	if err == nil {
 		apiURL := c.getAPIURL()
@@ -236,3 +332,12 @@ func resolveGithubAPIURL(hostname string) *url.URL {
 
 	return baseURL
 }
+
+// baseTransport returns the transport requests are sent through before
+// they're authenticated.
+func (c *GithubAppCredentials) baseTransport() http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
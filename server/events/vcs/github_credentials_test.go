@@ -1,8 +1,16 @@
 package vcs_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/google/go-github/v71/github"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/testdata"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -106,3 +114,85 @@ func TestGithubClient_MultipleAppAuthentication(t *testing.T) {
 		t.Errorf("app token was not cached: %q != %q", token, newToken)
 	}
 }
+
+// githubRepoScopedTokenServer mints installation tokens named after the
+// repositories and permissions they're restricted to, and echoes the token
+// other requests are authenticated with.
+func githubRepoScopedTokenServer(t *testing.T) string {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/app/installations/1/access_tokens":
+			var opts github.InstallationTokenOptions
+			if r.ContentLength > 0 {
+				Ok(t, json.NewDecoder(r.Body).Decode(&opts))
+			}
+			token := "installation"
+			if len(opts.Repositories) > 0 {
+				token = strings.Join(opts.Repositories, ",")
+			}
+			if opts.Permissions != nil {
+				token += fmt.Sprintf(":contents=%s", opts.Permissions.GetContents())
+			}
+			fmt.Fprintf(w, `{"token": %q, "expires_at": "2050-01-01T00:00:00Z"}`, token) // nolint: errcheck
+		default:
+			w.Write([]byte(strings.TrimPrefix(r.Header.Get("Authorization"), "token "))) // nolint: errcheck
+		}
+	}))
+	t.Cleanup(testServer.Close)
+	u, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	return u.Host
+}
+
+func TestGithubAppCredentials_GetCloneToken(t *testing.T) {
+	defer disableSSLVerification()()
+	appCreds := &vcs.GithubAppCredentials{
+		AppID:          1,
+		InstallationID: 1,
+		Key:            []byte(testdata.GithubPrivateKey),
+		Hostname:       githubRepoScopedTokenServer(t),
+	}
+
+	token, err := appCreds.GetCloneToken("runatlantis/atlantis")
+	Ok(t, err)
+	Equals(t, "atlantis:contents=read", token)
+
+	token, err = appCreds.GetToken()
+	Ok(t, err)
+	Equals(t, "installation", token)
+}
+
+func TestGithubAppCredentials_RepoScopedClient(t *testing.T) {
+	defer disableSSLVerification()()
+	hostname := githubRepoScopedTokenServer(t)
+	cases := []struct {
+		repoScoped bool
+		path       string
+		expToken   string
+	}{
+		{repoScoped: true, path: "/api/v3/repos/runatlantis/atlantis/pulls/1", expToken: "atlantis"},
+		{repoScoped: true, path: "/api/v3/repos/runatlantis/atlantis-tests", expToken: "atlantis-tests"},
+		{repoScoped: true, path: "/api/v3/app/installations", expToken: "installation"},
+		{repoScoped: true, path: "/api/graphql", expToken: "installation"},
+		{repoScoped: false, path: "/api/v3/repos/runatlantis/atlantis/pulls/1", expToken: "installation"},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%t %s", c.repoScoped, c.path), func(t *testing.T) {
+			appCreds := &vcs.GithubAppCredentials{
+				AppID:          1,
+				InstallationID: 1,
+				Key:            []byte(testdata.GithubPrivateKey),
+				Hostname:       hostname,
+				RepoScoped:     c.repoScoped,
+			}
+			client, err := appCreds.Client()
+			Ok(t, err)
+			resp, err := client.Get("https://" + hostname + c.path)
+			Ok(t, err)
+			defer resp.Body.Close() // nolint: errcheck
+			body, err := io.ReadAll(resp.Body)
+			Ok(t, err)
+			Equals(t, c.expToken, string(body))
+		})
+	}
+}
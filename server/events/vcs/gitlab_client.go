@@ -37,6 +37,15 @@ import (
 // and footer.
 const gitlabMaxCommentLength = 1000000 - 100
 
+// GitLab token types.
+const (
+	// GitlabAccessToken is a personal, group or project access token.
+	GitlabAccessToken = "access"
+	// GitlabJobToken is the CI_JOB_TOKEN of a GitLab CI job. It expires when
+	// the job finishes.
+	GitlabJobToken = "job"
+)
+
 type GitlabClient struct {
 	Client *gitlab.Client
 	// Version is set to the server version.
@@ -47,6 +56,9 @@ type GitlabClient struct {
 	PollingInterval time.Duration
 	// PollingInterval is the total duration for which to poll, where applicable.
 	PollingTimeout time.Duration
+	// TokenType is the type of token, either GitlabAccessToken or
+	// GitlabJobToken.
+	TokenType string
 }
 
 // commonMarkSupported is a version constraint that is true when this version of
@@ -57,18 +69,30 @@ var commonMarkSupported = MustConstraint(">=11.1")
 // gitlabClientUnderTest is true if we're running under go test.
 var gitlabClientUnderTest = false
 
-// NewGitlabClient returns a valid GitLab client.
-func NewGitlabClient(hostname string, token string, configuredGroups []string, logger logging.SimpleLogging) (*GitlabClient, error) {
+// NewGitlabClient returns a valid GitLab client. tokenType is the type of
+// token, either GitlabAccessToken or GitlabJobToken. httpClient is the
+// client requests are made with, or nil to use the default one.
+func NewGitlabClient(hostname string, token string, tokenType string, configuredGroups []string, httpClient *http.Client, logger logging.SimpleLogging) (*GitlabClient, error) {
 	logger.Debug("Creating new GitLab client for %s", hostname)
 	client := &GitlabClient{
 		ConfiguredGroups: configuredGroups,
 		PollingInterval:  time.Second,
 		PollingTimeout:   time.Second * 30,
+		TokenType:        tokenType,
+	}
+	var options []gitlab.ClientOptionFunc
+	if httpClient != nil {
+		options = append(options, gitlab.WithHTTPClient(httpClient))
+	}
+	// Job tokens are sent in a different header than access tokens.
+	newClient := gitlab.NewClient
+	if tokenType == GitlabJobToken {
+		newClient = gitlab.NewJobClient
 	}
 
 	// Create the client differently depending on the base URL.
 	if hostname == "gitlab.com" {
-		glClient, err := gitlab.NewClient(token)
+		glClient, err := newClient(token, options...)
 		if err != nil {
 			return nil, err
 		}
@@ -97,7 +121,7 @@ func NewGitlabClient(hostname string, token string, configuredGroups []string, l
 		// Now we're ready to construct the client.
 		absoluteURL = strings.TrimSuffix(absoluteURL, "/")
 		apiURL := fmt.Sprintf("%s/api/v4/", absoluteURL)
-		glClient, err := gitlab.NewClient(token, gitlab.WithBaseURL(apiURL))
+		glClient, err := newClient(token, append(options, gitlab.WithBaseURL(apiURL))...)
 		if err != nil {
 			return nil, err
 		}
@@ -112,17 +136,221 @@ func NewGitlabClient(hostname string, token string, configuredGroups []string, l
 			return nil, err
 		}
 		logger.Info("GitLab host '%s' is running version %s", client.Client.BaseURL().Host, client.Version.String())
+
+		// The scopes of job tokens can't be looked up, they're limited to
+		// the endpoints GitLab allows for CI_JOB_TOKEN.
+		if tokenType == GitlabJobToken {
+			logger.Warn("using a GitLab job token, which expires when its job finishes")
+		} else if err := client.CheckTokenScopes(logger); err != nil {
+			return nil, err
+		}
 	}
 
 	return client, nil
 }
 
+// CheckTokenScopes returns an error if the access token of the client is
+// inactive or lacks the api scope that Atlantis requires.
+func (g *GitlabClient) CheckTokenScopes(logger logging.SimpleLogging) error {
+	token, resp, err := g.Client.PersonalAccessTokens.GetSinglePersonalAccessToken()
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		// GitLab added this endpoint in 15.5.
+		logger.Warn("unable to check the scopes of the GitLab token, GitLab doesn't support looking them up")
+		return nil
+	}
+	if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("the GitLab token is invalid, expired or revoked")
+	}
+	if err != nil {
+		return errors.Wrap(err, "getting the scopes of the GitLab token")
+	}
+	if token.Revoked || !token.Active {
+		return fmt.Errorf("the GitLab token %q is inactive", token.Name)
+	}
+	for _, scope := range token.Scopes {
+		if scope == "api" {
+			return nil
+		}
+	}
+	return fmt.Errorf("the GitLab token %q has the scopes %s but Atlantis requires the api scope", token.Name, strings.Join(token.Scopes, ", "))
+}
+
+// ValidateCredentials checks that the access token is active and has the api
+// scope. The scopes of job tokens can't be looked up.
+func (g *GitlabClient) ValidateCredentials(logger logging.SimpleLogging) error {
+	if g.TokenType == GitlabJobToken {
+		return nil
+	}
+	if err := g.CheckTokenScopes(logger); err != nil {
+		return fmt.Errorf("%w, create a new token as described in https://www.runatlantis.io/docs/access-credentials.html#gitlab", err)
+	}
+	return nil
+}
+
+// ValidateWebhook checks that the project has a webhook sending the push,
+// comment and merge request events Atlantis needs to url. Group webhooks
+// aren't checked.
+func (g *GitlabClient) ValidateWebhook(logger logging.SimpleLogging, repoFullName string, url string) error {
+	hooks, resp, err := g.listProjectHooks(logger, repoFullName)
+	if resp != nil && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound) {
+		return fmt.Errorf("%w: the GitLab token needs the Maintainer role in %s to list its webhooks", ErrWebhookUnchecked, repoFullName)
+	}
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, hook := range hooks {
+		if !sameWebhookURL(hook.URL, url) {
+			continue
+		}
+		var missing []string
+		if !hook.PushEvents {
+			missing = append(missing, "Push events")
+		}
+		if !hook.NoteEvents {
+			missing = append(missing, "Comments")
+		}
+		if !hook.MergeRequestsEvents {
+			missing = append(missing, "Merge request events")
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("isn't triggered by %s, check them", strings.Join(missing, ", ")))
+			continue
+		}
+		if hook.AlertStatus == "disabled" || hook.AlertStatus == "temporarily_disabled" {
+			problems = append(problems, "was disabled by GitLab because it failed, check that GitLab can reach Atlantis and re-enable it")
+			continue
+		}
+		return nil
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the webhook of %s to %s %s", repoFullName, url, strings.Join(problems, " and "))
+	}
+	return fmt.Errorf("no webhook of %s sends events to %s, add one as described in https://www.runatlantis.io/docs/configuring-webhooks.html#gitlab", repoFullName, url)
+}
+
+// listProjectHooks returns all the webhooks of the project repoFullName and
+// the last response.
+func (g *GitlabClient) listProjectHooks(logger logging.SimpleLogging, repoFullName string) ([]*gitlab.ProjectHook, *gitlab.Response, error) {
+	var hooks []*gitlab.ProjectHook
+	opts := &gitlab.ListProjectHooksOptions{PerPage: 100}
+	for {
+		page, resp, err := g.Client.Projects.ListProjectHooks(repoFullName, opts)
+		if resp != nil {
+			logger.Debug("GET /projects/%s/hooks returned: %d", repoFullName, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, resp, errors.Wrapf(err, "listing the webhooks of %s", repoFullName)
+		}
+		hooks = append(hooks, page...)
+		if resp.NextPage == 0 {
+			return hooks, resp, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListOrgRepos returns the projects of the group org and its subgroups that
+// aren't archived.
+func (g *GitlabClient) ListOrgRepos(logger logging.SimpleLogging, org string) ([]string, error) {
+	var repos []string
+	opts := &gitlab.ListGroupProjectsOptions{
+		ListOptions:      gitlab.ListOptions{PerPage: 100},
+		Archived:         gitlab.Ptr(false),
+		IncludeSubGroups: gitlab.Ptr(true),
+	}
+	for {
+		page, resp, err := g.Client.Groups.ListGroupProjects(org, opts)
+		if resp != nil {
+			logger.Debug("GET /groups/%s/projects returned: %d", org, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the projects of %s", org)
+		}
+		for _, project := range page {
+			repos = append(repos, project.PathWithNamespace)
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// RegisterWebhook creates or updates the webhook of the project to url. Other
+// events the webhook is triggered by are kept.
+func (g *GitlabClient) RegisterWebhook(logger logging.SimpleLogging, repoFullName string, url string, secret string, updateSecret bool, dryRun bool) (string, error) {
+	hooks, _, err := g.listProjectHooks(logger, repoFullName)
+	if err != nil {
+		return "", err
+	}
+	for _, hook := range hooks {
+		if !sameWebhookURL(hook.URL, url) {
+			continue
+		}
+		if hook.PushEvents && hook.NoteEvents && hook.MergeRequestsEvents && !updateSecret {
+			return WebhookUnchanged, nil
+		}
+		if dryRun {
+			return WebhookUpdated, nil
+		}
+		_, resp, err := g.Client.Projects.EditProjectHook(repoFullName, hook.ID, &gitlab.EditProjectHookOptions{
+			URL:                 gitlab.Ptr(url),
+			Token:               gitlab.Ptr(secret),
+			PushEvents:          gitlab.Ptr(true),
+			NoteEvents:          gitlab.Ptr(true),
+			MergeRequestsEvents: gitlab.Ptr(true),
+		})
+		if resp != nil {
+			logger.Debug("PUT /projects/%s/hooks/%d returned: %d", repoFullName, hook.ID, resp.StatusCode)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "updating the webhook of %s", repoFullName)
+		}
+		return WebhookUpdated, nil
+	}
+
+	if dryRun {
+		return WebhookCreated, nil
+	}
+	_, resp, err := g.Client.Projects.AddProjectHook(repoFullName, &gitlab.AddProjectHookOptions{
+		Name:                  gitlab.Ptr("Atlantis"),
+		URL:                   gitlab.Ptr(url),
+		Token:                 gitlab.Ptr(secret),
+		PushEvents:            gitlab.Ptr(true),
+		NoteEvents:            gitlab.Ptr(true),
+		MergeRequestsEvents:   gitlab.Ptr(true),
+		EnableSSLVerification: gitlab.Ptr(true),
+	})
+	if resp != nil {
+		logger.Debug("POST /projects/%s/hooks returned: %d", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "creating the webhook of %s", repoFullName)
+	}
+	return WebhookCreated, nil
+}
+
 // GetModifiedFiles returns the names of files that were modified in the merge request
 // relative to the repo root, e.g. parent/child/file.txt.
 func (g *GitlabClient) GetModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	var files []string
+	err := g.ListModifiedFiles(logger, repo, pull, func(page []string) bool {
+		files = append(files, page...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ListModifiedFiles calls f with each page of the files that were modified in
+// the merge request until f returns false. See ModifiedFilesLister.
+func (g *GitlabClient) ListModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error {
 	logger.Debug("Getting modified files for GitLab merge request %d", pull.Num)
 	const maxPerPage = 100
-	var files []string
 	nextPage := 1
 	// Constructing the api url by hand so we can do pagination.
 	apiURL := fmt.Sprintf("projects/%s/merge_requests/%d/changes", url.QueryEscape(repo.FullName), pull.Num)
@@ -133,7 +361,7 @@ func (g *GitlabClient) GetModifiedFiles(logger logging.SimpleLogging, repo model
 		}
 		req, err := g.Client.NewRequest("GET", apiURL, opts, nil)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		resp := new(gitlab.Response)
 		mr := new(gitlab.MergeRequest)
@@ -144,33 +372,34 @@ func (g *GitlabClient) GetModifiedFiles(logger logging.SimpleLogging, repo model
 				logger.Debug("GET %s returned: %d", apiURL, resp.StatusCode)
 			}
 			if err != nil {
-				return nil, err
+				return err
 			}
 			if mr.ChangesCount != "" {
 				break
 			}
 			if time.Since(pollingStart) > g.PollingTimeout {
-				return nil, errors.Errorf("giving up polling %q after %s", apiURL, g.PollingTimeout.String())
+				return errors.Errorf("giving up polling %q after %s", apiURL, g.PollingTimeout.String())
 			}
 			time.Sleep(g.PollingInterval)
 		}
 
-		for _, f := range mr.Changes {
-			files = append(files, f.NewPath)
+		var files []string
+		for _, change := range mr.Changes {
+			files = append(files, change.NewPath)
 
 			// If the file was renamed, we'll want to run plan in the directory
 			// it was moved from as well.
-			if f.RenamedFile {
-				files = append(files, f.OldPath)
+			if change.RenamedFile {
+				files = append(files, change.OldPath)
 			}
 		}
-		if resp.NextPage == 0 {
+		if !f(files) || resp.NextPage == 0 {
 			break
 		}
 		nextPage = resp.NextPage
 	}
 
-	return files, nil
+	return nil
 }
 
 // CreateComment creates a comment on the merge request.
@@ -203,30 +432,71 @@ func (g *GitlabClient) ReactToComment(logger logging.SimpleLogging, repo models.
 	return err
 }
 
-func (g *GitlabClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
-	logger.Debug("Hiding previous command comments on GitLab merge request %d", pullNum)
-	var allComments []*gitlab.Note
-
-	nextPage := 0
-	for {
-		logger.Debug("/projects/%v/merge_requests/%d/notes", repo.FullName, pullNum)
-		comments, resp, err := g.Client.Notes.ListMergeRequestNotes(repo.FullName, pullNum,
-			&gitlab.ListMergeRequestNotesOptions{
-				Sort:        gitlab.Ptr("asc"),
-				OrderBy:     gitlab.Ptr("created_at"),
-				ListOptions: gitlab.ListOptions{Page: nextPage},
-			})
+// RemoveReaction removes reaction from the comment commentID if the Atlantis
+// user awarded it.
+func (g *GitlabClient) RemoveReaction(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	logger.Debug("Removing reaction '%s' from comment %d on GitLab merge request %d", reaction, commentID, pullNum)
+	currentUser, _, err := g.Client.Users.CurrentUser()
+	if err != nil {
+		return errors.Wrap(err, "error getting currentuser")
+	}
+	awards, _, err := g.Client.AwardEmoji.ListMergeRequestAwardEmojiOnNote(repo.FullName, pullNum, int(commentID), &gitlab.ListAwardEmojiOptions{})
+	if err != nil {
+		return err
+	}
+	for _, award := range awards {
+		if award.Name != reaction || award.User.ID != currentUser.ID {
+			continue
+		}
+		resp, err := g.Client.AwardEmoji.DeleteMergeRequestAwardEmojiOnNote(repo.FullName, pullNum, int(commentID), award.ID)
 		if resp != nil {
-			logger.Debug("GET /projects/%s/merge_requests/%d/notes returned: %d", repo.FullName, pullNum, resp.StatusCode)
+			logger.Debug("DELETE /projects/%s/merge_requests/%d/notes/%d/award_emoji/%d returned: %d", repo.FullName, pullNum, commentID, award.ID, resp.StatusCode)
 		}
 		if err != nil {
-			return errors.Wrap(err, "listing comments")
+			return err
 		}
-		allComments = append(allComments, comments...)
-		if resp.NextPage == 0 {
-			break
+	}
+	return nil
+}
+
+// CreateSuggestions starts a discussion on the first line of each suggestion
+// and comments body on the merge request.
+func (g *GitlabClient) CreateSuggestions(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, body string, suggestions []Suggestion) error {
+	logger.Debug("Creating %d suggestion(s) on GitLab merge request %d", len(suggestions), pull.Num)
+	mr, err := g.GetMergeRequest(logger, repo.FullName, pull.Num)
+	if err != nil {
+		return err
+	}
+	for _, s := range suggestions {
+		// The suggestion is anchored on its first line and spans the lines
+		// below it.
+		_, resp, err := g.Client.Discussions.CreateMergeRequestDiscussion(repo.FullName, pull.Num, &gitlab.CreateMergeRequestDiscussionOptions{
+			Body: gitlab.Ptr(fmt.Sprintf("```suggestion:-0+%d\n%s\n```", s.EndLine-s.StartLine, s.Replacement)),
+			Position: &gitlab.PositionOptions{
+				BaseSHA:      gitlab.Ptr(mr.DiffRefs.BaseSha),
+				StartSHA:     gitlab.Ptr(mr.DiffRefs.StartSha),
+				HeadSHA:      gitlab.Ptr(mr.DiffRefs.HeadSha),
+				PositionType: gitlab.Ptr("text"),
+				OldPath:      gitlab.Ptr(s.Path),
+				NewPath:      gitlab.Ptr(s.Path),
+				NewLine:      gitlab.Ptr(s.StartLine),
+			},
+		})
+		if resp != nil {
+			logger.Debug("POST /projects/%s/merge_requests/%d/discussions returned: %d", repo.FullName, pull.Num, resp.StatusCode)
+		}
+		if err != nil {
+			return err
 		}
-		nextPage = resp.NextPage
+	}
+	return g.CreateComment(logger, repo, pull.Num, body, "")
+}
+
+func (g *GitlabClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
+	logger.Debug("Hiding previous command comments on GitLab merge request %d", pullNum)
+	allComments, err := g.listNotes(logger, repo, pullNum)
+	if err != nil {
+		return err
 	}
 
 	currentUser, _, err := g.Client.Users.CurrentUser()
@@ -274,7 +544,84 @@ func (g *GitlabClient) HidePrevCommandComments(logger logging.SimpleLogging, rep
 	return nil
 }
 
+// UpdateLastComment replaces the body of the last note Atlantis made for
+// command and dir on the merge request. It returns false without editing
+// anything if there's no such note or if comment doesn't fit in a single note.
+func (g *GitlabClient) UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error) {
+	if len(comment) > gitlabMaxCommentLength {
+		return false, nil
+	}
+	allComments, err := g.listNotes(logger, repo, pullNum)
+	if err != nil {
+		return false, err
+	}
+	currentUser, _, err := g.Client.Users.CurrentUser()
+	if err != nil {
+		return false, errors.Wrap(err, "error getting currentuser")
+	}
+
+	var last *gitlab.Note
+	for _, note := range allComments {
+		if note.System || (note.Author.Username != "" && !strings.EqualFold(note.Author.Username, currentUser.Username)) {
+			continue
+		}
+		firstLine := strings.ToLower(strings.Split(note.Body, "\n")[0])
+		// Skip notes that were hidden by HidePrevCommandComments.
+		if !strings.Contains(firstLine, strings.ToLower(command)) || strings.HasPrefix(firstLine, "<!--- +-superseded command-+ --->") {
+			continue
+		}
+		if dir != "" && !strings.Contains(firstLine, strings.ToLower(dir)) {
+			continue
+		}
+		last = note
+	}
+	if last == nil {
+		return false, nil
+	}
+
+	logger.Debug("Updating note %d on GitLab merge request %d", last.ID, pullNum)
+	_, resp, err := g.Client.Notes.UpdateMergeRequestNote(repo.FullName, pullNum, last.ID, &gitlab.UpdateMergeRequestNoteOptions{Body: &comment})
+	if resp != nil {
+		logger.Debug("PUT /projects/%s/merge_requests/%d/notes/%d returned: %d", repo.FullName, pullNum, last.ID, resp.StatusCode)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "updating comment %d", last.ID)
+	}
+	return true, nil
+}
+
+// listNotes returns all the notes on the merge request, oldest first.
+func (g *GitlabClient) listNotes(logger logging.SimpleLogging, repo models.Repo, pullNum int) ([]*gitlab.Note, error) {
+	var allComments []*gitlab.Note
+
+	nextPage := 0
+	for {
+		logger.Debug("/projects/%v/merge_requests/%d/notes", repo.FullName, pullNum)
+		comments, resp, err := g.Client.Notes.ListMergeRequestNotes(repo.FullName, pullNum,
+			&gitlab.ListMergeRequestNotesOptions{
+				Sort:        gitlab.Ptr("asc"),
+				OrderBy:     gitlab.Ptr("created_at"),
+				ListOptions: gitlab.ListOptions{Page: nextPage},
+			})
+		if resp != nil {
+			logger.Debug("GET /projects/%s/merge_requests/%d/notes returned: %d", repo.FullName, pullNum, resp.StatusCode)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "listing comments")
+		}
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return allComments, nil
+}
+
 // PullIsApproved returns true if the merge request was approved.
+// A merge request is approved once it has at least one approval and every
+// approval rule that applies to it, including code owner rules, has collected
+// its required number of approvals.
 func (g *GitlabClient) PullIsApproved(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (approvalStatus models.ApprovalStatus, err error) {
 	logger.Debug("Checking if GitLab merge request %d is approved", pull.Num)
 	approvals, resp, err := g.Client.MergeRequests.GetMergeRequestApprovals(repo.FullName, pull.Num)
@@ -287,9 +634,36 @@ func (g *GitlabClient) PullIsApproved(logger logging.SimpleLogging, repo models.
 	if approvals.ApprovalsLeft > 0 {
 		return approvalStatus, nil
 	}
-	return models.ApprovalStatus{
-		IsApproved: true,
-	}, nil
+
+	state, resp, err := g.Client.MergeRequestApprovals.GetApprovalState(repo.FullName, pull.Num)
+	if resp != nil {
+		logger.Debug("GET /projects/%s/merge_requests/%d/approval_state returned: %d", repo.FullName, pull.Num, resp.StatusCode)
+	}
+	switch {
+	case err == nil:
+		for _, rule := range state.Rules {
+			if rule.ApprovalsRequired > 0 && !rule.Approved {
+				logger.Debug("GitLab merge request %d is missing approvals for rule %q (%s)", pull.Num, rule.Name, rule.RuleType)
+				return approvalStatus, nil
+			}
+		}
+	case resp != nil && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound):
+		// Approval rules aren't available on every GitLab edition and version,
+		// in which case the number of approvals left is all we have to go on.
+		logger.Debug("approval rules not available for merge request %d, relying on approvals left", pull.Num)
+	default:
+		return approvalStatus, errors.Wrap(err, "getting approval state")
+	}
+
+	for _, approver := range approvals.ApprovedBy {
+		if approver != nil && approver.User != nil {
+			return models.ApprovalStatus{
+				IsApproved: true,
+				ApprovedBy: approver.User.Username,
+			}, nil
+		}
+	}
+	return approvalStatus, nil
 }
 
 // PullIsMergeable returns true if the merge request can be merged.
@@ -404,6 +778,8 @@ func (g *GitlabClient) UpdateStatus(logger logging.SimpleLogging, repo models.Re
 		gitlabState = gitlab.Running
 	case models.FailedCommitStatus:
 		gitlabState = gitlab.Failed
+	case models.CanceledCommitStatus:
+		gitlabState = gitlab.Canceled
 	case models.SuccessCommitStatus:
 		gitlabState = gitlab.Success
 	}
@@ -638,6 +1014,61 @@ func MustConstraint(constraint string) version.Constraints {
 // GetTeamNamesForUser returns the names of the GitLab groups that the user belongs to.
 // The user membership is checked in each group from configuredTeams, groups
 // that the Atlantis user doesn't have access to are silently ignored.
+// gitlabCodeOwnersFiles are the paths, in order of precedence, of the
+// CODEOWNERS file of a repo.
+var gitlabCodeOwnersFiles = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// UnapprovedFiles returns the files, out of files, that have owners in the
+// CODEOWNERS file of the target branch and that none of their owners approved
+// the merge request. Owners are @usernames, or the @groups of
+// --gitlab-group-allowlist.
+func (g *GitlabClient) UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error) {
+	logger.Debug("Checking if the code owners of GitLab merge request %d approved it", pull.Num)
+	// The CODEOWNERS file is read from the target branch so merge requests
+	// can't change their own owners.
+	var codeOwners CodeOwners
+	for _, file := range gitlabCodeOwnersFiles {
+		opt := gitlab.GetRawFileOptions{Ref: gitlab.Ptr(pull.BaseBranch)}
+		content, resp, err := g.Client.RepositoryFiles.GetRawFile(repo.FullName, file, &opt)
+		if resp != nil {
+			logger.Debug("GET /projects/%s/repository/files/%s/raw returned: %d", repo.FullName, file, resp.StatusCode)
+		}
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting %s", file)
+		}
+		codeOwners = ParseCodeOwners(string(content))
+		break
+	}
+
+	approvals, resp, err := g.Client.MergeRequests.GetMergeRequestApprovals(repo.FullName, pull.Num)
+	if resp != nil {
+		logger.Debug("GET /projects/%s/merge_requests/%d/approvals returned: %d", repo.FullName, pull.Num, resp.StatusCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var approvers []string
+	for _, approver := range approvals.ApprovedBy {
+		if approver.User == nil {
+			continue
+		}
+		approvers = append(approvers, "@"+approver.User.Username)
+		if len(g.ConfiguredGroups) > 0 {
+			groups, err := g.GetTeamNamesForUser(logger, repo, models.User{Username: approver.User.Username})
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting the groups of %s", approver.User.Username)
+			}
+			for _, group := range groups {
+				approvers = append(approvers, "@"+group)
+			}
+		}
+	}
+	return codeOwners.UnapprovedFiles(files, nil, approvers), nil
+}
+
 func (g *GitlabClient) GetTeamNamesForUser(logger logging.SimpleLogging, _ models.Repo, user models.User) ([]string, error) {
 	logger.Debug("Getting GitLab group names for user '%s'", user)
 	var teamNames []string
@@ -721,3 +1152,99 @@ func (g *GitlabClient) GetPullLabels(logger logging.SimpleLogging, repo models.R
 
 	return mr.Labels, nil
 }
+
+// GetPullDescription returns the description of the merge request.
+func (g *GitlabClient) GetPullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (string, error) {
+	mr, err := g.GetMergeRequest(logger, repo.FullName, pull.Num)
+	if err != nil {
+		return "", err
+	}
+	return mr.Description, nil
+}
+
+// UpdatePullDescription replaces the description of the merge request.
+func (g *GitlabClient) UpdatePullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, description string) error {
+	logger.Debug("Updating GitLab description of merge request %d", pull.Num)
+	_, resp, err := g.Client.MergeRequests.UpdateMergeRequest(repo.FullName, pull.Num, &gitlab.UpdateMergeRequestOptions{
+		Description: &description,
+	})
+	if resp != nil {
+		logger.Debug("PUT /projects/%s/merge_requests/%d returned: %d", repo.FullName, pull.Num, resp.StatusCode)
+	}
+	return err
+}
+
+// ListDefaultBranchFiles returns the default branch of the project and the
+// paths of the files on it.
+func (g *GitlabClient) ListDefaultBranchFiles(logger logging.SimpleLogging, repoFullName string) (string, []string, error) {
+	project, resp, err := g.Client.Projects.GetProject(repoFullName, nil)
+	if resp != nil {
+		logger.Debug("GET /projects/%s returned: %d", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "getting project %s", repoFullName)
+	}
+	var files []string
+	opts := &gitlab.ListTreeOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		Ref:         gitlab.Ptr(project.DefaultBranch),
+		Recursive:   gitlab.Ptr(true),
+	}
+	for {
+		nodes, resp, err := g.Client.Repositories.ListTree(repoFullName, opts)
+		if resp != nil {
+			logger.Debug("GET /projects/%s/repository/tree returned: %d", repoFullName, resp.StatusCode)
+		}
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "listing the files of %s", repoFullName)
+		}
+		for _, node := range nodes {
+			if node.Type == "blob" {
+				files = append(files, node.Path)
+			}
+		}
+		if resp.NextPage == 0 {
+			return project.DefaultBranch, files, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// OpenPullWithFile creates branch from base, commits the file to it and opens
+// a merge request.
+func (g *GitlabClient) OpenPullWithFile(logger logging.SimpleLogging, repoFullName string, base string, branch string, path string, content []byte, title string, body string) (string, error) {
+	_, resp, err := g.Client.Branches.CreateBranch(repoFullName, &gitlab.CreateBranchOptions{
+		Branch: gitlab.Ptr(branch),
+		Ref:    gitlab.Ptr(base),
+	})
+	if resp != nil {
+		logger.Debug("POST /projects/%s/repository/branches returned: %d", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "creating branch %s", branch)
+	}
+	_, resp, err = g.Client.RepositoryFiles.CreateFile(repoFullName, path, &gitlab.CreateFileOptions{
+		Branch:        gitlab.Ptr(branch),
+		Content:       gitlab.Ptr(string(content)),
+		CommitMessage: gitlab.Ptr(title),
+	})
+	if resp != nil {
+		logger.Debug("POST /projects/%s/repository/files/%s returned: %d", repoFullName, path, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "committing %s", path)
+	}
+	mr, resp, err := g.Client.MergeRequests.CreateMergeRequest(repoFullName, &gitlab.CreateMergeRequestOptions{
+		Title:        gitlab.Ptr(title),
+		Description:  gitlab.Ptr(body),
+		SourceBranch: gitlab.Ptr(branch),
+		TargetBranch: gitlab.Ptr(base),
+	})
+	if resp != nil {
+		logger.Debug("POST /projects/%s/merge_requests returned: %d", repoFullName, resp.StatusCode)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "opening merge request")
+	}
+	return mr.WebURL, nil
+}
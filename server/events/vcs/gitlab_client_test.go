@@ -2,7 +2,9 @@ package vcs
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -94,7 +96,7 @@ func TestNewGitlabClient_BaseURL(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.Hostname, func(t *testing.T) {
 			log := logging.NewNoopLogger(t)
-			client, err := NewGitlabClient(c.Hostname, "token", []string{}, log)
+			client, err := NewGitlabClient(c.Hostname, "token", GitlabAccessToken, []string{}, nil, log)
 			Ok(t, err)
 			Equals(t, c.ExpBaseURL, client.Client.BaseURL().String())
 		})
@@ -887,7 +889,7 @@ func TestGitlabClient_MarkdownPullLink(t *testing.T) {
 	logger := logging.NewNoopLogger(t)
 	gitlabClientUnderTest = true
 	defer func() { gitlabClientUnderTest = false }()
-	client, err := NewGitlabClient("gitlab.com", "token", []string{}, logger)
+	client, err := NewGitlabClient("gitlab.com", "token", GitlabAccessToken, []string{}, nil, logger)
 	Ok(t, err)
 	pull := models.PullRequest{Num: 1}
 	s, _ := client.MarkdownPullLink(pull)
@@ -1257,3 +1259,346 @@ func TestGithubClient_DiscardReviews(t *testing.T) {
 		})
 	}
 }
+
+func TestGitlabClient_PullIsApproved(t *testing.T) {
+	approvedByUser := `"approved_by":[{"user":{"id":1,"username":"reviewer"}}]`
+	cases := []struct {
+		description   string
+		approvals     string
+		stateCode     int
+		approvalState string
+		expStatus     models.ApprovalStatus
+		expErr        string
+	}{
+		{
+			description: "approvals left",
+			approvals:   `{"approvals_left":1,"approved_by":[]}`,
+			expStatus:   models.ApprovalStatus{},
+		},
+		{
+			description:   "no approvals",
+			approvals:     `{"approvals_left":0,"approved_by":[]}`,
+			stateCode:     http.StatusOK,
+			approvalState: `{"rules":[]}`,
+			expStatus:     models.ApprovalStatus{},
+		},
+		{
+			description:   "all rules approved",
+			approvals:     `{"approvals_left":0,` + approvedByUser + `}`,
+			stateCode:     http.StatusOK,
+			approvalState: `{"rules":[{"name":"All Members","rule_type":"any_approver","approvals_required":1,"approved":true},{"name":"*.tf","rule_type":"code_owner","approvals_required":1,"approved":true}]}`,
+			expStatus:     models.ApprovalStatus{IsApproved: true, ApprovedBy: "reviewer"},
+		},
+		{
+			description:   "code owner rule not approved",
+			approvals:     `{"approvals_left":0,` + approvedByUser + `}`,
+			stateCode:     http.StatusOK,
+			approvalState: `{"rules":[{"name":"All Members","rule_type":"any_approver","approvals_required":1,"approved":true},{"name":"*.tf","rule_type":"code_owner","approvals_required":1,"approved":false}]}`,
+			expStatus:     models.ApprovalStatus{},
+		},
+		{
+			description:   "optional rule not approved",
+			approvals:     `{"approvals_left":0,` + approvedByUser + `}`,
+			stateCode:     http.StatusOK,
+			approvalState: `{"rules":[{"name":"Optional","rule_type":"regular","approvals_required":0,"approved":false}]}`,
+			expStatus:     models.ApprovalStatus{IsApproved: true, ApprovedBy: "reviewer"},
+		},
+		{
+			description:   "approval rules not available",
+			approvals:     `{"approvals_left":0,` + approvedByUser + `}`,
+			stateCode:     http.StatusNotFound,
+			approvalState: `{"message":"404 Not Found"}`,
+			expStatus:     models.ApprovalStatus{IsApproved: true, ApprovedBy: "reviewer"},
+		},
+		{
+			description:   "approval state error",
+			approvals:     `{"approvals_left":0,` + approvedByUser + `}`,
+			stateCode:     http.StatusInternalServerError,
+			approvalState: `{"message":"500 Internal Server Error"}`,
+			expErr:        "getting approval state",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/approvals":
+						w.WriteHeader(http.StatusOK)
+						w.Write([]byte(c.approvals)) // nolint: errcheck
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/approval_state":
+						w.WriteHeader(c.stateCode)
+						w.Write([]byte(c.approvalState)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			defer testServer.Close()
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL), gitlab.WithoutRetries())
+			Ok(t, err)
+			client := &GitlabClient{
+				Client:  internalClient,
+				Version: nil,
+			}
+
+			status, err := client.PullIsApproved(
+				logging.NewNoopLogger(t),
+				models.Repo{FullName: "runatlantis/atlantis"},
+				models.PullRequest{Num: 1},
+			)
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.expStatus, status)
+		})
+	}
+}
+
+func TestGitlabClient_DiscardReviews(t *testing.T) {
+	cases := []struct {
+		description string
+		statusCode  int
+		expErr      string
+	}{
+		{
+			description: "approvals reset",
+			statusCode:  http.StatusAccepted,
+		},
+		{
+			description: "reset forbidden",
+			statusCode:  http.StatusForbidden,
+			expErr:      "unable to reset approvals",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			testServer := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/reset_approvals":
+						Equals(t, http.MethodPut, r.Method)
+						w.WriteHeader(c.statusCode)
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+			defer testServer.Close()
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL), gitlab.WithoutRetries())
+			Ok(t, err)
+			client := &GitlabClient{
+				Client:  internalClient,
+				Version: nil,
+			}
+
+			err = client.DiscardReviews(
+				logging.NewNoopLogger(t),
+				models.Repo{FullName: "runatlantis/atlantis"},
+				models.PullRequest{Num: 1},
+			)
+			if c.expErr != "" {
+				ErrContains(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+		})
+	}
+}
+
+func TestGitlabClient_UnapprovedFiles(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/projects/runatlantis%2Fatlantis/repository/files/CODEOWNERS/raw?ref=main":
+				http.Error(w, "not found", http.StatusNotFound)
+			case "/api/v4/projects/runatlantis%2Fatlantis/repository/files/docs%2FCODEOWNERS/raw?ref=main":
+				w.Write([]byte("/env/prod/ @alice\n/modules/ @bob\n")) // nolint: errcheck
+			case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/approvals":
+				w.Write([]byte(`{"approvals_left":0,"approved_by":[{"user":{"username":"bob"}}]}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+	defer testServer.Close()
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL), gitlab.WithoutRetries())
+	Ok(t, err)
+	client := &GitlabClient{
+		Client:  internalClient,
+		Version: nil,
+	}
+
+	unapproved, err := client.UnapprovedFiles(
+		logging.NewNoopLogger(t),
+		models.Repo{FullName: "runatlantis/atlantis"},
+		models.PullRequest{Num: 1, BaseBranch: "main"},
+		[]string{"env/prod/main.tf", "modules/vpc/main.tf", "README.md"},
+	)
+	Ok(t, err)
+	Equals(t, []string{"env/prod/main.tf"}, unapproved)
+}
+
+func TestGitlabClient_CheckTokenScopes(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	cases := map[string]struct {
+		status int
+		token  string
+		expErr string
+	}{
+		"api scope": {
+			status: http.StatusOK,
+			token:  `{"name": "atlantis", "active": true, "scopes": ["api", "read_repository"]}`,
+		},
+		"missing api scope": {
+			status: http.StatusOK,
+			token:  `{"name": "atlantis", "active": true, "scopes": ["read_api", "read_repository"]}`,
+			expErr: `the GitLab token "atlantis" has the scopes read_api, read_repository but Atlantis requires the api scope`,
+		},
+		"inactive": {
+			status: http.StatusOK,
+			token:  `{"name": "atlantis", "active": false, "scopes": ["api"]}`,
+			expErr: `the GitLab token "atlantis" is inactive`,
+		},
+		"invalid": {
+			status: http.StatusUnauthorized,
+			token:  `{"message": "401 Unauthorized"}`,
+			expErr: "the GitLab token is invalid, expired or revoked",
+		},
+		"lookup unsupported": {
+			status: http.StatusNotFound,
+			token:  `{"message": "404 Not Found"}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.RequestURI != "/api/v4/personal_access_tokens/self" {
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(c.status)
+				w.Write([]byte(c.token)) // nolint: errcheck
+			}))
+			defer testServer.Close()
+
+			internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+			Ok(t, err)
+			client := &GitlabClient{Client: internalClient}
+
+			err = client.CheckTokenScopes(logger)
+			if c.expErr == "" {
+				Ok(t, err)
+			} else {
+				ErrEquals(t, c.expErr, err)
+			}
+		})
+	}
+}
+
+// Job tokens should be sent in their own header and their scopes shouldn't
+// be looked up.
+func TestNewGitlabClient_JobToken(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "job-token", r.Header.Get("JOB-TOKEN"))
+		Equals(t, "", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.RequestURI {
+		case "/api/v4/version":
+			w.Write([]byte(`{"version": "16.0.0-ee"}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := NewGitlabClient(testServer.URL, "job-token", GitlabJobToken, []string{}, nil, logger)
+	Ok(t, err)
+	Equals(t, "16.0.0", client.Version.String())
+}
+
+func TestGitlabClient_ValidateWebhook(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/api/v4/projects/group%2Fok/hooks?per_page=100":
+			w.Write([]byte(`[{"url": "https://other.example.com"}, {"url": "https://atlantis.example.com/events", "push_events": true, "note_events": true, "merge_requests_events": true}]`)) // nolint: errcheck
+		case "/api/v4/projects/group%2Fmissing-events/hooks?per_page=100":
+			w.Write([]byte(`[{"url": "https://atlantis.example.com/events", "push_events": true}]`)) // nolint: errcheck
+		case "/api/v4/projects/group%2Fdisabled/hooks?per_page=100":
+			w.Write([]byte(`[{"url": "https://atlantis.example.com/events", "push_events": true, "note_events": true, "merge_requests_events": true, "alert_status": "disabled"}]`)) // nolint: errcheck
+		case "/api/v4/projects/group%2Fnone/hooks?per_page=100":
+			w.Write([]byte(`[]`)) // nolint: errcheck
+		case "/api/v4/projects/group%2Fforbidden/hooks?per_page=100":
+			http.Error(w, `{"message": "403 Forbidden"}`, http.StatusForbidden)
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	url := "https://atlantis.example.com/events"
+	Ok(t, client.ValidateWebhook(logger, "group/ok", url))
+	ErrEquals(t, "the webhook of group/missing-events to https://atlantis.example.com/events isn't triggered by Comments, Merge request events, check them",
+		client.ValidateWebhook(logger, "group/missing-events", url))
+	ErrEquals(t, "the webhook of group/disabled to https://atlantis.example.com/events was disabled by GitLab because it failed, check that GitLab can reach Atlantis and re-enable it",
+		client.ValidateWebhook(logger, "group/disabled", url))
+	ErrEquals(t, "no webhook of group/none sends events to https://atlantis.example.com/events, add one as described in https://www.runatlantis.io/docs/configuring-webhooks.html#gitlab",
+		client.ValidateWebhook(logger, "group/none", url))
+	err = client.ValidateWebhook(logger, "group/forbidden", url)
+	Assert(t, errors.Is(err, ErrWebhookUnchecked), "expected the webhooks to be unchecked, got %s", err)
+}
+
+func TestGitlabClient_RegisterWebhook(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	var requests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.RequestURI {
+		case "GET /api/v4/groups/group/projects?archived=false&include_subgroups=true&per_page=100":
+			w.Write([]byte(`[{"path_with_namespace": "group/new"}, {"path_with_namespace": "group/sub/drifted"}]`)) // nolint: errcheck
+		case "GET /api/v4/projects/group%2Fnew/hooks?per_page=100":
+			w.Write([]byte(`[]`)) // nolint: errcheck
+		case "GET /api/v4/projects/group%2Fsub%2Fdrifted/hooks?per_page=100":
+			w.Write([]byte(`[{"id": 7, "url": "https://atlantis.example.com/events", "push_events": true}]`)) // nolint: errcheck
+		case "POST /api/v4/projects/group%2Fnew/hooks", "PUT /api/v4/projects/group%2Fsub%2Fdrifted/hooks/7":
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+string(body))
+			w.Write([]byte(`{}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request %s %q", r.Method, r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	internalClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(testServer.URL))
+	Ok(t, err)
+	client := &GitlabClient{Client: internalClient}
+
+	repos, err := client.ListOrgRepos(logger, "group")
+	Ok(t, err)
+	Equals(t, []string{"group/new", "group/sub/drifted"}, repos)
+
+	result, err := client.RegisterWebhook(logger, "group/new", "https://atlantis.example.com/events", "s3cr3t", false, false)
+	Ok(t, err)
+	Equals(t, WebhookCreated, result)
+	result, err = client.RegisterWebhook(logger, "group/sub/drifted", "https://atlantis.example.com/events", "s3cr3t", false, false)
+	Ok(t, err)
+	Equals(t, WebhookUpdated, result)
+	Equals(t, []string{
+		`POST {"name":"Atlantis","enable_ssl_verification":true,"merge_requests_events":true,"note_events":true,"push_events":true,"token":"s3cr3t","url":"https://atlantis.example.com/events"}`,
+		`PUT {"merge_requests_events":true,"note_events":true,"push_events":true,"token":"s3cr3t","url":"https://atlantis.example.com/events"}`,
+	}, requests)
+}
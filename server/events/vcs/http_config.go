@@ -0,0 +1,102 @@
+package vcs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// tlsVersions maps the TLS versions that can be configured to their
+// crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HTTPConfig configures how Atlantis connects to the API of a VCS host.
+type HTTPConfig struct {
+	// Proxy is the URL of the HTTP proxy requests are sent through. If
+	// empty, the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables.
+	Proxy string `json:"proxy"`
+	// CAFile is the path to a PEM bundle of CAs trusted in addition to the
+	// system's CAs.
+	CAFile string `json:"ca-file"`
+	// TLSMinVersion is the minimum TLS version, ex. 1.2. Defaults to Go's
+	// default.
+	TLSMinVersion string `json:"tls-min-version"`
+}
+
+// Transport builds the transport requests to the host are sent through.
+func (c HTTPConfig) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.Proxy != "" {
+		proxyURL, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing proxy %q", c.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{} // nolint: gosec
+	if c.TLSMinVersion != "" {
+		version, ok := tlsVersions[c.TLSMinVersion]
+		if !ok {
+			return nil, errors.Errorf("unsupported TLS version %q, must be one of 1.0, 1.1, 1.2 or 1.3", c.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading CA file %s", c.CAFile)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// HTTPConfigs are the HTTP configs of VCS hosts keyed by hostname, ex.
+// github.com.
+type HTTPConfigs map[string]HTTPConfig
+
+// Transport returns the transport for requests to hostname, or nil to use
+// http.DefaultTransport if it isn't configured. hostname can also be a URL.
+func (c HTTPConfigs) Transport(hostname string) (http.RoundTripper, error) {
+	if u, err := url.Parse(hostname); err == nil && u.Host != "" {
+		hostname = u.Host
+	}
+	config, ok := c[hostname]
+	if !ok {
+		return nil, nil
+	}
+	transport, err := config.Transport()
+	if err != nil {
+		return nil, errors.Wrapf(err, "configuring HTTP for %s", hostname)
+	}
+	return transport, nil
+}
+
+// Client returns the client for requests to hostname, or nil to use the
+// VCS client's default if it isn't configured.
+func (c HTTPConfigs) Client(hostname string) (*http.Client, error) {
+	transport, err := c.Transport(hostname)
+	if err != nil || transport == nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,84 @@
+package vcs_test
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestHTTPConfigs_Transport(t *testing.T) {
+	configs := vcs.HTTPConfigs{
+		"github.com": {Proxy: "http://proxy.corp.com:3128", TLSMinVersion: "1.2"},
+	}
+
+	transport, err := configs.Transport("github.com")
+	Ok(t, err)
+	httpTransport := transport.(*http.Transport)
+	proxyURL, err := httpTransport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "api.github.com"}})
+	Ok(t, err)
+	Equals(t, "http://proxy.corp.com:3128", proxyURL.String())
+	Equals(t, uint16(tls.VersionTLS12), httpTransport.TLSClientConfig.MinVersion)
+
+	// Hosts can be looked up by URL.
+	transport, err = configs.Transport("https://github.com")
+	Ok(t, err)
+	Assert(t, transport != nil, "expected a transport for the URL's host")
+
+	transport, err = configs.Transport("gitlab.com")
+	Ok(t, err)
+	Assert(t, transport == nil, "expected no transport for a host that isn't configured")
+	client, err := configs.Client("gitlab.com")
+	Ok(t, err)
+	Assert(t, client == nil, "expected no client for a host that isn't configured")
+}
+
+func TestHTTPConfigs_TransportErrors(t *testing.T) {
+	cases := map[string]struct {
+		config vcs.HTTPConfig
+		expErr string
+	}{
+		"unsupported TLS version": {
+			config: vcs.HTTPConfig{TLSMinVersion: "1.4"},
+			expErr: `configuring HTTP for github.com: unsupported TLS version "1.4", must be one of 1.0, 1.1, 1.2 or 1.3`,
+		},
+		"CA file without certificates": {
+			config: vcs.HTTPConfig{CAFile: "http_config_test.go"},
+			expErr: "configuring HTTP for github.com: no certificates found in CA file http_config_test.go",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := vcs.HTTPConfigs{"github.com": c.config}.Transport("github.com")
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+// A host whose certificate is signed by a CA from the CA file should be
+// trusted.
+func TestHTTPConfigs_CAFile(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	Ok(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw}), 0600))
+
+	client, err := vcs.HTTPConfigs{"example.com": {CAFile: caFile}}.Client("example.com")
+	Ok(t, err)
+	resp, err := client.Get(testServer.URL)
+	Ok(t, err)
+	defer resp.Body.Close() // nolint: errcheck
+	Equals(t, http.StatusOK, resp.StatusCode)
+
+	_, err = http.Get(testServer.URL) // nolint: noctx
+	Assert(t, err != nil, "expected the default client to not trust the test server")
+}
@@ -1,6 +1,7 @@
 package vcs
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/google/go-github/v71/github"
@@ -101,6 +102,26 @@ func (c *InstrumentedClient) GetModifiedFiles(logger logging.SimpleLogging, repo
 	return files, err
 }
 
+func (c *InstrumentedClient) ListModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error {
+	scope := c.StatsScope.SubScope("list_modified_files")
+	scope = SetGitScopeTags(scope, repo.FullName, pull.Num)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := ListModifiedFiles(c.Client, logger, repo, pull, f); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to list modified files, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+
 func (c *InstrumentedClient) CreateComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string) error {
 	scope := c.StatsScope.SubScope("create_comment")
 	scope = SetGitScopeTags(scope, repo.FullName, pullNum)
@@ -140,6 +161,78 @@ func (c *InstrumentedClient) ReactToComment(logger logging.SimpleLogging, repo m
 	return nil
 }
 
+func (c *InstrumentedClient) RemoveReaction(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	r, ok := c.Client.(ReactionRemover)
+	if !ok {
+		return nil
+	}
+	scope := c.StatsScope.SubScope("remove_reaction")
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := r.RemoveReaction(logger, repo, pullNum, commentID, reaction); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to remove reaction, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+
+func (c *InstrumentedClient) CreateSuggestions(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, body string, suggestions []Suggestion) error {
+	sc, ok := c.Client.(SuggestionCommenter)
+	if !ok {
+		return ErrSuggestionsUnsupported
+	}
+	scope := c.StatsScope.SubScope("create_suggestions")
+	scope = SetGitScopeTags(scope, repo.FullName, pull.Num)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := sc.CreateSuggestions(logger, repo, pull, body, suggestions); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to create suggestions, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+
+func (c *InstrumentedClient) UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error) {
+	cc, ok := c.Client.(CodeOwnersChecker)
+	if !ok {
+		return nil, ErrCodeOwnersUnsupported
+	}
+	scope := c.StatsScope.SubScope("unapproved_files")
+	scope = SetGitScopeTags(scope, repo.FullName, pull.Num)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	unapproved, err := cc.UnapprovedFiles(logger, repo, pull, files)
+	if err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to check the approvals of code owners, error: %s", err.Error())
+		return nil, err
+	}
+
+	executionSuccess.Inc(1)
+	return unapproved, nil
+}
+
 func (c *InstrumentedClient) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
 	scope := c.StatsScope.SubScope("hide_prev_plan_comments")
 	scope = SetGitScopeTags(scope, repo.FullName, pullNum)
@@ -245,6 +338,80 @@ func (c *InstrumentedClient) MergePull(logger logging.SimpleLogging, pull models
 	return nil
 }
 
+func (c *InstrumentedClient) GetPullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (string, error) {
+	u, ok := c.Client.(PullDescriptionUpdater)
+	if !ok {
+		return "", fmt.Errorf("editing pull request descriptions is not supported for %s", repo.VCSHost.Type.String())
+	}
+	scope := c.StatsScope.SubScope("get_pull_description")
+	scope = SetGitScopeTags(scope, repo.FullName, pull.Num)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	description, err := u.GetPullDescription(logger, repo, pull)
+	if err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to get pull description, error: %s", err.Error())
+		return "", err
+	}
+
+	executionSuccess.Inc(1)
+	return description, nil
+}
+
+func (c *InstrumentedClient) UpdatePullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, description string) error {
+	u, ok := c.Client.(PullDescriptionUpdater)
+	if !ok {
+		return fmt.Errorf("editing pull request descriptions is not supported for %s", repo.VCSHost.Type.String())
+	}
+	scope := c.StatsScope.SubScope("update_pull_description")
+	scope = SetGitScopeTags(scope, repo.FullName, pull.Num)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := u.UpdatePullDescription(logger, repo, pull, description); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to update pull description, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+
+func (c *InstrumentedClient) UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error) {
+	u, ok := c.Client.(LastCommentUpdater)
+	if !ok {
+		return false, nil
+	}
+	scope := c.StatsScope.SubScope("update_last_comment")
+	scope = SetGitScopeTags(scope, repo.FullName, pullNum)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	updated, err := u.UpdateLastComment(logger, repo, pullNum, comment, command, dir)
+	if err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to update comment for command %s, error: %s", command, err.Error())
+		return false, err
+	}
+
+	executionSuccess.Inc(1)
+	return updated, nil
+}
+
 func SetGitScopeTags(scope tally.Scope, repoFullName string, pullNum int) tally.Scope {
 	return scope.Tagged(map[string]string{
 		"base_repo": repoFullName,
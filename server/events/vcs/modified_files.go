@@ -0,0 +1,31 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ModifiedFilesLister is implemented by clients that can list the files
+// modified in a pull request a page at a time, so that callers that find what
+// they're looking for early don't fetch every page of pull requests with
+// thousands of modified files.
+type ModifiedFilesLister interface {
+	// ListModifiedFiles calls f with each page of the files modified in pull,
+	// in the order GetModifiedFiles returns them, until f returns false.
+	ListModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error
+}
+
+// ListModifiedFiles calls f with each page of the files modified in pull
+// until f returns false. If client can't list them a page at a time, f is
+// called once with all of them.
+func ListModifiedFiles(client Client, logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error {
+	if lister, ok := client.(ModifiedFilesLister); ok {
+		return lister.ListModifiedFiles(logger, repo, pull, f)
+	}
+	files, err := client.GetModifiedFiles(logger, repo, pull)
+	if err != nil {
+		return err
+	}
+	f(files)
+	return nil
+}
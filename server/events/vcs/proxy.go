@@ -14,6 +14,8 @@
 package vcs
 
 import (
+	"fmt"
+
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 )
@@ -24,6 +26,9 @@ type ClientProxy struct {
 	// clients maps from the vcs host type to the client that implements the
 	// api for that host type, ex. github -> github client.
 	clients map[models.VCSHostType]Client
+	// Budget, if set, skips the calls that aren't critical, ex. reactions and
+	// hiding comments, when the rate limit of the host is running low.
+	Budget *RateLimitBudget
 }
 
 func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClient Client, bitbucketServerClient Client, azuredevopsClient Client, giteaClient Client) *ClientProxy {
@@ -66,10 +71,18 @@ func (d *ClientProxy) CreateComment(logger logging.SimpleLogging, repo models.Re
 }
 
 func (d *ClientProxy) HidePrevCommandComments(logger logging.SimpleLogging, repo models.Repo, pullNum int, command string, dir string) error {
+	if d.Budget.Throttle(repo.VCSHost.Type) {
+		logger.Debug("not hiding previous comments because the %s rate limit is running low", repo.VCSHost.Type.String())
+		return nil
+	}
 	return d.clients[repo.VCSHost.Type].HidePrevCommandComments(logger, repo, pullNum, command, dir)
 }
 
 func (d *ClientProxy) ReactToComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	if d.Budget.Throttle(repo.VCSHost.Type) {
+		logger.Debug("not reacting to comment because the %s rate limit is running low", repo.VCSHost.Type.String())
+		return nil
+	}
 	return d.clients[repo.VCSHost.Type].ReactToComment(logger, repo, pullNum, commentID, reaction)
 }
 
@@ -85,6 +98,106 @@ func (d *ClientProxy) PullIsMergeable(logger logging.SimpleLogging, repo models.
 	return d.clients[repo.VCSHost.Type].PullIsMergeable(logger, repo, pull, vcsstatusname, ignoreVCSStatusNames)
 }
 
+// PullMergeBlockers returns why pull can't be merged if the client for its VCS
+// host supports it, otherwise nil.
+func (d *ClientProxy) PullMergeBlockers(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	if f, ok := d.clients[repo.VCSHost.Type].(PullMergeBlockersFetcher); ok {
+		return f.PullMergeBlockers(logger, repo, pull)
+	}
+	return nil, nil
+}
+
+// GetPullDescription returns the description of pull if the client for its
+// VCS host supports editing it.
+func (d *ClientProxy) GetPullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (string, error) {
+	if u, ok := d.clients[repo.VCSHost.Type].(PullDescriptionUpdater); ok {
+		return u.GetPullDescription(logger, repo, pull)
+	}
+	return "", fmt.Errorf("editing pull request descriptions is not supported for %s", repo.VCSHost.Type.String())
+}
+
+// UpdatePullDescription replaces the description of pull if the client for
+// its VCS host supports it.
+func (d *ClientProxy) UpdatePullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, description string) error {
+	if u, ok := d.clients[repo.VCSHost.Type].(PullDescriptionUpdater); ok {
+		return u.UpdatePullDescription(logger, repo, pull, description)
+	}
+	return fmt.Errorf("editing pull request descriptions is not supported for %s", repo.VCSHost.Type.String())
+}
+
+// RemoveReaction removes reaction from a comment if the client for the VCS
+// host of repo supports it, otherwise it does nothing.
+func (d *ClientProxy) RemoveReaction(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	if d.Budget.Throttle(repo.VCSHost.Type) {
+		logger.Debug("not removing reaction from comment because the %s rate limit is running low", repo.VCSHost.Type.String())
+		return nil
+	}
+	if r, ok := d.clients[repo.VCSHost.Type].(ReactionRemover); ok {
+		return r.RemoveReaction(logger, repo, pullNum, commentID, reaction)
+	}
+	return nil
+}
+
+// CreateAcknowledgment comments text if the client for the VCS host of repo
+// acknowledges commands with comments, otherwise it returns 0.
+func (d *ClientProxy) CreateAcknowledgment(logger logging.SimpleLogging, repo models.Repo, pullNum int, text string) (int64, error) {
+	if d.Budget.Throttle(repo.VCSHost.Type) {
+		logger.Debug("not acknowledging comment because the %s rate limit is running low", repo.VCSHost.Type.String())
+		return 0, nil
+	}
+	if c, ok := d.clients[repo.VCSHost.Type].(AcknowledgmentCommenter); ok {
+		return c.CreateAcknowledgment(logger, repo, pullNum, text)
+	}
+	return 0, nil
+}
+
+// DeleteAcknowledgment deletes a comment made by CreateAcknowledgment.
+func (d *ClientProxy) DeleteAcknowledgment(logger logging.SimpleLogging, repo models.Repo, pullNum int, id int64) error {
+	if d.Budget.Throttle(repo.VCSHost.Type) {
+		logger.Debug("not deleting acknowledgment comment %d because the %s rate limit is running low", id, repo.VCSHost.Type.String())
+		return nil
+	}
+	if c, ok := d.clients[repo.VCSHost.Type].(AcknowledgmentCommenter); ok {
+		return c.DeleteAcknowledgment(logger, repo, pullNum, id)
+	}
+	return nil
+}
+
+// CreateSuggestions comments on the pull request with suggestions if the
+// client for the VCS host of repo supports it, otherwise it returns
+// ErrSuggestionsUnsupported.
+func (d *ClientProxy) CreateSuggestions(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, body string, suggestions []Suggestion) error {
+	if c, ok := d.clients[repo.VCSHost.Type].(SuggestionCommenter); ok {
+		return c.CreateSuggestions(logger, repo, pull, body, suggestions)
+	}
+	return ErrSuggestionsUnsupported
+}
+
+// ListModifiedFiles lists the files modified in pull a page at a time if the
+// client for the VCS host of repo supports it, otherwise all at once.
+func (d *ClientProxy) ListModifiedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, f func(files []string) bool) error {
+	return ListModifiedFiles(d.clients[repo.VCSHost.Type], logger, repo, pull, f)
+}
+
+// UnapprovedFiles returns the files of the pull request that their owners
+// didn't approve if the client for the VCS host of repo supports it,
+// otherwise it returns ErrCodeOwnersUnsupported.
+func (d *ClientProxy) UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error) {
+	if c, ok := d.clients[repo.VCSHost.Type].(CodeOwnersChecker); ok {
+		return c.UnapprovedFiles(logger, repo, pull, files)
+	}
+	return nil, ErrCodeOwnersUnsupported
+}
+
+// UpdateLastComment edits the last comment for command and dir if the client
+// for the VCS host of repo supports it, otherwise it returns false.
+func (d *ClientProxy) UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error) {
+	if u, ok := d.clients[repo.VCSHost.Type].(LastCommentUpdater); ok {
+		return u.UpdateLastComment(logger, repo, pullNum, comment, command, dir)
+	}
+	return false, nil
+}
+
 func (d *ClientProxy) UpdateStatus(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
 	return d.clients[repo.VCSHost.Type].UpdateStatus(logger, repo, pull, state, src, description, url)
 }
@@ -37,8 +37,20 @@ func (f *pullReqStatusFetcher) FetchPullStatus(logger logging.SimpleLogging, pul
 		return pullStatus, errors.Wrapf(err, "fetching mergeability status for repo: %s, and pull number: %d", pull.BaseRepo.FullName, pull.Num)
 	}
 
+	var mergeBlockers []string
+	if bf, ok := f.client.(PullMergeBlockersFetcher); ok && !mergeable {
+		mergeBlockers, err = bf.PullMergeBlockers(logger, pull.BaseRepo, pull)
+		if err != nil {
+			// The blockers only make the failure message more helpful, so
+			// don't fail the command if they can't be fetched.
+			logger.Warn("unable to fetch merge blockers for repo: %s, and pull number: %d: %s", pull.BaseRepo.FullName, pull.Num, err)
+			err = nil
+		}
+	}
+
 	return models.PullReqStatus{
 		ApprovalStatus: approvalStatus,
 		Mergeable:      mergeable,
+		MergeBlockers:  mergeBlockers,
 	}, err
 }
@@ -0,0 +1,164 @@
+package vcs
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// searchRateLimitResource is the GitHub rate limit resource of the search
+// API. It has a much lower limit than the rest of the API, which Atlantis
+// doesn't share with it, so it's ignored when deciding to throttle.
+const searchRateLimitResource = "search"
+
+// RateLimitBudget tracks the API rate limit quota left on each VCS host, as
+// reported by the rate limit headers of their responses. When the quota runs
+// low, calls that aren't critical, ex. reactions and hiding comments, are
+// skipped so that the rest of it is kept for commit statuses and merges.
+type RateLimitBudget struct {
+	// Reserve is the percentage of the rate limit of a host kept for
+	// critical calls.
+	Reserve int
+	scope   tally.Scope
+
+	mu sync.Mutex
+	// quotas are the last quotas reported by each host, by rate limit
+	// resource. Hosts that don't report resources use "".
+	quotas map[models.VCSHostType]map[string]rateLimitQuota
+}
+
+type rateLimitQuota struct {
+	remaining int
+	limit     int
+	// reset is when the quota is replenished. It's zero if the host doesn't
+	// report it.
+	reset time.Time
+	// nearLimit is set by hosts that only report whether the quota is
+	// running low, ex. Bitbucket Cloud.
+	nearLimit bool
+}
+
+// NewRateLimitBudget returns a budget that keeps reserve percent of the rate
+// limit of each host for critical calls and publishes the quotas to scope.
+func NewRateLimitBudget(reserve int, scope tally.Scope) *RateLimitBudget {
+	return &RateLimitBudget{
+		Reserve: reserve,
+		scope:   scope.SubScope("vcs_rate_limit"),
+		quotas:  make(map[models.VCSHostType]map[string]rateLimitQuota),
+	}
+}
+
+// Transport wraps transport, or http.DefaultTransport if it's nil, so that
+// the quota of hostType is updated from every response. If b is nil,
+// transport is returned as is.
+func (b *RateLimitBudget) Transport(hostType models.VCSHostType, transport http.RoundTripper) http.RoundTripper {
+	if b == nil {
+		return transport
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &rateLimitTransport{budget: b, hostType: hostType, transport: transport}
+}
+
+// Client returns an HTTP client that sends requests through client's
+// transport, or http.DefaultTransport if client is nil, and updates the quota
+// of hostType. If b is nil, client is returned as is.
+func (b *RateLimitBudget) Client(hostType models.VCSHostType, client *http.Client) *http.Client {
+	if b == nil {
+		return client
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	wrapped := *client
+	wrapped.Transport = b.Transport(hostType, client.Transport)
+	return &wrapped
+}
+
+// Observe updates the quota of hostType from the rate limit headers of a
+// response. Responses without them are ignored.
+func (b *RateLimitBudget) Observe(hostType models.VCSHostType, header http.Header) {
+	remaining, hasRemaining := rateLimitHeader(header, "Remaining")
+	limit, hasLimit := rateLimitHeader(header, "Limit")
+	nearLimit := strings.EqualFold(header.Get("X-RateLimit-NearLimit"), "true")
+	if !hasRemaining && !hasLimit && !nearLimit {
+		return
+	}
+	quota := rateLimitQuota{remaining: remaining, limit: limit, nearLimit: nearLimit}
+	if !hasRemaining {
+		quota.remaining = limit
+	}
+	if reset, ok := rateLimitHeader(header, "Reset"); ok {
+		// GitHub and GitLab report when the quota resets as a Unix time,
+		// others report the seconds until it resets.
+		if reset > 1e9 {
+			quota.reset = time.Unix(int64(reset), 0)
+		} else {
+			quota.reset = time.Now().Add(time.Duration(reset) * time.Second)
+		}
+	}
+	resource := header.Get("X-RateLimit-Resource")
+
+	b.mu.Lock()
+	if b.quotas[hostType] == nil {
+		b.quotas[hostType] = make(map[string]rateLimitQuota)
+	}
+	b.quotas[hostType][resource] = quota
+	b.mu.Unlock()
+
+	scope := b.scope.Tagged(map[string]string{"host": hostType.String(), "resource": resource})
+	scope.Gauge("remaining").Update(float64(quota.remaining))
+	scope.Gauge("limit").Update(float64(quota.limit))
+}
+
+// Throttle returns true if calls to hostType that aren't critical should be
+// skipped because less than Reserve percent of its quota is left.
+func (b *RateLimitBudget) Throttle(hostType models.VCSHostType) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	for resource, quota := range b.quotas[hostType] {
+		if resource == searchRateLimitResource || (!quota.reset.IsZero() && now.After(quota.reset)) {
+			continue
+		}
+		if quota.nearLimit || (quota.limit > 0 && quota.remaining*100 < quota.limit*b.Reserve) {
+			b.scope.Tagged(map[string]string{"host": hostType.String()}).Counter("throttled").Inc(1)
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitHeader returns the X-RateLimit-<name> header, or the
+// RateLimit-<name> header GitLab uses.
+func rateLimitHeader(header http.Header, name string) (int, bool) {
+	value := header.Get("X-RateLimit-" + name)
+	if value == "" {
+		value = header.Get("RateLimit-" + name)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	return n, err == nil
+}
+
+type rateLimitTransport struct {
+	budget    *RateLimitBudget
+	hostType  models.VCSHostType
+	transport http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil {
+		t.budget.Observe(t.hostType, resp.Header)
+	}
+	return resp, err
+}
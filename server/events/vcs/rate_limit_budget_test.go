@@ -0,0 +1,163 @@
+package vcs_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestRateLimitBudget_Throttle(t *testing.T) {
+	reset := fmt.Sprint(time.Now().Add(time.Hour).Unix())
+	cases := []struct {
+		description string
+		header      map[string]string
+		expThrottle bool
+	}{
+		{
+			"no rate limit headers",
+			map[string]string{},
+			false,
+		},
+		{
+			"github above reserve",
+			map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "1000", "X-RateLimit-Reset": reset},
+			false,
+		},
+		{
+			"github below reserve",
+			map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "999", "X-RateLimit-Reset": reset},
+			true,
+		},
+		{
+			"github quota reset",
+			map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": fmt.Sprint(time.Now().Add(-time.Minute).Unix())},
+			false,
+		},
+		{
+			"github search",
+			map[string]string{"X-RateLimit-Limit": "30", "X-RateLimit-Remaining": "0", "X-RateLimit-Resource": "search"},
+			false,
+		},
+		{
+			"gitlab below reserve",
+			map[string]string{"RateLimit-Limit": "2000", "RateLimit-Remaining": "10", "RateLimit-Reset": reset},
+			true,
+		},
+		{
+			"seconds until reset",
+			map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "1", "X-RateLimit-Reset": "60"},
+			true,
+		},
+		{
+			"bitbucket near limit",
+			map[string]string{"X-RateLimit-Limit": "1000", "X-RateLimit-NearLimit": "true"},
+			true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			budget := vcs.NewRateLimitBudget(20, tally.NoopScope)
+			header := http.Header{}
+			for k, v := range c.header {
+				header.Set(k, v)
+			}
+			budget.Observe(models.Github, header)
+			Equals(t, c.expThrottle, budget.Throttle(models.Github))
+			Equals(t, false, budget.Throttle(models.Gitlab))
+		})
+	}
+
+	var noBudget *vcs.RateLimitBudget
+	Equals(t, false, noBudget.Throttle(models.Github))
+}
+
+func TestRateLimitBudget_Client(t *testing.T) {
+	remaining := "4999"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+	}))
+	defer server.Close()
+	scope := tally.NewTestScope("atlantis", nil)
+	budget := vcs.NewRateLimitBudget(10, scope)
+	client := budget.Client(models.Gitea, nil)
+
+	resp, err := client.Get(server.URL)
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+	Equals(t, false, budget.Throttle(models.Gitea))
+
+	remaining = "1"
+	resp, err = client.Get(server.URL)
+	Ok(t, err)
+	resp.Body.Close() // nolint: errcheck
+	Equals(t, true, budget.Throttle(models.Gitea))
+
+	gauges := scope.Snapshot().Gauges()
+	Equals(t, float64(1), gauges["atlantis.vcs_rate_limit.remaining+host=Gitea,resource="].Value())
+	Equals(t, float64(5000), gauges["atlantis.vcs_rate_limit.limit+host=Gitea,resource="].Value())
+	Equals(t, int64(1), scope.Snapshot().Counters()["atlantis.vcs_rate_limit.throttled+host=Gitea"].Value())
+}
+
+func TestClientProxy_Throttle(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Github}}
+	githubClient := &reactingClient{MockClient: mocks.NewMockClient()}
+	proxy := vcs.NewClientProxy(githubClient, nil, nil, nil, nil, nil)
+	proxy.Budget = vcs.NewRateLimitBudget(20, tally.NoopScope)
+
+	Ok(t, proxy.ReactToComment(logger, repo, 1, 2, "eyes"))
+	githubClient.VerifyWasCalledOnce().ReactToComment(logger, repo, 1, 2, "eyes")
+	Ok(t, proxy.RemoveReaction(logger, repo, 1, 2, "eyes"))
+	Equals(t, 1, githubClient.calls)
+
+	proxy.Budget.Observe(models.Github, http.Header{"X-Ratelimit-Limit": {"5000"}, "X-Ratelimit-Remaining": {"10"}})
+	Ok(t, proxy.ReactToComment(logger, repo, 1, 3, "eyes"))
+	Ok(t, proxy.HidePrevCommandComments(logger, repo, 1, "plan", ""))
+	githubClient.VerifyWasCalled(Never()).ReactToComment(logger, repo, 1, 3, "eyes")
+	githubClient.VerifyWasCalled(Never()).HidePrevCommandComments(logger, repo, 1, "plan", "")
+	// Removing the skipped reaction or acknowledging with a comment
+	// instead would spend the budget too.
+	Ok(t, proxy.RemoveReaction(logger, repo, 1, 3, "eyes"))
+	ackID, err := proxy.CreateAcknowledgment(logger, repo, 1, "Processing...")
+	Ok(t, err)
+	Equals(t, int64(0), ackID)
+	Ok(t, proxy.DeleteAcknowledgment(logger, repo, 1, 4))
+	Equals(t, 1, githubClient.calls)
+	// Critical calls aren't throttled.
+	Ok(t, proxy.UpdateStatus(logger, repo, models.PullRequest{Num: 1}, models.SuccessCommitStatus, "src", "description", "url"))
+	githubClient.VerifyWasCalledOnce().UpdateStatus(logger, repo, models.PullRequest{Num: 1}, models.SuccessCommitStatus, "src", "description", "url")
+}
+
+// reactingClient counts the calls to remove reactions and acknowledgments,
+// which the mock client doesn't support.
+type reactingClient struct {
+	*mocks.MockClient
+	calls int
+}
+
+func (c *reactingClient) RemoveReaction(_ logging.SimpleLogging, _ models.Repo, _ int, _ int64, _ string) error {
+	c.calls++
+	return nil
+}
+
+func (c *reactingClient) CreateAcknowledgment(_ logging.SimpleLogging, _ models.Repo, _ int, _ string) (int64, error) {
+	c.calls++
+	return 1, nil
+}
+
+func (c *reactingClient) DeleteAcknowledgment(_ logging.SimpleLogging, _ models.Repo, _ int, _ int64) error {
+	c.calls++
+	return nil
+}
@@ -0,0 +1,113 @@
+package vcs
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// RedactingClient masks secrets in comments before they're posted on pull
+// requests. All other calls go straight to Client.
+type RedactingClient struct {
+	Client
+	Redactor *logging.Redactor
+}
+
+// NewRedactingClient wraps client so that comments are masked by redactor.
+func NewRedactingClient(client Client, redactor *logging.Redactor) Client {
+	return &RedactingClient{
+		Client:   client,
+		Redactor: redactor,
+	}
+}
+
+func (c *RedactingClient) CreateComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string) error {
+	return c.Client.CreateComment(logger, repo, pullNum, c.Redactor.Redact(comment), command)
+}
+
+// PullMergeBlockers passes through to Client if it can explain why a pull
+// request isn't mergeable.
+func (c *RedactingClient) PullMergeBlockers(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) ([]string, error) {
+	if f, ok := c.Client.(PullMergeBlockersFetcher); ok {
+		return f.PullMergeBlockers(logger, repo, pull)
+	}
+	return nil, nil
+}
+
+// GetPullDescription passes through to Client if it can edit pull request
+// descriptions.
+func (c *RedactingClient) GetPullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest) (string, error) {
+	if u, ok := c.Client.(PullDescriptionUpdater); ok {
+		return u.GetPullDescription(logger, repo, pull)
+	}
+	return "", fmt.Errorf("editing pull request descriptions is not supported for %s", repo.VCSHost.Type.String())
+}
+
+// UpdatePullDescription masks secrets in description before passing it
+// through to Client.
+func (c *RedactingClient) UpdatePullDescription(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, description string) error {
+	if u, ok := c.Client.(PullDescriptionUpdater); ok {
+		return u.UpdatePullDescription(logger, repo, pull, c.Redactor.Redact(description))
+	}
+	return fmt.Errorf("editing pull request descriptions is not supported for %s", repo.VCSHost.Type.String())
+}
+
+// UpdateLastComment masks secrets in comment before passing it through to
+// Client if it can edit comments.
+func (c *RedactingClient) UpdateLastComment(logger logging.SimpleLogging, repo models.Repo, pullNum int, comment string, command string, dir string) (bool, error) {
+	if u, ok := c.Client.(LastCommentUpdater); ok {
+		return u.UpdateLastComment(logger, repo, pullNum, c.Redactor.Redact(comment), command, dir)
+	}
+	return false, nil
+}
+
+// UnapprovedFiles passes through to Client if it can check the approvals of
+// code owners.
+func (c *RedactingClient) UnapprovedFiles(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, files []string) ([]string, error) {
+	if cc, ok := c.Client.(CodeOwnersChecker); ok {
+		return cc.UnapprovedFiles(logger, repo, pull, files)
+	}
+	return nil, ErrCodeOwnersUnsupported
+}
+
+// CreateSuggestions masks secrets in body and the suggested lines before
+// passing them through to Client if it can comment with suggestions.
+func (c *RedactingClient) CreateSuggestions(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, body string, suggestions []Suggestion) error {
+	sc, ok := c.Client.(SuggestionCommenter)
+	if !ok {
+		return ErrSuggestionsUnsupported
+	}
+	redacted := make([]Suggestion, len(suggestions))
+	for i, suggestion := range suggestions {
+		suggestion.Replacement = c.Redactor.Redact(suggestion.Replacement)
+		redacted[i] = suggestion
+	}
+	return sc.CreateSuggestions(logger, repo, pull, c.Redactor.Redact(body), redacted)
+}
+
+// RemoveReaction passes through to Client if it can remove reactions.
+func (c *RedactingClient) RemoveReaction(logger logging.SimpleLogging, repo models.Repo, pullNum int, commentID int64, reaction string) error {
+	if r, ok := c.Client.(ReactionRemover); ok {
+		return r.RemoveReaction(logger, repo, pullNum, commentID, reaction)
+	}
+	return nil
+}
+
+// CreateAcknowledgment masks secrets in text before passing it through to
+// Client if it acknowledges commands with comments, otherwise it returns 0.
+func (c *RedactingClient) CreateAcknowledgment(logger logging.SimpleLogging, repo models.Repo, pullNum int, text string) (int64, error) {
+	if ac, ok := c.Client.(AcknowledgmentCommenter); ok {
+		return ac.CreateAcknowledgment(logger, repo, pullNum, c.Redactor.Redact(text))
+	}
+	return 0, nil
+}
+
+// DeleteAcknowledgment passes through to Client if it acknowledges commands
+// with comments.
+func (c *RedactingClient) DeleteAcknowledgment(logger logging.SimpleLogging, repo models.Repo, pullNum int, id int64) error {
+	if ac, ok := c.Client.(AcknowledgmentCommenter); ok {
+		return ac.DeleteAcknowledgment(logger, repo, pullNum, id)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package vcs_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRedactingClient_CreateComment(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "owner/repo"}
+	r, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+
+	underlying := mocks.NewMockClient()
+	client := vcs.NewRedactingClient(underlying, r)
+
+	Ok(t, client.CreateComment(logger, repo, 1, "token is s3cr3t", "plan"))
+	underlying.VerifyWasCalledOnce().CreateComment(logger, repo, 1, "token is [REDACTED]", "plan")
+}
+
+// codeOwnersClient is a client whose VCS host supports code owners.
+type codeOwnersClient struct {
+	*mocks.MockClient
+	unapproved []string
+}
+
+func (c *codeOwnersClient) UnapprovedFiles(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, _ []string) ([]string, error) {
+	return c.unapproved, nil
+}
+
+func TestRedactingClient_UnapprovedFiles(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "owner/repo"}
+	pull := models.PullRequest{Num: 1}
+	r, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+
+	client := vcs.NewRedactingClient(&codeOwnersClient{MockClient: mocks.NewMockClient(), unapproved: []string{"main.tf"}}, r)
+	checker, ok := client.(vcs.CodeOwnersChecker)
+	Assert(t, ok, "expected the redacting client to check code owners")
+	unapproved, err := checker.UnapprovedFiles(logger, repo, pull, []string{"main.tf", "README.md"})
+	Ok(t, err)
+	Equals(t, []string{"main.tf"}, unapproved)
+
+	client = vcs.NewRedactingClient(mocks.NewMockClient(), r)
+	_, err = client.(vcs.CodeOwnersChecker).UnapprovedFiles(logger, repo, pull, []string{"main.tf"})
+	Equals(t, vcs.ErrCodeOwnersUnsupported, err)
+}
+
+// suggestionClient is a client whose VCS host supports suggestions.
+type suggestionClient struct {
+	*mocks.MockClient
+	body        string
+	suggestions []vcs.Suggestion
+}
+
+func (c *suggestionClient) CreateSuggestions(_ logging.SimpleLogging, _ models.Repo, _ models.PullRequest, body string, suggestions []vcs.Suggestion) error {
+	c.body = body
+	c.suggestions = suggestions
+	return nil
+}
+
+func TestRedactingClient_CreateSuggestions(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "owner/repo"}
+	pull := models.PullRequest{Num: 1}
+	r, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+
+	underlying := &suggestionClient{MockClient: mocks.NewMockClient()}
+	client := vcs.NewRedactingClient(underlying, r)
+	commenter, ok := client.(vcs.SuggestionCommenter)
+	Assert(t, ok, "expected the redacting client to comment with suggestions")
+	suggestions := []vcs.Suggestion{{Path: "main.tf", StartLine: 1, EndLine: 1, Replacement: `password = "s3cr3t"`}}
+	Ok(t, commenter.CreateSuggestions(logger, repo, pull, "fmt failed with s3cr3t", suggestions))
+	Equals(t, "fmt failed with [REDACTED]", underlying.body)
+	Equals(t, []vcs.Suggestion{{Path: "main.tf", StartLine: 1, EndLine: 1, Replacement: `password = "[REDACTED]"`}}, underlying.suggestions)
+	Equals(t, `password = "s3cr3t"`, suggestions[0].Replacement)
+
+	client = vcs.NewRedactingClient(mocks.NewMockClient(), r)
+	Equals(t, vcs.ErrSuggestionsUnsupported, client.(vcs.SuggestionCommenter).CreateSuggestions(logger, repo, pull, "", suggestions))
+}
+
+// acknowledgmentClient is a client whose VCS host acknowledges commands with
+// comments and can remove reactions.
+type acknowledgmentClient struct {
+	*mocks.MockClient
+	text             string
+	deleted          int64
+	removedReactions []string
+}
+
+func (c *acknowledgmentClient) CreateAcknowledgment(_ logging.SimpleLogging, _ models.Repo, _ int, text string) (int64, error) {
+	c.text = text
+	return 42, nil
+}
+
+func (c *acknowledgmentClient) DeleteAcknowledgment(_ logging.SimpleLogging, _ models.Repo, _ int, id int64) error {
+	c.deleted = id
+	return nil
+}
+
+func (c *acknowledgmentClient) RemoveReaction(_ logging.SimpleLogging, _ models.Repo, _ int, _ int64, reaction string) error {
+	c.removedReactions = append(c.removedReactions, reaction)
+	return nil
+}
+
+func TestRedactingClient_Acknowledgments(t *testing.T) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	repo := models.Repo{FullName: "owner/repo"}
+	r, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+
+	underlying := &acknowledgmentClient{MockClient: mocks.NewMockClient()}
+	client := vcs.NewRedactingClient(underlying, r)
+	commenter, ok := client.(vcs.AcknowledgmentCommenter)
+	Assert(t, ok, "expected the redacting client to acknowledge commands")
+	id, err := commenter.CreateAcknowledgment(logger, repo, 1, "running plan -var key=s3cr3t")
+	Ok(t, err)
+	Equals(t, int64(42), id)
+	Equals(t, "running plan -var key=[REDACTED]", underlying.text)
+	Ok(t, commenter.DeleteAcknowledgment(logger, repo, 1, id))
+	Equals(t, int64(42), underlying.deleted)
+
+	remover, ok := client.(vcs.ReactionRemover)
+	Assert(t, ok, "expected the redacting client to remove reactions")
+	Ok(t, remover.RemoveReaction(logger, repo, 1, 1, "eyes"))
+	Equals(t, []string{"eyes"}, underlying.removedReactions)
+
+	client = vcs.NewRedactingClient(mocks.NewMockClient(), r)
+	id, err = client.(vcs.AcknowledgmentCommenter).CreateAcknowledgment(logger, repo, 1, "running plan")
+	Ok(t, err)
+	Equals(t, int64(0), id)
+}
@@ -0,0 +1,18 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// RepoBootstrapper is implemented by clients that can open a pull request
+// adding a file to a repo without a clone.
+type RepoBootstrapper interface {
+	// ListDefaultBranchFiles returns the default branch of the repo
+	// repoFullName, ex. "owner/repo", and the paths of the files on it
+	// relative to the repo root.
+	ListDefaultBranchFiles(logger logging.SimpleLogging, repoFullName string) (string, []string, error)
+	// OpenPullWithFile creates branch from base, commits content to the file
+	// path on it and opens a pull request from branch to base. It returns the
+	// URL of the pull request.
+	OpenPullWithFile(logger logging.SimpleLogging, repoFullName string, base string, branch string, path string, content []byte, title string, body string) (string, error)
+}
@@ -0,0 +1,161 @@
+package vcs
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ErrSuggestionsUnsupported is returned by SuggestionCommenter when the VCS
+// host of the repo can't comment with suggestions.
+var ErrSuggestionsUnsupported = errors.New("suggestions aren't supported by this VCS host")
+
+// hunkHeaderRegex matches the header of a hunk of a unified diff, ex.
+// "@@ -1,4 +1,5 @@". The line counts are omitted when they're 1.
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+// Suggestion is a change to lines of a file of a pull request that reviewers
+// can apply from the VCS host's UI.
+type Suggestion struct {
+	// Path is the path of the file relative to the repo root.
+	Path string
+	// StartLine and EndLine are the first and last lines, starting at 1, of
+	// the file at the head commit that Replacement replaces.
+	StartLine int
+	EndLine   int
+	// Replacement is the new content of the lines, without a trailing
+	// newline.
+	Replacement string
+}
+
+// SuggestionCommenter is implemented by clients that can comment on lines of
+// a pull request with suggestions.
+type SuggestionCommenter interface {
+	// CreateSuggestions comments on pull with suggestions, introduced by body.
+	// The lines of each suggestion must be part of the pull request's diff.
+	CreateSuggestions(logger logging.SimpleLogging, repo models.Repo, pull models.PullRequest, body string, suggestions []Suggestion) error
+}
+
+// SuggestionsFromDiff returns a suggestion per hunk of diff, a unified diff
+// with "+++ new/<path>" file headers like terraform fmt -diff outputs. The
+// paths are relative to dir, which is relative to the repo root. Unchanged
+// lines at the start and end of the hunks aren't part of the suggestions.
+func SuggestionsFromDiff(dir string, diff string) []Suggestion {
+	var suggestions []Suggestion
+	var file string
+	var hunk *diffHunk
+	flush := func() {
+		if hunk != nil {
+			if s, ok := hunk.suggestion(path.Join(dir, file)); ok {
+				suggestions = append(suggestions, s)
+			}
+		}
+		hunk = nil
+	}
+	var prev string
+	for _, line := range strings.Split(diff, "\n") {
+		if hunk != nil && hunk.add(line, prev) {
+			prev = line
+			continue
+		}
+		prev = line
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			flush()
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "new/")
+		case hunkHeaderRegex.MatchString(line):
+			flush()
+			match := hunkHeaderRegex.FindStringSubmatch(line)
+			start, _ := strconv.Atoi(match[1])
+			count := 1
+			if match[2] != "" {
+				count, _ = strconv.Atoi(match[2])
+			}
+			hunk = &diffHunk{start: start, remaining: count}
+		}
+	}
+	flush()
+	return suggestions
+}
+
+// diffHunk is a hunk of a unified diff being parsed.
+type diffHunk struct {
+	// start is the first line of the old file the hunk changes.
+	start int
+	// remaining is how many lines of the old file are left to parse.
+	remaining int
+	// lines are the lines of the hunk without the "\ No newline" markers.
+	lines []string
+}
+
+// add adds line, which follows prev, to the hunk and returns true if it was
+// part of it.
+func (h *diffHunk) add(line string, prev string) bool {
+	if h.remaining == 0 {
+		// Only added lines can follow the last old line of the hunk, and the
+		// "+++" header of the next file follows its "---" header.
+		if strings.HasPrefix(prev, "--- ") || !(strings.HasPrefix(line, "+") || strings.HasPrefix(line, "\\")) {
+			return false
+		}
+	}
+	switch {
+	case strings.HasPrefix(line, "\\"):
+	case strings.HasPrefix(line, "+"):
+		h.lines = append(h.lines, line)
+	case strings.HasPrefix(line, "-"), strings.HasPrefix(line, " "), line == "":
+		h.lines = append(h.lines, line)
+		h.remaining--
+	default:
+		return false
+	}
+	return true
+}
+
+// suggestion returns the suggestion replacing the changed lines of the hunk
+// of the file filePath, or false if there's nothing to replace.
+func (h *diffHunk) suggestion(filePath string) (Suggestion, bool) {
+	first, last := -1, -1
+	for i, line := range h.lines {
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first == -1 {
+		return Suggestion{}, false
+	}
+	isOld := func(line string) bool { return !strings.HasPrefix(line, "+") }
+	if !slices.ContainsFunc(h.lines[first:last+1], isOld) {
+		// Suggestions replace lines, so lines added between unchanged lines
+		// replace the unchanged line before them.
+		if first == 0 {
+			return Suggestion{}, false
+		}
+		first--
+	}
+
+	// Lines before first are unchanged so they're all old lines.
+	s := Suggestion{Path: filePath, StartLine: h.start + first, EndLine: h.start + first - 1}
+	var replacement []string
+	for _, line := range h.lines[first : last+1] {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			s.EndLine++
+		case strings.HasPrefix(line, "+"):
+			replacement = append(replacement, line[1:])
+		default:
+			s.EndLine++
+			replacement = append(replacement, strings.TrimPrefix(line, " "))
+		}
+	}
+	s.Replacement = strings.Join(replacement, "\n")
+	return s, true
+}
@@ -0,0 +1,59 @@
+package vcs_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestSuggestionsFromDiff(t *testing.T) {
+	diff := `main.tf
+--- old/main.tf
++++ new/main.tf
+@@ -1,7 +1,7 @@
+ resource "null_resource" "a" {
+-  triggers = {
+-    a = 1
+-    bb = 2
++  triggers = {
++    a  = 1
++    bb = 2
+   }
+ }
+ 
+@@ -10,3 +10,4 @@
+ output "a" {
+   value = 1
++
+ }
+modules/vpc/vpc.tf
+--- old/modules/vpc/vpc.tf
++++ new/modules/vpc/vpc.tf
+@@ -1 +1 @@
+-variable "cidr" {}
+\ No newline at end of file
++variable "cidr" {}
+`
+	Equals(t, []vcs.Suggestion{
+		{
+			Path:        "staging/main.tf",
+			StartLine:   2,
+			EndLine:     4,
+			Replacement: "  triggers = {\n    a  = 1\n    bb = 2",
+		},
+		{
+			Path:        "staging/main.tf",
+			StartLine:   11,
+			EndLine:     11,
+			Replacement: "  value = 1\n",
+		},
+		{
+			Path:        "staging/modules/vpc/vpc.tf",
+			StartLine:   1,
+			EndLine:     1,
+			Replacement: `variable "cidr" {}`,
+		},
+	}, vcs.SuggestionsFromDiff("staging", diff))
+	Equals(t, []vcs.Suggestion(nil), vcs.SuggestionsFromDiff(".", ""))
+}
@@ -0,0 +1,37 @@
+package vcs
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// ErrWebhookUnchecked is wrapped by the errors of WebhookValidator when the
+// webhooks of a repo can't be looked up, ex. because the token isn't allowed
+// to.
+var ErrWebhookUnchecked = errors.New("webhooks can't be checked")
+
+// CredentialsValidator is implemented by clients that can check their
+// credentials.
+type CredentialsValidator interface {
+	// ValidateCredentials returns an error explaining how to fix the
+	// credentials if they're invalid or lack permissions Atlantis needs.
+	ValidateCredentials(logger logging.SimpleLogging) error
+}
+
+// WebhookValidator is implemented by clients that can look up the webhooks
+// of a repo.
+type WebhookValidator interface {
+	// ValidateWebhook returns an error explaining how to fix the webhooks of
+	// the repo repoFullName, ex. "owner/repo", if none sends the events
+	// Atlantis needs to url. The error wraps ErrWebhookUnchecked if the
+	// webhooks can't be looked up.
+	ValidateWebhook(logger logging.SimpleLogging, repoFullName string, url string) error
+}
+
+// sameWebhookURL returns true if the webhook URLs a and b are the same,
+// ignoring case and a trailing slash.
+func sameWebhookURL(a string, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/"))
+}
@@ -0,0 +1,28 @@
+package vcs
+
+import (
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// The results of WebhookRegistrar.RegisterWebhook.
+const (
+	WebhookCreated   = "created"
+	WebhookUpdated   = "updated"
+	WebhookUnchanged = "unchanged"
+)
+
+// WebhookRegistrar is implemented by clients that can create and update the
+// webhooks of repos.
+type WebhookRegistrar interface {
+	// ListOrgRepos returns the full names, ex. "owner/repo", of the repos of
+	// org, ex. a GitHub organization, GitLab group or Bitbucket workspace.
+	// Archived repos are left out where the API allows.
+	ListOrgRepos(logger logging.SimpleLogging, org string) ([]string, error)
+	// RegisterWebhook creates a webhook on the repo repoFullName sending the
+	// events Atlantis needs to url, signed with secret. If the repo already
+	// has a webhook to url, it's updated if it's inactive or doesn't send all
+	// the events, or if updateSecret is true. It returns WebhookCreated,
+	// WebhookUpdated or WebhookUnchanged. If dryRun is true, it returns what
+	// it would do without changing anything.
+	RegisterWebhook(logger logging.SimpleLogging, repoFullName string, url string, secret string, updateSecret bool, dryRun bool) (string, error)
+}
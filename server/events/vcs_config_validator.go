@@ -0,0 +1,100 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// The statuses of VCSConfigCheck.
+const (
+	VCSConfigCheckOK      = "ok"
+	VCSConfigCheckFailed  = "failed"
+	VCSConfigCheckSkipped = "skipped"
+)
+
+// VCSConfigHost is a VCS host whose configuration is validated.
+type VCSConfigHost struct {
+	Type models.VCSHostType
+	// Hostname is the hostname allowlisted repos are matched with, ex.
+	// github.com.
+	Hostname string
+	// Client is checked if it implements vcs.CredentialsValidator or
+	// vcs.WebhookValidator.
+	Client vcs.Client
+	// WebhookSecret is the secret webhooks registered with
+	// WebhookRegistrar are signed with.
+	WebhookSecret string
+}
+
+// VCSConfigCheck is the result of checking the credentials of a VCS host or
+// the webhook of a repo.
+type VCSConfigCheck struct {
+	Host string `json:"host"`
+	// Repo is the repo whose webhook was checked, or empty if the
+	// credentials were.
+	Repo    string `json:"repo,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// VCSConfigValidator checks that the credentials of the VCS hosts are valid
+// and that the repos allowlisted without wildcards have webhooks sending
+// events to Atlantis.
+type VCSConfigValidator struct {
+	Hosts                []VCSConfigHost
+	RepoAllowlistChecker *RepoAllowlistChecker
+	// WebhookURL is the URL the webhooks must send events to.
+	WebhookURL string
+}
+
+// Validate runs the checks. The webhooks of a host are only checked if its
+// credentials are valid.
+func (v *VCSConfigValidator) Validate(logger logging.SimpleLogging) []VCSConfigCheck {
+	var checks []VCSConfigCheck
+	for _, host := range v.Hosts {
+		credsCheck := VCSConfigCheck{Host: host.Type.String(), Status: VCSConfigCheckSkipped, Message: "the credentials of this host can't be checked"}
+		if validator, ok := host.Client.(vcs.CredentialsValidator); ok {
+			credsCheck.Status, credsCheck.Message = VCSConfigCheckOK, ""
+			if err := validator.ValidateCredentials(logger); err != nil {
+				credsCheck.Status, credsCheck.Message = VCSConfigCheckFailed, err.Error()
+			}
+		}
+		checks = append(checks, credsCheck)
+
+		validator, ok := host.Client.(vcs.WebhookValidator)
+		if !ok || credsCheck.Status == VCSConfigCheckFailed || v.RepoAllowlistChecker == nil {
+			continue
+		}
+		for _, repo := range v.RepoAllowlistChecker.ExactRepos(host.Hostname) {
+			check := VCSConfigCheck{Host: host.Type.String(), Repo: repo, Status: VCSConfigCheckOK}
+			if err := validator.ValidateWebhook(logger, repo, v.WebhookURL); errors.Is(err, vcs.ErrWebhookUnchecked) {
+				check.Status, check.Message = VCSConfigCheckSkipped, err.Error()
+			} else if err != nil {
+				check.Status, check.Message = VCSConfigCheckFailed, err.Error()
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// FailedVCSConfigChecks returns an error listing the failed checks, or nil if
+// none failed.
+func FailedVCSConfigChecks(checks []VCSConfigCheck) error {
+	var errs []error
+	for _, check := range checks {
+		if check.Status != VCSConfigCheckFailed {
+			continue
+		}
+		if check.Repo != "" {
+			errs = append(errs, fmt.Errorf("%s webhook of %s: %s", check.Host, check.Repo, check.Message))
+		} else {
+			errs = append(errs, fmt.Errorf("%s credentials: %s", check.Host, check.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
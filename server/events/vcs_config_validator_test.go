@@ -0,0 +1,65 @@
+package events_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// validatingClient is a VCS client whose credentials and webhooks can be
+// validated.
+type validatingClient struct {
+	*vcsmocks.MockClient
+	credentialsErr error
+	webhookErrs    map[string]error
+}
+
+func (c validatingClient) ValidateCredentials(_ logging.SimpleLogging) error {
+	return c.credentialsErr
+}
+
+func (c validatingClient) ValidateWebhook(_ logging.SimpleLogging, repoFullName string, _ string) error {
+	return c.webhookErrs[repoFullName]
+}
+
+func TestVCSConfigValidator_Validate(t *testing.T) {
+	allowlist, err := events.NewRepoAllowlistChecker("github.com/owner/ok,github.com/owner/missing,github.com/owner/admin-only,github.com/other/*,gitlab.com/group/project")
+	Ok(t, err)
+	validator := events.VCSConfigValidator{
+		Hosts: []events.VCSConfigHost{
+			{Type: models.Github, Hostname: "github.com", Client: validatingClient{
+				MockClient: vcsmocks.NewMockClient(),
+				webhookErrs: map[string]error{
+					"owner/missing":    errors.New("no webhook"),
+					"owner/admin-only": fmt.Errorf("%w: needs admin", vcs.ErrWebhookUnchecked),
+				},
+			}},
+			{Type: models.Gitlab, Hostname: "gitlab.com", Client: validatingClient{
+				MockClient:     vcsmocks.NewMockClient(),
+				credentialsErr: errors.New("invalid token"),
+			}},
+			{Type: models.AzureDevops, Hostname: "dev.azure.com", Client: vcsmocks.NewMockClient()},
+		},
+		RepoAllowlistChecker: allowlist,
+		WebhookURL:           "https://atlantis.example.com/events",
+	}
+
+	checks := validator.Validate(logging.NewNoopLogger(t))
+	Equals(t, []events.VCSConfigCheck{
+		{Host: "Github", Status: events.VCSConfigCheckOK},
+		{Host: "Github", Repo: "owner/ok", Status: events.VCSConfigCheckOK},
+		{Host: "Github", Repo: "owner/missing", Status: events.VCSConfigCheckFailed, Message: "no webhook"},
+		{Host: "Github", Repo: "owner/admin-only", Status: events.VCSConfigCheckSkipped, Message: "webhooks can't be checked: needs admin"},
+		// The webhooks aren't checked when the credentials are invalid.
+		{Host: "Gitlab", Status: events.VCSConfigCheckFailed, Message: "invalid token"},
+		{Host: "AzureDevops", Status: events.VCSConfigCheckSkipped, Message: "the credentials of this host can't be checked"},
+	}, checks)
+	ErrEquals(t, "Github webhook of owner/missing: no webhook\nGitlab credentials: invalid token", events.FailedVCSConfigChecks(checks))
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// WebhookRegistrationFailed is the result of a WebhookRegistration that
+// failed. The others are vcs.WebhookCreated, vcs.WebhookUpdated and
+// vcs.WebhookUnchanged.
+const WebhookRegistrationFailed = "failed"
+
+// WebhookRegistration is the result of registering the webhook of a repo.
+type WebhookRegistration struct {
+	Repo   string `json:"repo"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WebhookRegistrar creates or updates the webhooks of the allowlisted repos
+// of an organization so they don't have to be set up by hand.
+type WebhookRegistrar struct {
+	Hosts                []VCSConfigHost
+	RepoAllowlistChecker *RepoAllowlistChecker
+	// WebhookURL is the URL the webhooks send events to.
+	WebhookURL string
+}
+
+// Register registers the webhooks of the repos of org on the VCS host
+// hostType, ex. "Github", that are allowlisted. See
+// vcs.WebhookRegistrar.RegisterWebhook for what updateSecrets and dryRun do.
+// It returns an error if the repos can't be listed, a repo whose webhook
+// can't be registered has a WebhookRegistrationFailed result.
+func (w *WebhookRegistrar) Register(logger logging.SimpleLogging, hostType string, org string, updateSecrets bool, dryRun bool) ([]WebhookRegistration, error) {
+	vcsHostType, err := models.NewVCSHostType(hostType)
+	if err != nil {
+		return nil, err
+	}
+	var host *VCSConfigHost
+	for i := range w.Hosts {
+		if w.Hosts[i].Type == vcsHostType {
+			host = &w.Hosts[i]
+		}
+	}
+	if host == nil {
+		return nil, fmt.Errorf("%s isn't configured", hostType)
+	}
+	registrar, ok := host.Client.(vcs.WebhookRegistrar)
+	if !ok {
+		return nil, fmt.Errorf("registering webhooks isn't supported on %s", hostType)
+	}
+
+	repos, err := registrar.ListOrgRepos(logger, org)
+	if err != nil {
+		return nil, err
+	}
+	registrations := []WebhookRegistration{}
+	for _, repo := range repos {
+		if w.RepoAllowlistChecker != nil && !w.RepoAllowlistChecker.IsAllowlisted(repo, host.Hostname) {
+			continue
+		}
+		registration := WebhookRegistration{Repo: repo}
+		registration.Result, err = registrar.RegisterWebhook(logger, repo, w.WebhookURL, host.WebhookSecret, updateSecrets, dryRun)
+		if err != nil {
+			registration.Result, registration.Error = WebhookRegistrationFailed, err.Error()
+			logger.Warn("unable to register the webhook of %s: %s", repo, err)
+		} else if !dryRun && registration.Result != vcs.WebhookUnchanged {
+			logger.Info("%s the webhook of %s", registration.Result, repo)
+		}
+		registrations = append(registrations, registration)
+	}
+	return registrations, nil
+}
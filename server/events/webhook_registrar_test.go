@@ -0,0 +1,70 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// registeringClient is a VCS client that can register webhooks.
+type registeringClient struct {
+	*vcsmocks.MockClient
+	repos      []string
+	results    map[string]string
+	registered map[string]string
+}
+
+func (c *registeringClient) ListOrgRepos(_ logging.SimpleLogging, _ string) ([]string, error) {
+	return c.repos, nil
+}
+
+func (c *registeringClient) RegisterWebhook(_ logging.SimpleLogging, repoFullName string, url string, secret string, _ bool, _ bool) (string, error) {
+	result, ok := c.results[repoFullName]
+	if !ok {
+		return "", errors.New("403 Forbidden")
+	}
+	c.registered[repoFullName] = url + " " + secret
+	return result, nil
+}
+
+func TestWebhookRegistrar_Register(t *testing.T) {
+	allowlist, err := events.NewRepoAllowlistChecker("github.com/owner/*,!github.com/owner/omitted")
+	Ok(t, err)
+	client := &registeringClient{
+		MockClient: vcsmocks.NewMockClient(),
+		repos:      []string{"owner/new", "owner/old", "owner/omitted", "owner/forbidden"},
+		results:    map[string]string{"owner/new": vcs.WebhookCreated, "owner/old": vcs.WebhookUnchanged, "owner/omitted": vcs.WebhookCreated},
+		registered: map[string]string{},
+	}
+	registrar := events.WebhookRegistrar{
+		Hosts: []events.VCSConfigHost{
+			{Type: models.Github, Hostname: "github.com", Client: client, WebhookSecret: "s3cr3t"},
+			{Type: models.AzureDevops, Hostname: "dev.azure.com", Client: vcsmocks.NewMockClient()},
+		},
+		RepoAllowlistChecker: allowlist,
+		WebhookURL:           "https://atlantis.example.com/events",
+	}
+	logger := logging.NewNoopLogger(t)
+
+	registrations, err := registrar.Register(logger, "Github", "owner", false, false)
+	Ok(t, err)
+	Equals(t, []events.WebhookRegistration{
+		{Repo: "owner/new", Result: vcs.WebhookCreated},
+		{Repo: "owner/old", Result: vcs.WebhookUnchanged},
+		{Repo: "owner/forbidden", Result: events.WebhookRegistrationFailed, Error: "403 Forbidden"},
+	}, registrations)
+	Equals(t, "https://atlantis.example.com/events s3cr3t", client.registered["owner/new"])
+	_, ok := client.registered["owner/omitted"]
+	Assert(t, !ok, "expected the webhook of a repo that isn't allowlisted not to be registered")
+
+	_, err = registrar.Register(logger, "AzureDevops", "org", false, false)
+	ErrEquals(t, "registering webhooks isn't supported on AzureDevops", err)
+	_, err = registrar.Register(logger, "Gitlab", "group", false, false)
+	ErrEquals(t, "Gitlab isn't configured", err)
+}
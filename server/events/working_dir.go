@@ -45,6 +45,11 @@ type WorkingDir interface {
 	// MergeAgain merges again with upstream if upstream has been modified, returns
 	// whether it actually did a new merge
 	MergeAgain(logger logging.SimpleLogging, headRepo models.Repo, p models.PullRequest, workspace string) (bool, error)
+	// ResetIfHistoryRewritten deletes the workspaces of the pull request, and
+	// the plans in them, if its branch was force-pushed so that the commit
+	// they have checked out isn't part of its history anymore. It returns
+	// whether they were deleted.
+	ResetIfHistoryRewritten(logger logging.SimpleLogging, headRepo models.Repo, p models.PullRequest) (bool, error)
 	// GetWorkingDir returns the path to the workspace for this repo and pull.
 	// If workspace does not exist on disk, error will be of type os.IsNotExist.
 	GetWorkingDir(r models.Repo, p models.PullRequest, workspace string) (string, error)
@@ -254,6 +259,89 @@ func (w *FileWorkspace) HasDiverged(logger logging.SimpleLogging, cloneDir strin
 	return hasDiverged
 }
 
+// ResetIfHistoryRewritten deletes the workspaces of the pull request if its
+// branch was force-pushed. A workspace whose checked out commit is an
+// ancestor of the new head commit was fast-forwarded so it's kept, and Clone
+// updates it as usual. Otherwise its plans were made for commits that were
+// rewritten, so all the workspaces are deleted and the next Clone recreates
+// them from scratch.
+// If we can't tell, we assume the branch wasn't force-pushed since Clone
+// re-clones workspaces that aren't at the right commit anyway.
+func (w *FileWorkspace) ResetIfHistoryRewritten(logger logging.SimpleLogging, headRepo models.Repo, p models.PullRequest) (bool, error) {
+	pullDir := w.repoPullDir(p.BaseRepo, p)
+	entries, err := os.ReadDir(pullDir)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "listing workspaces in '%s'", pullDir)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		c := wrappedGitContext{filepath.Join(pullDir, entry.Name()), headRepo, p}
+		if w.historyRewritten(logger, c) {
+			logger.Info("branch %q was force-pushed, deleting the workspaces and plans of the pull request", p.HeadBranch)
+			return true, w.Delete(logger, p.BaseRepo, p)
+		}
+	}
+	return false, nil
+}
+
+// historyRewritten returns true if the pull request's commit checked out in
+// the workspace at c.dir isn't an ancestor of its head commit anymore.
+func (w *FileWorkspace) historyRewritten(logger logging.SimpleLogging, c wrappedGitContext) bool {
+	value, _ := cloneLocks.LoadOrStore(c.dir, new(sync.Mutex))
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	pullHead := "HEAD"
+	if w.CheckoutMerge {
+		pullHead = "HEAD^2"
+	}
+	revParseCmd := exec.Command("git", "rev-parse", pullHead) // #nosec
+	revParseCmd.Dir = c.dir
+	output, err := revParseCmd.CombinedOutput()
+	if err != nil {
+		logger.Debug("not checking if %q was force-pushed, could not determine the commit in '%s': %s", c.pr.HeadBranch, c.dir, string(output))
+		return false
+	}
+	currCommit := strings.TrimSpace(string(output))
+	if strings.HasPrefix(currCommit, c.pr.HeadCommit) {
+		return false
+	}
+
+	// Fetch the new head commit the same way it's fetched when cloning. Its
+	// history is fetched down to the commits the workspace already has.
+	fetchRemote, fetchRef := "origin", c.pr.HeadBranch
+	if w.CheckoutMerge {
+		fetchRemote, fetchRef = "head", fmt.Sprintf("+refs/heads/%s:", c.pr.HeadBranch)
+		if w.GithubAppEnabled {
+			fetchRemote, fetchRef = "origin", fmt.Sprintf("pull/%d/head:", c.pr.Num)
+		}
+	}
+	if err := w.wrappedGit(logger, c, "fetch", fetchRemote, fetchRef); err != nil {
+		logger.Warn("not checking if %q was force-pushed, fetching it failed: %s", c.pr.HeadBranch, err)
+		return false
+	}
+
+	// merge-base --is-ancestor exits with 1 if the commit isn't an ancestor
+	// and with other codes if it fails.
+	isAncestorCmd := exec.Command("git", "merge-base", "--is-ancestor", currCommit, "FETCH_HEAD") // #nosec
+	isAncestorCmd.Dir = c.dir
+	output, err = isAncestorCmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true
+	}
+	if err != nil {
+		logger.Warn("not checking if %q was force-pushed, comparing commits failed: %s", c.pr.HeadBranch, string(output))
+	}
+	return false
+}
+
 func (w *FileWorkspace) forceClone(logger logging.SimpleLogging, c wrappedGitContext) error {
 	err := os.RemoveAll(c.dir)
 	if err != nil {
@@ -413,6 +501,11 @@ func (w *FileWorkspace) GetPullDir(r models.Repo, p models.PullRequest) (string,
 func (w *FileWorkspace) Delete(logger logging.SimpleLogging, r models.Repo, p models.PullRequest) error {
 	repoPullDir := w.repoPullDir(r, p)
 	logger.Info("Deleting repo pull directory: " + repoPullDir)
+	// The modules and providers shared between the workspaces of the pull
+	// request, if --share-workspace-init is set.
+	if err := os.RemoveAll(filepath.Join(w.DataDir, runtime.SharedInitDirName, r.FullName, strconv.Itoa(p.Num))); err != nil {
+		return err
+	}
 	return os.RemoveAll(repoPullDir)
 }
 
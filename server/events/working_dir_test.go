@@ -430,6 +430,56 @@ func TestClone_RecloneWrongCommit(t *testing.T) {
 	Equals(t, expCommit, actCommit)
 }
 
+// Test that the workspaces are only reset if the branch was force-pushed.
+func TestResetIfHistoryRewritten(t *testing.T) {
+	repoDir := initRepo(t)
+	runCmd(t, repoDir, "git", "checkout", "branch")
+	runCmd(t, repoDir, "touch", "branch-file")
+	runCmd(t, repoDir, "git", "add", "branch-file")
+	runCmd(t, repoDir, "git", "commit", "-m", "branch-commit")
+	branchCommit := runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+
+	logger := logging.NewNoopLogger(t)
+	wd := &events.FileWorkspace{
+		DataDir:                     t.TempDir(),
+		CheckoutMerge:               false,
+		TestingOverrideHeadCloneURL: fmt.Sprintf("file://%s", repoDir),
+		GpgNoSigningEnabled:         true,
+	}
+	pull := models.PullRequest{HeadBranch: "branch", HeadCommit: branchCommit}
+	cloneDir, err := wd.Clone(logger, models.Repo{}, pull, "default")
+	Ok(t, err)
+	planFile := filepath.Join(cloneDir, "default.tfplan")
+	_, err = os.Create(planFile)
+	Ok(t, err)
+
+	// A new commit on the branch is a fast-forward so the plans are kept.
+	runCmd(t, repoDir, "touch", "another-file")
+	runCmd(t, repoDir, "git", "add", "another-file")
+	runCmd(t, repoDir, "git", "commit", "-m", "another-commit")
+	pull.HeadCommit = runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+	reset, err := wd.ResetIfHistoryRewritten(logger, models.Repo{}, pull)
+	Ok(t, err)
+	Assert(t, !reset, "expected a fast-forward not to reset the workspaces")
+	assert.FileExists(t, planFile)
+
+	// Rewriting the branch's history deletes the workspaces and their plans.
+	runCmd(t, repoDir, "git", "reset", "--hard", "main")
+	runCmd(t, repoDir, "touch", "rewritten-file")
+	runCmd(t, repoDir, "git", "add", "rewritten-file")
+	runCmd(t, repoDir, "git", "commit", "-m", "rewritten-commit")
+	pull.HeadCommit = runCmd(t, repoDir, "git", "rev-parse", "HEAD")
+	reset, err = wd.ResetIfHistoryRewritten(logger, models.Repo{}, pull)
+	Ok(t, err)
+	Assert(t, reset, "expected a force-push to reset the workspaces")
+	assert.NoDirExists(t, cloneDir)
+
+	// There's nothing to reset once the workspaces are gone.
+	reset, err = wd.ResetIfHistoryRewritten(logger, models.Repo{}, pull)
+	Ok(t, err)
+	Assert(t, !reset, "expected no workspaces to reset")
+}
+
 // Test that if the branch we're merging into has diverged and we're using
 // checkout-strategy=merge, we actually merge the branch.
 // Also check that we do not merge if we are not using the merge strategy.
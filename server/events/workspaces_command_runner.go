@@ -0,0 +1,98 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+func NewWorkspacesCommandRunner(
+	pullUpdater *PullUpdater,
+	prjCmdBuilder ProjectWorkspacesCommandBuilder,
+	prjCmdRunner ProjectWorkspacesCommandRunner,
+	locker locking.Locker,
+	SilenceNoProjects bool,
+) *WorkspacesCommandRunner {
+	return &WorkspacesCommandRunner{
+		pullUpdater:       pullUpdater,
+		prjCmdBuilder:     prjCmdBuilder,
+		prjCmdRunner:      prjCmdRunner,
+		locker:            locker,
+		SilenceNoProjects: SilenceNoProjects,
+	}
+}
+
+// WorkspacesCommandRunner lists the terraform workspaces of projects and
+// which of them have an Atlantis lock or a plan for the pull request.
+type WorkspacesCommandRunner struct {
+	pullUpdater   *PullUpdater
+	prjCmdBuilder ProjectWorkspacesCommandBuilder
+	prjCmdRunner  ProjectWorkspacesCommandRunner
+	locker        locking.Locker
+	// SilenceNoProjects is whether Atlantis should respond to PRs if no projects
+	// are found
+	SilenceNoProjects bool
+}
+
+func (w *WorkspacesCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	projectCmds, err := w.prjCmdBuilder.BuildWorkspacesCommands(ctx, cmd)
+	if err != nil {
+		ctx.Log.Warn("Error %s", err)
+	}
+
+	if len(projectCmds) == 0 && w.SilenceNoProjects {
+		ctx.Log.Info("determined there was no project to list workspaces for.")
+		return
+	}
+
+	// Projects in the same directory only differ by their workspace so
+	// listing the workspaces of one of them is enough.
+	var dirCmds []command.ProjectContext
+	seenDirs := make(map[string]bool)
+	for _, projectCmd := range projectCmds {
+		if seenDirs[projectCmd.RepoRelDir] {
+			continue
+		}
+		seenDirs[projectCmd.RepoRelDir] = true
+		dirCmds = append(dirCmds, projectCmd)
+	}
+
+	result := runProjectCmds(dirCmds, w.prjCmdRunner.Workspaces)
+	w.addLocksAndPlans(ctx, result)
+	w.pullUpdater.updatePull(ctx, cmd, result)
+}
+
+// addLocksAndPlans sets the Atlantis locks and the plans of the pull request
+// on the workspaces of result.
+func (w *WorkspacesCommandRunner) addLocksAndPlans(ctx *command.Context, result command.Result) {
+	locks, err := w.locker.List()
+	if err != nil {
+		// The workspaces are still useful without their locks.
+		ctx.Log.Warn("unable to list locks: %s", err)
+	}
+
+	for _, projectResult := range result.ProjectResults {
+		if projectResult.WorkspacesSuccess == nil {
+			continue
+		}
+		for i := range projectResult.WorkspacesSuccess.Workspaces {
+			ws := &projectResult.WorkspacesSuccess.Workspaces[i]
+			for _, lock := range locks {
+				if lock.Project.RepoFullName == ctx.Pull.BaseRepo.FullName && lock.Project.Path == projectResult.RepoRelDir && lock.Workspace == ws.Name {
+					ws.LockedBy = lock.Pull.Num
+				}
+			}
+			if ctx.PullStatus == nil {
+				continue
+			}
+			for _, project := range ctx.PullStatus.Projects {
+				if project.RepoRelDir == projectResult.RepoRelDir && project.Workspace == ws.Name {
+					if ws.Plans == nil {
+						ws.Plans = make(map[string]models.ProjectPlanStatus)
+					}
+					ws.Plans[project.ProjectName] = project.Status
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestWorkspacesCommandRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	RegisterMockTestingT(t)
+
+	tests := []struct {
+		name         string
+		silenced     bool
+		projectCmds  []command.ProjectContext
+		expRuns      int
+		expContains  []string
+		expNoComment bool
+	}{
+		{
+			name: "workspaces with lock of this pull request",
+			projectCmds: []command.ProjectContext{
+				{RepoRelDir: "dir", Workspace: "default"},
+			},
+			expRuns: 1,
+			expContains: []string{
+				"| `default` |  | |",
+				"| `staging` | this pull request | |",
+			},
+		},
+		{
+			name: "projects in the same directory are listed once",
+			projectCmds: []command.ProjectContext{
+				{RepoRelDir: "dir", Workspace: "default"},
+				{RepoRelDir: "dir", Workspace: "staging"},
+			},
+			expRuns: 1,
+			expContains: []string{
+				"| `staging` | this pull request | |",
+			},
+		},
+		{
+			name:         "no comment with zero projects and silencing",
+			projectCmds:  []command.ProjectContext{},
+			silenced:     true,
+			expNoComment: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcsClient := setup(t, func(tc *TestConfig) {
+				tc.SilenceNoProjects = tt.silenced
+			})
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logger,
+				Scope:    scopeNull,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			cmd := &events.CommentCommand{Name: command.Workspaces}
+
+			When(projectCommandBuilder.BuildWorkspacesCommands(ctx, cmd)).ThenReturn(tt.projectCmds, nil)
+			When(projectCommandRunner.Workspaces(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+				RepoRelDir: "dir",
+				WorkspacesSuccess: &models.WorkspacesSuccess{
+					Workspaces: []models.WorkspaceStatus{{Name: "default"}, {Name: "staging"}},
+				},
+			})
+			When(lockingLocker.List()).ThenReturn(map[string]models.ProjectLock{
+				"lock": {
+					Project:   models.NewProject(testdata.GithubRepo.FullName, "dir", ""),
+					Workspace: "staging",
+					Pull:      modelPull,
+				},
+			}, nil)
+
+			workspacesCommandRunner.Run(ctx, cmd)
+
+			projectCommandRunner.VerifyWasCalled(Times(tt.expRuns)).Workspaces(Any[command.ProjectContext]())
+			if tt.expNoComment {
+				vcsClient.VerifyWasCalled(Never()).CreateComment(
+					Any[logging.SimpleLogging](), Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+				return
+			}
+			_, _, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(
+				Any[logging.SimpleLogging](), Eq(testdata.GithubRepo), Eq(modelPull.Num), Any[string](), Eq("workspaces")).GetCapturedArguments()
+			for _, exp := range tt.expContains {
+				Assert(t, strings.Contains(comment, exp), "expected comment to contain %q, got %q", exp, comment)
+			}
+		})
+	}
+}
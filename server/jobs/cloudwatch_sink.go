@@ -0,0 +1,245 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// cloudWatchMaxEvents and cloudWatchMaxBytes are the limits of a
+	// PutLogEvents request. Each event counts for its message plus 26 bytes.
+	cloudWatchMaxEvents = 10000
+	cloudWatchMaxBytes  = 1048576
+	cloudWatchEventSize = 26
+)
+
+// CloudWatchSink sends job output to AWS CloudWatch Logs. Each job is a log
+// stream named <repo>/<pull>/<job id>, and each line is a JSON event with the
+// labels of the job so they can be queried with Logs Insights. Requests are
+// signed with the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables of the server.
+type CloudWatchSink struct {
+	// LogGroup must already exist.
+	LogGroup string
+	Region   string
+	// Endpoint overrides the CloudWatch Logs endpoint. Used in tests.
+	Endpoint   string
+	HTTPClient *http.Client
+
+	mu sync.Mutex
+	// streams are the log streams that were created.
+	streams map[string]bool
+}
+
+type cloudWatchEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// cloudWatchError is the body of failed CloudWatch Logs requests.
+type cloudWatchError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (e cloudWatchError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (s *CloudWatchSink) Name() string {
+	return "CloudWatch Logs"
+}
+
+func (s *CloudWatchSink) Write(lines []LogSinkLine) error {
+	var streamNames []string
+	events := map[string][]cloudWatchEvent{}
+	for _, line := range lines {
+		streamName := fmt.Sprintf("%s/%d/%s", line.JobInfo.RepoFullName, line.JobInfo.PullNum, line.JobID)
+		if _, ok := events[streamName]; !ok {
+			streamNames = append(streamNames, streamName)
+		}
+		fields := line.labels()
+		fields["job_id"] = line.JobID
+		fields["line"] = line.Line
+		message, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		events[streamName] = append(events[streamName], cloudWatchEvent{Timestamp: line.Time.UnixMilli(), Message: string(message)})
+	}
+
+	for _, streamName := range streamNames {
+		if err := s.createStream(streamName); err != nil {
+			return errors.Wrapf(err, "creating log stream %s", streamName)
+		}
+		streamEvents := events[streamName]
+		for len(streamEvents) > 0 {
+			n, size := 0, 0
+			for n < len(streamEvents) && n < cloudWatchMaxEvents && size+len(streamEvents[n].Message)+cloudWatchEventSize <= cloudWatchMaxBytes {
+				size += len(streamEvents[n].Message) + cloudWatchEventSize
+				n++
+			}
+			if n == 0 {
+				// A single event over the limit is rejected on its own.
+				n = 1
+			}
+			err := s.call("PutLogEvents", map[string]interface{}{
+				"logGroupName":  s.LogGroup,
+				"logStreamName": streamName,
+				"logEvents":     streamEvents[:n],
+			})
+			if err != nil {
+				return errors.Wrapf(err, "putting events in log stream %s", streamName)
+			}
+			streamEvents = streamEvents[n:]
+		}
+	}
+	return nil
+}
+
+// createStream creates the log stream unless it was already created.
+func (s *CloudWatchSink) createStream(streamName string) error {
+	s.mu.Lock()
+	created := s.streams[streamName]
+	s.mu.Unlock()
+	if created {
+		return nil
+	}
+	err := s.call("CreateLogStream", map[string]string{
+		"logGroupName":  s.LogGroup,
+		"logStreamName": streamName,
+	})
+	var cwErr cloudWatchError
+	if err != nil && !(errors.As(err, &cwErr) && strings.HasSuffix(cwErr.Type, "ResourceAlreadyExistsException")) {
+		return err
+	}
+	s.mu.Lock()
+	if s.streams == nil {
+		s.streams = map[string]bool{}
+	}
+	s.streams[streamName] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// call runs a CloudWatch Logs action.
+func (s *CloudWatchSink) call(action string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://logs.%s.amazonaws.com", s.Region)
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	signAWSRequest(req, body, creds, s.Region, "logs", time.Now())
+
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var cwErr cloudWatchError
+		if json.Unmarshal(respBody, &cwErr) == nil && cwErr.Type != "" {
+			return cwErr
+		}
+		return fmt.Errorf("%s returned %s", action, resp.Status)
+	}
+	return nil
+}
+
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// signAWSRequest signs req and all its headers with AWS Signature Version 4.
+// req must not have a query string.
+func signAWSRequest(req *http.Request, body []byte, creds awsCredentials, region string, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data)) // nolint: errcheck
+	return h.Sum(nil)
+}
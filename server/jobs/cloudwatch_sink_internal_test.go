@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Uses the get-vanilla example of the AWS Signature Version 4 test suite.
+func TestSignAWSRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	Ok(t, err)
+	signAWSRequest(req, nil, awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	Equals(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	Equals(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31", req.Header.Get("Authorization"))
+}
@@ -0,0 +1,83 @@
+package jobs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/jobs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCloudWatchSink_Write(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	type call struct {
+		Action string
+		Body   map[string]interface{}
+	}
+	var calls []call
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "application/x-amz-json-1.1", r.Header.Get("Content-Type"))
+		Equals(t, "token", r.Header.Get("X-Amz-Security-Token"))
+		Assert(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), "unexpected authorization %q", r.Header.Get("Authorization"))
+		Assert(t, strings.Contains(r.Header.Get("Authorization"), "/us-east-1/logs/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, "), "unexpected authorization %q", r.Header.Get("Authorization"))
+		c := call{Action: strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")}
+		Ok(t, json.NewDecoder(r.Body).Decode(&c.Body))
+		calls = append(calls, c)
+		// The stream of the second job already exists.
+		if c.Action == "CreateLogStream" && c.Body["logStreamName"] == "owner/repo/1/2" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceAlreadyExistsException","message":"The specified log stream already exists"}`)) // nolint: errcheck
+			return
+		}
+		w.Write([]byte(`{}`)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	sink := &jobs.CloudWatchSink{LogGroup: "atlantis", Region: "us-east-1", Endpoint: testServer.URL}
+	info := jobs.JobInfo{
+		PullInfo: jobs.PullInfo{PullNum: 1, RepoFullName: "owner/repo", ProjectName: "project"},
+		JobStep:  "apply",
+	}
+	Ok(t, sink.Write([]jobs.LogSinkLine{
+		{JobID: "1", JobInfo: info, Time: time.UnixMilli(1), Line: "line 1"},
+		{JobID: "2", JobInfo: info, Time: time.UnixMilli(2), Line: "other job"},
+	}))
+	// Streams are only created once.
+	Ok(t, sink.Write([]jobs.LogSinkLine{
+		{JobID: "1", JobInfo: info, Time: time.UnixMilli(3), Line: "line 2"},
+	}))
+
+	event := func(timestamp float64, jobID string, line string) interface{} {
+		return map[string]interface{}{
+			"timestamp": timestamp,
+			"message":   `{"job_id":"` + jobID + `","line":"` + line + `","project":"project","pull":"1","repo":"owner/repo","step":"apply"}`,
+		}
+	}
+	Equals(t, []call{
+		{"CreateLogStream", map[string]interface{}{"logGroupName": "atlantis", "logStreamName": "owner/repo/1/1"}},
+		{"PutLogEvents", map[string]interface{}{"logGroupName": "atlantis", "logStreamName": "owner/repo/1/1", "logEvents": []interface{}{event(1, "1", "line 1")}}},
+		{"CreateLogStream", map[string]interface{}{"logGroupName": "atlantis", "logStreamName": "owner/repo/1/2"}},
+		{"PutLogEvents", map[string]interface{}{"logGroupName": "atlantis", "logStreamName": "owner/repo/1/2", "logEvents": []interface{}{event(2, "2", "other job")}}},
+		{"PutLogEvents", map[string]interface{}{"logGroupName": "atlantis", "logStreamName": "owner/repo/1/1", "logEvents": []interface{}{event(3, "1", "line 2")}}},
+	}, calls)
+}
+
+func TestCloudWatchSink_WriteErr(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.logs#ResourceNotFoundException","message":"The specified log group does not exist."}`)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	sink := &jobs.CloudWatchSink{LogGroup: "atlantis", Region: "us-east-1", Endpoint: testServer.URL}
+	err := sink.Write([]jobs.LogSinkLine{{JobID: "1", JobInfo: jobs.JobInfo{PullInfo: jobs.PullInfo{PullNum: 1, RepoFullName: "owner/repo"}}, Time: time.Now(), Line: "line"}})
+	ErrEquals(t, "creating log stream owner/repo/1/1: com.amazonaws.logs#ResourceNotFoundException: The specified log group does not exist.", err)
+}
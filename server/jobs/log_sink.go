@@ -0,0 +1,163 @@
+package jobs
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// sinkFlushInterval is how often job output is sent to log sinks.
+	sinkFlushInterval = time.Second
+	// sinkBatchSize is the number of lines that are sent to log sinks
+	// without waiting for the flush interval.
+	sinkBatchSize = 1000
+	// sinkQueueSize bounds the lines waiting to be sent. When sinks can't
+	// keep up, lines are dropped rather than slowing down jobs.
+	sinkQueueSize = 10000
+)
+
+// LogSinkLine is a line of job output sent to log sinks.
+type LogSinkLine struct {
+	JobID   string
+	JobInfo JobInfo
+	Time    time.Time
+	Line    string
+}
+
+// labels identifies the job of the line. Empty labels are omitted.
+func (l LogSinkLine) labels() map[string]string {
+	labels := map[string]string{
+		"repo":      l.JobInfo.RepoFullName,
+		"pull":      strconv.Itoa(l.JobInfo.PullNum),
+		"project":   l.JobInfo.ProjectName,
+		"dir":       l.JobInfo.Path,
+		"workspace": l.JobInfo.Workspace,
+		"step":      l.JobInfo.JobStep,
+	}
+	for k, v := range labels {
+		if v == "" {
+			delete(labels, k)
+		}
+	}
+	return labels
+}
+
+// LogSink retains job output in an external logging system.
+type LogSink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Write sends lines, which can be from different jobs, to the sink.
+	Write(lines []LogSinkLine) error
+}
+
+// SinkingProjectOutputHandler also sends job output to log sinks, in batches
+// so that jobs don't wait for them.
+type SinkingProjectOutputHandler struct {
+	ProjectCommandOutputHandler
+	sinks  []LogSink
+	lines  chan LogSinkLine
+	logger logging.SimpleLogging
+}
+
+func NewSinkingProjectOutputHandler(handler ProjectCommandOutputHandler, sinks []LogSink, logger logging.SimpleLogging) *SinkingProjectOutputHandler {
+	return &SinkingProjectOutputHandler{
+		ProjectCommandOutputHandler: handler,
+		sinks:                       sinks,
+		lines:                       make(chan LogSinkLine, sinkQueueSize),
+		logger:                      logger,
+	}
+}
+
+func (p *SinkingProjectOutputHandler) Send(ctx command.ProjectContext, msg string, operationComplete bool) {
+	p.ProjectCommandOutputHandler.Send(ctx, msg, operationComplete)
+	if operationComplete {
+		return
+	}
+	p.enqueue(LogSinkLine{
+		JobID: ctx.JobID,
+		JobInfo: JobInfo{
+			HeadCommit: ctx.Pull.HeadCommit,
+			PullInfo: PullInfo{
+				PullNum:      ctx.Pull.Num,
+				Repo:         ctx.BaseRepo.Name,
+				RepoFullName: ctx.BaseRepo.FullName,
+				ProjectName:  ctx.ProjectName,
+				Path:         ctx.RepoRelDir,
+				Workspace:    ctx.Workspace,
+			},
+			JobStep: ctx.CommandName.String(),
+		},
+		Time: time.Now(),
+		Line: msg,
+	})
+}
+
+func (p *SinkingProjectOutputHandler) SendWorkflowHook(ctx models.WorkflowHookCommandContext, msg string, operationComplete bool) {
+	p.ProjectCommandOutputHandler.SendWorkflowHook(ctx, msg, operationComplete)
+	if operationComplete {
+		return
+	}
+	p.enqueue(LogSinkLine{
+		JobID: ctx.HookID,
+		JobInfo: JobInfo{
+			HeadCommit: ctx.Pull.HeadCommit,
+			PullInfo: PullInfo{
+				PullNum:      ctx.Pull.Num,
+				Repo:         ctx.BaseRepo.Name,
+				RepoFullName: ctx.BaseRepo.FullName,
+			},
+			JobDescription: ctx.HookDescription,
+			JobStep:        ctx.HookStepName,
+		},
+		Time: time.Now(),
+		Line: msg,
+	})
+}
+
+// Handle sends the queued lines to the sinks while the wrapped handler
+// handles the output.
+func (p *SinkingProjectOutputHandler) Handle() {
+	go p.flushLoop()
+	p.ProjectCommandOutputHandler.Handle()
+}
+
+func (p *SinkingProjectOutputHandler) enqueue(line LogSinkLine) {
+	select {
+	case p.lines <- line:
+	default:
+		p.logger.Warn("dropping output of job %s, the log sinks aren't keeping up", line.JobID)
+	}
+}
+
+func (p *SinkingProjectOutputHandler) flushLoop() {
+	ticker := time.NewTicker(sinkFlushInterval)
+	defer ticker.Stop()
+	var batch []LogSinkLine
+	for {
+		select {
+		case line := <-p.lines:
+			batch = append(batch, line)
+			if len(batch) < sinkBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		p.flush(batch)
+		batch = nil
+	}
+}
+
+func (p *SinkingProjectOutputHandler) flush(batch []LogSinkLine) {
+	for _, sink := range p.sinks {
+		if err := sink.Write(batch); err != nil {
+			p.logger.Warn("unable to send %d lines of job output to %s: %s", len(batch), sink.Name(), err)
+		}
+	}
+}
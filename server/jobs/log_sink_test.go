@@ -0,0 +1,71 @@
+package jobs_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLogSink struct {
+	mu    sync.Mutex
+	lines []jobs.LogSinkLine
+}
+
+func (s *fakeLogSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeLogSink) Write(lines []jobs.LogSinkLine) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, lines...)
+	return nil
+}
+
+func (s *fakeLogSink) Lines() []jobs.LogSinkLine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lines
+}
+
+func TestSinkingProjectOutputHandler(t *testing.T) {
+	ctx := createTestProjectCmdContext(t)
+	ctx.BaseRepo.FullName = "test-org/test-repo"
+	ctx.CommandName = command.Plan
+	sink := &fakeLogSink{}
+	handler := jobs.NewSinkingProjectOutputHandler(createProjectCommandOutputHandler(t), []jobs.LogSink{sink}, logging.NewNoopLogger(t))
+	go handler.Handle()
+
+	handler.Send(ctx, "line 1", false)
+	handler.Send(ctx, "line 2", false)
+	handler.Send(ctx, "", true)
+
+	// The lines are still streamed to the job page.
+	assert.Eventually(t, func() bool { return handler.IsKeyExists(ctx.JobID) }, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(sink.Lines()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	lines := sink.Lines()
+	for i, line := range lines {
+		Equals(t, ctx.JobID, line.JobID)
+		Equals(t, jobs.JobInfo{
+			HeadCommit: "234r232432",
+			PullInfo: jobs.PullInfo{
+				PullNum:      1,
+				Repo:         "test-repo",
+				RepoFullName: "test-org/test-repo",
+				ProjectName:  "test-project",
+				Path:         "test-dir",
+				Workspace:    "myworkspace",
+			},
+			JobStep: "plan",
+		}, line.JobInfo)
+		Assert(t, !line.Time.IsZero(), "expected the time of line %d", i)
+	}
+	Equals(t, "line 1", lines[0].Line)
+	Equals(t, "line 2", lines[1].Line)
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LokiSink pushes job output to Grafana Loki. Each job's lines are a stream
+// labeled with the repo, pull request, project, dir, workspace and step of
+// the job.
+type LokiSink struct {
+	// URL is the base URL of Loki, ex. http://loki:3100. It can contain
+	// basic auth credentials.
+	URL string
+	// HTTPClient defaults to a client that times out after 10 seconds.
+	HTTPClient *http.Client
+}
+
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	// Values are pairs of nanosecond timestamps and lines.
+	Values [][2]string `json:"values"`
+}
+
+func (s *LokiSink) Name() string {
+	return "Loki"
+}
+
+func (s *LokiSink) Write(lines []LogSinkLine) error {
+	var push lokiPushRequest
+	streams := map[string]int{}
+	for _, line := range lines {
+		i, ok := streams[line.JobID]
+		if !ok {
+			labels := line.labels()
+			labels["source"] = "atlantis"
+			i = len(push.Streams)
+			streams[line.JobID] = i
+			push.Streams = append(push.Streams, lokiStream{Stream: labels})
+		}
+		push.Streams[i].Values = append(push.Streams[i].Values, [2]string{strconv.FormatInt(line.Time.UnixNano(), 10), line.Line})
+	}
+	body, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	url := strings.TrimRight(s.URL, "/") + "/loki/api/v1/push"
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST /loki/api/v1/push returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package jobs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/jobs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLokiSink_Write(t *testing.T) {
+	var got map[string]interface{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/loki/api/v1/push", r.URL.Path)
+		Equals(t, "application/json", r.Header.Get("Content-Type"))
+		user, password, ok := r.BasicAuth()
+		Assert(t, ok, "expected basic auth")
+		Equals(t, "user:pass", user+":"+password)
+		Ok(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	sink := &jobs.LokiSink{URL: "http://user:pass@" + testServer.Listener.Addr().String() + "/"}
+	plan := jobs.JobInfo{
+		PullInfo: jobs.PullInfo{PullNum: 1, RepoFullName: "owner/repo", Path: "dir", Workspace: "default"},
+		JobStep:  "plan",
+	}
+	hook := jobs.JobInfo{
+		PullInfo: jobs.PullInfo{PullNum: 1, RepoFullName: "owner/repo"},
+		JobStep:  "pre_workflow_hook",
+	}
+	Ok(t, sink.Write([]jobs.LogSinkLine{
+		{JobID: "1", JobInfo: plan, Time: time.Unix(0, 1), Line: "line 1"},
+		{JobID: "2", JobInfo: hook, Time: time.Unix(0, 2), Line: "hook line"},
+		{JobID: "1", JobInfo: plan, Time: time.Unix(0, 3), Line: "line 2"},
+	}))
+
+	Equals(t, map[string]interface{}{
+		"streams": []interface{}{
+			map[string]interface{}{
+				"stream": map[string]interface{}{"source": "atlantis", "repo": "owner/repo", "pull": "1", "dir": "dir", "workspace": "default", "step": "plan"},
+				"values": []interface{}{[]interface{}{"1", "line 1"}, []interface{}{"3", "line 2"}},
+			},
+			map[string]interface{}{
+				"stream": map[string]interface{}{"source": "atlantis", "repo": "owner/repo", "pull": "1", "step": "pre_workflow_hook"},
+				"values": []interface{}{[]interface{}{"2", "hook line"}},
+			},
+		},
+	}, got)
+}
+
+func TestLokiSink_WriteErr(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry too far behind", http.StatusBadRequest)
+	}))
+	defer testServer.Close()
+
+	sink := &jobs.LokiSink{URL: testServer.URL}
+	err := sink.Write([]jobs.LogSinkLine{{JobID: "1", Time: time.Now(), Line: "line"}})
+	ErrEquals(t, "POST /loki/api/v1/push returned 400 Bad Request: entry too far behind", err)
+}
@@ -349,3 +349,18 @@ func (p *NoopProjectOutputHandler) IsKeyExists(_ string) bool {
 func (p *NoopProjectOutputHandler) GetPullToJobMapping() []PullInfoWithJobIDs {
 	return []PullInfoWithJobIDs{}
 }
+
+// RedactingProjectOutputHandler masks secrets in job output before it's
+// buffered and streamed to the front end.
+type RedactingProjectOutputHandler struct {
+	ProjectCommandOutputHandler
+	Redactor *logging.Redactor
+}
+
+func (p *RedactingProjectOutputHandler) Send(ctx command.ProjectContext, msg string, operationComplete bool) {
+	p.ProjectCommandOutputHandler.Send(ctx, p.Redactor.Redact(msg), operationComplete)
+}
+
+func (p *RedactingProjectOutputHandler) SendWorkflowHook(ctx models.WorkflowHookCommandContext, msg string, operationComplete bool) {
+	p.ProjectCommandOutputHandler.SendWorkflowHook(ctx, p.Redactor.Redact(msg), operationComplete)
+}
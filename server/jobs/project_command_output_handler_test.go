@@ -5,9 +5,11 @@ import (
 	"testing"
 	"time"
 
+	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/jobs/mocks"
 	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 	"github.com/stretchr/testify/assert"
@@ -252,3 +254,23 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		assert.True(t, <-opComplete)
 	})
 }
+
+func TestRedactingProjectOutputHandler(t *testing.T) {
+	RegisterMockTestingT(t)
+	ctx := createTestProjectCmdContext(t)
+	r, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+
+	underlying := mocks.NewMockProjectCommandOutputHandler()
+	handler := &jobs.RedactingProjectOutputHandler{
+		ProjectCommandOutputHandler: underlying,
+		Redactor:                    r,
+	}
+
+	handler.Send(ctx, "export TOKEN=s3cr3t", false)
+	underlying.VerifyWasCalledOnce().Send(ctx, "export TOKEN=[REDACTED]", false)
+
+	hookCtx := models.WorkflowHookCommandContext{HookID: "hook"}
+	handler.SendWorkflowHook(hookCtx, "s3cr3t", true)
+	underlying.VerifyWasCalledOnce().SendWorkflowHook(hookCtx, "[REDACTED]", true)
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RecordedError is an error that was logged.
+type RecordedError struct {
+	Time    time.Time
+	Message string
+}
+
+// ErrorRecorder keeps the most recent errors logged so they can be included
+// in diagnostic bundles. A nil ErrorRecorder records nothing.
+type ErrorRecorder struct {
+	mu     sync.Mutex
+	size   int
+	errors []RecordedError
+}
+
+// NewErrorRecorder returns an ErrorRecorder that keeps the last size errors.
+func NewErrorRecorder(size int) *ErrorRecorder {
+	return &ErrorRecorder{size: size}
+}
+
+// Record keeps message, dropping the oldest error if size are already kept.
+func (r *ErrorRecorder) Record(t time.Time, message string) {
+	if r == nil || r.size <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errors) == r.size {
+		r.errors = r.errors[1:]
+	}
+	r.errors = append(r.errors, RecordedError{Time: t, Message: message})
+}
+
+// Recent returns the errors kept, oldest first.
+func (r *ErrorRecorder) Recent() []RecordedError {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedError(nil), r.errors...)
+}
+
+// WithErrorRecorder returns a copy of l that records the errors it logs in r.
+// Loggers other than StructuredLogger are returned unchanged. Wrap the logger
+// with WithRedactor afterwards so the errors are recorded redacted.
+func WithErrorRecorder(l SimpleLogging, r *ErrorRecorder) SimpleLogging {
+	sl, ok := l.(*StructuredLogger)
+	if !ok || r == nil {
+		return l
+	}
+	return &StructuredLogger{
+		z: sl.z.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return recordingCore{Core: c, recorder: r}
+		})),
+		level:       sl.level,
+		keepHistory: sl.keepHistory,
+		history:     sl.history,
+		redactor:    sl.redactor,
+	}
+}
+
+// recordingCore records the errors it writes.
+type recordingCore struct {
+	zapcore.Core
+	recorder *ErrorRecorder
+}
+
+func (c recordingCore) With(fields []zapcore.Field) zapcore.Core {
+	return recordingCore{Core: c.Core.With(fields), recorder: c.recorder}
+}
+
+func (c recordingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c recordingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level >= zapcore.ErrorLevel {
+		c.recorder.Record(ent.Time, ent.Message)
+	}
+	return c.Core.Write(ent, fields)
+}
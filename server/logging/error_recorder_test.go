@@ -0,0 +1,32 @@
+package logging_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestWithErrorRecorder(t *testing.T) {
+	recorder := logging.NewErrorRecorder(2)
+	redactor, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+	logger := logging.WithRedactor(logging.WithErrorRecorder(logging.NewNoopLogger(t), recorder), redactor)
+
+	logger.Info("not an error")
+	logger.Err("first")
+	logger.With("repo", "owner/repo").Err("second with %s", "s3cr3t")
+	logger.Err("third")
+
+	recent := recorder.Recent()
+	Equals(t, 2, len(recent))
+	Equals(t, "second with [REDACTED]", recent[0].Message)
+	Equals(t, "third", recent[1].Message)
+}
+
+func TestErrorRecorder_Nil(t *testing.T) {
+	var recorder *logging.ErrorRecorder
+	recorder.Record(time.Time{}, "ignored")
+	Equals(t, 0, len(recorder.Recent()))
+}
@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue is what secrets are replaced with.
+const RedactedValue = "[REDACTED]"
+
+// Redactor masks secrets in text before it leaves the server, ie. in logs,
+// job output and pull request comments. A nil Redactor masks nothing.
+type Redactor struct {
+	// mu guards values, which grow as secrets are read while running
+	// commands.
+	mu       sync.RWMutex
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor that masks every occurrence of values and
+// every match of patterns. If a pattern has capture groups, only the groups
+// are masked so that patterns like `password=(\S+)` keep their context.
+func NewRedactor(values []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	r.Add(values...)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling redaction pattern %q", p)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Add masks values from now on, ex. secrets read from Vault.
+func (r *Redactor) Add(values ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range values {
+		if v != "" && !slices.Contains(r.values, v) {
+			r.values = append(r.values, v)
+		}
+	}
+	// Replace longer values first in case one value contains another.
+	sort.SliceStable(r.values, func(i, j int) bool {
+		return len(r.values[i]) > len(r.values[j])
+	})
+}
+
+// Enabled returns true if r has anything to mask.
+func (r *Redactor) Enabled() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.values) > 0 || len(r.patterns) > 0
+}
+
+// Redact returns s with all secrets replaced by RedactedValue.
+func (r *Redactor) Redact(s string) string {
+	if !r.Enabled() {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, RedactedValue)
+	}
+	for _, re := range r.patterns {
+		s = redactPattern(re, s)
+	}
+	return s
+}
+
+func redactPattern(re *regexp.Regexp, s string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(s, RedactedValue)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		// m[0:2] is the whole match, the rest are the capture groups.
+		for i := 2; i+1 < len(m); i += 2 {
+			start, end := m[i], m[i+1]
+			if start < last || start == end {
+				// Unmatched optional group, or nested in a group we've masked.
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(RedactedValue)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// WithRedactor returns a copy of l that masks the secrets found by r in every
+// log message, including its history. Loggers other than StructuredLogger are
+// returned unchanged. r may have nothing to mask yet since values can be
+// added to it later.
+func WithRedactor(l SimpleLogging, r *Redactor) SimpleLogging {
+	sl, ok := l.(*StructuredLogger)
+	if !ok || r == nil {
+		return l
+	}
+	return &StructuredLogger{
+		z: sl.z.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return redactingCore{Core: c, redactor: r}
+		})),
+		level:       sl.level,
+		keepHistory: sl.keepHistory,
+		history:     sl.history,
+		redactor:    r,
+	}
+}
+
+// redactingCore masks secrets in log messages before they're written.
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func (c redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactingCore{Core: c.Core.With(fields), redactor: c.redactor}
+}
+
+func (c redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.Redact(ent.Message)
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"testing"
+
+	. "github.com/runatlantis/atlantis/testing"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithRedactor_MasksMessages(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := &StructuredLogger{
+		z:     zap.New(core).Sugar(),
+		level: zap.NewAtomicLevelAt(zap.DebugLevel),
+	}
+	r, err := NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+
+	WithRedactor(logger, r).With("repo", "owner/repo").Err("failed with %s", "s3cr3t")
+	entries := logs.All()
+	Equals(t, 1, len(entries))
+	Equals(t, "failed with [REDACTED]", entries[0].Message)
+	Equals(t, "owner/repo", entries[0].ContextMap()["repo"])
+}
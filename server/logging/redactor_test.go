@@ -0,0 +1,91 @@
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	cases := map[string]struct {
+		values   []string
+		patterns []string
+		input    string
+		exp      string
+	}{
+		"nothing configured": {
+			input: "token=abc",
+			exp:   "token=abc",
+		},
+		"values": {
+			values: []string{"s3cr3t", "", "another"},
+			input:  "key s3cr3t and another s3cr3t",
+			exp:    "key [REDACTED] and [REDACTED] [REDACTED]",
+		},
+		"longer values first": {
+			values: []string{"abc", "abcdef"},
+			input:  "abcdef abc",
+			exp:    "[REDACTED] [REDACTED]",
+		},
+		"pattern without groups": {
+			patterns: []string{`ghp_[A-Za-z0-9]{8}`},
+			input:    "token ghp_a1b2c3d4 used",
+			exp:      "token [REDACTED] used",
+		},
+		"pattern with groups": {
+			patterns: []string{`password=(\S+)`},
+			input:    "user=bob password=hunter2 password=x",
+			exp:      "user=bob password=[REDACTED] password=[REDACTED]",
+		},
+		"pattern with optional group": {
+			patterns: []string{`key=(\w+)?;`},
+			input:    "key=; key=abc;",
+			exp:      "key=; key=[REDACTED];",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := logging.NewRedactor(c.values, c.patterns)
+			Ok(t, err)
+			Equals(t, c.exp, r.Redact(c.input))
+		})
+	}
+}
+
+func TestRedactor_InvalidPattern(t *testing.T) {
+	_, err := logging.NewRedactor(nil, []string{"("})
+	ErrContains(t, `compiling redaction pattern "("`, err)
+}
+
+func TestRedactor_Nil(t *testing.T) {
+	var r *logging.Redactor
+	Assert(t, !r.Enabled(), "nil redactor shouldn't be enabled")
+	Equals(t, "secret", r.Redact("secret"))
+	r.Add("secret")
+}
+
+func TestRedactor_Add(t *testing.T) {
+	r, err := logging.NewRedactor([]string{"abc"}, nil)
+	Ok(t, err)
+	r.Add("abcdef", "", "abc")
+	Equals(t, "[REDACTED] [REDACTED]", r.Redact("abcdef abc"))
+
+	// Values added later are masked by loggers that had nothing to mask when
+	// they were created.
+	empty, err := logging.NewRedactor(nil, nil)
+	Ok(t, err)
+	logger := logging.WithRedactor(logging.NewNoopLogger(t), empty).WithHistory()
+	empty.Add("s3cr3t")
+	logger.Info("using %s", "s3cr3t")
+	Equals(t, "[INFO] using [REDACTED]\n", logger.GetHistory())
+}
+
+func TestWithRedactor_History(t *testing.T) {
+	r, err := logging.NewRedactor([]string{"s3cr3t"}, nil)
+	Ok(t, err)
+	logger := logging.WithRedactor(logging.NewNoopLogger(t), r).WithHistory()
+
+	logger.Info("using %s", "s3cr3t")
+	Equals(t, "[INFO] using [REDACTED]\n", logger.GetHistory())
+}
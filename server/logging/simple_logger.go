@@ -70,6 +70,9 @@ type StructuredLogger struct {
 	// gives us the ability to query our logs across multiple dimensions
 	// I don't believe we should mix this in with atlantis commands and expose this to the user
 	history bytes.Buffer
+	// redactor masks secrets in the history. Messages written by z are
+	// masked by its core, see WithRedactor.
+	redactor *Redactor
 }
 
 func NewStructuredLoggerFromLevel(lvl LogLevel) (SimpleLogging, error) {
@@ -108,15 +111,17 @@ func newStructuredLogger(cfg zap.Config) (*StructuredLogger, error) {
 
 func (l *StructuredLogger) With(a ...interface{}) SimpleLogging {
 	return &StructuredLogger{
-		z:     l.z.With(a...),
-		level: l.level,
+		z:        l.z.With(a...),
+		level:    l.level,
+		redactor: l.redactor,
 	}
 }
 
 func (l *StructuredLogger) WithHistory(a ...interface{}) SimpleLogging {
 	logger := &StructuredLogger{
-		z:     l.z.With(a...),
-		level: l.level,
+		z:        l.z.With(a...),
+		level:    l.level,
+		redactor: l.redactor,
 	}
 
 	// ensure that the history is kept across loggers.
@@ -177,7 +182,7 @@ func (l *StructuredLogger) saveToHistory(lvl LogLevel, format string, a ...inter
 	if !l.keepHistory {
 		return
 	}
-	msg := fmt.Sprintf(format, a...)
+	msg := l.redactor.Redact(fmt.Sprintf(format, a...))
 	l.history.WriteString(fmt.Sprintf("[%s] %s\n", lvl.shortStr, msg))
 }
 
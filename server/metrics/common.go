@@ -5,4 +5,5 @@ const (
 	ExecutionSuccessMetric = "execution_success"
 	ExecutionErrorMetric   = "execution_error"
 	ExecutionFailureMetric = "execution_failure"
+	RateLimitedMetric      = "rate_limited"
 )
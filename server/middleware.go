@@ -14,9 +14,13 @@
 package server
 
 import (
+	"crypto/subtle"
+	"crypto/x509"
 	"net/http"
+	"path"
 	"strings"
 
+	"github.com/runatlantis/atlantis/server/core/ipallowlist"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/urfave/negroni/v3"
 )
@@ -54,7 +58,8 @@ func (l *RequestLogger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 		user, pass, ok := r.BasicAuth()
 		if ok {
 			r.SetBasicAuth(user, pass)
-			if user == l.WebUsername && pass == l.WebPassword {
+			if subtle.ConstantTimeCompare([]byte(user), []byte(l.WebUsername)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(pass), []byte(l.WebPassword)) == 1 {
 				l.logger.Debug("[VALID] log in: >> url: %s", r.URL.RequestURI())
 				allowed = true
 			} else {
@@ -71,3 +76,106 @@ func (l *RequestLogger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 	}
 	l.logger.Debug("%s %s – respond HTTP %d", r.Method, r.URL.RequestURI(), rw.(negroni.ResponseWriter).Status())
 }
+
+// ClientCertAuth requires requests to present a client certificate verified
+// against the client CAs of the TLS listener, for environments where webhook
+// secrets aren't considered sufficient. The TLS listener only verifies
+// certificates that are given so that health checks keep working.
+type ClientCertAuth struct {
+	logger logging.SimpleLogging
+	// AllPaths requires a certificate for every path except /healthz instead
+	// of only the webhook, Slack and API endpoints.
+	AllPaths bool
+	// SANAllowlist, if not empty, are the patterns one of the DNS, URI, email
+	// or IP subject alternative names of the certificate must match, ex.
+	// *.example.com or spiffe://example.com/ns/ci/*.
+	SANAllowlist []string
+}
+
+// NewClientCertAuth creates a ClientCertAuth.
+func NewClientCertAuth(s *Server) *ClientCertAuth {
+	return &ClientCertAuth{
+		logger:       s.Logger,
+		AllPaths:     s.SSLClientAuthAllPaths,
+		SANAllowlist: s.SSLClientSANAllowlist,
+	}
+}
+
+// ServeHTTP implements the middleware function.
+func (c *ClientCertAuth) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !c.requiresCert(r.URL.Path) {
+		next(rw, r)
+		return
+	}
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		c.logger.Info("rejected %s %s from %s: no verified client certificate", r.Method, r.URL.Path, r.RemoteAddr)
+		http.Error(rw, "Client certificate required", http.StatusUnauthorized)
+		return
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	if !c.sanAllowed(leaf) {
+		c.logger.Info("rejected %s %s from %s: client certificate %q doesn't match the SAN allowlist", r.Method, r.URL.Path, r.RemoteAddr, leaf.Subject.String())
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+	next(rw, r)
+}
+
+func (c *ClientCertAuth) requiresCert(urlPath string) bool {
+	if urlPath == "/healthz" {
+		return false
+	}
+	return c.AllPaths || urlPath == "/events" || urlPath == "/slack/commands" || strings.HasPrefix(urlPath, "/api/")
+}
+
+func (c *ClientCertAuth) sanAllowed(cert *x509.Certificate) bool {
+	if len(c.SANAllowlist) == 0 {
+		return true
+	}
+	sans := append([]string{}, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, pattern := range c.SANAllowlist {
+		for _, san := range sans {
+			if ok, _ := path.Match(pattern, san); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EventsIPAllowlist rejects webhooks to /events from IPs that aren't in the
+// allowlist.
+type EventsIPAllowlist struct {
+	logger    logging.SimpleLogging
+	Allowlist *ipallowlist.Allowlist
+}
+
+// NewEventsIPAllowlist creates an EventsIPAllowlist.
+func NewEventsIPAllowlist(s *Server) *EventsIPAllowlist {
+	return &EventsIPAllowlist{
+		logger:    s.Logger,
+		Allowlist: s.EventsIPAllowlist,
+	}
+}
+
+// ServeHTTP implements the middleware function.
+func (e *EventsIPAllowlist) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL.Path != "/events" {
+		next(rw, r)
+		return
+	}
+	ip := e.Allowlist.ClientIP(r)
+	if !e.Allowlist.Contains(ip) {
+		e.logger.Info("rejected %s %s from %s: %s isn't in the IP allowlist", r.Method, r.URL.Path, r.RemoteAddr, ip)
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+	next(rw, r)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/ipallowlist"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestClientCertAuth(t *testing.T) {
+	spiffeID, err := url.Parse("spiffe://example.com/ns/ci/sa/webhooks")
+	Ok(t, err)
+	ciCert := &x509.Certificate{URIs: []*url.URL{spiffeID}}
+	hostCert := &x509.Certificate{DNSNames: []string{"proxy.example.com"}, IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}}
+	otherCert := &x509.Certificate{DNSNames: []string{"proxy.example.org"}}
+
+	cases := map[string]struct {
+		allPaths  bool
+		allowlist []string
+		path      string
+		cert      *x509.Certificate
+		expStatus int
+	}{
+		"webhook without certificate": {
+			path:      "/events",
+			expStatus: http.StatusUnauthorized,
+		},
+		"slack without certificate": {
+			path:      "/slack/commands",
+			expStatus: http.StatusUnauthorized,
+		},
+		"api without certificate": {
+			path:      "/api/plan",
+			expStatus: http.StatusUnauthorized,
+		},
+		"ui without certificate": {
+			path:      "/",
+			expStatus: http.StatusOK,
+		},
+		"ui without certificate for all paths": {
+			allPaths:  true,
+			path:      "/",
+			expStatus: http.StatusUnauthorized,
+		},
+		"healthz without certificate for all paths": {
+			allPaths:  true,
+			path:      "/healthz",
+			expStatus: http.StatusOK,
+		},
+		"webhook with certificate": {
+			path:      "/events",
+			cert:      otherCert,
+			expStatus: http.StatusOK,
+		},
+		"dns san allowed": {
+			allowlist: []string{"*.example.com"},
+			path:      "/events",
+			cert:      hostCert,
+			expStatus: http.StatusOK,
+		},
+		"ip san allowed": {
+			allowlist: []string{"10.0.0.1"},
+			path:      "/events",
+			cert:      hostCert,
+			expStatus: http.StatusOK,
+		},
+		"uri san allowed": {
+			allowlist: []string{"*.example.com", "spiffe://example.com/ns/ci/sa/*"},
+			path:      "/api/apply",
+			cert:      ciCert,
+			expStatus: http.StatusOK,
+		},
+		"san not allowed": {
+			allowlist: []string{"*.example.com"},
+			path:      "/events",
+			cert:      otherCert,
+			expStatus: http.StatusForbidden,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			auth := &ClientCertAuth{
+				logger:       logging.NewNoopLogger(t),
+				AllPaths:     c.allPaths,
+				SANAllowlist: c.allowlist,
+			}
+			req := httptest.NewRequest(http.MethodPost, c.path, nil)
+			req.TLS = &tls.ConnectionState{}
+			if c.cert != nil {
+				req.TLS.VerifiedChains = [][]*x509.Certificate{{c.cert}}
+			}
+			rec := httptest.NewRecorder()
+			auth.ServeHTTP(rec, req, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			Equals(t, c.expStatus, rec.Code)
+		})
+	}
+}
+
+func TestEventsIPAllowlist(t *testing.T) {
+	allowlist, err := ipallowlist.New(logging.NewNoopLogger(t), []string{"192.0.2.0/24"}, nil, nil)
+	Ok(t, err)
+	middleware := &EventsIPAllowlist{
+		logger:    logging.NewNoopLogger(t),
+		Allowlist: allowlist,
+	}
+
+	cases := map[string]struct {
+		path       string
+		remoteAddr string
+		expStatus  int
+	}{
+		"webhook from allowed IP": {
+			path:       "/events",
+			remoteAddr: "192.0.2.10:1234",
+			expStatus:  http.StatusOK,
+		},
+		"webhook from other IP": {
+			path:       "/events",
+			remoteAddr: "198.51.100.1:1234",
+			expStatus:  http.StatusForbidden,
+		},
+		"ui from other IP": {
+			path:       "/",
+			remoteAddr: "198.51.100.1:1234",
+			expStatus:  http.StatusOK,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, c.path, nil)
+			req.RemoteAddr = c.remoteAddr
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, req, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			Equals(t, c.expStatus, rec.Code)
+		})
+	}
+}
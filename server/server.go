@@ -18,7 +18,9 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"embed"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -46,6 +48,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/core/redis"
 	"github.com/runatlantis/atlantis/server/core/terraform/tfclient"
+	"github.com/runatlantis/atlantis/server/core/vault"
 	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/metrics"
 	"github.com/runatlantis/atlantis/server/scheduled"
@@ -56,16 +59,20 @@ import (
 	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
 	"github.com/runatlantis/atlantis/server/controllers/web_templates"
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
+	"github.com/runatlantis/atlantis/server/core/groupsync"
+	"github.com/runatlantis/atlantis/server/core/ipallowlist"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/core/runtime/policy"
 	"github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/messages"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/vcs/fake"
 	"github.com/runatlantis/atlantis/server/events/vcs/gitea"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -88,6 +95,23 @@ const (
 	// terraformPluginCacheDir is the name of the dir inside our data dir
 	// where we tell terraform to cache plugins and modules.
 	TerraformPluginCacheDirName = "plugin-cache"
+	// pullCleanUpRetries is how many times cleaning up after a closed pull
+	// request is retried if it fails. With pullCleanUpRetryDelay doubling
+	// each retry, the last retry is around 15 minutes after the pull request
+	// was closed.
+	pullCleanUpRetries    = 5
+	pullCleanUpRetryDelay = 30 * time.Second
+	// recentErrorsKept is how many of the errors logged last are included in
+	// diagnostic bundles.
+	recentErrorsKept = 50
+	// minFreeDiskSpace is the space in the data dir below which /healthz/deep
+	// fails.
+	minFreeDiskSpace = 1 << 30
+	// healthCheckTimeout is how long /healthz/deep waits for VCS hosts.
+	healthCheckTimeout = 10 * time.Second
+	// healthCheckCacheTTL is how long /healthz/deep reuses the results of
+	// its checks.
+	healthCheckCacheTTL = 30 * time.Second
 )
 
 // Server runs the Atlantis web server.
@@ -111,6 +135,7 @@ type Server struct {
 	StatusController               *controllers.StatusController
 	JobsController                 *controllers.JobsController
 	APIController                  *controllers.APIController
+	SlackController                *controllers.SlackController
 	IndexTemplate                  web_templates.TemplateWriter
 	LockDetailTemplate             web_templates.TemplateWriter
 	ProjectJobsTemplate            web_templates.TemplateWriter
@@ -120,14 +145,28 @@ type Server struct {
 	CertLastRefreshTime            time.Time
 	KeyLastRefreshTime             time.Time
 	SSLCert                        *tls.Certificate
-	Drainer                        *events.Drainer
-	WebAuthentication              bool
-	WebUsername                    string
-	WebPassword                    string
-	ProjectCmdOutputHandler        jobs.ProjectCommandOutputHandler
-	ScheduledExecutorService       *scheduled.ExecutorService
-	DisableGlobalApplyLock         bool
-	EnableProfilingAPI             bool
+	// SSLClientCAs, if set, are the CAs client certificates are verified
+	// against, see ClientCertAuth.
+	SSLClientCAs          *x509.CertPool
+	SSLClientAuthAllPaths bool
+	SSLClientSANAllowlist []string
+	// EventsIPAllowlist, if set, are the IPs allowed to send webhooks, see
+	// EventsIPAllowlist.
+	EventsIPAllowlist        *ipallowlist.Allowlist
+	Drainer                  *events.Drainer
+	WebAuthentication        bool
+	WebUsername              string
+	WebPassword              string
+	ProjectCmdOutputHandler  jobs.ProjectCommandOutputHandler
+	ScheduledExecutorService *scheduled.ExecutorService
+	DisableGlobalApplyLock   bool
+	EnableProfilingAPI       bool
+	// HealthChecks are run by /healthz/deep.
+	HealthChecks *controllers.CachedHealthChecks
+	// VCSConfigValidator is run on startup unless VCSConfigValidation is
+	// "off". If it's "fail", problems found stop Atlantis from starting.
+	VCSConfigValidator  *events.VCSConfigValidator
+	VCSConfigValidation string
 }
 
 // Config holds config for server that isn't passed in by the user.
@@ -135,8 +174,10 @@ type Config struct {
 	AllowForkPRsFlag          string
 	AtlantisURLFlag           string
 	AtlantisVersion           string
+	CgroupParentFlag          string
 	DefaultTFDistributionFlag string
 	DefaultTFVersionFlag      string
+	ForkPRAllowlistFlag       string
 	RepoConfigJSONFlag        string
 	SilenceForkPRErrorsFlag   string
 }
@@ -177,8 +218,27 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, err
 	}
 
+	redactor, err := userConfig.ToRedactor()
+	if err != nil {
+		return nil, err
+	}
+	sslClientCAs, err := userConfig.ToSSLClientCAs()
+	if err != nil {
+		return nil, err
+	}
+	// Errors are recorded before they're redacted for diagnostic bundles.
+	errorRecorder := logging.NewErrorRecorder(recentErrorsKept)
+	logger = logging.WithRedactor(logging.WithErrorRecorder(logger, errorRecorder), redactor)
+	// Secrets read from Vault are added to the redactor while running
+	// commands so it must be used even if it has nothing to mask yet.
+	redactSecrets := redactor.Enabled() || userConfig.VaultAddr != ""
+
 	var supportedVCSHosts []models.VCSHostType
+	// vcsConfigHosts are the hosts whose credentials and webhooks are
+	// validated.
+	var vcsConfigHosts []events.VCSConfigHost
 	var githubClient vcs.IGithubClient
+	var githubCheckRunUpdater vcs.GithubCheckRunUpdater
 	var githubAppEnabled bool
 	var githubConfig vcs.GithubConfig
 	var githubCredentials vcs.GithubCredentials
@@ -206,12 +266,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		}
 	}
 
-	parserValidator := &cfg.ParserValidator{}
+	parserValidator := &cfg.ParserValidator{
+		NoSingleFileDownloadHosts: noSingleFileDownloadHosts(userConfig),
+	}
 
-	globalCfg := valid.NewGlobalCfgFromArgs(
-		valid.GlobalCfgArgs{
-			PolicyCheckEnabled: userConfig.EnablePolicyChecksFlag,
-		})
+	globalCfgArgs := valid.GlobalCfgArgs{
+		PolicyCheckEnabled: userConfig.EnablePolicyChecksFlag,
+	}
+	globalCfg := valid.NewGlobalCfgFromArgs(globalCfgArgs)
 	if userConfig.RepoConfig != "" {
 		globalCfg, err = parserValidator.ParseGlobalCfg(userConfig.RepoConfig, globalCfg)
 		if err != nil {
@@ -223,6 +285,12 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			return nil, errors.Wrapf(err, "parsing --%s", config.RepoConfigJSONFlag)
 		}
 	}
+	if userConfig.CgroupParent == "" && globalCfg.HasCgroupResourceLimits() {
+		return nil, fmt.Errorf("the cpu and memory %s of the server-side repo config require --%s to be set", valid.ResourceLimitsKey, config.CgroupParentFlag)
+	}
+	// The server-side repo config can be reloaded via the API so it's read
+	// from reloadableGlobalCfg wherever reloading is supported.
+	reloadableGlobalCfg := valid.NewReloadableGlobalCfg(globalCfg)
 
 	statsScope, statsReporter, closer, err := metrics.NewScope(globalCfg.Metrics, logger, userConfig.StatsNamespace)
 
@@ -230,7 +298,20 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, errors.Wrapf(err, "instantiating metrics scope")
 	}
 
+	vcsHTTPConfigs, err := userConfig.ToVCSHTTPConfigs()
+	if err != nil {
+		return nil, err
+	}
+	var rateLimitBudget *vcs.RateLimitBudget
+	if userConfig.VCSRateLimitReserve > 0 {
+		rateLimitBudget = vcs.NewRateLimitBudget(userConfig.VCSRateLimitReserve, statsScope)
+	}
+
 	if userConfig.GithubUser != "" || userConfig.GithubAppID != 0 {
+		githubTransport, err := vcsHTTPConfigs.Transport(userConfig.GithubHostname)
+		if err != nil {
+			return nil, err
+		}
 		if userConfig.GithubAllowMergeableBypassApply {
 			githubConfig = vcs.GithubConfig{
 				AllowMergeableBypassApply: true,
@@ -242,6 +323,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				User:      userConfig.GithubUser,
 				Token:     userConfig.GithubToken,
 				TokenFile: userConfig.GithubTokenFile,
+				Transport: githubTransport,
 			}
 		} else if userConfig.GithubAppID != 0 && userConfig.GithubAppKeyFile != "" {
 			privateKey, err := os.ReadFile(userConfig.GithubAppKeyFile)
@@ -254,6 +336,8 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				Key:            privateKey,
 				Hostname:       userConfig.GithubHostname,
 				AppSlug:        userConfig.GithubAppSlug,
+				RepoScoped:     userConfig.GithubAppRepoScopedTokens,
+				Transport:      githubTransport,
 			}
 			githubAppEnabled = true
 		} else if userConfig.GithubAppID != 0 && userConfig.GithubAppKey != "" {
@@ -263,17 +347,68 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 				Key:            []byte(userConfig.GithubAppKey),
 				Hostname:       userConfig.GithubHostname,
 				AppSlug:        userConfig.GithubAppSlug,
+				RepoScoped:     userConfig.GithubAppRepoScopedTokens,
+				Transport:      githubTransport,
 			}
 			githubAppEnabled = true
 		}
 
-		var err error
+		if userConfig.VCSHTTPCassette != "" {
+			recorder, err := fake.NewRecorder(userConfig.VCSHTTPCassetteMode, userConfig.VCSHTTPCassette)
+			if err != nil {
+				return nil, err
+			}
+			githubConfig.WrapTransport = recorder.Wrap
+			logger.Info("GitHub API requests will be %sed with cassette %s", userConfig.VCSHTTPCassetteMode, userConfig.VCSHTTPCassette)
+		}
+		if rateLimitBudget != nil {
+			wrapTransport := githubConfig.WrapTransport
+			githubConfig.WrapTransport = func(transport http.RoundTripper) http.RoundTripper {
+				if wrapTransport != nil {
+					transport = wrapTransport(transport)
+				}
+				return rateLimitBudget.Transport(models.Github, transport)
+			}
+		}
+
 		rawGithubClient, err := vcs.NewGithubClient(userConfig.GithubHostname, githubCredentials, githubConfig, userConfig.MaxCommentsPerCommand, logger)
 		if err != nil {
 			return nil, err
 		}
 
+		if userConfig.GithubHostname != "github.com" {
+			// Features older GitHub Enterprise Server versions don't have
+			// are disabled at startup rather than failing when used.
+			if err := rawGithubClient.DetectVersion(logger); err != nil {
+				logger.Warn("unable to get the version of GitHub host %s, assuming it supports all features: %s", userConfig.GithubHostname, err)
+			}
+		}
+
 		githubClient = vcs.NewInstrumentedGithubClient(rawGithubClient, statsScope, logger)
+		vcsConfigHosts = append(vcsConfigHosts, events.VCSConfigHost{Type: models.Github, Hostname: userConfig.GithubHostname, Client: rawGithubClient, WebhookSecret: userConfig.GithubWebhookSecret})
+		if userConfig.GithubUseCheckRuns && rawGithubClient.SupportsCheckRuns() {
+			githubCheckRunUpdater = rawGithubClient
+		}
+	}
+	if userConfig.VCSFakeFixtures != "" {
+		fixtures, err := fake.LoadFixtures(userConfig.VCSFakeFixtures)
+		if err != nil {
+			return nil, err
+		}
+		// Repos are cloned from their local paths instead of the fake host.
+		gitEnv, err := fixtures.GitConfigEnv(userConfig.GithubUser, userConfig.GithubToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, env := range gitEnv {
+			name, value, _ := strings.Cut(env, "=")
+			if err := os.Setenv(name, value); err != nil {
+				return nil, err
+			}
+		}
+		supportedVCSHosts = append(supportedVCSHosts, models.Github)
+		githubClient = fake.NewClient(fixtures, os.Stdout)
+		logger.Warn("serving pull requests from fixtures %s on fake VCS host %s", userConfig.VCSFakeFixtures, fake.Hostname)
 	}
 	if userConfig.GitlabUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Gitlab)
@@ -286,24 +421,35 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 		gitlabGroups := slices.Concat(gitlabGroupAllowlistChecker.AllTeams(), globalCfg.PolicySets.AllTeams())
 		slices.Sort(gitlabGroups)
-		gitlabClient, err = vcs.NewGitlabClient(userConfig.GitlabHostname, userConfig.GitlabToken, slices.Compact(gitlabGroups), logger)
+		gitlabHTTPClient, err := vcsHTTPConfigs.Client(userConfig.GitlabHostname)
+		if err != nil {
+			return nil, err
+		}
+		gitlabHTTPClient = rateLimitBudget.Client(models.Gitlab, gitlabHTTPClient)
+		gitlabClient, err = vcs.NewGitlabClient(userConfig.GitlabHostname, userConfig.GitlabToken, userConfig.GitlabTokenType, slices.Compact(gitlabGroups), gitlabHTTPClient, logger)
 		if err != nil {
 			return nil, err
 		}
+		vcsConfigHosts = append(vcsConfigHosts, events.VCSConfigHost{Type: models.Gitlab, Hostname: hostnameOf(userConfig.GitlabHostname), Client: gitlabClient, WebhookSecret: userConfig.GitlabWebhookSecret})
 	}
 	if userConfig.BitbucketUser != "" {
+		bitbucketHTTPClient, err := vcsHTTPConfigs.Client(userConfig.BitbucketBaseURL)
+		if err != nil {
+			return nil, err
+		}
 		if userConfig.BitbucketBaseURL == bitbucketcloud.BaseURL {
 			supportedVCSHosts = append(supportedVCSHosts, models.BitbucketCloud)
 			bitbucketCloudClient = bitbucketcloud.NewClient(
-				http.DefaultClient,
+				rateLimitBudget.Client(models.BitbucketCloud, bitbucketHTTPClient),
 				userConfig.BitbucketUser,
 				userConfig.BitbucketToken,
 				userConfig.AtlantisURL)
+			vcsConfigHosts = append(vcsConfigHosts, events.VCSConfigHost{Type: models.BitbucketCloud, Hostname: hostnameOf(userConfig.BitbucketBaseURL), Client: bitbucketCloudClient, WebhookSecret: userConfig.BitbucketWebhookSecret})
 		} else {
 			supportedVCSHosts = append(supportedVCSHosts, models.BitbucketServer)
 			var err error
 			bitbucketServerClient, err = bitbucketserver.NewClient(
-				http.DefaultClient,
+				rateLimitBudget.Client(models.BitbucketServer, bitbucketHTTPClient),
 				userConfig.BitbucketUser,
 				userConfig.BitbucketToken,
 				userConfig.BitbucketBaseURL,
@@ -311,27 +457,37 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			if err != nil {
 				return nil, errors.Wrapf(err, "setting up Bitbucket Server client")
 			}
+			vcsConfigHosts = append(vcsConfigHosts, events.VCSConfigHost{Type: models.BitbucketServer, Hostname: hostnameOf(userConfig.BitbucketBaseURL), Client: bitbucketServerClient, WebhookSecret: userConfig.BitbucketWebhookSecret})
 		}
 	}
 	if userConfig.AzureDevopsUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.AzureDevops)
 
-		var err error
-		azuredevopsClient, err = vcs.NewAzureDevopsClient(userConfig.AzureDevOpsHostname, userConfig.AzureDevopsUser, userConfig.AzureDevopsToken)
+		azuredevopsTransport, err := vcsHTTPConfigs.Transport(userConfig.AzureDevOpsHostname)
+		if err != nil {
+			return nil, err
+		}
+		azuredevopsClient, err = vcs.NewAzureDevopsClient(userConfig.AzureDevOpsHostname, userConfig.AzureDevopsUser, userConfig.AzureDevopsToken, rateLimitBudget.Transport(models.AzureDevops, azuredevopsTransport))
 		if err != nil {
 			return nil, err
 		}
+		vcsConfigHosts = append(vcsConfigHosts, events.VCSConfigHost{Type: models.AzureDevops, Hostname: userConfig.AzureDevOpsHostname, Client: azuredevopsClient})
 	}
 	if userConfig.GiteaToken != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Gitea)
 
-		giteaClient, err = gitea.NewClient(userConfig.GiteaBaseURL, userConfig.GiteaUser, userConfig.GiteaToken, userConfig.GiteaPageSize, logger)
+		giteaHTTPClient, err := vcsHTTPConfigs.Client(userConfig.GiteaBaseURL)
+		if err != nil {
+			return nil, err
+		}
+		giteaClient, err = gitea.NewClient(rateLimitBudget.Client(models.Gitea, giteaHTTPClient), userConfig.GiteaBaseURL, userConfig.GiteaUser, userConfig.GiteaToken, userConfig.GiteaPageSize, logger)
 		if err != nil {
 			fmt.Println("error setting up gitea client", "error", err)
 			return nil, errors.Wrapf(err, "setting up Gitea client")
 		} else {
 			logger.Info("gitea client configured successfully")
 		}
+		vcsConfigHosts = append(vcsConfigHosts, events.VCSConfigHost{Type: models.Gitea, Hostname: hostnameOf(userConfig.GiteaBaseURL), Client: giteaClient, WebhookSecret: userConfig.GiteaWebhookSecret})
 	}
 
 	var supportedVCSHostsStr []string
@@ -412,8 +568,28 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing webhooks")
 	}
-	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient, giteaClient)
-	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient, StatusName: userConfig.VCSStatusName}
+	clientProxy := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azuredevopsClient, giteaClient)
+	clientProxy.Budget = rateLimitBudget
+	var vcsClient vcs.Client = clientProxy
+	if redactSecrets {
+		vcsClient = vcs.NewRedactingClient(vcsClient, redactor)
+	}
+	var messageCatalog *messages.Catalog
+	if userConfig.MessageCatalog != "" {
+		messageCatalog, err = messages.Load(userConfig.MessageCatalog)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading %s file", userConfig.MessageCatalog)
+		}
+	}
+	commitStatusUpdater := &events.DefaultCommitStatusUpdater{
+		Client:                 vcsClient,
+		StatusName:             userConfig.VCSStatusName,
+		CheckRunUpdater:        githubCheckRunUpdater,
+		Redactor:               redactor,
+		SummaryStatus:          userConfig.SummaryStatus,
+		DisableProjectStatuses: userConfig.DisableProjectStatuses,
+		Messages:               messageCatalog,
+	}
 
 	binDir, err := mkSubDir(userConfig.DataDir, BinDirName)
 
@@ -427,6 +603,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, err
 	}
 
+	var sharedInitDir string
+	if userConfig.ShareWorkspaceInit {
+		sharedInitDir, err = mkSubDir(userConfig.DataDir, runtime.SharedInitDirName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	parsedURL, err := ParseAtlantisURL(userConfig.AtlantisURL)
 	if err != nil {
 		return nil, errors.Wrapf(err, "parsing --%s flag %q", config.AtlantisURLFlag, userConfig.AtlantisURL)
@@ -453,6 +637,27 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			logger,
 		)
 	}
+	var logSinks []jobs.LogSink
+	if userConfig.JobLogLokiURL != "" {
+		logSinks = append(logSinks, &jobs.LokiSink{URL: userConfig.JobLogLokiURL})
+	}
+	if userConfig.JobLogCloudWatchGroup != "" {
+		region := userConfig.JobLogCloudWatchRegion
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		logSinks = append(logSinks, &jobs.CloudWatchSink{LogGroup: userConfig.JobLogCloudWatchGroup, Region: region})
+	}
+	if len(logSinks) > 0 {
+		// Secrets are redacted before the output reaches the sinks.
+		projectCmdOutputHandler = jobs.NewSinkingProjectOutputHandler(projectCmdOutputHandler, logSinks, logger)
+	}
+	if redactSecrets {
+		projectCmdOutputHandler = &jobs.RedactingProjectOutputHandler{
+			ProjectCommandOutputHandler: projectCmdOutputHandler,
+			Redactor:                    redactor,
+		}
+	}
 
 	distribution := terraform.NewDistribution(userConfig.DefaultTFDistribution)
 
@@ -492,19 +697,43 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	var applyLockingClient locking.ApplyLocker
 	var backend locking.Backend
 
+	var recordCipher *locking.RecordCipher
+	if userConfig.LockingDBEncryptionKeyFile != "" {
+		recordCipher, err = locking.NewRecordCipher(userConfig.LockingDBEncryptionKeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading --locking-db-encryption-key-file")
+		}
+	}
+
 	switch dbtype := userConfig.LockingDBType; dbtype {
 	case "redis":
 		logger.Info("Utilizing Redis DB")
-		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
+		redisDB, err := redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
 		if err != nil {
 			return nil, err
 		}
+		redisDB.Cipher = recordCipher
+		backend = redisDB
 	case "boltdb":
 		logger.Info("Utilizing BoltDB")
-		backend, err = db.New(userConfig.DataDir)
+		boltDB, err := db.New(userConfig.DataDir)
 		if err != nil {
 			return nil, err
 		}
+		boltDB.Cipher = recordCipher
+		backend = boltDB
+	}
+
+	if userConfig.RestoreBackup != "" {
+		if err := restoreBackup(logger, backend, userConfig.RestoreBackup); err != nil {
+			return nil, err
+		}
+	}
+	// Records stored before encryption was enabled or encrypted with a key
+	// that was since rotated are rewritten via /api/admin/reencrypt.
+	var reencrypter locking.Reencrypter
+	if recordCipher != nil {
+		reencrypter, _ = backend.(locking.Reencrypter)
 	}
 
 	noOpLocker := locking.NewNoOpLocker()
@@ -539,11 +768,16 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		if !userConfig.WriteGitCreds {
 			return nil, errors.New("Github App requires --write-git-creds to support cloning")
 		}
-		workingDir = &events.GithubAppWorkingDir{
+		githubAppWorkingDir := &events.GithubAppWorkingDir{
 			WorkingDir:     workingDir,
 			Credentials:    githubCredentials,
 			GithubHostname: userConfig.GithubHostname,
+			HomeDir:        home,
 		}
+		if repoCredentials, ok := githubCredentials.(vcs.GithubRepoCredentials); ok && userConfig.GithubAppRepoScopedTokens {
+			githubAppWorkingDir.RepoCredentials = repoCredentials
+		}
+		workingDir = githubAppWorkingDir
 
 		githubAppTokenRotator := vcs.NewGithubTokenRotator(logger, githubCredentials, userConfig.GithubHostname, "x-access-token", home)
 		tokenJd, err := githubAppTokenRotator.GenerateJob()
@@ -562,6 +796,33 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		scheduledExecutorService.AddJob(tokenJd)
 	}
 
+	var eventsIPAllowlist *ipallowlist.Allowlist
+	if userConfig.EventsIPAllowlist != "" {
+		eventsIPAllowlist, err = ipallowlist.New(logger, userConfig.ToEventsIPAllowlist(), userConfig.ToEventsIPAllowlistTrustedProxies(), ipallowlist.DefaultFetchers(userConfig.GithubHostname))
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing events IP allowlist")
+		}
+		allowlistJd, err := eventsIPAllowlist.GenerateJob()
+		if err != nil {
+			return nil, errors.Wrap(err, "fetching events IP allowlist")
+		}
+		scheduledExecutorService.AddJob(allowlistJd)
+	}
+
+	var groupSyncer *groupsync.Syncer
+	if userConfig.TeamSyncSCIMURL != "" {
+		groupSyncer = groupsync.New(logger, &groupsync.SCIMSource{
+			URL:        userConfig.TeamSyncSCIMURL,
+			TokenFile:  userConfig.TeamSyncSCIMTokenFile,
+			HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		})
+		groupSyncJd, err := groupSyncer.GenerateJob()
+		if err != nil {
+			return nil, errors.Wrap(err, "syncing groups from --team-sync-scim-url")
+		}
+		scheduledExecutorService.AddJob(groupSyncJd)
+	}
+
 	projectLocker := &events.DefaultProjectLocker{
 		Locker:     lockingClient,
 		NoOpLocker: noOpLocker,
@@ -574,18 +835,78 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Backend:          backend,
 	}
 
-	pullClosedExecutor := events.NewInstrumentedPullClosedExecutor(
-		statsScope,
-		logger,
-		&events.PullClosedExecutor{
-			Locker:                   lockingClient,
-			WorkingDir:               workingDir,
-			Backend:                  backend,
-			PullClosedTemplate:       &events.PullClosedEventTemplate{},
-			LogStreamResourceCleaner: projectCmdOutputHandler,
-			VCSClient:                vcsClient,
+	planRetentionMaxAge, err := userConfig.ToPlanRetentionMaxAge()
+	if err != nil {
+		return nil, err
+	}
+	pendingPlanReminderAge, err := userConfig.ToPendingPlanReminderAge()
+	if err != nil {
+		return nil, err
+	}
+	pendingPlanDiscardAge, err := userConfig.ToPendingPlanDiscardAge()
+	if err != nil {
+		return nil, err
+	}
+	if pendingPlanReminderAge > 0 || pendingPlanDiscardAge > 0 {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job: &events.PendingPlanReminder{
+				Backend:             backend,
+				WorkingDir:          workingDir,
+				WorkingDirLocker:    workingDirLocker,
+				PendingPlanFinder:   &events.DefaultPendingPlanFinder{},
+				CommitStatusUpdater: commitStatusUpdater,
+				VCSClient:           vcsClient,
+				Logger:              logger,
+				Scope:               statsScope,
+				ExecutableName:      userConfig.ExecutableName,
+				RemindAfter:         pendingPlanReminderAge,
+				DiscardAfter:        pendingPlanDiscardAge,
+			},
+			Period: 10 * time.Minute,
+		})
+	}
+	if planRetentionMaxAge > 0 || userConfig.PlanRetentionMaxCount > 0 {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job: &events.PlanRetentionCleaner{
+				Backend:             backend,
+				WorkingDir:          workingDir,
+				WorkingDirLocker:    workingDirLocker,
+				PendingPlanFinder:   &events.DefaultPendingPlanFinder{},
+				CommitStatusUpdater: commitStatusUpdater,
+				Logger:              logger,
+				Scope:               statsScope,
+				MaxAge:              planRetentionMaxAge,
+				MaxCount:            userConfig.PlanRetentionMaxCount,
+			},
+			Period: 10 * time.Minute,
+		})
+	}
+
+	scheduledExecutorService.AddJob(scheduled.JobDefinition{
+		Job: &events.PostureReporter{
+			Backend: backend,
+			Logger:  logger,
+			Scope:   statsScope,
 		},
-	)
+		Period: time.Minute,
+	})
+
+	pullClosedExecutor := &events.RetryingPullCleaner{
+		PullCleaner: events.NewInstrumentedPullClosedExecutor(
+			statsScope,
+			logger,
+			&events.PullClosedExecutor{
+				Locker:                   lockingClient,
+				WorkingDir:               workingDir,
+				Backend:                  backend,
+				PullClosedTemplate:       &events.PullClosedEventTemplate{},
+				LogStreamResourceCleaner: projectCmdOutputHandler,
+				VCSClient:                vcsClient,
+			},
+		),
+		Retries: pullCleanUpRetries,
+		Delay:   pullCleanUpRetryDelay,
+	}
 
 	eventParser := &events.EventParser{
 		GithubUser:         userConfig.GithubUser,
@@ -595,13 +916,20 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		GitlabToken:        userConfig.GitlabToken,
 		GiteaUser:          userConfig.GiteaUser,
 		GiteaToken:         userConfig.GiteaToken,
-		AllowDraftPRs:      userConfig.PlanDrafts,
 		BitbucketUser:      userConfig.BitbucketUser,
 		BitbucketToken:     userConfig.BitbucketToken,
 		BitbucketServerURL: userConfig.BitbucketBaseURL,
 		AzureDevopsUser:    userConfig.AzureDevopsUser,
 		AzureDevopsToken:   userConfig.AzureDevopsToken,
 	}
+	allowExtraArgs, err := userConfig.ToAllowExtraArgs()
+	if err != nil {
+		return nil, err
+	}
+	denyExtraArgs, err := userConfig.ToDenyExtraArgs()
+	if err != nil {
+		return nil, err
+	}
 	commentParser := events.NewCommentParser(
 		userConfig.GithubUser,
 		userConfig.GitlabUser,
@@ -611,6 +939,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.ExecutableName,
 		allowCommands,
 	)
+	commentParser.AllowExtraArgs = allowExtraArgs
+	commentParser.DenyExtraArgs = denyExtraArgs
+	commentParser.Messages = messageCatalog
 	defaultTfDistribution := terraformClient.DefaultDistribution()
 	defaultTfVersion := terraformClient.DefaultVersion()
 	pendingPlanFinder := &events.DefaultPendingPlanFinder{}
@@ -621,6 +952,18 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		TerraformBinDir:         terraformClient.TerraformBinDir(),
 		ProjectCmdOutputHandler: projectCmdOutputHandler,
 	}
+	if userConfig.VaultAddr != "" {
+		runStepRunner.SecretResolver = &vault.Client{
+			Address:             userConfig.VaultAddr,
+			Namespace:           userConfig.VaultNamespace,
+			AuthMethod:          userConfig.VaultAuthMethod,
+			AuthMount:           userConfig.VaultAuthMount,
+			Role:                userConfig.VaultRole,
+			SecretIDFile:        userConfig.VaultSecretIDFile,
+			KubernetesTokenFile: userConfig.VaultKubernetesTokenFile,
+			Redactor:            redactor,
+		}
+	}
 	drainer := &events.Drainer{}
 	statusController := &controllers.StatusController{
 		Logger:          logger,
@@ -637,6 +980,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		},
 		CommitStatusUpdater: commitStatusUpdater,
 		Router:              router,
+		ReloadableGlobalCfg: reloadableGlobalCfg,
 	}
 	postWorkflowHooksCommandRunner := &events.DefaultPostWorkflowHooksCommandRunner{
 		VCSClient:        vcsClient,
@@ -648,6 +992,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		},
 		CommitStatusUpdater: commitStatusUpdater,
 		Router:              router,
+		ReloadableGlobalCfg: reloadableGlobalCfg,
 	}
 	projectCommandBuilder := events.NewInstrumentedProjectCommandBuilder(
 		logger,
@@ -657,7 +1002,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		vcsClient,
 		workingDir,
 		workingDirLocker,
-		globalCfg,
+		reloadableGlobalCfg,
 		pendingPlanFinder,
 		commentParser,
 		userConfig.SkipCloneNoChanges,
@@ -681,10 +1026,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, errors.Wrap(err, "initializing show step runner")
 	}
 
+	conftestExecutorWorkflow := policy.NewConfTestExecutorWorkflow(logger, binDir, &policy.ConfTestGoGetterVersionDownloader{})
+	if userConfig.ReuseIdenticalPlanAnalysis {
+		conftestExecutorWorkflow.AnalysisCache = policy.NewPlanAnalysisCache()
+	}
 	policyCheckStepRunner, err := runtime.NewPolicyCheckStepRunner(
 		defaultTfDistribution,
 		defaultTfVersion,
-		policy.NewConfTestExecutorWorkflow(logger, binDir, &policy.ConfTestGoGetterVersionDownloader{}),
+		conftestExecutorWorkflow,
 	)
 
 	if err != nil {
@@ -693,8 +1042,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 	applyRequirementHandler := &events.DefaultCommandRequirementHandler{
 		WorkingDir: workingDir,
+		VCSClient:  vcsClient,
 	}
 
+	runningCommands := events.NewRunningCommands()
 	projectCommandRunner := &events.DefaultProjectCommandRunner{
 		VcsClient:        vcsClient,
 		Locker:           projectLocker,
@@ -704,6 +1055,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			TerraformExecutor:     terraformClient,
 			DefaultTFDistribution: defaultTfDistribution,
 			DefaultTFVersion:      defaultTfVersion,
+			SharedInitDir:         sharedInitDir,
 		},
 		PlanStepRunner:        runtime.NewPlanStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion, commitStatusUpdater, terraformClient),
 		ShowStepRunner:        showStepRunner,
@@ -722,26 +1074,67 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		MultiEnvStepRunner: &runtime.MultiEnvStepRunner{
 			RunStepRunner: runStepRunner,
 		},
+		CheckoutStepRunner: &runtime.CheckoutStepRunner{},
 		VersionStepRunner: &runtime.VersionStepRunner{
 			TerraformExecutor: terraformClient,
 			DefaultTFVersion:  defaultTfVersion,
 		},
 		ImportStepRunner:          runtime.NewImportStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
 		StateRmStepRunner:         runtime.NewStateRmStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		StateCheckStepRunner:      runtime.NewStateCheckStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		ForceUnlockStepRunner:     runtime.NewForceUnlockStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		FmtCheckStepRunner:        runtime.NewFmtCheckStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		ValidateStepRunner:        runtime.NewValidateStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		LockfileCheckStepRunner:   runtime.NewLockfileCheckStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		WorkspacesStepRunner:      runtime.NewWorkspacesStepRunner(terraformClient, defaultTfDistribution, defaultTfVersion),
+		PulumiPreviewStepRunner:   runtime.NewPulumiPreviewStepRunner(projectCmdOutputHandler),
+		PulumiUpStepRunner:        runtime.NewPulumiUpStepRunner(projectCmdOutputHandler),
 		WorkingDir:                workingDir,
 		Webhooks:                  webhooksManager,
 		WorkingDirLocker:          workingDirLocker,
 		CommandRequirementHandler: applyRequirementHandler,
+		OutputsFetcher: &runtime.DefaultOutputsFetcher{
+			TerraformExecutor:     terraformClient,
+			DefaultTFDistribution: defaultTfDistribution,
+			DefaultTFVersion:      defaultTfVersion,
+		},
+		RunningCommands: runningCommands,
+		CgroupParent:    userConfig.CgroupParent,
+		CloudCredentialsMinter: &runtime.DefaultCloudCredentialsMinter{
+			IdentityTokenFile: userConfig.CloudIdentityTokenFile,
+		},
+	}
+	if userConfig.EnableOutdatedDependencies {
+		projectCommandRunner.DependencyChecker = &runtime.RegistryDependencyChecker{}
+	}
+	if userConfig.PlanEncryptionKeyFile != "" {
+		planEncryptor, err := events.NewAESPlanEncryptor(userConfig.PlanEncryptionKeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading --plan-encryption-key-file")
+		}
+		projectCommandRunner.PlanEncryptor = planEncryptor
+	}
+	if groupSyncer != nil {
+		projectCommandRunner.TeamNamesFetcher = groupSyncer
 	}
 
 	dbUpdater := &events.DBUpdater{
 		Backend: backend,
 	}
+	if u, ok := vcsClient.(vcs.PullDescriptionUpdater); ok && userConfig.EnablePRDescriptionStatus {
+		dbUpdater.PullDescriptionUpdater = &events.PullDescriptionStatusUpdater{
+			VCSClient: u,
+		}
+	}
 
 	pullUpdater := &events.PullUpdater{
-		HidePrevPlanComments: userConfig.HidePrevPlanComments,
-		VCSClient:            vcsClient,
-		MarkdownRenderer:     markdownRenderer,
+		HidePrevPlanComments:   userConfig.HidePrevPlanComments,
+		VCSClient:              vcsClient,
+		MarkdownRenderer:       markdownRenderer,
+		CheckRunResultsUpdater: commitStatusUpdater,
+		CommentStrategy:        userConfig.CommentStrategy,
+		GlobalCfg:              globalCfg,
+		ReloadableGlobalCfg:    reloadableGlobalCfg,
 	}
 
 	autoMerger := &events.AutoMerger{
@@ -789,6 +1182,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		lockingClient,
 		userConfig.DiscardApprovalOnPlanFlag,
 		pullReqStatusFetcher,
+		userConfig.MaxAutoplanProjects,
 	)
 
 	applyCommandRunner := events.NewApplyCommandRunner(
@@ -806,7 +1200,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.SilenceNoProjects,
 		userConfig.SilenceVCSStatusNoProjects,
 		pullReqStatusFetcher,
+		userConfig.MarkAppliedComments,
 	)
+	applyCommandRunner.Messages = messageCatalog
 
 	approvePoliciesCommandRunner := events.NewApprovePoliciesCommandRunner(
 		commitStatusUpdater,
@@ -848,6 +1244,42 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		instrumentedProjectCmdRunner,
 	)
 
+	forceUnlockCommandRunner := events.NewForceUnlockCommandRunner(
+		pullUpdater,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		userConfig.SilenceNoProjects,
+	)
+
+	cancelCommandRunner := events.NewCancelCommandRunner(
+		runningCommands,
+		vcsClient,
+		userConfig.SilenceNoProjects,
+	)
+
+	workspacesCommandRunner := events.NewWorkspacesCommandRunner(
+		pullUpdater,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		lockingClient,
+		userConfig.SilenceNoProjects,
+	)
+
+	statusCommandRunner := events.NewStatusCommandRunner(
+		vcsClient,
+		pullReqStatusFetcher,
+		projectCommandBuilder,
+		workingDir,
+		applyRequirementHandler,
+		userConfig.SilenceNoProjects,
+	)
+
+	helpCommandRunner := events.NewHelpCommandRunner(
+		vcsClient,
+		commentParser,
+		projectCommandBuilder,
+	)
+
 	commentCommandRunnerByCmd := map[command.Name]events.CommentCommandRunner{
 		command.Plan:            planCommandRunner,
 		command.Apply:           applyCommandRunner,
@@ -856,6 +1288,11 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		command.Version:         versionCommandRunner,
 		command.Import:          importCommandRunner,
 		command.State:           stateCommandRunner,
+		command.ForceUnlock:     forceUnlockCommandRunner,
+		command.Cancel:          cancelCommandRunner,
+		command.Workspaces:      workspacesCommandRunner,
+		command.Status:          statusCommandRunner,
+		command.Help:            helpCommandRunner,
 	}
 
 	var teamAllowlistChecker command.TeamAllowlistChecker
@@ -893,13 +1330,18 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		FailOnPreWorkflowHookError:     userConfig.FailOnPreWorkflowHookError,
 		Logger:                         logger,
 		GlobalCfg:                      globalCfg,
+		ReloadableGlobalCfg:            reloadableGlobalCfg,
 		StatsScope:                     statsScope.SubScope("cmd"),
 		AllowForkPRs:                   userConfig.AllowForkPRs,
 		AllowForkPRsFlag:               config.AllowForkPRsFlag,
+		RestrictForkPRs:                userConfig.RestrictForkPRs,
+		ForkPRAllowlist:                userConfig.ToForkPRAllowlist(),
+		ForkPRAllowlistFlag:            config.ForkPRAllowlistFlag,
 		SilenceForkPRErrors:            userConfig.SilenceForkPRErrors,
 		SilenceForkPRErrorsFlag:        config.SilenceForkPRErrorsFlag,
 		DisableAutoplan:                userConfig.DisableAutoplan,
 		DisableAutoplanLabel:           userConfig.DisableAutoplanLabel,
+		AllowDraftPRs:                  userConfig.PlanDrafts,
 		Drainer:                        drainer,
 		PreWorkflowHooksCommandRunner:  preWorkflowHooksCommandRunner,
 		PostWorkflowHooksCommandRunner: postWorkflowHooksCommandRunner,
@@ -907,11 +1349,37 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		TeamAllowlistChecker:           teamAllowlistChecker,
 		VarFileAllowlistChecker:        varFileAllowlistChecker,
 		CommitStatusUpdater:            commitStatusUpdater,
+		ApplyOnMerge:                   userConfig.ApplyOnMerge,
+		WorkingDir:                     workingDir,
+		Messages:                       messageCatalog,
+	}
+	if groupSyncer != nil {
+		commandRunner.TeamNamesFetcher = groupSyncer
+	}
+	if userConfig.CommandRateLimitPerUser > 0 || userConfig.CommandRateLimitPerPull > 0 {
+		commandRunner.CommandRateLimiter = events.NewDefaultCommandRateLimiter(userConfig.CommandRateLimitPerUser, userConfig.CommandRateLimitPerPull)
+	}
+	applyConfirmationTTL, err := userConfig.ToApplyConfirmationTTL()
+	if err != nil {
+		return nil, err
 	}
+	commandRunner.ApplyConfirmations = events.NewApplyConfirmations(applyConfirmationTTL)
 	repoAllowlist, err := events.NewRepoAllowlistChecker(userConfig.RepoAllowlist)
 	if err != nil {
 		return nil, err
 	}
+	// The repo allowlist may have been changed via the API since it was
+	// last started, in which case that takes precedence over the flag.
+	persistedAllowlist, ok, err := backend.GetSetting(controllers.RepoAllowlistSetting)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting persisted repo allowlist")
+	}
+	if ok {
+		if err := repoAllowlist.Update(persistedAllowlist); err != nil {
+			return nil, errors.Wrap(err, "invalid persisted repo allowlist")
+		}
+		logger.Info("using repo allowlist %q set via the API instead of --repo-allowlist", persistedAllowlist)
+	}
 	locksController := &controllers.LocksController{
 		AtlantisVersion:    config.AtlantisVersion,
 		AtlantisURL:        parsedURL,
@@ -943,6 +1411,54 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WsMux:                    wsMux,
 		KeyGenerator:             controllers.JobIDKeyGenerator{},
 		StatsScope:               statsScope.SubScope("api"),
+		RunningCommands:          runningCommands,
+		WebAuthentication:        userConfig.WebBasicAuth,
+		WebUsername:              userConfig.WebUsername,
+		WebPassword:              userConfig.WebPassword,
+	}
+
+	vcsConfigValidator := &events.VCSConfigValidator{
+		Hosts:                vcsConfigHosts,
+		RepoAllowlistChecker: repoAllowlist,
+		WebhookURL:           strings.TrimSuffix(parsedURL.String(), "/") + "/events",
+	}
+	webhookRegistrar := &events.WebhookRegistrar{
+		Hosts:                vcsConfigHosts,
+		RepoAllowlistChecker: repoAllowlist,
+		WebhookURL:           vcsConfigValidator.WebhookURL,
+	}
+	repoOnboarder := &events.RepoOnboarder{
+		Hosts:                vcsConfigHosts,
+		RepoAllowlistChecker: repoAllowlist,
+		ProjectFinder:        &events.DefaultProjectFinder{},
+		AutoplanFileList:     userConfig.AutoplanFileList,
+	}
+
+	healthChecks := []controllers.HealthCheck{
+		{Name: "locking DB", Check: func() error {
+			_, err := backend.List()
+			return err
+		}},
+		controllers.DiskSpaceHealthCheck(userConfig.DataDir, minFreeDiskSpace),
+	}
+	if userConfig.VCSFakeFixtures == "" {
+		for _, host := range supportedVCSHosts {
+			hostname, apiURL := vcsAPI(userConfig, host)
+			vcsHTTPClient, err := vcsHTTPConfigs.Client(hostname)
+			if err != nil {
+				return nil, err
+			}
+			if vcsHTTPClient == nil {
+				vcsHTTPClient = &http.Client{}
+			}
+			vcsHTTPClient.Timeout = healthCheckTimeout
+			healthChecks = append(healthChecks, controllers.VCSHealthCheck(host.String(), apiURL, vcsHTTPClient))
+		}
+	}
+	if terraformClient != nil {
+		healthChecks = append(healthChecks, controllers.HealthCheck{Name: userConfig.DefaultTFDistribution, Check: func() error {
+			return terraformClient.EnsureVersion(logger, distribution, nil)
+		}})
 	}
 
 	apiController := &controllers.APIController{
@@ -962,32 +1478,98 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WorkingDir:                     workingDir,
 		WorkingDirLocker:               workingDirLocker,
 		CommitStatusUpdater:            commitStatusUpdater,
+		ApplyOnMerge:                   userConfig.ApplyOnMerge,
+		Snapshotter:                    backend,
+		Reencrypter:                    reencrypter,
+		SettingsStore:                  backend,
+		DefaultRepoAllowlist:           userConfig.RepoAllowlist,
+		OutputHandler:                  projectCmdOutputHandler,
+		PullStatusFetcher:              backend,
+		PlanEncryptor:                  projectCommandRunner.PlanEncryptor,
+		DeleteLockCommand:              deleteLockCommand,
+		AtlantisVersion:                config.AtlantisVersion,
+		ServerConfig:                   userConfig.RedactedFlags(),
+		HealthChecks:                   healthChecks,
+		ErrorRecorder:                  errorRecorder,
+		VCSConfigValidator:             vcsConfigValidator,
+		WebhookRegistrar:               webhookRegistrar,
+		RepoOnboarder:                  repoOnboarder,
+		ProjectSimulator:               projectCommandBuilder,
+		GlobalCfgParser:                parserValidator,
+		DefaultGlobalCfgArgs:           globalCfgArgs,
+	}
+	if userConfig.RepoConfig != "" {
+		apiController.GlobalCfgReloader = &cfg.GlobalCfgReloader{
+			ParserValidator: parserValidator,
+			Path:            userConfig.RepoConfig,
+			DefaultCfgArgs:  globalCfgArgs,
+			GlobalCfg:       reloadableGlobalCfg,
+			Logger:          logger,
+		}
 	}
 
+	emojiReactions, err := userConfig.ToVCSEmojiReactions()
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing --vcs-emoji-reactions")
+	}
 	eventsController := &events_controllers.VCSEventsController{
-		CommandRunner:                   commandRunner,
-		PullCleaner:                     pullClosedExecutor,
+		CommandRunner: commandRunner,
+		PullCleaner:   pullClosedExecutor,
+		DeletedBranchCleaner: &events.DefaultDeletedBranchCleaner{
+			Backend:     backend,
+			PullCleaner: pullClosedExecutor,
+		},
 		Parser:                          eventParser,
 		CommentParser:                   commentParser,
 		Logger:                          logger,
 		Scope:                           statsScope,
 		ApplyDisabled:                   disableApply,
+		ApplyOnMerge:                    userConfig.ApplyOnMerge,
 		GithubWebhookSecret:             []byte(userConfig.GithubWebhookSecret),
+		GithubAllowEditedComments:       userConfig.GithubAllowEditedComments,
+		GithubAllowReviewComments:       userConfig.GithubAllowReviewComments,
+		GithubAppID:                     userConfig.GithubAppID,
 		GithubRequestValidator:          &events_controllers.DefaultGithubRequestValidator{},
 		GitlabRequestParserValidator:    &events_controllers.DefaultGitlabRequestParserValidator{},
 		GitlabWebhookSecret:             []byte(userConfig.GitlabWebhookSecret),
 		RepoAllowlistChecker:            repoAllowlist,
 		SilenceAllowlistErrors:          userConfig.SilenceAllowlistErrors,
 		EmojiReaction:                   userConfig.EmojiReaction,
+		EmojiReactions:                  emojiReactions,
+		AckComment:                      userConfig.AckComment,
 		ExecutableName:                  userConfig.ExecutableName,
 		SupportedVCSHosts:               supportedVCSHosts,
 		VCSClient:                       vcsClient,
+		BitbucketWebhookSecondarySecret: []byte(userConfig.BitbucketWebhookSecondarySecret),
 		BitbucketWebhookSecret:          []byte(userConfig.BitbucketWebhookSecret),
 		AzureDevopsWebhookBasicUser:     []byte(userConfig.AzureDevopsWebhookUser),
 		AzureDevopsWebhookBasicPassword: []byte(userConfig.AzureDevopsWebhookPassword),
 		AzureDevopsRequestValidator:     &events_controllers.DefaultAzureDevopsRequestValidator{},
 		GiteaWebhookSecret:              []byte(userConfig.GiteaWebhookSecret),
 	}
+	if userConfig.EventTranslatorURL != "" {
+		eventsController.EventTranslator = &events_controllers.HTTPEventTranslator{
+			URL:    userConfig.EventTranslatorURL,
+			Client: &http.Client{Timeout: 30 * time.Second},
+		}
+	}
+	slackUsers, err := userConfig.ToSlackUserMapping()
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing --slack-user-mapping")
+	}
+	slackController := &controllers.SlackController{
+		SigningSecret:        userConfig.SlackSigningSecret,
+		Users:                slackUsers,
+		SupportedVCSHosts:    supportedVCSHosts,
+		ExecutableName:       userConfig.ExecutableName,
+		CommandRunner:        commandRunner,
+		CommentParser:        commentParser,
+		Parser:               eventParser,
+		PullStatusFetcher:    backend,
+		RepoAllowlistChecker: repoAllowlist,
+		VCSClient:            vcsClient,
+		Logger:               logger,
+	}
 	githubAppController := &controllers.GithubAppController{
 		AtlantisURL:         parsedURL,
 		Logger:              logger,
@@ -1016,12 +1598,17 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		JobsController:                 jobsController,
 		StatusController:               statusController,
 		APIController:                  apiController,
+		SlackController:                slackController,
 		IndexTemplate:                  web_templates.IndexTemplate,
 		LockDetailTemplate:             web_templates.LockTemplate,
 		ProjectJobsTemplate:            web_templates.ProjectJobsTemplate,
 		ProjectJobsErrorTemplate:       web_templates.ProjectJobsErrorTemplate,
 		SSLKeyFile:                     userConfig.SSLKeyFile,
 		SSLCertFile:                    userConfig.SSLCertFile,
+		SSLClientCAs:                   sslClientCAs,
+		SSLClientAuthAllPaths:          userConfig.SSLClientAuthAllPaths,
+		SSLClientSANAllowlist:          userConfig.ToSSLClientSANAllowlist(),
+		EventsIPAllowlist:              eventsIPAllowlist,
 		DisableGlobalApplyLock:         userConfig.DisableGlobalApplyLock,
 		Drainer:                        drainer,
 		ProjectCmdOutputHandler:        projectCmdOutputHandler,
@@ -1030,6 +1617,9 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WebPassword:                    userConfig.WebPassword,
 		ScheduledExecutorService:       scheduledExecutorService,
 		EnableProfilingAPI:             userConfig.EnableProfilingAPI,
+		HealthChecks:                   &controllers.CachedHealthChecks{Checks: healthChecks, TTL: healthCheckCacheTTL},
+		VCSConfigValidator:             vcsConfigValidator,
+		VCSConfigValidation:            userConfig.VCSConfigValidation,
 	}
 
 	validate := validator.New(validator.WithRequiredStructEnabled())
@@ -1044,16 +1634,36 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 // Start creates the routes and starts serving traffic.
 func (s *Server) Start() error {
+	if err := s.validateVCSConfig(); err != nil {
+		return err
+	}
+
 	s.Router.HandleFunc("/", s.Index).Methods("GET").MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
 		return r.URL.Path == "/" || r.URL.Path == "/index.html"
 	})
 	s.Router.HandleFunc("/healthz", s.Healthz).Methods("GET")
+	s.Router.HandleFunc("/healthz/deep", s.DeepHealthz).Methods("GET")
 	s.Router.HandleFunc("/status", s.StatusController.Get).Methods("GET")
 	s.Router.PathPrefix("/static/").Handler(http.FileServer(http.FS(staticAssets)))
 	s.Router.HandleFunc("/events", s.VCSEventsController.Post).Methods("POST")
 	s.Router.HandleFunc("/api/plan", s.APIController.Plan).Methods("POST")
 	s.Router.HandleFunc("/api/apply", s.APIController.Apply).Methods("POST")
 	s.Router.HandleFunc("/api/locks", s.APIController.ListLocks).Methods("GET")
+	s.Router.HandleFunc("/api/locks", s.APIController.DeleteLocks).Methods("DELETE")
+	s.Router.HandleFunc("/api/jobs", s.APIController.ListJobs).Methods("GET")
+	s.Router.HandleFunc("/api/pulls/{repo:.+}/{pr:[0-9]+}/projects/{project:.+}/plan.json", s.APIController.GetPlanJSON).Methods("GET")
+	s.Router.HandleFunc("/api/pulls/{repo:.+}/{pr:[0-9]+}/status", s.APIController.GetPullStatus).Methods("GET")
+	s.Router.HandleFunc("/api/admin/backup", s.APIController.Backup).Methods("GET")
+	s.Router.HandleFunc("/api/admin/reencrypt", s.APIController.Reencrypt).Methods("POST")
+	s.Router.HandleFunc("/api/admin/settings", s.APIController.GetSettings).Methods("GET")
+	s.Router.HandleFunc("/api/admin/settings", s.APIController.UpdateSettings).Methods("PUT")
+	s.Router.HandleFunc("/api/admin/reload", s.APIController.Reload).Methods("POST")
+	s.Router.HandleFunc("/api/admin/diagnostics", s.APIController.Diagnostics).Methods("GET")
+	s.Router.HandleFunc("/api/admin/vcs-config", s.APIController.ValidateVCSConfig).Methods("GET")
+	s.Router.HandleFunc("/api/admin/webhooks", s.APIController.RegisterWebhooks).Methods("POST")
+	s.Router.HandleFunc("/api/admin/init-repo", s.APIController.InitRepo).Methods("POST")
+	s.Router.HandleFunc("/api/admin/simulate", s.APIController.Simulate).Methods("POST")
+	s.Router.HandleFunc("/slack/commands", s.SlackController.Post).Methods("POST")
 	s.Router.HandleFunc("/github-app/exchange-code", s.GithubAppController.ExchangeCode).Methods("GET")
 	s.Router.HandleFunc("/github-app/setup", s.GithubAppController.New).Methods("GET")
 	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
@@ -1061,6 +1671,7 @@ func (s *Server) Start() error {
 		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
 	s.Router.HandleFunc("/jobs/{job-id}", s.JobsController.GetProjectJobs).Methods("GET").Name(ProjectJobsViewRouteName)
 	s.Router.HandleFunc("/jobs/{job-id}/ws", s.JobsController.GetProjectJobsWS).Methods("GET")
+	s.Router.HandleFunc("/jobs/{job-id}/cancel", s.JobsController.CancelProjectJob).Methods("POST")
 
 	r, ok := s.StatsReporter.(prometheus.Reporter)
 	if ok {
@@ -1089,6 +1700,12 @@ func (s *Server) Start() error {
 		StackAll:   false,
 		StackSize:  1024 * 8,
 	}, NewRequestLogger(s))
+	if s.SSLClientCAs != nil {
+		n.Use(NewClientCertAuth(s))
+	}
+	if s.EventsIPAllowlist != nil {
+		n.Use(NewEventsIPAllowlist(s))
+	}
 	n.UseHandler(s.Router)
 
 	defer s.Logger.Flush()
@@ -1105,6 +1722,10 @@ func (s *Server) Start() error {
 	}()
 
 	tlsConfig := &tls.Config{GetCertificate: s.GetSSLCertificate, MinVersion: tls.VersionTLS12}
+	if s.SSLClientCAs != nil {
+		tlsConfig.ClientCAs = s.SSLClientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
 
 	server := &http.Server{Addr: fmt.Sprintf(":%d", s.Port), Handler: n, TLSConfig: tlsConfig, ReadHeaderTimeout: 10 * time.Second}
 	go func() {
@@ -1266,6 +1887,118 @@ var healthzData = []byte(`{
   "status": "ok"
 }`)
 
+// validateVCSConfig checks the VCS credentials and webhooks and logs the
+// problems found. It returns an error if any was found and
+// VCSConfigValidation is "fail".
+func (s *Server) validateVCSConfig() error {
+	if s.VCSConfigValidator == nil || s.VCSConfigValidation == "off" {
+		return nil
+	}
+	checks := s.VCSConfigValidator.Validate(s.Logger)
+	for _, check := range checks {
+		subject := check.Host + " credentials"
+		if check.Repo != "" {
+			subject = fmt.Sprintf("%s webhook of %s", check.Host, check.Repo)
+		}
+		switch check.Status {
+		case events.VCSConfigCheckFailed:
+			s.Logger.Warn("invalid %s: %s", subject, check.Message)
+		case events.VCSConfigCheckSkipped:
+			s.Logger.Debug("skipped checking %s: %s", subject, check.Message)
+		}
+	}
+	if err := events.FailedVCSConfigChecks(checks); err != nil && s.VCSConfigValidation == "fail" {
+		return fmt.Errorf("invalid VCS config, fix it or set --vcs-config-validation=warn to start anyway:\n%w", err)
+	}
+	return nil
+}
+
+// hostnameOf returns the hostname of baseURL, ex. gitlab.com for
+// https://gitlab.com/. baseURL doesn't need a scheme.
+// noSingleFileDownloadHosts returns the hostnames of the configured VCS hosts
+// that can't download single files: Bitbucket and Azure DevOps.
+func noSingleFileDownloadHosts(userConfig UserConfig) []string {
+	var hosts []string
+	if userConfig.BitbucketUser != "" {
+		if userConfig.BitbucketBaseURL == bitbucketcloud.BaseURL {
+			hosts = append(hosts, "bitbucket.org")
+		} else {
+			hosts = append(hosts, hostnameOf(userConfig.BitbucketBaseURL))
+		}
+	}
+	if userConfig.AzureDevopsUser != "" {
+		hosts = append(hosts, userConfig.AzureDevOpsHostname)
+	}
+	return hosts
+}
+
+func hostnameOf(baseURL string) string {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	return u.Host
+}
+
+// DeepHealthzResponse is the response of /healthz/deep.
+type DeepHealthzResponse struct {
+	Status string                          `json:"status"`
+	Checks []controllers.HealthCheckResult `json:"checks"`
+}
+
+// DeepHealthz checks that the VCS hosts, locking DB, disk and Terraform
+// binary Atlantis depends on work. It returns a 503 if any check fails.
+// Errors are left out since it may be unauthenticated, they're in the
+// diagnostics bundle instead.
+func (s *Server) DeepHealthz(w http.ResponseWriter, _ *http.Request) {
+	resp := DeepHealthzResponse{Status: "ok"}
+	code := http.StatusOK
+	for _, check := range s.HealthChecks.Run() {
+		if !check.OK {
+			resp.Status = "failed"
+			code = http.StatusServiceUnavailable
+		}
+		resp.Checks = append(resp.Checks, controllers.HealthCheckResult{Name: check.Name, OK: check.OK})
+	}
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Error creating health check json response: %s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data) // nolint: errcheck
+}
+
+// vcsAPI returns the hostname the HTTP config of a VCS host is looked up by
+// and the URL of its API.
+func vcsAPI(userConfig UserConfig, host models.VCSHostType) (string, string) {
+	switch host {
+	case models.Github:
+		if userConfig.GithubHostname == "github.com" {
+			return userConfig.GithubHostname, "https://api.github.com/"
+		}
+		return userConfig.GithubHostname, fmt.Sprintf("https://%s/api/v3/", userConfig.GithubHostname)
+	case models.Gitlab:
+		apiURL := userConfig.GitlabHostname
+		if !strings.HasPrefix(apiURL, "http://") && !strings.HasPrefix(apiURL, "https://") {
+			apiURL = "https://" + apiURL
+		}
+		return userConfig.GitlabHostname, strings.TrimSuffix(apiURL, "/") + "/api/v4/version"
+	case models.BitbucketCloud, models.BitbucketServer:
+		return userConfig.BitbucketBaseURL, userConfig.BitbucketBaseURL
+	case models.AzureDevops:
+		return userConfig.AzureDevOpsHostname, fmt.Sprintf("https://%s/", userConfig.AzureDevOpsHostname)
+	case models.Gitea:
+		return userConfig.GiteaBaseURL, userConfig.GiteaBaseURL
+	}
+	return "", ""
+}
+
 func (s *Server) GetSSLCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 	certStat, err := os.Stat(s.SSLCertFile)
 	if err != nil {
@@ -1307,3 +2040,33 @@ func ParseAtlantisURL(u string) (*url.URL, error) {
 	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
 	return parsed, nil
 }
+
+// restoreBackup restores the backup at path into backend unless backend
+// already has locks or pull statuses, so that leaving --restore-backup set
+// doesn't bring back stale locks on every restart.
+func restoreBackup(logger logging.SimpleLogging, backend locking.Backend, path string) error {
+	existing, err := backend.Snapshot()
+	if err != nil {
+		return errors.Wrap(err, "checking if locking DB is empty")
+	}
+	if !existing.Empty() {
+		logger.Warn("not restoring backup %s since the locking DB isn't empty", path)
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "opening backup")
+	}
+	defer f.Close() // nolint: errcheck
+	snapshot, err := locking.ReadSnapshot(f)
+	if err != nil {
+		return errors.Wrapf(err, "reading backup %s", path)
+	}
+	if err := backend.Restore(snapshot); err != nil {
+		return errors.Wrapf(err, "restoring backup %s", path)
+	}
+	logger.Info("restored %d locks, %d pull statuses and %d command locks from backup %s created at %s",
+		len(snapshot.Locks), len(snapshot.Pulls), len(snapshot.CommandLocks), path, snapshot.CreatedAt.Format(time.RFC3339))
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/cmd"
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/controllers"
 	"github.com/runatlantis/atlantis/server/controllers/web_templates"
 	tMocks "github.com/runatlantis/atlantis/server/controllers/web_templates/mocks"
 	"github.com/runatlantis/atlantis/server/core/locking/mocks"
@@ -167,6 +168,49 @@ func TestHealthz(t *testing.T) {
 }`, string(body))
 }
 
+func TestDeepHealthz(t *testing.T) {
+	lockingDBChecks := 0
+	s := server.Server{
+		HealthChecks: &controllers.CachedHealthChecks{
+			Checks: []controllers.HealthCheck{
+				{Name: "locking DB", Check: func() error { lockingDBChecks++; return nil }},
+				{Name: "terraform", Check: func() error { return errors.New("not installed") }},
+			},
+			TTL: time.Minute,
+		},
+	}
+	req, _ := http.NewRequest("GET", "/healthz/deep", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	s.DeepHealthz(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	Equals(t, http.StatusServiceUnavailable, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	Equals(t, "application/json", resp.Header["Content-Type"][0])
+	Equals(t,
+		`{
+  "status": "failed",
+  "checks": [
+    {
+      "name": "locking DB",
+      "ok": true
+    },
+    {
+      "name": "terraform",
+      "ok": false
+    }
+  ]
+}`, string(body))
+
+	// The results are reused until they're older than the TTL.
+	s.DeepHealthz(httptest.NewRecorder(), req)
+	Equals(t, 1, lockingDBChecks)
+	s.HealthChecks.TTL = 0
+	s.DeepHealthz(httptest.NewRecorder(), req)
+	Equals(t, 2, lockingDBChecks)
+}
+
 type mockRW struct{}
 
 var _ http.ResponseWriter = mockRW{}
@@ -1,12 +1,20 @@
 package server
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
 )
 
@@ -14,47 +22,68 @@ import (
 // The mapstructure tags correspond to flags in cmd/server.go and are used when
 // the config is parsed from a YAML file.
 type UserConfig struct {
-	AllowForkPRs                bool   `mapstructure:"allow-fork-prs"`
-	AllowCommands               string `mapstructure:"allow-commands"`
-	AtlantisURL                 string `mapstructure:"atlantis-url"`
-	AutoDiscoverModeFlag        string `mapstructure:"autodiscover-mode"`
-	Automerge                   bool   `mapstructure:"automerge"`
-	AutoplanFileList            string `mapstructure:"autoplan-file-list"`
-	AutoplanModules             bool   `mapstructure:"autoplan-modules"`
-	AutoplanModulesFromProjects string `mapstructure:"autoplan-modules-from-projects"`
-	AzureDevopsToken            string `mapstructure:"azuredevops-token"`
-	AzureDevopsUser             string `mapstructure:"azuredevops-user"`
-	AzureDevopsWebhookPassword  string `mapstructure:"azuredevops-webhook-password"`
-	AzureDevopsWebhookUser      string `mapstructure:"azuredevops-webhook-user"`
-	AzureDevOpsHostname         string `mapstructure:"azuredevops-hostname"`
-	BitbucketBaseURL            string `mapstructure:"bitbucket-base-url"`
-	BitbucketToken              string `mapstructure:"bitbucket-token"`
-	BitbucketUser               string `mapstructure:"bitbucket-user"`
-	BitbucketWebhookSecret      string `mapstructure:"bitbucket-webhook-secret"`
-	CheckoutDepth               int    `mapstructure:"checkout-depth"`
-	CheckoutStrategy            string `mapstructure:"checkout-strategy"`
-	DataDir                     string `mapstructure:"data-dir"`
-	DisableApplyAll             bool   `mapstructure:"disable-apply-all"`
-	DisableAutoplan             bool   `mapstructure:"disable-autoplan"`
-	DisableAutoplanLabel        string `mapstructure:"disable-autoplan-label"`
-	DisableMarkdownFolding      bool   `mapstructure:"disable-markdown-folding"`
-	DisableRepoLocking          bool   `mapstructure:"disable-repo-locking"`
-	DisableGlobalApplyLock      bool   `mapstructure:"disable-global-apply-lock"`
-	DisableUnlockLabel          string `mapstructure:"disable-unlock-label"`
-	DiscardApprovalOnPlanFlag   bool   `mapstructure:"discard-approval-on-plan"`
-	EmojiReaction               string `mapstructure:"emoji-reaction"`
-	EnablePolicyChecksFlag      bool   `mapstructure:"enable-policy-checks"`
-	EnableRegExpCmd             bool   `mapstructure:"enable-regexp-cmd"`
-	EnableProfilingAPI          bool   `mapstructure:"enable-profiling-api"`
-	EnableDiffMarkdownFormat    bool   `mapstructure:"enable-diff-markdown-format"`
-	ExecutableName              string `mapstructure:"executable-name"`
+	AckComment                      string `mapstructure:"ack-comment"`
+	AllowForkPRs                    bool   `mapstructure:"allow-fork-prs"`
+	AllowCommands                   string `mapstructure:"allow-commands"`
+	AllowExtraArgs                  string `mapstructure:"allow-extra-args"`
+	ApplyConfirmationTTL            string `mapstructure:"apply-confirmation-ttl"`
+	ApplyOnMerge                    bool   `mapstructure:"apply-on-merge"`
+	AtlantisURL                     string `mapstructure:"atlantis-url"`
+	AutoDiscoverModeFlag            string `mapstructure:"autodiscover-mode"`
+	Automerge                       bool   `mapstructure:"automerge"`
+	AutoplanFileList                string `mapstructure:"autoplan-file-list"`
+	AutoplanModules                 bool   `mapstructure:"autoplan-modules"`
+	AutoplanModulesFromProjects     string `mapstructure:"autoplan-modules-from-projects"`
+	AzureDevopsToken                string `mapstructure:"azuredevops-token"`
+	AzureDevopsUser                 string `mapstructure:"azuredevops-user"`
+	AzureDevopsWebhookPassword      string `mapstructure:"azuredevops-webhook-password"`
+	AzureDevopsWebhookUser          string `mapstructure:"azuredevops-webhook-user"`
+	AzureDevOpsHostname             string `mapstructure:"azuredevops-hostname"`
+	BitbucketBaseURL                string `mapstructure:"bitbucket-base-url"`
+	BitbucketToken                  string `mapstructure:"bitbucket-token"`
+	BitbucketUser                   string `mapstructure:"bitbucket-user"`
+	BitbucketWebhookSecondarySecret string `mapstructure:"bitbucket-webhook-secondary-secret"`
+	BitbucketWebhookSecret          string `mapstructure:"bitbucket-webhook-secret"`
+	CgroupParent                    string `mapstructure:"cgroup-parent"`
+	CheckoutDepth                   int    `mapstructure:"checkout-depth"`
+	CheckoutStrategy                string `mapstructure:"checkout-strategy"`
+	CloudIdentityTokenFile          string `mapstructure:"cloud-identity-token-file"`
+	CommandRateLimitPerPull         int    `mapstructure:"command-rate-limit-per-pull"`
+	CommandRateLimitPerUser         int    `mapstructure:"command-rate-limit-per-user"`
+	CommentStrategy                 string `mapstructure:"comment-strategy"`
+	DataDir                         string `mapstructure:"data-dir"`
+	DenyExtraArgs                   string `mapstructure:"deny-extra-args"`
+	DisableApplyAll                 bool   `mapstructure:"disable-apply-all"`
+	DisableAutoplan                 bool   `mapstructure:"disable-autoplan"`
+	DisableAutoplanLabel            string `mapstructure:"disable-autoplan-label"`
+	DisableMarkdownFolding          bool   `mapstructure:"disable-markdown-folding"`
+	DisableProjectStatuses          bool   `mapstructure:"disable-project-statuses"`
+	DisableRepoLocking              bool   `mapstructure:"disable-repo-locking"`
+	DisableGlobalApplyLock          bool   `mapstructure:"disable-global-apply-lock"`
+	DisableUnlockLabel              string `mapstructure:"disable-unlock-label"`
+	DiscardApprovalOnPlanFlag       bool   `mapstructure:"discard-approval-on-plan"`
+	EmojiReaction                   string `mapstructure:"emoji-reaction"`
+	EnableOutdatedDependencies      bool   `mapstructure:"enable-outdated-dependencies"`
+	EnablePolicyChecksFlag          bool   `mapstructure:"enable-policy-checks"`
+	EventTranslatorURL              string `mapstructure:"event-translator-url"`
+	EventsIPAllowlist               string `mapstructure:"events-ip-allowlist"`
+	EventsIPAllowlistTrustedProxies string `mapstructure:"events-ip-allowlist-trusted-proxies"`
+	EnablePRDescriptionStatus       bool   `mapstructure:"enable-pr-description-status"`
+	EnableRegExpCmd                 bool   `mapstructure:"enable-regexp-cmd"`
+	EnableProfilingAPI              bool   `mapstructure:"enable-profiling-api"`
+	EnableDiffMarkdownFormat        bool   `mapstructure:"enable-diff-markdown-format"`
+	ExecutableName                  string `mapstructure:"executable-name"`
 	// Fail and do not run the Atlantis command request if any of the pre workflow hooks error.
 	FailOnPreWorkflowHookError      bool   `mapstructure:"fail-on-pre-workflow-hook-error"`
+	ForkPRAllowlist                 string `mapstructure:"fork-pr-allowlist"`
 	HideUnchangedPlanComments       bool   `mapstructure:"hide-unchanged-plan-comments"`
+	GithubAllowEditedComments       bool   `mapstructure:"gh-allow-edited-comments"`
 	GithubAllowMergeableBypassApply bool   `mapstructure:"gh-allow-mergeable-bypass-apply"`
+	GithubAllowReviewComments       bool   `mapstructure:"gh-allow-review-comments"`
 	GithubHostname                  string `mapstructure:"gh-hostname"`
 	GithubToken                     string `mapstructure:"gh-token"`
 	GithubTokenFile                 string `mapstructure:"gh-token-file"`
+	GithubUseCheckRuns              bool   `mapstructure:"gh-use-check-runs"`
 	GithubUser                      string `mapstructure:"gh-user"`
 	GithubWebhookSecret             string `mapstructure:"gh-webhook-secret"`
 	GithubOrg                       string `mapstructure:"gh-org"`
@@ -63,6 +92,7 @@ type UserConfig struct {
 	GithubAppKeyFile                string `mapstructure:"gh-app-key-file"`
 	GithubAppSlug                   string `mapstructure:"gh-app-slug"`
 	GithubAppInstallationID         int64  `mapstructure:"gh-app-installation-id"`
+	GithubAppRepoScopedTokens       bool   `mapstructure:"gh-app-repo-scoped-tokens"`
 	GithubTeamAllowlist             string `mapstructure:"gh-team-allowlist"`
 	GiteaBaseURL                    string `mapstructure:"gitea-base-url"`
 	GiteaToken                      string `mapstructure:"gitea-token"`
@@ -72,20 +102,34 @@ type UserConfig struct {
 	GitlabHostname                  string `mapstructure:"gitlab-hostname"`
 	GitlabGroupAllowlist            string `mapstructure:"gitlab-group-allowlist"`
 	GitlabToken                     string `mapstructure:"gitlab-token"`
+	GitlabTokenType                 string `mapstructure:"gitlab-token-type"`
 	GitlabUser                      string `mapstructure:"gitlab-user"`
 	GitlabWebhookSecret             string `mapstructure:"gitlab-webhook-secret"`
 	IncludeGitUntrackedFiles        bool   `mapstructure:"include-git-untracked-files"`
+	JobLogCloudWatchGroup           string `mapstructure:"job-log-cloudwatch-group"`
+	JobLogCloudWatchRegion          string `mapstructure:"job-log-cloudwatch-region"`
+	JobLogLokiURL                   string `mapstructure:"job-log-loki-url"`
 	APISecret                       string `mapstructure:"api-secret"`
 	HidePrevPlanComments            bool   `mapstructure:"hide-prev-plan-comments"`
+	LockingDBEncryptionKeyFile      string `mapstructure:"locking-db-encryption-key-file"`
 	LockingDBType                   string `mapstructure:"locking-db-type"`
 	LogLevel                        string `mapstructure:"log-level"`
+	MarkAppliedComments             bool   `mapstructure:"mark-applied-comments"`
 	MarkdownTemplateOverridesDir    string `mapstructure:"markdown-template-overrides-dir"`
+	MaxAutoplanProjects             int    `mapstructure:"max-autoplan-projects"`
 	MaxCommentsPerCommand           int    `mapstructure:"max-comments-per-command"`
+	MessageCatalog                  string `mapstructure:"message-catalog"`
 	IgnoreVCSStatusNames            string `mapstructure:"ignore-vcs-status-names"`
 	ParallelPoolSize                int    `mapstructure:"parallel-pool-size"`
 	ParallelPlan                    bool   `mapstructure:"parallel-plan"`
 	ParallelApply                   bool   `mapstructure:"parallel-apply"`
+	PendingPlanDiscardAge           string `mapstructure:"pending-plan-discard-age"`
+	PendingPlanReminderAge          string `mapstructure:"pending-plan-reminder-age"`
+	PlanEncryptionKeyFile           string `mapstructure:"plan-encryption-key-file"`
+	PlanRetentionMaxAge             string `mapstructure:"plan-retention-max-age"`
+	PlanRetentionMaxCount           int    `mapstructure:"plan-retention-max-count"`
 	StatsNamespace                  string `mapstructure:"stats-namespace"`
+	SummaryStatus                   bool   `mapstructure:"summary-status"`
 	PlanDrafts                      bool   `mapstructure:"allow-draft-prs"`
 	Port                            int    `mapstructure:"port"`
 	QuietPolicyChecks               bool   `mapstructure:"quiet-policy-checks"`
@@ -95,9 +139,21 @@ type UserConfig struct {
 	RedisPort                       int    `mapstructure:"redis-port"`
 	RedisTLSEnabled                 bool   `mapstructure:"redis-tls-enabled"`
 	RedisInsecureSkipVerify         bool   `mapstructure:"redis-insecure-skip-verify"`
-	RepoConfig                      string `mapstructure:"repo-config"`
-	RepoConfigJSON                  string `mapstructure:"repo-config-json"`
-	RepoAllowlist                   string `mapstructure:"repo-allowlist"`
+	// RedactEnvVars are the names of environment variables whose values are
+	// masked in logs, job output and comments.
+	RedactEnvVars string `mapstructure:"redact-env-vars"`
+	// RedactPatterns is a JSON array of regular expressions that are masked
+	// in logs, job output and comments.
+	RedactPatterns string `mapstructure:"redact-patterns"`
+	RepoConfig     string `mapstructure:"repo-config"`
+	RepoConfigJSON string `mapstructure:"repo-config-json"`
+	RepoAllowlist  string `mapstructure:"repo-allowlist"`
+	// RestoreBackup is the path to a backup to restore into an empty
+	// locking DB on startup.
+	RestoreBackup string `mapstructure:"restore-backup"`
+	// ShareWorkspaceInit is whether the workspaces of a project dir share the
+	// modules and providers installed by init.
+	ShareWorkspaceInit bool `mapstructure:"share-workspace-init"`
 
 	// SilenceNoProjects is whether Atlantis should respond to a PR if no projects are found.
 	SilenceNoProjects   bool `mapstructure:"silence-no-projects"`
@@ -110,10 +166,19 @@ type UserConfig struct {
 	SilenceVCSStatusNoProjects bool            `mapstructure:"silence-vcs-status-no-projects"`
 	SilenceAllowlistErrors     bool            `mapstructure:"silence-allowlist-errors"`
 	SkipCloneNoChanges         bool            `mapstructure:"skip-clone-no-changes"`
+	SlackSigningSecret         string          `mapstructure:"slack-signing-secret"`
 	SlackToken                 string          `mapstructure:"slack-token"`
+	SlackUserMapping           string          `mapstructure:"slack-user-mapping"`
 	SSLCertFile                string          `mapstructure:"ssl-cert-file"`
 	SSLKeyFile                 string          `mapstructure:"ssl-key-file"`
+	SSLClientAuthAllPaths      bool            `mapstructure:"ssl-client-auth-all-paths"`
+	SSLClientCAFile            string          `mapstructure:"ssl-client-ca-file"`
+	SSLClientSANAllowlist      string          `mapstructure:"ssl-client-san-allowlist"`
 	RestrictFileList           bool            `mapstructure:"restrict-file-list"`
+	RestrictForkPRs            bool            `mapstructure:"restrict-fork-prs"`
+	ReuseIdenticalPlanAnalysis bool            `mapstructure:"reuse-identical-plan-analysis"`
+	TeamSyncSCIMTokenFile      string          `mapstructure:"team-sync-scim-token-file"`
+	TeamSyncSCIMURL            string          `mapstructure:"team-sync-scim-url"`
 	TFDistribution             string          `mapstructure:"tf-distribution"` // deprecated in favor of DefaultTFDistribution
 	TFDownload                 bool            `mapstructure:"tf-download"`
 	TFDownloadURL              string          `mapstructure:"tf-download-url"`
@@ -121,6 +186,20 @@ type UserConfig struct {
 	TFELocalExecutionMode      bool            `mapstructure:"tfe-local-execution-mode"`
 	TFEToken                   string          `mapstructure:"tfe-token"`
 	VarFileAllowlist           string          `mapstructure:"var-file-allowlist"`
+	VaultAddr                  string          `mapstructure:"vault-addr"`
+	VaultAuthMethod            string          `mapstructure:"vault-auth-method"`
+	VaultAuthMount             string          `mapstructure:"vault-auth-mount"`
+	VaultKubernetesTokenFile   string          `mapstructure:"vault-kubernetes-token-file"`
+	VaultNamespace             string          `mapstructure:"vault-namespace"`
+	VaultRole                  string          `mapstructure:"vault-role"`
+	VaultSecretIDFile          string          `mapstructure:"vault-secret-id-file"`
+	VCSConfigValidation        string          `mapstructure:"vcs-config-validation"`
+	VCSEmojiReactions          string          `mapstructure:"vcs-emoji-reactions"`
+	VCSFakeFixtures            string          `mapstructure:"vcs-fake-fixtures"`
+	VCSHTTPCassette            string          `mapstructure:"vcs-http-cassette"`
+	VCSHTTPConfig              string          `mapstructure:"vcs-http-config"`
+	VCSHTTPCassetteMode        string          `mapstructure:"vcs-http-cassette-mode"`
+	VCSRateLimitReserve        int             `mapstructure:"vcs-rate-limit-reserve"`
 	VCSStatusName              string          `mapstructure:"vcs-status-name"`
 	DefaultTFDistribution      string          `mapstructure:"default-tf-distribution"`
 	DefaultTFVersion           string          `mapstructure:"default-tf-version"`
@@ -134,7 +213,8 @@ type UserConfig struct {
 	UseTFPluginCache           bool            `mapstructure:"use-tf-plugin-cache"`
 }
 
-// ToAllowCommandNames parse AllowCommands into a slice of CommandName
+// ToAllowCommandNames parse AllowCommands into a slice of CommandName. all
+// allows every command but force-unlock, which has to be listed explicitly.
 func (u UserConfig) ToAllowCommandNames() ([]command.Name, error) {
 	var allowCommands []command.Name
 	var hasAll bool
@@ -153,11 +233,289 @@ func (u UserConfig) ToAllowCommandNames() ([]command.Name, error) {
 		allowCommands = append(allowCommands, cmd)
 	}
 	if hasAll {
-		return command.AllCommentCommands, nil
+		// force-unlock can break the lock of an apply that's still running,
+		// so it's only allowed if it's listed explicitly.
+		forceUnlock := slices.Contains(allowCommands, command.ForceUnlock)
+		allowCommands = nil
+		for _, cmd := range command.AllCommentCommands {
+			if cmd != command.ForceUnlock || forceUnlock {
+				allowCommands = append(allowCommands, cmd)
+			}
+		}
 	}
 	return allowCommands, nil
 }
 
+// ToAllowExtraArgs parses AllowExtraArgs into a slice of terraform flags.
+func (u UserConfig) ToAllowExtraArgs() ([]string, error) {
+	return parseExtraArgFlags(u.AllowExtraArgs)
+}
+
+// ToDenyExtraArgs parses DenyExtraArgs into a slice of terraform flags.
+func (u UserConfig) ToDenyExtraArgs() ([]string, error) {
+	return parseExtraArgFlags(u.DenyExtraArgs)
+}
+
+func parseExtraArgFlags(list string) ([]string, error) {
+	var flags []string
+	for _, input := range strings.Split(list, ",") {
+		flag := strings.TrimSpace(input)
+		if flag == "" {
+			continue
+		}
+		if !strings.HasPrefix(flag, "-") {
+			return nil, errors.Errorf("%q is not a flag, flags must start with '-'", flag)
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// sensitiveFlagRegex matches the names of the flags whose values are secrets.
+var sensitiveFlagRegex = regexp.MustCompile(`(token|secret|password|-key)$`)
+
+// sensitiveFlags are the flags whose values can contain secrets even though
+// their names don't say so: headers with credentials, URLs with tokens in
+// their query and repo configs with secrets in their workflows.
+var sensitiveFlags = []string{"job-log-loki-url", "repo-config-json", "webhook-http-headers"}
+
+// urlUserinfoRegex matches the user and password of URLs, ex. of proxies.
+var urlUserinfoRegex = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/?#@\s"']+@`)
+
+// RedactedFlags returns the flags by name with the values of the ones that
+// are secrets redacted, ex. to include them in diagnostic bundles. The user
+// and password of the URLs in the other flags are redacted too.
+func (u UserConfig) RedactedFlags() map[string]interface{} {
+	flags := map[string]interface{}{}
+	v := reflect.ValueOf(u)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		value := v.Field(i)
+		if (sensitiveFlagRegex.MatchString(name) || slices.Contains(sensitiveFlags, name)) && !value.IsZero() {
+			flags[name] = logging.RedactedValue
+			continue
+		}
+		if value.Kind() == reflect.String {
+			flags[name] = urlUserinfoRegex.ReplaceAllString(value.String(), "${1}"+logging.RedactedValue+"@")
+			continue
+		}
+		flags[name] = value.Interface()
+	}
+	return flags
+}
+
+// ToApplyConfirmationTTL parses ApplyConfirmationTTL. It returns 0 if it
+// isn't set.
+func (u UserConfig) ToApplyConfirmationTTL() (time.Duration, error) {
+	if u.ApplyConfirmationTTL == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(u.ApplyConfirmationTTL)
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return 0, errors.Errorf("%s must be positive", u.ApplyConfirmationTTL)
+	}
+	return ttl, nil
+}
+
+// ToPlanRetentionMaxAge parses PlanRetentionMaxAge. It returns 0 if it isn't
+// set.
+func (u UserConfig) ToPlanRetentionMaxAge() (time.Duration, error) {
+	if u.PlanRetentionMaxAge == "" {
+		return 0, nil
+	}
+	maxAge, err := time.ParseDuration(u.PlanRetentionMaxAge)
+	if err != nil {
+		return 0, err
+	}
+	if maxAge <= 0 {
+		return 0, errors.Errorf("%s must be positive", u.PlanRetentionMaxAge)
+	}
+	return maxAge, nil
+}
+
+// ToPendingPlanReminderAge parses PendingPlanReminderAge. It returns 0 if it
+// isn't set.
+func (u UserConfig) ToPendingPlanReminderAge() (time.Duration, error) {
+	if u.PendingPlanReminderAge == "" {
+		return 0, nil
+	}
+	age, err := time.ParseDuration(u.PendingPlanReminderAge)
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, errors.Errorf("%s must be positive", u.PendingPlanReminderAge)
+	}
+	return age, nil
+}
+
+// ToPendingPlanDiscardAge parses PendingPlanDiscardAge. It returns 0 if it
+// isn't set.
+func (u UserConfig) ToPendingPlanDiscardAge() (time.Duration, error) {
+	if u.PendingPlanDiscardAge == "" {
+		return 0, nil
+	}
+	age, err := time.ParseDuration(u.PendingPlanDiscardAge)
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, errors.Errorf("%s must be positive", u.PendingPlanDiscardAge)
+	}
+	return age, nil
+}
+
+// ToForkPRAllowlist parses ForkPRAllowlist into a slice of usernames.
+func (u UserConfig) ToForkPRAllowlist() []string {
+	var users []string
+	for _, input := range strings.Split(u.ForkPRAllowlist, ",") {
+		user := strings.TrimPrefix(strings.TrimSpace(input), "@")
+		if user == "" {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+// ToSSLClientCAs reads the CA certificates in SSLClientCAFile. It returns nil
+// if client certificates aren't required.
+func (u UserConfig) ToSSLClientCAs() (*x509.CertPool, error) {
+	if u.SSLClientCAFile == "" {
+		return nil, nil
+	}
+	bundle, err := os.ReadFile(u.SSLClientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading client CA file")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, errors.Errorf("no PEM encoded certificates found in %s", u.SSLClientCAFile)
+	}
+	return pool, nil
+}
+
+// ToSSLClientSANAllowlist parses SSLClientSANAllowlist into a slice of
+// patterns.
+func (u UserConfig) ToSSLClientSANAllowlist() []string {
+	return splitCommaList(u.SSLClientSANAllowlist)
+}
+
+// ToEventsIPAllowlist parses EventsIPAllowlist into a slice of IPs, CIDRs and
+// VCS host names.
+func (u UserConfig) ToEventsIPAllowlist() []string {
+	return splitCommaList(u.EventsIPAllowlist)
+}
+
+// ToEventsIPAllowlistTrustedProxies parses EventsIPAllowlistTrustedProxies
+// into a slice of IPs and CIDRs.
+func (u UserConfig) ToEventsIPAllowlistTrustedProxies() []string {
+	return splitCommaList(u.EventsIPAllowlistTrustedProxies)
+}
+
+func splitCommaList(list string) []string {
+	var entries []string
+	for _, input := range strings.Split(list, ",") {
+		if entry := strings.TrimSpace(input); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// slackUserIDRegex matches Slack user IDs, ex. U012AB3CD. Enterprise Grid
+// user IDs start with a W.
+var slackUserIDRegex = regexp.MustCompile(`^[UW][A-Z0-9]+$`)
+
+// ToSlackUserMapping parses SlackUserMapping into a map from Slack user IDs to
+// VCS usernames. Slack usernames aren't accepted since users can change them.
+func (u UserConfig) ToSlackUserMapping() (map[string]string, error) {
+	users := make(map[string]string)
+	for _, input := range strings.Split(u.SlackUserMapping, ",") {
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		slackUser, vcsUser, ok := strings.Cut(input, ":")
+		slackUser = strings.TrimSpace(slackUser)
+		vcsUser = strings.TrimPrefix(strings.TrimSpace(vcsUser), "@")
+		if !ok || slackUser == "" || vcsUser == "" {
+			return nil, errors.Errorf("%q must be in the format {slack user id}:{vcs user}", input)
+		}
+		if !slackUserIDRegex.MatchString(slackUser) {
+			return nil, errors.Errorf("%q isn't a Slack user ID, ex. U012AB3CD", slackUser)
+		}
+		users[slackUser] = vcsUser
+	}
+	return users, nil
+}
+
+// ToVCSEmojiReactions parses VCSEmojiReactions into a map from VCS hosts to
+// the reaction comments are reacted to with on them.
+func (u UserConfig) ToVCSEmojiReactions() (map[models.VCSHostType]string, error) {
+	reactions := make(map[models.VCSHostType]string)
+	for _, input := range strings.Split(u.VCSEmojiReactions, ",") {
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		host, reaction, ok := strings.Cut(input, "=")
+		if !ok {
+			return nil, errors.Errorf("%q must be in the format {vcs host}={reaction}", input)
+		}
+		hostType, err := models.NewVCSHostType(strings.TrimSpace(host))
+		if err != nil {
+			return nil, err
+		}
+		reactions[hostType] = strings.TrimSpace(reaction)
+	}
+	return reactions, nil
+}
+
+// ToRedactor builds the Redactor configured by RedactEnvVars and
+// RedactPatterns. The values of RedactEnvVars are read from the environment.
+func (u UserConfig) ToRedactor() (*logging.Redactor, error) {
+	var values []string
+	for _, name := range strings.Split(u.RedactEnvVars, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		values = append(values, os.Getenv(name))
+	}
+	var patterns []string
+	if u.RedactPatterns != "" {
+		if err := json.Unmarshal([]byte(u.RedactPatterns), &patterns); err != nil {
+			return nil, errors.Wrap(err, "parsing redact patterns")
+		}
+	}
+	return logging.NewRedactor(values, patterns)
+}
+
+// ToVCSHTTPConfigs parses VCSHTTPConfig into the HTTP configs of VCS hosts
+// and checks that their transports can be built.
+func (u UserConfig) ToVCSHTTPConfigs() (vcs.HTTPConfigs, error) {
+	if u.VCSHTTPConfig == "" {
+		return nil, nil
+	}
+	var configs vcs.HTTPConfigs
+	if err := json.Unmarshal([]byte(u.VCSHTTPConfig), &configs); err != nil {
+		return nil, err
+	}
+	for hostname := range configs {
+		if _, err := configs.Transport(hostname); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
 // ToWebhookHttpHeaders parses WebhookHttpHeaders into a map of HTTP headers.
 func (u UserConfig) ToWebhookHttpHeaders() (map[string][]string, error) {
 	if u.WebhookHttpHeaders == "" {
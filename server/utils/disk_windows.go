@@ -0,0 +1,17 @@
+package utils
+
+import "golang.org/x/sys/windows"
+
+// FreeDiskSpace returns the number of bytes available to Atlantis on the
+// file system of path.
+func FreeDiskSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,65 @@
+package testdrive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/colorstring"
+	"github.com/runatlantis/atlantis/server/events/vcs/fake"
+)
+
+// StartFixtures drives an Atlantis server started with --vcs-fake-fixtures
+// at atlantisURL. It opens the fixture pull request pullNum, or the first
+// one if pullNum is 0, then sends each line read from in as a comment on it.
+// Atlantis' comments are printed by the server.
+func StartFixtures(fixturesPath string, pullNum int, atlantisURL string, in io.Reader) error {
+	fixtures, err := fake.LoadFixtures(fixturesPath)
+	if err != nil {
+		return err
+	}
+	pull := fixtures.Pulls[0]
+	if pullNum != 0 {
+		pull = nil
+		for _, p := range fixtures.Pulls {
+			if p.Number == pullNum {
+				pull = p
+				break
+			}
+		}
+		if pull == nil {
+			return fmt.Errorf("pull %d isn't in %s", pullNum, fixturesPath)
+		}
+	}
+
+	eventsURL := strings.TrimSuffix(atlantisURL, "/") + "/events"
+	client := &http.Client{Timeout: 10 * time.Second}
+	payload, err := fake.OpenedEvent(pull)
+	if err != nil {
+		return err
+	}
+	if err := fake.SendEvent(client, eventsURL, "pull_request", payload); err != nil {
+		return err
+	}
+	colorstring.Printf("[green]=> opened %s#%d (%s into %s)\n", pull.Repo, pull.Number, pull.HeadBranch, pull.BaseBranch)
+	colorstring.Println("[bold]Type comments to send to Atlantis, ex. atlantis plan, one per line. Press Ctrl-d to exit.")
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		comment := strings.TrimSpace(scanner.Text())
+		if comment == "" {
+			continue
+		}
+		payload, err := fake.CommentEvent(pull, pull.Author, comment)
+		if err != nil {
+			return err
+		}
+		if err := fake.SendEvent(client, eventsURL, "issue_comment", payload); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}